@@ -1,130 +0,0 @@
-package config
-
-import (
-	"fmt"
-	"os"
-	"strconv"
-	"time"
-	"github.com/golang-jwt/jwt/v5"
-)
-
-type JWTConfig struct {
-	Secret          string
-	AccessTokenTTL  time.Duration
-	RefreshTokenTTL time.Duration
-	Issuer          string
-	Audience        string
-}
-
-type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Name   string `json:"name"`
-	Role   string `json:"role"`
-	jwt.RegisteredClaims
-}
-
-func LoadJWTConfig() (*JWTConfig, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return nil, fmt.Errorf("JWT_SECRET environment variable is required")
-	}
-
-	accessTTL := 15 * time.Minute
-	refreshTTL := 7 * 24 * time.Hour
-
-	if ttl := os.Getenv("JWT_ACCESS_TOKEN_TTL"); ttl != "" {
-		if minutes, err := strconv.Atoi(ttl); err == nil {
-			accessTTL = time.Duration(minutes) * time.Minute
-		}
-	}
-
-	if ttl := os.Getenv("JWT_REFRESH_TOKEN_TTL"); ttl != "" {
-		if hours, err := strconv.Atoi(ttl); err == nil {
-			refreshTTL = time.Duration(hours) * time.Hour
-		}
-	}
-
-	return &JWTConfig{
-		Secret:          secret,
-		AccessTokenTTL:  accessTTL,
-		RefreshTokenTTL: refreshTTL,
-		Issuer:          "microbank",
-		Audience:        "microbank-users",
-	}, nil
-}
-
-func (c *JWTConfig) GenerateAccessToken(userID, email, name, role string) (string, error) {
-	now := time.Now()
-	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Name:   name,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    c.Issuer,
-			Subject:   userID,
-			Audience:  []string{c.Audience},
-			ExpiresAt: jwt.NewNumericDate(now.Add(c.AccessTokenTTL)),
-			NotBefore: jwt.NewNumericDate(now),
-			IssuedAt:  jwt.NewNumericDate(now),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(c.Secret))
-}
-
-func (c *JWTConfig) GenerateRefreshToken(userID string) (string, error) {
-	now := time.Now()
-	claims := &jwt.RegisteredClaims{
-		Issuer:    c.Issuer,
-		Subject:   userID,
-		Audience:  []string{c.Audience},
-		ExpiresAt: jwt.NewNumericDate(now.Add(c.RefreshTokenTTL)),
-		NotBefore: jwt.NewNumericDate(now),
-		IssuedAt:  jwt.NewNumericDate(now),
-		ID:        fmt.Sprintf("refresh_%s", userID),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(c.Secret))
-}
-
-func (c *JWTConfig) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(c.Secret), nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse token: %w", err)
-	}
-
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
-	}
-
-	return nil, fmt.Errorf("invalid token")
-}
-
-func (c *JWTConfig) ValidateRefreshToken(tokenString string) (string, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return "", fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(c.Secret), nil
-	})
-
-	if err != nil {
-		return "", fmt.Errorf("failed to parse refresh token: %w", err)
-	}
-
-	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
-		return claims.Subject, nil
-	}
-
-	return "", fmt.Errorf("invalid refresh token")
-}
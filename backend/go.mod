@@ -2,4 +2,8 @@ module microbank
 
 go 1.21
 
-require github.com/golang-jwt/jwt/v5 v5.2.0
+require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/golang-jwt/jwt/v5 v5.2.0
+	github.com/google/uuid v1.5.0
+)
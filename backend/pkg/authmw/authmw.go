@@ -0,0 +1,285 @@
+// Package authmw authenticates API requests by their access token. It is
+// shared by every service so they agree on how tokens are read, which
+// claims they must carry and what blacklisted users may do. Like httpx it
+// does not import a web framework: requests are handled through a Context,
+// which *gin.Context satisfies, and each service wraps Middleware.Handle in
+// its own middleware.
+package authmw
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"microbank/pkg/httpx"
+	sharedjwt "microbank/pkg/jwt"
+)
+
+// Context keys the authenticated user's claims are stored under
+const (
+	UserIDKey        = "user_id"
+	EmailKey         = "email"
+	NameKey          = "name"
+	IsAdminKey       = "is_admin"
+	IsBlacklistedKey = "is_blacklisted"
+)
+
+// Claims that RequireClaims can insist on
+const (
+	ClaimUserID = "user_id"
+	ClaimEmail  = "email"
+	ClaimName   = "name"
+	ClaimID     = "jti"
+)
+
+// Context is the part of a request context the middleware needs
+type Context interface {
+	httpx.Context
+	GetHeader(key string) string
+	Cookie(name string) (string, error)
+	Set(key string, value any)
+	Next()
+}
+
+// TokenVerifier checks an access token's signature and expiry and returns
+// its claims. *sharedjwt.TokenManager satisfies it.
+type TokenVerifier interface {
+	ValidateToken(tokenString string, opts ...sharedjwt.ValidateOption) (*sharedjwt.Claims, error)
+}
+
+// Check runs a service's own test of a verified token, such as a lookup of
+// its revocation status. A non-nil error stops the request; an
+// *httpx.AppError is reported as is.
+type Check func(c Context, token string, claims *sharedjwt.Claims) error
+
+// Option configures a Middleware
+type Option func(*Middleware)
+
+// WithCookie accepts the token from the named cookie when the request has
+// no Authorization header, for browsers that keep their token in a cookie
+func WithCookie(name string) Option {
+	return func(m *Middleware) {
+		m.cookie = name
+	}
+}
+
+// RequireClaims rejects tokens missing any of the named claims, such as
+// ClaimUserID, with 401 INVALID_TOKEN. It panics on names other than the
+// Claim constants.
+func RequireClaims(names ...string) Option {
+	for _, name := range names {
+		switch name {
+		case ClaimUserID, ClaimEmail, ClaimName, ClaimID:
+		default:
+			panic("authmw: unknown claim " + name)
+		}
+	}
+	return func(m *Middleware) {
+		m.requiredClaims = append(m.requiredClaims, names...)
+	}
+}
+
+// AllowBlacklisted lets tokens of blacklisted users through. By default
+// they are rejected with 403 USER_BLACKLISTED.
+func AllowBlacklisted() Option {
+	return func(m *Middleware) {
+		m.allowBlacklisted = true
+	}
+}
+
+// AdminOnly rejects tokens without the admin role with 403
+// INSUFFICIENT_PERMISSIONS
+func AdminOnly() Option {
+	return func(m *Middleware) {
+		m.adminOnly = true
+	}
+}
+
+// WithCheck adds a check run on every verified access token, in the order
+// added, before the blacklist and admin checks
+func WithCheck(check Check) Option {
+	return func(m *Middleware) {
+		m.checks = append(m.checks, check)
+	}
+}
+
+// WithPersonalAccessTokens accepts personal access tokens, which start with
+// PersonalAccessTokenPrefix, looking each up with resolve. The checks added
+// with WithCheck are for access tokens and do not run on them.
+func WithPersonalAccessTokens(resolve PersonalAccessTokenResolver) Option {
+	return func(m *Middleware) {
+		m.resolvePersonal = resolve
+	}
+}
+
+// WithErrorDetails sets how the cause of a rejected token is described to
+// clients. Without it no details are sent.
+func WithErrorDetails(details func(c httpx.Context, err error) string) Option {
+	return func(m *Middleware) {
+		m.details = details
+	}
+}
+
+// Middleware authenticates requests by their access token
+type Middleware struct {
+	verifier         TokenVerifier
+	cookie           string
+	requiredClaims   []string
+	allowBlacklisted bool
+	adminOnly        bool
+	checks           []Check
+	resolvePersonal  PersonalAccessTokenResolver
+	details          func(c httpx.Context, err error) string
+}
+
+// New creates a middleware verifying tokens with verifier
+func New(verifier TokenVerifier, opts ...Option) *Middleware {
+	m := &Middleware{verifier: verifier}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Handle authenticates the request. Accepted requests have the token's
+// claims stored under the context keys, including ImpersonatorIDKey and
+// TokenIDKey, and a personal access token's scopes under ScopesKey, and
+// continue; the rest are aborted with an error response.
+func (m *Middleware) Handle(c Context) {
+	tokenString, err := m.token(c)
+	if err != nil {
+		httpx.AbortWithError(c, err)
+		return
+	}
+
+	personal := m.resolvePersonal != nil && strings.HasPrefix(tokenString, PersonalAccessTokenPrefix)
+	var claims *sharedjwt.Claims
+	var scopes []string
+	if personal {
+		claims, scopes, err = m.resolvePersonal(c, tokenString)
+	} else {
+		claims, err = m.verifier.ValidateToken(tokenString)
+	}
+	if err != nil {
+		var appErr *httpx.AppError
+		if !personal || !errors.As(err, &appErr) {
+			appErr = &httpx.AppError{
+				Status:  http.StatusUnauthorized,
+				Code:    "INVALID_TOKEN",
+				Message: "Invalid or expired token",
+			}
+			if m.details != nil {
+				appErr.Details = m.details(c, err)
+			}
+		}
+		httpx.AbortWithError(c, appErr)
+		return
+	}
+
+	if missing := missingClaims(claims, m.requiredClaims); len(missing) > 0 {
+		httpx.AbortWithError(c, &httpx.AppError{
+			Status:  http.StatusUnauthorized,
+			Code:    "INVALID_TOKEN",
+			Message: "Token is missing required claims",
+			Details: map[string][]string{"missing_claims": missing},
+		})
+		return
+	}
+
+	if !personal {
+		for _, check := range m.checks {
+			if err := check(c, tokenString, claims); err != nil {
+				httpx.AbortWithError(c, err)
+				return
+			}
+		}
+	}
+
+	if claims.IsBlacklisted && !m.allowBlacklisted {
+		httpx.AbortWithError(c, &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "USER_BLACKLISTED",
+			Message: "User account has been suspended",
+		})
+		return
+	}
+
+	// Personal access tokens and impersonation tokens never act with the
+	// user's staff roles
+	roles := ClaimRoles(claims)
+	if personal || claims.ImpersonatorID != "" {
+		roles = []string{}
+	}
+	if m.adminOnly && !HasRole(roles, RoleAdmin) {
+		httpx.AbortWithError(c, &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "INSUFFICIENT_PERMISSIONS",
+			Message: "Admin privileges required",
+		})
+		return
+	}
+
+	c.Set(UserIDKey, claims.UserID)
+	c.Set(EmailKey, claims.Email)
+	c.Set(NameKey, claims.Name)
+	c.Set(IsAdminKey, claims.IsAdmin)
+	c.Set(IsBlacklistedKey, claims.IsBlacklisted)
+	c.Set(RolesKey, roles)
+	c.Set(ImpersonatorIDKey, claims.ImpersonatorID)
+	c.Set(TokenIDKey, claims.ID)
+	if personal {
+		c.Set(ScopesKey, scopes)
+	}
+
+	c.Next()
+}
+
+// token returns the request's access token from the Authorization header,
+// or from the cookie when one is configured and there is no header
+func (m *Middleware) token(c Context) (string, error) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		if m.cookie != "" {
+			if cookie, err := c.Cookie(m.cookie); err == nil && cookie != "" {
+				return cookie, nil
+			}
+		}
+		return "", &httpx.AppError{
+			Status:  http.StatusUnauthorized,
+			Code:    "MISSING_TOKEN",
+			Message: "Authorization header is required",
+		}
+	}
+
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", &httpx.AppError{
+			Status:  http.StatusUnauthorized,
+			Code:    "INVALID_TOKEN_FORMAT",
+			Message: "Token must be in format: Bearer <token>",
+		}
+	}
+
+	return strings.TrimPrefix(authHeader, "Bearer "), nil
+}
+
+// missingClaims returns the names of the required claims that are empty
+func missingClaims(claims *sharedjwt.Claims, required []string) []string {
+	var missing []string
+	for _, name := range required {
+		var value string
+		switch name {
+		case ClaimUserID:
+			value = claims.UserID
+		case ClaimEmail:
+			value = claims.Email
+		case ClaimName:
+			value = claims.Name
+		case ClaimID:
+			value = claims.ID
+		}
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,219 @@
+package authmw
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"microbank/pkg/httpx"
+	sharedjwt "microbank/pkg/jwt"
+)
+
+// fakeContext is a request with the given headers and cookies that records
+// the response and context values written through it
+type fakeContext struct {
+	headers map[string]string
+	cookies map[string]string
+	values  map[string]any
+	status  int
+	body    string
+	aborted bool
+	next    bool
+}
+
+func (c *fakeContext) JSON(code int, obj interface{}) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		panic(err)
+	}
+	c.status = code
+	c.body = string(body)
+}
+
+func (c *fakeContext) GetString(key string) string {
+	value, _ := c.values[key].(string)
+	return value
+}
+
+func (c *fakeContext) Abort() {
+	c.aborted = true
+}
+
+func (c *fakeContext) GetHeader(key string) string {
+	return c.headers[key]
+}
+
+func (c *fakeContext) Cookie(name string) (string, error) {
+	value, ok := c.cookies[name]
+	if !ok {
+		return "", errors.New("cookie not present")
+	}
+	return value, nil
+}
+
+func (c *fakeContext) Set(key string, value any) {
+	if c.values == nil {
+		c.values = make(map[string]any)
+	}
+	c.values[key] = value
+}
+
+func (c *fakeContext) Get(key string) (any, bool) {
+	value, ok := c.values[key]
+	return value, ok
+}
+
+func (c *fakeContext) Next() {
+	c.next = true
+}
+
+func signToken(t *testing.T, tokens *sharedjwt.TokenManager, claims sharedjwt.Claims) string {
+	t.Helper()
+	token, err := tokens.GenerateAccessToken(&claims)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestMiddleware_Handle(t *testing.T) {
+	tokens := sharedjwt.NewTokenManager("test-secret", time.Minute, time.Hour)
+	user := signToken(t, tokens, sharedjwt.Claims{UserID: "user-1", Email: "user@example.com"})
+	admin := signToken(t, tokens, sharedjwt.Claims{UserID: "admin-1", IsAdmin: true})
+	adminRole := signToken(t, tokens, sharedjwt.Claims{UserID: "admin-2", Roles: []string{RoleAdmin}})
+	blacklisted := signToken(t, tokens, sharedjwt.Claims{UserID: "user-2", IsBlacklisted: true})
+	nameless := signToken(t, tokens, sharedjwt.Claims{UserID: "user-3"})
+	revoked := errors.New("revoked")
+
+	tests := []struct {
+		name       string
+		opts       []Option
+		headers    map[string]string
+		cookies    map[string]string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "bearer token", headers: map[string]string{"Authorization": "Bearer " + user}},
+		{name: "missing token", wantStatus: http.StatusUnauthorized, wantCode: "MISSING_TOKEN"},
+		{name: "not a bearer token", headers: map[string]string{"Authorization": "Basic " + user}, wantStatus: http.StatusUnauthorized, wantCode: "INVALID_TOKEN_FORMAT"},
+		{name: "invalid token", headers: map[string]string{"Authorization": "Bearer not-a-token"}, wantStatus: http.StatusUnauthorized, wantCode: "INVALID_TOKEN"},
+		{name: "cookie without the cookie option", cookies: map[string]string{"access_token": user}, wantStatus: http.StatusUnauthorized, wantCode: "MISSING_TOKEN"},
+		{name: "cookie", opts: []Option{WithCookie("access_token")}, cookies: map[string]string{"access_token": user}},
+		{name: "header wins over cookie", opts: []Option{WithCookie("access_token")}, headers: map[string]string{"Authorization": "Bearer not-a-token"}, cookies: map[string]string{"access_token": user}, wantStatus: http.StatusUnauthorized, wantCode: "INVALID_TOKEN"},
+		{name: "required claims present", opts: []Option{RequireClaims(ClaimUserID, ClaimEmail, ClaimID)}, headers: map[string]string{"Authorization": "Bearer " + user}},
+		{name: "required claim missing", opts: []Option{RequireClaims(ClaimName)}, headers: map[string]string{"Authorization": "Bearer " + nameless}, wantStatus: http.StatusUnauthorized, wantCode: "INVALID_TOKEN"},
+		{name: "blacklisted user", headers: map[string]string{"Authorization": "Bearer " + blacklisted}, wantStatus: http.StatusForbidden, wantCode: "USER_BLACKLISTED"},
+		{name: "blacklisted user allowed", opts: []Option{AllowBlacklisted()}, headers: map[string]string{"Authorization": "Bearer " + blacklisted}},
+		{name: "admin only, admin", opts: []Option{AdminOnly()}, headers: map[string]string{"Authorization": "Bearer " + admin}},
+		{name: "admin only, admin role", opts: []Option{AdminOnly()}, headers: map[string]string{"Authorization": "Bearer " + adminRole}},
+		{name: "admin only, not admin", opts: []Option{AdminOnly()}, headers: map[string]string{"Authorization": "Bearer " + user}, wantStatus: http.StatusForbidden, wantCode: "INSUFFICIENT_PERMISSIONS"},
+		{
+			name: "check fails with an app error",
+			opts: []Option{WithCheck(func(Context, string, *sharedjwt.Claims) error {
+				return &httpx.AppError{Status: http.StatusUnauthorized, Code: "TOKEN_REVOKED", Message: "Token has been revoked"}
+			})},
+			headers:    map[string]string{"Authorization": "Bearer " + user},
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "TOKEN_REVOKED",
+		},
+		{
+			name:       "check fails with another error",
+			opts:       []Option{WithCheck(func(Context, string, *sharedjwt.Claims) error { return revoked })},
+			headers:    map[string]string{"Authorization": "Bearer " + user},
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "INTERNAL_ERROR",
+		},
+		{
+			name: "check runs before the blacklist check",
+			opts: []Option{WithCheck(func(Context, string, *sharedjwt.Claims) error {
+				return &httpx.AppError{Status: http.StatusUnauthorized, Code: "TOKEN_REVOKED", Message: "Token has been revoked"}
+			})},
+			headers:    map[string]string{"Authorization": "Bearer " + blacklisted},
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "TOKEN_REVOKED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeContext{headers: tt.headers, cookies: tt.cookies}
+			New(tokens, tt.opts...).Handle(c)
+
+			if tt.wantStatus == 0 {
+				if !c.next || c.aborted {
+					t.Fatalf("Expected the request to continue, got status %d: %s", c.status, c.body)
+				}
+				return
+			}
+			if c.next || !c.aborted {
+				t.Fatalf("Expected the request to be aborted")
+			}
+			if c.status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, c.status, c.body)
+			}
+			if !strings.Contains(c.body, `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("Expected error code %s, got %s", tt.wantCode, c.body)
+			}
+		})
+	}
+}
+
+func TestMiddleware_Handle_SetsClaims(t *testing.T) {
+	tokens := sharedjwt.NewTokenManager("test-secret", time.Minute, time.Hour)
+	token := signToken(t, tokens, sharedjwt.Claims{UserID: "user-1", Email: "user@example.com", Name: "Andile", IsAdmin: true})
+
+	var checked *sharedjwt.Claims
+	c := &fakeContext{headers: map[string]string{"Authorization": "Bearer " + token}}
+	New(tokens, WithCheck(func(c Context, got string, claims *sharedjwt.Claims) error {
+		if got != token {
+			t.Errorf("Expected the check to get the token")
+		}
+		checked = claims
+		c.Set("checked", true)
+		return nil
+	})).Handle(c)
+
+	if checked == nil || checked.UserID != "user-1" {
+		t.Fatalf("Expected the check to get the claims, got %+v", checked)
+	}
+	want := map[string]any{
+		UserIDKey:        "user-1",
+		EmailKey:         "user@example.com",
+		NameKey:          "Andile",
+		IsAdminKey:       true,
+		IsBlacklistedKey: false,
+		"checked":        true,
+	}
+	for key, value := range want {
+		if c.values[key] != value {
+			t.Errorf("Expected %s to be %v, got %v", key, value, c.values[key])
+		}
+	}
+	if roles := ContextRoles(c); !reflect.DeepEqual(roles, []string{RoleAdmin}) {
+		t.Errorf("Expected roles [admin], got %v", roles)
+	}
+}
+
+func TestMiddleware_Handle_ErrorDetails(t *testing.T) {
+	tokens := sharedjwt.NewTokenManager("test-secret", time.Minute, time.Hour)
+
+	c := &fakeContext{headers: map[string]string{"Authorization": "Bearer not-a-token"}}
+	New(tokens, WithErrorDetails(func(httpx.Context, error) string { return "malformed token" })).Handle(c)
+
+	if !strings.Contains(c.body, `"details":"malformed token"`) {
+		t.Errorf("Expected the error details in the response, got %s", c.body)
+	}
+}
+
+func TestRequireClaims_UnknownClaim(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected RequireClaims to panic on an unknown claim")
+		}
+	}()
+	RequireClaims("role")
+}
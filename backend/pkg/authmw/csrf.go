@@ -0,0 +1,57 @@
+package authmw
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"microbank/pkg/httpx"
+)
+
+// Cookies and header of cookie mode, in which browsers keep their tokens in
+// HttpOnly cookies. The client-service sets them and every service reads
+// them.
+const (
+	// AccessTokenCookie holds the access token
+	AccessTokenCookie = "access_token"
+	// CSRFTokenCookie holds the CSRF token. It is readable by scripts so the
+	// page can copy it into CSRFHeader.
+	CSRFTokenCookie = "csrf_token"
+	// CSRFHeader carries the CSRF token on state-changing requests
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// CheckCSRF returns a 403 INVALID_CSRF_TOKEN error for state-changing
+// requests that carry any of the named auth cookies without repeating the
+// CSRF cookie in CSRFHeader (the double-submit pattern). Requests that send
+// their tokens in headers or bodies cannot be forged by another site and
+// pass.
+func CheckCSRF(r *http.Request, authCookies ...string) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	for _, name := range authCookies {
+		if cookie, err := r.Cookie(name); err == nil && cookie.Value != "" {
+			if ValidCSRF(r) {
+				return nil
+			}
+			return &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "INVALID_CSRF_TOKEN",
+				Message: "The " + CSRFHeader + " header must match the CSRF cookie",
+			}
+		}
+	}
+	return nil
+}
+
+// ValidCSRF reports whether a request's CSRF header matches its CSRF cookie
+func ValidCSRF(r *http.Request) bool {
+	cookie, err := r.Cookie(CSRFTokenCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	header := r.Header.Get(CSRFHeader)
+	return subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) == 1
+}
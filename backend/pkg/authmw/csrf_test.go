@@ -0,0 +1,84 @@
+package authmw
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"microbank/pkg/httpx"
+)
+
+func TestCheckCSRF(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		authCookie bool
+		csrfCookie string
+		csrfHeader string
+		wantErr    bool
+	}{
+		{name: "safe method with cookie", method: http.MethodGet, authCookie: true},
+		{name: "no auth cookie", method: http.MethodPost},
+		{name: "missing header", method: http.MethodPost, authCookie: true, csrfCookie: "token", wantErr: true},
+		{name: "mismatched header", method: http.MethodDelete, authCookie: true, csrfCookie: "token", csrfHeader: "forged", wantErr: true},
+		{name: "missing csrf cookie", method: http.MethodPut, authCookie: true, csrfHeader: "token", wantErr: true},
+		{name: "matching header", method: http.MethodPost, authCookie: true, csrfCookie: "token", csrfHeader: "token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.authCookie {
+				req.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: "access"})
+			}
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFTokenCookie, Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(CSRFHeader, tt.csrfHeader)
+			}
+
+			err := CheckCSRF(req, "refresh_token", AccessTokenCookie)
+			var appErr *httpx.AppError
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &appErr) || appErr.Status != http.StatusForbidden || appErr.Code != "INVALID_CSRF_TOKEN" {
+				t.Fatalf("Expected 403 INVALID_CSRF_TOKEN, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidCSRF(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		want   bool
+	}{
+		{name: "matching", cookie: "token", header: "token", want: true},
+		{name: "mismatched", cookie: "token", header: "other", want: false},
+		{name: "missing header", cookie: "token", want: false},
+		{name: "missing cookie", header: "token", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFTokenCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+			if got := ValidCSRF(req); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
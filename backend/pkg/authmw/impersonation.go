@@ -0,0 +1,35 @@
+package authmw
+
+import (
+	"net/http"
+
+	"microbank/pkg/httpx"
+)
+
+// ImpersonatorIDKey is the context key the ID of the admin impersonating
+// the user is stored under. It is empty unless the request was made with an
+// impersonation token.
+const ImpersonatorIDKey = "impersonator_id"
+
+// TokenIDKey is the context key the access token's jti claim is stored under
+const TokenIDKey = "token_id"
+
+// ContextImpersonatorID returns the ID of the admin impersonating the user,
+// or "" when the request was made by the user themselves
+func ContextImpersonatorID(c httpx.Context) string {
+	return c.GetString(ImpersonatorIDKey)
+}
+
+// ForbidImpersonation rejects requests made with an impersonation token
+// with 403 IMPERSONATION_FORBIDDEN, for actions only the user may take
+func ForbidImpersonation(c RoleContext) {
+	if ContextImpersonatorID(c) != "" {
+		httpx.AbortWithError(c, &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "IMPERSONATION_FORBIDDEN",
+			Message: "This action is not allowed while impersonating a user",
+		})
+		return
+	}
+	c.Next()
+}
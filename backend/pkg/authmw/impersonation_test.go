@@ -0,0 +1,57 @@
+package authmw
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	sharedjwt "microbank/pkg/jwt"
+)
+
+func TestMiddleware_Handle_Impersonation(t *testing.T) {
+	tokens := sharedjwt.NewTokenManager("test-secret", time.Minute, time.Hour)
+	token := signToken(t, tokens, sharedjwt.Claims{UserID: "user-1", Roles: []string{RoleSupport}, ImpersonatorID: "admin-1"})
+
+	c := &fakeContext{headers: map[string]string{"Authorization": "Bearer " + token}}
+	New(tokens).Handle(c)
+
+	if !c.next {
+		t.Fatalf("Expected the request to continue, got status %d: %s", c.status, c.body)
+	}
+	if got := ContextImpersonatorID(c); got != "admin-1" {
+		t.Errorf("Expected impersonator admin-1, got %q", got)
+	}
+	if c.GetString(TokenIDKey) == "" {
+		t.Error("Expected the token ID to be stored")
+	}
+	if roles := ContextRoles(c); len(roles) != 0 {
+		t.Errorf("Expected no roles while impersonating, got %v", roles)
+	}
+}
+
+func TestForbidImpersonation(t *testing.T) {
+	tests := []struct {
+		name         string
+		impersonator string
+		wantNext     bool
+	}{
+		{name: "user", wantNext: true},
+		{name: "impersonating", impersonator: "admin-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeContext{}
+			c.Set(ImpersonatorIDKey, tt.impersonator)
+			ForbidImpersonation(c)
+
+			if c.next != tt.wantNext {
+				t.Fatalf("Expected next=%v, got %v", tt.wantNext, c.next)
+			}
+			if !tt.wantNext && (c.status != http.StatusForbidden || !strings.Contains(c.body, `"code":"IMPERSONATION_FORBIDDEN"`)) {
+				t.Errorf("Expected 403 IMPERSONATION_FORBIDDEN, got %d: %s", c.status, c.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package authmw
+
+import (
+	"net/http"
+
+	"microbank/pkg/httpx"
+	sharedjwt "microbank/pkg/jwt"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token, which tells
+// them apart from access tokens without a lookup
+const PersonalAccessTokenPrefix = "pat_"
+
+// ScopesKey is the context key the scopes of a personal access token are
+// stored under, as a []string. Nothing is stored for access tokens.
+const ScopesKey = "scopes"
+
+// Scopes a personal access token can be granted
+const (
+	ScopeReadBalance       = "read:balance"
+	ScopeReadTransactions  = "read:transactions"
+	ScopeWriteTransactions = "write:transactions"
+	ScopeReadGoals         = "read:goals"
+	ScopeWriteGoals        = "write:goals"
+)
+
+// Scopes lists every scope
+var Scopes = []string{ScopeReadBalance, ScopeReadTransactions, ScopeWriteTransactions, ScopeReadGoals, ScopeWriteGoals}
+
+// ValidScope reports whether scope is one of Scopes
+func ValidScope(scope string) bool {
+	for _, known := range Scopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+// PersonalAccessTokenResolver looks up the owner of a personal access token
+// and the scopes it grants. The claims describe the owner in place of an
+// access token's. A non-nil error stops the request; an *httpx.AppError is
+// reported as is, and anything else as 401 INVALID_TOKEN.
+type PersonalAccessTokenResolver func(c Context, token string) (*sharedjwt.Claims, []string, error)
+
+// ContextScopes returns the scopes stored under ScopesKey, and whether the
+// request was authenticated with a personal access token at all
+func ContextScopes(c RoleContext) ([]string, bool) {
+	value, ok := c.Get(ScopesKey)
+	if !ok {
+		return nil, false
+	}
+	scopes, _ := value.([]string)
+	return scopes, true
+}
+
+// RequireScope returns a handler that lets through requests authenticated
+// with an access token, which act with the user's full rights, and requests
+// whose personal access token grants scope. The rest are rejected with 403
+// INSUFFICIENT_SCOPE.
+func RequireScope(scope string) func(c RoleContext) {
+	return func(c RoleContext) {
+		if scopes, personal := ContextScopes(c); personal && !hasScope(scopes, scope) {
+			httpx.AbortWithError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "INSUFFICIENT_SCOPE",
+				Message: "Token does not grant the required scope",
+				Details: map[string]string{"required_scope": scope},
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// hasScope reports whether scopes include scope
+func hasScope(scopes []string, scope string) bool {
+	for _, granted := range scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
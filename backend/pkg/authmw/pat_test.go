@@ -0,0 +1,127 @@
+package authmw
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"microbank/pkg/httpx"
+	sharedjwt "microbank/pkg/jwt"
+)
+
+func TestMiddleware_Handle_PersonalAccessTokens(t *testing.T) {
+	tokens := sharedjwt.NewTokenManager("test-secret", time.Minute, time.Hour)
+	access := signToken(t, tokens, sharedjwt.Claims{UserID: "user-1"})
+
+	// The resolver knows one token per outcome
+	resolve := func(_ Context, token string) (*sharedjwt.Claims, []string, error) {
+		switch token {
+		case "pat_valid":
+			return &sharedjwt.Claims{UserID: "user-1", Email: "user@example.com"}, []string{ScopeReadBalance}, nil
+		case "pat_admin":
+			return &sharedjwt.Claims{UserID: "admin-1", IsAdmin: true, Roles: []string{RoleAdmin}}, []string{ScopeReadBalance}, nil
+		case "pat_blacklisted":
+			return &sharedjwt.Claims{UserID: "user-2", IsBlacklisted: true}, []string{ScopeReadBalance}, nil
+		case "pat_unavailable":
+			return nil, nil, &httpx.AppError{Status: http.StatusServiceUnavailable, Code: "AUTH_SERVICE_UNAVAILABLE", Message: "Unable to verify token"}
+		default:
+			return nil, nil, errors.New("unknown token")
+		}
+	}
+	var checked bool
+	check := WithCheck(func(Context, string, *sharedjwt.Claims) error {
+		checked = true
+		return nil
+	})
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		token       string
+		wantStatus  int
+		wantCode    string
+		wantScopes  []string
+		wantChecked bool
+	}{
+		{name: "personal access token", opts: []Option{WithPersonalAccessTokens(resolve), check}, token: "pat_valid", wantScopes: []string{ScopeReadBalance}},
+		{name: "access token", opts: []Option{WithPersonalAccessTokens(resolve), check}, token: access, wantChecked: true},
+		{name: "not accepted", token: "pat_valid", wantStatus: http.StatusUnauthorized, wantCode: "INVALID_TOKEN"},
+		{name: "unknown token", opts: []Option{WithPersonalAccessTokens(resolve)}, token: "pat_unknown", wantStatus: http.StatusUnauthorized, wantCode: "INVALID_TOKEN"},
+		{name: "resolver app error", opts: []Option{WithPersonalAccessTokens(resolve)}, token: "pat_unavailable", wantStatus: http.StatusServiceUnavailable, wantCode: "AUTH_SERVICE_UNAVAILABLE"},
+		{name: "blacklisted owner", opts: []Option{WithPersonalAccessTokens(resolve)}, token: "pat_blacklisted", wantStatus: http.StatusForbidden, wantCode: "USER_BLACKLISTED"},
+		{name: "admin owner, admin only", opts: []Option{WithPersonalAccessTokens(resolve), AdminOnly()}, token: "pat_admin", wantStatus: http.StatusForbidden, wantCode: "INSUFFICIENT_PERMISSIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checked = false
+			c := &fakeContext{headers: map[string]string{"Authorization": "Bearer " + tt.token}}
+			New(tokens, tt.opts...).Handle(c)
+
+			if tt.wantStatus != 0 {
+				if c.status != tt.wantStatus || !strings.Contains(c.body, `"code":"`+tt.wantCode+`"`) {
+					t.Fatalf("Expected %d %s, got %d: %s", tt.wantStatus, tt.wantCode, c.status, c.body)
+				}
+				return
+			}
+			if !c.next {
+				t.Fatalf("Expected the request to continue, got status %d: %s", c.status, c.body)
+			}
+			if checked != tt.wantChecked {
+				t.Errorf("Expected checks to run: %v, got %v", tt.wantChecked, checked)
+			}
+			scopes, personal := ContextScopes(c)
+			if personal != (tt.wantScopes != nil) || !reflect.DeepEqual(scopes, tt.wantScopes) {
+				t.Errorf("Expected scopes %v, got %v (personal %v)", tt.wantScopes, scopes, personal)
+			}
+		})
+	}
+}
+
+func TestMiddleware_Handle_PersonalAccessTokenHasNoRoles(t *testing.T) {
+	tokens := sharedjwt.NewTokenManager("test-secret", time.Minute, time.Hour)
+	resolve := func(Context, string) (*sharedjwt.Claims, []string, error) {
+		return &sharedjwt.Claims{UserID: "admin-1", IsAdmin: true, Roles: []string{RoleAdmin}}, []string{ScopeReadBalance}, nil
+	}
+
+	c := &fakeContext{headers: map[string]string{"Authorization": "Bearer pat_admin"}}
+	New(tokens, WithPersonalAccessTokens(resolve)).Handle(c)
+
+	if roles := ContextRoles(c); len(roles) != 0 {
+		t.Errorf("Expected no roles, got %v", roles)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []string
+		personal bool
+		wantNext bool
+	}{
+		{name: "access token", wantNext: true},
+		{name: "scope granted", scopes: []string{ScopeReadBalance, ScopeReadTransactions}, personal: true, wantNext: true},
+		{name: "scope missing", scopes: []string{ScopeReadBalance}, personal: true},
+		{name: "no scopes", personal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeContext{}
+			if tt.personal {
+				c.Set(ScopesKey, tt.scopes)
+			}
+			RequireScope(ScopeReadTransactions)(c)
+
+			if c.next != tt.wantNext {
+				t.Fatalf("Expected next=%v, got %v", tt.wantNext, c.next)
+			}
+			if !tt.wantNext && (c.status != http.StatusForbidden || !strings.Contains(c.body, `"code":"INSUFFICIENT_SCOPE"`)) {
+				t.Errorf("Expected 403 INSUFFICIENT_SCOPE, got %d: %s", c.status, c.body)
+			}
+		})
+	}
+}
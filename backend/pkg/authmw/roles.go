@@ -0,0 +1,157 @@
+package authmw
+
+import (
+	"net/http"
+
+	"microbank/pkg/httpx"
+	sharedjwt "microbank/pkg/jwt"
+)
+
+// RolesKey is the context key the authenticated user's roles are stored
+// under, as a []string
+const RolesKey = "roles"
+
+// Staff roles. Users without a role are customers.
+const (
+	// RoleAdmin may do everything
+	RoleAdmin = "admin"
+	// RoleSupport may look up clients and their transactions,
+	// impersonate clients and review their KYC details
+	RoleSupport = "support"
+	// RoleAuditor may look up clients, their transactions, the admin
+	// audit log and suspicious activity reports, and export client lists
+	RoleAuditor = "auditor"
+	// RoleTeller may record cheque deposits taken at a branch, open client
+	// accounts on customers' behalf and look up transactions
+	RoleTeller = "teller"
+)
+
+// Roles lists every role, in the order they are listed to clients
+var Roles = []string{RoleAdmin, RoleSupport, RoleAuditor, RoleTeller}
+
+// Permissions granted by roles
+const (
+	PermissionClientsRead        = "clients:read"
+	PermissionClientsCreate      = "clients:create"
+	PermissionClientsExport      = "clients:export"
+	PermissionClientsBlacklist   = "clients:blacklist"
+	PermissionClientsDelete      = "clients:delete"
+	PermissionClientsImpersonate = "clients:impersonate"
+	PermissionKYCReview          = "kyc:review"
+	PermissionSessionsRevoke     = "sessions:revoke"
+	PermissionRolesManage        = "roles:manage"
+	PermissionInvitationsManage  = "invitations:manage"
+	PermissionAuditRead          = "audit:read"
+	PermissionMaintenanceRun     = "maintenance:run"
+	PermissionTransactionsRead   = "transactions:read"
+	PermissionTransactionsAdjust = "transactions:adjust"
+	PermissionChequesDeposit     = "cheques:deposit"
+	PermissionPromotionsManage   = "promotions:manage"
+	PermissionComplianceRead     = "compliance:read"
+	PermissionComplianceReview   = "compliance:review"
+)
+
+// rolePermissions maps each role other than admin, which holds every
+// permission, to the permissions it grants
+var rolePermissions = map[string][]string{
+	RoleSupport: {PermissionClientsRead, PermissionClientsImpersonate, PermissionKYCReview, PermissionTransactionsRead},
+	RoleAuditor: {PermissionClientsRead, PermissionClientsExport, PermissionAuditRead, PermissionTransactionsRead, PermissionComplianceRead},
+	RoleTeller:  {PermissionClientsCreate, PermissionTransactionsRead, PermissionChequesDeposit},
+}
+
+// ValidRole reports whether role is one of Roles
+func ValidRole(role string) bool {
+	for _, known := range Roles {
+		if role == known {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether roles include any of wanted
+func HasRole(roles []string, wanted ...string) bool {
+	for _, role := range roles {
+		for _, w := range wanted {
+			if role == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether any of roles grants permission
+func HasPermission(roles []string, permission string) bool {
+	for _, role := range roles {
+		if role == RoleAdmin {
+			return true
+		}
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClaimRoles returns the roles a token grants. Tokens issued before the
+// roles claim was added carry only is_admin, which maps to the admin role.
+func ClaimRoles(claims *sharedjwt.Claims) []string {
+	if len(claims.Roles) > 0 {
+		return claims.Roles
+	}
+	if claims.IsAdmin {
+		return []string{RoleAdmin}
+	}
+	return []string{}
+}
+
+// RoleContext is the part of a request context the role checks need
+type RoleContext interface {
+	httpx.Context
+	Get(key string) (any, bool)
+	Next()
+}
+
+// ContextRoles returns the roles stored under RolesKey
+func ContextRoles(c RoleContext) []string {
+	value, _ := c.Get(RolesKey)
+	roles, _ := value.([]string)
+	return roles
+}
+
+// RequireRole returns a handler that lets through users holding any of
+// roles and rejects the rest with 403 INSUFFICIENT_PERMISSIONS
+func RequireRole(roles ...string) func(c RoleContext) {
+	return func(c RoleContext) {
+		if !HasRole(ContextRoles(c), roles...) {
+			httpx.AbortWithError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "INSUFFICIENT_PERMISSIONS",
+				Message: "A role you do not hold is required",
+				Details: map[string][]string{"required_roles": roles},
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission returns a handler that lets through users whose roles
+// grant permission and rejects the rest with 403 INSUFFICIENT_PERMISSIONS
+func RequirePermission(permission string) func(c RoleContext) {
+	return func(c RoleContext) {
+		if !HasPermission(ContextRoles(c), permission) {
+			httpx.AbortWithError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "INSUFFICIENT_PERMISSIONS",
+				Message: "You do not have permission to do this",
+				Details: map[string]string{"required_permission": permission},
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,101 @@
+package authmw
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	sharedjwt "microbank/pkg/jwt"
+)
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		roles      []string
+		permission string
+		want       bool
+	}{
+		{name: "admin holds every permission", roles: []string{RoleAdmin}, permission: PermissionTransactionsAdjust, want: true},
+		{name: "support reads clients", roles: []string{RoleSupport}, permission: PermissionClientsRead, want: true},
+		{name: "support cannot blacklist", roles: []string{RoleSupport}, permission: PermissionClientsBlacklist},
+		{name: "support cannot adjust balances", roles: []string{RoleSupport}, permission: PermissionTransactionsAdjust},
+		{name: "support impersonates clients", roles: []string{RoleSupport}, permission: PermissionClientsImpersonate, want: true},
+		{name: "auditor cannot impersonate", roles: []string{RoleAuditor}, permission: PermissionClientsImpersonate},
+		{name: "support reviews KYC", roles: []string{RoleSupport}, permission: PermissionKYCReview, want: true},
+		{name: "auditor cannot review KYC", roles: []string{RoleAuditor}, permission: PermissionKYCReview},
+		{name: "auditor reads the audit log", roles: []string{RoleAuditor}, permission: PermissionAuditRead, want: true},
+		{name: "auditor reads compliance reports", roles: []string{RoleAuditor}, permission: PermissionComplianceRead, want: true},
+		{name: "auditor cannot review compliance findings", roles: []string{RoleAuditor}, permission: PermissionComplianceReview},
+		{name: "support cannot read compliance reports", roles: []string{RoleSupport}, permission: PermissionComplianceRead},
+		{name: "teller deposits cheques", roles: []string{RoleTeller}, permission: PermissionChequesDeposit, want: true},
+		{name: "teller cannot adjust balances", roles: []string{RoleTeller}, permission: PermissionTransactionsAdjust},
+		{name: "teller opens client accounts", roles: []string{RoleTeller}, permission: PermissionClientsCreate, want: true},
+		{name: "support cannot open client accounts", roles: []string{RoleSupport}, permission: PermissionClientsCreate},
+		{name: "admin manages promotions", roles: []string{RoleAdmin}, permission: PermissionPromotionsManage, want: true},
+		{name: "support cannot manage promotions", roles: []string{RoleSupport}, permission: PermissionPromotionsManage},
+		{name: "roles combine", roles: []string{RoleSupport, RoleAuditor}, permission: PermissionClientsExport, want: true},
+		{name: "customer", roles: []string{}, permission: PermissionClientsRead},
+		{name: "unknown role", roles: []string{"owner"}, permission: PermissionClientsRead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPermission(tt.roles, tt.permission); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClaimRoles(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims sharedjwt.Claims
+		want   []string
+	}{
+		{name: "roles claim", claims: sharedjwt.Claims{Roles: []string{RoleSupport}}, want: []string{RoleSupport}},
+		{name: "is_admin without roles", claims: sharedjwt.Claims{IsAdmin: true}, want: []string{RoleAdmin}},
+		{name: "customer", claims: sharedjwt.Claims{}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClaimRoles(&tt.claims); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		roles    []string
+		handler  func(c RoleContext)
+		wantNext bool
+	}{
+		{name: "permission granted", roles: []string{RoleSupport}, handler: RequirePermission(PermissionClientsRead), wantNext: true},
+		{name: "permission missing", roles: []string{RoleSupport}, handler: RequirePermission(PermissionClientsBlacklist)},
+		{name: "no roles", handler: RequirePermission(PermissionClientsRead)},
+		{name: "role held", roles: []string{RoleAuditor}, handler: RequireRole(RoleAdmin, RoleAuditor), wantNext: true},
+		{name: "role missing", roles: []string{RoleSupport}, handler: RequireRole(RoleAdmin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeContext{}
+			if tt.roles != nil {
+				c.Set(RolesKey, tt.roles)
+			}
+			tt.handler(c)
+
+			if c.next != tt.wantNext {
+				t.Fatalf("Expected next %v, got %v", tt.wantNext, c.next)
+			}
+			if !tt.wantNext && (c.status != http.StatusForbidden || !strings.Contains(c.body, "INSUFFICIENT_PERMISSIONS")) {
+				t.Errorf("Expected 403 INSUFFICIENT_PERMISSIONS, got %d: %s", c.status, c.body)
+			}
+		})
+	}
+}
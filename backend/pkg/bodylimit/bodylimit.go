@@ -0,0 +1,116 @@
+// Package bodylimit bounds the size of request bodies and checks that JSON
+// endpoints are sent JSON. It only depends on net/http; each service wraps
+// it in its own middleware.
+package bodylimit
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"microbank/pkg/httpx"
+)
+
+// Default limits, used when MAX_BODY_BYTES and MAX_UPLOAD_BYTES are not set
+const (
+	DefaultMaxBytes       int64 = 1 << 20  // 1 MiB, far more than any JSON request needs
+	DefaultMaxUploadBytes int64 = 10 << 20 // 10 MiB
+)
+
+// Limits holds the largest request bodies the services accept
+type Limits struct {
+	// MaxBytes bounds the bodies of JSON endpoints
+	MaxBytes int64
+	// MaxUploadBytes bounds the bodies of endpoints that accept uploads,
+	// such as imported files and documents
+	MaxUploadBytes int64
+}
+
+// LimitsFromEnv reads MAX_BODY_BYTES and MAX_UPLOAD_BYTES, both in bytes.
+// The upload limit cannot be smaller than the JSON one.
+func LimitsFromEnv() (Limits, error) {
+	limits := Limits{MaxBytes: DefaultMaxBytes, MaxUploadBytes: DefaultMaxUploadBytes}
+
+	for _, setting := range []struct {
+		name  string
+		limit *int64
+	}{
+		{"MAX_BODY_BYTES", &limits.MaxBytes},
+		{"MAX_UPLOAD_BYTES", &limits.MaxUploadBytes},
+	} {
+		value := os.Getenv(setting.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 1 {
+			return Limits{}, fmt.Errorf("invalid %s %q: must be a positive number of bytes", setting.name, value)
+		}
+		*setting.limit = n
+	}
+
+	if limits.MaxUploadBytes < limits.MaxBytes {
+		return Limits{}, fmt.Errorf("MAX_UPLOAD_BYTES (%d) must be at least MAX_BODY_BYTES (%d)", limits.MaxUploadBytes, limits.MaxBytes)
+	}
+	return limits, nil
+}
+
+// TooLarge is the error reported for a body over maxBytes
+func TooLarge(maxBytes int64) *httpx.AppError {
+	return &httpx.AppError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    "PAYLOAD_TOO_LARGE",
+		Message: "Request body is too large",
+		Details: map[string]int64{"max_bytes": maxBytes},
+	}
+}
+
+// ErrUnsupportedMediaType is reported for a JSON endpoint sent a body that
+// is not JSON
+var ErrUnsupportedMediaType = &httpx.AppError{
+	Status:  http.StatusUnsupportedMediaType,
+	Code:    "UNSUPPORTED_MEDIA_TYPE",
+	Message: "Request body must be JSON (Content-Type: application/json)",
+}
+
+// Limit stops r's body from being read past maxBytes. A body whose
+// Content-Length is already over the limit is refused at once with
+// TooLarge; one sent without a length fails when it is read, which
+// IsTooLarge recognizes.
+func Limit(w http.ResponseWriter, r *http.Request, maxBytes int64) error {
+	if r.ContentLength > maxBytes {
+		return TooLarge(maxBytes)
+	}
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+	return nil
+}
+
+// IsTooLarge reports whether err came from reading a body past its limit,
+// and returns the error to report for it
+func IsTooLarge(err error) (*httpx.AppError, bool) {
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		return nil, false
+	}
+	return TooLarge(maxBytesErr.Limit), true
+}
+
+// RequireJSON refuses a request with a body whose Content-Type is not JSON,
+// application/json or a +json type, with ErrUnsupportedMediaType. Requests
+// without a body are accepted.
+func RequireJSON(r *http.Request) error {
+	if r.ContentLength == 0 || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return ErrUnsupportedMediaType
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package bodylimit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"microbank/pkg/httpx"
+)
+
+func TestLimitsFromEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		upload     string
+		wantBody   int64
+		wantUpload int64
+		wantErr    string
+	}{
+		{name: "defaults", wantBody: DefaultMaxBytes, wantUpload: DefaultMaxUploadBytes},
+		{name: "configured", body: "2048", upload: "4096", wantBody: 2048, wantUpload: 4096},
+		{name: "not a number", body: "1MB", wantErr: "invalid MAX_BODY_BYTES"},
+		{name: "zero", upload: "0", wantErr: "invalid MAX_UPLOAD_BYTES"},
+		{name: "upload below body", body: "2048", upload: "1024", wantErr: "must be at least MAX_BODY_BYTES"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MAX_BODY_BYTES", tt.body)
+			t.Setenv("MAX_UPLOAD_BYTES", tt.upload)
+
+			limits, err := LimitsFromEnv()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LimitsFromEnv failed: %v", err)
+			}
+			if limits.MaxBytes != tt.wantBody || limits.MaxUploadBytes != tt.wantUpload {
+				t.Errorf("Expected limits %d and %d, got %+v", tt.wantBody, tt.wantUpload, limits)
+			}
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	const maxBytes = 16
+
+	tests := []struct {
+		name          string
+		size          int
+		unknownLength bool
+		wantTooLarge  bool
+	}{
+		{name: "exactly at the limit", size: maxBytes},
+		{name: "one byte over", size: maxBytes + 1, wantTooLarge: true},
+		{name: "exactly at the limit without a length", size: maxBytes, unknownLength: true},
+		{name: "one byte over without a length", size: maxBytes + 1, unknownLength: true, wantTooLarge: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.Repeat("x", tt.size)
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			if tt.unknownLength {
+				r.ContentLength = -1
+			}
+
+			err := Limit(httptest.NewRecorder(), r, maxBytes)
+			if err == nil {
+				var read []byte
+				read, err = io.ReadAll(r.Body)
+				if err == nil && string(read) != body {
+					t.Fatalf("Expected the whole body to be read, got %d bytes", len(read))
+				}
+				if err != nil {
+					appErr, ok := IsTooLarge(err)
+					if !ok {
+						t.Fatalf("Expected a body too large error, got %v", err)
+					}
+					err = appErr
+				}
+			}
+
+			var appErr *httpx.AppError
+			if tooLarge := errors.As(err, &appErr); tooLarge != tt.wantTooLarge {
+				t.Fatalf("Expected too large=%v, got %v", tt.wantTooLarge, err)
+			}
+			if tt.wantTooLarge && (appErr.Status != http.StatusRequestEntityTooLarge || appErr.Code != "PAYLOAD_TOO_LARGE") {
+				t.Errorf("Expected 413 PAYLOAD_TOO_LARGE, got %d %s", appErr.Status, appErr.Code)
+			}
+		})
+	}
+}
+
+func TestRequireJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		wantErr     bool
+	}{
+		{name: "json", body: "{}", contentType: "application/json"},
+		{name: "json with charset", body: "{}", contentType: "application/json; charset=utf-8"},
+		{name: "json suffix", body: "{}", contentType: "application/merge-patch+json"},
+		{name: "no body", contentType: ""},
+		{name: "form", body: "a=b", contentType: "application/x-www-form-urlencoded", wantErr: true},
+		{name: "text", body: "{}", contentType: "text/plain", wantErr: true},
+		{name: "missing content type", body: "{}", contentType: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			r := httptest.NewRequest(http.MethodPost, "/", body)
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			err := RequireJSON(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error=%v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrUnsupportedMediaType) {
+				t.Errorf("Expected ErrUnsupportedMediaType, got %v", err)
+			}
+		})
+	}
+}
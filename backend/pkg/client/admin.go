@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ClientFilter narrows ListClients. Zero fields do not filter.
+type ClientFilter struct {
+	ListOptions
+	// Search matches email or name, ignoring case
+	Search        string
+	IsBlacklisted *bool
+	IsAdmin       *bool
+	// Deleted lists soft-deleted users instead of active ones
+	Deleted       bool
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Sort is created_at (the default) or email, and Order asc or desc
+	Sort  string
+	Order string
+}
+
+// query returns the filter as listing query parameters
+func (f ClientFilter) query() url.Values {
+	query := url.Values{}
+	if f.Search != "" {
+		query.Set("search", f.Search)
+	}
+	if f.IsBlacklisted != nil {
+		query.Set("is_blacklisted", strconv.FormatBool(*f.IsBlacklisted))
+	}
+	if f.IsAdmin != nil {
+		query.Set("is_admin", strconv.FormatBool(*f.IsAdmin))
+	}
+	if f.Deleted {
+		query.Set("deleted", "true")
+	}
+	if !f.CreatedAfter.IsZero() {
+		query.Set("created_after", f.CreatedAfter.Format(time.RFC3339))
+	}
+	if !f.CreatedBefore.IsZero() {
+		query.Set("created_before", f.CreatedBefore.Format(time.RFC3339))
+	}
+	if f.Sort != "" {
+		query.Set("sort", f.Sort)
+	}
+	if f.Order != "" {
+		query.Set("order", f.Order)
+	}
+	return query
+}
+
+// ClientDetail is one user's full detail, as admins see it
+type ClientDetail struct {
+	User
+	Blacklist struct {
+		Reason    string     `json:"reason"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	} `json:"blacklist"`
+	EmailVerification struct {
+		Verified          bool       `json:"verified"`
+		VerifiedAt        *time.Time `json:"verified_at"`
+		PendingEmail      string     `json:"pending_email"`
+		ChangeRequestedAt *time.Time `json:"change_requested_at"`
+	} `json:"email_verification"`
+	ActiveSessions int `json:"active_sessions"`
+}
+
+// ListClients iterates over the users matching filter (admin only)
+func (c *Client) ListClients(filter ClientFilter) *Iterator[User] {
+	return newIterator(filter.ListOptions, func(ctx context.Context, limit, offset int) ([]User, *pagination, error) {
+		var data struct {
+			Users []User `json:"users"`
+		}
+		p, err := c.do(ctx, call{
+			method:        http.MethodGet,
+			service:       c.clientService,
+			path:          "/api/v1/admin/clients",
+			query:         pageQuery(filter.query(), limit, offset),
+			authenticated: true,
+		}, &data)
+		return data.Users, p, err
+	})
+}
+
+// GetClient returns one user's detail (admin only)
+func (c *Client) GetClient(ctx context.Context, userID string) (*ClientDetail, error) {
+	var data struct {
+		User ClientDetail `json:"user"`
+	}
+	if err := c.admin(ctx, http.MethodGet, userID, "", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	data.User.EmailVerified = data.User.EmailVerification.Verified
+	return &data.User, nil
+}
+
+// BlacklistClient suspends a user until expiresAt, or until the blacklist
+// is lifted if expiresAt is zero (admin only)
+func (c *Client) BlacklistClient(ctx context.Context, userID, reason string, expiresAt time.Time) error {
+	body := map[string]interface{}{"reason": reason}
+	if !expiresAt.IsZero() {
+		body["expires_at"] = expiresAt
+	}
+	return c.admin(ctx, http.MethodPost, userID, "/blacklist", nil, body, nil)
+}
+
+// UnblacklistClient lifts a user's blacklist, keeping reason in its
+// history (admin only)
+func (c *Client) UnblacklistClient(ctx context.Context, userID, reason string) error {
+	query := url.Values{}
+	if reason != "" {
+		query.Set("reason", reason)
+	}
+	return c.admin(ctx, http.MethodDelete, userID, "/blacklist", query, nil, nil)
+}
+
+// DeleteClient soft-deletes a user. Deleting another admin needs force
+// (admin only).
+func (c *Client) DeleteClient(ctx context.Context, userID string, force bool) error {
+	query := url.Values{}
+	if force {
+		query.Set("force", "true")
+	}
+	return c.admin(ctx, http.MethodDelete, userID, "", query, nil, nil)
+}
+
+// RestoreClient restores a soft-deleted user within the retention window
+// (admin only)
+func (c *Client) RestoreClient(ctx context.Context, userID string) (*User, error) {
+	var data struct {
+		User User `json:"user"`
+	}
+	if err := c.admin(ctx, http.MethodPost, userID, "/restore", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data.User, nil
+}
+
+// ForceLogoutResult reports what a force logout ended
+type ForceLogoutResult struct {
+	SessionsTerminated  int `json:"sessions_terminated"`
+	AccessTokensRevoked int `json:"access_tokens_revoked"`
+}
+
+// ForceLogout ends every session of a user. With mustResetPassword the
+// user cannot log in again until they reset their password (admin only).
+func (c *Client) ForceLogout(ctx context.Context, userID string, mustResetPassword bool) (*ForceLogoutResult, error) {
+	var result ForceLogoutResult
+	body := map[string]bool{"must_reset_password": mustResetPassword}
+	if err := c.admin(ctx, http.MethodPost, userID, "/force-logout", nil, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Impersonation is a short-lived access token for acting as a user
+type Impersonation struct {
+	UserID         string `json:"user_id"`
+	ImpersonatorID string `json:"impersonator_id"`
+	Tokens         struct {
+		AccessToken string    `json:"access_token"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	} `json:"tokens"`
+}
+
+// Impersonate issues an access token for acting as a user, valid for 5
+// minutes. Staff users cannot be impersonated (support or admin only).
+func (c *Client) Impersonate(ctx context.Context, userID string) (*Impersonation, error) {
+	var impersonation Impersonation
+	if err := c.admin(ctx, http.MethodPost, userID, "/impersonate", nil, nil, &impersonation); err != nil {
+		return nil, err
+	}
+	return &impersonation, nil
+}
+
+// GrantRole grants a user a staff role: admin, support, auditor or teller
+// (admin only)
+func (c *Client) GrantRole(ctx context.Context, userID, role string) error {
+	body := map[string]string{"role": role}
+	return c.admin(ctx, http.MethodPost, userID, "/roles", nil, body, nil)
+}
+
+// RevokeRole revokes a staff role from a user (admin only)
+func (c *Client) RevokeRole(ctx context.Context, userID, role string) error {
+	return c.admin(ctx, http.MethodDelete, userID, "/roles/"+url.PathEscape(role), nil, nil, nil)
+}
+
+// admin calls an endpoint under /api/v1/admin/clients/{userID}
+func (c *Client) admin(ctx context.Context, method, userID, path string, query url.Values, body, out interface{}) error {
+	_, err := c.do(ctx, call{
+		method:        method,
+		service:       c.clientService,
+		path:          "/api/v1/admin/clients/" + url.PathEscape(userID) + path,
+		query:         query,
+		body:          body,
+		authenticated: true,
+	}, out)
+	return err
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// User is a user as returned by the client service
+type User struct {
+	ID            string     `json:"id"`
+	Email         string     `json:"email"`
+	Name          string     `json:"name"`
+	IsAdmin       bool       `json:"is_admin"`
+	Roles         []string   `json:"roles"`
+	IsBlacklisted bool       `json:"is_blacklisted"`
+	EmailVerified bool       `json:"email_verified"`
+	KYCStatus     string     `json:"kyc_status"`
+	LastLoginAt   *time.Time `json:"last_login_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Login logs in with an email and password and keeps the session's tokens
+// for later calls. With rememberMe the refresh token lives longer.
+func (c *Client) Login(ctx context.Context, email, password string, rememberMe bool) (*User, error) {
+	var data struct {
+		User   User   `json:"user"`
+		Tokens Tokens `json:"tokens"`
+	}
+	if _, err := c.do(ctx, call{
+		method:  http.MethodPost,
+		service: c.clientService,
+		path:    "/api/v1/auth/login",
+		body: map[string]interface{}{
+			"email":       email,
+			"password":    password,
+			"remember_me": rememberMe,
+		},
+	}, &data); err != nil {
+		return nil, err
+	}
+
+	c.SetTokens(data.Tokens)
+	return &data.User, nil
+}
+
+// Refresh replaces the access token using the refresh token. Calls refresh
+// it on their own when it is refused, so this is only needed to renew it
+// ahead of time.
+func (c *Client) Refresh(ctx context.Context) error {
+	_, err := c.refresh(ctx, c.accessToken())
+	return err
+}
+
+// Logout ends the session on the server and forgets its tokens
+func (c *Client) Logout(ctx context.Context) error {
+	tokens := c.Tokens()
+	if tokens.RefreshToken == "" {
+		return ErrNotLoggedIn
+	}
+	if _, err := c.do(ctx, call{
+		method:  http.MethodPost,
+		service: c.clientService,
+		path:    "/api/v1/auth/logout",
+		body:    map[string]string{"refresh_token": tokens.RefreshToken},
+	}, nil); err != nil {
+		return err
+	}
+
+	c.SetTokens(Tokens{})
+	return nil
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Balance is the balance of the logged in user's account
+type Balance struct {
+	Balance  float64 `json:"balance"`
+	Currency string  `json:"currency"`
+}
+
+// Transaction is a deposit or withdrawal
+type Transaction struct {
+	ID            string    `json:"id"`
+	AccountID     string    `json:"account_id,omitempty"`
+	UserID        string    `json:"user_id,omitempty"`
+	Type          string    `json:"type"`
+	Amount        float64   `json:"amount"`
+	BalanceBefore float64   `json:"balance_before"`
+	BalanceAfter  float64   `json:"balance_after"`
+	Description   string    `json:"description"`
+	CreatedAt     time.Time `json:"created_at"`
+	// RelatedTransactionID is set on fees and round-ups, to the withdrawal
+	// they were made on, on refunds, to the disputed transaction, and on
+	// each side of a transfer, to the other
+	RelatedTransactionID string `json:"related_transaction_id,omitempty"`
+}
+
+// Transaction types
+const (
+	TransactionTypeDeposit    = "deposit"
+	TransactionTypeWithdrawal = "withdrawal"
+	TransactionTypeFee        = "fee"
+	TransactionTypeRoundUp    = "round_up"
+	TransactionTypeRefund     = "refund"
+	// Transfers between customers are recorded as a transfer_out on the
+	// sender's account and a transfer_in on the recipient's
+	TransactionTypeTransferOut = "transfer_out"
+	TransactionTypeTransferIn  = "transfer_in"
+	// Transfers to other banks are recorded as an external_transfer once
+	// they settle
+	TransactionTypeExternalTransfer = "external_transfer"
+	// A cheque that bounces is reversed by a cheque_return
+	TransactionTypeChequeReturn = "cheque_return"
+	// Promotion bonuses are credited as a bonus on the deposit that earned
+	// them
+	TransactionTypeBonus = "bonus"
+)
+
+// GetBalance returns the balance of the logged in user's account
+func (c *Client) GetBalance(ctx context.Context) (*Balance, error) {
+	var balance Balance
+	if _, err := c.do(ctx, call{
+		method:        http.MethodGet,
+		service:       c.bankingService,
+		path:          "/api/v1/account/balance",
+		authenticated: true,
+	}, &balance); err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+// Deposit pays amount into the logged in user's account
+func (c *Client) Deposit(ctx context.Context, amount float64, description string) (*Transaction, error) {
+	return c.transact(ctx, "/api/v1/transactions/deposit", amount, description)
+}
+
+// Withdraw takes amount out of the logged in user's account. It fails with
+// ErrInsufficientFunds if the balance is too low.
+func (c *Client) Withdraw(ctx context.Context, amount float64, description string) (*Transaction, error) {
+	return c.transact(ctx, "/api/v1/transactions/withdraw", amount, description)
+}
+
+func (c *Client) transact(ctx context.Context, path string, amount float64, description string) (*Transaction, error) {
+	var data struct {
+		Transaction Transaction `json:"transaction"`
+	}
+	if _, err := c.do(ctx, call{
+		method:  http.MethodPost,
+		service: c.bankingService,
+		path:    path,
+		body: map[string]interface{}{
+			"amount":      amount,
+			"description": description,
+		},
+		authenticated: true,
+	}, &data); err != nil {
+		return nil, err
+	}
+	return &data.Transaction, nil
+}
+
+// GetTransaction returns one of the logged in user's transactions
+func (c *Client) GetTransaction(ctx context.Context, id string) (*Transaction, error) {
+	var data struct {
+		Transaction Transaction `json:"transaction"`
+	}
+	if _, err := c.do(ctx, call{
+		method:        http.MethodGet,
+		service:       c.bankingService,
+		path:          "/api/v1/transactions/" + url.PathEscape(id),
+		authenticated: true,
+	}, &data); err != nil {
+		return nil, err
+	}
+	return &data.Transaction, nil
+}
+
+// ListTransactions iterates over the logged in user's transactions, newest
+// first
+func (c *Client) ListTransactions(options ListOptions) *Iterator[Transaction] {
+	return newIterator(options, func(ctx context.Context, limit, offset int) ([]Transaction, *pagination, error) {
+		var data struct {
+			Transactions []Transaction `json:"transactions"`
+		}
+		p, err := c.do(ctx, call{
+			method:        http.MethodGet,
+			service:       c.bankingService,
+			path:          "/api/v1/account/transactions",
+			query:         pageQuery(url.Values{}, limit, offset),
+			authenticated: true,
+		}, &data)
+		return data.Transactions, p, err
+	})
+}
+
+// pageQuery adds limit and offset to query
+func pageQuery(query url.Values, limit, offset int) url.Values {
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	return query
+}
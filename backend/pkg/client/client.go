@@ -0,0 +1,260 @@
+// Package client is a Go client for the microbank REST API. It covers
+// logging in, the account and transaction endpoints of the banking service
+// and the admin endpoints of the client service. A Client keeps the tokens
+// of the session it logged in, and refreshes an expired access token with
+// the refresh token before retrying the request once.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config describes where the services are. Point both URLs at the gateway
+// to go through it.
+type Config struct {
+	// ClientServiceURL is the base URL of the client service, such as
+	// http://localhost:8081
+	ClientServiceURL string
+	// BankingServiceURL is the base URL of the banking service, such as
+	// http://localhost:8080
+	BankingServiceURL string
+	// HTTPClient sends the requests; defaults to a client with a 30 second
+	// timeout
+	HTTPClient *http.Client
+}
+
+// Tokens are the credentials of a logged in session
+type Tokens struct {
+	AccessToken           string    `json:"access_token"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+// Client calls the microbank API. It is safe for concurrent use.
+type Client struct {
+	clientService  *url.URL
+	bankingService *url.URL
+	httpClient     *http.Client
+
+	mu     sync.Mutex
+	tokens Tokens
+	// refreshing serializes refreshes, so concurrent requests that find
+	// the access token expired refresh it once
+	refreshing sync.Mutex
+}
+
+// New creates a client for the services in config
+func New(config Config) (*Client, error) {
+	clientService, err := parseBaseURL("client service", config.ClientServiceURL)
+	if err != nil {
+		return nil, err
+	}
+	bankingService, err := parseBaseURL("banking service", config.BankingServiceURL)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{clientService: clientService, bankingService: bankingService, httpClient: httpClient}, nil
+}
+
+// parseBaseURL checks that raw is an absolute http or https URL
+func parseBaseURL(name, raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimRight(raw, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s URL %q: must be an absolute http or https URL", name, raw)
+	}
+	return u, nil
+}
+
+// Tokens returns the tokens of the current session, for example to store
+// the refresh token for a later run
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens
+}
+
+// SetTokens resumes a session with stored tokens. The access token may be
+// left empty; it is then fetched with the refresh token on the first call.
+func (c *Client) SetTokens(tokens Tokens) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens = tokens
+}
+
+// envelope is the shape of every API response
+type envelope struct {
+	Data       json.RawMessage `json:"data"`
+	Error      *Error          `json:"error"`
+	RequestID  string          `json:"request_id"`
+	Pagination *pagination     `json:"pagination"`
+}
+
+// pagination describes the page of a list response. Total and HasMore are
+// only sent for counted lists.
+type pagination struct {
+	Limit   int   `json:"limit"`
+	Offset  int   `json:"offset"`
+	Count   int   `json:"count"`
+	Total   *int  `json:"total"`
+	HasMore *bool `json:"has_more"`
+}
+
+// call describes one API request
+type call struct {
+	method  string
+	service *url.URL
+	path    string
+	query   url.Values
+	body    interface{}
+	// authenticated calls send the access token, and are retried once
+	// after a refresh if it is refused
+	authenticated bool
+}
+
+// do sends the call and decodes the data of the response into out, which
+// may be nil. It returns the pagination of list responses.
+func (c *Client) do(ctx context.Context, req call, out interface{}) (*pagination, error) {
+	accessToken := ""
+	if req.authenticated {
+		accessToken = c.accessToken()
+		if accessToken == "" {
+			var err error
+			if accessToken, err = c.refresh(ctx, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	env, err := c.send(ctx, req, accessToken)
+	var apiErr *Error
+	if req.authenticated && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized && c.canRefresh() {
+		if accessToken, err = c.refresh(ctx, accessToken); err != nil {
+			return nil, err
+		}
+		env, err = c.send(ctx, req, accessToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if out != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return nil, fmt.Errorf("failed to decode %s %s response: %w", req.method, req.path, err)
+		}
+	}
+	return env.Pagination, nil
+}
+
+// send makes one attempt at the call
+func (c *Client) send(ctx context.Context, req call, accessToken string) (*envelope, error) {
+	u := *req.service
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+
+	var body io.Reader
+	if req.body != nil {
+		encoded, err := json.Marshal(req.body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s %s request: %w", req.method, req.path, err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s %s request: %w", req.method, req.path, err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if accessToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w", req.method, req.path, err)
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return nil, &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		}
+		return nil, fmt.Errorf("failed to decode %s %s response: %w", req.method, req.path, err)
+	}
+	if env.Error != nil || resp.StatusCode >= http.StatusBadRequest {
+		apiErr := env.Error
+		if apiErr == nil {
+			apiErr = &Error{Message: http.StatusText(resp.StatusCode)}
+		}
+		apiErr.StatusCode = resp.StatusCode
+		apiErr.RequestID = env.RequestID
+		return nil, apiErr
+	}
+	return &env, nil
+}
+
+func (c *Client) accessToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens.AccessToken
+}
+
+func (c *Client) canRefresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens.RefreshToken != ""
+}
+
+// refresh gets a new access token with the refresh token, in place of the
+// refused one. If another call has already replaced it, that token is
+// returned without refreshing again.
+func (c *Client) refresh(ctx context.Context, refused string) (string, error) {
+	c.refreshing.Lock()
+	defer c.refreshing.Unlock()
+
+	tokens := c.Tokens()
+	if tokens.AccessToken != "" && tokens.AccessToken != refused {
+		return tokens.AccessToken, nil
+	}
+	if tokens.RefreshToken == "" {
+		return "", ErrNotLoggedIn
+	}
+
+	var data struct {
+		Tokens struct {
+			AccessToken string `json:"access_token"`
+		} `json:"tokens"`
+	}
+	if _, err := c.do(ctx, call{
+		method:  http.MethodPost,
+		service: c.clientService,
+		path:    "/api/v1/auth/refresh",
+		body:    map[string]string{"refresh_token": tokens.RefreshToken},
+	}, &data); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens.AccessToken = data.Tokens.AccessToken
+	return c.tokens.AccessToken, nil
+}
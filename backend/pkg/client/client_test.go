@@ -0,0 +1,410 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"microbank/pkg/httpx"
+)
+
+// fakeAPI mimics the handlers of both services, answering with the same
+// envelope, codes and pagination
+type fakeAPI struct {
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+	issued       int
+	refreshes    int
+	refreshValid bool
+	balance      float64
+	transactions []Transaction
+	users        []User
+	requests     []string
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *Client) {
+	t.Helper()
+	api := &fakeAPI{refreshToken: "refresh-1", refreshValid: true, balance: 100}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	c, err := New(Config{ClientServiceURL: server.URL, BankingServiceURL: server.URL + "/"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return api, c
+}
+
+func (a *fakeAPI) respond(w http.ResponseWriter, status int, env httpx.Envelope) {
+	env.RequestID = "req-" + strconv.Itoa(len(a.requests))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(env)
+}
+
+func (a *fakeAPI) fail(w http.ResponseWriter, status int, code, message string) {
+	a.respond(w, status, httpx.Envelope{Error: &httpx.ErrorBody{Code: code, Message: message}})
+}
+
+// locked runs f while no request is being served, to read or change the
+// fake's state
+func (a *fakeAPI) locked(f func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f()
+}
+
+func (a *fakeAPI) issueAccessToken() string {
+	a.issued++
+	a.accessToken = fmt.Sprintf("access-%d", a.issued)
+	return a.accessToken
+}
+
+func (a *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests = append(a.requests, r.Method+" "+r.URL.Path)
+
+	var body map[string]interface{}
+	if r.Body != nil && r.ContentLength != 0 {
+		if r.Header.Get("Content-Type") != "application/json" {
+			a.fail(w, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", "Content-Type must be application/json")
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	switch r.URL.Path {
+	case "/api/v1/auth/login":
+		if body["email"] != "andile.mbele@example.com" || body["password"] != "securepassword123" {
+			a.fail(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password")
+			return
+		}
+		a.respond(w, http.StatusOK, httpx.Envelope{Data: map[string]interface{}{
+			"message": "Login successful",
+			"user":    User{ID: "user-1", Email: "andile.mbele@example.com", Name: "Andile Mbele"},
+			"tokens": map[string]interface{}{
+				"access_token":             a.issueAccessToken(),
+				"refresh_token":            a.refreshToken,
+				"refresh_token_expires_at": time.Now().Add(time.Hour),
+				"token_type":               "Bearer",
+			},
+		}})
+		return
+	case "/api/v1/auth/refresh":
+		a.refreshes++
+		if body["refresh_token"] != a.refreshToken || !a.refreshValid {
+			a.fail(w, http.StatusUnauthorized, "REFRESH_TOKEN_EXPIRED", "Refresh token has expired")
+			return
+		}
+		a.respond(w, http.StatusOK, httpx.Envelope{Data: map[string]interface{}{
+			"message": "Token refreshed successfully",
+			"tokens":  map[string]string{"access_token": a.issueAccessToken(), "token_type": "Bearer"},
+		}})
+		return
+	case "/api/v1/auth/logout":
+		a.refreshToken = ""
+		a.respond(w, http.StatusOK, httpx.Envelope{Data: map[string]string{"message": "Logged out successfully"}})
+		return
+	}
+
+	// Everything else is protected, like the services' AuthMiddleware
+	if r.Header.Get("Authorization") != "Bearer "+a.accessToken || a.accessToken == "expired" {
+		a.fail(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired token")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	switch {
+	case r.URL.Path == "/api/v1/account/balance":
+		a.respond(w, http.StatusOK, httpx.Envelope{Data: map[string]interface{}{
+			"message":  "Balance retrieved successfully",
+			"balance":  a.balance,
+			"currency": "USD",
+		}})
+	case r.URL.Path == "/api/v1/transactions/deposit" || r.URL.Path == "/api/v1/transactions/withdraw":
+		amount, _ := body["amount"].(float64)
+		tx := Transaction{ID: fmt.Sprintf("tx-%d", len(a.transactions)+1), Type: TransactionTypeDeposit, Amount: amount, BalanceBefore: a.balance}
+		if strings.HasSuffix(r.URL.Path, "withdraw") {
+			if amount > a.balance {
+				a.fail(w, http.StatusBadRequest, "INSUFFICIENT_FUNDS", "Insufficient funds for withdrawal")
+				return
+			}
+			tx.Type, amount = TransactionTypeWithdrawal, -amount
+		}
+		a.balance += amount
+		tx.BalanceAfter = a.balance
+		a.transactions = append(a.transactions, tx)
+		a.respond(w, http.StatusCreated, httpx.Envelope{Data: map[string]interface{}{"transaction": tx}})
+	case r.URL.Path == "/api/v1/account/transactions":
+		page := a.transactions[min(offset, len(a.transactions)):min(offset+limit, len(a.transactions))]
+		a.respond(w, http.StatusOK, httpx.Envelope{
+			Data:       map[string]interface{}{"transactions": page},
+			Pagination: &httpx.Pagination{Limit: limit, Offset: offset, Count: len(page)},
+		})
+	case r.URL.Path == "/api/v1/admin/clients":
+		if r.URL.Query().Get("search") != "example.com" {
+			a.fail(w, http.StatusBadRequest, "INVALID_QUERY_PARAMETER", "Expected the search filter")
+			return
+		}
+		page := a.users[min(offset, len(a.users)):min(offset+limit, len(a.users))]
+		a.respond(w, http.StatusOK, httpx.Envelope{
+			Data:       map[string]interface{}{"users": page},
+			Pagination: httpx.NewPagination(limit, offset, len(page), len(a.users)),
+		})
+	case r.URL.Path == "/api/v1/admin/clients/user-2/force-logout":
+		if body["must_reset_password"] != true {
+			a.fail(w, http.StatusBadRequest, "VALIDATION_ERROR", "Expected must_reset_password")
+			return
+		}
+		a.respond(w, http.StatusOK, httpx.Envelope{Data: map[string]interface{}{"user_id": "user-2", "sessions_terminated": 2, "access_tokens_revoked": 3}})
+	case r.URL.Path == "/api/v1/admin/clients/user-2/impersonate":
+		a.respond(w, http.StatusCreated, httpx.Envelope{Data: map[string]interface{}{
+			"user_id":         "user-2",
+			"impersonator_id": "admin-1",
+			"tokens":          map[string]interface{}{"access_token": "impersonation-token", "token_type": "Bearer", "expires_at": "2026-10-18T12:05:00Z"},
+		}})
+	case strings.HasPrefix(r.URL.Path, "/api/v1/admin/clients/"):
+		a.fail(w, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+	default:
+		a.fail(w, http.StatusNotFound, "NOT_FOUND", "Not found")
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{name: "valid", config: Config{ClientServiceURL: "http://localhost:8081", BankingServiceURL: "https://bank.example.com/"}},
+		{name: "missing client service", config: Config{BankingServiceURL: "http://localhost:8080"}, wantErr: "invalid client service URL"},
+		{name: "relative banking service", config: Config{ClientServiceURL: "http://localhost:8081", BankingServiceURL: "localhost:8080"}, wantErr: "invalid banking service URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestClient_LoginAndTransact(t *testing.T) {
+	api, c := newFakeAPI(t)
+	ctx := context.Background()
+
+	if _, err := c.GetBalance(ctx); !errors.Is(err, ErrNotLoggedIn) {
+		t.Fatalf("Expected ErrNotLoggedIn before logging in, got %v", err)
+	}
+	if _, err := c.Login(ctx, "andile.mbele@example.com", "wrong", false); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Expected ErrInvalidCredentials, got %v", err)
+	}
+
+	user, err := c.Login(ctx, "andile.mbele@example.com", "securepassword123", true)
+	if err != nil || user.ID != "user-1" {
+		t.Fatalf("Expected to log in as user-1, got %+v %v", user, err)
+	}
+	if tokens := c.Tokens(); tokens.AccessToken != "access-1" || tokens.RefreshToken != "refresh-1" || tokens.RefreshTokenExpiresAt.IsZero() {
+		t.Errorf("Expected the session's tokens to be kept, got %+v", tokens)
+	}
+
+	tx, err := c.Deposit(ctx, 50, "Salary deposit")
+	if err != nil || tx.Type != TransactionTypeDeposit || tx.BalanceAfter != 150 {
+		t.Fatalf("Expected a deposit to 150, got %+v %v", tx, err)
+	}
+	balance, err := c.GetBalance(ctx)
+	if err != nil || balance.Balance != 150 || balance.Currency != "USD" {
+		t.Errorf("Expected a balance of 150 USD, got %+v %v", balance, err)
+	}
+
+	_, err = c.Withdraw(ctx, 500, "ATM withdrawal")
+	var apiErr *Error
+	if !errors.Is(err, ErrInsufficientFunds) || !errors.As(err, &apiErr) {
+		t.Fatalf("Expected ErrInsufficientFunds, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.RequestID == "" || apiErr.Message == "" {
+		t.Errorf("Expected the error envelope to be decoded, got %+v", apiErr)
+	}
+	if errors.Is(err, ErrAccountFrozen) {
+		t.Errorf("Expected only the matching code to match, got %v", err)
+	}
+
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	api.locked(func() {
+		if c.Tokens() != (Tokens{}) || api.refreshToken != "" {
+			t.Errorf("Expected logging out to end the session, got %+v", c.Tokens())
+		}
+	})
+}
+
+func TestClient_RefreshesExpiredAccessToken(t *testing.T) {
+	api, c := newFakeAPI(t)
+	ctx := context.Background()
+	if _, err := c.Login(ctx, "andile.mbele@example.com", "securepassword123", false); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	// Concurrent calls that find the token expired refresh it once
+	api.locked(func() { api.accessToken = "expired" })
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetBalance(ctx)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected the call to succeed after a refresh, got %v", err)
+		}
+	}
+	api.locked(func() {
+		if api.refreshes != 1 || c.Tokens().AccessToken != "access-2" {
+			t.Errorf("Expected one refresh to access-2, got %d refreshes and %q", api.refreshes, c.Tokens().AccessToken)
+		}
+		// A refresh token that no longer works is reported as such
+		api.accessToken = "expired"
+		api.refreshValid = false
+	})
+	if _, err := c.GetBalance(ctx); !errors.Is(err, ErrRefreshTokenExpired) {
+		t.Errorf("Expected ErrRefreshTokenExpired, got %v", err)
+	}
+}
+
+func TestClient_SetTokens(t *testing.T) {
+	api, c := newFakeAPI(t)
+
+	// A stored refresh token is enough to resume a session
+	c.SetTokens(Tokens{RefreshToken: "refresh-1"})
+	if _, err := c.GetBalance(context.Background()); err != nil {
+		t.Fatalf("Expected the session to resume, got %v", err)
+	}
+	api.locked(func() {
+		if api.refreshes != 1 {
+			t.Errorf("Expected the access token to be fetched once, got %d refreshes", api.refreshes)
+		}
+	})
+}
+
+func TestClient_ListTransactions(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions int
+		wantRequests int
+	}{
+		{name: "short last page", transactions: 7, wantRequests: 3},
+		{name: "full last page", transactions: 6, wantRequests: 3},
+		{name: "empty", transactions: 0, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, c := newFakeAPI(t)
+			ctx := context.Background()
+			if _, err := c.Login(ctx, "andile.mbele@example.com", "securepassword123", false); err != nil {
+				t.Fatalf("Login failed: %v", err)
+			}
+			api.locked(func() {
+				for i := 0; i < tt.transactions; i++ {
+					api.transactions = append(api.transactions, Transaction{ID: fmt.Sprintf("tx-%d", i)})
+				}
+				api.requests = nil
+			})
+
+			var ids []string
+			it := c.ListTransactions(ListOptions{PageSize: 3})
+			for it.Next(ctx) {
+				ids = append(ids, it.Value().ID)
+			}
+			if err := it.Err(); err != nil {
+				t.Fatalf("Iteration failed: %v", err)
+			}
+			if len(ids) != tt.transactions || (len(ids) > 0 && ids[len(ids)-1] != fmt.Sprintf("tx-%d", tt.transactions-1)) {
+				t.Errorf("Expected all %d transactions in order, got %v", tt.transactions, ids)
+			}
+			api.locked(func() {
+				if len(api.requests) != tt.wantRequests {
+					t.Errorf("Expected %d page requests, got %v", tt.wantRequests, api.requests)
+				}
+			})
+		})
+	}
+}
+
+func TestClient_AdminOperations(t *testing.T) {
+	api, c := newFakeAPI(t)
+	ctx := context.Background()
+	if _, err := c.Login(ctx, "andile.mbele@example.com", "securepassword123", false); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	api.locked(func() {
+		for i := 0; i < 5; i++ {
+			api.users = append(api.users, User{ID: fmt.Sprintf("user-%d", i+1)})
+		}
+		api.requests = nil
+	})
+
+	// Counted lists stop when has_more is false, without an empty page
+	count := 0
+	it := c.ListClients(ClientFilter{ListOptions: ListOptions{PageSize: 5}, Search: "example.com"})
+	for it.Next(ctx) {
+		count++
+	}
+	api.locked(func() {
+		if it.Err() != nil || count != 5 || len(api.requests) != 1 {
+			t.Errorf("Expected 5 users from one request, got %d from %v: %v", count, api.requests, it.Err())
+		}
+	})
+
+	result, err := c.ForceLogout(ctx, "user-2", true)
+	if err != nil || result.SessionsTerminated != 2 || result.AccessTokensRevoked != 3 {
+		t.Errorf("Expected 2 sessions and 3 access tokens ended, got %+v %v", result, err)
+	}
+	impersonation, err := c.Impersonate(ctx, "user-2")
+	if err != nil || impersonation.Tokens.AccessToken != "impersonation-token" || impersonation.ImpersonatorID != "admin-1" {
+		t.Errorf("Expected an impersonation token, got %+v %v", impersonation, err)
+	}
+	if _, err := c.GetClient(ctx, "user-9"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestIterator_StopsOnError(t *testing.T) {
+	_, c := newFakeAPI(t)
+	if _, err := c.Login(context.Background(), "andile.mbele@example.com", "securepassword123", false); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	it := c.ListTransactions(ListOptions{})
+	if it.Next(ctx) || !errors.Is(it.Err(), context.Canceled) {
+		t.Errorf("Expected a cancelled context to stop the iteration, got %v", it.Err())
+	}
+	if it.Next(context.Background()) {
+		t.Error("Expected the iteration to stay stopped after an error")
+	}
+}
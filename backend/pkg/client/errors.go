@@ -0,0 +1,59 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNotLoggedIn is returned by calls that need a session before Login or
+// SetTokens has given the client one
+var ErrNotLoggedIn = errors.New("not logged in")
+
+// Error is an error response from the API, decoded from the error envelope
+type Error struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int `json:"-"`
+	// Code is the machine-readable error code, such as INSUFFICIENT_FUNDS
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Details json.RawMessage `json:"details,omitempty"`
+	// RequestID identifies the request in the service logs
+	RequestID string `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%d %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Is matches an Error against the sentinels below by code, so callers can
+// write errors.Is(err, client.ErrInsufficientFunds)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code != "" && t.Code == e.Code
+}
+
+// Sentinels for the error codes callers most often handle. Match them with
+// errors.Is; use errors.As with *Error for the status, message and details.
+var (
+	ErrValidation            = &Error{Code: "VALIDATION_ERROR"}
+	ErrRateLimited           = &Error{Code: "RATE_LIMITED"}
+	ErrInvalidCredentials    = &Error{Code: "INVALID_CREDENTIALS"}
+	ErrInvalidToken          = &Error{Code: "INVALID_TOKEN"}
+	ErrTokenRevoked          = &Error{Code: "TOKEN_REVOKED"}
+	ErrInvalidRefreshToken   = &Error{Code: "INVALID_REFRESH_TOKEN"}
+	ErrRefreshTokenExpired   = &Error{Code: "REFRESH_TOKEN_EXPIRED"}
+	ErrAccountSuspended      = &Error{Code: "ACCOUNT_SUSPENDED"}
+	ErrPasswordResetRequired = &Error{Code: "PASSWORD_RESET_REQUIRED"}
+	ErrUserBlacklisted       = &Error{Code: "USER_BLACKLISTED"}
+	ErrForbidden             = &Error{Code: "INSUFFICIENT_PERMISSIONS"}
+	ErrUserNotFound          = &Error{Code: "USER_NOT_FOUND"}
+	ErrAccountNotFound       = &Error{Code: "ACCOUNT_NOT_FOUND"}
+	ErrAccountFrozen         = &Error{Code: "ACCOUNT_FROZEN"}
+	ErrInsufficientFunds     = &Error{Code: "INSUFFICIENT_FUNDS"}
+	ErrKYCRequired           = &Error{Code: "KYC_REQUIRED"}
+	ErrTransactionNotFound   = &Error{Code: "TRANSACTION_NOT_FOUND"}
+)
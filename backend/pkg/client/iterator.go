@@ -0,0 +1,89 @@
+package client
+
+import "context"
+
+// DefaultPageSize is the page size list iterators request when none is set
+const DefaultPageSize = 50
+
+// Iterator walks a list endpoint one item at a time, fetching each page
+// as the previous one runs out:
+//
+//	it := c.ListTransactions(ListOptions{})
+//	for it.Next(ctx) {
+//		tx := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	fetch  func(ctx context.Context, limit, offset int) ([]T, *pagination, error)
+	limit  int
+	offset int
+	page   []T
+	index  int
+	last   bool
+	err    error
+}
+
+// newIterator creates an iterator fetching pages of limit items from offset
+func newIterator[T any](options ListOptions, fetch func(ctx context.Context, limit, offset int) ([]T, *pagination, error)) *Iterator[T] {
+	limit := options.PageSize
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	return &Iterator[T]{fetch: fetch, limit: limit, offset: options.Offset, index: -1}
+}
+
+// Next moves to the next item, fetching the next page if needed. It
+// returns false at the end of the list or on an error, which Err reports.
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if it.index+1 < len(it.page) {
+		it.index++
+		return true
+	}
+	if it.last {
+		return false
+	}
+
+	page, p, err := it.fetch(ctx, it.limit, it.offset)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page, it.index = page, 0
+	it.offset += len(page)
+	// Counted lists say whether there is more; otherwise a short page is
+	// the last one
+	if p != nil && p.HasMore != nil {
+		it.last = !*p.HasMore
+	} else {
+		it.last = len(page) < it.limit
+	}
+	if len(page) == 0 {
+		it.last = true
+		return false
+	}
+	return true
+}
+
+// Value returns the current item
+func (it *Iterator[T]) Value() T {
+	return it.page[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// ListOptions pages through a list
+type ListOptions struct {
+	// PageSize is how many items each request fetches; defaults to
+	// DefaultPageSize
+	PageSize int
+	// Offset is the number of items to skip
+	Offset int
+}
@@ -0,0 +1,205 @@
+// Package config holds the checks both services run on their configuration
+// at startup. Each service collects every problem it finds in a Problems
+// list so a bad deployment is reported in one go rather than one variable
+// per restart.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinSecretLength is the shortest secret accepted for signing tokens or
+// authenticating services
+const MinSecretLength = 32
+
+// MinSecretEntropy is the fewest bits of entropy per character a secret may
+// have. Random base64 or hex strings have 4 or more; repeated or padded
+// values such as "aaaa…" fall well short.
+const MinSecretEntropy = 3.0
+
+// Error lists every problem found in a configuration
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Problems collects configuration problems
+type Problems struct {
+	list []string
+}
+
+// Add records err, if it is not nil. The problems of an *Error are added
+// one by one.
+func (p *Problems) Add(err error) {
+	if err == nil {
+		return
+	}
+	var configErr *Error
+	if errors.As(err, &configErr) {
+		p.list = append(p.list, configErr.Problems...)
+		return
+	}
+	p.list = append(p.list, err.Error())
+}
+
+// Addf records a problem described by format and args
+func (p *Problems) Addf(format string, args ...interface{}) {
+	p.list = append(p.list, fmt.Sprintf(format, args...))
+}
+
+// Err returns an *Error listing every problem recorded, or nil if there
+// were none
+func (p *Problems) Err() error {
+	if len(p.list) == 0 {
+		return nil
+	}
+	return &Error{Problems: append([]string(nil), p.list...)}
+}
+
+// RequiredEnv returns the value of the environment variable name, or an
+// error if it is not set
+func RequiredEnv(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("%s is required", name)
+	}
+	return value, nil
+}
+
+// PortFromEnv returns the TCP port in the environment variable name, or
+// fallback when it is not set
+func PortFromEnv(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid %s %q: must be a port between 1 and 65535", name, value)
+	}
+	return port, nil
+}
+
+// IntFromEnv returns the whole number of at least min in the environment
+// variable name, or fallback when it is not set
+func IntFromEnv(name string, fallback, min int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min {
+		return 0, fmt.Errorf("invalid %s %q: must be a whole number of at least %d", name, value, min)
+	}
+	return n, nil
+}
+
+// DurationFromEnv returns the positive duration in the environment
+// variable name, or fallback when it is not set
+func DurationFromEnv(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive duration such as 1h", name, value)
+	}
+	return d, nil
+}
+
+// BoolFromEnv returns the boolean in the environment variable name, or
+// fallback when it is not set
+func BoolFromEnv(name string, fallback bool) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: must be true or false", name, value)
+	}
+	return b, nil
+}
+
+// URLFromEnv returns the absolute http or https URL in the environment
+// variable name, or fallback when it is not set
+func URLFromEnv(name, fallback string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("invalid %s %q: must be an http or https URL", name, value)
+	}
+	return value, nil
+}
+
+// CheckSecret reports whether value, the secret held by name, is long and
+// varied enough to be used
+func CheckSecret(name, value string) error {
+	if len(value) < MinSecretLength {
+		return fmt.Errorf("%s must be at least %d characters, got %d", name, MinSecretLength, len(value))
+	}
+	if entropy := shannonEntropy(value); entropy < MinSecretEntropy {
+		return fmt.Errorf("%s is too predictable (%.1f bits per character, need %.1f); use a random value", name, entropy, MinSecretEntropy)
+	}
+	return nil
+}
+
+// shannonEntropy returns the bits of entropy per character of s, judged by
+// how often each byte occurs
+func shannonEntropy(s string) float64 {
+	counts := make(map[byte]int)
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+
+	var entropy float64
+	for _, count := range counts {
+		p := float64(count) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// AllOrNone returns an error when some, but not all, of the named
+// environment variables are set
+func AllOrNone(names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if os.Getenv(name) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 && len(missing) < len(names) {
+		return fmt.Errorf("%s must be set together (missing %s)", strings.Join(names, ", "), strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// OneOfEnv returns the value of the environment variable name, or fallback
+// when it is not set, and an error unless the value is one of allowed
+func OneOfEnv(name, fallback string, allowed ...string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	for _, candidate := range allowed {
+		if value == candidate {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("invalid %s %q: must be one of %s", name, value, strings.Join(allowed, ", "))
+}
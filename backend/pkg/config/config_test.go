@@ -0,0 +1,195 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProblems(t *testing.T) {
+	var problems Problems
+	if err := problems.Err(); err != nil {
+		t.Fatalf("Expected no error without problems, got %v", err)
+	}
+
+	problems.Add(nil)
+	problems.Add(errors.New("PORT is invalid"))
+	problems.Add(&Error{Problems: []string{"DB_PASSWORD is required", "DB_PORT is invalid"}})
+	problems.Addf("%s is required", "INTERNAL_SERVICE_TOKEN")
+
+	var configErr *Error
+	if !errors.As(problems.Err(), &configErr) {
+		t.Fatalf("Expected an *Error, got %v", problems.Err())
+	}
+	want := []string{"PORT is invalid", "DB_PASSWORD is required", "DB_PORT is invalid", "INTERNAL_SERVICE_TOKEN is required"}
+	if strings.Join(configErr.Problems, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected problems %q, got %q", want, configErr.Problems)
+	}
+	if !strings.Contains(configErr.Error(), "\n  - DB_PASSWORD is required\n") {
+		t.Errorf("Expected one problem per line, got %q", configErr.Error())
+	}
+}
+
+func TestPortFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "", want: 8080},
+		{value: "5432", want: 5432},
+		{value: "0", wantErr: true},
+		{value: "65536", wantErr: true},
+		{value: "http", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("PORT", tt.value)
+		got, err := PortFromEnv("PORT", 8080)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("PortFromEnv(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("PortFromEnv(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestIntAndDurationFromEnv(t *testing.T) {
+	intTests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "", want: 5},
+		{value: "0", want: 0},
+		{value: "12", want: 12},
+		{value: "-1", wantErr: true},
+		{value: "many", wantErr: true},
+	}
+	for _, tt := range intTests {
+		t.Setenv("MAX_SESSIONS", tt.value)
+		got, err := IntFromEnv("MAX_SESSIONS", 5, 0)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("IntFromEnv(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("IntFromEnv(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+
+	durationTests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: time.Hour},
+		{value: "90m", want: 90 * time.Minute},
+		{value: "0s", wantErr: true},
+		{value: "24", wantErr: true},
+	}
+	for _, tt := range durationTests {
+		t.Setenv("LINK_TTL", tt.value)
+		got, err := DurationFromEnv("LINK_TTL", time.Hour)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("DurationFromEnv(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if err != nil && !strings.Contains(err.Error(), "LINK_TTL") {
+			t.Errorf("Expected the error to name LINK_TTL, got %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("DurationFromEnv(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestBoolAndURLFromEnv(t *testing.T) {
+	t.Setenv("FLAG", "maybe")
+	if _, err := BoolFromEnv("FLAG", false); err == nil {
+		t.Error("Expected an error for an invalid boolean")
+	}
+	t.Setenv("FLAG", "")
+	if got, err := BoolFromEnv("FLAG", true); err != nil || !got {
+		t.Errorf("Expected the fallback, got %v %v", got, err)
+	}
+
+	t.Setenv("SERVICE_URL", "localhost:8080")
+	if _, err := URLFromEnv("SERVICE_URL", ""); err == nil {
+		t.Error("Expected an error for a URL without a scheme")
+	}
+	t.Setenv("SERVICE_URL", "https://banking.internal")
+	if got, err := URLFromEnv("SERVICE_URL", ""); err != nil || got != "https://banking.internal" {
+		t.Errorf("Expected the configured URL, got %q %v", got, err)
+	}
+}
+
+func TestCheckSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "random", value: "q3Jx9VbT0mWc7LkZpR2sYd5HnE8aFu4G"},
+		{name: "too short", value: "microBankSecret", wantErr: true},
+		{name: "repeated", value: strings.Repeat("ab", 20), wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSecret("JWT_SECRET", tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckSecret error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && strings.Contains(err.Error(), tt.value) && tt.value != "" {
+				t.Errorf("Expected the error not to contain the secret, got %q", err)
+			}
+		})
+	}
+}
+
+func TestAllOrNone(t *testing.T) {
+	t.Setenv("A", "1")
+	t.Setenv("B", "")
+	t.Setenv("C", "1")
+
+	if err := AllOrNone("A", "C"); err != nil {
+		t.Errorf("Expected A and C to be accepted together, got %v", err)
+	}
+	if err := AllOrNone("B"); err != nil {
+		t.Errorf("Expected none set to be accepted, got %v", err)
+	}
+	if err := AllOrNone("A", "B"); err == nil || !strings.Contains(err.Error(), "missing B") {
+		t.Errorf("Expected B to be reported missing, got %v", err)
+	}
+}
+
+func TestDatabaseFromEnv(t *testing.T) {
+	for _, name := range []string{"DB_HOST", "DB_PORT", "DB_USER", "DB_NAME", "DB_SSLMODE", "DB_LEGACY_TIME_ZONE"} {
+		t.Setenv(name, "")
+	}
+
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("DB_PORT", "none")
+	t.Setenv("DB_LEGACY_TIME_ZONE", "Mars/Olympus_Mons")
+	var configErr *Error
+	if _, err := DatabaseFromEnv("client_service"); !errors.As(err, &configErr) || len(configErr.Problems) != 3 {
+		t.Fatalf("Expected DB_PORT, DB_PASSWORD and DB_LEGACY_TIME_ZONE to be reported, got %v", err)
+	}
+
+	t.Setenv("DB_PORT", "")
+	t.Setenv("DB_LEGACY_TIME_ZONE", "")
+	t.Setenv("DB_PASSWORD", "it's a secret")
+	db, err := DatabaseFromEnv("client_service")
+	if err != nil {
+		t.Fatalf("DatabaseFromEnv failed: %v", err)
+	}
+	want := `host='localhost' port=5432 user='postgres' password='it\'s a secret' dbname='client_service' sslmode=disable timezone=UTC`
+	if got := db.DSN(); got != want {
+		t.Errorf("Expected DSN %s, got %s", want, got)
+	}
+	if db.LegacyTimeZone != "UTC" {
+		t.Errorf("Expected legacy time zone UTC, got %s", db.LegacyTimeZone)
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Database holds the PostgreSQL connection settings
+type Database struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+
+	// LegacyTimeZone is the zone the wall-clock times in columns still
+	// typed timestamp without time zone were written in. They are read in
+	// it when the columns are migrated to timestamptz.
+	LegacyTimeZone string
+}
+
+// DatabaseFromEnv loads the connection settings from DB_HOST (default
+// localhost), DB_PORT (default 5432), DB_USER (default postgres),
+// DB_PASSWORD, DB_NAME (default defaultName), DB_SSLMODE (default
+// disable) and DB_LEGACY_TIME_ZONE (default UTC). DB_PASSWORD has no
+// default and must be set.
+func DatabaseFromEnv(defaultName string) (Database, error) {
+	var problems Problems
+
+	db := Database{
+		Host: envOr("DB_HOST", "localhost"),
+		User: envOr("DB_USER", "postgres"),
+		Name: envOr("DB_NAME", defaultName),
+	}
+
+	var err error
+	db.Port, err = PortFromEnv("DB_PORT", 5432)
+	problems.Add(err)
+	db.Password, err = RequiredEnv("DB_PASSWORD")
+	problems.Add(err)
+	db.SSLMode, err = OneOfEnv("DB_SSLMODE", "disable", "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	problems.Add(err)
+	db.LegacyTimeZone = envOr("DB_LEGACY_TIME_ZONE", "UTC")
+	if _, err := time.LoadLocation(db.LegacyTimeZone); err != nil {
+		problems.Add(fmt.Errorf("invalid DB_LEGACY_TIME_ZONE %q: must be an IANA time zone such as UTC or Europe/London", db.LegacyTimeZone))
+	}
+
+	return db, problems.Err()
+}
+
+// DSN returns the connection string for the lib/pq driver. Sessions use
+// UTC, so timestamptz values are read back as UTC times.
+func (d Database) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s timezone=UTC",
+		quoteDSN(d.Host), d.Port, quoteDSN(d.User), quoteDSN(d.Password), quoteDSN(d.Name), d.SSLMode)
+}
+
+// quoteDSN quotes a connection string value, escaping backslashes and
+// single quotes, so values with spaces are not split by the driver
+func quoteDSN(value string) string {
+	return "'" + dsnEscaper.Replace(value) + "'"
+}
+
+var dsnEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// envOr returns the value of the environment variable name, or fallback
+// when it is not set
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
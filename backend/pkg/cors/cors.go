@@ -0,0 +1,218 @@
+// Package cors implements the Cross-Origin Resource Sharing policy shared by
+// every service. It only depends on net/http; each service wraps it in its
+// own middleware.
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults used for settings left empty in Config
+var (
+	DefaultAllowedMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	DefaultAllowedHeaders = []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "X-Requested-With", "X-Request-ID"}
+	DefaultExposedHeaders = []string{"Content-Length", "Content-Type", "Content-Disposition", "X-Request-ID", "X-Total-Count", "X-Total-Pages"}
+)
+
+// Config describes which cross-origin requests browsers may make
+type Config struct {
+	// AllowedOrigins lists origins such as https://app.example.com. An entry
+	// may use one wildcard for subdomains, as in https://*.example.com, or be
+	// "*" to allow every origin.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	MaxAge           time.Duration
+	AllowCredentials bool
+}
+
+// Policy answers CORS requests according to a validated Config
+type Policy struct {
+	exact            map[string]bool
+	wildcards        []wildcardOrigin
+	allowAll         bool
+	allowedMethods   string
+	allowedHeaders   string
+	exposedHeaders   string
+	maxAge           string
+	allowCredentials bool
+}
+
+// wildcardOrigin matches origins with any subdomain in place of the wildcard
+type wildcardOrigin struct {
+	prefix string // scheme and "://"
+	suffix string // everything after the wildcard, starting with "."
+}
+
+// New validates config and creates a policy. Allowing credentials from every
+// origin is refused, since it would let any site act as the user.
+func New(config Config) (*Policy, error) {
+	p := &Policy{
+		exact:            make(map[string]bool),
+		allowedMethods:   strings.Join(withDefault(config.AllowedMethods, DefaultAllowedMethods), ", "),
+		allowedHeaders:   strings.Join(withDefault(config.AllowedHeaders, DefaultAllowedHeaders), ", "),
+		exposedHeaders:   strings.Join(withDefault(config.ExposedHeaders, DefaultExposedHeaders), ", "),
+		allowCredentials: config.AllowCredentials,
+	}
+	if config.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(config.MaxAge.Seconds()))
+	}
+
+	for _, origin := range config.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "":
+		case origin == "*":
+			if config.AllowCredentials {
+				return nil, fmt.Errorf("the wildcard origin cannot be allowed with credentials")
+			}
+			p.allowAll = true
+		case strings.Contains(origin, "*"):
+			wildcard, err := parseWildcard(origin)
+			if err != nil {
+				return nil, err
+			}
+			p.wildcards = append(p.wildcards, wildcard)
+		default:
+			if !strings.Contains(origin, "://") || strings.HasSuffix(origin, "/") {
+				return nil, fmt.Errorf("invalid origin %q: must be scheme://host[:port]", origin)
+			}
+			p.exact[origin] = true
+		}
+	}
+
+	return p, nil
+}
+
+// parseWildcard parses an origin of the form scheme://*.domain[:port]
+func parseWildcard(origin string) (wildcardOrigin, error) {
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || scheme == "" || !strings.HasPrefix(host, "*.") || strings.Count(host, "*") != 1 || len(host) == len("*.") || strings.HasSuffix(host, "/") {
+		return wildcardOrigin{}, fmt.Errorf("invalid origin %q: wildcards must look like https://*.example.com", origin)
+	}
+	return wildcardOrigin{prefix: scheme + "://", suffix: host[1:]}, nil
+}
+
+// matches reports whether origin is a subdomain matching the wildcard
+func (w wildcardOrigin) matches(origin string) bool {
+	if !strings.HasPrefix(origin, w.prefix) || !strings.HasSuffix(origin, w.suffix) {
+		return false
+	}
+	subdomain := origin[len(w.prefix) : len(origin)-len(w.suffix)]
+	return subdomain != "" && !strings.ContainsAny(subdomain, ":/@")
+}
+
+// Allows reports whether requests from origin are allowed
+func (p *Policy) Allows(origin string) bool {
+	origin = strings.ToLower(origin)
+	if p.allowAll || p.exact[origin] {
+		return true
+	}
+	for _, wildcard := range p.wildcards {
+		if wildcard.matches(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply sets the CORS headers for r on h and reports whether r is a
+// preflight request, which the caller should answer with 204 No Content
+// without running any further handlers. Requests from disallowed origins
+// get no CORS headers, so the browser blocks them.
+func (p *Policy) Apply(h http.Header, r *http.Request) (preflight bool) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	preflight = r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+	h.Add("Vary", "Origin")
+	if !p.Allows(origin) {
+		return preflight
+	}
+
+	if p.allowAll {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.allowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		h.Set("Access-Control-Expose-Headers", p.exposedHeaders)
+		return false
+	}
+
+	h.Set("Access-Control-Allow-Methods", p.allowedMethods)
+	h.Set("Access-Control-Allow-Headers", p.allowedHeaders)
+	if p.maxAge != "" {
+		h.Set("Access-Control-Max-Age", p.maxAge)
+	}
+	return true
+}
+
+// ConfigFromEnv reads CORS_ALLOWED_ORIGINS (default the local dashboards,
+// http://localhost:3000 and http://localhost:3001), CORS_ALLOWED_METHODS,
+// CORS_ALLOWED_HEADERS and CORS_EXPOSED_HEADERS (comma-separated),
+// CORS_MAX_AGE (seconds, default 600) and CORS_ALLOW_CREDENTIALS (default
+// true)
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:3001"},
+		AllowedMethods:   splitList(os.Getenv("CORS_ALLOWED_METHODS")),
+		AllowedHeaders:   splitList(os.Getenv("CORS_ALLOWED_HEADERS")),
+		ExposedHeaders:   splitList(os.Getenv("CORS_EXPOSED_HEADERS")),
+		MaxAge:           10 * time.Minute,
+		AllowCredentials: true,
+	}
+
+	if value, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS"); ok {
+		config.AllowedOrigins = splitList(value)
+	}
+
+	if value := os.Getenv("CORS_MAX_AGE"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			return Config{}, fmt.Errorf("invalid CORS_MAX_AGE %q: must be a number of seconds", value)
+		}
+		config.MaxAge = time.Duration(seconds) * time.Second
+	}
+
+	if value := os.Getenv("CORS_ALLOW_CREDENTIALS"); value != "" {
+		allow, err := strconv.ParseBool(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid CORS_ALLOW_CREDENTIALS %q: %w", value, err)
+		}
+		config.AllowCredentials = allow
+	}
+
+	return config, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// withDefault returns values, or defaults when values is empty
+func withDefault(values, defaults []string) []string {
+	if len(values) == 0 {
+		return defaults
+	}
+	return values
+}
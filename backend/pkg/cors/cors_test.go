@@ -0,0 +1,180 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNew_RejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "wildcard with credentials", config: Config{AllowedOrigins: []string{"*"}, AllowCredentials: true}},
+		{name: "wildcard in the middle", config: Config{AllowedOrigins: []string{"https://app.*.example.com"}}},
+		{name: "bare wildcard subdomain", config: Config{AllowedOrigins: []string{"https://*."}}},
+		{name: "missing scheme", config: Config{AllowedOrigins: []string{"app.example.com"}}},
+		{name: "trailing slash", config: Config{AllowedOrigins: []string{"https://app.example.com/"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.config); err == nil {
+				t.Error("Expected New to reject the config")
+			}
+		})
+	}
+}
+
+func TestPolicy_Allows(t *testing.T) {
+	policy, err := New(Config{AllowedOrigins: []string{"https://app.example.com", "https://*.example.org", "http://*.localhost:3000"}})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"http://app.example.com", false},
+		{"https://evil.example.com", false},
+		{"https://admin.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evilexample.org", false},
+		{"https://example.org.evil.com", false},
+		{"https://user@x.example.org", false},
+		{"http://dev.localhost:3000", true},
+		{"http://dev.localhost:4000", false},
+		{"null", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := policy.Allows(tt.origin); got != tt.want {
+				t.Errorf("Allows(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	policy, err := New(Config{AllowedOrigins: []string{"https://app.example.com"}, MaxAge: 10 * time.Minute, AllowCredentials: true})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		method          string
+		origin          string
+		requestMethod   string
+		wantPreflight   bool
+		wantAllowOrigin string
+		wantMaxAge      string
+	}{
+		{name: "same origin", method: http.MethodGet},
+		{name: "allowed request", method: http.MethodGet, origin: "https://app.example.com", wantAllowOrigin: "https://app.example.com"},
+		{name: "disallowed request", method: http.MethodGet, origin: "https://evil.example.com"},
+		{name: "allowed preflight", method: http.MethodOptions, origin: "https://app.example.com", requestMethod: "POST", wantPreflight: true, wantAllowOrigin: "https://app.example.com", wantMaxAge: "600"},
+		{name: "disallowed preflight", method: http.MethodOptions, origin: "https://evil.example.com", requestMethod: "POST", wantPreflight: true},
+		{name: "plain options", method: http.MethodOptions, origin: "https://app.example.com", wantAllowOrigin: "https://app.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				r.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			h := http.Header{}
+
+			if preflight := policy.Apply(h, r); preflight != tt.wantPreflight {
+				t.Errorf("Expected preflight = %v, got %v", tt.wantPreflight, preflight)
+			}
+			if got := h.Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.wantAllowOrigin, got)
+			}
+			if got := h.Get("Access-Control-Max-Age"); got != tt.wantMaxAge {
+				t.Errorf("Expected Access-Control-Max-Age %q, got %q", tt.wantMaxAge, got)
+			}
+			if tt.wantAllowOrigin == "" && len(h.Values("Access-Control-Allow-Credentials")) != 0 {
+				t.Error("Expected no CORS headers for a disallowed origin")
+			}
+			if tt.wantAllowOrigin != "" && h.Get("Access-Control-Allow-Credentials") != "true" {
+				t.Error("Expected credentials to be allowed")
+			}
+		})
+	}
+}
+
+func TestPolicy_ApplyAllowAll(t *testing.T) {
+	policy, err := New(Config{AllowedOrigins: []string{"*"}})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "https://anywhere.example")
+	h := http.Header{}
+	policy.Apply(h, r)
+
+	if h.Get("Access-Control-Allow-Origin") != "*" || h.Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("Expected a wildcard origin without credentials, got %v", h)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		config, err := ConfigFromEnv()
+		if err != nil {
+			t.Fatalf("ConfigFromEnv returned error: %v", err)
+		}
+		if len(config.AllowedOrigins) != 2 || !config.AllowCredentials || config.MaxAge != 10*time.Minute {
+			t.Errorf("Unexpected defaults: %+v", config)
+		}
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com, https://*.example.com")
+		t.Setenv("CORS_ALLOWED_METHODS", "GET,POST")
+		t.Setenv("CORS_MAX_AGE", "60")
+		t.Setenv("CORS_ALLOW_CREDENTIALS", "false")
+		config, err := ConfigFromEnv()
+		if err != nil {
+			t.Fatalf("ConfigFromEnv returned error: %v", err)
+		}
+		if len(config.AllowedOrigins) != 2 || config.AllowedOrigins[1] != "https://*.example.com" || len(config.AllowedMethods) != 2 || config.MaxAge != time.Minute || config.AllowCredentials {
+			t.Errorf("Unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("empty origins disables cross-origin requests", func(t *testing.T) {
+		t.Setenv("CORS_ALLOWED_ORIGINS", "")
+		config, err := ConfigFromEnv()
+		if err != nil {
+			t.Fatalf("ConfigFromEnv returned error: %v", err)
+		}
+		if len(config.AllowedOrigins) != 0 {
+			t.Errorf("Expected no allowed origins, got %v", config.AllowedOrigins)
+		}
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		for name, value := range map[string]string{"CORS_MAX_AGE": "ten", "CORS_ALLOW_CREDENTIALS": "maybe"} {
+			t.Run(name, func(t *testing.T) {
+				t.Setenv(name, value)
+				if _, err := ConfigFromEnv(); err == nil {
+					t.Errorf("Expected %s=%q to be rejected", name, value)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transaction dispute event types, published by the banking service
+const (
+	TypeDisputeStatusChanged = "dispute.status_changed"
+)
+
+// DisputeStatusChanged is the v1 payload of dispute.status_changed, written
+// when a transaction dispute is filed and each time its status changes.
+// PreviousStatus is empty for newly filed disputes. ActorID is the user
+// who filed the dispute or the staff member who changed it.
+type DisputeStatusChanged struct {
+	DisputeID      uuid.UUID  `json:"dispute_id"`
+	TransactionID  uuid.UUID  `json:"transaction_id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	Status         string     `json:"status"`
+	PreviousStatus string     `json:"previous_status,omitempty"`
+	ActorID        uuid.UUID  `json:"actor_id"`
+	AssignedTo     *uuid.UUID `json:"assigned_to,omitempty"`
+	Amount         float64    `json:"amount"`
+	Note           string     `json:"note,omitempty"`
+	// RefundTransactionID is set when the dispute is resolved with a refund
+	RefundTransactionID *uuid.UUID `json:"refund_transaction_id,omitempty"`
+	RequestID           string     `json:"request_id,omitempty"`
+	ChangedAt           time.Time  `json:"changed_at"`
+}
@@ -0,0 +1,95 @@
+// Package events carries domain events between the microbank services.
+//
+// A service records events in an outbox table in the same transaction as
+// the change they describe, and a Relay publishes them in order, retrying
+// until the consumer accepts each one. Every event type has a versioned
+// payload schema; consumers decode a payload with Decode, which refuses
+// versions they do not know.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUnknownType is returned for an event type this package does not
+	// define
+	ErrUnknownType = errors.New("unknown event type")
+	// ErrUnsupportedVersion is returned when decoding a payload version this
+	// build does not understand
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+)
+
+// Event is the envelope every event travels in. Payload holds the
+// type-specific body in the schema identified by Type and Version.
+type Event struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	Source     string          `json:"source"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// currentVersions is the payload version New writes for each event type.
+// Bump a type's version, and add a payload struct for it, whenever its
+// schema changes incompatibly; keep decoding the old version until no
+// producer writes it.
+var currentVersions = map[string]int{
+	TypeUserRegistered:                1,
+	TypeUserBlacklisted:               1,
+	TypeUserUnblacklisted:             1,
+	TypeUserDeleted:                   1,
+	TypeUserKYCStatusChanged:          1,
+	TypeSavingsGoalCompleted:          1,
+	TypeDisputeStatusChanged:          1,
+	TypeMaintenanceChanged:            1,
+	TypeReconciliationMismatchesFound: 1,
+	TypeExternalTransferFailed:        1,
+	TypeTransactionPINReset:           1,
+}
+
+// New creates an event of the given type from source, encoding payload in
+// the type's current version
+func New(eventType, source string, payload any) (Event, error) {
+	version, ok := currentVersions[eventType]
+	if !ok {
+		return Event{}, fmt.Errorf("%w: %s", ErrUnknownType, eventType)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+
+	return Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		Version:    version,
+		Source:     source,
+		OccurredAt: time.Now().UTC(),
+		Payload:    body,
+	}, nil
+}
+
+// Decode unmarshals the event's payload into v after checking that the
+// payload version is one this build understands
+func Decode(event Event, v any) error {
+	version, ok := currentVersions[event.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, event.Type)
+	}
+	if event.Version < 1 || event.Version > version {
+		return fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, event.Type, event.Version)
+	}
+
+	if err := json.Unmarshal(event.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", event.Type, err)
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// contractCases pins the wire format of every event version. Consumers
+// deployed against these files must keep decoding them, so a failing case
+// means a schema change that needs a new version rather than an edit.
+var contractCases = []struct {
+	file      string
+	eventType string
+	source    string
+	payload   any
+	decoded   func() any
+}{
+	{
+		file:      "user.registered.v1.json",
+		eventType: TypeUserRegistered,
+		source:    SourceClientService,
+		payload: UserRegistered{
+			UserID:       uuid.MustParse("6b1c9a4e-2f43-4f5e-9a0a-1d2c3b4a5e6f"),
+			Method:       "password",
+			RegisteredAt: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		decoded: func() any { return &UserRegistered{} },
+	},
+	{
+		file:      "user.blacklisted.v1.json",
+		eventType: TypeUserBlacklisted,
+		source:    SourceClientService,
+		payload: UserBlacklisted{
+			UserID:        uuid.MustParse("6b1c9a4e-2f43-4f5e-9a0a-1d2c3b4a5e6f"),
+			Reason:        "Chargeback fraud",
+			ExpiresAt:     timePtr(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)),
+			BlacklistedAt: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		decoded: func() any { return &UserBlacklisted{} },
+	},
+	{
+		file:      "user.unblacklisted.v1.json",
+		eventType: TypeUserUnblacklisted,
+		source:    SourceClientService,
+		payload: UserUnblacklisted{
+			UserID:          uuid.MustParse("6b1c9a4e-2f43-4f5e-9a0a-1d2c3b4a5e6f"),
+			Expired:         true,
+			UnblacklistedAt: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		decoded: func() any { return &UserUnblacklisted{} },
+	},
+	{
+		file:      "user.deleted.v1.json",
+		eventType: TypeUserDeleted,
+		source:    SourceClientService,
+		payload: UserDeleted{
+			UserID:      uuid.MustParse("6b1c9a4e-2f43-4f5e-9a0a-1d2c3b4a5e6f"),
+			SelfDeleted: true,
+			DeletedAt:   time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		decoded: func() any { return &UserDeleted{} },
+	},
+	{
+		file:      "user.kyc_status_changed.v1.json",
+		eventType: TypeUserKYCStatusChanged,
+		source:    SourceClientService,
+		payload: UserKYCStatusChanged{
+			UserID:         uuid.MustParse("6b1c9a4e-2f43-4f5e-9a0a-1d2c3b4a5e6f"),
+			Status:         "verified",
+			PreviousStatus: "pending",
+			ChangedAt:      time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		decoded: func() any { return &UserKYCStatusChanged{} },
+	},
+	{
+		file:      "savings_goal.completed.v1.json",
+		eventType: TypeSavingsGoalCompleted,
+		source:    SourceBankingService,
+		payload: SavingsGoalCompleted{
+			GoalID:       uuid.MustParse("3d2c1b0a-9f8e-4d7c-8b6a-5f4e3d2c1b0a"),
+			UserID:       uuid.MustParse("6b1c9a4e-2f43-4f5e-9a0a-1d2c3b4a5e6f"),
+			Name:         "New laptop",
+			TargetAmount: 1200,
+			CompletedAt:  time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		decoded: func() any { return &SavingsGoalCompleted{} },
+	},
+	{
+		file:      "dispute.status_changed.v1.json",
+		eventType: TypeDisputeStatusChanged,
+		source:    SourceBankingService,
+		payload: DisputeStatusChanged{
+			DisputeID:           uuid.MustParse("9a8b7c6d-5e4f-4a3b-9c2d-1e0f9a8b7c6d"),
+			TransactionID:       uuid.MustParse("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"),
+			UserID:              uuid.MustParse("6b1c9a4e-2f43-4f5e-9a0a-1d2c3b4a5e6f"),
+			Status:              "resolved_refunded",
+			PreviousStatus:      "investigating",
+			ActorID:             uuid.MustParse("2c3d4e5f-6a7b-4c8d-9e0f-1a2b3c4d5e6f"),
+			AssignedTo:          uuidPtr(uuid.MustParse("2c3d4e5f-6a7b-4c8d-9e0f-1a2b3c4d5e6f")),
+			Amount:              49.99,
+			Note:                "Merchant confirmed the duplicate charge",
+			RefundTransactionID: uuidPtr(uuid.MustParse("4e5f6a7b-8c9d-4e0f-8a1b-2c3d4e5f6a7b")),
+			RequestID:           "req-123",
+			ChangedAt:           time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		decoded: func() any { return &DisputeStatusChanged{} },
+	},
+	{
+		file:      "maintenance.changed.v1.json",
+		eventType: TypeMaintenanceChanged,
+		source:    SourceBankingService,
+		payload: MaintenanceChanged{
+			Enabled:   true,
+			Message:   "Transfers are paused for a database upgrade",
+			EndsAt:    timePtr(time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC)),
+			ActorID:   uuid.MustParse("2c3d4e5f-6a7b-4c8d-9e0f-1a2b3c4d5e6f"),
+			RequestID: "req-123",
+			ChangedAt: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		decoded: func() any { return &MaintenanceChanged{} },
+	},
+	{
+		file:      "reconciliation.mismatches_found.v1.json",
+		eventType: TypeReconciliationMismatchesFound,
+		source:    SourceBankingService,
+		payload: ReconciliationMismatchesFound{
+			RunID:           uuid.MustParse("3d4e5f6a-7b8c-4d9e-8f0a-1b2c3d4e5f6a"),
+			RunDate:         "2024-03-01",
+			AccountsChecked: 1250,
+			MismatchesFound: 2,
+			TotalBalance:    1048576.25,
+			TotalExpected:   1048526.25,
+			FinishedAt:      time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		decoded: func() any { return &ReconciliationMismatchesFound{} },
+	},
+	{
+		file:      "external_transfer.failed.v1.json",
+		eventType: TypeExternalTransferFailed,
+		source:    SourceBankingService,
+		payload: ExternalTransferFailed{
+			TransferID:        uuid.MustParse("5d4c3b2a-1f0e-4d9c-8b7a-6f5e4d3c2b1a"),
+			UserID:            uuid.MustParse("6b1c9a4e-2f43-4f5e-9a0a-1d2c3b4a5e6f"),
+			Amount:            250,
+			AccountNumber:     "****6789",
+			AccountHolderName: "Jane Doe",
+			Reason:            "rejected_by_receiving_bank",
+			FailedAt:          time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		decoded: func() any { return &ExternalTransferFailed{} },
+	},
+	{
+		file:      "transaction_pin.reset.v1.json",
+		eventType: TypeTransactionPINReset,
+		source:    SourceBankingService,
+		payload: TransactionPINReset{
+			UserID:    uuid.MustParse("6b1c9a4e-2f43-4f5e-9a0a-1d2c3b4a5e6f"),
+			AccountID: uuid.MustParse("9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"),
+			ActorID:   uuid.MustParse("2c3d4e5f-6a7b-4c8d-9e0f-1a2b3c4d5e6f"),
+			RequestID: "req-123",
+			ResetAt:   time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		decoded: func() any { return &TransactionPINReset{} },
+	},
+}
+
+func uuidPtr(id uuid.UUID) *uuid.UUID {
+	return &id
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestEventContracts(t *testing.T) {
+	for _, tc := range contractCases {
+		t.Run(tc.file, func(t *testing.T) {
+			golden, err := os.ReadFile(filepath.Join("testdata", tc.file))
+			if err != nil {
+				t.Fatalf("Failed to read contract: %v", err)
+			}
+
+			// Producers write exactly the contract
+			event, err := New(tc.eventType, tc.source, tc.payload)
+			if err != nil {
+				t.Fatalf("New returned error: %v", err)
+			}
+			event.ID = uuid.MustParse("0f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a")
+			event.OccurredAt = time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+			got, err := json.MarshalIndent(event, "", "  ")
+			if err != nil {
+				t.Fatalf("Failed to encode event: %v", err)
+			}
+			if !bytes.Equal(bytes.TrimSpace(golden), got) {
+				t.Errorf("Event does not match %s:\n%s", tc.file, got)
+			}
+
+			// Consumers read the contract back into the payload
+			var decodedEvent Event
+			if err := json.Unmarshal(golden, &decodedEvent); err != nil {
+				t.Fatalf("Failed to decode contract: %v", err)
+			}
+			payload := tc.decoded()
+			if err := Decode(decodedEvent, payload); err != nil {
+				t.Fatalf("Decode returned error: %v", err)
+			}
+			if !reflect.DeepEqual(reflect.ValueOf(payload).Elem().Interface(), tc.payload) {
+				t.Errorf("Decoded payload %+v, want %+v", payload, tc.payload)
+			}
+		})
+	}
+}
+
+func TestNew_RejectsUnknownType(t *testing.T) {
+	if _, err := New("user.renamed", SourceClientService, struct{}{}); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("Expected ErrUnknownType, got %v", err)
+	}
+}
+
+func TestDecode_RejectsUnsupportedVersion(t *testing.T) {
+	event, err := New(TypeUserDeleted, SourceClientService, UserDeleted{UserID: uuid.New()})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	for _, version := range []int{0, event.Version + 1} {
+		event.Version = version
+		if err := Decode(event, &UserDeleted{}); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("Expected ErrUnsupportedVersion for v%d, got %v", version, err)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// External transfer event types, published by the banking service
+const (
+	TypeExternalTransferFailed = "external_transfer.failed"
+)
+
+// ExternalTransferFailed is the v1 payload of external_transfer.failed,
+// written when a transfer to another bank fails and its hold is released.
+// AccountNumber is the destination with all but its last four digits
+// masked. ActorID is the staff member who failed the transfer, and is
+// left out when the settlement worker did.
+type ExternalTransferFailed struct {
+	TransferID        uuid.UUID  `json:"transfer_id"`
+	UserID            uuid.UUID  `json:"user_id"`
+	Amount            float64    `json:"amount"`
+	AccountNumber     string     `json:"account_number"`
+	AccountHolderName string     `json:"account_holder_name"`
+	Reason            string     `json:"reason"`
+	ActorID           *uuid.UUID `json:"actor_id,omitempty"`
+	RequestID         string     `json:"request_id,omitempty"`
+	FailedAt          time.Time  `json:"failed_at"`
+}
@@ -0,0 +1,25 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Maintenance mode event types, published by the banking service
+const (
+	TypeMaintenanceChanged = "maintenance.changed"
+)
+
+// MaintenanceChanged is the v1 payload of maintenance.changed, written each
+// time money movement is paused or resumed. ActorID is the staff member
+// who made the change, or the nil UUID when MAINTENANCE_MODE turned it on
+// at startup. EndsAt is when a pause lifts by itself, if it does.
+type MaintenanceChanged struct {
+	Enabled   bool       `json:"enabled"`
+	Message   string     `json:"message,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	ActorID   uuid.UUID  `json:"actor_id"`
+	RequestID string     `json:"request_id,omitempty"`
+	ChangedAt time.Time  `json:"changed_at"`
+}
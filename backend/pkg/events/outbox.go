@@ -0,0 +1,203 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// OutboxSchema creates the outbox table. Each publishing service runs it
+// against its own database. sequence keeps events in commit order.
+const OutboxSchema = `
+	CREATE TABLE IF NOT EXISTS event_outbox (
+		id UUID PRIMARY KEY,
+		sequence BIGSERIAL,
+		event_type VARCHAR(100) NOT NULL,
+		version INTEGER NOT NULL,
+		source VARCHAR(50) NOT NULL,
+		payload JSONB NOT NULL,
+		occurred_at TIMESTAMPTZ NOT NULL,
+		published_at TIMESTAMPTZ,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox(sequence) WHERE published_at IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_event_outbox_published_at ON event_outbox(published_at);`
+
+// outboxLockKey is the advisory lock held while relaying, so relays in
+// several replicas of a service never publish out of order
+const outboxLockKey = 7263401
+
+// Default relay settings
+const (
+	DefaultRelayBatchSize = 100
+	DefaultRelayInterval  = 5 * time.Second
+	// publishedRetention is how long published events stay in the outbox
+	publishedRetention = 7 * 24 * time.Hour
+)
+
+// Execer runs a statement. *sql.Tx satisfies it, so events can be written
+// in the same transaction as the change they describe.
+type Execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// WriteOutbox records events in the outbox through e for the relay to
+// publish
+func WriteOutbox(e Execer, events ...Event) error {
+	query := `
+		INSERT INTO event_outbox (id, event_type, version, source, payload, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	for _, event := range events {
+		_, err := e.Exec(query, event.ID, event.Type, event.Version, event.Source, []byte(event.Payload), event.OccurredAt)
+		if err != nil {
+			return fmt.Errorf("failed to write %s event to outbox: %w", event.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// Relay publishes outbox events in the order they were written. An event
+// that fails to publish is retried on the next pass, and events behind it
+// wait, so consumers never see them out of order.
+type Relay struct {
+	db        *sql.DB
+	publisher Publisher
+	batchSize int
+}
+
+// NewRelay creates a relay that publishes events from db's outbox
+func NewRelay(db *sql.DB, publisher Publisher, batchSize int) *Relay {
+	if batchSize <= 0 {
+		batchSize = DefaultRelayBatchSize
+	}
+	return &Relay{db: db, publisher: publisher, batchSize: batchSize}
+}
+
+// Run publishes pending events every interval until ctx is done, and
+// prunes events published more than a week ago
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			published, err := r.PublishPending(ctx)
+			if err != nil {
+				log.Printf("Failed to publish outbox events: %v", err)
+			}
+			if err != nil || published < r.batchSize {
+				break
+			}
+		}
+
+		if _, err := r.PrunePublished(ctx, time.Now().Add(-publishedRetention)); err != nil {
+			log.Printf("Failed to prune published outbox events: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishPending publishes up to one batch of pending events and returns
+// how many were published. It publishes nothing while a relay in another
+// process holds the outbox.
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxLockKey).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("failed to lock outbox: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	pending, err := pendingEvents(ctx, tx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	var publishErr error
+	for _, event := range pending {
+		if publishErr = r.publisher.Publish(ctx, event); publishErr != nil {
+			query := `UPDATE event_outbox SET attempts = attempts + 1, last_error = $1 WHERE id = $2`
+			if _, err := tx.ExecContext(ctx, query, publishErr.Error(), event.ID); err != nil {
+				return 0, fmt.Errorf("failed to record publish failure: %w", err)
+			}
+			publishErr = fmt.Errorf("failed to publish %s event %s: %w", event.Type, event.ID, publishErr)
+			break
+		}
+
+		query := `UPDATE event_outbox SET published_at = $1 WHERE id = $2`
+		if _, err := tx.ExecContext(ctx, query, time.Now(), event.ID); err != nil {
+			return 0, fmt.Errorf("failed to mark event published: %w", err)
+		}
+		published++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return published, publishErr
+}
+
+// pendingEvents reads the oldest unpublished events
+func pendingEvents(ctx context.Context, tx *sql.Tx, limit int) ([]Event, error) {
+	query := `
+		SELECT id, event_type, version, source, payload, occurred_at
+		FROM event_outbox
+		WHERE published_at IS NULL
+		ORDER BY sequence
+		LIMIT $1`
+
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending events: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []Event
+	for rows.Next() {
+		var event Event
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.Type, &event.Version, &event.Source, &payload, &event.OccurredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		event.Payload = payload
+		pending = append(pending, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over event rows: %w", err)
+	}
+
+	return pending, nil
+}
+
+// PrunePublished deletes events published before the cutoff and returns how
+// many were removed
+func (r *Relay) PrunePublished(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM event_outbox WHERE published_at IS NOT NULL AND published_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune published events: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,139 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+// fakePublisher records published events and fails on request
+type fakePublisher struct {
+	published []Event
+	failOn    uuid.UUID
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, event Event) error {
+	if event.ID == p.failOn {
+		return errors.New("consumer unavailable")
+	}
+	p.published = append(p.published, event)
+	return nil
+}
+
+func newTestEvent(t *testing.T) Event {
+	t.Helper()
+	event, err := New(TypeUserDeleted, SourceClientService, UserDeleted{UserID: uuid.New(), DeletedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	return event
+}
+
+func pendingRows(events ...Event) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "event_type", "version", "source", "payload", "occurred_at"})
+	for _, e := range events {
+		rows.AddRow(e.ID, e.Type, e.Version, e.Source, []byte(e.Payload), e.OccurredAt)
+	}
+	return rows
+}
+
+func TestWriteOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	event := newTestEvent(t)
+	mock.ExpectExec(`INSERT INTO event_outbox`).
+		WithArgs(event.ID, event.Type, event.Version, event.Source, []byte(event.Payload), event.OccurredAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := WriteOutbox(db, event); err != nil {
+		t.Fatalf("WriteOutbox returned error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %v", err)
+	}
+}
+
+func TestRelay_PublishPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	first, second := newTestEvent(t), newTestEvent(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery(`FROM event_outbox`).WithArgs(10).WillReturnRows(pendingRows(first, second))
+	mock.ExpectExec(`UPDATE event_outbox SET published_at`).WithArgs(sqlmock.AnyArg(), first.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE event_outbox SET published_at`).WithArgs(sqlmock.AnyArg(), second.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	publisher := &fakePublisher{}
+	published, err := NewRelay(db, publisher, 10).PublishPending(context.Background())
+	if err != nil {
+		t.Fatalf("PublishPending returned error: %v", err)
+	}
+	if published != 2 || len(publisher.published) != 2 || publisher.published[0].ID != first.ID {
+		t.Errorf("Expected both events published in order, got %d: %+v", published, publisher.published)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %v", err)
+	}
+}
+
+func TestRelay_PublishPending_StopsAtFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	first, second := newTestEvent(t), newTestEvent(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery(`FROM event_outbox`).WillReturnRows(pendingRows(first, second))
+	mock.ExpectExec(`UPDATE event_outbox SET attempts = attempts \+ 1`).
+		WithArgs("consumer unavailable", first.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	publisher := &fakePublisher{failOn: first.ID}
+	published, err := NewRelay(db, publisher, 10).PublishPending(context.Background())
+	if err == nil {
+		t.Fatal("Expected the publish failure to be returned")
+	}
+	if published != 0 || len(publisher.published) != 0 {
+		t.Errorf("Expected the later event to wait, got %d published", published)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %v", err)
+	}
+}
+
+func TestRelay_PublishPending_SkipsWhenLocked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	mock.ExpectRollback()
+
+	published, err := NewRelay(db, &fakePublisher{}, 10).PublishPending(context.Background())
+	if err != nil || published != 0 {
+		t.Errorf("Expected nothing published, got %d (%v)", published, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Publisher delivers an event to its consumers. Returning nil means the
+// event was accepted and will not be sent again.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// HTTPPublisher posts events as JSON to a consumer endpoint, authenticating
+// with the shared internal service token
+type HTTPPublisher struct {
+	url          string
+	serviceToken string
+	httpClient   *http.Client
+}
+
+// NewHTTPPublisher creates a publisher posting to url
+func NewHTTPPublisher(url, serviceToken string) *HTTPPublisher {
+	return &HTTPPublisher{
+		url:          url,
+		serviceToken: serviceToken,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WithTransport makes the publisher send events through transport, such as
+// one presenting a client certificate
+func (p *HTTPPublisher) WithTransport(transport http.RoundTripper) *HTTPPublisher {
+	p.httpClient.Transport = transport
+	return p
+}
+
+// Publish posts the event, treating any non-2xx response as a failure
+func (p *HTTPPublisher) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Token", p.serviceToken)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach event consumer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("event consumer returned status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPPublisher_Publish(t *testing.T) {
+	var received Event
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("X-Service-Token")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode event: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	event := newTestEvent(t)
+	if err := NewHTTPPublisher(server.URL, "secret").Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	if token != "secret" {
+		t.Errorf("Expected service token header, got %q", token)
+	}
+	if received.ID != event.ID || received.Type != event.Type {
+		t.Errorf("Expected event %s, got %+v", event.ID, received)
+	}
+}
+
+func TestHTTPPublisher_Publish_RejectsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unsupported version", http.StatusUnprocessableEntity)
+	}))
+	defer server.Close()
+
+	if err := NewHTTPPublisher(server.URL, "secret").Publish(context.Background(), newTestEvent(t)); err == nil {
+		t.Error("Expected an error for a 422 response")
+	}
+}
@@ -0,0 +1,27 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ledger reconciliation event types, published by the banking service
+const (
+	TypeReconciliationMismatchesFound = "reconciliation.mismatches_found"
+)
+
+// ReconciliationMismatchesFound is the v1 payload of
+// reconciliation.mismatches_found, written when a reconciliation run
+// finishes having found stored balances that do not match the transaction
+// history. TotalBalance and TotalExpected are the sum of every account's
+// balance and what the transactions add up to, system-wide.
+type ReconciliationMismatchesFound struct {
+	RunID           uuid.UUID `json:"run_id"`
+	RunDate         string    `json:"run_date"`
+	AccountsChecked int       `json:"accounts_checked"`
+	MismatchesFound int       `json:"mismatches_found"`
+	TotalBalance    float64   `json:"total_balance"`
+	TotalExpected   float64   `json:"total_expected"`
+	FinishedAt      time.Time `json:"finished_at"`
+}
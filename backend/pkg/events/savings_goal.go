@@ -0,0 +1,25 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Savings goal event types, published by the banking service
+const (
+	TypeSavingsGoalCompleted = "savings_goal.completed"
+)
+
+// SourceBankingService identifies events published by the banking service
+const SourceBankingService = "banking-service"
+
+// SavingsGoalCompleted is the v1 payload of savings_goal.completed, written
+// when the money earmarked for a goal first reaches its target
+type SavingsGoalCompleted struct {
+	GoalID       uuid.UUID `json:"goal_id"`
+	UserID       uuid.UUID `json:"user_id"`
+	Name         string    `json:"name"`
+	TargetAmount float64   `json:"target_amount"`
+	CompletedAt  time.Time `json:"completed_at"`
+}
@@ -0,0 +1,23 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transaction PIN event types, published by the banking service
+const (
+	TypeTransactionPINReset = "transaction_pin.reset"
+)
+
+// TransactionPINReset is the v1 payload of transaction_pin.reset, written
+// when staff reset a user's transaction PIN. It never carries the PIN or
+// its hash. ActorID is the staff member who reset it.
+type TransactionPINReset struct {
+	UserID    uuid.UUID `json:"user_id"`
+	AccountID uuid.UUID `json:"account_id"`
+	ActorID   uuid.UUID `json:"actor_id"`
+	RequestID string    `json:"request_id,omitempty"`
+	ResetAt   time.Time `json:"reset_at"`
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User lifecycle event types, published by the client service
+const (
+	TypeUserRegistered       = "user.registered"
+	TypeUserBlacklisted      = "user.blacklisted"
+	TypeUserUnblacklisted    = "user.unblacklisted"
+	TypeUserDeleted          = "user.deleted"
+	TypeUserKYCStatusChanged = "user.kyc_status_changed"
+)
+
+// SourceClientService identifies events published by the client service
+const SourceClientService = "client-service"
+
+// UserRegistered is the v1 payload of user.registered. Method is how the
+// user signed up: "password", "invitation" or an OAuth provider name.
+type UserRegistered struct {
+	UserID       uuid.UUID `json:"user_id"`
+	Method       string    `json:"method"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// UserBlacklisted is the v1 payload of user.blacklisted. ExpiresAt is nil
+// for a blacklist that lasts until an admin lifts it.
+type UserBlacklisted struct {
+	UserID        uuid.UUID  `json:"user_id"`
+	Reason        string     `json:"reason"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	BlacklistedAt time.Time  `json:"blacklisted_at"`
+}
+
+// UserUnblacklisted is the v1 payload of user.unblacklisted. Expired is
+// true when the blacklist lapsed on its own rather than being lifted by an
+// admin.
+type UserUnblacklisted struct {
+	UserID          uuid.UUID `json:"user_id"`
+	Expired         bool      `json:"expired"`
+	UnblacklistedAt time.Time `json:"unblacklisted_at"`
+}
+
+// UserDeleted is the v1 payload of user.deleted. The user may still be
+// restored within the client service's retention window.
+type UserDeleted struct {
+	UserID      uuid.UUID `json:"user_id"`
+	SelfDeleted bool      `json:"self_deleted"`
+	DeletedAt   time.Time `json:"deleted_at"`
+}
+
+// UserKYCStatusChanged is the v1 payload of user.kyc_status_changed,
+// written when a user submits identity documents ("pending") and when a
+// reviewer approves ("verified") or rejects ("rejected") them
+type UserKYCStatusChanged struct {
+	UserID         uuid.UUID `json:"user_id"`
+	Status         string    `json:"status"`
+	PreviousStatus string    `json:"previous_status"`
+	ChangedAt      time.Time `json:"changed_at"`
+}
@@ -0,0 +1,177 @@
+// Package httpx writes every API response in the same envelope. It does not
+// import a web framework: the helpers take a Context, which *gin.Context
+// satisfies, so each service calls them straight from its handlers.
+package httpx
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// RequestIDKey is the context key the services store the request ID under
+const RequestIDKey = "request_id"
+
+// Headers giving the size of a counted list, so clients can tell how many
+// pages there are before fetching any
+const (
+	TotalCountHeader = "X-Total-Count"
+	TotalPagesHeader = "X-Total-Pages"
+)
+
+// Context is the part of a request context the helpers need
+type Context interface {
+	JSON(code int, obj interface{})
+	GetString(key string) string
+	Abort()
+}
+
+// HeaderContext is a Context that can also set response headers and send a
+// response without a body, as *gin.Context can
+type HeaderContext interface {
+	Context
+	Header(key, value string)
+	Status(code int)
+}
+
+// Envelope is the body of every response. Successful responses carry data
+// and, for lists, pagination; failed responses carry error.
+type Envelope struct {
+	Data       interface{} `json:"data,omitempty"`
+	Error      *ErrorBody  `json:"error,omitempty"`
+	RequestID  string      `json:"request_id"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// ErrorBody describes a failed request
+type ErrorBody struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Pagination describes which part of a list a response holds. Total and
+// HasMore are left out for lists whose size is not counted. NextCursor,
+// for lists that can be paged by cursor, is passed back to get the part
+// that follows; it is left out on the last part.
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Count      int    `json:"count"`
+	Total      *int   `json:"total,omitempty"`
+	HasMore    *bool  `json:"has_more,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPagination describes count items starting at offset of a list of
+// total items
+func NewPagination(limit, offset, count, total int) *Pagination {
+	hasMore := offset+count < total
+	return &Pagination{
+		Limit:   limit,
+		Offset:  offset,
+		Count:   count,
+		Total:   &total,
+		HasMore: &hasMore,
+	}
+}
+
+// AppError is an error reported to clients with its own status, code and
+// optional details. Err, when set, is the underlying cause; it is never
+// shown to clients.
+type AppError struct {
+	Status  int
+	Code    string
+	Message string
+	Details interface{}
+	Err     error
+}
+
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// internalError is reported for errors that are not an *AppError
+var internalError = &AppError{
+	Status:  http.StatusInternalServerError,
+	Code:    "INTERNAL_ERROR",
+	Message: "An internal error occurred",
+}
+
+// RespondOK writes a 200 response carrying data
+func RespondOK(c Context, data interface{}) {
+	Respond(c, http.StatusOK, data)
+}
+
+// RespondCreated writes a 201 response carrying data
+func RespondCreated(c Context, data interface{}) {
+	Respond(c, http.StatusCreated, data)
+}
+
+// RespondPage writes a 200 response carrying one page of a list
+func RespondPage(c Context, data interface{}, pagination *Pagination) {
+	c.JSON(http.StatusOK, Envelope{
+		Data:       data,
+		RequestID:  c.GetString(RequestIDKey),
+		Pagination: pagination,
+	})
+}
+
+// SetTotalHeaders sets the X-Total-Count and X-Total-Pages headers of a
+// list of total items read in pages of limit
+func SetTotalHeaders(c HeaderContext, total, limit int) {
+	pages := 0
+	if limit > 0 {
+		pages = (total + limit - 1) / limit
+	}
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.Header(TotalPagesHeader, strconv.Itoa(pages))
+}
+
+// RespondTotal answers a HEAD request for a list with a 200 carrying only
+// the X-Total-Count and X-Total-Pages headers
+func RespondTotal(c HeaderContext, total, limit int) {
+	SetTotalHeaders(c, total, limit)
+	c.Status(http.StatusOK)
+}
+
+// Respond writes a successful response with any status carrying data
+func Respond(c Context, status int, data interface{}) {
+	c.JSON(status, Envelope{
+		Data:      data,
+		RequestID: c.GetString(RequestIDKey),
+	})
+}
+
+// RespondError writes the response for err. An *AppError anywhere in the
+// chain is reported as is; any other error is reported as a 500
+// INTERNAL_ERROR without its text.
+func RespondError(c Context, err error) {
+	var appErr *AppError
+	if !errors.As(err, &appErr) {
+		appErr = internalError
+	}
+
+	c.JSON(appErr.Status, Envelope{
+		Error: &ErrorBody{
+			Code:    appErr.Code,
+			Message: appErr.Message,
+			Details: appErr.Details,
+		},
+		RequestID: c.GetString(RequestIDKey),
+	})
+}
+
+// AbortWithError writes the response for err, as RespondError does, and
+// stops the remaining handlers from running
+func AbortWithError(c Context, err error) {
+	c.Abort()
+	RespondError(c, err)
+}
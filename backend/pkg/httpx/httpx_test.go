@@ -0,0 +1,195 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// fakeContext records the response written through it
+type fakeContext struct {
+	requestID string
+	status    int
+	headers   map[string]string
+	body      string
+	aborted   bool
+}
+
+func (c *fakeContext) Header(key, value string) {
+	if c.headers == nil {
+		c.headers = map[string]string{}
+	}
+	c.headers[key] = value
+}
+
+func (c *fakeContext) Status(code int) {
+	c.status = code
+}
+
+func (c *fakeContext) JSON(code int, obj interface{}) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		panic(err)
+	}
+	c.status = code
+	c.body = string(body)
+}
+
+func (c *fakeContext) GetString(key string) string {
+	if key == RequestIDKey {
+		return c.requestID
+	}
+	return ""
+}
+
+func (c *fakeContext) Abort() {
+	c.aborted = true
+}
+
+func TestEnvelope(t *testing.T) {
+	notFound := &AppError{Status: http.StatusNotFound, Code: "USER_NOT_FOUND", Message: "User not found"}
+
+	tests := []struct {
+		name       string
+		respond    func(c Context)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "ok",
+			respond:    func(c Context) { RespondOK(c, map[string]string{"name": "Andile"}) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"name":"Andile"},"request_id":"req-1"}`,
+		},
+		{
+			name:       "created",
+			respond:    func(c Context) { RespondCreated(c, map[string]int{"id": 7}) },
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"data":{"id":7},"request_id":"req-1"}`,
+		},
+		{
+			name:       "other status",
+			respond:    func(c Context) { Respond(c, http.StatusAccepted, map[string]string{"message": "queued"}) },
+			wantStatus: http.StatusAccepted,
+			wantBody:   `{"data":{"message":"queued"},"request_id":"req-1"}`,
+		},
+		{
+			name:       "page",
+			respond:    func(c Context) { RespondPage(c, []int{3, 4}, NewPagination(2, 2, 2, 5)) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":[3,4],"request_id":"req-1","pagination":{"limit":2,"offset":2,"count":2,"total":5,"has_more":true}}`,
+		},
+		{
+			name:       "page without total",
+			respond:    func(c Context) { RespondPage(c, []int{}, &Pagination{Limit: 50}) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":[],"request_id":"req-1","pagination":{"limit":50,"offset":0,"count":0}}`,
+		},
+		{
+			name:       "page with cursor",
+			respond:    func(c Context) { RespondPage(c, []int{3, 4}, &Pagination{Limit: 2, Count: 2, NextCursor: "4"}) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":[3,4],"request_id":"req-1","pagination":{"limit":2,"offset":0,"count":2,"next_cursor":"4"}}`,
+		},
+		{
+			name:       "app error",
+			respond:    func(c Context) { RespondError(c, notFound) },
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":{"code":"USER_NOT_FOUND","message":"User not found"},"request_id":"req-1"}`,
+		},
+		{
+			name: "app error with details",
+			respond: func(c Context) {
+				RespondError(c, &AppError{Status: http.StatusBadRequest, Code: "INSUFFICIENT_FUNDS", Message: "Insufficient funds", Details: map[string]float64{"requested_amount": 10}})
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":{"code":"INSUFFICIENT_FUNDS","message":"Insufficient funds","details":{"requested_amount":10}},"request_id":"req-1"}`,
+		},
+		{
+			name:       "wrapped app error",
+			respond:    func(c Context) { RespondError(c, fmt.Errorf("lookup failed: %w", notFound)) },
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":{"code":"USER_NOT_FOUND","message":"User not found"},"request_id":"req-1"}`,
+		},
+		{
+			name:       "other error",
+			respond:    func(c Context) { RespondError(c, errors.New("pq: connection refused")) },
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":{"code":"INTERNAL_ERROR","message":"An internal error occurred"},"request_id":"req-1"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeContext{requestID: "req-1"}
+			tt.respond(c)
+
+			if c.status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, c.status)
+			}
+			if c.body != tt.wantBody {
+				t.Errorf("Expected body %s, got %s", tt.wantBody, c.body)
+			}
+			if c.aborted {
+				t.Error("Expected the request not to be aborted")
+			}
+		})
+	}
+}
+
+func TestRespondTotal(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		limit     int
+		wantPages string
+	}{
+		{name: "empty", total: 0, limit: 50, wantPages: "0"},
+		{name: "one partial page", total: 3, limit: 50, wantPages: "1"},
+		{name: "full pages", total: 100, limit: 50, wantPages: "2"},
+		{name: "last page partial", total: 101, limit: 50, wantPages: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeContext{}
+			RespondTotal(c, tt.total, tt.limit)
+
+			if c.status != http.StatusOK || c.body != "" {
+				t.Errorf("Expected 200 without a body, got %d %q", c.status, c.body)
+			}
+			if got := c.headers[TotalCountHeader]; got != fmt.Sprint(tt.total) {
+				t.Errorf("Expected %s %d, got %q", TotalCountHeader, tt.total, got)
+			}
+			if got := c.headers[TotalPagesHeader]; got != tt.wantPages {
+				t.Errorf("Expected %s %s, got %q", TotalPagesHeader, tt.wantPages, got)
+			}
+		})
+	}
+}
+
+func TestAbortWithError(t *testing.T) {
+	c := &fakeContext{}
+	AbortWithError(c, &AppError{Status: http.StatusUnauthorized, Code: "MISSING_TOKEN", Message: "Authorization header is required"})
+
+	if !c.aborted {
+		t.Error("Expected the request to be aborted")
+	}
+	if want := `{"error":{"code":"MISSING_TOKEN","message":"Authorization header is required"},"request_id":""}`; c.status != http.StatusUnauthorized || c.body != want {
+		t.Errorf("Expected 401 %s, got %d %s", want, c.status, c.body)
+	}
+}
+
+func TestAppError(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := &AppError{Status: http.StatusBadGateway, Code: "BANKING_UNAVAILABLE", Message: "Banking service unavailable", Err: cause}
+
+	if got := err.Error(); got != "Banking service unavailable: connection refused" {
+		t.Errorf("Unexpected error text %q", got)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Expected the AppError to unwrap to its cause")
+	}
+}
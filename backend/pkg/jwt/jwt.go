@@ -3,150 +3,392 @@ package jwt
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+const (
+	// DefaultIssuer is the iss claim set on every token
+	DefaultIssuer = "microbank"
+	// DefaultAudience is the aud claim set on every token
+	DefaultAudience = "microbank-users"
+
+	// TokenTypeAccess marks access tokens in the type claim
+	TokenTypeAccess = "access"
+	// TokenTypeRefresh marks refresh tokens in the type claim
+	TokenTypeRefresh = "refresh"
+)
+
+// Signer signs claims with the active key
+type Signer interface {
+	Sign(claims jwt.Claims) (string, error)
+}
+
+// Verifier supplies the keys that verify tokens
+type Verifier interface {
+	// Keyfunc returns the key that verifies a token, usually chosen by its
+	// kid header
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	// ValidMethods lists the accepted signing algorithms
+	ValidMethods() []string
+}
+
 // TokenManager handles JWT token operations
 type TokenManager struct {
-	secret          string
-	accessTokenTTL  time.Duration
-	refreshTokenTTL time.Duration
-	issuer          string
-	audience        string
+	signer               Signer
+	verifier             Verifier
+	accessTokenTTL       time.Duration
+	refreshTokenTTL      time.Duration
+	shortRefreshTokenTTL time.Duration
+	issuer               string
+	audience             string
+	validateOptions      []ValidateOption
+	now                  func() time.Time
 }
 
-// NewTokenManager creates a new token manager
+// NewTokenManager creates a new token manager that signs and verifies
+// tokens with a shared HS256 secret
 func NewTokenManager(secret string, accessTTL, refreshTTL time.Duration) *TokenManager {
+	key := hmacKey(secret)
+	return NewTokenManagerWithKeys(key, key, accessTTL, refreshTTL)
+}
+
+// NewTokenManagerWithKeys creates a new token manager that signs tokens with
+// signer and verifies them with verifier. signer may be nil for services
+// that only verify tokens.
+func NewTokenManagerWithKeys(signer Signer, verifier Verifier, accessTTL, refreshTTL time.Duration) *TokenManager {
 	return &TokenManager{
-		secret:          secret,
+		signer:          signer,
+		verifier:        verifier,
 		accessTokenTTL:  accessTTL,
 		refreshTokenTTL: refreshTTL,
-		issuer:          "microbank",
-		audience:        "microbank-users",
+		issuer:          DefaultIssuer,
+		audience:        DefaultAudience,
+		now:             time.Now,
+	}
+}
+
+// WithValidateOptions returns a copy of the token manager that applies opts
+// on every validation, in addition to those passed to each call
+func (tm *TokenManager) WithValidateOptions(opts ...ValidateOption) *TokenManager {
+	copied := *tm
+	copied.validateOptions = append(append([]ValidateOption{}, tm.validateOptions...), opts...)
+	return &copied
+}
+
+// WithShortRefreshTTL returns a copy of the token manager whose refresh
+// tokens live for ttl when the user did not ask to be remembered
+func (tm *TokenManager) WithShortRefreshTTL(ttl time.Duration) *TokenManager {
+	copied := *tm
+	copied.shortRefreshTokenTTL = ttl
+	return &copied
+}
+
+// WithAccessTTL returns a copy of the token manager whose access tokens
+// live for ttl, for tokens that must be shorter-lived than a session's
+func (tm *TokenManager) WithAccessTTL(ttl time.Duration) *TokenManager {
+	copied := *tm
+	copied.accessTokenTTL = ttl
+	return &copied
+}
+
+// RefreshTokenTTL returns how long a refresh token lives. Users who asked to
+// be remembered get the full refresh TTL; others get the short one, when it
+// is set.
+func (tm *TokenManager) RefreshTokenTTL(rememberMe bool) time.Duration {
+	if rememberMe || tm.shortRefreshTokenTTL <= 0 {
+		return tm.refreshTokenTTL
+	}
+	return tm.shortRefreshTokenTTL
+}
+
+// TTLs holds how long each kind of token lives
+type TTLs struct {
+	Access       time.Duration
+	Refresh      time.Duration
+	ShortRefresh time.Duration
+}
+
+// TTLsFromEnv reads token lifetimes from JWT_ACCESS_TOKEN_TTL (minutes,
+// default 15), JWT_REFRESH_TOKEN_TTL (hours, default 168) and
+// JWT_SHORT_REFRESH_TOKEN_TTL (hours, default 12). Access tokens must expire
+// before either kind of refresh token.
+func TTLsFromEnv() (TTLs, error) {
+	ttls := TTLs{
+		Access:       15 * time.Minute,
+		Refresh:      7 * 24 * time.Hour,
+		ShortRefresh: 12 * time.Hour,
+	}
+
+	for _, setting := range []struct {
+		name   string
+		unit   time.Duration
+		target *time.Duration
+	}{
+		{"JWT_ACCESS_TOKEN_TTL", time.Minute, &ttls.Access},
+		{"JWT_REFRESH_TOKEN_TTL", time.Hour, &ttls.Refresh},
+		{"JWT_SHORT_REFRESH_TOKEN_TTL", time.Hour, &ttls.ShortRefresh},
+	} {
+		value := os.Getenv(setting.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return TTLs{}, fmt.Errorf("invalid %s %q: must be a positive integer", setting.name, value)
+		}
+		*setting.target = time.Duration(n) * setting.unit
+	}
+
+	if ttls.ShortRefresh > ttls.Refresh {
+		return TTLs{}, fmt.Errorf("JWT_SHORT_REFRESH_TOKEN_TTL must not exceed JWT_REFRESH_TOKEN_TTL")
 	}
+	if ttls.Access >= ttls.ShortRefresh {
+		return TTLs{}, fmt.Errorf("JWT_ACCESS_TOKEN_TTL must be shorter than JWT_SHORT_REFRESH_TOKEN_TTL")
+	}
+	return ttls, nil
 }
 
-// Claims represents the JWT claims
+// Claims represents the JWT claims of an access token
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Name   string `json:"name"`
-	Role   string `json:"role"`
+	UserID        string `json:"user_id"`
+	Email         string `json:"email"`
+	Name          string `json:"name"`
+	IsAdmin       bool   `json:"is_admin"`
+	IsBlacklisted bool   `json:"is_blacklisted"`
+	// Roles lists the user's staff roles. IsAdmin is kept for tokens read
+	// by code that predates roles.
+	Roles []string `json:"roles,omitempty"`
+	// ImpersonatorID is set on tokens an admin was issued to act as the
+	// user, and names that admin
+	ImpersonatorID string `json:"impersonator_id,omitempty"`
+	TokenVersion   int    `json:"token_version"`
+	Type           string `json:"type"`
 	jwt.RegisteredClaims
 }
 
-// GenerateAccessToken creates a new access token
-func (tm *TokenManager) GenerateAccessToken(userID, email, name, role string) (string, error) {
-	now := time.Now()
-	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Name:   name,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    tm.issuer,
-			Issuer:    tm.issuer,
-			Subject:   userID,
-			Audience:  []string{tm.audience},
-			ExpiresAt: jwt.NewNumericDate(now.Add(tm.accessTokenTTL)),
-			NotBefore: jwt.NewNumericDate(now),
-			IssuedAt:  jwt.NewNumericDate(now),
-		},
+// refreshClaims represents the JWT claims of a refresh token
+type refreshClaims struct {
+	Type string `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// ValidateOption adds a check to token validation
+type ValidateOption func(*validation)
+
+// validation collects the checks requested by ValidateOptions
+type validation struct {
+	issuer   string
+	audience string
+}
+
+// WithIssuer requires the token's iss claim to match issuer
+func WithIssuer(issuer string) ValidateOption {
+	return func(v *validation) {
+		v.issuer = issuer
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(tm.secret))
+// WithAudience requires the token's aud claim to contain audience
+func WithAudience(audience string) ValidateOption {
+	return func(v *validation) {
+		v.audience = audience
+	}
 }
 
-// GenerateRefreshToken creates a new refresh token
-func (tm *TokenManager) GenerateRefreshToken(userID string) (string, error) {
-	now := time.Now()
-	claims := &jwt.RegisteredClaims{
+// ValidateOptionsFromEnv returns the checks every service applies when
+// JWT_VERIFY_ISSUER_AUDIENCE is true: tokens must carry DefaultIssuer and
+// DefaultAudience. It is off by default so tokens issued before these claims
+// were added keep working until they expire.
+func ValidateOptionsFromEnv() ([]ValidateOption, error) {
+	value := os.Getenv("JWT_VERIFY_ISSUER_AUDIENCE")
+	if value == "" {
+		return nil, nil
+	}
+
+	verify, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_VERIFY_ISSUER_AUDIENCE %q: %w", value, err)
+	}
+	if !verify {
+		return nil, nil
+	}
+	return []ValidateOption{WithIssuer(DefaultIssuer), WithAudience(DefaultAudience)}, nil
+}
+
+// GenerateAccessToken signs an access token for the user described by
+// claims. The registered claims (iss, sub, aud, exp, nbf, iat and a new jti)
+// and the type are filled in on claims, so the caller can read the token's
+// ID and expiry from it afterwards.
+func (tm *TokenManager) GenerateAccessToken(claims *Claims) (string, error) {
+	if tm.signer == nil {
+		return "", errors.New("token manager cannot sign tokens")
+	}
+	if claims.UserID == "" {
+		return "", errors.New("user_id is required")
+	}
+
+	now := tm.now()
+	claims.Type = TokenTypeAccess
+	claims.RegisteredClaims = jwt.RegisteredClaims{
 		Issuer:    tm.issuer,
-		Subject:   userID,
+		Subject:   claims.UserID,
 		Audience:  []string{tm.audience},
-		ExpiresAt: jwt.NewNumericDate(now.Add(tm.refreshTokenTTL)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(tm.accessTokenTTL)),
 		NotBefore: jwt.NewNumericDate(now),
 		IssuedAt:  jwt.NewNumericDate(now),
-		ID:        fmt.Sprintf("refresh_%s", userID),
+		ID:        uuid.New().String(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(tm.secret))
+	token, err := tm.signer.Sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return token, nil
 }
 
-// ValidateToken validates and parses a JWT token
-func (tm *TokenManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(tm.secret), nil
-	})
+// GenerateRefreshToken creates a new refresh token
+func (tm *TokenManager) GenerateRefreshToken(userID string) (string, error) {
+	if tm.signer == nil {
+		return "", errors.New("token manager cannot sign tokens")
+	}
+
+	now := tm.now()
+	claims := &refreshClaims{
+		Type: TokenTypeRefresh,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tm.issuer,
+			Subject:   userID,
+			Audience:  []string{tm.audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(tm.refreshTokenTTL)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.New().String(),
+		},
+	}
 
+	token, err := tm.signer.Sign(claims)
 	if err != nil {
+		return "", fmt.Errorf("failed to sign refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// ValidateToken validates and parses an access token. Tokens without an
+// iss or aud claim are accepted unless WithIssuer or WithAudience is given.
+func (tm *TokenManager) ValidateToken(tokenString string, opts ...ValidateOption) (*Claims, error) {
+	claims := &Claims{}
+	if err := tm.parse(tokenString, claims, opts); err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	if claims.Type != TokenTypeAccess {
+		return nil, fmt.Errorf("not an access token")
+	}
+	if claims.UserID == "" {
+		return nil, fmt.Errorf("user_id not found in token")
 	}
 
-	return nil, fmt.Errorf("invalid token")
+	return claims, nil
 }
 
-// ValidateRefreshToken validates a refresh token
-func (tm *TokenManager) ValidateRefreshToken(tokenString string) (string, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return "", fmt.Errorf("failed to parse refresh token: %w", err)
-		}
-		return []byte(tm.secret), nil
-	})
-
-	if err != nil {
+// ValidateRefreshToken validates a refresh token and returns its user ID
+func (tm *TokenManager) ValidateRefreshToken(tokenString string, opts ...ValidateOption) (string, error) {
+	claims := &refreshClaims{}
+	if err := tm.parse(tokenString, claims, opts); err != nil {
 		return "", fmt.Errorf("failed to parse refresh token: %w", err)
 	}
 
-	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
-		return claims.Subject, nil
+	if claims.Type != TokenTypeRefresh {
+		return "", fmt.Errorf("not a refresh token")
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("subject not found in refresh token")
 	}
 
-	return "", fmt.Errorf("invalid refresh token")
+	return claims.Subject, nil
 }
 
-// GenerateSecureSecret generates a cryptographically secure secret
-func GenerateSecureSecret(length int) (string, error) {
-	if length < 32 {
-		length = 32
+// GetTokenExpiration returns the expiration time of a valid token
+func (tm *TokenManager) GetTokenExpiration(tokenString string) (time.Time, error) {
+	claims := &jwt.RegisteredClaims{}
+	if err := tm.parse(tokenString, claims, nil); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse token: %w", err)
 	}
-	
-	bytes := make([]byte, length)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+
+	if claims.ExpiresAt == nil {
+		return time.Time{}, fmt.Errorf("could not extract expiration time")
 	}
+	return claims.ExpiresAt.Time, nil
 }
 
-// GetTokenExpiration returns the expiration time of a token
-func (tm *TokenManager) GetTokenExpiration(tokenString string) (time.Time, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(tm.secret), nil
-	})
+// parse verifies a token's signature, expiry and any requested issuer and
+// audience, decoding its claims into claims
+func (tm *TokenManager) parse(tokenString string, claims jwt.Claims, opts []ValidateOption) error {
+	var v validation
+	for _, opt := range tm.validateOptions {
+		opt(&v)
+	}
+	for _, opt := range opts {
+		opt(&v)
+	}
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods(tm.verifier.ValidMethods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(tm.now),
+	}
+	if v.issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(v.issuer))
+	}
+	if v.audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(v.audience))
+	}
 
+	token, err := jwt.ParseWithClaims(tokenString, claims, tm.verifier.Keyfunc, parserOptions...)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to parse token: %w", err)
+		return err
 	}
+	if !token.Valid {
+		return fmt.Errorf("invalid token")
+	}
+	return nil
+}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		if exp, exists := claims["exp"]; exists {
-			if expFloat, ok := exp.(float64); ok {
-				return time.Unix(int64(expFloat), 0), nil
-			}
-		}
+// hmacKey signs and verifies tokens with a single HS256 secret
+type hmacKey []byte
+
+// Sign signs claims with the secret
+func (k hmacKey) Sign(claims jwt.Claims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(k))
+}
+
+// Keyfunc returns the secret
+func (k hmacKey) Keyfunc(token *jwt.Token) (interface{}, error) {
+	return []byte(k), nil
+}
+
+// ValidMethods accepts HS256 only
+func (k hmacKey) ValidMethods() []string {
+	return []string{jwt.SigningMethodHS256.Alg()}
+}
+
+// GenerateSecureSecret generates a cryptographically secure secret of at
+// least 32 random bytes, encoded as URL-safe base64
+func GenerateSecureSecret(length int) (string, error) {
+	if length < 32 {
+		length = 32
 	}
 
-	return time.Time{}, fmt.Errorf("could not extract expiration time")
+	bytes := make([]byte, length)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
 }
@@ -0,0 +1,460 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+func newTestManager() *TokenManager {
+	return NewTokenManager(testSecret, 15*time.Minute, 7*24*time.Hour)
+}
+
+// signRaw signs arbitrary claims with secret, bypassing the token manager
+func signRaw(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestGenerateAccessToken_RoundTrip(t *testing.T) {
+	tm := newTestManager()
+	now := time.Now().Truncate(time.Second)
+	tm.now = func() time.Time { return now }
+
+	claims := &Claims{
+		UserID:        "user-1",
+		Email:         "user@example.com",
+		Name:          "Test User",
+		IsAdmin:       true,
+		IsBlacklisted: false,
+		TokenVersion:  3,
+	}
+	token, err := tm.GenerateAccessToken(claims)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken returned error: %v", err)
+	}
+
+	// The registered claims are filled in for the caller
+	if claims.ID == "" {
+		t.Error("Expected a jti to be assigned")
+	}
+	if !claims.ExpiresAt.Time.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("Expected the token to expire after the access TTL, got %v", claims.ExpiresAt)
+	}
+
+	parsed, err := tm.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken returned error: %v", err)
+	}
+	if parsed.UserID != "user-1" || parsed.Email != "user@example.com" || parsed.Name != "Test User" ||
+		!parsed.IsAdmin || parsed.IsBlacklisted || parsed.TokenVersion != 3 || parsed.Type != TokenTypeAccess {
+		t.Errorf("Unexpected claims: %+v", parsed)
+	}
+	if parsed.ID != claims.ID || parsed.Subject != "user-1" || parsed.Issuer != DefaultIssuer {
+		t.Errorf("Unexpected registered claims: %+v", parsed.RegisteredClaims)
+	}
+	if len(parsed.Audience) != 1 || parsed.Audience[0] != DefaultAudience {
+		t.Errorf("Expected audience %q, got %v", DefaultAudience, parsed.Audience)
+	}
+
+	// Each token gets its own jti
+	other := &Claims{UserID: "user-1"}
+	tm.GenerateAccessToken(other)
+	if other.ID == claims.ID {
+		t.Error("Expected each token to get a new jti")
+	}
+}
+
+func TestGenerateAccessToken_RequiresUserID(t *testing.T) {
+	if _, err := newTestManager().GenerateAccessToken(&Claims{}); err == nil {
+		t.Error("Expected an error without a user_id")
+	}
+}
+
+func TestValidateToken_Rejects(t *testing.T) {
+	tm := newTestManager()
+	valid := jwt.MapClaims{
+		"user_id": "user-1",
+		"type":    TokenTypeAccess,
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	}
+	with := func(key string, value interface{}) jwt.MapClaims {
+		claims := jwt.MapClaims{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	refresh, _ := tm.GenerateRefreshToken("user-1")
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, valid).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong secret", signRaw(t, "other-secret", valid)},
+		{"expired", signRaw(t, testSecret, with("exp", time.Now().Add(-time.Minute).Unix()))},
+		{"no expiry", signRaw(t, testSecret, with("exp", nil))},
+		{"not yet valid", signRaw(t, testSecret, with("nbf", time.Now().Add(time.Hour).Unix()))},
+		{"missing user_id", signRaw(t, testSecret, with("user_id", nil))},
+		{"wrong user_id type", signRaw(t, testSecret, with("user_id", 42))},
+		{"missing type", signRaw(t, testSecret, with("type", nil))},
+		{"refresh token", refresh},
+		{"unsigned", unsigned},
+		{"malformed", "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if claims, err := tm.ValidateToken(tt.token); err == nil {
+				t.Errorf("Expected an error, got claims %+v", claims)
+			}
+		})
+	}
+
+	if _, err := tm.ValidateToken(signRaw(t, testSecret, valid)); err != nil {
+		t.Errorf("Expected a token without iss or aud to be accepted, got %v", err)
+	}
+}
+
+func TestValidateToken_IssuerAndAudience(t *testing.T) {
+	tm := newTestManager()
+	token, _ := tm.GenerateAccessToken(&Claims{UserID: "user-1"})
+	legacy := signRaw(t, testSecret, jwt.MapClaims{
+		"user_id": "user-1",
+		"type":    TokenTypeAccess,
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	})
+
+	tests := []struct {
+		name    string
+		token   string
+		opts    []ValidateOption
+		wantErr bool
+	}{
+		{"matching issuer", token, []ValidateOption{WithIssuer(DefaultIssuer)}, false},
+		{"matching audience", token, []ValidateOption{WithAudience(DefaultAudience)}, false},
+		{"matching both", token, []ValidateOption{WithIssuer(DefaultIssuer), WithAudience(DefaultAudience)}, false},
+		{"wrong issuer", token, []ValidateOption{WithIssuer("someone-else")}, true},
+		{"wrong audience", token, []ValidateOption{WithAudience("admins")}, true},
+		{"missing issuer", legacy, []ValidateOption{WithIssuer(DefaultIssuer)}, true},
+		{"missing audience", legacy, []ValidateOption{WithAudience(DefaultAudience)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tm.ValidateToken(tt.token, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateToken error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithValidateOptions(t *testing.T) {
+	tm := newTestManager()
+	strict := tm.WithValidateOptions(WithIssuer(DefaultIssuer), WithAudience(DefaultAudience))
+	legacy := signRaw(t, testSecret, jwt.MapClaims{
+		"user_id": "user-1",
+		"type":    TokenTypeAccess,
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	})
+
+	if _, err := strict.ValidateToken(legacy); err == nil {
+		t.Error("Expected the strict manager to reject a token without iss and aud")
+	}
+	if _, err := tm.ValidateToken(legacy); err != nil {
+		t.Errorf("Expected the original manager to be unchanged, got %v", err)
+	}
+
+	token, _ := strict.GenerateAccessToken(&Claims{UserID: "user-1"})
+	if _, err := strict.ValidateToken(token); err != nil {
+		t.Errorf("Expected the strict manager to accept its own token, got %v", err)
+	}
+	if _, err := strict.ValidateToken(token, WithAudience("admins")); err == nil {
+		t.Error("Expected per-call options to override the defaults")
+	}
+}
+
+func TestValidateOptionsFromEnv(t *testing.T) {
+	tm := newTestManager()
+	legacy := signRaw(t, testSecret, jwt.MapClaims{
+		"user_id": "user-1",
+		"type":    TokenTypeAccess,
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	})
+
+	tests := []struct {
+		value        string
+		wantRejected bool
+		wantErr      bool
+	}{
+		{value: "", wantRejected: false},
+		{value: "false", wantRejected: false},
+		{value: "true", wantRejected: true},
+		{value: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("JWT_VERIFY_ISSUER_AUDIENCE", tt.value)
+			opts, err := ValidateOptionsFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateOptionsFromEnv error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			_, err = tm.WithValidateOptions(opts...).ValidateToken(legacy)
+			if rejected := err != nil; rejected != tt.wantRejected {
+				t.Errorf("Expected rejected = %v for a token without iss and aud, got %v", tt.wantRejected, err)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenTTL(t *testing.T) {
+	tm := NewTokenManager(testSecret, time.Minute, 7*24*time.Hour)
+	if got := tm.RefreshTokenTTL(false); got != 7*24*time.Hour {
+		t.Errorf("Expected the full TTL without a short TTL, got %v", got)
+	}
+
+	short := tm.WithShortRefreshTTL(12 * time.Hour)
+	if got := short.RefreshTokenTTL(false); got != 12*time.Hour {
+		t.Errorf("Expected the short TTL when not remembered, got %v", got)
+	}
+	if got := short.RefreshTokenTTL(true); got != 7*24*time.Hour {
+		t.Errorf("Expected the full TTL when remembered, got %v", got)
+	}
+	if got := tm.RefreshTokenTTL(false); got != 7*24*time.Hour {
+		t.Errorf("Expected the original manager to be unchanged, got %v", got)
+	}
+}
+
+func TestWithAccessTTL(t *testing.T) {
+	tm := newTestManager()
+	now := time.Now().Truncate(time.Second)
+	tm.now = func() time.Time { return now }
+
+	short := tm.WithAccessTTL(5 * time.Minute)
+	claims := &Claims{UserID: "user-1", ImpersonatorID: "admin-1"}
+	token, err := short.GenerateAccessToken(claims)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken returned error: %v", err)
+	}
+	if !claims.ExpiresAt.Time.Equal(now.Add(5 * time.Minute)) {
+		t.Errorf("Expected the token to expire after 5 minutes, got %v", claims.ExpiresAt)
+	}
+
+	// Tokens of either manager verify with the other
+	parsed, err := tm.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken returned error: %v", err)
+	}
+	if parsed.ImpersonatorID != "admin-1" {
+		t.Errorf("Expected impersonator_id admin-1, got %q", parsed.ImpersonatorID)
+	}
+
+	tm.GenerateAccessToken(claims)
+	if !claims.ExpiresAt.Time.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("Expected the original manager to be unchanged, got %v", claims.ExpiresAt)
+	}
+}
+
+func TestTTLsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    TTLs
+		wantErr bool
+	}{
+		{name: "defaults", want: TTLs{Access: 15 * time.Minute, Refresh: 168 * time.Hour, ShortRefresh: 12 * time.Hour}},
+		{
+			name: "overrides",
+			env:  map[string]string{"JWT_ACCESS_TOKEN_TTL": "5", "JWT_REFRESH_TOKEN_TTL": "720", "JWT_SHORT_REFRESH_TOKEN_TTL": "2"},
+			want: TTLs{Access: 5 * time.Minute, Refresh: 720 * time.Hour, ShortRefresh: 2 * time.Hour},
+		},
+		{name: "not a number", env: map[string]string{"JWT_SHORT_REFRESH_TOKEN_TTL": "12h"}, wantErr: true},
+		{name: "zero", env: map[string]string{"JWT_ACCESS_TOKEN_TTL": "0"}, wantErr: true},
+		{name: "short longer than full", env: map[string]string{"JWT_REFRESH_TOKEN_TTL": "6"}, wantErr: true},
+		{name: "access outlives refresh", env: map[string]string{"JWT_ACCESS_TOKEN_TTL": "720"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"JWT_ACCESS_TOKEN_TTL", "JWT_REFRESH_TOKEN_TTL", "JWT_SHORT_REFRESH_TOKEN_TTL"} {
+				t.Setenv(name, tt.env[name])
+			}
+			got, err := TTLsFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TTLsFromEnv error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRefreshToken_RoundTrip(t *testing.T) {
+	tm := newTestManager()
+
+	token, err := tm.GenerateRefreshToken("user-1")
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken returned error: %v", err)
+	}
+	userID, err := tm.ValidateRefreshToken(token, WithIssuer(DefaultIssuer), WithAudience(DefaultAudience))
+	if err != nil {
+		t.Fatalf("ValidateRefreshToken returned error: %v", err)
+	}
+	if userID != "user-1" {
+		t.Errorf("Expected user-1, got %q", userID)
+	}
+
+	// Refresh tokens expire after the refresh TTL
+	expiresAt, err := tm.GetTokenExpiration(token)
+	if err != nil {
+		t.Fatalf("GetTokenExpiration returned error: %v", err)
+	}
+	if d := time.Until(expiresAt); d < 7*24*time.Hour-time.Minute || d > 7*24*time.Hour {
+		t.Errorf("Expected the refresh token to expire in 7 days, got %v", d)
+	}
+
+	access, _ := tm.GenerateAccessToken(&Claims{UserID: "user-1"})
+	if _, err := tm.ValidateRefreshToken(access); err == nil {
+		t.Error("Expected an access token to be rejected as a refresh token")
+	}
+	other := NewTokenManager("other-secret", time.Minute, time.Hour)
+	if _, err := other.ValidateRefreshToken(token); err == nil {
+		t.Error("Expected a refresh token signed with another secret to be rejected")
+	}
+}
+
+func TestGetTokenExpiration(t *testing.T) {
+	tm := newTestManager()
+	now := time.Now().Truncate(time.Second)
+	tm.now = func() time.Time { return now }
+
+	token, _ := tm.GenerateAccessToken(&Claims{UserID: "user-1"})
+	expiresAt, err := tm.GetTokenExpiration(token)
+	if err != nil {
+		t.Fatalf("GetTokenExpiration returned error: %v", err)
+	}
+	if !expiresAt.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("Expected %v, got %v", now.Add(15*time.Minute), expiresAt)
+	}
+
+	if _, err := tm.GetTokenExpiration(signRaw(t, "other-secret", jwt.MapClaims{"exp": now.Add(time.Minute).Unix()})); err == nil {
+		t.Error("Expected an error for a token signed with another secret")
+	}
+}
+
+// fakeKeys signs with named HS256 secrets, verifying by kid
+type fakeKeys struct {
+	active string
+	keys   map[string]string
+}
+
+func (k fakeKeys) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = k.active
+	return token.SignedString([]byte(k.keys[k.active]))
+}
+
+func (k fakeKeys) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	secret, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return []byte(secret), nil
+}
+
+func (k fakeKeys) ValidMethods() []string {
+	return []string{"HS256"}
+}
+
+func TestNewTokenManagerWithKeys(t *testing.T) {
+	keys := fakeKeys{active: "new", keys: map[string]string{"old": "old-secret", "new": "new-secret"}}
+	signer := NewTokenManagerWithKeys(keys, keys, time.Minute, time.Hour)
+
+	token, err := signer.GenerateAccessToken(&Claims{UserID: "user-1"})
+	if err != nil {
+		t.Fatalf("GenerateAccessToken returned error: %v", err)
+	}
+	header, _ := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	if !strings.Contains(string(header), `"kid":"new"`) {
+		t.Errorf("Expected the signer to set the kid header, got %s", header)
+	}
+
+	// A verify-only manager accepts the token but cannot sign
+	verifier := NewTokenManagerWithKeys(nil, fakeKeys{keys: map[string]string{"new": "new-secret"}}, time.Minute, time.Hour)
+	if _, err := verifier.ValidateToken(token); err != nil {
+		t.Errorf("ValidateToken returned error: %v", err)
+	}
+	if _, err := verifier.GenerateAccessToken(&Claims{UserID: "user-1"}); err == nil {
+		t.Error("Expected a verify-only manager to refuse to sign")
+	}
+	if _, err := verifier.GenerateRefreshToken("user-1"); err == nil {
+		t.Error("Expected a verify-only manager to refuse to sign")
+	}
+
+	// Tokens signed with an unknown key are rejected
+	retired := NewTokenManagerWithKeys(nil, fakeKeys{keys: map[string]string{"old": "old-secret"}}, time.Minute, time.Hour)
+	if _, err := retired.ValidateToken(token); err == nil {
+		t.Error("Expected a token with an unknown kid to be rejected")
+	}
+
+	// Algorithms the verifier does not list are rejected
+	if _, err := verifier.ValidateToken(signRaw(t, "new-secret", jwt.MapClaims{"user_id": "user-1"})); err == nil {
+		t.Error("Expected a token without a kid to be rejected")
+	}
+}
+
+func TestGenerateSecureSecret(t *testing.T) {
+	tests := []struct {
+		length    int
+		wantBytes int
+	}{
+		{length: 0, wantBytes: 32},
+		{length: 16, wantBytes: 32},
+		{length: 48, wantBytes: 48},
+	}
+
+	for _, tt := range tests {
+		secret, err := GenerateSecureSecret(tt.length)
+		if err != nil {
+			t.Fatalf("GenerateSecureSecret(%d) returned error: %v", tt.length, err)
+		}
+		decoded, err := base64.RawURLEncoding.DecodeString(secret)
+		if err != nil {
+			t.Fatalf("Expected URL-safe base64, got %q: %v", secret, err)
+		}
+		if len(decoded) != tt.wantBytes {
+			t.Errorf("GenerateSecureSecret(%d) gave %d bytes, want %d", tt.length, len(decoded), tt.wantBytes)
+		}
+	}
+
+	a, _ := GenerateSecureSecret(32)
+	b, _ := GenerateSecureSecret(32)
+	if a == b {
+		t.Error("Expected secrets to differ")
+	}
+}
@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultFrom is the sender used when SMTP_FROM is not set
+const defaultFrom = "Microbank <no-reply@microbank.local>"
+
+// SMTPConfigFromEnv loads SMTP settings from the environment. It returns nil
+// when SMTP_HOST is not set, meaning email should go to the log instead.
+func SMTPConfigFromEnv() (*SMTPConfig, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil, nil
+	}
+
+	config := &SMTPConfig{
+		Host:     host,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     defaultFrom,
+		TLS:      TLSStartTLS,
+	}
+
+	if value := os.Getenv("SMTP_FROM"); value != "" {
+		config.From = value
+	}
+
+	if value := os.Getenv("SMTP_TLS"); value != "" {
+		config.TLS = TLSMode(strings.ToLower(value))
+	}
+
+	config.Port = 587
+	if config.TLS == TLSImplicit {
+		config.Port = 465
+	}
+	if value := os.Getenv("SMTP_PORT"); value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMTP_PORT %q: must be a number", value)
+		}
+		config.Port = port
+	}
+
+	return config, nil
+}
+
+// NewFromEnv creates an SMTP sender when SMTP_HOST is set, and a LogSender
+// otherwise
+func NewFromEnv() (EmailSender, error) {
+	config, err := SMTPConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return NewLogSender(nil), nil
+	}
+	return NewSMTPSender(*config)
+}
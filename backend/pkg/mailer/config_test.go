@@ -0,0 +1,43 @@
+package mailer
+
+import "testing"
+
+func TestSMTPConfigFromEnv(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	config, err := SMTPConfigFromEnv()
+	if err != nil || config != nil {
+		t.Fatalf("Expected no config without SMTP_HOST, got %+v (%v)", config, err)
+	}
+	sender, err := NewFromEnv()
+	if err != nil {
+		t.Fatalf("NewFromEnv returned error: %v", err)
+	}
+	if _, ok := sender.(*LogSender); !ok {
+		t.Errorf("Expected a LogSender without SMTP_HOST, got %T", sender)
+	}
+
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_TLS", "TLS")
+	config, err = SMTPConfigFromEnv()
+	if err != nil {
+		t.Fatalf("SMTPConfigFromEnv returned error: %v", err)
+	}
+	if config.Port != 465 || config.TLS != TLSImplicit || config.From != defaultFrom {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	t.Setenv("SMTP_PORT", "2525")
+	t.Setenv("SMTP_FROM", "Bank <bank@example.com>")
+	config, err = SMTPConfigFromEnv()
+	if err != nil {
+		t.Fatalf("SMTPConfigFromEnv returned error: %v", err)
+	}
+	if config.Port != 2525 || config.From != "Bank <bank@example.com>" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	t.Setenv("SMTP_PORT", "smtp")
+	if _, err := SMTPConfigFromEnv(); err == nil {
+		t.Error("Expected an error for a non-numeric SMTP_PORT")
+	}
+}
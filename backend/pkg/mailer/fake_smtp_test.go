@@ -0,0 +1,202 @@
+package mailer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receivedMail is what the fake SMTP server recorded for one session
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+	auth string
+	tls  bool
+}
+
+// fakeSMTPServer accepts SMTP sessions on 127.0.0.1 and records them. It
+// always offers AUTH PLAIN. In TLSStartTLS mode it offers STARTTLS, in
+// TLSImplicit mode it speaks TLS from the start, and in TLSNone mode it
+// offers no TLS at all.
+type fakeSMTPServer struct {
+	listener net.Listener
+	mode     TLSMode
+	tls      *tls.Config
+	// roots trusts the server's self-signed certificate
+	roots *x509.CertPool
+
+	mu       sync.Mutex
+	received []receivedMail
+	wg       sync.WaitGroup
+}
+
+func newFakeSMTPServer(t *testing.T, mode TLSMode) *fakeSMTPServer {
+	t.Helper()
+
+	cert, roots := newTestCertificate(t)
+	s := &fakeSMTPServer{
+		mode:  mode,
+		tls:   &tls.Config{Certificates: []tls.Certificate{cert}},
+		roots: roots,
+	}
+
+	var err error
+	if mode == TLSImplicit {
+		s.listener, err = tls.Listen("tcp", "127.0.0.1:0", s.tls)
+	} else {
+		s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	go s.serve()
+	t.Cleanup(func() {
+		s.listener.Close()
+		s.wg.Wait()
+	})
+	return s
+}
+
+// port returns the port the server listens on
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// messages returns the sessions that delivered a message
+func (s *fakeSMTPServer) messages() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.received...)
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	tp := textproto.NewConn(conn)
+	mail := receivedMail{tls: s.mode == TLSImplicit}
+	tp.PrintfLine("220 fake ESMTP")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-fake")
+			if s.mode == TLSStartTLS && !mail.tls {
+				tp.PrintfLine("250-STARTTLS")
+			}
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			if s.mode != TLSStartTLS || mail.tls {
+				tp.PrintfLine("502 not implemented")
+				continue
+			}
+			tp.PrintfLine("220 ready")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			mail.tls = true
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				tp.PrintfLine("501 bad encoding")
+				continue
+			}
+			mail.auth = string(decoded)
+			tp.PrintfLine("235 authenticated")
+		case "MAIL":
+			mail.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			mail.to = append(mail.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = string(data)
+			s.mu.Lock()
+			s.received = append(s.received, mail)
+			s.mu.Unlock()
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// newTestCertificate creates a self-signed certificate for 127.0.0.1 and a
+// pool that trusts it
+func newTestCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(parsed)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, roots
+}
@@ -0,0 +1,27 @@
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// LogSender writes emails to a logger instead of delivering them. It is
+// intended for local development where no mail server is available.
+type LogSender struct {
+	logger *log.Logger
+}
+
+// NewLogSender creates a sender that writes to logger, or to the standard
+// logger when logger is nil
+func NewLogSender(logger *log.Logger) *LogSender {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogSender{logger: logger}
+}
+
+// Send logs the email's text part
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.logger.Printf("Email to %s | %s [%s]\n%s", msg.To, msg.Subject, msg.Metadata, msg.Text)
+	return nil
+}
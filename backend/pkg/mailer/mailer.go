@@ -0,0 +1,45 @@
+// Package mailer sends transactional email. Messages are rendered from
+// embedded text and HTML templates, and delivered through SMTP or written to
+// the log when no mail server is configured. A Queue in front of either
+// keeps request handlers from waiting on delivery.
+package mailer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// EmailSender delivers email
+type EmailSender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is an email ready to send. HTML is optional; when it is set the
+// message is sent as multipart/alternative with Text as the fallback.
+type Message struct {
+	To       string
+	Subject  string
+	Text     string
+	HTML     string
+	Metadata Metadata
+}
+
+// Metadata identifies a message in logs without logging its content
+type Metadata struct {
+	UserID   uuid.UUID
+	Template string
+}
+
+// String formats the metadata for log lines
+func (m Metadata) String() string {
+	var parts []string
+	if m.Template != "" {
+		parts = append(parts, "template="+m.Template)
+	}
+	if m.UserID != uuid.Nil {
+		parts = append(parts, "user_id="+m.UserID.String())
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,112 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Default queue settings
+const (
+	DefaultQueueWorkers = 2
+	DefaultQueueSize    = 100
+)
+
+// queueSendTimeout bounds each delivery made by a queue worker
+const queueSendTimeout = time.Minute
+
+var (
+	// ErrQueueFull is returned when a message arrives while the queue is at
+	// capacity
+	ErrQueueFull = errors.New("email queue is full")
+	// ErrQueueClosed is returned when a message arrives after Close
+	ErrQueueClosed = errors.New("email queue is closed")
+)
+
+// Queue hands messages to a fixed number of workers that deliver them
+// through another sender, so callers never wait on the mail server. Failed
+// deliveries are logged with the message metadata.
+type Queue struct {
+	sender   EmailSender
+	messages chan Message
+	workers  sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewQueue starts workers goroutines delivering through sender, buffering
+// up to size messages
+func NewQueue(sender EmailSender, workers, size int) *Queue {
+	if workers <= 0 {
+		workers = DefaultQueueWorkers
+	}
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+
+	q := &Queue{
+		sender:   sender,
+		messages: make(chan Message, size),
+	}
+	for i := 0; i < workers; i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Send queues the message without waiting for delivery. It returns
+// ErrQueueFull rather than blocking when the queue is at capacity.
+func (q *Queue) Send(ctx context.Context, msg Message) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case q.messages <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting messages and waits until queued messages are
+// delivered or ctx is done
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.messages)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work delivers queued messages until the queue is closed
+func (q *Queue) work() {
+	defer q.workers.Done()
+	for msg := range q.messages {
+		ctx, cancel := context.WithTimeout(context.Background(), queueSendTimeout)
+		if err := q.sender.Send(ctx, msg); err != nil {
+			log.Printf("Failed to send email [%s]: %v", msg.Metadata, err)
+		}
+		cancel()
+	}
+}
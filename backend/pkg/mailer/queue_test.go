@@ -0,0 +1,100 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSender records messages, optionally blocking until released
+type recordingSender struct {
+	mu       sync.Mutex
+	messages []Message
+	release  chan struct{}
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg Message) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func (s *recordingSender) sent() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+func TestQueue_DeliversOnClose(t *testing.T) {
+	sender := &recordingSender{}
+	queue := NewQueue(sender, 2, 10)
+
+	for _, to := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		if err := queue.Send(context.Background(), Message{To: to}); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+	}
+
+	if err := queue.Close(context.Background()); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if sent := sender.sent(); len(sent) != 3 {
+		t.Errorf("Expected 3 delivered messages, got %d", len(sent))
+	}
+
+	if err := queue.Send(context.Background(), Message{To: "d@example.com"}); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Expected ErrQueueClosed after Close, got %v", err)
+	}
+}
+
+func TestQueue_RejectsWhenFull(t *testing.T) {
+	sender := &recordingSender{release: make(chan struct{})}
+	queue := NewQueue(sender, 1, 1)
+
+	// The worker takes the first message and blocks; the second fills the
+	// buffer
+	if err := queue.Send(context.Background(), Message{To: "a@example.com"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(queue.messages) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := queue.Send(context.Background(), Message{To: "b@example.com"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	if err := queue.Send(context.Background(), Message{To: "c@example.com"}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	close(sender.release)
+	if err := queue.Close(context.Background()); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if sent := sender.sent(); len(sent) != 2 {
+		t.Errorf("Expected 2 delivered messages, got %d", len(sent))
+	}
+}
+
+func TestQueue_CloseHonoursContext(t *testing.T) {
+	sender := &recordingSender{release: make(chan struct{})}
+	defer close(sender.release)
+	queue := NewQueue(sender, 1, 1)
+
+	if err := queue.Send(context.Background(), Message{To: "a@example.com"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := queue.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
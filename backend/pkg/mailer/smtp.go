@@ -0,0 +1,260 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLSMode selects how connections to the SMTP server are secured
+type TLSMode string
+
+const (
+	// TLSStartTLS upgrades a plain connection with STARTTLS, as on port 587
+	TLSStartTLS TLSMode = "starttls"
+	// TLSImplicit connects over TLS from the start, as on port 465
+	TLSImplicit TLSMode = "tls"
+	// TLSNone sends in the clear. Only use it with a local relay.
+	TLSNone TLSMode = "none"
+)
+
+// defaultSMTPTimeout bounds a delivery when the context has no deadline
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig describes the SMTP server to deliver through
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password authenticate with PLAIN auth; no auth is used
+	// when Username is empty
+	Username string
+	Password string
+	// From is the sender, such as "Microbank <no-reply@microbank.example>"
+	From    string
+	TLS     TLSMode
+	Timeout time.Duration
+	// TLSConfig overrides the TLS settings, for instance to trust a private
+	// CA. ServerName defaults to Host.
+	TLSConfig *tls.Config
+}
+
+// SMTPSender delivers email through an SMTP server, opening a connection
+// per message
+type SMTPSender struct {
+	config SMTPConfig
+	from   *mail.Address
+}
+
+// NewSMTPSender validates config and creates a new SMTP sender
+func NewSMTPSender(config SMTPConfig) (*SMTPSender, error) {
+	if config.Host == "" {
+		return nil, errors.New("SMTP host is required")
+	}
+	if config.Port <= 0 || config.Port > 65535 {
+		return nil, fmt.Errorf("invalid SMTP port %d", config.Port)
+	}
+	switch config.TLS {
+	case "":
+		config.TLS = TLSStartTLS
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("invalid SMTP TLS mode %q: must be starttls, tls or none", config.TLS)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultSMTPTimeout
+	}
+
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP from address %q: %w", config.From, err)
+	}
+
+	return &SMTPSender{config: config, from: from}, nil
+}
+
+// Send delivers the message. The context bounds the whole exchange with the
+// server.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	data, err := buildMessage(s.from, to, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(s.config.Timeout)
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if s.config.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(s.tlsConfig()); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("SMTP server rejected recipient: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message data: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// dial connects to the server, over TLS in implicit mode
+func (s *SMTPSender) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	if s.config.TLS == TLSImplicit {
+		return (&tls.Dialer{NetDialer: dialer, Config: s.tlsConfig()}).DialContext(ctx, "tcp", addr)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// tlsConfig returns the configured TLS settings for the server
+func (s *SMTPSender) tlsConfig() *tls.Config {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.config.TLSConfig != nil {
+		config = s.config.TLSConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = s.config.Host
+	}
+	return config
+}
+
+// buildMessage formats msg as a MIME message. Bodies are quoted-printable
+// encoded, and messages with HTML are multipart/alternative.
+func buildMessage(from, to *mail.Address, msg Message, now time.Time) ([]byte, error) {
+	messageID, err := newMessageID(from.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		header("Content-Type", "text/plain; charset=UTF-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, msg.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build message: %w", err)
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build message: %w", err)
+	}
+
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable writes content with CRLF line endings, encoded as
+// quoted-printable
+func writeQuotedPrintable(w io.Writer, content string) error {
+	content = strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return fmt.Errorf("failed to encode message body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("failed to encode message body: %w", err)
+	}
+	return nil
+}
+
+// newMessageID returns a unique Message-ID in the sender's domain
+func newMessageID(from string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate message ID: %w", err)
+	}
+	domain := "localhost"
+	if _, d, ok := strings.Cut(from, "@"); ok {
+		domain = d
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain), nil
+}
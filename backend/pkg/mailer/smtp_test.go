@@ -0,0 +1,171 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSMTPSender_RejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SMTPConfig
+	}{
+		{name: "missing host", config: SMTPConfig{Port: 587, From: "a@example.com"}},
+		{name: "invalid port", config: SMTPConfig{Host: "smtp.example.com", Port: 0, From: "a@example.com"}},
+		{name: "invalid TLS mode", config: SMTPConfig{Host: "smtp.example.com", Port: 587, From: "a@example.com", TLS: "ssl"}},
+		{name: "invalid from", config: SMTPConfig{Host: "smtp.example.com", Port: 587, From: "not an address"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSMTPSender(tt.config); err == nil {
+				t.Error("Expected NewSMTPSender to reject the config")
+			}
+		})
+	}
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     TLSMode
+		username string
+		wantAuth string
+		wantTLS  bool
+	}{
+		{name: "plain", mode: TLSNone},
+		{name: "starttls with auth", mode: TLSStartTLS, username: "mailer", wantAuth: "\x00mailer\x00secret", wantTLS: true},
+		{name: "implicit tls", mode: TLSImplicit, wantTLS: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t, tt.mode)
+			sender, err := NewSMTPSender(SMTPConfig{
+				Host:      "127.0.0.1",
+				Port:      server.port(),
+				Username:  tt.username,
+				Password:  "secret",
+				From:      "Microbank <no-reply@microbank.test>",
+				TLS:       tt.mode,
+				TLSConfig: &tls.Config{RootCAs: server.roots},
+			})
+			if err != nil {
+				t.Fatalf("NewSMTPSender returned error: %v", err)
+			}
+
+			err = sender.Send(context.Background(), Message{To: "jane@example.com", Subject: "Hello", Text: "Hi Jane"})
+			if err != nil {
+				t.Fatalf("Send returned error: %v", err)
+			}
+
+			received := server.messages()
+			if len(received) != 1 {
+				t.Fatalf("Expected 1 message, got %d", len(received))
+			}
+			got := received[0]
+			if got.from != "no-reply@microbank.test" {
+				t.Errorf("Expected sender no-reply@microbank.test, got %q", got.from)
+			}
+			if len(got.to) != 1 || got.to[0] != "jane@example.com" {
+				t.Errorf("Expected recipient jane@example.com, got %v", got.to)
+			}
+			if got.auth != tt.wantAuth {
+				t.Errorf("Expected auth %q, got %q", tt.wantAuth, got.auth)
+			}
+			if got.tls != tt.wantTLS {
+				t.Errorf("Expected TLS %v, got %v", tt.wantTLS, got.tls)
+			}
+			if !strings.Contains(got.data, "Hi Jane") {
+				t.Errorf("Expected message body in data, got %q", got.data)
+			}
+		})
+	}
+}
+
+func TestSMTPSender_Send_RequiresStartTLS(t *testing.T) {
+	server := newFakeSMTPServer(t, TLSNone)
+	sender, err := NewSMTPSender(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "mailer",
+		Password: "secret",
+		From:     "no-reply@microbank.test",
+		TLS:      TLSStartTLS,
+	})
+	if err != nil {
+		t.Fatalf("NewSMTPSender returned error: %v", err)
+	}
+
+	if err := sender.Send(context.Background(), Message{To: "jane@example.com", Subject: "Hello", Text: "Hi"}); err == nil {
+		t.Error("Expected Send to fail without a STARTTLS session")
+	}
+	if received := server.messages(); len(received) != 0 {
+		t.Errorf("Expected no messages, got %d", len(received))
+	}
+}
+
+func TestBuildMessage_Multipart(t *testing.T) {
+	from := &mail.Address{Name: "Microbank", Address: "no-reply@microbank.test"}
+	to := &mail.Address{Address: "jane@example.com"}
+	data, err := buildMessage(from, to, Message{
+		Subject: "Café statement",
+		Text:    "Hi Jane\nYour statement is ready.",
+		HTML:    "<p>Hi Jane</p>",
+	}, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("buildMessage returned error: %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "Café statement" {
+		t.Errorf("Expected decoded subject %q, got %q (%v)", "Café statement", subject, err)
+	}
+	if !strings.HasSuffix(parsed.Header.Get("Message-ID"), "@microbank.test>") {
+		t.Errorf("Expected Message-ID in sender domain, got %q", parsed.Header.Get("Message-ID"))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative, got %q (%v)", mediaType, err)
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var contents []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read part: %v", err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("Failed to read part body: %v", err)
+		}
+		contents = append(contents, string(body))
+	}
+
+	if len(contents) != 2 {
+		t.Fatalf("Expected 2 parts, got %d", len(contents))
+	}
+	if contents[0] != "Hi Jane\r\nYour statement is ready." {
+		t.Errorf("Unexpected text part %q", contents[0])
+	}
+	if contents[1] != "<p>Hi Jane</p>" {
+		t.Errorf("Unexpected HTML part %q", contents[1])
+	}
+}
@@ -0,0 +1,110 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+// Templates renders the emails embedded in this package
+var Templates = mustRenderer(embeddedTemplates, "templates")
+
+// Renderer renders messages from templates. Each template has a text file,
+// <name>.txt, starting with a "Subject: " line and a blank line before the
+// body. An optional <name>.html defines the "content" block of layout.html
+// for the HTML part.
+type Renderer struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+// NewRenderer parses the templates in fsys
+func NewRenderer(fsys fs.FS) (*Renderer, error) {
+	r := &Renderer{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+
+	textFiles, err := fs.Glob(fsys, "*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email templates: %w", err)
+	}
+	for _, file := range textFiles {
+		name := strings.TrimSuffix(file, ".txt")
+		t, err := texttemplate.New(file).Option("missingkey=error").ParseFS(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s: %w", file, err)
+		}
+		r.text[name] = t
+
+		htmlFile := name + ".html"
+		if _, err := fs.Stat(fsys, htmlFile); err != nil {
+			continue
+		}
+		h, err := htmltemplate.New("layout.html").Option("missingkey=error").ParseFS(fsys, "layout.html", htmlFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s: %w", htmlFile, err)
+		}
+		r.html[name] = h
+	}
+
+	return r, nil
+}
+
+// mustRenderer parses the templates in dir of fsys, panicking on errors
+func mustRenderer(fsys fs.FS, dir string) *Renderer {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	r, err := NewRenderer(sub)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Render renders the named template with data into a message addressed to
+// no one yet; callers set To and Metadata.UserID
+func (r *Renderer) Render(name string, data any) (Message, error) {
+	t, ok := r.text[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var text bytes.Buffer
+	if err := t.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render email template %s: %w", name, err)
+	}
+	subjectLine, body, _ := strings.Cut(text.String(), "\n")
+	subject, ok := strings.CutPrefix(subjectLine, "Subject: ")
+	if !ok {
+		return Message{}, fmt.Errorf("email template %s must start with a Subject line", name)
+	}
+
+	msg := Message{
+		Subject:  strings.TrimSpace(subject),
+		Text:     strings.TrimSpace(body),
+		Metadata: Metadata{Template: name},
+	}
+
+	if h, ok := r.html[name]; ok {
+		var html bytes.Buffer
+		if err := h.Execute(&html, struct {
+			Subject string
+			Data    any
+		}{msg.Subject, data}); err != nil {
+			return Message{}, fmt.Errorf("failed to render email template %s: %w", name+".html", err)
+		}
+		msg.HTML = html.String()
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,133 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTemplates_RenderAll(t *testing.T) {
+	data := map[string]string{
+		"Name":              "Jane <Doe>",
+		"Link":              "http://localhost:3000/reset-password?token=abc",
+		"NewEmail":          "jane@new.example.com",
+		"Time":              "2 January 2024 15:04 UTC",
+		"Location":          "Harare, Zimbabwe",
+		"IPAddress":         "203.0.113.1",
+		"Device":            "Firefox",
+		"GoalName":          "New laptop",
+		"TargetAmount":      "1200.00",
+		"Message":           "Your dispute of 49.99 was upheld.",
+		"Note":              "Card was stolen",
+		"Amount":            "250.00",
+		"AccountNumber":     "****6789",
+		"AccountHolderName": "Tendai Moyo",
+		"Reason":            "The receiving bank turned the transfer down.",
+		"TransactionType":   "withdrawal",
+		"Balance":           "80.00",
+		"Threshold":         "100.00",
+	}
+
+	for _, name := range []string{"password_reset", "password_setup", "magic_link", "email_change_verify", "email_change_notice", "login_alert", "savings_goal_completed", "dispute_updated", "export_ready", "external_transfer_failed", "large_transaction", "low_balance"} {
+		t.Run(name, func(t *testing.T) {
+			msg, err := Templates.Render(name, data)
+			if err != nil {
+				t.Fatalf("Render returned error: %v", err)
+			}
+			if msg.Subject == "" || strings.Contains(msg.Subject, "\n") {
+				t.Errorf("Expected a single-line subject, got %q", msg.Subject)
+			}
+			if msg.Metadata.Template != name {
+				t.Errorf("Expected template metadata %q, got %q", name, msg.Metadata.Template)
+			}
+			if !strings.HasPrefix(msg.Text, "Hi Jane <Doe>,") || !strings.Contains(msg.Text, data["Link"]) {
+				t.Errorf("Unexpected text body %q", msg.Text)
+			}
+			if !strings.Contains(msg.HTML, "Jane &lt;Doe&gt;") {
+				t.Errorf("Expected escaped name in HTML body, got %q", msg.HTML)
+			}
+			if !strings.Contains(msg.HTML, "<title>"+msg.Subject+"</title>") {
+				t.Errorf("Expected HTML body wrapped in the layout")
+			}
+		})
+	}
+}
+
+func TestRenderer_Render(t *testing.T) {
+	renderer, err := NewRenderer(fstest.MapFS{
+		"layout.html":   {Data: []byte(`{{template "content" .Data}}`)},
+		"greeting.txt":  {Data: []byte("Subject: Hello {{.Name}}\n\nHi {{.Name}}\n")},
+		"greeting.html": {Data: []byte(`{{define "content"}}<p>{{.Name}}</p>{{end}}`)},
+		"plain.txt":     {Data: []byte("Subject: Plain\n\nJust text")},
+		"broken.txt":    {Data: []byte("Hi {{.Name}}")},
+	})
+	if err != nil {
+		t.Fatalf("NewRenderer returned error: %v", err)
+	}
+
+	msg, err := renderer.Render("greeting", map[string]string{"Name": "Jane"})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if msg.Subject != "Hello Jane" || msg.Text != "Hi Jane" || msg.HTML != "<p>Jane</p>" {
+		t.Errorf("Unexpected message %+v", msg)
+	}
+
+	msg, err = renderer.Render("plain", nil)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if msg.HTML != "" {
+		t.Errorf("Expected no HTML part, got %q", msg.HTML)
+	}
+
+	if _, err := renderer.Render("broken", map[string]string{"Name": "Jane"}); err == nil {
+		t.Error("Expected an error for a template without a Subject line")
+	}
+	if _, err := renderer.Render("greeting", map[string]string{}); err == nil {
+		t.Error("Expected an error for missing template data")
+	}
+	if _, err := renderer.Render("missing", nil); err == nil {
+		t.Error("Expected an error for an unknown template")
+	}
+}
+
+func TestTemplates_RenderStatement(t *testing.T) {
+	data := map[string]any{
+		"Name":           "Jane <Doe>",
+		"Month":          "September 2026",
+		"Currency":       "USD",
+		"OpeningBalance": "100.00",
+		"ClosingBalance": "120.25",
+		"MoneyIn":        "50.25",
+		"MoneyOut":       "30.00",
+		"Link":           "http://localhost:3000/statements",
+		"Transactions": []map[string]string{
+			{"Date": "3 Sep", "Description": "Salary <bonus>", "Amount": "+50.25", "Balance": "150.25"},
+			{"Date": "10 Sep", "Description": "ATM", "Amount": "-30.00", "Balance": "120.25"},
+		},
+	}
+
+	msg, err := Templates.Render("statement", data)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if msg.Subject != "Your Microbank statement for September 2026" {
+		t.Errorf("Unexpected subject %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "3 Sep  Salary <bonus>  +50.25  (balance 150.25)") || !strings.Contains(msg.Text, "Closing balance: 120.25 USD") {
+		t.Errorf("Unexpected text body %q", msg.Text)
+	}
+	if !strings.Contains(msg.HTML, "Salary &lt;bonus&gt;") || strings.Contains(msg.HTML, "no transactions") {
+		t.Errorf("Expected escaped transactions in HTML body, got %q", msg.HTML)
+	}
+
+	data["Transactions"] = []map[string]string{}
+	msg, err = Templates.Render("statement", data)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if !strings.Contains(msg.Text, "There were no transactions this month.") || !strings.Contains(msg.HTML, "There were no transactions this month.") {
+		t.Errorf("Expected quiet months to say so, got %q", msg.Text)
+	}
+}
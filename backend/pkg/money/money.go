@@ -0,0 +1,244 @@
+// Package money represents amounts of money exactly, as a whole number of
+// a currency's minor units, such as cents. It parses and formats decimal
+// amounts with as many decimals as the currency has, and refuses arithmetic
+// that would overflow. It only depends on the standard library.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+// Currencies in use
+const (
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	JPY Currency = "JPY"
+)
+
+// otherDecimals lists the currencies whose amounts do not have two
+// decimals
+var otherDecimals = map[Currency]int{
+	JPY:   0,
+	"KRW": 0,
+	"VND": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+}
+
+// Errors returned for amounts that cannot be read or computed
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrOverflow         = errors.New("amount out of range")
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+)
+
+// Decimals returns how many digits follow the decimal point in the
+// currency's amounts: two unless the currency is known to differ
+func (c Currency) Decimals() int {
+	if decimals, ok := otherDecimals[c]; ok {
+		return decimals
+	}
+	return 2
+}
+
+// scale returns how many minor units make one major unit
+func (c Currency) scale() int64 {
+	scale := int64(1)
+	for i := 0; i < c.Decimals(); i++ {
+		scale *= 10
+	}
+	return scale
+}
+
+// Money is an amount of Minor units of Currency, so 1050 USD is $10.50.
+// Its JSON form is the decimal amount as a number, such as 10.50; the
+// currency is left to the surrounding object.
+type Money struct {
+	Minor    int64
+	Currency Currency
+}
+
+// New returns minor units of currency
+func New(minor int64, currency Currency) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// Parse reads a decimal amount of currency, such as "-10.50". Decimals
+// beyond the currency's are only accepted when they are zeros, so no amount
+// is rounded.
+func Parse(s string, currency Currency) (Money, error) {
+	digits := s
+	negative := false
+	if digits != "" && (digits[0] == '-' || digits[0] == '+') {
+		negative = digits[0] == '-'
+		digits = digits[1:]
+	}
+	whole, fraction, hasPoint := strings.Cut(digits, ".")
+	if whole == "" || (hasPoint && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q is not a decimal amount", ErrInvalidAmount, s)
+	}
+
+	decimals := currency.Decimals()
+	if len(fraction) > decimals {
+		if strings.Trim(fraction[decimals:], "0") != "" {
+			return Money{}, fmt.Errorf("%w: %q has more than %d decimals", ErrInvalidAmount, s, decimals)
+		}
+		fraction = fraction[:decimals]
+	}
+	fraction += strings.Repeat("0", decimals-len(fraction))
+
+	// The magnitude may reach 2^63 only when negative
+	limit := uint64(math.MaxInt64)
+	if negative {
+		limit++
+	}
+	var magnitude uint64
+	for _, digit := range whole + fraction {
+		d := uint64(digit - '0')
+		if magnitude > (limit-d)/10 {
+			return Money{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+		}
+		magnitude = magnitude*10 + d
+	}
+
+	if negative {
+		return Money{Minor: int64(-magnitude), Currency: currency}, nil
+	}
+	return Money{Minor: int64(magnitude), Currency: currency}, nil
+}
+
+// FromFloat converts a floating point amount of currency, rounding it to
+// the nearest minor unit
+func FromFloat(amount float64, currency Currency) (Money, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return Money{}, fmt.Errorf("%w: %v", ErrInvalidAmount, amount)
+	}
+	return Parse(strconv.FormatFloat(amount, 'f', currency.Decimals(), 64), currency)
+}
+
+// isDigits reports whether s only holds ASCII digits
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Float64 returns the amount in major units, for code still working with
+// floating point amounts
+func (m Money) Float64() float64 {
+	return float64(m.Minor) / float64(m.Currency.scale())
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Minor < 0
+}
+
+// Add returns m plus other, which must be in the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	sum := m.Minor + other.Minor
+	if (other.Minor > 0 && sum < m.Minor) || (other.Minor < 0 && sum > m.Minor) {
+		return Money{}, fmt.Errorf("%w: %s plus %s", ErrOverflow, m, other)
+	}
+	return Money{Minor: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m minus other, which must be in the same currency
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	difference := m.Minor - other.Minor
+	if (other.Minor > 0 && difference > m.Minor) || (other.Minor < 0 && difference < m.Minor) {
+		return Money{}, fmt.Errorf("%w: %s minus %s", ErrOverflow, m, other)
+	}
+	return Money{Minor: difference, Currency: m.Currency}, nil
+}
+
+// Mul returns m times n
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Minor == 0 || n == 0 {
+		return Money{Currency: m.Currency}, nil
+	}
+	product := m.Minor * n
+	if product/n != m.Minor || (m.Minor == -1 && n == math.MinInt64) || (n == -1 && m.Minor == math.MinInt64) {
+		return Money{}, fmt.Errorf("%w: %s times %d", ErrOverflow, m, n)
+	}
+	return Money{Minor: product, Currency: m.Currency}, nil
+}
+
+// Amount formats the amount with the currency's decimals, such as "10.50"
+// for USD and "1050" for JPY
+func (m Money) Amount() string {
+	magnitude := uint64(m.Minor)
+	sign := ""
+	if m.Minor < 0 {
+		magnitude = -magnitude
+		sign = "-"
+	}
+
+	digits := strconv.FormatUint(magnitude, 10)
+	decimals := m.Currency.Decimals()
+	if decimals == 0 {
+		return sign + digits
+	}
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	point := len(digits) - decimals
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// String formats the amount and its currency, such as "10.50 USD", for
+// messages
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Amount()
+	}
+	return m.Amount() + " " + string(m.Currency)
+}
+
+// MarshalJSON writes the amount as a JSON number with the currency's
+// decimals, such as 10.50
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Amount()), nil
+}
+
+// UnmarshalJSON reads an amount written as a JSON number or string, in the
+// Money's currency, which should be set before decoding. null leaves the
+// Money as it is.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := Parse(s, m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,226 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"testing/quick"
+)
+
+// currencies are the currencies the properties are checked in, one for
+// each number of decimals
+var currencies = []Currency{JPY, USD, "KWD"}
+
+// randomMoney generates amounts over the whole range, in one of the
+// currencies
+func randomMoney(values []reflect.Value, r *rand.Rand) {
+	for i := range values {
+		minor := int64(r.Uint64())
+		if r.Intn(2) == 0 {
+			minor %= 1000000
+		}
+		values[i] = reflect.ValueOf(Money{Minor: minor, Currency: currencies[r.Intn(len(currencies))]})
+	}
+}
+
+func TestParseFormatRoundTrip(t *testing.T) {
+	property := func(m Money) bool {
+		parsed, err := Parse(m.Amount(), m.Currency)
+		return err == nil && parsed == m
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 5000, Values: randomMoney}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	// Any amount written with the currency's decimals formats back the
+	// same, apart from leading zeros
+	property := func(whole uint32, fraction uint16, negative bool, pick uint8) bool {
+		currency := currencies[int(pick)%len(currencies)]
+		s := strconv.FormatUint(uint64(whole), 10)
+		if decimals := currency.Decimals(); decimals > 0 {
+			digits := strconv.Itoa(int(fraction) % 1000)
+			for len(digits) < 3 {
+				digits = "0" + digits
+			}
+			s += "." + digits[3-decimals:]
+		}
+		if negative && s != "0" && s != "0.00" && s != "0.000" {
+			s = "-" + s
+		}
+		m, err := Parse(s, currency)
+		return err == nil && m.Amount() == s
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	property := func(m Money) bool {
+		data, err := json.Marshal(m)
+		if err != nil || !json.Valid(data) {
+			return false
+		}
+		decoded := Money{Currency: m.Currency}
+		return json.Unmarshal(data, &decoded) == nil && decoded == m
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 5000, Values: randomMoney}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestArithmeticMatchesBigInts(t *testing.T) {
+	// Add and Sub succeed exactly when the result fits, and then agree
+	// with arbitrary precision arithmetic
+	fits := func(n *big.Int) bool { return n.IsInt64() }
+	property := func(a, b Money) bool {
+		b.Currency = a.Currency
+		x, y := big.NewInt(a.Minor), big.NewInt(b.Minor)
+
+		sum, err := a.Add(b)
+		want := new(big.Int).Add(x, y)
+		if fits(want) != (err == nil) || (err == nil && sum.Minor != want.Int64()) || (err != nil && !errors.Is(err, ErrOverflow)) {
+			return false
+		}
+
+		difference, err := a.Sub(b)
+		want = new(big.Int).Sub(x, y)
+		if fits(want) != (err == nil) || (err == nil && difference.Minor != want.Int64()) {
+			return false
+		}
+
+		product, err := a.Mul(b.Minor)
+		want = new(big.Int).Mul(x, y)
+		return fits(want) == (err == nil) && (err != nil || product.Minor == want.Int64())
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 5000, Values: randomMoney}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAmount(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: New(10000, USD), want: "100.00"},
+		{money: New(5, USD), want: "0.05"},
+		{money: New(-50, EUR), want: "-0.50"},
+		{money: New(0, GBP), want: "0.00"},
+		{money: New(1050, JPY), want: "1050"},
+		{money: New(1, "KWD"), want: "0.001"},
+		{money: New(math.MinInt64, USD), want: "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.Amount(); got != tt.want {
+			t.Errorf("Expected %+v to format as %q, got %q", tt.money, tt.want, got)
+		}
+	}
+	if got := New(10000, USD).String(); got != "100.00 USD" {
+		t.Errorf("Expected %q, got %q", "100.00 USD", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency Currency
+		want     Money
+		wantErr  error
+	}{
+		{input: "100", currency: USD, want: New(10000, USD)},
+		{input: "10.5", currency: USD, want: New(1050, USD)},
+		{input: "+0.01", currency: USD, want: New(1, USD)},
+		{input: "-3.20", currency: EUR, want: New(-320, EUR)},
+		{input: "12.500", currency: USD, want: New(1250, USD)},
+		{input: "1000", currency: JPY, want: New(1000, JPY)},
+		{input: "92233720368547758.07", currency: USD, want: New(math.MaxInt64, USD)},
+		{input: "-92233720368547758.08", currency: USD, want: New(math.MinInt64, USD)},
+		{input: "92233720368547758.08", currency: USD, wantErr: ErrOverflow},
+		{input: "10.505", currency: USD, wantErr: ErrInvalidAmount},
+		{input: "10.5", currency: JPY, wantErr: ErrInvalidAmount},
+		{input: "", currency: USD, wantErr: ErrInvalidAmount},
+		{input: "-", currency: USD, wantErr: ErrInvalidAmount},
+		{input: ".5", currency: USD, wantErr: ErrInvalidAmount},
+		{input: "5.", currency: USD, wantErr: ErrInvalidAmount},
+		{input: "1e3", currency: USD, wantErr: ErrInvalidAmount},
+		{input: "1,000", currency: USD, wantErr: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.input, tt.currency)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %q to fail with %v, got %+v, %v", tt.input, tt.wantErr, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Expected %q to parse as %+v, got %+v, %v", tt.input, tt.want, got, err)
+		}
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency Currency
+		want     Money
+		wantErr  error
+	}{
+		{amount: 0.1 + 0.2, currency: USD, want: New(30, USD)},
+		{amount: 19.99, currency: USD, want: New(1999, USD)},
+		{amount: -0.005, currency: USD, want: New(-1, USD)},
+		{amount: 1234.6, currency: JPY, want: New(1235, JPY)},
+		{amount: math.NaN(), currency: USD, wantErr: ErrInvalidAmount},
+		{amount: math.Inf(1), currency: USD, wantErr: ErrInvalidAmount},
+		{amount: 1e30, currency: USD, wantErr: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		got, err := FromFloat(tt.amount, tt.currency)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v to fail with %v, got %+v, %v", tt.amount, tt.wantErr, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Expected %v to convert to %+v, got %+v, %v", tt.amount, tt.want, got, err)
+		}
+	}
+}
+
+func TestMoney_AddCurrencyMismatch(t *testing.T) {
+	if _, err := New(100, USD).Add(New(100, EUR)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := New(100, USD).Sub(New(100, JPY)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+}
+
+func TestMoney_UnmarshalJSON(t *testing.T) {
+	var body struct {
+		Amount Money `json:"amount"`
+		Fee    Money `json:"fee"`
+	}
+	body.Amount.Currency, body.Fee.Currency = USD, USD
+	if err := json.Unmarshal([]byte(`{"amount": "12.30", "fee": 0.5}`), &body); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if body.Amount != New(1230, USD) || body.Fee != New(50, USD) {
+		t.Errorf("Expected 12.30 and 0.50, got %s and %s", body.Amount, body.Fee)
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 1.005}`), &body); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount for a fraction of a cent, got %v", err)
+	}
+}
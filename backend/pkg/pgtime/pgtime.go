@@ -0,0 +1,121 @@
+// Package pgtime migrates PostgreSQL columns typed timestamp without time
+// zone to timestamptz, so every time a service stores is an instant rather
+// than a wall-clock reading.
+package pgtime
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// convertLockKey is the advisory lock held while columns are converted, so
+// replicas starting together convert each column once
+const convertLockKey = 7263403
+
+// ConvertColumns changes every timestamp without time zone column of the
+// tables in schemas to timestamptz, reading the times already stored as
+// wall-clock times in legacyZone, and returns the columns it changed as
+// schema.table.column. Partitions follow their partitioned table, and
+// partition key columns are left to the caller, since they cannot change
+// type in place. A table's columns are changed in one rewrite, and all of
+// them in one transaction.
+func ConvertColumns(db *sql.DB, legacyZone string, schemas ...string) ([]string, error) {
+	if _, err := time.LoadLocation(legacyZone); err != nil {
+		return nil, fmt.Errorf("invalid legacy time zone %q: %w", legacyZone, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, convertLockKey); err != nil {
+		return nil, fmt.Errorf("failed to lock timestamp columns: %w", err)
+	}
+
+	tables, err := legacyColumns(tx, schemas)
+	if err != nil {
+		return nil, err
+	}
+
+	var converted []string
+	for _, table := range tables {
+		alterations := make([]string, 0, len(table.columns))
+		for _, column := range table.columns {
+			alterations = append(alterations, fmt.Sprintf("ALTER COLUMN %[1]s TYPE timestamptz USING %[1]s AT TIME ZONE %[2]s",
+				quoteIdentifier(column), quoteLiteral(legacyZone)))
+			converted = append(converted, table.schema+"."+table.name+"."+column)
+		}
+
+		query := fmt.Sprintf("ALTER TABLE %s.%s %s", quoteIdentifier(table.schema), quoteIdentifier(table.name), strings.Join(alterations, ", "))
+		if _, err := tx.Exec(query); err != nil {
+			return nil, fmt.Errorf("failed to convert timestamp columns of %s.%s: %w", table.schema, table.name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return converted, nil
+}
+
+// legacyTable is a table and its timestamp without time zone columns
+type legacyTable struct {
+	schema  string
+	name    string
+	columns []string
+}
+
+// legacyColumns returns the tables in schemas with timestamp without time
+// zone columns that can be converted, in name order
+func legacyColumns(tx *sql.Tx, schemas []string) ([]legacyTable, error) {
+	rows, err := tx.Query(`
+		SELECT n.nspname, c.relname, a.attname
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_partitioned_table p ON p.partrelid = c.oid
+		WHERE a.atttypid = 'timestamp'::regtype
+			AND a.attnum > 0 AND NOT a.attisdropped
+			AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+			AND NOT COALESCE(a.attnum = ANY (p.partattrs::int2[]), false)
+			AND n.nspname = ANY (string_to_array($1, ','))
+		ORDER BY n.nspname, c.relname, a.attnum`, strings.Join(schemas, ","))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query timestamp columns: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []legacyTable
+	for rows.Next() {
+		var schema, name, column string
+		if err := rows.Scan(&schema, &name, &column); err != nil {
+			return nil, fmt.Errorf("failed to scan timestamp column row: %w", err)
+		}
+		if last := len(tables) - 1; last >= 0 && tables[last].schema == schema && tables[last].name == name {
+			tables[last].columns = append(tables[last].columns, column)
+			continue
+		}
+		tables = append(tables, legacyTable{schema: schema, name: name, columns: []string{column}})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over timestamp column rows: %w", err)
+	}
+
+	return tables, nil
+}
+
+// quoteIdentifier quotes a table or column name for a statement
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteLiteral quotes a string constant for a statement
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,63 @@
+package pgtime
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestConvertColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+		WithArgs(convertLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE a.atttypid = 'timestamp'::regtype")).
+		WithArgs("public,archive").
+		WillReturnRows(sqlmock.NewRows([]string{"nspname", "relname", "attname"}).
+			AddRow("public", "accounts", "created_at").
+			AddRow("public", "accounts", "updated_at").
+			AddRow("public", "disputes", "resolved_at"))
+	mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE "public"."accounts" ` +
+		`ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'America/New_York', ` +
+		`ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'America/New_York'`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE "public"."disputes" ALTER COLUMN "resolved_at"`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	converted, err := ConvertColumns(db, "America/New_York", "public", "archive")
+	if err != nil {
+		t.Fatalf("ConvertColumns returned error: %v", err)
+	}
+	want := "public.accounts.created_at public.accounts.updated_at public.disputes.resolved_at"
+	if got := strings.Join(converted, " "); got != want {
+		t.Errorf("Expected %s to be converted, got %s", want, got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestConvertColumns_RejectsUnknownZone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := ConvertColumns(db, "Mars/Olympus_Mons", "public"); err == nil {
+		t.Error("Expected an unknown time zone to be rejected")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expected no statements to run, got %v", err)
+	}
+}
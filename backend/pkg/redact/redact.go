@@ -0,0 +1,114 @@
+// Package redact keeps personal data and secrets out of logs and error
+// responses. It only depends on the standard library; each service decides
+// when to apply it.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GenericDetail is shown to clients in place of an internal error
+const GenericDetail = "An internal error occurred. Quote the request_id when contacting support."
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// bearerPattern matches Authorization header values and JWTs
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*|eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+)
+
+// sensitiveParams are query parameters whose values are secrets
+var sensitiveParams = map[string]bool{
+	"token":         true,
+	"code":          true,
+	"state":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"password":      true,
+	"secret":        true,
+	"pin":           true,
+	"current_pin":   true,
+}
+
+// Email masks the local part of an email address, keeping its first
+// character and the domain: jane@example.com becomes j***@example.com
+func Email(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at <= 0 {
+		return "***"
+	}
+	return address[:1] + "***" + address[at:]
+}
+
+// Token replaces a secret with a short fingerprint, so log lines about the
+// same token can still be matched without revealing it
+func Token(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
+
+// Text masks email addresses, bearer tokens and JWTs in free text such as
+// an error message
+func Text(s string) string {
+	s = emailPattern.ReplaceAllStringFunc(s, Email)
+	return bearerPattern.ReplaceAllStringFunc(s, Token)
+}
+
+// RequestURI masks a request path and query for logging. Email addresses
+// are masked wherever they appear, and the values of secret query
+// parameters such as token and code are replaced with fingerprints.
+func RequestURI(uri string) string {
+	path, rawQuery, hasQuery := strings.Cut(uri, "?")
+	path = Text(path)
+	if !hasQuery {
+		return path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return path + "?" + Text(rawQuery)
+	}
+	for name, values := range query {
+		for i, value := range values {
+			if sensitiveParams[strings.ToLower(name)] {
+				values[i] = Token(value)
+			} else {
+				values[i] = Text(value)
+			}
+		}
+		query[name] = values
+	}
+	return path + "?" + query.Encode()
+}
+
+// PublicDetail returns what a client may be told about err. With expose
+// set, as in debug mode, it is the error text. Otherwise it is the message
+// of the first public error that err matches, or GenericDetail.
+func PublicDetail(err error, expose bool, public ...error) string {
+	if expose {
+		return err.Error()
+	}
+	for _, target := range public {
+		if errors.Is(err, target) {
+			return target.Error()
+		}
+	}
+	return GenericDetail
+}
+
+// LogRedactionFromEnv reports whether request logs should be redacted, from
+// LOG_REDACT_PII (default true)
+func LogRedactionFromEnv() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("LOG_REDACT_PII"))
+	return err != nil || enabled
+}
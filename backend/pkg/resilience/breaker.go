@@ -0,0 +1,160 @@
+package resilience
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is the cause of a DependencyError for calls refused
+// without being attempted because the dependency has been failing
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// StateClosed lets every call through
+	StateClosed State = iota
+	// StateHalfOpen lets a few probe calls through to test whether the
+	// dependency has recovered
+	StateHalfOpen
+	// StateOpen refuses every call until the open duration has passed
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// BreakerConfig describes when a circuit breaker opens and closes again
+type BreakerConfig struct {
+	// FailureThreshold is how many calls in a row must fail to open the
+	// breaker
+	FailureThreshold int
+	// OpenDuration is how long the breaker stays open before probing
+	OpenDuration time.Duration
+	// HalfOpenProbes is how many probe calls may run at once while half
+	// open, and how many must succeed to close the breaker
+	HalfOpenProbes int
+}
+
+// Breaker stops calls to a dependency that keeps failing, so callers fail
+// fast instead of waiting on it, and lets calls through again once probes
+// show it has recovered
+type Breaker struct {
+	dependency string
+	config     BreakerConfig
+	now        func() time.Time
+
+	mu             sync.Mutex
+	state          State
+	failures       int
+	openedAt       time.Time
+	probesInFlight int
+	probeSuccesses int
+	opens          int64
+}
+
+// NewBreaker creates a closed breaker for dependency and registers it for
+// WriteMetrics
+func NewBreaker(dependency string, config BreakerConfig) *Breaker {
+	b := &Breaker{dependency: dependency, config: config, now: time.Now}
+	register(b)
+	return b
+}
+
+// Allow reports whether a call may be made, returning ErrCircuitOpen if
+// not. When it may, done must be called with the outcome of the call.
+func (b *Breaker) Allow() (done func(success bool), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if b.now().Sub(b.openedAt) < b.config.OpenDuration {
+			return nil, ErrCircuitOpen
+		}
+		b.state = StateHalfOpen
+		b.probesInFlight, b.probeSuccesses = 0, 0
+	}
+
+	if b.state == StateHalfOpen {
+		if b.probesInFlight >= b.config.HalfOpenProbes {
+			return nil, ErrCircuitOpen
+		}
+		b.probesInFlight++
+		return b.recordProbe, nil
+	}
+	return b.record, nil
+}
+
+// record counts the outcome of a call made while closed
+func (b *Breaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	// A call let through before the breaker opened may finish after it
+	if b.state == StateClosed && b.failures >= b.config.FailureThreshold {
+		b.open()
+	}
+}
+
+// recordProbe counts the outcome of a probe call made while half open
+func (b *Breaker) recordProbe(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != StateHalfOpen {
+		return
+	}
+	b.probesInFlight--
+	if !success {
+		b.open()
+		return
+	}
+	b.probeSuccesses++
+	if b.probeSuccesses >= b.config.HalfOpenProbes {
+		b.state = StateClosed
+		b.failures = 0
+	}
+}
+
+// open opens the breaker. b.mu must be held.
+func (b *Breaker) open() {
+	b.state = StateOpen
+	b.openedAt = b.now()
+	b.opens++
+}
+
+// State returns the breaker's current state. An open breaker whose open
+// duration has passed is reported as half open.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.config.OpenDuration {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// snapshot returns the values WriteMetrics reports
+func (b *Breaker) snapshot() (State, int64) {
+	state := b.State()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return state, b.opens
+}
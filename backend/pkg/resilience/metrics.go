@@ -0,0 +1,62 @@
+package resilience
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// breakers holds every breaker created, by dependency, for WriteMetrics.
+// A breaker created for a dependency replaces the previous one.
+var breakers = struct {
+	mu           sync.Mutex
+	byDependency map[string]*Breaker
+}{byDependency: make(map[string]*Breaker)}
+
+func register(b *Breaker) {
+	breakers.mu.Lock()
+	defer breakers.mu.Unlock()
+	breakers.byDependency[b.dependency] = b
+}
+
+// WriteMetrics writes the state of every breaker in the Prometheus text
+// format
+func WriteMetrics(w io.Writer) {
+	breakers.mu.Lock()
+	registered := make([]*Breaker, 0, len(breakers.byDependency))
+	for _, b := range breakers.byDependency {
+		registered = append(registered, b)
+	}
+	breakers.mu.Unlock()
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i].dependency < registered[j].dependency
+	})
+
+	states := make([]State, len(registered))
+	opens := make([]int64, len(registered))
+	for i, b := range registered {
+		states[i], opens[i] = b.snapshot()
+	}
+
+	fmt.Fprintln(w, "# HELP microbank_circuit_breaker_state State of the circuit breaker for calls to a dependency: 0 closed, 1 half-open, 2 open.")
+	fmt.Fprintln(w, "# TYPE microbank_circuit_breaker_state gauge")
+	for i, b := range registered {
+		fmt.Fprintf(w, "microbank_circuit_breaker_state{dependency=%q} %d\n", b.dependency, states[i])
+	}
+
+	fmt.Fprintln(w, "# HELP microbank_circuit_breaker_opens_total Times the circuit breaker for calls to a dependency has opened.")
+	fmt.Fprintln(w, "# TYPE microbank_circuit_breaker_opens_total counter")
+	for i, b := range registered {
+		fmt.Fprintf(w, "microbank_circuit_breaker_opens_total{dependency=%q} %d\n", b.dependency, opens[i])
+	}
+}
+
+// MetricsHandler serves WriteMetrics for scraping
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteMetrics(w)
+	})
+}
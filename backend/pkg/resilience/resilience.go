@@ -0,0 +1,235 @@
+// Package resilience protects services from the services they call. A
+// Client gives each call its own timeout, retries idempotent GETs with
+// jittered exponential backoff, and stops calling a dependency that keeps
+// failing through a circuit breaker. Every failure reaches the caller as
+// ErrDependencyUnavailable, so it can choose to fail open or closed.
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrDependencyUnavailable is matched, with errors.Is, by every error a
+// Client returns when the dependency could not give an answer
+var ErrDependencyUnavailable = errors.New("dependency unavailable")
+
+// DependencyError reports a call to a dependency that failed, after any
+// retries, or was refused by its open circuit breaker
+type DependencyError struct {
+	Dependency string
+	Err        error
+}
+
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("%s unavailable: %v", e.Dependency, e.Err)
+}
+
+func (e *DependencyError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes every DependencyError match ErrDependencyUnavailable
+func (e *DependencyError) Is(target error) bool {
+	return target == ErrDependencyUnavailable
+}
+
+// RetryPolicy describes how idempotent calls are retried
+type RetryPolicy struct {
+	// MaxAttempts is how many times a call is made in all; 1 disables
+	// retries
+	MaxAttempts int
+	// BaseDelay is the longest wait before the first retry. Each retry
+	// doubles it, up to MaxDelay, and waits a random part of it.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Backoff returns how long to wait before retry number retry, counting
+// from 1. The wait is random, up to BaseDelay doubled for each earlier
+// retry and capped at MaxDelay, so callers retrying together spread out.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	ceiling := p.BaseDelay
+	for i := 1; i < retry && ceiling < p.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	if ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// Config holds the retry policy and circuit breaker settings of a Client
+type Config struct {
+	Retry   RetryPolicy
+	Breaker BreakerConfig
+}
+
+// DefaultConfig retries a GET twice, waiting up to 100ms and then 200ms,
+// and opens the breaker for 30 seconds after 5 failures in a row. One
+// successful probe closes it again.
+var DefaultConfig = Config{
+	Retry:   RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second},
+	Breaker: BreakerConfig{FailureThreshold: 5, OpenDuration: 30 * time.Second, HalfOpenProbes: 1},
+}
+
+// ConfigFromEnv reads RETRY_MAX_ATTEMPTS, RETRY_BASE_DELAY,
+// RETRY_MAX_DELAY, CIRCUIT_BREAKER_FAILURE_THRESHOLD,
+// CIRCUIT_BREAKER_OPEN_DURATION and CIRCUIT_BREAKER_HALF_OPEN_PROBES,
+// using DefaultConfig for those not set. Delays and durations are Go
+// durations such as "250ms".
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig
+
+	for _, setting := range []struct {
+		name  string
+		value *int
+	}{
+		{"RETRY_MAX_ATTEMPTS", &config.Retry.MaxAttempts},
+		{"CIRCUIT_BREAKER_FAILURE_THRESHOLD", &config.Breaker.FailureThreshold},
+		{"CIRCUIT_BREAKER_HALF_OPEN_PROBES", &config.Breaker.HalfOpenProbes},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Config{}, fmt.Errorf("invalid %s %q: must be a positive number", setting.name, value)
+			}
+			*setting.value = n
+		}
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"RETRY_BASE_DELAY", &config.Retry.BaseDelay},
+		{"RETRY_MAX_DELAY", &config.Retry.MaxDelay},
+		{"CIRCUIT_BREAKER_OPEN_DURATION", &config.Breaker.OpenDuration},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return Config{}, fmt.Errorf("invalid %s %q: must be a positive duration such as 250ms", setting.name, value)
+			}
+			*setting.value = d
+		}
+	}
+
+	if config.Retry.MaxDelay < config.Retry.BaseDelay {
+		return Config{}, errors.New("RETRY_MAX_DELAY must be at least RETRY_BASE_DELAY")
+	}
+	return config, nil
+}
+
+// Client makes HTTP calls to one dependency through its circuit breaker
+type Client struct {
+	dependency string
+	httpClient *http.Client
+	retry      RetryPolicy
+	breaker    *Breaker
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// NewClient creates a client calling dependency with httpClient
+func NewClient(dependency string, httpClient *http.Client, config Config) *Client {
+	return &Client{
+		dependency: dependency,
+		httpClient: httpClient,
+		retry:      config.Retry,
+		breaker:    NewBreaker(dependency, config.Breaker),
+		sleep:      sleep,
+	}
+}
+
+// Breaker returns the client's circuit breaker
+func (c *Client) Breaker() *Breaker {
+	return c.breaker
+}
+
+// Do sends req, giving each attempt timeout to complete, including reading
+// the response body. GET and HEAD requests are retried while the
+// dependency cannot be reached or answers with a 5xx status; other methods
+// are sent once. Responses below 500 are returned as is. Every failure is
+// a *DependencyError.
+func (c *Client) Do(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	attempts := 1
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		attempts = c.retry.MaxAttempts
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			if err := c.sleep(req.Context(), c.retry.Backoff(attempt-1)); err != nil {
+				break
+			}
+		}
+
+		done, err := c.breaker.Allow()
+		if err != nil {
+			if lastErr == nil {
+				lastErr = err
+			}
+			break
+		}
+
+		resp, err := c.attempt(req, timeout)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			done(true)
+			return resp, nil
+		}
+		done(false)
+		if err == nil {
+			resp.Body.Close()
+			err = fmt.Errorf("returned status %d", resp.StatusCode)
+		}
+		lastErr = err
+	}
+	return nil, &DependencyError{Dependency: c.dependency, Err: lastErr}
+}
+
+// attempt sends req once with its own timeout
+func (c *Client) attempt(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := c.httpClient.Do(req.Clone(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases an attempt's timeout once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// sleep waits for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,242 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient calls server with retries that do not wait
+func newTestClient(dependency string, config Config) *Client {
+	client := NewClient(dependency, &http.Client{}, config)
+	client.sleep = func(context.Context, time.Duration) error { return nil }
+	return client
+}
+
+// countingServer answers with the statuses in order, repeating the last,
+// and counts the requests it receives
+func countingServer(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if n > len(statuses) {
+			n = len(statuses)
+		}
+		w.WriteHeader(statuses[n-1])
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestClient_RetriesIdempotentGets(t *testing.T) {
+	server, calls := countingServer(t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+	client := newTestClient("retry-get", DefaultConfig)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req, time.Second)
+	if err != nil {
+		t.Fatalf("Expected the third attempt to succeed, got %v", err)
+	}
+	resp.Body.Close()
+	if *calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", *calls)
+	}
+}
+
+func TestClient_DoesNotRetryOtherMethods(t *testing.T) {
+	server, calls := countingServer(t, http.StatusServiceUnavailable, http.StatusOK)
+	client := newTestClient("no-retry-post", DefaultConfig)
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("{}"))
+	_, err := client.Do(req, time.Second)
+	if !errors.Is(err, ErrDependencyUnavailable) {
+		t.Fatalf("Expected ErrDependencyUnavailable, got %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("Expected a POST to be sent once, got %d attempts", *calls)
+	}
+}
+
+func TestClient_ReturnsClientErrors(t *testing.T) {
+	server, calls := countingServer(t, http.StatusUnauthorized)
+	client := newTestClient("client-error", DefaultConfig)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req, time.Second)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected the 401 to be returned, got %v", err)
+	}
+	resp.Body.Close()
+	if *calls != 1 || client.Breaker().State() != StateClosed {
+		t.Errorf("Expected one attempt and a closed breaker, got %d attempts and %s", *calls, client.Breaker().State())
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+	client := newTestClient("slow", Config{Retry: RetryPolicy{MaxAttempts: 1}, Breaker: DefaultConfig.Breaker})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	start := time.Now()
+	_, err := client.Do(req, 50*time.Millisecond)
+	if !errors.Is(err, ErrDependencyUnavailable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a timed out DependencyError, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the call to give up after its timeout, took %s", elapsed)
+	}
+}
+
+func TestClient_OpenBreakerFailsFast(t *testing.T) {
+	server, calls := countingServer(t, http.StatusInternalServerError)
+	config := Config{
+		Retry:   RetryPolicy{MaxAttempts: 1},
+		Breaker: BreakerConfig{FailureThreshold: 2, OpenDuration: time.Minute, HalfOpenProbes: 1},
+	}
+	client := newTestClient("failing", config)
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		if _, err := client.Do(req, time.Second); !errors.Is(err, ErrDependencyUnavailable) {
+			t.Fatalf("call %d: expected ErrDependencyUnavailable, got %v", i+1, err)
+		}
+	}
+
+	var depErr *DependencyError
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req, time.Second)
+	if !errors.As(err, &depErr) || depErr.Dependency != "failing" || !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the open breaker to refuse the call, got %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("Expected the dependency to be called until the breaker opened, got %d calls", *calls)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker("breaker-test", BreakerConfig{FailureThreshold: 3, OpenDuration: 10 * time.Second, HalfOpenProbes: 2})
+	b.now = func() time.Time { return now }
+
+	call := func(success bool) error {
+		done, err := b.Allow()
+		if err == nil {
+			done(success)
+		}
+		return err
+	}
+
+	// A success resets the count of failures in a row
+	call(false)
+	call(false)
+	call(true)
+	call(false)
+	call(false)
+	if b.State() != StateClosed {
+		t.Fatalf("Expected the breaker to stay closed, got %s", b.State())
+	}
+	call(false)
+	if b.State() != StateOpen || call(true) != ErrCircuitOpen {
+		t.Fatalf("Expected the breaker to open after 3 failures in a row, got %s", b.State())
+	}
+
+	// After the open duration, probes are let through up to HalfOpenProbes
+	now = now.Add(10 * time.Second)
+	first, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Expected a probe to be allowed, got %v", err)
+	}
+	second, _ := b.Allow()
+	if _, err := b.Allow(); err != ErrCircuitOpen {
+		t.Errorf("Expected only 2 probes at once, got %v", err)
+	}
+
+	// A failed probe opens the breaker again
+	first(false)
+	second(true)
+	if b.State() != StateOpen {
+		t.Fatalf("Expected a failed probe to reopen the breaker, got %s", b.State())
+	}
+
+	// Enough successful probes close it
+	now = now.Add(10 * time.Second)
+	call(true)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("Expected the breaker to wait for a second probe, got %s", b.State())
+	}
+	call(true)
+	if b.State() != StateClosed {
+		t.Errorf("Expected successful probes to close the breaker, got %s", b.State())
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	ceilings := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+
+	for i, ceiling := range ceilings {
+		for j := 0; j < 50; j++ {
+			if d := policy.Backoff(i + 1); d < 0 || d > ceiling {
+				t.Fatalf("retry %d: expected a wait up to %s, got %s", i+1, ceiling, d)
+			}
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	for _, name := range []string{"RETRY_MAX_ATTEMPTS", "RETRY_BASE_DELAY", "RETRY_MAX_DELAY", "CIRCUIT_BREAKER_FAILURE_THRESHOLD", "CIRCUIT_BREAKER_OPEN_DURATION", "CIRCUIT_BREAKER_HALF_OPEN_PROBES"} {
+		t.Setenv(name, "")
+	}
+	if config, err := ConfigFromEnv(); err != nil || config != DefaultConfig {
+		t.Fatalf("Expected the defaults, got %+v %v", config, err)
+	}
+
+	t.Setenv("RETRY_MAX_ATTEMPTS", "1")
+	t.Setenv("CIRCUIT_BREAKER_OPEN_DURATION", "1m")
+	config, err := ConfigFromEnv()
+	if err != nil || config.Retry.MaxAttempts != 1 || config.Breaker.OpenDuration != time.Minute {
+		t.Errorf("Expected the configured values, got %+v %v", config, err)
+	}
+
+	t.Setenv("CIRCUIT_BREAKER_FAILURE_THRESHOLD", "0")
+	if _, err := ConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "CIRCUIT_BREAKER_FAILURE_THRESHOLD") {
+		t.Errorf("Expected a zero threshold to be rejected, got %v", err)
+	}
+	t.Setenv("CIRCUIT_BREAKER_FAILURE_THRESHOLD", "")
+
+	t.Setenv("RETRY_MAX_DELAY", "10ms")
+	if _, err := ConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "RETRY_MAX_DELAY must be at least") {
+		t.Errorf("Expected a max delay below the base delay to be rejected, got %v", err)
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	b := NewBreaker("metrics-test", BreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute, HalfOpenProbes: 1})
+	done, _ := b.Allow()
+	done(false)
+
+	w := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	for _, want := range []string{
+		"# TYPE microbank_circuit_breaker_state gauge",
+		`microbank_circuit_breaker_state{dependency="metrics-test"} 2`,
+		`microbank_circuit_breaker_opens_total{dependency="metrics-test"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("Expected the metrics to contain %q, got:\n%s", want, w.Body.String())
+		}
+	}
+}
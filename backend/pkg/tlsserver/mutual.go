@@ -0,0 +1,199 @@
+package tlsserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors reported when a peer's client certificate is refused. They appear
+// in the server's TLS handshake error log.
+var (
+	ErrNoClientCertificate        = errors.New("mTLS: no client certificate presented")
+	ErrUntrustedClientCertificate = errors.New("mTLS: untrusted client certificate")
+	ErrPeerNotAllowed             = errors.New("mTLS: client certificate is not for an allowed service")
+)
+
+// MutualConfig holds the mutual TLS settings services use between
+// themselves
+type MutualConfig struct {
+	// CertFile and KeyFile hold this service's certificate, presented both
+	// on its internal listener and when it calls other services
+	CertFile string
+	KeyFile  string
+	// CAFile holds the CA bundle peer certificates must chain to
+	CAFile string
+	// AllowedPeers lists the service names, matched against a certificate's
+	// DNS SANs or common name, allowed to call the internal listener
+	AllowedPeers []string
+	// InternalPort is the port internal routes are served on
+	InternalPort int
+}
+
+// MutualConfigFromEnv loads mutual TLS settings from MTLS_CERT_FILE,
+// MTLS_KEY_FILE, MTLS_CA_FILE, MTLS_ALLOWED_PEERS (comma-separated,
+// default defaultPeers) and INTERNAL_PORT. It returns nil when none of the
+// files is set, meaning mutual TLS is off.
+func MutualConfigFromEnv(defaultPeers ...string) (*MutualConfig, error) {
+	config := &MutualConfig{
+		CertFile:     os.Getenv("MTLS_CERT_FILE"),
+		KeyFile:      os.Getenv("MTLS_KEY_FILE"),
+		CAFile:       os.Getenv("MTLS_CA_FILE"),
+		AllowedPeers: defaultPeers,
+	}
+	if config.CertFile == "" && config.KeyFile == "" && config.CAFile == "" {
+		return nil, nil
+	}
+
+	var missing []string
+	for _, setting := range []struct{ name, value string }{
+		{"MTLS_CERT_FILE", config.CertFile},
+		{"MTLS_KEY_FILE", config.KeyFile},
+		{"MTLS_CA_FILE", config.CAFile},
+		{"INTERNAL_PORT", os.Getenv("INTERNAL_PORT")},
+	} {
+		if setting.value == "" {
+			missing = append(missing, setting.name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("mutual TLS requires %s", strings.Join(missing, ", "))
+	}
+
+	value := os.Getenv("INTERNAL_PORT")
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid INTERNAL_PORT %q: must be a port between 1 and 65535", value)
+	}
+	config.InternalPort = port
+
+	if value := os.Getenv("MTLS_ALLOWED_PEERS"); value != "" {
+		config.AllowedPeers = nil
+		for _, peer := range strings.Split(value, ",") {
+			if peer = strings.TrimSpace(peer); peer != "" {
+				config.AllowedPeers = append(config.AllowedPeers, peer)
+			}
+		}
+	}
+	if len(config.AllowedPeers) == 0 {
+		return nil, errors.New("MTLS_ALLOWED_PEERS must name at least one service")
+	}
+	return config, nil
+}
+
+// Mutual authenticates services to each other with certificates issued by
+// a shared CA
+type Mutual struct {
+	certs   *CertReloader
+	roots   *x509.CertPool
+	allowed map[string]bool
+}
+
+// NewMutual loads the certificate, key and CA bundle in config. Like
+// NewCertReloader, it fails if they cannot be used.
+func NewMutual(config *MutualConfig) (*Mutual, error) {
+	certs, err := NewCertReloader(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pem, err := os.ReadFile(config.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mTLS CA bundle: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("mTLS CA bundle %s holds no PEM certificates", config.CAFile)
+	}
+
+	allowed := make(map[string]bool, len(config.AllowedPeers))
+	for _, peer := range config.AllowedPeers {
+		allowed[peer] = true
+	}
+	return &Mutual{certs: certs, roots: roots, allowed: allowed}, nil
+}
+
+// Certificates returns the reloader holding this service's certificate
+func (m *Mutual) Certificates() *CertReloader {
+	return m.certs
+}
+
+// ServerConfig returns the TLS configuration of the internal listener. It
+// accepts only clients presenting a certificate that chains to the CA and
+// names an allowed service.
+func (m *Mutual) ServerConfig() *tls.Config {
+	config := ServerConfig(m.certs)
+	// Verification is done in verifyClient so each refusal is reported with
+	// its own error rather than the handshake's generic one
+	config.ClientAuth = tls.RequestClientCert
+	config.VerifyPeerCertificate = m.verifyClient
+	return config
+}
+
+// NewServer returns a server on port that serves handler only to allowed
+// services
+func (m *Mutual) NewServer(port int, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + strconv.Itoa(port),
+		Handler:           handler,
+		TLSConfig:         m.ServerConfig(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// verifyClient checks the certificate chain a client presented
+func (m *Mutual) verifyClient(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return ErrNoClientCertificate
+	}
+
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrUntrustedClientCertificate, err)
+		}
+		certs[i] = cert
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         m.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return fmt.Errorf("%w (subject %q): %w", ErrUntrustedClientCertificate, certs[0].Subject.CommonName, err)
+	}
+
+	for _, name := range append([]string{certs[0].Subject.CommonName}, certs[0].DNSNames...) {
+		if m.allowed[name] {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w (subject %q, DNS names %v)", ErrPeerNotAllowed, certs[0].Subject.CommonName, certs[0].DNSNames)
+}
+
+// ClientTransport returns a transport for calling other services' internal
+// listeners. It presents this service's certificate and only trusts
+// servers whose certificate chains to the CA.
+func (m *Mutual) ClientTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    m.roots,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return m.certs.GetCertificate(nil)
+		},
+	}
+	transport.TLSHandshakeTimeout = 5 * time.Second
+	return transport
+}
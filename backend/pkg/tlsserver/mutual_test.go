@@ -0,0 +1,247 @@
+package tlsserver
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testCA issues certificates for services in tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newTestCA(t *testing.T, dir string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "microbank test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	file := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+// issue writes a certificate for service, usable as both a client and a
+// server certificate for localhost, and its key to dir
+func (ca *testCA) issue(t *testing.T, dir, service string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: service},
+		DNSNames:     []string{service, "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, service+".pem"), filepath.Join(dir, service+"-key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// newTestMutual creates the mutual TLS setup of service, allowing peers
+func newTestMutual(t *testing.T, ca *testCA, dir, service string, peers ...string) *Mutual {
+	t.Helper()
+	certFile, keyFile := ca.issue(t, dir, service)
+	mutual, err := NewMutual(&MutualConfig{CertFile: certFile, KeyFile: keyFile, CAFile: ca.file, AllowedPeers: peers})
+	if err != nil {
+		t.Fatalf("NewMutual failed: %v", err)
+	}
+	return mutual
+}
+
+// syncBuffer collects log output written from server goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestMutualConfigFromEnv(t *testing.T) {
+	set := func(cert, key, ca, port, peers string) {
+		t.Setenv("MTLS_CERT_FILE", cert)
+		t.Setenv("MTLS_KEY_FILE", key)
+		t.Setenv("MTLS_CA_FILE", ca)
+		t.Setenv("INTERNAL_PORT", port)
+		t.Setenv("MTLS_ALLOWED_PEERS", peers)
+	}
+
+	set("", "", "", "", "")
+	if config, err := MutualConfigFromEnv("client-service"); config != nil || err != nil {
+		t.Errorf("Expected mutual TLS to be off, got %+v %v", config, err)
+	}
+
+	set("cert.pem", "key.pem", "ca.pem", "9443", "")
+	config, err := MutualConfigFromEnv("client-service")
+	if err != nil {
+		t.Fatalf("MutualConfigFromEnv failed: %v", err)
+	}
+	if config.InternalPort != 9443 || strings.Join(config.AllowedPeers, ",") != "client-service" {
+		t.Errorf("Expected port 9443 allowing client-service, got %+v", config)
+	}
+
+	set("cert.pem", "key.pem", "ca.pem", "9443", " gateway, client-service ")
+	if config, _ := MutualConfigFromEnv("client-service"); strings.Join(config.AllowedPeers, ",") != "gateway,client-service" {
+		t.Errorf("Expected the configured peers, got %v", config.AllowedPeers)
+	}
+
+	set("cert.pem", "", "ca.pem", "", "")
+	if _, err := MutualConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "MTLS_KEY_FILE, INTERNAL_PORT") {
+		t.Errorf("Expected the missing settings to be named, got %v", err)
+	}
+}
+
+func TestNewMutual_RejectsBadCABundle(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	certFile, keyFile := ca.issue(t, dir, "banking-service")
+	bundle := filepath.Join(dir, "empty.pem")
+	os.WriteFile(bundle, []byte("no certificates here"), 0o600)
+
+	if _, err := NewMutual(&MutualConfig{CertFile: certFile, KeyFile: keyFile, CAFile: bundle}); err == nil {
+		t.Error("Expected a CA bundle without certificates to be rejected")
+	}
+}
+
+func TestMutual(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	banking := newTestMutual(t, ca, dir, "banking-service", "client-service")
+
+	var handshakeErrors syncBuffer
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = banking.ServerConfig()
+	server.Config.ErrorLog = log.New(&handshakeErrors, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	call := func(transport *http.Transport) error {
+		client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+		resp, err := client.Get(strings.Replace(server.URL, "127.0.0.1", "localhost", 1))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+	// waitForLog waits for the server to log a refused handshake
+	waitForLog := func(want error) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !strings.Contains(handshakeErrors.String(), want.Error()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the server to log %q, got %q", want, handshakeErrors.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	client := newTestMutual(t, ca, dir, "client-service", "banking-service")
+	if err := call(client.ClientTransport()); err != nil {
+		t.Fatalf("Expected the client-service to be accepted, got %v", err)
+	}
+
+	// A trusted transport without a client certificate
+	noCert := http.DefaultTransport.(*http.Transport).Clone()
+	noCert.TLSClientConfig = &tls.Config{RootCAs: client.roots}
+	if err := call(noCert); err == nil {
+		t.Error("Expected a caller without a certificate to be refused")
+	}
+	waitForLog(ErrNoClientCertificate)
+
+	// A certificate from another CA
+	otherDir := t.TempDir()
+	other := newTestMutual(t, newTestCA(t, otherDir), otherDir, "client-service")
+	untrusted := other.ClientTransport()
+	untrusted.TLSClientConfig.RootCAs = client.roots
+	if err := call(untrusted); err == nil {
+		t.Error("Expected a certificate from another CA to be refused")
+	}
+	waitForLog(ErrUntrustedClientCertificate)
+
+	// A trusted certificate for a service that is not allowed
+	gateway := newTestMutual(t, ca, dir, "gateway")
+	if err := call(gateway.ClientTransport()); err == nil {
+		t.Error("Expected a service that is not allowed to be refused")
+	}
+	waitForLog(ErrPeerNotAllowed)
+}
+
+func TestMutual_VerifyClientErrors(t *testing.T) {
+	dir := t.TempDir()
+	banking := newTestMutual(t, newTestCA(t, dir), dir, "banking-service", "client-service")
+
+	if err := banking.verifyClient(nil, nil); !errors.Is(err, ErrNoClientCertificate) {
+		t.Errorf("Expected ErrNoClientCertificate, got %v", err)
+	}
+	if err := banking.verifyClient([][]byte{[]byte("garbage")}, nil); !errors.Is(err, ErrUntrustedClientCertificate) {
+		t.Errorf("Expected ErrUntrustedClientCertificate, got %v", err)
+	}
+}
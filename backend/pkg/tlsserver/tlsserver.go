@@ -0,0 +1,248 @@
+// Package tlsserver lets the services terminate TLS themselves, for
+// deployments without a proxy in front of them. Certificates are reloaded
+// when their files change or the process receives SIGHUP, so renewals take
+// effect without a restart.
+package tlsserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultReloadInterval is how often the certificate files are checked for
+// changes
+const DefaultReloadInterval = 30 * time.Second
+
+// Config holds the TLS settings of a server
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// RedirectPort, when not zero, is a plain HTTP port that redirects
+	// every request to HTTPS
+	RedirectPort int
+}
+
+// ConfigFromEnv loads TLS settings from TLS_CERT_FILE and TLS_KEY_FILE,
+// both PEM files, and TLS_REDIRECT_PORT. It returns nil when neither file
+// is set, meaning the server should serve plain HTTP.
+func ConfigFromEnv() (*Config, error) {
+	config := &Config{
+		CertFile: os.Getenv("TLS_CERT_FILE"),
+		KeyFile:  os.Getenv("TLS_KEY_FILE"),
+	}
+	redirect := os.Getenv("TLS_REDIRECT_PORT")
+
+	switch {
+	case config.CertFile == "" && config.KeyFile == "":
+		if redirect != "" {
+			return nil, errors.New("TLS_REDIRECT_PORT requires TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		return nil, nil
+	case config.CertFile == "":
+		return nil, errors.New("TLS_CERT_FILE is required with TLS_KEY_FILE")
+	case config.KeyFile == "":
+		return nil, errors.New("TLS_KEY_FILE is required with TLS_CERT_FILE")
+	}
+
+	if redirect != "" {
+		port, err := strconv.Atoi(redirect)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid TLS_REDIRECT_PORT %q: must be a port between 1 and 65535", redirect)
+		}
+		config.RedirectPort = port
+	}
+	return config, nil
+}
+
+// CertReloader holds the certificate a server presents and replaces it
+// when the files it was loaded from change
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+}
+
+// NewCertReloader loads the certificate chain in certFile and its private
+// key in keyFile. It fails if either cannot be read, they do not match or
+// the certificate has expired.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the certificate files again. On failure the current
+// certificate is kept.
+func (r *CertReloader) Reload() error {
+	modTimes, err := r.fileModTimes()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate %s and key %s: %w", r.certFile, r.keyFile, err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse TLS certificate %s: %w", r.certFile, err)
+	}
+	if time.Now().After(leaf.NotAfter) {
+		return fmt.Errorf("TLS certificate %s expired on %s", r.certFile, leaf.NotAfter.Format(time.RFC3339))
+	}
+	cert.Leaf = leaf
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTimes = modTimes
+	r.mu.Unlock()
+	return nil
+}
+
+// GetCertificate returns the current certificate. It is used as
+// tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Watch reloads the certificate when the process receives SIGHUP or, checked
+// every interval, either file has changed, until ctx is cancelled
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			r.reloadAndLog("SIGHUP")
+		case <-ticker.C:
+			if r.changed() {
+				r.reloadAndLog("file change")
+			}
+		}
+	}
+}
+
+// reloadAndLog reloads the certificate, logging the outcome
+func (r *CertReloader) reloadAndLog(reason string) {
+	if err := r.Reload(); err != nil {
+		log.Printf("TLS certificate reload on %s failed, keeping the current certificate: %v", reason, err)
+		return
+	}
+	r.mu.RLock()
+	notAfter := r.cert.Leaf.NotAfter
+	r.mu.RUnlock()
+	log.Printf("Reloaded TLS certificate on %s (valid until %s)", reason, notAfter.Format(time.RFC3339))
+}
+
+// changed reports whether either file has been modified since the
+// certificate was last loaded
+func (r *CertReloader) changed() bool {
+	modTimes, err := r.fileModTimes()
+	if err != nil {
+		// A file being replaced may briefly be missing; check again later
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return modTimes != r.modTimes
+}
+
+// fileModTimes returns when the certificate and key files were last modified
+func (r *CertReloader) fileModTimes() ([2]time.Time, error) {
+	var modTimes [2]time.Time
+	for i, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return modTimes, fmt.Errorf("failed to read TLS file: %w", err)
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// ServerConfig returns the TLS configuration servers use: TLS 1.2 or later,
+// with only forward-secret AEAD cipher suites for TLS 1.2, and certificates
+// taken from certs
+func ServerConfig(certs *CertReloader) *tls.Config {
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		GetCertificate: certs.GetCertificate,
+	}
+}
+
+// ListenAndServe serves server over TLS with certificates from certs, or
+// over plain HTTP when certs is nil
+func ListenAndServe(server *http.Server, certs *CertReloader) error {
+	if certs == nil {
+		return server.ListenAndServe()
+	}
+	server.TLSConfig = ServerConfig(certs)
+	return server.ListenAndServeTLS("", "")
+}
+
+// NewRedirectServer returns a plain HTTP server on port that redirects
+// every request to HTTPS on httpsPort
+func NewRedirectServer(port, httpsPort int) *http.Server {
+	return &http.Server{
+		Addr:              ":" + strconv.Itoa(port),
+		Handler:           RedirectHandler(httpsPort),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// RedirectHandler redirects every request to the same host and path over
+// HTTPS on httpsPort
+func RedirectHandler(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		target := "https://" + host + req.URL.RequestURI()
+		// 308 keeps the method and body of API calls
+		http.Redirect(w, req, target, http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,204 @@
+package tlsserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate for commonName, valid until
+// notAfter, and its key to dir, returning their paths
+func writeCert(t *testing.T, dir, commonName string, notAfter time.Time) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    notAfter.Add(-48 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// commonName returns the subject of the certificate certs currently holds
+func commonName(t *testing.T, certs *CertReloader) string {
+	t.Helper()
+	cert, err := certs.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert.Leaf.Subject.CommonName
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		cert     string
+		key      string
+		redirect string
+		want     *Config
+		wantErr  bool
+	}{
+		{name: "disabled"},
+		{name: "enabled", cert: "cert.pem", key: "key.pem", want: &Config{CertFile: "cert.pem", KeyFile: "key.pem"}},
+		{name: "with redirect", cert: "cert.pem", key: "key.pem", redirect: "8080", want: &Config{CertFile: "cert.pem", KeyFile: "key.pem", RedirectPort: 8080}},
+		{name: "key missing", cert: "cert.pem", wantErr: true},
+		{name: "cert missing", key: "key.pem", wantErr: true},
+		{name: "redirect without TLS", redirect: "8080", wantErr: true},
+		{name: "invalid redirect port", cert: "cert.pem", key: "key.pem", redirect: "http", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TLS_CERT_FILE", tt.cert)
+			t.Setenv("TLS_KEY_FILE", tt.key)
+			t.Setenv("TLS_REDIRECT_PORT", tt.redirect)
+
+			got, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewCertReloader_RejectsBadPairs(t *testing.T) {
+	certFile, _ := writeCert(t, t.TempDir(), "first", time.Now().Add(time.Hour))
+	_, otherKey := writeCert(t, t.TempDir(), "second", time.Now().Add(time.Hour))
+	if _, err := NewCertReloader(certFile, otherKey); err == nil {
+		t.Error("Expected a certificate with another certificate's key to be rejected")
+	}
+
+	expiredCert, expiredKey := writeCert(t, t.TempDir(), "expired", time.Now().Add(-time.Hour))
+	if _, err := NewCertReloader(expiredCert, expiredKey); err == nil {
+		t.Error("Expected an expired certificate to be rejected")
+	}
+
+	if _, err := NewCertReloader(filepath.Join(t.TempDir(), "missing.pem"), otherKey); err == nil {
+		t.Error("Expected a missing certificate file to be rejected")
+	}
+}
+
+func TestCertReloader_Watch(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "first", time.Now().Add(time.Hour))
+	certs, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertReloader failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go certs.Watch(ctx, 10*time.Millisecond)
+
+	// A broken renewal keeps the current certificate
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := commonName(t, certs); got != "first" {
+		t.Fatalf("Expected the first certificate to be kept, got %q", got)
+	}
+
+	// Make sure the renewed files get a new modification time
+	time.Sleep(10 * time.Millisecond)
+	writeCert(t, dir, "renewed", time.Now().Add(time.Hour))
+	deadline := time.Now().Add(2 * time.Second)
+	for commonName(t, certs) != "renewed" {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the renewed certificate to be loaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerConfig(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "server", time.Now().Add(time.Hour))
+	certs, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertReloader failed: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = ServerConfig(certs)
+	server.StartTLS()
+	defer server.Close()
+
+	dial := func(version uint16) error {
+		conn, err := tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{
+			InsecureSkipVerify: true,
+			MinVersion:         version,
+			MaxVersion:         version,
+		})
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+	if err := dial(tls.VersionTLS12); err != nil {
+		t.Errorf("Expected TLS 1.2 to be accepted, got %v", err)
+	}
+	if err := dial(tls.VersionTLS11); err == nil {
+		t.Error("Expected TLS 1.1 to be rejected")
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		host string
+		port int
+		want string
+	}{
+		{host: "api.example.com", port: 443, want: "https://api.example.com/api/v1/account/balance?x=1"},
+		{host: "localhost:8080", port: 8443, want: "https://localhost:8443/api/v1/account/balance?x=1"},
+		{host: "[::1]:8080", port: 443, want: "https://[::1]/api/v1/account/balance?x=1"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/account/balance?x=1", nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		RedirectHandler(tt.port).ServeHTTP(w, req)
+
+		if w.Code != http.StatusPermanentRedirect || w.Header().Get("Location") != tt.want {
+			t.Errorf("Expected 308 to %s, got %d to %s", tt.want, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
@@ -1,26 +1,61 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+	// Embedded, so users' time zones load on hosts without a zone database
+	_ "time/tzdata"
 
+	"microbank/banking-service/internal/config"
 	"microbank/banking-service/internal/handlers"
 	"microbank/banking-service/internal/middleware"
 	"microbank/banking-service/internal/repository"
 	"microbank/banking-service/internal/services"
+	"microbank/pkg/authmw"
+	"microbank/pkg/events"
+	sharedjwt "microbank/pkg/jwt"
+	"microbank/pkg/redact"
+	"microbank/pkg/resilience"
+	"microbank/pkg/tlsserver"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 )
 
 func main() {
+	// Work in UTC whatever the host's zone, so every time is written and
+	// returned in UTC
+	time.Local = time.UTC
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using system environment variables")
 	}
 
+	// Load and check the configuration before anything is started
+	validateOnly := flag.Bool("validate-config", false, "check the configuration and exit with status 0 if it is valid or 1 if not")
+	flag.Parse()
+	cfg, err := config.Load()
+	if *validateOnly {
+		exitAfterValidation(err)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize database connection
-	db, err := repository.NewPostgresDB()
+	db, err := repository.NewPostgresDB(cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -29,26 +64,211 @@ func main() {
 	// Initialize repositories
 	accountRepo := repository.NewAccountRepository(db)
 	transactionRepo := repository.NewTransactionRepository(db)
+	goalRepo := repository.NewSavingsGoalRepository(db)
+	beneficiaryRepo := repository.NewBeneficiaryRepository(db)
+	externalTransferRepo := repository.NewExternalTransferRepository(db)
+	chequeRepo := repository.NewChequeRepository(db)
+	promotionRepo := repository.NewPromotionRepository(db)
+	accountMemberRepo := repository.NewAccountMemberRepository(db)
+	transactionPINRepo := repository.NewTransactionPINRepository(db)
+	transactionConfirmationRepo := repository.NewTransactionConfirmationRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	disputeRepo := repository.NewDisputeRepository(db)
+	maintenanceRepo := repository.NewMaintenanceRepository(db)
+	depositJobRepo := repository.NewDepositJobRepository(db)
+	transactionPartitionRepo := repository.NewTransactionPartitionRepository(db)
+	suspiciousActivityRepo := repository.NewSuspiciousActivityRepository(db)
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+
+	// "verify-integrity" checks the transaction integrity chains of the
+	// accounts given by ID and exits instead of serving
+	if flag.Arg(0) == "verify-integrity" {
+		verifyIntegrity(services.NewIntegrityService(transactionRepo), flag.Args()[1:])
+		return
+	}
+
+	// Initialize client-service client
+	clientServiceClient := services.NewHTTPClientServiceClient(cfg.ClientServiceURL, cfg.Resilience)
+
+	// Initialize access token verification against the client-service keys
+	jwksVerifier := cfg.JWKSVerifier
+	if jwksVerifier.HS256FallbackEnabled() {
+		log.Printf("Accepting HS256 access tokens with kids %v (JWT_HS256_FALLBACK)", jwksVerifier.HMACKeyIDs())
+	}
+	// Tokens are only verified here, so the manager has no signer or TTLs
+	tokenManager := sharedjwt.NewTokenManagerWithKeys(nil, jwksVerifier, 0, 0).WithValidateOptions(cfg.ValidateOptions...)
+
+	// Initialize the list of access tokens revoked by the client-service
+	tokenRevocations := services.NewTokenRevocationList()
 
 	// Initialize services
 	accountService := services.NewAccountService(accountRepo)
-	transactionService := services.NewTransactionService(transactionRepo, accountRepo)
+	// Withdrawals over the KYC limit need the user's identity verified,
+	// which is looked up on the client-service
+	userStatusClient := services.NewUserStatusClient(cfg.ClientServiceInternalURL, cfg.InternalServiceToken, services.DefaultUserStatusTTL, cfg.Resilience)
+	if cfg.Mutual != nil {
+		userStatusClient.WithTransport(cfg.Mutual.ClientTransport())
+	}
+	promotionService := services.NewPromotionService(promotionRepo)
+	transactionService := services.NewTransactionService(transactionRepo, accountRepo).
+		WithKYCLimit(userStatusClient, cfg.KYCWithdrawalLimit).
+		WithWithdrawalFees(cfg.WithdrawalFees).
+		WithDescriptionLength(cfg.TransactionDescriptionLength).
+		WithSavingsGoals(goalRepo).
+		WithBeneficiaries(beneficiaryRepo, cfg.Beneficiaries).
+		WithExternalTransfers(externalTransferRepo).
+		WithCheques(chequeRepo, cfg.Cheques).
+		WithPromotions(promotionService).
+		WithJointAccounts(accountMemberRepo)
+	// Joint account owners invite users by email, looked up on the
+	// client-service
+	accountMemberService := services.NewAccountMemberService(accountMemberRepo, accountRepo, userStatusClient, cfg.AccountInvitationTTL)
+	goalService := services.NewSavingsGoalService(goalRepo, accountRepo)
+	beneficiaryService := services.NewBeneficiaryService(beneficiaryRepo, accountRepo, cfg.Beneficiaries)
+	roundUpService := services.NewRoundUpService(goalRepo, accountRepo, transactionRepo).WithUserTimeZones(userStatusClient)
+	disputeService := services.NewDisputeService(disputeRepo, transactionRepo, userStatusClient)
+	// Changing a transaction PIN can be confirmed with the password, which
+	// is checked on the client-service
+	transactionPINService := services.NewTransactionPINService(transactionPINRepo, accountRepo, userStatusClient, cfg.TransactionPINs)
+	transactionConfirmationService := services.NewTransactionConfirmationService(transactionConfirmationRepo, cfg.TransactionConfirmations)
+
+	// Money movement can be paused for every replica during maintenance;
+	// MAINTENANCE_MODE pauses it from startup
+	maintenanceService := services.NewMaintenanceService(maintenanceRepo, services.DefaultMaintenanceTTL)
+	if cfg.MaintenanceMode {
+		if err := maintenanceService.EnableAtStartup(cfg.MaintenanceMessage); err != nil {
+			log.Fatalf("Failed to pause transactions: %v", err)
+		}
+		log.Println("Transactions are paused (MAINTENANCE_MODE)")
+	}
+
+	// Start publishing savings goal, dispute and maintenance events from
+	// the outbox to the client-service, stopped on shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var background sync.WaitGroup
+	eventPublisher := events.NewHTTPPublisher(cfg.EventsURL, cfg.InternalServiceToken)
+	if cfg.Mutual != nil {
+		eventPublisher.WithTransport(cfg.Mutual.ClientTransport())
+	}
+	eventRelay := events.NewRelay(db.DB, eventPublisher, events.DefaultRelayBatchSize)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		eventRelay.Run(ctx, events.DefaultRelayInterval)
+	}()
+
+	// Start making deposits queued in batches; on shutdown the workers
+	// finish the deposits in progress and leave the rest queued
+	depositBatchService := services.NewDepositBatchService(depositJobRepo, transactionService)
+	if cfg.DepositWorkers > 0 {
+		depositWorkers := services.NewDepositWorkerPool(depositJobRepo, depositBatchService, cfg.DepositWorkers, cfg.DepositMaxAttempts).
+			WithMaintenance(maintenanceService)
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			depositWorkers.Run(ctx)
+		}()
+		log.Printf("Started %d deposit workers", cfg.DepositWorkers)
+	}
+
+	// Keep making monthly transaction partitions ahead of time and moving
+	// old ones to the archive
+	transactionArchiveService := services.NewTransactionArchiveService(transactionPartitionRepo, cfg.TransactionArchiveAfterMonths)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		maintainTransactionPartitionsPeriodically(ctx, transactionArchiveService)
+	}()
+
+	// Make each day's suspicious activity report once it is due, catching
+	// up on days missed while no replica was running
+	reportService := services.NewReportService(suspiciousActivityRepo, cfg.SuspiciousActivity)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		generateReportsPeriodically(ctx, reportService)
+	}()
+
+	// Check every balance against its transactions once a day, carrying on
+	// with a run interrupted by a restart
+	reconciliationService := services.NewReconciliationService(reconciliationRepo)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		reconcilePeriodically(ctx, reconciliationService)
+	}()
+
+	// Settle transfers to other banks once they have been pending for the
+	// settlement delay, unless it is zero and staff settle them
+	externalTransferService := services.NewExternalTransferService(externalTransferRepo, cfg.ExternalTransfers).
+		WithMaintenance(maintenanceService)
+	if cfg.ExternalTransfers.SettlementDelay > 0 {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			settleExternalTransfersPeriodically(ctx, externalTransferService)
+		}()
+	}
+
+	// Notify users of large transactions and low balances on the channels
+	// they chose on the client-service, reading the ledger as it grows.
+	// Text messages are only logged, since there is no SMS gateway.
+	notificationService := services.NewNotificationService(notificationRepo, transactionRepo, accountRepo, userStatusClient, cfg.Notifications).
+		WithEmail(cfg.EmailSender).
+		WithSMS(services.NewLogSMSSender()).
+		WithWebhooks(services.NewHTTPWebhookSender(services.DefaultWebhookTimeout)).
+		WithJointAccounts(accountMemberRepo)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		dispatchNotificationsPeriodically(ctx, notificationService)
+	}()
 
 	// Initialize handlers
-	accountHandler := handlers.NewAccountHandler(accountService, transactionService)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	accountHandler := handlers.NewAccountHandler(transactionService)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, transactionPINService, transactionConfirmationService)
+	goalHandler := handlers.NewSavingsGoalHandler(goalService)
+	beneficiaryHandler := handlers.NewBeneficiaryHandler(beneficiaryService)
+	externalTransferHandler := handlers.NewExternalTransferHandler(transactionService, externalTransferService, transactionPINService, transactionConfirmationService)
+	transactionConfirmationHandler := handlers.NewTransactionConfirmationHandler(transactionConfirmationService, transactionHandler, externalTransferHandler)
+	transactionPINHandler := handlers.NewTransactionPINHandler(transactionPINService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+	accountMemberHandler := handlers.NewAccountMemberHandler(accountMemberService)
+	chequeHandler := handlers.NewChequeHandler(transactionService, services.NewChequeService(chequeRepo))
+	roundUpHandler := handlers.NewRoundUpHandler(roundUpService)
+	disputeHandler := handlers.NewDisputeHandler(disputeService)
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceService)
+	depositBatchHandler := handlers.NewDepositBatchHandler(depositBatchService)
+	transactionExportHandler := handlers.NewTransactionExportHandler(transactionService)
+	journalExportHandler := handlers.NewJournalExportHandler(transactionService)
+	// Staff see transactions with their customer's email and name, looked
+	// up on the client-service
+	transactionEnricher := services.NewTransactionEnricher(userStatusClient)
+	adminTransactionHandler := handlers.NewAdminTransactionHandler(transactionService, transactionEnricher)
+	transactionArchiveHandler := handlers.NewTransactionArchiveHandler(transactionArchiveService, transactionEnricher)
+	reportHandler := handlers.NewReportHandler(reportService)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService)
+	integrityHandler := handlers.NewIntegrityHandler(services.NewIntegrityService(transactionRepo))
+	internalHandler := handlers.NewInternalHandler(accountService, tokenRevocations, services.NewUserEventConsumer(accountService))
+	statsHandler := handlers.NewStatsHandler(services.NewStatsService(statsRepo))
+	statementHandler := handlers.NewStatementHandler(services.NewStatementService(transactionRepo, accountRepo).WithJointAccounts(accountMemberRepo))
 
 	// Set Gin mode
-	if os.Getenv("GIN_MODE") == "release" {
-		gin.SetMode(gin.ReleaseMode)
-	}
+	gin.SetMode(cfg.GinMode)
+
+	// Optionally reject request bodies with fields the endpoint does not accept
+	handlers.RejectUnknownFields(cfg.RejectUnknownFields)
 
 	// Create router
 	r := gin.Default()
 
 	// Add middleware
-	r.Use(middleware.CORS())
-	r.Use(middleware.Logger())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.CORS(cfg.CORS))
+	r.Use(middleware.Logger(redact.LogRedactionFromEnv()))
 	r.Use(middleware.Recovery())
 
 	// Health check endpoint
@@ -59,38 +279,385 @@ func main() {
 		})
 	})
 
+	// Circuit breaker state for inter-service calls
+	r.GET("/metrics", gin.WrapH(resilience.MetricsHandler()))
+
 	// API routes
 	api := r.Group("/api/v1")
+	// Every API endpoint takes JSON; bound the body before anything reads it
+	api.Use(middleware.JSONBody(cfg.BodyLimits.MaxBytes))
 	{
 		// Protected routes - require authentication
 		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.CSRF(), middleware.AuthMiddleware(tokenManager, clientServiceClient, tokenRevocations, cfg.ClientServiceFailOpen))
 		{
-			// Account routes
+			// Account routes. Personal access tokens need the scope each
+			// route names, and admins impersonating the user may not set
+			// their transaction PIN, confirmation threshold or notification
+			// settings or change who may use their accounts.
+			// Members of a joint account select it with account_id.
 			account := protected.Group("/account")
 			{
-				account.GET("/balance", accountHandler.GetBalance)
-				account.GET("/transactions", accountHandler.GetTransactions)
+				account.GET("/balance", middleware.RequireScope(authmw.ScopeReadBalance), accountHandler.GetBalance)
+				account.GET("/transactions", middleware.RequireScope(authmw.ScopeReadTransactions), accountHandler.GetTransactions)
+				account.HEAD("/transactions", middleware.RequireScope(authmw.ScopeReadTransactions), accountHandler.GetTransactions)
+				account.GET("/statements/:period", middleware.RequireScope(authmw.ScopeReadTransactions), statementHandler.GetStatement)
+				account.GET("/round-ups", middleware.RequireScope(authmw.ScopeReadGoals), roundUpHandler.GetSettings)
+				account.PUT("/round-ups", middleware.RequireScope(authmw.ScopeWriteGoals), middleware.ForbidImpersonation(), roundUpHandler.UpdateSettings)
+				account.GET("/disputes", middleware.RequireScope(authmw.ScopeReadTransactions), disputeHandler.ListDisputes)
+				account.GET("/pin", middleware.RequireScope(authmw.ScopeReadBalance), transactionPINHandler.GetPIN)
+				account.PUT("/pin", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), transactionPINHandler.SetPIN)
+				account.GET("/confirmation-threshold", middleware.RequireScope(authmw.ScopeReadBalance), transactionConfirmationHandler.GetThreshold)
+				account.PUT("/confirmation-threshold", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), transactionConfirmationHandler.UpdateThreshold)
+				account.GET("/notifications", middleware.RequireScope(authmw.ScopeReadBalance), notificationHandler.GetSettings)
+				account.PUT("/notifications", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), notificationHandler.UpdateSettings)
+				account.GET("/promotions", middleware.RequireScope(authmw.ScopeReadTransactions), promotionHandler.ListUserPromotions)
+				account.GET("/memberships", middleware.RequireScope(authmw.ScopeReadBalance), accountMemberHandler.ListMemberships)
+				account.GET("/members", middleware.RequireScope(authmw.ScopeReadBalance), accountMemberHandler.ListMembers)
+				account.POST("/members/invitations", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), accountMemberHandler.Invite)
+				account.PUT("/members/:user_id", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), accountMemberHandler.UpdateMember)
+				account.DELETE("/members/:user_id", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), accountMemberHandler.RemoveMember)
+				account.GET("/invitations", middleware.RequireScope(authmw.ScopeReadBalance), accountMemberHandler.ListInvitations)
+				account.POST("/invitations/:id/accept", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), accountMemberHandler.AcceptInvitation)
 			}
 
-			// Transaction routes
+			// Transaction routes. Admins impersonating the user may look but
+			// not move money, and no one may while transactions are paused.
+			paused := middleware.PauseMoneyMovement(maintenanceService)
 			transactions := protected.Group("/transactions")
 			{
-				transactions.POST("/deposit", transactionHandler.Deposit)
-				transactions.POST("/withdraw", transactionHandler.Withdraw)
-				transactions.GET("/:id", transactionHandler.GetTransaction)
+				transactions.POST("/deposit", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), paused, transactionHandler.Deposit)
+				transactions.POST("/withdraw", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), paused, transactionHandler.Withdraw)
+				transactions.POST("/transfer", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), paused, transactionHandler.Transfer)
+				transactions.POST("/external-transfer", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), paused, externalTransferHandler.CreateExternalTransfer)
+				transactions.POST("/confirm", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), paused, transactionConfirmationHandler.Confirm)
+				transactions.GET("/external-transfers", middleware.RequireScope(authmw.ScopeReadTransactions), externalTransferHandler.ListExternalTransfers)
+				transactions.GET("/external-transfers/:id", middleware.RequireScope(authmw.ScopeReadTransactions), externalTransferHandler.GetExternalTransfer)
+				transactions.GET("/:id", middleware.RequireScope(authmw.ScopeReadTransactions), transactionHandler.GetTransaction)
+				transactions.GET("/:id/dispute", middleware.RequireScope(authmw.ScopeReadTransactions), disputeHandler.GetTransactionDispute)
+				transactions.POST("/:id/dispute", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), disputeHandler.FileDispute)
+			}
+
+			// Savings goal routes. Admins impersonating the user may look
+			// but not change goals.
+			goals := protected.Group("/goals")
+			{
+				goals.POST("", middleware.RequireScope(authmw.ScopeWriteGoals), middleware.ForbidImpersonation(), goalHandler.CreateGoal)
+				goals.GET("", middleware.RequireScope(authmw.ScopeReadGoals), goalHandler.ListGoals)
+				goals.GET("/:id", middleware.RequireScope(authmw.ScopeReadGoals), goalHandler.GetGoal)
+				goals.PATCH("/:id", middleware.RequireScope(authmw.ScopeWriteGoals), middleware.ForbidImpersonation(), goalHandler.UpdateGoal)
+				goals.DELETE("/:id", middleware.RequireScope(authmw.ScopeWriteGoals), middleware.ForbidImpersonation(), goalHandler.DeleteGoal)
+				goals.POST("/:id/allocate", middleware.RequireScope(authmw.ScopeWriteGoals), middleware.ForbidImpersonation(), goalHandler.Allocate)
+				goals.POST("/:id/deallocate", middleware.RequireScope(authmw.ScopeWriteGoals), middleware.ForbidImpersonation(), goalHandler.Deallocate)
+			}
+
+			// Beneficiary routes. Admins impersonating the user may look
+			// but not change beneficiaries.
+			beneficiaries := protected.Group("/beneficiaries")
+			{
+				beneficiaries.POST("", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), beneficiaryHandler.CreateBeneficiary)
+				beneficiaries.GET("", middleware.RequireScope(authmw.ScopeReadTransactions), beneficiaryHandler.ListBeneficiaries)
+				beneficiaries.DELETE("/:id", middleware.RequireScope(authmw.ScopeWriteTransactions), middleware.ForbidImpersonation(), beneficiaryHandler.DeleteBeneficiary)
 			}
+
+			// Admin routes - require a staff role, and each route the
+			// permission it needs. Staff may look at disputes; only those
+			// who may adjust transactions can assign and resolve them, and
+			// resolving, which may refund, waits while transactions are
+			// paused. Staff who read transactions can verify an account's
+			// integrity chain, and those who adjust them can reset its
+			// transaction PIN. Staff who adjust transactions may settle or
+			// fail external transfers early, waiting while transactions are
+			// paused. Tellers record cheque deposits, and those who adjust
+			// transactions release or return them, all waiting while
+			// transactions are paused. Staff who read transactions can see
+			// promotions, and only admins run them. Compliance staff read
+			// suspicious activity reports and reconciliation results, and
+			// admins mark findings reviewed.
+			admin := protected.Group("/admin")
+			admin.Use(middleware.StaffMiddleware(userStatusClient))
+			{
+				can := middleware.RequirePermission
+				admin.GET("/disputes", can(authmw.PermissionTransactionsRead), disputeHandler.AdminListDisputes)
+				admin.GET("/disputes/:id", can(authmw.PermissionTransactionsRead), disputeHandler.AdminGetDispute)
+				admin.POST("/disputes/:id/assign", can(authmw.PermissionTransactionsAdjust), disputeHandler.AssignDispute)
+				admin.POST("/disputes/:id/resolve", can(authmw.PermissionTransactionsAdjust), paused, disputeHandler.ResolveDispute)
+				admin.GET("/transactions", can(authmw.PermissionTransactionsRead), adminTransactionHandler.ListTransactions)
+				admin.HEAD("/transactions", can(authmw.PermissionTransactionsRead), adminTransactionHandler.ListTransactions)
+				admin.GET("/transactions/export", can(authmw.PermissionTransactionsRead), transactionExportHandler.ExportTransactions)
+				admin.GET("/export/journal", can(authmw.PermissionTransactionsRead), journalExportHandler.ExportJournal)
+				admin.GET("/transactions/archive", can(authmw.PermissionTransactionsRead), transactionArchiveHandler.ListArchivedTransactions)
+				admin.GET("/transactions/:id", can(authmw.PermissionTransactionsRead), adminTransactionHandler.GetTransaction)
+				admin.GET("/external-transfers", can(authmw.PermissionTransactionsRead), externalTransferHandler.AdminListExternalTransfers)
+				admin.POST("/external-transfers/:id/settle", can(authmw.PermissionTransactionsAdjust), paused, externalTransferHandler.SettleExternalTransfer)
+				admin.POST("/external-transfers/:id/fail", can(authmw.PermissionTransactionsAdjust), paused, externalTransferHandler.FailExternalTransfer)
+				admin.POST("/cheques", can(authmw.PermissionChequesDeposit), paused, chequeHandler.DepositCheque)
+				admin.GET("/cheques", can(authmw.PermissionTransactionsRead), chequeHandler.ListCheques)
+				admin.GET("/cheques/:id", can(authmw.PermissionTransactionsRead), chequeHandler.GetCheque)
+				admin.POST("/cheques/:id/release", can(authmw.PermissionTransactionsAdjust), paused, chequeHandler.ReleaseCheque)
+				admin.POST("/cheques/:id/return", can(authmw.PermissionTransactionsAdjust), paused, chequeHandler.ReturnCheque)
+				admin.GET("/promotions", can(authmw.PermissionTransactionsRead), promotionHandler.ListPromotions)
+				admin.POST("/promotions", can(authmw.PermissionPromotionsManage), promotionHandler.CreatePromotion)
+				admin.GET("/promotions/:id", can(authmw.PermissionTransactionsRead), promotionHandler.GetPromotion)
+				admin.PUT("/promotions/:id", can(authmw.PermissionPromotionsManage), promotionHandler.UpdatePromotion)
+				admin.DELETE("/promotions/:id", can(authmw.PermissionPromotionsManage), promotionHandler.DeletePromotion)
+				admin.GET("/accounts/:id/integrity", can(authmw.PermissionTransactionsRead), integrityHandler.VerifyAccount)
+				admin.DELETE("/accounts/:id/pin", can(authmw.PermissionTransactionsAdjust), transactionPINHandler.ResetPIN)
+				admin.GET("/maintenance", can(authmw.PermissionMaintenanceRun), maintenanceHandler.GetMaintenance)
+				admin.POST("/maintenance", can(authmw.PermissionMaintenanceRun), maintenanceHandler.UpdateMaintenance)
+				admin.GET("/reports/suspicious-activity", can(authmw.PermissionComplianceRead), reportHandler.ListSuspiciousActivity)
+				admin.GET("/reports/suspicious-activity/export", can(authmw.PermissionComplianceRead), reportHandler.ExportSuspiciousActivity)
+				admin.POST("/reports/suspicious-activity/:id/review", can(authmw.PermissionComplianceReview), reportHandler.ReviewSuspiciousActivity)
+				admin.GET("/reconciliation/latest", can(authmw.PermissionComplianceRead), reconciliationHandler.GetLatest)
+			}
+		}
+	}
+
+	// Internal routes - called by other services, never exposed publicly.
+	// With mutual TLS they get their own listener that only accepts the
+	// certificates of allowed services.
+	internalRouter := r
+	if cfg.Mutual != nil {
+		internalRouter = gin.New()
+		internalRouter.Use(middleware.RequestID())
+		internalRouter.Use(middleware.Logger(redact.LogRedactionFromEnv()))
+		internalRouter.Use(middleware.Recovery())
+	}
+	internal := internalRouter.Group("/internal")
+	internal.Use(middleware.ServiceAuthMiddleware(cfg.InternalServiceToken))
+	internal.Use(middleware.JSONBody(cfg.BodyLimits.MaxBytes))
+	{
+		internal.POST("/accounts", internalHandler.ProvisionAccount)
+		internal.POST("/users/:id/deleted", internalHandler.UserDeleted)
+		internal.POST("/users/:id/restored", internalHandler.UserRestored)
+		internal.GET("/users/:id/balance", internalHandler.UserBalance)
+		internal.GET("/users/:id/statements/:period", statementHandler.UserStatement)
+		internal.GET("/stats", statsHandler.GetStats)
+		internal.POST("/token-revocations", internalHandler.RevokeTokens)
+		internal.POST("/events", internalHandler.HandleEvent)
+		// Deposits submitted in batches are queued while transactions are
+		// paused, but not made until they resume
+		internal.POST("/deposits/batches", depositBatchHandler.CreateBatch)
+		internal.GET("/deposits/batches/:id", depositBatchHandler.GetBatch)
+	}
+
+	server := &http.Server{Addr: ":" + strconv.Itoa(cfg.Port), Handler: r}
+	go func() {
+		log.Printf("Banking Service starting on port %d (TLS: %t)", cfg.Port, cfg.Certificates != nil)
+		if err := tlsserver.ListenAndServe(server, cfg.Certificates); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// With TLS, pick up renewed certificates and optionally redirect plain
+	// HTTP to HTTPS
+	var redirectServer *http.Server
+	if cfg.Certificates != nil {
+		go cfg.Certificates.Watch(ctx, tlsserver.DefaultReloadInterval)
+		if cfg.TLS.RedirectPort != 0 {
+			redirectServer = tlsserver.NewRedirectServer(cfg.TLS.RedirectPort, cfg.Port)
+			go func() {
+				log.Printf("Redirecting HTTP on port %d to HTTPS", cfg.TLS.RedirectPort)
+				if err := redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Fatalf("Failed to start HTTP redirect server: %v", err)
+				}
+			}()
 		}
 	}
 
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// With mutual TLS, serve the internal routes on their own listener
+	var internalServer *http.Server
+	if cfg.Mutual != nil {
+		go cfg.Mutual.Certificates().Watch(ctx, tlsserver.DefaultReloadInterval)
+		internalServer = cfg.Mutual.NewServer(cfg.InternalPort, internalRouter)
+		go func() {
+			log.Printf("Serving internal routes with mutual TLS on port %d", cfg.InternalPort)
+			if err := internalServer.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Failed to start internal server: %v", err)
+			}
+		}()
 	}
 
-	log.Printf("Banking Service starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	<-ctx.Done()
+	log.Println("Banking Service shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if redirectServer != nil {
+		redirectServer.Shutdown(shutdownCtx)
+	}
+	if internalServer != nil {
+		internalServer.Shutdown(shutdownCtx)
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown failed: %v", err)
+	}
+	background.Wait()
+}
+
+// maintainTransactionPartitionsPeriodically creates missing transaction
+// partitions and archives old ones once an hour until ctx is cancelled
+func maintainTransactionPartitionsPeriodically(ctx context.Context, archiveService *services.TransactionArchiveService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		created, archived, err := archiveService.MaintainPartitions()
+		if len(created) > 0 {
+			log.Printf("Created transaction partitions %v", created)
+		}
+		if len(archived) > 0 {
+			log.Printf("Archived transaction partitions %v", archived)
+		}
+		if err != nil {
+			log.Printf("Transaction partition maintenance failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// generateReportsPeriodically makes the suspicious activity reports that
+// are due once an hour until ctx is cancelled
+func generateReportsPeriodically(ctx context.Context, reportService *services.ReportService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		made, err := reportService.GenerateDueReports()
+		for _, report := range made {
+			log.Printf("Made the suspicious activity report for %s with %d findings", report.ReportDate.Format(time.DateOnly), report.FindingCount)
+		}
+		if err != nil {
+			log.Printf("Suspicious activity report failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcilePeriodically runs the day's reconciliation once it is due, or
+// resumes an interrupted one, checking once an hour until ctx is cancelled
+func reconcilePeriodically(ctx context.Context, reconciliationService *services.ReconciliationService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		run, err := reconciliationService.RunDue(ctx)
+		if run != nil {
+			log.Printf("Reconciled %d accounts for %s with %d mismatches", run.AccountsChecked, run.RunDate.Format(time.DateOnly), run.MismatchesFound)
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Reconciliation failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// settleExternalTransfersPeriodically settles the external transfers that
+// are due, checking every ten seconds until ctx is cancelled
+func settleExternalTransfersPeriodically(ctx context.Context, externalTransferService *services.ExternalTransferService) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		settled, err := externalTransferService.SettleDue(services.DefaultExternalTransferPageSize)
+		if settled > 0 {
+			log.Printf("Settled %d external transfers", settled)
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("External transfer settlement failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchNotificationsPeriodically queues the notifications of new
+// transactions and sends those that are due, every five seconds until ctx
+// is cancelled
+func dispatchNotificationsPeriodically(ctx context.Context, notificationService *services.NotificationService) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if _, err := notificationService.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Notification dispatch failed: %v", err)
+		}
+		sent, err := notificationService.Deliver(ctx)
+		if sent > 0 {
+			log.Printf("Sent %d notifications", sent)
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Notification delivery failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// verifyIntegrity verifies the integrity chain of each account in
+// accountIDs, logging the first break of each, and exits with status 1 if
+// any chain is broken or cannot be checked
+func verifyIntegrity(integrityService *services.IntegrityService, accountIDs []string) {
+	if len(accountIDs) == 0 {
+		log.Fatal("Usage: verify-integrity ACCOUNT_ID...")
+	}
+
+	failed := 0
+	for _, value := range accountIDs {
+		accountID, err := uuid.Parse(value)
+		if err != nil {
+			log.Printf("Invalid account ID %q", value)
+			failed++
+			continue
+		}
+		result, err := integrityService.VerifyAccount(accountID)
+		if err != nil {
+			log.Printf("Account %s could not be verified: %v", accountID, err)
+			failed++
+			continue
+		}
+		if result.Break != nil {
+			log.Printf("Account %s integrity chain is broken at transaction %d: %s", accountID, result.Break.Sequence, result.Break.Reason)
+			failed++
+			continue
+		}
+		log.Printf("Account %s integrity chain is intact: %d transactions checked, %d made before chaining", accountID, result.TransactionsChecked, result.UnchainedTransactions)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// exitAfterValidation reports the result of -validate-config and exits with
+// status 1 if the configuration is invalid, or 0 otherwise
+func exitAfterValidation(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
+	fmt.Println("Configuration is valid")
+	os.Exit(0)
 }
@@ -0,0 +1,415 @@
+// Package config loads the banking-service configuration from the
+// environment and checks it before anything is started, so a bad
+// deployment fails at once with every problem listed instead of at the
+// first request that needs the broken setting.
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/bodylimit"
+	sharedconfig "microbank/pkg/config"
+	"microbank/pkg/cors"
+	sharedjwt "microbank/pkg/jwt"
+	"microbank/pkg/mailer"
+	"microbank/pkg/resilience"
+	"microbank/pkg/tlsserver"
+)
+
+// Config holds the validated banking-service configuration
+type Config struct {
+	Port                 int
+	GinMode              string
+	Database             sharedconfig.Database
+	InternalServiceToken string
+	ClientServiceURL     string
+	RejectUnknownFields  bool
+
+	// ClientServiceInternalURL is where the client-service's internal
+	// routes are served, its mutual TLS listener when it has one
+	ClientServiceInternalURL string
+	// EventsURL is where events from the outbox, such as completed
+	// savings goals, are published
+	EventsURL string
+	// KYCWithdrawalLimit is the largest withdrawal users whose identity is
+	// not verified may make
+	KYCWithdrawalLimit float64
+	// WithdrawalFees are charged on withdrawals beyond the free monthly
+	// allowance
+	WithdrawalFees models.WithdrawalFees
+	// TransactionDescriptionLength is how many characters transaction
+	// descriptions are cut to
+	TransactionDescriptionLength int
+	// MaintenanceMode pauses money movement at startup, showing users
+	// MaintenanceMessage, unless it is already paused. It is turned off
+	// at runtime, since the flag is shared by every replica.
+	MaintenanceMode    bool
+	MaintenanceMessage string
+	// DepositWorkers is how many workers make deposits queued in batches
+	// on this replica; with none the queue is left to other replicas.
+	// Each deposit is tried at most DepositMaxAttempts times.
+	DepositWorkers     int
+	DepositMaxAttempts int
+	// TransactionArchiveAfterMonths is how many whole months transactions
+	// stay live after the month they were made in before they are
+	// archived; 0 never archives them
+	TransactionArchiveAfterMonths int
+	// SuspiciousActivity are the rules the nightly suspicious activity
+	// reports are made with
+	SuspiciousActivity models.SuspiciousActivityRules
+	// Beneficiaries limits how many beneficiaries users save and when
+	// large transfers to them are allowed
+	Beneficiaries models.BeneficiaryRules
+	// ExternalTransfers control how the simulated transfers to other banks
+	// settle
+	ExternalTransfers models.ExternalTransferRules
+	// Cheques control how long cheque deposits are held
+	Cheques models.ChequeRules
+	// TransactionPINs control how wrong transaction PINs lock them
+	TransactionPINs models.TransactionPINRules
+	// AccountInvitationTTL is how long invitations to join an account can
+	// be accepted
+	AccountInvitationTTL time.Duration
+	// TransactionConfirmations decide which withdrawals and transfers need
+	// confirming, and for how long they can be
+	TransactionConfirmations models.TransactionConfirmationRules
+	// Notifications decide which account activity users are notified of,
+	// and how often. EmailSender sends their emails.
+	Notifications models.NotificationRules
+	EmailSender   mailer.EmailSender
+
+	// TLS and Certificates are nil when the service serves plain HTTP
+	TLS          *tlsserver.Config
+	Certificates *tlsserver.CertReloader
+	// Mutual is nil, and InternalPort 0, when internal routes are served
+	// alongside the public ones rather than on their own mutual TLS
+	// listener
+	Mutual       *tlsserver.Mutual
+	InternalPort int
+
+	JWKSVerifier    *middleware.JWKSVerifier
+	ValidateOptions []sharedjwt.ValidateOption
+	CORS            *cors.Policy
+	BodyLimits      bodylimit.Limits
+	// Resilience sets the retries and circuit breaker of calls to the
+	// client-service. With ClientServiceFailOpen, tokens are accepted
+	// without the client-service's confirmation while it is unavailable.
+	Resilience            resilience.Config
+	ClientServiceFailOpen bool
+}
+
+// Load reads the configuration from the environment. The error, when not
+// nil, is a *sharedconfig.Error listing every problem found.
+func Load() (*Config, error) {
+	var problems sharedconfig.Problems
+	cfg := &Config{}
+	var err error
+
+	cfg.Port, err = sharedconfig.PortFromEnv("PORT", 8080)
+	problems.Add(err)
+	cfg.GinMode, err = sharedconfig.OneOfEnv("GIN_MODE", "debug", "debug", "release", "test")
+	problems.Add(err)
+	cfg.Database, err = sharedconfig.DatabaseFromEnv("banking_service")
+	problems.Add(err)
+	cfg.RejectUnknownFields, err = sharedconfig.BoolFromEnv("REJECT_UNKNOWN_FIELDS", false)
+	problems.Add(err)
+
+	cfg.TLS, err = tlsserver.ConfigFromEnv()
+	problems.Add(err)
+	if cfg.TLS != nil {
+		if cfg.TLS.RedirectPort == cfg.Port {
+			problems.Addf("TLS_REDIRECT_PORT must differ from PORT")
+		}
+		// A certificate that does not match its key fails here rather than
+		// at the first handshake
+		cfg.Certificates, err = tlsserver.NewCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		problems.Add(err)
+	}
+
+	mutualConfig, err := tlsserver.MutualConfigFromEnv("client-service")
+	problems.Add(err)
+	if mutualConfig != nil {
+		cfg.InternalPort = mutualConfig.InternalPort
+		if cfg.InternalPort == cfg.Port || (cfg.TLS != nil && cfg.InternalPort == cfg.TLS.RedirectPort) {
+			problems.Addf("INTERNAL_PORT must differ from PORT and TLS_REDIRECT_PORT")
+		}
+		cfg.Mutual, err = tlsserver.NewMutual(mutualConfig)
+		problems.Add(err)
+	}
+
+	// The internal token authenticates calls in both directions between the
+	// services, so it is held to the same standard as a signing secret
+	cfg.InternalServiceToken, err = sharedconfig.RequiredEnv("INTERNAL_SERVICE_TOKEN")
+	if err == nil {
+		err = sharedconfig.CheckSecret("INTERNAL_SERVICE_TOKEN", cfg.InternalServiceToken)
+	}
+	problems.Add(err)
+
+	cfg.ClientServiceURL, err = sharedconfig.URLFromEnv("CLIENT_SERVICE_URL", "http://localhost:8081")
+	problems.Add(err)
+	cfg.ClientServiceInternalURL, err = sharedconfig.URLFromEnv("CLIENT_SERVICE_INTERNAL_URL", cfg.ClientServiceURL)
+	problems.Add(err)
+	cfg.EventsURL, err = sharedconfig.URLFromEnv("EVENTS_PUBLISH_URL", strings.TrimRight(cfg.ClientServiceInternalURL, "/")+"/internal/events")
+	problems.Add(err)
+	if cfg.Mutual != nil {
+		for _, setting := range []struct{ name, value string }{
+			{"CLIENT_SERVICE_INTERNAL_URL", cfg.ClientServiceInternalURL},
+			{"EVENTS_PUBLISH_URL", cfg.EventsURL},
+		} {
+			if !strings.HasPrefix(setting.value, "https://") {
+				problems.Addf("%s must be an https URL with mutual TLS", setting.name)
+			}
+		}
+	}
+	cfg.KYCWithdrawalLimit, err = amountFromEnv("KYC_WITHDRAWAL_LIMIT", 1000)
+	problems.Add(err)
+	cfg.WithdrawalFees.Flat, err = amountFromEnv("WITHDRAWAL_FEE_FLAT", 0)
+	problems.Add(err)
+	cfg.WithdrawalFees.Percent, err = amountFromEnv("WITHDRAWAL_FEE_PERCENT", 0)
+	if err == nil && cfg.WithdrawalFees.Percent > 100 {
+		err = fmt.Errorf("invalid WITHDRAWAL_FEE_PERCENT %q: must be at most 100", os.Getenv("WITHDRAWAL_FEE_PERCENT"))
+	}
+	problems.Add(err)
+	cfg.WithdrawalFees.FreePerMonth, err = countFromEnv("WITHDRAWAL_FREE_PER_MONTH", 0)
+	problems.Add(err)
+	cfg.TransactionDescriptionLength, err = countFromEnv("TRANSACTION_DESCRIPTION_MAX_LENGTH", 255)
+	if err == nil && cfg.TransactionDescriptionLength < 10 {
+		err = fmt.Errorf("invalid TRANSACTION_DESCRIPTION_MAX_LENGTH %q: must be at least 10", os.Getenv("TRANSACTION_DESCRIPTION_MAX_LENGTH"))
+	}
+	problems.Add(err)
+	cfg.MaintenanceMode, err = sharedconfig.BoolFromEnv("MAINTENANCE_MODE", false)
+	problems.Add(err)
+	cfg.MaintenanceMessage = strings.TrimSpace(os.Getenv("MAINTENANCE_MESSAGE"))
+	if len(cfg.MaintenanceMessage) > 500 {
+		problems.Addf("MAINTENANCE_MESSAGE must be at most 500 characters")
+	}
+	cfg.DepositWorkers, err = countFromEnv("DEPOSIT_WORKERS", 4)
+	problems.Add(err)
+	cfg.DepositMaxAttempts, err = countFromEnv("DEPOSIT_MAX_ATTEMPTS", 5)
+	if err == nil && cfg.DepositMaxAttempts == 0 {
+		err = fmt.Errorf("invalid DEPOSIT_MAX_ATTEMPTS %q: must be at least 1", os.Getenv("DEPOSIT_MAX_ATTEMPTS"))
+	}
+	problems.Add(err)
+	cfg.TransactionArchiveAfterMonths, err = countFromEnv("TRANSACTION_ARCHIVE_AFTER_MONTHS", 24)
+	if err == nil && cfg.TransactionArchiveAfterMonths > 0 && cfg.TransactionArchiveAfterMonths < 12 {
+		// Round-up summaries read the last 12 months of live transactions
+		err = fmt.Errorf("invalid TRANSACTION_ARCHIVE_AFTER_MONTHS %q: must be 0 or at least 12", os.Getenv("TRANSACTION_ARCHIVE_AFTER_MONTHS"))
+	}
+	problems.Add(err)
+	cfg.SuspiciousActivity = suspiciousActivityRulesFromEnv(&problems)
+	cfg.Beneficiaries.MaxPerUser, err = countFromEnv("MAX_BENEFICIARIES_PER_USER", 50)
+	if err == nil && cfg.Beneficiaries.MaxPerUser == 0 {
+		err = fmt.Errorf("invalid MAX_BENEFICIARIES_PER_USER %q: must be at least 1", os.Getenv("MAX_BENEFICIARIES_PER_USER"))
+	}
+	problems.Add(err)
+	coolingOffHours, err := countFromEnv("BENEFICIARY_COOLING_OFF_HOURS", 24)
+	problems.Add(err)
+	cfg.Beneficiaries.CoolingOff = time.Duration(coolingOffHours) * time.Hour
+	cfg.Beneficiaries.LargeTransferLimit, err = amountFromEnv("BENEFICIARY_LARGE_TRANSFER_LIMIT", 1000)
+	problems.Add(err)
+	settlementSeconds, err := countFromEnv("EXTERNAL_TRANSFER_SETTLEMENT_SECONDS", 60)
+	problems.Add(err)
+	cfg.ExternalTransfers.SettlementDelay = time.Duration(settlementSeconds) * time.Second
+	cfg.ExternalTransfers.FailurePercent, err = amountFromEnv("EXTERNAL_TRANSFER_FAILURE_PERCENT", 0)
+	if err == nil && cfg.ExternalTransfers.FailurePercent > 100 {
+		err = fmt.Errorf("invalid EXTERNAL_TRANSFER_FAILURE_PERCENT %q: must be at most 100", os.Getenv("EXTERNAL_TRANSFER_FAILURE_PERCENT"))
+	}
+	problems.Add(err)
+	chequeHoldHours, err := countFromEnv("CHEQUE_HOLD_HOURS", 72)
+	if err == nil && (chequeHoldHours == 0 || time.Duration(chequeHoldHours)*time.Hour > models.MaxChequeHold) {
+		err = fmt.Errorf("invalid CHEQUE_HOLD_HOURS %q: must be between 1 and %d", os.Getenv("CHEQUE_HOLD_HOURS"), int(models.MaxChequeHold.Hours()))
+	}
+	problems.Add(err)
+	cfg.Cheques.DefaultHold = time.Duration(chequeHoldHours) * time.Hour
+	cfg.TransactionPINs.MaxAttempts, err = countFromEnv("TRANSACTION_PIN_MAX_ATTEMPTS", 5)
+	if err == nil && cfg.TransactionPINs.MaxAttempts == 0 {
+		err = fmt.Errorf("invalid TRANSACTION_PIN_MAX_ATTEMPTS %q: must be at least 1", os.Getenv("TRANSACTION_PIN_MAX_ATTEMPTS"))
+	}
+	problems.Add(err)
+	pinLockoutMinutes, err := countFromEnv("TRANSACTION_PIN_LOCKOUT_MINUTES", 15)
+	if err == nil && pinLockoutMinutes == 0 {
+		err = fmt.Errorf("invalid TRANSACTION_PIN_LOCKOUT_MINUTES %q: must be at least 1", os.Getenv("TRANSACTION_PIN_LOCKOUT_MINUTES"))
+	}
+	problems.Add(err)
+	cfg.TransactionPINs.Lockout = time.Duration(pinLockoutMinutes) * time.Minute
+	invitationTTLHours, err := countFromEnv("ACCOUNT_INVITATION_TTL_HOURS", 168)
+	if err == nil && invitationTTLHours == 0 {
+		err = fmt.Errorf("invalid ACCOUNT_INVITATION_TTL_HOURS %q: must be at least 1", os.Getenv("ACCOUNT_INVITATION_TTL_HOURS"))
+	}
+	problems.Add(err)
+	cfg.AccountInvitationTTL = time.Duration(invitationTTLHours) * time.Hour
+	cfg.TransactionConfirmations.DefaultThreshold, err = amountFromEnv("TRANSACTION_CONFIRMATION_THRESHOLD", 1000)
+	if err == nil && cfg.TransactionConfirmations.DefaultThreshold == 0 {
+		err = fmt.Errorf("invalid TRANSACTION_CONFIRMATION_THRESHOLD %q: must be greater than 0", os.Getenv("TRANSACTION_CONFIRMATION_THRESHOLD"))
+	}
+	problems.Add(err)
+	confirmationTTLMinutes, err := countFromEnv("TRANSACTION_CONFIRMATION_TTL_MINUTES", 5)
+	if err == nil && confirmationTTLMinutes == 0 {
+		err = fmt.Errorf("invalid TRANSACTION_CONFIRMATION_TTL_MINUTES %q: must be at least 1", os.Getenv("TRANSACTION_CONFIRMATION_TTL_MINUTES"))
+	}
+	problems.Add(err)
+	cfg.TransactionConfirmations.TTL = time.Duration(confirmationTTLMinutes) * time.Minute
+	cfg.Notifications.LargeTransactionThreshold, err = amountFromEnv("LARGE_TRANSACTION_THRESHOLD", 1000)
+	if err == nil && cfg.Notifications.LargeTransactionThreshold == 0 {
+		err = fmt.Errorf("invalid LARGE_TRANSACTION_THRESHOLD %q: must be greater than 0", os.Getenv("LARGE_TRANSACTION_THRESHOLD"))
+	}
+	problems.Add(err)
+	cfg.Notifications.HourlyCap, err = countFromEnv("NOTIFICATION_HOURLY_CAP", 10)
+	if err == nil && cfg.Notifications.HourlyCap == 0 {
+		err = fmt.Errorf("invalid NOTIFICATION_HOURLY_CAP %q: must be at least 1", os.Getenv("NOTIFICATION_HOURLY_CAP"))
+	}
+	problems.Add(err)
+	cfg.Notifications.MaxAttempts, err = countFromEnv("NOTIFICATION_MAX_ATTEMPTS", 5)
+	if err == nil && cfg.Notifications.MaxAttempts == 0 {
+		err = fmt.Errorf("invalid NOTIFICATION_MAX_ATTEMPTS %q: must be at least 1", os.Getenv("NOTIFICATION_MAX_ATTEMPTS"))
+	}
+	problems.Add(err)
+	notificationRetrySeconds, err := countFromEnv("NOTIFICATION_RETRY_SECONDS", 30)
+	if err == nil && notificationRetrySeconds == 0 {
+		err = fmt.Errorf("invalid NOTIFICATION_RETRY_SECONDS %q: must be at least 1", os.Getenv("NOTIFICATION_RETRY_SECONDS"))
+	}
+	problems.Add(err)
+	cfg.Notifications.RetryBackoff = time.Duration(notificationRetrySeconds) * time.Second
+	if cfg.EmailSender, err = mailer.NewFromEnv(); err != nil {
+		problems.Add(fmt.Errorf("email: %w", err))
+	}
+	_, err = sharedconfig.URLFromEnv("JWKS_URL", "")
+	problems.Add(err)
+
+	checkFallbackSecrets(&problems)
+	if cfg.JWKSVerifier, err = middleware.NewJWKSVerifierFromEnv(cfg.ClientServiceURL); err != nil {
+		problems.Add(fmt.Errorf("token verification: %w", err))
+	}
+	cfg.ValidateOptions, err = sharedjwt.ValidateOptionsFromEnv()
+	problems.Add(err)
+
+	cfg.BodyLimits, err = bodylimit.LimitsFromEnv()
+	problems.Add(err)
+	cfg.Resilience, err = resilience.ConfigFromEnv()
+	problems.Add(err)
+	cfg.ClientServiceFailOpen, err = sharedconfig.BoolFromEnv("CLIENT_SERVICE_FAIL_OPEN", false)
+	problems.Add(err)
+
+	corsConfig, err := cors.ConfigFromEnv()
+	if err == nil {
+		cfg.CORS, err = cors.New(corsConfig)
+	}
+	if err != nil {
+		problems.Add(fmt.Errorf("CORS: %w", err))
+	}
+
+	if err := problems.Err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// amountFromEnv returns the non-negative amount in the environment
+// variable name, or fallback when it is not set
+func amountFromEnv(name string, fallback float64) (float64, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative amount", name, value)
+	}
+	return amount, nil
+}
+
+// countFromEnv returns the non-negative whole number in the environment
+// variable name, or fallback when it is not set
+func countFromEnv(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative whole number", name, value)
+	}
+	return count, nil
+}
+
+// suspiciousActivityRulesFromEnv reads the suspicious activity rules,
+// adding any invalid setting to problems
+func suspiciousActivityRulesFromEnv(problems *sharedconfig.Problems) models.SuspiciousActivityRules {
+	// Balance swings are compared with the last 90 days, and need a week
+	// of activity in them to have a meaningful average
+	rules := models.SuspiciousActivityRules{
+		BalanceSwing: models.BalanceSwingRule{LookbackDays: 90, MinHistoryDays: 7},
+	}
+	var err error
+
+	rules.RapidCycle.Enabled, err = sharedconfig.BoolFromEnv("SUSPICIOUS_RAPID_CYCLE_ENABLED", true)
+	problems.Add(err)
+	hours, err := countFromEnv("SUSPICIOUS_RAPID_CYCLE_HOURS", 24)
+	if err == nil && hours == 0 {
+		err = fmt.Errorf("invalid SUSPICIOUS_RAPID_CYCLE_HOURS %q: must be at least 1", os.Getenv("SUSPICIOUS_RAPID_CYCLE_HOURS"))
+	}
+	problems.Add(err)
+	rules.RapidCycle.Window = time.Duration(hours) * time.Hour
+	rules.RapidCycle.Percent, err = percentFromEnv("SUSPICIOUS_RAPID_CYCLE_PERCENT", 90)
+	problems.Add(err)
+
+	rules.Structuring.Enabled, err = sharedconfig.BoolFromEnv("SUSPICIOUS_STRUCTURING_ENABLED", true)
+	problems.Add(err)
+	rules.Structuring.Threshold, err = amountFromEnv("SUSPICIOUS_ALERT_THRESHOLD", 10000)
+	if err == nil && rules.Structuring.Threshold == 0 {
+		err = fmt.Errorf("invalid SUSPICIOUS_ALERT_THRESHOLD %q: must be greater than zero", os.Getenv("SUSPICIOUS_ALERT_THRESHOLD"))
+	}
+	problems.Add(err)
+	rules.Structuring.MarginPercent, err = percentFromEnv("SUSPICIOUS_STRUCTURING_MARGIN_PERCENT", 10)
+	problems.Add(err)
+	rules.Structuring.MinCount, err = countFromEnv("SUSPICIOUS_STRUCTURING_MIN_COUNT", 3)
+	if err == nil && rules.Structuring.MinCount < 2 {
+		err = fmt.Errorf("invalid SUSPICIOUS_STRUCTURING_MIN_COUNT %q: must be at least 2", os.Getenv("SUSPICIOUS_STRUCTURING_MIN_COUNT"))
+	}
+	problems.Add(err)
+
+	rules.BalanceSwing.Enabled, err = sharedconfig.BoolFromEnv("SUSPICIOUS_BALANCE_SWING_ENABLED", true)
+	problems.Add(err)
+	rules.BalanceSwing.Multiple, err = amountFromEnv("SUSPICIOUS_BALANCE_SWING_MULTIPLE", 5)
+	if err == nil && rules.BalanceSwing.Multiple < 1 {
+		err = fmt.Errorf("invalid SUSPICIOUS_BALANCE_SWING_MULTIPLE %q: must be at least 1", os.Getenv("SUSPICIOUS_BALANCE_SWING_MULTIPLE"))
+	}
+	problems.Add(err)
+
+	return rules
+}
+
+// percentFromEnv returns the percentage above zero and at most 100 in the
+// environment variable name, or fallback when it is not set
+func percentFromEnv(name string, fallback float64) (float64, error) {
+	percent, err := amountFromEnv(name, fallback)
+	if err == nil && (percent == 0 || percent > 100) {
+		err = fmt.Errorf("invalid %s %q: must be above 0 and at most 100", name, os.Getenv(name))
+	}
+	return percent, err
+}
+
+// checkFallbackSecrets rejects JWT_SECRET and JWT_KEYS secrets too weak to
+// verify HS256 tokens with
+func checkFallbackSecrets(problems *sharedconfig.Problems) {
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		problems.Add(sharedconfig.CheckSecret("JWT_SECRET", secret))
+	}
+	// Malformed JWT_KEYS are reported by middleware.NewJWKSVerifierFromEnv
+	keys, _ := middleware.ParseHMACKeys(os.Getenv("JWT_KEYS"))
+	kids := make([]string, 0, len(keys))
+	for kid := range keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	for _, kid := range kids {
+		problems.Add(sharedconfig.CheckSecret(fmt.Sprintf("JWT_KEYS secret %q", kid), keys[kid]))
+	}
+}
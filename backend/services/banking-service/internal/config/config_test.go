@@ -0,0 +1,229 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	sharedconfig "microbank/pkg/config"
+)
+
+const testSecret = "q3Jx9VbT0mWc7LkZpR2sYd5HnE8aFu4G"
+
+// setValidEnv sets a minimal valid configuration, clearing the settings the
+// tests change
+func setValidEnv(t *testing.T) {
+	t.Helper()
+	env := map[string]string{
+		"PORT":                                  "",
+		"GIN_MODE":                              "",
+		"DB_PORT":                               "",
+		"DB_PASSWORD":                           "password",
+		"TLS_CERT_FILE":                         "",
+		"TLS_KEY_FILE":                          "",
+		"TLS_REDIRECT_PORT":                     "",
+		"MTLS_CERT_FILE":                        "",
+		"MTLS_KEY_FILE":                         "",
+		"MTLS_CA_FILE":                          "",
+		"MTLS_ALLOWED_PEERS":                    "",
+		"INTERNAL_PORT":                         "",
+		"INTERNAL_SERVICE_TOKEN":                testSecret,
+		"CLIENT_SERVICE_URL":                    "",
+		"CLIENT_SERVICE_INTERNAL_URL":           "",
+		"EVENTS_PUBLISH_URL":                    "",
+		"KYC_WITHDRAWAL_LIMIT":                  "",
+		"WITHDRAWAL_FEE_FLAT":                   "",
+		"WITHDRAWAL_FEE_PERCENT":                "",
+		"WITHDRAWAL_FREE_PER_MONTH":             "",
+		"TRANSACTION_DESCRIPTION_MAX_LENGTH":    "",
+		"MAINTENANCE_MODE":                      "",
+		"MAINTENANCE_MESSAGE":                   "",
+		"DEPOSIT_WORKERS":                       "",
+		"DEPOSIT_MAX_ATTEMPTS":                  "",
+		"TRANSACTION_ARCHIVE_AFTER_MONTHS":      "",
+		"SUSPICIOUS_RAPID_CYCLE_ENABLED":        "",
+		"SUSPICIOUS_RAPID_CYCLE_HOURS":          "",
+		"SUSPICIOUS_RAPID_CYCLE_PERCENT":        "",
+		"SUSPICIOUS_STRUCTURING_ENABLED":        "",
+		"SUSPICIOUS_ALERT_THRESHOLD":            "",
+		"SUSPICIOUS_STRUCTURING_MARGIN_PERCENT": "",
+		"SUSPICIOUS_STRUCTURING_MIN_COUNT":      "",
+		"SUSPICIOUS_BALANCE_SWING_ENABLED":      "",
+		"SUSPICIOUS_BALANCE_SWING_MULTIPLE":     "",
+		"MAX_BENEFICIARIES_PER_USER":            "",
+		"BENEFICIARY_COOLING_OFF_HOURS":         "",
+		"BENEFICIARY_LARGE_TRANSFER_LIMIT":      "",
+		"EXTERNAL_TRANSFER_SETTLEMENT_SECONDS":  "",
+		"EXTERNAL_TRANSFER_FAILURE_PERCENT":     "",
+		"CHEQUE_HOLD_HOURS":                     "",
+		"TRANSACTION_PIN_MAX_ATTEMPTS":          "",
+		"TRANSACTION_PIN_LOCKOUT_MINUTES":       "",
+		"ACCOUNT_INVITATION_TTL_HOURS":          "",
+		"TRANSACTION_CONFIRMATION_THRESHOLD":    "",
+		"TRANSACTION_CONFIRMATION_TTL_MINUTES":  "",
+		"LARGE_TRANSACTION_THRESHOLD":           "",
+		"NOTIFICATION_HOURLY_CAP":               "",
+		"NOTIFICATION_MAX_ATTEMPTS":             "",
+		"NOTIFICATION_RETRY_SECONDS":            "",
+		"SMTP_HOST":                             "",
+		"JWKS_URL":                              "",
+		"JWT_HS256_FALLBACK":                    "",
+		"JWT_SECRET":                            "",
+		"JWT_KEYS":                              "",
+	}
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	setValidEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Port != 8080 || cfg.ClientServiceURL != "http://localhost:8081" {
+		t.Errorf("Expected the defaults, got port %d and client service %s", cfg.Port, cfg.ClientServiceURL)
+	}
+	if cfg.JWKSVerifier.HS256FallbackEnabled() {
+		t.Error("Expected the HS256 fallback to be off")
+	}
+	if cfg.ClientServiceInternalURL != cfg.ClientServiceURL || cfg.KYCWithdrawalLimit != 1000 {
+		t.Errorf("Expected the internal URL to default to the client service and a KYC limit of 1000, got %s and %v", cfg.ClientServiceInternalURL, cfg.KYCWithdrawalLimit)
+	}
+	if cfg.WithdrawalFees.Enabled() {
+		t.Errorf("Expected withdrawals to be free by default, got %+v", cfg.WithdrawalFees)
+	}
+	if cfg.MaintenanceMode {
+		t.Error("Expected transactions not to be paused at startup by default")
+	}
+	if cfg.DepositWorkers != 4 || cfg.DepositMaxAttempts != 5 {
+		t.Errorf("Expected 4 deposit workers trying each deposit 5 times, got %d and %d", cfg.DepositWorkers, cfg.DepositMaxAttempts)
+	}
+	if cfg.TransactionDescriptionLength != 255 {
+		t.Errorf("Expected descriptions to be cut to 255 characters, got %d", cfg.TransactionDescriptionLength)
+	}
+	if cfg.TransactionArchiveAfterMonths != 24 {
+		t.Errorf("Expected transactions to be archived after 24 months, got %d", cfg.TransactionArchiveAfterMonths)
+	}
+	rules := cfg.SuspiciousActivity
+	if len(rules.Enabled()) != 3 {
+		t.Errorf("Expected every suspicious activity rule to be on by default, got %v", rules.Enabled())
+	}
+	if rules.RapidCycle.Window != 24*time.Hour || rules.RapidCycle.Percent != 90 {
+		t.Errorf("Expected rapid cycles of 90%% within 24h, got %+v", rules.RapidCycle)
+	}
+	if rules.Structuring.Floor() != 9000 || rules.Structuring.MinCount != 3 {
+		t.Errorf("Expected structuring of 3 transactions from 9000, got %+v", rules.Structuring)
+	}
+	if beneficiaries := cfg.Beneficiaries; beneficiaries.MaxPerUser != 50 || beneficiaries.CoolingOff != 24*time.Hour || beneficiaries.LargeTransferLimit != 1000 {
+		t.Errorf("Expected 50 beneficiaries, with transfers over 1000 held for 24h, got %+v", beneficiaries)
+	}
+	if externalTransfers := cfg.ExternalTransfers; externalTransfers.SettlementDelay != time.Minute || externalTransfers.FailurePercent != 0 {
+		t.Errorf("Expected external transfers to settle after a minute without failing, got %+v", externalTransfers)
+	}
+	if cfg.Cheques.DefaultHold != 72*time.Hour {
+		t.Errorf("Expected cheques to be held for 72h, got %v", cfg.Cheques.DefaultHold)
+	}
+	if pins := cfg.TransactionPINs; pins.MaxAttempts != 5 || pins.Lockout != 15*time.Minute {
+		t.Errorf("Expected transaction PINs to lock for 15m after 5 wrong attempts, got %+v", pins)
+	}
+	if cfg.AccountInvitationTTL != 7*24*time.Hour {
+		t.Errorf("Expected account invitations to expire after 7 days, got %v", cfg.AccountInvitationTTL)
+	}
+	if confirmations := cfg.TransactionConfirmations; confirmations.DefaultThreshold != 1000 || confirmations.TTL != 5*time.Minute {
+		t.Errorf("Expected withdrawals and transfers over 1000 to need confirming within 5m, got %+v", confirmations)
+	}
+	if notifications := cfg.Notifications; notifications.LargeTransactionThreshold != 1000 || notifications.HourlyCap != 10 || notifications.MaxAttempts != 5 || notifications.RetryBackoff != 30*time.Second {
+		t.Errorf("Expected transactions over 1000 notified, 10 an hour, tried 5 times from 30s apart, got %+v", notifications)
+	}
+	if cfg.EmailSender == nil {
+		t.Error("Expected notification emails to be logged without SMTP_HOST")
+	}
+	if cfg.EventsURL != "http://localhost:8081/internal/events" {
+		t.Errorf("Expected events to be published to the client service, got %s", cfg.EventsURL)
+	}
+}
+
+func TestLoad_ReportsEveryProblem(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("DB_PORT", "postgres")
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("TLS_CERT_FILE", "/nonexistent/cert.pem")
+	t.Setenv("TLS_KEY_FILE", "/nonexistent/key.pem")
+	t.Setenv("MTLS_CA_FILE", "/etc/microbank/ca.pem")
+	t.Setenv("INTERNAL_SERVICE_TOKEN", "change-me")
+	t.Setenv("CLIENT_SERVICE_URL", "client-service:8081")
+	t.Setenv("JWT_HS256_FALLBACK", "true")
+	t.Setenv("JWT_SECRET", "microBankSecret")
+	t.Setenv("KYC_WITHDRAWAL_LIMIT", "-5")
+	t.Setenv("WITHDRAWAL_FEE_PERCENT", "150")
+	t.Setenv("WITHDRAWAL_FREE_PER_MONTH", "three")
+	t.Setenv("TRANSACTION_DESCRIPTION_MAX_LENGTH", "3")
+	t.Setenv("MAINTENANCE_MODE", "sometimes")
+	t.Setenv("DEPOSIT_WORKERS", "-1")
+	t.Setenv("DEPOSIT_MAX_ATTEMPTS", "0")
+	t.Setenv("TRANSACTION_ARCHIVE_AFTER_MONTHS", "6")
+	t.Setenv("SUSPICIOUS_RAPID_CYCLE_PERCENT", "0")
+	t.Setenv("SUSPICIOUS_STRUCTURING_MIN_COUNT", "1")
+	t.Setenv("SUSPICIOUS_BALANCE_SWING_ENABLED", "often")
+	t.Setenv("MAX_BENEFICIARIES_PER_USER", "0")
+	t.Setenv("BENEFICIARY_COOLING_OFF_HOURS", "a day")
+	t.Setenv("EXTERNAL_TRANSFER_FAILURE_PERCENT", "150")
+	t.Setenv("CHEQUE_HOLD_HOURS", "1000")
+	t.Setenv("TRANSACTION_PIN_MAX_ATTEMPTS", "0")
+	t.Setenv("TRANSACTION_PIN_LOCKOUT_MINUTES", "soon")
+	t.Setenv("ACCOUNT_INVITATION_TTL_HOURS", "0")
+	t.Setenv("TRANSACTION_CONFIRMATION_THRESHOLD", "0")
+	t.Setenv("TRANSACTION_CONFIRMATION_TTL_MINUTES", "-5")
+	t.Setenv("LARGE_TRANSACTION_THRESHOLD", "0")
+	t.Setenv("NOTIFICATION_HOURLY_CAP", "0")
+	t.Setenv("NOTIFICATION_MAX_ATTEMPTS", "many")
+	t.Setenv("NOTIFICATION_RETRY_SECONDS", "0")
+
+	_, err := Load()
+	var configErr *sharedconfig.Error
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected a *sharedconfig.Error, got %v", err)
+	}
+
+	for _, want := range []string{
+		"invalid DB_PORT",
+		"DB_PASSWORD is required",
+		"failed to read TLS file",
+		"mutual TLS requires MTLS_CERT_FILE, MTLS_KEY_FILE, INTERNAL_PORT",
+		"INTERNAL_SERVICE_TOKEN must be at least 32 characters",
+		"invalid CLIENT_SERVICE_URL",
+		"JWT_SECRET must be at least 32 characters",
+		"invalid KYC_WITHDRAWAL_LIMIT",
+		"invalid WITHDRAWAL_FEE_PERCENT",
+		"invalid WITHDRAWAL_FREE_PER_MONTH",
+		"invalid TRANSACTION_DESCRIPTION_MAX_LENGTH",
+		"invalid MAINTENANCE_MODE",
+		"invalid DEPOSIT_WORKERS",
+		"invalid DEPOSIT_MAX_ATTEMPTS",
+		"invalid TRANSACTION_ARCHIVE_AFTER_MONTHS",
+		"invalid SUSPICIOUS_RAPID_CYCLE_PERCENT",
+		"invalid SUSPICIOUS_STRUCTURING_MIN_COUNT",
+		"invalid SUSPICIOUS_BALANCE_SWING_ENABLED",
+		"invalid MAX_BENEFICIARIES_PER_USER",
+		"invalid BENEFICIARY_COOLING_OFF_HOURS",
+		"invalid EXTERNAL_TRANSFER_FAILURE_PERCENT",
+		"invalid CHEQUE_HOLD_HOURS",
+		"invalid TRANSACTION_PIN_MAX_ATTEMPTS",
+		"invalid TRANSACTION_PIN_LOCKOUT_MINUTES",
+		"invalid ACCOUNT_INVITATION_TTL_HOURS",
+		"invalid TRANSACTION_CONFIRMATION_THRESHOLD",
+		"invalid TRANSACTION_CONFIRMATION_TTL_MINUTES",
+		"invalid LARGE_TRANSACTION_THRESHOLD",
+		"invalid NOTIFICATION_HOURLY_CAP",
+		"invalid NOTIFICATION_MAX_ATTEMPTS",
+		"invalid NOTIFICATION_RETRY_SECONDS",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to report %q, got:\n%v", want, err)
+		}
+	}
+}
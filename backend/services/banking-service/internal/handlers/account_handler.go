@@ -1,38 +1,43 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
 	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
 )
 
 // AccountHandler handles account-related HTTP requests
 type AccountHandler struct {
-	accountService     *services.AccountService
 	transactionService *services.TransactionService
 }
 
 // NewAccountHandler creates a new account handler
-func NewAccountHandler(accountService *services.AccountService, transactionService *services.TransactionService) *AccountHandler {
+func NewAccountHandler(transactionService *services.TransactionService) *AccountHandler {
 	return &AccountHandler{
-		accountService:     accountService,
 		transactionService: transactionService,
 	}
 }
 
-// GetBalance retrieves the current account balance for the authenticated user
+// GetBalance retrieves the current account balance for the authenticated
+// user, or of the joint account selected by the account_id query parameter.
+// Clients that just made a transaction may pass its account_version as
+// min_version to read their own write: the account is read again from the
+// primary database until it reaches that version, for a bounded time.
 func (h *AccountHandler) GetBalance(c *gin.Context) {
 	// Get user ID from context (set by AuthMiddleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "User information not found in context",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
 		})
 		return
 	}
@@ -40,46 +45,75 @@ func (h *AccountHandler) GetBalance(c *gin.Context) {
 	// Parse user ID
 	userUUID, err := uuid.Parse(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Invalid user ID format",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
 		})
 		return
 	}
 
-	// Get account balance
-	balance, err := h.accountService.GetAccountBalance(userUUID)
+	accountID, ok := accountIDFromQuery(c)
+	if !ok {
+		return
+	}
+	var minVersion int64
+	if value := c.Query("min_version"); value != "" {
+		minVersion, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || minVersion < 0 {
+			respondInvalidQuery(c, errors.New("min_version must be an account version"))
+			return
+		}
+	}
+
+	// Get account balance, at least as new as min_version
+	account, err := h.transactionService.AccountAtVersion(userUUID, accountID, minVersion)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "ACCOUNT_NOT_FOUND",
+			Message: "Account not found",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Pending external transfers hold part of the balance
+	balance := account.Balance
+	held, err := h.transactionService.HeldFunds(account.ID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": gin.H{
-				"code":    "ACCOUNT_NOT_FOUND",
-				"message": "Account not found",
-				"details": err.Error(),
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_BALANCE_FAILED",
+			Message: "Failed to fetch held funds",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
 
 	// Return balance
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Balance retrieved successfully",
-		"balance": balance,
-		"currency": "USD",
+	httpx.RespondOK(c, gin.H{
+		"message":           "Balance retrieved successfully",
+		"account_id":        account.ID,
+		"version":           account.Version,
+		"balance":           models.Amount(balance),
+		"held":              models.Amount(held),
+		"available_balance": models.Amount(balance - held),
+		"currency":          models.Currency,
 	})
 }
 
-// GetTransactions retrieves transaction history for the authenticated user
+// GetTransactions retrieves the transaction history of the authenticated
+// user's account, or of the joint account selected by the account_id query
+// parameter, with the member who made each transaction
 func (h *AccountHandler) GetTransactions(c *gin.Context) {
 	// Get user ID from context (set by AuthMiddleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "User information not found in context",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
 		})
 		return
 	}
@@ -87,11 +121,10 @@ func (h *AccountHandler) GetTransactions(c *gin.Context) {
 	// Parse user ID
 	userUUID, err := uuid.Parse(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Invalid user ID format",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
 		})
 		return
 	}
@@ -110,15 +143,83 @@ func (h *AccountHandler) GetTransactions(c *gin.Context) {
 		offset = 0
 	}
 
+	// Pages after the first can start after the last transaction seen
+	var before *uuid.UUID
+	if beforeStr := c.Query("before"); beforeStr != "" {
+		beforeID, err := uuid.Parse(beforeStr)
+		if err != nil {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_QUERY_PARAMETER",
+				Message: "before must be a transaction ID",
+			})
+			return
+		}
+		before = &beforeID
+	}
+
+	// Filter and sort, newest first by default
+	filter, err := parseTransactionFilter(c, "desc")
+	if err != nil {
+		respondInvalidQuery(c, err)
+		return
+	}
+
+	accountID, ok := accountIDFromQuery(c)
+	if !ok {
+		return
+	}
+	account, _, err := h.transactionService.AccountFor(userUUID, accountID)
+	if err != nil {
+		if errors.Is(err, services.ErrAccountNotFound) {
+			respondAccountNotFound(c)
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_TRANSACTIONS_FAILED",
+			Message: "Failed to fetch transactions",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Count the transactions matching the filters, which is all HEAD
+	// requests get, so clients can tell how many pages there are
+	total, err := h.transactionService.GetTransactionCountByAccountID(account.ID, filter)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_TRANSACTIONS_FAILED",
+			Message: "Failed to fetch transactions",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+	if c.Request.Method == http.MethodHead {
+		httpx.RespondTotal(c, total, limit)
+		return
+	}
+	httpx.SetTotalHeaders(c, total, limit)
+
 	// Get transactions
-	transactions, err := h.transactionService.GetTransactionsByUserID(userUUID, limit, offset)
+	transactions, err := h.transactionService.GetTransactionsByAccountID(account.ID, filter, before, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "FETCH_TRANSACTIONS_FAILED",
-				"message": "Failed to fetch transactions",
-				"details": err.Error(),
-			},
+		if errors.Is(err, services.ErrTransactionNotFound) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_QUERY_PARAMETER",
+				Message: "before must be a transaction on the account",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_TRANSACTIONS_FAILED",
+			Message: "Failed to fetch transactions",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
@@ -128,23 +229,29 @@ func (h *AccountHandler) GetTransactions(c *gin.Context) {
 	for _, transaction := range transactions {
 		transactionResponses = append(transactionResponses, gin.H{
 			"id":             transaction.ID,
+			"user_id":        transaction.UserID,
 			"type":           transaction.Type,
-			"amount":         transaction.Amount,
-			"balance_before": transaction.BalanceBefore,
-			"balance_after":  transaction.BalanceAfter,
+			"amount":         models.Amount(transaction.Amount),
+			"balance_before": models.Amount(transaction.BalanceBefore),
+			"balance_after":  models.Amount(transaction.BalanceAfter),
 			"description":    transaction.Description,
 			"created_at":     transaction.CreatedAt,
 		})
 	}
 
+	// A full page may be followed by another
+	pagination := &httpx.Pagination{
+		Limit:  limit,
+		Offset: offset,
+		Count:  len(transactionResponses),
+	}
+	if len(transactions) == limit {
+		pagination.NextCursor = transactions[len(transactions)-1].ID.String()
+	}
+
 	// Return transactions
-	c.JSON(http.StatusOK, gin.H{
+	httpx.RespondPage(c, gin.H{
 		"message": "Transactions retrieved successfully",
 		"transactions": transactionResponses,
-		"pagination": gin.H{
-			"limit":  limit,
-			"offset": offset,
-			"count":  len(transactionResponses),
-		},
-	})
+	}, pagination)
 }
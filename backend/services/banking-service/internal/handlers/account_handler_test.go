@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+func TestAccountHandler_GetTransactionsHead(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+	account := &models.Account{ID: uuid.New(), UserID: userID, Balance: 500}
+	accounts := &fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: account}}
+	transactions := &fakeTransactionRepo{transactions: []models.Transaction{
+		{ID: uuid.New(), AccountID: account.ID, UserID: userID, Type: models.TransactionTypeDeposit, Amount: 300},
+		{ID: uuid.New(), AccountID: account.ID, UserID: userID, Type: models.TransactionTypeWithdrawal, Amount: 20},
+		{ID: uuid.New(), AccountID: account.ID, UserID: userID, Type: models.TransactionTypeDeposit, Amount: 220},
+		// Another user's transaction is never counted
+		{ID: uuid.New(), AccountID: uuid.New(), UserID: uuid.New(), Type: models.TransactionTypeDeposit, Amount: 1000},
+	}}
+	handler := NewAccountHandler(services.NewTransactionService(transactions, accounts))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", userID.String()) })
+	r.GET("/account/transactions", handler.GetTransactions)
+	r.HEAD("/account/transactions", handler.GetTransactions)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCount  string
+		wantPages  string
+	}{
+		{name: "no filters", query: "?limit=2", wantStatus: http.StatusOK, wantCount: "3", wantPages: "2"},
+		{name: "filtered", query: "?limit=2&min_amount=100", wantStatus: http.StatusOK, wantCount: "2", wantPages: "1"},
+		{name: "filtered to nothing", query: "?min_amount=500", wantStatus: http.StatusOK, wantCount: "0", wantPages: "0"},
+		{name: "invalid filter", query: "?min_amount=lots", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/account/transactions"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("Expected no body, got %s", w.Body.String())
+			}
+			if count, pages := w.Header().Get(httpx.TotalCountHeader), w.Header().Get(httpx.TotalPagesHeader); count != tt.wantCount || pages != tt.wantPages {
+				t.Errorf("Expected %s transactions on %s pages, got %s on %s", tt.wantCount, tt.wantPages, count, pages)
+			}
+
+			// GET counts the same transactions
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/account/transactions"+tt.query, nil))
+			if w.Code != http.StatusOK || w.Header().Get(httpx.TotalCountHeader) != tt.wantCount {
+				t.Errorf("Expected GET to count %s transactions, got status %d and %s", tt.wantCount, w.Code, w.Header().Get(httpx.TotalCountHeader))
+			}
+		})
+	}
+}
+
+// laggingAccountRepo is a fakeAccountRepo whose accounts reach the next
+// version each time they are read, as when a write lands after the read
+type laggingAccountRepo struct {
+	*fakeAccountRepo
+}
+
+func (r laggingAccountRepo) GetAccountByUserID(userID uuid.UUID) (*models.Account, error) {
+	account, err := r.fakeAccountRepo.GetAccountByUserID(userID)
+	if err == nil {
+		r.accounts[userID].Version++
+	}
+	return account, err
+}
+
+func TestAccountHandler_GetBalanceMinVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantVersion int64
+	}{
+		{name: "no min_version", query: "", wantStatus: http.StatusOK, wantVersion: 1},
+		{name: "version already reached", query: "?min_version=1", wantStatus: http.StatusOK, wantVersion: 1},
+		{name: "read again until reached", query: "?min_version=3", wantStatus: http.StatusOK, wantVersion: 3},
+		{name: "never reached", query: "?min_version=100", wantStatus: http.StatusOK, wantVersion: 5},
+		{name: "invalid", query: "?min_version=-1", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			account := &models.Account{ID: uuid.New(), UserID: userID, Balance: 500, Version: 1}
+			accounts := laggingAccountRepo{&fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: account}}}
+			handler := NewAccountHandler(services.NewTransactionService(&fakeTransactionRepo{}, accounts))
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set("user_id", userID.String()) })
+			r.GET("/account/balance", handler.GetBalance)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/account/balance"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body struct {
+				Data struct {
+					Version int64 `json:"version"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Data.Version != tt.wantVersion {
+				t.Errorf("Expected version %d, got %d", tt.wantVersion, body.Data.Version)
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+	"microbank/pkg/resilience"
+)
+
+// AccountMemberHandler handles HTTP requests for joint account members and
+// invitations
+type AccountMemberHandler struct {
+	memberService *services.AccountMemberService
+}
+
+// NewAccountMemberHandler creates a new account member handler
+func NewAccountMemberHandler(memberService *services.AccountMemberService) *AccountMemberHandler {
+	return &AccountMemberHandler{
+		memberService: memberService,
+	}
+}
+
+// ListMemberships retrieves the accounts the current user is a member of,
+// their own first
+func (h *AccountMemberHandler) ListMemberships(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get memberships
+	memberships, err := h.memberService.ListMemberships(userID)
+	if err != nil {
+		respondAccountMemberError(c, err, "FETCH_ACCOUNTS_FAILED", "Failed to fetch accounts")
+		return
+	}
+
+	// Return memberships
+	httpx.RespondOK(c, gin.H{
+		"message":  "Accounts retrieved successfully",
+		"accounts": memberships,
+	})
+}
+
+// ListMembers retrieves the members of the account selected by the
+// account_id query parameter, the current user's own by default, and the
+// invitations to it that can still be accepted
+func (h *AccountMemberHandler) ListMembers(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	accountID, ok := accountIDFromQuery(c)
+	if !ok {
+		return
+	}
+
+	// Get members
+	account, members, invitations, err := h.memberService.ListMembers(userID, accountID)
+	if err != nil {
+		respondAccountMemberError(c, err, "FETCH_ACCOUNT_MEMBERS_FAILED", "Failed to fetch account members")
+		return
+	}
+
+	// Return members and invitations
+	memberResponses := make([]models.AccountMemberResponse, 0, len(members))
+	for i := range members {
+		memberResponses = append(memberResponses, members[i].ToResponse(account.UserID))
+	}
+	httpx.RespondOK(c, gin.H{
+		"message":     "Account members retrieved successfully",
+		"account_id":  account.ID,
+		"members":     memberResponses,
+		"invitations": accountInvitationResponses(invitations),
+	})
+}
+
+// Invite invites a user, by email, to join an account the current user
+// owns
+func (h *AccountMemberHandler) Invite(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.InviteAccountMemberRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Create invitation
+	invitation, err := h.memberService.Invite(userID, request)
+	if err != nil {
+		respondAccountMemberError(c, err, "INVITE_ACCOUNT_MEMBER_FAILED", "Failed to invite account member")
+		return
+	}
+
+	// Return invitation
+	httpx.RespondCreated(c, gin.H{
+		"message":    "Invitation sent successfully",
+		"invitation": invitation.ToResponse(time.Now()),
+	})
+}
+
+// ListInvitations retrieves the invitations the current user can still
+// accept, newest first
+func (h *AccountMemberHandler) ListInvitations(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get invitations
+	invitations, err := h.memberService.ListInvitations(userID)
+	if err != nil {
+		respondAccountMemberError(c, err, "FETCH_INVITATIONS_FAILED", "Failed to fetch invitations")
+		return
+	}
+
+	// Return invitations
+	httpx.RespondOK(c, gin.H{
+		"message":     "Invitations retrieved successfully",
+		"invitations": accountInvitationResponses(invitations),
+	})
+}
+
+// AcceptInvitation makes the current user a member of the account one of
+// their invitations is to
+func (h *AccountMemberHandler) AcceptInvitation(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	invitationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_INVITATION_ID",
+			Message: "Invalid invitation ID format",
+		})
+		return
+	}
+
+	// Accept invitation
+	invitation, err := h.memberService.AcceptInvitation(userID, invitationID)
+	if err != nil {
+		respondAccountMemberError(c, err, "ACCEPT_INVITATION_FAILED", "Failed to accept invitation")
+		return
+	}
+
+	// Return the accepted invitation
+	invitation.Status = models.AccountInvitationStatusAccepted
+	httpx.RespondOK(c, gin.H{
+		"message":    "Invitation accepted successfully",
+		"invitation": invitation.ToResponse(time.Now()),
+	})
+}
+
+// UpdateMember replaces the role and spending limit of a member of the
+// account selected by the account_id query parameter (owners only)
+func (h *AccountMemberHandler) UpdateMember(c *gin.Context) {
+	userID, accountID, memberID, ok := accountMemberIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.UpdateAccountMemberRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Update member
+	account, member, err := h.memberService.UpdateMember(userID, accountID, memberID, request)
+	if err != nil {
+		respondAccountMemberError(c, err, "UPDATE_ACCOUNT_MEMBER_FAILED", "Failed to update account member")
+		return
+	}
+
+	// Return member
+	httpx.RespondOK(c, gin.H{
+		"message": "Account member updated successfully",
+		"member":  member.ToResponse(account.UserID),
+	})
+}
+
+// RemoveMember removes a member from the account selected by the
+// account_id query parameter. Owners remove other members; members may
+// remove themselves.
+func (h *AccountMemberHandler) RemoveMember(c *gin.Context) {
+	userID, accountID, memberID, ok := accountMemberIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Remove member
+	if err := h.memberService.RemoveMember(userID, accountID, memberID); err != nil {
+		respondAccountMemberError(c, err, "REMOVE_ACCOUNT_MEMBER_FAILED", "Failed to remove account member")
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Account member removed successfully",
+	})
+}
+
+// respondAccountMemberError writes the response for an error from the
+// account member service, falling back to a 500 with code and message
+func respondAccountMemberError(c *gin.Context, err error, code, message string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondValidationError(c, validationErr)
+	case errors.Is(err, services.ErrAccountNotFound):
+		respondAccountNotFound(c)
+	case errors.Is(err, services.ErrAccountOwnerRequired):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "ACCOUNT_OWNER_REQUIRED",
+			Message: "Only owners of the account can do this",
+		})
+	case errors.Is(err, services.ErrAccountMemberNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "ACCOUNT_MEMBER_NOT_FOUND",
+			Message: "Account member not found",
+		})
+	case errors.Is(err, services.ErrInviteeNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "INVITEE_NOT_FOUND",
+			Message: "No user with that email address",
+		})
+	case errors.Is(err, services.ErrInvitationNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "INVITATION_NOT_FOUND",
+			Message: "Invitation not found",
+		})
+	case errors.Is(err, services.ErrAccountMemberExists):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "ACCOUNT_MEMBER_EXISTS",
+			Message: "User is already a member of the account",
+		})
+	case errors.Is(err, services.ErrInvitationPending):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "INVITATION_PENDING",
+			Message: "User already has a pending invitation to the account",
+		})
+	case errors.Is(err, services.ErrInvitationNotPending):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "INVITATION_NOT_PENDING",
+			Message: "Invitation was already accepted or has expired",
+		})
+	case errors.Is(err, services.ErrAccountHolderRemoval):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "ACCOUNT_HOLDER_REMOVAL",
+			Message: "The user the account was opened for cannot be removed",
+		})
+	case errors.Is(err, services.ErrLastAccountOwner):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "LAST_ACCOUNT_OWNER",
+			Message: "The account must keep at least one owner",
+		})
+	case errors.Is(err, resilience.ErrDependencyUnavailable):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "USER_LOOKUP_UNAVAILABLE",
+			Message: "Unable to look up the invited user",
+			Details: middleware.ErrorDetails(c, err),
+		})
+	default:
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    code,
+			Message: message,
+			Details: middleware.ErrorDetails(c, err),
+		})
+	}
+}
+
+// respondAccountNotFound writes a 404 for an account the user is not a
+// member of
+func respondAccountNotFound(c *gin.Context) {
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusNotFound,
+		Code:    "ACCOUNT_NOT_FOUND",
+		Message: "Account not found",
+	})
+}
+
+// respondSpendingLimitExceeded writes a 403 for an amount over the
+// member's spending limit on a joint account
+func respondSpendingLimitExceeded(c *gin.Context, spendingLimit *services.SpendingLimitError, amount float64) {
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusForbidden,
+		Code:    "SPENDING_LIMIT_EXCEEDED",
+		Message: "Amount is over your spending limit on this account",
+		Details: gin.H{
+			"requested_amount": models.Amount(amount),
+			"limit":            models.Amount(spendingLimit.Limit),
+		},
+	})
+}
+
+// accountIDFromQuery returns the account selected by the account_id query
+// parameter, or nil for the current user's own account, writing a 400 if
+// it is malformed
+func accountIDFromQuery(c *gin.Context) (*uuid.UUID, bool) {
+	value := c.Query("account_id")
+	if value == "" {
+		return nil, true
+	}
+	accountID, err := uuid.Parse(value)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_QUERY_PARAMETER",
+			Message: "account_id must be an account ID",
+		})
+		return nil, false
+	}
+	return &accountID, true
+}
+
+// accountMemberIDsFromRequest returns the current user's ID, the account
+// selected by the account_id query parameter and the member's user ID in
+// the URL, writing an error response if any is missing or malformed
+func accountMemberIDsFromRequest(c *gin.Context) (uuid.UUID, *uuid.UUID, uuid.UUID, bool) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return uuid.Nil, nil, uuid.Nil, false
+	}
+	accountID, ok := accountIDFromQuery(c)
+	if !ok {
+		return uuid.Nil, nil, uuid.Nil, false
+	}
+
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return uuid.Nil, nil, uuid.Nil, false
+	}
+	return userID, accountID, memberID, true
+}
+
+// accountInvitationResponses converts invitations to their response form
+func accountInvitationResponses(invitations []models.AccountInvitation) []models.AccountInvitationResponse {
+	now := time.Now()
+	responses := make([]models.AccountInvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		responses = append(responses, invitations[i].ToResponse(now))
+	}
+	return responses
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// Page size bounds for staff transaction listings. A page's customers are
+// looked up in one call, which covers at most 100 of them.
+const (
+	defaultAdminTransactionPageSize = 50
+	maxAdminTransactionPageSize     = 100
+)
+
+// customerDetailsWarning tells staff why transactions are missing their
+// customer's details
+const customerDetailsWarning = "Customer details could not be looked up, so customer_email and customer_name are null"
+
+// AdminTransactionHandler handles staff views of transactions
+type AdminTransactionHandler struct {
+	transactionService *services.TransactionService
+	enricher           *services.TransactionEnricher
+}
+
+// NewAdminTransactionHandler creates a new admin transaction handler
+func NewAdminTransactionHandler(transactionService *services.TransactionService, enricher *services.TransactionEnricher) *AdminTransactionHandler {
+	return &AdminTransactionHandler{
+		transactionService: transactionService,
+		enricher:           enricher,
+	}
+}
+
+// ListTransactions retrieves every user's transactions, newest first
+// unless sorted otherwise, with their customer's details (staff only)
+func (h *AdminTransactionHandler) ListTransactions(c *gin.Context) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultAdminTransactionPageSize
+	}
+	if limit > maxAdminTransactionPageSize {
+		limit = maxAdminTransactionPageSize
+	}
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	filter, err := parseTransactionFilter(c, "desc")
+	if err != nil {
+		respondInvalidQuery(c, err)
+		return
+	}
+
+	// Count the transactions matching the filters, which is all HEAD
+	// requests get
+	total, err := h.transactionService.CountAllTransactions(filter)
+	if err != nil {
+		respondFetchTransactionsFailed(c, err)
+		return
+	}
+	if c.Request.Method == http.MethodHead {
+		httpx.RespondTotal(c, total, limit)
+		return
+	}
+
+	// Get transactions
+	transactions, err := h.transactionService.GetAllTransactions(filter, limit, offset)
+	if err != nil {
+		respondFetchTransactionsFailed(c, err)
+		return
+	}
+
+	// Return transactions with their customers
+	response := gin.H{"message": "Transactions retrieved successfully"}
+	response["transactions"] = enrichTransactions(h.enricher, transactions, response)
+	httpx.SetTotalHeaders(c, total, limit)
+	httpx.RespondPage(c, response, httpx.NewPagination(limit, offset, len(transactions), total))
+}
+
+// respondFetchTransactionsFailed reports a failed transaction listing
+func respondFetchTransactionsFailed(c *gin.Context, err error) {
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusInternalServerError,
+		Code:    "FETCH_TRANSACTIONS_FAILED",
+		Message: "Failed to fetch transactions",
+		Details: middleware.ErrorDetails(c, err),
+	})
+}
+
+// GetTransaction retrieves any transaction with its customer's details
+// (staff only)
+func (h *AdminTransactionHandler) GetTransaction(c *gin.Context) {
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_TRANSACTION_ID",
+			Message: "Invalid transaction ID format",
+		})
+		return
+	}
+
+	// Get transaction
+	transaction, err := h.transactionService.GetTransactionByID(transactionID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "TRANSACTION_NOT_FOUND",
+			Message: "Transaction not found",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return transaction with its customer
+	response := gin.H{"message": "Transaction retrieved successfully"}
+	response["transaction"] = enrichTransactions(h.enricher, []models.Transaction{*transaction}, response)[0]
+	httpx.RespondOK(c, response)
+}
+
+// enrichTransactions adds their customer's details to transactions shown to
+// staff. When the customers cannot be looked up, the transactions are shown
+// without them and response gets a warning.
+func enrichTransactions(enricher *services.TransactionEnricher, transactions []models.Transaction, response gin.H) []models.AdminTransactionResponse {
+	enriched, err := enricher.Enrich(transactions)
+	if err != nil {
+		log.Printf("Showing transactions without customer details: %v", err)
+		response["warning"] = customerDetailsWarning
+	}
+	return enriched
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+func TestAdminTransactionHandler_ListTransactionsHead(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	transactions := &fakeTransactionRepo{transactions: []models.Transaction{
+		{ID: uuid.New(), AccountID: uuid.New(), UserID: uuid.New(), Type: models.TransactionTypeDeposit, Amount: 300},
+		{ID: uuid.New(), AccountID: uuid.New(), UserID: uuid.New(), Type: models.TransactionTypeWithdrawal, Amount: 20},
+		{ID: uuid.New(), AccountID: uuid.New(), UserID: uuid.New(), Type: models.TransactionTypeDeposit, Amount: 220},
+	}}
+	handler := NewAdminTransactionHandler(services.NewTransactionService(transactions, &fakeAccountRepo{}), services.NewTransactionEnricher(fakeUserDetails{}))
+
+	r := gin.New()
+	r.GET("/admin/transactions", handler.ListTransactions)
+	r.HEAD("/admin/transactions", handler.ListTransactions)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCount  string
+		wantPages  string
+	}{
+		{name: "no filters", query: "?limit=2", wantStatus: http.StatusOK, wantCount: "3", wantPages: "2"},
+		{name: "filtered", query: "?max_amount=250", wantStatus: http.StatusOK, wantCount: "2", wantPages: "1"},
+		{name: "filtered to nothing", query: "?min_amount=500", wantStatus: http.StatusOK, wantCount: "0", wantPages: "0"},
+		{name: "invalid filter", query: "?order=sideways", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/admin/transactions"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("Expected no body, got %s", w.Body.String())
+			}
+			if count, pages := w.Header().Get(httpx.TotalCountHeader), w.Header().Get(httpx.TotalPagesHeader); count != tt.wantCount || pages != tt.wantPages {
+				t.Errorf("Expected %s transactions on %s pages, got %s on %s", tt.wantCount, tt.wantPages, count, pages)
+			}
+
+			// GET counts the same transactions
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/transactions"+tt.query, nil))
+			if w.Code != http.StatusOK || w.Header().Get(httpx.TotalCountHeader) != tt.wantCount {
+				t.Errorf("Expected GET to count %s transactions, got status %d and %s", tt.wantCount, w.Code, w.Header().Get(httpx.TotalCountHeader))
+			}
+		})
+	}
+}
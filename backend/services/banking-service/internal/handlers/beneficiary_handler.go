@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// BeneficiaryHandler handles beneficiary HTTP requests
+type BeneficiaryHandler struct {
+	beneficiaryService *services.BeneficiaryService
+}
+
+// NewBeneficiaryHandler creates a new beneficiary handler
+func NewBeneficiaryHandler(beneficiaryService *services.BeneficiaryService) *BeneficiaryHandler {
+	return &BeneficiaryHandler{
+		beneficiaryService: beneficiaryService,
+	}
+}
+
+// CreateBeneficiary saves another account as a beneficiary of the current
+// user
+func (h *BeneficiaryHandler) CreateBeneficiary(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.CreateBeneficiaryRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Create beneficiary
+	beneficiary, err := h.beneficiaryService.CreateBeneficiary(userID, request)
+	if err != nil {
+		h.respondBeneficiaryError(c, err, "CREATE_BENEFICIARY_FAILED", "Failed to create beneficiary")
+		return
+	}
+
+	// Return beneficiary
+	httpx.RespondCreated(c, gin.H{
+		"message":     "Beneficiary created successfully",
+		"beneficiary": beneficiary.ToResponse(h.beneficiaryService.Rules()),
+	})
+}
+
+// ListBeneficiaries retrieves the current user's beneficiaries
+func (h *BeneficiaryHandler) ListBeneficiaries(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get beneficiaries
+	beneficiaries, err := h.beneficiaryService.ListBeneficiaries(userID)
+	if err != nil {
+		h.respondBeneficiaryError(c, err, "FETCH_BENEFICIARIES_FAILED", "Failed to fetch beneficiaries")
+		return
+	}
+
+	rules := h.beneficiaryService.Rules()
+	responses := make([]models.BeneficiaryResponse, 0, len(beneficiaries))
+	for i := range beneficiaries {
+		responses = append(responses, beneficiaries[i].ToResponse(rules))
+	}
+
+	// Return beneficiaries
+	httpx.RespondOK(c, gin.H{
+		"message":       "Beneficiaries retrieved successfully",
+		"beneficiaries": responses,
+		"limit":         rules.MaxPerUser,
+	})
+}
+
+// DeleteBeneficiary deletes one of the current user's beneficiaries
+func (h *BeneficiaryHandler) DeleteBeneficiary(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	beneficiaryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_BENEFICIARY_ID",
+			Message: "Invalid beneficiary ID format",
+		})
+		return
+	}
+
+	// Delete beneficiary
+	if err := h.beneficiaryService.DeleteBeneficiary(userID, beneficiaryID); err != nil {
+		h.respondBeneficiaryError(c, err, "DELETE_BENEFICIARY_FAILED", "Failed to delete beneficiary")
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Beneficiary deleted successfully",
+	})
+}
+
+// respondBeneficiaryError writes the response for an error from the
+// beneficiary service, falling back to a 500 with code and message
+func (h *BeneficiaryHandler) respondBeneficiaryError(c *gin.Context, err error, code, message string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondValidationError(c, validationErr)
+	case errors.Is(err, services.ErrBeneficiaryNotFound):
+		respondBeneficiaryNotFound(c)
+	case errors.Is(err, services.ErrDestinationAccountNotFound):
+		respondDestinationAccountNotFound(c)
+	case errors.Is(err, services.ErrBeneficiaryExists):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "BENEFICIARY_EXISTS",
+			Message: "The account is already one of your beneficiaries",
+		})
+	case errors.Is(err, services.ErrBeneficiaryLimitReached):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "BENEFICIARY_LIMIT_REACHED",
+			Message: "You have saved as many beneficiaries as allowed",
+			Details: gin.H{"limit": h.beneficiaryService.Rules().MaxPerUser},
+		})
+	default:
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    code,
+			Message: message,
+			Details: middleware.ErrorDetails(c, err),
+		})
+	}
+}
+
+// respondBeneficiaryNotFound writes a 404 for a beneficiary that does not
+// exist or belongs to another user
+func respondBeneficiaryNotFound(c *gin.Context) {
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusNotFound,
+		Code:    "BENEFICIARY_NOT_FOUND",
+		Message: "Beneficiary not found",
+	})
+}
+
+// respondDestinationAccountNotFound writes a 404 for a beneficiary or
+// transfer to an account that does not exist
+func respondDestinationAccountNotFound(c *gin.Context) {
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusNotFound,
+		Code:    "DESTINATION_ACCOUNT_NOT_FOUND",
+		Message: "Destination account not found",
+	})
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/bodylimit"
+	"microbank/pkg/httpx"
+)
+
+func init() {
+	// Report invalid fields by the names clients send them under
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(requestFieldName)
+	}
+}
+
+// requestFieldName returns the JSON name of a struct field
+func requestFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// RejectUnknownFields makes JSON request bodies with fields the endpoint
+// does not accept fail validation. It is off by default so clients sending
+// extra fields keep working.
+func RejectUnknownFields(reject bool) {
+	binding.EnableDecoderDisallowUnknownFields = reject
+}
+
+// validationMessages holds a human-readable message for each binding rule.
+// Rules without one are described by name.
+var validationMessages = map[string]func(fe validator.FieldError) string{
+	"required": func(fe validator.FieldError) string { return "is required" },
+	"email":    func(fe validator.FieldError) string { return "must be a valid email address" },
+	"min":      func(fe validator.FieldError) string { return "must be at least " + ruleBound(fe) },
+	"max":      func(fe validator.FieldError) string { return "must be at most " + ruleBound(fe) },
+	"len":      func(fe validator.FieldError) string { return "must be exactly " + ruleBound(fe) },
+	"gt":       func(fe validator.FieldError) string { return "must be greater than " + fe.Param() },
+	"numeric":  func(fe validator.FieldError) string { return "must be a number" },
+}
+
+// ruleBound describes the parameter of a min, max or len rule in the unit
+// the field is measured in
+func ruleBound(fe validator.FieldError) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return fe.Param() + " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fe.Param() + " items"
+	}
+	return fe.Param()
+}
+
+// bindJSON binds the request body into obj, writing a 400 listing every
+// invalid field if it cannot, or a 413 if the body is over the limit set
+// by middleware.JSONBody. It reports whether binding succeeded.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindWith(obj, binding.JSON); err != nil {
+		if tooLarge, ok := bodylimit.IsTooLarge(err); ok {
+			httpx.RespondError(c, tooLarge)
+			return false
+		}
+		respondValidationError(c, bindingValidationError(err, "body"))
+		return false
+	}
+	return true
+}
+
+// respondValidationError writes a 400 listing every invalid field of a
+// request
+func respondValidationError(c *gin.Context, validationErr *services.ValidationError) {
+	details := make([]gin.H, 0, len(validationErr.Fields))
+	for _, f := range validationErr.Fields {
+		details = append(details, gin.H{
+			"field":   f.Field,
+			"rule":    f.Rule,
+			"message": f.Message,
+		})
+	}
+
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusBadRequest,
+		Code:    "VALIDATION_ERROR",
+		Message: "One or more fields are invalid",
+		Details: details,
+	})
+}
+
+// bindingValidationError converts a binding error into a
+// *services.ValidationError. Errors that are not about a single field,
+// such as malformed JSON, are reported against source.
+func bindingValidationError(err error, source string) *services.ValidationError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]services.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			message := "must satisfy the " + fe.Tag() + " rule"
+			if describe, ok := validationMessages[fe.Tag()]; ok {
+				message = describe(fe)
+			}
+			fields = append(fields, services.FieldError{
+				Field:   fieldPath(fe),
+				Rule:    fe.Tag(),
+				Message: message,
+			})
+		}
+		return &services.ValidationError{Fields: fields}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return fieldValidationError(typeErr.Field, "type", "must be "+jsonTypeName(typeErr.Type))
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return fieldValidationError(source, "json", "must be valid JSON")
+	case errors.Is(err, io.EOF):
+		return fieldValidationError(source, "required", "is required")
+	}
+
+	// With unknown fields disallowed, the decoder names the first one it finds
+	if name, ok := strings.CutPrefix(err.Error(), `json: unknown field "`); ok {
+		return fieldValidationError(strings.TrimSuffix(name, `"`), "unknown", "is not an accepted field")
+	}
+
+	return fieldValidationError(source, "format", "could not be parsed")
+}
+
+// fieldValidationError reports a single invalid field
+func fieldValidationError(field, rule, message string) *services.ValidationError {
+	return &services.ValidationError{Fields: []services.FieldError{{Field: field, Rule: rule, Message: message}}}
+}
+
+// fieldPath returns the path of an invalid field within the request, such
+// as tokens[0].jti, without the name of the request struct
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
+}
+
+// jsonTypeName describes a Go type as the JSON value it is decoded from
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
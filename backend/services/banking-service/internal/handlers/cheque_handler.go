@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// ChequeHandler handles HTTP requests from branch staff for cheque
+// deposits
+type ChequeHandler struct {
+	transactionService *services.TransactionService
+	chequeService      *services.ChequeService
+}
+
+// NewChequeHandler creates a new cheque handler
+func NewChequeHandler(transactionService *services.TransactionService, chequeService *services.ChequeService) *ChequeHandler {
+	return &ChequeHandler{
+		transactionService: transactionService,
+		chequeService:      chequeService,
+	}
+}
+
+// DepositCheque records a cheque taken for a customer (staff only). Its
+// amount is added to the balance at once but held until hold_until.
+func (h *ChequeHandler) DepositCheque(c *gin.Context) {
+	staffID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.ChequeDepositRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Process cheque deposit
+	cheque, transaction, err := h.transactionService.ProcessChequeDeposit(staffID, request)
+	if err != nil {
+		h.respondChequeError(c, err, "CHEQUE_DEPOSIT_FAILED", "Failed to process cheque deposit")
+		return
+	}
+
+	// Return the held cheque and its deposit
+	httpx.RespondCreated(c, gin.H{
+		"message":     "Cheque deposited and held",
+		"cheque":      cheque.ToResponse(time.Now()),
+		"transaction": transaction.ToResponse(),
+	})
+}
+
+// ListCheques retrieves cheque deposits, newest first, optionally filtered
+// by status and user (staff only)
+func (h *ChequeHandler) ListCheques(c *gin.Context) {
+	filter := models.ChequeDepositFilter{Status: models.ChequeStatus(c.Query("status"))}
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_USER_ID",
+				Message: "Invalid user ID format",
+			})
+			return
+		}
+		filter.UserID = &id
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	// Get cheque deposits
+	page, err := h.chequeService.ListCheques(filter)
+	if err != nil {
+		h.respondChequeError(c, err, "FETCH_CHEQUES_FAILED", "Failed to fetch cheque deposits")
+		return
+	}
+
+	// Return cheque deposits
+	now := time.Now()
+	cheques := make([]models.ChequeDepositResponse, 0, len(page.Cheques))
+	for i := range page.Cheques {
+		cheques = append(cheques, page.Cheques[i].ToResponse(now))
+	}
+	httpx.RespondPage(c, gin.H{
+		"message": "Cheque deposits retrieved successfully",
+		"cheques": cheques,
+	}, httpx.NewPagination(page.Limit, page.Offset, len(cheques), page.Total))
+}
+
+// GetCheque retrieves a cheque deposit (staff only)
+func (h *ChequeHandler) GetCheque(c *gin.Context) {
+	_, chequeID, ok := chequeIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Get cheque deposit
+	cheque, err := h.chequeService.GetCheque(chequeID)
+	if err != nil {
+		h.respondChequeError(c, err, "FETCH_CHEQUE_FAILED", "Failed to fetch cheque deposit")
+		return
+	}
+
+	// Return cheque deposit
+	httpx.RespondOK(c, gin.H{
+		"message": "Cheque deposit retrieved successfully",
+		"cheque":  cheque.ToResponse(time.Now()),
+	})
+}
+
+// ReleaseCheque lifts the hold of a cheque early (staff only)
+func (h *ChequeHandler) ReleaseCheque(c *gin.Context) {
+	staffID, chequeID, ok := chequeIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Release cheque
+	cheque, err := h.chequeService.Release(chequeID, staffID)
+	if err != nil {
+		h.respondChequeError(c, err, "RELEASE_CHEQUE_FAILED", "Failed to release cheque")
+		return
+	}
+
+	// Return cheque deposit
+	httpx.RespondOK(c, gin.H{
+		"message": "Cheque hold released",
+		"cheque":  cheque.ToResponse(time.Now()),
+	})
+}
+
+// ReturnCheque reverses the deposit of a cheque that bounced, optionally
+// charging a fee (staff only)
+func (h *ChequeHandler) ReturnCheque(c *gin.Context) {
+	staffID, chequeID, ok := chequeIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.ReturnChequeRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Return cheque
+	cheque, err := h.chequeService.Return(chequeID, staffID, request)
+	if err != nil {
+		h.respondChequeError(c, err, "RETURN_CHEQUE_FAILED", "Failed to return cheque")
+		return
+	}
+
+	// Return cheque deposit
+	httpx.RespondOK(c, gin.H{
+		"message": "Cheque returned",
+		"cheque":  cheque.ToResponse(time.Now()),
+	})
+}
+
+// respondChequeError writes the response for an error from the cheque
+// services, falling back to a 500 with code and message
+func (h *ChequeHandler) respondChequeError(c *gin.Context, err error, code, message string) {
+	var validationErr *services.ValidationError
+	var insufficientFunds *services.InsufficientFundsError
+	switch {
+	case errors.As(err, &validationErr):
+		respondValidationError(c, validationErr)
+	case errors.Is(err, services.ErrAccountNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "ACCOUNT_NOT_FOUND",
+			Message: "Account not found",
+		})
+	case errors.Is(err, services.ErrAccountFrozen):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "ACCOUNT_FROZEN",
+			Message: "Account is frozen",
+		})
+	case errors.Is(err, services.ErrChequeNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "CHEQUE_NOT_FOUND",
+			Message: "Cheque deposit not found",
+		})
+	case errors.Is(err, services.ErrChequeNotOnHold):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "CHEQUE_NOT_ON_HOLD",
+			Message: "Cheque is no longer on hold",
+		})
+	case errors.As(err, &insufficientFunds):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INSUFFICIENT_FUNDS",
+			Message: "Balance does not cover the return fee",
+			Details: gin.H{"fee": models.Amount(insufficientFunds.Fee)},
+		})
+	default:
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    code,
+			Message: message,
+			Details: middleware.ErrorDetails(c, err),
+		})
+	}
+}
+
+// chequeIDsFromRequest returns the current user's ID and the cheque
+// deposit ID in the path, responding with an error when either is missing
+// or invalid
+func chequeIDsFromRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	chequeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_CHEQUE_ID",
+			Message: "Invalid cheque ID format",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, chequeID, true
+}
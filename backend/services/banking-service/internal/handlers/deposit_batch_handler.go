@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// DepositBatchHandler handles HTTP requests for deposits submitted in
+// batches by other services
+type DepositBatchHandler struct {
+	depositBatchService *services.DepositBatchService
+}
+
+// NewDepositBatchHandler creates a new deposit batch handler
+func NewDepositBatchHandler(depositBatchService *services.DepositBatchService) *DepositBatchHandler {
+	return &DepositBatchHandler{
+		depositBatchService: depositBatchService,
+	}
+}
+
+// CreateBatch queues a batch of deposits to be made in the background.
+// Submitting a batch again with the same reference returns the batch
+// queued first.
+func (h *DepositBatchHandler) CreateBatch(c *gin.Context) {
+	var request models.CreateDepositBatchRequest
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Queue the deposits unless the batch was already submitted
+	batch, created, err := h.depositBatchService.QueueBatch(request)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "QUEUE_DEPOSIT_BATCH_FAILED",
+			Message: "Failed to queue deposit batch",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	if !created {
+		httpx.RespondOK(c, gin.H{
+			"message": "Deposit batch already queued",
+			"batch":   batch,
+			"created": false,
+		})
+		return
+	}
+
+	log.Printf("Queued deposit batch %s of %d deposits", batch.ID, batch.ItemCount)
+
+	// Return accepted response
+	httpx.Respond(c, http.StatusAccepted, gin.H{
+		"message": "Deposit batch queued successfully",
+		"batch":   batch,
+		"created": true,
+	})
+}
+
+// GetBatch reports how far the processing of a batch has come, listing the
+// deposits that failed
+func (h *DepositBatchHandler) GetBatch(c *gin.Context) {
+	// Get batch ID from URL parameter
+	batchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_BATCH_ID",
+			Message: "Invalid deposit batch ID format",
+		})
+		return
+	}
+
+	progress, err := h.depositBatchService.GetProgress(batchID)
+	if err != nil {
+		if errors.Is(err, services.ErrDepositBatchNotFound) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "DEPOSIT_BATCH_NOT_FOUND",
+				Message: "Deposit batch not found",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_DEPOSIT_BATCH_FAILED",
+			Message: "Failed to fetch deposit batch",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Deposit batch retrieved successfully",
+		"batch":   progress,
+	})
+}
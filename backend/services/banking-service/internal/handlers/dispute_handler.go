@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+	"microbank/pkg/resilience"
+)
+
+// DisputeHandler handles transaction dispute HTTP requests
+type DisputeHandler struct {
+	disputeService *services.DisputeService
+}
+
+// NewDisputeHandler creates a new dispute handler
+func NewDisputeHandler(disputeService *services.DisputeService) *DisputeHandler {
+	return &DisputeHandler{
+		disputeService: disputeService,
+	}
+}
+
+// FileDispute disputes one of the current user's transactions
+func (h *DisputeHandler) FileDispute(c *gin.Context) {
+	userID, transactionID, ok := transactionIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.FileDisputeRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// File dispute
+	dispute, err := h.disputeService.FileDispute(userID, transactionID, request, c.GetString(httpx.RequestIDKey))
+	if err != nil {
+		h.respondDisputeError(c, err, "FILE_DISPUTE_FAILED", "Failed to file dispute")
+		return
+	}
+
+	// Return dispute
+	httpx.RespondCreated(c, gin.H{
+		"message": "Dispute filed successfully",
+		"dispute": dispute,
+	})
+}
+
+// GetTransactionDispute retrieves the newest dispute of one of the current
+// user's transactions
+func (h *DisputeHandler) GetTransactionDispute(c *gin.Context) {
+	userID, transactionID, ok := transactionIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Get dispute
+	dispute, err := h.disputeService.GetTransactionDispute(userID, transactionID)
+	if err != nil {
+		h.respondDisputeError(c, err, "FETCH_DISPUTE_FAILED", "Failed to fetch dispute")
+		return
+	}
+
+	// Return dispute
+	httpx.RespondOK(c, gin.H{
+		"message": "Dispute retrieved successfully",
+		"dispute": dispute,
+	})
+}
+
+// ListDisputes retrieves the current user's disputes, newest first
+func (h *DisputeHandler) ListDisputes(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get disputes
+	disputes, err := h.disputeService.ListUserDisputes(userID)
+	if err != nil {
+		h.respondDisputeError(c, err, "FETCH_DISPUTES_FAILED", "Failed to fetch disputes")
+		return
+	}
+	if disputes == nil {
+		disputes = []models.Dispute{}
+	}
+
+	// Return disputes
+	httpx.RespondOK(c, gin.H{
+		"message":  "Disputes retrieved successfully",
+		"disputes": disputes,
+	})
+}
+
+// AdminListDisputes retrieves disputes, oldest first, optionally filtered
+// by status and assignee (staff only)
+func (h *DisputeHandler) AdminListDisputes(c *gin.Context) {
+	filter := models.DisputeFilter{Status: models.DisputeStatus(c.Query("status"))}
+	if assignedTo := c.Query("assigned_to"); assignedTo != "" {
+		assigneeID, err := uuid.Parse(assignedTo)
+		if err != nil {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_ASSIGNEE_ID",
+				Message: "Invalid assignee ID format",
+			})
+			return
+		}
+		filter.AssignedTo = &assigneeID
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	// Get disputes
+	page, err := h.disputeService.ListDisputes(filter)
+	if err != nil {
+		h.respondDisputeError(c, err, "FETCH_DISPUTES_FAILED", "Failed to fetch disputes")
+		return
+	}
+	disputes := page.Disputes
+	if disputes == nil {
+		disputes = []models.Dispute{}
+	}
+
+	// Return disputes
+	httpx.RespondPage(c, gin.H{
+		"message":  "Disputes retrieved successfully",
+		"disputes": disputes,
+	}, httpx.NewPagination(page.Limit, page.Offset, len(disputes), page.Total))
+}
+
+// AdminGetDispute retrieves any dispute (staff only)
+func (h *DisputeHandler) AdminGetDispute(c *gin.Context) {
+	_, disputeID, ok := disputeIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Get dispute
+	dispute, err := h.disputeService.GetDispute(disputeID)
+	if err != nil {
+		h.respondDisputeError(c, err, "FETCH_DISPUTE_FAILED", "Failed to fetch dispute")
+		return
+	}
+
+	// Return dispute
+	httpx.RespondOK(c, gin.H{
+		"message": "Dispute retrieved successfully",
+		"dispute": dispute,
+	})
+}
+
+// AssignDispute puts a dispute under investigation (staff only)
+func (h *DisputeHandler) AssignDispute(c *gin.Context) {
+	staffID, disputeID, ok := disputeIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.AssignDisputeRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Assign dispute
+	dispute, err := h.disputeService.AssignDispute(disputeID, request, models.DisputeChange{ActorID: staffID, RequestID: c.GetString(httpx.RequestIDKey)})
+	if err != nil {
+		h.respondDisputeError(c, err, "ASSIGN_DISPUTE_FAILED", "Failed to assign dispute")
+		return
+	}
+
+	// Return dispute
+	httpx.RespondOK(c, gin.H{
+		"message": "Dispute assigned successfully",
+		"dispute": dispute,
+	})
+}
+
+// ResolveDispute closes a dispute, refunding the disputed amount when it is
+// upheld (staff only)
+func (h *DisputeHandler) ResolveDispute(c *gin.Context) {
+	staffID, disputeID, ok := disputeIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.ResolveDisputeRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Resolve dispute
+	dispute, refund, err := h.disputeService.ResolveDispute(disputeID, request, models.DisputeChange{ActorID: staffID, RequestID: c.GetString(httpx.RequestIDKey)})
+	if err != nil {
+		h.respondDisputeError(c, err, "RESOLVE_DISPUTE_FAILED", "Failed to resolve dispute")
+		return
+	}
+
+	// Return dispute, with the refund of upheld disputes
+	response := gin.H{
+		"message": "Dispute resolved successfully",
+		"dispute": dispute,
+	}
+	if refund != nil {
+		response["refund"] = refund.ToResponse()
+	}
+	httpx.RespondOK(c, response)
+}
+
+// respondDisputeError writes the response for an error from the dispute
+// service, falling back to a 500 with code and message
+func (h *DisputeHandler) respondDisputeError(c *gin.Context, err error, code, message string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondValidationError(c, validationErr)
+	case errors.Is(err, services.ErrTransactionNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "TRANSACTION_NOT_FOUND",
+			Message: "Transaction not found",
+		})
+	case errors.Is(err, services.ErrTransactionAccessDenied):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "ACCESS_DENIED",
+			Message: "Access denied to this transaction",
+		})
+	case errors.Is(err, services.ErrTransactionNotDisputable):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "TRANSACTION_NOT_DISPUTABLE",
+			Message: "Only withdrawals and fees can be disputed",
+		})
+	case errors.Is(err, services.ErrDisputeExists):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "DISPUTE_EXISTS",
+			Message: "Transaction is already disputed",
+		})
+	case errors.Is(err, services.ErrDisputeNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "DISPUTE_NOT_FOUND",
+			Message: "Dispute not found",
+		})
+	case errors.Is(err, services.ErrDisputeResolved):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "DISPUTE_ALREADY_RESOLVED",
+			Message: "Dispute is already resolved",
+		})
+	case errors.Is(err, services.ErrInvalidAssignee):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "INVALID_ASSIGNEE",
+			Message: "Disputes can only be assigned to staff members",
+		})
+	case errors.Is(err, resilience.ErrDependencyUnavailable):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "STAFF_LOOKUP_UNAVAILABLE",
+			Message: "Unable to verify the assignee",
+			Details: middleware.ErrorDetails(c, err),
+		})
+	default:
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    code,
+			Message: message,
+			Details: middleware.ErrorDetails(c, err),
+		})
+	}
+}
+
+// transactionIDsFromRequest returns the current user's ID and the
+// transaction ID from the URL, writing an error response if either is
+// missing or malformed
+func transactionIDsFromRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_TRANSACTION_ID",
+			Message: "Invalid transaction ID format",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, transactionID, true
+}
+
+// disputeIDsFromRequest returns the current user's ID and the dispute ID
+// from the URL, writing an error response if either is missing or
+// malformed
+func disputeIDsFromRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_DISPUTE_ID",
+			Message: "Invalid dispute ID format",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, disputeID, true
+}
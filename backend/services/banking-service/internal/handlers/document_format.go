@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Formats the statement and receipt endpoints answer in
+const (
+	formatJSON = "json"
+	formatPDF  = "pdf"
+)
+
+// parseFormat reads the format query parameter: json, the default, or pdf
+func parseFormat(c *gin.Context) (string, error) {
+	format := c.DefaultQuery("format", formatJSON)
+	if format != formatJSON && format != formatPDF {
+		return "", fmt.Errorf("format must be json or pdf")
+	}
+	return format, nil
+}
+
+// respondPDF sends a rendered PDF as an attachment named filename. It is
+// never cached, and browsers must not guess a different type for it.
+func respondPDF(c *gin.Context, filename string, pdf []byte) {
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+	"microbank/pkg/resilience"
+)
+
+// ExternalTransferHandler handles HTTP requests for transfers to other
+// banks
+type ExternalTransferHandler struct {
+	transactionService      *services.TransactionService
+	externalTransferService *services.ExternalTransferService
+	pinService              *services.TransactionPINService
+	confirmationService     *services.TransactionConfirmationService
+}
+
+// NewExternalTransferHandler creates a new external transfer handler.
+// Sending a transfer needs the user's transaction PIN, checked with
+// pinService, once they set one, and transfers over the user's threshold
+// wait in confirmationService until they are confirmed.
+func NewExternalTransferHandler(transactionService *services.TransactionService, externalTransferService *services.ExternalTransferService, pinService *services.TransactionPINService, confirmationService *services.TransactionConfirmationService) *ExternalTransferHandler {
+	return &ExternalTransferHandler{
+		transactionService:      transactionService,
+		externalTransferService: externalTransferService,
+		pinService:              pinService,
+		confirmationService:     confirmationService,
+	}
+}
+
+// CreateExternalTransfer sends money to an account at another bank. The
+// transfer is pending, holding its amount, until it settles.
+func (h *ExternalTransferHandler) CreateExternalTransfer(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.ExternalTransferRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Check the transaction PIN, if the user set one
+	if !verifyTransactionPIN(c, h.pinService, userID, request.PIN) {
+		return
+	}
+
+	// Hold transfers over the user's threshold until they are confirmed
+	request.PIN = ""
+	if requireConfirmation(c, h.confirmationService, userID, models.TransactionConfirmationExternalTransfer, request.Amount, request, gin.H{
+		"account_id":          request.AccountID,
+		"amount":              models.Amount(request.Amount),
+		"description":         request.Description,
+		"routing_number":      request.RoutingNumber,
+		"account_number":      (&models.ExternalTransfer{AccountNumber: request.AccountNumber}).MaskedAccountNumber(),
+		"account_holder_name": request.AccountHolderName,
+	}) {
+		return
+	}
+
+	h.createExternalTransfer(c, userID, request)
+}
+
+// createExternalTransfer sends an external transfer whose PIN, and
+// confirmation if it needed one, were checked
+func (h *ExternalTransferHandler) createExternalTransfer(c *gin.Context, userID uuid.UUID, request models.ExternalTransferRequest) {
+	// Process external transfer
+	transfer, err := h.transactionService.ProcessExternalTransfer(userID, request)
+	if err != nil {
+		var validationErr *services.ValidationError
+		var insufficientFunds *services.InsufficientFundsError
+		var earmarked *services.EarmarkedFundsError
+		var spendingLimit *services.SpendingLimitError
+		var kycRequired *services.KYCRequiredError
+		switch {
+		case errors.As(err, &validationErr):
+			respondValidationError(c, validationErr)
+		case errors.Is(err, services.ErrAccountNotFound):
+			respondAccountNotFound(c)
+		case errors.Is(err, services.ErrAccountFrozen):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "ACCOUNT_FROZEN",
+				Message: "Account is frozen",
+			})
+		case errors.As(err, &insufficientFunds):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INSUFFICIENT_FUNDS",
+				Message: "Insufficient funds for transfer",
+				Details: gin.H{"requested_amount": models.Amount(request.Amount)},
+			})
+		case errors.As(err, &earmarked):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "FUNDS_EARMARKED",
+				Message: "Transfer would use money earmarked for savings goals",
+				Details: gin.H{
+					"requested_amount": models.Amount(request.Amount),
+					"transferable":     models.Amount(earmarked.Withdrawable),
+				},
+			})
+		case errors.As(err, &spendingLimit):
+			respondSpendingLimitExceeded(c, spendingLimit, request.Amount)
+		case errors.As(err, &kycRequired):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "KYC_REQUIRED",
+				Message: "Identity verification is required for transfers over the limit",
+				Details: gin.H{
+					"requested_amount": models.Amount(request.Amount),
+					"limit":            models.Amount(kycRequired.Limit),
+					"kyc_status":       kycRequired.KYCStatus,
+				},
+			})
+		case errors.Is(err, resilience.ErrDependencyUnavailable):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusServiceUnavailable,
+				Code:    "KYC_CHECK_UNAVAILABLE",
+				Message: "Unable to check identity verification status",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "EXTERNAL_TRANSFER_FAILED",
+				Message: "Failed to process external transfer",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return the pending transfer
+	httpx.RespondCreated(c, gin.H{
+		"message":           "External transfer is pending settlement",
+		"external_transfer": transfer.ToResponse(),
+	})
+}
+
+// ListExternalTransfers retrieves the current user's external transfers,
+// newest first
+func (h *ExternalTransferHandler) ListExternalTransfers(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	// Get external transfers
+	page, err := h.externalTransferService.ListUserTransfers(userID, limit, offset)
+	if err != nil {
+		h.respondExternalTransferError(c, err, "FETCH_EXTERNAL_TRANSFERS_FAILED", "Failed to fetch external transfers")
+		return
+	}
+
+	// Return external transfers
+	httpx.RespondPage(c, gin.H{
+		"message":            "External transfers retrieved successfully",
+		"external_transfers": externalTransferResponses(page.Transfers),
+	}, httpx.NewPagination(page.Limit, page.Offset, len(page.Transfers), page.Total))
+}
+
+// GetExternalTransfer retrieves one of the current user's external
+// transfers
+func (h *ExternalTransferHandler) GetExternalTransfer(c *gin.Context) {
+	userID, transferID, ok := externalTransferIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Get external transfer
+	transfer, err := h.externalTransferService.GetUserTransfer(userID, transferID)
+	if err != nil {
+		h.respondExternalTransferError(c, err, "FETCH_EXTERNAL_TRANSFER_FAILED", "Failed to fetch external transfer")
+		return
+	}
+
+	// Return external transfer
+	httpx.RespondOK(c, gin.H{
+		"message":           "External transfer retrieved successfully",
+		"external_transfer": transfer.ToResponse(),
+	})
+}
+
+// AdminListExternalTransfers retrieves external transfers, newest first,
+// optionally filtered by status and user (staff only)
+func (h *ExternalTransferHandler) AdminListExternalTransfers(c *gin.Context) {
+	filter := models.ExternalTransferFilter{Status: models.ExternalTransferStatus(c.Query("status"))}
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_USER_ID",
+				Message: "Invalid user ID format",
+			})
+			return
+		}
+		filter.UserID = &id
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	// Get external transfers
+	page, err := h.externalTransferService.ListTransfers(filter)
+	if err != nil {
+		h.respondExternalTransferError(c, err, "FETCH_EXTERNAL_TRANSFERS_FAILED", "Failed to fetch external transfers")
+		return
+	}
+
+	// Return external transfers, with their owners
+	transfers := page.Transfers
+	if transfers == nil {
+		transfers = []models.ExternalTransfer{}
+	}
+	httpx.RespondPage(c, gin.H{
+		"message":            "External transfers retrieved successfully",
+		"external_transfers": transfers,
+	}, httpx.NewPagination(page.Limit, page.Offset, len(transfers), page.Total))
+}
+
+// SettleExternalTransfer completes a pending external transfer without
+// waiting for the settlement delay (staff only). When the balance no
+// longer covers it, the transfer fails with insufficient_funds instead.
+func (h *ExternalTransferHandler) SettleExternalTransfer(c *gin.Context) {
+	staffID, transferID, ok := externalTransferIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Settle external transfer
+	transfer, err := h.externalTransferService.Complete(transferID, models.ExternalTransferChange{ActorID: &staffID, RequestID: c.GetString(httpx.RequestIDKey)})
+	if err != nil {
+		h.respondExternalTransferError(c, err, "SETTLE_EXTERNAL_TRANSFER_FAILED", "Failed to settle external transfer")
+		return
+	}
+
+	// Return external transfer
+	httpx.RespondOK(c, gin.H{
+		"message":           "External transfer settled",
+		"external_transfer": transfer,
+	})
+}
+
+// FailExternalTransfer fails a pending external transfer, releasing its
+// hold and emailing the user the reason (staff only)
+func (h *ExternalTransferHandler) FailExternalTransfer(c *gin.Context) {
+	staffID, transferID, ok := externalTransferIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.FailExternalTransferRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Fail external transfer
+	transfer, err := h.externalTransferService.Fail(transferID, request.Reason, models.ExternalTransferChange{ActorID: &staffID, RequestID: c.GetString(httpx.RequestIDKey)})
+	if err != nil {
+		h.respondExternalTransferError(c, err, "FAIL_EXTERNAL_TRANSFER_FAILED", "Failed to fail external transfer")
+		return
+	}
+
+	// Return external transfer
+	httpx.RespondOK(c, gin.H{
+		"message":           "External transfer failed",
+		"external_transfer": transfer,
+	})
+}
+
+// respondExternalTransferError writes the response for an error from the
+// external transfer service, falling back to a 500 with code and message
+func (h *ExternalTransferHandler) respondExternalTransferError(c *gin.Context, err error, code, message string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondValidationError(c, validationErr)
+	case errors.Is(err, services.ErrExternalTransferNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "EXTERNAL_TRANSFER_NOT_FOUND",
+			Message: "External transfer not found",
+		})
+	case errors.Is(err, services.ErrExternalTransferSettled):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "EXTERNAL_TRANSFER_SETTLED",
+			Message: "External transfer is already settled",
+		})
+	default:
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    code,
+			Message: message,
+			Details: middleware.ErrorDetails(c, err),
+		})
+	}
+}
+
+// externalTransferResponses converts external transfers for a user's
+// response, masking their account numbers
+func externalTransferResponses(transfers []models.ExternalTransfer) []models.ExternalTransferResponse {
+	responses := make([]models.ExternalTransferResponse, 0, len(transfers))
+	for i := range transfers {
+		responses = append(responses, transfers[i].ToResponse())
+	}
+	return responses
+}
+
+// externalTransferIDsFromRequest returns the current user's ID and the
+// external transfer ID in the path, responding with an error when either
+// is missing or invalid
+func externalTransferIDsFromRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	transferID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_EXTERNAL_TRANSFER_ID",
+			Message: "Invalid external transfer ID format",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, transferID, true
+}
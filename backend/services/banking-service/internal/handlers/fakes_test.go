@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakeAccountRepo is an in-memory AccountRepository keyed by owner. Methods
+// not needed by the handler tests panic.
+type fakeAccountRepo struct {
+	repository.AccountRepository
+	accounts map[uuid.UUID]*models.Account
+}
+
+func (r *fakeAccountRepo) GetAccountByUserID(userID uuid.UUID) (*models.Account, error) {
+	account, ok := r.accounts[userID]
+	if !ok {
+		return nil, fmt.Errorf("account not found")
+	}
+	clone := *account
+	return &clone, nil
+}
+
+// fakeTransactionRepo is an in-memory TransactionRepository that lists and
+// counts its transactions matching the amount filters, in the order they
+// were added. Methods not needed by the handler tests panic.
+type fakeTransactionRepo struct {
+	repository.TransactionRepository
+	transactions []models.Transaction
+}
+
+func (r *fakeTransactionRepo) matching(accountID *uuid.UUID, filter models.TransactionFilter) []models.Transaction {
+	var matching []models.Transaction
+	for _, transaction := range r.transactions {
+		if accountID != nil && transaction.AccountID != *accountID {
+			continue
+		}
+		if (filter.MinAmount != nil && transaction.Amount < *filter.MinAmount) || (filter.MaxAmount != nil && transaction.Amount > *filter.MaxAmount) {
+			continue
+		}
+		matching = append(matching, transaction)
+	}
+	return matching
+}
+
+func page(transactions []models.Transaction, limit, offset int) []models.Transaction {
+	if offset > len(transactions) {
+		offset = len(transactions)
+	}
+	transactions = transactions[offset:]
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+	}
+	return transactions
+}
+
+func (r *fakeTransactionRepo) GetTransactionsByAccountID(accountID uuid.UUID, filter models.TransactionFilter, cursor *models.Transaction, limit, offset int) ([]models.Transaction, error) {
+	return page(r.matching(&accountID, filter), limit, offset), nil
+}
+
+func (r *fakeTransactionRepo) GetTransactionCountByAccountID(accountID uuid.UUID, filter models.TransactionFilter) (int, error) {
+	return len(r.matching(&accountID, filter)), nil
+}
+
+func (r *fakeTransactionRepo) GetAllTransactions(filter models.TransactionFilter, limit, offset int) ([]models.Transaction, error) {
+	return page(r.matching(nil, filter), limit, offset), nil
+}
+
+func (r *fakeTransactionRepo) CountAllTransactions(filter models.TransactionFilter) (int, error) {
+	return len(r.matching(nil, filter)), nil
+}
+
+// fakeUserDetails knows no customers
+type fakeUserDetails struct{}
+
+func (fakeUserDetails) GetUserDetails(userIDs []string) (map[string]models.UserDetail, error) {
+	return map[string]models.UserDetail{}, nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// IntegrityHandler handles transaction integrity HTTP requests
+type IntegrityHandler struct {
+	integrityService *services.IntegrityService
+}
+
+// NewIntegrityHandler creates a new integrity handler
+func NewIntegrityHandler(integrityService *services.IntegrityService) *IntegrityHandler {
+	return &IntegrityHandler{
+		integrityService: integrityService,
+	}
+}
+
+// VerifyAccount walks an account's transaction integrity chain and
+// reports the first break, if any (staff only)
+func (h *IntegrityHandler) VerifyAccount(c *gin.Context) {
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_ACCOUNT_ID",
+			Message: "Invalid account ID format",
+		})
+		return
+	}
+
+	// Verify chain
+	verification, err := h.integrityService.VerifyAccount(accountID)
+	if err != nil {
+		if errors.Is(err, services.ErrAccountNotFound) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "ACCOUNT_NOT_FOUND",
+				Message: "Account not found",
+			})
+			return
+		}
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "VERIFY_INTEGRITY_FAILED",
+			Message: "Failed to verify transaction integrity",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return verification
+	httpx.RespondOK(c, gin.H{
+		"message":      "Transaction integrity verified",
+		"verification": verification,
+	})
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/events"
+	"microbank/pkg/httpx"
+)
+
+// InternalHandler handles service-to-service HTTP requests
+type InternalHandler struct {
+	accountService *services.AccountService
+	revocations    *services.TokenRevocationList
+	userEvents     *services.UserEventConsumer
+}
+
+// NewInternalHandler creates a new internal handler
+func NewInternalHandler(accountService *services.AccountService, revocations *services.TokenRevocationList, userEvents *services.UserEventConsumer) *InternalHandler {
+	return &InternalHandler{
+		accountService: accountService,
+		revocations:    revocations,
+		userEvents:     userEvents,
+	}
+}
+
+// ProvisionAccount creates the account of a user registered in the client
+// service. Repeating the call for the same user returns the existing account.
+func (h *InternalHandler) ProvisionAccount(c *gin.Context) {
+	var request models.ProvisionAccountRequest
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Create the account unless it exists
+	account, created, err := h.accountService.ProvisionAccount(request.UserID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "PROVISION_ACCOUNT_FAILED",
+			Message: "Failed to provision account",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	if !created {
+		httpx.RespondOK(c, gin.H{
+			"message": "Account already exists",
+			"account": account.ToResponse(),
+			"created": false,
+		})
+		return
+	}
+
+	log.Printf("Provisioned account for user %s", request.UserID)
+
+	// Return success response
+	httpx.RespondCreated(c, gin.H{
+		"message": "Account provisioned successfully",
+		"account": account.ToResponse(),
+		"created": true,
+	})
+}
+
+// UserDeleted flags the account of a user deleted in the client service
+func (h *InternalHandler) UserDeleted(c *gin.Context) {
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Flag the account
+	flagged, err := h.accountService.FlagOrphanedAccount(userID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FLAG_ACCOUNT_FAILED",
+			Message: "Failed to flag orphaned account",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	if flagged {
+		log.Printf("Flagged account of deleted user %s as orphaned", userID)
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message":         "User deletion recorded",
+		"user_id":         userID,
+		"account_flagged": flagged,
+	})
+}
+
+// UserRestored clears the orphaned flag from the account of a user restored
+// in the client service
+func (h *InternalHandler) UserRestored(c *gin.Context) {
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Unflag the account
+	cleared, err := h.accountService.UnflagOrphanedAccount(userID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "UNFLAG_ACCOUNT_FAILED",
+			Message: "Failed to clear orphaned account flag",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	if cleared {
+		log.Printf("Cleared orphaned flag on account of restored user %s", userID)
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message":         "User restoration recorded",
+		"user_id":         userID,
+		"account_cleared": cleared,
+	})
+}
+
+// UserBalance returns the balance of a user's account so the client service
+// can check it before the user deletes their account
+func (h *InternalHandler) UserBalance(c *gin.Context) {
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Get balance
+	balance, hasAccount, err := h.accountService.GetUserBalance(userID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_BALANCE_FAILED",
+			Message: "Failed to fetch balance",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return balance
+	httpx.RespondOK(c, gin.H{
+		"user_id":     userID,
+		"balance":     balance,
+		"has_account": hasAccount,
+	})
+}
+
+// RevokeTokens adds access tokens revoked in the client service to the
+// revocation list
+func (h *InternalHandler) RevokeTokens(c *gin.Context) {
+	var request models.TokenRevocationRequest
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Revoke tokens
+	h.revocations.Revoke(request.Tokens)
+	log.Printf("Revoked %d access tokens", len(request.Tokens))
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message": "Tokens revoked successfully",
+		"revoked": len(request.Tokens),
+	})
+}
+
+// HandleEvent applies an event published by another service. Event types
+// this service does not act on are acknowledged and ignored. A payload
+// version this service does not understand yet is refused with 422 so the
+// publisher retries it after an upgrade.
+func (h *InternalHandler) HandleEvent(c *gin.Context) {
+	var event events.Event
+
+	// Bind and validate request body
+	if !bindJSON(c, &event) {
+		return
+	}
+
+	// Apply the event
+	handled, err := h.userEvents.Handle(event)
+	if err != nil {
+		if errors.Is(err, events.ErrUnsupportedVersion) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "UNSUPPORTED_EVENT_VERSION",
+				Message: "Event version is not supported",
+				Details: middleware.ErrorDetails(c, err),
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "HANDLE_EVENT_FAILED",
+			Message: "Failed to handle event",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message":  "Event received",
+		"event_id": event.ID,
+		"handled":  handled,
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// journalHeader is the first row of the journal export
+var journalHeader = []string{"posted_at", "transaction_id", "transaction_type", "account_code", "account_name", "customer_account_id", "description", "debit", "credit"}
+
+// JournalExportHandler handles exporting transactions as double-entry
+// journal lines for accounting
+type JournalExportHandler struct {
+	transactionService *services.TransactionService
+}
+
+// NewJournalExportHandler creates a new journal export handler
+func NewJournalExportHandler(transactionService *services.TransactionService) *JournalExportHandler {
+	return &JournalExportHandler{
+		transactionService: transactionService,
+	}
+}
+
+// ExportJournal streams the journal lines of the transactions made on the
+// days from through to as CSV, ending with a TOTAL row of the debits and
+// credits (staff only). Each batch read from the database is flushed to
+// the client as soon as it is written. An export cut short has no TOTAL
+// row.
+func (h *JournalExportHandler) ExportJournal(c *gin.Context) {
+	from, to, ok := journalPeriodFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// The CSV response starts with the first entry, so errors found before
+	// then can still be reported as JSON
+	var w *csv.Writer
+	started := false
+	start := func() {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="journal-%s-%s.csv"`, from.Format("20060102"), to.Format("20060102")))
+		c.Status(http.StatusOK)
+		w = csv.NewWriter(c.Writer)
+		w.Write(journalHeader)
+		started = true
+	}
+
+	// Export journal
+	ctx := c.Request.Context()
+	totals, err := h.transactionService.ExportJournal(ctx, from, to, func(entry []models.JournalLine, flush bool) error {
+		if !started {
+			start()
+		}
+		for _, line := range entry {
+			w.Write(journalRow(line))
+		}
+		if flush {
+			w.Flush()
+			if err := w.Error(); err != nil {
+				return err
+			}
+			if flusher, ok := c.Writer.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		respondValidationError(c, validationErr)
+		return
+	}
+	if err != nil && !started {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "EXPORT_JOURNAL_FAILED",
+			Message: "Failed to export journal",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+	if err != nil {
+		// Headers are already sent, so the export can only be cut short
+		if ctx.Err() != nil {
+			log.Printf("Journal export stopped after %d transactions: client went away", totals.Transactions)
+		} else {
+			log.Printf("Journal export stopped after %d transactions: %v", totals.Transactions, err)
+		}
+		c.Abort()
+		return
+	}
+
+	if !started {
+		start()
+	}
+	w.Write([]string{"TOTAL", "", "", "", "", "", plural(totals.Transactions, "transaction") + ", " + plural(totals.Lines, "line"), totals.Debit.Amount(), totals.Credit.Amount()})
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("Failed to finish journal export: %v", err)
+	}
+}
+
+// journalRow formats a journal line as a CSV row, leaving the side it does
+// not post to empty
+func journalRow(line models.JournalLine) []string {
+	customerAccountID, debit, credit := "", "", ""
+	if line.CustomerAccountID != nil {
+		customerAccountID = line.CustomerAccountID.String()
+	}
+	if !line.Debit.IsZero() {
+		debit = line.Debit.Amount()
+	}
+	if !line.Credit.IsZero() {
+		credit = line.Credit.Amount()
+	}
+	return []string{
+		line.PostedAt.UTC().Format(time.RFC3339),
+		line.TransactionID.String(),
+		string(line.TransactionType),
+		line.Account.Code,
+		line.Account.Name,
+		customerAccountID,
+		line.Description,
+		debit,
+		credit,
+	}
+}
+
+// journalPeriodFromRequest reads the required from and to dates, writing
+// an error response if one is missing or malformed
+func journalPeriodFromRequest(c *gin.Context) (time.Time, time.Time, bool) {
+	var dates [2]time.Time
+	for i, name := range []string{"from", "to"} {
+		value := c.Query(name)
+		if value == "" {
+			respondValidationError(c, fieldValidationError(name, "required", "is required"))
+			return time.Time{}, time.Time{}, false
+		}
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			respondValidationError(c, fieldValidationError(name, "datetime", "must be a date in YYYY-MM-DD format"))
+			return time.Time{}, time.Time{}, false
+		}
+		dates[i] = parsed
+	}
+	return dates[0], dates[1], true
+}
+
+// plural returns n and noun, adding an s when n is not 1
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
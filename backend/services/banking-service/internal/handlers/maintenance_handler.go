@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// MaintenanceHandler handles maintenance mode HTTP requests
+type MaintenanceHandler struct {
+	maintenanceService *services.MaintenanceService
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(maintenanceService *services.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceService: maintenanceService,
+	}
+}
+
+// GetMaintenance retrieves whether money movement is paused (staff only)
+func (h *MaintenanceHandler) GetMaintenance(c *gin.Context) {
+	state, err := h.maintenanceService.GetState()
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_MAINTENANCE_FAILED",
+			Message: "Failed to fetch maintenance mode",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message":     "Maintenance mode retrieved successfully",
+		"maintenance": state.ToResponse(time.Now()),
+	})
+}
+
+// UpdateMaintenance pauses or resumes money movement (staff only)
+func (h *MaintenanceHandler) UpdateMaintenance(c *gin.Context) {
+	staffID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.UpdateMaintenanceRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Update maintenance mode
+	state, err := h.maintenanceService.UpdateState(request, models.MaintenanceChange{ActorID: staffID, RequestID: c.GetString(httpx.RequestIDKey)})
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			respondValidationError(c, validationErr)
+			return
+		}
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "UPDATE_MAINTENANCE_FAILED",
+			Message: "Failed to update maintenance mode",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	message := "Transactions resumed"
+	if state.Enabled {
+		message = "Transactions paused"
+	}
+	httpx.RespondOK(c, gin.H{
+		"message":     message,
+		"maintenance": state.ToResponse(time.Now()),
+	})
+}
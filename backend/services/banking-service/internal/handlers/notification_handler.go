@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// NotificationHandler handles HTTP requests for account notification
+// settings
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// GetSettings retrieves the notification settings of the account selected
+// by the account_id query parameter, the current user's own by default
+func (h *NotificationHandler) GetSettings(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	accountID, ok := accountIDFromQuery(c)
+	if !ok {
+		return
+	}
+
+	// Get settings
+	settings, err := h.notificationService.Settings(userID, accountID)
+	if err != nil {
+		respondAccountMemberError(c, err, "FETCH_NOTIFICATION_SETTINGS_FAILED", "Failed to fetch notification settings")
+		return
+	}
+
+	// Return settings
+	httpx.RespondOK(c, gin.H{
+		"message":       "Notification settings retrieved successfully",
+		"notifications": settings,
+	})
+}
+
+// UpdateSettings replaces the notification settings of the account
+// selected by the account_id query parameter, the current user's own by
+// default
+func (h *NotificationHandler) UpdateSettings(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	accountID, ok := accountIDFromQuery(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.UpdateNotificationSettingsRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Update settings
+	settings, err := h.notificationService.UpdateSettings(userID, accountID, request)
+	if err != nil {
+		respondAccountMemberError(c, err, "UPDATE_NOTIFICATION_SETTINGS_FAILED", "Failed to update notification settings")
+		return
+	}
+
+	// Return settings
+	httpx.RespondOK(c, gin.H{
+		"message":       "Notification settings updated successfully",
+		"notifications": settings,
+	})
+}
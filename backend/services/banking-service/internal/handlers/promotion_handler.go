@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// PromotionHandler handles HTTP requests for deposit promotions
+type PromotionHandler struct {
+	promotionService *services.PromotionService
+}
+
+// NewPromotionHandler creates a new promotion handler
+func NewPromotionHandler(promotionService *services.PromotionService) *PromotionHandler {
+	return &PromotionHandler{
+		promotionService: promotionService,
+	}
+}
+
+// ListUserPromotions retrieves the active promotions with whether the user
+// earned each one's bonus and can still earn it
+func (h *PromotionHandler) ListUserPromotions(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get active promotions
+	promotions, err := h.promotionService.ListForUser(userID)
+	if err != nil {
+		respondPromotionError(c, err, "FETCH_PROMOTIONS_FAILED", "Failed to fetch promotions")
+		return
+	}
+
+	// Return promotions
+	httpx.RespondOK(c, gin.H{
+		"message":    "Promotions retrieved successfully",
+		"promotions": promotions,
+	})
+}
+
+// ListPromotions retrieves promotions, latest starting first, optionally
+// filtered by status (staff only)
+func (h *PromotionHandler) ListPromotions(c *gin.Context) {
+	filter := models.PromotionFilter{Status: models.PromotionStatus(c.Query("status"))}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	// Get promotions
+	page, err := h.promotionService.ListPromotions(filter)
+	if err != nil {
+		respondPromotionError(c, err, "FETCH_PROMOTIONS_FAILED", "Failed to fetch promotions")
+		return
+	}
+
+	// Return promotions
+	now := time.Now()
+	promotions := make([]models.PromotionResponse, 0, len(page.Promotions))
+	for i := range page.Promotions {
+		promotions = append(promotions, page.Promotions[i].ToResponse(now))
+	}
+	httpx.RespondPage(c, gin.H{
+		"message":    "Promotions retrieved successfully",
+		"promotions": promotions,
+	}, httpx.NewPagination(page.Limit, page.Offset, len(promotions), page.Total))
+}
+
+// GetPromotion retrieves a promotion (staff only)
+func (h *PromotionHandler) GetPromotion(c *gin.Context) {
+	promotionID, ok := promotionIDFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Get promotion
+	promotion, err := h.promotionService.GetPromotion(promotionID)
+	if err != nil {
+		respondPromotionError(c, err, "FETCH_PROMOTION_FAILED", "Failed to fetch promotion")
+		return
+	}
+
+	// Return promotion
+	httpx.RespondOK(c, gin.H{
+		"message":   "Promotion retrieved successfully",
+		"promotion": promotion.ToResponse(time.Now()),
+	})
+}
+
+// CreatePromotion creates a promotion (staff only)
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	staffID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.CreatePromotionRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Create promotion
+	promotion, err := h.promotionService.CreatePromotion(staffID, request)
+	if err != nil {
+		respondPromotionError(c, err, "CREATE_PROMOTION_FAILED", "Failed to create promotion")
+		return
+	}
+
+	// Return promotion
+	httpx.RespondCreated(c, gin.H{
+		"message":   "Promotion created successfully",
+		"promotion": promotion.ToResponse(time.Now()),
+	})
+}
+
+// UpdatePromotion changes a promotion (staff only)
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	promotionID, ok := promotionIDFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.UpdatePromotionRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Update promotion
+	promotion, err := h.promotionService.UpdatePromotion(promotionID, request)
+	if err != nil {
+		respondPromotionError(c, err, "UPDATE_PROMOTION_FAILED", "Failed to update promotion")
+		return
+	}
+
+	// Return promotion
+	httpx.RespondOK(c, gin.H{
+		"message":   "Promotion updated successfully",
+		"promotion": promotion.ToResponse(time.Now()),
+	})
+}
+
+// DeletePromotion deletes a promotion that never paid a bonus (staff only)
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	promotionID, ok := promotionIDFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Delete promotion
+	if err := h.promotionService.DeletePromotion(promotionID); err != nil {
+		respondPromotionError(c, err, "DELETE_PROMOTION_FAILED", "Failed to delete promotion")
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Promotion deleted successfully",
+	})
+}
+
+// respondPromotionError writes the response for an error from the
+// promotion service, falling back to a 500 with code and message
+func respondPromotionError(c *gin.Context, err error, code, message string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondValidationError(c, validationErr)
+	case errors.Is(err, services.ErrPromotionNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "PROMOTION_NOT_FOUND",
+			Message: "Promotion not found",
+		})
+	case errors.Is(err, services.ErrPromotionRedeemed):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "PROMOTION_REDEEMED",
+			Message: "Promotion has paid bonuses; end it instead of deleting it",
+		})
+	default:
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    code,
+			Message: message,
+			Details: middleware.ErrorDetails(c, err),
+		})
+	}
+}
+
+// promotionIDFromRequest returns the promotion ID in the path, responding
+// with an error when it is invalid
+func promotionIDFromRequest(c *gin.Context) (uuid.UUID, bool) {
+	promotionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_PROMOTION_ID",
+			Message: "Invalid promotion ID format",
+		})
+		return uuid.Nil, false
+	}
+	return promotionID, true
+}
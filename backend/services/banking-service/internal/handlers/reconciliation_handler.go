@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// ReconciliationHandler handles balance reconciliation HTTP requests
+type ReconciliationHandler struct {
+	reconciliationService *services.ReconciliationService
+}
+
+// NewReconciliationHandler creates a new reconciliation handler
+func NewReconciliationHandler(reconciliationService *services.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciliationService: reconciliationService,
+	}
+}
+
+// GetLatest retrieves the most recent reconciliation run with one page of
+// its mismatches (staff only)
+func (h *ReconciliationHandler) GetLatest(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	// Get report
+	report, err := h.reconciliationService.GetLatestReport(limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrReconciliationNotFound) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "RECONCILIATION_NOT_FOUND",
+				Message: "No reconciliation has run yet",
+			})
+			return
+		}
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_RECONCILIATION_FAILED",
+			Message: "Failed to fetch reconciliation",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+	mismatches := make([]models.ReconciliationMismatchResponse, 0, len(report.Mismatches))
+	for i := range report.Mismatches {
+		mismatches = append(mismatches, report.Mismatches[i].ToResponse())
+	}
+
+	// Return run and mismatches
+	httpx.RespondPage(c, gin.H{
+		"message":    "Reconciliation retrieved successfully",
+		"run":        report.Run.ToResponse(),
+		"mismatches": mismatches,
+	}, httpx.NewPagination(report.Limit, report.Offset, len(mismatches), report.Run.MismatchesFound))
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// ReportHandler handles compliance report HTTP requests
+type ReportHandler struct {
+	reportService *services.ReportService
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// ListSuspiciousActivity retrieves suspicious activity findings, newest
+// report first, optionally filtered by report date, rule and status
+// (staff only)
+func (h *ReportHandler) ListSuspiciousActivity(c *gin.Context) {
+	filter, ok := suspiciousActivityFilterFromRequest(c)
+	if !ok {
+		return
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	// Get findings
+	page, err := h.reportService.ListFindings(filter)
+	if err != nil {
+		h.respondReportError(c, err, "FETCH_SUSPICIOUS_ACTIVITY_FAILED", "Failed to fetch suspicious activity")
+		return
+	}
+	findings := page.Findings
+	if findings == nil {
+		findings = []models.SuspiciousActivityFinding{}
+	}
+
+	// Return findings
+	httpx.RespondPage(c, gin.H{
+		"message":  "Suspicious activity retrieved successfully",
+		"findings": findings,
+	}, httpx.NewPagination(page.Limit, page.Offset, len(findings), page.Total))
+}
+
+// ExportSuspiciousActivity downloads the suspicious activity findings
+// matching the list's filters as CSV (staff only)
+func (h *ReportHandler) ExportSuspiciousActivity(c *gin.Context) {
+	filter, ok := suspiciousActivityFilterFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Get findings
+	findings, err := h.reportService.ExportFindings(filter)
+	if err != nil {
+		h.respondReportError(c, err, "EXPORT_SUSPICIOUS_ACTIVITY_FAILED", "Failed to export suspicious activity")
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="suspicious-activity.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "report_date", "rule", "account_id", "user_id", "transaction_count", "amount", "baseline", "description", "status", "reviewed_by", "reviewed_at", "review_note"})
+	for _, finding := range findings {
+		baseline, reviewedBy, reviewedAt := "", "", ""
+		if finding.Baseline != nil {
+			baseline = strconv.FormatFloat(*finding.Baseline, 'f', 2, 64)
+		}
+		if finding.ReviewedBy != nil {
+			reviewedBy = finding.ReviewedBy.String()
+		}
+		if finding.ReviewedAt != nil {
+			reviewedAt = finding.ReviewedAt.UTC().Format(time.RFC3339)
+		}
+		w.Write([]string{
+			finding.ID.String(),
+			finding.ReportDate.Format(time.DateOnly),
+			finding.Rule,
+			finding.AccountID.String(),
+			finding.UserID.String(),
+			strconv.Itoa(finding.TransactionCount),
+			strconv.FormatFloat(finding.Amount, 'f', 2, 64),
+			baseline,
+			finding.Description,
+			finding.Status,
+			reviewedBy,
+			reviewedAt,
+			finding.ReviewNote,
+		})
+	}
+	w.Flush()
+}
+
+// ReviewSuspiciousActivity marks a suspicious activity finding reviewed
+// with a note (staff only)
+func (h *ReportHandler) ReviewSuspiciousActivity(c *gin.Context) {
+	reviewerID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	findingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_FINDING_ID",
+			Message: "Invalid finding ID format",
+		})
+		return
+	}
+
+	// Bind and validate request body
+	var request models.ReviewFindingRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Review finding
+	finding, err := h.reportService.ReviewFinding(findingID, reviewerID, request)
+	if err != nil {
+		h.respondReportError(c, err, "REVIEW_FINDING_FAILED", "Failed to review finding")
+		return
+	}
+
+	// Return finding
+	httpx.RespondOK(c, gin.H{
+		"message": "Finding reviewed successfully",
+		"finding": finding,
+	})
+}
+
+// respondReportError writes the response for an error from the report
+// service, falling back to a 500 with code and message
+func (h *ReportHandler) respondReportError(c *gin.Context, err error, code, message string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondValidationError(c, validationErr)
+	case errors.Is(err, services.ErrFindingNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "FINDING_NOT_FOUND",
+			Message: "Finding not found",
+		})
+	case errors.Is(err, services.ErrFindingReviewed):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "FINDING_ALREADY_REVIEWED",
+			Message: "Finding is already reviewed",
+		})
+	default:
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    code,
+			Message: message,
+			Details: middleware.ErrorDetails(c, err),
+		})
+	}
+}
+
+// suspiciousActivityFilterFromRequest reads the from and to report dates,
+// rule and status query parameters, writing an error response if a date
+// is malformed
+func suspiciousActivityFilterFromRequest(c *gin.Context) (models.SuspiciousActivityFilter, bool) {
+	filter := models.SuspiciousActivityFilter{Rule: c.Query("rule"), Status: c.Query("status")}
+	dates := []struct {
+		name string
+		at   **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}}
+	for _, param := range dates {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			respondValidationError(c, fieldValidationError(param.name, "datetime", "must be a date in YYYY-MM-DD format"))
+			return filter, false
+		}
+		*param.at = &parsed
+	}
+	return filter, true
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// RoundUpHandler handles round-up settings HTTP requests
+type RoundUpHandler struct {
+	roundUpService *services.RoundUpService
+}
+
+// NewRoundUpHandler creates a new round-up handler
+func NewRoundUpHandler(roundUpService *services.RoundUpService) *RoundUpHandler {
+	return &RoundUpHandler{
+		roundUpService: roundUpService,
+	}
+}
+
+// GetSettings retrieves the current user's round-up settings and how much
+// round-ups saved each month
+func (h *RoundUpHandler) GetSettings(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get settings
+	settings, err := h.roundUpService.GetSettings(userID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_ROUND_UPS_FAILED",
+			Message: "Failed to fetch round-up settings",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return settings
+	httpx.RespondOK(c, gin.H{
+		"message":   "Round-up settings retrieved successfully",
+		"round_ups": settings,
+	})
+}
+
+// UpdateSettings turns the current user's round-ups on or off
+func (h *RoundUpHandler) UpdateSettings(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.UpdateRoundUpsRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Update settings
+	settings, err := h.roundUpService.UpdateSettings(userID, request)
+	if err != nil {
+		var validationErr *services.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			respondValidationError(c, validationErr)
+		case errors.Is(err, services.ErrSavingsGoalNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "SAVINGS_GOAL_NOT_FOUND",
+				Message: "Savings goal not found",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "UPDATE_ROUND_UPS_FAILED",
+				Message: "Failed to update round-up settings",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return settings
+	httpx.RespondOK(c, gin.H{
+		"message":   "Round-up settings updated successfully",
+		"round_ups": settings,
+	})
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// SavingsGoalHandler handles savings goal HTTP requests
+type SavingsGoalHandler struct {
+	goalService *services.SavingsGoalService
+}
+
+// NewSavingsGoalHandler creates a new savings goal handler
+func NewSavingsGoalHandler(goalService *services.SavingsGoalService) *SavingsGoalHandler {
+	return &SavingsGoalHandler{
+		goalService: goalService,
+	}
+}
+
+// CreateGoal creates a savings goal on the current user's account
+func (h *SavingsGoalHandler) CreateGoal(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.CreateSavingsGoalRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Create goal
+	goal, err := h.goalService.CreateGoal(userID, request)
+	if err != nil {
+		h.respondGoalError(c, err, "CREATE_SAVINGS_GOAL_FAILED", "Failed to create savings goal")
+		return
+	}
+
+	// Return goal
+	httpx.RespondCreated(c, gin.H{
+		"message": "Savings goal created successfully",
+		"goal":    goal.ToResponse(),
+	})
+}
+
+// ListGoals retrieves the current user's savings goals and how much of the
+// balance they earmark
+func (h *SavingsGoalHandler) ListGoals(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get goals
+	goals, balance, err := h.goalService.ListGoals(userID)
+	if err != nil {
+		h.respondGoalError(c, err, "FETCH_SAVINGS_GOALS_FAILED", "Failed to fetch savings goals")
+		return
+	}
+
+	responses := make([]models.SavingsGoalResponse, 0, len(goals))
+	for i := range goals {
+		responses = append(responses, goals[i].ToResponse())
+	}
+
+	// Return goals
+	httpx.RespondOK(c, gin.H{
+		"message":           "Savings goals retrieved successfully",
+		"goals":             responses,
+		"balance":           balance.Balance,
+		"earmarked":         balance.Earmarked,
+		"available_balance": balance.Available,
+	})
+}
+
+// GetGoal retrieves one of the current user's savings goals
+func (h *SavingsGoalHandler) GetGoal(c *gin.Context) {
+	userID, goalID, ok := goalIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Get goal
+	goal, err := h.goalService.GetGoal(userID, goalID)
+	if err != nil {
+		h.respondGoalError(c, err, "FETCH_SAVINGS_GOAL_FAILED", "Failed to fetch savings goal")
+		return
+	}
+
+	// Return goal
+	httpx.RespondOK(c, gin.H{
+		"message": "Savings goal retrieved successfully",
+		"goal":    goal.ToResponse(),
+	})
+}
+
+// UpdateGoal changes one of the current user's savings goals
+func (h *SavingsGoalHandler) UpdateGoal(c *gin.Context) {
+	userID, goalID, ok := goalIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.UpdateSavingsGoalRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Update goal
+	goal, err := h.goalService.UpdateGoal(userID, goalID, request)
+	if err != nil {
+		h.respondGoalError(c, err, "UPDATE_SAVINGS_GOAL_FAILED", "Failed to update savings goal")
+		return
+	}
+
+	// Return goal
+	httpx.RespondOK(c, gin.H{
+		"message": "Savings goal updated successfully",
+		"goal":    goal.ToResponse(),
+	})
+}
+
+// DeleteGoal deletes one of the current user's savings goals, making the
+// money earmarked for it available again
+func (h *SavingsGoalHandler) DeleteGoal(c *gin.Context) {
+	userID, goalID, ok := goalIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Delete goal
+	if err := h.goalService.DeleteGoal(userID, goalID); err != nil {
+		h.respondGoalError(c, err, "DELETE_SAVINGS_GOAL_FAILED", "Failed to delete savings goal")
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Savings goal deleted successfully",
+	})
+}
+
+// Allocate earmarks part of the available balance for one of the current
+// user's savings goals
+func (h *SavingsGoalHandler) Allocate(c *gin.Context) {
+	userID, goalID, ok := goalIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.SavingsGoalAllocationRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Allocate to goal
+	goal, err := h.goalService.Allocate(userID, goalID, request.Amount)
+	if err != nil {
+		h.respondGoalError(c, err, "SAVINGS_GOAL_ALLOCATION_FAILED", "Failed to allocate to savings goal")
+		return
+	}
+
+	// Return goal
+	httpx.RespondOK(c, gin.H{
+		"message": "Funds allocated to savings goal successfully",
+		"goal":    goal.ToResponse(),
+	})
+}
+
+// Deallocate releases money earmarked for one of the current user's
+// savings goals
+func (h *SavingsGoalHandler) Deallocate(c *gin.Context) {
+	userID, goalID, ok := goalIDsFromRequest(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.SavingsGoalAllocationRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Release from goal
+	goal, err := h.goalService.Deallocate(userID, goalID, request.Amount)
+	if err != nil {
+		h.respondGoalError(c, err, "SAVINGS_GOAL_RELEASE_FAILED", "Failed to release savings goal funds")
+		return
+	}
+
+	// Return goal
+	httpx.RespondOK(c, gin.H{
+		"message": "Funds released from savings goal successfully",
+		"goal":    goal.ToResponse(),
+	})
+}
+
+// respondGoalError writes the response for an error from the savings goal
+// service, falling back to a 500 with code and message
+func (h *SavingsGoalHandler) respondGoalError(c *gin.Context, err error, code, message string) {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondValidationError(c, validationErr)
+	case errors.Is(err, services.ErrSavingsGoalNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "SAVINGS_GOAL_NOT_FOUND",
+			Message: "Savings goal not found",
+		})
+	case errors.Is(err, services.ErrInsufficientAvailableFunds):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "INSUFFICIENT_AVAILABLE_FUNDS",
+			Message: "Not enough of the balance is available to allocate",
+		})
+	case errors.Is(err, services.ErrGoalReleaseTooLarge):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusConflict,
+			Code:    "GOAL_RELEASE_TOO_LARGE",
+			Message: "Cannot release more than is allocated to the savings goal",
+		})
+	default:
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    code,
+			Message: message,
+			Details: middleware.ErrorDetails(c, err),
+		})
+	}
+}
+
+// userIDFromContext returns the ID of the current user, set by
+// AuthMiddleware, writing a 500 if it is missing or malformed
+func userIDFromContext(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
+		})
+		return uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
+		})
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+// goalIDsFromRequest returns the current user's ID and the goal ID from
+// the URL, writing an error response if either is missing or malformed
+func goalIDsFromRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	goalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_GOAL_ID",
+			Message: "Invalid goal ID format",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, goalID, true
+}
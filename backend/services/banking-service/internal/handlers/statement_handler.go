@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/pdfrender"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// StatementHandler handles monthly account statement HTTP requests
+type StatementHandler struct {
+	statementService *services.StatementService
+}
+
+// NewStatementHandler creates a new statement handler
+func NewStatementHandler(statementService *services.StatementService) *StatementHandler {
+	return &StatementHandler{
+		statementService: statementService,
+	}
+}
+
+// GetStatement retrieves the statement for the month in the URL, in
+// YYYY-MM form, of the authenticated user's account or the joint account
+// selected by the account_id query parameter, as JSON or, with
+// format=pdf, as a PDF document
+func (h *StatementHandler) GetStatement(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	accountID, ok := accountIDFromQuery(c)
+	if !ok {
+		return
+	}
+
+	h.respondStatement(c, userID, accountID)
+}
+
+// UserStatement returns a user's statement for the month in the URL, so
+// the client service can email it to them
+func (h *StatementHandler) UserStatement(c *gin.Context) {
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	h.respondStatement(c, userID, nil)
+}
+
+// respondStatement writes the statement of the account the user selects,
+// their own when accountID is nil, for the period in the URL, in the
+// format asked for
+func (h *StatementHandler) respondStatement(c *gin.Context, userID uuid.UUID, accountID *uuid.UUID) {
+	format, err := parseFormat(c)
+	if err != nil {
+		respondInvalidQuery(c, err)
+		return
+	}
+
+	// Get statement
+	statement, err := h.statementService.GetStatement(userID, accountID, c.Param("period"))
+	if err != nil {
+		var validationErr *services.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			respondValidationError(c, validationErr)
+		case errors.Is(err, services.ErrAccountNotFound):
+			respondAccountNotFound(c)
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "FETCH_STATEMENT_FAILED",
+				Message: "Failed to fetch statement",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return statement
+	if format == formatPDF {
+		respondPDF(c, "statement-"+statement.Period+".pdf", pdfrender.Statement(statement.ToResponse(), time.Now()))
+		return
+	}
+	httpx.RespondOK(c, gin.H{
+		"message":   "Statement retrieved successfully",
+		"statement": statement.ToResponse(),
+	})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// StatsHandler serves the banking figures of the admin dashboard to the
+// client-service
+type StatsHandler struct {
+	statsService *services.StatsService
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(statsService *services.StatsService) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+	}
+}
+
+// GetStats returns the money held, the day's activity and the alerts
+// waiting for staff
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	stats, err := h.statsService.GetStats()
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_STATS_FAILED",
+			Message: "Failed to fetch banking stats",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"stats": stats,
+	})
+}
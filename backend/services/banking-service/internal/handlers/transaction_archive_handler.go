@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// archivedTransactionsNotice tells staff reading archived transactions how
+// they differ from live ones
+const archivedTransactionsNotice = "These transactions are archived. They are read on a slower path than live transactions and are left out of users' transaction history."
+
+// TransactionArchiveHandler handles looking up archived transactions
+type TransactionArchiveHandler struct {
+	archiveService *services.TransactionArchiveService
+	enricher       *services.TransactionEnricher
+}
+
+// NewTransactionArchiveHandler creates a new transaction archive handler
+func NewTransactionArchiveHandler(archiveService *services.TransactionArchiveService, enricher *services.TransactionEnricher) *TransactionArchiveHandler {
+	return &TransactionArchiveHandler{
+		archiveService: archiveService,
+		enricher:       enricher,
+	}
+}
+
+// ListArchivedTransactions retrieves archived transactions of a user or
+// account, newest first, with their customer's details, optionally made
+// from and before given RFC 3339 times (staff only)
+func (h *TransactionArchiveHandler) ListArchivedTransactions(c *gin.Context) {
+	var filter models.ArchivedTransactionFilter
+	ids := []struct {
+		name string
+		id   **uuid.UUID
+	}{{"user_id", &filter.UserID}, {"account_id", &filter.AccountID}}
+	for _, param := range ids {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			respondValidationError(c, fieldValidationError(param.name, "uuid", "must be a UUID"))
+			return
+		}
+		*param.id = &parsed
+	}
+	times := []struct {
+		name string
+		at   **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}}
+	for _, param := range times {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondValidationError(c, fieldValidationError(param.name, "datetime", "must be an RFC 3339 time"))
+			return
+		}
+		*param.at = &parsed
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	// Get archived transactions
+	page, err := h.archiveService.ListArchivedTransactions(filter)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			respondValidationError(c, validationErr)
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_ARCHIVED_TRANSACTIONS_FAILED",
+			Message: "Failed to fetch archived transactions",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return archived transactions with their customers, flagged as such
+	response := gin.H{
+		"message":  "Archived transactions retrieved successfully",
+		"archived": true,
+		"notice":   archivedTransactionsNotice,
+	}
+	response["transactions"] = enrichTransactions(h.enricher, page.Transactions, response)
+	httpx.RespondPage(c, response, &httpx.Pagination{
+		Limit:  page.Limit,
+		Offset: page.Offset,
+		Count:  len(page.Transactions),
+	})
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// TransactionConfirmationHandler handles confirmation threshold settings
+// and the confirmation of withdrawals and transfers over them
+type TransactionConfirmationHandler struct {
+	confirmationService *services.TransactionConfirmationService
+	transactions        *TransactionHandler
+	externalTransfers   *ExternalTransferHandler
+}
+
+// NewTransactionConfirmationHandler creates a new transaction confirmation
+// handler. Confirmed withdrawals and transfers are carried out by
+// transactions, and external transfers by externalTransfers, as if they
+// had just been made.
+func NewTransactionConfirmationHandler(confirmationService *services.TransactionConfirmationService, transactions *TransactionHandler, externalTransfers *ExternalTransferHandler) *TransactionConfirmationHandler {
+	return &TransactionConfirmationHandler{
+		confirmationService: confirmationService,
+		transactions:        transactions,
+		externalTransfers:   externalTransfers,
+	}
+}
+
+// GetThreshold retrieves the amount over which the current user's
+// withdrawals and transfers need confirming
+func (h *TransactionConfirmationHandler) GetThreshold(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get threshold
+	threshold, err := h.confirmationService.Threshold(userID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_CONFIRMATION_THRESHOLD_FAILED",
+			Message: "Failed to fetch confirmation threshold",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return threshold
+	httpx.RespondOK(c, gin.H{
+		"message":      "Confirmation threshold retrieved successfully",
+		"confirmation": threshold,
+	})
+}
+
+// UpdateThreshold sets the amount over which the current user's
+// withdrawals and transfers need confirming
+func (h *TransactionConfirmationHandler) UpdateThreshold(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.UpdateConfirmationThresholdRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Update threshold
+	threshold, err := h.confirmationService.SetThreshold(userID, request)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "UPDATE_CONFIRMATION_THRESHOLD_FAILED",
+			Message: "Failed to update confirmation threshold",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return threshold
+	httpx.RespondOK(c, gin.H{
+		"message":      "Confirmation threshold updated successfully",
+		"confirmation": threshold,
+	})
+}
+
+// Confirm carries out a withdrawal or transfer held for confirmation. The
+// token is used up even when the withdrawal or transfer then fails, for
+// example because the account no longer has the funds.
+func (h *TransactionConfirmationHandler) Confirm(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.ConfirmTransactionRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Use the token
+	confirmation, err := h.confirmationService.Confirm(userID, request.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrConfirmationInvalid) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_CONFIRMATION_TOKEN",
+				Message: "Confirmation token is invalid, expired or already used",
+			})
+			return
+		}
+		respondConfirmationFailed(c, err)
+		return
+	}
+
+	// Carry out the withdrawal or transfer it held
+	switch confirmation.Kind {
+	case models.TransactionConfirmationWithdrawal:
+		var withdrawal models.TransactionRequest
+		if decodeConfirmedRequest(c, confirmation, &withdrawal) {
+			h.transactions.withdraw(c, userID, withdrawal)
+		}
+	case models.TransactionConfirmationTransfer:
+		var transfer models.TransferRequest
+		if decodeConfirmedRequest(c, confirmation, &transfer) {
+			h.transactions.transfer(c, userID, transfer)
+		}
+	case models.TransactionConfirmationExternalTransfer:
+		var transfer models.ExternalTransferRequest
+		if decodeConfirmedRequest(c, confirmation, &transfer) {
+			h.externalTransfers.createExternalTransfer(c, userID, transfer)
+		}
+	default:
+		respondConfirmationFailed(c, fmt.Errorf("unknown confirmation kind %q", confirmation.Kind))
+	}
+}
+
+// requireConfirmation holds a withdrawal or transfer of amount over the
+// user's threshold, responding with the token to confirm it with and
+// summary, which describes it to the user. It reports whether it
+// responded, held or failed, in which case the caller stops.
+func requireConfirmation(c *gin.Context, confirmationService *services.TransactionConfirmationService, userID uuid.UUID, kind models.TransactionConfirmationKind, amount float64, request any, summary gin.H) bool {
+	pending, err := confirmationService.Require(userID, kind, amount, request)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "CONFIRMATION_CHECK_FAILED",
+			Message: "Failed to check whether the transaction needs confirming",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return true
+	}
+	if pending == nil {
+		return false
+	}
+
+	summary["kind"] = kind
+	httpx.Respond(c, http.StatusAccepted, gin.H{
+		"message":               "Transaction is over your confirmation threshold and must be confirmed",
+		"confirmation_required": true,
+		"confirmation":          pending,
+		"summary":               summary,
+	})
+	return true
+}
+
+// decodeConfirmedRequest reads the request a confirmation held into
+// request, responding with an error if it cannot
+func decodeConfirmedRequest(c *gin.Context, confirmation *models.TransactionConfirmation, request any) bool {
+	if err := json.Unmarshal(confirmation.Request, request); err != nil {
+		respondConfirmationFailed(c, fmt.Errorf("failed to decode %s request: %w", confirmation.Kind, err))
+		return false
+	}
+	return true
+}
+
+func respondConfirmationFailed(c *gin.Context, err error) {
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusInternalServerError,
+		Code:    "CONFIRMATION_FAILED",
+		Message: "Failed to confirm transaction",
+		Details: middleware.ErrorDetails(c, err),
+	})
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// TransactionExportHandler handles exporting every transaction
+type TransactionExportHandler struct {
+	transactionService *services.TransactionService
+}
+
+// NewTransactionExportHandler creates a new transaction export handler
+func NewTransactionExportHandler(transactionService *services.TransactionService) *TransactionExportHandler {
+	return &TransactionExportHandler{
+		transactionService: transactionService,
+	}
+}
+
+// ExportTransactions streams every transaction, oldest first, as NDJSON
+// (staff only). since, an RFC 3339 time, limits the export to transactions
+// created at or after it, for incremental dumps, and the listing's amount
+// filters and sorting apply too. Clients that accept gzip
+// get the stream gzipped. Each batch read from the database is flushed to
+// the client as soon as it is written, and the export stops when the
+// client goes away.
+func (h *TransactionExportHandler) ExportTransactions(c *gin.Context) {
+	filter, err := parseTransactionFilter(c, "asc")
+	if err != nil {
+		respondInvalidQuery(c, err)
+		return
+	}
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_QUERY_PARAMETER",
+				Message: "since must be an RFC 3339 time",
+			})
+			return
+		}
+		filter.Since = &parsed
+	}
+
+	// The NDJSON response starts with the first transaction, so errors
+	// found before then can still be reported as JSON
+	var out io.Writer = c.Writer
+	var gz *gzip.Writer
+	var encoder *json.Encoder
+	started := false
+	start := func() {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="transactions-`+time.Now().UTC().Format("20060102")+`.ndjson"`)
+		c.Header("Vary", "Accept-Encoding")
+		if acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Header("Content-Encoding", "gzip")
+			gz = gzip.NewWriter(c.Writer)
+			out = gz
+		}
+		c.Status(http.StatusOK)
+		encoder = json.NewEncoder(out)
+		started = true
+	}
+	flush := func() error {
+		if gz != nil {
+			if err := gz.Flush(); err != nil {
+				return err
+			}
+		}
+		if flusher, ok := c.Writer.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	// Export transactions
+	ctx := c.Request.Context()
+	written, err := h.transactionService.ExportTransactions(ctx, filter, func(transaction *models.Transaction, last bool) error {
+		if !started {
+			start()
+		}
+		if err := encoder.Encode(transaction.ToResponse()); err != nil {
+			return err
+		}
+		if last {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil && !started {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "EXPORT_TRANSACTIONS_FAILED",
+			Message: "Failed to export transactions",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+	if err != nil {
+		// Headers are already sent, so the export can only be cut short
+		if ctx.Err() != nil {
+			log.Printf("Transaction export stopped after %d rows: client went away", written)
+		} else {
+			log.Printf("Transaction export stopped after %d rows: %v", written, err)
+		}
+		c.Abort()
+		return
+	}
+
+	if !started {
+		start()
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			log.Printf("Failed to finish transaction export: %v", err)
+		}
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		name, value, found := strings.Cut(params, "=")
+		if found && strings.TrimSpace(name) == "q" {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			return err == nil && q > 0
+		}
+		return true
+	}
+	return false
+}
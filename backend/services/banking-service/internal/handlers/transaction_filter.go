@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/httpx"
+)
+
+// parseTransactionFilter reads the transaction listing query parameters:
+// min_amount and max_amount, sort (created_at or amount) and order (asc or
+// desc, defaultOrder when it is not given)
+func parseTransactionFilter(c *gin.Context, defaultOrder string) (models.TransactionFilter, error) {
+	var filter models.TransactionFilter
+
+	// Amount filters
+	amounts := []struct {
+		name   string
+		target **float64
+	}{{"min_amount", &filter.MinAmount}, {"max_amount", &filter.MaxAmount}}
+	for _, param := range amounts {
+		if value := c.Query(param.name); value != "" {
+			amount, err := strconv.ParseFloat(value, 64)
+			if err != nil || !(amount >= 0) || math.IsInf(amount, 1) {
+				return filter, fmt.Errorf("%s must be a non-negative amount", param.name)
+			}
+			*param.target = &amount
+		}
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return filter, fmt.Errorf("min_amount must not be more than max_amount")
+	}
+
+	// Sorting
+	filter.SortBy = c.DefaultQuery("sort", models.TransactionSortCreatedAt)
+	if filter.SortBy != models.TransactionSortCreatedAt && filter.SortBy != models.TransactionSortAmount {
+		return filter, fmt.Errorf("sort must be created_at or amount")
+	}
+	switch c.DefaultQuery("order", defaultOrder) {
+	case "asc":
+		filter.SortDesc = false
+	case "desc":
+		filter.SortDesc = true
+	default:
+		return filter, fmt.Errorf("order must be asc or desc")
+	}
+
+	return filter, nil
+}
+
+// respondInvalidQuery writes a 400 for a query parameter that could not be
+// used
+func respondInvalidQuery(c *gin.Context, err error) {
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_QUERY_PARAMETER",
+		Message: err.Error(),
+	})
+}
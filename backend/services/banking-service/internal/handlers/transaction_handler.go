@@ -1,24 +1,36 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
 	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/pdfrender"
 	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+	"microbank/pkg/resilience"
 )
 
 // TransactionHandler handles transaction-related HTTP requests
 type TransactionHandler struct {
-	transactionService *services.TransactionService
+	transactionService  *services.TransactionService
+	pinService          *services.TransactionPINService
+	confirmationService *services.TransactionConfirmationService
 }
 
-// NewTransactionHandler creates a new transaction handler
-func NewTransactionHandler(transactionService *services.TransactionService) *TransactionHandler {
+// NewTransactionHandler creates a new transaction handler. Withdrawals and
+// transfers need the user's transaction PIN, checked with pinService, once
+// they set one, and those over the user's threshold wait in
+// confirmationService until they are confirmed.
+func NewTransactionHandler(transactionService *services.TransactionService, pinService *services.TransactionPINService, confirmationService *services.TransactionConfirmationService) *TransactionHandler {
 	return &TransactionHandler{
-		transactionService: transactionService,
+		transactionService:  transactionService,
+		pinService:          pinService,
+		confirmationService: confirmationService,
 	}
 }
 
@@ -27,11 +39,10 @@ func (h *TransactionHandler) Deposit(c *gin.Context) {
 	// Get user ID from context (set by AuthMiddleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "User information not found in context",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
 		})
 		return
 	}
@@ -39,46 +50,64 @@ func (h *TransactionHandler) Deposit(c *gin.Context) {
 	// Parse user ID
 	userUUID, err := uuid.Parse(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Invalid user ID format",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
 		})
 		return
 	}
 
 	// Bind and validate request body
 	var request models.TransactionRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "Invalid request data",
-				"details": err.Error(),
-			},
-		})
+	if !bindJSON(c, &request) {
 		return
 	}
 
 	// Process deposit
-	transaction, err := h.transactionService.ProcessDeposit(userUUID, request.Amount, request.Description)
+	transaction, bonuses, err := h.transactionService.ProcessDeposit(userUUID, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "DEPOSIT_FAILED",
-				"message": "Failed to process deposit",
-				"details": err.Error(),
-			},
+		if errors.Is(err, services.ErrAccountNotFound) {
+			respondAccountNotFound(c)
+			return
+		}
+
+		if errors.Is(err, services.ErrAccountFrozen) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "ACCOUNT_FROZEN",
+				Message: "Account is frozen",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "DEPOSIT_FAILED",
+			Message: "Failed to process deposit",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
 
-	// Return success response
-	c.JSON(http.StatusCreated, gin.H{
+	// Return success response, with the balance after the deposit and its
+	// bonuses and the account version to read it back at
+	latest := transaction
+	response := gin.H{
 		"message": "Deposit processed successfully",
 		"transaction": transaction.ToResponse(),
-	})
+	}
+	if len(bonuses) > 0 {
+		bonusResponses := make([]models.TransactionResponse, len(bonuses))
+		for i := range bonuses {
+			bonusResponses[i] = bonuses[i].ToResponse()
+		}
+		response["bonuses"] = bonusResponses
+		latest = &bonuses[len(bonuses)-1]
+	}
+	response["balance"] = models.Amount(latest.BalanceAfter)
+	response["account_version"] = latest.AccountVersion
+	httpx.RespondCreated(c, response)
 }
 
 // Withdraw handles withdrawal requests
@@ -86,11 +115,10 @@ func (h *TransactionHandler) Withdraw(c *gin.Context) {
 	// Get user ID from context (set by AuthMiddleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "User information not found in context",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
 		})
 		return
 	}
@@ -98,73 +126,314 @@ func (h *TransactionHandler) Withdraw(c *gin.Context) {
 	// Parse user ID
 	userUUID, err := uuid.Parse(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Invalid user ID format",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
 		})
 		return
 	}
 
 	// Bind and validate request body
 	var request models.TransactionRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "Invalid request data",
-				"details": err.Error(),
-			},
-		})
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Check the transaction PIN, if the user set one
+	if !verifyTransactionPIN(c, h.pinService, userUUID, request.PIN) {
+		return
+	}
+
+	// Hold withdrawals over the user's threshold until they are confirmed
+	request.PIN = ""
+	if requireConfirmation(c, h.confirmationService, userUUID, models.TransactionConfirmationWithdrawal, request.Amount, request, gin.H{
+		"account_id":  request.AccountID,
+		"amount":      models.Amount(request.Amount),
+		"description": request.Description,
+	}) {
 		return
 	}
 
+	h.withdraw(c, userUUID, request)
+}
+
+// withdraw carries out a withdrawal whose PIN, and confirmation if it
+// needed one, were checked
+func (h *TransactionHandler) withdraw(c *gin.Context, userID uuid.UUID, request models.TransactionRequest) {
 	// Process withdrawal
-	transaction, err := h.transactionService.ProcessWithdrawal(userUUID, request.Amount, request.Description)
+	withdrawal, err := h.transactionService.ProcessWithdrawal(userID, request)
 	if err != nil {
 		// Check for specific error types
-		if err.Error() == "insufficient funds: requested "+fmt.Sprintf("%f", request.Amount)+", available "+fmt.Sprintf("%f", 0.0) {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": gin.H{
-					"code":    "INSUFFICIENT_FUNDS",
-					"message": "Insufficient funds for withdrawal",
-					"details": gin.H{
-						"requested_amount": request.Amount,
-					},
+		if errors.Is(err, services.ErrAccountNotFound) {
+			respondAccountNotFound(c)
+			return
+		}
+
+		if errors.Is(err, services.ErrAccountFrozen) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "ACCOUNT_FROZEN",
+				Message: "Account is frozen",
+			})
+			return
+		}
+
+		var insufficientFunds *services.InsufficientFundsError
+		if errors.As(err, &insufficientFunds) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INSUFFICIENT_FUNDS",
+				Message: "Insufficient funds for withdrawal",
+				Details: gin.H{
+					"requested_amount": models.Amount(request.Amount),
+					"fee":              models.Amount(insufficientFunds.Fee),
+				},
+			})
+			return
+		}
+
+		var earmarked *services.EarmarkedFundsError
+		if errors.As(err, &earmarked) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "FUNDS_EARMARKED",
+				Message: "Withdrawal would use money earmarked for strict savings goals",
+				Details: gin.H{
+					"requested_amount": models.Amount(request.Amount),
+					"fee":              models.Amount(earmarked.Fee),
+					"withdrawable":     models.Amount(earmarked.Withdrawable),
 				},
 			})
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "WITHDRAWAL_FAILED",
-				"message": "Failed to process withdrawal",
-				"details": err.Error(),
-			},
+		var spendingLimit *services.SpendingLimitError
+		if errors.As(err, &spendingLimit) {
+			respondSpendingLimitExceeded(c, spendingLimit, request.Amount)
+			return
+		}
+
+		var kycRequired *services.KYCRequiredError
+		if errors.As(err, &kycRequired) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "KYC_REQUIRED",
+				Message: "Identity verification is required for withdrawals over the limit",
+				Details: gin.H{
+					"requested_amount": models.Amount(request.Amount),
+					"limit":            models.Amount(kycRequired.Limit),
+					"kyc_status":       kycRequired.KYCStatus,
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, resilience.ErrDependencyUnavailable) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusServiceUnavailable,
+				Code:    "KYC_CHECK_UNAVAILABLE",
+				Message: "Unable to check identity verification status",
+				Details: middleware.ErrorDetails(c, err),
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "WITHDRAWAL_FAILED",
+			Message: "Failed to process withdrawal",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
 
-	// Return success response
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Withdrawal processed successfully",
-		"transaction": transaction.ToResponse(),
+	// Return success response, with the fee charged, the round-up and the
+	// earmarked money taken from savings goals if any, and the balance
+	// after the withdrawal and fee with the account version to read it
+	// back at
+	response := gin.H{
+		"message":         "Withdrawal processed successfully",
+		"transaction":     withdrawal.Transaction.ToResponse(),
+		"fee":             models.Amount(0),
+		"balance":         models.Amount(withdrawal.Transaction.BalanceAfter),
+		"account_version": withdrawal.Transaction.AccountVersion,
+	}
+	if withdrawal.Fee != nil {
+		response["fee"] = models.Amount(withdrawal.Fee.Amount)
+		response["fee_transaction"] = withdrawal.Fee.ToResponse()
+		response["balance"] = models.Amount(withdrawal.Fee.BalanceAfter)
+	}
+	if withdrawal.RoundUp != nil {
+		response["round_up"] = withdrawal.RoundUp.ToResponse()
+	}
+	if len(withdrawal.GoalReleases) > 0 {
+		response["warning"] = "This withdrawal used money earmarked for your savings goals"
+		releases := make([]models.SavingsGoalReleaseResponse, 0, len(withdrawal.GoalReleases))
+		for i := range withdrawal.GoalReleases {
+			releases = append(releases, withdrawal.GoalReleases[i].ToResponse())
+		}
+		response["goal_releases"] = releases
+	}
+	httpx.RespondCreated(c, response)
+}
+
+// Transfer handles transfers to another account, given by its ID or by
+// one of the current user's beneficiaries
+func (h *TransactionHandler) Transfer(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.TransferRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Check the transaction PIN, if the user set one
+	if !verifyTransactionPIN(c, h.pinService, userID, request.PIN) {
+		return
+	}
+
+	// Hold transfers over the user's threshold until they are confirmed
+	request.PIN = ""
+	if requireConfirmation(c, h.confirmationService, userID, models.TransactionConfirmationTransfer, request.Amount, request, gin.H{
+		"account_id":             request.AccountID,
+		"amount":                 models.Amount(request.Amount),
+		"description":            request.Description,
+		"destination_account_id": request.DestinationAccountID,
+		"beneficiary_id":         request.BeneficiaryID,
+	}) {
+		return
+	}
+
+	h.transfer(c, userID, request)
+}
+
+// transfer carries out a transfer whose PIN, and confirmation if it
+// needed one, were checked
+func (h *TransactionHandler) transfer(c *gin.Context, userID uuid.UUID, request models.TransferRequest) {
+	// Process transfer
+	transfer, err := h.transactionService.ProcessTransfer(userID, request)
+	if err != nil {
+		var validationErr *services.ValidationError
+		var coolingOff *services.BeneficiaryCoolingOffError
+		var insufficientFunds *services.InsufficientFundsError
+		var earmarked *services.EarmarkedFundsError
+		var spendingLimit *services.SpendingLimitError
+		var kycRequired *services.KYCRequiredError
+		switch {
+		case errors.As(err, &validationErr):
+			respondValidationError(c, validationErr)
+		case errors.Is(err, services.ErrAccountNotFound):
+			respondAccountNotFound(c)
+		case errors.Is(err, services.ErrAccountFrozen):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "ACCOUNT_FROZEN",
+				Message: "Account is frozen",
+			})
+		case errors.Is(err, services.ErrBeneficiaryNotFound):
+			respondBeneficiaryNotFound(c)
+		case errors.Is(err, services.ErrDestinationAccountNotFound):
+			respondDestinationAccountNotFound(c)
+		case errors.Is(err, services.ErrDestinationAccountUnavailable):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "DESTINATION_ACCOUNT_UNAVAILABLE",
+				Message: "The destination account cannot receive transfers",
+			})
+		case errors.As(err, &coolingOff):
+			details := gin.H{
+				"requested_amount": models.Amount(request.Amount),
+				"limit":            models.Amount(coolingOff.Limit),
+			}
+			if coolingOff.AllowedAt != nil {
+				details["large_transfers_allowed_at"] = coolingOff.AllowedAt.UTC()
+			}
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "BENEFICIARY_COOLING_OFF",
+				Message: "Transfers over the limit are only allowed to beneficiaries saved for the cooling-off period",
+				Details: details,
+			})
+		case errors.As(err, &insufficientFunds):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INSUFFICIENT_FUNDS",
+				Message: "Insufficient funds for transfer",
+				Details: gin.H{"requested_amount": models.Amount(request.Amount)},
+			})
+		case errors.As(err, &earmarked):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "FUNDS_EARMARKED",
+				Message: "Transfer would use money earmarked for savings goals",
+				Details: gin.H{
+					"requested_amount": models.Amount(request.Amount),
+					"transferable":     models.Amount(earmarked.Withdrawable),
+				},
+			})
+		case errors.As(err, &spendingLimit):
+			respondSpendingLimitExceeded(c, spendingLimit, request.Amount)
+		case errors.As(err, &kycRequired):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "KYC_REQUIRED",
+				Message: "Identity verification is required for transfers over the limit",
+				Details: gin.H{
+					"requested_amount": models.Amount(request.Amount),
+					"limit":            models.Amount(kycRequired.Limit),
+					"kyc_status":       kycRequired.KYCStatus,
+				},
+			})
+		case errors.Is(err, resilience.ErrDependencyUnavailable):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusServiceUnavailable,
+				Code:    "KYC_CHECK_UNAVAILABLE",
+				Message: "Unable to check identity verification status",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "TRANSFER_FAILED",
+				Message: "Failed to process transfer",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return the sender's side of the transfer
+	response := transfer.ToResponse()
+	httpx.RespondCreated(c, gin.H{
+		"message":                "Transfer processed successfully",
+		"transaction":            response.Transaction,
+		"destination_account_id": response.DestinationAccountID,
+		"beneficiary_id":         response.BeneficiaryID,
 	})
 }
 
-// GetTransaction retrieves a specific transaction by ID
+// GetTransaction retrieves a specific transaction by ID, as JSON or, with
+// format=pdf, as a PDF receipt
 func (h *TransactionHandler) GetTransaction(c *gin.Context) {
+	format, err := parseFormat(c)
+	if err != nil {
+		respondInvalidQuery(c, err)
+		return
+	}
+
 	// Get transaction ID from URL parameter
 	transactionIDStr := c.Param("id")
 	transactionID, err := uuid.Parse(transactionIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "INVALID_TRANSACTION_ID",
-				"message": "Invalid transaction ID format",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_TRANSACTION_ID",
+			Message: "Invalid transaction ID format",
 		})
 		return
 	}
@@ -172,11 +441,10 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 	// Get user ID from context (set by AuthMiddleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "User information not found in context",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
 		})
 		return
 	}
@@ -184,11 +452,10 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 	// Parse user ID
 	userUUID, err := uuid.Parse(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Invalid user ID format",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
 		})
 		return
 	}
@@ -196,29 +463,42 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 	// Get transaction
 	transaction, err := h.transactionService.GetTransactionByID(transactionID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": gin.H{
-				"code":    "TRANSACTION_NOT_FOUND",
-				"message": "Transaction not found",
-				"details": err.Error(),
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "TRANSACTION_NOT_FOUND",
+			Message: "Transaction not found",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
 
-	// Check if the transaction belongs to the authenticated user
-	if transaction.UserID != userUUID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": gin.H{
-				"code":    "ACCESS_DENIED",
-				"message": "Access denied to this transaction",
-			},
+	// Check if the transaction is on an account the authenticated user is
+	// a member of
+	allowed, err := h.transactionService.CanView(userUUID, transaction)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_TRANSACTION_FAILED",
+			Message: "Failed to fetch transaction",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+	if !allowed {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "ACCESS_DENIED",
+			Message: "Access denied to this transaction",
 		})
 		return
 	}
 
 	// Return transaction
-	c.JSON(http.StatusOK, gin.H{
+	if format == formatPDF {
+		respondPDF(c, "receipt-"+transaction.ID.String()+".pdf", pdfrender.Receipt(transaction.ToResponse(), time.Now()))
+		return
+	}
+	httpx.RespondOK(c, gin.H{
 		"message": "Transaction retrieved successfully",
 		"transaction": transaction.ToResponse(),
 	})
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/middleware"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/services"
+	"microbank/pkg/httpx"
+	"microbank/pkg/resilience"
+)
+
+// TransactionPINHandler handles HTTP requests for transaction PINs
+type TransactionPINHandler struct {
+	pinService *services.TransactionPINService
+}
+
+// NewTransactionPINHandler creates a new transaction PIN handler
+func NewTransactionPINHandler(pinService *services.TransactionPINService) *TransactionPINHandler {
+	return &TransactionPINHandler{
+		pinService: pinService,
+	}
+}
+
+// GetPIN reports whether the user has a transaction PIN, without revealing
+// anything about it
+func (h *TransactionPINHandler) GetPIN(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get PIN status
+	status, err := h.pinService.Status(userID)
+	if err != nil {
+		respondTransactionPINError(c, err, "FETCH_TRANSACTION_PIN_FAILED", "Failed to fetch transaction PIN")
+		return
+	}
+
+	// Return PIN status
+	httpx.RespondOK(c, gin.H{
+		"message":         "Transaction PIN status retrieved successfully",
+		"transaction_pin": status,
+	})
+}
+
+// SetPIN sets or changes the user's transaction PIN
+func (h *TransactionPINHandler) SetPIN(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.SetTransactionPINRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Set PIN
+	status, err := h.pinService.SetPIN(userID, request)
+	if err != nil {
+		respondTransactionPINError(c, err, "SET_TRANSACTION_PIN_FAILED", "Failed to set transaction PIN")
+		return
+	}
+
+	// Return PIN status
+	httpx.RespondOK(c, gin.H{
+		"message":         "Transaction PIN set successfully",
+		"transaction_pin": status,
+	})
+}
+
+// ResetPIN removes the transaction PIN of an account, so its user must set
+// a new one before their next withdrawal or transfer (staff only)
+func (h *TransactionPINHandler) ResetPIN(c *gin.Context) {
+	staffID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_ACCOUNT_ID",
+			Message: "Invalid account ID format",
+		})
+		return
+	}
+
+	// Reset PIN
+	err = h.pinService.Reset(accountID, models.TransactionPINReset{ActorID: staffID, RequestID: c.GetString(httpx.RequestIDKey)})
+	if err != nil {
+		respondTransactionPINError(c, err, "RESET_TRANSACTION_PIN_FAILED", "Failed to reset transaction PIN")
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Transaction PIN reset; the customer must set a new one",
+	})
+}
+
+// verifyTransactionPIN checks the PIN given with a withdrawal or transfer,
+// writing an error response and returning false when it does not pass
+func verifyTransactionPIN(c *gin.Context, pinService *services.TransactionPINService, userID uuid.UUID, pin string) bool {
+	if err := pinService.Verify(userID, pin); err != nil {
+		respondTransactionPINError(c, err, "TRANSACTION_PIN_CHECK_FAILED", "Failed to check transaction PIN")
+		return false
+	}
+	return true
+}
+
+// respondTransactionPINError writes the response for an error from the
+// transaction PIN service, falling back to a 500 with code and message.
+// Lockouts are answered with 429 and Retry-After.
+func respondTransactionPINError(c *gin.Context, err error, code, message string) {
+	var validationErr *services.ValidationError
+	var attempt *services.PINAttemptError
+	var locked *services.TransactionPINLockedError
+	switch {
+	case errors.As(err, &validationErr):
+		respondValidationError(c, validationErr)
+	case errors.Is(err, services.ErrAccountNotFound):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "ACCOUNT_NOT_FOUND",
+			Message: "Account not found",
+		})
+	case errors.Is(err, services.ErrTransactionPINRequired):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "TRANSACTION_PIN_REQUIRED",
+			Message: "Your transaction PIN is required",
+		})
+	case errors.Is(err, services.ErrTransactionPINResetRequired):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "TRANSACTION_PIN_RESET_REQUIRED",
+			Message: "Your transaction PIN was reset; set a new one to continue",
+		})
+	case errors.As(err, &attempt):
+		appErr := &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "INVALID_TRANSACTION_PIN",
+			Message: "Transaction PIN is incorrect",
+			Details: gin.H{"attempts_remaining": attempt.AttemptsRemaining},
+		}
+		if errors.Is(err, services.ErrInvalidPassword) {
+			appErr.Code = "INVALID_PASSWORD"
+			appErr.Message = "Password is incorrect"
+		}
+		httpx.RespondError(c, appErr)
+	case errors.As(err, &locked):
+		seconds := int(time.Until(locked.Until).Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusTooManyRequests,
+			Code:    "TRANSACTION_PIN_LOCKED",
+			Message: "Too many wrong attempts; try again later",
+			Details: gin.H{"locked_until": locked.Until.UTC()},
+		})
+	case errors.Is(err, services.ErrTransactionPINNotSet):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "TRANSACTION_PIN_NOT_SET",
+			Message: "Account has no transaction PIN",
+		})
+	case errors.Is(err, resilience.ErrDependencyUnavailable):
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "PASSWORD_CHECK_UNAVAILABLE",
+			Message: "Unable to check your password",
+			Details: middleware.ErrorDetails(c, err),
+		})
+	default:
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    code,
+			Message: message,
+			Details: middleware.ErrorDetails(c, err),
+		})
+	}
+}
@@ -1,160 +1,221 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
+	"log"
 	"net/http"
-	"os"
-	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/authmw"
+	"microbank/pkg/httpx"
+	sharedjwt "microbank/pkg/jwt"
+	"microbank/pkg/resilience"
 )
 
-// Claims represents the JWT claims structure (for backward compatibility)
-type Claims struct {
-	UserID        string `json:"user_id"`
-	Email         string `json:"email"`
-	Name          string `json:"name"`
-	IsAdmin       bool   `json:"is_admin"`
-	IsBlacklisted bool   `json:"is_blacklisted"`
-	jwt.RegisteredClaims
+// TokenValidator confirms with the client-service that an access token has
+// not been revoked, and its user not blacklisted, since it was issued. It
+// also looks up personal access tokens, which only the client-service can
+// check.
+type TokenValidator interface {
+	ValidateToken(accessToken string) (models.TokenStatus, error)
+	ResolvePersonalAccessToken(token string) (models.PersonalAccessTokenGrant, models.TokenStatus, error)
 }
 
-// AuthMiddleware validates JWT tokens and extracts user information
-func AuthMiddleware() gin.HandlerFunc {
+// RevocationChecker reports whether an access token has been revoked by its
+// jti claim
+type RevocationChecker interface {
+	IsRevoked(jti string) bool
+}
+
+// UserStatusSource looks up a user's current status on the client-service
+type UserStatusSource interface {
+	GetUserStatus(userID string) (models.UserStatus, error)
+}
+
+// TokenConfirmedKey is set in the context once AuthMiddleware has confirmed
+// the token with the client-service, which checks its token version. Role
+// changes bump the version, so the token's roles claim is then current.
+const TokenConfirmedKey = "token_confirmed"
+
+// AuthMiddleware validates JWT tokens with tokens, which verifies them
+// against the client-service keys, and extracts user information. Tokens on
+// the local revocation list are rejected, and the rest are confirmed with
+// the validator so revocations take effect at once. When the validator is
+// unavailable the request is refused, unless failOpen is set, in which case
+// the signed token is trusted. Personal access tokens are looked up with the
+// validator too, and are always refused while it is unavailable. Browsers
+// in cookie mode send the access token in the cookie the client-service
+// sets.
+func AuthMiddleware(tokens *sharedjwt.TokenManager, validator TokenValidator, revocations RevocationChecker, failOpen bool) gin.HandlerFunc {
+	auth := authmw.New(tokens,
+		authmw.WithCookie(authmw.AccessTokenCookie),
+		authmw.RequireClaims(authmw.ClaimUserID),
+		authmw.WithErrorDetails(ErrorDetails),
+		// Reject tokens revoked by the client-service without a round trip
+		authmw.WithCheck(func(_ authmw.Context, _ string, claims *sharedjwt.Claims) error {
+			if claims.ID != "" && revocations.IsRevoked(claims.ID) {
+				return errTokenRevoked
+			}
+			return nil
+		}),
+		authmw.WithCheck(func(c authmw.Context, token string, claims *sharedjwt.Claims) error {
+			return confirmToken(c, validator, token, claims, failOpen)
+		}),
+		authmw.WithPersonalAccessTokens(func(c authmw.Context, token string) (*sharedjwt.Claims, []string, error) {
+			return resolvePersonalAccessToken(c, validator, token)
+		}),
+	)
 	return func(c *gin.Context) {
-		// Get the Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "MISSING_TOKEN",
-					"message": "Authorization header is required",
-				},
-			})
-			c.Abort()
-			return
+		auth.Handle(c)
+	}
+}
+
+// errTokenRevoked is reported for tokens revoked since they were issued
+var errTokenRevoked = &httpx.AppError{
+	Status:  http.StatusUnauthorized,
+	Code:    "TOKEN_REVOKED",
+	Message: "Token has been revoked, please log in again",
+}
+
+// confirmToken confirms with the validator that the token has not been
+// revoked, nor its user blacklisted, and records whether it was confirmed
+func confirmToken(c authmw.Context, validator TokenValidator, token string, claims *sharedjwt.Claims, failOpen bool) error {
+	status, err := validator.ValidateToken(token)
+	confirmed := err == nil
+	if err != nil && failOpen && errors.Is(err, resilience.ErrDependencyUnavailable) {
+		log.Printf("Accepting token for user %s without validation: %v", claims.UserID, err)
+		status, err = models.TokenValid, nil
+	}
+	if err != nil {
+		return &httpx.AppError{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "AUTH_SERVICE_UNAVAILABLE",
+			Message: "Unable to verify token",
+			Details: ErrorDetails(c, err),
+		}
+	}
+	if status == models.TokenSuspended {
+		return &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "USER_BLACKLISTED",
+			Message: "User account has been suspended",
 		}
+	}
+	if status != models.TokenValid {
+		return errTokenRevoked
+	}
+
+	c.Set(TokenConfirmedKey, confirmed)
+	return nil
+}
 
-		// Check if the header starts with "Bearer "
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "INVALID_TOKEN_FORMAT",
-					"message": "Token must be in format: Bearer <token>",
-				},
+// resolvePersonalAccessToken asks the validator whom a personal access token
+// acts for and with which scopes
+func resolvePersonalAccessToken(c authmw.Context, validator TokenValidator, token string) (*sharedjwt.Claims, []string, error) {
+	grant, status, err := validator.ResolvePersonalAccessToken(token)
+	if err != nil {
+		return nil, nil, &httpx.AppError{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "AUTH_SERVICE_UNAVAILABLE",
+			Message: "Unable to verify token",
+			Details: ErrorDetails(c, err),
+		}
+	}
+	switch status {
+	case models.TokenValid:
+	case models.TokenSuspended:
+		return nil, nil, &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "USER_BLACKLISTED",
+			Message: "User account has been suspended",
+		}
+	default:
+		return nil, nil, errPersonalAccessTokenRejected
+	}
+
+	c.Set(TokenConfirmedKey, true)
+	return &sharedjwt.Claims{UserID: grant.UserID}, grant.Scopes, nil
+}
+
+// errPersonalAccessTokenRejected is reported for personal access tokens the
+// client-service does not accept
+var errPersonalAccessTokenRejected = errors.New("personal access token rejected by the client-service")
+
+// StaffMiddleware ensures the user holds a staff role; RequirePermission
+// then decides what each route needs. The token's roles are trusted only
+// when AuthMiddleware confirmed the token with the client-service;
+// otherwise statuses looks up the user's current roles, which replace the
+// token's, and requests from users left without any get 403 ADMIN_REVOKED.
+func StaffMiddleware(statuses UserStatusSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(authmw.ContextRoles(c)) == 0 {
+			httpx.AbortWithError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "INSUFFICIENT_PERMISSIONS",
+				Message: "Admin privileges required",
 			})
-			c.Abort()
 			return
 		}
 
-		// Extract the token
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if c.GetBool(TokenConfirmedKey) {
+			c.Next()
+			return
+		}
 
-		// Parse and validate the token
-		claims, err := parseAndValidateToken(tokenString)
+		status, err := statuses.GetUserStatus(c.GetString("user_id"))
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "INVALID_TOKEN",
-					"message": "Invalid or expired token",
-					"details": err.Error(),
-				},
+			httpx.AbortWithError(c, &httpx.AppError{
+				Status:  http.StatusServiceUnavailable,
+				Code:    "AUTH_SERVICE_UNAVAILABLE",
+				Message: "Unable to verify admin privileges",
+				Details: ErrorDetails(c, err),
 			})
-			c.Abort()
 			return
 		}
-
-		// Check if user is blacklisted
-		if claims.IsBlacklisted {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": gin.H{
-					"code":    "USER_BLACKLISTED",
-					"message": "User account has been suspended",
-				},
+		if !status.Exists || status.IsDeleted || len(status.Roles) == 0 {
+			httpx.AbortWithError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "ADMIN_REVOKED",
+				Message: "Admin privileges have been revoked",
 			})
-			c.Abort()
 			return
 		}
-
-		// Store user information in context for handlers to use
-		c.Set("user_id", claims.UserID)
-		c.Set("email", claims.Email)
-		c.Set("name", claims.Name)
-		c.Set("is_admin", claims.IsAdmin)
-		c.Set("is_blacklisted", claims.IsBlacklisted)
+		c.Set(authmw.RolesKey, status.Roles)
 
 		c.Next()
 	}
 }
 
-// parseAndValidateToken parses and validates a JWT token using MapClaims
-func parseAndValidateToken(tokenString string) (*Claims, error) {
-	// Get JWT secret from environment
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET environment variable not set")
+// RequireRole lets through users holding any of roles
+func RequireRole(roles ...string) gin.HandlerFunc {
+	check := authmw.RequireRole(roles...)
+	return func(c *gin.Context) {
+		check(c)
 	}
+}
 
-	// Parse token using MapClaims
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwtSecret), nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse token: %w", err)
+// RequirePermission lets through users whose roles grant permission
+func RequirePermission(permission string) gin.HandlerFunc {
+	check := authmw.RequirePermission(permission)
+	return func(c *gin.Context) {
+		check(c)
 	}
+}
 
-	// Extract claims from MapClaims
-	if mapClaims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Convert MapClaims to our Claims struct
-		claims := &Claims{}
-		
-		// Extract user_id (required)
-		if userID, exists := mapClaims["user_id"]; exists {
-			if userIDStr, ok := userID.(string); ok {
-				claims.UserID = userIDStr
-			} else {
-				return nil, fmt.Errorf("invalid user_id type in token")
-			}
-		} else {
-			return nil, fmt.Errorf("user_id not found in token")
-		}
-
-		// Extract email (optional)
-		if email, exists := mapClaims["email"]; exists {
-			if emailStr, ok := email.(string); ok {
-				claims.Email = emailStr
-			}
-		}
-
-		// Extract name (optional)
-		if name, exists := mapClaims["name"]; exists {
-			if nameStr, ok := name.(string); ok {
-				claims.Name = nameStr
-			}
-		}
-
-		// Extract is_admin (optional, default to false)
-		if isAdmin, exists := mapClaims["is_admin"]; exists {
-			if isAdminBool, ok := isAdmin.(bool); ok {
-				claims.IsAdmin = isAdminBool
-			}
-		}
-
-		// Extract is_blacklisted (optional, default to false)
-		if isBlacklisted, exists := mapClaims["is_blacklisted"]; exists {
-			if isBlacklistedBool, ok := isBlacklisted.(bool); ok {
-				claims.IsBlacklisted = isBlacklistedBool
-			}
-		}
-
-		return claims, nil
+// RequireScope lets through requests made with an access token, and with a
+// personal access token granting scope
+func RequireScope(scope string) gin.HandlerFunc {
+	check := authmw.RequireScope(scope)
+	return func(c *gin.Context) {
+		check(c)
 	}
+}
 
-	return nil, fmt.Errorf("invalid token")
+// ForbidImpersonation rejects requests made with an impersonation token,
+// for actions only the user may take
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authmw.ForbidImpersonation(c)
+	}
 }
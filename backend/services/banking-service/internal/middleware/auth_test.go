@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/authmw"
+	sharedjwt "microbank/pkg/jwt"
+	"microbank/pkg/resilience"
+)
+
+// fakeTokenValidator answers validation with a fixed result. Personal
+// access tokens get grant.
+type fakeTokenValidator struct {
+	status models.TokenStatus
+	err    error
+	grant  models.PersonalAccessTokenGrant
+}
+
+func (v fakeTokenValidator) ValidateToken(accessToken string) (models.TokenStatus, error) {
+	return v.status, v.err
+}
+
+func (v fakeTokenValidator) ResolvePersonalAccessToken(token string) (models.PersonalAccessTokenGrant, models.TokenStatus, error) {
+	return v.grant, v.status, v.err
+}
+
+// fakeRevocations is a revocation list keyed by jti
+type fakeRevocations map[string]bool
+
+func (r fakeRevocations) IsRevoked(jti string) bool {
+	return r[jti]
+}
+
+// fakeUserStatuses maps user IDs to their status, failing for unknown IDs
+type fakeUserStatuses map[string]models.UserStatus
+
+func (s fakeUserStatuses) GetUserStatus(userID string) (models.UserStatus, error) {
+	status, ok := s[userID]
+	if !ok {
+		return models.UserStatus{}, &resilience.DependencyError{Dependency: "client-service", Err: resilience.ErrCircuitOpen}
+	}
+	return status, nil
+}
+
+func TestAuthMiddleware_TokenValidator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "user-1",
+		"type":    sharedjwt.TokenTypeAccess,
+		"jti":     "jti-1",
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		validator   fakeTokenValidator
+		revocations fakeRevocations
+		failOpen    bool
+		wantStatus  int
+	}{
+		{name: "valid token", validator: fakeTokenValidator{status: models.TokenValid}, wantStatus: http.StatusOK},
+		{name: "revoked token", validator: fakeTokenValidator{status: models.TokenRevoked}, wantStatus: http.StatusUnauthorized},
+		{name: "user blacklisted since the token was issued", validator: fakeTokenValidator{status: models.TokenSuspended}, wantStatus: http.StatusForbidden},
+		{name: "client-service unavailable", validator: fakeTokenValidator{err: fmt.Errorf("connection refused")}, wantStatus: http.StatusServiceUnavailable},
+		{name: "revoked locally", validator: fakeTokenValidator{status: models.TokenValid}, revocations: fakeRevocations{"jti-1": true}, wantStatus: http.StatusUnauthorized},
+		{name: "revoked locally while client-service unavailable", validator: fakeTokenValidator{err: fmt.Errorf("connection refused")}, revocations: fakeRevocations{"jti-1": true}, wantStatus: http.StatusUnauthorized},
+		{name: "client-service unavailable failing open", validator: fakeTokenValidator{err: &resilience.DependencyError{Dependency: "client-service", Err: resilience.ErrCircuitOpen}}, failOpen: true, wantStatus: http.StatusOK},
+		{name: "revoked locally failing open", validator: fakeTokenValidator{err: &resilience.DependencyError{Dependency: "client-service", Err: resilience.ErrCircuitOpen}}, revocations: fakeRevocations{"jti-1": true}, failOpen: true, wantStatus: http.StatusUnauthorized},
+		{name: "unexpected error failing open", validator: fakeTokenValidator{err: fmt.Errorf("invalid response")}, failOpen: true, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/protected", AuthMiddleware(sharedjwt.NewTokenManagerWithKeys(nil, NewJWKSVerifier("", map[string]string{"": "test-secret"}), 0, 0), tt.validator, tt.revocations, tt.failOpen), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_PersonalAccessTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	grant := models.PersonalAccessTokenGrant{UserID: "user-1", Scopes: []string{authmw.ScopeReadBalance}}
+	unavailable := &resilience.DependencyError{Dependency: "client-service", Err: resilience.ErrCircuitOpen}
+
+	tests := []struct {
+		name       string
+		validator  fakeTokenValidator
+		failOpen   bool
+		path       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "scope granted", validator: fakeTokenValidator{grant: grant}, path: "/balance", wantStatus: http.StatusOK},
+		{name: "scope missing", validator: fakeTokenValidator{grant: grant}, path: "/deposit", wantStatus: http.StatusForbidden, wantCode: "INSUFFICIENT_SCOPE"},
+		{name: "rejected token", validator: fakeTokenValidator{status: models.TokenRevoked}, path: "/balance", wantStatus: http.StatusUnauthorized, wantCode: "INVALID_TOKEN"},
+		{name: "owner blacklisted", validator: fakeTokenValidator{status: models.TokenSuspended}, path: "/balance", wantStatus: http.StatusForbidden, wantCode: "USER_BLACKLISTED"},
+		{name: "client-service unavailable failing open", validator: fakeTokenValidator{err: unavailable}, failOpen: true, path: "/balance", wantStatus: http.StatusServiceUnavailable, wantCode: "AUTH_SERVICE_UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID string
+			r := gin.New()
+			auth := AuthMiddleware(sharedjwt.NewTokenManagerWithKeys(nil, NewJWKSVerifier("", map[string]string{"": "test-secret"}), 0, 0), tt.validator, fakeRevocations{}, tt.failOpen)
+			ok := func(c *gin.Context) {
+				userID = c.GetString("user_id")
+				c.Status(http.StatusOK)
+			}
+			r.GET("/balance", auth, RequireScope(authmw.ScopeReadBalance), ok)
+			r.GET("/deposit", auth, RequireScope(authmw.ScopeWriteTransactions), ok)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer pat_token")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), tt.wantCode) {
+				t.Errorf("Expected error code %s, got %s", tt.wantCode, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && userID != grant.UserID {
+				t.Errorf("Expected user %s, got %q", grant.UserID, userID)
+			}
+		})
+	}
+}
+
+func TestStaffMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := []string{authmw.RoleAdmin}
+	statuses := fakeUserStatuses{
+		"admin":   {UserID: "admin", Exists: true, IsAdmin: true, Roles: admin},
+		"demoted": {UserID: "demoted", Exists: true, Roles: []string{}},
+		"deleted": {UserID: "deleted", Exists: true, IsAdmin: true, IsDeleted: true, Roles: admin},
+		"support": {UserID: "support", Exists: true, Roles: []string{authmw.RoleSupport}},
+	}
+
+	tests := []struct {
+		name       string
+		userID     string
+		roles      []string
+		confirmed  bool
+		permission string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "admin role, token confirmed", userID: "demoted", roles: admin, confirmed: true, permission: authmw.PermissionTransactionsAdjust, wantStatus: http.StatusOK},
+		{name: "admin role, still admin", userID: "admin", roles: admin, permission: authmw.PermissionTransactionsAdjust, wantStatus: http.StatusOK},
+		{name: "admin role, admin revoked", userID: "demoted", roles: admin, permission: authmw.PermissionTransactionsRead, wantStatus: http.StatusForbidden, wantCode: "ADMIN_REVOKED"},
+		{name: "admin role, user deleted", userID: "deleted", roles: admin, permission: authmw.PermissionTransactionsRead, wantStatus: http.StatusForbidden, wantCode: "ADMIN_REVOKED"},
+		{name: "admin role, now support only", userID: "support", roles: admin, permission: authmw.PermissionTransactionsAdjust, wantStatus: http.StatusForbidden, wantCode: "INSUFFICIENT_PERMISSIONS"},
+		{name: "admin role, client-service unavailable", userID: "unknown", roles: admin, permission: authmw.PermissionTransactionsRead, wantStatus: http.StatusServiceUnavailable, wantCode: "AUTH_SERVICE_UNAVAILABLE"},
+		{name: "support reads transactions", userID: "support", roles: []string{authmw.RoleSupport}, confirmed: true, permission: authmw.PermissionTransactionsRead, wantStatus: http.StatusOK},
+		{name: "no role", userID: "admin", confirmed: true, permission: authmw.PermissionTransactionsRead, wantStatus: http.StatusForbidden, wantCode: "INSUFFICIENT_PERMISSIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/admin", func(c *gin.Context) {
+				c.Set("user_id", tt.userID)
+				c.Set(authmw.RolesKey, tt.roles)
+				c.Set(TokenConfirmedKey, tt.confirmed)
+			}, StaffMiddleware(statuses), RequirePermission(tt.permission), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), tt.wantCode) {
+				t.Errorf("Expected error code %s, got %s", tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
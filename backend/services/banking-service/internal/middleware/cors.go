@@ -4,20 +4,14 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"microbank/pkg/cors"
 )
 
-// CORS handles Cross-Origin Resource Sharing
-func CORS() gin.HandlerFunc {
+// CORS handles Cross-Origin Resource Sharing according to policy. Preflight
+// requests are answered here, before authentication runs.
+func CORS(policy *cors.Policy) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Set CORS headers
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Requested-With")
-		c.Header("Access-Control-Expose-Headers", "Content-Length, Content-Type")
-		c.Header("Access-Control-Allow-Credentials", "true")
-
-		// Handle preflight requests
-		if c.Request.Method == "OPTIONS" {
+		if policy.Apply(c.Writer.Header(), c.Request) {
 			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
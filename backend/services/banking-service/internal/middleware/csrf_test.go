@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/authmw"
+	sharedjwt "microbank/pkg/jwt"
+)
+
+func TestCSRF_AccessTokenCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "user-1",
+		"type":    sharedjwt.TokenTypeAccess,
+		"jti":     "jti-1",
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	r := gin.New()
+	protected := r.Group("", CSRF(), AuthMiddleware(sharedjwt.NewTokenManagerWithKeys(nil, NewJWKSVerifier("", map[string]string{"": "test-secret"}), 0, 0), fakeTokenValidator{status: models.TokenValid}, fakeRevocations{}, false))
+	protected.GET("/balance", func(c *gin.Context) { c.Status(http.StatusOK) })
+	protected.POST("/deposit", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		cookie     string
+		csrfCookie string
+		csrfHeader string
+		wantStatus int
+	}{
+		{name: "read with cookie", method: http.MethodGet, path: "/balance", cookie: token, wantStatus: http.StatusOK},
+		{name: "write with cookie and CSRF token", method: http.MethodPost, path: "/deposit", cookie: token, csrfCookie: "csrf", csrfHeader: "csrf", wantStatus: http.StatusOK},
+		{name: "write with cookie without CSRF token", method: http.MethodPost, path: "/deposit", cookie: token, csrfCookie: "csrf", wantStatus: http.StatusForbidden},
+		{name: "write with cookie and forged CSRF token", method: http.MethodPost, path: "/deposit", cookie: token, csrfCookie: "csrf", csrfHeader: "forged", wantStatus: http.StatusForbidden},
+		{name: "invalid cookie", method: http.MethodGet, path: "/balance", cookie: "not-a-token", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.AddCookie(&http.Cookie{Name: authmw.AccessTokenCookie, Value: tt.cookie})
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: authmw.CSRFTokenCookie, Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(authmw.CSRFHeader, tt.csrfHeader)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval is the minimum time between fetches of the
+// client-service signing keys when a token names a key that is not cached
+const jwksRefreshInterval = time.Minute
+
+// JWKSVerifier verifies access tokens against the signing keys the
+// client-service publishes as a JSON Web Key Set. Keys are cached and
+// refetched when a token names an unknown key, so a rotated key is picked
+// up without a restart. HMAC secrets, when set, are accepted for HS256
+// tokens while services move to RS256, selected by the kid header. The
+// secret under the empty kid verifies tokens issued without one.
+type JWKSVerifier struct {
+	jwksURL    string
+	httpClient *http.Client
+	hmacKeys   map[string][]byte
+
+	mu            sync.Mutex
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewJWKSVerifier creates a verifier for the key set at jwksURL.
+// hmacFallback maps key IDs to secrets accepted for HS256 tokens.
+func NewJWKSVerifier(jwksURL string, hmacFallback map[string]string) *JWKSVerifier {
+	v := &JWKSVerifier{
+		jwksURL:    jwksURL,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		hmacKeys:   make(map[string][]byte, len(hmacFallback)),
+	}
+	for kid, secret := range hmacFallback {
+		v.hmacKeys[kid] = []byte(secret)
+	}
+	return v
+}
+
+// NewJWKSVerifierFromEnv creates a verifier for JWKS_URL, defaulting to the
+// key set published by the client-service at clientServiceURL. With
+// JWT_HS256_FALLBACK=true, HS256 tokens signed with the JWT_KEYS secrets
+// (kid:secret pairs) are still accepted, as are tokens without a kid signed
+// with JWT_SECRET.
+func NewJWKSVerifierFromEnv(clientServiceURL string) (*JWKSVerifier, error) {
+	jwksURL := os.Getenv("JWKS_URL")
+	if jwksURL == "" {
+		jwksURL = strings.TrimRight(clientServiceURL, "/") + "/.well-known/jwks.json"
+	}
+
+	var fallback map[string]string
+	if value := os.Getenv("JWT_HS256_FALLBACK"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_HS256_FALLBACK must be true or false: %w", err)
+		}
+		if enabled {
+			fallback, err = ParseHMACKeys(os.Getenv("JWT_KEYS"))
+			if err != nil {
+				return nil, err
+			}
+			if secret := os.Getenv("JWT_SECRET"); secret != "" {
+				fallback[""] = secret
+			}
+			if len(fallback) == 0 {
+				return nil, fmt.Errorf("JWT_SECRET or JWT_KEYS is required when JWT_HS256_FALLBACK is enabled")
+			}
+		}
+	}
+
+	return NewJWKSVerifier(jwksURL, fallback), nil
+}
+
+// HS256FallbackEnabled reports whether HS256 tokens are accepted
+func (v *JWKSVerifier) HS256FallbackEnabled() bool {
+	return len(v.hmacKeys) > 0
+}
+
+// HMACKeyIDs returns the IDs of the HMAC keys HS256 tokens are verified
+// with. The unnamed JWT_SECRET is not listed.
+func (v *JWKSVerifier) HMACKeyIDs() []string {
+	var ids []string
+	for kid := range v.hmacKeys {
+		if kid != "" {
+			ids = append(ids, kid)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ValidMethods lists the algorithms accepted when verifying tokens
+func (v *JWKSVerifier) ValidMethods() []string {
+	methods := []string{"RS256"}
+	if v.HS256FallbackEnabled() {
+		methods = append(methods, "HS256")
+	}
+	return methods
+}
+
+// Keyfunc picks the verification key for a token by algorithm and kid
+func (v *JWKSVerifier) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		secret, ok := v.hmacKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return secret, nil
+	}
+
+	return v.signingKey(kid)
+}
+
+// signingKey returns the public key with the given key ID, fetching the
+// current keys if it is not cached. Keys are refetched at most once per
+// jwksRefreshInterval so unknown key IDs cannot flood the client-service.
+func (v *JWKSVerifier) signingKey(kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+
+	if time.Since(v.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := v.fetchKeys()
+	if err != nil {
+		return nil, err
+	}
+	v.keys = keys
+	v.keysFetchedAt = time.Now()
+
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// ParseHMACKeys parses a comma-separated list of kid:secret pairs into a map
+// of key IDs to secrets
+func ParseHMACKeys(value string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for i, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		// The entry is not echoed back as it may be a bare secret
+		kid, secret, ok := strings.Cut(pair, ":")
+		if !ok || kid == "" || secret == "" {
+			return nil, fmt.Errorf("invalid JWT key at position %d: expected kid:secret", i+1)
+		}
+		if _, exists := keys[kid]; exists {
+			return nil, fmt.Errorf("duplicate JWT key %q", kid)
+		}
+		keys[kid] = secret
+	}
+	return keys, nil
+}
+
+// fetchKeys downloads the JSON Web Key Set
+func (v *JWKSVerifier) fetchKeys() (map[string]*rsa.PublicKey, error) {
+	resp, err := v.httpClient.Get(v.jwksURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signing keys endpoint returned status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.N, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus for signing key %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.E, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent for signing key %q: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
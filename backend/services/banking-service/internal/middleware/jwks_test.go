@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeJWKS serves a JSON Web Key Set that tests can change, counting fetches
+type fakeJWKS struct {
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fetches int
+}
+
+func newFakeJWKS(t *testing.T, keys map[string]*rsa.PrivateKey) (*fakeJWKS, string) {
+	t.Helper()
+	f := &fakeJWKS{keys: keys}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.fetches++
+
+		var set []map[string]string
+		for kid, key := range f.keys {
+			set = append(set, map[string]string{
+				"kid": kid,
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": set})
+	}))
+	t.Cleanup(server.Close)
+	return f, server.URL
+}
+
+func (f *fakeJWKS) setKeys(keys map[string]*rsa.PrivateKey) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = keys
+}
+
+func generateTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"user_id": "user-1",
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestJWKSVerifier_KeyRotation(t *testing.T) {
+	oldKey := generateTestKey(t)
+	newKey := generateTestKey(t)
+	jwks, url := newFakeJWKS(t, map[string]*rsa.PrivateKey{"old": oldKey})
+	verifier := NewJWKSVerifier(url, nil)
+
+	oldToken := signRS256(t, oldKey, "old")
+	if _, err := parse(verifier, oldToken); err != nil {
+		t.Fatalf("Expected a token signed with the published key to verify, got %v", err)
+	}
+
+	// The client-service starts signing with a new key and publishes both
+	jwks.setKeys(map[string]*rsa.PrivateKey{"old": oldKey, "new": newKey})
+	verifier.keysFetchedAt = time.Time{}
+
+	if _, err := parse(verifier, signRS256(t, newKey, "new")); err != nil {
+		t.Errorf("Expected a token signed with the new key to verify after a refresh, got %v", err)
+	}
+	if _, err := parse(verifier, oldToken); err != nil {
+		t.Errorf("Expected a token signed with the old key to still verify, got %v", err)
+	}
+	if jwks.fetches != 2 {
+		t.Errorf("Expected 2 fetches, got %d", jwks.fetches)
+	}
+
+	// A token claiming a published kid but signed by another key is rejected
+	if _, err := parse(verifier, signRS256(t, generateTestKey(t), "new")); err == nil {
+		t.Error("Expected a token with a forged signature to be rejected")
+	}
+}
+
+func TestJWKSVerifier_UnknownKeyRefreshIsRateLimited(t *testing.T) {
+	key := generateTestKey(t)
+	jwks, url := newFakeJWKS(t, map[string]*rsa.PrivateKey{"key-1": key})
+	verifier := NewJWKSVerifier(url, nil)
+
+	for i := 0; i < 3; i++ {
+		if _, err := parse(verifier, signRS256(t, key, "unknown")); err == nil {
+			t.Fatal("Expected a token with an unknown kid to be rejected")
+		}
+	}
+	if jwks.fetches != 1 {
+		t.Errorf("Expected unknown kids to trigger 1 fetch within the refresh interval, got %d", jwks.fetches)
+	}
+}
+
+func TestJWKSVerifier_HS256Fallback(t *testing.T) {
+	_, url := newFakeJWKS(t, map[string]*rsa.PrivateKey{})
+
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "user-1",
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	if _, err := parse(NewJWKSVerifier(url, nil), legacy); err == nil {
+		t.Error("Expected an HS256 token to be rejected without the fallback")
+	}
+	if _, err := parse(NewJWKSVerifier(url, map[string]string{"": "test-secret"}), legacy); err != nil {
+		t.Errorf("Expected an HS256 token to verify with the fallback, got %v", err)
+	}
+}
+
+func TestJWKSVerifier_HS256KeysByKid(t *testing.T) {
+	_, url := newFakeJWKS(t, map[string]*rsa.PrivateKey{})
+	verifier := NewJWKSVerifier(url, map[string]string{"2026-01": "old-secret", "2026-02": "new-secret"})
+
+	sign := func(kid, secret string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": "user-1",
+			"exp":     time.Now().Add(time.Minute).Unix(),
+		})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "active key", token: sign("2026-02", "new-secret")},
+		{name: "retired key", token: sign("2026-01", "old-secret")},
+		{name: "kid naming another key's secret", token: sign("2026-02", "old-secret"), wantErr: true},
+		{name: "removed key", token: sign("2025-12", "older-secret"), wantErr: true},
+		{name: "no kid without an unnamed secret", token: sign("", "new-secret"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(verifier, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewJWKSVerifierFromEnv(t *testing.T) {
+	t.Setenv("JWKS_URL", "")
+	t.Setenv("JWT_HS256_FALLBACK", "")
+	t.Setenv("JWT_KEYS", "")
+
+	verifier, err := NewJWKSVerifierFromEnv("http://client-service:8081/")
+	if err != nil {
+		t.Fatalf("NewJWKSVerifierFromEnv returned error: %v", err)
+	}
+	if verifier.jwksURL != "http://client-service:8081/.well-known/jwks.json" || verifier.HS256FallbackEnabled() {
+		t.Errorf("Unexpected verifier: %s, fallback %v", verifier.jwksURL, verifier.HS256FallbackEnabled())
+	}
+
+	t.Setenv("JWT_HS256_FALLBACK", "true")
+	t.Setenv("JWT_SECRET", "")
+	if _, err := NewJWKSVerifierFromEnv("http://client-service:8081"); err == nil {
+		t.Error("Expected an error when the fallback is enabled without JWT_SECRET or JWT_KEYS")
+	}
+
+	t.Setenv("JWT_KEYS", "2026-02:new-secret,2026-01:old-secret")
+	verifier, err = NewJWKSVerifierFromEnv("http://client-service:8081")
+	if err != nil {
+		t.Fatalf("NewJWKSVerifierFromEnv returned error: %v", err)
+	}
+	if ids := verifier.HMACKeyIDs(); len(ids) != 2 || ids[0] != "2026-01" || ids[1] != "2026-02" {
+		t.Errorf("Unexpected HMAC key IDs: %v", ids)
+	}
+
+	t.Setenv("JWT_KEYS", "bare-secret")
+	if _, err := NewJWKSVerifierFromEnv("http://client-service:8081"); err == nil {
+		t.Error("Expected an error for a malformed JWT_KEYS entry")
+	}
+}
+
+// parse verifies a token with verifier the way the shared token manager does
+func parse(verifier *JWKSVerifier, tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, verifier.Keyfunc, jwt.WithValidMethods(verifier.ValidMethods())); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
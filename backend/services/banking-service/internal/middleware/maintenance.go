@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/httpx"
+)
+
+// MaintenanceSource reports whether money movement is paused, and if so
+// the state that paused it
+type MaintenanceSource interface {
+	Paused() (models.MaintenanceState, bool)
+}
+
+// PauseMoneyMovement rejects requests to routes that create transactions
+// with 503 TRANSACTIONS_PAUSED while maintenance mode is on. Pauses with an
+// end time also send Retry-After.
+func PauseMoneyMovement(maintenance MaintenanceSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state, paused := maintenance.Paused()
+		if !paused {
+			c.Next()
+			return
+		}
+
+		appErr := &httpx.AppError{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "TRANSACTIONS_PAUSED",
+			Message: state.DisplayMessage(),
+		}
+		if state.EndsAt != nil {
+			appErr.Details = gin.H{"ends_at": state.EndsAt.UTC()}
+			seconds := int(time.Until(*state.EndsAt).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(seconds))
+		}
+		httpx.AbortWithError(c, appErr)
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/banking-service/internal/models"
+)
+
+// fakeMaintenanceSource pauses money movement with a fixed state
+type fakeMaintenanceSource struct {
+	state models.MaintenanceState
+}
+
+func (s fakeMaintenanceSource) Paused() (models.MaintenanceState, bool) {
+	return s.state, s.state.Active(time.Now())
+}
+
+func TestPauseMoneyMovement(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	endsAt := time.Now().Add(time.Hour)
+	ended := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name       string
+		state      models.MaintenanceState
+		wantStatus int
+		wantBody   string
+		retryAfter bool
+	}{
+		{name: "not paused", wantStatus: http.StatusOK},
+		{name: "pause ended", state: models.MaintenanceState{Enabled: true, EndsAt: &ended}, wantStatus: http.StatusOK},
+		{name: "paused", state: models.MaintenanceState{Enabled: true, Message: "Database upgrade"}, wantStatus: http.StatusServiceUnavailable, wantBody: `"message":"Database upgrade"`},
+		{name: "paused without a message", state: models.MaintenanceState{Enabled: true}, wantStatus: http.StatusServiceUnavailable, wantBody: models.DefaultMaintenanceMessage},
+		{name: "paused until", state: models.MaintenanceState{Enabled: true, EndsAt: &endsAt}, wantStatus: http.StatusServiceUnavailable, wantBody: `"ends_at"`, retryAfter: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/transactions/deposit", PauseMoneyMovement(fakeMaintenanceSource{state: tt.state}), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/transactions/deposit", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+			body := w.Body.String()
+			if !strings.Contains(body, `"code":"TRANSACTIONS_PAUSED"`) || !strings.Contains(body, tt.wantBody) {
+				t.Errorf("Expected TRANSACTIONS_PAUSED with %s, got %s", tt.wantBody, body)
+			}
+			if got := w.Header().Get("Retry-After"); (got != "") != tt.retryAfter {
+				t.Errorf("Expected Retry-After to be sent: %v, got %q", tt.retryAfter, got)
+			}
+		})
+	}
+}
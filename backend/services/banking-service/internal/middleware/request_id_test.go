@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{name: "generated when missing", incoming: ""},
+		{name: "caller id reused", incoming: "abc-123", wantSame: true},
+		{name: "oversized caller id replaced", incoming: strings.Repeat("x", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			r := gin.New()
+			r.GET("/", RequestID(), func(c *gin.Context) {
+				seen = c.GetString("request_id")
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if seen == "" || w.Header().Get(RequestIDHeader) != seen {
+				t.Fatalf("Expected the context and response header to share a request ID, got %q and %q", seen, w.Header().Get(RequestIDHeader))
+			}
+			if (seen == tt.incoming) != tt.wantSame {
+				t.Errorf("Expected reuse=%v, got request ID %q", tt.wantSame, seen)
+			}
+		})
+	}
+}
+
+func TestRequestIDInErrorResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(RequestID())
+	r.POST("/internal", ServiceAuthMiddleware("secret"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/internal", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response struct {
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if w.Code != http.StatusUnauthorized || response.RequestID != "abc-123" {
+		t.Errorf("Expected a 401 quoting the request ID, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServiceAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		configured string
+		provided   string
+		wantStatus int
+	}{
+		{name: "matching token", configured: "secret", provided: "secret", wantStatus: http.StatusOK},
+		{name: "wrong token", configured: "secret", provided: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "missing token", configured: "secret", provided: "", wantStatus: http.StatusUnauthorized},
+		{name: "not configured", configured: "", provided: "", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/internal", ServiceAuthMiddleware(tt.configured), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/internal", nil)
+			if tt.provided != "" {
+				req.Header.Set(ServiceTokenHeader, tt.provided)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
@@ -4,8 +4,20 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"microbank/pkg/money"
 )
 
+// Currency is the currency every account is held in
+const Currency = money.USD
+
+// Amount converts a stored amount to money for responses and messages.
+// Amounts are stored with two decimals and at most 13 whole digits, so
+// they always convert.
+func Amount(amount float64) money.Money {
+	converted, _ := money.FromFloat(amount, Currency)
+	return converted
+}
+
 // Account represents a user's bank account
 type Account struct {
 	ID        uuid.UUID `json:"id" db:"id"`
@@ -13,15 +25,28 @@ type Account struct {
 	Balance   float64   `json:"balance" db:"balance"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// FrozenAt is set while the owner is blacklisted; a frozen account
+	// refuses deposits and withdrawals
+	FrozenAt *time.Time `json:"frozen_at,omitempty" db:"frozen_at"`
+	// Version counts the account's balance changes. A balance read at a
+	// version is at least as new as every change up to it.
+	Version int64 `json:"version" db:"version"`
+}
+
+// IsFrozen reports whether the account is frozen
+func (a *Account) IsFrozen() bool {
+	return a.FrozenAt != nil
 }
 
 // AccountResponse represents the account data sent in responses
 type AccountResponse struct {
-	ID        uuid.UUID `json:"id"`
-	UserID    uuid.UUID `json:"user_id"`
-	Balance   float64   `json:"balance"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID   `json:"id"`
+	UserID    uuid.UUID   `json:"user_id"`
+	Balance   money.Money `json:"balance"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	Frozen    bool        `json:"frozen"`
+	FrozenAt  *time.Time  `json:"frozen_at,omitempty"`
 }
 
 // ToResponse converts an Account to AccountResponse
@@ -29,8 +54,16 @@ func (a *Account) ToResponse() AccountResponse {
 	return AccountResponse{
 		ID:        a.ID,
 		UserID:    a.UserID,
-		Balance:   a.Balance,
+		Balance:   Amount(a.Balance),
 		CreatedAt: a.CreatedAt,
 		UpdatedAt: a.UpdatedAt,
+		Frozen:    a.IsFrozen(),
+		FrozenAt:  a.FrozenAt,
 	}
 }
+
+// ProvisionAccountRequest asks for an account to be created for a user
+// registered in the client service
+type ProvisionAccountRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
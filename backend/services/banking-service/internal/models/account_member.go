@@ -0,0 +1,181 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/pkg/money"
+)
+
+// AccountMemberRole is what a member of an account may do with it
+type AccountMemberRole string
+
+const (
+	// AccountMemberRoleOwner members may also invite, change and remove
+	// members
+	AccountMemberRoleOwner AccountMemberRole = "owner"
+	// AccountMemberRoleMember members may see the account and move money
+	// from it, up to their spending limit if they have one
+	AccountMemberRoleMember AccountMemberRole = "member"
+)
+
+// IsAccountMemberRole reports whether role is a known account member role
+func IsAccountMemberRole(role string) bool {
+	switch AccountMemberRole(role) {
+	case AccountMemberRoleOwner, AccountMemberRoleMember:
+		return true
+	}
+	return false
+}
+
+// AccountMember is a user who may use an account. The user an account was
+// opened for, its holder, is an owner from the start and cannot be
+// removed; the other members of joint accounts join by accepting an
+// invitation. Transactions record which member made them.
+type AccountMember struct {
+	AccountID uuid.UUID         `json:"account_id" db:"account_id"`
+	UserID    uuid.UUID         `json:"user_id" db:"user_id"`
+	Role      AccountMemberRole `json:"role" db:"role"`
+	// SpendingLimit is the most a member, never an owner, may withdraw or
+	// transfer at once, or nil for no limit beyond the account's
+	SpendingLimit *float64 `json:"spending_limit,omitempty" db:"spending_limit"`
+	// AddedBy is the owner who invited the member, and nil for holders
+	AddedBy   *uuid.UUID `json:"added_by,omitempty" db:"added_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsOwner reports whether the member is an owner of the account
+func (m *AccountMember) IsOwner() bool {
+	return m.Role == AccountMemberRoleOwner
+}
+
+// AccountMemberResponse represents the account member data sent in
+// responses
+type AccountMemberResponse struct {
+	AccountID     uuid.UUID         `json:"account_id"`
+	UserID        uuid.UUID         `json:"user_id"`
+	Role          AccountMemberRole `json:"role"`
+	Holder        bool              `json:"holder"`
+	SpendingLimit *money.Money      `json:"spending_limit,omitempty"`
+	AddedBy       *uuid.UUID        `json:"added_by,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// ToResponse converts an AccountMember to AccountMemberResponse. holderID
+// is the user the account was opened for.
+func (m *AccountMember) ToResponse(holderID uuid.UUID) AccountMemberResponse {
+	response := AccountMemberResponse{
+		AccountID: m.AccountID,
+		UserID:    m.UserID,
+		Role:      m.Role,
+		Holder:    m.UserID == holderID,
+		AddedBy:   m.AddedBy,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+	if m.SpendingLimit != nil {
+		limit := Amount(*m.SpendingLimit)
+		response.SpendingLimit = &limit
+	}
+	return response
+}
+
+// AccountInvitationStatus is where an invitation to join an account is
+type AccountInvitationStatus string
+
+const (
+	// AccountInvitationStatusPending invitations can be accepted until
+	// they expire
+	AccountInvitationStatusPending AccountInvitationStatus = "pending"
+	// AccountInvitationStatusAccepted invitations made their user a member
+	AccountInvitationStatusAccepted AccountInvitationStatus = "accepted"
+	// AccountInvitationStatusExpired invitations were not accepted in time.
+	// Pending invitations past expires_at are reported expired before they
+	// are stored as such, which happens when the user is invited again.
+	AccountInvitationStatusExpired AccountInvitationStatus = "expired"
+)
+
+// AccountInvitation is an owner's invitation for a user, found by email
+// on the client-service, to become a member of their account
+type AccountInvitation struct {
+	ID         uuid.UUID               `json:"id" db:"id"`
+	AccountID  uuid.UUID               `json:"account_id" db:"account_id"`
+	UserID     uuid.UUID               `json:"user_id" db:"user_id"`
+	Email      string                  `json:"email" db:"email"`
+	Role       AccountMemberRole       `json:"role" db:"role"`
+	Status     AccountInvitationStatus `json:"status" db:"status"`
+	InvitedBy  uuid.UUID               `json:"invited_by" db:"invited_by"`
+	ExpiresAt  time.Time               `json:"expires_at" db:"expires_at"`
+	CreatedAt  time.Time               `json:"created_at" db:"created_at"`
+	AcceptedAt *time.Time              `json:"accepted_at,omitempty" db:"accepted_at"`
+}
+
+// StatusAt returns the invitation's status at the given time, which is
+// expired for pending invitations past their expiry
+func (i *AccountInvitation) StatusAt(at time.Time) AccountInvitationStatus {
+	if i.Status == AccountInvitationStatusPending && !at.Before(i.ExpiresAt) {
+		return AccountInvitationStatusExpired
+	}
+	return i.Status
+}
+
+// AccountInvitationResponse represents the invitation data sent in
+// responses, with its status at the time of the response
+type AccountInvitationResponse struct {
+	ID         uuid.UUID               `json:"id"`
+	AccountID  uuid.UUID               `json:"account_id"`
+	UserID     uuid.UUID               `json:"user_id"`
+	Email      string                  `json:"email"`
+	Role       AccountMemberRole       `json:"role"`
+	Status     AccountInvitationStatus `json:"status"`
+	InvitedBy  uuid.UUID               `json:"invited_by"`
+	ExpiresAt  time.Time               `json:"expires_at"`
+	CreatedAt  time.Time               `json:"created_at"`
+	AcceptedAt *time.Time              `json:"accepted_at,omitempty"`
+}
+
+// ToResponse converts an AccountInvitation to AccountInvitationResponse
+// with its status at now
+func (i *AccountInvitation) ToResponse(now time.Time) AccountInvitationResponse {
+	return AccountInvitationResponse{
+		ID:         i.ID,
+		AccountID:  i.AccountID,
+		UserID:     i.UserID,
+		Email:      i.Email,
+		Role:       i.Role,
+		Status:     i.StatusAt(now),
+		InvitedBy:  i.InvitedBy,
+		ExpiresAt:  i.ExpiresAt,
+		CreatedAt:  i.CreatedAt,
+		AcceptedAt: i.AcceptedAt,
+	}
+}
+
+// InviteAccountMemberRequest represents an owner's invitation for the user
+// with an email to join an account, their own unless AccountID selects a
+// joint account they own. Role defaults to member.
+type InviteAccountMemberRequest struct {
+	AccountID *uuid.UUID        `json:"account_id"`
+	Email     string            `json:"email" binding:"required,email"`
+	Role      AccountMemberRole `json:"role" binding:"omitempty,oneof=owner member"`
+}
+
+// UpdateAccountMemberRequest replaces a member's role and spending limit.
+// Leaving SpendingLimit out removes the limit; owners cannot have one.
+type UpdateAccountMemberRequest struct {
+	Role          AccountMemberRole `json:"role" binding:"required,oneof=owner member"`
+	SpendingLimit *float64          `json:"spending_limit" binding:"omitempty,gt=0"`
+}
+
+// AccountMembership is an account the user is a member of, with their
+// role and spending limit on it
+type AccountMembership struct {
+	AccountID     uuid.UUID         `json:"account_id"`
+	Role          AccountMemberRole `json:"role"`
+	Holder        bool              `json:"holder"`
+	SpendingLimit *money.Money      `json:"spending_limit,omitempty"`
+	Balance       money.Money       `json:"balance"`
+	Frozen        bool              `json:"frozen"`
+}
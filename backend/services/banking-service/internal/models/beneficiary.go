@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Beneficiary is another account a user saved under a nickname to
+// transfer to. Transactions do not refer to beneficiaries, so deleting one
+// leaves past transfers to its account as they were.
+type Beneficiary struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Nickname  string    `json:"nickname" db:"nickname"`
+	AccountID uuid.UUID `json:"account_id" db:"account_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// BeneficiaryRules limit beneficiaries and the transfers made to them
+type BeneficiaryRules struct {
+	// MaxPerUser is how many beneficiaries a user may save
+	MaxPerUser int
+	// CoolingOff is how long a beneficiary must be saved before transfers
+	// over LargeTransferLimit are allowed to its account
+	CoolingOff         time.Duration
+	LargeTransferLimit float64
+}
+
+// LargeTransfersAllowedAt returns when transfers over the large transfer
+// limit to the beneficiary are allowed
+func (b *Beneficiary) LargeTransfersAllowedAt(rules BeneficiaryRules) time.Time {
+	return b.CreatedAt.Add(rules.CoolingOff)
+}
+
+// BeneficiaryResponse represents the beneficiary data sent in responses
+type BeneficiaryResponse struct {
+	ID                      uuid.UUID `json:"id"`
+	Nickname                string    `json:"nickname"`
+	AccountID               uuid.UUID `json:"account_id"`
+	CreatedAt               time.Time `json:"created_at"`
+	LargeTransfersAllowedAt time.Time `json:"large_transfers_allowed_at"`
+}
+
+// ToResponse converts a Beneficiary to BeneficiaryResponse
+func (b *Beneficiary) ToResponse(rules BeneficiaryRules) BeneficiaryResponse {
+	return BeneficiaryResponse{
+		ID:                      b.ID,
+		Nickname:                b.Nickname,
+		AccountID:               b.AccountID,
+		CreatedAt:               b.CreatedAt,
+		LargeTransfersAllowedAt: b.LargeTransfersAllowedAt(rules).UTC(),
+	}
+}
+
+// CreateBeneficiaryRequest represents the data needed to save a
+// beneficiary. Account numbers are account IDs.
+type CreateBeneficiaryRequest struct {
+	Nickname  string    `json:"nickname" binding:"required,max=50"`
+	AccountID uuid.UUID `json:"account_id" binding:"required"`
+}
+
+// TransferRequest represents a transfer to another account, given either
+// by its ID or by a beneficiary saved for it. PIN is the user's
+// transaction PIN, needed once one is set. AccountID selects a joint
+// account the user is a member of to send from instead of their own.
+type TransferRequest struct {
+	AccountID            *uuid.UUID `json:"account_id"`
+	Amount               float64    `json:"amount" binding:"required,gt=0"`
+	Description          string     `json:"description" binding:"max=255"`
+	DestinationAccountID *uuid.UUID `json:"destination_account_id"`
+	BeneficiaryID        *uuid.UUID `json:"beneficiary_id"`
+	PIN                  string     `json:"pin" binding:"max=6"`
+}
+
+// Transfer is the outcome of a transfer: the transaction taking it from
+// the sender's account, the one crediting it to the recipient's and the
+// beneficiary it was made to, if any
+type Transfer struct {
+	Out           *Transaction
+	In            *Transaction
+	BeneficiaryID *uuid.UUID
+}
+
+// ToResponse converts a Transfer to TransferResponse
+func (t *Transfer) ToResponse() TransferResponse {
+	return TransferResponse{
+		Transaction:          t.Out.ToResponse(),
+		DestinationAccountID: t.In.AccountID,
+		BeneficiaryID:        t.BeneficiaryID,
+	}
+}
+
+// TransferResponse is a transfer as its sender sees it: their own
+// transaction and where the money went. The recipient's transaction, and
+// so their balance, is left out.
+type TransferResponse struct {
+	Transaction          TransactionResponse `json:"transaction"`
+	DestinationAccountID uuid.UUID           `json:"destination_account_id"`
+	BeneficiaryID        *uuid.UUID          `json:"beneficiary_id,omitempty"`
+}
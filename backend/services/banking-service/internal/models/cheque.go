@@ -0,0 +1,164 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/pkg/money"
+)
+
+// ChequeStatus is where a cheque deposit is in its clearing
+type ChequeStatus string
+
+const (
+	// ChequeStatusHeld cheques hold their amount on the account until
+	// HoldUntil. Once it passes the cheque has cleared and is reported as
+	// ChequeStatusCleared, though its stored status stays held.
+	ChequeStatusHeld ChequeStatus = "held"
+	// ChequeStatusCleared is reported for held cheques whose hold has
+	// lapsed. It is never stored.
+	ChequeStatusCleared ChequeStatus = "cleared"
+	// ChequeStatusReleased cheques had their hold lifted early by staff
+	ChequeStatusReleased ChequeStatus = "released"
+	// ChequeStatusReturned cheques bounced, and their deposit was reversed
+	// by a cheque_return transaction
+	ChequeStatusReturned ChequeStatus = "returned"
+)
+
+// IsChequeStatus reports whether status is a cheque status, as reported
+func IsChequeStatus(status string) bool {
+	switch ChequeStatus(status) {
+	case ChequeStatusHeld, ChequeStatusCleared, ChequeStatusReleased, ChequeStatusReturned:
+		return true
+	}
+	return false
+}
+
+// MaxChequeHold is how far ahead a cheque's hold may be set to lapse
+const MaxChequeHold = 30 * 24 * time.Hour
+
+// ChequeDeposit is a cheque recorded by branch staff. Its amount is
+// deposited at once, adding to the balance, but is held until HoldUntil:
+// it cannot be spent before then unless staff release it.
+type ChequeDeposit struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	AccountID    uuid.UUID `json:"account_id" db:"account_id"`
+	Amount       float64   `json:"amount" db:"amount"`
+	ChequeNumber string    `json:"cheque_number" db:"cheque_number"`
+	// TransactionID is the deposit transaction the cheque was credited by
+	TransactionID uuid.UUID    `json:"transaction_id" db:"transaction_id"`
+	Status        ChequeStatus `json:"status" db:"status"`
+	HoldUntil     time.Time    `json:"hold_until" db:"hold_until"`
+	// RecordedBy is the staff member who took the cheque, and ResolvedBy
+	// the one who released or returned it
+	RecordedBy   uuid.UUID  `json:"recorded_by" db:"recorded_by"`
+	ResolvedBy   *uuid.UUID `json:"resolved_by,omitempty" db:"resolved_by"`
+	ReturnReason string     `json:"return_reason,omitempty" db:"return_reason"`
+	// ReturnTransactionID is the cheque_return transaction of returned
+	// cheques, and ReturnFeeTransactionID the fee charged on it, if any
+	ReturnTransactionID    *uuid.UUID `json:"return_transaction_id,omitempty" db:"return_transaction_id"`
+	ReturnFeeTransactionID *uuid.UUID `json:"return_fee_transaction_id,omitempty" db:"return_fee_transaction_id"`
+	CreatedAt              time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at" db:"updated_at"`
+	ResolvedAt             *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// OnHold reports whether the cheque's amount is held at the given time.
+// The hold lapses at HoldUntil.
+func (c *ChequeDeposit) OnHold(at time.Time) bool {
+	return c.Status == ChequeStatusHeld && at.Before(c.HoldUntil)
+}
+
+// StatusAt returns the cheque's status as reported at the given time, which
+// is cleared for held cheques whose hold has lapsed
+func (c *ChequeDeposit) StatusAt(at time.Time) ChequeStatus {
+	if c.Status == ChequeStatusHeld && !c.OnHold(at) {
+		return ChequeStatusCleared
+	}
+	return c.Status
+}
+
+// ChequeDepositResponse represents the cheque deposit data sent in
+// responses
+type ChequeDepositResponse struct {
+	ID                     uuid.UUID    `json:"id"`
+	UserID                 uuid.UUID    `json:"user_id"`
+	AccountID              uuid.UUID    `json:"account_id"`
+	Amount                 money.Money  `json:"amount"`
+	ChequeNumber           string       `json:"cheque_number"`
+	TransactionID          uuid.UUID    `json:"transaction_id"`
+	Status                 ChequeStatus `json:"status"`
+	HoldUntil              time.Time    `json:"hold_until"`
+	RecordedBy             uuid.UUID    `json:"recorded_by"`
+	ResolvedBy             *uuid.UUID   `json:"resolved_by,omitempty"`
+	ReturnReason           string       `json:"return_reason,omitempty"`
+	ReturnTransactionID    *uuid.UUID   `json:"return_transaction_id,omitempty"`
+	ReturnFeeTransactionID *uuid.UUID   `json:"return_fee_transaction_id,omitempty"`
+	CreatedAt              time.Time    `json:"created_at"`
+	ResolvedAt             *time.Time   `json:"resolved_at,omitempty"`
+}
+
+// ToResponse converts a ChequeDeposit to ChequeDepositResponse, with its
+// status as reported at the given time
+func (c *ChequeDeposit) ToResponse(at time.Time) ChequeDepositResponse {
+	return ChequeDepositResponse{
+		ID:                     c.ID,
+		UserID:                 c.UserID,
+		AccountID:              c.AccountID,
+		Amount:                 Amount(c.Amount),
+		ChequeNumber:           c.ChequeNumber,
+		TransactionID:          c.TransactionID,
+		Status:                 c.StatusAt(at),
+		HoldUntil:              c.HoldUntil,
+		RecordedBy:             c.RecordedBy,
+		ResolvedBy:             c.ResolvedBy,
+		ReturnReason:           c.ReturnReason,
+		ReturnTransactionID:    c.ReturnTransactionID,
+		ReturnFeeTransactionID: c.ReturnFeeTransactionID,
+		CreatedAt:              c.CreatedAt,
+		ResolvedAt:             c.ResolvedAt,
+	}
+}
+
+// ChequeDepositFilter controls filtering and paging of cheque deposits.
+// Empty filters are not applied. At is the time Status is reported at.
+type ChequeDepositFilter struct {
+	UserID *uuid.UUID
+	Status ChequeStatus
+	At     time.Time
+	Limit  int
+	Offset int
+}
+
+// ChequeDepositPage is one page of cheque deposits along with the total
+// number matching the filters
+type ChequeDepositPage struct {
+	Cheques []ChequeDeposit
+	Total   int
+	Limit   int
+	Offset  int
+}
+
+// ChequeRules control how cheque deposits are held
+type ChequeRules struct {
+	// DefaultHold is how long cheques are held when staff do not say
+	DefaultHold time.Duration
+}
+
+// ChequeDepositRequest represents a request from staff to record a cheque
+// deposit. HoldUntil defaults to the configured hold from now.
+type ChequeDepositRequest struct {
+	UserID       uuid.UUID  `json:"user_id" binding:"required"`
+	Amount       float64    `json:"amount" binding:"required,gt=0"`
+	ChequeNumber string     `json:"cheque_number" binding:"required,max=20,numeric"`
+	HoldUntil    *time.Time `json:"hold_until"`
+	Description  string     `json:"description" binding:"max=255"`
+}
+
+// ReturnChequeRequest represents a request from staff to return a cheque
+// that bounced, optionally charging a fee
+type ReturnChequeRequest struct {
+	Reason string  `json:"reason" binding:"required,max=255"`
+	Fee    float64 `json:"fee" binding:"gte=0"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DepositJobStatus is where a queued deposit is in its processing
+type DepositJobStatus string
+
+const (
+	// DepositJobStatusPending jobs wait for a worker, possibly until their
+	// next attempt is due
+	DepositJobStatusPending DepositJobStatus = "pending"
+	// DepositJobStatusProcessing jobs are claimed by a worker until their
+	// lease runs out
+	DepositJobStatusProcessing DepositJobStatus = "processing"
+	// DepositJobStatusCompleted jobs were deposited
+	DepositJobStatusCompleted DepositJobStatus = "completed"
+	// DepositJobStatusFailed jobs will not be deposited
+	DepositJobStatusFailed DepositJobStatus = "failed"
+)
+
+// DepositBatch is a set of deposits submitted together and processed in
+// the background. Reference, when set, is the submitter's own ID for the
+// batch; submitting it again returns the existing batch.
+type DepositBatch struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Reference string    `json:"reference,omitempty" db:"reference"`
+	ItemCount int       `json:"item_count" db:"item_count"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// DepositJob is one queued deposit of a batch. Position is its index in
+// the submitted batch.
+type DepositJob struct {
+	ID            uuid.UUID        `db:"id"`
+	BatchID       uuid.UUID        `db:"batch_id"`
+	Position      int              `db:"position"`
+	UserID        uuid.UUID        `db:"user_id"`
+	Amount        float64          `db:"amount"`
+	Description   string           `db:"description"`
+	Status        DepositJobStatus `db:"status"`
+	Attempts      int              `db:"attempts"`
+	LastError     string           `db:"last_error"`
+	TransactionID *uuid.UUID       `db:"transaction_id"`
+}
+
+// DepositJobFailure describes a deposit of a batch that will not be made
+type DepositJobFailure struct {
+	Position int       `json:"position"`
+	UserID   uuid.UUID `json:"user_id"`
+	Amount   float64   `json:"amount"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+}
+
+// DepositBatchProgress is how far the processing of a batch has come
+type DepositBatchProgress struct {
+	DepositBatch
+	Pending    int                 `json:"pending"`
+	Processing int                 `json:"processing"`
+	Completed  int                 `json:"completed"`
+	Failed     int                 `json:"failed"`
+	Done       bool                `json:"done"`
+	Failures   []DepositJobFailure `json:"failures"`
+}
+
+// CreateDepositBatchRequest represents the request to queue a batch of up
+// to 1000 deposits
+type CreateDepositBatchRequest struct {
+	Reference string                    `json:"reference" binding:"max=100"`
+	Deposits  []DepositBatchItemRequest `json:"deposits" binding:"required,min=1,max=1000,dive"`
+}
+
+// DepositBatchItemRequest is one deposit of a batch
+type DepositBatchItemRequest struct {
+	UserID      uuid.UUID `json:"user_id" binding:"required"`
+	Amount      float64   `json:"amount" binding:"required,gt=0"`
+	Description string    `json:"description" binding:"max=255"`
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DisputeStatus is where a transaction dispute is in its review
+type DisputeStatus string
+
+const (
+	// DisputeStatusOpen disputes are waiting for a staff member
+	DisputeStatusOpen DisputeStatus = "open"
+	// DisputeStatusInvestigating disputes are assigned to a staff member
+	DisputeStatusInvestigating DisputeStatus = "investigating"
+	// DisputeStatusResolvedRefunded disputes were upheld, and the disputed
+	// amount refunded
+	DisputeStatusResolvedRefunded DisputeStatus = "resolved_refunded"
+	// DisputeStatusResolvedRejected disputes were turned down
+	DisputeStatusResolvedRejected DisputeStatus = "resolved_rejected"
+)
+
+// Resolved reports whether the dispute is closed
+func (s DisputeStatus) Resolved() bool {
+	return s == DisputeStatusResolvedRefunded || s == DisputeStatusResolvedRejected
+}
+
+// IsDisputeStatus reports whether status is a known dispute status
+func IsDisputeStatus(status string) bool {
+	switch DisputeStatus(status) {
+	case DisputeStatusOpen, DisputeStatusInvestigating, DisputeStatusResolvedRefunded, DisputeStatusResolvedRejected:
+		return true
+	}
+	return false
+}
+
+// Dispute outcomes staff can resolve a dispute with
+const (
+	DisputeOutcomeRefunded = "refunded"
+	DisputeOutcomeRejected = "rejected"
+)
+
+// Dispute is a user's claim that one of their transactions is wrong. A
+// transaction has at most one dispute that is not rejected, so it can be
+// refunded only once.
+type Dispute struct {
+	ID             uuid.UUID     `json:"id" db:"id"`
+	TransactionID  uuid.UUID     `json:"transaction_id" db:"transaction_id"`
+	AccountID      uuid.UUID     `json:"account_id" db:"account_id"`
+	UserID         uuid.UUID     `json:"user_id" db:"user_id"`
+	ReasonCategory string        `json:"reason_category" db:"reason_category"`
+	Description    string        `json:"description" db:"description"`
+	Amount         float64       `json:"amount" db:"amount"`
+	Status         DisputeStatus `json:"status" db:"status"`
+	AssignedTo     *uuid.UUID    `json:"assigned_to,omitempty" db:"assigned_to"`
+	ResolutionNote string        `json:"resolution_note,omitempty" db:"resolution_note"`
+	// RefundTransactionID is the refund of disputes resolved with one
+	RefundTransactionID *uuid.UUID `json:"refund_transaction_id,omitempty" db:"refund_transaction_id"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+	ResolvedAt          *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// DisputeChange describes a change to a dispute for its event: who made
+// it, in which request, and the note they left
+type DisputeChange struct {
+	ActorID   uuid.UUID
+	RequestID string
+	Note      string
+}
+
+// DisputeFilter controls filtering and paging of the dispute list. Empty
+// filters are not applied.
+type DisputeFilter struct {
+	Status     DisputeStatus
+	AssignedTo *uuid.UUID
+	Limit      int
+	Offset     int
+}
+
+// DisputePage is one page of disputes along with the total number
+// matching the filters
+type DisputePage struct {
+	Disputes []Dispute
+	Total    int
+	Limit    int
+	Offset   int
+}
+
+// FileDisputeRequest represents a request to dispute a transaction
+type FileDisputeRequest struct {
+	ReasonCategory string `json:"reason_category" binding:"required,oneof=unauthorized duplicate incorrect_amount not_received other"`
+	Description    string `json:"description" binding:"required,max=1000"`
+}
+
+// AssignDisputeRequest represents a request to assign a dispute for
+// investigation. The staff member making it is assigned when AssigneeID is
+// not set.
+type AssignDisputeRequest struct {
+	AssigneeID *uuid.UUID `json:"assignee_id"`
+	Note       string     `json:"note" binding:"max=1000"`
+}
+
+// ResolveDisputeRequest represents a request to close a dispute
+type ResolveDisputeRequest struct {
+	Outcome string `json:"outcome" binding:"required,oneof=refunded rejected"`
+	Note    string `json:"note" binding:"required,max=1000"`
+}
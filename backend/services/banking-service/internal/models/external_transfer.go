@@ -0,0 +1,166 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/pkg/money"
+)
+
+// ExternalTransferStatus is where a transfer to another bank is in its
+// settlement
+type ExternalTransferStatus string
+
+const (
+	// ExternalTransferStatusPending transfers hold their amount on the
+	// account until they settle
+	ExternalTransferStatusPending ExternalTransferStatus = "pending"
+	// ExternalTransferStatusCompleted transfers were settled, and their
+	// amount taken from the balance by an external_transfer transaction
+	ExternalTransferStatusCompleted ExternalTransferStatus = "completed"
+	// ExternalTransferStatusFailed transfers were not settled, and their
+	// hold released
+	ExternalTransferStatusFailed ExternalTransferStatus = "failed"
+)
+
+// IsExternalTransferStatus reports whether status is a known external
+// transfer status
+func IsExternalTransferStatus(status string) bool {
+	switch ExternalTransferStatus(status) {
+	case ExternalTransferStatusPending, ExternalTransferStatusCompleted, ExternalTransferStatusFailed:
+		return true
+	}
+	return false
+}
+
+// Reasons external transfers fail for, other than those given by staff
+const (
+	// ExternalTransferFailureInsufficientFunds is used when the balance no
+	// longer covers the transfer when it settles
+	ExternalTransferFailureInsufficientFunds = "insufficient_funds"
+	// ExternalTransferFailureRejected is used when the simulated receiving
+	// bank turns the transfer down
+	ExternalTransferFailureRejected = "rejected_by_receiving_bank"
+)
+
+// ExternalTransfer is a transfer to an account at another bank. The
+// network is simulated: transfers are settled in the background after a
+// delay, or by staff. While pending, the amount is held: it stays in the
+// balance but cannot be spent.
+type ExternalTransfer struct {
+	ID                uuid.UUID              `json:"id" db:"id"`
+	UserID            uuid.UUID              `json:"user_id" db:"user_id"`
+	AccountID         uuid.UUID              `json:"account_id" db:"account_id"`
+	Amount            float64                `json:"amount" db:"amount"`
+	RoutingNumber     string                 `json:"routing_number" db:"routing_number"`
+	AccountNumber     string                 `json:"-" db:"account_number"`
+	AccountHolderName string                 `json:"account_holder_name" db:"account_holder_name"`
+	Description       string                 `json:"description" db:"description"`
+	Status            ExternalTransferStatus `json:"status" db:"status"`
+	FailureReason     string                 `json:"failure_reason,omitempty" db:"failure_reason"`
+	// TransactionID is the external_transfer transaction of completed
+	// transfers
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty" db:"transaction_id"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	SettledAt     *time.Time `json:"settled_at,omitempty" db:"settled_at"`
+}
+
+// MaskedAccountNumber returns the destination account number with all but
+// its last four digits hidden
+func (t *ExternalTransfer) MaskedAccountNumber() string {
+	if len(t.AccountNumber) <= 4 {
+		return t.AccountNumber
+	}
+	return "****" + t.AccountNumber[len(t.AccountNumber)-4:]
+}
+
+// ExternalTransferResponse is an external transfer as sent in responses,
+// with the destination account number masked
+type ExternalTransferResponse struct {
+	ID                uuid.UUID              `json:"id"`
+	AccountID         uuid.UUID              `json:"account_id"`
+	Amount            money.Money            `json:"amount"`
+	RoutingNumber     string                 `json:"routing_number"`
+	AccountNumber     string                 `json:"account_number"`
+	AccountHolderName string                 `json:"account_holder_name"`
+	Description       string                 `json:"description"`
+	Status            ExternalTransferStatus `json:"status"`
+	FailureReason     string                 `json:"failure_reason,omitempty"`
+	TransactionID     *uuid.UUID             `json:"transaction_id,omitempty"`
+	CreatedAt         time.Time              `json:"created_at"`
+	SettledAt         *time.Time             `json:"settled_at,omitempty"`
+}
+
+// ToResponse converts an ExternalTransfer to ExternalTransferResponse
+func (t *ExternalTransfer) ToResponse() ExternalTransferResponse {
+	return ExternalTransferResponse{
+		ID:                t.ID,
+		AccountID:         t.AccountID,
+		Amount:            Amount(t.Amount),
+		RoutingNumber:     t.RoutingNumber,
+		AccountNumber:     t.MaskedAccountNumber(),
+		AccountHolderName: t.AccountHolderName,
+		Description:       t.Description,
+		Status:            t.Status,
+		FailureReason:     t.FailureReason,
+		TransactionID:     t.TransactionID,
+		CreatedAt:         t.CreatedAt,
+		SettledAt:         t.SettledAt,
+	}
+}
+
+// ExternalTransferChange describes who settled an external transfer, for
+// its event. ActorID is nil when the settlement worker did.
+type ExternalTransferChange struct {
+	ActorID   *uuid.UUID
+	RequestID string
+}
+
+// ExternalTransferFilter controls filtering and paging of external
+// transfers. Empty filters are not applied.
+type ExternalTransferFilter struct {
+	UserID *uuid.UUID
+	Status ExternalTransferStatus
+	Limit  int
+	Offset int
+}
+
+// ExternalTransferPage is one page of external transfers along with the
+// total number matching the filters
+type ExternalTransferPage struct {
+	Transfers []ExternalTransfer
+	Total     int
+	Limit     int
+	Offset    int
+}
+
+// ExternalTransferRules control how external transfers are settled
+type ExternalTransferRules struct {
+	// SettlementDelay is how long transfers stay pending before the
+	// settlement worker settles them; zero leaves them to staff
+	SettlementDelay time.Duration
+	// FailurePercent is the share of transfers the simulated receiving
+	// bank turns down
+	FailurePercent float64
+}
+
+// ExternalTransferRequest represents a request to send money to another
+// bank. PIN is the user's transaction PIN, needed once one is set.
+// AccountID selects a joint account the user is a member of to send from
+// instead of their own.
+type ExternalTransferRequest struct {
+	AccountID         *uuid.UUID `json:"account_id"`
+	Amount            float64    `json:"amount" binding:"required,gt=0"`
+	RoutingNumber     string     `json:"routing_number" binding:"required,len=9,numeric"`
+	AccountNumber     string     `json:"account_number" binding:"required,min=4,max=17,numeric"`
+	AccountHolderName string     `json:"account_holder_name" binding:"required,max=100"`
+	Description       string     `json:"description" binding:"max=255"`
+	PIN               string     `json:"pin" binding:"max=6"`
+}
+
+// FailExternalTransferRequest represents a request from staff to fail a
+// pending external transfer
+type FailExternalTransferRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
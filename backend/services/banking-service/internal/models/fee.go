@@ -0,0 +1,45 @@
+package models
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// WithdrawalFees is what withdrawals beyond the free monthly allowance are
+// charged: Flat plus Percent of the amount
+type WithdrawalFees struct {
+	Flat    float64
+	Percent float64
+	// FreePerMonth is how many withdrawals each account makes free of
+	// charge per calendar month
+	FreePerMonth int
+}
+
+// Enabled reports whether any withdrawal is charged a fee
+func (f WithdrawalFees) Enabled() bool {
+	return f.Flat > 0 || f.Percent > 0
+}
+
+// FeeFor returns the fee on a withdrawal of amount beyond the free
+// allowance, rounded to the cent
+func (f WithdrawalFees) FeeFor(amount float64) float64 {
+	return math.Round((f.Flat+amount*f.Percent/100)*100) / 100
+}
+
+// NewWithdrawalFee returns the fee transaction charging amount on
+// withdrawal, taken from the balance the withdrawal leaves
+func NewWithdrawalFee(withdrawal *Transaction, amount float64) *Transaction {
+	return &Transaction{
+		ID:                   uuid.New(),
+		AccountID:            withdrawal.AccountID,
+		UserID:               withdrawal.UserID,
+		Type:                 TransactionTypeFee,
+		Amount:               amount,
+		BalanceBefore:        withdrawal.BalanceAfter,
+		BalanceAfter:         withdrawal.BalanceAfter - amount,
+		Description:          "Withdrawal fee",
+		CreatedAt:            withdrawal.CreatedAt,
+		RelatedTransactionID: &withdrawal.ID,
+	}
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/pkg/money"
+)
+
+// GenesisHash stands in for the previous hash of the first transaction in
+// an account's integrity chain
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// Integrity chain break reasons
+const (
+	// IntegrityBreakHashMismatch means a transaction's stored hash is not
+	// the hash of its contents and the transaction before it
+	IntegrityBreakHashMismatch = "hash_mismatch"
+	// IntegrityBreakMissing means a transaction in the chain is missing
+	IntegrityBreakMissing = "missing_transaction"
+	// IntegrityBreakDuplicate means two transactions claim the same place
+	// in the chain
+	IntegrityBreakDuplicate = "duplicate_transaction"
+	// IntegrityBreakHead means the last transaction's hash is not the
+	// account's recorded head
+	IntegrityBreakHead = "head_mismatch"
+)
+
+// canonicalTransaction is the form of a transaction that is hashed. Its
+// fields are encoded in this order, amounts to the cent and times to the
+// microsecond in UTC, as they are stored.
+type canonicalTransaction struct {
+	Sequence             int64           `json:"sequence"`
+	ID                   uuid.UUID       `json:"id"`
+	AccountID            uuid.UUID       `json:"account_id"`
+	UserID               uuid.UUID       `json:"user_id"`
+	Type                 TransactionType `json:"type"`
+	Amount               money.Money     `json:"amount"`
+	BalanceBefore        money.Money     `json:"balance_before"`
+	BalanceAfter         money.Money     `json:"balance_after"`
+	Description          string          `json:"description"`
+	CreatedAt            string          `json:"created_at"`
+	RelatedTransactionID *uuid.UUID      `json:"related_transaction_id"`
+}
+
+// ComputeIntegrityHash returns the hex SHA-256 of previous, the hash of the
+// transaction before it in its account's chain, followed by the
+// transaction's canonical JSON encoding
+func (t *Transaction) ComputeIntegrityHash(previous string) string {
+	encoded, _ := json.Marshal(canonicalTransaction{
+		Sequence:             t.ChainSequence,
+		ID:                   t.ID,
+		AccountID:            t.AccountID,
+		UserID:               t.UserID,
+		Type:                 t.Type,
+		Amount:               Amount(t.Amount),
+		BalanceBefore:        Amount(t.BalanceBefore),
+		BalanceAfter:         Amount(t.BalanceAfter),
+		Description:          t.Description,
+		CreatedAt:            t.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+		RelatedTransactionID: t.RelatedTransactionID,
+	})
+	hash := sha256.New()
+	hash.Write([]byte(previous))
+	hash.Write(encoded)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// IntegrityChainHead is where an account's integrity chain ends
+type IntegrityChainHead struct {
+	// Sequence is the number of transactions in the chain and Hash the
+	// last one's hash, empty before the first
+	Sequence int64
+	Hash     string
+	// Unchained counts the account's transactions made before chaining,
+	// which are not part of it
+	Unchained int
+}
+
+// IntegrityBreak is the first place an account's integrity chain does not
+// hold
+type IntegrityBreak struct {
+	Reason   string `json:"reason"`
+	Sequence int64  `json:"sequence"`
+	// TransactionID is the transaction at Sequence, unless it is missing
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	// ExpectedHash is the hash recomputed for Sequence and StoredHash the
+	// one on record. For a head mismatch they are the account's recorded
+	// head and the last transaction's hash.
+	ExpectedHash string `json:"expected_hash,omitempty"`
+	StoredHash   string `json:"stored_hash,omitempty"`
+}
+
+// IntegrityVerification is the result of walking an account's integrity
+// chain
+type IntegrityVerification struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Valid     bool      `json:"valid"`
+	// TransactionsChecked counts the chained transactions whose hashes
+	// were checked before the walk ended
+	TransactionsChecked   int64           `json:"transactions_checked"`
+	UnchainedTransactions int             `json:"unchained_transactions"`
+	HeadHash              string          `json:"head_hash"`
+	Break                 *IntegrityBreak `json:"break,omitempty"`
+	VerifiedAt            time.Time       `json:"verified_at"`
+}
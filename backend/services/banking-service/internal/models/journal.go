@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/pkg/money"
+)
+
+// JournalAccount is a general ledger account journal lines post to
+type JournalAccount struct {
+	Code string
+	Name string
+}
+
+// General ledger accounts of the journal export. Customer balances are
+// what the bank owes its customers, so they are a liability.
+var (
+	JournalAccountCash             = JournalAccount{Code: "1000", Name: "Cash"}
+	JournalAccountCustomerDeposits = JournalAccount{Code: "2000", Name: "Customer deposits"}
+	JournalAccountFeeIncome        = JournalAccount{Code: "4000", Name: "Fee income"}
+	// JournalAccountTransfersClearing holds transfers between customers
+	// between their two sides, so it nets to zero once both are posted
+	JournalAccountTransfersClearing = JournalAccount{Code: "2100", Name: "Transfers clearing"}
+	// JournalAccountPromotionsExpense is the system account promotion
+	// bonuses are paid from
+	JournalAccountPromotionsExpense = JournalAccount{Code: "5000", Name: "Promotions expense"}
+)
+
+// JournalLine is one debit or credit of a transaction's journal entry.
+// Exactly one of Debit and Credit is non-zero.
+type JournalLine struct {
+	TransactionID   uuid.UUID
+	TransactionType TransactionType
+	PostedAt        time.Time
+	Account         JournalAccount
+	// CustomerAccountID is the customer's bank account, on lines posted
+	// to customer deposits
+	CustomerAccountID *uuid.UUID
+	Description       string
+	Debit             money.Money
+	Credit            money.Money
+}
+
+// JournalTotals sums the lines of a journal export. A balanced journal
+// has equal debits and credits.
+type JournalTotals struct {
+	Transactions int
+	Lines        int
+	Debit        money.Money
+	Credit       money.Money
+}
+
+// JournalEntry returns the debit and credit lines the transaction posts,
+// synthesized from its type since transactions are single-entry:
+//
+//   - deposit: debit cash, credit customer deposits
+//   - withdrawal, external_transfer, cheque_return: debit customer
+//     deposits, credit cash
+//   - fee: debit customer deposits, credit fee income
+//   - refund: debit cash, credit customer deposits
+//   - transfer_out: debit customer deposits, credit transfers clearing
+//   - transfer_in: debit transfers clearing, credit customer deposits
+//   - bonus: debit promotions expense, credit customer deposits
+//
+// Round-ups earmark money for a savings goal without moving it, so they
+// post nothing and JournalEntry returns nil.
+func (t *Transaction) JournalEntry() []JournalLine {
+	var debit, credit JournalAccount
+	switch t.Type {
+	case TransactionTypeDeposit, TransactionTypeRefund:
+		debit, credit = JournalAccountCash, JournalAccountCustomerDeposits
+	case TransactionTypeWithdrawal, TransactionTypeExternalTransfer, TransactionTypeChequeReturn:
+		debit, credit = JournalAccountCustomerDeposits, JournalAccountCash
+	case TransactionTypeFee:
+		debit, credit = JournalAccountCustomerDeposits, JournalAccountFeeIncome
+	case TransactionTypeTransferOut:
+		debit, credit = JournalAccountCustomerDeposits, JournalAccountTransfersClearing
+	case TransactionTypeTransferIn:
+		debit, credit = JournalAccountTransfersClearing, JournalAccountCustomerDeposits
+	case TransactionTypeBonus:
+		debit, credit = JournalAccountPromotionsExpense, JournalAccountCustomerDeposits
+	default:
+		return nil
+	}
+
+	amount := Amount(t.Amount)
+	line := func(account JournalAccount) JournalLine {
+		line := JournalLine{
+			TransactionID:   t.ID,
+			TransactionType: t.Type,
+			PostedAt:        t.CreatedAt,
+			Account:         account,
+			Description:     t.Description,
+			Debit:           money.New(0, Currency),
+			Credit:          money.New(0, Currency),
+		}
+		if account == JournalAccountCustomerDeposits {
+			accountID := t.AccountID
+			line.CustomerAccountID = &accountID
+		}
+		return line
+	}
+	debitLine, creditLine := line(debit), line(credit)
+	debitLine.Debit = amount
+	creditLine.Credit = amount
+	return []JournalLine{debitLine, creditLine}
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultMaintenanceMessage is shown to users when a pause was started
+// without a message of its own
+const DefaultMaintenanceMessage = "Transactions are temporarily paused for maintenance. Please try again later."
+
+// MaintenanceState says whether money movement is paused. It is stored
+// once for every replica of the service.
+type MaintenanceState struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+	// EndsAt is when the pause lifts by itself; nil pauses until it is
+	// turned off
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Active reports whether money movement is paused at now
+func (s MaintenanceState) Active(now time.Time) bool {
+	return s.Enabled && (s.EndsAt == nil || now.Before(*s.EndsAt))
+}
+
+// DisplayMessage returns the message shown to users while paused
+func (s MaintenanceState) DisplayMessage() string {
+	if s.Message == "" {
+		return DefaultMaintenanceMessage
+	}
+	return s.Message
+}
+
+// MaintenanceResponse is a MaintenanceState along with whether it pauses
+// money movement now, which a pause no longer does once it ends
+type MaintenanceResponse struct {
+	MaintenanceState
+	Active bool `json:"active"`
+}
+
+// ToResponse converts the state to its response at now
+func (s MaintenanceState) ToResponse(now time.Time) MaintenanceResponse {
+	return MaintenanceResponse{MaintenanceState: s, Active: s.Active(now)}
+}
+
+// MaintenanceChange identifies who paused or resumed money movement and
+// the request they did it in. ActorID is the nil UUID for changes made
+// from the environment at startup.
+type MaintenanceChange struct {
+	ActorID   uuid.UUID
+	RequestID string
+}
+
+// UpdateMaintenanceRequest represents the request to pause or resume money
+// movement. Message and EndsAt only apply when enabling.
+type UpdateMaintenanceRequest struct {
+	Enabled *bool      `json:"enabled" binding:"required"`
+	Message string     `json:"message" binding:"max=500"`
+	EndsAt  *time.Time `json:"ends_at"`
+}
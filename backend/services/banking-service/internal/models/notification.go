@@ -0,0 +1,150 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/pkg/money"
+)
+
+// Account activity notification events, named as the client-service's
+// notification preferences name them
+const (
+	NotificationEventLargeTransaction = "large_transaction"
+	NotificationEventLowBalance       = "low_balance"
+)
+
+// NotificationChannel is how a notification is delivered
+type NotificationChannel string
+
+const (
+	NotificationChannelEmail   NotificationChannel = "email"
+	NotificationChannelSMS     NotificationChannel = "sms"
+	NotificationChannelWebhook NotificationChannel = "webhook"
+)
+
+// NotificationDeliveryStatus is where a notification delivery is
+type NotificationDeliveryStatus string
+
+const (
+	// NotificationDeliveryPending deliveries are waiting to be sent, or to
+	// be tried again after a failed attempt
+	NotificationDeliveryPending NotificationDeliveryStatus = "pending"
+	NotificationDeliverySent    NotificationDeliveryStatus = "sent"
+	// NotificationDeliveryFailed deliveries ran out of attempts
+	NotificationDeliveryFailed NotificationDeliveryStatus = "failed"
+	// NotificationDeliverySuppressed deliveries were over the user's
+	// hourly cap and are never sent
+	NotificationDeliverySuppressed NotificationDeliveryStatus = "suppressed"
+)
+
+// NotificationDelivery is one notification of an account activity event on
+// one channel, and how its delivery went. Destination is the email
+// address, phone number or webhook URL as it was when the event was seen.
+type NotificationDelivery struct {
+	ID            uuid.UUID                  `json:"id" db:"id"`
+	UserID        uuid.UUID                  `json:"user_id" db:"user_id"`
+	AccountID     uuid.UUID                  `json:"account_id" db:"account_id"`
+	TransactionID uuid.UUID                  `json:"transaction_id" db:"transaction_id"`
+	EventType     string                     `json:"event_type" db:"event_type"`
+	Channel       NotificationChannel        `json:"channel" db:"channel"`
+	Destination   string                     `json:"-" db:"destination"`
+	Data          NotificationData           `json:"data" db:"data"`
+	Status        NotificationDeliveryStatus `json:"status" db:"status"`
+	Attempts      int                        `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time                  `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     string                     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     time.Time                  `json:"created_at" db:"created_at"`
+	DeliveredAt   *time.Time                 `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// NotificationData is what a notification tells its user about the
+// transaction it was sent for. Threshold is the low balance threshold
+// crossed, for low balance notifications.
+type NotificationData struct {
+	Name            string          `json:"name"`
+	TransactionType TransactionType `json:"transaction_type"`
+	Amount          float64         `json:"amount"`
+	Balance         float64         `json:"balance"`
+	Threshold       float64         `json:"threshold,omitempty"`
+	OccurredAt      time.Time       `json:"occurred_at"`
+}
+
+// NotificationRules decide which transactions are notified and how their
+// notifications are delivered
+type NotificationRules struct {
+	// LargeTransactionThreshold is the amount over which transactions are
+	// notified as large
+	LargeTransactionThreshold float64
+	// HourlyCap is the most notifications a user is sent in an hour;
+	// those over it are suppressed
+	HourlyCap int
+	// MaxAttempts is how many times a delivery is tried before it fails
+	MaxAttempts int
+	// RetryBackoff is how long the first retry of a delivery waits; each
+	// further retry waits twice as long
+	RetryBackoff time.Duration
+}
+
+// NotificationRecipient is where and how a user wants one notification
+// event delivered, as the client-service tells it. Unknown and deleted
+// users have Exists false and no channels, and PhoneNumber is only set
+// once it is verified.
+type NotificationRecipient struct {
+	UserID      string               `json:"user_id"`
+	Exists      bool                 `json:"exists"`
+	Email       string               `json:"email"`
+	Name        string               `json:"name"`
+	PhoneNumber string               `json:"phone_number,omitempty"`
+	Channels    NotificationChannels `json:"channels"`
+}
+
+// NotificationChannels are the channels a user wants an event delivered on
+type NotificationChannels struct {
+	Email   bool `json:"email"`
+	SMS     bool `json:"sms"`
+	Webhook bool `json:"webhook"`
+}
+
+// NotificationSettings are an account's notification settings. The
+// account's holder is told when its balance drops below
+// LowBalanceThreshold, unless it is nil. Webhook notifications of the
+// account's activity are posted to WebhookURL, signed with WebhookSecret.
+type NotificationSettings struct {
+	AccountID           uuid.UUID `json:"account_id" db:"account_id"`
+	UserID              uuid.UUID `json:"-" db:"user_id"`
+	LowBalanceThreshold *float64  `json:"low_balance_threshold" db:"low_balance_threshold"`
+	WebhookURL          string    `json:"webhook_url" db:"webhook_url"`
+	WebhookSecret       string    `json:"webhook_secret,omitempty" db:"webhook_secret"`
+	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// UpdateNotificationSettingsRequest replaces an account's notification
+// settings. Leaving LowBalanceThreshold out turns low balance
+// notifications off, and leaving WebhookURL out removes the webhook.
+type UpdateNotificationSettingsRequest struct {
+	LowBalanceThreshold *float64 `json:"low_balance_threshold" binding:"omitempty,gt=0"`
+	WebhookURL          string   `json:"webhook_url" binding:"omitempty,url,startswith=https://,max=500"`
+}
+
+// NotificationWebhookPayload is the JSON body posted to an account's
+// webhook. ID is the delivery's, so retries of one notification can be
+// told apart from new ones.
+type NotificationWebhookPayload struct {
+	ID              uuid.UUID       `json:"id"`
+	Event           string          `json:"event"`
+	AccountID       uuid.UUID       `json:"account_id"`
+	TransactionID   uuid.UUID       `json:"transaction_id"`
+	TransactionType TransactionType `json:"transaction_type"`
+	Amount          money.Money     `json:"amount"`
+	Balance         money.Money     `json:"balance"`
+	Threshold       *money.Money    `json:"threshold,omitempty"`
+	OccurredAt      time.Time       `json:"occurred_at"`
+}
+
+// NotificationCursor is the last transaction the notification dispatcher
+// looked at, in the order transactions are made
+type NotificationCursor struct {
+	CreatedAt     time.Time
+	TransactionID uuid.UUID
+}
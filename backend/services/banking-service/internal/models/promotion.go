@@ -0,0 +1,190 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/pkg/money"
+)
+
+// PromotionStatus is where a promotion is in its window
+type PromotionStatus string
+
+const (
+	// PromotionStatusScheduled promotions have not started yet
+	PromotionStatusScheduled PromotionStatus = "scheduled"
+	// PromotionStatusActive promotions pay bonuses on qualifying deposits
+	PromotionStatusActive PromotionStatus = "active"
+	// PromotionStatusEnded promotions are past their window
+	PromotionStatusEnded PromotionStatus = "ended"
+)
+
+// IsPromotionStatus reports whether status is a promotion status
+func IsPromotionStatus(status string) bool {
+	switch PromotionStatus(status) {
+	case PromotionStatusScheduled, PromotionStatusActive, PromotionStatusEnded:
+		return true
+	}
+	return false
+}
+
+// Promotion pays a bonus on deposits of at least MinDeposit made from
+// StartsAt until EndsAt. With OncePerUser each user earns it at most once;
+// otherwise every qualifying deposit does. Status is never stored: it
+// follows from the window.
+type Promotion struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	MinDeposit  float64   `json:"min_deposit" db:"min_deposit"`
+	BonusAmount float64   `json:"bonus_amount" db:"bonus_amount"`
+	StartsAt    time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt      time.Time `json:"ends_at" db:"ends_at"`
+	OncePerUser bool      `json:"once_per_user" db:"once_per_user"`
+	CreatedBy   uuid.UUID `json:"created_by" db:"created_by"`
+	// RedemptionCount is how many bonuses the promotion has paid
+	RedemptionCount int       `json:"redemption_count" db:"redemption_count"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ActiveAt reports whether the promotion pays bonuses on deposits made at
+// the given time
+func (p *Promotion) ActiveAt(at time.Time) bool {
+	return !at.Before(p.StartsAt) && at.Before(p.EndsAt)
+}
+
+// StatusAt returns the promotion's status at the given time
+func (p *Promotion) StatusAt(at time.Time) PromotionStatus {
+	switch {
+	case at.Before(p.StartsAt):
+		return PromotionStatusScheduled
+	case at.Before(p.EndsAt):
+		return PromotionStatusActive
+	}
+	return PromotionStatusEnded
+}
+
+// Qualifies reports whether a deposit of amount made at the given time
+// earns the promotion's bonus, leaving aside whether the user already
+// earned it
+func (p *Promotion) Qualifies(amount float64, at time.Time) bool {
+	return p.ActiveAt(at) && amount >= p.MinDeposit
+}
+
+// PromotionResponse represents the promotion data sent in responses
+type PromotionResponse struct {
+	ID              uuid.UUID       `json:"id"`
+	Name            string          `json:"name"`
+	Description     string          `json:"description"`
+	MinDeposit      money.Money     `json:"min_deposit"`
+	BonusAmount     money.Money     `json:"bonus_amount"`
+	StartsAt        time.Time       `json:"starts_at"`
+	EndsAt          time.Time       `json:"ends_at"`
+	OncePerUser     bool            `json:"once_per_user"`
+	Status          PromotionStatus `json:"status"`
+	CreatedBy       uuid.UUID       `json:"created_by"`
+	RedemptionCount int             `json:"redemption_count"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// ToResponse converts a Promotion to PromotionResponse, with its status at
+// the given time
+func (p *Promotion) ToResponse(at time.Time) PromotionResponse {
+	return PromotionResponse{
+		ID:              p.ID,
+		Name:            p.Name,
+		Description:     p.Description,
+		MinDeposit:      Amount(p.MinDeposit),
+		BonusAmount:     Amount(p.BonusAmount),
+		StartsAt:        p.StartsAt,
+		EndsAt:          p.EndsAt,
+		OncePerUser:     p.OncePerUser,
+		Status:          p.StatusAt(at),
+		CreatedBy:       p.CreatedBy,
+		RedemptionCount: p.RedemptionCount,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+	}
+}
+
+// PromotionRedemption records a bonus paid by a promotion: the deposit
+// that earned it and the bonus transaction that credited it
+type PromotionRedemption struct {
+	ID                   uuid.UUID `json:"id" db:"id"`
+	PromotionID          uuid.UUID `json:"promotion_id" db:"promotion_id"`
+	UserID               uuid.UUID `json:"user_id" db:"user_id"`
+	AccountID            uuid.UUID `json:"account_id" db:"account_id"`
+	DepositTransactionID uuid.UUID `json:"deposit_transaction_id" db:"deposit_transaction_id"`
+	BonusTransactionID   uuid.UUID `json:"bonus_transaction_id" db:"bonus_transaction_id"`
+	Amount               float64   `json:"amount" db:"amount"`
+	// OncePerUser is copied from the promotion, so a user can only redeem
+	// a one-time promotion once however many deposits race for it
+	OncePerUser bool      `json:"once_per_user" db:"once_per_user"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// UserPromotion is an active promotion as one user sees it: whether they
+// earned its bonus and whether they can still earn it
+type UserPromotion struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	MinDeposit  money.Money `json:"min_deposit"`
+	BonusAmount money.Money `json:"bonus_amount"`
+	StartsAt    time.Time   `json:"starts_at"`
+	EndsAt      time.Time   `json:"ends_at"`
+	OncePerUser bool        `json:"once_per_user"`
+	// Redeemed is set once the user earned the bonus, RedemptionCount
+	// counts how many times and LastRedeemedAt is when they last did
+	Redeemed        bool       `json:"redeemed"`
+	RedemptionCount int        `json:"redemption_count"`
+	LastRedeemedAt  *time.Time `json:"last_redeemed_at,omitempty"`
+	// Eligible is set while another qualifying deposit would earn the
+	// bonus
+	Eligible bool `json:"eligible"`
+}
+
+// PromotionFilter controls filtering and paging of promotions. Empty
+// filters are not applied. At is the time Status is checked at.
+type PromotionFilter struct {
+	Status PromotionStatus
+	At     time.Time
+	Limit  int
+	Offset int
+}
+
+// PromotionPage is one page of promotions along with the total number
+// matching the filters
+type PromotionPage struct {
+	Promotions []Promotion
+	Total      int
+	Limit      int
+	Offset     int
+}
+
+// CreatePromotionRequest represents a request from staff to create a
+// promotion. OncePerUser defaults to true.
+type CreatePromotionRequest struct {
+	Name        string    `json:"name" binding:"required,max=100"`
+	Description string    `json:"description" binding:"max=255"`
+	MinDeposit  float64   `json:"min_deposit" binding:"required,gt=0"`
+	BonusAmount float64   `json:"bonus_amount" binding:"required,gt=0"`
+	StartsAt    time.Time `json:"starts_at" binding:"required"`
+	EndsAt      time.Time `json:"ends_at" binding:"required"`
+	OncePerUser *bool     `json:"once_per_user"`
+}
+
+// UpdatePromotionRequest represents a change to a promotion. Fields that
+// are left out keep their current values. Changes apply to deposits made
+// from then on; bonuses already paid are kept.
+type UpdatePromotionRequest struct {
+	Name        *string    `json:"name" binding:"omitempty,min=1,max=100"`
+	Description *string    `json:"description" binding:"omitempty,max=255"`
+	MinDeposit  *float64   `json:"min_deposit" binding:"omitempty,gt=0"`
+	BonusAmount *float64   `json:"bonus_amount" binding:"omitempty,gt=0"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	OncePerUser *bool      `json:"once_per_user"`
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/pkg/money"
+)
+
+// Reconciliation run statuses
+const (
+	// ReconciliationStatusRunning runs are checking accounts, or were
+	// interrupted and are resumed from their checkpoint
+	ReconciliationStatusRunning = "running"
+	// ReconciliationStatusCompleted runs checked every account and the
+	// system-wide totals
+	ReconciliationStatusCompleted = "completed"
+)
+
+// ReconciliationRun is one sweep checking every account's stored balance
+// against its transaction history. Accounts are checked in ID order, in
+// batches, and LastAccountID is the last one checked, so an interrupted run
+// carries on where it stopped.
+type ReconciliationRun struct {
+	ID uuid.UUID `json:"id" db:"id"`
+	// RunDate is the UTC day the run was started for, at midnight UTC
+	RunDate         time.Time  `json:"run_date" db:"run_date"`
+	Status          string     `json:"status" db:"status"`
+	StartedAt       time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+	LastAccountID   *uuid.UUID `json:"-" db:"last_account_id"`
+	AccountsChecked int        `json:"accounts_checked" db:"accounts_checked"`
+	MismatchesFound int        `json:"mismatches_found" db:"mismatches_found"`
+	// TotalBalance is the sum of every account's balance and TotalExpected
+	// what every transaction adds up to, both set when the run finishes
+	TotalBalance  *float64 `json:"total_balance,omitempty" db:"total_balance"`
+	TotalExpected *float64 `json:"total_expected,omitempty" db:"total_expected"`
+}
+
+// ReconciliationRunResponse represents the reconciliation run data sent in
+// responses
+type ReconciliationRunResponse struct {
+	ID              uuid.UUID    `json:"id"`
+	RunDate         time.Time    `json:"run_date"`
+	Status          string       `json:"status"`
+	StartedAt       time.Time    `json:"started_at"`
+	FinishedAt      *time.Time   `json:"finished_at,omitempty"`
+	AccountsChecked int          `json:"accounts_checked"`
+	MismatchesFound int          `json:"mismatches_found"`
+	TotalBalance    *money.Money `json:"total_balance,omitempty"`
+	TotalExpected   *money.Money `json:"total_expected,omitempty"`
+}
+
+// ToResponse converts a ReconciliationRun to ReconciliationRunResponse
+func (r *ReconciliationRun) ToResponse() ReconciliationRunResponse {
+	response := ReconciliationRunResponse{
+		ID:              r.ID,
+		RunDate:         r.RunDate,
+		Status:          r.Status,
+		StartedAt:       r.StartedAt,
+		FinishedAt:      r.FinishedAt,
+		AccountsChecked: r.AccountsChecked,
+		MismatchesFound: r.MismatchesFound,
+	}
+	if r.TotalBalance != nil {
+		total := Amount(*r.TotalBalance)
+		response.TotalBalance = &total
+	}
+	if r.TotalExpected != nil {
+		expected := Amount(*r.TotalExpected)
+		response.TotalExpected = &expected
+	}
+	return response
+}
+
+// ReconciliationMismatch is a balance that does not match the
+// transactions behind it. AccountID and UserID are nil for the
+// system-wide check of the sum of all balances.
+type ReconciliationMismatch struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	RunID     uuid.UUID  `json:"run_id" db:"run_id"`
+	AccountID *uuid.UUID `json:"account_id" db:"account_id"`
+	UserID    *uuid.UUID `json:"user_id" db:"user_id"`
+	// StoredBalance is the balance on record and ExpectedBalance what its
+	// deposits and refunds less its withdrawals and fees add up to
+	StoredBalance    float64   `json:"stored_balance" db:"stored_balance"`
+	ExpectedBalance  float64   `json:"expected_balance" db:"expected_balance"`
+	Difference       float64   `json:"difference" db:"difference"`
+	TransactionCount int       `json:"transaction_count" db:"transaction_count"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// ReconciliationMismatchResponse represents the reconciliation mismatch
+// data sent in responses
+type ReconciliationMismatchResponse struct {
+	ID               uuid.UUID   `json:"id"`
+	RunID            uuid.UUID   `json:"run_id"`
+	AccountID        *uuid.UUID  `json:"account_id"`
+	UserID           *uuid.UUID  `json:"user_id"`
+	StoredBalance    money.Money `json:"stored_balance"`
+	ExpectedBalance  money.Money `json:"expected_balance"`
+	Difference       money.Money `json:"difference"`
+	TransactionCount int         `json:"transaction_count"`
+	CreatedAt        time.Time   `json:"created_at"`
+}
+
+// ToResponse converts a ReconciliationMismatch to
+// ReconciliationMismatchResponse
+func (m *ReconciliationMismatch) ToResponse() ReconciliationMismatchResponse {
+	return ReconciliationMismatchResponse{
+		ID:               m.ID,
+		RunID:            m.RunID,
+		AccountID:        m.AccountID,
+		UserID:           m.UserID,
+		StoredBalance:    Amount(m.StoredBalance),
+		ExpectedBalance:  Amount(m.ExpectedBalance),
+		Difference:       Amount(m.Difference),
+		TransactionCount: m.TransactionCount,
+		CreatedAt:        m.CreatedAt,
+	}
+}
+
+// ReconciliationReport is a run along with one page of its mismatches
+type ReconciliationReport struct {
+	Run        ReconciliationRun
+	Mismatches []ReconciliationMismatch
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/pkg/money"
+)
+
+// SavingsGoal is money a user earmarks in their account toward a target.
+// Earmarked money stays in the account, but is not available to spend.
+type SavingsGoal struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	UserID          uuid.UUID  `json:"user_id" db:"user_id"`
+	AccountID       uuid.UUID  `json:"account_id" db:"account_id"`
+	Name            string     `json:"name" db:"name"`
+	TargetAmount    float64    `json:"target_amount" db:"target_amount"`
+	TargetDate      *time.Time `json:"target_date,omitempty" db:"target_date"`
+	AllocatedAmount float64    `json:"allocated_amount" db:"allocated_amount"`
+	// Strict goals refuse withdrawals that would need their money; other
+	// goals give it up, and the withdrawal response warns about it
+	Strict bool `json:"strict" db:"strict"`
+	// CompletedAt is set when the allocated amount first reaches the target
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Progress returns how much of the target is allocated, as a percentage
+// from 0 to 100 rounded to two decimals
+func (g *SavingsGoal) Progress() float64 {
+	if g.TargetAmount <= 0 {
+		return 0
+	}
+	return math.Min(100, math.Round(g.AllocatedAmount/g.TargetAmount*10000)/100)
+}
+
+// Remaining returns how much is still to be allocated to reach the target
+func (g *SavingsGoal) Remaining() float64 {
+	return math.Max(0, math.Round((g.TargetAmount-g.AllocatedAmount)*100)/100)
+}
+
+// SavingsGoalResponse represents the savings goal data sent in responses,
+// with its progress computed when it is read
+type SavingsGoalResponse struct {
+	ID              uuid.UUID   `json:"id"`
+	AccountID       uuid.UUID   `json:"account_id"`
+	Name            string      `json:"name"`
+	TargetAmount    money.Money `json:"target_amount"`
+	TargetDate      *time.Time  `json:"target_date,omitempty"`
+	AllocatedAmount money.Money `json:"allocated_amount"`
+	Remaining       money.Money `json:"remaining"`
+	Progress        float64     `json:"progress"`
+	Strict          bool        `json:"strict"`
+	Completed       bool        `json:"completed"`
+	CompletedAt     *time.Time  `json:"completed_at,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+}
+
+// ToResponse converts a SavingsGoal to SavingsGoalResponse
+func (g *SavingsGoal) ToResponse() SavingsGoalResponse {
+	return SavingsGoalResponse{
+		ID:              g.ID,
+		AccountID:       g.AccountID,
+		Name:            g.Name,
+		TargetAmount:    Amount(g.TargetAmount),
+		TargetDate:      g.TargetDate,
+		AllocatedAmount: Amount(g.AllocatedAmount),
+		Remaining:       Amount(g.Remaining()),
+		Progress:        g.Progress(),
+		Strict:          g.Strict,
+		Completed:       g.CompletedAt != nil,
+		CompletedAt:     g.CompletedAt,
+		CreatedAt:       g.CreatedAt,
+		UpdatedAt:       g.UpdatedAt,
+	}
+}
+
+// CreateSavingsGoalRequest represents the data needed to create a savings
+// goal
+type CreateSavingsGoalRequest struct {
+	Name         string     `json:"name" binding:"required,max=100"`
+	TargetAmount float64    `json:"target_amount" binding:"required,gt=0"`
+	TargetDate   *time.Time `json:"target_date"`
+	Strict       bool       `json:"strict"`
+}
+
+// UpdateSavingsGoalRequest represents a change to a savings goal. Fields
+// that are left out keep their current values.
+type UpdateSavingsGoalRequest struct {
+	Name         *string    `json:"name" binding:"omitempty,min=1,max=100"`
+	TargetAmount *float64   `json:"target_amount" binding:"omitempty,gt=0"`
+	TargetDate   *time.Time `json:"target_date"`
+	Strict       *bool      `json:"strict"`
+}
+
+// SavingsGoalAllocationRequest represents an amount to earmark for a goal,
+// or to release from it
+type SavingsGoalAllocationRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// EarmarkedBalance splits an account's balance into the money savings goals
+// earmark and the money available to spend
+type EarmarkedBalance struct {
+	Balance   money.Money `json:"balance"`
+	Earmarked money.Money `json:"earmarked"`
+	Available money.Money `json:"available_balance"`
+}
+
+// SavingsGoalRelease is earmarked money a withdrawal took from a goal that
+// is not strict
+type SavingsGoalRelease struct {
+	GoalID uuid.UUID
+	Name   string
+	Amount float64
+}
+
+// SavingsGoalReleaseResponse represents the savings goal release data sent
+// in responses
+type SavingsGoalReleaseResponse struct {
+	GoalID uuid.UUID   `json:"goal_id"`
+	Name   string      `json:"name"`
+	Amount money.Money `json:"amount"`
+}
+
+// ToResponse converts a SavingsGoalRelease to SavingsGoalReleaseResponse
+func (r *SavingsGoalRelease) ToResponse() SavingsGoalReleaseResponse {
+	return SavingsGoalReleaseResponse{
+		GoalID: r.GoalID,
+		Name:   r.Name,
+		Amount: Amount(r.Amount),
+	}
+}
+
+// Withdrawal is the outcome of a withdrawal: the withdrawal itself, the fee
+// charged on it if any, the earmarked money it took from savings goals,
+// and its round-up if any
+type Withdrawal struct {
+	Transaction *Transaction
+	Fee         *Transaction
+	// Fees, when they have a free allowance, decide Fee again when the
+	// withdrawal is saved: the month's withdrawals are counted with the
+	// account locked, and Fee is dropped while the allowance lasts or
+	// charged once it is used up
+	Fees         WithdrawalFees
+	GoalReleases []SavingsGoalRelease
+	// RoundUp earmarks the withdrawal's change for RoundUpGoalID
+	RoundUp       *Transaction
+	RoundUpGoalID uuid.UUID
+}
+
+// RoundUpSettings says whether a user's withdrawals are rounded up, and
+// how much round-ups have saved each month
+type RoundUpSettings struct {
+	Enabled  bool       `json:"enabled"`
+	GoalID   *uuid.UUID `json:"goal_id"`
+	GoalName string     `json:"goal_name,omitempty"`
+	// Monthly lists the months with round-ups, newest first
+	Monthly []RoundUpMonth `json:"monthly_summary"`
+}
+
+// RoundUpMonth is how much round-ups saved in a calendar month
+type RoundUpMonth struct {
+	// Month is formatted as YYYY-MM
+	Month  string      `json:"month"`
+	Amount money.Money `json:"amount"`
+	Count  int         `json:"count"`
+}
+
+// UpdateRoundUpsRequest turns round-ups on, saving into GoalID, or off
+type UpdateRoundUpsRequest struct {
+	Enabled *bool      `json:"enabled" binding:"required"`
+	GoalID  *uuid.UUID `json:"goal_id"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/pkg/money"
+)
+
+// StatementPeriodLayout is the YYYY-MM form statement periods are named in
+const StatementPeriodLayout = "2006-01"
+
+// Statement is a user's account activity over one calendar month in UTC,
+// from PeriodStart inclusive to PeriodEnd exclusive. Transactions are in
+// the order they were made.
+type Statement struct {
+	UserID         uuid.UUID
+	AccountID      uuid.UUID
+	Period         string
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	OpeningBalance float64
+	ClosingBalance float64
+	// MoneyIn totals deposits and refunds, and MoneyOut withdrawals and
+	// fees. Round-ups leave the balance unchanged, so neither counts them.
+	MoneyIn      float64
+	MoneyOut     float64
+	Transactions []Transaction
+}
+
+// HasActivity reports whether any transactions were made in the period
+func (s *Statement) HasActivity() bool {
+	return len(s.Transactions) > 0
+}
+
+// StatementResponse represents the statement data sent in responses
+type StatementResponse struct {
+	UserID         uuid.UUID             `json:"user_id"`
+	AccountID      uuid.UUID             `json:"account_id"`
+	Period         string                `json:"period"`
+	PeriodStart    time.Time             `json:"period_start"`
+	PeriodEnd      time.Time             `json:"period_end"`
+	Currency       money.Currency        `json:"currency"`
+	OpeningBalance money.Money           `json:"opening_balance"`
+	ClosingBalance money.Money           `json:"closing_balance"`
+	MoneyIn        money.Money           `json:"money_in"`
+	MoneyOut       money.Money           `json:"money_out"`
+	Transactions   []TransactionResponse `json:"transactions"`
+}
+
+// ToResponse converts a Statement to StatementResponse
+func (s *Statement) ToResponse() StatementResponse {
+	transactions := make([]TransactionResponse, len(s.Transactions))
+	for i := range s.Transactions {
+		transactions[i] = s.Transactions[i].ToResponse()
+	}
+	return StatementResponse{
+		UserID:         s.UserID,
+		AccountID:      s.AccountID,
+		Period:         s.Period,
+		PeriodStart:    s.PeriodStart,
+		PeriodEnd:      s.PeriodEnd,
+		Currency:       Currency,
+		OpeningBalance: Amount(s.OpeningBalance),
+		ClosingBalance: Amount(s.ClosingBalance),
+		MoneyIn:        Amount(s.MoneyIn),
+		MoneyOut:       Amount(s.MoneyOut),
+		Transactions:   transactions,
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// BankingStats summarizes the money held and the day's activity for the
+// admin dashboard
+type BankingStats struct {
+	Accounts     int     `json:"accounts"`
+	TotalBalance float64 `json:"total_balance"`
+	// TransactionsToday and VolumeToday count the deposits and withdrawals
+	// made since midnight UTC. Fees, round-ups and refunds are left out.
+	TransactionsToday int     `json:"transactions_today"`
+	VolumeToday       float64 `json:"volume_today"`
+	// OpenDisputes are open or being investigated, and
+	// OpenSuspiciousActivity are findings not yet reviewed
+	OpenDisputes           int       `json:"open_disputes"`
+	OpenSuspiciousActivity int       `json:"open_suspicious_activity"`
+	GeneratedAt            time.Time `json:"generated_at"`
+}
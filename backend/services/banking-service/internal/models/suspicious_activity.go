@@ -0,0 +1,161 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Suspicious activity rules, each finding an account with one kind of
+// unusual pattern
+const (
+	// SuspiciousRuleRapidCycle finds deposits mostly withdrawn again soon
+	// after they were made
+	SuspiciousRuleRapidCycle = "rapid_cycle"
+	// SuspiciousRuleStructuring finds accounts making many transactions
+	// just under the alert threshold in a day
+	SuspiciousRuleStructuring = "structuring"
+	// SuspiciousRuleBalanceSwing finds balances moving far more in a day
+	// than they usually do
+	SuspiciousRuleBalanceSwing = "balance_swing"
+)
+
+// IsSuspiciousActivityRule reports whether rule is a known suspicious
+// activity rule
+func IsSuspiciousActivityRule(rule string) bool {
+	switch rule {
+	case SuspiciousRuleRapidCycle, SuspiciousRuleStructuring, SuspiciousRuleBalanceSwing:
+		return true
+	}
+	return false
+}
+
+// Suspicious activity finding statuses
+const (
+	// FindingStatusOpen findings are waiting for compliance to look at
+	// them
+	FindingStatusOpen = "open"
+	// FindingStatusReviewed findings were looked at, with a note left on
+	// what was found
+	FindingStatusReviewed = "reviewed"
+)
+
+// SuspiciousActivityRules configures the rules suspicious activity reports
+// are made with. Each rule can be turned off on its own.
+type SuspiciousActivityRules struct {
+	RapidCycle   RapidCycleRule
+	Structuring  StructuringRule
+	BalanceSwing BalanceSwingRule
+}
+
+// Enabled returns the names of the rules that are turned on
+func (r SuspiciousActivityRules) Enabled() []string {
+	rules := []string{}
+	if r.RapidCycle.Enabled {
+		rules = append(rules, SuspiciousRuleRapidCycle)
+	}
+	if r.Structuring.Enabled {
+		rules = append(rules, SuspiciousRuleStructuring)
+	}
+	if r.BalanceSwing.Enabled {
+		rules = append(rules, SuspiciousRuleBalanceSwing)
+	}
+	return rules
+}
+
+// RapidCycleRule flags deposits of which at least Percent is withdrawn
+// from the same account within Window
+type RapidCycleRule struct {
+	Enabled bool
+	Window  time.Duration
+	Percent float64
+}
+
+// StructuringRule flags accounts making at least MinCount deposits or
+// withdrawals in a day that are under Threshold by at most MarginPercent
+// of it
+type StructuringRule struct {
+	Enabled       bool
+	Threshold     float64
+	MarginPercent float64
+	MinCount      int
+}
+
+// Floor returns the smallest amount the rule counts as just under the
+// threshold
+func (r StructuringRule) Floor() float64 {
+	return r.Threshold * (1 - r.MarginPercent/100)
+}
+
+// BalanceSwingRule flags accounts whose balance moved in a day by more
+// than Multiple times its average daily movement over the LookbackDays
+// before. Accounts with fewer than MinHistoryDays days of activity in that
+// time have no average to compare with and are not flagged.
+type BalanceSwingRule struct {
+	Enabled        bool
+	Multiple       float64
+	LookbackDays   int
+	MinHistoryDays int
+}
+
+// SuspiciousActivityReport is the suspicious activity found in one UTC
+// day's transactions
+type SuspiciousActivityReport struct {
+	// ReportDate is the day the report covers, at midnight UTC
+	ReportDate time.Time `json:"report_date"`
+	// Rules are the rules that were turned on when the report was made
+	Rules        []string  `json:"rules"`
+	FindingCount int       `json:"finding_count"`
+	GeneratedAt  time.Time `json:"generated_at"`
+}
+
+// SuspiciousActivityFinding is an account that matched a suspicious
+// activity rule on a report's day
+type SuspiciousActivityFinding struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	ReportDate time.Time `json:"report_date" db:"report_date"`
+	Rule       string    `json:"rule" db:"rule"`
+	AccountID  uuid.UUID `json:"account_id" db:"account_id"`
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
+	// TransactionCount and Amount are the transactions that matched the
+	// rule and their total; for balance swings Amount is how far the
+	// balance moved
+	TransactionCount int     `json:"transaction_count" db:"transaction_count"`
+	Amount           float64 `json:"amount" db:"amount"`
+	// Baseline is the account's average daily balance movement, for
+	// balance swings only
+	Baseline    *float64   `json:"baseline,omitempty" db:"baseline"`
+	Description string     `json:"description" db:"description"`
+	Status      string     `json:"status" db:"status"`
+	ReviewedBy  *uuid.UUID `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewNote  string     `json:"review_note,omitempty" db:"review_note"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SuspiciousActivityFilter controls filtering and paging of suspicious
+// activity findings. From and To are report dates, both included. Empty
+// filters are not applied.
+type SuspiciousActivityFilter struct {
+	From   *time.Time
+	To     *time.Time
+	Rule   string
+	Status string
+	Limit  int
+	Offset int
+}
+
+// SuspiciousActivityPage is one page of findings along with the total
+// number matching the filters
+type SuspiciousActivityPage struct {
+	Findings []SuspiciousActivityFinding
+	Total    int
+	Limit    int
+	Offset   int
+}
+
+// ReviewFindingRequest represents a request to mark a suspicious activity
+// finding reviewed
+type ReviewFindingRequest struct {
+	Note string `json:"note" binding:"required,max=1000"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// RevokedToken identifies an access token revoked before it expired
+type RevokedToken struct {
+	JTI       string    `json:"jti" binding:"required,max=64"`
+	ExpiresAt time.Time `json:"expires_at" binding:"required"`
+}
+
+// TokenRevocationRequest represents revocations pushed by the client service
+type TokenRevocationRequest struct {
+	Tokens []RevokedToken `json:"tokens" binding:"required,min=1,max=1000,dive"`
+}
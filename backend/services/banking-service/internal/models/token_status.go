@@ -0,0 +1,20 @@
+package models
+
+// TokenStatus is the client service's current verdict on an access token
+type TokenStatus int
+
+const (
+	// TokenValid means the token may be used
+	TokenValid TokenStatus = iota
+	// TokenRevoked means the token was revoked or its user no longer exists
+	TokenRevoked
+	// TokenSuspended means the token's user has been blacklisted
+	TokenSuspended
+)
+
+// PersonalAccessTokenGrant is what a personal access token lets its bearer
+// do: act as UserID within Scopes
+type PersonalAccessTokenGrant struct {
+	UserID string   `json:"user_id"`
+	Scopes []string `json:"scopes"`
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"microbank/pkg/money"
 )
 
 // TransactionType represents the type of transaction
@@ -12,6 +13,31 @@ type TransactionType string
 const (
 	TransactionTypeDeposit    TransactionType = "deposit"
 	TransactionTypeWithdrawal TransactionType = "withdrawal"
+	// TransactionTypeFee is a fee charged on another transaction, which
+	// RelatedTransactionID points to
+	TransactionTypeFee TransactionType = "fee"
+	// TransactionTypeRoundUp records the change of a withdrawal earmarked
+	// for a savings goal. The money stays in the account, so it leaves the
+	// balance unchanged; RelatedTransactionID points to the withdrawal.
+	TransactionTypeRoundUp TransactionType = "round_up"
+	// TransactionTypeRefund credits back a transaction upheld in a dispute,
+	// which RelatedTransactionID points to
+	TransactionTypeRefund TransactionType = "refund"
+	// TransactionTypeTransferOut takes a transfer from the sender's
+	// account and TransactionTypeTransferIn credits it to the recipient's.
+	// Each one's RelatedTransactionID points to the other.
+	TransactionTypeTransferOut TransactionType = "transfer_out"
+	TransactionTypeTransferIn  TransactionType = "transfer_in"
+	// TransactionTypeExternalTransfer takes a transfer to another bank
+	// from the balance once it settles. Until then its amount is only
+	// held, with no transaction.
+	TransactionTypeExternalTransfer TransactionType = "external_transfer"
+	// TransactionTypeChequeReturn reverses the deposit of a cheque that
+	// bounced, which RelatedTransactionID points to
+	TransactionTypeChequeReturn TransactionType = "cheque_return"
+	// TransactionTypeBonus credits a promotion's bonus for the deposit
+	// that earned it, which RelatedTransactionID points to
+	TransactionTypeBonus TransactionType = "bonus"
 )
 
 // Transaction represents a banking transaction
@@ -25,12 +51,32 @@ type Transaction struct {
 	BalanceAfter  float64         `json:"balance_after" db:"balance_after"`
 	Description   string          `json:"description" db:"description"`
 	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	// RelatedTransactionID links a fee, round-up, refund, cheque return or
+	// bonus to the transaction it was made on, and the two sides of a
+	// transfer to each other
+	RelatedTransactionID *uuid.UUID `json:"related_transaction_id,omitempty" db:"related_transaction_id"`
+	// RawDescription keeps the description as it was given when it had to
+	// be cut short, for audit. It is only written, never read back.
+	RawDescription *string `json:"-" db:"raw_description"`
+	// ChainSequence is the transaction's place in its account's integrity
+	// chain, counting from 1, and IntegrityHash its hash. Both are set
+	// when the transaction is written and when its chain is read.
+	ChainSequence int64  `json:"-" db:"chain_seq"`
+	IntegrityHash string `json:"-" db:"integrity_hash"`
+	// AccountVersion is the account's version once the transaction changed
+	// its balance. It is only set on transactions just written.
+	AccountVersion int64 `json:"account_version,omitempty" db:"-"`
 }
 
-// TransactionRequest represents the data needed to create a transaction
+// TransactionRequest represents the data needed to create a transaction.
+// PIN is the user's transaction PIN, needed for withdrawals once one is
+// set; deposits ignore it. AccountID selects a joint account the user is a
+// member of instead of their own.
 type TransactionRequest struct {
-	Amount      float64 `json:"amount" binding:"required,gt=0"`
-	Description string  `json:"description" binding:"max=255"`
+	AccountID   *uuid.UUID `json:"account_id"`
+	Amount      float64    `json:"amount" binding:"required,gt=0"`
+	Description string     `json:"description" binding:"max=255"`
+	PIN         string     `json:"pin" binding:"max=6"`
 }
 
 // TransactionResponse represents the transaction data sent in responses
@@ -39,24 +85,81 @@ type TransactionResponse struct {
 	AccountID     uuid.UUID       `json:"account_id"`
 	UserID        uuid.UUID       `json:"user_id"`
 	Type          TransactionType `json:"type"`
-	Amount        float64         `json:"amount"`
-	BalanceBefore float64         `json:"balance_before"`
-	BalanceAfter  float64         `json:"balance_after"`
+	Amount        money.Money     `json:"amount"`
+	BalanceBefore money.Money     `json:"balance_before"`
+	BalanceAfter  money.Money     `json:"balance_after"`
 	Description   string          `json:"description"`
 	CreatedAt     time.Time       `json:"created_at"`
+	// RelatedTransactionID links a fee, round-up, refund, cheque return or
+	// bonus to the transaction it was made on, and the two sides of a
+	// transfer to each other
+	RelatedTransactionID *uuid.UUID `json:"related_transaction_id,omitempty"`
+	// AccountVersion is set on transactions just made, for clients to pass
+	// as min_version when reading the balance
+	AccountVersion int64 `json:"account_version,omitempty"`
 }
 
 // ToResponse converts a Transaction to TransactionResponse
 func (t *Transaction) ToResponse() TransactionResponse {
 	return TransactionResponse{
-		ID:            t.ID,
-		AccountID:     t.AccountID,
-		UserID:        t.UserID,
-		Type:          t.Type,
-		Amount:        t.Amount,
-		BalanceBefore: t.BalanceBefore,
-		BalanceAfter:  t.BalanceAfter,
-		Description:   t.Description,
-		CreatedAt:     t.CreatedAt,
+		ID:                   t.ID,
+		AccountID:            t.AccountID,
+		UserID:               t.UserID,
+		Type:                 t.Type,
+		Amount:               Amount(t.Amount),
+		BalanceBefore:        Amount(t.BalanceBefore),
+		BalanceAfter:         Amount(t.BalanceAfter),
+		Description:          t.Description,
+		CreatedAt:            t.CreatedAt,
+		RelatedTransactionID: t.RelatedTransactionID,
+		AccountVersion:       t.AccountVersion,
 	}
 }
+
+// AdminTransactionResponse is a transaction as staff see it, with its
+// customer's email and name. They are null when the customer could not be
+// looked up.
+type AdminTransactionResponse struct {
+	TransactionResponse
+	CustomerEmail *string `json:"customer_email"`
+	CustomerName  *string `json:"customer_name"`
+}
+
+// ArchivedTransactionFilter controls filtering and paging of archived
+// transactions. Empty filters are not applied; From is inclusive and To
+// exclusive.
+type ArchivedTransactionFilter struct {
+	UserID    *uuid.UUID
+	AccountID *uuid.UUID
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+	Offset    int
+}
+
+// ArchivedTransactionPage is one page of archived transactions. Archived
+// transactions are not counted, since that reads the whole archive.
+type ArchivedTransactionPage struct {
+	Transactions []Transaction
+	Limit        int
+	Offset       int
+}
+
+// Sortable columns for transaction listings
+const (
+	TransactionSortCreatedAt = "created_at"
+	TransactionSortAmount    = "amount"
+)
+
+// TransactionFilter controls filtering and sorting of transaction
+// listings and the export. Nil filters are not applied; amount bounds are
+// inclusive, Since is inclusive and Until exclusive. Ties in SortBy are
+// broken by id in the same direction.
+type TransactionFilter struct {
+	Since     *time.Time
+	Until     *time.Time
+	MinAmount *float64
+	MaxAmount *float64
+	SortBy    string
+	SortDesc  bool
+}
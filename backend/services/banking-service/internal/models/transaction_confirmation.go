@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/pkg/money"
+)
+
+// TransactionConfirmationKind is the kind of money movement a confirmation
+// carries out
+type TransactionConfirmationKind string
+
+const (
+	TransactionConfirmationWithdrawal       TransactionConfirmationKind = "withdrawal"
+	TransactionConfirmationTransfer         TransactionConfirmationKind = "transfer"
+	TransactionConfirmationExternalTransfer TransactionConfirmationKind = "external_transfer"
+)
+
+// TransactionConfirmation is a withdrawal or transfer over the user's
+// confirmation threshold, waiting for the user to confirm it. Only the
+// SHA-256 hash of its token is stored, and Request holds the original
+// request without its PIN.
+type TransactionConfirmation struct {
+	ID        uuid.UUID                   `json:"id" db:"id"`
+	UserID    uuid.UUID                   `json:"user_id" db:"user_id"`
+	Kind      TransactionConfirmationKind `json:"kind" db:"kind"`
+	Amount    float64                     `json:"amount" db:"amount"`
+	Request   json.RawMessage             `json:"-" db:"request"`
+	TokenHash string                      `json:"-" db:"token_hash"`
+	ExpiresAt time.Time                   `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time                  `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time                   `json:"created_at" db:"created_at"`
+}
+
+// TransactionConfirmationRules decide which withdrawals and transfers need
+// confirming
+type TransactionConfirmationRules struct {
+	// DefaultThreshold is the amount over which withdrawals and transfers
+	// need confirming, for users who did not choose their own
+	DefaultThreshold float64
+	// TTL is how long a confirmation token can be used
+	TTL time.Duration
+}
+
+// ConfirmationThreshold is the amount over which a user's withdrawals and
+// transfers need confirming
+type ConfirmationThreshold struct {
+	Threshold money.Money `json:"threshold"`
+	// Default is set while the user has not chosen a threshold
+	Default bool `json:"default"`
+}
+
+// UpdateConfirmationThresholdRequest sets the amount over which the
+// user's withdrawals and transfers need confirming
+type UpdateConfirmationThresholdRequest struct {
+	Threshold float64 `json:"threshold" binding:"required,gt=0"`
+}
+
+// PendingConfirmation is returned instead of carrying out a withdrawal or
+// transfer over the user's threshold. Token is only ever shown here.
+type PendingConfirmation struct {
+	Token     string                      `json:"token"`
+	Kind      TransactionConfirmationKind `json:"kind"`
+	Amount    money.Money                 `json:"amount"`
+	Threshold money.Money                 `json:"threshold"`
+	ExpiresAt time.Time                   `json:"expires_at"`
+}
+
+// ConfirmTransactionRequest carries out a withdrawal or transfer waiting
+// for confirmation
+type ConfirmTransactionRequest struct {
+	Token string `json:"token" binding:"required,max=128"`
+}
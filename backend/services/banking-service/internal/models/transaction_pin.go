@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransactionPIN is the optional PIN a user set on their account to
+// confirm withdrawals and transfers. Only its bcrypt hash is stored.
+// PINHash is empty once staff reset the PIN, until the user sets a new
+// one.
+type TransactionPIN struct {
+	AccountID uuid.UUID `json:"account_id" db:"account_id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	PINHash   string    `json:"-" db:"pin_hash"`
+	// FailedAttempts counts wrong PINs and passwords since the last right
+	// one or the last lockout
+	FailedAttempts int        `json:"failed_attempts" db:"failed_attempts"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+	// ResetRequired is set when staff reset the PIN: withdrawals and
+	// transfers are refused until the user sets a new one
+	ResetRequired bool      `json:"reset_required" db:"reset_required"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TransactionPINRules limit wrong PIN attempts
+type TransactionPINRules struct {
+	// MaxAttempts is how many wrong PINs or passwords in a row lock the
+	// PIN for Lockout
+	MaxAttempts int
+	Lockout     time.Duration
+}
+
+// Locked reports whether the PIN is locked at now
+func (p *TransactionPIN) Locked(now time.Time) bool {
+	return p.LockedUntil != nil && now.Before(*p.LockedUntil)
+}
+
+// TransactionPINStatus says whether a user has a transaction PIN, without
+// revealing anything about it
+type TransactionPINStatus struct {
+	PINSet        bool       `json:"pin_set"`
+	ResetRequired bool       `json:"reset_required"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+// StatusAt returns the PIN's status at now. A nil PIN is one that was
+// never set.
+func (p *TransactionPIN) StatusAt(now time.Time) TransactionPINStatus {
+	if p == nil {
+		return TransactionPINStatus{}
+	}
+	status := TransactionPINStatus{PINSet: p.PINHash != "", ResetRequired: p.ResetRequired}
+	if p.Locked(now) {
+		lockedUntil := p.LockedUntil.UTC()
+		status.LockedUntil = &lockedUntil
+	}
+	return status
+}
+
+// SetTransactionPINRequest represents a request to set or change the
+// transaction PIN. Changing a PIN, or setting one after staff reset it,
+// needs the current PIN or the user's password.
+type SetTransactionPINRequest struct {
+	PIN        string `json:"pin" binding:"required,min=4,max=6,numeric"`
+	CurrentPIN string `json:"current_pin" binding:"omitempty,max=6"`
+	Password   string `json:"password" binding:"max=128"`
+}
+
+// TransactionPINReset identifies the staff member who reset a user's
+// transaction PIN and the request they did it in
+type TransactionPINReset struct {
+	ActorID   uuid.UUID
+	RequestID string
+}
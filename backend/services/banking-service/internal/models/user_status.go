@@ -0,0 +1,34 @@
+package models
+
+// KYCStatusVerified is the KYC status of users whose identity a reviewer has
+// verified
+const KYCStatusVerified = "verified"
+
+// UserStatus is the client-service's compact view of a user. Unknown users
+// have Exists false.
+type UserStatus struct {
+	UserID        string `json:"user_id"`
+	Exists        bool   `json:"exists"`
+	IsBlacklisted bool   `json:"is_blacklisted"`
+	IsAdmin       bool   `json:"is_admin"`
+	IsDeleted     bool   `json:"is_deleted"`
+	EmailVerified bool   `json:"email_verified"`
+	TokenVersion  int    `json:"token_version"`
+	// KYCStatus is one of "none", "pending", "verified" or "rejected"
+	KYCStatus string `json:"kyc_status"`
+	// Roles lists the user's staff roles
+	Roles []string `json:"roles"`
+	// TimeZone is the user's preferred IANA time zone, used only to group
+	// times for display
+	TimeZone string `json:"timezone"`
+}
+
+// UserDetail is who a user is, as the client-service tells staff-facing
+// services. Unknown users have Exists false and empty details.
+type UserDetail struct {
+	UserID    string `json:"user_id"`
+	Exists    bool   `json:"exists"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	IsDeleted bool   `json:"is_deleted"`
+}
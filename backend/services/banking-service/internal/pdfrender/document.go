@@ -0,0 +1,240 @@
+// Package pdfrender renders statements and transaction receipts as PDF
+// documents. It writes PDF 1.4 itself, with the standard Helvetica fonts
+// every reader has, so it needs nothing beyond the standard library.
+// Content streams are left uncompressed and no random IDs are written, so
+// the same input always renders the same bytes.
+package pdfrender
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A4 page size and margins, in points
+const (
+	pageWidth    = 595.28
+	pageHeight   = 841.89
+	marginLeft   = 40.0
+	marginRight  = pageWidth - 40.0
+	marginBottom = pageHeight - 60.0
+)
+
+// color is an RGB color with components from 0 to 1
+type color struct{ r, g, b float64 }
+
+// Colors of the Microbank brand
+var (
+	brandColor = color{0.05, 0.24, 0.45}
+	white      = color{1, 1, 1}
+	black      = color{0.1, 0.1, 0.1}
+	grey       = color{0.42, 0.45, 0.5}
+	ruleColor  = color{0.85, 0.87, 0.9}
+	shadeColor = color{0.95, 0.96, 0.97}
+)
+
+// font is one of the standard fonts, with the widths of its printable
+// ASCII characters in thousandths of the font size
+type font struct {
+	resource string
+	widths   [95]int
+}
+
+var (
+	regular = &font{resource: "F1", widths: helveticaWidths}
+	bold    = &font{resource: "F2", widths: helveticaBoldWidths}
+)
+
+// winAnsi maps the characters outside Latin-1 that are written in
+// WinAnsiEncoding to their codes
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '…': 0x85,
+}
+
+// encode converts s to WinAnsiEncoding. Characters it cannot hold are
+// written as "?".
+func encode(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80 && r >= 0x20:
+			encoded = append(encoded, byte(r))
+		case r >= 0xA0 && r <= 0xFF:
+			encoded = append(encoded, byte(r))
+		case winAnsi[r] != 0:
+			encoded = append(encoded, winAnsi[r])
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// width returns the width of s set in f at size points
+func (f *font) width(s string, size float64) float64 {
+	total := 0
+	for _, c := range encode(s) {
+		switch {
+		case c >= 0x20 && c < 0x7F:
+			total += f.widths[c-0x20]
+		case c == 0x95:
+			total += 350
+		default:
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// fit shortens s with an ellipsis until it is at most width wide
+func (f *font) fit(s string, size, width float64) string {
+	if f.width(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		shortened := strings.TrimRight(string(runes), " ") + "…"
+		if f.width(shortened, size) <= width {
+			return shortened
+		}
+	}
+	return ""
+}
+
+// document is a PDF being drawn, page by page. Positions are in points
+// from the top left corner of the page, and text is placed by its
+// baseline.
+type document struct {
+	title     string
+	createdAt time.Time
+	pages     []*bytes.Buffer
+	page      *bytes.Buffer
+}
+
+// newDocument starts an empty document
+func newDocument(title string, createdAt time.Time) *document {
+	return &document{title: title, createdAt: createdAt}
+}
+
+// addPage starts a new page, which is drawn on from then on
+func (d *document) addPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+}
+
+// text writes s with its baseline at y, starting at x
+func (d *document) text(x, y float64, f *font, size float64, c color, s string) {
+	fmt.Fprintf(d.page, "BT /%s %s Tf %s %s %s rg %s %s Td (%s) Tj ET\n",
+		f.resource, num(size), num(c.r), num(c.g), num(c.b), num(x), num(pageHeight-y), escape(encode(s)))
+}
+
+// textRight writes s with its baseline at y, ending at right
+func (d *document) textRight(right, y float64, f *font, size float64, c color, s string) {
+	d.text(right-f.width(s, size), y, f, size, c, s)
+}
+
+// fillRect fills the rectangle whose top left corner is at x, y
+func (d *document) fillRect(x, y, width, height float64, c color) {
+	fmt.Fprintf(d.page, "%s %s %s rg %s %s %s %s re f\n",
+		num(c.r), num(c.g), num(c.b), num(x), num(pageHeight-y-height), num(width), num(height))
+}
+
+// rule draws a horizontal line at y from x1 to x2
+func (d *document) rule(x1, x2, y float64, c color) {
+	fmt.Fprintf(d.page, "%s %s %s RG 0.5 w %s %s m %s %s l S\n",
+		num(c.r), num(c.g), num(c.b), num(x1), num(pageHeight-y), num(x2), num(pageHeight-y))
+}
+
+// bytes writes out the document: the catalog, the page tree, the fonts,
+// the document information and then each page and its content, followed by
+// the cross-reference table
+func (d *document) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	const firstPage = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (Microbank) /CreationDate (D:%s) >>",
+		escape(encode(d.title)), d.createdAt.UTC().Format("20060102150405Z")))
+
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			num(pageWidth), num(pageHeight), firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// escape writes encoded text as the inside of a PDF string, with bytes
+// outside printable ASCII as octal escapes
+func escape(encoded []byte) string {
+	var b strings.Builder
+	for _, c := range encoded {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7F:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// num formats a coordinate or size with at most two decimals
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// helveticaWidths are the widths of Helvetica's characters from space to
+// tilde
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// helveticaBoldWidths are the widths of Helvetica-Bold's characters from
+// space to tilde
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,104 @@
+package pdfrender
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/money"
+)
+
+// Header band heights: the first page's carries the title, later pages
+// have a slimmer one
+const (
+	headerHeight      = 72.0
+	slimHeaderHeight  = 40.0
+	footerBaseline    = pageHeight - 32.0
+	generatedAtLayout = "2 January 2006 15:04 UTC"
+)
+
+// header draws the brand band across the top of the page, with the
+// document's title on the right
+func (d *document) header(title string, slim bool) float64 {
+	height := headerHeight
+	nameSize, titleSize := 22.0, 12.0
+	if slim {
+		height = slimHeaderHeight
+		nameSize, titleSize = 14.0, 9.0
+	}
+	d.fillRect(0, 0, pageWidth, height, brandColor)
+	d.text(marginLeft, height/2+nameSize*0.35, bold, nameSize, white, "Microbank")
+	d.textRight(marginRight, height/2+titleSize*0.35, regular, titleSize, white, title)
+	return height
+}
+
+// footers writes when the document was generated and "Page n of N" at the
+// foot of every page. It is called once every page has been drawn.
+func (d *document) footers() {
+	for i, page := range d.pages {
+		d.page = page
+		d.rule(marginLeft, marginRight, footerBaseline-14, ruleColor)
+		d.text(marginLeft, footerBaseline, regular, 8, grey, "Generated "+d.createdAt.UTC().Format(generatedAtLayout))
+		d.textRight(marginRight, footerBaseline, regular, 8, grey, fmt.Sprintf("Page %d of %d", i+1, len(d.pages)))
+	}
+}
+
+// field writes a small grey label with its value underneath
+func (d *document) field(x, y float64, label, value string, valueSize float64) {
+	d.text(x, y, regular, 8, grey, strings.ToUpper(label))
+	d.text(x, y+valueSize+5, bold, valueSize, black, value)
+}
+
+// maskAccountNumber shows only the last four characters of an account ID,
+// as on a card
+func maskAccountNumber(accountID uuid.UUID) string {
+	id := strings.ToUpper(strings.ReplaceAll(accountID.String(), "-", ""))
+	return "•••• •••• " + id[len(id)-4:]
+}
+
+// signedAmount formats a transaction's amount with the sign of its effect
+// on the balance: money in is positive and money out negative. Round-ups,
+// which leave the balance as it is, are unsigned.
+func signedAmount(transaction models.TransactionResponse) string {
+	switch transaction.Type {
+	case models.TransactionTypeDeposit, models.TransactionTypeRefund, models.TransactionTypeTransferIn, models.TransactionTypeBonus:
+		return "+" + transaction.Amount.Amount()
+	case models.TransactionTypeWithdrawal, models.TransactionTypeFee, models.TransactionTypeTransferOut, models.TransactionTypeExternalTransfer, models.TransactionTypeChequeReturn:
+		return money.New(-transaction.Amount.Minor, transaction.Amount.Currency).Amount()
+	}
+	return transaction.Amount.Amount()
+}
+
+// typeLabel names a transaction type for people
+func typeLabel(kind models.TransactionType) string {
+	switch kind {
+	case models.TransactionTypeDeposit:
+		return "Deposit"
+	case models.TransactionTypeWithdrawal:
+		return "Withdrawal"
+	case models.TransactionTypeFee:
+		return "Fee"
+	case models.TransactionTypeRoundUp:
+		return "Round-up"
+	case models.TransactionTypeRefund:
+		return "Refund"
+	case models.TransactionTypeTransferOut:
+		return "Transfer out"
+	case models.TransactionTypeTransferIn:
+		return "Transfer in"
+	case models.TransactionTypeExternalTransfer:
+		return "External transfer"
+	case models.TransactionTypeChequeReturn:
+		return "Cheque returned"
+	case models.TransactionTypeBonus:
+		return "Bonus"
+	}
+	return string(kind)
+}
+
+// dateLabel formats a time as a day, such as "3 Sep 2026", in UTC
+func dateLabel(t time.Time) string {
+	return t.UTC().Format("2 Jan 2006")
+}
@@ -0,0 +1,205 @@
+package pdfrender
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// update rewrites the golden files from the current output; run
+// go test ./internal/pdfrender -update and review the PDFs before
+// committing them
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var generatedAt = time.Date(2026, time.October, 18, 9, 30, 0, 0, time.UTC)
+
+// testID returns a fixed UUID numbered n, so output is the same every run
+func testID(n int) uuid.UUID {
+	return uuid.MustParse(fmt.Sprintf("5f0c3a1e-8b2d-4c6e-9a7f-%012d", n))
+}
+
+// testStatement returns a September 2026 statement with count deposits
+// and withdrawals, alternating
+func testStatement(count int) models.StatementResponse {
+	statement := models.Statement{
+		UserID:         testID(1),
+		AccountID:      uuid.MustParse("9b2e4f6a-1c3d-4e5f-8a7b-6c5d4e3f1a2b"),
+		Period:         "2026-09",
+		PeriodStart:    time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:      time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC),
+		OpeningBalance: 1250.5,
+		ClosingBalance: 1250.5,
+	}
+	balance := statement.OpeningBalance
+	for i := 0; i < count; i++ {
+		transaction := models.Transaction{
+			ID:            testID(100 + i),
+			AccountID:     statement.AccountID,
+			UserID:        statement.UserID,
+			BalanceBefore: balance,
+			Description:   fmt.Sprintf("Payment %d", i+1),
+			CreatedAt:     statement.PeriodStart.Add(time.Duration(i) * 10 * time.Hour),
+		}
+		if i%2 == 0 {
+			transaction.Type, transaction.Amount = models.TransactionTypeDeposit, 100.25
+			statement.MoneyIn += transaction.Amount
+			balance += transaction.Amount
+		} else {
+			transaction.Type, transaction.Amount = models.TransactionTypeWithdrawal, 40
+			statement.MoneyOut += transaction.Amount
+			balance -= transaction.Amount
+		}
+		transaction.BalanceAfter = balance
+		statement.Transactions = append(statement.Transactions, transaction)
+	}
+	statement.ClosingBalance = balance
+	return statement.ToResponse()
+}
+
+// checkGolden compares got with testdata/name, or rewrites the file when
+// -update is set
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(golden, got) {
+		failed := filepath.Join(t.TempDir(), name)
+		os.WriteFile(failed, got, 0o644)
+		t.Errorf("Output does not match %s; it was written to %s for comparison", path, failed)
+	}
+}
+
+// checkStructure checks the cross-reference table points at each object
+// and returns the page count
+func checkStructure(t *testing.T, pdf []byte) int {
+	t.Helper()
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	if match == nil {
+		t.Fatal("Expected the document to end with startxref and EOF")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the cross-reference table", xref)
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("Object %d is not at offset %d", i+1, offset)
+		}
+	}
+
+	count := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindSubmatch(pdf)
+	if count == nil {
+		t.Fatal("Expected a page tree")
+	}
+	pages, _ := strconv.Atoi(string(count[1]))
+	return pages
+}
+
+func TestStatement(t *testing.T) {
+	statement := testStatement(70)
+	pdf := Statement(statement, generatedAt)
+	checkGolden(t, "statement.pdf", pdf)
+
+	pages := checkStructure(t, pdf)
+	if pages != 3 {
+		t.Fatalf("Expected 70 transactions to take 3 pages, got %d", pages)
+	}
+	for page := 1; page <= pages; page++ {
+		if !bytes.Contains(pdf, []byte(fmt.Sprintf("(Page %d of %d)", page, pages))) {
+			t.Errorf("Expected page %d to be numbered", page)
+		}
+	}
+	if n := bytes.Count(pdf, []byte("(DESCRIPTION)")); n != pages {
+		t.Errorf("Expected the column headings on each of the %d pages, got %d", pages, n)
+	}
+
+	// Every transaction is listed once, none lost at a page break
+	for i := 1; i <= len(statement.Transactions); i++ {
+		if n := bytes.Count(pdf, []byte(fmt.Sprintf("(Payment %d)", i))); n != 1 {
+			t.Errorf("Expected Payment %d to be listed once, got %d", i, n)
+		}
+	}
+
+	for _, want := range []string{
+		"(1 September 2026 to 30 September 2026)",
+		"(\\225\\225\\225\\225 \\225\\225\\225\\225 1A2B)",
+		"(1250.50 USD)", "(3508.75 USD)", "(1400.00 USD)", "(3359.25 USD)", "(+100.25)", "(-40.00)",
+		"(Generated 18 October 2026 09:30 UTC)",
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("Expected the statement to contain %s", want)
+		}
+	}
+}
+
+func TestStatement_NoTransactions(t *testing.T) {
+	pdf := Statement(testStatement(0), generatedAt)
+	checkGolden(t, "statement_empty.pdf", pdf)
+
+	if pages := checkStructure(t, pdf); pages != 1 {
+		t.Errorf("Expected 1 page, got %d", pages)
+	}
+	if !bytes.Contains(pdf, []byte("(There were no transactions this month.)")) {
+		t.Error("Expected the statement to say there were no transactions")
+	}
+}
+
+func TestReceipt(t *testing.T) {
+	related := testID(7)
+	transaction := models.Transaction{
+		ID:                   testID(42),
+		AccountID:            uuid.MustParse("9b2e4f6a-1c3d-4e5f-8a7b-6c5d4e3f1a2b"),
+		UserID:               testID(1),
+		Type:                 models.TransactionTypeFee,
+		Amount:               1.5,
+		BalanceBefore:        120.25,
+		BalanceAfter:         118.75,
+		Description:          "Withdrawal fee (ATM)",
+		CreatedAt:            time.Date(2026, time.September, 10, 12, 0, 1, 0, time.UTC),
+		RelatedTransactionID: &related,
+	}
+	pdf := Receipt(transaction.ToResponse(), generatedAt)
+	checkGolden(t, "receipt.pdf", pdf)
+
+	if pages := checkStructure(t, pdf); pages != 1 {
+		t.Errorf("Expected 1 page, got %d", pages)
+	}
+	for _, want := range []string{"(-1.50 USD)", "(Withdrawal fee \\(ATM\\))", "(118.75 USD)", "(10 September 2026 12:00 UTC)", "(Page 1 of 1)"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("Expected the receipt to contain %s", want)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	if got := regular.fit("Rent", 9, 100); got != "Rent" {
+		t.Errorf("Expected short text to be kept, got %q", got)
+	}
+	long := "Transfer to a very long named merchant somewhere far away"
+	got := regular.fit(long, 9, 100)
+	if regular.width(got, 9) > 100 || got[len(got)-len("…"):] != "…" {
+		t.Errorf("Expected text cut to 100 points with an ellipsis, got %q (%v points)", got, regular.width(got, 9))
+	}
+}
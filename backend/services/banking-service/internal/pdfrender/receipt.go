@@ -0,0 +1,53 @@
+package pdfrender
+
+import (
+	"time"
+
+	"microbank/banking-service/internal/models"
+)
+
+// receiptLabelWidth is how far a receipt's values sit from their labels
+const receiptLabelWidth = 140.0
+
+// Receipt renders a single-page receipt for a transaction: its signed
+// amount, then its reference, time, type, description, account and the
+// balance before and after it. generatedAt is printed in the footer.
+func Receipt(transaction models.TransactionResponse, generatedAt time.Time) []byte {
+	d := newDocument("Microbank receipt "+transaction.ID.String(), generatedAt)
+	d.addPage()
+	y := d.header("Transaction receipt", false)
+
+	// Amount
+	y += 45
+	d.text(marginLeft, y, regular, 10, grey, typeLabel(transaction.Type))
+	d.text(marginLeft, y+30, bold, 26, black, signedAmount(transaction)+" "+string(transaction.Amount.Currency))
+	y += 55
+	d.rule(marginLeft, marginRight, y, ruleColor)
+
+	// Details
+	description := transaction.Description
+	if description == "" {
+		description = "-"
+	}
+	rows := []struct{ label, value string }{
+		{"Reference", transaction.ID.String()},
+		{"Date", transaction.CreatedAt.UTC().Format(generatedAtLayout)},
+		{"Type", typeLabel(transaction.Type)},
+		{"Description", description},
+		{"Account", maskAccountNumber(transaction.AccountID)},
+		{"Balance before", transaction.BalanceBefore.String()},
+		{"Balance after", transaction.BalanceAfter.String()},
+	}
+	if transaction.RelatedTransactionID != nil {
+		rows = append(rows, struct{ label, value string }{"Related transaction", transaction.RelatedTransactionID.String()})
+	}
+	for _, row := range rows {
+		y += 26
+		d.text(marginLeft, y, regular, 10, grey, row.label)
+		d.text(marginLeft+receiptLabelWidth, y, bold, 10, black, bold.fit(row.value, 10, marginRight-marginLeft-receiptLabelWidth))
+		d.rule(marginLeft, marginRight, y+10, ruleColor)
+	}
+
+	d.footers()
+	return d.bytes()
+}
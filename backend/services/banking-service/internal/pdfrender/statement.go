@@ -0,0 +1,98 @@
+package pdfrender
+
+import (
+	"time"
+
+	"microbank/banking-service/internal/models"
+)
+
+// Statement table layout: where each column starts, or ends for the
+// right-aligned amounts, and the height of each row
+const (
+	tableRowHeight    = 18.0
+	tableHeaderHeight = 20.0
+	dateColumn        = marginLeft + 6
+	descriptionColumn = marginLeft + 72
+	descriptionWidth  = 200.0
+	typeColumn        = marginLeft + 284
+	amountColumn      = marginRight - 96
+	balanceColumn     = marginRight - 6
+)
+
+// Statement renders a monthly statement: a header with the period and the
+// masked account number, the opening and closing balances and the money
+// in and out, then the month's transactions in a table that runs over as
+// many pages as it needs, with its column headings repeated on each.
+// generatedAt is printed in the footer of every page.
+func Statement(statement models.StatementResponse, generatedAt time.Time) []byte {
+	month := statement.PeriodStart.UTC().Format("January 2006")
+	d := newDocument("Microbank statement for "+month, generatedAt)
+	d.addPage()
+	y := d.header("Statement for "+month, false)
+
+	// Period and account
+	y += 33
+	lastDay := statement.PeriodEnd.UTC().AddDate(0, 0, -1)
+	d.field(marginLeft, y, "Statement period", statement.PeriodStart.UTC().Format("2 January 2006")+" to "+lastDay.Format("2 January 2006"), 11)
+	d.field(marginLeft+270, y, "Account", maskAccountNumber(statement.AccountID), 11)
+	d.field(marginLeft+420, y, "Currency", string(statement.Currency), 11)
+
+	// Summary
+	y += 35
+	const summaryHeight = 52.0
+	d.fillRect(marginLeft, y, marginRight-marginLeft, summaryHeight, shadeColor)
+	cell := (marginRight - marginLeft) / 4
+	for i, figure := range []struct {
+		label string
+		value string
+	}{
+		{"Opening balance", statement.OpeningBalance.String()},
+		{"Money in", statement.MoneyIn.String()},
+		{"Money out", statement.MoneyOut.String()},
+		{"Closing balance", statement.ClosingBalance.String()},
+	} {
+		d.field(marginLeft+12+float64(i)*cell, y+19, figure.label, figure.value, 12)
+	}
+
+	// Transactions
+	y += summaryHeight + 23
+	y = d.tableHeader(y)
+	if len(statement.Transactions) == 0 {
+		d.text(dateColumn, y+tableRowHeight, regular, 9, grey, "There were no transactions this month.")
+	}
+	for _, transaction := range statement.Transactions {
+		if y+tableRowHeight > marginBottom {
+			d.addPage()
+			y = d.header("Statement for "+month+" (continued)", true) + 25
+			y = d.tableHeader(y)
+		}
+		baseline := y + 12
+		description := transaction.Description
+		if description == "" {
+			description = typeLabel(transaction.Type)
+		}
+		d.text(dateColumn, baseline, regular, 9, black, dateLabel(transaction.CreatedAt))
+		d.text(descriptionColumn, baseline, regular, 9, black, regular.fit(description, 9, descriptionWidth))
+		d.text(typeColumn, baseline, regular, 9, grey, typeLabel(transaction.Type))
+		d.textRight(amountColumn, baseline, regular, 9, black, signedAmount(transaction))
+		d.textRight(balanceColumn, baseline, regular, 9, black, transaction.BalanceAfter.Amount())
+		y += tableRowHeight
+		d.rule(marginLeft, marginRight, y, ruleColor)
+	}
+
+	d.footers()
+	return d.bytes()
+}
+
+// tableHeader draws the statement table's column headings at y and
+// returns where the first row starts
+func (d *document) tableHeader(y float64) float64 {
+	d.fillRect(marginLeft, y, marginRight-marginLeft, tableHeaderHeight, shadeColor)
+	baseline := y + 13
+	d.text(dateColumn, baseline, bold, 8, grey, "DATE")
+	d.text(descriptionColumn, baseline, bold, 8, grey, "DESCRIPTION")
+	d.text(typeColumn, baseline, bold, 8, grey, "TYPE")
+	d.textRight(amountColumn, baseline, bold, 8, grey, "AMOUNT")
+	d.textRight(balanceColumn, baseline, bold, 8, grey, "BALANCE")
+	return y + tableHeaderHeight
+}
@@ -0,0 +1,378 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// accountMemberColumns lists the account_members columns in the order
+// scanAccountMember reads them
+const accountMemberColumns = `account_id, user_id, role, spending_limit, added_by, created_at, updated_at`
+
+// accountInvitationColumns lists the account_invitations columns in the
+// order scanAccountInvitation reads them
+const accountInvitationColumns = `id, account_id, user_id, email, role, status, invited_by, expires_at, created_at, accepted_at`
+
+// ErrLastOwner is returned by AccountMemberRepositoryImpl.UpdateMember and
+// RemoveMember, when nothing is saved, for changes that would leave an
+// account without an owner
+var ErrLastOwner = errors.New("account would have no owner")
+
+// AccountMemberRepositoryImpl handles all database operations related to
+// the members of accounts and the invitations to join them
+type AccountMemberRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewAccountMemberRepository creates a new account member repository
+func NewAccountMemberRepository(db *PostgresDB) AccountMemberRepository {
+	return &AccountMemberRepositoryImpl{db: db}
+}
+
+// GetMember retrieves a user's membership of an account, or nil when they
+// are not a member
+func (r *AccountMemberRepositoryImpl) GetMember(accountID, userID uuid.UUID) (*models.AccountMember, error) {
+	query := `SELECT ` + accountMemberColumns + ` FROM account_members WHERE account_id = $1 AND user_id = $2`
+
+	member, err := scanAccountMember(r.db.QueryRow(query, accountID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get account member: %w", err)
+	}
+
+	return member, nil
+}
+
+// ListMembers retrieves an account's members, in the order they joined
+func (r *AccountMemberRepositoryImpl) ListMembers(accountID uuid.UUID) ([]models.AccountMember, error) {
+	query := `
+		SELECT ` + accountMemberColumns + `
+		FROM account_members
+		WHERE account_id = $1
+		ORDER BY created_at, user_id`
+
+	return r.queryMembers(query, accountID)
+}
+
+// ListByUserID retrieves the memberships of a user, oldest first
+func (r *AccountMemberRepositoryImpl) ListByUserID(userID uuid.UUID) ([]models.AccountMember, error) {
+	query := `
+		SELECT ` + accountMemberColumns + `
+		FROM account_members
+		WHERE user_id = $1
+		ORDER BY created_at, account_id`
+
+	return r.queryMembers(query, userID)
+}
+
+// UpdateMember saves a member's role and spending limit, and reports
+// whether they are still a member. Demoting the account's last owner
+// returns ErrLastOwner.
+func (r *AccountMemberRepositoryImpl) UpdateMember(member *models.AccountMember) (bool, error) {
+	updated := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		found, err := checkOwnersLeft(tx, member.AccountID, member.UserID, member.IsOwner())
+		if err != nil || !found {
+			return err
+		}
+
+		now := time.Now()
+		if _, err := tx.Exec(`
+			UPDATE account_members SET role = $1, spending_limit = $2, updated_at = $3
+			WHERE account_id = $4 AND user_id = $5`,
+			member.Role, member.SpendingLimit, now, member.AccountID, member.UserID); err != nil {
+			return fmt.Errorf("failed to update account member: %w", err)
+		}
+
+		member.UpdatedAt = now
+		updated = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return updated, nil
+}
+
+// RemoveMember removes a user from an account's members, and reports
+// whether they were one. Removing the account's last owner returns
+// ErrLastOwner.
+func (r *AccountMemberRepositoryImpl) RemoveMember(accountID, userID uuid.UUID) (bool, error) {
+	removed := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		found, err := checkOwnersLeft(tx, accountID, userID, false)
+		if err != nil || !found {
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM account_members WHERE account_id = $1 AND user_id = $2`, accountID, userID); err != nil {
+			return fmt.Errorf("failed to remove account member: %w", err)
+		}
+
+		removed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return removed, nil
+}
+
+// checkOwnersLeft locks an account's members and reports whether userID is
+// one of them. It returns ErrLastOwner when they are its only owner and
+// stayOwner is false, so changes racing each other cannot both take the
+// last owners away.
+func checkOwnersLeft(tx *sql.Tx, accountID, userID uuid.UUID, stayOwner bool) (bool, error) {
+	rows, err := tx.Query(`SELECT user_id, role FROM account_members WHERE account_id = $1 FOR UPDATE`, accountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock account members: %w", err)
+	}
+	defer rows.Close()
+
+	found, otherOwners, isOwner := false, 0, false
+	for rows.Next() {
+		var memberID uuid.UUID
+		var role models.AccountMemberRole
+		if err := rows.Scan(&memberID, &role); err != nil {
+			return false, fmt.Errorf("failed to scan account member row: %w", err)
+		}
+		switch {
+		case memberID == userID:
+			found, isOwner = true, role == models.AccountMemberRoleOwner
+		case role == models.AccountMemberRoleOwner:
+			otherOwners++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("error iterating over account member rows: %w", err)
+	}
+
+	if found && isOwner && !stayOwner && otherOwners == 0 {
+		return true, ErrLastOwner
+	}
+	return found, nil
+}
+
+// CreateInvitation saves an invitation unless its user already has a
+// pending one to the account that has not expired, and reports whether it
+// did. Expired invitations are marked as such first, so they do not keep
+// the user from being invited again.
+func (r *AccountMemberRepositoryImpl) CreateInvitation(invitation *models.AccountInvitation) (bool, error) {
+	created := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		if _, err := tx.Exec(`
+			UPDATE account_invitations SET status = 'expired'
+			WHERE account_id = $1 AND user_id = $2 AND status = 'pending' AND expires_at <= $3`,
+			invitation.AccountID, invitation.UserID, now); err != nil {
+			return fmt.Errorf("failed to expire account invitations: %w", err)
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO account_invitations (id, account_id, user_id, email, role, status, invited_by, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, 'pending', $6, $7, $8)
+			ON CONFLICT DO NOTHING`,
+			invitation.ID, invitation.AccountID, invitation.UserID, invitation.Email, invitation.Role,
+			invitation.InvitedBy, invitation.ExpiresAt, now)
+		if err != nil {
+			return fmt.Errorf("failed to create account invitation: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		invitation.Status = models.AccountInvitationStatusPending
+		invitation.CreatedAt = now
+		created = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// GetInvitation retrieves an invitation by its ID, or nil when there is
+// none
+func (r *AccountMemberRepositoryImpl) GetInvitation(id uuid.UUID) (*models.AccountInvitation, error) {
+	query := `SELECT ` + accountInvitationColumns + ` FROM account_invitations WHERE id = $1`
+
+	invitation, err := scanAccountInvitation(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get account invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// ListPendingInvitations retrieves the invitations to an account that can
+// still be accepted at the given time, newest first
+func (r *AccountMemberRepositoryImpl) ListPendingInvitations(accountID uuid.UUID, at time.Time) ([]models.AccountInvitation, error) {
+	query := `
+		SELECT ` + accountInvitationColumns + `
+		FROM account_invitations
+		WHERE account_id = $1 AND status = 'pending' AND expires_at > $2
+		ORDER BY created_at DESC, id DESC`
+
+	return r.queryInvitations(query, accountID, at)
+}
+
+// ListPendingInvitationsByUserID retrieves the invitations a user can
+// still accept at the given time, newest first
+func (r *AccountMemberRepositoryImpl) ListPendingInvitationsByUserID(userID uuid.UUID, at time.Time) ([]models.AccountInvitation, error) {
+	query := `
+		SELECT ` + accountInvitationColumns + `
+		FROM account_invitations
+		WHERE user_id = $1 AND status = 'pending' AND expires_at > $2
+		ORDER BY created_at DESC, id DESC`
+
+	return r.queryInvitations(query, userID, at)
+}
+
+// AcceptInvitation marks an invitation accepted and makes its user a
+// member of the account with its role, in one database transaction, and
+// reports whether it did. Nothing is written once the invitation was
+// accepted or has expired; users who became members meanwhile keep their
+// role.
+func (r *AccountMemberRepositoryImpl) AcceptInvitation(invitation *models.AccountInvitation) (bool, error) {
+	accepted := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		result, err := tx.Exec(`
+			UPDATE account_invitations SET status = 'accepted', accepted_at = $1
+			WHERE id = $2 AND status = 'pending' AND expires_at > $1`,
+			now, invitation.ID)
+		if err != nil {
+			return fmt.Errorf("failed to accept account invitation: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO account_members (account_id, user_id, role, added_by, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $5)
+			ON CONFLICT (account_id, user_id) DO NOTHING`,
+			invitation.AccountID, invitation.UserID, invitation.Role, invitation.InvitedBy, now); err != nil {
+			return fmt.Errorf("failed to add account member: %w", err)
+		}
+
+		invitation.Status = models.AccountInvitationStatusAccepted
+		invitation.AcceptedAt = &now
+		accepted = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return accepted, nil
+}
+
+// queryMembers runs a query selecting accountMemberColumns
+func (r *AccountMemberRepositoryImpl) queryMembers(query string, args ...interface{}) ([]models.AccountMember, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []models.AccountMember
+	for rows.Next() {
+		member, err := scanAccountMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account member row: %w", err)
+		}
+		members = append(members, *member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over account member rows: %w", err)
+	}
+
+	return members, nil
+}
+
+// queryInvitations runs a query selecting accountInvitationColumns
+func (r *AccountMemberRepositoryImpl) queryInvitations(query string, args ...interface{}) ([]models.AccountInvitation, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []models.AccountInvitation
+	for rows.Next() {
+		invitation, err := scanAccountInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account invitation row: %w", err)
+		}
+		invitations = append(invitations, *invitation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over account invitation rows: %w", err)
+	}
+
+	return invitations, nil
+}
+
+// scanAccountMember reads a row of accountMemberColumns
+func scanAccountMember(row rowScanner) (*models.AccountMember, error) {
+	member := &models.AccountMember{}
+	err := row.Scan(
+		&member.AccountID,
+		&member.UserID,
+		&member.Role,
+		&member.SpendingLimit,
+		&member.AddedBy,
+		&member.CreatedAt,
+		&member.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// scanAccountInvitation reads a row of accountInvitationColumns
+func scanAccountInvitation(row rowScanner) (*models.AccountInvitation, error) {
+	invitation := &models.AccountInvitation{}
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.AccountID,
+		&invitation.UserID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.Status,
+		&invitation.InvitedBy,
+		&invitation.ExpiresAt,
+		&invitation.CreatedAt,
+		&invitation.AcceptedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
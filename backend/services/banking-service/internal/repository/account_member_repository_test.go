@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+func TestAccountMemberRepository_RemoveMemberKeepsAnOwner(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewAccountMemberRepository(db)
+	accountID, owner, member := uuid.New(), uuid.New(), uuid.New()
+	lockMembers := regexp.QuoteMeta("SELECT user_id, role FROM account_members WHERE account_id = $1 FOR UPDATE")
+	memberRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"user_id", "role"}).
+			AddRow(owner, models.AccountMemberRoleOwner).
+			AddRow(member, models.AccountMemberRoleMember)
+	}
+
+	// The only owner cannot be removed, and nothing is deleted
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockMembers).WithArgs(accountID).WillReturnRows(memberRows())
+	mock.ExpectRollback()
+
+	if removed, err := repo.RemoveMember(accountID, owner); !errors.Is(err, ErrLastOwner) || removed {
+		t.Errorf("Expected ErrLastOwner, got %v (%v)", removed, err)
+	}
+
+	// Nor demoted
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockMembers).WithArgs(accountID).WillReturnRows(memberRows())
+	mock.ExpectRollback()
+
+	demoted := &models.AccountMember{AccountID: accountID, UserID: owner, Role: models.AccountMemberRoleMember}
+	if updated, err := repo.UpdateMember(demoted); !errors.Is(err, ErrLastOwner) || updated {
+		t.Errorf("Expected ErrLastOwner demoting the last owner, got %v (%v)", updated, err)
+	}
+
+	// Members are removed
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockMembers).WithArgs(accountID).WillReturnRows(memberRows())
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM account_members WHERE account_id = $1 AND user_id = $2")).
+		WithArgs(accountID, member).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if removed, err := repo.RemoveMember(accountID, member); err != nil || !removed {
+		t.Errorf("Expected the member to be removed, got %v (%v)", removed, err)
+	}
+
+	// Users who are not members are reported as such
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockMembers).WithArgs(accountID).WillReturnRows(memberRows())
+	mock.ExpectCommit()
+
+	if removed, err := repo.RemoveMember(accountID, uuid.New()); err != nil || removed {
+		t.Errorf("Expected nothing to be removed, got %v (%v)", removed, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
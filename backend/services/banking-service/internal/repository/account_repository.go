@@ -19,12 +19,17 @@ func NewAccountRepository(db *PostgresDB) AccountRepository {
 	return &AccountRepositoryImpl{db: db}
 }
 
-// CreateAccount creates a new account for a user
+// CreateAccount creates a new account for a user, with them as its owner
 func (r *AccountRepositoryImpl) CreateAccount(userID uuid.UUID) (*models.Account, error) {
 	query := `
-		INSERT INTO accounts (id, user_id, balance, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, user_id, balance, created_at, updated_at`
+		WITH account AS (
+			INSERT INTO accounts (id, user_id, balance, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, user_id, balance, created_at, updated_at, frozen_at, version
+		), holder AS (
+			` + insertHolder + `
+		)
+		SELECT id, user_id, balance, created_at, updated_at, frozen_at, version FROM account`
 
 	now := time.Now()
 	account := &models.Account{
@@ -48,6 +53,8 @@ func (r *AccountRepositoryImpl) CreateAccount(userID uuid.UUID) (*models.Account
 		&account.Balance,
 		&account.CreatedAt,
 		&account.UpdatedAt,
+		&account.FrozenAt,
+		&account.Version,
 	)
 
 	if err != nil {
@@ -57,6 +64,52 @@ func (r *AccountRepositoryImpl) CreateAccount(userID uuid.UUID) (*models.Account
 	return account, nil
 }
 
+// insertHolder adds the user an account was created for, in the account
+// CTE, as its owner
+const insertHolder = `INSERT INTO account_members (account_id, user_id, role, created_at, updated_at)
+			SELECT id, user_id, 'owner', created_at, created_at FROM account`
+
+// CreateAccountIfMissing creates an account for a user unless they already
+// have one, and reports whether it was created. The unique user_id makes it
+// safe to call concurrently for the same user.
+func (r *AccountRepositoryImpl) CreateAccountIfMissing(userID uuid.UUID) (*models.Account, bool, error) {
+	query := `
+		WITH account AS (
+			INSERT INTO accounts (id, user_id, balance, created_at, updated_at)
+			VALUES ($1, $2, 0.00, $3, $3)
+			ON CONFLICT (user_id) DO NOTHING
+			RETURNING id, user_id, balance, created_at, updated_at, frozen_at, version
+		), holder AS (
+			` + insertHolder + `
+		)
+		SELECT id, user_id, balance, created_at, updated_at, frozen_at, version FROM account`
+
+	account := &models.Account{}
+	err := r.db.QueryRow(query, uuid.New(), userID, time.Now()).Scan(
+		&account.ID,
+		&account.UserID,
+		&account.Balance,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+		&account.FrozenAt,
+		&account.Version,
+	)
+
+	if err == sql.ErrNoRows {
+		// The user already has an account
+		account, err := r.GetAccountByUserID(userID)
+		if err != nil {
+			return nil, false, err
+		}
+		return account, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create account: %w", err)
+	}
+
+	return account, true, nil
+}
+
 // GetOrCreateAccount gets an existing account or creates a new one for a user
 func (r *AccountRepositoryImpl) GetOrCreateAccount(userID uuid.UUID) (*models.Account, error) {
 	// Check if account exists
@@ -86,7 +139,7 @@ func (r *AccountRepositoryImpl) GetOrCreateAccount(userID uuid.UUID) (*models.Ac
 // GetAccountByUserID retrieves an account by user ID
 func (r *AccountRepositoryImpl) GetAccountByUserID(userID uuid.UUID) (*models.Account, error) {
 	query := `
-		SELECT id, user_id, balance, created_at, updated_at
+		SELECT id, user_id, balance, created_at, updated_at, frozen_at, version
 		FROM accounts WHERE user_id = $1`
 
 	account := &models.Account{}
@@ -96,6 +149,8 @@ func (r *AccountRepositoryImpl) GetAccountByUserID(userID uuid.UUID) (*models.Ac
 		&account.Balance,
 		&account.CreatedAt,
 		&account.UpdatedAt,
+		&account.FrozenAt,
+		&account.Version,
 	)
 
 	if err != nil {
@@ -111,7 +166,7 @@ func (r *AccountRepositoryImpl) GetAccountByUserID(userID uuid.UUID) (*models.Ac
 // GetAccountByID retrieves an account by its ID
 func (r *AccountRepositoryImpl) GetAccountByID(id uuid.UUID) (*models.Account, error) {
 	query := `
-		SELECT id, user_id, balance, created_at, updated_at
+		SELECT id, user_id, balance, created_at, updated_at, frozen_at, version
 		FROM accounts WHERE id = $1`
 
 	account := &models.Account{}
@@ -121,6 +176,8 @@ func (r *AccountRepositoryImpl) GetAccountByID(id uuid.UUID) (*models.Account, e
 		&account.Balance,
 		&account.CreatedAt,
 		&account.UpdatedAt,
+		&account.FrozenAt,
+		&account.Version,
 	)
 
 	if err != nil {
@@ -137,7 +194,7 @@ func (r *AccountRepositoryImpl) GetAccountByID(id uuid.UUID) (*models.Account, e
 func (r *AccountRepositoryImpl) UpdateBalance(accountID uuid.UUID, newBalance float64) error {
 	query := `
 		UPDATE accounts 
-		SET balance = $1, updated_at = $2
+		SET balance = $1, updated_at = $2, version = version + 1
 		WHERE id = $3`
 
 	result, err := r.db.Exec(query, newBalance, time.Now(), accountID)
@@ -157,6 +214,76 @@ func (r *AccountRepositoryImpl) UpdateBalance(accountID uuid.UUID, newBalance fl
 	return nil
 }
 
+// MarkOwnerDeleted flags a user's account as orphaned. It reports whether
+// an account existed for the user.
+func (r *AccountRepositoryImpl) MarkOwnerDeleted(userID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE accounts 
+		SET owner_deleted_at = COALESCE(owner_deleted_at, $1), updated_at = $1
+		WHERE user_id = $2`
+
+	result, err := r.db.Exec(query, time.Now(), userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to flag orphaned account: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ClearOwnerDeleted removes the orphaned flag from a user's account after
+// the user is restored. It reports whether a flagged account existed.
+func (r *AccountRepositoryImpl) ClearOwnerDeleted(userID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE accounts 
+		SET owner_deleted_at = NULL, updated_at = $1
+		WHERE user_id = $2 AND owner_deleted_at IS NOT NULL`
+
+	result, err := r.db.Exec(query, time.Now(), userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to clear orphaned account flag: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// SetFrozen freezes or unfreezes a user's account. A frozen account keeps
+// its original freeze time if frozen again. It reports whether the account
+// changed.
+func (r *AccountRepositoryImpl) SetFrozen(userID uuid.UUID, frozen bool) (bool, error) {
+	query := `
+		UPDATE accounts
+		SET frozen_at = $1, updated_at = $2
+		WHERE user_id = $3 AND (frozen_at IS NULL) = $4`
+
+	now := time.Now()
+	var frozenAt *time.Time
+	if frozen {
+		frozenAt = &now
+	}
+
+	result, err := r.db.Exec(query, frozenAt, now, userID, frozen)
+	if err != nil {
+		return false, fmt.Errorf("failed to update account freeze: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
 // AccountExists checks if an account exists for a user
 func (r *AccountRepositoryImpl) AccountExists(userID uuid.UUID) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM accounts WHERE user_id = $1)`
@@ -173,7 +300,7 @@ func (r *AccountRepositoryImpl) AccountExists(userID uuid.UUID) (bool, error) {
 // GetAllAccounts retrieves all accounts (for admin purposes)
 func (r *AccountRepositoryImpl) GetAllAccounts() ([]models.Account, error) {
 	query := `
-		SELECT id, user_id, balance, created_at, updated_at
+		SELECT id, user_id, balance, created_at, updated_at, frozen_at, version
 		FROM accounts
 		ORDER BY created_at DESC`
 
@@ -192,6 +319,8 @@ func (r *AccountRepositoryImpl) GetAllAccounts() ([]models.Account, error) {
 			&account.Balance,
 			&account.CreatedAt,
 			&account.UpdatedAt,
+			&account.FrozenAt,
+			&account.Version,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan account row: %w", err)
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// beneficiaryColumns lists the beneficiaries columns in the order
+// scanBeneficiary reads them
+const beneficiaryColumns = `id, user_id, nickname, account_id, created_at`
+
+// Errors returned by BeneficiaryRepositoryImpl.Create, when nothing is
+// saved
+var (
+	// ErrBeneficiaryLimit is returned when the user already has as many
+	// beneficiaries as allowed
+	ErrBeneficiaryLimit = errors.New("beneficiary limit reached")
+	// ErrBeneficiaryDuplicate is returned when the user already saved the
+	// account
+	ErrBeneficiaryDuplicate = errors.New("account is already a beneficiary")
+)
+
+// BeneficiaryRepositoryImpl handles all database operations related to
+// beneficiaries
+type BeneficiaryRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewBeneficiaryRepository creates a new beneficiary repository
+func NewBeneficiaryRepository(db *PostgresDB) BeneficiaryRepository {
+	return &BeneficiaryRepositoryImpl{db: db}
+}
+
+// Create saves a beneficiary unless its user already has limit of them.
+// The user's account is locked while they are counted, so beneficiaries
+// saved at once cannot go over the limit together.
+func (r *BeneficiaryRepositoryImpl) Create(beneficiary *models.Beneficiary, limit int) error {
+	now := time.Now()
+	return r.db.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`SELECT id FROM accounts WHERE user_id = $1 FOR UPDATE`, beneficiary.UserID); err != nil {
+			return fmt.Errorf("failed to lock account: %w", err)
+		}
+
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM beneficiaries WHERE user_id = $1`, beneficiary.UserID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count beneficiaries: %w", err)
+		}
+		if count >= limit {
+			return ErrBeneficiaryLimit
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO beneficiaries (id, user_id, nickname, account_id, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, account_id) DO NOTHING`,
+			beneficiary.ID, beneficiary.UserID, beneficiary.Nickname, beneficiary.AccountID, now)
+		if err != nil {
+			return fmt.Errorf("failed to create beneficiary: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return ErrBeneficiaryDuplicate
+		}
+
+		beneficiary.CreatedAt = now
+		return nil
+	})
+}
+
+// GetByID retrieves a beneficiary by its ID, or nil when there is none
+func (r *BeneficiaryRepositoryImpl) GetByID(id uuid.UUID) (*models.Beneficiary, error) {
+	query := `SELECT ` + beneficiaryColumns + ` FROM beneficiaries WHERE id = $1`
+
+	beneficiary, err := scanBeneficiary(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get beneficiary: %w", err)
+	}
+
+	return beneficiary, nil
+}
+
+// GetByAccountID retrieves the beneficiary a user saved for an account,
+// or nil when they have not saved it
+func (r *BeneficiaryRepositoryImpl) GetByAccountID(userID, accountID uuid.UUID) (*models.Beneficiary, error) {
+	query := `SELECT ` + beneficiaryColumns + ` FROM beneficiaries WHERE user_id = $1 AND account_id = $2`
+
+	beneficiary, err := scanBeneficiary(r.db.QueryRow(query, userID, accountID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get beneficiary: %w", err)
+	}
+
+	return beneficiary, nil
+}
+
+// ListByUserID retrieves a user's beneficiaries, ordered by nickname
+func (r *BeneficiaryRepositoryImpl) ListByUserID(userID uuid.UUID) ([]models.Beneficiary, error) {
+	query := `
+		SELECT ` + beneficiaryColumns + `
+		FROM beneficiaries
+		WHERE user_id = $1
+		ORDER BY LOWER(nickname), created_at, id`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query beneficiaries: %w", err)
+	}
+	defer rows.Close()
+
+	var beneficiaries []models.Beneficiary
+	for rows.Next() {
+		beneficiary, err := scanBeneficiary(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan beneficiary row: %w", err)
+		}
+		beneficiaries = append(beneficiaries, *beneficiary)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over beneficiary rows: %w", err)
+	}
+
+	return beneficiaries, nil
+}
+
+// Delete deletes one of a user's beneficiaries, reporting whether there
+// was one to delete
+func (r *BeneficiaryRepositoryImpl) Delete(userID, id uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM beneficiaries WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete beneficiary: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// scanBeneficiary reads a row of beneficiaryColumns
+func scanBeneficiary(row rowScanner) (*models.Beneficiary, error) {
+	beneficiary := &models.Beneficiary{}
+	err := row.Scan(&beneficiary.ID, &beneficiary.UserID, &beneficiary.Nickname, &beneficiary.AccountID, &beneficiary.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return beneficiary, nil
+}
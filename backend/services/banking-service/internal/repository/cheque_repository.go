@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// chequeDepositColumns lists the cheque_deposits columns in the order
+// scanChequeDeposit reads them
+const chequeDepositColumns = `id, user_id, account_id, amount, cheque_number, transaction_id, status, hold_until, recorded_by, resolved_by, COALESCE(return_reason, ''), return_transaction_id, return_fee_transaction_id, created_at, updated_at, resolved_at`
+
+// chequeHoldsQuery adds up what cheques still on hold at $2 hold on the
+// account $1
+const chequeHoldsQuery = `SELECT COALESCE(SUM(amount), 0) FROM cheque_deposits WHERE account_id = $1 AND status = 'held' AND hold_until > $2`
+
+// ErrChequeNotOnHold is returned by ChequeRepositoryImpl.Release and
+// Return, when nothing is written, if the cheque's hold has lapsed or it
+// was already released or returned
+var ErrChequeNotOnHold = errors.New("cheque is not on hold")
+
+// ChequeRepositoryImpl handles all database operations related to cheque
+// deposits
+type ChequeRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewChequeRepository creates a new cheque repository
+func NewChequeRepository(db *PostgresDB) ChequeRepository {
+	return &ChequeRepositoryImpl{db: db}
+}
+
+// Create records a cheque deposit: its deposit transaction is added to the
+// balance and the cheque saved, holding its amount, in one database
+// transaction
+func (r *ChequeRepositoryImpl) Create(cheque *models.ChequeDeposit, transaction *models.Transaction) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		if err := creditAccount(tx, transaction, now); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO cheque_deposits (id, user_id, account_id, amount, cheque_number, transaction_id, status, hold_until, recorded_by, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)`,
+			cheque.ID, cheque.UserID, cheque.AccountID, cheque.Amount, cheque.ChequeNumber, transaction.ID,
+			models.ChequeStatusHeld, cheque.HoldUntil, cheque.RecordedBy, now)
+		if err != nil {
+			return fmt.Errorf("failed to create cheque deposit: %w", err)
+		}
+
+		cheque.TransactionID = transaction.ID
+		cheque.Status = models.ChequeStatusHeld
+		cheque.CreatedAt = now
+		cheque.UpdatedAt = now
+		return nil
+	})
+}
+
+// GetByID retrieves a cheque deposit by its ID, or nil when there is none
+func (r *ChequeRepositoryImpl) GetByID(id uuid.UUID) (*models.ChequeDeposit, error) {
+	query := `SELECT ` + chequeDepositColumns + ` FROM cheque_deposits WHERE id = $1`
+
+	cheque, err := scanChequeDeposit(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get cheque deposit: %w", err)
+	}
+
+	return cheque, nil
+}
+
+// List retrieves one page of cheque deposits matching the filter, newest
+// first, and how many match in all. Held and cleared cheques are told
+// apart by whether their hold lapsed by filter.At.
+func (r *ChequeRepositoryImpl) List(filter models.ChequeDepositFilter) ([]models.ChequeDeposit, int, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	switch filter.Status {
+	case "":
+	case models.ChequeStatusHeld:
+		args = append(args, filter.At)
+		conditions = append(conditions, fmt.Sprintf("status = 'held' AND hold_until > $%d", len(args)))
+	case models.ChequeStatusCleared:
+		args = append(args, filter.At)
+		conditions = append(conditions, fmt.Sprintf("status = 'held' AND hold_until <= $%d", len(args)))
+	default:
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count all matches
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM cheque_deposits`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count cheque deposits: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+chequeDepositColumns+`
+		FROM cheque_deposits`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := r.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query cheque deposits: %w", err)
+	}
+	defer rows.Close()
+
+	var cheques []models.ChequeDeposit
+	for rows.Next() {
+		cheque, err := scanChequeDeposit(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan cheque deposit row: %w", err)
+		}
+		cheques = append(cheques, *cheque)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating over cheque deposit rows: %w", err)
+	}
+
+	return cheques, total, nil
+}
+
+// HeldByAccountID returns the amount cheques still on hold at the given
+// time hold on an account
+func (r *ChequeRepositoryImpl) HeldByAccountID(accountID uuid.UUID, at time.Time) (float64, error) {
+	var held float64
+	if err := r.db.QueryRow(chequeHoldsQuery, accountID, at).Scan(&held); err != nil {
+		return 0, fmt.Errorf("failed to sum cheque holds: %w", err)
+	}
+	return held, nil
+}
+
+// Release lifts the hold of a cheque still on hold, making its amount
+// available at once. cheque.ResolvedBy is recorded as the staff member
+// who released it.
+func (r *ChequeRepositoryImpl) Release(cheque *models.ChequeDeposit) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		if err := lockHeldCheque(tx, cheque.ID, now); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`
+			UPDATE cheque_deposits
+			SET status = $1, resolved_by = $2, resolved_at = $3, updated_at = $3
+			WHERE id = $4`, models.ChequeStatusReleased, cheque.ResolvedBy, now, cheque.ID)
+		if err != nil {
+			return fmt.Errorf("failed to release cheque: %w", err)
+		}
+
+		cheque.Status = models.ChequeStatusReleased
+		cheque.ResolvedAt = &now
+		cheque.UpdatedAt = now
+		return nil
+	})
+}
+
+// Return reverses the deposit of a cheque still on hold with reversal, a
+// cheque_return transaction, and charges fee on it when it is not nil, all
+// in one database transaction. Their balances are set from the locked
+// account, and ErrInsufficientBalance is returned when the balance does
+// not cover them. cheque.ReturnReason and cheque.ResolvedBy are recorded.
+func (r *ChequeRepositoryImpl) Return(cheque *models.ChequeDeposit, reversal, fee *models.Transaction) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		if err := lockHeldCheque(tx, cheque.ID, now); err != nil {
+			return err
+		}
+
+		if err := debitAccount(tx, reversal, now); err != nil {
+			return err
+		}
+		var feeID *uuid.UUID
+		if fee != nil {
+			if err := debitAccount(tx, fee, now); err != nil {
+				return err
+			}
+			feeID = &fee.ID
+		}
+
+		_, err := tx.Exec(`
+			UPDATE cheque_deposits
+			SET status = $1, return_reason = $2, return_transaction_id = $3, return_fee_transaction_id = $4, resolved_by = $5, resolved_at = $6, updated_at = $6
+			WHERE id = $7`, models.ChequeStatusReturned, cheque.ReturnReason, reversal.ID, feeID, cheque.ResolvedBy, now, cheque.ID)
+		if err != nil {
+			return fmt.Errorf("failed to return cheque: %w", err)
+		}
+
+		cheque.Status = models.ChequeStatusReturned
+		cheque.ReturnTransactionID = &reversal.ID
+		cheque.ReturnFeeTransactionID = feeID
+		cheque.ResolvedAt = &now
+		cheque.UpdatedAt = now
+		return nil
+	})
+}
+
+// lockHeldCheque locks a cheque deposit with tx, failing with
+// ErrChequeNotOnHold unless it is still on hold at now
+func lockHeldCheque(tx *sql.Tx, id uuid.UUID, now time.Time) error {
+	cheque := models.ChequeDeposit{}
+	err := tx.QueryRow(`SELECT status, hold_until FROM cheque_deposits WHERE id = $1 FOR UPDATE`, id).Scan(&cheque.Status, &cheque.HoldUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("cheque deposit not found")
+		}
+		return fmt.Errorf("failed to lock cheque deposit: %w", err)
+	}
+	if !cheque.OnHold(now) {
+		return ErrChequeNotOnHold
+	}
+	return nil
+}
+
+// scanChequeDeposit reads a row of chequeDepositColumns
+func scanChequeDeposit(row rowScanner) (*models.ChequeDeposit, error) {
+	cheque := &models.ChequeDeposit{}
+	err := row.Scan(
+		&cheque.ID,
+		&cheque.UserID,
+		&cheque.AccountID,
+		&cheque.Amount,
+		&cheque.ChequeNumber,
+		&cheque.TransactionID,
+		&cheque.Status,
+		&cheque.HoldUntil,
+		&cheque.RecordedBy,
+		&cheque.ResolvedBy,
+		&cheque.ReturnReason,
+		&cheque.ReturnTransactionID,
+		&cheque.ReturnFeeTransactionID,
+		&cheque.CreatedAt,
+		&cheque.UpdatedAt,
+		&cheque.ResolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return cheque, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+func TestChequeRepository_ReturnReversesDeposit(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewChequeRepository(db)
+	staffID := uuid.New()
+	cheque := &models.ChequeDeposit{ID: uuid.New(), UserID: uuid.New(), AccountID: uuid.New(), Amount: 300, TransactionID: uuid.New(), Status: models.ChequeStatusHeld, ResolvedBy: &staffID, ReturnReason: "Refer to drawer"}
+	reversal := &models.Transaction{ID: uuid.New(), AccountID: cheque.AccountID, UserID: cheque.UserID, Type: models.TransactionTypeChequeReturn, Amount: 300, Description: "Returned cheque #100234", RelatedTransactionID: &cheque.TransactionID}
+	fee := &models.Transaction{ID: uuid.New(), AccountID: cheque.AccountID, UserID: cheque.UserID, Type: models.TransactionTypeFee, Amount: 15, Description: "Returned cheque fee", RelatedTransactionID: &reversal.ID}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status, hold_until FROM cheque_deposits WHERE id = $1 FOR UPDATE")).
+		WithArgs(cheque.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "hold_until"}).AddRow("held", time.Now().Add(time.Hour)))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE")).
+		WithArgs(cheque.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(1300.0))
+	expectChained(mock, cheque.AccountID, 5, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(reversal.ID, cheque.AccountID, cheque.UserID, models.TransactionTypeChequeReturn, 300.0, 1300.0, 1000.0, "Returned cheque #100234", sqlmock.AnyArg(), &cheque.TransactionID, int64(6), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(1000.0, sqlmock.AnyArg(), cheque.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE")).
+		WithArgs(cheque.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(1000.0))
+	expectChained(mock, cheque.AccountID, 6, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(fee.ID, cheque.AccountID, cheque.UserID, models.TransactionTypeFee, 15.0, 1000.0, 985.0, "Returned cheque fee", sqlmock.AnyArg(), &reversal.ID, int64(7), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(985.0, sqlmock.AnyArg(), cheque.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE cheque_deposits")).
+		WithArgs(models.ChequeStatusReturned, "Refer to drawer", reversal.ID, &fee.ID, &staffID, sqlmock.AnyArg(), cheque.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Return(cheque, reversal, fee); err != nil {
+		t.Fatalf("Return returned error: %v", err)
+	}
+	if cheque.Status != models.ChequeStatusReturned || *cheque.ReturnTransactionID != reversal.ID || *cheque.ReturnFeeTransactionID != fee.ID || cheque.ResolvedAt == nil {
+		t.Errorf("Expected the cheque to be returned by its reversal and fee, got %+v", cheque)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestChequeRepository_ReleaseNeedsHold(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		status    string
+		holdUntil time.Time
+		wantErr   error
+	}{
+		{name: "on hold", status: "held", holdUntil: now.Add(time.Minute)},
+		{name: "hold lapsed", status: "held", holdUntil: now.Add(-time.Second), wantErr: ErrChequeNotOnHold},
+		{name: "already returned", status: "returned", holdUntil: now.Add(time.Minute), wantErr: ErrChequeNotOnHold},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewChequeRepository(db)
+			staffID := uuid.New()
+			cheque := &models.ChequeDeposit{ID: uuid.New(), Status: models.ChequeStatusHeld, HoldUntil: tt.holdUntil, ResolvedBy: &staffID}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT status, hold_until FROM cheque_deposits WHERE id = $1 FOR UPDATE")).
+				WithArgs(cheque.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"status", "hold_until"}).AddRow(tt.status, tt.holdUntil))
+			if tt.wantErr == nil {
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE cheque_deposits")).
+					WithArgs(models.ChequeStatusReleased, &staffID, sqlmock.AnyArg(), cheque.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err := repo.Release(cheque)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && cheque.Status != models.ChequeStatusReleased {
+				t.Errorf("Expected the cheque to be released, got %s", cheque.Status)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
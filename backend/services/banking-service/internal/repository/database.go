@@ -4,7 +4,11 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
+	"time"
+
+	sharedconfig "microbank/pkg/config"
+	"microbank/pkg/events"
+	"microbank/pkg/pgtime"
 
 	_ "github.com/lib/pq"
 )
@@ -15,21 +19,9 @@ type PostgresDB struct {
 }
 
 // NewPostgresDB creates a new PostgreSQL database connection
-func NewPostgresDB() (*PostgresDB, error) {
-	// Get database connection parameters from environment
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "postgres")
-	password := getEnv("DB_PASSWORD", "password")
-	dbname := getEnv("DB_NAME", "banking_service")
-	sslmode := getEnv("DB_SSLMODE", "disable")
-
-	// Build connection string
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		host, port, user, password, dbname, sslmode)
-
+func NewPostgresDB(config sharedconfig.Database) (*PostgresDB, error) {
 	// Open database connection
-	db, err := sql.Open("postgres", connStr)
+	db, err := sql.Open("postgres", config.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
@@ -46,49 +38,465 @@ func NewPostgresDB() (*PostgresDB, error) {
 	log.Println("Successfully connected to PostgreSQL database")
 
 	// Initialize database schema
-	if err := initSchema(db); err != nil {
+	if err := initSchema(db, config.LegacyTimeZone); err != nil {
 		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
 	}
 
 	return &PostgresDB{db}, nil
 }
 
-// initSchema creates the necessary database tables if they don't exist
-func initSchema(db *sql.DB) error {
+// withTx runs fn inside a transaction, committing when it returns nil and
+// rolling back otherwise
+func (db *PostgresDB) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// initSchema creates the necessary database tables if they don't exist,
+// and migrates timestamp columns written in legacyZone to timestamptz
+func initSchema(db *sql.DB, legacyZone string) error {
 	// Create accounts table
 	createAccountsTable := `
 	CREATE TABLE IF NOT EXISTS accounts (
 		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 		user_id UUID UNIQUE NOT NULL,
 		balance DECIMAL(15,2) DEFAULT 0.00,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
 	);`
 
-	// Create transactions table
-	createTransactionsTable := `
-	CREATE TABLE IF NOT EXISTS transactions (
+	// Track accounts whose owner was deleted in the client service
+	alterAccountsOwner := `
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS owner_deleted_at TIMESTAMPTZ;`
+
+	// Freeze accounts of users blacklisted in the client service
+	alterAccountsFrozen := `
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS frozen_at TIMESTAMPTZ;`
+
+	// Keep the head of each account's transaction integrity chain: how
+	// many transactions it holds and the last one's hash
+	alterAccountsChain := `
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS chain_seq BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS integrity_hash CHAR(64);`
+
+	// Count each account's balance changes, so clients can tell whether a
+	// balance they read is at least as new as one they wrote
+	alterAccountsVersion := `
+	ALTER TABLE accounts ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0;`
+
+	// Allow fee, round-up, refund, transfer and bonus transactions, linked
+	// to the transaction they were made on, in tables created before they
+	// existed
+	alterTransactionsFees := `
+	ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+	ALTER TABLE transactions ADD CONSTRAINT transactions_type_check CHECK (type IN ('deposit', 'withdrawal', 'fee', 'round_up', 'refund', 'transfer_out', 'transfer_in', 'external_transfer', 'cheque_return', 'bonus'));
+	ALTER TABLE transactions ADD COLUMN IF NOT EXISTS related_transaction_id UUID;`
+
+	// Chain each transaction to the one before it on its account, in
+	// tables created before transactions were chained. Transactions made
+	// before then have neither column set.
+	alterTransactionsChain := `
+	ALTER TABLE transactions ADD COLUMN IF NOT EXISTS chain_seq BIGINT;
+	ALTER TABLE transactions ADD COLUMN IF NOT EXISTS integrity_hash CHAR(64);`
+
+	// Keep the original of descriptions that were cut short, in tables
+	// created before descriptions were cleaned up
+	alterTransactionsRawDescription := `
+	ALTER TABLE transactions ADD COLUMN IF NOT EXISTS raw_description TEXT;`
+
+	// Create savings goals table. Goals earmark part of an account's balance
+	// without moving it.
+	createSavingsGoalsTable := `
+	CREATE TABLE IF NOT EXISTS savings_goals (
 		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-		account_id UUID REFERENCES accounts(id) ON DELETE CASCADE,
 		user_id UUID NOT NULL,
-		type VARCHAR(20) NOT NULL CHECK (type IN ('deposit', 'withdrawal')),
+		account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+		name VARCHAR(100) NOT NULL,
+		target_amount DECIMAL(15,2) NOT NULL CHECK (target_amount > 0),
+		target_date TIMESTAMPTZ,
+		allocated_amount DECIMAL(15,2) NOT NULL DEFAULT 0.00 CHECK (allocated_amount >= 0),
+		strict BOOLEAN NOT NULL DEFAULT FALSE,
+		completed_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_savings_goals_account_id ON savings_goals(account_id);`
+
+	// Create round-up settings table. An account has a row while its
+	// withdrawals are rounded up into the goal; deleting the goal turns
+	// round-ups off.
+	createRoundUpSettingsTable := `
+	CREATE TABLE IF NOT EXISTS round_up_settings (
+		account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+		goal_id UUID NOT NULL REFERENCES savings_goals(id) ON DELETE CASCADE,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create beneficiaries table. A user saves each account at most once,
+	// and transactions do not refer to beneficiaries, so deleting one
+	// leaves past transfers as they were.
+	createBeneficiariesTable := `
+	CREATE TABLE IF NOT EXISTS beneficiaries (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL,
+		nickname VARCHAR(50) NOT NULL,
+		account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, account_id)
+	);`
+
+	// Create external transfers table. A pending transfer holds its amount
+	// on the account; a completed one points to the external_transfer
+	// transaction that took it from the balance.
+	createExternalTransfersTable := `
+	CREATE TABLE IF NOT EXISTS external_transfers (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL,
+		account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
 		amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
-		balance_before DECIMAL(15,2) NOT NULL,
-		balance_after DECIMAL(15,2) NOT NULL,
-		description TEXT,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		routing_number CHAR(9) NOT NULL,
+		account_number VARCHAR(17) NOT NULL,
+		account_holder_name VARCHAR(100) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'failed')),
+		failure_reason VARCHAR(255),
+		transaction_id UUID,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		settled_at TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS idx_external_transfers_user_id_created_at ON external_transfers(user_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_external_transfers_status_created_at ON external_transfers(status, created_at);
+	CREATE INDEX IF NOT EXISTS idx_external_transfers_pending_account_id ON external_transfers(account_id) WHERE status = 'pending';`
+
+	// Create cheque deposits table. A held cheque holds its amount on the
+	// account until hold_until; a returned one points to the cheque_return
+	// transaction that reversed its deposit.
+	createChequeDepositsTable := `
+	CREATE TABLE IF NOT EXISTS cheque_deposits (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL,
+		account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+		amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+		cheque_number VARCHAR(20) NOT NULL,
+		transaction_id UUID NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'held' CHECK (status IN ('held', 'released', 'returned')),
+		hold_until TIMESTAMPTZ NOT NULL,
+		recorded_by UUID NOT NULL,
+		resolved_by UUID,
+		return_reason VARCHAR(255),
+		return_transaction_id UUID,
+		return_fee_transaction_id UUID,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		resolved_at TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS idx_cheque_deposits_user_id_created_at ON cheque_deposits(user_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_cheque_deposits_held_account_id ON cheque_deposits(account_id, hold_until) WHERE status = 'held';`
+
+	// Create transaction PINs table. Only bcrypt hashes are stored, and
+	// pin_hash is NULL once staff reset a PIN until the user sets another.
+	createTransactionPINsTable := `
+	CREATE TABLE IF NOT EXISTS transaction_pins (
+		account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL UNIQUE,
+		pin_hash VARCHAR(72),
+		failed_attempts INTEGER NOT NULL DEFAULT 0,
+		locked_until TIMESTAMPTZ,
+		reset_required BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
 	);`
 
-	// Create indexes for better performance
+	// Create confirmation thresholds and the withdrawals and transfers
+	// waiting for confirmation. Only SHA-256 hashes of confirmation tokens
+	// are stored, and used_at is set once, when the token is used.
+	createTransactionConfirmationsTables := `
+	CREATE TABLE IF NOT EXISTS confirmation_thresholds (
+		user_id UUID PRIMARY KEY,
+		threshold DECIMAL(15,2) NOT NULL CHECK (threshold > 0),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS transaction_confirmations (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL,
+		kind VARCHAR(20) NOT NULL CHECK (kind IN ('withdrawal', 'transfer', 'external_transfer')),
+		amount DECIMAL(15,2) NOT NULL,
+		request JSONB NOT NULL,
+		token_hash VARCHAR(64) NOT NULL UNIQUE,
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_transaction_confirmations_user_id ON transaction_confirmations(user_id, created_at DESC);`
+
+	// Create account notification settings, the notifications of account
+	// activity and how far the dispatcher has read the ledger. A
+	// transaction is notified at most once per event and channel, and the
+	// cursor is a single row every replica moves forward together.
+	createNotificationsTables := `
+	CREATE TABLE IF NOT EXISTS notification_settings (
+		account_id UUID PRIMARY KEY REFERENCES accounts(id),
+		user_id UUID NOT NULL,
+		low_balance_threshold DECIMAL(15,2) CHECK (low_balance_threshold > 0),
+		webhook_url VARCHAR(500) NOT NULL DEFAULT '',
+		webhook_secret VARCHAR(64) NOT NULL DEFAULT '',
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS notification_deliveries (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL,
+		account_id UUID NOT NULL,
+		transaction_id UUID NOT NULL,
+		event_type VARCHAR(30) NOT NULL,
+		channel VARCHAR(10) NOT NULL CHECK (channel IN ('email', 'sms', 'webhook')),
+		destination VARCHAR(500) NOT NULL,
+		data JSONB NOT NULL,
+		status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'sent', 'failed', 'suppressed')),
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		delivered_at TIMESTAMPTZ,
+		UNIQUE (transaction_id, event_type, channel)
+	);
+	CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due ON notification_deliveries(next_attempt_at) WHERE status = 'pending';
+	CREATE INDEX IF NOT EXISTS idx_notification_deliveries_user_id ON notification_deliveries(user_id, created_at);
+	CREATE TABLE IF NOT EXISTS notification_cursor (
+		id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+		last_created_at TIMESTAMPTZ NOT NULL,
+		last_transaction_id UUID NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create promotions and their redemptions. A deposit earns each
+	// promotion's bonus at most once, and a user earns a one-time
+	// promotion's bonus at most once, however many deposits race for it.
+	createPromotionsTables := `
+	CREATE TABLE IF NOT EXISTS promotions (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		name VARCHAR(100) NOT NULL,
+		description VARCHAR(255) NOT NULL DEFAULT '',
+		min_deposit DECIMAL(15,2) NOT NULL CHECK (min_deposit > 0),
+		bonus_amount DECIMAL(15,2) NOT NULL CHECK (bonus_amount > 0),
+		starts_at TIMESTAMPTZ NOT NULL,
+		ends_at TIMESTAMPTZ NOT NULL,
+		once_per_user BOOLEAN NOT NULL DEFAULT TRUE,
+		created_by UUID NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		CHECK (ends_at > starts_at)
+	);
+	CREATE INDEX IF NOT EXISTS idx_promotions_window ON promotions(starts_at, ends_at);
+	CREATE TABLE IF NOT EXISTS promotion_redemptions (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		promotion_id UUID NOT NULL REFERENCES promotions(id),
+		user_id UUID NOT NULL,
+		account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+		deposit_transaction_id UUID NOT NULL,
+		bonus_transaction_id UUID NOT NULL,
+		amount DECIMAL(15,2) NOT NULL,
+		once_per_user BOOLEAN NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (promotion_id, deposit_transaction_id)
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_promotion_redemptions_once ON promotion_redemptions(promotion_id, user_id) WHERE once_per_user;
+	CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_user_id ON promotion_redemptions(user_id, created_at DESC);`
+
+	// Create account members and invitations tables. Every account has its
+	// holder as an owner, added for accounts opened before accounts could
+	// be shared; joint accounts have the members who accepted an
+	// invitation too. A user has at most one pending invitation to an
+	// account.
+	createAccountMembersTables := `
+	CREATE TABLE IF NOT EXISTS account_members (
+		account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL,
+		role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'member')),
+		spending_limit DECIMAL(15,2) CHECK (spending_limit > 0),
+		added_by UUID,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (account_id, user_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_account_members_user_id ON account_members(user_id);
+	INSERT INTO account_members (account_id, user_id, role, created_at, updated_at)
+	SELECT a.id, a.user_id, 'owner', a.created_at, a.created_at FROM accounts a
+	WHERE NOT EXISTS (SELECT 1 FROM account_members m WHERE m.account_id = a.id);
+	CREATE TABLE IF NOT EXISTS account_invitations (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL,
+		email VARCHAR(255) NOT NULL,
+		role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'member')),
+		status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'expired')),
+		invited_by UUID NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		accepted_at TIMESTAMPTZ
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_account_invitations_pending ON account_invitations(account_id, user_id) WHERE status = 'pending';
+	CREATE INDEX IF NOT EXISTS idx_account_invitations_user_id ON account_invitations(user_id, created_at DESC);`
+
+	// Create disputes table. A transaction has at most one dispute that
+	// was not rejected, so it is never refunded twice.
+	createDisputesTable := `
+	CREATE TABLE IF NOT EXISTS disputes (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		transaction_id UUID NOT NULL,
+		account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL,
+		reason_category VARCHAR(30) NOT NULL,
+		description TEXT NOT NULL,
+		amount DECIMAL(15,2) NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'investigating', 'resolved_refunded', 'resolved_rejected')),
+		assigned_to UUID,
+		resolution_note TEXT,
+		refund_transaction_id UUID,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		resolved_at TIMESTAMPTZ
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_disputes_transaction_id_active ON disputes(transaction_id) WHERE status <> 'resolved_rejected';
+	CREATE INDEX IF NOT EXISTS idx_disputes_user_id ON disputes(user_id);
+	CREATE INDEX IF NOT EXISTS idx_disputes_status_created_at ON disputes(status, created_at);`
+
+	// Create maintenance mode table. Its single row is shared by every
+	// replica; no row means money movement was never paused.
+	createMaintenanceModeTable := `
+	CREATE TABLE IF NOT EXISTS maintenance_mode (
+		id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+		enabled BOOLEAN NOT NULL DEFAULT FALSE,
+		message TEXT,
+		ends_at TIMESTAMPTZ,
+		updated_by UUID,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create deposit batch and job tables. Workers claim pending jobs, and
+	// jobs whose lease ran out, with FOR UPDATE SKIP LOCKED; a job is
+	// marked completed in the transaction that makes its deposit.
+	createDepositJobsTables := `
+	CREATE TABLE IF NOT EXISTS deposit_batches (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		reference VARCHAR(100) UNIQUE,
+		item_count INTEGER NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS deposit_jobs (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		batch_id UUID NOT NULL REFERENCES deposit_batches(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		user_id UUID NOT NULL,
+		amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+		description TEXT,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		next_attempt_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		locked_until TIMESTAMPTZ,
+		transaction_id UUID,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		completed_at TIMESTAMPTZ,
+		UNIQUE (batch_id, position)
+	);
+	CREATE INDEX IF NOT EXISTS idx_deposit_jobs_status_next_attempt_at ON deposit_jobs(status, next_attempt_at);`
+
+	// Create suspicious activity report tables. Each UTC day has at most
+	// one report, written with its findings in one transaction, so a day
+	// is never reported twice. Findings keep no reference to their account
+	// so they outlive it.
+	createSuspiciousActivityTables := `
+	CREATE TABLE IF NOT EXISTS suspicious_activity_reports (
+		report_date DATE PRIMARY KEY,
+		rules TEXT[] NOT NULL,
+		finding_count INTEGER NOT NULL,
+		generated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS suspicious_activity_findings (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		report_date DATE NOT NULL REFERENCES suspicious_activity_reports(report_date) ON DELETE CASCADE,
+		rule VARCHAR(30) NOT NULL,
+		account_id UUID NOT NULL,
+		user_id UUID NOT NULL,
+		transaction_count INTEGER NOT NULL,
+		amount DECIMAL(15,2) NOT NULL,
+		baseline DECIMAL(15,2),
+		description TEXT NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'reviewed')),
+		reviewed_by UUID,
+		review_note TEXT,
+		reviewed_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (report_date, rule, account_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_suspicious_activity_findings_status_report_date ON suspicious_activity_findings(status, report_date);`
+
+	// Create reconciliation tables. A run is started once per UTC day and
+	// checks accounts in ID order, keeping the last one checked so an
+	// interrupted run carries on from there.
+	createReconciliationTables := `
+	CREATE TABLE IF NOT EXISTS reconciliation_runs (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		run_date DATE UNIQUE NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'completed')),
+		started_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		finished_at TIMESTAMPTZ,
+		last_account_id UUID,
+		accounts_checked INTEGER NOT NULL DEFAULT 0,
+		mismatches_found INTEGER NOT NULL DEFAULT 0,
+		total_balance DECIMAL(15,2),
+		total_expected DECIMAL(15,2)
+	);
+	CREATE TABLE IF NOT EXISTS reconciliation_mismatches (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		run_id UUID NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+		account_id UUID,
+		user_id UUID,
+		stored_balance DECIMAL(15,2) NOT NULL,
+		expected_balance DECIMAL(15,2) NOT NULL,
+		difference DECIMAL(15,2) NOT NULL,
+		transaction_count INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_reconciliation_mismatches_run_id ON reconciliation_mismatches(run_id, account_id);`
+
+	// Create indexes for better performance. The user and account listings
+	// and the export read transactions in (created_at, id) order, so their
+	// indexes end in those columns; they replace the single-column indexes
+	// created before, which made large histories sort on every page. The
+	// listings and the export may also be sorted by (amount, id).
 	createIndexes := `
 	CREATE INDEX IF NOT EXISTS idx_accounts_user_id ON accounts(user_id);
-	CREATE INDEX IF NOT EXISTS idx_transactions_account_id ON transactions(account_id);
-	CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions(user_id);
-	CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions(created_at);
-	CREATE INDEX IF NOT EXISTS idx_transactions_type ON transactions(type);`
+	CREATE INDEX IF NOT EXISTS idx_transactions_account_id_created_at_id ON transactions(account_id, created_at DESC, id DESC);
+	CREATE INDEX IF NOT EXISTS idx_transactions_user_id_created_at_id ON transactions(user_id, created_at DESC, id DESC);
+	CREATE INDEX IF NOT EXISTS idx_transactions_created_at_id ON transactions(created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_transactions_user_id_amount_id ON transactions(user_id, amount DESC, id DESC);
+	CREATE INDEX IF NOT EXISTS idx_transactions_account_id_amount_id ON transactions(account_id, amount DESC, id DESC);
+	CREATE INDEX IF NOT EXISTS idx_transactions_amount_id ON transactions(amount, id);
+	DROP INDEX IF EXISTS idx_transactions_account_id;
+	DROP INDEX IF EXISTS idx_transactions_user_id;
+	DROP INDEX IF EXISTS idx_transactions_created_at;
+	CREATE INDEX IF NOT EXISTS idx_transactions_type ON transactions(type);
+	CREATE INDEX IF NOT EXISTS idx_transactions_account_id_type_created_at ON transactions(account_id, type, created_at);
+	CREATE INDEX IF NOT EXISTS idx_transactions_account_id_chain_seq ON transactions(account_id, chain_seq);`
 
 	// Execute schema creation
-	queries := []string{createAccountsTable, createTransactionsTable, createIndexes}
+	queries := []string{createAccountsTable, alterAccountsOwner, alterAccountsFrozen, alterAccountsChain, alterAccountsVersion, createTransactionsTable, alterTransactionsFees, alterTransactionsChain, alterTransactionsRawDescription, createSavingsGoalsTable, createRoundUpSettingsTable, createBeneficiariesTable, createExternalTransfersTable, createChequeDepositsTable, createTransactionPINsTable, createTransactionConfirmationsTables, createNotificationsTables, createPromotionsTables, createAccountMembersTables, createDisputesTable, createMaintenanceModeTable, createDepositJobsTables, createSuspiciousActivityTables, createReconciliationTables, events.OutboxSchema}
 	
 	for _, query := range queries {
 		if _, err := db.Exec(query); err != nil {
@@ -96,14 +504,30 @@ func initSchema(db *sql.DB) error {
 		}
 	}
 
-	log.Println("Database schema initialized successfully")
-	return nil
-}
+	// Partition transactions tables created before they were partitioned
+	// or by timestamp without time zone, convert the other timestamp
+	// columns, and make sure this month's transactions have a partition to
+	// go in
+	if err := partitionTransactions(db, legacyZone); err != nil {
+		return err
+	}
+	converted, err := pgtime.ConvertColumns(db, legacyZone, "public", "archive")
+	if err != nil {
+		return err
+	}
+	if len(converted) > 0 {
+		log.Printf("Converted timestamp columns written in %s to timestamptz: %v", legacyZone, converted)
+	}
+	if _, err := NewTransactionPartitionRepository(&PostgresDB{db}).EnsurePartitions(time.Now()); err != nil {
+		return err
+	}
 
-// getEnv gets an environment variable with a fallback default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	for _, query := range []string{createIndexes, createArchiveTables} {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to execute schema query: %w", err)
+		}
 	}
-	return defaultValue
+
+	log.Println("Database schema initialized successfully")
+	return nil
 }
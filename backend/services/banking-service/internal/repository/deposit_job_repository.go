@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// DepositJobRepositoryImpl handles all database operations related to
+// deposits queued in batches
+type DepositJobRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewDepositJobRepository creates a new deposit job repository
+func NewDepositJobRepository(db *PostgresDB) DepositJobRepository {
+	return &DepositJobRepositoryImpl{db: db}
+}
+
+// CreateBatch queues a batch and its jobs and reports whether it was
+// created. It is not when another batch already has its reference.
+func (r *DepositJobRepositoryImpl) CreateBatch(batch *models.DepositBatch, jobs []models.DepositJob) (bool, error) {
+	var reference *string
+	if batch.Reference != "" {
+		reference = &batch.Reference
+	}
+
+	created := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		result, err := tx.Exec(`
+			INSERT INTO deposit_batches (id, reference, item_count, created_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (reference) DO NOTHING`, batch.ID, reference, len(jobs), now)
+		if err != nil {
+			return fmt.Errorf("failed to create deposit batch: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		stmt, err := tx.Prepare(`
+			INSERT INTO deposit_jobs (id, batch_id, position, user_id, amount, description, status, next_attempt_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8, $8)`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare deposit job insert: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, job := range jobs {
+			if _, err := stmt.Exec(job.ID, batch.ID, job.Position, job.UserID, job.Amount, job.Description, models.DepositJobStatusPending, now); err != nil {
+				return fmt.Errorf("failed to create deposit job: %w", err)
+			}
+		}
+
+		batch.ItemCount = len(jobs)
+		batch.CreatedAt = now
+		created = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// GetBatchByReference retrieves the batch submitted with a reference, or
+// nil if there is none
+func (r *DepositJobRepositoryImpl) GetBatchByReference(reference string) (*models.DepositBatch, error) {
+	query := `SELECT id, COALESCE(reference, ''), item_count, created_at FROM deposit_batches WHERE reference = $1`
+
+	batch := &models.DepositBatch{}
+	err := r.db.QueryRow(query, reference).Scan(&batch.ID, &batch.Reference, &batch.ItemCount, &batch.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get deposit batch: %w", err)
+	}
+
+	return batch, nil
+}
+
+// GetBatchProgress counts the jobs of a batch by status and lists those
+// that failed in the order they were submitted. It returns nil if there is
+// no such batch.
+func (r *DepositJobRepositoryImpl) GetBatchProgress(id uuid.UUID) (*models.DepositBatchProgress, error) {
+	progress := &models.DepositBatchProgress{Failures: []models.DepositJobFailure{}}
+	err := r.db.QueryRow(`SELECT id, COALESCE(reference, ''), item_count, created_at FROM deposit_batches WHERE id = $1`, id).
+		Scan(&progress.ID, &progress.Reference, &progress.ItemCount, &progress.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get deposit batch: %w", err)
+	}
+
+	rows, err := r.db.Query(`SELECT status, COUNT(*) FROM deposit_jobs WHERE batch_id = $1 GROUP BY status`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count deposit jobs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status models.DepositJobStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan deposit job count: %w", err)
+		}
+		switch status {
+		case models.DepositJobStatusPending:
+			progress.Pending = count
+		case models.DepositJobStatusProcessing:
+			progress.Processing = count
+		case models.DepositJobStatusCompleted:
+			progress.Completed = count
+		case models.DepositJobStatusFailed:
+			progress.Failed = count
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over deposit job counts: %w", err)
+	}
+
+	if progress.Failed > 0 {
+		failures, err := r.db.Query(`
+			SELECT position, user_id, amount, COALESCE(last_error, ''), attempts
+			FROM deposit_jobs
+			WHERE batch_id = $1 AND status = $2
+			ORDER BY position`, id, models.DepositJobStatusFailed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query failed deposit jobs: %w", err)
+		}
+		defer failures.Close()
+
+		for failures.Next() {
+			var failure models.DepositJobFailure
+			if err := failures.Scan(&failure.Position, &failure.UserID, &failure.Amount, &failure.Error, &failure.Attempts); err != nil {
+				return nil, fmt.Errorf("failed to scan failed deposit job row: %w", err)
+			}
+			progress.Failures = append(progress.Failures, failure)
+		}
+		if err = failures.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating over failed deposit job rows: %w", err)
+		}
+	}
+
+	progress.Done = progress.Completed+progress.Failed == progress.ItemCount
+	return progress, nil
+}
+
+// ClaimNext claims the oldest job that is due for lease and counts the
+// attempt, or returns nil if no job is due. Jobs whose lease ran out, say
+// because their worker crashed, are due again. Rows locked by other
+// workers are skipped, so no two workers claim the same job.
+func (r *DepositJobRepositoryImpl) ClaimNext(lease time.Duration) (*models.DepositJob, error) {
+	query := `
+		UPDATE deposit_jobs
+		SET status = $1, attempts = attempts + 1, locked_until = $2, updated_at = $3
+		WHERE id = (
+			SELECT id FROM deposit_jobs
+			WHERE (status = $4 AND next_attempt_at <= $3) OR (status = $1 AND locked_until < $3)
+			ORDER BY created_at, position
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, batch_id, position, user_id, amount, COALESCE(description, ''), status, attempts, COALESCE(last_error, ''), transaction_id`
+
+	now := time.Now()
+	job := &models.DepositJob{}
+	err := r.db.QueryRow(query, models.DepositJobStatusProcessing, now.Add(lease), now, models.DepositJobStatusPending).Scan(
+		&job.ID,
+		&job.BatchID,
+		&job.Position,
+		&job.UserID,
+		&job.Amount,
+		&job.Description,
+		&job.Status,
+		&job.Attempts,
+		&job.LastError,
+		&job.TransactionID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim deposit job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Complete makes the deposit of a job and marks the job completed in one
+// database transaction, with the transaction's balances set from the
+// locked account. It reports whether the deposit was made: it is not when
+// the job is already completed or failed, so a job claimed again after its
+// lease ran out is never deposited twice.
+func (r *DepositJobRepositoryImpl) Complete(jobID uuid.UUID, transaction *models.Transaction) (bool, error) {
+	completed := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		var status models.DepositJobStatus
+		if err := tx.QueryRow(`SELECT status FROM deposit_jobs WHERE id = $1 FOR UPDATE`, jobID).Scan(&status); err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("deposit job not found")
+			}
+			return fmt.Errorf("failed to lock deposit job: %w", err)
+		}
+		if status == models.DepositJobStatusCompleted || status == models.DepositJobStatusFailed {
+			return nil
+		}
+
+		now := time.Now()
+		if err := creditAccount(tx, transaction, now); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`
+			UPDATE deposit_jobs
+			SET status = $1, transaction_id = $2, last_error = NULL, locked_until = NULL, completed_at = $3, updated_at = $3
+			WHERE id = $4`, models.DepositJobStatusCompleted, transaction.ID, now, jobID)
+		if err != nil {
+			return fmt.Errorf("failed to complete deposit job: %w", err)
+		}
+
+		completed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return completed, nil
+}
+
+// Retry puts a claimed job back in the queue, due again at next, noting
+// why its attempt failed
+func (r *DepositJobRepositoryImpl) Retry(jobID uuid.UUID, reason string, next time.Time) error {
+	query := `
+		UPDATE deposit_jobs
+		SET status = $1, last_error = $2, next_attempt_at = $3, locked_until = NULL, updated_at = $4
+		WHERE id = $5 AND status = $6`
+
+	_, err := r.db.Exec(query, models.DepositJobStatusPending, reason, next, time.Now(), jobID, models.DepositJobStatusProcessing)
+	if err != nil {
+		return fmt.Errorf("failed to retry deposit job: %w", err)
+	}
+
+	return nil
+}
+
+// Fail marks a job that was not completed as failed, noting why
+func (r *DepositJobRepositoryImpl) Fail(jobID uuid.UUID, reason string) error {
+	query := `
+		UPDATE deposit_jobs
+		SET status = $1, last_error = $2, locked_until = NULL, completed_at = $3, updated_at = $3
+		WHERE id = $4 AND status <> $5`
+
+	_, err := r.db.Exec(query, models.DepositJobStatusFailed, reason, time.Now(), jobID, models.DepositJobStatusCompleted)
+	if err != nil {
+		return fmt.Errorf("failed to fail deposit job: %w", err)
+	}
+
+	return nil
+}
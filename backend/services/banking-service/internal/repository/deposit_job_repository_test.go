@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+func TestDepositJobRepository_ClaimNextSkipsLockedJobs(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewDepositJobRepository(db)
+	jobID, batchID, userID := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE SKIP LOCKED")).
+		WithArgs(models.DepositJobStatusProcessing, sqlmock.AnyArg(), sqlmock.AnyArg(), models.DepositJobStatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "batch_id", "position", "user_id", "amount", "description", "status", "attempts", "last_error", "transaction_id"}).
+			AddRow(jobID, batchID, 3, userID, 25.0, "Payroll", "processing", 2, "connection refused", nil))
+
+	job, err := repo.ClaimNext(time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimNext returned error: %v", err)
+	}
+	if job == nil || job.ID != jobID || job.Position != 3 || job.Attempts != 2 || job.Status != models.DepositJobStatusProcessing {
+		t.Errorf("Expected the second attempt at job %s, got %+v", jobID, job)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDepositJobRepository_CompleteCreditsAccount(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewDepositJobRepository(db)
+	jobID := uuid.New()
+	transaction := &models.Transaction{ID: uuid.New(), AccountID: uuid.New(), UserID: uuid.New(), Type: models.TransactionTypeDeposit, Amount: 25}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status FROM deposit_jobs WHERE id = $1 FOR UPDATE")).
+		WithArgs(jobID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("processing"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE")).
+		WithArgs(transaction.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(100.1))
+	expectChained(mock, transaction.AccountID, 0, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(transaction.ID, transaction.AccountID, transaction.UserID, models.TransactionTypeDeposit, 25.0, 100.1, 125.1, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), int64(1), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(125.1, sqlmock.AnyArg(), transaction.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE deposit_jobs")).
+		WithArgs(models.DepositJobStatusCompleted, transaction.ID, sqlmock.AnyArg(), jobID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	completed, err := repo.Complete(jobID, transaction)
+	if err != nil {
+		t.Fatalf("Complete returned error: %v", err)
+	}
+	if !completed || transaction.BalanceBefore != 100.1 || transaction.BalanceAfter != 125.1 {
+		t.Errorf("Expected the deposit to take the balance from 100.10 to 125.10, got %+v", transaction)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDepositJobRepository_CompleteSkipsCompletedJob(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewDepositJobRepository(db)
+	jobID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status FROM deposit_jobs WHERE id = $1 FOR UPDATE")).
+		WithArgs(jobID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("completed"))
+	mock.ExpectCommit()
+
+	completed, err := repo.Complete(jobID, &models.Transaction{ID: uuid.New(), Amount: 25})
+	if err != nil {
+		t.Fatalf("Complete returned error: %v", err)
+	}
+	if completed {
+		t.Error("Expected a completed job not to be deposited again")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
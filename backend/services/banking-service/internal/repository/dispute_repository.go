@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/events"
+)
+
+// disputeColumns lists the disputes columns in the order scanDispute reads
+// them
+const disputeColumns = `id, transaction_id, account_id, user_id, reason_category, description, amount, status, assigned_to, COALESCE(resolution_note, ''), refund_transaction_id, created_at, updated_at, resolved_at`
+
+// DisputeRepositoryImpl handles all database operations related to
+// transaction disputes
+type DisputeRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewDisputeRepository creates a new dispute repository
+func NewDisputeRepository(db *PostgresDB) DisputeRepository {
+	return &DisputeRepositoryImpl{db: db}
+}
+
+// Create files a dispute and reports whether it was created. It is not
+// when the transaction already has a dispute that was not rejected.
+func (r *DisputeRepositoryImpl) Create(dispute *models.Dispute, change models.DisputeChange) (bool, error) {
+	query := `
+		INSERT INTO disputes (id, transaction_id, account_id, user_id, reason_category, description, amount, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (transaction_id) WHERE status <> 'resolved_rejected' DO NOTHING`
+
+	created := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		result, err := tx.Exec(query, dispute.ID, dispute.TransactionID, dispute.AccountID, dispute.UserID, dispute.ReasonCategory, dispute.Description, dispute.Amount, models.DisputeStatusOpen, now)
+		if err != nil {
+			return fmt.Errorf("failed to create dispute: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		dispute.Status = models.DisputeStatusOpen
+		dispute.CreatedAt = now
+		dispute.UpdatedAt = now
+		created = true
+		return writeDisputeStatusChanged(tx, dispute, "", change)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a dispute by its ID
+func (r *DisputeRepositoryImpl) GetByID(id uuid.UUID) (*models.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes WHERE id = $1`
+
+	dispute, err := scanDispute(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("dispute not found")
+		}
+		return nil, fmt.Errorf("failed to get dispute: %w", err)
+	}
+
+	return dispute, nil
+}
+
+// GetLatestByTransactionID retrieves the newest dispute of a transaction,
+// or nil when it has never been disputed
+func (r *DisputeRepositoryImpl) GetLatestByTransactionID(transactionID uuid.UUID) (*models.Dispute, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes
+		WHERE transaction_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	dispute, err := scanDispute(r.db.QueryRow(query, transactionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get dispute: %w", err)
+	}
+
+	return dispute, nil
+}
+
+// ListByUserID retrieves a user's disputes, newest first
+func (r *DisputeRepositoryImpl) ListByUserID(userID uuid.UUID) ([]models.Dispute, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query disputes: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDisputes(rows)
+}
+
+// List retrieves one page of disputes matching the filter, oldest first so
+// the longest waiting come first, along with the total number of matches
+func (r *DisputeRepositoryImpl) List(filter models.DisputeFilter) ([]models.Dispute, int, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.AssignedTo != nil {
+		args = append(args, *filter.AssignedTo)
+		conditions = append(conditions, fmt.Sprintf("assigned_to = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count all matches
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM disputes`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count disputes: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+disputeColumns+`
+		FROM disputes`+where+`
+		ORDER BY created_at, id
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := r.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query disputes: %w", err)
+	}
+	defer rows.Close()
+
+	disputes, err := scanDisputes(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return disputes, total, nil
+}
+
+// Assign puts a dispute under investigation by dispute.AssignedTo
+func (r *DisputeRepositoryImpl) Assign(dispute *models.Dispute, change models.DisputeChange) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		previous, err := lockUnresolvedDispute(tx, dispute.ID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		_, err = tx.Exec(`
+			UPDATE disputes
+			SET status = $1, assigned_to = $2, updated_at = $3
+			WHERE id = $4`, models.DisputeStatusInvestigating, dispute.AssignedTo, now, dispute.ID)
+		if err != nil {
+			return fmt.Errorf("failed to assign dispute: %w", err)
+		}
+
+		dispute.Status = models.DisputeStatusInvestigating
+		dispute.UpdatedAt = now
+		return writeDisputeStatusChanged(tx, dispute, previous, change)
+	})
+}
+
+// Resolve closes a dispute with dispute.Status and dispute.ResolutionNote.
+// A refund, when given, is credited to the dispute's account in the same
+// database transaction, with its balances set from the locked account.
+func (r *DisputeRepositoryImpl) Resolve(dispute *models.Dispute, refund *models.Transaction, change models.DisputeChange) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		previous, err := lockUnresolvedDispute(tx, dispute.ID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if refund != nil {
+			if err := creditAccount(tx, refund, now); err != nil {
+				return err
+			}
+			dispute.RefundTransactionID = &refund.ID
+		}
+
+		_, err = tx.Exec(`
+			UPDATE disputes
+			SET status = $1, assigned_to = $2, resolution_note = $3, refund_transaction_id = $4, resolved_at = $5, updated_at = $5
+			WHERE id = $6`, dispute.Status, dispute.AssignedTo, dispute.ResolutionNote, dispute.RefundTransactionID, now, dispute.ID)
+		if err != nil {
+			return fmt.Errorf("failed to resolve dispute: %w", err)
+		}
+
+		dispute.ResolvedAt = &now
+		dispute.UpdatedAt = now
+		return writeDisputeStatusChanged(tx, dispute, previous, change)
+	})
+}
+
+// lockUnresolvedDispute locks a dispute with tx and returns its status,
+// failing if it is already resolved
+func lockUnresolvedDispute(tx *sql.Tx, id uuid.UUID) (models.DisputeStatus, error) {
+	var status models.DisputeStatus
+	if err := tx.QueryRow(`SELECT status FROM disputes WHERE id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("dispute not found")
+		}
+		return "", fmt.Errorf("failed to lock dispute: %w", err)
+	}
+	if status.Resolved() {
+		return "", fmt.Errorf("dispute %s is already %s", id, status)
+	}
+	return status, nil
+}
+
+// writeDisputeStatusChanged records a dispute.status_changed event in the
+// outbox using tx, so it is published only if the change commits
+func writeDisputeStatusChanged(tx *sql.Tx, dispute *models.Dispute, previous models.DisputeStatus, change models.DisputeChange) error {
+	event, err := events.New(events.TypeDisputeStatusChanged, events.SourceBankingService, events.DisputeStatusChanged{
+		DisputeID:           dispute.ID,
+		TransactionID:       dispute.TransactionID,
+		UserID:              dispute.UserID,
+		Status:              string(dispute.Status),
+		PreviousStatus:      string(previous),
+		ActorID:             change.ActorID,
+		AssignedTo:          dispute.AssignedTo,
+		Amount:              dispute.Amount,
+		Note:                change.Note,
+		RefundTransactionID: dispute.RefundTransactionID,
+		RequestID:           change.RequestID,
+		ChangedAt:           dispute.UpdatedAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return events.WriteOutbox(tx, event)
+}
+
+// scanDisputes reads every row of disputeColumns
+func scanDisputes(rows *sql.Rows) ([]models.Dispute, error) {
+	var disputes []models.Dispute
+	for rows.Next() {
+		dispute, err := scanDispute(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dispute row: %w", err)
+		}
+		disputes = append(disputes, *dispute)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over dispute rows: %w", err)
+	}
+
+	return disputes, nil
+}
+
+// scanDispute reads a row of disputeColumns
+func scanDispute(row rowScanner) (*models.Dispute, error) {
+	dispute := &models.Dispute{}
+	err := row.Scan(
+		&dispute.ID,
+		&dispute.TransactionID,
+		&dispute.AccountID,
+		&dispute.UserID,
+		&dispute.ReasonCategory,
+		&dispute.Description,
+		&dispute.Amount,
+		&dispute.Status,
+		&dispute.AssignedTo,
+		&dispute.ResolutionNote,
+		&dispute.RefundTransactionID,
+		&dispute.CreatedAt,
+		&dispute.UpdatedAt,
+		&dispute.ResolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+func TestDisputeRepository_ResolveRefunds(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewDisputeRepository(db)
+	staffID := uuid.New()
+	dispute := &models.Dispute{ID: uuid.New(), TransactionID: uuid.New(), AccountID: uuid.New(), UserID: uuid.New(), Amount: 49.99, Status: models.DisputeStatusResolvedRefunded, AssignedTo: &staffID, ResolutionNote: "Card was stolen"}
+	refund := &models.Transaction{ID: uuid.New(), AccountID: dispute.AccountID, UserID: dispute.UserID, Type: models.TransactionTypeRefund, Amount: 49.99, RelatedTransactionID: &dispute.TransactionID}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status FROM disputes WHERE id = $1 FOR UPDATE")).
+		WithArgs(dispute.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("investigating"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE")).
+		WithArgs(dispute.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(100.01))
+	expectChained(mock, dispute.AccountID, 0, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(refund.ID, dispute.AccountID, dispute.UserID, models.TransactionTypeRefund, 49.99, 100.01, 150.0, sqlmock.AnyArg(), sqlmock.AnyArg(), &dispute.TransactionID, int64(1), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(150.0, sqlmock.AnyArg(), dispute.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE disputes")).
+		WithArgs(models.DisputeStatusResolvedRefunded, &staffID, "Card was stolen", &refund.ID, sqlmock.AnyArg(), dispute.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Resolve(dispute, refund, models.DisputeChange{ActorID: staffID, Note: "Card was stolen"}); err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if refund.BalanceBefore != 100.01 || refund.BalanceAfter != 150 {
+		t.Errorf("Expected the refund to take the balance from 100.01 to 150, got %v to %v", refund.BalanceBefore, refund.BalanceAfter)
+	}
+	if dispute.RefundTransactionID == nil || *dispute.RefundTransactionID != refund.ID || dispute.ResolvedAt == nil {
+		t.Errorf("Expected the dispute to be resolved with refund %s, got %+v", refund.ID, dispute)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDisputeRepository_ResolveRefusesResolvedDisputes(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewDisputeRepository(db)
+	dispute := &models.Dispute{ID: uuid.New(), Status: models.DisputeStatusResolvedRejected}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status FROM disputes WHERE id = $1 FOR UPDATE")).
+		WithArgs(dispute.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("resolved_refunded"))
+	mock.ExpectRollback()
+
+	if err := repo.Resolve(dispute, nil, models.DisputeChange{ActorID: uuid.New()}); err == nil {
+		t.Fatal("Expected an error resolving a resolved dispute, got nil")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/events"
+)
+
+// externalTransferColumns lists the external_transfers columns in the
+// order scanExternalTransfer reads them
+const externalTransferColumns = `id, user_id, account_id, amount, routing_number, account_number, account_holder_name, description, status, COALESCE(failure_reason, ''), transaction_id, created_at, updated_at, settled_at`
+
+// pendingTransfersQuery adds up what pending transfers hold on the account
+// $1
+const pendingTransfersQuery = `SELECT COALESCE(SUM(amount), 0) FROM external_transfers WHERE account_id = $1 AND status = 'pending'`
+
+// heldFundsQuery adds up everything held on the account $1 at $2: pending
+// transfers and cheques still on hold
+const heldFundsQuery = `SELECT (` + pendingTransfersQuery + `) + (` + chequeHoldsQuery + `)`
+
+// ErrExternalTransferNotPending is returned by
+// ExternalTransferRepositoryImpl.Complete and Fail, when nothing is
+// written, if the transfer was already settled
+var ErrExternalTransferNotPending = errors.New("external transfer is not pending")
+
+// ExternalTransferRepositoryImpl handles all database operations related
+// to transfers to other banks
+type ExternalTransferRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewExternalTransferRepository creates a new external transfer repository
+func NewExternalTransferRepository(db *PostgresDB) ExternalTransferRepository {
+	return &ExternalTransferRepositoryImpl{db: db}
+}
+
+// Create saves a pending transfer, holding its amount on the account. The
+// account is locked while its holds, including cheques on hold, are added
+// up, so transfers made at once cannot hold more than the balance
+// together; ErrInsufficientBalance is returned when the balance left
+// unheld does not cover the transfer.
+func (r *ExternalTransferRepositoryImpl) Create(transfer *models.ExternalTransfer) error {
+	now := time.Now()
+	return r.db.withTx(func(tx *sql.Tx) error {
+		var balance float64
+		err := tx.QueryRow(`SELECT balance FROM accounts WHERE id = $1 FOR UPDATE`, transfer.AccountID).Scan(&balance)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("account not found for external transfer")
+			}
+			return fmt.Errorf("failed to lock account: %w", err)
+		}
+
+		var held float64
+		if err := tx.QueryRow(heldFundsQuery, transfer.AccountID, now).Scan(&held); err != nil {
+			return fmt.Errorf("failed to sum held funds: %w", err)
+		}
+		if math.Round((balance-held-transfer.Amount)*100) < 0 {
+			return ErrInsufficientBalance
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO external_transfers (id, user_id, account_id, amount, routing_number, account_number, account_holder_name, description, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)`,
+			transfer.ID, transfer.UserID, transfer.AccountID, transfer.Amount, transfer.RoutingNumber, transfer.AccountNumber,
+			transfer.AccountHolderName, transfer.Description, models.ExternalTransferStatusPending, now)
+		if err != nil {
+			return fmt.Errorf("failed to create external transfer: %w", err)
+		}
+
+		transfer.Status = models.ExternalTransferStatusPending
+		transfer.CreatedAt = now
+		transfer.UpdatedAt = now
+		return nil
+	})
+}
+
+// GetByID retrieves an external transfer by its ID, or nil when there is
+// none
+func (r *ExternalTransferRepositoryImpl) GetByID(id uuid.UUID) (*models.ExternalTransfer, error) {
+	query := `SELECT ` + externalTransferColumns + ` FROM external_transfers WHERE id = $1`
+
+	transfer, err := scanExternalTransfer(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get external transfer: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// List retrieves one page of external transfers matching the filter,
+// newest first, and how many match in all
+func (r *ExternalTransferRepositoryImpl) List(filter models.ExternalTransferFilter) ([]models.ExternalTransfer, int, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count all matches
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM external_transfers`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count external transfers: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+externalTransferColumns+`
+		FROM external_transfers`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := r.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query external transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers, err := scanExternalTransfers(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return transfers, total, nil
+}
+
+// ListDue retrieves up to limit pending transfers made at or before
+// createdBefore, oldest first
+func (r *ExternalTransferRepositoryImpl) ListDue(createdBefore time.Time, limit int) ([]models.ExternalTransfer, error) {
+	query := `
+		SELECT ` + externalTransferColumns + `
+		FROM external_transfers
+		WHERE status = $1 AND created_at <= $2
+		ORDER BY created_at, id
+		LIMIT $3`
+
+	rows, err := r.db.Query(query, models.ExternalTransferStatusPending, createdBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due external transfers: %w", err)
+	}
+	defer rows.Close()
+
+	return scanExternalTransfers(rows)
+}
+
+// HeldByAccountID returns the amount pending transfers hold on an account
+func (r *ExternalTransferRepositoryImpl) HeldByAccountID(accountID uuid.UUID) (float64, error) {
+	var held float64
+	if err := r.db.QueryRow(pendingTransfersQuery, accountID).Scan(&held); err != nil {
+		return 0, fmt.Errorf("failed to sum held funds: %w", err)
+	}
+	return held, nil
+}
+
+// Complete settles a pending transfer, taking its amount from the balance
+// with transaction in the same database transaction. Its balances are set
+// from the locked account, and ErrInsufficientBalance is returned when the
+// balance no longer covers it.
+func (r *ExternalTransferRepositoryImpl) Complete(transfer *models.ExternalTransfer, transaction *models.Transaction) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		if err := lockPendingExternalTransfer(tx, transfer.ID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := debitAccount(tx, transaction, now); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`
+			UPDATE external_transfers
+			SET status = $1, transaction_id = $2, settled_at = $3, updated_at = $3
+			WHERE id = $4`, models.ExternalTransferStatusCompleted, transaction.ID, now, transfer.ID)
+		if err != nil {
+			return fmt.Errorf("failed to complete external transfer: %w", err)
+		}
+
+		transfer.Status = models.ExternalTransferStatusCompleted
+		transfer.TransactionID = &transaction.ID
+		transfer.SettledAt = &now
+		transfer.UpdatedAt = now
+		return nil
+	})
+}
+
+// Fail fails a pending transfer with transfer.FailureReason, releasing its
+// hold. An external_transfer.failed event is recorded in the same
+// transaction, so the user is told.
+func (r *ExternalTransferRepositoryImpl) Fail(transfer *models.ExternalTransfer, change models.ExternalTransferChange) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		if err := lockPendingExternalTransfer(tx, transfer.ID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		_, err := tx.Exec(`
+			UPDATE external_transfers
+			SET status = $1, failure_reason = $2, settled_at = $3, updated_at = $3
+			WHERE id = $4`, models.ExternalTransferStatusFailed, transfer.FailureReason, now, transfer.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fail external transfer: %w", err)
+		}
+
+		transfer.Status = models.ExternalTransferStatusFailed
+		transfer.SettledAt = &now
+		transfer.UpdatedAt = now
+		return writeExternalTransferFailed(tx, transfer, change)
+	})
+}
+
+// lockPendingExternalTransfer locks an external transfer with tx, failing
+// with ErrExternalTransferNotPending if it is already settled
+func lockPendingExternalTransfer(tx *sql.Tx, id uuid.UUID) error {
+	var status models.ExternalTransferStatus
+	if err := tx.QueryRow(`SELECT status FROM external_transfers WHERE id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("external transfer not found")
+		}
+		return fmt.Errorf("failed to lock external transfer: %w", err)
+	}
+	if status != models.ExternalTransferStatusPending {
+		return ErrExternalTransferNotPending
+	}
+	return nil
+}
+
+// writeExternalTransferFailed records an external_transfer.failed event in
+// the outbox using tx, so it is published only if the failure commits
+func writeExternalTransferFailed(tx *sql.Tx, transfer *models.ExternalTransfer, change models.ExternalTransferChange) error {
+	event, err := events.New(events.TypeExternalTransferFailed, events.SourceBankingService, events.ExternalTransferFailed{
+		TransferID:        transfer.ID,
+		UserID:            transfer.UserID,
+		Amount:            transfer.Amount,
+		AccountNumber:     transfer.MaskedAccountNumber(),
+		AccountHolderName: transfer.AccountHolderName,
+		Reason:            transfer.FailureReason,
+		ActorID:           change.ActorID,
+		RequestID:         change.RequestID,
+		FailedAt:          transfer.SettledAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return events.WriteOutbox(tx, event)
+}
+
+// scanExternalTransfers reads every row of externalTransferColumns
+func scanExternalTransfers(rows *sql.Rows) ([]models.ExternalTransfer, error) {
+	var transfers []models.ExternalTransfer
+	for rows.Next() {
+		transfer, err := scanExternalTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan external transfer row: %w", err)
+		}
+		transfers = append(transfers, *transfer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over external transfer rows: %w", err)
+	}
+
+	return transfers, nil
+}
+
+// scanExternalTransfer reads a row of externalTransferColumns
+func scanExternalTransfer(row rowScanner) (*models.ExternalTransfer, error) {
+	transfer := &models.ExternalTransfer{}
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.UserID,
+		&transfer.AccountID,
+		&transfer.Amount,
+		&transfer.RoutingNumber,
+		&transfer.AccountNumber,
+		&transfer.AccountHolderName,
+		&transfer.Description,
+		&transfer.Status,
+		&transfer.FailureReason,
+		&transfer.TransactionID,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+		&transfer.SettledAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+func TestExternalTransferRepository_CreateChecksHeldFunds(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewExternalTransferRepository(db)
+	transfer := &models.ExternalTransfer{ID: uuid.New(), UserID: uuid.New(), AccountID: uuid.New(), Amount: 150, RoutingNumber: "021000021", AccountNumber: "123456789", AccountHolderName: "Tendai Moyo"}
+
+	// 400 less 250 already held covers the transfer
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE")).
+		WithArgs(transfer.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(400.0))
+	mock.ExpectQuery(regexp.QuoteMeta(heldFundsQuery)).
+		WithArgs(transfer.AccountID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(250.0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO external_transfers")).
+		WithArgs(transfer.ID, transfer.UserID, transfer.AccountID, 150.0, "021000021", "123456789", "Tendai Moyo", "", models.ExternalTransferStatusPending, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Create(transfer); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if transfer.Status != models.ExternalTransferStatusPending || transfer.CreatedAt.IsZero() {
+		t.Errorf("Expected a pending transfer, got %+v", transfer)
+	}
+
+	// A cent more is not covered
+	again := &models.ExternalTransfer{ID: uuid.New(), AccountID: transfer.AccountID, Amount: 0.01}
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE")).
+		WithArgs(transfer.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(400.0))
+	mock.ExpectQuery(regexp.QuoteMeta(heldFundsQuery)).
+		WithArgs(transfer.AccountID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(400.0))
+	mock.ExpectRollback()
+
+	if err := repo.Create(again); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("Expected ErrInsufficientBalance, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExternalTransferRepository_CompleteDebitsAccount(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewExternalTransferRepository(db)
+	transfer := &models.ExternalTransfer{ID: uuid.New(), UserID: uuid.New(), AccountID: uuid.New(), Amount: 150, Status: models.ExternalTransferStatusPending}
+	transaction := &models.Transaction{ID: uuid.New(), AccountID: transfer.AccountID, UserID: transfer.UserID, Type: models.TransactionTypeExternalTransfer, Amount: 150, Description: "Transfer to ****6789"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status FROM external_transfers WHERE id = $1 FOR UPDATE")).
+		WithArgs(transfer.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("pending"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE")).
+		WithArgs(transfer.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(400.0))
+	expectChained(mock, transfer.AccountID, 2, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(transaction.ID, transfer.AccountID, transfer.UserID, models.TransactionTypeExternalTransfer, 150.0, 400.0, 250.0, "Transfer to ****6789", sqlmock.AnyArg(), nil, int64(3), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(250.0, sqlmock.AnyArg(), transfer.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE external_transfers")).
+		WithArgs(models.ExternalTransferStatusCompleted, transaction.ID, sqlmock.AnyArg(), transfer.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Complete(transfer, transaction); err != nil {
+		t.Fatalf("Complete returned error: %v", err)
+	}
+	if transfer.Status != models.ExternalTransferStatusCompleted || transfer.TransactionID == nil || *transfer.TransactionID != transaction.ID || transfer.SettledAt == nil {
+		t.Errorf("Expected the transfer to be completed by its transaction, got %+v", transfer)
+	}
+
+	// Completing it again writes nothing
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status FROM external_transfers WHERE id = $1 FOR UPDATE")).
+		WithArgs(transfer.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("completed"))
+	mock.ExpectRollback()
+
+	if err := repo.Complete(transfer, &models.Transaction{ID: uuid.New(), AccountID: transfer.AccountID, Amount: 150}); !errors.Is(err, ErrExternalTransferNotPending) {
+		t.Fatalf("Expected ErrExternalTransferNotPending, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExternalTransferRepository_FailRecordsEvent(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewExternalTransferRepository(db)
+	staffID := uuid.New()
+	transfer := &models.ExternalTransfer{ID: uuid.New(), UserID: uuid.New(), AccountID: uuid.New(), Amount: 150, AccountNumber: "123456789", Status: models.ExternalTransferStatusPending, CreatedAt: time.Now(), FailureReason: "Account closed"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status FROM external_transfers WHERE id = $1 FOR UPDATE")).
+		WithArgs(transfer.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("pending"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE external_transfers")).
+		WithArgs(models.ExternalTransferStatusFailed, "Account closed", sqlmock.AnyArg(), transfer.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Fail(transfer, models.ExternalTransferChange{ActorID: &staffID, RequestID: "req-1"}); err != nil {
+		t.Fatalf("Fail returned error: %v", err)
+	}
+	if transfer.Status != models.ExternalTransferStatusFailed || transfer.SettledAt == nil {
+		t.Errorf("Expected the transfer to be failed, got %+v", transfer)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
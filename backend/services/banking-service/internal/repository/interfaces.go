@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"microbank/banking-service/internal/models"
 )
@@ -8,20 +11,212 @@ import (
 // AccountRepository defines the interface for account data operations
 type AccountRepository interface {
 	CreateAccount(userID uuid.UUID) (*models.Account, error)
+	CreateAccountIfMissing(userID uuid.UUID) (*models.Account, bool, error)
 	GetAccountByUserID(userID uuid.UUID) (*models.Account, error)
 	GetAccountByID(id uuid.UUID) (*models.Account, error)
 	GetOrCreateAccount(userID uuid.UUID) (*models.Account, error)
 	UpdateBalance(accountID uuid.UUID, newBalance float64) error
 	AccountExists(userID uuid.UUID) (bool, error)
 	GetAllAccounts() ([]models.Account, error)
+	MarkOwnerDeleted(userID uuid.UUID) (bool, error)
+	ClearOwnerDeleted(userID uuid.UUID) (bool, error)
+	SetFrozen(userID uuid.UUID, frozen bool) (bool, error)
 }
 
 // TransactionRepository defines the interface for transaction operations
 type TransactionRepository interface {
 	CreateTransaction(transaction *models.Transaction) error
+	CreateDeposit(transaction *models.Transaction) error
+	CreateWithdrawal(withdrawal *models.Withdrawal) error
+	CreateTransfer(transfer *models.Transfer) error
+	CountWithdrawalsSince(accountID uuid.UUID, since time.Time) (int, error)
+	ListRoundUpsSince(accountID uuid.UUID, since time.Time) ([]models.Transaction, error)
 	GetTransactionByID(id uuid.UUID) (*models.Transaction, error)
-	GetTransactionsByUserID(userID uuid.UUID, limit, offset int) ([]models.Transaction, error)
-	GetTransactionsByAccountID(accountID uuid.UUID, limit, offset int) ([]models.Transaction, error)
-	GetTransactionCountByUserID(userID uuid.UUID) (int, error)
-	GetAllTransactions(limit, offset int) ([]models.Transaction, error)
+	GetTransactionsByUserID(userID uuid.UUID, filter models.TransactionFilter, before *models.Transaction, limit, offset int) ([]models.Transaction, error)
+	GetTransactionsByAccountID(accountID uuid.UUID, filter models.TransactionFilter, before *models.Transaction, limit, offset int) ([]models.Transaction, error)
+	GetTransactionCountByUserID(userID uuid.UUID, filter models.TransactionFilter) (int, error)
+	GetTransactionCountByAccountID(accountID uuid.UUID, filter models.TransactionFilter) (int, error)
+	GetAllTransactions(filter models.TransactionFilter, limit, offset int) ([]models.Transaction, error)
+	CountAllTransactions(filter models.TransactionFilter) (int, error)
+	GetTransactionsAfter(ctx context.Context, filter models.TransactionFilter, after *models.Transaction, limit int) ([]models.Transaction, error)
+	GetIntegrityChainHead(accountID uuid.UUID) (*models.IntegrityChainHead, error)
+	ListIntegrityChain(accountID uuid.UUID, after int64, limit int) ([]models.Transaction, error)
+}
+
+// AccountMemberRepository defines the interface for the members of
+// accounts and the invitations to join them. Changes that would leave an
+// account without an owner return ErrLastOwner.
+type AccountMemberRepository interface {
+	GetMember(accountID, userID uuid.UUID) (*models.AccountMember, error)
+	ListMembers(accountID uuid.UUID) ([]models.AccountMember, error)
+	ListByUserID(userID uuid.UUID) ([]models.AccountMember, error)
+	UpdateMember(member *models.AccountMember) (bool, error)
+	RemoveMember(accountID, userID uuid.UUID) (bool, error)
+	CreateInvitation(invitation *models.AccountInvitation) (bool, error)
+	GetInvitation(id uuid.UUID) (*models.AccountInvitation, error)
+	ListPendingInvitations(accountID uuid.UUID, at time.Time) ([]models.AccountInvitation, error)
+	ListPendingInvitationsByUserID(userID uuid.UUID, at time.Time) ([]models.AccountInvitation, error)
+	AcceptInvitation(invitation *models.AccountInvitation) (bool, error)
+}
+
+// SavingsGoalRepository defines the interface for savings goal operations
+type SavingsGoalRepository interface {
+	Create(goal *models.SavingsGoal) error
+	GetByID(id uuid.UUID) (*models.SavingsGoal, error)
+	ListByAccountID(accountID uuid.UUID) ([]models.SavingsGoal, error)
+	Update(goal *models.SavingsGoal) error
+	Delete(id uuid.UUID) error
+	ChangeAllocation(goalID uuid.UUID, amount float64) (*models.SavingsGoal, error)
+	GetRoundUpGoal(accountID uuid.UUID) (*models.SavingsGoal, error)
+	SetRoundUpGoal(accountID uuid.UUID, goalID *uuid.UUID) error
+}
+
+// BeneficiaryRepository defines the interface for beneficiary operations
+type BeneficiaryRepository interface {
+	Create(beneficiary *models.Beneficiary, limit int) error
+	GetByID(id uuid.UUID) (*models.Beneficiary, error)
+	GetByAccountID(userID, accountID uuid.UUID) (*models.Beneficiary, error)
+	ListByUserID(userID uuid.UUID) ([]models.Beneficiary, error)
+	Delete(userID, id uuid.UUID) (bool, error)
+}
+
+// ExternalTransferRepository defines the interface for operations on
+// transfers to other banks. Failing one records an
+// external_transfer.failed event.
+type ExternalTransferRepository interface {
+	Create(transfer *models.ExternalTransfer) error
+	GetByID(id uuid.UUID) (*models.ExternalTransfer, error)
+	List(filter models.ExternalTransferFilter) ([]models.ExternalTransfer, int, error)
+	ListDue(createdBefore time.Time, limit int) ([]models.ExternalTransfer, error)
+	HeldByAccountID(accountID uuid.UUID) (float64, error)
+	Complete(transfer *models.ExternalTransfer, transaction *models.Transaction) error
+	Fail(transfer *models.ExternalTransfer, change models.ExternalTransferChange) error
+}
+
+// ChequeRepository defines the interface for cheque deposit operations
+type ChequeRepository interface {
+	Create(cheque *models.ChequeDeposit, transaction *models.Transaction) error
+	GetByID(id uuid.UUID) (*models.ChequeDeposit, error)
+	List(filter models.ChequeDepositFilter) ([]models.ChequeDeposit, int, error)
+	HeldByAccountID(accountID uuid.UUID, at time.Time) (float64, error)
+	Release(cheque *models.ChequeDeposit) error
+	Return(cheque *models.ChequeDeposit, reversal, fee *models.Transaction) error
+}
+
+// PromotionRepository defines the interface for promotion operations.
+// Redeeming a promotion credits its bonus.
+type PromotionRepository interface {
+	Create(promotion *models.Promotion) error
+	GetByID(id uuid.UUID) (*models.Promotion, error)
+	List(filter models.PromotionFilter) ([]models.Promotion, int, error)
+	ListActive(at time.Time) ([]models.Promotion, error)
+	Update(promotion *models.Promotion) error
+	Delete(id uuid.UUID) (bool, error)
+	ListRedemptionsByUserID(userID uuid.UUID) ([]models.PromotionRedemption, error)
+	Redeem(redemption *models.PromotionRedemption, bonus *models.Transaction) (bool, error)
+}
+
+// TransactionPINRepository defines the interface for transaction PIN
+// operations. Resets record a transaction_pin.reset event.
+type TransactionPINRepository interface {
+	GetByUserID(userID uuid.UUID) (*models.TransactionPIN, error)
+	Save(pin *models.TransactionPIN) error
+	RecordAttempt(accountID uuid.UUID, rules models.TransactionPINRules, now time.Time) (*models.TransactionPIN, error)
+	ClearAttempts(accountID uuid.UUID) error
+	Reset(accountID uuid.UUID, reset models.TransactionPINReset) (*models.TransactionPIN, error)
+}
+
+// TransactionConfirmationRepository defines the interface for
+// confirmation thresholds and the withdrawals and transfers waiting for
+// confirmation
+type TransactionConfirmationRepository interface {
+	GetThreshold(userID uuid.UUID) (*float64, error)
+	SetThreshold(userID uuid.UUID, threshold float64) error
+	Create(confirmation *models.TransactionConfirmation) error
+	Consume(userID uuid.UUID, tokenHash string, now time.Time) (*models.TransactionConfirmation, error)
+}
+
+// NotificationRepository defines the interface for account notification
+// settings and the queue of notifications of account activity
+type NotificationRepository interface {
+	GetSettings(accountID uuid.UUID) (*models.NotificationSettings, error)
+	GetSettingsForAccounts(accountIDs []uuid.UUID) (map[uuid.UUID]models.NotificationSettings, error)
+	SaveSettings(settings *models.NotificationSettings) error
+	GetCursor() (*models.NotificationCursor, error)
+	Enqueue(from *models.NotificationCursor, next models.NotificationCursor, deliveries []models.NotificationDelivery) (bool, error)
+	CountNotifiedSince(userID uuid.UUID, since time.Time) (int, error)
+	ClaimDue(lease time.Duration, limit int) ([]models.NotificationDelivery, error)
+	MarkSent(id uuid.UUID, at time.Time) error
+	Retry(id uuid.UUID, reason string, next time.Time) error
+	Fail(id uuid.UUID, reason string) error
+}
+
+// DisputeRepository defines the interface for transaction dispute
+// operations. Every change records a dispute.status_changed event.
+type DisputeRepository interface {
+	Create(dispute *models.Dispute, change models.DisputeChange) (bool, error)
+	GetByID(id uuid.UUID) (*models.Dispute, error)
+	GetLatestByTransactionID(transactionID uuid.UUID) (*models.Dispute, error)
+	ListByUserID(userID uuid.UUID) ([]models.Dispute, error)
+	List(filter models.DisputeFilter) ([]models.Dispute, int, error)
+	Assign(dispute *models.Dispute, change models.DisputeChange) error
+	Resolve(dispute *models.Dispute, refund *models.Transaction, change models.DisputeChange) error
+}
+
+// MaintenanceRepository defines the interface for the maintenance mode flag
+type MaintenanceRepository interface {
+	Get() (*models.MaintenanceState, error)
+	Set(state *models.MaintenanceState, change models.MaintenanceChange) error
+}
+
+// DepositJobRepository defines the interface for the queue of deposits
+// submitted in batches
+type DepositJobRepository interface {
+	CreateBatch(batch *models.DepositBatch, jobs []models.DepositJob) (bool, error)
+	GetBatchByReference(reference string) (*models.DepositBatch, error)
+	GetBatchProgress(id uuid.UUID) (*models.DepositBatchProgress, error)
+	ClaimNext(lease time.Duration) (*models.DepositJob, error)
+	Complete(jobID uuid.UUID, transaction *models.Transaction) (bool, error)
+	Retry(jobID uuid.UUID, reason string, next time.Time) error
+	Fail(jobID uuid.UUID, reason string) error
+}
+
+// TransactionPartitionRepository defines the interface for the monthly
+// partitions of the transactions table and the archive old ones are moved
+// to
+type TransactionPartitionRepository interface {
+	EnsurePartitions(now time.Time) ([]string, error)
+	ArchivePartitions(before time.Time) ([]string, error)
+	GetArchivedTransactions(filter models.ArchivedTransactionFilter) ([]models.Transaction, error)
+}
+
+// SuspiciousActivityRepository defines the interface for the suspicious
+// activity detection queries, each finding the accounts matching a rule on
+// a UTC day, and the reports their findings are kept in
+type SuspiciousActivityRepository interface {
+	FindRapidCycles(day time.Time, rule models.RapidCycleRule) ([]models.SuspiciousActivityFinding, error)
+	FindStructuring(day time.Time, rule models.StructuringRule) ([]models.SuspiciousActivityFinding, error)
+	FindBalanceSwings(day time.Time, rule models.BalanceSwingRule) ([]models.SuspiciousActivityFinding, error)
+	ListReports(from, to *time.Time) ([]models.SuspiciousActivityReport, error)
+	SaveReport(report *models.SuspiciousActivityReport, findings []models.SuspiciousActivityFinding) (bool, error)
+	ListFindings(filter models.SuspiciousActivityFilter) ([]models.SuspiciousActivityFinding, int, error)
+	GetFinding(id uuid.UUID) (*models.SuspiciousActivityFinding, error)
+	ReviewFinding(finding *models.SuspiciousActivityFinding) error
+}
+
+// ReconciliationRepository defines the interface for reconciliation runs,
+// which check every account's stored balance against its transactions in
+// resumable batches
+type ReconciliationRepository interface {
+	StartRun(runDate time.Time) (*models.ReconciliationRun, error)
+	GetLatestRun() (*models.ReconciliationRun, error)
+	CheckBatch(runID uuid.UUID, batchSize int) (*models.ReconciliationRun, bool, error)
+	FinishRun(runID uuid.UUID) (*models.ReconciliationRun, bool, error)
+	ListMismatches(runID uuid.UUID, limit, offset int) ([]models.ReconciliationMismatch, error)
+}
+
+// StatsRepository defines the interface for the aggregate queries behind
+// the admin dashboard
+type StatsRepository interface {
+	GetBankingStats(since time.Time) (*models.BankingStats, error)
 }
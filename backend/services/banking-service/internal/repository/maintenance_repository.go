@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/events"
+)
+
+// MaintenanceRepositoryImpl handles the database operations of the
+// maintenance mode flag
+type MaintenanceRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewMaintenanceRepository creates a new maintenance repository
+func NewMaintenanceRepository(db *PostgresDB) MaintenanceRepository {
+	return &MaintenanceRepositoryImpl{db: db}
+}
+
+// Get retrieves the maintenance mode flag. It is off when it was never
+// set.
+func (r *MaintenanceRepositoryImpl) Get() (*models.MaintenanceState, error) {
+	query := `SELECT enabled, COALESCE(message, ''), ends_at, updated_by, updated_at FROM maintenance_mode WHERE id`
+
+	state := &models.MaintenanceState{}
+	err := r.db.QueryRow(query).Scan(&state.Enabled, &state.Message, &state.EndsAt, &state.UpdatedBy, &state.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &models.MaintenanceState{}, nil
+		}
+		return nil, fmt.Errorf("failed to get maintenance mode: %w", err)
+	}
+
+	return state, nil
+}
+
+// Set stores the maintenance mode flag and records the change in the
+// outbox in the same transaction
+func (r *MaintenanceRepositoryImpl) Set(state *models.MaintenanceState, change models.MaintenanceChange) error {
+	query := `
+		INSERT INTO maintenance_mode (id, enabled, message, ends_at, updated_by, updated_at)
+		VALUES (TRUE, $1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE
+		SET enabled = EXCLUDED.enabled, message = EXCLUDED.message, ends_at = EXCLUDED.ends_at,
+			updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`
+
+	return r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		var updatedBy *uuid.UUID
+		if change.ActorID != uuid.Nil {
+			updatedBy = &change.ActorID
+		}
+		if _, err := tx.Exec(query, state.Enabled, state.Message, state.EndsAt, updatedBy, now); err != nil {
+			return fmt.Errorf("failed to set maintenance mode: %w", err)
+		}
+		state.UpdatedBy = updatedBy
+		state.UpdatedAt = now
+
+		var endsAt *time.Time
+		if state.EndsAt != nil {
+			utc := state.EndsAt.UTC()
+			endsAt = &utc
+		}
+		event, err := events.New(events.TypeMaintenanceChanged, events.SourceBankingService, events.MaintenanceChanged{
+			Enabled:   state.Enabled,
+			Message:   state.Message,
+			EndsAt:    endsAt,
+			ActorID:   change.ActorID,
+			RequestID: change.RequestID,
+			ChangedAt: now.UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		return events.WriteOutbox(tx, event)
+	})
+}
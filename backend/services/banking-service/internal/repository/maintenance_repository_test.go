@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+func TestMaintenanceRepository_GetNeverSet(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewMaintenanceRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM maintenance_mode WHERE id")).
+		WillReturnRows(sqlmock.NewRows([]string{"enabled", "message", "ends_at", "updated_by", "updated_at"}))
+
+	state, err := repo.Get()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if state.Enabled {
+		t.Errorf("Expected maintenance mode to be off when never set, got %+v", state)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestMaintenanceRepository_SetRecordsEvent(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewMaintenanceRepository(db)
+	staffID := uuid.New()
+	endsAt := time.Now().Add(time.Hour)
+	state := &models.MaintenanceState{Enabled: true, Message: "Database upgrade", EndsAt: &endsAt}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO maintenance_mode")).
+		WithArgs(true, "Database upgrade", &endsAt, &staffID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Set(state, models.MaintenanceChange{ActorID: staffID, RequestID: "req-1"}); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if state.UpdatedBy == nil || *state.UpdatedBy != staffID || state.UpdatedAt.IsZero() {
+		t.Errorf("Expected the state to be updated by %s, got %+v", staffID, state)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"microbank/banking-service/internal/models"
+)
+
+// notificationSettingsColumns lists the notification_settings columns in
+// the order scanNotificationSettings reads them
+const notificationSettingsColumns = `account_id, user_id, low_balance_threshold, webhook_url, webhook_secret, updated_at`
+
+// notificationDeliveryColumns lists the notification_deliveries columns in
+// the order scanNotificationDelivery reads them
+const notificationDeliveryColumns = `id, user_id, account_id, transaction_id, event_type, channel, destination, data, status, attempts, next_attempt_at, last_error, created_at, delivered_at`
+
+// NotificationRepositoryImpl handles all database operations related to
+// account notification settings and the notifications of account activity
+type NotificationRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *PostgresDB) NotificationRepository {
+	return &NotificationRepositoryImpl{db: db}
+}
+
+// GetSettings retrieves an account's notification settings, or nil when
+// they were never set
+func (r *NotificationRepositoryImpl) GetSettings(accountID uuid.UUID) (*models.NotificationSettings, error) {
+	query := `SELECT ` + notificationSettingsColumns + ` FROM notification_settings WHERE account_id = $1`
+
+	settings, err := scanNotificationSettings(r.db.QueryRow(query, accountID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// GetSettingsForAccounts retrieves the notification settings of the
+// accounts that have them, by account ID
+func (r *NotificationRepositoryImpl) GetSettingsForAccounts(accountIDs []uuid.UUID) (map[uuid.UUID]models.NotificationSettings, error) {
+	settings := make(map[uuid.UUID]models.NotificationSettings)
+	if len(accountIDs) == 0 {
+		return settings, nil
+	}
+
+	ids := make([]string, len(accountIDs))
+	for i, id := range accountIDs {
+		ids[i] = id.String()
+	}
+	query := `SELECT ` + notificationSettingsColumns + ` FROM notification_settings WHERE account_id = ANY($1::uuid[])`
+
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		account, err := scanNotificationSettings(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification settings: %w", err)
+		}
+		settings[account.AccountID] = *account
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over notification settings rows: %w", err)
+	}
+
+	return settings, nil
+}
+
+// SaveSettings stores an account's notification settings, replacing any
+// earlier ones
+func (r *NotificationRepositoryImpl) SaveSettings(settings *models.NotificationSettings) error {
+	query := `
+		INSERT INTO notification_settings (account_id, user_id, low_balance_threshold, webhook_url, webhook_secret, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (account_id) DO UPDATE
+		SET user_id = EXCLUDED.user_id, low_balance_threshold = EXCLUDED.low_balance_threshold,
+			webhook_url = EXCLUDED.webhook_url, webhook_secret = EXCLUDED.webhook_secret, updated_at = EXCLUDED.updated_at`
+
+	settings.UpdatedAt = time.Now()
+	_, err := r.db.Exec(query, settings.AccountID, settings.UserID, settings.LowBalanceThreshold, settings.WebhookURL, settings.WebhookSecret, settings.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save notification settings: %w", err)
+	}
+
+	return nil
+}
+
+// GetCursor retrieves the last transaction the dispatcher looked at, or
+// nil before it first ran
+func (r *NotificationRepositoryImpl) GetCursor() (*models.NotificationCursor, error) {
+	query := `SELECT last_created_at, last_transaction_id FROM notification_cursor`
+
+	cursor := &models.NotificationCursor{}
+	if err := r.db.QueryRow(query).Scan(&cursor.CreatedAt, &cursor.TransactionID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get notification cursor: %w", err)
+	}
+
+	return cursor, nil
+}
+
+// Enqueue queues deliveries and moves the cursor from where it was read to
+// next in one database transaction, reporting whether it did. It does not
+// when another replica moved the cursor first, so each transaction is
+// looked at once however many replicas dispatch. from is nil when there
+// was no cursor yet.
+func (r *NotificationRepositoryImpl) Enqueue(from *models.NotificationCursor, next models.NotificationCursor, deliveries []models.NotificationDelivery) (bool, error) {
+	moved := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		var result sql.Result
+		var err error
+		if from == nil {
+			result, err = tx.Exec(`
+				INSERT INTO notification_cursor (id, last_created_at, last_transaction_id, updated_at)
+				VALUES (TRUE, $1, $2, $3)
+				ON CONFLICT (id) DO NOTHING`, next.CreatedAt, next.TransactionID, now)
+		} else {
+			result, err = tx.Exec(`
+				UPDATE notification_cursor
+				SET last_created_at = $1, last_transaction_id = $2, updated_at = $3
+				WHERE last_created_at = $4 AND last_transaction_id = $5`, next.CreatedAt, next.TransactionID, now, from.CreatedAt, from.TransactionID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to move notification cursor: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to move notification cursor: %w", err)
+		}
+		if rows == 0 {
+			return nil
+		}
+
+		for i := range deliveries {
+			delivery := &deliveries[i]
+			data, err := json.Marshal(delivery.Data)
+			if err != nil {
+				return fmt.Errorf("failed to encode notification data: %w", err)
+			}
+			delivery.CreatedAt = now
+			err = tx.QueryRow(`
+				INSERT INTO notification_deliveries (user_id, account_id, transaction_id, event_type, channel, destination, data, status, next_attempt_at, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+				ON CONFLICT (transaction_id, event_type, channel) DO NOTHING
+				RETURNING id`,
+				delivery.UserID, delivery.AccountID, delivery.TransactionID, delivery.EventType, delivery.Channel,
+				delivery.Destination, data, delivery.Status, delivery.NextAttemptAt, delivery.CreatedAt,
+			).Scan(&delivery.ID)
+			if err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("failed to queue notification: %w", err)
+			}
+		}
+
+		moved = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return moved, nil
+}
+
+// CountNotifiedSince counts the events a user was notified of, or is still
+// to be, since a time. Each event counts once however many channels it
+// goes by, and suppressed ones do not count.
+func (r *NotificationRepositoryImpl) CountNotifiedSince(userID uuid.UUID, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(DISTINCT (transaction_id, event_type))
+		FROM notification_deliveries
+		WHERE user_id = $1 AND created_at >= $2 AND status <> $3`
+
+	var count int
+	if err := r.db.QueryRow(query, userID, since, models.NotificationDeliverySuppressed).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	return count, nil
+}
+
+// ClaimDue claims up to limit pending deliveries that are due for lease
+// and counts the attempt. A delivery whose lease ran out, say because its
+// dispatcher crashed, is due again. Rows locked by other dispatchers are
+// skipped, so no two claim the same delivery.
+func (r *NotificationRepositoryImpl) ClaimDue(lease time.Duration, limit int) ([]models.NotificationDelivery, error) {
+	query := `
+		UPDATE notification_deliveries
+		SET attempts = attempts + 1, next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM notification_deliveries
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + notificationDeliveryColumns
+
+	now := time.Now()
+	rows, err := r.db.Query(query, now.Add(lease), models.NotificationDeliveryPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.NotificationDelivery
+	for rows.Next() {
+		delivery, err := scanNotificationDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over notification rows: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// MarkSent marks a claimed delivery sent at a time
+func (r *NotificationRepositoryImpl) MarkSent(id uuid.UUID, at time.Time) error {
+	query := `
+		UPDATE notification_deliveries
+		SET status = $1, delivered_at = $2, last_error = ''
+		WHERE id = $3 AND status = $4`
+
+	if _, err := r.db.Exec(query, models.NotificationDeliverySent, at, id, models.NotificationDeliveryPending); err != nil {
+		return fmt.Errorf("failed to mark notification sent: %w", err)
+	}
+	return nil
+}
+
+// Retry puts a claimed delivery back in the queue, due again at next,
+// noting why its attempt failed
+func (r *NotificationRepositoryImpl) Retry(id uuid.UUID, reason string, next time.Time) error {
+	query := `
+		UPDATE notification_deliveries
+		SET last_error = $1, next_attempt_at = $2
+		WHERE id = $3 AND status = $4`
+
+	if _, err := r.db.Exec(query, reason, next, id, models.NotificationDeliveryPending); err != nil {
+		return fmt.Errorf("failed to retry notification: %w", err)
+	}
+	return nil
+}
+
+// Fail marks a claimed delivery failed, noting why
+func (r *NotificationRepositoryImpl) Fail(id uuid.UUID, reason string) error {
+	query := `
+		UPDATE notification_deliveries
+		SET status = $1, last_error = $2
+		WHERE id = $3 AND status = $4`
+
+	if _, err := r.db.Exec(query, models.NotificationDeliveryFailed, reason, id, models.NotificationDeliveryPending); err != nil {
+		return fmt.Errorf("failed to fail notification: %w", err)
+	}
+	return nil
+}
+
+// scanNotificationSettings reads a row of notificationSettingsColumns
+func scanNotificationSettings(row rowScanner) (*models.NotificationSettings, error) {
+	settings := &models.NotificationSettings{}
+	err := row.Scan(
+		&settings.AccountID,
+		&settings.UserID,
+		&settings.LowBalanceThreshold,
+		&settings.WebhookURL,
+		&settings.WebhookSecret,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// scanNotificationDelivery reads a row of notificationDeliveryColumns
+func scanNotificationDelivery(row rowScanner) (*models.NotificationDelivery, error) {
+	delivery := &models.NotificationDelivery{}
+	var data []byte
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.UserID,
+		&delivery.AccountID,
+		&delivery.TransactionID,
+		&delivery.EventType,
+		&delivery.Channel,
+		&delivery.Destination,
+		&data,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &delivery.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode notification data: %w", err)
+	}
+	return delivery, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+func TestNotificationRepository_EnqueueOnlyFromTheCursorRead(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewNotificationRepository(db)
+	from := models.NotificationCursor{CreatedAt: time.Now().Add(-time.Minute), TransactionID: uuid.New()}
+	next := models.NotificationCursor{CreatedAt: time.Now(), TransactionID: uuid.New()}
+	delivery := models.NotificationDelivery{
+		UserID:        uuid.New(),
+		AccountID:     uuid.New(),
+		TransactionID: next.TransactionID,
+		EventType:     models.NotificationEventLargeTransaction,
+		Channel:       models.NotificationChannelEmail,
+		Destination:   "user@example.com",
+		Status:        models.NotificationDeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	moveCursor := regexp.QuoteMeta("WHERE last_created_at = $4 AND last_transaction_id = $5")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(moveCursor).
+		WithArgs(next.CreatedAt, next.TransactionID, sqlmock.AnyArg(), from.CreatedAt, from.TransactionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("ON CONFLICT (transaction_id, event_type, channel) DO NOTHING")).
+		WithArgs(delivery.UserID, delivery.AccountID, delivery.TransactionID, "large_transaction", models.NotificationChannelEmail, "user@example.com", sqlmock.AnyArg(), models.NotificationDeliveryPending, delivery.NextAttemptAt, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	// Another replica moved the cursor first, so nothing is queued
+	mock.ExpectBegin()
+	mock.ExpectExec(moveCursor).
+		WithArgs(next.CreatedAt, next.TransactionID, sqlmock.AnyArg(), from.CreatedAt, from.TransactionID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	moved, err := repo.Enqueue(&from, next, []models.NotificationDelivery{delivery})
+	if err != nil || !moved {
+		t.Fatalf("Expected the notification to be queued, got %v (%v)", moved, err)
+	}
+	if moved, err := repo.Enqueue(&from, next, []models.NotificationDelivery{delivery}); err != nil || moved {
+		t.Errorf("Expected nothing to be queued from a stale cursor, got %v (%v)", moved, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestNotificationRepository_ClaimDueSkipsLockedDeliveries(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewNotificationRepository(db)
+	id, userID, accountID, transactionID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE SKIP LOCKED")).
+		WithArgs(sqlmock.AnyArg(), models.NotificationDeliveryPending, sqlmock.AnyArg(), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "account_id", "transaction_id", "event_type", "channel", "destination", "data", "status", "attempts", "next_attempt_at", "last_error", "created_at", "delivered_at"}).
+			AddRow(id, userID, accountID, transactionID, "low_balance", "sms", "+15551234567", []byte(`{"name":"Ada","balance":80,"threshold":100}`), "pending", 2, now.Add(time.Minute), "gateway timeout", now, nil))
+
+	deliveries, err := repo.ClaimDue(time.Minute, 10)
+	if err != nil {
+		t.Fatalf("ClaimDue returned error: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].ID != id || deliveries[0].Attempts != 2 || deliveries[0].Channel != models.NotificationChannelSMS || deliveries[0].Data.Threshold != 100 {
+		t.Errorf("Expected the second attempt at delivery %s, got %+v", id, deliveries)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
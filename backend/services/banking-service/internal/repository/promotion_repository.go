@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// promotionColumns lists the promotions columns in the order
+// scanPromotion reads them, with how many bonuses each promotion paid
+const promotionColumns = `id, name, description, min_deposit, bonus_amount, starts_at, ends_at, once_per_user, created_by, (SELECT COUNT(*) FROM promotion_redemptions r WHERE r.promotion_id = promotions.id), created_at, updated_at`
+
+// promotionRedemptionColumns lists the promotion_redemptions columns in
+// the order scanPromotionRedemption reads them
+const promotionRedemptionColumns = `id, promotion_id, user_id, account_id, deposit_transaction_id, bonus_transaction_id, amount, once_per_user, created_at`
+
+// PromotionRepositoryImpl handles all database operations related to
+// promotions and the bonuses they pay
+type PromotionRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewPromotionRepository creates a new promotion repository
+func NewPromotionRepository(db *PostgresDB) PromotionRepository {
+	return &PromotionRepositoryImpl{db: db}
+}
+
+// Create saves a new promotion
+func (r *PromotionRepositoryImpl) Create(promotion *models.Promotion) error {
+	query := `
+		INSERT INTO promotions (id, name, description, min_deposit, bonus_amount, starts_at, ends_at, once_per_user, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)`
+
+	now := time.Now()
+	_, err := r.db.Exec(query, promotion.ID, promotion.Name, promotion.Description, promotion.MinDeposit, promotion.BonusAmount,
+		promotion.StartsAt, promotion.EndsAt, promotion.OncePerUser, promotion.CreatedBy, now)
+	if err != nil {
+		return fmt.Errorf("failed to create promotion: %w", err)
+	}
+
+	promotion.CreatedAt = now
+	promotion.UpdatedAt = now
+	return nil
+}
+
+// GetByID retrieves a promotion by its ID, or nil when there is none
+func (r *PromotionRepositoryImpl) GetByID(id uuid.UUID) (*models.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE id = $1`
+
+	promotion, err := scanPromotion(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get promotion: %w", err)
+	}
+
+	return promotion, nil
+}
+
+// List retrieves one page of promotions matching the filter, latest
+// starting first, and how many match in all. Statuses are checked at
+// filter.At.
+func (r *PromotionRepositoryImpl) List(filter models.PromotionFilter) ([]models.Promotion, int, error) {
+	var conditions []string
+	var args []interface{}
+	switch filter.Status {
+	case "":
+	case models.PromotionStatusScheduled:
+		args = append(args, filter.At)
+		conditions = append(conditions, fmt.Sprintf("starts_at > $%d", len(args)))
+	case models.PromotionStatusActive:
+		args = append(args, filter.At)
+		conditions = append(conditions, fmt.Sprintf("starts_at <= $%d AND ends_at > $%d", len(args), len(args)))
+	case models.PromotionStatusEnded:
+		args = append(args, filter.At)
+		conditions = append(conditions, fmt.Sprintf("ends_at <= $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count all matches
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM promotions`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count promotions: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+promotionColumns+`
+		FROM promotions`+where+`
+		ORDER BY starts_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	promotions, err := r.queryPromotions(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return promotions, total, nil
+}
+
+// ListActive retrieves the promotions paying bonuses on deposits made at
+// the given time, ending soonest first
+func (r *PromotionRepositoryImpl) ListActive(at time.Time) ([]models.Promotion, error) {
+	query := `
+		SELECT ` + promotionColumns + `
+		FROM promotions
+		WHERE starts_at <= $1 AND ends_at > $1
+		ORDER BY ends_at, id`
+
+	return r.queryPromotions(query, at)
+}
+
+// Update saves changes to a promotion's name, description, criteria,
+// bonus and window
+func (r *PromotionRepositoryImpl) Update(promotion *models.Promotion) error {
+	query := `
+		UPDATE promotions
+		SET name = $1, description = $2, min_deposit = $3, bonus_amount = $4, starts_at = $5, ends_at = $6, once_per_user = $7, updated_at = $8
+		WHERE id = $9`
+
+	now := time.Now()
+	result, err := r.db.Exec(query, promotion.Name, promotion.Description, promotion.MinDeposit, promotion.BonusAmount,
+		promotion.StartsAt, promotion.EndsAt, promotion.OncePerUser, now, promotion.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update promotion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("promotion not found")
+	}
+
+	promotion.UpdatedAt = now
+	return nil
+}
+
+// Delete removes a promotion that never paid a bonus, and reports whether
+// it did. Promotions that paid bonuses are kept with their redemptions.
+func (r *PromotionRepositoryImpl) Delete(id uuid.UUID) (bool, error) {
+	query := `
+		DELETE FROM promotions
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM promotion_redemptions WHERE promotion_id = $1)`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete promotion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// ListRedemptionsByUserID retrieves the bonuses paid to a user, newest
+// first
+func (r *PromotionRepositoryImpl) ListRedemptionsByUserID(userID uuid.UUID) ([]models.PromotionRedemption, error) {
+	query := `
+		SELECT ` + promotionRedemptionColumns + `
+		FROM promotion_redemptions
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query promotion redemptions: %w", err)
+	}
+	defer rows.Close()
+
+	var redemptions []models.PromotionRedemption
+	for rows.Next() {
+		redemption, err := scanPromotionRedemption(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan promotion redemption row: %w", err)
+		}
+		redemptions = append(redemptions, *redemption)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over promotion redemption rows: %w", err)
+	}
+
+	return redemptions, nil
+}
+
+// Redeem records the redemption and credits its bonus transaction in one
+// database transaction, and reports whether it did. Nothing is written when
+// the deposit already earned the promotion's bonus, or, for one-time
+// promotions, when the user already did: the unique indexes on redemptions
+// make a deposit racing another wait for it and then skip the bonus.
+func (r *PromotionRepositoryImpl) Redeem(redemption *models.PromotionRedemption, bonus *models.Transaction) (bool, error) {
+	redeemed := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		result, err := tx.Exec(`
+			INSERT INTO promotion_redemptions (id, promotion_id, user_id, account_id, deposit_transaction_id, bonus_transaction_id, amount, once_per_user, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT DO NOTHING`,
+			redemption.ID, redemption.PromotionID, redemption.UserID, redemption.AccountID, redemption.DepositTransactionID,
+			bonus.ID, bonus.Amount, redemption.OncePerUser, now)
+		if err != nil {
+			return fmt.Errorf("failed to record promotion redemption: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		if err := creditAccount(tx, bonus, now); err != nil {
+			return err
+		}
+
+		redemption.BonusTransactionID = bonus.ID
+		redemption.Amount = bonus.Amount
+		redemption.CreatedAt = now
+		redeemed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return redeemed, nil
+}
+
+// queryPromotions runs a query selecting promotionColumns
+func (r *PromotionRepositoryImpl) queryPromotions(query string, args ...interface{}) ([]models.Promotion, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query promotions: %w", err)
+	}
+	defer rows.Close()
+
+	var promotions []models.Promotion
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan promotion row: %w", err)
+		}
+		promotions = append(promotions, *promotion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over promotion rows: %w", err)
+	}
+
+	return promotions, nil
+}
+
+// scanPromotion reads a row of promotionColumns
+func scanPromotion(row rowScanner) (*models.Promotion, error) {
+	promotion := &models.Promotion{}
+	err := row.Scan(
+		&promotion.ID,
+		&promotion.Name,
+		&promotion.Description,
+		&promotion.MinDeposit,
+		&promotion.BonusAmount,
+		&promotion.StartsAt,
+		&promotion.EndsAt,
+		&promotion.OncePerUser,
+		&promotion.CreatedBy,
+		&promotion.RedemptionCount,
+		&promotion.CreatedAt,
+		&promotion.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// scanPromotionRedemption reads a row of promotionRedemptionColumns
+func scanPromotionRedemption(row rowScanner) (*models.PromotionRedemption, error) {
+	redemption := &models.PromotionRedemption{}
+	err := row.Scan(
+		&redemption.ID,
+		&redemption.PromotionID,
+		&redemption.UserID,
+		&redemption.AccountID,
+		&redemption.DepositTransactionID,
+		&redemption.BonusTransactionID,
+		&redemption.Amount,
+		&redemption.OncePerUser,
+		&redemption.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return redemption, nil
+}
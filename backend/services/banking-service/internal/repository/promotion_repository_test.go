@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+func TestPromotionRepository_RedeemCreditsBonusOnce(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewPromotionRepository(db)
+	depositID := uuid.New()
+	redemption := &models.PromotionRedemption{ID: uuid.New(), PromotionID: uuid.New(), UserID: uuid.New(), AccountID: uuid.New(), DepositTransactionID: depositID, OncePerUser: true}
+	bonus := &models.Transaction{ID: uuid.New(), AccountID: redemption.AccountID, UserID: redemption.UserID, Type: models.TransactionTypeBonus, Amount: 5, Description: "Bonus: October deposits", RelatedTransactionID: &depositID}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO promotion_redemptions")).
+		WithArgs(redemption.ID, redemption.PromotionID, redemption.UserID, redemption.AccountID, depositID, bonus.ID, 5.0, true, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE")).
+		WithArgs(redemption.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(100.0))
+	expectChained(mock, redemption.AccountID, 1, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(bonus.ID, redemption.AccountID, redemption.UserID, models.TransactionTypeBonus, 5.0, 100.0, 105.0, "Bonus: October deposits", sqlmock.AnyArg(), &depositID, int64(2), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(105.0, sqlmock.AnyArg(), redemption.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectCommit()
+
+	redeemed, err := repo.Redeem(redemption, bonus)
+	if err != nil {
+		t.Fatalf("Redeem returned error: %v", err)
+	}
+	if !redeemed || redemption.BonusTransactionID != bonus.ID || bonus.BalanceAfter != 105 {
+		t.Errorf("Expected the bonus to be credited, got %+v", redemption)
+	}
+
+	// A deposit racing the first finds the redemption taken and credits
+	// nothing
+	second := &models.PromotionRedemption{ID: uuid.New(), PromotionID: redemption.PromotionID, UserID: redemption.UserID, AccountID: redemption.AccountID, DepositTransactionID: uuid.New(), OncePerUser: true}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO promotion_redemptions")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if redeemed, err := repo.Redeem(second, &models.Transaction{ID: uuid.New(), AccountID: second.AccountID, Amount: 5}); err != nil || redeemed {
+		t.Errorf("Expected no second bonus, got %v (%v)", redeemed, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
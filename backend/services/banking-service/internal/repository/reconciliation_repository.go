@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/events"
+)
+
+// reconciliationRunColumns lists the reconciliation_runs columns in the
+// order scanReconciliationRun reads them
+const reconciliationRunColumns = `id, run_date, status, started_at, finished_at, last_account_id, accounts_checked, mismatches_found, total_balance, total_expected`
+
+// signedTransactionAmount is what a transaction adds to its account's
+// balance. Round-ups move money into a savings goal without changing the
+// balance.
+const signedTransactionAmount = `
+	CASE type
+		WHEN 'deposit' THEN amount
+		WHEN 'refund' THEN amount
+		WHEN 'transfer_in' THEN amount
+		WHEN 'bonus' THEN amount
+		WHEN 'withdrawal' THEN -amount
+		WHEN 'fee' THEN -amount
+		WHEN 'transfer_out' THEN -amount
+		WHEN 'external_transfer' THEN -amount
+		WHEN 'cheque_return' THEN -amount
+		ELSE 0
+	END`
+
+// ReconciliationRepositoryImpl handles reconciliation runs, which check
+// stored balances against the transactions behind them
+type ReconciliationRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewReconciliationRepository creates a new reconciliation repository
+func NewReconciliationRepository(db *PostgresDB) ReconciliationRepository {
+	return &ReconciliationRepositoryImpl{db: db}
+}
+
+// StartRun starts the run of runDate, or returns it when it was already
+// started, so replicas starting the day's run at once share it
+func (r *ReconciliationRepositoryImpl) StartRun(runDate time.Time) (*models.ReconciliationRun, error) {
+	_, err := r.db.Exec(`
+		INSERT INTO reconciliation_runs (run_date, status, started_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (run_date) DO NOTHING`, runDate.Format(reportDateLayout), models.ReconciliationStatusRunning, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to start reconciliation run: %w", err)
+	}
+
+	run, err := scanReconciliationRun(r.db.QueryRow(`SELECT `+reconciliationRunColumns+` FROM reconciliation_runs WHERE run_date = $1`, runDate.Format(reportDateLayout)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reconciliation run: %w", err)
+	}
+
+	return run, nil
+}
+
+// GetLatestRun retrieves the most recently started run, or nil when there
+// has been none
+func (r *ReconciliationRepositoryImpl) GetLatestRun() (*models.ReconciliationRun, error) {
+	query := `SELECT ` + reconciliationRunColumns + ` FROM reconciliation_runs ORDER BY run_date DESC LIMIT 1`
+
+	run, err := scanReconciliationRun(r.db.QueryRow(query))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get reconciliation run: %w", err)
+	}
+
+	return run, nil
+}
+
+// CheckBatch checks the next batchSize accounts after the run's checkpoint,
+// recording their mismatches and moving the checkpoint past them in one
+// database transaction. It reports whether there were no accounts left to
+// check. The run is locked while the batch is checked, so replicas
+// carrying on with the same run never check an account twice.
+func (r *ReconciliationRepositoryImpl) CheckBatch(runID uuid.UUID, batchSize int) (*models.ReconciliationRun, bool, error) {
+	var run *models.ReconciliationRun
+	done := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		var err error
+		run, err = lockReconciliationRun(tx, runID)
+		if err != nil {
+			return err
+		}
+		if run.Status != models.ReconciliationStatusRunning {
+			done = true
+			return nil
+		}
+
+		checked, last, mismatches, err := checkAccountBalances(tx, runID, run.LastAccountID, batchSize)
+		if err != nil {
+			return err
+		}
+		if checked == 0 {
+			done = true
+			return nil
+		}
+
+		for _, mismatch := range mismatches {
+			if err := insertReconciliationMismatch(tx, mismatch); err != nil {
+				return err
+			}
+		}
+		run.LastAccountID = last
+		run.AccountsChecked += checked
+		run.MismatchesFound += len(mismatches)
+		_, err = tx.Exec(`
+			UPDATE reconciliation_runs
+			SET last_account_id = $1, accounts_checked = $2, mismatches_found = $3
+			WHERE id = $4`, run.LastAccountID, run.AccountsChecked, run.MismatchesFound, runID)
+		if err != nil {
+			return fmt.Errorf("failed to update reconciliation run: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return run, done, nil
+}
+
+// FinishRun checks that the sum of every balance matches what every
+// transaction adds up to, recording a mismatch without an account if it
+// does not, and completes the run. When the run found mismatches a
+// reconciliation.mismatches_found event is written to the outbox in the
+// same transaction. It reports whether this call completed the run.
+func (r *ReconciliationRepositoryImpl) FinishRun(runID uuid.UUID) (*models.ReconciliationRun, bool, error) {
+	var run *models.ReconciliationRun
+	finished := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		var err error
+		run, err = lockReconciliationRun(tx, runID)
+		if err != nil {
+			return err
+		}
+		if run.Status != models.ReconciliationStatusRunning {
+			return nil
+		}
+
+		// Both totals are read in one statement, so they agree even while
+		// money moves
+		var totalBalance, totalExpected, difference float64
+		var transactionCount int
+		err = tx.QueryRow(`
+			SELECT b.total, h.expected, b.total - h.expected, h.transaction_count
+			FROM (SELECT COALESCE(SUM(balance), 0) AS total FROM accounts) b,
+			(
+				SELECT COALESCE(SUM(`+signedTransactionAmount+`), 0) AS expected, COUNT(*) AS transaction_count
+				FROM (
+					SELECT type, amount FROM transactions
+					UNION ALL
+					SELECT type, amount FROM archive.transactions
+				) history
+			) h`).Scan(&totalBalance, &totalExpected, &difference, &transactionCount)
+		if err != nil {
+			return fmt.Errorf("failed to total balances: %w", err)
+		}
+		if difference != 0 {
+			mismatch := models.ReconciliationMismatch{
+				ID:               uuid.New(),
+				RunID:            runID,
+				StoredBalance:    totalBalance,
+				ExpectedBalance:  totalExpected,
+				Difference:       difference,
+				TransactionCount: transactionCount,
+			}
+			if err := insertReconciliationMismatch(tx, mismatch); err != nil {
+				return err
+			}
+			run.MismatchesFound++
+		}
+
+		now := time.Now()
+		_, err = tx.Exec(`
+			UPDATE reconciliation_runs
+			SET status = $1, finished_at = $2, mismatches_found = $3, total_balance = $4, total_expected = $5
+			WHERE id = $6`, models.ReconciliationStatusCompleted, now, run.MismatchesFound, totalBalance, totalExpected, runID)
+		if err != nil {
+			return fmt.Errorf("failed to complete reconciliation run: %w", err)
+		}
+		run.Status = models.ReconciliationStatusCompleted
+		run.FinishedAt = &now
+		run.TotalBalance = &totalBalance
+		run.TotalExpected = &totalExpected
+		finished = true
+
+		if run.MismatchesFound == 0 {
+			return nil
+		}
+		event, err := events.New(events.TypeReconciliationMismatchesFound, events.SourceBankingService, events.ReconciliationMismatchesFound{
+			RunID:           run.ID,
+			RunDate:         run.RunDate.Format(reportDateLayout),
+			AccountsChecked: run.AccountsChecked,
+			MismatchesFound: run.MismatchesFound,
+			TotalBalance:    totalBalance,
+			TotalExpected:   totalExpected,
+			FinishedAt:      now.UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		return events.WriteOutbox(tx, event)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return run, finished, nil
+}
+
+// ListMismatches retrieves one page of a run's mismatches, the system-wide
+// one first and then by account
+func (r *ReconciliationRepositoryImpl) ListMismatches(runID uuid.UUID, limit, offset int) ([]models.ReconciliationMismatch, error) {
+	query := `
+		SELECT id, run_id, account_id, user_id, stored_balance, expected_balance, difference, transaction_count, created_at
+		FROM reconciliation_mismatches
+		WHERE run_id = $1
+		ORDER BY account_id NULLS FIRST, id
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(query, runID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reconciliation mismatches: %w", err)
+	}
+	defer rows.Close()
+
+	var mismatches []models.ReconciliationMismatch
+	for rows.Next() {
+		var mismatch models.ReconciliationMismatch
+		err := rows.Scan(&mismatch.ID, &mismatch.RunID, &mismatch.AccountID, &mismatch.UserID, &mismatch.StoredBalance, &mismatch.ExpectedBalance, &mismatch.Difference, &mismatch.TransactionCount, &mismatch.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reconciliation mismatch row: %w", err)
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over reconciliation mismatch rows: %w", err)
+	}
+
+	return mismatches, nil
+}
+
+// checkAccountBalances compares the balance of up to limit accounts after
+// the account after, or from the first one when it is nil, with their
+// transactions. It returns how many were checked, the last one, and the
+// mismatches found.
+func checkAccountBalances(tx *sql.Tx, runID uuid.UUID, after *uuid.UUID, limit int) (int, *uuid.UUID, []models.ReconciliationMismatch, error) {
+	// Each account's balance and its transactions, live and archived, are
+	// read in one statement, so they agree even while money moves
+	rows, err := tx.Query(`
+		SELECT a.id, a.user_id, a.balance, COALESCE(t.expected, 0), a.balance - COALESCE(t.expected, 0), COALESCE(t.transaction_count, 0)
+		FROM accounts a
+		LEFT JOIN LATERAL (
+			SELECT SUM(`+signedTransactionAmount+`) AS expected, COUNT(*) AS transaction_count
+			FROM (
+				SELECT type, amount FROM transactions WHERE account_id = a.id
+				UNION ALL
+				SELECT type, amount FROM archive.transactions WHERE account_id = a.id
+			) history
+		) t ON TRUE
+		WHERE $1::uuid IS NULL OR a.id > $1
+		ORDER BY a.id
+		LIMIT $2`, after, limit)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to query account balances: %w", err)
+	}
+	defer rows.Close()
+
+	checked := 0
+	var last *uuid.UUID
+	var mismatches []models.ReconciliationMismatch
+	for rows.Next() {
+		var accountID, userID uuid.UUID
+		mismatch := models.ReconciliationMismatch{ID: uuid.New(), RunID: runID}
+		if err := rows.Scan(&accountID, &userID, &mismatch.StoredBalance, &mismatch.ExpectedBalance, &mismatch.Difference, &mismatch.TransactionCount); err != nil {
+			return 0, nil, nil, fmt.Errorf("failed to scan account balance row: %w", err)
+		}
+		checked++
+		last = &accountID
+		if mismatch.Difference != 0 {
+			mismatch.AccountID = &accountID
+			mismatch.UserID = &userID
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, nil, nil, fmt.Errorf("error iterating over account balance rows: %w", err)
+	}
+
+	return checked, last, mismatches, nil
+}
+
+// lockReconciliationRun locks a run with tx and returns it
+func lockReconciliationRun(tx *sql.Tx, runID uuid.UUID) (*models.ReconciliationRun, error) {
+	run, err := scanReconciliationRun(tx.QueryRow(`SELECT `+reconciliationRunColumns+` FROM reconciliation_runs WHERE id = $1 FOR UPDATE`, runID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("reconciliation run not found")
+		}
+		return nil, fmt.Errorf("failed to lock reconciliation run: %w", err)
+	}
+	return run, nil
+}
+
+// insertReconciliationMismatch records a mismatch using tx
+func insertReconciliationMismatch(tx *sql.Tx, mismatch models.ReconciliationMismatch) error {
+	_, err := tx.Exec(`
+		INSERT INTO reconciliation_mismatches (id, run_id, account_id, user_id, stored_balance, expected_balance, difference, transaction_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		mismatch.ID, mismatch.RunID, mismatch.AccountID, mismatch.UserID, mismatch.StoredBalance, mismatch.ExpectedBalance, mismatch.Difference, mismatch.TransactionCount)
+	if err != nil {
+		return fmt.Errorf("failed to record reconciliation mismatch: %w", err)
+	}
+	return nil
+}
+
+// scanReconciliationRun reads a row of reconciliationRunColumns
+func scanReconciliationRun(row rowScanner) (*models.ReconciliationRun, error) {
+	run := &models.ReconciliationRun{}
+	err := row.Scan(
+		&run.ID,
+		&run.RunDate,
+		&run.Status,
+		&run.StartedAt,
+		&run.FinishedAt,
+		&run.LastAccountID,
+		&run.AccountsChecked,
+		&run.MismatchesFound,
+		&run.TotalBalance,
+		&run.TotalExpected,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// reconciliationRunRow returns a row of reconciliationRunColumns for a
+// running run
+func reconciliationRunRow(runID uuid.UUID, lastAccountID *uuid.UUID, checked, mismatches int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "run_date", "status", "started_at", "finished_at", "last_account_id", "accounts_checked", "mismatches_found", "total_balance", "total_expected"}).
+		AddRow(runID, time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC), models.ReconciliationStatusRunning, time.Now(), nil, lastAccountID, checked, mismatches, nil, nil)
+}
+
+func TestReconciliationRepository_CheckBatch(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewReconciliationRepository(db)
+	runID, checkpoint := uuid.New(), uuid.New()
+	matchingID, mismatchedID, userID := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM reconciliation_runs WHERE id = $1 FOR UPDATE")).
+		WithArgs(runID).
+		WillReturnRows(reconciliationRunRow(runID, &checkpoint, 500, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE $1::uuid IS NULL OR a.id > $1")).
+		WithArgs(&checkpoint, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "balance", "expected", "difference", "transaction_count"}).
+			AddRow(matchingID, userID, 100.0, 100.0, 0.0, 3).
+			AddRow(mismatchedID, userID, 150.0, 100.0, 50.0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO reconciliation_mismatches")).
+		WithArgs(sqlmock.AnyArg(), runID, &mismatchedID, &userID, 150.0, 100.0, 50.0, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE reconciliation_runs")).
+		WithArgs(&mismatchedID, 502, 2, runID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	run, done, err := repo.CheckBatch(runID, 2)
+	if err != nil {
+		t.Fatalf("CheckBatch returned error: %v", err)
+	}
+	if done {
+		t.Error("Expected more accounts to check after a full batch")
+	}
+	if run.AccountsChecked != 502 || run.MismatchesFound != 2 || run.LastAccountID == nil || *run.LastAccountID != mismatchedID {
+		t.Errorf("Expected 502 accounts checked up to %s with 2 mismatches, got %+v", mismatchedID, run)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestReconciliationRepository_FinishRunAlertsOnMismatches(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewReconciliationRepository(db)
+	runID, checkpoint := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM reconciliation_runs WHERE id = $1 FOR UPDATE")).
+		WithArgs(runID).
+		WillReturnRows(reconciliationRunRow(runID, &checkpoint, 800, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT b.total, h.expected, b.total - h.expected, h.transaction_count")).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "expected", "difference", "transaction_count"}).AddRow(125050.0, 125000.0, 50.0, 9120))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO reconciliation_mismatches")).
+		WithArgs(sqlmock.AnyArg(), runID, nil, nil, 125050.0, 125000.0, 50.0, 9120).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE reconciliation_runs")).
+		WithArgs(models.ReconciliationStatusCompleted, sqlmock.AnyArg(), 2, 125050.0, 125000.0, runID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	run, finished, err := repo.FinishRun(runID)
+	if err != nil {
+		t.Fatalf("FinishRun returned error: %v", err)
+	}
+	if !finished {
+		t.Error("Expected the run to be finished")
+	}
+	if run.Status != models.ReconciliationStatusCompleted || run.MismatchesFound != 2 || run.FinishedAt == nil {
+		t.Errorf("Expected a completed run with 2 mismatches, got %+v", run)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,351 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/events"
+)
+
+// savingsGoalColumns lists the savings_goals columns in the order
+// scanSavingsGoal reads them
+const savingsGoalColumns = `id, user_id, account_id, name, target_amount, target_date, allocated_amount, strict, completed_at, created_at, updated_at`
+
+// earmarkedFundsQuery adds up what savings goals earmark on the account $1
+const earmarkedFundsQuery = `SELECT COALESCE(SUM(allocated_amount), 0) FROM savings_goals WHERE account_id = $1`
+
+// SavingsGoalRepositoryImpl handles all database operations related to
+// savings goals
+type SavingsGoalRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewSavingsGoalRepository creates a new savings goal repository
+func NewSavingsGoalRepository(db *PostgresDB) SavingsGoalRepository {
+	return &SavingsGoalRepositoryImpl{db: db}
+}
+
+// Create creates a new savings goal
+func (r *SavingsGoalRepositoryImpl) Create(goal *models.SavingsGoal) error {
+	query := `
+		INSERT INTO savings_goals (id, user_id, account_id, name, target_amount, target_date, allocated_amount, strict, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8, $8)`
+
+	now := time.Now()
+	_, err := r.db.Exec(query, goal.ID, goal.UserID, goal.AccountID, goal.Name, goal.TargetAmount, goal.TargetDate, goal.Strict, now)
+	if err != nil {
+		return fmt.Errorf("failed to create savings goal: %w", err)
+	}
+
+	goal.AllocatedAmount = 0
+	goal.CreatedAt = now
+	goal.UpdatedAt = now
+	return nil
+}
+
+// GetByID retrieves a savings goal by its ID
+func (r *SavingsGoalRepositoryImpl) GetByID(id uuid.UUID) (*models.SavingsGoal, error) {
+	query := `SELECT ` + savingsGoalColumns + ` FROM savings_goals WHERE id = $1`
+
+	goal, err := scanSavingsGoal(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("savings goal not found")
+		}
+		return nil, fmt.Errorf("failed to get savings goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+// ListByAccountID retrieves the savings goals of an account, oldest first
+func (r *SavingsGoalRepositoryImpl) ListByAccountID(accountID uuid.UUID) ([]models.SavingsGoal, error) {
+	query := `
+		SELECT ` + savingsGoalColumns + `
+		FROM savings_goals
+		WHERE account_id = $1
+		ORDER BY created_at, id`
+
+	rows, err := r.db.Query(query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query savings goals: %w", err)
+	}
+	defer rows.Close()
+
+	var goals []models.SavingsGoal
+	for rows.Next() {
+		goal, err := scanSavingsGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan savings goal row: %w", err)
+		}
+		goals = append(goals, *goal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over savings goal rows: %w", err)
+	}
+
+	return goals, nil
+}
+
+// Update saves a goal's name, target, target date, strictness and
+// completion time. A goal completed by the update has a
+// savings_goal.completed event recorded in the same transaction.
+func (r *SavingsGoalRepositoryImpl) Update(goal *models.SavingsGoal) error {
+	query := `
+		UPDATE savings_goals
+		SET name = $1, target_amount = $2, target_date = $3, strict = $4, completed_at = $5, updated_at = $6
+		WHERE id = $7`
+
+	now := time.Now()
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		var completedAt *time.Time
+		err := tx.QueryRow(`SELECT completed_at FROM savings_goals WHERE id = $1 FOR UPDATE`, goal.ID).Scan(&completedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("savings goal not found")
+			}
+			return fmt.Errorf("failed to lock savings goal: %w", err)
+		}
+
+		_, err = tx.Exec(query, goal.Name, goal.TargetAmount, goal.TargetDate, goal.Strict, goal.CompletedAt, now, goal.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update savings goal: %w", err)
+		}
+
+		if completedAt == nil && goal.CompletedAt != nil {
+			return writeSavingsGoalCompleted(tx, goal)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	goal.UpdatedAt = now
+	return nil
+}
+
+// Delete removes a savings goal, releasing the money earmarked for it
+func (r *SavingsGoalRepositoryImpl) Delete(id uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM savings_goals WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete savings goal: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("savings goal not found")
+	}
+
+	return nil
+}
+
+// ChangeAllocation earmarks amount more for a goal, or releases it when
+// amount is negative, and returns the updated goal. The goal's account is
+// locked so the account's goals never earmark more than its balance and a
+// goal never holds less than nothing. When the allocation first reaches
+// the target the goal is marked completed and a savings_goal.completed
+// event is recorded in the same transaction.
+func (r *SavingsGoalRepositoryImpl) ChangeAllocation(goalID uuid.UUID, amount float64) (*models.SavingsGoal, error) {
+	var goal *models.SavingsGoal
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		var balance float64
+		err := tx.QueryRow(`
+			SELECT a.balance FROM accounts a
+			JOIN savings_goals g ON g.account_id = a.id
+			WHERE g.id = $1
+			FOR UPDATE OF a`, goalID).Scan(&balance)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("savings goal not found")
+			}
+			return fmt.Errorf("failed to lock account: %w", err)
+		}
+
+		current, err := scanSavingsGoal(tx.QueryRow(`SELECT `+savingsGoalColumns+` FROM savings_goals WHERE id = $1`, goalID))
+		if err != nil {
+			return fmt.Errorf("failed to get savings goal: %w", err)
+		}
+
+		allocated := math.Round((current.AllocatedAmount+amount)*100) / 100
+		if allocated < 0 {
+			return fmt.Errorf("savings goal holds %s, cannot release %s", models.Amount(current.AllocatedAmount), models.Amount(-amount))
+		}
+		if amount > 0 {
+			var earmarked float64
+			err := tx.QueryRow(earmarkedFundsQuery, current.AccountID).Scan(&earmarked)
+			if err != nil {
+				return fmt.Errorf("failed to sum earmarked funds: %w", err)
+			}
+			if math.Round((earmarked+amount)*100) > math.Round(balance*100) {
+				return fmt.Errorf("account has %s available, cannot earmark %s", models.Amount(balance-earmarked), models.Amount(amount))
+			}
+		}
+
+		now := time.Now()
+		completedAt := current.CompletedAt
+		if completedAt == nil && allocated >= current.TargetAmount {
+			completedAt = &now
+		}
+
+		_, err = tx.Exec(`
+			UPDATE savings_goals
+			SET allocated_amount = $1, completed_at = $2, updated_at = $3
+			WHERE id = $4`, allocated, completedAt, now, goalID)
+		if err != nil {
+			return fmt.Errorf("failed to update savings goal allocation: %w", err)
+		}
+
+		current.AllocatedAmount = allocated
+		current.UpdatedAt = now
+		if current.CompletedAt == nil && completedAt != nil {
+			current.CompletedAt = completedAt
+			if err := writeSavingsGoalCompleted(tx, current); err != nil {
+				return err
+			}
+		}
+
+		goal = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return goal, nil
+}
+
+// GetRoundUpGoal returns the goal an account's withdrawals are rounded up
+// into, or nil when round-ups are off
+func (r *SavingsGoalRepositoryImpl) GetRoundUpGoal(accountID uuid.UUID) (*models.SavingsGoal, error) {
+	query := `
+		SELECT ` + savingsGoalColumns + `
+		FROM savings_goals
+		WHERE id = (SELECT goal_id FROM round_up_settings WHERE account_id = $1)`
+
+	goal, err := scanSavingsGoal(r.db.QueryRow(query, accountID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get round-up goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+// SetRoundUpGoal rounds an account's withdrawals up into goalID, or stops
+// rounding them up when goalID is nil
+func (r *SavingsGoalRepositoryImpl) SetRoundUpGoal(accountID uuid.UUID, goalID *uuid.UUID) error {
+	if goalID == nil {
+		if _, err := r.db.Exec(`DELETE FROM round_up_settings WHERE account_id = $1`, accountID); err != nil {
+			return fmt.Errorf("failed to turn off round-ups: %w", err)
+		}
+		return nil
+	}
+
+	query := `
+		INSERT INTO round_up_settings (account_id, goal_id, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (account_id) DO UPDATE SET goal_id = EXCLUDED.goal_id, updated_at = EXCLUDED.updated_at`
+
+	if _, err := r.db.Exec(query, accountID, *goalID, time.Now()); err != nil {
+		return fmt.Errorf("failed to turn on round-ups: %w", err)
+	}
+	return nil
+}
+
+// allocateRoundUp earmarks a withdrawal's round-up for its goal and records
+// the round-up transaction using tx. The goal is completed, with a
+// savings_goal.completed event, if the round-up first takes it to its
+// target.
+func allocateRoundUp(tx *sql.Tx, withdrawal *models.Withdrawal) error {
+	roundUp := withdrawal.RoundUp
+	goal, err := scanSavingsGoal(tx.QueryRow(`SELECT `+savingsGoalColumns+` FROM savings_goals WHERE id = $1 FOR UPDATE`, withdrawal.RoundUpGoalID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("savings goal %s no longer exists", withdrawal.RoundUpGoalID)
+		}
+		return fmt.Errorf("failed to lock savings goal: %w", err)
+	}
+	if goal.AccountID != roundUp.AccountID {
+		return fmt.Errorf("savings goal %s belongs to another account", goal.ID)
+	}
+
+	allocated := math.Round((goal.AllocatedAmount+roundUp.Amount)*100) / 100
+	completedAt := goal.CompletedAt
+	if completedAt == nil && allocated >= goal.TargetAmount {
+		completedAt = &roundUp.CreatedAt
+	}
+
+	_, err = tx.Exec(`
+		UPDATE savings_goals
+		SET allocated_amount = $1, completed_at = $2, updated_at = $3
+		WHERE id = $4`, allocated, completedAt, roundUp.CreatedAt, goal.ID)
+	if err != nil {
+		return fmt.Errorf("failed to allocate round-up: %w", err)
+	}
+	if err := insertTransaction(tx, roundUp); err != nil {
+		return err
+	}
+
+	if goal.CompletedAt == nil && completedAt != nil {
+		goal.AllocatedAmount = allocated
+		goal.CompletedAt = completedAt
+		return writeSavingsGoalCompleted(tx, goal)
+	}
+	return nil
+}
+
+// writeSavingsGoalCompleted records a savings_goal.completed event in the
+// outbox using tx, so it is published only if the completion commits
+func writeSavingsGoalCompleted(tx *sql.Tx, goal *models.SavingsGoal) error {
+	event, err := events.New(events.TypeSavingsGoalCompleted, events.SourceBankingService, events.SavingsGoalCompleted{
+		GoalID:       goal.ID,
+		UserID:       goal.UserID,
+		Name:         goal.Name,
+		TargetAmount: goal.TargetAmount,
+		CompletedAt:  goal.CompletedAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return events.WriteOutbox(tx, event)
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSavingsGoal reads a row of savingsGoalColumns
+func scanSavingsGoal(row rowScanner) (*models.SavingsGoal, error) {
+	goal := &models.SavingsGoal{}
+	err := row.Scan(
+		&goal.ID,
+		&goal.UserID,
+		&goal.AccountID,
+		&goal.Name,
+		&goal.TargetAmount,
+		&goal.TargetDate,
+		&goal.AllocatedAmount,
+		&goal.Strict,
+		&goal.CompletedAt,
+		&goal.CreatedAt,
+		&goal.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"microbank/banking-service/internal/models"
+)
+
+// StatsRepositoryImpl runs the aggregate queries behind the admin dashboard
+type StatsRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewStatsRepository creates a new stats repository
+func NewStatsRepository(db *PostgresDB) StatsRepository {
+	return &StatsRepositoryImpl{db: db}
+}
+
+// GetBankingStats counts accounts, the money they hold, the deposits and
+// withdrawals made since the cutoff, and the disputes and suspicious
+// activity findings still waiting for staff, in one query
+func (r *StatsRepositoryImpl) GetBankingStats(since time.Time) (*models.BankingStats, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM accounts),
+			(SELECT COALESCE(SUM(balance), 0) FROM accounts),
+			(SELECT COUNT(*) FROM transactions WHERE created_at >= $1 AND type IN ('deposit', 'withdrawal')),
+			(SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE created_at >= $1 AND type IN ('deposit', 'withdrawal')),
+			(SELECT COUNT(*) FROM disputes WHERE status IN ('open', 'investigating')),
+			(SELECT COUNT(*) FROM suspicious_activity_findings WHERE status = 'open')`
+
+	stats := &models.BankingStats{}
+	err := r.db.QueryRow(query, since).Scan(
+		&stats.Accounts,
+		&stats.TotalBalance,
+		&stats.TransactionsToday,
+		&stats.VolumeToday,
+		&stats.OpenDisputes,
+		&stats.OpenSuspiciousActivity,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get banking stats: %w", err)
+	}
+
+	return stats, nil
+}
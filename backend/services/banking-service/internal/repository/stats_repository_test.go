@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestStatsRepository_GetBankingStats(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewStatsRepository(db)
+	since := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM disputes WHERE status IN ('open', 'investigating')")).
+		WithArgs(since).
+		WillReturnRows(sqlmock.NewRows([]string{"accounts", "total_balance", "transactions_today", "volume_today", "open_disputes", "open_suspicious_activity"}).
+			AddRow(12, 5400.5, 7, 830.25, 2, 3))
+
+	stats, err := repo.GetBankingStats(since)
+	if err != nil {
+		t.Fatalf("GetBankingStats returned error: %v", err)
+	}
+	if stats.Accounts != 12 || stats.TotalBalance != 5400.5 || stats.TransactionsToday != 7 || stats.VolumeToday != 830.25 || stats.OpenDisputes != 2 || stats.OpenSuspiciousActivity != 3 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"microbank/banking-service/internal/models"
+)
+
+// reportDateLayout is how report dates are passed to DATE columns
+const reportDateLayout = "2006-01-02"
+
+// suspiciousActivityFindingColumns lists the suspicious_activity_findings
+// columns in the order scanSuspiciousActivityFinding reads them
+const suspiciousActivityFindingColumns = `id, report_date, rule, account_id, user_id, transaction_count, amount, baseline, description, status, reviewed_by, COALESCE(review_note, ''), reviewed_at, created_at`
+
+// SuspiciousActivityRepositoryImpl handles the suspicious activity
+// detection queries and the reports their findings are written to
+type SuspiciousActivityRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewSuspiciousActivityRepository creates a new suspicious activity
+// repository
+func NewSuspiciousActivityRepository(db *PostgresDB) SuspiciousActivityRepository {
+	return &SuspiciousActivityRepositoryImpl{db: db}
+}
+
+// FindRapidCycles returns the accounts with deposits made on day of which
+// at least rule.Percent was withdrawn again within rule.Window. Amount is
+// the total of those deposits.
+func (r *SuspiciousActivityRepositoryImpl) FindRapidCycles(day time.Time, rule models.RapidCycleRule) ([]models.SuspiciousActivityFinding, error) {
+	query := `
+		SELECT d.account_id, d.user_id, COUNT(*), SUM(d.amount), NULL::numeric
+		FROM transactions d
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(SUM(w.amount), 0) AS withdrawn
+			FROM transactions w
+			WHERE w.account_id = d.account_id AND w.type = 'withdrawal'
+				AND w.created_at > d.created_at AND w.created_at <= d.created_at + make_interval(secs => $3)
+		) w
+		WHERE d.type = 'deposit' AND d.created_at >= $1 AND d.created_at < $2
+			AND w.withdrawn >= d.amount * $4 / 100
+		GROUP BY d.account_id, d.user_id
+		ORDER BY d.account_id`
+
+	return r.findSuspiciousActivity(models.SuspiciousRuleRapidCycle, query, day, day.AddDate(0, 0, 1), rule.Window.Seconds(), rule.Percent)
+}
+
+// FindStructuring returns the accounts that made at least rule.MinCount
+// deposits or withdrawals on day just under rule.Threshold. Amount is the
+// total of those transactions.
+func (r *SuspiciousActivityRepositoryImpl) FindStructuring(day time.Time, rule models.StructuringRule) ([]models.SuspiciousActivityFinding, error) {
+	query := `
+		SELECT account_id, user_id, COUNT(*), SUM(amount), NULL::numeric
+		FROM transactions
+		WHERE type IN ('deposit', 'withdrawal') AND created_at >= $1 AND created_at < $2
+			AND amount >= $3 AND amount < $4
+		GROUP BY account_id, user_id
+		HAVING COUNT(*) >= $5
+		ORDER BY account_id`
+
+	return r.findSuspiciousActivity(models.SuspiciousRuleStructuring, query, day, day.AddDate(0, 0, 1), rule.Floor(), rule.Threshold, rule.MinCount)
+}
+
+// FindBalanceSwings returns the accounts whose balance moved on day by
+// more than rule.Multiple times its average daily movement on the days
+// with transactions in the rule.LookbackDays before. A day's movement is
+// the difference between the highest and lowest balance it reached.
+// Amount is the movement on day and Baseline the average.
+func (r *SuspiciousActivityRepositoryImpl) FindBalanceSwings(day time.Time, rule models.BalanceSwingRule) ([]models.SuspiciousActivityFinding, error) {
+	query := `
+		WITH daily AS (
+			SELECT account_id, user_id, (created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) AS transaction_count,
+				MAX(GREATEST(balance_before, balance_after)) - MIN(LEAST(balance_before, balance_after)) AS swing
+			FROM transactions
+			WHERE created_at >= $1::timestamptz - make_interval(days => $3) AND created_at < $2
+			GROUP BY account_id, user_id, day
+		), history AS (
+			SELECT account_id, ROUND(AVG(swing), 2) AS average
+			FROM daily
+			WHERE day < $6::date
+			GROUP BY account_id
+			HAVING COUNT(*) >= $4
+		)
+		SELECT d.account_id, d.user_id, d.transaction_count, d.swing, h.average
+		FROM daily d
+		JOIN history h ON h.account_id = d.account_id
+		WHERE d.day = $6::date AND h.average > 0 AND d.swing > h.average * $5
+		ORDER BY d.account_id`
+
+	return r.findSuspiciousActivity(models.SuspiciousRuleBalanceSwing, query, day, day.AddDate(0, 0, 1), rule.LookbackDays, rule.MinHistoryDays, rule.Multiple, day.Format(reportDateLayout))
+}
+
+// findSuspiciousActivity runs a detection query returning the account ID,
+// user ID, transaction count, amount and baseline of each finding
+func (r *SuspiciousActivityRepositoryImpl) findSuspiciousActivity(rule, query string, args ...interface{}) ([]models.SuspiciousActivityFinding, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s activity: %w", rule, err)
+	}
+	defer rows.Close()
+
+	var findings []models.SuspiciousActivityFinding
+	for rows.Next() {
+		finding := models.SuspiciousActivityFinding{Rule: rule}
+		if err := rows.Scan(&finding.AccountID, &finding.UserID, &finding.TransactionCount, &finding.Amount, &finding.Baseline); err != nil {
+			return nil, fmt.Errorf("failed to scan %s activity row: %w", rule, err)
+		}
+		findings = append(findings, finding)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over %s activity rows: %w", rule, err)
+	}
+
+	return findings, nil
+}
+
+// ListReports retrieves the reports of the days from from to to, both
+// included, newest first. Nil bounds are not applied.
+func (r *SuspiciousActivityRepositoryImpl) ListReports(from, to *time.Time) ([]models.SuspiciousActivityReport, error) {
+	conditions, args := reportDateConditions(from, to)
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := r.db.Query(`
+		SELECT report_date, rules, finding_count, generated_at
+		FROM suspicious_activity_reports`+where+`
+		ORDER BY report_date DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suspicious activity reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []models.SuspiciousActivityReport
+	for rows.Next() {
+		var report models.SuspiciousActivityReport
+		if err := rows.Scan(&report.ReportDate, pq.Array(&report.Rules), &report.FindingCount, &report.GeneratedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan suspicious activity report row: %w", err)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over suspicious activity report rows: %w", err)
+	}
+
+	return reports, nil
+}
+
+// SaveReport writes a report and its findings in one database transaction
+// and reports whether it was written. It is not when the day already has
+// a report, so a day made by two replicas at once is reported only once.
+func (r *SuspiciousActivityRepositoryImpl) SaveReport(report *models.SuspiciousActivityReport, findings []models.SuspiciousActivityFinding) (bool, error) {
+	saved := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		result, err := tx.Exec(`
+			INSERT INTO suspicious_activity_reports (report_date, rules, finding_count, generated_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (report_date) DO NOTHING`, report.ReportDate.Format(reportDateLayout), pq.Array(report.Rules), len(findings), now)
+		if err != nil {
+			return fmt.Errorf("failed to create suspicious activity report: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		for i := range findings {
+			finding := &findings[i]
+			_, err := tx.Exec(`
+				INSERT INTO suspicious_activity_findings (id, report_date, rule, account_id, user_id, transaction_count, amount, baseline, description, status, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+				finding.ID, report.ReportDate.Format(reportDateLayout), finding.Rule, finding.AccountID, finding.UserID, finding.TransactionCount, finding.Amount, finding.Baseline, finding.Description, models.FindingStatusOpen, now)
+			if err != nil {
+				return fmt.Errorf("failed to create suspicious activity finding: %w", err)
+			}
+			finding.ReportDate = report.ReportDate
+			finding.Status = models.FindingStatusOpen
+			finding.CreatedAt = now
+		}
+
+		report.FindingCount = len(findings)
+		report.GeneratedAt = now
+		saved = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return saved, nil
+}
+
+// ListFindings retrieves one page of findings matching the filter, newest
+// report first, along with the total number of matches
+func (r *SuspiciousActivityRepositoryImpl) ListFindings(filter models.SuspiciousActivityFilter) ([]models.SuspiciousActivityFinding, int, error) {
+	conditions, args := reportDateConditions(filter.From, filter.To)
+	if filter.Rule != "" {
+		args = append(args, filter.Rule)
+		conditions = append(conditions, fmt.Sprintf("rule = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Count all matches
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM suspicious_activity_findings`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count suspicious activity findings: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+suspiciousActivityFindingColumns+`
+		FROM suspicious_activity_findings`+where+`
+		ORDER BY report_date DESC, rule, account_id
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := r.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query suspicious activity findings: %w", err)
+	}
+	defer rows.Close()
+
+	var findings []models.SuspiciousActivityFinding
+	for rows.Next() {
+		finding, err := scanSuspiciousActivityFinding(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan suspicious activity finding row: %w", err)
+		}
+		findings = append(findings, *finding)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating over suspicious activity finding rows: %w", err)
+	}
+
+	return findings, total, nil
+}
+
+// GetFinding retrieves a finding by its ID
+func (r *SuspiciousActivityRepositoryImpl) GetFinding(id uuid.UUID) (*models.SuspiciousActivityFinding, error) {
+	query := `SELECT ` + suspiciousActivityFindingColumns + ` FROM suspicious_activity_findings WHERE id = $1`
+
+	finding, err := scanSuspiciousActivityFinding(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("suspicious activity finding not found")
+		}
+		return nil, fmt.Errorf("failed to get suspicious activity finding: %w", err)
+	}
+
+	return finding, nil
+}
+
+// ReviewFinding marks an open finding reviewed by finding.ReviewedBy with
+// finding.ReviewNote, failing if it was already reviewed
+func (r *SuspiciousActivityRepositoryImpl) ReviewFinding(finding *models.SuspiciousActivityFinding) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		UPDATE suspicious_activity_findings
+		SET status = $1, reviewed_by = $2, review_note = $3, reviewed_at = $4
+		WHERE id = $5 AND status = $6`, models.FindingStatusReviewed, finding.ReviewedBy, finding.ReviewNote, now, finding.ID, models.FindingStatusOpen)
+	if err != nil {
+		return fmt.Errorf("failed to review suspicious activity finding: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("suspicious activity finding %s is not open", finding.ID)
+	}
+
+	finding.Status = models.FindingStatusReviewed
+	finding.ReviewedAt = &now
+	return nil
+}
+
+// reportDateConditions returns the conditions and arguments limiting
+// report_date to from and to, both included
+func reportDateConditions(from, to *time.Time) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if from != nil {
+		args = append(args, from.Format(reportDateLayout))
+		conditions = append(conditions, fmt.Sprintf("report_date >= $%d", len(args)))
+	}
+	if to != nil {
+		args = append(args, to.Format(reportDateLayout))
+		conditions = append(conditions, fmt.Sprintf("report_date <= $%d", len(args)))
+	}
+	return conditions, args
+}
+
+// scanSuspiciousActivityFinding reads a row of
+// suspiciousActivityFindingColumns
+func scanSuspiciousActivityFinding(row rowScanner) (*models.SuspiciousActivityFinding, error) {
+	finding := &models.SuspiciousActivityFinding{}
+	err := row.Scan(
+		&finding.ID,
+		&finding.ReportDate,
+		&finding.Rule,
+		&finding.AccountID,
+		&finding.UserID,
+		&finding.TransactionCount,
+		&finding.Amount,
+		&finding.Baseline,
+		&finding.Description,
+		&finding.Status,
+		&finding.ReviewedBy,
+		&finding.ReviewNote,
+		&finding.ReviewedAt,
+		&finding.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return finding, nil
+}
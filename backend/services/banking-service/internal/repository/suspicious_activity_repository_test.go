@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+func TestSuspiciousActivityRepository_FindStructuring(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewSuspiciousActivityRepository(db)
+	day := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	accountID, userID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("HAVING COUNT(*) >= $5")).
+		WithArgs(day, day.AddDate(0, 0, 1), 9000.0, 10000.0, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "user_id", "count", "sum", "numeric"}).
+			AddRow(accountID, userID, 4, 38500.0, nil))
+
+	findings, err := repo.FindStructuring(day, models.StructuringRule{Enabled: true, Threshold: 10000, MarginPercent: 10, MinCount: 3})
+	if err != nil {
+		t.Fatalf("FindStructuring returned error: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(findings))
+	}
+	finding := findings[0]
+	if finding.Rule != models.SuspiciousRuleStructuring || finding.AccountID != accountID || finding.TransactionCount != 4 || finding.Amount != 38500 || finding.Baseline != nil {
+		t.Errorf("Expected 4 structuring transactions totalling 38500 on account %s, got %+v", accountID, finding)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSuspiciousActivityRepository_SaveReport(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewSuspiciousActivityRepository(db)
+	report := &models.SuspiciousActivityReport{
+		ReportDate: time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC),
+		Rules:      []string{models.SuspiciousRuleRapidCycle},
+	}
+	findings := []models.SuspiciousActivityFinding{{ID: uuid.New(), Rule: models.SuspiciousRuleRapidCycle, AccountID: uuid.New(), UserID: uuid.New(), TransactionCount: 1, Amount: 5000, Description: "1 deposit"}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO suspicious_activity_reports")).
+		WithArgs("2026-10-17", sqlmock.AnyArg(), 1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO suspicious_activity_findings")).
+		WithArgs(findings[0].ID, "2026-10-17", models.SuspiciousRuleRapidCycle, findings[0].AccountID, findings[0].UserID, 1, 5000.0, nil, "1 deposit", models.FindingStatusOpen, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	saved, err := repo.SaveReport(report, findings)
+	if err != nil {
+		t.Fatalf("SaveReport returned error: %v", err)
+	}
+	if !saved {
+		t.Fatal("Expected the report to be saved")
+	}
+	if report.FindingCount != 1 || findings[0].Status != models.FindingStatusOpen || !findings[0].ReportDate.Equal(report.ReportDate) {
+		t.Errorf("Expected the report and its open finding to be filled in, got %+v and %+v", report, findings[0])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSuspiciousActivityRepository_SaveReportSkipsReportedDays(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewSuspiciousActivityRepository(db)
+	report := &models.SuspiciousActivityReport{ReportDate: time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)}
+	findings := []models.SuspiciousActivityFinding{{ID: uuid.New(), Rule: models.SuspiciousRuleStructuring}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO suspicious_activity_reports")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	saved, err := repo.SaveReport(report, findings)
+	if err != nil {
+		t.Fatalf("SaveReport returned error: %v", err)
+	}
+	if saved {
+		t.Error("Expected a day that already has a report not to be saved again")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSuspiciousActivityRepository_ListFindingsFilters(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewSuspiciousActivityRepository(db)
+	from := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM suspicious_activity_findings WHERE report_date >= $1 AND report_date <= $2 AND rule = $3 AND status = $4")).
+		WithArgs("2026-10-01", "2026-10-17", models.SuspiciousRuleBalanceSwing, models.FindingStatusOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta("LIMIT $5 OFFSET $6")).
+		WithArgs("2026-10-01", "2026-10-17", models.SuspiciousRuleBalanceSwing, models.FindingStatusOpen, 50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if _, _, err := repo.ListFindings(models.SuspiciousActivityFilter{From: &from, To: &to, Rule: models.SuspiciousRuleBalanceSwing, Status: models.FindingStatusOpen, Limit: 50}); err != nil {
+		t.Fatalf("ListFindings returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSuspiciousActivityRepository_ReviewFindingRefusesReviewedFindings(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewSuspiciousActivityRepository(db)
+	reviewerID := uuid.New()
+	finding := &models.SuspiciousActivityFinding{ID: uuid.New(), ReviewedBy: &reviewerID, ReviewNote: "Salary and rent"}
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE suspicious_activity_findings")).
+		WithArgs(models.FindingStatusReviewed, &reviewerID, "Salary and rent", sqlmock.AnyArg(), finding.ID, models.FindingStatusOpen).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := repo.ReviewFinding(finding); err == nil {
+		t.Fatal("Expected an error reviewing a finding that is not open, got nil")
+	}
+	if finding.ReviewedAt != nil {
+		t.Errorf("Expected the finding not to be marked reviewed, got %+v", finding)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
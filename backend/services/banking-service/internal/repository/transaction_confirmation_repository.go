@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// transactionConfirmationColumns lists the transaction_confirmations
+// columns in the order scanTransactionConfirmation reads them
+const transactionConfirmationColumns = `id, user_id, kind, amount, request, token_hash, expires_at, used_at, created_at`
+
+// TransactionConfirmationRepositoryImpl handles all database operations
+// related to confirmation thresholds and the withdrawals and transfers
+// waiting for confirmation
+type TransactionConfirmationRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewTransactionConfirmationRepository creates a new transaction
+// confirmation repository
+func NewTransactionConfirmationRepository(db *PostgresDB) TransactionConfirmationRepository {
+	return &TransactionConfirmationRepositoryImpl{db: db}
+}
+
+// GetThreshold retrieves the threshold a user chose, or nil when they
+// never chose one
+func (r *TransactionConfirmationRepositoryImpl) GetThreshold(userID uuid.UUID) (*float64, error) {
+	query := `SELECT threshold FROM confirmation_thresholds WHERE user_id = $1`
+
+	var threshold float64
+	if err := r.db.QueryRow(query, userID).Scan(&threshold); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get confirmation threshold: %w", err)
+	}
+
+	return &threshold, nil
+}
+
+// SetThreshold stores the threshold a user chose, replacing any earlier
+// one
+func (r *TransactionConfirmationRepositoryImpl) SetThreshold(userID uuid.UUID, threshold float64) error {
+	query := `
+		INSERT INTO confirmation_thresholds (user_id, threshold, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET threshold = EXCLUDED.threshold, updated_at = EXCLUDED.updated_at`
+
+	if _, err := r.db.Exec(query, userID, threshold, time.Now()); err != nil {
+		return fmt.Errorf("failed to set confirmation threshold: %w", err)
+	}
+	return nil
+}
+
+// Create stores a withdrawal or transfer waiting for confirmation
+func (r *TransactionConfirmationRepositoryImpl) Create(confirmation *models.TransactionConfirmation) error {
+	query := `
+		INSERT INTO transaction_confirmations (user_id, kind, amount, request, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	confirmation.CreatedAt = time.Now()
+	err := r.db.QueryRow(query, confirmation.UserID, confirmation.Kind, confirmation.Amount, []byte(confirmation.Request), confirmation.TokenHash, confirmation.ExpiresAt, confirmation.CreatedAt).Scan(&confirmation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create transaction confirmation: %w", err)
+	}
+
+	return nil
+}
+
+// Consume marks the user's confirmation with the token hash used at now
+// and returns it. It returns nil when there is no such confirmation or it
+// expired or was already used; the update only succeeds once, so a token
+// confirmed twice at the same time is only used once.
+func (r *TransactionConfirmationRepositoryImpl) Consume(userID uuid.UUID, tokenHash string, now time.Time) (*models.TransactionConfirmation, error) {
+	query := `
+		UPDATE transaction_confirmations
+		SET used_at = $3
+		WHERE token_hash = $1 AND user_id = $2 AND used_at IS NULL AND expires_at > $3
+		RETURNING ` + transactionConfirmationColumns
+
+	confirmation, err := scanTransactionConfirmation(r.db.QueryRow(query, tokenHash, userID, now))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to use transaction confirmation: %w", err)
+	}
+
+	return confirmation, nil
+}
+
+// scanTransactionConfirmation reads a row of
+// transactionConfirmationColumns
+func scanTransactionConfirmation(row rowScanner) (*models.TransactionConfirmation, error) {
+	confirmation := &models.TransactionConfirmation{}
+	var request []byte
+	err := row.Scan(
+		&confirmation.ID,
+		&confirmation.UserID,
+		&confirmation.Kind,
+		&confirmation.Amount,
+		&request,
+		&confirmation.TokenHash,
+		&confirmation.ExpiresAt,
+		&confirmation.UsedAt,
+		&confirmation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	confirmation.Request = request
+	return confirmation, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+var transactionConfirmationRowColumns = []string{"id", "user_id", "kind", "amount", "request", "token_hash", "expires_at", "used_at", "created_at"}
+
+func TestTransactionConfirmationRepository_ConsumeUsesTokenOnce(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionConfirmationRepository(db)
+	id, userID := uuid.New(), uuid.New()
+	now := time.Now()
+	consume := regexp.QuoteMeta("WHERE token_hash = $1 AND user_id = $2 AND used_at IS NULL AND expires_at > $3")
+
+	mock.ExpectQuery(consume).
+		WithArgs("hash", userID, now).
+		WillReturnRows(sqlmock.NewRows(transactionConfirmationRowColumns).
+			AddRow(id, userID, "withdrawal", 1500.0, []byte(`{"amount":1500}`), "hash", now.Add(5*time.Minute), now, now.Add(-time.Minute)))
+	mock.ExpectQuery(consume).
+		WithArgs("hash", userID, now).
+		WillReturnRows(sqlmock.NewRows(transactionConfirmationRowColumns))
+
+	confirmation, err := repo.Consume(userID, "hash", now)
+	if err != nil {
+		t.Fatalf("Consume returned error: %v", err)
+	}
+	if confirmation == nil || confirmation.ID != id || confirmation.Kind != models.TransactionConfirmationWithdrawal || string(confirmation.Request) != `{"amount":1500}` || confirmation.UsedAt == nil {
+		t.Errorf("Expected the withdrawal to be used, got %+v", confirmation)
+	}
+
+	// A used, expired or unknown token matches nothing
+	if confirmation, err := repo.Consume(userID, "hash", now); err != nil || confirmation != nil {
+		t.Errorf("Expected nothing to be used again, got %+v (%v)", confirmation, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,417 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"microbank/banking-service/internal/models"
+)
+
+// createTransactionsTable creates the transactions table, partitioned by
+// the month each transaction was made in. A primary key has to include
+// the partition key, so other tables refer to transactions without
+// foreign keys.
+const createTransactionsTable = `
+	CREATE TABLE IF NOT EXISTS transactions (
+		id UUID NOT NULL DEFAULT gen_random_uuid(),
+		account_id UUID REFERENCES accounts(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL,
+		type VARCHAR(20) NOT NULL CONSTRAINT transactions_type_check CHECK (type IN ('deposit', 'withdrawal', 'fee', 'round_up', 'refund', 'transfer_out', 'transfer_in', 'external_transfer', 'cheque_return', 'bonus')),
+		amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+		balance_before DECIMAL(15,2) NOT NULL,
+		balance_after DECIMAL(15,2) NOT NULL,
+		description TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		related_transaction_id UUID,
+		chain_seq BIGINT,
+		integrity_hash CHAR(64),
+		raw_description TEXT,
+		PRIMARY KEY (id, created_at)
+	) PARTITION BY RANGE (created_at);`
+
+// createArchiveTables creates the table archived transaction partitions
+// are attached to. Its columns and indexes match the live table's listing
+// and integrity chain indexes, so the partitions' own indexes are attached
+// along with them.
+const createArchiveTables = `
+	CREATE SCHEMA IF NOT EXISTS archive;
+	CREATE TABLE IF NOT EXISTS archive.transactions (
+		id UUID NOT NULL,
+		account_id UUID,
+		user_id UUID NOT NULL,
+		type VARCHAR(20) NOT NULL,
+		amount DECIMAL(15,2) NOT NULL,
+		balance_before DECIMAL(15,2) NOT NULL,
+		balance_after DECIMAL(15,2) NOT NULL,
+		description TEXT,
+		created_at TIMESTAMPTZ NOT NULL,
+		related_transaction_id UUID,
+		chain_seq BIGINT,
+		integrity_hash CHAR(64),
+		raw_description TEXT,
+		PRIMARY KEY (id, created_at)
+	) PARTITION BY RANGE (created_at);
+	ALTER TABLE archive.transactions ADD COLUMN IF NOT EXISTS chain_seq BIGINT;
+	ALTER TABLE archive.transactions ADD COLUMN IF NOT EXISTS integrity_hash CHAR(64);
+	ALTER TABLE archive.transactions ADD COLUMN IF NOT EXISTS raw_description TEXT;
+	CREATE INDEX IF NOT EXISTS idx_transactions_account_id_chain_seq ON archive.transactions(account_id, chain_seq);
+	CREATE INDEX IF NOT EXISTS idx_transactions_account_id_created_at_id ON archive.transactions(account_id, created_at DESC, id DESC);
+	CREATE INDEX IF NOT EXISTS idx_transactions_user_id_created_at_id ON archive.transactions(user_id, created_at DESC, id DESC);`
+
+// transactionPartitionLockKey is the advisory lock held while partitions
+// are created or archived, so replicas never change them at the same time
+const transactionPartitionLockKey = 7263402
+
+// transactionPartitionMonthsAhead is how many months after the current one
+// have partitions made ahead of time
+const transactionPartitionMonthsAhead = 3
+
+// transactionPartitionLayout names the partition of the transactions made
+// in a month, such as transactions_y2026m10
+const transactionPartitionLayout = "transactions_y2006m01"
+
+// queryExecer is satisfied by both the database and its transactions
+type queryExecer interface {
+	execer
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// TransactionPartitionRepositoryImpl handles the monthly partitions of the
+// transactions table and the archive old partitions are moved to
+type TransactionPartitionRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewTransactionPartitionRepository creates a new transaction partition
+// repository
+func NewTransactionPartitionRepository(db *PostgresDB) TransactionPartitionRepository {
+	return &TransactionPartitionRepositoryImpl{db: db}
+}
+
+// EnsurePartitions creates the partitions for the month of now, the month
+// before it and the months ahead that do not exist yet, and returns their
+// names
+func (r *TransactionPartitionRepositoryImpl) EnsurePartitions(now time.Time) ([]string, error) {
+	var created []string
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, transactionPartitionLockKey); err != nil {
+			return fmt.Errorf("failed to lock transaction partitions: %w", err)
+		}
+
+		var err error
+		created, err = ensureTransactionPartitions(tx, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// ArchivePartitions moves the partitions holding only transactions made
+// before the month of before from the live table to archive.transactions,
+// and returns their names. A partition is detached and attached as a
+// whole, so its rows are not copied.
+func (r *TransactionPartitionRepositoryImpl) ArchivePartitions(before time.Time) ([]string, error) {
+	cutoff := startOfMonth(before)
+
+	var archived []string
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, transactionPartitionLockKey); err != nil {
+			return fmt.Errorf("failed to lock transaction partitions: %w", err)
+		}
+
+		partitions, err := transactionPartitions(tx, "")
+		if err != nil {
+			return err
+		}
+
+		for _, partition := range partitions {
+			end := partition.month.AddDate(0, 1, 0)
+			if end.After(cutoff) {
+				break
+			}
+
+			query := fmt.Sprintf(`
+				ALTER TABLE transactions DETACH PARTITION %[1]s;
+				ALTER TABLE %[1]s SET SCHEMA archive;
+				ALTER TABLE archive.transactions ATTACH PARTITION archive.%[1]s FOR VALUES FROM ('%[2]s') TO ('%[3]s');`,
+				partition.name, partition.month.Format("2006-01-02"), end.Format("2006-01-02"))
+			if _, err := tx.Exec(query); err != nil {
+				return fmt.Errorf("failed to archive transaction partition %s: %w", partition.name, err)
+			}
+			archived = append(archived, partition.name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return archived, nil
+}
+
+// GetArchivedTransactions retrieves archived transactions matching filter,
+// newest first
+func (r *TransactionPartitionRepositoryImpl) GetArchivedTransactions(filter models.ArchivedTransactionFilter) ([]models.Transaction, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.AccountID != nil {
+		args = append(args, *filter.AccountID)
+		conditions = append(conditions, fmt.Sprintf("account_id = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at, related_transaction_id
+		FROM archive.transactions`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := r.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archived transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var transaction models.Transaction
+		err := rows.Scan(
+			&transaction.ID,
+			&transaction.AccountID,
+			&transaction.UserID,
+			&transaction.Type,
+			&transaction.Amount,
+			&transaction.BalanceBefore,
+			&transaction.BalanceAfter,
+			&transaction.Description,
+			&transaction.CreatedAt,
+			&transaction.RelatedTransactionID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan archived transaction row: %w", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over archived transaction rows: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// partitionTransactions rebuilds the transactions tables that cannot be
+// changed in place, in one transaction: a live table created before it was
+// partitioned, and live or archive tables whose created_at is still
+// timestamp without time zone, which as the partition key cannot change
+// type. The rows are copied to a new partitioned table, reading legacy
+// times as wall-clock times in legacyZone. The foreign keys other tables
+// had to the live table are dropped along with it.
+func partitionTransactions(db *sql.DB, legacyZone string) error {
+	return (&PostgresDB{db}).withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, transactionPartitionLockKey); err != nil {
+			return fmt.Errorf("failed to lock transaction partitions: %w", err)
+		}
+
+		if err := rebuildTransactionsTable(tx, "", createTransactionsTable, legacyZone); err != nil {
+			return err
+		}
+		return rebuildTransactionsTable(tx, "archive", createArchiveTables, legacyZone)
+	})
+}
+
+// rebuildTransactionsTable replaces the transactions table of schema ("" for
+// the live one) with a partitioned one made by create, unless it is already
+// partitioned by timestamptz or does not exist. The old table and its
+// partitions are renamed out of the way, and dropped once their rows are
+// copied.
+func rebuildTransactionsTable(tx *sql.Tx, schema, create, legacyZone string) error {
+	table := qualify(schema, "transactions")
+
+	var kind string
+	var zoned bool
+	err := tx.QueryRow(`
+		SELECT c.relkind, a.atttypid = 'timestamptz'::regtype
+		FROM pg_class c
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attname = 'created_at'
+		WHERE c.oid = to_regclass($1)`, table).Scan(&kind, &zoned)
+	if err == sql.ErrNoRows || (kind == "p" && zoned) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up %s table: %w", table, err)
+	}
+
+	log.Printf("Rebuilding the %s table partitioned by month in UTC", table)
+	partitions, err := transactionPartitions(tx, schema)
+	if err != nil {
+		return err
+	}
+	// The renamed tables keep their index names, which the new primary key
+	// would clash with
+	for _, partition := range partitions {
+		if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE %s RENAME TO %s_legacy`, qualify(schema, partition.name), partition.name)); err != nil {
+			return fmt.Errorf("failed to rename transaction partition %s: %w", partition.name, err)
+		}
+	}
+	_, err = tx.Exec(fmt.Sprintf(`
+		ALTER TABLE %s RENAME TO transactions_legacy;
+		ALTER INDEX %s RENAME TO transactions_legacy_pkey;`, table, qualify(schema, "transactions_pkey")))
+	if err != nil {
+		return fmt.Errorf("failed to rename %s table: %w", table, err)
+	}
+	if _, err := tx.Exec(create); err != nil {
+		return fmt.Errorf("failed to create partitioned %s table: %w", table, err)
+	}
+
+	// Sessions use UTC, so a time already zoned is read back unchanged
+	zone := legacyZone
+	if zoned {
+		zone = "UTC"
+	}
+	legacy := qualify(schema, "transactions_legacy")
+
+	var first, last time.Time
+	err = tx.QueryRow(fmt.Sprintf(`
+		SELECT COALESCE(MIN(created_at::timestamp AT TIME ZONE $1), now()), GREATEST(MAX(created_at::timestamp AT TIME ZONE $1), now())
+		FROM %s`, legacy), zone).Scan(&first, &last)
+	if err != nil {
+		return fmt.Errorf("failed to find range of transactions: %w", err)
+	}
+	if _, err := createTransactionPartitions(tx, schema, first, last); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`
+		INSERT INTO %s (id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at, related_transaction_id)
+		SELECT id, account_id, user_id, type, amount, balance_before, balance_after, description, COALESCE(created_at::timestamp AT TIME ZONE $1, now()), related_transaction_id
+		FROM %s`, table, legacy), zone)
+	if err != nil {
+		return fmt.Errorf("failed to move transactions to partitioned %s table: %w", table, err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`DROP TABLE %s CASCADE`, legacy)); err != nil {
+		return fmt.Errorf("failed to drop legacy %s table: %w", table, err)
+	}
+
+	return nil
+}
+
+// ensureTransactionPartitions creates the partitions for the month of now,
+// the month before it and the months ahead that do not exist yet. The
+// month before is covered for clocks that are behind the database's.
+func ensureTransactionPartitions(db queryExecer, now time.Time) ([]string, error) {
+	month := startOfMonth(now)
+	return createTransactionPartitions(db, "", month.AddDate(0, -1, 0), month.AddDate(0, transactionPartitionMonthsAhead, 0))
+}
+
+// createTransactionPartitions creates the partitions of the transactions
+// table of schema ("" for the live one) for the months from the month of
+// from through the month of through that do not exist yet, and returns
+// their names
+func createTransactionPartitions(db queryExecer, schema string, from, through time.Time) ([]string, error) {
+	partitions, err := transactionPartitions(db, schema)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(partitions))
+	for _, partition := range partitions {
+		existing[partition.name] = true
+	}
+
+	var created []string
+	for month := startOfMonth(from); !month.After(through); month = month.AddDate(0, 1, 0) {
+		name := month.Format(transactionPartitionLayout)
+		if existing[name] {
+			continue
+		}
+
+		query := fmt.Sprintf(`CREATE TABLE %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')`,
+			qualify(schema, name), qualify(schema, "transactions"), month.Format("2006-01-02"), month.AddDate(0, 1, 0).Format("2006-01-02"))
+		if _, err := db.Exec(query); err != nil {
+			return nil, fmt.Errorf("failed to create transaction partition %s: %w", name, err)
+		}
+		created = append(created, name)
+	}
+
+	return created, nil
+}
+
+// transactionPartition is a live partition of the transactions table and
+// the month whose transactions it holds
+type transactionPartition struct {
+	name  string
+	month time.Time
+}
+
+// transactionPartitions returns the partitions of the transactions table
+// of schema ("" for the live one), oldest first
+func transactionPartitions(db queryExecer, schema string) ([]transactionPartition, error) {
+	rows, err := db.Query(`
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = $1::regclass
+		ORDER BY c.relname`, qualify(schema, "transactions"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []transactionPartition
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction partition row: %w", err)
+		}
+		// Partitions not named by month were not made here; leave them be
+		month, err := time.Parse(transactionPartitionLayout, name)
+		if err != nil {
+			continue
+		}
+		partitions = append(partitions, transactionPartition{name: name, month: month})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over transaction partition rows: %w", err)
+	}
+
+	return partitions, nil
+}
+
+// qualify returns name in schema, or name alone when schema is ""
+func qualify(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
+// startOfMonth returns midnight UTC on the first day of t's month in UTC.
+// Partitions hold UTC months; sessions use UTC, so their bounds are read
+// as UTC midnights.
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+func TestTransactionPartitionRepository_EnsurePartitionsCreatesMissingMonths(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionPartitionRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+		WithArgs(transactionPartitionLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_inherits")).
+		WillReturnRows(sqlmock.NewRows([]string{"relname"}).
+			AddRow("transactions_y2026m09").
+			AddRow("transactions_y2026m10").
+			AddRow("transactions_legacy"))
+	for _, bounds := range [][3]string{
+		{"transactions_y2026m11", "2026-11-01", "2026-12-01"},
+		{"transactions_y2026m12", "2026-12-01", "2027-01-01"},
+		{"transactions_y2027m01", "2027-01-01", "2027-02-01"},
+	} {
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE " + bounds[0] + " PARTITION OF transactions FOR VALUES FROM ('" + bounds[1] + "') TO ('" + bounds[2] + "')")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectCommit()
+
+	created, err := repo.EnsurePartitions(time.Date(2026, 10, 31, 23, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("EnsurePartitions returned error: %v", err)
+	}
+	if len(created) != 3 || created[0] != "transactions_y2026m11" || created[2] != "transactions_y2027m01" {
+		t.Errorf("Expected the partitions for November through January, got %v", created)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionPartitionRepository_ArchivePartitionsMovesOldMonths(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionPartitionRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+		WithArgs(transactionPartitionLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_inherits")).
+		WillReturnRows(sqlmock.NewRows([]string{"relname"}).
+			AddRow("transactions_y2024m08").
+			AddRow("transactions_y2024m09").
+			AddRow("transactions_y2024m10"))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE transactions DETACH PARTITION transactions_y2024m08;") +
+		`\s+` + regexp.QuoteMeta("ALTER TABLE transactions_y2024m08 SET SCHEMA archive;") +
+		`\s+` + regexp.QuoteMeta("ALTER TABLE archive.transactions ATTACH PARTITION archive.transactions_y2024m08 FOR VALUES FROM ('2024-08-01') TO ('2024-09-01');")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DETACH PARTITION transactions_y2024m09")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	archived, err := repo.ArchivePartitions(time.Date(2024, 10, 1, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("ArchivePartitions returned error: %v", err)
+	}
+	if len(archived) != 2 || archived[0] != "transactions_y2024m08" || archived[1] != "transactions_y2024m09" {
+		t.Errorf("Expected August and September to be archived, got %v", archived)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPartitionTransactions_RebuildsLegacyTable(t *testing.T) {
+	db, mock := newMockDB(t)
+	zone := "America/New_York"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+		WithArgs(transactionPartitionLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT c.relkind")).
+		WithArgs("transactions").
+		WillReturnRows(sqlmock.NewRows([]string{"relkind", "zoned"}).AddRow("p", false))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_inherits")).
+		WithArgs("transactions").
+		WillReturnRows(sqlmock.NewRows([]string{"relname"}).AddRow("transactions_y2026m10"))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE transactions_y2026m10 RENAME TO transactions_y2026m10_legacy")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE transactions RENAME TO transactions_legacy;") +
+		`\s+` + regexp.QuoteMeta("ALTER INDEX transactions_pkey RENAME TO transactions_legacy_pkey;")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS transactions (")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// October in New York runs past the end of October in UTC
+	mock.ExpectQuery(regexp.QuoteMeta("MIN(created_at::timestamp AT TIME ZONE $1)")).
+		WithArgs(zone).
+		WillReturnRows(sqlmock.NewRows([]string{"first", "last"}).
+			AddRow(time.Date(2026, 10, 1, 4, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 3, 59, 0, 0, time.UTC)))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_inherits")).
+		WithArgs("transactions").
+		WillReturnRows(sqlmock.NewRows([]string{"relname"}))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE transactions_y2026m10 PARTITION OF transactions FOR VALUES FROM ('2026-10-01') TO ('2026-11-01')")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE transactions_y2026m11 PARTITION OF transactions FOR VALUES FROM ('2026-11-01') TO ('2026-12-01')")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("COALESCE(created_at::timestamp AT TIME ZONE $1, now())") + `[\s\S]+FROM transactions_legacy`).
+		WithArgs(zone).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE transactions_legacy CASCADE")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// No archive yet
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT c.relkind")).
+		WithArgs("archive.transactions").
+		WillReturnRows(sqlmock.NewRows([]string{"relkind", "zoned"}))
+	mock.ExpectCommit()
+
+	if err := partitionTransactions(db.DB, zone); err != nil {
+		t.Fatalf("partitionTransactions returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPartitionTransactions_SkipsZonedTables(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+		WithArgs(transactionPartitionLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, table := range []string{"transactions", "archive.transactions"} {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT c.relkind")).
+			WithArgs(table).
+			WillReturnRows(sqlmock.NewRows([]string{"relkind", "zoned"}).AddRow("p", true))
+	}
+	mock.ExpectCommit()
+
+	if err := partitionTransactions(db.DB, "UTC"); err != nil {
+		t.Fatalf("partitionTransactions returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionPartitionRepository_GetArchivedTransactions(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionPartitionRepository(db)
+	userID := uuid.New()
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "account_id", "user_id", "type", "amount", "balance_before", "balance_after", "description", "created_at", "related_transaction_id"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM archive.transactions WHERE user_id = $1 AND created_at >= $2")+`\s+ORDER BY created_at DESC, id DESC\s+LIMIT \$3 OFFSET \$4`).
+		WithArgs(userID, from, 50, 0).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), uuid.New(), userID, "deposit", 10.0, 0.0, 10.0, "", from.Add(time.Hour), nil))
+
+	transactions, err := repo.GetArchivedTransactions(models.ArchivedTransactionFilter{UserID: &userID, From: &from, Limit: 50})
+	if err != nil {
+		t.Fatalf("GetArchivedTransactions returned error: %v", err)
+	}
+	if len(transactions) != 1 || transactions[0].UserID != userID {
+		t.Errorf("Expected one archived transaction of user %s, got %+v", userID, transactions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
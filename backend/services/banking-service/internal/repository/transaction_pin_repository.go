@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/events"
+)
+
+// transactionPINColumns lists the transaction_pins columns in the order
+// scanTransactionPIN reads them
+const transactionPINColumns = `account_id, user_id, COALESCE(pin_hash, ''), failed_attempts, locked_until, reset_required, created_at, updated_at`
+
+// TransactionPINRepositoryImpl handles all database operations related to
+// transaction PINs
+type TransactionPINRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewTransactionPINRepository creates a new transaction PIN repository
+func NewTransactionPINRepository(db *PostgresDB) TransactionPINRepository {
+	return &TransactionPINRepositoryImpl{db: db}
+}
+
+// GetByUserID retrieves a user's transaction PIN, or nil when they never
+// set one
+func (r *TransactionPINRepositoryImpl) GetByUserID(userID uuid.UUID) (*models.TransactionPIN, error) {
+	query := `SELECT ` + transactionPINColumns + ` FROM transaction_pins WHERE user_id = $1`
+
+	pin, err := scanTransactionPIN(r.db.QueryRow(query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get transaction PIN: %w", err)
+	}
+
+	return pin, nil
+}
+
+// Save stores the PIN's hash for its account, replacing any earlier one,
+// and clears its failed attempts, lockout and pending reset
+func (r *TransactionPINRepositoryImpl) Save(pin *models.TransactionPIN) error {
+	query := `
+		INSERT INTO transaction_pins (account_id, user_id, pin_hash, failed_attempts, locked_until, reset_required, created_at, updated_at)
+		VALUES ($1, $2, $3, 0, NULL, FALSE, $4, $4)
+		ON CONFLICT (account_id) DO UPDATE
+		SET pin_hash = EXCLUDED.pin_hash, failed_attempts = 0, locked_until = NULL, reset_required = FALSE, updated_at = EXCLUDED.updated_at
+		RETURNING created_at`
+
+	now := time.Now()
+	if err := r.db.QueryRow(query, pin.AccountID, pin.UserID, pin.PINHash, now).Scan(&pin.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save transaction PIN: %w", err)
+	}
+
+	pin.FailedAttempts = 0
+	pin.LockedUntil = nil
+	pin.ResetRequired = false
+	pin.UpdatedAt = now
+	return nil
+}
+
+// RecordAttempt counts an attempt at the PIN of an account before it is
+// checked, so attempts made at the same time cannot get past the limit.
+// The attempt that reaches rules.MaxAttempts locks the PIN for
+// rules.Lockout, and the first one after a lockout starts counting again.
+// It returns the PIN as counted, or nil without counting when the PIN is
+// locked at now. A right attempt is cleared with ClearAttempts.
+func (r *TransactionPINRepositoryImpl) RecordAttempt(accountID uuid.UUID, rules models.TransactionPINRules, now time.Time) (*models.TransactionPIN, error) {
+	query := `
+		UPDATE transaction_pins
+		SET failed_attempts = CASE WHEN locked_until IS NULL THEN failed_attempts ELSE 0 END + 1,
+			locked_until = CASE WHEN CASE WHEN locked_until IS NULL THEN failed_attempts ELSE 0 END + 1 >= $2 THEN $3::timestamptz END,
+			updated_at = $4
+		WHERE account_id = $1 AND (locked_until IS NULL OR locked_until <= $4)
+		RETURNING ` + transactionPINColumns
+
+	pin, err := scanTransactionPIN(r.db.QueryRow(query, accountID, rules.MaxAttempts, now.Add(rules.Lockout), now))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to record transaction PIN attempt: %w", err)
+	}
+
+	return pin, nil
+}
+
+// ClearAttempts forgets the counted attempts at the PIN of an account,
+// lifting any lockout, once one was right
+func (r *TransactionPINRepositoryImpl) ClearAttempts(accountID uuid.UUID) error {
+	query := `
+		UPDATE transaction_pins
+		SET failed_attempts = 0, locked_until = NULL, updated_at = $2
+		WHERE account_id = $1`
+
+	if _, err := r.db.Exec(query, accountID, time.Now()); err != nil {
+		return fmt.Errorf("failed to clear transaction PIN attempts: %w", err)
+	}
+	return nil
+}
+
+// Reset removes the PIN of an account on behalf of staff, so withdrawals
+// and transfers are refused until its user sets a new one, and records a
+// transaction_pin.reset event in the outbox in the same database
+// transaction. It returns the reset PIN, or nil when the account has no
+// PIN to reset.
+func (r *TransactionPINRepositoryImpl) Reset(accountID uuid.UUID, reset models.TransactionPINReset) (*models.TransactionPIN, error) {
+	var pin *models.TransactionPIN
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		query := `
+			UPDATE transaction_pins
+			SET pin_hash = NULL, failed_attempts = 0, locked_until = NULL, reset_required = TRUE, updated_at = $2
+			WHERE account_id = $1 AND pin_hash IS NOT NULL
+			RETURNING ` + transactionPINColumns
+
+		var err error
+		pin, err = scanTransactionPIN(tx.QueryRow(query, accountID, now))
+		if err != nil {
+			pin = nil
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return fmt.Errorf("failed to reset transaction PIN: %w", err)
+		}
+
+		event, err := events.New(events.TypeTransactionPINReset, events.SourceBankingService, events.TransactionPINReset{
+			UserID:    pin.UserID,
+			AccountID: pin.AccountID,
+			ActorID:   reset.ActorID,
+			RequestID: reset.RequestID,
+			ResetAt:   now.UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		return events.WriteOutbox(tx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pin, nil
+}
+
+// scanTransactionPIN reads a row of transactionPINColumns
+func scanTransactionPIN(row rowScanner) (*models.TransactionPIN, error) {
+	pin := &models.TransactionPIN{}
+	err := row.Scan(
+		&pin.AccountID,
+		&pin.UserID,
+		&pin.PINHash,
+		&pin.FailedAttempts,
+		&pin.LockedUntil,
+		&pin.ResetRequired,
+		&pin.CreatedAt,
+		&pin.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pin, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+var transactionPINRowColumns = []string{"account_id", "user_id", "pin_hash", "failed_attempts", "locked_until", "reset_required", "created_at", "updated_at"}
+
+func TestTransactionPINRepository_RecordAttemptLocksAtLimit(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionPINRepository(db)
+	accountID := uuid.New()
+	now := time.Now()
+	rules := models.TransactionPINRules{MaxAttempts: 5, Lockout: 15 * time.Minute}
+	lockedUntil := now.Add(rules.Lockout)
+
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE transaction_pins")).
+		WithArgs(accountID, 5, lockedUntil, now).
+		WillReturnRows(sqlmock.NewRows(transactionPINRowColumns).AddRow(accountID, uuid.New(), "hash", 5, lockedUntil, false, now, now))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE transaction_pins")).
+		WithArgs(accountID, 5, lockedUntil, now).
+		WillReturnRows(sqlmock.NewRows(transactionPINRowColumns))
+
+	pin, err := repo.RecordAttempt(accountID, rules, now)
+	if err != nil {
+		t.Fatalf("RecordAttempt returned error: %v", err)
+	}
+	if pin == nil || pin.FailedAttempts != 5 || !pin.Locked(now) {
+		t.Errorf("Expected the fifth attempt to lock the PIN, got %+v", pin)
+	}
+
+	// Attempts at a locked PIN are not counted
+	if pin, err := repo.RecordAttempt(accountID, rules, now); err != nil || pin != nil {
+		t.Errorf("Expected no attempt to be counted while locked, got %+v (%v)", pin, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionPINRepository_ResetRecordsEvent(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionPINRepository(db)
+	accountID, userID, staffID := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE transaction_pins")).
+		WithArgs(accountID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(transactionPINRowColumns).AddRow(accountID, userID, "", 0, nil, true, now, now))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	pin, err := repo.Reset(accountID, models.TransactionPINReset{ActorID: staffID, RequestID: "req-1"})
+	if err != nil {
+		t.Fatalf("Reset returned error: %v", err)
+	}
+	if pin == nil || pin.UserID != userID || !pin.ResetRequired || pin.PINHash != "" {
+		t.Errorf("Expected the PIN of %s to need setting again, got %+v", userID, pin)
+	}
+
+	// Accounts without a PIN have nothing to reset and record no event
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE transaction_pins")).
+		WithArgs(accountID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(transactionPINRowColumns))
+	mock.ExpectCommit()
+
+	if pin, err := repo.Reset(accountID, models.TransactionPINReset{ActorID: staffID}); err != nil || pin != nil {
+		t.Errorf("Expected nothing to reset, got %+v (%v)", pin, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -1,8 +1,14 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"microbank/banking-service/internal/models"
@@ -20,11 +26,264 @@ func NewTransactionRepository(db *PostgresDB) TransactionRepository {
 
 // CreateTransaction creates a new transaction record
 func (r *TransactionRepositoryImpl) CreateTransaction(transaction *models.Transaction) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		return insertTransaction(tx, transaction)
+	})
+}
+
+// CreateDeposit records a deposit and adds it to the account balance in
+// one database transaction. Its balances are set from the locked account,
+// so concurrent deposits to an account are never lost.
+func (r *TransactionRepositoryImpl) CreateDeposit(transaction *models.Transaction) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		return creditAccount(tx, transaction, transaction.CreatedAt)
+	})
+}
+
+// CreateWithdrawal records a withdrawal and the fee charged on it, if any,
+// and sets the account balance to what is left after both. Money the
+// withdrawal takes from savings goals is released from them. Everything is
+// written in one database transaction, so a fee is never posted without
+// its withdrawal or the other way round. The fee is decided again with the
+// account locked, as decideWithdrawalFee describes. The balances of the
+// withdrawal, fee and round-up are set from the locked account, and
+// ErrInsufficientBalance is returned, with nothing written, when the
+// balance left unheld no longer covers the withdrawal and its fee. A
+// round-up is written in the same transaction under a savepoint: if it
+// cannot be applied it is dropped, and withdrawal.RoundUp cleared, rather
+// than failing the withdrawal.
+func (r *TransactionRepositoryImpl) CreateWithdrawal(withdrawal *models.Withdrawal) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		now := time.Now()
+		transaction := withdrawal.Transaction
+		balance, err := lockBalance(tx, transaction)
+		if err != nil {
+			return err
+		}
+		if err := decideWithdrawalFee(tx, withdrawal); err != nil {
+			return err
+		}
+
+		var held float64
+		if err := tx.QueryRow(heldFundsQuery, transaction.AccountID, now).Scan(&held); err != nil {
+			return fmt.Errorf("failed to sum held funds: %w", err)
+		}
+		total := transaction.Amount
+		if withdrawal.Fee != nil {
+			total += withdrawal.Fee.Amount
+		}
+		if math.Round((balance-held-total)*100) < 0 {
+			return ErrInsufficientBalance
+		}
+
+		transaction.BalanceBefore = balance
+		transaction.BalanceAfter = math.Round((balance-transaction.Amount)*100) / 100
+		if err := insertTransaction(tx, transaction); err != nil {
+			return err
+		}
+
+		balanceAfter := transaction.BalanceAfter
+		if withdrawal.Fee != nil {
+			withdrawal.Fee.BalanceBefore = balanceAfter
+			withdrawal.Fee.BalanceAfter = math.Round((balanceAfter-withdrawal.Fee.Amount)*100) / 100
+			if err := insertTransaction(tx, withdrawal.Fee); err != nil {
+				return err
+			}
+			balanceAfter = withdrawal.Fee.BalanceAfter
+		}
+
+		for _, release := range withdrawal.GoalReleases {
+			result, err := tx.Exec(`
+				UPDATE savings_goals
+				SET allocated_amount = allocated_amount - $1, updated_at = $2
+				WHERE id = $3 AND allocated_amount >= $1`, release.Amount, now, release.GoalID)
+			if err != nil {
+				return fmt.Errorf("failed to release savings goal funds: %w", err)
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to get rows affected: %w", err)
+			}
+			if rowsAffected == 0 {
+				return fmt.Errorf("savings goal %s no longer holds %s", release.GoalID, models.Amount(release.Amount))
+			}
+		}
+
+		if err := setBalance(tx, transaction, balanceAfter, now); err != nil {
+			return err
+		}
+		if withdrawal.Fee != nil {
+			withdrawal.Fee.AccountVersion = transaction.AccountVersion
+		}
+
+		if withdrawal.RoundUp != nil {
+			withdrawal.RoundUp.BalanceBefore = balanceAfter
+			withdrawal.RoundUp.BalanceAfter = balanceAfter
+			if _, err := tx.Exec(`SAVEPOINT round_up`); err != nil {
+				return fmt.Errorf("failed to create round-up savepoint: %w", err)
+			}
+			if err := allocateRoundUp(tx, withdrawal); err != nil {
+				log.Printf("Skipping round-up of withdrawal %s: %v", withdrawal.Transaction.ID, err)
+				if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT round_up`); err != nil {
+					return fmt.Errorf("failed to roll back round-up: %w", err)
+				}
+				withdrawal.RoundUp = nil
+			}
+		}
+		return nil
+	})
+}
+
+// ErrInsufficientBalance is returned by CreateWithdrawal and
+// CreateTransfer, when nothing is written, if the account's balance no
+// longer covers the withdrawal or transfer
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// CreateTransfer records both sides of a transfer and moves the money
+// between the two accounts in one database transaction. The accounts are
+// locked in ID order, so transfers crossing each other cannot deadlock,
+// and each side's balances are set from its locked account.
+// ErrInsufficientBalance is returned, with nothing written, when the
+// balance left unheld and unearmarked does not cover the transfer.
+func (r *TransactionRepositoryImpl) CreateTransfer(transfer *models.Transfer) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		rows, err := tx.Query(`SELECT id, balance FROM accounts WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`, transfer.Out.AccountID, transfer.In.AccountID)
+		if err != nil {
+			return fmt.Errorf("failed to lock accounts: %w", err)
+		}
+		balances := make(map[uuid.UUID]float64, 2)
+		for rows.Next() {
+			var id uuid.UUID
+			var balance float64
+			if err := rows.Scan(&id, &balance); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan account row: %w", err)
+			}
+			balances[id] = balance
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating over account rows: %w", err)
+		}
+
+		now := transfer.Out.CreatedAt
+		for _, side := range []struct {
+			transaction *models.Transaction
+			sign        float64
+		}{{transfer.Out, -1}, {transfer.In, 1}} {
+			balance, ok := balances[side.transaction.AccountID]
+			if !ok {
+				return fmt.Errorf("account not found for %s", side.transaction.Type)
+			}
+			side.transaction.BalanceBefore = balance
+			side.transaction.BalanceAfter = math.Round((balance+side.sign*side.transaction.Amount)*100) / 100
+			if side.transaction.BalanceAfter < 0 {
+				return ErrInsufficientBalance
+			}
+		}
+
+		// Held and earmarked money stays on the account
+		var held, earmarked float64
+		if err := tx.QueryRow(heldFundsQuery, transfer.Out.AccountID, time.Now()).Scan(&held); err != nil {
+			return fmt.Errorf("failed to sum held funds: %w", err)
+		}
+		if err := tx.QueryRow(earmarkedFundsQuery, transfer.Out.AccountID).Scan(&earmarked); err != nil {
+			return fmt.Errorf("failed to sum earmarked funds: %w", err)
+		}
+		if math.Round((transfer.Out.BalanceBefore-held-earmarked-transfer.Out.Amount)*100) < 0 {
+			return ErrInsufficientBalance
+		}
+
+		for _, transaction := range []*models.Transaction{transfer.Out, transfer.In} {
+			if err := insertTransaction(tx, transaction); err != nil {
+				return err
+			}
+			if err := setBalance(tx, transaction, transaction.BalanceAfter, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// countWithdrawalsQuery counts the withdrawals made from the account $1 at
+// or after $3, with $2 the withdrawal type
+const countWithdrawalsQuery = `
+		SELECT COUNT(*) FROM transactions
+		WHERE account_id = $1 AND type = $2 AND created_at >= $3`
+
+// CountWithdrawalsSince counts the withdrawals made from an account at or
+// after since
+func (r *TransactionRepositoryImpl) CountWithdrawalsSince(accountID uuid.UUID, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(countWithdrawalsQuery, accountID, models.TransactionTypeWithdrawal, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count withdrawals: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListRoundUpsSince retrieves the round-ups made on an account at or after
+// since, newest first
+func (r *TransactionRepositoryImpl) ListRoundUpsSince(accountID uuid.UUID, since time.Time) ([]models.Transaction, error) {
 	query := `
-		INSERT INTO transactions (id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+		SELECT id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at, related_transaction_id
+		FROM transactions
+		WHERE account_id = $1 AND type = $2 AND created_at >= $3
+		ORDER BY created_at DESC`
 
-	_, err := r.db.Exec(
+	rows, err := r.db.Query(query, accountID, models.TransactionTypeRoundUp, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query round-ups: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var transaction models.Transaction
+		err := rows.Scan(
+			&transaction.ID,
+			&transaction.AccountID,
+			&transaction.UserID,
+			&transaction.Type,
+			&transaction.Amount,
+			&transaction.BalanceBefore,
+			&transaction.BalanceAfter,
+			&transaction.Description,
+			&transaction.CreatedAt,
+			&transaction.RelatedTransactionID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan round-up row: %w", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over round-up rows: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// execer is satisfied by both the database and its transactions
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertTransaction writes a transaction record with tx, chained to the
+// transaction before it on its account
+func insertTransaction(tx *sql.Tx, transaction *models.Transaction) error {
+	if err := chainTransaction(tx, transaction); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO transactions (id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at, related_transaction_id, chain_seq, integrity_hash, raw_description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	_, err := tx.Exec(
 		query,
 		transaction.ID,
 		transaction.AccountID,
@@ -35,6 +294,10 @@ func (r *TransactionRepositoryImpl) CreateTransaction(transaction *models.Transa
 		transaction.BalanceAfter,
 		transaction.Description,
 		transaction.CreatedAt,
+		transaction.RelatedTransactionID,
+		transaction.ChainSequence,
+		transaction.IntegrityHash,
+		transaction.RawDescription,
 	)
 
 	if err != nil {
@@ -44,10 +307,138 @@ func (r *TransactionRepositoryImpl) CreateTransaction(transaction *models.Transa
 	return nil
 }
 
+// chainTransaction locks the account of transaction with tx and makes the
+// transaction the new head of the account's integrity chain: it is given
+// the next sequence number and hashed with the current head's hash, or
+// models.GenesisHash when it is the first. Its time is cut to the
+// microseconds the database keeps, so the hash can be checked against the
+// stored row.
+func chainTransaction(tx *sql.Tx, transaction *models.Transaction) error {
+	var sequence int64
+	var head sql.NullString
+	err := tx.QueryRow(`SELECT chain_seq, integrity_hash FROM accounts WHERE id = $1 FOR UPDATE`, transaction.AccountID).Scan(&sequence, &head)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("account not found for %s", transaction.Type)
+		}
+		return fmt.Errorf("failed to lock integrity chain: %w", err)
+	}
+
+	previous := models.GenesisHash
+	if head.Valid {
+		previous = head.String
+	}
+	transaction.CreatedAt = transaction.CreatedAt.Truncate(time.Microsecond)
+	transaction.ChainSequence = sequence + 1
+	transaction.IntegrityHash = transaction.ComputeIntegrityHash(previous)
+
+	_, err = tx.Exec(`UPDATE accounts SET chain_seq = $1, integrity_hash = $2 WHERE id = $3`, transaction.ChainSequence, transaction.IntegrityHash, transaction.AccountID)
+	if err != nil {
+		return fmt.Errorf("failed to update integrity chain: %w", err)
+	}
+	return nil
+}
+
+// creditAccount locks the account of transaction with tx, sets the
+// transaction's balances from it and records the transaction, created at
+// now, with the amount added to the balance
+func creditAccount(tx *sql.Tx, transaction *models.Transaction, now time.Time) error {
+	balance, err := lockBalance(tx, transaction)
+	if err != nil {
+		return err
+	}
+
+	transaction.BalanceBefore = balance
+	transaction.BalanceAfter = math.Round((balance+transaction.Amount)*100) / 100
+	transaction.CreatedAt = now
+	if err := insertTransaction(tx, transaction); err != nil {
+		return err
+	}
+	return setBalance(tx, transaction, transaction.BalanceAfter, now)
+}
+
+// debitAccount locks the account of transaction with tx, sets the
+// transaction's balances from it and records the transaction, created at
+// now, with the amount taken from the balance. ErrInsufficientBalance is
+// returned when the balance does not cover it.
+func debitAccount(tx *sql.Tx, transaction *models.Transaction, now time.Time) error {
+	balance, err := lockBalance(tx, transaction)
+	if err != nil {
+		return err
+	}
+
+	after := math.Round((balance-transaction.Amount)*100) / 100
+	if after < 0 {
+		return ErrInsufficientBalance
+	}
+	transaction.BalanceBefore = balance
+	transaction.BalanceAfter = after
+	transaction.CreatedAt = now
+	if err := insertTransaction(tx, transaction); err != nil {
+		return err
+	}
+	return setBalance(tx, transaction, transaction.BalanceAfter, now)
+}
+
+// lockBalance locks the account of transaction with tx and returns its
+// balance
+func lockBalance(tx *sql.Tx, transaction *models.Transaction) (float64, error) {
+	var balance float64
+	err := tx.QueryRow(`SELECT balance FROM accounts WHERE id = $1 FOR UPDATE`, transaction.AccountID).Scan(&balance)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("account not found for %s", transaction.Type)
+		}
+		return 0, fmt.Errorf("failed to lock account: %w", err)
+	}
+	return balance, nil
+}
+
+// decideWithdrawalFee counts with tx, once the account is locked, the
+// withdrawals made from it in the withdrawal's calendar month, when its
+// fees have a free allowance. The withdrawal's fee is dropped while the
+// allowance lasts and charged once it is used up, so withdrawals made at
+// once cannot share the last free one.
+func decideWithdrawalFee(tx *sql.Tx, withdrawal *models.Withdrawal) error {
+	fees, transaction := withdrawal.Fees, withdrawal.Transaction
+	if !fees.Enabled() || fees.FreePerMonth <= 0 {
+		return nil
+	}
+
+	var made int
+	err := tx.QueryRow(countWithdrawalsQuery, transaction.AccountID, models.TransactionTypeWithdrawal, startOfMonth(transaction.CreatedAt)).Scan(&made)
+	if err != nil {
+		return fmt.Errorf("failed to count this month's withdrawals: %w", err)
+	}
+	switch {
+	case made < fees.FreePerMonth:
+		withdrawal.Fee = nil
+	case withdrawal.Fee == nil:
+		withdrawal.Fee = models.NewWithdrawalFee(transaction, fees.FeeFor(transaction.Amount))
+	}
+	return nil
+}
+
+// setBalance sets the balance of transaction's account with tx, at now,
+// and moves the account to its next version, which is recorded as the
+// transaction's AccountVersion
+func setBalance(tx *sql.Tx, transaction *models.Transaction, balance float64, now time.Time) error {
+	query := `UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version`
+
+	err := tx.QueryRow(query, balance, now, transaction.AccountID).Scan(&transaction.AccountVersion)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("account not found for balance update")
+		}
+		return fmt.Errorf("failed to update account balance: %w", err)
+	}
+	return nil
+}
+
 // GetTransactionByID retrieves a transaction by its ID
 func (r *TransactionRepositoryImpl) GetTransactionByID(id uuid.UUID) (*models.Transaction, error) {
 	query := `
-		SELECT id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at
+		SELECT id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at, related_transaction_id
 		FROM transactions WHERE id = $1`
 
 	transaction := &models.Transaction{}
@@ -61,6 +452,7 @@ func (r *TransactionRepositoryImpl) GetTransactionByID(id uuid.UUID) (*models.Tr
 		&transaction.BalanceAfter,
 		&transaction.Description,
 		&transaction.CreatedAt,
+		&transaction.RelatedTransactionID,
 	)
 
 	if err != nil {
@@ -73,16 +465,29 @@ func (r *TransactionRepositoryImpl) GetTransactionByID(id uuid.UUID) (*models.Tr
 	return transaction, nil
 }
 
-// GetTransactionsByUserID retrieves all transactions for a specific user
-func (r *TransactionRepositoryImpl) GetTransactionsByUserID(userID uuid.UUID, limit, offset int) ([]models.Transaction, error) {
-	query := `
-		SELECT id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at
-		FROM transactions 
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3`
+// GetTransactionsByUserID retrieves a user's transactions matching filter,
+// in its order. When before is set, only transactions that come after it
+// in that order are retrieved, so pages can be read by cursor instead of
+// offset.
+func (r *TransactionRepositoryImpl) GetTransactionsByUserID(userID uuid.UUID, filter models.TransactionFilter, before *models.Transaction, limit, offset int) ([]models.Transaction, error) {
+	return r.listTransactions("user_id", userID, filter, before, limit, offset)
+}
 
-	rows, err := r.db.Query(query, userID, limit, offset)
+// GetTransactionsByAccountID retrieves an account's transactions matching
+// filter, in its order, after before when it is set
+func (r *TransactionRepositoryImpl) GetTransactionsByAccountID(accountID uuid.UUID, filter models.TransactionFilter, before *models.Transaction, limit, offset int) ([]models.Transaction, error) {
+	return r.listTransactions("account_id", accountID, filter, before, limit, offset)
+}
+
+// listTransactions retrieves the transactions whose column is id. Sorted
+// by created_at or amount, with ties broken by id, the order matches the
+// (column, created_at DESC, id DESC) and (column, amount DESC, id DESC)
+// indexes, read backward for ascending order. A page is read from the
+// index without sorting the owner's whole history, and the before cursor
+// is a range condition on the same index.
+func (r *TransactionRepositoryImpl) listTransactions(column string, id uuid.UUID, filter models.TransactionFilter, before *models.Transaction, limit, offset int) ([]models.Transaction, error) {
+	query, args := listTransactionsQuery(column, id, filter, before, limit, offset)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query transactions: %w", err)
 	}
@@ -101,6 +506,7 @@ func (r *TransactionRepositoryImpl) GetTransactionsByUserID(userID uuid.UUID, li
 			&transaction.BalanceAfter,
 			&transaction.Description,
 			&transaction.CreatedAt,
+			&transaction.RelatedTransactionID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction row: %w", err)
@@ -115,16 +521,113 @@ func (r *TransactionRepositoryImpl) GetTransactionsByUserID(userID uuid.UUID, li
 	return transactions, nil
 }
 
-// GetTransactionsByAccountID retrieves all transactions for a specific account
-func (r *TransactionRepositoryImpl) GetTransactionsByAccountID(accountID uuid.UUID, limit, offset int) ([]models.Transaction, error) {
+// listTransactionsQuery builds the query listTransactions runs and its
+// arguments
+func listTransactionsQuery(column string, id uuid.UUID, filter models.TransactionFilter, before *models.Transaction, limit, offset int) (string, []interface{}) {
+	conditions, args := transactionConditions(filter, before, []string{column + " = $1"}, []interface{}{id})
+	sortColumn, direction := transactionSortOrder(filter)
+	query := `
+		SELECT id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at, related_transaction_id
+		FROM transactions WHERE ` + strings.Join(conditions, " AND ") + fmt.Sprintf(`
+		ORDER BY %[1]s %[2]s, id %[2]s
+		LIMIT $%[3]d OFFSET $%[4]d`, sortColumn, direction, len(args)+1, len(args)+2)
+
+	return query, append(args, limit, offset)
+}
+
+// transactionSortOrder returns the column and direction transactions are
+// sorted by. Ties are broken by id in the same direction.
+func transactionSortOrder(filter models.TransactionFilter) (string, string) {
+	direction := "ASC"
+	if filter.SortDesc {
+		direction = "DESC"
+	}
+	sortColumn := "created_at"
+	if filter.SortBy == models.TransactionSortAmount {
+		sortColumn = "amount"
+	}
+	return sortColumn, direction
+}
+
+// transactionConditions appends filter's conditions, and one keeping only
+// transactions that come after cursor in filter's order when it is set, to
+// conditions and their arguments to args
+func transactionConditions(filter models.TransactionFilter, cursor *models.Transaction, conditions []string, args []interface{}) ([]string, []interface{}) {
+	add := func(condition string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
+
+	if filter.Since != nil {
+		add("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		add("created_at < $%d", *filter.Until)
+	}
+	if filter.MinAmount != nil {
+		add("amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		add("amount <= $%d", *filter.MaxAmount)
+	}
+	if cursor != nil {
+		sortColumn, direction := transactionSortOrder(filter)
+		comparison := ">"
+		if direction == "DESC" {
+			comparison = "<"
+		}
+		var sortValue interface{} = cursor.CreatedAt
+		if sortColumn == "amount" {
+			sortValue = cursor.Amount
+		}
+		add("("+sortColumn+", id) "+comparison+" ($%d, $%d)", sortValue, cursor.ID)
+	}
+
+	return conditions, args
+}
+
+// GetTransactionCountByUserID counts a user's transactions matching filter
+func (r *TransactionRepositoryImpl) GetTransactionCountByUserID(userID uuid.UUID, filter models.TransactionFilter) (int, error) {
+	return r.countTransactions("user_id", userID, filter)
+}
+
+// GetTransactionCountByAccountID counts an account's transactions matching
+// filter
+func (r *TransactionRepositoryImpl) GetTransactionCountByAccountID(accountID uuid.UUID, filter models.TransactionFilter) (int, error) {
+	return r.countTransactions("account_id", accountID, filter)
+}
+
+// countTransactions counts the transactions whose column is id matching
+// filter
+func (r *TransactionRepositoryImpl) countTransactions(column string, id uuid.UUID, filter models.TransactionFilter) (int, error) {
+	conditions, args := transactionConditions(filter, nil, []string{column + " = $1"}, []interface{}{id})
+	query := `SELECT COUNT(*) FROM transactions WHERE ` + strings.Join(conditions, " AND ")
+
+	var count int
+	err := r.db.QueryRow(query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get transaction count: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetAllTransactions retrieves every user's transactions matching filter,
+// in its order (for admin purposes)
+func (r *TransactionRepositoryImpl) GetAllTransactions(filter models.TransactionFilter, limit, offset int) ([]models.Transaction, error) {
+	conditions, args := transactionConditions(filter, nil, []string{"TRUE"}, nil)
+	sortColumn, direction := transactionSortOrder(filter)
 	query := `
-		SELECT id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at
-		FROM transactions 
-		WHERE account_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3`
+		SELECT id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at, related_transaction_id
+		FROM transactions WHERE ` + strings.Join(conditions, " AND ") + fmt.Sprintf(`
+		ORDER BY %[1]s %[2]s, id %[2]s
+		LIMIT $%[3]d OFFSET $%[4]d`, sortColumn, direction, len(args)+1, len(args)+2)
 
-	rows, err := r.db.Query(query, accountID, limit, offset)
+	rows, err := r.db.Query(query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query transactions: %w", err)
 	}
@@ -143,6 +646,7 @@ func (r *TransactionRepositoryImpl) GetTransactionsByAccountID(accountID uuid.UU
 			&transaction.BalanceAfter,
 			&transaction.Description,
 			&transaction.CreatedAt,
+			&transaction.RelatedTransactionID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction row: %w", err)
@@ -157,28 +661,33 @@ func (r *TransactionRepositoryImpl) GetTransactionsByAccountID(accountID uuid.UU
 	return transactions, nil
 }
 
-// GetTransactionCountByUserID gets the total count of transactions for a user
-func (r *TransactionRepositoryImpl) GetTransactionCountByUserID(userID uuid.UUID) (int, error) {
-	query := `SELECT COUNT(*) FROM transactions WHERE user_id = $1`
+// CountAllTransactions counts every user's transactions matching filter
+func (r *TransactionRepositoryImpl) CountAllTransactions(filter models.TransactionFilter) (int, error) {
+	conditions, args := transactionConditions(filter, nil, []string{"TRUE"}, nil)
+	query := `SELECT COUNT(*) FROM transactions WHERE ` + strings.Join(conditions, " AND ")
 
 	var count int
-	err := r.db.QueryRow(query, userID).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get transaction count: %w", err)
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
 	}
 
 	return count, nil
 }
 
-// GetAllTransactions retrieves all transactions (for admin purposes)
-func (r *TransactionRepositoryImpl) GetAllTransactions(limit, offset int) ([]models.Transaction, error) {
+// GetTransactionsAfter retrieves up to limit transactions matching filter,
+// in its order, that come after the one given, or from the first if after
+// is nil. Each call is a query of its own, so paging through every
+// transaction holds no database transaction open.
+func (r *TransactionRepositoryImpl) GetTransactionsAfter(ctx context.Context, filter models.TransactionFilter, after *models.Transaction, limit int) ([]models.Transaction, error) {
+	conditions, args := transactionConditions(filter, after, []string{"TRUE"}, nil)
+	sortColumn, direction := transactionSortOrder(filter)
 	query := `
-		SELECT id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at
-		FROM transactions 
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2`
+		SELECT id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at, related_transaction_id
+		FROM transactions WHERE ` + strings.Join(conditions, " AND ") + fmt.Sprintf(`
+		ORDER BY %[1]s %[2]s, id %[2]s
+		LIMIT $%[3]d`, sortColumn, direction, len(args)+1)
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query transactions: %w", err)
 	}
@@ -197,6 +706,7 @@ func (r *TransactionRepositoryImpl) GetAllTransactions(limit, offset int) ([]mod
 			&transaction.BalanceAfter,
 			&transaction.Description,
 			&transaction.CreatedAt,
+			&transaction.RelatedTransactionID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction row: %w", err)
@@ -210,3 +720,75 @@ func (r *TransactionRepositoryImpl) GetAllTransactions(limit, offset int) ([]mod
 
 	return transactions, nil
 }
+
+// GetIntegrityChainHead retrieves where an account's integrity chain ends
+// and how many of its transactions, live or archived, predate chaining. It
+// returns nil when the account does not exist.
+func (r *TransactionRepositoryImpl) GetIntegrityChainHead(accountID uuid.UUID) (*models.IntegrityChainHead, error) {
+	query := `
+		SELECT a.chain_seq, COALESCE(a.integrity_hash, ''),
+			(SELECT COUNT(*) FROM transactions WHERE account_id = a.id AND chain_seq IS NULL) +
+			(SELECT COUNT(*) FROM archive.transactions WHERE account_id = a.id AND chain_seq IS NULL)
+		FROM accounts a WHERE a.id = $1`
+
+	head := &models.IntegrityChainHead{}
+	err := r.db.QueryRow(query, accountID).Scan(&head.Sequence, &head.Hash, &head.Unchained)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get integrity chain head: %w", err)
+	}
+
+	return head, nil
+}
+
+// ListIntegrityChain retrieves up to limit of an account's chained
+// transactions, live or archived, after sequence number after, in chain
+// order
+func (r *TransactionRepositoryImpl) ListIntegrityChain(accountID uuid.UUID, after int64, limit int) ([]models.Transaction, error) {
+	columns := `id, account_id, user_id, type, amount, balance_before, balance_after, description, created_at, related_transaction_id, chain_seq, COALESCE(integrity_hash, '')`
+	query := `
+		SELECT * FROM (
+			SELECT ` + columns + ` FROM transactions WHERE account_id = $1 AND chain_seq > $2
+			UNION ALL
+			SELECT ` + columns + ` FROM archive.transactions WHERE account_id = $1 AND chain_seq > $2
+		) chain
+		ORDER BY chain_seq
+		LIMIT $3`
+
+	rows, err := r.db.Query(query, accountID, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query integrity chain: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var transaction models.Transaction
+		err := rows.Scan(
+			&transaction.ID,
+			&transaction.AccountID,
+			&transaction.UserID,
+			&transaction.Type,
+			&transaction.Amount,
+			&transaction.BalanceBefore,
+			&transaction.BalanceAfter,
+			&transaction.Description,
+			&transaction.CreatedAt,
+			&transaction.RelatedTransactionID,
+			&transaction.ChainSequence,
+			&transaction.IntegrityHash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan integrity chain row: %w", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over integrity chain rows: %w", err)
+	}
+
+	return transactions, nil
+}
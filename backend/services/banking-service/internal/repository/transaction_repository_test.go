@@ -0,0 +1,597 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+func newMockDB(t *testing.T) (*PostgresDB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &PostgresDB{db}, mock
+}
+
+// expectChained expects a transaction on accountID to be linked to its
+// integrity chain, whose head is sequence with hash head ("" for none)
+func expectChained(mock sqlmock.Sqlmock, accountID uuid.UUID, sequence int64, head string) {
+	var hash interface{}
+	if head != "" {
+		hash = head
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT chain_seq, integrity_hash FROM accounts WHERE id = $1 FOR UPDATE")).
+		WithArgs(accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"chain_seq", "integrity_hash"}).AddRow(sequence, hash))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET chain_seq = $1, integrity_hash = $2 WHERE id = $3")).
+		WithArgs(sequence+1, sqlmock.AnyArg(), accountID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectWithdrawalLock expects a withdrawal to lock accountID, finding
+// balance, and to add up the held funds on it
+func expectWithdrawalLock(mock sqlmock.Sqlmock, accountID uuid.UUID, balance, held float64) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE")).
+		WithArgs(accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(balance))
+	mock.ExpectQuery(regexp.QuoteMeta(heldFundsQuery)).
+		WithArgs(accountID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(held))
+}
+
+// expectTransferHolds expects a transfer from accountID to add up the
+// held and earmarked funds on it
+func expectTransferHolds(mock sqlmock.Sqlmock, accountID uuid.UUID, held, earmarked float64) {
+	mock.ExpectQuery(regexp.QuoteMeta(heldFundsQuery)).
+		WithArgs(accountID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(held))
+	mock.ExpectQuery(regexp.QuoteMeta(earmarkedFundsQuery)).
+		WithArgs(accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"earmarked"}).AddRow(earmarked))
+}
+
+func TestTransactionRepository_CreateTransactionChainsToHead(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	transaction := &models.Transaction{ID: uuid.New(), AccountID: uuid.New(), UserID: uuid.New(), Type: models.TransactionTypeDeposit, Amount: 25, BalanceAfter: 25, CreatedAt: time.Date(2026, time.October, 18, 9, 30, 0, 123456789, time.UTC)}
+	head := (&models.Transaction{ID: uuid.New()}).ComputeIntegrityHash(models.GenesisHash)
+
+	mock.ExpectBegin()
+	expectChained(mock, transaction.AccountID, 4, head)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(transaction.ID, transaction.AccountID, transaction.UserID, models.TransactionTypeDeposit, 25.0, 0.0, 25.0, "", time.Date(2026, time.October, 18, 9, 30, 0, 123456000, time.UTC), nil, int64(5), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.CreateTransaction(transaction); err != nil {
+		t.Fatalf("CreateTransaction returned error: %v", err)
+	}
+	if transaction.ChainSequence != 5 {
+		t.Errorf("Expected the transaction to follow the head at 4, got sequence %d", transaction.ChainSequence)
+	}
+	if want := transaction.ComputeIntegrityHash(head); transaction.IntegrityHash != want || transaction.IntegrityHash == transaction.ComputeIntegrityHash(models.GenesisHash) {
+		t.Errorf("Expected the hash to cover the head's hash, got %s", transaction.IntegrityHash)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_CreateWithdrawal(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	accountID := uuid.New()
+	withdrawal := &models.Transaction{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeWithdrawal, Amount: 100, BalanceBefore: 500, BalanceAfter: 400}
+	fee := &models.Transaction{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeFee, Amount: 2, BalanceBefore: 400, BalanceAfter: 398, RelatedTransactionID: &withdrawal.ID}
+
+	mock.ExpectBegin()
+	expectWithdrawalLock(mock, accountID, 500, 0)
+	expectChained(mock, accountID, 0, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(withdrawal.ID, accountID, sqlmock.AnyArg(), models.TransactionTypeWithdrawal, 100.0, 500.0, 400.0, "", sqlmock.AnyArg(), nil, int64(1), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectChained(mock, accountID, 1, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(fee.ID, accountID, sqlmock.AnyArg(), models.TransactionTypeFee, 2.0, 400.0, 398.0, "", sqlmock.AnyArg(), &withdrawal.ID, int64(2), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(398.0, sqlmock.AnyArg(), accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectCommit()
+
+	if err := repo.CreateWithdrawal(&models.Withdrawal{Transaction: withdrawal, Fee: fee}); err != nil {
+		t.Fatalf("CreateWithdrawal returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_CreateWithdrawalChargesFeeOnceAllowanceUsed(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	accountID := uuid.New()
+	createdAt := time.Date(2026, time.October, 18, 9, 30, 0, 0, time.UTC)
+	// Checked as the last free withdrawal of the month, but another
+	// withdrawal took it before the account was locked
+	transaction := &models.Transaction{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeWithdrawal, Amount: 100, CreatedAt: createdAt}
+	withdrawal := &models.Withdrawal{Transaction: transaction, Fees: models.WithdrawalFees{Flat: 2, FreePerMonth: 3}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT balance FROM accounts WHERE id = $1 FOR UPDATE")).
+		WithArgs(accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(500.0))
+	mock.ExpectQuery(regexp.QuoteMeta(countWithdrawalsQuery)).
+		WithArgs(accountID, models.TransactionTypeWithdrawal, time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(heldFundsQuery)).
+		WithArgs(accountID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(0.0))
+	expectChained(mock, accountID, 0, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(transaction.ID, accountID, sqlmock.AnyArg(), models.TransactionTypeWithdrawal, 100.0, 500.0, 400.0, "", sqlmock.AnyArg(), nil, int64(1), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectChained(mock, accountID, 1, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(sqlmock.AnyArg(), accountID, sqlmock.AnyArg(), models.TransactionTypeFee, 2.0, 400.0, 398.0, "Withdrawal fee", sqlmock.AnyArg(), &transaction.ID, int64(2), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(398.0, sqlmock.AnyArg(), accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectCommit()
+
+	if err := repo.CreateWithdrawal(withdrawal); err != nil {
+		t.Fatalf("CreateWithdrawal returned error: %v", err)
+	}
+	if withdrawal.Fee == nil || withdrawal.Fee.Amount != 2 {
+		t.Errorf("Expected a fee of 2 once the allowance is used up, got %+v", withdrawal.Fee)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_CreateWithdrawalUsesLockedBalance(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	accountID := uuid.New()
+	// The service read 500, but a deposit of 50 has committed since
+	transaction := &models.Transaction{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeWithdrawal, Amount: 99.5, BalanceBefore: 500, BalanceAfter: 400.5}
+	fee := &models.Transaction{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeFee, Amount: 2, BalanceBefore: 400.5, BalanceAfter: 398.5, RelatedTransactionID: &transaction.ID}
+	roundUp := &models.Transaction{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeRoundUp, Amount: 0.5, BalanceBefore: 398.5, BalanceAfter: 398.5, RelatedTransactionID: &transaction.ID}
+	withdrawal := &models.Withdrawal{Transaction: transaction, Fee: fee, RoundUp: roundUp, RoundUpGoalID: uuid.New()}
+
+	mock.ExpectBegin()
+	expectWithdrawalLock(mock, accountID, 550, 0)
+	expectChained(mock, accountID, 0, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(transaction.ID, accountID, sqlmock.AnyArg(), models.TransactionTypeWithdrawal, 99.5, 550.0, 450.5, "", sqlmock.AnyArg(), nil, int64(1), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectChained(mock, accountID, 1, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(fee.ID, accountID, sqlmock.AnyArg(), models.TransactionTypeFee, 2.0, 450.5, 448.5, "", sqlmock.AnyArg(), &transaction.ID, int64(2), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(448.5, sqlmock.AnyArg(), accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectExec(regexp.QuoteMeta("SAVEPOINT round_up")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM savings_goals WHERE id = $1 FOR UPDATE")).
+		WithArgs(withdrawal.RoundUpGoalID).
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectExec(regexp.QuoteMeta("ROLLBACK TO SAVEPOINT round_up")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := repo.CreateWithdrawal(withdrawal); err != nil {
+		t.Fatalf("CreateWithdrawal returned error: %v", err)
+	}
+	if transaction.BalanceBefore != 550 || transaction.BalanceAfter != 450.5 || fee.BalanceBefore != 450.5 || fee.BalanceAfter != 448.5 {
+		t.Errorf("Expected balances to be set from the locked account, got %v -> %v and %v -> %v", transaction.BalanceBefore, transaction.BalanceAfter, fee.BalanceBefore, fee.BalanceAfter)
+	}
+	if roundUp.BalanceBefore != 448.5 || roundUp.BalanceAfter != 448.5 {
+		t.Errorf("Expected the round-up to follow the locked balance, got %v -> %v", roundUp.BalanceBefore, roundUp.BalanceAfter)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_CreateWithdrawalInsufficientBalance(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	transaction := &models.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: models.TransactionTypeWithdrawal, Amount: 100, BalanceBefore: 500, BalanceAfter: 400}
+	fee := &models.Transaction{ID: uuid.New(), AccountID: transaction.AccountID, Type: models.TransactionTypeFee, Amount: 2, BalanceBefore: 400, BalanceAfter: 398, RelatedTransactionID: &transaction.ID}
+
+	// Another withdrawal has left 150, of which 50 is held
+	mock.ExpectBegin()
+	expectWithdrawalLock(mock, transaction.AccountID, 150, 50)
+	mock.ExpectRollback()
+
+	if err := repo.CreateWithdrawal(&models.Withdrawal{Transaction: transaction, Fee: fee}); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("Expected ErrInsufficientBalance, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_CreateWithdrawalRollsBack(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	withdrawal := &models.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: models.TransactionTypeWithdrawal, Amount: 100, BalanceBefore: 500, BalanceAfter: 400}
+	fee := &models.Transaction{ID: uuid.New(), AccountID: withdrawal.AccountID, Type: models.TransactionTypeFee, Amount: 2, BalanceBefore: 400, BalanceAfter: 398, RelatedTransactionID: &withdrawal.ID}
+
+	mock.ExpectBegin()
+	expectWithdrawalLock(mock, withdrawal.AccountID, 500, 0)
+	expectChained(mock, withdrawal.AccountID, 0, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectChained(mock, withdrawal.AccountID, 1, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	if err := repo.CreateWithdrawal(&models.Withdrawal{Transaction: withdrawal, Fee: fee}); err == nil {
+		t.Fatal("Expected an error when the fee cannot be recorded, got nil")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_CreateWithdrawalReleasesGoalFunds(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	goalID := uuid.New()
+	withdrawal := &models.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: models.TransactionTypeWithdrawal, Amount: 100, BalanceBefore: 500, BalanceAfter: 400}
+
+	mock.ExpectBegin()
+	expectWithdrawalLock(mock, withdrawal.AccountID, 500, 0)
+	expectChained(mock, withdrawal.AccountID, 0, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("SET allocated_amount = allocated_amount - $1")).
+		WithArgs(30.0, sqlmock.AnyArg(), goalID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.CreateWithdrawal(&models.Withdrawal{
+		Transaction:  withdrawal,
+		GoalReleases: []models.SavingsGoalRelease{{GoalID: goalID, Name: "Holiday", Amount: 30}},
+	})
+	if err == nil {
+		t.Fatal("Expected an error when the goal no longer holds the released amount, got nil")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_CreateWithdrawalSkipsFailedRoundUp(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	accountID := uuid.New()
+	transaction := &models.Transaction{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeWithdrawal, Amount: 12.45, BalanceBefore: 100, BalanceAfter: 87.55}
+	roundUp := &models.Transaction{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeRoundUp, Amount: 0.55, BalanceBefore: 87.55, BalanceAfter: 87.55, RelatedTransactionID: &transaction.ID}
+	withdrawal := &models.Withdrawal{Transaction: transaction, RoundUp: roundUp, RoundUpGoalID: uuid.New()}
+
+	mock.ExpectBegin()
+	expectWithdrawalLock(mock, accountID, 100, 0)
+	expectChained(mock, accountID, 0, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(87.55, sqlmock.AnyArg(), accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta("SAVEPOINT round_up")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM savings_goals WHERE id = $1 FOR UPDATE")).
+		WithArgs(withdrawal.RoundUpGoalID).
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectExec(regexp.QuoteMeta("ROLLBACK TO SAVEPOINT round_up")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := repo.CreateWithdrawal(withdrawal); err != nil {
+		t.Fatalf("CreateWithdrawal returned error: %v", err)
+	}
+	if withdrawal.RoundUp != nil {
+		t.Error("Expected the round-up into a deleted goal to be dropped")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_CreateTransfer(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	from, to := uuid.New(), uuid.New()
+	out := &models.Transaction{ID: uuid.New(), AccountID: from, Type: models.TransactionTypeTransferOut, Amount: 150, CreatedAt: time.Now()}
+	in := &models.Transaction{ID: uuid.New(), AccountID: to, Type: models.TransactionTypeTransferIn, Amount: 150, CreatedAt: out.CreatedAt, RelatedTransactionID: &out.ID}
+	out.RelatedTransactionID = &in.ID
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, balance FROM accounts WHERE id IN ($1, $2) ORDER BY id FOR UPDATE")).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance"}).AddRow(to, 20.0).AddRow(from, 400.0))
+	expectTransferHolds(mock, from, 0, 0)
+	expectChained(mock, from, 0, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(out.ID, from, sqlmock.AnyArg(), models.TransactionTypeTransferOut, 150.0, 400.0, 250.0, "", sqlmock.AnyArg(), &in.ID, int64(1), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(250.0, sqlmock.AnyArg(), from).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(7))
+	expectChained(mock, to, 3, "")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transactions")).
+		WithArgs(in.ID, to, sqlmock.AnyArg(), models.TransactionTypeTransferIn, 150.0, 20.0, 170.0, "", sqlmock.AnyArg(), &out.ID, int64(4), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING version")).
+		WithArgs(170.0, sqlmock.AnyArg(), to).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+	mock.ExpectCommit()
+
+	if err := repo.CreateTransfer(&models.Transfer{Out: out, In: in}); err != nil {
+		t.Fatalf("CreateTransfer returned error: %v", err)
+	}
+	if out.BalanceAfter != 250 || in.BalanceBefore != 20 || in.BalanceAfter != 170 {
+		t.Errorf("Expected balances to be set from the locked accounts, got %v and %v -> %v", out.BalanceAfter, in.BalanceBefore, in.BalanceAfter)
+	}
+	if out.AccountVersion != 7 || in.AccountVersion != 4 {
+		t.Errorf("Expected each side to carry its account's new version, got %d and %d", out.AccountVersion, in.AccountVersion)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_CreateTransferInsufficientBalance(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	out := &models.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: models.TransactionTypeTransferOut, Amount: 150}
+	in := &models.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: models.TransactionTypeTransferIn, Amount: 150}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, balance FROM accounts WHERE id IN ($1, $2) ORDER BY id FOR UPDATE")).
+		WithArgs(out.AccountID, in.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance"}).AddRow(out.AccountID, 149.99).AddRow(in.AccountID, 0.0))
+	mock.ExpectRollback()
+
+	if err := repo.CreateTransfer(&models.Transfer{Out: out, In: in}); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("Expected ErrInsufficientBalance, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_CreateTransferHeldFunds(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	out := &models.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: models.TransactionTypeTransferOut, Amount: 150}
+	in := &models.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: models.TransactionTypeTransferIn, Amount: 150}
+
+	// 400 on the account, but 200 is held and 50.01 earmarked by the time
+	// it is locked
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, balance FROM accounts WHERE id IN ($1, $2) ORDER BY id FOR UPDATE")).
+		WithArgs(out.AccountID, in.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance"}).AddRow(out.AccountID, 400.0).AddRow(in.AccountID, 0.0))
+	expectTransferHolds(mock, out.AccountID, 200, 50.01)
+	mock.ExpectRollback()
+
+	if err := repo.CreateTransfer(&models.Transfer{Out: out, In: in}); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("Expected ErrInsufficientBalance, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_CountWithdrawalsSince(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	accountID := uuid.New()
+	since := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE account_id = $1 AND type = $2 AND created_at >= $3")).
+		WithArgs(accountID, models.TransactionTypeWithdrawal, since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := repo.CountWithdrawalsSince(accountID, since)
+	if err != nil {
+		t.Fatalf("CountWithdrawalsSince returned error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 withdrawals, got %d", count)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_GetTransactionCountByUserID(t *testing.T) {
+	userID := uuid.New()
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	minAmount := 20.0
+
+	tests := []struct {
+		name   string
+		filter models.TransactionFilter
+		where  string
+		args   []driver.Value
+		count  int
+	}{
+		{name: "no filters", where: "WHERE user_id = $1", args: []driver.Value{userID}, count: 12},
+		{
+			name:   "every filter, sorted by amount",
+			filter: models.TransactionFilter{Since: &since, MinAmount: &minAmount, SortBy: models.TransactionSortAmount, SortDesc: true},
+			where:  "WHERE user_id = $1 AND created_at >= $2 AND amount >= $3",
+			args:   []driver.Value{userID, since, minAmount},
+			count:  3,
+		},
+		{name: "nothing matches", filter: models.TransactionFilter{Since: &since}, where: "WHERE user_id = $1 AND created_at >= $2", args: []driver.Value{userID, since}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewTransactionRepository(db)
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM transactions "+tt.where) + "$").
+				WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+
+			count, err := repo.GetTransactionCountByUserID(userID, tt.filter)
+			if err != nil {
+				t.Fatalf("GetTransactionCountByUserID returned error: %v", err)
+			}
+			if count != tt.count {
+				t.Errorf("Expected %d transactions, got %d", tt.count, count)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestTransactionRepository_CountAllTransactions(t *testing.T) {
+	maxAmount := 250.0
+
+	tests := []struct {
+		name   string
+		filter models.TransactionFilter
+		where  string
+		args   []driver.Value
+		count  int
+	}{
+		{name: "no filters", where: "WHERE TRUE", count: 40},
+		{name: "filtered", filter: models.TransactionFilter{MaxAmount: &maxAmount}, where: "WHERE TRUE AND amount <= $1", args: []driver.Value{maxAmount}, count: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewTransactionRepository(db)
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM transactions "+tt.where) + "$").
+				WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+
+			count, err := repo.CountAllTransactions(tt.filter)
+			if err != nil {
+				t.Fatalf("CountAllTransactions returned error: %v", err)
+			}
+			if count != tt.count {
+				t.Errorf("Expected %d transactions, got %d", tt.count, count)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestTransactionRepository_GetTransactionsAfter(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	after := &models.Transaction{ID: uuid.New(), CreatedAt: since.Add(time.Hour)}
+	columns := []string{"id", "account_id", "user_id", "type", "amount", "balance_before", "balance_after", "description", "created_at", "related_transaction_id"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("AND created_at >= $1 AND (created_at, id) > ($2, $3)")+`\s+ORDER BY created_at ASC, id ASC\s+LIMIT \$4`).
+		WithArgs(since, after.CreatedAt, after.ID, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), uuid.New(), uuid.New(), "deposit", 10.0, 0.0, 10.0, "", since.Add(2*time.Hour), nil))
+
+	transactions, err := repo.GetTransactionsAfter(context.Background(), models.TransactionFilter{Since: &since}, after, 2)
+	if err != nil {
+		t.Fatalf("GetTransactionsAfter returned error: %v", err)
+	}
+	if len(transactions) != 1 || transactions[0].Type != models.TransactionTypeDeposit {
+		t.Errorf("Expected one deposit, got %+v", transactions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_GetTransactionsByUserIDBefore(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	userID := uuid.New()
+	before := &models.Transaction{ID: uuid.New(), CreatedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)}
+	columns := []string{"id", "account_id", "user_id", "type", "amount", "balance_before", "balance_after", "description", "created_at", "related_transaction_id"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE user_id = $1 AND (created_at, id) < ($2, $3)")+`\s+ORDER BY created_at DESC, id DESC\s+LIMIT \$4 OFFSET \$5`).
+		WithArgs(userID, before.CreatedAt, before.ID, 10, 0).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), uuid.New(), userID, "withdrawal", 5.0, 10.0, 5.0, "", before.CreatedAt.Add(-time.Hour), nil))
+
+	transactions, err := repo.GetTransactionsByUserID(userID, models.TransactionFilter{SortDesc: true}, before, 10, 0)
+	if err != nil {
+		t.Fatalf("GetTransactionsByUserID returned error: %v", err)
+	}
+	if len(transactions) != 1 || transactions[0].Type != models.TransactionTypeWithdrawal {
+		t.Errorf("Expected one withdrawal, got %+v", transactions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionRepository_GetTransactionsByUserIDByAmount(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTransactionRepository(db)
+	userID := uuid.New()
+	minAmount, maxAmount := 10.0, 500.0
+	before := &models.Transaction{ID: uuid.New(), Amount: 250, CreatedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)}
+	filter := models.TransactionFilter{MinAmount: &minAmount, MaxAmount: &maxAmount, SortBy: models.TransactionSortAmount, SortDesc: true}
+	columns := []string{"id", "account_id", "user_id", "type", "amount", "balance_before", "balance_after", "description", "created_at", "related_transaction_id"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE user_id = $1 AND amount >= $2 AND amount <= $3 AND (amount, id) < ($4, $5)")+`\s+ORDER BY amount DESC, id DESC\s+LIMIT \$6 OFFSET \$7`).
+		WithArgs(userID, minAmount, maxAmount, before.Amount, before.ID, 10, 0).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), uuid.New(), userID, "deposit", 200.0, 0.0, 200.0, "", before.CreatedAt, nil))
+
+	transactions, err := repo.GetTransactionsByUserID(userID, filter, before, 10, 0)
+	if err != nil {
+		t.Fatalf("GetTransactionsByUserID returned error: %v", err)
+	}
+	if len(transactions) != 1 || transactions[0].Amount != 200 {
+		t.Errorf("Expected one transaction of 200, got %+v", transactions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// DefaultAccountInvitationTTL is how long invitations to join an account
+// can be accepted
+const DefaultAccountInvitationTTL = 7 * 24 * time.Hour
+
+// UserLookup finds users by email on the client-service. *UserStatusClient
+// satisfies it.
+type UserLookup interface {
+	LookupUserByEmail(email string) (models.UserDetail, error)
+}
+
+// AccountMemberService manages who may use an account. Owners invite
+// users by email, and invited users become members by accepting within
+// the invitation TTL. Members use the account through the services taking
+// an account selection, such as TransactionService.
+type AccountMemberService struct {
+	memberRepo    repository.AccountMemberRepository
+	accountRepo   repository.AccountRepository
+	users         UserLookup
+	invitationTTL time.Duration
+	now           func() time.Time
+}
+
+// NewAccountMemberService creates a new account member service whose
+// invitations can be accepted for invitationTTL
+func NewAccountMemberService(memberRepo repository.AccountMemberRepository, accountRepo repository.AccountRepository, users UserLookup, invitationTTL time.Duration) *AccountMemberService {
+	return &AccountMemberService{
+		memberRepo:    memberRepo,
+		accountRepo:   accountRepo,
+		users:         users,
+		invitationTTL: invitationTTL,
+		now:           time.Now,
+	}
+}
+
+// memberAccount returns the account a user acts on, with their membership
+// of it: their own account when accountID is nil, or the account it
+// selects when they are a member of it. Accounts they are not a member of
+// are ErrAccountNotFound. Without memberRepo only their own account can be
+// selected, and the membership is nil.
+func memberAccount(accountRepo repository.AccountRepository, memberRepo repository.AccountMemberRepository, userID uuid.UUID, accountID *uuid.UUID) (*models.Account, *models.AccountMember, error) {
+	var account *models.Account
+	var err error
+	if accountID == nil {
+		if account, err = accountRepo.GetAccountByUserID(userID); err != nil {
+			return nil, nil, fmt.Errorf("failed to get account: %w", err)
+		}
+	} else if account, err = accountRepo.GetAccountByID(*accountID); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrAccountNotFound, err)
+	}
+
+	if memberRepo == nil {
+		if account.UserID != userID {
+			return nil, nil, ErrAccountNotFound
+		}
+		return account, nil, nil
+	}
+	member, err := memberRepo.GetMember(account.ID, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get account member: %w", err)
+	}
+	if member == nil {
+		if account.UserID != userID {
+			return nil, nil, ErrAccountNotFound
+		}
+		// Holders are owners even before their membership is recorded
+		member = &models.AccountMember{AccountID: account.ID, UserID: userID, Role: models.AccountMemberRoleOwner}
+	}
+	return account, member, nil
+}
+
+// ownedAccount returns the account a user selects, as memberAccount does,
+// returning ErrAccountOwnerRequired unless they are one of its owners
+func (s *AccountMemberService) ownedAccount(userID uuid.UUID, accountID *uuid.UUID) (*models.Account, error) {
+	account, member, err := memberAccount(s.accountRepo, s.memberRepo, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.IsOwner() {
+		return nil, ErrAccountOwnerRequired
+	}
+	return account, nil
+}
+
+// ListMemberships returns the accounts the user is a member of, their own
+// first
+func (s *AccountMemberService) ListMemberships(userID uuid.UUID) ([]models.AccountMembership, error) {
+	members, err := s.memberRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account memberships: %w", err)
+	}
+
+	memberships := make([]models.AccountMembership, 0, len(members))
+	for _, member := range members {
+		account, err := s.accountRepo.GetAccountByID(member.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account: %w", err)
+		}
+		membership := models.AccountMembership{
+			AccountID: account.ID,
+			Role:      member.Role,
+			Holder:    account.UserID == userID,
+			Balance:   models.Amount(account.Balance),
+			Frozen:    account.IsFrozen(),
+		}
+		if member.SpendingLimit != nil {
+			limit := models.Amount(*member.SpendingLimit)
+			membership.SpendingLimit = &limit
+		}
+		if membership.Holder {
+			memberships = append([]models.AccountMembership{membership}, memberships...)
+		} else {
+			memberships = append(memberships, membership)
+		}
+	}
+
+	return memberships, nil
+}
+
+// ListMembers returns the account the user selects, its members and the
+// invitations to it that can still be accepted
+func (s *AccountMemberService) ListMembers(userID uuid.UUID, accountID *uuid.UUID) (*models.Account, []models.AccountMember, []models.AccountInvitation, error) {
+	account, _, err := memberAccount(s.accountRepo, s.memberRepo, userID, accountID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	members, err := s.memberRepo.ListMembers(account.ID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get account members: %w", err)
+	}
+	invitations, err := s.memberRepo.ListPendingInvitations(account.ID, s.now())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get account invitations: %w", err)
+	}
+	if members == nil {
+		members = []models.AccountMember{}
+	}
+	if invitations == nil {
+		invitations = []models.AccountInvitation{}
+	}
+
+	return account, members, invitations, nil
+}
+
+// Invite invites the user with request.Email to join an account the user
+// owns. The email is looked up on the client-service; emails of no active
+// user are ErrInviteeNotFound.
+func (s *AccountMemberService) Invite(userID uuid.UUID, request models.InviteAccountMemberRequest) (*models.AccountInvitation, error) {
+	account, err := s.ownedAccount(userID, request.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(request.Email)
+	detail, err := s.users.LookupUserByEmail(email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up invited user: %w", err)
+	}
+	if !detail.Exists || detail.IsDeleted {
+		return nil, ErrInviteeNotFound
+	}
+	inviteeID, err := uuid.Parse(detail.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up invited user: %w", err)
+	}
+
+	member, err := s.memberRepo.GetMember(account.ID, inviteeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account member: %w", err)
+	}
+	if member != nil || inviteeID == account.UserID {
+		return nil, ErrAccountMemberExists
+	}
+
+	role := request.Role
+	if role == "" {
+		role = models.AccountMemberRoleMember
+	}
+	invitation := &models.AccountInvitation{
+		ID:        uuid.New(),
+		AccountID: account.ID,
+		UserID:    inviteeID,
+		Email:     detail.Email,
+		Role:      role,
+		InvitedBy: userID,
+		ExpiresAt: s.now().Add(s.invitationTTL),
+	}
+	created, err := s.memberRepo.CreateInvitation(invitation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+	if !created {
+		return nil, ErrInvitationPending
+	}
+
+	return invitation, nil
+}
+
+// ListInvitations returns the invitations the user can still accept,
+// newest first
+func (s *AccountMemberService) ListInvitations(userID uuid.UUID) ([]models.AccountInvitation, error) {
+	invitations, err := s.memberRepo.ListPendingInvitationsByUserID(userID, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+	if invitations == nil {
+		invitations = []models.AccountInvitation{}
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation makes the user a member of the account one of their
+// invitations is to, with the invitation's role
+func (s *AccountMemberService) AcceptInvitation(userID, invitationID uuid.UUID) (*models.AccountInvitation, error) {
+	invitation, err := s.memberRepo.GetInvitation(invitationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if invitation == nil || invitation.UserID != userID {
+		return nil, ErrInvitationNotFound
+	}
+	if invitation.StatusAt(s.now()) != models.AccountInvitationStatusPending {
+		return nil, ErrInvitationNotPending
+	}
+
+	accepted, err := s.memberRepo.AcceptInvitation(invitation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	if !accepted {
+		return nil, ErrInvitationNotPending
+	}
+
+	return invitation, nil
+}
+
+// UpdateMember replaces the role and spending limit of a member of an
+// account the user owns. Only members can have a spending limit, and
+// demoting the last owner is ErrLastAccountOwner.
+func (s *AccountMemberService) UpdateMember(userID uuid.UUID, accountID *uuid.UUID, memberID uuid.UUID, request models.UpdateAccountMemberRequest) (*models.Account, *models.AccountMember, error) {
+	if request.Role == models.AccountMemberRoleOwner && request.SpendingLimit != nil {
+		return nil, nil, &ValidationError{Fields: []FieldError{{Field: "spending_limit", Rule: "excluded_if", Message: "owners cannot have a spending limit"}}}
+	}
+
+	account, err := s.ownedAccount(userID, accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	member, err := s.memberRepo.GetMember(account.ID, memberID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get account member: %w", err)
+	}
+	if member == nil {
+		return nil, nil, ErrAccountMemberNotFound
+	}
+
+	member.Role = request.Role
+	member.SpendingLimit = request.SpendingLimit
+	updated, err := s.memberRepo.UpdateMember(member)
+	if err != nil {
+		if errors.Is(err, repository.ErrLastOwner) {
+			return nil, nil, ErrLastAccountOwner
+		}
+		return nil, nil, fmt.Errorf("failed to update account member: %w", err)
+	}
+	if !updated {
+		return nil, nil, ErrAccountMemberNotFound
+	}
+
+	return account, member, nil
+}
+
+// RemoveMember removes a member from an account. Owners remove any member
+// but the holder, and members may remove themselves; removing the last
+// owner is ErrLastAccountOwner. Transactions the member made stay on the
+// account.
+func (s *AccountMemberService) RemoveMember(userID uuid.UUID, accountID *uuid.UUID, memberID uuid.UUID) error {
+	account, member, err := memberAccount(s.accountRepo, s.memberRepo, userID, accountID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && !member.IsOwner() {
+		return ErrAccountOwnerRequired
+	}
+	if memberID == account.UserID {
+		return ErrAccountHolderRemoval
+	}
+
+	removed, err := s.memberRepo.RemoveMember(account.ID, memberID)
+	if err != nil {
+		if errors.Is(err, repository.ErrLastOwner) {
+			return ErrLastAccountOwner
+		}
+		return fmt.Errorf("failed to remove account member: %w", err)
+	}
+	if !removed {
+		return ErrAccountMemberNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakeAccountMemberRepo keeps members and invitations in memory, refusing
+// what the database's indexes and owner checks would. Invitations expire
+// at now.
+type fakeAccountMemberRepo struct {
+	members     []models.AccountMember
+	invitations []models.AccountInvitation
+	now         func() time.Time
+}
+
+func (r *fakeAccountMemberRepo) find(accountID, userID uuid.UUID) int {
+	for i, member := range r.members {
+		if member.AccountID == accountID && member.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *fakeAccountMemberRepo) GetMember(accountID, userID uuid.UUID) (*models.AccountMember, error) {
+	i := r.find(accountID, userID)
+	if i < 0 {
+		return nil, nil
+	}
+	clone := r.members[i]
+	return &clone, nil
+}
+
+func (r *fakeAccountMemberRepo) ListMembers(accountID uuid.UUID) ([]models.AccountMember, error) {
+	var members []models.AccountMember
+	for _, member := range r.members {
+		if member.AccountID == accountID {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+func (r *fakeAccountMemberRepo) ListByUserID(userID uuid.UUID) ([]models.AccountMember, error) {
+	var members []models.AccountMember
+	for _, member := range r.members {
+		if member.UserID == userID {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+// ownersLeft reports whether an account keeps an owner other than userID
+func (r *fakeAccountMemberRepo) ownersLeft(accountID, userID uuid.UUID) bool {
+	for _, member := range r.members {
+		if member.AccountID == accountID && member.UserID != userID && member.IsOwner() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeAccountMemberRepo) UpdateMember(member *models.AccountMember) (bool, error) {
+	i := r.find(member.AccountID, member.UserID)
+	if i < 0 {
+		return false, nil
+	}
+	if r.members[i].IsOwner() && !member.IsOwner() && !r.ownersLeft(member.AccountID, member.UserID) {
+		return false, repository.ErrLastOwner
+	}
+	r.members[i] = *member
+	return true, nil
+}
+
+func (r *fakeAccountMemberRepo) RemoveMember(accountID, userID uuid.UUID) (bool, error) {
+	i := r.find(accountID, userID)
+	if i < 0 {
+		return false, nil
+	}
+	if r.members[i].IsOwner() && !r.ownersLeft(accountID, userID) {
+		return false, repository.ErrLastOwner
+	}
+	r.members = append(r.members[:i], r.members[i+1:]...)
+	return true, nil
+}
+
+func (r *fakeAccountMemberRepo) CreateInvitation(invitation *models.AccountInvitation) (bool, error) {
+	for i, existing := range r.invitations {
+		if existing.AccountID != invitation.AccountID || existing.UserID != invitation.UserID || existing.Status != models.AccountInvitationStatusPending {
+			continue
+		}
+		if existing.StatusAt(r.now()) == models.AccountInvitationStatusPending {
+			return false, nil
+		}
+		r.invitations[i].Status = models.AccountInvitationStatusExpired
+	}
+	invitation.Status = models.AccountInvitationStatusPending
+	r.invitations = append(r.invitations, *invitation)
+	return true, nil
+}
+
+func (r *fakeAccountMemberRepo) GetInvitation(id uuid.UUID) (*models.AccountInvitation, error) {
+	for _, invitation := range r.invitations {
+		if invitation.ID == id {
+			clone := invitation
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeAccountMemberRepo) ListPendingInvitations(accountID uuid.UUID, at time.Time) ([]models.AccountInvitation, error) {
+	var invitations []models.AccountInvitation
+	for _, invitation := range r.invitations {
+		if invitation.AccountID == accountID && invitation.StatusAt(at) == models.AccountInvitationStatusPending {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations, nil
+}
+
+func (r *fakeAccountMemberRepo) ListPendingInvitationsByUserID(userID uuid.UUID, at time.Time) ([]models.AccountInvitation, error) {
+	var invitations []models.AccountInvitation
+	for _, invitation := range r.invitations {
+		if invitation.UserID == userID && invitation.StatusAt(at) == models.AccountInvitationStatusPending {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations, nil
+}
+
+func (r *fakeAccountMemberRepo) AcceptInvitation(invitation *models.AccountInvitation) (bool, error) {
+	for i, existing := range r.invitations {
+		if existing.ID == invitation.ID && existing.Status == models.AccountInvitationStatusPending {
+			r.invitations[i].Status = models.AccountInvitationStatusAccepted
+			addedBy := invitation.InvitedBy
+			r.members = append(r.members, models.AccountMember{AccountID: invitation.AccountID, UserID: invitation.UserID, Role: invitation.Role, AddedBy: &addedBy})
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeUserLookup finds users by email in a map
+type fakeUserLookup map[string]uuid.UUID
+
+func (l fakeUserLookup) LookupUserByEmail(email string) (models.UserDetail, error) {
+	userID, ok := l[email]
+	if !ok {
+		return models.UserDetail{Email: email}, nil
+	}
+	return models.UserDetail{UserID: userID.String(), Exists: true, Email: email}, nil
+}
+
+// jointAccountFixture is Alice's account, with Bob and Carol able to join
+// it, on a fixed clock
+type jointAccountFixture struct {
+	accounts          *balanceAccountRepo
+	members           *fakeAccountMemberRepo
+	svc               *AccountMemberService
+	clock             time.Time
+	alice, bob, carol uuid.UUID
+	joint             uuid.UUID
+}
+
+func newJointAccountFixture() *jointAccountFixture {
+	f := &jointAccountFixture{clock: time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC), alice: uuid.New(), bob: uuid.New(), carol: uuid.New()}
+	f.accounts = newBeneficiaryAccounts(f.alice, f.bob, f.carol)
+	f.joint = f.accounts.accounts[f.alice].ID
+	f.members = &fakeAccountMemberRepo{now: func() time.Time { return f.clock }}
+	for _, userID := range []uuid.UUID{f.alice, f.bob, f.carol} {
+		f.members.members = append(f.members.members, models.AccountMember{AccountID: f.accounts.accounts[userID].ID, UserID: userID, Role: models.AccountMemberRoleOwner})
+	}
+	users := fakeUserLookup{"bob@example.com": f.bob, "carol@example.com": f.carol}
+	f.svc = NewAccountMemberService(f.members, f.accounts, users, DefaultAccountInvitationTTL)
+	f.svc.now = func() time.Time { return f.clock }
+	return f
+}
+
+// join invites the user with email to Alice's account and accepts it
+func (f *jointAccountFixture) join(t *testing.T, email string, role models.AccountMemberRole) {
+	t.Helper()
+	invitation, err := f.svc.Invite(f.alice, models.InviteAccountMemberRequest{Email: email, Role: role})
+	if err != nil {
+		t.Fatalf("Invite returned error: %v", err)
+	}
+	if _, err := f.svc.AcceptInvitation(invitation.UserID, invitation.ID); err != nil {
+		t.Fatalf("AcceptInvitation returned error: %v", err)
+	}
+}
+
+func TestAccountMemberService_InviteAndAccept(t *testing.T) {
+	f := newJointAccountFixture()
+
+	if _, err := f.svc.Invite(f.alice, models.InviteAccountMemberRequest{Email: "nobody@example.com"}); !errors.Is(err, ErrInviteeNotFound) {
+		t.Errorf("Expected ErrInviteeNotFound for an unknown email, got %v", err)
+	}
+
+	invitation, err := f.svc.Invite(f.alice, models.InviteAccountMemberRequest{Email: " bob@example.com "})
+	if err != nil {
+		t.Fatalf("Invite returned error: %v", err)
+	}
+	if invitation.UserID != f.bob || invitation.Role != models.AccountMemberRoleMember || !invitation.ExpiresAt.Equal(f.clock.Add(DefaultAccountInvitationTTL)) {
+		t.Errorf("Unexpected invitation: %+v", invitation)
+	}
+	if _, err := f.svc.Invite(f.alice, models.InviteAccountMemberRequest{Email: "bob@example.com"}); !errors.Is(err, ErrInvitationPending) {
+		t.Errorf("Expected ErrInvitationPending inviting Bob twice, got %v", err)
+	}
+
+	// Only Bob can accept it, and Bob cannot use the account before then
+	if _, err := f.svc.AcceptInvitation(f.carol, invitation.ID); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("Expected ErrInvitationNotFound for another user, got %v", err)
+	}
+	if _, _, _, err := f.svc.ListMembers(f.bob, &f.joint); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("Expected ErrAccountNotFound before accepting, got %v", err)
+	}
+	if invitations, _ := f.svc.ListInvitations(f.bob); len(invitations) != 1 {
+		t.Errorf("Expected Bob to have one invitation, got %+v", invitations)
+	}
+
+	if _, err := f.svc.AcceptInvitation(f.bob, invitation.ID); err != nil {
+		t.Fatalf("AcceptInvitation returned error: %v", err)
+	}
+	if _, err := f.svc.AcceptInvitation(f.bob, invitation.ID); !errors.Is(err, ErrInvitationNotPending) {
+		t.Errorf("Expected ErrInvitationNotPending accepting twice, got %v", err)
+	}
+
+	account, members, invitations, err := f.svc.ListMembers(f.bob, &f.joint)
+	if err != nil {
+		t.Fatalf("ListMembers returned error: %v", err)
+	}
+	if account.ID != f.joint || len(members) != 2 || len(invitations) != 0 {
+		t.Errorf("Expected Alice and Bob on the account, got %+v and %+v", members, invitations)
+	}
+	memberships, err := f.svc.ListMemberships(f.bob)
+	if err != nil {
+		t.Fatalf("ListMemberships returned error: %v", err)
+	}
+	if len(memberships) != 2 || !memberships[0].Holder || memberships[1].AccountID != f.joint || memberships[1].Role != models.AccountMemberRoleMember {
+		t.Errorf("Expected Bob's own account then the joint one, got %+v", memberships)
+	}
+
+	// Members cannot invite
+	if _, err := f.svc.Invite(f.bob, models.InviteAccountMemberRequest{AccountID: &f.joint, Email: "carol@example.com"}); !errors.Is(err, ErrAccountOwnerRequired) {
+		t.Errorf("Expected ErrAccountOwnerRequired, got %v", err)
+	}
+	if _, err := f.svc.Invite(f.alice, models.InviteAccountMemberRequest{Email: "bob@example.com"}); !errors.Is(err, ErrAccountMemberExists) {
+		t.Errorf("Expected ErrAccountMemberExists, got %v", err)
+	}
+}
+
+func TestAccountMemberService_InvitationsExpire(t *testing.T) {
+	f := newJointAccountFixture()
+
+	invitation, err := f.svc.Invite(f.alice, models.InviteAccountMemberRequest{Email: "carol@example.com"})
+	if err != nil {
+		t.Fatalf("Invite returned error: %v", err)
+	}
+
+	f.clock = invitation.ExpiresAt
+	if _, err := f.svc.AcceptInvitation(f.carol, invitation.ID); !errors.Is(err, ErrInvitationNotPending) {
+		t.Errorf("Expected ErrInvitationNotPending once expired, got %v", err)
+	}
+	if invitations, _ := f.svc.ListInvitations(f.carol); len(invitations) != 0 {
+		t.Errorf("Expected no invitations once expired, got %+v", invitations)
+	}
+
+	// Carol can be invited again
+	if _, err := f.svc.Invite(f.alice, models.InviteAccountMemberRequest{Email: "carol@example.com"}); err != nil {
+		t.Errorf("Expected a new invitation once the first expired, got %v", err)
+	}
+}
+
+func TestAccountMemberService_UpdateAndRemove(t *testing.T) {
+	f := newJointAccountFixture()
+	f.join(t, "bob@example.com", models.AccountMemberRoleMember)
+	f.join(t, "carol@example.com", models.AccountMemberRoleMember)
+
+	limit := 50.0
+	if _, _, err := f.svc.UpdateMember(f.alice, nil, f.bob, models.UpdateAccountMemberRequest{Role: models.AccountMemberRoleOwner, SpendingLimit: &limit}); err == nil {
+		t.Error("Expected owners not to have a spending limit")
+	}
+	if _, _, err := f.svc.UpdateMember(f.bob, &f.joint, f.carol, models.UpdateAccountMemberRequest{Role: models.AccountMemberRoleOwner}); !errors.Is(err, ErrAccountOwnerRequired) {
+		t.Errorf("Expected ErrAccountOwnerRequired, got %v", err)
+	}
+	_, member, err := f.svc.UpdateMember(f.alice, nil, f.bob, models.UpdateAccountMemberRequest{Role: models.AccountMemberRoleMember, SpendingLimit: &limit})
+	if err != nil {
+		t.Fatalf("UpdateMember returned error: %v", err)
+	}
+	if *member.SpendingLimit != 50 {
+		t.Errorf("Expected a spending limit of 50, got %+v", member)
+	}
+
+	// Alice is the only owner, so she cannot step down
+	if _, _, err := f.svc.UpdateMember(f.alice, nil, f.alice, models.UpdateAccountMemberRequest{Role: models.AccountMemberRoleMember}); !errors.Is(err, ErrLastAccountOwner) {
+		t.Errorf("Expected ErrLastAccountOwner, got %v", err)
+	}
+
+	// Members may leave but not remove others; no one removes the holder
+	if err := f.svc.RemoveMember(f.bob, &f.joint, f.carol); !errors.Is(err, ErrAccountOwnerRequired) {
+		t.Errorf("Expected ErrAccountOwnerRequired, got %v", err)
+	}
+	if err := f.svc.RemoveMember(f.alice, nil, f.alice); !errors.Is(err, ErrAccountHolderRemoval) {
+		t.Errorf("Expected ErrAccountHolderRemoval, got %v", err)
+	}
+	if err := f.svc.RemoveMember(f.bob, &f.joint, f.bob); err != nil {
+		t.Errorf("Expected Bob to leave, got %v", err)
+	}
+	if err := f.svc.RemoveMember(f.alice, nil, f.carol); err != nil {
+		t.Errorf("Expected Alice to remove Carol, got %v", err)
+	}
+	if err := f.svc.RemoveMember(f.alice, nil, f.carol); !errors.Is(err, ErrAccountMemberNotFound) {
+		t.Errorf("Expected ErrAccountMemberNotFound removing Carol twice, got %v", err)
+	}
+	if _, _, _, err := f.svc.ListMembers(f.bob, &f.joint); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("Expected ErrAccountNotFound after leaving, got %v", err)
+	}
+}
+
+func TestTransactionService_JointAccountWithdrawals(t *testing.T) {
+	f := newJointAccountFixture()
+	f.join(t, "bob@example.com", models.AccountMemberRoleMember)
+	limit := 100.0
+	if _, _, err := f.svc.UpdateMember(f.alice, nil, f.bob, models.UpdateAccountMemberRequest{Role: models.AccountMemberRoleMember, SpendingLimit: &limit}); err != nil {
+		t.Fatalf("UpdateMember returned error: %v", err)
+	}
+
+	transactions := &fakeTransactionRepo{accounts: f.accounts}
+	svc := NewTransactionService(transactions, f.accounts).WithJointAccounts(f.members)
+
+	var spendingLimit *SpendingLimitError
+	if _, err := svc.ProcessWithdrawal(f.bob, models.TransactionRequest{AccountID: &f.joint, Amount: 100.01}); !errors.As(err, &spendingLimit) || spendingLimit.Limit != 100 {
+		t.Errorf("Expected a SpendingLimitError, got %v", err)
+	}
+
+	// Bob's withdrawal comes out of Alice's account but records Bob
+	withdrawal, err := svc.ProcessWithdrawal(f.bob, models.TransactionRequest{AccountID: &f.joint, Amount: 100, Description: "Groceries"})
+	if err != nil {
+		t.Fatalf("ProcessWithdrawal returned error: %v", err)
+	}
+	if withdrawal.Transaction.AccountID != f.joint || withdrawal.Transaction.UserID != f.bob || f.accounts.accounts[f.alice].Balance != 900 || f.accounts.accounts[f.bob].Balance != 1000 {
+		t.Errorf("Expected Bob to withdraw from the joint account, got %+v", withdrawal.Transaction)
+	}
+	if ok, err := svc.CanView(f.alice, withdrawal.Transaction); err != nil || !ok {
+		t.Errorf("Expected Alice to see Bob's withdrawal, got %v (%v)", ok, err)
+	}
+
+	// The limit does not apply to owners, and non-members cannot select it
+	if _, err := svc.ProcessWithdrawal(f.alice, models.TransactionRequest{Amount: 500}); err != nil {
+		t.Errorf("Expected Alice to withdraw over Bob's limit, got %v", err)
+	}
+	if _, err := svc.ProcessWithdrawal(f.carol, models.TransactionRequest{AccountID: &f.joint, Amount: 10}); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("Expected ErrAccountNotFound for a non-member, got %v", err)
+	}
+	if ok, _ := svc.CanView(f.carol, withdrawal.Transaction); ok {
+		t.Error("Expected Carol not to see the withdrawal")
+	}
+}
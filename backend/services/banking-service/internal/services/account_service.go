@@ -46,6 +46,18 @@ func (s *AccountService) GetOrCreateAccount(userID uuid.UUID) (*models.Account,
 	return account, nil
 }
 
+// ProvisionAccount creates an account for a newly registered user. It is
+// idempotent: a user who already has an account keeps it, and the result
+// reports whether one was created.
+func (s *AccountService) ProvisionAccount(userID uuid.UUID) (*models.Account, bool, error) {
+	account, created, err := s.accountRepo.CreateAccountIfMissing(userID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to provision account: %w", err)
+	}
+
+	return account, created, nil
+}
+
 // GetAccountByUserID retrieves an account by user ID
 func (s *AccountService) GetAccountByUserID(userID uuid.UUID) (*models.Account, error) {
 	account, err := s.accountRepo.GetAccountByUserID(userID)
@@ -66,6 +78,26 @@ func (s *AccountService) GetAccountBalance(userID uuid.UUID) (float64, error) {
 	return account.Balance, nil
 }
 
+// GetUserBalance gets the balance of a user's account and reports whether
+// the user has one. Users without an account have a balance of zero.
+func (s *AccountService) GetUserBalance(userID uuid.UUID) (float64, bool, error) {
+	exists, err := s.accountRepo.AccountExists(userID)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to check account existence: %w", err)
+	}
+
+	if !exists {
+		return 0, false, nil
+	}
+
+	balance, err := s.GetAccountBalance(userID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return balance, true, nil
+}
+
 // UpdateAccountBalance updates the account balance
 func (s *AccountService) UpdateAccountBalance(accountID uuid.UUID, newBalance float64) error {
 	if err := s.accountRepo.UpdateBalance(accountID, newBalance); err != nil {
@@ -84,3 +116,49 @@ func (s *AccountService) GetAllAccounts() ([]models.Account, error) {
 
 	return accounts, nil
 }
+
+// FlagOrphanedAccount marks the account of a user deleted in the client
+// service so it is kept for reconciliation rather than left dangling. It
+// reports whether the user had an account.
+func (s *AccountService) FlagOrphanedAccount(userID uuid.UUID) (bool, error) {
+	flagged, err := s.accountRepo.MarkOwnerDeleted(userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to flag orphaned account: %w", err)
+	}
+
+	return flagged, nil
+}
+
+// UnflagOrphanedAccount clears the orphaned flag from the account of a user
+// restored in the client service. It reports whether a flagged account was
+// found.
+func (s *AccountService) UnflagOrphanedAccount(userID uuid.UUID) (bool, error) {
+	cleared, err := s.accountRepo.ClearOwnerDeleted(userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to clear orphaned account flag: %w", err)
+	}
+
+	return cleared, nil
+}
+
+// FreezeAccount freezes the account of a user blacklisted in the client
+// service. It reports whether an unfrozen account was found.
+func (s *AccountService) FreezeAccount(userID uuid.UUID) (bool, error) {
+	frozen, err := s.accountRepo.SetFrozen(userID, true)
+	if err != nil {
+		return false, fmt.Errorf("failed to freeze account: %w", err)
+	}
+
+	return frozen, nil
+}
+
+// UnfreezeAccount unfreezes the account of a user whose blacklist was
+// lifted. It reports whether a frozen account was found.
+func (s *AccountService) UnfreezeAccount(userID uuid.UUID) (bool, error) {
+	unfrozen, err := s.accountRepo.SetFrozen(userID, false)
+	if err != nil {
+		return false, fmt.Errorf("failed to unfreeze account: %w", err)
+	}
+
+	return unfrozen, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// BeneficiaryService handles beneficiaries, the accounts users save to
+// transfer to. Transfers themselves are made by
+// TransactionService.ProcessTransfer.
+type BeneficiaryService struct {
+	beneficiaryRepo repository.BeneficiaryRepository
+	accountRepo     repository.AccountRepository
+	rules           models.BeneficiaryRules
+}
+
+// NewBeneficiaryService creates a new beneficiary service
+func NewBeneficiaryService(beneficiaryRepo repository.BeneficiaryRepository, accountRepo repository.AccountRepository, rules models.BeneficiaryRules) *BeneficiaryService {
+	return &BeneficiaryService{
+		beneficiaryRepo: beneficiaryRepo,
+		accountRepo:     accountRepo,
+		rules:           rules,
+	}
+}
+
+// Rules returns the rules beneficiaries are held to
+func (s *BeneficiaryService) Rules() models.BeneficiaryRules {
+	return s.rules
+}
+
+// CreateBeneficiary saves another account as one of the user's
+// beneficiaries. The account must exist and not be the user's own.
+func (s *BeneficiaryService) CreateBeneficiary(userID uuid.UUID, request models.CreateBeneficiaryRequest) (*models.Beneficiary, error) {
+	nickname := strings.TrimSpace(request.Nickname)
+	if nickname == "" {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "nickname", Rule: "required", Message: "is required"}}}
+	}
+
+	account, err := s.accountRepo.GetAccountByID(request.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDestinationAccountNotFound, err)
+	}
+	if account.UserID == userID {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "account_id", Rule: "ne", Message: "must be another user's account"}}}
+	}
+
+	beneficiary := &models.Beneficiary{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Nickname:  nickname,
+		AccountID: account.ID,
+	}
+	if err := s.beneficiaryRepo.Create(beneficiary, s.rules.MaxPerUser); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrBeneficiaryLimit):
+			return nil, fmt.Errorf("%w: at most %d are allowed", ErrBeneficiaryLimitReached, s.rules.MaxPerUser)
+		case errors.Is(err, repository.ErrBeneficiaryDuplicate):
+			return nil, ErrBeneficiaryExists
+		}
+		return nil, fmt.Errorf("failed to create beneficiary: %w", err)
+	}
+
+	return beneficiary, nil
+}
+
+// ListBeneficiaries returns the user's beneficiaries, ordered by nickname
+func (s *BeneficiaryService) ListBeneficiaries(userID uuid.UUID) ([]models.Beneficiary, error) {
+	beneficiaries, err := s.beneficiaryRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get beneficiaries: %w", err)
+	}
+	if beneficiaries == nil {
+		beneficiaries = []models.Beneficiary{}
+	}
+	return beneficiaries, nil
+}
+
+// DeleteBeneficiary deletes one of the user's beneficiaries. Transfers
+// already made to it are left as they were.
+func (s *BeneficiaryService) DeleteBeneficiary(userID, beneficiaryID uuid.UUID) error {
+	deleted, err := s.beneficiaryRepo.Delete(userID, beneficiaryID)
+	if err != nil {
+		return fmt.Errorf("failed to delete beneficiary: %w", err)
+	}
+	if !deleted {
+		return ErrBeneficiaryNotFound
+	}
+	return nil
+}
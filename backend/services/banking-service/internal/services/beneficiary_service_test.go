@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakeBeneficiaryRepo keeps beneficiaries in memory, enforcing the
+// per-user limit and each account being saved once as the database does
+type fakeBeneficiaryRepo struct {
+	repository.BeneficiaryRepository
+	beneficiaries []*models.Beneficiary
+	now           time.Time
+}
+
+func (r *fakeBeneficiaryRepo) Create(beneficiary *models.Beneficiary, limit int) error {
+	count := 0
+	for _, existing := range r.beneficiaries {
+		if existing.UserID != beneficiary.UserID {
+			continue
+		}
+		count++
+		if existing.AccountID == beneficiary.AccountID {
+			return repository.ErrBeneficiaryDuplicate
+		}
+	}
+	if count >= limit {
+		return repository.ErrBeneficiaryLimit
+	}
+	beneficiary.CreatedAt = r.now
+	clone := *beneficiary
+	r.beneficiaries = append(r.beneficiaries, &clone)
+	return nil
+}
+
+func (r *fakeBeneficiaryRepo) GetByID(id uuid.UUID) (*models.Beneficiary, error) {
+	for _, beneficiary := range r.beneficiaries {
+		if beneficiary.ID == id {
+			clone := *beneficiary
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeBeneficiaryRepo) GetByAccountID(userID, accountID uuid.UUID) (*models.Beneficiary, error) {
+	for _, beneficiary := range r.beneficiaries {
+		if beneficiary.UserID == userID && beneficiary.AccountID == accountID {
+			clone := *beneficiary
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeBeneficiaryRepo) ListByUserID(userID uuid.UUID) ([]models.Beneficiary, error) {
+	var beneficiaries []models.Beneficiary
+	for _, beneficiary := range r.beneficiaries {
+		if beneficiary.UserID == userID {
+			beneficiaries = append(beneficiaries, *beneficiary)
+		}
+	}
+	sort.Slice(beneficiaries, func(i, j int) bool {
+		return strings.ToLower(beneficiaries[i].Nickname) < strings.ToLower(beneficiaries[j].Nickname)
+	})
+	return beneficiaries, nil
+}
+
+func (r *fakeBeneficiaryRepo) Delete(userID, id uuid.UUID) (bool, error) {
+	for i, beneficiary := range r.beneficiaries {
+		if beneficiary.ID == id && beneficiary.UserID == userID {
+			r.beneficiaries = append(r.beneficiaries[:i], r.beneficiaries[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var testBeneficiaryRules = models.BeneficiaryRules{MaxPerUser: 2, CoolingOff: 24 * time.Hour, LargeTransferLimit: 500}
+
+// newBeneficiaryAccounts returns accounts for the users, each holding 1000
+func newBeneficiaryAccounts(userIDs ...uuid.UUID) *balanceAccountRepo {
+	accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{}}}
+	for _, userID := range userIDs {
+		accounts.accounts[userID] = &models.Account{ID: uuid.New(), UserID: userID, Balance: 1000}
+	}
+	return accounts
+}
+
+func TestBeneficiaryService_CreateBeneficiary(t *testing.T) {
+	user, alice, bob, carol := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	accounts := newBeneficiaryAccounts(user, alice, bob, carol)
+	repo := &fakeBeneficiaryRepo{now: time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)}
+	svc := NewBeneficiaryService(repo, accounts, testBeneficiaryRules)
+
+	beneficiary, err := svc.CreateBeneficiary(user, models.CreateBeneficiaryRequest{Nickname: "  Alice  ", AccountID: accounts.accounts[alice].ID})
+	if err != nil {
+		t.Fatalf("CreateBeneficiary returned error: %v", err)
+	}
+	if beneficiary.Nickname != "Alice" || beneficiary.AccountID != accounts.accounts[alice].ID || beneficiary.UserID != user {
+		t.Errorf("Unexpected beneficiary: %+v", beneficiary)
+	}
+	if want := repo.now.Add(24 * time.Hour); !beneficiary.ToResponse(svc.Rules()).LargeTransfersAllowedAt.Equal(want) {
+		t.Errorf("Expected large transfers to be allowed from %v, got %v", want, beneficiary.ToResponse(svc.Rules()).LargeTransfersAllowedAt)
+	}
+
+	tests := []struct {
+		name      string
+		nickname  string
+		accountID uuid.UUID
+		wantErr   error
+	}{
+		{name: "account saved already", nickname: "Alice again", accountID: accounts.accounts[alice].ID, wantErr: ErrBeneficiaryExists},
+		{name: "unknown account", nickname: "Nobody", accountID: uuid.New(), wantErr: ErrDestinationAccountNotFound},
+		{name: "blank nickname", nickname: "   ", accountID: accounts.accounts[bob].ID},
+		{name: "own account", nickname: "Me", accountID: accounts.accounts[user].ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateBeneficiary(user, models.CreateBeneficiaryRequest{Nickname: tt.nickname, AccountID: tt.accountID})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected a validation error, got %v", err)
+			}
+		})
+	}
+
+	if _, err := svc.CreateBeneficiary(user, models.CreateBeneficiaryRequest{Nickname: "Bob", AccountID: accounts.accounts[bob].ID}); err != nil {
+		t.Fatalf("CreateBeneficiary returned error: %v", err)
+	}
+	if _, err := svc.CreateBeneficiary(user, models.CreateBeneficiaryRequest{Nickname: "Carol", AccountID: accounts.accounts[carol].ID}); !errors.Is(err, ErrBeneficiaryLimitReached) {
+		t.Fatalf("Expected a third beneficiary to be over the limit of 2, got %v", err)
+	}
+
+	// The limit is per user
+	if _, err := svc.CreateBeneficiary(alice, models.CreateBeneficiaryRequest{Nickname: "Carol", AccountID: accounts.accounts[carol].ID}); err != nil {
+		t.Fatalf("Expected another user to save a beneficiary, got %v", err)
+	}
+
+	beneficiaries, err := svc.ListBeneficiaries(user)
+	if err != nil {
+		t.Fatalf("ListBeneficiaries returned error: %v", err)
+	}
+	if len(beneficiaries) != 2 || beneficiaries[0].Nickname != "Alice" || beneficiaries[1].Nickname != "Bob" {
+		t.Errorf("Expected Alice and Bob, got %+v", beneficiaries)
+	}
+}
+
+func TestBeneficiaryService_DeleteBeneficiary(t *testing.T) {
+	user, other, payee := uuid.New(), uuid.New(), uuid.New()
+	accounts := newBeneficiaryAccounts(user, other, payee)
+	repo := &fakeBeneficiaryRepo{now: time.Now()}
+	svc := NewBeneficiaryService(repo, accounts, testBeneficiaryRules)
+	transactions := &fakeTransactionRepo{accounts: accounts}
+	transactionService := NewTransactionService(transactions, accounts).WithBeneficiaries(repo, testBeneficiaryRules)
+
+	beneficiary, err := svc.CreateBeneficiary(user, models.CreateBeneficiaryRequest{Nickname: "Rent", AccountID: accounts.accounts[payee].ID})
+	if err != nil {
+		t.Fatalf("CreateBeneficiary returned error: %v", err)
+	}
+	if _, err := transactionService.ProcessTransfer(user, models.TransferRequest{Amount: 100, BeneficiaryID: &beneficiary.ID}); err != nil {
+		t.Fatalf("ProcessTransfer returned error: %v", err)
+	}
+
+	if err := svc.DeleteBeneficiary(other, beneficiary.ID); !errors.Is(err, ErrBeneficiaryNotFound) {
+		t.Fatalf("Expected another user's beneficiary not to be found, got %v", err)
+	}
+	if err := svc.DeleteBeneficiary(user, beneficiary.ID); err != nil {
+		t.Fatalf("DeleteBeneficiary returned error: %v", err)
+	}
+	if err := svc.DeleteBeneficiary(user, beneficiary.ID); !errors.Is(err, ErrBeneficiaryNotFound) {
+		t.Fatalf("Expected a deleted beneficiary not to be found, got %v", err)
+	}
+
+	// The transfer made to it stands, and it can no longer be used
+	if len(transactions.created) != 2 || accounts.accounts[payee].Balance != 1100 {
+		t.Errorf("Expected the earlier transfer to stand, got %d transactions and a balance of %v", len(transactions.created), accounts.accounts[payee].Balance)
+	}
+	if _, err := transactionService.ProcessTransfer(user, models.TransferRequest{Amount: 100, BeneficiaryID: &beneficiary.ID}); !errors.Is(err, ErrBeneficiaryNotFound) {
+		t.Errorf("Expected a transfer to the deleted beneficiary to fail, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// Cheque deposit list paging limits
+const (
+	DefaultChequePageSize = 50
+	MaxChequePageSize     = 200
+)
+
+// ChequeService lists cheque deposits and resolves their holds. Cheques
+// are deposited by TransactionService.ProcessChequeDeposit; their holds
+// lapse on their own, or staff release them early or return cheques that
+// bounced.
+type ChequeService struct {
+	chequeRepo repository.ChequeRepository
+	now        func() time.Time
+}
+
+// NewChequeService creates a new cheque service
+func NewChequeService(chequeRepo repository.ChequeRepository) *ChequeService {
+	return &ChequeService{
+		chequeRepo: chequeRepo,
+		now:        time.Now,
+	}
+}
+
+// ListCheques returns one page of cheque deposits matching the filter,
+// newest first
+func (s *ChequeService) ListCheques(filter models.ChequeDepositFilter) (*models.ChequeDepositPage, error) {
+	if filter.Status != "" && !models.IsChequeStatus(string(filter.Status)) {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "status", Rule: "oneof", Message: "must be held, cleared, released or returned"}}}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultChequePageSize
+	}
+	if filter.Limit > MaxChequePageSize {
+		filter.Limit = MaxChequePageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	filter.At = s.now()
+
+	cheques, total, err := s.chequeRepo.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cheque deposits: %w", err)
+	}
+	if cheques == nil {
+		cheques = []models.ChequeDeposit{}
+	}
+
+	return &models.ChequeDepositPage{Cheques: cheques, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// GetCheque returns a cheque deposit
+func (s *ChequeService) GetCheque(chequeID uuid.UUID) (*models.ChequeDeposit, error) {
+	cheque, err := s.chequeRepo.GetByID(chequeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cheque deposit: %w", err)
+	}
+	if cheque == nil {
+		return nil, ErrChequeNotFound
+	}
+	return cheque, nil
+}
+
+// Release lifts the hold of a cheque still on hold, on behalf of the staff
+// member staffID, making its amount available at once
+func (s *ChequeService) Release(chequeID, staffID uuid.UUID) (*models.ChequeDeposit, error) {
+	cheque, err := s.heldCheque(chequeID)
+	if err != nil {
+		return nil, err
+	}
+
+	cheque.ResolvedBy = &staffID
+	if err := s.chequeRepo.Release(cheque); err != nil {
+		if errors.Is(err, repository.ErrChequeNotOnHold) {
+			return nil, ErrChequeNotOnHold
+		}
+		return nil, fmt.Errorf("failed to release cheque: %w", err)
+	}
+
+	return cheque, nil
+}
+
+// Return reverses the deposit of a cheque still on hold that bounced, on
+// behalf of the staff member staffID, with a cheque_return transaction
+// pointing to the deposit. A fee above zero is charged on the return as a
+// fee transaction pointing to it; an *InsufficientFundsError is returned
+// when the balance does not cover it, and nothing is written.
+func (s *ChequeService) Return(chequeID, staffID uuid.UUID, request models.ReturnChequeRequest) (*models.ChequeDeposit, error) {
+	cheque, err := s.heldCheque(chequeID)
+	if err != nil {
+		return nil, err
+	}
+
+	reversal := &models.Transaction{
+		ID:                   uuid.New(),
+		AccountID:            cheque.AccountID,
+		UserID:               cheque.UserID,
+		Type:                 models.TransactionTypeChequeReturn,
+		Amount:               cheque.Amount,
+		Description:          "Returned cheque #" + cheque.ChequeNumber,
+		RelatedTransactionID: &cheque.TransactionID,
+	}
+	var fee *models.Transaction
+	if amount := roundCents(request.Fee); amount > 0 {
+		fee = &models.Transaction{
+			ID:                   uuid.New(),
+			AccountID:            cheque.AccountID,
+			UserID:               cheque.UserID,
+			Type:                 models.TransactionTypeFee,
+			Amount:               amount,
+			Description:          "Returned cheque fee",
+			RelatedTransactionID: &reversal.ID,
+		}
+	}
+
+	cheque.ReturnReason = request.Reason
+	cheque.ResolvedBy = &staffID
+	err = s.chequeRepo.Return(cheque, reversal, fee)
+	switch {
+	case err == nil:
+		return cheque, nil
+	case errors.Is(err, repository.ErrChequeNotOnHold):
+		return nil, ErrChequeNotOnHold
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		return nil, &InsufficientFundsError{Requested: cheque.Amount, Fee: request.Fee}
+	}
+	return nil, fmt.Errorf("failed to return cheque: %w", err)
+}
+
+// heldCheque returns a cheque deposit still on hold
+func (s *ChequeService) heldCheque(chequeID uuid.UUID) (*models.ChequeDeposit, error) {
+	cheque, err := s.GetCheque(chequeID)
+	if err != nil {
+		return nil, err
+	}
+	if !cheque.OnHold(s.now()) {
+		return nil, ErrChequeNotOnHold
+	}
+	return cheque, nil
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakeChequeRepo keeps cheque deposits in memory, crediting and debiting
+// accounts as the database does. Holds are checked at now.
+type fakeChequeRepo struct {
+	repository.ChequeRepository
+	accounts *balanceAccountRepo
+	now      func() time.Time
+	cheques  []*models.ChequeDeposit
+	posted   []models.Transaction
+}
+
+func (r *fakeChequeRepo) Create(cheque *models.ChequeDeposit, transaction *models.Transaction) error {
+	if err := r.post(transaction, transaction.Amount); err != nil {
+		return err
+	}
+	cheque.TransactionID = transaction.ID
+	cheque.Status = models.ChequeStatusHeld
+	clone := *cheque
+	r.cheques = append(r.cheques, &clone)
+	return nil
+}
+
+func (r *fakeChequeRepo) GetByID(id uuid.UUID) (*models.ChequeDeposit, error) {
+	for _, cheque := range r.cheques {
+		if cheque.ID == id {
+			clone := *cheque
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeChequeRepo) HeldByAccountID(accountID uuid.UUID, at time.Time) (float64, error) {
+	held := 0.0
+	for _, cheque := range r.cheques {
+		if cheque.AccountID == accountID && cheque.OnHold(at) {
+			held += cheque.Amount
+		}
+	}
+	return roundCents(held), nil
+}
+
+func (r *fakeChequeRepo) Release(cheque *models.ChequeDeposit) error {
+	stored, err := r.held(cheque.ID)
+	if err != nil {
+		return err
+	}
+	cheque.Status = models.ChequeStatusReleased
+	*stored = *cheque
+	return nil
+}
+
+func (r *fakeChequeRepo) Return(cheque *models.ChequeDeposit, reversal, fee *models.Transaction) error {
+	stored, err := r.held(cheque.ID)
+	if err != nil {
+		return err
+	}
+	account, err := r.accounts.GetAccountByID(cheque.AccountID)
+	if err != nil {
+		return err
+	}
+	total := reversal.Amount
+	if fee != nil {
+		total += fee.Amount
+	}
+	if roundCents(account.Balance-total) < 0 {
+		return repository.ErrInsufficientBalance
+	}
+	if err := r.post(reversal, -reversal.Amount); err != nil {
+		return err
+	}
+	cheque.ReturnTransactionID = &reversal.ID
+	if fee != nil {
+		if err := r.post(fee, -fee.Amount); err != nil {
+			return err
+		}
+		cheque.ReturnFeeTransactionID = &fee.ID
+	}
+	cheque.Status = models.ChequeStatusReturned
+	*stored = *cheque
+	return nil
+}
+
+// post records transaction, changing its account's balance by change
+func (r *fakeChequeRepo) post(transaction *models.Transaction, change float64) error {
+	account, err := r.accounts.GetAccountByID(transaction.AccountID)
+	if err != nil {
+		return err
+	}
+	transaction.BalanceBefore = account.Balance
+	transaction.BalanceAfter = roundCents(account.Balance + change)
+	r.posted = append(r.posted, *transaction)
+	return r.accounts.UpdateBalance(account.ID, transaction.BalanceAfter)
+}
+
+func (r *fakeChequeRepo) held(id uuid.UUID) (*models.ChequeDeposit, error) {
+	for _, cheque := range r.cheques {
+		if cheque.ID == id {
+			if !cheque.OnHold(r.now()) {
+				return nil, repository.ErrChequeNotOnHold
+			}
+			return cheque, nil
+		}
+	}
+	return nil, errors.New("cheque deposit not found")
+}
+
+// chequeFixture is a user holding 1000 with services sharing one clock,
+// which tests move
+type chequeFixture struct {
+	user         uuid.UUID
+	accounts     *balanceAccountRepo
+	cheques      *fakeChequeRepo
+	transactions *TransactionService
+	svc          *ChequeService
+	clock        time.Time
+}
+
+func newChequeFixture() *chequeFixture {
+	f := &chequeFixture{user: uuid.New(), clock: time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)}
+	now := func() time.Time { return f.clock }
+	f.accounts = newBeneficiaryAccounts(f.user)
+	f.cheques = &fakeChequeRepo{accounts: f.accounts, now: now}
+	f.transactions = NewTransactionService(&fakeTransactionRepo{accounts: f.accounts}, f.accounts).WithCheques(f.cheques, models.ChequeRules{DefaultHold: 72 * time.Hour})
+	f.transactions.now = now
+	f.svc = NewChequeService(f.cheques)
+	f.svc.now = now
+	return f
+}
+
+func (f *chequeFixture) deposit(t *testing.T, amount float64) *models.ChequeDeposit {
+	t.Helper()
+	cheque, _, err := f.transactions.ProcessChequeDeposit(uuid.New(), models.ChequeDepositRequest{UserID: f.user, Amount: amount, ChequeNumber: "100234"})
+	if err != nil {
+		t.Fatalf("ProcessChequeDeposit returned error: %v", err)
+	}
+	return cheque
+}
+
+func TestTransactionService_ChequeHoldLapses(t *testing.T) {
+	f := newChequeFixture()
+	deposited := f.clock
+	cheque := f.deposit(t, 500)
+
+	if !cheque.HoldUntil.Equal(deposited.Add(72 * time.Hour)) {
+		t.Errorf("Expected the default 72h hold, got %v", cheque.HoldUntil)
+	}
+	if f.accounts.accounts[f.user].Balance != 1500 {
+		t.Errorf("Expected the cheque to be booked at once, got a balance of %v", f.accounts.accounts[f.user].Balance)
+	}
+	if len(f.cheques.posted) != 1 || f.cheques.posted[0].Type != models.TransactionTypeDeposit || f.cheques.posted[0].Description != "Cheque deposit #100234" {
+		t.Errorf("Expected one deposit transaction, got %+v", f.cheques.posted)
+	}
+
+	tests := []struct {
+		name      string
+		at        time.Time
+		wantHeld  float64
+		wantState models.ChequeStatus
+	}{
+		{name: "just deposited", at: deposited, wantHeld: 500, wantState: models.ChequeStatusHeld},
+		{name: "a moment before the hold lapses", at: cheque.HoldUntil.Add(-time.Nanosecond), wantHeld: 500, wantState: models.ChequeStatusHeld},
+		{name: "when the hold lapses", at: cheque.HoldUntil, wantHeld: 0, wantState: models.ChequeStatusCleared},
+		{name: "after the hold lapses", at: cheque.HoldUntil.Add(time.Hour), wantHeld: 0, wantState: models.ChequeStatusCleared},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.clock = tt.at
+			if held, err := f.transactions.HeldFunds(f.accounts.accounts[f.user].ID); err != nil || held != tt.wantHeld {
+				t.Errorf("Expected %v held, got %v (%v)", tt.wantHeld, held, err)
+			}
+			if state := cheque.StatusAt(tt.at); state != tt.wantState {
+				t.Errorf("Expected the cheque to be %s, got %s", tt.wantState, state)
+			}
+		})
+	}
+
+	// Only the booked balance less the hold can be spent until it lapses
+	f.clock = cheque.HoldUntil.Add(-time.Second)
+	var insufficient *InsufficientFundsError
+	if _, err := f.transactions.ProcessWithdrawal(f.user, models.TransactionRequest{Amount: 1000.01, Description: ""}); !errors.As(err, &insufficient) || insufficient.Available != 1000 {
+		t.Errorf("Expected a withdrawal into the cheque to find 1000 available, got %v", err)
+	}
+	f.clock = cheque.HoldUntil
+	if _, err := f.transactions.ProcessWithdrawal(f.user, models.TransactionRequest{Amount: 1500, Description: ""}); err != nil {
+		t.Errorf("Expected the whole balance to be withdrawable once the hold lapses, got %v", err)
+	}
+}
+
+func TestTransactionService_ProcessChequeDepositValidates(t *testing.T) {
+	f := newChequeFixture()
+	past, tooFar, furthest := f.clock.Add(-time.Minute), f.clock.Add(models.MaxChequeHold+time.Second), f.clock.Add(models.MaxChequeHold)
+
+	tests := []struct {
+		name    string
+		request models.ChequeDepositRequest
+		wantErr error
+	}{
+		{name: "hold in the past", request: models.ChequeDepositRequest{UserID: f.user, Amount: 10, ChequeNumber: "1", HoldUntil: &past}},
+		{name: "hold too long", request: models.ChequeDepositRequest{UserID: f.user, Amount: 10, ChequeNumber: "1", HoldUntil: &tooFar}},
+		{name: "no account", request: models.ChequeDepositRequest{UserID: uuid.New(), Amount: 10, ChequeNumber: "1"}, wantErr: ErrAccountNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := f.transactions.ProcessChequeDeposit(uuid.New(), tt.request)
+			var validationErr *ValidationError
+			if tt.wantErr == nil && (!errors.As(err, &validationErr) || validationErr.Fields[0].Field != "hold_until") {
+				t.Fatalf("Expected a validation error on hold_until, got %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	cheque, _, err := f.transactions.ProcessChequeDeposit(uuid.New(), models.ChequeDepositRequest{UserID: f.user, Amount: 10, ChequeNumber: "1", HoldUntil: &furthest})
+	if err != nil || !cheque.HoldUntil.Equal(furthest) {
+		t.Errorf("Expected a hold of exactly the maximum to be allowed, got %v", err)
+	}
+	if len(f.cheques.posted) != 1 {
+		t.Errorf("Expected only the valid cheque to be deposited, got %d", len(f.cheques.posted))
+	}
+}
+
+func TestChequeService_Release(t *testing.T) {
+	f := newChequeFixture()
+	cheque := f.deposit(t, 500)
+	staffID := uuid.New()
+
+	released, err := f.svc.Release(cheque.ID, staffID)
+	if err != nil {
+		t.Fatalf("Release returned error: %v", err)
+	}
+	if released.Status != models.ChequeStatusReleased || *released.ResolvedBy != staffID {
+		t.Errorf("Expected the cheque to be released by the staff member, got %+v", released)
+	}
+	if held, _ := f.transactions.HeldFunds(f.accounts.accounts[f.user].ID); held != 0 {
+		t.Errorf("Expected nothing held once released, got %v", held)
+	}
+
+	if _, err := f.svc.Release(cheque.ID, staffID); !errors.Is(err, ErrChequeNotOnHold) {
+		t.Errorf("Expected a released cheque not to be released again, got %v", err)
+	}
+	if _, err := f.svc.Return(cheque.ID, staffID, models.ReturnChequeRequest{Reason: "Too late"}); !errors.Is(err, ErrChequeNotOnHold) {
+		t.Errorf("Expected a released cheque not to be returned, got %v", err)
+	}
+	if _, err := f.svc.Release(uuid.New(), staffID); !errors.Is(err, ErrChequeNotFound) {
+		t.Errorf("Expected an unknown cheque not to be found, got %v", err)
+	}
+}
+
+func TestChequeService_Return(t *testing.T) {
+	f := newChequeFixture()
+	cheque := f.deposit(t, 500)
+	staffID := uuid.New()
+
+	// A fee the balance cannot cover returns nothing
+	if _, err := f.svc.Return(cheque.ID, staffID, models.ReturnChequeRequest{Reason: "Refer to drawer", Fee: 1000.01}); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("Expected the fee to be more than the balance, got %v", err)
+	}
+	if len(f.cheques.posted) != 1 {
+		t.Fatalf("Expected nothing posted for the refused return, got %+v", f.cheques.posted)
+	}
+
+	returned, err := f.svc.Return(cheque.ID, staffID, models.ReturnChequeRequest{Reason: "Refer to drawer", Fee: 15})
+	if err != nil {
+		t.Fatalf("Return returned error: %v", err)
+	}
+	if returned.Status != models.ChequeStatusReturned || returned.ReturnReason != "Refer to drawer" || f.accounts.accounts[f.user].Balance != 985 {
+		t.Errorf("Expected the cheque to be returned with its fee, got %s and a balance of %v", returned.Status, f.accounts.accounts[f.user].Balance)
+	}
+
+	reversal, fee := f.cheques.posted[1], f.cheques.posted[2]
+	if reversal.Type != models.TransactionTypeChequeReturn || reversal.Amount != 500 || *reversal.RelatedTransactionID != cheque.TransactionID {
+		t.Errorf("Expected a cheque_return of 500 linked to the deposit, got %+v", reversal)
+	}
+	if fee.Type != models.TransactionTypeFee || fee.Amount != 15 || *fee.RelatedTransactionID != reversal.ID {
+		t.Errorf("Expected a fee of 15 linked to the return, got %+v", fee)
+	}
+	if held, _ := f.transactions.HeldFunds(f.accounts.accounts[f.user].ID); held != 0 {
+		t.Errorf("Expected nothing held once returned, got %v", held)
+	}
+
+	// A cheque cannot be returned once its hold lapses
+	lapsed := f.deposit(t, 200)
+	f.clock = lapsed.HoldUntil
+	if _, err := f.svc.Return(lapsed.ID, staffID, models.ReturnChequeRequest{Reason: "Refer to drawer"}); !errors.Is(err, ErrChequeNotOnHold) {
+		t.Errorf("Expected a cleared cheque not to be returned, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/httpx"
+	"microbank/pkg/resilience"
+)
+
+// validateTokenTimeout bounds each token check. It runs on every
+// authenticated request, so it is kept short.
+const validateTokenTimeout = 2 * time.Second
+
+// HTTPClientServiceClient calls the client-service API
+type HTTPClientServiceClient struct {
+	baseURL string
+	client  *resilience.Client
+}
+
+// NewHTTPClientServiceClient creates a new client-service client, retrying
+// and breaking its calls as config describes
+func NewHTTPClientServiceClient(baseURL string, config resilience.Config) *HTTPClientServiceClient {
+	return &HTTPClientServiceClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  resilience.NewClient("client-service", &http.Client{}, config),
+	}
+}
+
+// ValidateToken asks the client-service whether an access token is still
+// valid for its user as they are now. It reports the token as revoked when
+// it has been revoked or the user deleted, as suspended when the user has
+// been blacklisted, and returns an error matching
+// resilience.ErrDependencyUnavailable when the client-service could not
+// answer.
+func (c *HTTPClientServiceClient) ValidateToken(accessToken string) (models.TokenStatus, error) {
+	resp, err := c.validate(accessToken)
+	if err != nil {
+		return models.TokenRevoked, err
+	}
+	defer resp.Body.Close()
+
+	return tokenStatus(resp)
+}
+
+// ResolvePersonalAccessToken asks the client-service whom a personal access
+// token acts for and with which scopes. The grant is only filled in for
+// valid tokens; statuses and errors are reported as by ValidateToken.
+func (c *HTTPClientServiceClient) ResolvePersonalAccessToken(token string) (models.PersonalAccessTokenGrant, models.TokenStatus, error) {
+	var grant models.PersonalAccessTokenGrant
+	resp, err := c.validate(token)
+	if err != nil {
+		return grant, models.TokenRevoked, err
+	}
+	defer resp.Body.Close()
+
+	status, err := tokenStatus(resp)
+	if err != nil || status != models.TokenValid {
+		return grant, status, err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&httpx.Envelope{Data: &grant}); err != nil {
+		return grant, models.TokenRevoked, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("failed to decode token grant: %w", err)}
+	}
+	return grant, models.TokenValid, nil
+}
+
+// validate sends a token to the client-service's validation endpoint
+func (c *HTTPClientServiceClient) validate(token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/api/v1/auth/validate?view=service", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client-service request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return c.client.Do(req, validateTokenTimeout)
+}
+
+// tokenStatus reads the client-service's verdict from a validation response
+func tokenStatus(resp *http.Response) (models.TokenStatus, error) {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return models.TokenValid, nil
+	case http.StatusUnauthorized:
+		return models.TokenRevoked, nil
+	case http.StatusForbidden:
+		return models.TokenSuspended, nil
+	default:
+		return models.TokenRevoked, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("returned status %d", resp.StatusCode)}
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/httpx"
+	"microbank/pkg/resilience"
+)
+
+func TestHTTPClientServiceClient_ValidateToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/auth/validate" || r.URL.Query().Get("view") != "service" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Header.Get("Authorization") {
+		case "Bearer current":
+			w.WriteHeader(http.StatusOK)
+		case "Bearer revoked":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "Bearer suspended":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClientServiceClient(server.URL+"/", resilience.DefaultConfig)
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus models.TokenStatus
+		wantErr    bool
+	}{
+		{name: "current token", token: "current", wantStatus: models.TokenValid},
+		{name: "revoked token", token: "revoked", wantStatus: models.TokenRevoked},
+		{name: "suspended user", token: "suspended", wantStatus: models.TokenSuspended},
+		{name: "client-service failure", token: "broken", wantStatus: models.TokenRevoked, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := client.ValidateToken(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, resilience.ErrDependencyUnavailable) {
+				t.Errorf("Expected ErrDependencyUnavailable, got %v", err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("Expected status %v, got %v", tt.wantStatus, status)
+			}
+		})
+	}
+}
+
+func TestHTTPClientServiceClient_ResolvePersonalAccessToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer pat_valid":
+			json.NewEncoder(w).Encode(httpx.Envelope{Data: models.PersonalAccessTokenGrant{UserID: "user-1", Scopes: []string{"read:balance"}}})
+		case "Bearer pat_revoked":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "Bearer pat_garbled":
+			w.Write([]byte("not json"))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClientServiceClient(server.URL, resilience.DefaultConfig)
+
+	tests := []struct {
+		name       string
+		token      string
+		wantGrant  models.PersonalAccessTokenGrant
+		wantStatus models.TokenStatus
+		wantErr    bool
+	}{
+		{name: "valid token", token: "pat_valid", wantGrant: models.PersonalAccessTokenGrant{UserID: "user-1", Scopes: []string{"read:balance"}}, wantStatus: models.TokenValid},
+		{name: "revoked token", token: "pat_revoked", wantStatus: models.TokenRevoked},
+		{name: "undecodable answer", token: "pat_garbled", wantStatus: models.TokenRevoked, wantErr: true},
+		{name: "client-service failure", token: "pat_broken", wantStatus: models.TokenRevoked, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grant, status, err := client.ResolvePersonalAccessToken(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, resilience.ErrDependencyUnavailable) {
+				t.Errorf("Expected ErrDependencyUnavailable, got %v", err)
+			}
+			if status != tt.wantStatus || !reflect.DeepEqual(grant, tt.wantGrant) {
+				t.Errorf("Expected %+v (%v), got %+v (%v)", tt.wantGrant, tt.wantStatus, grant, status)
+			}
+		})
+	}
+}
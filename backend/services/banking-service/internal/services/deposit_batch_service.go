@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// DepositBatchService queues deposits submitted in batches, to be made in
+// the background by a DepositWorkerPool, and reports on their progress
+type DepositBatchService struct {
+	depositJobRepo repository.DepositJobRepository
+	transactions   *TransactionService
+}
+
+// NewDepositBatchService creates a new deposit batch service making the
+// deposits with transactions
+func NewDepositBatchService(depositJobRepo repository.DepositJobRepository, transactions *TransactionService) *DepositBatchService {
+	return &DepositBatchService{
+		depositJobRepo: depositJobRepo,
+		transactions:   transactions,
+	}
+}
+
+// QueueBatch queues the deposits of a batch and reports whether it was
+// queued. A batch submitted again with the same reference is not; the
+// batch queued first is returned instead.
+func (s *DepositBatchService) QueueBatch(request models.CreateDepositBatchRequest) (*models.DepositBatch, bool, error) {
+	batch := &models.DepositBatch{ID: uuid.New(), Reference: request.Reference}
+	jobs := make([]models.DepositJob, len(request.Deposits))
+	for i, deposit := range request.Deposits {
+		jobs[i] = models.DepositJob{
+			ID:          uuid.New(),
+			BatchID:     batch.ID,
+			Position:    i,
+			UserID:      deposit.UserID,
+			Amount:      deposit.Amount,
+			Description: deposit.Description,
+			Status:      models.DepositJobStatusPending,
+		}
+	}
+
+	created, err := s.depositJobRepo.CreateBatch(batch, jobs)
+	if err != nil {
+		return nil, false, err
+	}
+	if created {
+		return batch, true, nil
+	}
+
+	existing, err := s.depositJobRepo.GetBatchByReference(request.Reference)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		return nil, false, fmt.Errorf("deposit batch %q was not created and cannot be found", request.Reference)
+	}
+	return existing, false, nil
+}
+
+// GetProgress reports how far the processing of a batch has come
+func (s *DepositBatchService) GetProgress(batchID uuid.UUID) (*models.DepositBatchProgress, error) {
+	progress, err := s.depositJobRepo.GetBatchProgress(batchID)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		return nil, ErrDepositBatchNotFound
+	}
+
+	return progress, nil
+}
+
+// ProcessJob makes the deposit of a claimed job, checked as ProcessDeposit
+// checks deposits, and marks the job completed with it, paying the
+// promotion bonuses it earned. It returns nil without depositing anything
+// when the job was already completed or failed.
+func (s *DepositBatchService) ProcessJob(job *models.DepositJob) (*models.Transaction, error) {
+	transaction, err := s.transactions.prepareDeposit(job.UserID, nil, job.Amount, job.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := s.depositJobRepo.Complete(job.ID, transaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save transaction: %w", err)
+	}
+	if !completed {
+		return nil, nil
+	}
+	s.transactions.payBonuses(transaction)
+
+	return transaction, nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// Deposit worker pool defaults
+const (
+	// DefaultDepositJobLease is how long a claimed job is left to its
+	// worker before another may claim it
+	DefaultDepositJobLease = 30 * time.Second
+	// DefaultDepositPollInterval is how often idle workers look for jobs
+	DefaultDepositPollInterval = time.Second
+	// DefaultDepositRetryBackoff is how long the first retry of a job
+	// waits; each further retry waits twice as long, up to
+	// MaxDepositRetryBackoff
+	DefaultDepositRetryBackoff = time.Second
+	MaxDepositRetryBackoff     = time.Minute
+)
+
+// DepositJobProcessor makes the deposit of a claimed job.
+// *DepositBatchService satisfies it.
+type DepositJobProcessor interface {
+	ProcessJob(job *models.DepositJob) (*models.Transaction, error)
+}
+
+// MaintenanceSource reports whether money movement is paused.
+// *MaintenanceService satisfies it.
+type MaintenanceSource interface {
+	Paused() (models.MaintenanceState, bool)
+}
+
+// DepositWorkerPool makes queued deposits in the background. Every replica
+// may run one: workers claim jobs from the shared queue, skipping those
+// claimed by others.
+type DepositWorkerPool struct {
+	depositJobRepo repository.DepositJobRepository
+	processor      DepositJobProcessor
+	maintenance    MaintenanceSource
+	workers        int
+	maxAttempts    int
+	lease          time.Duration
+	pollInterval   time.Duration
+	backoff        time.Duration
+	now            func() time.Time
+}
+
+// NewDepositWorkerPool creates a pool of workers making the deposits
+// claimed from depositJobRepo with processor. A job is tried at most
+// maxAttempts times.
+func NewDepositWorkerPool(depositJobRepo repository.DepositJobRepository, processor DepositJobProcessor, workers, maxAttempts int) *DepositWorkerPool {
+	return &DepositWorkerPool{
+		depositJobRepo: depositJobRepo,
+		processor:      processor,
+		workers:        workers,
+		maxAttempts:    maxAttempts,
+		lease:          DefaultDepositJobLease,
+		pollInterval:   DefaultDepositPollInterval,
+		backoff:        DefaultDepositRetryBackoff,
+		now:            time.Now,
+	}
+}
+
+// WithMaintenance stops workers claiming jobs while maintenance pauses
+// money movement. Without it they never stop.
+func (p *DepositWorkerPool) WithMaintenance(maintenance MaintenanceSource) *DepositWorkerPool {
+	p.maintenance = maintenance
+	return p
+}
+
+// Run makes queued deposits until ctx is done, then waits for the jobs in
+// progress to finish before it returns
+func (p *DepositWorkerPool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// work processes jobs one after another, waiting a poll interval whenever
+// none is due, until ctx is done
+func (p *DepositWorkerPool) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		if p.processNext() {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+// processNext claims and processes one job and reports whether there was
+// one. A job failing for a reason that will not go away, such as a frozen
+// account, fails at once; other failures are retried with exponential
+// backoff until the job runs out of attempts.
+func (p *DepositWorkerPool) processNext() bool {
+	if p.maintenance != nil {
+		if _, paused := p.maintenance.Paused(); paused {
+			return false
+		}
+	}
+
+	job, err := p.depositJobRepo.ClaimNext(p.lease)
+	if err != nil {
+		log.Printf("Failed to claim deposit job: %v", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	_, err = p.processor.ProcessJob(job)
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, ErrAccountFrozen) || errors.Is(err, ErrInvalidAmount) || job.Attempts >= p.maxAttempts {
+		log.Printf("Deposit job %s of batch %s failed after %d attempts: %v", job.ID, job.BatchID, job.Attempts, err)
+		if err := p.depositJobRepo.Fail(job.ID, err.Error()); err != nil {
+			log.Printf("Failed to mark deposit job %s failed: %v", job.ID, err)
+		}
+		return true
+	}
+
+	next := p.now().Add(p.retryDelay(job.Attempts))
+	if err := p.depositJobRepo.Retry(job.ID, err.Error(), next); err != nil {
+		log.Printf("Failed to retry deposit job %s: %v", job.ID, err)
+	}
+	return true
+}
+
+// retryDelay is how long a job waits after its attempts-th failed attempt
+func (p *DepositWorkerPool) retryDelay(attempts int) time.Duration {
+	delay := p.backoff
+	for i := 1; i < attempts && delay < MaxDepositRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > MaxDepositRetryBackoff {
+		delay = MaxDepositRetryBackoff
+	}
+	return delay
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakeDepositJobRepo hands out queued jobs and records what became of them
+type fakeDepositJobRepo struct {
+	repository.DepositJobRepository
+	mu        sync.Mutex
+	queue     []*models.DepositJob
+	claims    int
+	completed map[uuid.UUID]*models.Transaction
+	retried   map[uuid.UUID]time.Time
+	failed    map[uuid.UUID]string
+	batches   map[string]*models.DepositBatch
+}
+
+func newFakeDepositJobRepo(queue ...*models.DepositJob) *fakeDepositJobRepo {
+	return &fakeDepositJobRepo{
+		queue:     queue,
+		completed: map[uuid.UUID]*models.Transaction{},
+		retried:   map[uuid.UUID]time.Time{},
+		failed:    map[uuid.UUID]string{},
+		batches:   map[string]*models.DepositBatch{},
+	}
+}
+
+func (r *fakeDepositJobRepo) CreateBatch(batch *models.DepositBatch, jobs []models.DepositJob) (bool, error) {
+	if _, ok := r.batches[batch.Reference]; ok && batch.Reference != "" {
+		return false, nil
+	}
+	batch.ItemCount = len(jobs)
+	r.batches[batch.Reference] = batch
+	for i := range jobs {
+		r.queue = append(r.queue, &jobs[i])
+	}
+	return true, nil
+}
+
+func (r *fakeDepositJobRepo) GetBatchByReference(reference string) (*models.DepositBatch, error) {
+	return r.batches[reference], nil
+}
+
+func (r *fakeDepositJobRepo) ClaimNext(lease time.Duration) (*models.DepositJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.claims++
+	if len(r.queue) == 0 {
+		return nil, nil
+	}
+	job := r.queue[0]
+	r.queue = r.queue[1:]
+	job.Status = models.DepositJobStatusProcessing
+	job.Attempts++
+	return job, nil
+}
+
+func (r *fakeDepositJobRepo) Complete(jobID uuid.UUID, transaction *models.Transaction) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.completed[jobID]; ok {
+		return false, nil
+	}
+	r.completed[jobID] = transaction
+	return true, nil
+}
+
+func (r *fakeDepositJobRepo) Retry(jobID uuid.UUID, reason string, next time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retried[jobID] = next
+	return nil
+}
+
+func (r *fakeDepositJobRepo) Fail(jobID uuid.UUID, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed[jobID] = reason
+	return nil
+}
+
+// fakeDepositJobProcessor fails jobs with a fixed error and counts the
+// jobs it is given
+type fakeDepositJobProcessor struct {
+	mu        sync.Mutex
+	err       error
+	processed map[uuid.UUID]int
+}
+
+func (p *fakeDepositJobProcessor) ProcessJob(job *models.DepositJob) (*models.Transaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.processed == nil {
+		p.processed = map[uuid.UUID]int{}
+	}
+	p.processed[job.ID]++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &models.Transaction{ID: uuid.New()}, nil
+}
+
+// pausedSource pauses money movement
+type pausedSource struct{}
+
+func (pausedSource) Paused() (models.MaintenanceState, bool) {
+	return models.MaintenanceState{Enabled: true}, true
+}
+
+func TestDepositWorkerPool_ProcessNext(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	transient := fmt.Errorf("failed to get or create account: %w", errors.New("connection refused"))
+
+	tests := []struct {
+		name      string
+		attempts  int
+		err       error
+		wantRetry time.Duration
+		wantFail  bool
+	}{
+		{name: "deposited"},
+		{name: "frozen account", err: ErrAccountFrozen, wantFail: true},
+		{name: "first transient failure", err: transient, wantRetry: time.Second},
+		{name: "third transient failure", attempts: 2, err: transient, wantRetry: 4 * time.Second},
+		{name: "out of attempts", attempts: 4, err: transient, wantFail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &models.DepositJob{ID: uuid.New(), Attempts: tt.attempts, Status: models.DepositJobStatusPending}
+			jobs := newFakeDepositJobRepo(job)
+			pool := NewDepositWorkerPool(jobs, &fakeDepositJobProcessor{err: tt.err}, 1, 5)
+			pool.now = func() time.Time { return now }
+
+			if !pool.processNext() {
+				t.Fatal("Expected a job to be processed")
+			}
+
+			next, retried := jobs.retried[job.ID]
+			if retried != (tt.wantRetry > 0) || (retried && !next.Equal(now.Add(tt.wantRetry))) {
+				t.Errorf("Expected a retry after %v, got %v (retried: %v)", tt.wantRetry, next.Sub(now), retried)
+			}
+			if _, failed := jobs.failed[job.ID]; failed != tt.wantFail {
+				t.Errorf("Expected the job to fail: %v, got %v", tt.wantFail, failed)
+			}
+		})
+	}
+}
+
+func TestDepositWorkerPool_PausedDuringMaintenance(t *testing.T) {
+	jobs := newFakeDepositJobRepo(&models.DepositJob{ID: uuid.New()})
+	pool := NewDepositWorkerPool(jobs, &fakeDepositJobProcessor{}, 1, 5).WithMaintenance(pausedSource{})
+
+	if pool.processNext() {
+		t.Error("Expected no job to be processed while transactions are paused")
+	}
+	if jobs.claims != 0 {
+		t.Errorf("Expected no job to be claimed, got %d claims", jobs.claims)
+	}
+}
+
+func TestDepositWorkerPool_RunDrainsQueueAndStops(t *testing.T) {
+	var queue []*models.DepositJob
+	for i := 0; i < 20; i++ {
+		queue = append(queue, &models.DepositJob{ID: uuid.New(), Position: i})
+	}
+	jobs := newFakeDepositJobRepo(queue...)
+	processor := &fakeDepositJobProcessor{}
+	pool := NewDepositWorkerPool(jobs, processor, 4, 5)
+	pool.pollInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		processor.mu.Lock()
+		processed := len(processor.processed)
+		processor.mu.Unlock()
+		if processed == len(queue) {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("Expected %d jobs to be processed, got %d", len(queue), processed)
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the pool to stop once its context was cancelled")
+	}
+
+	for _, job := range queue {
+		if processor.processed[job.ID] != 1 {
+			t.Errorf("Expected job %d to be processed once, got %d", job.Position, processor.processed[job.ID])
+		}
+	}
+}
+
+func TestDepositBatchService_QueueBatchReference(t *testing.T) {
+	jobs := newFakeDepositJobRepo()
+	service := NewDepositBatchService(jobs, nil)
+	request := models.CreateDepositBatchRequest{
+		Reference: "partner-2025-05-01",
+		Deposits:  []models.DepositBatchItemRequest{{UserID: uuid.New(), Amount: 10}, {UserID: uuid.New(), Amount: 20}},
+	}
+
+	first, created, err := service.QueueBatch(request)
+	if err != nil || !created {
+		t.Fatalf("Expected the batch to be queued, got %v (%v)", created, err)
+	}
+	if first.ItemCount != 2 || len(jobs.queue) != 2 || jobs.queue[1].Position != 1 {
+		t.Errorf("Expected two jobs in submission order, got %+v", jobs.queue)
+	}
+
+	again, created, err := service.QueueBatch(request)
+	if err != nil || created {
+		t.Fatalf("Expected the batch not to be queued again, got %v (%v)", created, err)
+	}
+	if again.ID != first.ID || len(jobs.queue) != 2 {
+		t.Errorf("Expected batch %s to be returned without new jobs, got %s with %d jobs", first.ID, again.ID, len(jobs.queue))
+	}
+}
+
+func TestDepositBatchService_ProcessJob(t *testing.T) {
+	userID := uuid.New()
+	frozenID := uuid.New()
+	frozenAt := time.Now()
+	accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{
+		userID:   {ID: uuid.New(), UserID: userID, Balance: 50},
+		frozenID: {ID: uuid.New(), UserID: frozenID, FrozenAt: &frozenAt},
+	}}}
+	jobs := newFakeDepositJobRepo()
+	service := NewDepositBatchService(jobs, NewTransactionService(&fakeTransactionRepo{accounts: accounts}, accounts))
+
+	job := &models.DepositJob{ID: uuid.New(), UserID: userID, Amount: 25, Description: "Payroll"}
+	transaction, err := service.ProcessJob(job)
+	if err != nil {
+		t.Fatalf("ProcessJob returned error: %v", err)
+	}
+	if transaction == nil || jobs.completed[job.ID] != transaction || transaction.Type != models.TransactionTypeDeposit || transaction.Amount != 25 {
+		t.Errorf("Expected the job to be completed with a deposit of 25, got %+v", transaction)
+	}
+
+	transaction, err = service.ProcessJob(job)
+	if err != nil || transaction != nil {
+		t.Errorf("Expected a completed job not to be deposited again, got %+v (%v)", transaction, err)
+	}
+
+	_, err = service.ProcessJob(&models.DepositJob{ID: uuid.New(), UserID: frozenID, Amount: 25})
+	if !errors.Is(err, ErrAccountFrozen) {
+		t.Errorf("Expected ErrAccountFrozen, got %v", err)
+	}
+}
+
+func (r *balanceAccountRepo) GetOrCreateAccount(userID uuid.UUID) (*models.Account, error) {
+	return r.GetAccountByUserID(userID)
+}
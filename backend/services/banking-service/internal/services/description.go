@@ -0,0 +1,46 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultDescriptionLength is how many characters transaction descriptions
+// are cut to when no other length is configured. It matches the limit on
+// descriptions given to the API.
+const DefaultDescriptionLength = 255
+
+// descriptionEllipsis ends descriptions that were cut short
+const descriptionEllipsis = "…"
+
+// sanitizeDescription makes a transaction description safe to store and
+// export: invalid UTF-8 is replaced, line breaks and tabs become spaces,
+// other control characters are dropped, surrounding space is trimmed and
+// descriptions longer than maxLength characters are cut short with an
+// ellipsis. When the description was cut short the original is returned
+// too, with only what the database cannot store (invalid UTF-8 and NUL
+// bytes) replaced.
+func sanitizeDescription(description string, maxLength int) (string, *string) {
+	valid := strings.ToValidUTF8(description, string(utf8.RuneError))
+
+	cleaned := strings.TrimSpace(strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r' || r == '\v' || r == '\f':
+			return ' '
+		case unicode.IsControl(r):
+			return -1
+		default:
+			return r
+		}
+	}, valid))
+
+	if maxLength <= 0 || utf8.RuneCountInString(cleaned) <= maxLength {
+		return cleaned, nil
+	}
+
+	runes := []rune(cleaned)
+	truncated := strings.TrimRightFunc(string(runes[:maxLength-1]), unicode.IsSpace) + descriptionEllipsis
+	raw := strings.ReplaceAll(valid, "\x00", "")
+	return truncated, &raw
+}
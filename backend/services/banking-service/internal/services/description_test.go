@@ -0,0 +1,39 @@
+package services
+
+import "testing"
+
+func TestSanitizeDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		maxLength   int
+		want        string
+		wantRaw     string
+	}{
+		{name: "clean", description: "Salary", maxLength: 10, want: "Salary"},
+		{name: "trimmed", description: "  Salary \n", maxLength: 10, want: "Salary"},
+		{name: "line breaks and tabs", description: "Rent\r\nMay\tflat 2", maxLength: 20, want: "Rent  May flat 2"},
+		{name: "control characters", description: "Pay\x00\x1b[31mroll\x7f", maxLength: 20, want: "Pay[31mroll"},
+		{name: "invalid UTF-8", description: "Caf\xe9 bill", maxLength: 20, want: "Caf� bill"},
+		{name: "multi-byte characters at the limit", description: "Café ☕ für €5", maxLength: 13, want: "Café ☕ für €5"},
+		{name: "cut short", description: "Invoice 2024-05 for consulting", maxLength: 12, want: "Invoice 202…", wantRaw: "Invoice 2024-05 for consulting"},
+		{name: "cut short at a space", description: "Invoice for consulting", maxLength: 9, want: "Invoice…", wantRaw: "Invoice for consulting"},
+		{name: "cut short by characters, not bytes", description: "€€€€€€", maxLength: 4, want: "€€€…", wantRaw: "€€€€€€"},
+		{name: "raw keeps only what can be stored", description: "Line\x00one\nline two\xff", maxLength: 5, want: "Line…", wantRaw: "Lineone\nline two�"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, raw := sanitizeDescription(tt.description, tt.maxLength)
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+			switch {
+			case tt.wantRaw == "" && raw != nil:
+				t.Errorf("Expected no raw description, got %q", *raw)
+			case tt.wantRaw != "" && (raw == nil || *raw != tt.wantRaw):
+				t.Errorf("Expected raw description %q, got %v", tt.wantRaw, raw)
+			}
+		})
+	}
+}
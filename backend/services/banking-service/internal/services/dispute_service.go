@@ -0,0 +1,211 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// Dispute list paging limits
+const (
+	DefaultDisputePageSize = 50
+	MaxDisputePageSize     = 200
+)
+
+// DisputeService handles transaction disputes: users file them, and staff
+// investigate and resolve them, refunding upheld ones
+type DisputeService struct {
+	disputeRepo     repository.DisputeRepository
+	transactionRepo repository.TransactionRepository
+	staff           UserStatusSource
+}
+
+// NewDisputeService creates a new dispute service. Staff assigning a
+// dispute to someone else are checked against staff, which looks up users'
+// roles on the client-service.
+func NewDisputeService(disputeRepo repository.DisputeRepository, transactionRepo repository.TransactionRepository, staff UserStatusSource) *DisputeService {
+	return &DisputeService{
+		disputeRepo:     disputeRepo,
+		transactionRepo: transactionRepo,
+		staff:           staff,
+	}
+}
+
+// FileDispute disputes one of the user's withdrawals or fees. A
+// transaction can be disputed again only after its last dispute was
+// rejected.
+func (s *DisputeService) FileDispute(userID, transactionID uuid.UUID, request models.FileDisputeRequest, requestID string) (*models.Dispute, error) {
+	transaction, err := s.transactionRepo.GetTransactionByID(transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTransactionNotFound, err)
+	}
+	if transaction.UserID != userID {
+		return nil, ErrTransactionAccessDenied
+	}
+	if transaction.Type != models.TransactionTypeWithdrawal && transaction.Type != models.TransactionTypeFee {
+		return nil, ErrTransactionNotDisputable
+	}
+
+	description := strings.TrimSpace(request.Description)
+	if description == "" {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "description", Rule: "required", Message: "is required"}}}
+	}
+
+	dispute := &models.Dispute{
+		ID:             uuid.New(),
+		TransactionID:  transaction.ID,
+		AccountID:      transaction.AccountID,
+		UserID:         userID,
+		ReasonCategory: request.ReasonCategory,
+		Description:    description,
+		Amount:         transaction.Amount,
+	}
+	created, err := s.disputeRepo.Create(dispute, models.DisputeChange{ActorID: userID, RequestID: requestID})
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrDisputeExists
+	}
+
+	return dispute, nil
+}
+
+// GetTransactionDispute returns the newest dispute of one of the user's
+// transactions
+func (s *DisputeService) GetTransactionDispute(userID, transactionID uuid.UUID) (*models.Dispute, error) {
+	dispute, err := s.disputeRepo.GetLatestByTransactionID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute == nil || dispute.UserID != userID {
+		return nil, ErrDisputeNotFound
+	}
+
+	return dispute, nil
+}
+
+// ListUserDisputes returns the user's disputes, newest first
+func (s *DisputeService) ListUserDisputes(userID uuid.UUID) ([]models.Dispute, error) {
+	disputes, err := s.disputeRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+
+	return disputes, nil
+}
+
+// ListDisputes returns one page of disputes matching the filter
+func (s *DisputeService) ListDisputes(filter models.DisputeFilter) (*models.DisputePage, error) {
+	if filter.Status != "" && !models.IsDisputeStatus(string(filter.Status)) {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "status", Rule: "oneof", Message: "must be open, investigating, resolved_refunded or resolved_rejected"}}}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultDisputePageSize
+	}
+	if filter.Limit > MaxDisputePageSize {
+		filter.Limit = MaxDisputePageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	disputes, total, err := s.disputeRepo.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+
+	return &models.DisputePage{Disputes: disputes, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// GetDispute returns any dispute, for staff
+func (s *DisputeService) GetDispute(disputeID uuid.UUID) (*models.Dispute, error) {
+	dispute, err := s.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDisputeNotFound, err)
+	}
+
+	return dispute, nil
+}
+
+// AssignDispute puts an unresolved dispute under investigation by the
+// requested staff member, or by the staff member making the change
+func (s *DisputeService) AssignDispute(disputeID uuid.UUID, request models.AssignDisputeRequest, change models.DisputeChange) (*models.Dispute, error) {
+	dispute, err := s.GetDispute(disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status.Resolved() {
+		return nil, ErrDisputeResolved
+	}
+
+	assignee := change.ActorID
+	if request.AssigneeID != nil && *request.AssigneeID != change.ActorID {
+		status, err := s.staff.GetUserStatus(request.AssigneeID.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up assignee: %w", err)
+		}
+		if !status.Exists || status.IsDeleted || len(status.Roles) == 0 {
+			return nil, ErrInvalidAssignee
+		}
+		assignee = *request.AssigneeID
+	}
+
+	dispute.AssignedTo = &assignee
+	change.Note = strings.TrimSpace(request.Note)
+	if err := s.disputeRepo.Assign(dispute, change); err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// ResolveDispute closes an unresolved dispute. Refunded disputes credit
+// the disputed amount back to the account as a refund transaction linked
+// to the disputed one. A dispute nobody was assigned is assigned to the
+// staff member resolving it.
+func (s *DisputeService) ResolveDispute(disputeID uuid.UUID, request models.ResolveDisputeRequest, change models.DisputeChange) (*models.Dispute, *models.Transaction, error) {
+	note := strings.TrimSpace(request.Note)
+	if note == "" {
+		return nil, nil, &ValidationError{Fields: []FieldError{{Field: "note", Rule: "required", Message: "is required"}}}
+	}
+
+	dispute, err := s.GetDispute(disputeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dispute.Status.Resolved() {
+		return nil, nil, ErrDisputeResolved
+	}
+
+	if dispute.AssignedTo == nil {
+		dispute.AssignedTo = &change.ActorID
+	}
+	dispute.ResolutionNote = note
+	change.Note = note
+
+	var refund *models.Transaction
+	if request.Outcome == models.DisputeOutcomeRefunded {
+		dispute.Status = models.DisputeStatusResolvedRefunded
+		refund = &models.Transaction{
+			ID:                   uuid.New(),
+			AccountID:            dispute.AccountID,
+			UserID:               dispute.UserID,
+			Type:                 models.TransactionTypeRefund,
+			Amount:               dispute.Amount,
+			Description:          "Refund of disputed transaction",
+			RelatedTransactionID: &dispute.TransactionID,
+		}
+	} else {
+		dispute.Status = models.DisputeStatusResolvedRejected
+	}
+
+	if err := s.disputeRepo.Resolve(dispute, refund, change); err != nil {
+		return nil, nil, err
+	}
+
+	return dispute, refund, nil
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// disputeTransactionRepo looks up fixed transactions
+type disputeTransactionRepo struct {
+	repository.TransactionRepository
+	transactions map[uuid.UUID]*models.Transaction
+}
+
+func (r *disputeTransactionRepo) GetTransactionByID(id uuid.UUID) (*models.Transaction, error) {
+	transaction, ok := r.transactions[id]
+	if !ok {
+		return nil, fmt.Errorf("transaction not found")
+	}
+	return transaction, nil
+}
+
+// fakeDisputeRepo keeps disputes in memory and records the changes made
+type fakeDisputeRepo struct {
+	repository.DisputeRepository
+	disputes []*models.Dispute
+	changes  []models.DisputeChange
+	refunds  []models.Transaction
+}
+
+func (r *fakeDisputeRepo) Create(dispute *models.Dispute, change models.DisputeChange) (bool, error) {
+	for _, existing := range r.disputes {
+		if existing.TransactionID == dispute.TransactionID && existing.Status != models.DisputeStatusResolvedRejected {
+			return false, nil
+		}
+	}
+	dispute.Status = models.DisputeStatusOpen
+	clone := *dispute
+	r.disputes = append(r.disputes, &clone)
+	r.changes = append(r.changes, change)
+	return true, nil
+}
+
+func (r *fakeDisputeRepo) GetByID(id uuid.UUID) (*models.Dispute, error) {
+	for _, dispute := range r.disputes {
+		if dispute.ID == id {
+			clone := *dispute
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("dispute not found")
+}
+
+func (r *fakeDisputeRepo) save(dispute *models.Dispute, change models.DisputeChange) error {
+	for i, existing := range r.disputes {
+		if existing.ID == dispute.ID {
+			clone := *dispute
+			r.disputes[i] = &clone
+			r.changes = append(r.changes, change)
+			return nil
+		}
+	}
+	return fmt.Errorf("dispute not found")
+}
+
+func (r *fakeDisputeRepo) Assign(dispute *models.Dispute, change models.DisputeChange) error {
+	dispute.Status = models.DisputeStatusInvestigating
+	return r.save(dispute, change)
+}
+
+func (r *fakeDisputeRepo) Resolve(dispute *models.Dispute, refund *models.Transaction, change models.DisputeChange) error {
+	if refund != nil {
+		r.refunds = append(r.refunds, *refund)
+		dispute.RefundTransactionID = &refund.ID
+	}
+	return r.save(dispute, change)
+}
+
+// newDisputeFixture returns a service over one withdrawal and one deposit
+// of the returned user
+func newDisputeFixture(staff UserStatusSource) (*DisputeService, *fakeDisputeRepo, uuid.UUID, *models.Transaction, *models.Transaction) {
+	userID := uuid.New()
+	accountID := uuid.New()
+	withdrawal := &models.Transaction{ID: uuid.New(), AccountID: accountID, UserID: userID, Type: models.TransactionTypeWithdrawal, Amount: 49.99}
+	deposit := &models.Transaction{ID: uuid.New(), AccountID: accountID, UserID: userID, Type: models.TransactionTypeDeposit, Amount: 100}
+	transactions := &disputeTransactionRepo{transactions: map[uuid.UUID]*models.Transaction{withdrawal.ID: withdrawal, deposit.ID: deposit}}
+	disputes := &fakeDisputeRepo{}
+	return NewDisputeService(disputes, transactions, staff), disputes, userID, withdrawal, deposit
+}
+
+func TestDisputeService_FileDispute(t *testing.T) {
+	svc, disputes, userID, withdrawal, deposit := newDisputeFixture(&fakeUserStatusSource{})
+	request := models.FileDisputeRequest{ReasonCategory: "duplicate", Description: " Charged twice at the same shop "}
+
+	if _, err := svc.FileDispute(uuid.New(), withdrawal.ID, request, ""); !errors.Is(err, ErrTransactionAccessDenied) {
+		t.Errorf("Expected %v disputing another user's transaction, got %v", ErrTransactionAccessDenied, err)
+	}
+	if _, err := svc.FileDispute(userID, deposit.ID, request, ""); !errors.Is(err, ErrTransactionNotDisputable) {
+		t.Errorf("Expected %v disputing a deposit, got %v", ErrTransactionNotDisputable, err)
+	}
+	if _, err := svc.FileDispute(userID, uuid.New(), request, ""); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("Expected %v disputing an unknown transaction, got %v", ErrTransactionNotFound, err)
+	}
+
+	dispute, err := svc.FileDispute(userID, withdrawal.ID, request, "req-1")
+	if err != nil {
+		t.Fatalf("FileDispute returned error: %v", err)
+	}
+	if dispute.Status != models.DisputeStatusOpen || dispute.Amount != 49.99 || dispute.Description != "Charged twice at the same shop" {
+		t.Errorf("Expected an open dispute of 49.99 with a trimmed description, got %+v", dispute)
+	}
+	if len(disputes.changes) != 1 || disputes.changes[0].ActorID != userID || disputes.changes[0].RequestID != "req-1" {
+		t.Errorf("Expected the filing to be recorded as the user's, got %+v", disputes.changes)
+	}
+
+	if _, err := svc.FileDispute(userID, withdrawal.ID, request, ""); !errors.Is(err, ErrDisputeExists) {
+		t.Errorf("Expected %v disputing the transaction again, got %v", ErrDisputeExists, err)
+	}
+}
+
+func TestDisputeService_AssignAndResolve(t *testing.T) {
+	staffID := uuid.New()
+	supportID := uuid.New()
+	clientID := uuid.New()
+	staff := &fakeUserStatusSource{statuses: map[string]models.UserStatus{
+		supportID.String(): {UserID: supportID.String(), Exists: true, Roles: []string{"support"}},
+		clientID.String():  {UserID: clientID.String(), Exists: true},
+	}}
+	svc, disputes, userID, withdrawal, _ := newDisputeFixture(staff)
+	dispute, err := svc.FileDispute(userID, withdrawal.ID, models.FileDisputeRequest{ReasonCategory: "unauthorized", Description: "Not me"}, "")
+	if err != nil {
+		t.Fatalf("FileDispute returned error: %v", err)
+	}
+	change := models.DisputeChange{ActorID: staffID, RequestID: "req-2"}
+
+	if _, err := svc.AssignDispute(dispute.ID, models.AssignDisputeRequest{AssigneeID: &clientID}, change); !errors.Is(err, ErrInvalidAssignee) {
+		t.Errorf("Expected %v assigning a client, got %v", ErrInvalidAssignee, err)
+	}
+
+	assigned, err := svc.AssignDispute(dispute.ID, models.AssignDisputeRequest{AssigneeID: &supportID, Note: "Checking with the merchant"}, change)
+	if err != nil {
+		t.Fatalf("AssignDispute returned error: %v", err)
+	}
+	if assigned.Status != models.DisputeStatusInvestigating || assigned.AssignedTo == nil || *assigned.AssignedTo != supportID {
+		t.Errorf("Expected the dispute to be investigated by %s, got %+v", supportID, assigned)
+	}
+
+	if _, _, err := svc.ResolveDispute(dispute.ID, models.ResolveDisputeRequest{Outcome: models.DisputeOutcomeRefunded, Note: " "}, change); err == nil {
+		t.Error("Expected a validation error resolving without a note")
+	}
+
+	resolved, refund, err := svc.ResolveDispute(dispute.ID, models.ResolveDisputeRequest{Outcome: models.DisputeOutcomeRefunded, Note: "Card was stolen"}, change)
+	if err != nil {
+		t.Fatalf("ResolveDispute returned error: %v", err)
+	}
+	if resolved.Status != models.DisputeStatusResolvedRefunded || resolved.ResolutionNote != "Card was stolen" || *resolved.AssignedTo != supportID {
+		t.Errorf("Expected a refunded dispute still assigned to %s, got %+v", supportID, resolved)
+	}
+	if refund == nil || refund.Type != models.TransactionTypeRefund || refund.Amount != 49.99 || refund.AccountID != withdrawal.AccountID {
+		t.Fatalf("Expected a refund of 49.99 to the account, got %+v", refund)
+	}
+	if refund.RelatedTransactionID == nil || *refund.RelatedTransactionID != withdrawal.ID {
+		t.Errorf("Expected the refund to be linked to withdrawal %s, got %v", withdrawal.ID, refund.RelatedTransactionID)
+	}
+	if len(disputes.refunds) != 1 || len(disputes.changes) != 3 {
+		t.Errorf("Expected one refund and three recorded changes, got %d and %d", len(disputes.refunds), len(disputes.changes))
+	}
+
+	if _, _, err := svc.ResolveDispute(dispute.ID, models.ResolveDisputeRequest{Outcome: models.DisputeOutcomeRejected, Note: "Again"}, change); !errors.Is(err, ErrDisputeResolved) {
+		t.Errorf("Expected %v resolving the dispute twice, got %v", ErrDisputeResolved, err)
+	}
+	if _, err := svc.FileDispute(userID, withdrawal.ID, models.FileDisputeRequest{ReasonCategory: "other", Description: "Again"}, ""); !errors.Is(err, ErrDisputeExists) {
+		t.Errorf("Expected %v disputing a refunded transaction, got %v", ErrDisputeExists, err)
+	}
+}
+
+func TestDisputeService_RejectedDisputesCanBeRefiled(t *testing.T) {
+	staffID := uuid.New()
+	svc, disputes, userID, withdrawal, _ := newDisputeFixture(&fakeUserStatusSource{})
+	request := models.FileDisputeRequest{ReasonCategory: "incorrect_amount", Description: "Wrong amount"}
+	dispute, err := svc.FileDispute(userID, withdrawal.ID, request, "")
+	if err != nil {
+		t.Fatalf("FileDispute returned error: %v", err)
+	}
+
+	resolved, refund, err := svc.ResolveDispute(dispute.ID, models.ResolveDisputeRequest{Outcome: models.DisputeOutcomeRejected, Note: "Amount matches the receipt"}, models.DisputeChange{ActorID: staffID})
+	if err != nil {
+		t.Fatalf("ResolveDispute returned error: %v", err)
+	}
+	if resolved.Status != models.DisputeStatusResolvedRejected || refund != nil || len(disputes.refunds) != 0 {
+		t.Errorf("Expected a rejected dispute without a refund, got %+v and %+v", resolved, refund)
+	}
+	if resolved.AssignedTo == nil || *resolved.AssignedTo != staffID {
+		t.Errorf("Expected the dispute to be assigned to the resolver %s, got %v", staffID, resolved.AssignedTo)
+	}
+
+	if _, err := svc.FileDispute(userID, withdrawal.ID, request, ""); err != nil {
+		t.Errorf("Expected a rejected dispute to be refiled, got %v", err)
+	}
+}
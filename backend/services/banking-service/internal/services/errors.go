@@ -0,0 +1,322 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/events"
+)
+
+// Sentinel errors returned (usually wrapped) by the services. Handlers
+// match them with errors.Is to choose a response.
+var (
+	// ErrAccountFrozen is returned for deposits and withdrawals on an
+	// account frozen because its owner is blacklisted
+	ErrAccountFrozen     = errors.New("account is frozen")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrInvalidAmount     = errors.New("amount must be greater than zero")
+	// ErrKYCRequired is returned for withdrawals over the KYC limit by
+	// users whose identity has not been verified
+	ErrKYCRequired = errors.New("identity verification is required for this withdrawal")
+	// ErrFundsEarmarked is returned for withdrawals that would need money
+	// earmarked for a strict savings goal
+	ErrFundsEarmarked = errors.New("funds are earmarked for a savings goal")
+	// ErrSavingsGoalNotFound is returned for goals that do not exist or
+	// belong to another user
+	ErrSavingsGoalNotFound = errors.New("savings goal not found")
+	// ErrInsufficientAvailableFunds is returned when earmarking more than
+	// the part of the balance no goal holds yet
+	ErrInsufficientAvailableFunds = errors.New("not enough available funds")
+	// ErrGoalReleaseTooLarge is returned when releasing more than a goal
+	// holds
+	ErrGoalReleaseTooLarge = errors.New("amount is more than the savings goal holds")
+	// ErrTransactionNotFound is returned for transactions that do not
+	// exist
+	ErrTransactionNotFound = errors.New("transaction not found")
+	// ErrTransactionAccessDenied is returned for another user's
+	// transactions
+	ErrTransactionAccessDenied = errors.New("access denied to this transaction")
+	// ErrTransactionNotDisputable is returned when disputing a transaction
+	// other than a withdrawal or fee
+	ErrTransactionNotDisputable = errors.New("only withdrawals and fees can be disputed")
+	// ErrDisputeExists is returned when disputing a transaction whose last
+	// dispute is still open or was refunded
+	ErrDisputeExists = errors.New("transaction is already disputed")
+	// ErrDisputeNotFound is returned for disputes that do not exist or
+	// belong to another user
+	ErrDisputeNotFound = errors.New("dispute not found")
+	// ErrDisputeResolved is returned when changing a resolved dispute
+	ErrDisputeResolved = errors.New("dispute is already resolved")
+	// ErrInvalidAssignee is returned when assigning a dispute to a user
+	// without a staff role
+	ErrInvalidAssignee = errors.New("assignee is not a staff member")
+	// ErrDepositBatchNotFound is returned for deposit batches that do not
+	// exist
+	ErrDepositBatchNotFound = errors.New("deposit batch not found")
+	// ErrFindingNotFound is returned for suspicious activity findings that
+	// do not exist
+	ErrFindingNotFound = errors.New("suspicious activity finding not found")
+	// ErrFindingReviewed is returned when reviewing a finding again
+	ErrFindingReviewed = errors.New("suspicious activity finding is already reviewed")
+	// ErrReconciliationNotFound is returned when no reconciliation has run
+	// yet
+	ErrReconciliationNotFound = errors.New("no reconciliation has run yet")
+	// ErrAccountNotFound is returned when verifying an account that does
+	// not exist
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrBeneficiaryNotFound is returned for beneficiaries that do not
+	// exist or belong to another user
+	ErrBeneficiaryNotFound = errors.New("beneficiary not found")
+	// ErrBeneficiaryExists is returned when saving an account the user
+	// already saved as a beneficiary
+	ErrBeneficiaryExists = errors.New("account is already a beneficiary")
+	// ErrBeneficiaryLimitReached is returned when saving a beneficiary
+	// would go over the per-user limit
+	ErrBeneficiaryLimitReached = errors.New("too many beneficiaries")
+	// ErrBeneficiaryCoolingOff is returned for large transfers to an
+	// account that has not been a beneficiary for the cooling-off period
+	ErrBeneficiaryCoolingOff = errors.New("large transfers need a beneficiary saved for the cooling-off period")
+	// ErrDestinationAccountNotFound is returned for transfers, and
+	// beneficiaries, to accounts that do not exist
+	ErrDestinationAccountNotFound = errors.New("destination account not found")
+	// ErrDestinationAccountUnavailable is returned for transfers to a
+	// frozen account
+	ErrDestinationAccountUnavailable = errors.New("destination account cannot receive transfers")
+	// ErrExternalTransferNotFound is returned for external transfers that
+	// do not exist or belong to another user
+	ErrExternalTransferNotFound = errors.New("external transfer not found")
+	// ErrExternalTransferSettled is returned when settling an external
+	// transfer that already completed or failed
+	ErrExternalTransferSettled = errors.New("external transfer is already settled")
+	// ErrChequeNotFound is returned for cheque deposits that do not exist
+	ErrChequeNotFound = errors.New("cheque deposit not found")
+	// ErrChequeNotOnHold is returned when releasing or returning a cheque
+	// whose hold has lapsed or that was already released or returned
+	ErrChequeNotOnHold = errors.New("cheque is not on hold")
+	// ErrTransactionPINRequired is returned for withdrawals and transfers
+	// made without the transaction PIN the user set
+	ErrTransactionPINRequired = errors.New("transaction PIN is required")
+	// ErrTransactionPINResetRequired is returned for withdrawals and
+	// transfers after staff reset the user's transaction PIN, until they
+	// set a new one
+	ErrTransactionPINResetRequired = errors.New("transaction PIN was reset and a new one must be set")
+	// ErrInvalidTransactionPIN is returned for a wrong transaction PIN
+	ErrInvalidTransactionPIN = errors.New("transaction PIN is incorrect")
+	// ErrInvalidPassword is returned for a wrong password given to change
+	// the transaction PIN
+	ErrInvalidPassword = errors.New("password is incorrect")
+	// ErrTransactionPINLocked is returned while the transaction PIN is
+	// locked after too many wrong attempts
+	ErrTransactionPINLocked = errors.New("transaction PIN is locked after too many wrong attempts")
+	// ErrTransactionPINNotSet is returned when resetting the transaction
+	// PIN of an account without one
+	ErrTransactionPINNotSet = errors.New("account has no transaction PIN")
+	// ErrPromotionNotFound is returned for promotions that do not exist
+	ErrPromotionNotFound = errors.New("promotion not found")
+	// ErrPromotionRedeemed is returned when deleting a promotion that
+	// already paid bonuses
+	ErrPromotionRedeemed = errors.New("promotion has paid bonuses and can only be ended")
+	// ErrAccountOwnerRequired is returned when a member who is not an
+	// owner manages an account's members
+	ErrAccountOwnerRequired = errors.New("only account owners can manage members")
+	// ErrAccountMemberNotFound is returned for users who are not members
+	// of the account
+	ErrAccountMemberNotFound = errors.New("account member not found")
+	// ErrAccountMemberExists is returned when inviting a user who is
+	// already a member of the account
+	ErrAccountMemberExists = errors.New("user is already a member of the account")
+	// ErrAccountHolderRemoval is returned when removing the user an account
+	// was opened for from its members
+	ErrAccountHolderRemoval = errors.New("the account holder cannot be removed")
+	// ErrLastAccountOwner is returned when removing or demoting an
+	// account's only owner
+	ErrLastAccountOwner = errors.New("account must keep an owner")
+	// ErrInviteeNotFound is returned when inviting an email no active user
+	// has
+	ErrInviteeNotFound = errors.New("no user has this email")
+	// ErrInvitationNotFound is returned for invitations that do not exist
+	// or are for another user
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationPending is returned when inviting a user who has a
+	// pending invitation to the account
+	ErrInvitationPending = errors.New("user already has a pending invitation to the account")
+	// ErrInvitationNotPending is returned when accepting an invitation
+	// that expired or was already accepted
+	ErrInvitationNotPending = errors.New("invitation has expired or was already accepted")
+	// ErrSpendingLimitExceeded is returned for withdrawals and transfers
+	// over the member's spending limit
+	ErrSpendingLimitExceeded = errors.New("amount is over the member's spending limit")
+	// ErrConfirmationInvalid is returned when confirming a withdrawal or
+	// transfer with a token that does not exist, belongs to another user,
+	// expired or was already used
+	ErrConfirmationInvalid = errors.New("confirmation token is invalid, expired or already used")
+)
+
+// PublicErrors are the sentinel errors whose messages may be shown to
+// clients in error details. Other error text stays in the server logs.
+var PublicErrors = []error{
+	ErrAccountFrozen, ErrInsufficientFunds, ErrInvalidAmount, ErrKYCRequired,
+	ErrFundsEarmarked, ErrSavingsGoalNotFound, ErrInsufficientAvailableFunds, ErrGoalReleaseTooLarge,
+	ErrTransactionNotFound, ErrTransactionAccessDenied, ErrTransactionNotDisputable, ErrDisputeExists,
+	ErrDisputeNotFound, ErrDisputeResolved, ErrInvalidAssignee, ErrDepositBatchNotFound,
+	ErrFindingNotFound, ErrFindingReviewed, ErrReconciliationNotFound, ErrAccountNotFound,
+	ErrBeneficiaryNotFound, ErrBeneficiaryExists, ErrBeneficiaryLimitReached, ErrBeneficiaryCoolingOff,
+	ErrDestinationAccountNotFound, ErrDestinationAccountUnavailable, ErrExternalTransferNotFound, ErrExternalTransferSettled,
+	ErrChequeNotFound, ErrChequeNotOnHold, ErrTransactionPINRequired, ErrTransactionPINResetRequired,
+	ErrInvalidTransactionPIN, ErrInvalidPassword, ErrTransactionPINLocked, ErrTransactionPINNotSet,
+	ErrPromotionNotFound, ErrPromotionRedeemed, ErrAccountOwnerRequired, ErrAccountMemberNotFound,
+	ErrAccountMemberExists, ErrAccountHolderRemoval, ErrLastAccountOwner, ErrInviteeNotFound,
+	ErrInvitationNotFound, ErrInvitationPending, ErrInvitationNotPending, ErrSpendingLimitExceeded,
+	ErrConfirmationInvalid, events.ErrUnsupportedVersion,
+}
+
+// KYCRequiredError reports that a withdrawal is over Limit and the user's
+// KYC status is not verified. It matches ErrKYCRequired with errors.Is.
+type KYCRequiredError struct {
+	Limit     float64
+	KYCStatus string
+}
+
+func (e *KYCRequiredError) Error() string {
+	return fmt.Sprintf("%s: withdrawals over %s need a verified identity, KYC status is %q", ErrKYCRequired, models.Amount(e.Limit), e.KYCStatus)
+}
+
+// Is makes errors.Is(err, ErrKYCRequired) true for KYC required errors
+func (e *KYCRequiredError) Is(target error) bool {
+	return target == ErrKYCRequired
+}
+
+// InsufficientFundsError reports that an account's Available balance does
+// not cover a withdrawal of Requested and the Fee charged on it. It matches
+// ErrInsufficientFunds with errors.Is.
+type InsufficientFundsError struct {
+	Requested float64
+	Fee       float64
+	Available float64
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("%s: requested %s plus a fee of %s, available %s", ErrInsufficientFunds, models.Amount(e.Requested), models.Amount(e.Fee), models.Amount(e.Available))
+}
+
+// Is makes errors.Is(err, ErrInsufficientFunds) true for insufficient funds
+// errors
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+// EarmarkedFundsError reports that a withdrawal of Requested and the Fee
+// charged on it would need money earmarked for strict savings goals, and
+// that at most Withdrawable can be taken out. It matches ErrFundsEarmarked
+// with errors.Is.
+type EarmarkedFundsError struct {
+	Requested    float64
+	Fee          float64
+	Withdrawable float64
+}
+
+func (e *EarmarkedFundsError) Error() string {
+	return fmt.Sprintf("%s: requested %s plus a fee of %s, withdrawable %s", ErrFundsEarmarked, models.Amount(e.Requested), models.Amount(e.Fee), models.Amount(e.Withdrawable))
+}
+
+// Is makes errors.Is(err, ErrFundsEarmarked) true for earmarked funds
+// errors
+func (e *EarmarkedFundsError) Is(target error) bool {
+	return target == ErrFundsEarmarked
+}
+
+// BeneficiaryCoolingOffError reports that a transfer is over Limit and
+// goes to an account saved as a beneficiary for less than the cooling-off
+// period, or not saved at all. AllowedAt is when large transfers to it are
+// allowed, and nil when it is not a beneficiary. It matches
+// ErrBeneficiaryCoolingOff with errors.Is.
+type BeneficiaryCoolingOffError struct {
+	Limit     float64
+	AllowedAt *time.Time
+}
+
+func (e *BeneficiaryCoolingOffError) Error() string {
+	if e.AllowedAt == nil {
+		return fmt.Sprintf("%s: transfers over %s need a saved beneficiary", ErrBeneficiaryCoolingOff, models.Amount(e.Limit))
+	}
+	return fmt.Sprintf("%s: transfers over %s to this beneficiary are allowed from %s", ErrBeneficiaryCoolingOff, models.Amount(e.Limit), e.AllowedAt.UTC().Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrBeneficiaryCoolingOff) true for cooling-off
+// errors
+func (e *BeneficiaryCoolingOffError) Is(target error) bool {
+	return target == ErrBeneficiaryCoolingOff
+}
+
+// SpendingLimitError reports that an amount is over the member's Limit on
+// what they may withdraw or transfer at once. It matches
+// ErrSpendingLimitExceeded with errors.Is.
+type SpendingLimitError struct {
+	Limit float64
+}
+
+func (e *SpendingLimitError) Error() string {
+	return fmt.Sprintf("%s of %s", ErrSpendingLimitExceeded, models.Amount(e.Limit))
+}
+
+// Is makes errors.Is(err, ErrSpendingLimitExceeded) true for spending limit
+// errors
+func (e *SpendingLimitError) Is(target error) bool {
+	return target == ErrSpendingLimitExceeded
+}
+
+// PINAttemptError reports a wrong transaction PIN or password, Err being
+// ErrInvalidTransactionPIN or ErrInvalidPassword, and how many attempts
+// are left before the PIN locks. It matches Err with errors.Is.
+type PINAttemptError struct {
+	Err               error
+	AttemptsRemaining int
+}
+
+func (e *PINAttemptError) Error() string {
+	return fmt.Sprintf("%s: %d attempts remaining", e.Err, e.AttemptsRemaining)
+}
+
+// Unwrap returns Err, so errors.Is matches it
+func (e *PINAttemptError) Unwrap() error {
+	return e.Err
+}
+
+// TransactionPINLockedError reports that the transaction PIN is locked
+// until Until. It matches ErrTransactionPINLocked with errors.Is.
+type TransactionPINLockedError struct {
+	Until time.Time
+}
+
+func (e *TransactionPINLockedError) Error() string {
+	return fmt.Sprintf("%s until %s", ErrTransactionPINLocked, e.Until.UTC().Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrTransactionPINLocked) true for locked PIN
+// errors
+func (e *TransactionPINLockedError) Is(target error) bool {
+	return target == ErrTransactionPINLocked
+}
+
+// FieldError describes why one field of a request is invalid
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+// ValidationError reports every invalid field of a request at once
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Field + ": " + f.Message
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// External transfer list paging limits
+const (
+	DefaultExternalTransferPageSize = 50
+	MaxExternalTransferPageSize     = 200
+)
+
+// ExternalTransferService settles transfers to other banks and lists them.
+// Transfers are made by TransactionService.ProcessExternalTransfer. The
+// network is simulated: SettleDue completes transfers once they have been
+// pending for the settlement delay, turning down the configured share of
+// them as the receiving bank would, and staff can settle any transfer
+// early.
+type ExternalTransferService struct {
+	externalTransferRepo repository.ExternalTransferRepository
+	rules                models.ExternalTransferRules
+	maintenance          MaintenanceSource
+	now                  func() time.Time
+	// random returns a number in [0, 1), deciding which transfers the
+	// receiving bank turns down
+	random func() float64
+}
+
+// NewExternalTransferService creates a new external transfer service
+func NewExternalTransferService(externalTransferRepo repository.ExternalTransferRepository, rules models.ExternalTransferRules) *ExternalTransferService {
+	return &ExternalTransferService{
+		externalTransferRepo: externalTransferRepo,
+		rules:                rules,
+		now:                  time.Now,
+		random:               rand.Float64,
+	}
+}
+
+// WithMaintenance stops SettleDue settling transfers while maintenance
+// pauses money movement
+func (s *ExternalTransferService) WithMaintenance(maintenance MaintenanceSource) *ExternalTransferService {
+	s.maintenance = maintenance
+	return s
+}
+
+// ListUserTransfers returns one page of the user's external transfers,
+// newest first
+func (s *ExternalTransferService) ListUserTransfers(userID uuid.UUID, limit, offset int) (*models.ExternalTransferPage, error) {
+	return s.ListTransfers(models.ExternalTransferFilter{UserID: &userID, Limit: limit, Offset: offset})
+}
+
+// GetUserTransfer returns one of the user's external transfers
+func (s *ExternalTransferService) GetUserTransfer(userID, transferID uuid.UUID) (*models.ExternalTransfer, error) {
+	transfer, err := s.externalTransferRepo.GetByID(transferID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get external transfer: %w", err)
+	}
+	if transfer == nil || transfer.UserID != userID {
+		return nil, ErrExternalTransferNotFound
+	}
+	return transfer, nil
+}
+
+// ListTransfers returns one page of external transfers matching the
+// filter, newest first
+func (s *ExternalTransferService) ListTransfers(filter models.ExternalTransferFilter) (*models.ExternalTransferPage, error) {
+	if filter.Status != "" && !models.IsExternalTransferStatus(string(filter.Status)) {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "status", Rule: "oneof", Message: "must be pending, completed or failed"}}}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultExternalTransferPageSize
+	}
+	if filter.Limit > MaxExternalTransferPageSize {
+		filter.Limit = MaxExternalTransferPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	transfers, total, err := s.externalTransferRepo.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list external transfers: %w", err)
+	}
+	if transfers == nil {
+		transfers = []models.ExternalTransfer{}
+	}
+
+	return &models.ExternalTransferPage{Transfers: transfers, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// Complete settles a pending transfer, taking its amount from the balance.
+// When the balance no longer covers it the transfer fails with
+// insufficient_funds instead, and is returned failed.
+func (s *ExternalTransferService) Complete(transferID uuid.UUID, change models.ExternalTransferChange) (*models.ExternalTransfer, error) {
+	transfer, err := s.pendingTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.complete(transfer, change); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// Fail fails a pending transfer for reason, releasing its hold. The user
+// is told by email.
+func (s *ExternalTransferService) Fail(transferID uuid.UUID, reason string, change models.ExternalTransferChange) (*models.ExternalTransfer, error) {
+	transfer, err := s.pendingTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fail(transfer, reason, change); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// SettleDue settles up to limit transfers that have been pending for the
+// settlement delay, oldest first, and returns how many it settled. Each
+// is turned down by the receiving bank with the configured chance, and
+// completed otherwise. Transfers that fail to settle are logged and left
+// pending for the next run. Nothing is settled when the delay is zero or
+// while money movement is paused.
+func (s *ExternalTransferService) SettleDue(limit int) (int, error) {
+	if s.rules.SettlementDelay <= 0 {
+		return 0, nil
+	}
+	if s.maintenance != nil {
+		if _, paused := s.maintenance.Paused(); paused {
+			return 0, nil
+		}
+	}
+
+	due, err := s.externalTransferRepo.ListDue(s.now().Add(-s.rules.SettlementDelay), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due external transfers: %w", err)
+	}
+
+	settled := 0
+	for i := range due {
+		transfer := &due[i]
+		if s.random()*100 < s.rules.FailurePercent {
+			err = s.fail(transfer, models.ExternalTransferFailureRejected, models.ExternalTransferChange{})
+		} else {
+			err = s.complete(transfer, models.ExternalTransferChange{})
+		}
+		if err != nil {
+			// Another replica may have settled it first
+			if !errors.Is(err, ErrExternalTransferSettled) {
+				log.Printf("Failed to settle external transfer %s: %v", transfer.ID, err)
+			}
+			continue
+		}
+		settled++
+	}
+
+	return settled, nil
+}
+
+// pendingTransfer returns a transfer that has not settled yet
+func (s *ExternalTransferService) pendingTransfer(transferID uuid.UUID) (*models.ExternalTransfer, error) {
+	transfer, err := s.externalTransferRepo.GetByID(transferID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get external transfer: %w", err)
+	}
+	if transfer == nil {
+		return nil, ErrExternalTransferNotFound
+	}
+	if transfer.Status != models.ExternalTransferStatusPending {
+		return nil, ErrExternalTransferSettled
+	}
+	return transfer, nil
+}
+
+// complete posts the external_transfer transaction of a pending transfer,
+// failing it with insufficient_funds when the balance does not cover it
+func (s *ExternalTransferService) complete(transfer *models.ExternalTransfer, change models.ExternalTransferChange) error {
+	description := transfer.Description
+	if description == "" {
+		description = "Transfer to " + transfer.MaskedAccountNumber()
+	}
+	transaction := &models.Transaction{
+		ID:          uuid.New(),
+		AccountID:   transfer.AccountID,
+		UserID:      transfer.UserID,
+		Type:        models.TransactionTypeExternalTransfer,
+		Amount:      transfer.Amount,
+		Description: description,
+		CreatedAt:   s.now(),
+	}
+
+	err := s.externalTransferRepo.Complete(transfer, transaction)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		return s.fail(transfer, models.ExternalTransferFailureInsufficientFunds, change)
+	case errors.Is(err, repository.ErrExternalTransferNotPending):
+		return ErrExternalTransferSettled
+	}
+	return fmt.Errorf("failed to complete external transfer: %w", err)
+}
+
+// fail fails a pending transfer for reason
+func (s *ExternalTransferService) fail(transfer *models.ExternalTransfer, reason string, change models.ExternalTransferChange) error {
+	transfer.FailureReason = reason
+	if err := s.externalTransferRepo.Fail(transfer, change); err != nil {
+		if errors.Is(err, repository.ErrExternalTransferNotPending) {
+			return ErrExternalTransferSettled
+		}
+		return fmt.Errorf("failed to fail external transfer: %w", err)
+	}
+	return nil
+}
+
+// validRoutingNumber reports whether routing is a nine-digit ABA routing
+// number with a valid check digit
+func validRoutingNumber(routing string) bool {
+	if len(routing) != 9 {
+		return false
+	}
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i, r := range routing {
+		if r < '0' || r > '9' {
+			return false
+		}
+		sum += int(r-'0') * weights[i%3]
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakeExternalTransferRepo keeps external transfers in memory, holding
+// and taking their amounts from accounts as the database does
+type fakeExternalTransferRepo struct {
+	repository.ExternalTransferRepository
+	accounts  *balanceAccountRepo
+	transfers []*models.ExternalTransfer
+	posted    []models.Transaction
+	failed    []models.ExternalTransferChange
+}
+
+func (r *fakeExternalTransferRepo) Create(transfer *models.ExternalTransfer) error {
+	account, err := r.accounts.GetAccountByID(transfer.AccountID)
+	if err != nil {
+		return err
+	}
+	held, _ := r.HeldByAccountID(transfer.AccountID)
+	if roundCents(account.Balance-held-transfer.Amount) < 0 {
+		return repository.ErrInsufficientBalance
+	}
+	transfer.Status = models.ExternalTransferStatusPending
+	clone := *transfer
+	r.transfers = append(r.transfers, &clone)
+	return nil
+}
+
+func (r *fakeExternalTransferRepo) GetByID(id uuid.UUID) (*models.ExternalTransfer, error) {
+	for _, transfer := range r.transfers {
+		if transfer.ID == id {
+			clone := *transfer
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeExternalTransferRepo) List(filter models.ExternalTransferFilter) ([]models.ExternalTransfer, int, error) {
+	var transfers []models.ExternalTransfer
+	for _, transfer := range r.transfers {
+		if (filter.UserID == nil || transfer.UserID == *filter.UserID) && (filter.Status == "" || transfer.Status == filter.Status) {
+			transfers = append(transfers, *transfer)
+		}
+	}
+	return transfers, len(transfers), nil
+}
+
+func (r *fakeExternalTransferRepo) ListDue(createdBefore time.Time, limit int) ([]models.ExternalTransfer, error) {
+	var due []models.ExternalTransfer
+	for _, transfer := range r.transfers {
+		if transfer.Status == models.ExternalTransferStatusPending && !transfer.CreatedAt.After(createdBefore) {
+			due = append(due, *transfer)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].CreatedAt.Before(due[j].CreatedAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *fakeExternalTransferRepo) HeldByAccountID(accountID uuid.UUID) (float64, error) {
+	held := 0.0
+	for _, transfer := range r.transfers {
+		if transfer.AccountID == accountID && transfer.Status == models.ExternalTransferStatusPending {
+			held += transfer.Amount
+		}
+	}
+	return roundCents(held), nil
+}
+
+func (r *fakeExternalTransferRepo) Complete(transfer *models.ExternalTransfer, transaction *models.Transaction) error {
+	stored, err := r.pending(transfer.ID)
+	if err != nil {
+		return err
+	}
+	account, err := r.accounts.GetAccountByID(transaction.AccountID)
+	if err != nil {
+		return err
+	}
+	if roundCents(account.Balance-transaction.Amount) < 0 {
+		return repository.ErrInsufficientBalance
+	}
+	transaction.BalanceBefore = account.Balance
+	transaction.BalanceAfter = roundCents(account.Balance - transaction.Amount)
+	r.posted = append(r.posted, *transaction)
+	if err := r.accounts.UpdateBalance(account.ID, transaction.BalanceAfter); err != nil {
+		return err
+	}
+	transfer.Status = models.ExternalTransferStatusCompleted
+	transfer.TransactionID = &transaction.ID
+	*stored = *transfer
+	return nil
+}
+
+func (r *fakeExternalTransferRepo) Fail(transfer *models.ExternalTransfer, change models.ExternalTransferChange) error {
+	stored, err := r.pending(transfer.ID)
+	if err != nil {
+		return err
+	}
+	transfer.Status = models.ExternalTransferStatusFailed
+	*stored = *transfer
+	r.failed = append(r.failed, change)
+	return nil
+}
+
+func (r *fakeExternalTransferRepo) pending(id uuid.UUID) (*models.ExternalTransfer, error) {
+	for _, transfer := range r.transfers {
+		if transfer.ID == id {
+			if transfer.Status != models.ExternalTransferStatusPending {
+				return nil, repository.ErrExternalTransferNotPending
+			}
+			return transfer, nil
+		}
+	}
+	return nil, errors.New("external transfer not found")
+}
+
+// testExternalTransferRequest is a transfer of amount to a valid routing
+// number
+func testExternalTransferRequest(amount float64) models.ExternalTransferRequest {
+	return models.ExternalTransferRequest{Amount: amount, RoutingNumber: "021000021", AccountNumber: "123456789", AccountHolderName: " Tendai Moyo "}
+}
+
+func TestTransactionService_ProcessExternalTransferHoldsFunds(t *testing.T) {
+	user, payee := uuid.New(), uuid.New()
+	accounts := newBeneficiaryAccounts(user, payee)
+	transfers := &fakeExternalTransferRepo{accounts: accounts}
+	transactions := &fakeTransactionRepo{accounts: accounts}
+	svc := NewTransactionService(transactions, accounts).WithExternalTransfers(transfers)
+
+	transfer, err := svc.ProcessExternalTransfer(user, testExternalTransferRequest(600))
+	if err != nil {
+		t.Fatalf("ProcessExternalTransfer returned error: %v", err)
+	}
+	if transfer.Status != models.ExternalTransferStatusPending || transfer.AccountHolderName != "Tendai Moyo" || transfer.MaskedAccountNumber() != "****6789" {
+		t.Errorf("Unexpected transfer: %+v", transfer)
+	}
+
+	// The amount stays in the balance but cannot be spent
+	if accounts.accounts[user].Balance != 1000 {
+		t.Errorf("Expected the balance to be untouched while pending, got %v", accounts.accounts[user].Balance)
+	}
+	if held, err := svc.HeldFunds(accounts.accounts[user].ID); err != nil || held != 600 {
+		t.Errorf("Expected 600 held, got %v (%v)", held, err)
+	}
+
+	var insufficient *InsufficientFundsError
+	if _, err := svc.ProcessWithdrawal(user, models.TransactionRequest{Amount: 500, Description: ""}); !errors.As(err, &insufficient) || insufficient.Available != 400 {
+		t.Errorf("Expected a withdrawal of 500 to find 400 available, got %v", err)
+	}
+	if _, err := svc.ProcessTransfer(user, models.TransferRequest{Amount: 500, DestinationAccountID: &accounts.accounts[payee].ID}); !errors.As(err, &insufficient) {
+		t.Errorf("Expected a transfer of 500 to find too little available, got %v", err)
+	}
+	if _, err := svc.ProcessExternalTransfer(user, testExternalTransferRequest(400.01)); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("Expected another external transfer over what is left to fail, got %v", err)
+	}
+	if _, err := svc.ProcessWithdrawal(user, models.TransactionRequest{Amount: 400, Description: ""}); err != nil {
+		t.Errorf("Expected the unheld 400 to be withdrawable, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		request models.ExternalTransferRequest
+		field   string
+	}{
+		{name: "bad check digit", request: models.ExternalTransferRequest{Amount: 1, RoutingNumber: "021000022", AccountNumber: "1234", AccountHolderName: "A"}, field: "routing_number"},
+		{name: "blank holder", request: models.ExternalTransferRequest{Amount: 1, RoutingNumber: "021000021", AccountNumber: "1234", AccountHolderName: "   "}, field: "account_holder_name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ProcessExternalTransfer(user, tt.request)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != tt.field {
+				t.Fatalf("Expected a validation error on %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestExternalTransferService_Complete(t *testing.T) {
+	user, other := uuid.New(), uuid.New()
+	accounts := newBeneficiaryAccounts(user, other)
+	transfers := &fakeExternalTransferRepo{accounts: accounts}
+	transactionService := NewTransactionService(&fakeTransactionRepo{accounts: accounts}, accounts).WithExternalTransfers(transfers)
+	svc := NewExternalTransferService(transfers, models.ExternalTransferRules{})
+
+	pending, err := transactionService.ProcessExternalTransfer(user, testExternalTransferRequest(250))
+	if err != nil {
+		t.Fatalf("ProcessExternalTransfer returned error: %v", err)
+	}
+	if _, err := svc.GetUserTransfer(other, pending.ID); !errors.Is(err, ErrExternalTransferNotFound) {
+		t.Errorf("Expected another user's transfer not to be found, got %v", err)
+	}
+
+	staffID := uuid.New()
+	completed, err := svc.Complete(pending.ID, models.ExternalTransferChange{ActorID: &staffID})
+	if err != nil {
+		t.Fatalf("Complete returned error: %v", err)
+	}
+	if completed.Status != models.ExternalTransferStatusCompleted || accounts.accounts[user].Balance != 750 {
+		t.Errorf("Expected the transfer to complete and take 250, got %s and a balance of %v", completed.Status, accounts.accounts[user].Balance)
+	}
+	if len(transfers.posted) != 1 || transfers.posted[0].Type != models.TransactionTypeExternalTransfer || transfers.posted[0].Description != "Transfer to ****6789" {
+		t.Errorf("Expected one external_transfer transaction, got %+v", transfers.posted)
+	}
+	if held, _ := transactionService.HeldFunds(accounts.accounts[user].ID); held != 0 {
+		t.Errorf("Expected the hold to be released, got %v", held)
+	}
+
+	if _, err := svc.Complete(pending.ID, models.ExternalTransferChange{}); !errors.Is(err, ErrExternalTransferSettled) {
+		t.Errorf("Expected a completed transfer not to settle again, got %v", err)
+	}
+	if _, err := svc.Fail(pending.ID, "Too late", models.ExternalTransferChange{}); !errors.Is(err, ErrExternalTransferSettled) {
+		t.Errorf("Expected a completed transfer not to fail, got %v", err)
+	}
+	if _, err := svc.Complete(uuid.New(), models.ExternalTransferChange{}); !errors.Is(err, ErrExternalTransferNotFound) {
+		t.Errorf("Expected an unknown transfer not to be found, got %v", err)
+	}
+}
+
+func TestExternalTransferService_CompleteFailsWhenBalanceIsShort(t *testing.T) {
+	user := uuid.New()
+	accounts := newBeneficiaryAccounts(user)
+	transfers := &fakeExternalTransferRepo{accounts: accounts}
+	transactionService := NewTransactionService(&fakeTransactionRepo{accounts: accounts}, accounts).WithExternalTransfers(transfers)
+	svc := NewExternalTransferService(transfers, models.ExternalTransferRules{})
+
+	pending, err := transactionService.ProcessExternalTransfer(user, testExternalTransferRequest(600))
+	if err != nil {
+		t.Fatalf("ProcessExternalTransfer returned error: %v", err)
+	}
+	// A fee or adjustment may take the balance below the hold meanwhile
+	accounts.accounts[user].Balance = 500
+
+	transfer, err := svc.Complete(pending.ID, models.ExternalTransferChange{})
+	if err != nil {
+		t.Fatalf("Complete returned error: %v", err)
+	}
+	if transfer.Status != models.ExternalTransferStatusFailed || transfer.FailureReason != models.ExternalTransferFailureInsufficientFunds {
+		t.Errorf("Expected the transfer to fail for insufficient funds, got %s (%s)", transfer.Status, transfer.FailureReason)
+	}
+	if len(transfers.posted) != 0 || accounts.accounts[user].Balance != 500 || len(transfers.failed) != 1 {
+		t.Errorf("Expected nothing posted and one failure recorded, got %d posted, %d failed and a balance of %v", len(transfers.posted), len(transfers.failed), accounts.accounts[user].Balance)
+	}
+}
+
+func TestExternalTransferService_SettleDue(t *testing.T) {
+	user := uuid.New()
+	accounts := newBeneficiaryAccounts(user)
+	transfers := &fakeExternalTransferRepo{accounts: accounts}
+	now := time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)
+	for i, age := range []time.Duration{3 * time.Minute, 2 * time.Minute, 30 * time.Second} {
+		transfers.transfers = append(transfers.transfers, &models.ExternalTransfer{
+			ID:            uuid.New(),
+			UserID:        user,
+			AccountID:     accounts.accounts[user].ID,
+			Amount:        float64(100 * (i + 1)),
+			AccountNumber: "123456789",
+			Status:        models.ExternalTransferStatusPending,
+			CreatedAt:     now.Add(-age),
+		})
+	}
+
+	// The receiving bank turns down a quarter of transfers; the first
+	// draw falls in that quarter
+	svc := NewExternalTransferService(transfers, models.ExternalTransferRules{SettlementDelay: time.Minute, FailurePercent: 25})
+	svc.now = func() time.Time { return now }
+	draws := []float64{0.1, 0.9}
+	svc.random = func() float64 {
+		draw := draws[0]
+		draws = draws[1:]
+		return draw
+	}
+
+	paused := NewExternalTransferService(transfers, svc.rules).WithMaintenance(pausedSource{})
+	if settled, err := paused.SettleDue(10); err != nil || settled != 0 {
+		t.Fatalf("Expected nothing settled while paused, got %d (%v)", settled, err)
+	}
+
+	settled, err := svc.SettleDue(10)
+	if err != nil || settled != 2 {
+		t.Fatalf("Expected the 2 due transfers to settle, got %d (%v)", settled, err)
+	}
+	want := []struct {
+		status models.ExternalTransferStatus
+		reason string
+	}{
+		{models.ExternalTransferStatusFailed, models.ExternalTransferFailureRejected},
+		{models.ExternalTransferStatusCompleted, ""},
+		{models.ExternalTransferStatusPending, ""},
+	}
+	for i, transfer := range transfers.transfers {
+		if transfer.Status != want[i].status || transfer.FailureReason != want[i].reason {
+			t.Errorf("Expected transfer %d to be %s (%s), got %s (%s)", i, want[i].status, want[i].reason, transfer.Status, transfer.FailureReason)
+		}
+	}
+	if accounts.accounts[user].Balance != 800 {
+		t.Errorf("Expected only the completed 200 to be taken, got a balance of %v", accounts.accounts[user].Balance)
+	}
+
+	// With no delay transfers wait for staff
+	manual := NewExternalTransferService(transfers, models.ExternalTransferRules{})
+	manual.now = func() time.Time { return now.Add(time.Hour) }
+	if settled, err := manual.SettleDue(10); err != nil || settled != 0 {
+		t.Errorf("Expected nothing settled without a delay, got %d (%v)", settled, err)
+	}
+}
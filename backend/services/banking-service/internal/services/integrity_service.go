@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// integrityChainBatchSize is how many transactions of a chain are read at
+// a time
+const integrityChainBatchSize = 1000
+
+// IntegrityService verifies the hash chains that make changes to an
+// account's transactions evident. Every transaction is hashed together
+// with the hash of the one before it on its account, so editing, removing
+// or inserting one breaks the chain from there on.
+type IntegrityService struct {
+	transactionRepo repository.TransactionRepository
+	batchSize       int
+	now             func() time.Time
+}
+
+// NewIntegrityService creates a new integrity service
+func NewIntegrityService(transactionRepo repository.TransactionRepository) *IntegrityService {
+	return &IntegrityService{
+		transactionRepo: transactionRepo,
+		batchSize:       integrityChainBatchSize,
+		now:             time.Now,
+	}
+}
+
+// VerifyAccount walks an account's integrity chain from its first
+// transaction, recomputing each hash, and reports the first break. The
+// chain is checked up to the head recorded when the walk starts, so
+// transactions made meanwhile are left for the next check.
+func (s *IntegrityService) VerifyAccount(accountID uuid.UUID) (*models.IntegrityVerification, error) {
+	head, err := s.transactionRepo.GetIntegrityChainHead(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	result := &models.IntegrityVerification{
+		AccountID:             accountID,
+		UnchainedTransactions: head.Unchained,
+		HeadHash:              head.Hash,
+		VerifiedAt:            s.now().UTC(),
+	}
+
+	previous := models.GenesisHash
+	var sequence int64
+	for sequence < head.Sequence {
+		limit := s.batchSize
+		if remaining := head.Sequence - sequence; remaining < int64(limit) {
+			limit = int(remaining)
+		}
+		chain, err := s.transactionRepo.ListIntegrityChain(accountID, sequence, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read integrity chain: %w", err)
+		}
+		if len(chain) == 0 {
+			break
+		}
+
+		for i := range chain {
+			transaction := &chain[i]
+			sequence++
+			switch {
+			case transaction.ChainSequence > sequence:
+				result.Break = &models.IntegrityBreak{Reason: models.IntegrityBreakMissing, Sequence: sequence}
+				return result, nil
+			case transaction.ChainSequence < sequence:
+				result.Break = &models.IntegrityBreak{Reason: models.IntegrityBreakDuplicate, Sequence: transaction.ChainSequence, TransactionID: &transaction.ID}
+				return result, nil
+			}
+
+			expected := transaction.ComputeIntegrityHash(previous)
+			if transaction.IntegrityHash != expected {
+				result.Break = &models.IntegrityBreak{
+					Reason:        models.IntegrityBreakHashMismatch,
+					Sequence:      sequence,
+					TransactionID: &transaction.ID,
+					ExpectedHash:  expected,
+					StoredHash:    transaction.IntegrityHash,
+				}
+				return result, nil
+			}
+			previous = expected
+			result.TransactionsChecked++
+		}
+	}
+
+	// The chain ends early when transactions were removed from its end
+	if sequence < head.Sequence {
+		result.Break = &models.IntegrityBreak{Reason: models.IntegrityBreakMissing, Sequence: sequence + 1}
+		return result, nil
+	}
+	if sequence > 0 && previous != head.Hash {
+		result.Break = &models.IntegrityBreak{Reason: models.IntegrityBreakHead, Sequence: sequence, ExpectedHash: head.Hash, StoredHash: previous}
+		return result, nil
+	}
+
+	result.Valid = true
+	return result, nil
+}
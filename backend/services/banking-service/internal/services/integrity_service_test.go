@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakeIntegrityRepo holds one account's chained transactions and head
+type fakeIntegrityRepo struct {
+	repository.TransactionRepository
+	accountID uuid.UUID
+	head      models.IntegrityChainHead
+	chain     []models.Transaction
+	reads     int
+}
+
+func (r *fakeIntegrityRepo) GetIntegrityChainHead(accountID uuid.UUID) (*models.IntegrityChainHead, error) {
+	if accountID != r.accountID {
+		return nil, nil
+	}
+	head := r.head
+	return &head, nil
+}
+
+func (r *fakeIntegrityRepo) ListIntegrityChain(accountID uuid.UUID, after int64, limit int) ([]models.Transaction, error) {
+	r.reads++
+	var chain []models.Transaction
+	for _, transaction := range r.chain {
+		if transaction.ChainSequence > after && len(chain) < limit {
+			chain = append(chain, transaction)
+		}
+	}
+	return chain, nil
+}
+
+// newChainedAccount chains a deposit, a withdrawal, its fee, a refund of
+// the fee and another deposit the way the repository does
+func newChainedAccount() *fakeIntegrityRepo {
+	repo := &fakeIntegrityRepo{accountID: uuid.New()}
+	userID := uuid.New()
+	at := time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)
+	steps := []struct {
+		kind   models.TransactionType
+		amount float64
+	}{
+		{models.TransactionTypeDeposit, 500},
+		{models.TransactionTypeWithdrawal, 120.5},
+		{models.TransactionTypeFee, 2},
+		{models.TransactionTypeRefund, 2},
+		{models.TransactionTypeDeposit, 75.25},
+	}
+	previous := models.GenesisHash
+	for i, step := range steps {
+		transaction := models.Transaction{
+			ID:            uuid.New(),
+			AccountID:     repo.accountID,
+			UserID:        userID,
+			Type:          step.kind,
+			Amount:        step.amount,
+			CreatedAt:     at.Add(time.Duration(i) * time.Minute),
+			ChainSequence: int64(i + 1),
+		}
+		transaction.IntegrityHash = transaction.ComputeIntegrityHash(previous)
+		previous = transaction.IntegrityHash
+		repo.chain = append(repo.chain, transaction)
+	}
+	repo.head = models.IntegrityChainHead{Sequence: int64(len(steps)), Hash: previous, Unchained: 3}
+	return repo
+}
+
+func TestIntegrityService_VerifyAccountValidChain(t *testing.T) {
+	repo := newChainedAccount()
+	service := NewIntegrityService(repo)
+	service.batchSize = 2
+
+	result, err := service.VerifyAccount(repo.accountID)
+	if err != nil {
+		t.Fatalf("VerifyAccount returned error: %v", err)
+	}
+	if !result.Valid || result.Break != nil {
+		t.Fatalf("Expected the chain to be valid, got break %+v", result.Break)
+	}
+	if result.TransactionsChecked != 5 || result.UnchainedTransactions != 3 || result.HeadHash != repo.head.Hash {
+		t.Errorf("Expected 5 transactions checked up to the head and 3 unchained, got %+v", result)
+	}
+	if repo.reads != 3 {
+		t.Errorf("Expected the chain to be read in 3 batches of 2, got %d", repo.reads)
+	}
+}
+
+func TestIntegrityService_VerifyAccountFindsFirstBreak(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(repo *fakeIntegrityRepo)
+		want   models.IntegrityBreak
+	}{
+		{
+			name:   "edited amount",
+			tamper: func(repo *fakeIntegrityRepo) { repo.chain[2].Amount = 0.02 },
+			want:   models.IntegrityBreak{Reason: models.IntegrityBreakHashMismatch, Sequence: 3},
+		},
+		{
+			name:   "removed transaction",
+			tamper: func(repo *fakeIntegrityRepo) { repo.chain = append(repo.chain[:1], repo.chain[2:]...) },
+			want:   models.IntegrityBreak{Reason: models.IntegrityBreakMissing, Sequence: 2},
+		},
+		{
+			name:   "removed last transaction",
+			tamper: func(repo *fakeIntegrityRepo) { repo.chain = repo.chain[:4] },
+			want:   models.IntegrityBreak{Reason: models.IntegrityBreakMissing, Sequence: 5},
+		},
+		{
+			name:   "replaced head",
+			tamper: func(repo *fakeIntegrityRepo) { repo.head.Hash = models.GenesisHash },
+			want:   models.IntegrityBreak{Reason: models.IntegrityBreakHead, Sequence: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newChainedAccount()
+			tt.tamper(repo)
+
+			result, err := NewIntegrityService(repo).VerifyAccount(repo.accountID)
+			if err != nil {
+				t.Fatalf("VerifyAccount returned error: %v", err)
+			}
+			if result.Valid || result.Break == nil {
+				t.Fatal("Expected the chain to be broken")
+			}
+			if result.Break.Reason != tt.want.Reason || result.Break.Sequence != tt.want.Sequence {
+				t.Errorf("Expected %s at %d, got %s at %d", tt.want.Reason, tt.want.Sequence, result.Break.Reason, result.Break.Sequence)
+			}
+		})
+	}
+}
+
+func TestIntegrityService_VerifyAccountWithoutTransactions(t *testing.T) {
+	repo := &fakeIntegrityRepo{accountID: uuid.New()}
+	service := NewIntegrityService(repo)
+
+	result, err := service.VerifyAccount(repo.accountID)
+	if err != nil {
+		t.Fatalf("VerifyAccount returned error: %v", err)
+	}
+	if !result.Valid || repo.reads != 0 {
+		t.Errorf("Expected an empty chain to be valid without reading it, got %+v after %d reads", result, repo.reads)
+	}
+
+	if _, err := service.VerifyAccount(uuid.New()); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("Expected ErrAccountNotFound, got %v", err)
+	}
+}
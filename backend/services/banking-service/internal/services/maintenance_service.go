@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// DefaultMaintenanceTTL is how long the maintenance mode flag is reused
+// before it is read again, so a change made on another replica takes up to
+// this long to apply
+const DefaultMaintenanceTTL = 2 * time.Second
+
+// MaintenanceService pauses and resumes money movement. The flag is stored
+// in the database for every replica and cached in memory, so checking it
+// on each request is cheap.
+type MaintenanceService struct {
+	maintenanceRepo repository.MaintenanceRepository
+	ttl             time.Duration
+
+	mu        sync.Mutex
+	cached    *models.MaintenanceState
+	expiresAt time.Time
+	now       func() time.Time
+}
+
+// NewMaintenanceService creates a new maintenance service reusing the flag
+// for ttl
+func NewMaintenanceService(maintenanceRepo repository.MaintenanceRepository, ttl time.Duration) *MaintenanceService {
+	return &MaintenanceService{
+		maintenanceRepo: maintenanceRepo,
+		ttl:             ttl,
+		now:             time.Now,
+	}
+}
+
+// GetState returns the stored maintenance mode flag, read afresh
+func (s *MaintenanceService) GetState() (*models.MaintenanceState, error) {
+	state, err := s.maintenanceRepo.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance mode: %w", err)
+	}
+	s.store(state)
+	return state, nil
+}
+
+// Paused reports whether money movement is paused now, and if so the
+// state that paused it. When the flag cannot be read, the last value read
+// is used, or money movement is allowed if there is none; the writes it
+// guards fail on their own while the database is unavailable.
+func (s *MaintenanceService) Paused() (models.MaintenanceState, bool) {
+	s.mu.Lock()
+	cached, expiresAt := s.cached, s.expiresAt
+	s.mu.Unlock()
+
+	now := s.now()
+	if cached == nil || !expiresAt.After(now) {
+		state, err := s.maintenanceRepo.Get()
+		if err != nil {
+			log.Printf("Failed to read maintenance mode: %v", err)
+		} else {
+			s.store(state)
+			cached = state
+		}
+	}
+	if cached == nil || !cached.Active(now) {
+		return models.MaintenanceState{}, false
+	}
+	return *cached, true
+}
+
+// UpdateState pauses or resumes money movement. A pause may end by itself
+// at EndsAt, which must be in the future; resuming clears the message and
+// end time.
+func (s *MaintenanceService) UpdateState(req models.UpdateMaintenanceRequest, change models.MaintenanceChange) (*models.MaintenanceState, error) {
+	state := &models.MaintenanceState{Enabled: *req.Enabled}
+	if state.Enabled {
+		if req.EndsAt != nil && !req.EndsAt.After(s.now()) {
+			return nil, &ValidationError{Fields: []FieldError{{Field: "ends_at", Rule: "future", Message: "must be in the future"}}}
+		}
+		state.Message = strings.TrimSpace(req.Message)
+		state.EndsAt = req.EndsAt
+	}
+
+	if err := s.maintenanceRepo.Set(state, change); err != nil {
+		return nil, fmt.Errorf("failed to set maintenance mode: %w", err)
+	}
+	s.store(state)
+
+	return state, nil
+}
+
+// EnableAtStartup pauses money movement with message when the service is
+// started with MAINTENANCE_MODE, unless it is already paused. The change
+// is recorded with the nil UUID as its actor.
+func (s *MaintenanceService) EnableAtStartup(message string) error {
+	if _, paused := s.Paused(); paused {
+		return nil
+	}
+
+	enabled := true
+	_, err := s.UpdateState(models.UpdateMaintenanceRequest{Enabled: &enabled, Message: message}, models.MaintenanceChange{ActorID: uuid.Nil})
+	return err
+}
+
+// store keeps state for the TTL
+func (s *MaintenanceService) store(state *models.MaintenanceState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cached = state
+	s.expiresAt = s.now().Add(s.ttl)
+}
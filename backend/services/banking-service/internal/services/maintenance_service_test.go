@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// fakeMaintenanceRepo stores the flag in memory and counts reads, failing
+// them while failGet is set
+type fakeMaintenanceRepo struct {
+	state   models.MaintenanceState
+	changes []models.MaintenanceChange
+	reads   int
+	failGet bool
+}
+
+func (r *fakeMaintenanceRepo) Get() (*models.MaintenanceState, error) {
+	r.reads++
+	if r.failGet {
+		return nil, errors.New("database unavailable")
+	}
+	state := r.state
+	return &state, nil
+}
+
+func (r *fakeMaintenanceRepo) Set(state *models.MaintenanceState, change models.MaintenanceChange) error {
+	r.state = *state
+	r.changes = append(r.changes, change)
+	return nil
+}
+
+func TestMaintenanceService_Paused(t *testing.T) {
+	repo := &fakeMaintenanceRepo{}
+	svc := NewMaintenanceService(repo, time.Minute)
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	if _, paused := svc.Paused(); paused {
+		t.Fatal("Expected transactions not to be paused before the flag is set")
+	}
+
+	// Another replica pauses transactions; this one sees it once the
+	// cached flag expires
+	endsAt := now.Add(time.Hour)
+	repo.state = models.MaintenanceState{Enabled: true, Message: "Database upgrade", EndsAt: &endsAt}
+	if _, paused := svc.Paused(); paused || repo.reads != 1 {
+		t.Errorf("Expected the cached flag to be used, got paused=%v after %d reads", paused, repo.reads)
+	}
+	now = now.Add(2 * time.Minute)
+	state, paused := svc.Paused()
+	if !paused || state.Message != "Database upgrade" {
+		t.Errorf("Expected transactions to be paused with the stored message, got %v and %+v", paused, state)
+	}
+
+	// A failed read keeps the last value read
+	repo.failGet = true
+	now = now.Add(2 * time.Minute)
+	if _, paused := svc.Paused(); !paused {
+		t.Error("Expected the last value read to be used when the flag cannot be read")
+	}
+
+	// The pause lifts by itself at its end time
+	now = endsAt
+	if _, paused := svc.Paused(); paused {
+		t.Error("Expected transactions to resume at the pause's end time")
+	}
+}
+
+func TestMaintenanceService_UpdateState(t *testing.T) {
+	repo := &fakeMaintenanceRepo{}
+	svc := NewMaintenanceService(repo, time.Minute)
+	staffID := uuid.New()
+	change := models.MaintenanceChange{ActorID: staffID, RequestID: "req-1"}
+	enabled, disabled := true, false
+
+	past := time.Now().Add(-time.Minute)
+	var validationErr *ValidationError
+	if _, err := svc.UpdateState(models.UpdateMaintenanceRequest{Enabled: &enabled, EndsAt: &past}, change); !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error for an end time in the past, got %v", err)
+	}
+
+	state, err := svc.UpdateState(models.UpdateMaintenanceRequest{Enabled: &enabled, Message: " Database upgrade "}, change)
+	if err != nil {
+		t.Fatalf("UpdateState returned error: %v", err)
+	}
+	if !state.Enabled || state.Message != "Database upgrade" {
+		t.Errorf("Expected transactions to be paused with a trimmed message, got %+v", state)
+	}
+	if _, paused := svc.Paused(); !paused || repo.reads != 0 {
+		t.Errorf("Expected the change to apply on this replica without a read, got paused=%v after %d reads", paused, repo.reads)
+	}
+
+	state, err = svc.UpdateState(models.UpdateMaintenanceRequest{Enabled: &disabled, Message: "ignored"}, change)
+	if err != nil {
+		t.Fatalf("UpdateState returned error: %v", err)
+	}
+	if state.Enabled || state.Message != "" {
+		t.Errorf("Expected resuming to clear the message, got %+v", state)
+	}
+	if len(repo.changes) != 2 || repo.changes[1] != change {
+		t.Errorf("Expected both changes to be recorded as %+v, got %+v", change, repo.changes)
+	}
+}
+
+func TestMaintenanceService_EnableAtStartup(t *testing.T) {
+	repo := &fakeMaintenanceRepo{}
+	svc := NewMaintenanceService(repo, 0)
+
+	if err := svc.EnableAtStartup(""); err != nil {
+		t.Fatalf("EnableAtStartup returned error: %v", err)
+	}
+	if !repo.state.Enabled || len(repo.changes) != 1 || repo.changes[0].ActorID != uuid.Nil {
+		t.Fatalf("Expected transactions to be paused by the environment, got %+v and %+v", repo.state, repo.changes)
+	}
+
+	// Replicas starting later leave the pause as it is
+	if err := svc.EnableAtStartup("Another message"); err != nil {
+		t.Fatalf("EnableAtStartup returned error: %v", err)
+	}
+	if len(repo.changes) != 1 {
+		t.Errorf("Expected an existing pause to be kept, got %d changes", len(repo.changes))
+	}
+}
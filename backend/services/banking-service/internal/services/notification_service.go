@@ -0,0 +1,501 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+	"microbank/pkg/mailer"
+)
+
+const (
+	// DefaultNotificationBatchSize is how many transactions are looked at,
+	// and how many deliveries are claimed, at a time
+	DefaultNotificationBatchSize = 100
+	// MaxNotificationRetryBackoff is the longest a delivery waits between
+	// attempts
+	MaxNotificationRetryBackoff = time.Hour
+	// notificationLedgerLag is how far behind the newest transactions the
+	// ledger is read, so a transaction committed a little after a later
+	// one is not passed over
+	notificationLedgerLag = 30 * time.Second
+	// notificationLease is how long a claimed delivery is kept from other
+	// dispatchers
+	notificationLease = time.Minute
+)
+
+// errWebhookChanged fails webhook deliveries to a URL the account no
+// longer uses, without retrying them
+var errWebhookChanged = errors.New("webhook was removed or changed")
+
+// NotificationRecipientSource looks up where and how users want account
+// activity events delivered
+type NotificationRecipientSource interface {
+	GetNotificationRecipient(userID, event string) (models.NotificationRecipient, error)
+}
+
+// NotificationService notifies users of account activity. Dispatch reads
+// the transactions ledger in order, queueing a delivery on each channel
+// the user chose for large transactions and balances dropping below their
+// account's low balance threshold; Deliver sends the queued deliveries,
+// retrying failures with exponential backoff. Users are notified of at
+// most HourlyCap events an hour, and later ones are recorded as
+// suppressed. Channels without a sender are not queued.
+type NotificationService struct {
+	notificationRepo repository.NotificationRepository
+	transactionRepo  repository.TransactionRepository
+	accountRepo      repository.AccountRepository
+	memberRepo       repository.AccountMemberRepository
+	recipients       NotificationRecipientSource
+	rules            models.NotificationRules
+	email            mailer.EmailSender
+	sms              SMSSender
+	webhooks         WebhookSender
+	now              func() time.Time
+}
+
+// NewNotificationService creates a new notification service notifying the
+// users recipients describes as rules decide
+func NewNotificationService(notificationRepo repository.NotificationRepository, transactionRepo repository.TransactionRepository, accountRepo repository.AccountRepository, recipients NotificationRecipientSource, rules models.NotificationRules) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		transactionRepo:  transactionRepo,
+		accountRepo:      accountRepo,
+		recipients:       recipients,
+		rules:            rules,
+		now:              time.Now,
+	}
+}
+
+// WithEmail delivers notifications by email through sender
+func (s *NotificationService) WithEmail(sender mailer.EmailSender) *NotificationService {
+	s.email = sender
+	return s
+}
+
+// WithSMS delivers notifications by text message through sender
+func (s *NotificationService) WithSMS(sender SMSSender) *NotificationService {
+	s.sms = sender
+	return s
+}
+
+// WithWebhooks delivers notifications to accounts' webhooks through sender
+func (s *NotificationService) WithWebhooks(sender WebhookSender) *NotificationService {
+	s.webhooks = sender
+	return s
+}
+
+// WithJointAccounts lets owners of joint accounts manage their
+// notification settings
+func (s *NotificationService) WithJointAccounts(memberRepo repository.AccountMemberRepository) *NotificationService {
+	s.memberRepo = memberRepo
+	return s
+}
+
+// Settings returns the notification settings of the account the user
+// selects, as TransactionService.AccountFor does. Only its owners can see
+// them, since they include the webhook's secret.
+func (s *NotificationService) Settings(userID uuid.UUID, accountID *uuid.UUID) (*models.NotificationSettings, error) {
+	account, err := s.ownedAccount(userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.notificationRepo.GetSettings(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &models.NotificationSettings{AccountID: account.ID, UserID: account.UserID}
+	}
+	return settings, nil
+}
+
+// UpdateSettings replaces the notification settings of the account the
+// user selects. A webhook gets a new secret whenever its URL changes.
+func (s *NotificationService) UpdateSettings(userID uuid.UUID, accountID *uuid.UUID, request models.UpdateNotificationSettingsRequest) (*models.NotificationSettings, error) {
+	settings, err := s.Settings(userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings.LowBalanceThreshold = request.LowBalanceThreshold
+	if request.WebhookURL != settings.WebhookURL {
+		settings.WebhookURL = request.WebhookURL
+		settings.WebhookSecret = ""
+		if request.WebhookURL != "" {
+			if settings.WebhookSecret, err = generateWebhookSecret(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.notificationRepo.SaveSettings(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// Dispatch queues the notifications of the next transactions in the
+// ledger and returns how many deliveries it queued. The first call only
+// marks where the ledger ends, so past transactions are never notified.
+// When another replica dispatched the same transactions first, nothing is
+// queued.
+func (s *NotificationService) Dispatch(ctx context.Context) (int, error) {
+	cursor, err := s.notificationRepo.GetCursor()
+	if err != nil {
+		return 0, err
+	}
+	until := s.now().Add(-notificationLedgerLag)
+	if cursor == nil {
+		start := models.NotificationCursor{CreatedAt: until.Truncate(time.Microsecond)}
+		_, err := s.notificationRepo.Enqueue(nil, start, nil)
+		return 0, err
+	}
+
+	after := &models.Transaction{ID: cursor.TransactionID, CreatedAt: cursor.CreatedAt}
+	transactions, err := s.transactionRepo.GetTransactionsAfter(ctx, models.TransactionFilter{Until: &until}, after, DefaultNotificationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(transactions) == 0 {
+		return 0, nil
+	}
+
+	accountIDs := make([]uuid.UUID, 0, len(transactions))
+	for _, transaction := range transactions {
+		accountIDs = append(accountIDs, transaction.AccountID)
+	}
+	settings, err := s.notificationRepo.GetSettingsForAccounts(accountIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	batch := notificationBatch{service: s, settings: settings, since: s.now().Add(-time.Hour), notified: map[uuid.UUID]int{}}
+	for _, transaction := range transactions {
+		for _, event := range s.eventsFor(transaction, settings) {
+			if err := batch.add(event); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	last := transactions[len(transactions)-1]
+	next := models.NotificationCursor{CreatedAt: last.CreatedAt, TransactionID: last.ID}
+	moved, err := s.notificationRepo.Enqueue(cursor, next, batch.deliveries)
+	if err != nil || !moved {
+		return 0, err
+	}
+	return batch.queued, nil
+}
+
+// Deliver sends the deliveries that are due and returns how many were
+// sent. A failed delivery is tried again after a backoff doubling with
+// each attempt until it runs out of attempts.
+func (s *NotificationService) Deliver(ctx context.Context) (int, error) {
+	deliveries, err := s.notificationRepo.ClaimDue(notificationLease, DefaultNotificationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+
+		err := s.send(ctx, delivery)
+		if err == nil {
+			if err := s.notificationRepo.MarkSent(delivery.ID, s.now()); err != nil {
+				log.Printf("Failed to mark notification %s sent: %v", delivery.ID, err)
+			}
+			sent++
+			continue
+		}
+
+		if errors.Is(err, errWebhookChanged) || delivery.Attempts >= s.rules.MaxAttempts {
+			log.Printf("Notification %s by %s failed after %d attempts: %v", delivery.ID, delivery.Channel, delivery.Attempts, err)
+			if err := s.notificationRepo.Fail(delivery.ID, err.Error()); err != nil {
+				log.Printf("Failed to mark notification %s failed: %v", delivery.ID, err)
+			}
+			continue
+		}
+
+		next := s.now().Add(s.retryDelay(delivery.Attempts))
+		if err := s.notificationRepo.Retry(delivery.ID, err.Error(), next); err != nil {
+			log.Printf("Failed to retry notification %s: %v", delivery.ID, err)
+		}
+	}
+
+	return sent, nil
+}
+
+// notificationEvent is an account activity event a user is notified of
+type notificationEvent struct {
+	eventType   string
+	userID      uuid.UUID
+	transaction models.Transaction
+	threshold   float64
+}
+
+// eventsFor returns the events a transaction raises. Large transactions
+// are notified to the user who made them, and low balances to the
+// account's holder. Round-ups move no money, so raise none.
+func (s *NotificationService) eventsFor(transaction models.Transaction, settings map[uuid.UUID]models.NotificationSettings) []notificationEvent {
+	if transaction.Type == models.TransactionTypeRoundUp {
+		return nil
+	}
+
+	var events []notificationEvent
+	if transaction.Amount > s.rules.LargeTransactionThreshold {
+		events = append(events, notificationEvent{
+			eventType:   models.NotificationEventLargeTransaction,
+			userID:      transaction.UserID,
+			transaction: transaction,
+		})
+	}
+	account, ok := settings[transaction.AccountID]
+	if ok && account.LowBalanceThreshold != nil {
+		threshold := *account.LowBalanceThreshold
+		if transaction.BalanceBefore >= threshold && transaction.BalanceAfter < threshold {
+			events = append(events, notificationEvent{
+				eventType:   models.NotificationEventLowBalance,
+				userID:      account.UserID,
+				transaction: transaction,
+				threshold:   threshold,
+			})
+		}
+	}
+	return events
+}
+
+// notificationBatch collects the deliveries of the events of one batch of
+// transactions, counting each user's events against the hourly cap
+type notificationBatch struct {
+	service    *NotificationService
+	settings   map[uuid.UUID]models.NotificationSettings
+	since      time.Time
+	notified   map[uuid.UUID]int
+	deliveries []models.NotificationDelivery
+	queued     int
+}
+
+// add queues a delivery of event on each channel its user chose, or
+// records them suppressed once the user is over the hourly cap
+func (b *notificationBatch) add(event notificationEvent) error {
+	s := b.service
+	recipient, err := s.recipients.GetNotificationRecipient(event.userID.String(), event.eventType)
+	if err != nil {
+		return fmt.Errorf("failed to look up notification recipient: %w", err)
+	}
+	if !recipient.Exists {
+		return nil
+	}
+
+	transaction := event.transaction
+	destinations := map[models.NotificationChannel]string{}
+	if recipient.Channels.Email && recipient.Email != "" && s.email != nil {
+		destinations[models.NotificationChannelEmail] = recipient.Email
+	}
+	if recipient.Channels.SMS && recipient.PhoneNumber != "" && s.sms != nil {
+		destinations[models.NotificationChannelSMS] = recipient.PhoneNumber
+	}
+	if account := b.settings[transaction.AccountID]; recipient.Channels.Webhook && account.WebhookURL != "" && s.webhooks != nil {
+		destinations[models.NotificationChannelWebhook] = account.WebhookURL
+	}
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	notified, counted := b.notified[event.userID]
+	if !counted {
+		if notified, err = s.notificationRepo.CountNotifiedSince(event.userID, b.since); err != nil {
+			return err
+		}
+	}
+	status := models.NotificationDeliveryPending
+	if notified >= s.rules.HourlyCap {
+		status = models.NotificationDeliverySuppressed
+	} else {
+		notified++
+	}
+	b.notified[event.userID] = notified
+
+	data := models.NotificationData{
+		Name:            recipient.Name,
+		TransactionType: transaction.Type,
+		Amount:          transaction.Amount,
+		Balance:         transaction.BalanceAfter,
+		Threshold:       event.threshold,
+		OccurredAt:      transaction.CreatedAt,
+	}
+	for _, channel := range []models.NotificationChannel{models.NotificationChannelEmail, models.NotificationChannelSMS, models.NotificationChannelWebhook} {
+		destination, ok := destinations[channel]
+		if !ok {
+			continue
+		}
+		b.deliveries = append(b.deliveries, models.NotificationDelivery{
+			UserID:        event.userID,
+			AccountID:     transaction.AccountID,
+			TransactionID: transaction.ID,
+			EventType:     event.eventType,
+			Channel:       channel,
+			Destination:   destination,
+			Data:          data,
+			Status:        status,
+			NextAttemptAt: s.now(),
+		})
+		if status == models.NotificationDeliveryPending {
+			b.queued++
+		}
+	}
+	return nil
+}
+
+// send makes one attempt at a delivery
+func (s *NotificationService) send(ctx context.Context, delivery models.NotificationDelivery) error {
+	switch delivery.Channel {
+	case models.NotificationChannelEmail:
+		if s.email == nil {
+			return fmt.Errorf("no email sender")
+		}
+		msg, err := mailer.Templates.Render(delivery.EventType, notificationEmailData(delivery))
+		if err != nil {
+			return err
+		}
+		msg.To = delivery.Destination
+		msg.Metadata.UserID = delivery.UserID
+		return s.email.Send(ctx, msg)
+	case models.NotificationChannelSMS:
+		if s.sms == nil {
+			return fmt.Errorf("no SMS sender")
+		}
+		return s.sms.Send(delivery.Destination, notificationText(delivery))
+	case models.NotificationChannelWebhook:
+		if s.webhooks == nil {
+			return fmt.Errorf("no webhook sender")
+		}
+		return s.sendWebhook(ctx, delivery)
+	default:
+		return fmt.Errorf("unknown notification channel %q", delivery.Channel)
+	}
+}
+
+// sendWebhook posts a delivery to its account's webhook, signed with the
+// webhook's current secret. Deliveries queued for a URL the account no
+// longer uses fail with errWebhookChanged.
+func (s *NotificationService) sendWebhook(ctx context.Context, delivery models.NotificationDelivery) error {
+	settings, err := s.notificationRepo.GetSettings(delivery.AccountID)
+	if err != nil {
+		return err
+	}
+	if settings == nil || settings.WebhookURL != delivery.Destination {
+		return errWebhookChanged
+	}
+
+	payload := models.NotificationWebhookPayload{
+		ID:              delivery.ID,
+		Event:           delivery.EventType,
+		AccountID:       delivery.AccountID,
+		TransactionID:   delivery.TransactionID,
+		TransactionType: delivery.Data.TransactionType,
+		Amount:          models.Amount(delivery.Data.Amount),
+		Balance:         models.Amount(delivery.Data.Balance),
+		OccurredAt:      delivery.Data.OccurredAt.UTC(),
+	}
+	if delivery.Data.Threshold > 0 {
+		threshold := models.Amount(delivery.Data.Threshold)
+		payload.Threshold = &threshold
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	return s.webhooks.Send(ctx, settings.WebhookURL, settings.WebhookSecret, body)
+}
+
+// retryDelay is how long a delivery waits after its attempts-th failed
+// attempt
+func (s *NotificationService) retryDelay(attempts int) time.Duration {
+	delay := s.rules.RetryBackoff
+	for i := 1; i < attempts && delay < MaxNotificationRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > MaxNotificationRetryBackoff {
+		delay = MaxNotificationRetryBackoff
+	}
+	return delay
+}
+
+// ownedAccount returns the account a user selects, returning
+// ErrAccountOwnerRequired unless they are one of its owners
+func (s *NotificationService) ownedAccount(userID uuid.UUID, accountID *uuid.UUID) (*models.Account, error) {
+	account, member, err := memberAccount(s.accountRepo, s.memberRepo, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil && !member.IsOwner() {
+		return nil, ErrAccountOwnerRequired
+	}
+	return account, nil
+}
+
+// notificationEmailData is what the email templates of account activity
+// events are rendered with
+func notificationEmailData(delivery models.NotificationDelivery) map[string]string {
+	return map[string]string{
+		"Name":            delivery.Data.Name,
+		"TransactionType": transactionTypeName(delivery.Data.TransactionType),
+		"Amount":          models.Amount(delivery.Data.Amount).String(),
+		"Balance":         models.Amount(delivery.Data.Balance).String(),
+		"Threshold":       models.Amount(delivery.Data.Threshold).String(),
+		"Time":            delivery.Data.OccurredAt.UTC().Format("2 January 2006 15:04 MST"),
+		"Link":            transactionsURL(),
+	}
+}
+
+// notificationText is the text message of an account activity event
+func notificationText(delivery models.NotificationDelivery) string {
+	data := delivery.Data
+	if delivery.EventType == models.NotificationEventLowBalance {
+		return fmt.Sprintf("Microbank: your balance is %s after a %s of %s, below your %s alert.",
+			models.Amount(data.Balance), transactionTypeName(data.TransactionType), models.Amount(data.Amount), models.Amount(data.Threshold))
+	}
+	return fmt.Sprintf("Microbank: a %s of %s was made on your account. Your balance is %s. Not you? Contact us now.",
+		transactionTypeName(data.TransactionType), models.Amount(data.Amount), models.Amount(data.Balance))
+}
+
+// transactionTypeName is how a transaction type reads in a sentence, such
+// as "transfer out"
+func transactionTypeName(transactionType models.TransactionType) string {
+	return strings.ReplaceAll(string(transactionType), "_", " ")
+}
+
+// transactionsURL returns the frontend page that lists a user's
+// transactions
+func transactionsURL() string {
+	if url := os.Getenv("TRANSACTIONS_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000/transactions"
+}
+
+// generateWebhookSecret returns a random secret to sign webhook payloads
+// with
+func generateWebhookSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/mailer"
+)
+
+// fakeNotificationRepo keeps notification settings, the cursor and the
+// queue in memory. notified is what CountNotifiedSince returns.
+type fakeNotificationRepo struct {
+	settings   map[uuid.UUID]models.NotificationSettings
+	cursor     *models.NotificationCursor
+	deliveries []models.NotificationDelivery
+	notified   int
+}
+
+func newFakeNotificationRepo() *fakeNotificationRepo {
+	return &fakeNotificationRepo{settings: map[uuid.UUID]models.NotificationSettings{}}
+}
+
+func (r *fakeNotificationRepo) GetSettings(accountID uuid.UUID) (*models.NotificationSettings, error) {
+	settings, ok := r.settings[accountID]
+	if !ok {
+		return nil, nil
+	}
+	return &settings, nil
+}
+
+func (r *fakeNotificationRepo) GetSettingsForAccounts(accountIDs []uuid.UUID) (map[uuid.UUID]models.NotificationSettings, error) {
+	settings := map[uuid.UUID]models.NotificationSettings{}
+	for _, id := range accountIDs {
+		if account, ok := r.settings[id]; ok {
+			settings[id] = account
+		}
+	}
+	return settings, nil
+}
+
+func (r *fakeNotificationRepo) SaveSettings(settings *models.NotificationSettings) error {
+	r.settings[settings.AccountID] = *settings
+	return nil
+}
+
+func (r *fakeNotificationRepo) GetCursor() (*models.NotificationCursor, error) {
+	if r.cursor == nil {
+		return nil, nil
+	}
+	cursor := *r.cursor
+	return &cursor, nil
+}
+
+func (r *fakeNotificationRepo) Enqueue(from *models.NotificationCursor, next models.NotificationCursor, deliveries []models.NotificationDelivery) (bool, error) {
+	if (from == nil) != (r.cursor == nil) || (from != nil && *from != *r.cursor) {
+		return false, nil
+	}
+	r.cursor = &next
+	for _, delivery := range deliveries {
+		delivery.ID = uuid.New()
+		r.deliveries = append(r.deliveries, delivery)
+	}
+	return true, nil
+}
+
+func (r *fakeNotificationRepo) CountNotifiedSince(userID uuid.UUID, since time.Time) (int, error) {
+	return r.notified, nil
+}
+
+func (r *fakeNotificationRepo) ClaimDue(lease time.Duration, limit int) ([]models.NotificationDelivery, error) {
+	var claimed []models.NotificationDelivery
+	now := time.Now()
+	for i := range r.deliveries {
+		delivery := &r.deliveries[i]
+		if delivery.Status == models.NotificationDeliveryPending && !delivery.NextAttemptAt.After(now) && len(claimed) < limit {
+			delivery.Attempts++
+			delivery.NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, *delivery)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *fakeNotificationRepo) update(id uuid.UUID, fn func(*models.NotificationDelivery)) error {
+	for i := range r.deliveries {
+		if r.deliveries[i].ID == id {
+			fn(&r.deliveries[i])
+		}
+	}
+	return nil
+}
+
+func (r *fakeNotificationRepo) MarkSent(id uuid.UUID, at time.Time) error {
+	return r.update(id, func(delivery *models.NotificationDelivery) {
+		delivery.Status = models.NotificationDeliverySent
+		delivery.DeliveredAt = &at
+	})
+}
+
+func (r *fakeNotificationRepo) Retry(id uuid.UUID, reason string, next time.Time) error {
+	return r.update(id, func(delivery *models.NotificationDelivery) {
+		delivery.LastError = reason
+		delivery.NextAttemptAt = next
+	})
+}
+
+func (r *fakeNotificationRepo) Fail(id uuid.UUID, reason string) error {
+	return r.update(id, func(delivery *models.NotificationDelivery) {
+		delivery.Status = models.NotificationDeliveryFailed
+		delivery.LastError = reason
+	})
+}
+
+// fakeNotificationRecipients answers recipient lookups by user and event
+type fakeNotificationRecipients map[string]models.NotificationRecipient
+
+func (f fakeNotificationRecipients) GetNotificationRecipient(userID, event string) (models.NotificationRecipient, error) {
+	return f[userID+"/"+event], nil
+}
+
+// recordingEmailSender keeps the emails it is asked to send, failing them
+// all with err when it is set
+type recordingEmailSender struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (s *recordingEmailSender) Send(ctx context.Context, msg mailer.Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+// recordingSMSSender keeps the text messages it is asked to send
+type recordingSMSSender struct {
+	sent []string
+}
+
+func (s *recordingSMSSender) Send(to, message string) error {
+	s.sent = append(s.sent, to+": "+message)
+	return nil
+}
+
+func TestNotificationService_QueuesLargeTransactionsAndLowBalances(t *testing.T) {
+	userID, accountID := uuid.New(), uuid.New()
+	threshold := 600.0
+	repo := newFakeNotificationRepo()
+	repo.cursor = &models.NotificationCursor{}
+	repo.settings[accountID] = models.NotificationSettings{AccountID: accountID, UserID: userID, LowBalanceThreshold: &threshold, WebhookURL: "https://hooks.example.com/microbank", WebhookSecret: "secret"}
+	transactions := &pagedTransactionRepo{transactions: []models.Transaction{
+		{ID: uuid.New(), AccountID: accountID, UserID: userID, Type: models.TransactionTypeWithdrawal, Amount: 1500, BalanceBefore: 2000, BalanceAfter: 500},
+		{ID: uuid.New(), AccountID: accountID, UserID: userID, Type: models.TransactionTypeDeposit, Amount: 50, BalanceBefore: 500, BalanceAfter: 550},
+		{ID: uuid.New(), AccountID: accountID, UserID: userID, Type: models.TransactionTypeRoundUp, Amount: 2000, BalanceBefore: 550, BalanceAfter: 550},
+	}}
+	recipients := fakeNotificationRecipients{
+		userID.String() + "/large_transaction": {UserID: userID.String(), Exists: true, Email: "user@example.com", Name: "Ada", PhoneNumber: "+15551234567", Channels: models.NotificationChannels{Email: true, SMS: true}},
+		userID.String() + "/low_balance":       {UserID: userID.String(), Exists: true, Email: "user@example.com", Name: "Ada", Channels: models.NotificationChannels{Email: true, SMS: true, Webhook: true}},
+	}
+	service := NewNotificationService(repo, transactions, nil, recipients, models.NotificationRules{LargeTransactionThreshold: 1000, HourlyCap: 10, MaxAttempts: 3, RetryBackoff: time.Minute}).
+		WithEmail(&recordingEmailSender{}).
+		WithSMS(&recordingSMSSender{}).
+		WithWebhooks(NewHTTPWebhookSender(DefaultWebhookTimeout))
+
+	queued, err := service.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch returned error: %v", err)
+	}
+
+	// The withdrawal is large and drops the balance below 600; SMS of the
+	// low balance is left out for want of a verified phone number
+	var got []string
+	for _, delivery := range repo.deliveries {
+		got = append(got, delivery.EventType+"/"+string(delivery.Channel)+"/"+delivery.Destination)
+	}
+	want := []string{
+		"large_transaction/email/user@example.com",
+		"large_transaction/sms/+15551234567",
+		"low_balance/email/user@example.com",
+		"low_balance/webhook/https://hooks.example.com/microbank",
+	}
+	if queued != 4 || strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("Expected %v queued, got %d: %v", want, queued, got)
+	}
+	if data := repo.deliveries[2].Data; data.Name != "Ada" || data.Threshold != 600 || data.Balance != 500 {
+		t.Errorf("Expected the low balance to be described, got %+v", data)
+	}
+	if until := transactions.filter.Until; until == nil || time.Since(*until) < notificationLedgerLag {
+		t.Errorf("Expected the newest transactions to be left for later, got until %v", until)
+	}
+
+	// The cursor moved past the round-up, so nothing is queued twice
+	if repo.cursor.TransactionID != transactions.transactions[2].ID {
+		t.Errorf("Expected the cursor at the last transaction, got %+v", repo.cursor)
+	}
+	if queued, err := service.Dispatch(context.Background()); err != nil || queued != 0 || len(repo.deliveries) != 4 {
+		t.Errorf("Expected nothing new to be queued, got %d (%v)", queued, err)
+	}
+}
+
+func TestNotificationService_StartsAtTheEndOfTheLedger(t *testing.T) {
+	repo := newFakeNotificationRepo()
+	transactions := &pagedTransactionRepo{transactions: []models.Transaction{{ID: uuid.New(), Amount: 5000}}}
+	service := NewNotificationService(repo, transactions, nil, fakeNotificationRecipients{}, models.NotificationRules{LargeTransactionThreshold: 1000, HourlyCap: 10, MaxAttempts: 3})
+
+	if queued, err := service.Dispatch(context.Background()); err != nil || queued != 0 {
+		t.Fatalf("Expected nothing to be queued on the first run, got %d (%v)", queued, err)
+	}
+	if repo.cursor == nil || transactions.pages != 0 {
+		t.Errorf("Expected the cursor to be set without reading the ledger, got %+v after %d reads", repo.cursor, transactions.pages)
+	}
+}
+
+func TestNotificationService_SuppressesNotificationsOverHourlyCap(t *testing.T) {
+	userID := uuid.New()
+	repo := newFakeNotificationRepo()
+	repo.cursor = &models.NotificationCursor{}
+	repo.notified = 1
+	transactions := &pagedTransactionRepo{transactions: []models.Transaction{
+		{ID: uuid.New(), AccountID: uuid.New(), UserID: userID, Type: models.TransactionTypeWithdrawal, Amount: 1500},
+		{ID: uuid.New(), AccountID: uuid.New(), UserID: userID, Type: models.TransactionTypeWithdrawal, Amount: 2500},
+	}}
+	recipients := fakeNotificationRecipients{
+		userID.String() + "/large_transaction": {UserID: userID.String(), Exists: true, Email: "user@example.com", Channels: models.NotificationChannels{Email: true}},
+	}
+	service := NewNotificationService(repo, transactions, nil, recipients, models.NotificationRules{LargeTransactionThreshold: 1000, HourlyCap: 2, MaxAttempts: 3}).
+		WithEmail(&recordingEmailSender{})
+
+	queued, err := service.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch returned error: %v", err)
+	}
+	if queued != 1 || len(repo.deliveries) != 2 {
+		t.Fatalf("Expected one of two notifications queued, got %d of %d", queued, len(repo.deliveries))
+	}
+	if repo.deliveries[0].Status != models.NotificationDeliveryPending || repo.deliveries[1].Status != models.NotificationDeliverySuppressed {
+		t.Errorf("Expected the second notification over the cap to be suppressed, got %s and %s", repo.deliveries[0].Status, repo.deliveries[1].Status)
+	}
+}
+
+func TestNotificationService_DeliversAndRetriesWithBackoff(t *testing.T) {
+	var signature, timestamp string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature, timestamp = r.Header.Get(WebhookSignatureHeader), r.Header.Get(WebhookTimestampHeader)
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	userID, accountID := uuid.New(), uuid.New()
+	repo := newFakeNotificationRepo()
+	repo.settings[accountID] = models.NotificationSettings{AccountID: accountID, UserID: userID, WebhookURL: server.URL, WebhookSecret: "secret"}
+	data := models.NotificationData{Name: "Ada", TransactionType: models.TransactionTypeTransferOut, Amount: 1500, Balance: 250, OccurredAt: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)}
+	for _, channel := range []models.NotificationChannel{models.NotificationChannelEmail, models.NotificationChannelWebhook} {
+		destination := "user@example.com"
+		if channel == models.NotificationChannelWebhook {
+			destination = server.URL
+		}
+		repo.deliveries = append(repo.deliveries, models.NotificationDelivery{
+			ID:            uuid.New(),
+			UserID:        userID,
+			AccountID:     accountID,
+			EventType:     models.NotificationEventLargeTransaction,
+			Channel:       channel,
+			Destination:   destination,
+			Data:          data,
+			Status:        models.NotificationDeliveryPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+	email := &recordingEmailSender{err: errors.New("connection refused")}
+	service := NewNotificationService(repo, nil, nil, nil, models.NotificationRules{MaxAttempts: 2, RetryBackoff: time.Minute}).
+		WithEmail(email).
+		WithWebhooks(NewHTTPWebhookSender(DefaultWebhookTimeout))
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	sent, err := service.Deliver(context.Background())
+	if err != nil || sent != 1 {
+		t.Fatalf("Expected the webhook to be sent, got %d (%v)", sent, err)
+	}
+
+	// The webhook is signed with the account's secret
+	if signature == "" || signature != SignWebhook("secret", timestamp, body) {
+		t.Errorf("Expected a valid signature, got %q", signature)
+	}
+	var payload models.NotificationWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Event != models.NotificationEventLargeTransaction || payload.Amount.Float64() != 1500 || payload.ID != repo.deliveries[1].ID {
+		t.Errorf("Expected the large transfer in the payload, got %s (%v)", body, err)
+	}
+	if repo.deliveries[1].Status != models.NotificationDeliverySent {
+		t.Errorf("Expected the webhook delivery to be sent, got %s", repo.deliveries[1].Status)
+	}
+
+	// The email failed, so is tried again after the backoff
+	if delivery := repo.deliveries[0]; delivery.Status != models.NotificationDeliveryPending || !delivery.NextAttemptAt.Equal(now.Add(time.Minute)) || delivery.LastError != "connection refused" {
+		t.Fatalf("Expected the email to be retried in 1m, got %+v", delivery)
+	}
+
+	// and fails for good once it runs out of attempts
+	repo.deliveries[0].NextAttemptAt = time.Now()
+	if _, err := service.Deliver(context.Background()); err != nil {
+		t.Fatalf("Deliver returned error: %v", err)
+	}
+	if delivery := repo.deliveries[0]; delivery.Status != models.NotificationDeliveryFailed || delivery.Attempts != 2 {
+		t.Errorf("Expected the email to fail after 2 attempts, got %+v", delivery)
+	}
+
+	// Once delivered, the email reads as the template says
+	email.err = nil
+	repo.deliveries[0].Status = models.NotificationDeliveryPending
+	repo.deliveries[0].NextAttemptAt = time.Now()
+	if sent, err := service.Deliver(context.Background()); err != nil || sent != 1 {
+		t.Fatalf("Expected the email to be sent, got %d (%v)", sent, err)
+	}
+	if msg := email.sent[0]; msg.To != "user@example.com" || !strings.Contains(msg.Text, "transfer out of 1500.00 USD") || !strings.Contains(msg.Text, "1 March 2024 09:30 UTC") {
+		t.Errorf("Expected the large transfer to be described, got %+v", msg)
+	}
+}
+
+func TestNotificationService_UpdateSettings(t *testing.T) {
+	userID := uuid.New()
+	account := &models.Account{ID: uuid.New(), UserID: userID}
+	repo := newFakeNotificationRepo()
+	service := NewNotificationService(repo, nil, &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: account}}}, nil, models.NotificationRules{})
+
+	settings, err := service.Settings(userID, nil)
+	if err != nil || settings.AccountID != account.ID || settings.LowBalanceThreshold != nil || settings.WebhookURL != "" {
+		t.Fatalf("Expected no settings yet, got %+v (%v)", settings, err)
+	}
+
+	threshold := 100.0
+	settings, err = service.UpdateSettings(userID, nil, models.UpdateNotificationSettingsRequest{LowBalanceThreshold: &threshold, WebhookURL: "https://hooks.example.com/a"})
+	if err != nil || *settings.LowBalanceThreshold != 100 || len(settings.WebhookSecret) != 64 {
+		t.Fatalf("Expected a threshold and a webhook with a secret, got %+v (%v)", settings, err)
+	}
+	secret := settings.WebhookSecret
+
+	// The secret is kept while the URL is, and replaced when it changes
+	settings, _ = service.UpdateSettings(userID, nil, models.UpdateNotificationSettingsRequest{WebhookURL: "https://hooks.example.com/a"})
+	if settings.WebhookSecret != secret || settings.LowBalanceThreshold != nil {
+		t.Errorf("Expected the secret kept and the threshold cleared, got %+v", settings)
+	}
+	settings, _ = service.UpdateSettings(userID, nil, models.UpdateNotificationSettingsRequest{WebhookURL: "https://hooks.example.com/b"})
+	if settings.WebhookSecret == secret || settings.WebhookSecret == "" {
+		t.Errorf("Expected a new secret for the new URL, got %+v", settings)
+	}
+	settings, _ = service.UpdateSettings(userID, nil, models.UpdateNotificationSettingsRequest{})
+	if settings.WebhookURL != "" || settings.WebhookSecret != "" {
+		t.Errorf("Expected the webhook removed, got %+v", settings)
+	}
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// Promotion list paging limits
+const (
+	DefaultPromotionPageSize = 50
+	MaxPromotionPageSize     = 200
+)
+
+// PromotionService manages the promotions staff run and pays their
+// bonuses. Deposits made through TransactionService.ProcessDeposit are
+// checked against every active promotion once they are saved.
+type PromotionService struct {
+	promotionRepo repository.PromotionRepository
+	now           func() time.Time
+}
+
+// NewPromotionService creates a new promotion service
+func NewPromotionService(promotionRepo repository.PromotionRepository) *PromotionService {
+	return &PromotionService{
+		promotionRepo: promotionRepo,
+		now:           time.Now,
+	}
+}
+
+// CreatePromotion creates a promotion on behalf of the staff member
+// staffID
+func (s *PromotionService) CreatePromotion(staffID uuid.UUID, request models.CreatePromotionRequest) (*models.Promotion, error) {
+	promotion := &models.Promotion{
+		ID:          uuid.New(),
+		Name:        strings.TrimSpace(request.Name),
+		Description: strings.TrimSpace(request.Description),
+		MinDeposit:  request.MinDeposit,
+		BonusAmount: request.BonusAmount,
+		StartsAt:    request.StartsAt,
+		EndsAt:      request.EndsAt,
+		OncePerUser: request.OncePerUser == nil || *request.OncePerUser,
+		CreatedBy:   staffID,
+	}
+	if err := validatePromotion(promotion); err != nil {
+		return nil, err
+	}
+
+	if err := s.promotionRepo.Create(promotion); err != nil {
+		return nil, fmt.Errorf("failed to create promotion: %w", err)
+	}
+
+	return promotion, nil
+}
+
+// ListPromotions returns one page of promotions matching the filter,
+// latest starting first
+func (s *PromotionService) ListPromotions(filter models.PromotionFilter) (*models.PromotionPage, error) {
+	if filter.Status != "" && !models.IsPromotionStatus(string(filter.Status)) {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "status", Rule: "oneof", Message: "must be scheduled, active or ended"}}}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPromotionPageSize
+	}
+	if filter.Limit > MaxPromotionPageSize {
+		filter.Limit = MaxPromotionPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	filter.At = s.now()
+
+	promotions, total, err := s.promotionRepo.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list promotions: %w", err)
+	}
+	if promotions == nil {
+		promotions = []models.Promotion{}
+	}
+
+	return &models.PromotionPage{Promotions: promotions, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// GetPromotion returns a promotion
+func (s *PromotionService) GetPromotion(promotionID uuid.UUID) (*models.Promotion, error) {
+	promotion, err := s.promotionRepo.GetByID(promotionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promotion: %w", err)
+	}
+	if promotion == nil {
+		return nil, ErrPromotionNotFound
+	}
+	return promotion, nil
+}
+
+// UpdatePromotion changes a promotion. Deposits made from then on are
+// checked against the new criteria; bonuses already paid are kept.
+func (s *PromotionService) UpdatePromotion(promotionID uuid.UUID, request models.UpdatePromotionRequest) (*models.Promotion, error) {
+	promotion, err := s.GetPromotion(promotionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Name != nil {
+		promotion.Name = strings.TrimSpace(*request.Name)
+	}
+	if request.Description != nil {
+		promotion.Description = strings.TrimSpace(*request.Description)
+	}
+	if request.MinDeposit != nil {
+		promotion.MinDeposit = *request.MinDeposit
+	}
+	if request.BonusAmount != nil {
+		promotion.BonusAmount = *request.BonusAmount
+	}
+	if request.StartsAt != nil {
+		promotion.StartsAt = *request.StartsAt
+	}
+	if request.EndsAt != nil {
+		promotion.EndsAt = *request.EndsAt
+	}
+	if request.OncePerUser != nil {
+		promotion.OncePerUser = *request.OncePerUser
+	}
+	if err := validatePromotion(promotion); err != nil {
+		return nil, err
+	}
+
+	if err := s.promotionRepo.Update(promotion); err != nil {
+		return nil, fmt.Errorf("failed to update promotion: %w", err)
+	}
+
+	return promotion, nil
+}
+
+// DeletePromotion deletes a promotion that never paid a bonus. Promotions
+// that did are kept with their redemptions, and return
+// ErrPromotionRedeemed; end them by moving ends_at instead.
+func (s *PromotionService) DeletePromotion(promotionID uuid.UUID) error {
+	if _, err := s.GetPromotion(promotionID); err != nil {
+		return err
+	}
+
+	deleted, err := s.promotionRepo.Delete(promotionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete promotion: %w", err)
+	}
+	if !deleted {
+		return ErrPromotionRedeemed
+	}
+
+	return nil
+}
+
+// ListForUser returns the active promotions with whether the user earned
+// each one's bonus and can still earn it
+func (s *PromotionService) ListForUser(userID uuid.UUID) ([]models.UserPromotion, error) {
+	now := s.now()
+	promotions, err := s.promotionRepo.ListActive(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active promotions: %w", err)
+	}
+	redemptions, err := s.promotionRepo.ListRedemptionsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list promotion redemptions: %w", err)
+	}
+
+	result := make([]models.UserPromotion, 0, len(promotions))
+	for _, promotion := range promotions {
+		entry := models.UserPromotion{
+			ID:          promotion.ID,
+			Name:        promotion.Name,
+			Description: promotion.Description,
+			MinDeposit:  models.Amount(promotion.MinDeposit),
+			BonusAmount: models.Amount(promotion.BonusAmount),
+			StartsAt:    promotion.StartsAt,
+			EndsAt:      promotion.EndsAt,
+			OncePerUser: promotion.OncePerUser,
+		}
+		// Redemptions are newest first
+		for _, redemption := range redemptions {
+			if redemption.PromotionID != promotion.ID {
+				continue
+			}
+			if entry.LastRedeemedAt == nil {
+				redeemedAt := redemption.CreatedAt
+				entry.LastRedeemedAt = &redeemedAt
+			}
+			entry.RedemptionCount++
+		}
+		entry.Redeemed = entry.RedemptionCount > 0
+		entry.Eligible = !promotion.OncePerUser || !entry.Redeemed
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
+
+// Evaluate pays the bonus of every active promotion the saved deposit
+// qualifies for, each as a bonus transaction linked to the deposit, and
+// returns the bonuses paid. Promotions the deposit or, for one-time
+// promotions, the user already earned are skipped, even when deposits
+// race for them. A bonus that cannot be paid does not keep the others
+// from being paid; their errors are returned together.
+func (s *PromotionService) Evaluate(deposit *models.Transaction) ([]models.Transaction, error) {
+	promotions, err := s.promotionRepo.ListActive(deposit.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active promotions: %w", err)
+	}
+
+	var bonuses []models.Transaction
+	var errs []error
+	for _, promotion := range promotions {
+		if !promotion.Qualifies(deposit.Amount, deposit.CreatedAt) {
+			continue
+		}
+
+		bonus := &models.Transaction{
+			ID:                   uuid.New(),
+			AccountID:            deposit.AccountID,
+			UserID:               deposit.UserID,
+			Type:                 models.TransactionTypeBonus,
+			Amount:               promotion.BonusAmount,
+			RelatedTransactionID: &deposit.ID,
+			CreatedAt:            s.now(),
+		}
+		bonus.Description, bonus.RawDescription = sanitizeDescription("Bonus: "+promotion.Name, DefaultDescriptionLength)
+		redemption := &models.PromotionRedemption{
+			ID:                   uuid.New(),
+			PromotionID:          promotion.ID,
+			UserID:               deposit.UserID,
+			AccountID:            deposit.AccountID,
+			DepositTransactionID: deposit.ID,
+			OncePerUser:          promotion.OncePerUser,
+		}
+
+		redeemed, err := s.promotionRepo.Redeem(redemption, bonus)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to pay bonus of promotion %s: %w", promotion.ID, err))
+			continue
+		}
+		if redeemed {
+			bonuses = append(bonuses, *bonus)
+		}
+	}
+
+	return bonuses, errors.Join(errs...)
+}
+
+// validatePromotion checks a promotion's name and window. Amounts are
+// checked when the request is bound.
+func validatePromotion(promotion *models.Promotion) error {
+	var fields []FieldError
+	if promotion.Name == "" {
+		fields = append(fields, FieldError{Field: "name", Rule: "required", Message: "must not be blank"})
+	}
+	if !promotion.EndsAt.After(promotion.StartsAt) {
+		fields = append(fields, FieldError{Field: "ends_at", Rule: "gtfield", Message: "must be after starts_at"})
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
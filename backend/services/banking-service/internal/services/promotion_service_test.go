@@ -0,0 +1,245 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// fakePromotionRepo keeps promotions and redemptions in memory, refusing
+// redemptions the database's unique indexes would
+type fakePromotionRepo struct {
+	promotions  map[uuid.UUID]*models.Promotion
+	redemptions []models.PromotionRedemption
+	credited    []models.Transaction
+}
+
+func (r *fakePromotionRepo) Create(promotion *models.Promotion) error {
+	clone := *promotion
+	r.promotions[promotion.ID] = &clone
+	return nil
+}
+
+func (r *fakePromotionRepo) GetByID(id uuid.UUID) (*models.Promotion, error) {
+	promotion, ok := r.promotions[id]
+	if !ok {
+		return nil, nil
+	}
+	clone := *promotion
+	return &clone, nil
+}
+
+func (r *fakePromotionRepo) List(filter models.PromotionFilter) ([]models.Promotion, int, error) {
+	var promotions []models.Promotion
+	for _, promotion := range r.promotions {
+		if filter.Status == "" || promotion.StatusAt(filter.At) == filter.Status {
+			promotions = append(promotions, *promotion)
+		}
+	}
+	return promotions, len(promotions), nil
+}
+
+func (r *fakePromotionRepo) ListActive(at time.Time) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	for _, promotion := range r.promotions {
+		if promotion.ActiveAt(at) {
+			promotions = append(promotions, *promotion)
+		}
+	}
+	return promotions, nil
+}
+
+func (r *fakePromotionRepo) Update(promotion *models.Promotion) error {
+	return r.Create(promotion)
+}
+
+func (r *fakePromotionRepo) Delete(id uuid.UUID) (bool, error) {
+	for _, redemption := range r.redemptions {
+		if redemption.PromotionID == id {
+			return false, nil
+		}
+	}
+	delete(r.promotions, id)
+	return true, nil
+}
+
+func (r *fakePromotionRepo) ListRedemptionsByUserID(userID uuid.UUID) ([]models.PromotionRedemption, error) {
+	var redemptions []models.PromotionRedemption
+	for i := len(r.redemptions) - 1; i >= 0; i-- {
+		if r.redemptions[i].UserID == userID {
+			redemptions = append(redemptions, r.redemptions[i])
+		}
+	}
+	return redemptions, nil
+}
+
+func (r *fakePromotionRepo) Redeem(redemption *models.PromotionRedemption, bonus *models.Transaction) (bool, error) {
+	for _, existing := range r.redemptions {
+		if existing.PromotionID != redemption.PromotionID {
+			continue
+		}
+		if existing.DepositTransactionID == redemption.DepositTransactionID || (redemption.OncePerUser && existing.OncePerUser && existing.UserID == redemption.UserID) {
+			return false, nil
+		}
+	}
+	redemption.BonusTransactionID = bonus.ID
+	redemption.Amount = bonus.Amount
+	r.redemptions = append(r.redemptions, *redemption)
+	r.credited = append(r.credited, *bonus)
+	return true, nil
+}
+
+// promotionFixture is a promotion service on a clock in the middle of
+// October 2026
+type promotionFixture struct {
+	repo  *fakePromotionRepo
+	svc   *PromotionService
+	clock time.Time
+}
+
+func newPromotionFixture() *promotionFixture {
+	f := &promotionFixture{clock: time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)}
+	f.repo = &fakePromotionRepo{promotions: map[uuid.UUID]*models.Promotion{}}
+	f.svc = NewPromotionService(f.repo)
+	f.svc.now = func() time.Time { return f.clock }
+	return f
+}
+
+// create creates a promotion for October paying bonus on deposits of at
+// least minDeposit
+func (f *promotionFixture) create(t *testing.T, minDeposit, bonus float64, oncePerUser bool) *models.Promotion {
+	t.Helper()
+	promotion, err := f.svc.CreatePromotion(uuid.New(), models.CreatePromotionRequest{
+		Name:        "October deposits",
+		MinDeposit:  minDeposit,
+		BonusAmount: bonus,
+		StartsAt:    time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC),
+		EndsAt:      time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC),
+		OncePerUser: &oncePerUser,
+	})
+	if err != nil {
+		t.Fatalf("CreatePromotion returned error: %v", err)
+	}
+	return promotion
+}
+
+// promotionDeposit is a saved deposit by userID
+func promotionDeposit(userID uuid.UUID, amount float64, at time.Time) *models.Transaction {
+	return &models.Transaction{ID: uuid.New(), AccountID: uuid.New(), UserID: userID, Type: models.TransactionTypeDeposit, Amount: amount, CreatedAt: at}
+}
+
+func TestPromotionService_EvaluatePaysOncePerUser(t *testing.T) {
+	f := newPromotionFixture()
+	promotion := f.create(t, 100, 5, true)
+	user := uuid.New()
+
+	if bonuses, err := f.svc.Evaluate(promotionDeposit(user, 99.99, f.clock)); err != nil || len(bonuses) != 0 {
+		t.Errorf("Expected no bonus below the minimum deposit, got %+v (%v)", bonuses, err)
+	}
+	if bonuses, err := f.svc.Evaluate(promotionDeposit(user, 100, promotion.EndsAt)); err != nil || len(bonuses) != 0 {
+		t.Errorf("Expected no bonus once the promotion ended, got %+v (%v)", bonuses, err)
+	}
+
+	qualifying := promotionDeposit(user, 100, f.clock)
+	bonuses, err := f.svc.Evaluate(qualifying)
+	if err != nil {
+		t.Fatalf("Evaluate returned error: %v", err)
+	}
+	if len(bonuses) != 1 || bonuses[0].Type != models.TransactionTypeBonus || bonuses[0].Amount != 5 || *bonuses[0].RelatedTransactionID != qualifying.ID || bonuses[0].AccountID != qualifying.AccountID {
+		t.Fatalf("Expected a bonus of 5 linked to the deposit, got %+v", bonuses)
+	}
+	if bonuses[0].Description != "Bonus: October deposits" {
+		t.Errorf("Expected the bonus to be described by the promotion, got %q", bonuses[0].Description)
+	}
+
+	// The user earned it; other users still can
+	if bonuses, err := f.svc.Evaluate(promotionDeposit(user, 250, f.clock)); err != nil || len(bonuses) != 0 {
+		t.Errorf("Expected no second bonus, got %+v (%v)", bonuses, err)
+	}
+	if bonuses, err := f.svc.Evaluate(promotionDeposit(uuid.New(), 100, f.clock)); err != nil || len(bonuses) != 1 {
+		t.Errorf("Expected another user to earn the bonus, got %+v (%v)", bonuses, err)
+	}
+	if len(f.repo.credited) != 2 {
+		t.Errorf("Expected two bonuses credited, got %d", len(f.repo.credited))
+	}
+
+	promotions, err := f.svc.ListForUser(user)
+	if err != nil {
+		t.Fatalf("ListForUser returned error: %v", err)
+	}
+	if len(promotions) != 1 || !promotions[0].Redeemed || promotions[0].Eligible || promotions[0].RedemptionCount != 1 {
+		t.Errorf("Expected the promotion to show as redeemed, got %+v", promotions)
+	}
+	if promotions, _ := f.svc.ListForUser(uuid.New()); len(promotions) != 1 || promotions[0].Redeemed || !promotions[0].Eligible {
+		t.Errorf("Expected the promotion to be open to a new user, got %+v", promotions)
+	}
+}
+
+func TestPromotionService_EvaluateRepeatable(t *testing.T) {
+	f := newPromotionFixture()
+	f.create(t, 50, 1, false)
+	user := uuid.New()
+
+	first := promotionDeposit(user, 50, f.clock)
+	for _, d := range []*models.Transaction{first, promotionDeposit(user, 75, f.clock), first} {
+		f.svc.Evaluate(d)
+	}
+	if len(f.repo.credited) != 2 {
+		t.Errorf("Expected a bonus on each deposit but only one per deposit, got %d", len(f.repo.credited))
+	}
+
+	if promotions, _ := f.svc.ListForUser(user); len(promotions) != 1 || promotions[0].RedemptionCount != 2 || !promotions[0].Eligible {
+		t.Errorf("Expected the promotion to stay open after two bonuses, got %+v", promotions)
+	}
+}
+
+func TestPromotionService_CreateAndDelete(t *testing.T) {
+	f := newPromotionFixture()
+
+	_, err := f.svc.CreatePromotion(uuid.New(), models.CreatePromotionRequest{Name: "  ", MinDeposit: 100, BonusAmount: 5, StartsAt: f.clock, EndsAt: f.clock})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 2 {
+		t.Errorf("Expected a blank name and an empty window to be refused, got %v", err)
+	}
+
+	promotion, err := f.svc.CreatePromotion(uuid.New(), models.CreatePromotionRequest{Name: "Welcome", MinDeposit: 100, BonusAmount: 5, StartsAt: f.clock, EndsAt: f.clock.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("CreatePromotion returned error: %v", err)
+	}
+	if !promotion.OncePerUser {
+		t.Error("Expected promotions to pay once per user by default")
+	}
+
+	if _, err := f.svc.ListPromotions(models.PromotionFilter{Status: "paused"}); !errors.As(err, &validationErr) {
+		t.Errorf("Expected an unknown status to be refused, got %v", err)
+	}
+
+	f.svc.Evaluate(promotionDeposit(uuid.New(), 100, f.clock))
+	if err := f.svc.DeletePromotion(promotion.ID); !errors.Is(err, ErrPromotionRedeemed) {
+		t.Errorf("Expected a promotion that paid a bonus to be kept, got %v", err)
+	}
+
+	// Ending it early stops further bonuses
+	endsAt := f.clock
+	if _, err := f.svc.UpdatePromotion(promotion.ID, models.UpdatePromotionRequest{EndsAt: &endsAt}); err == nil {
+		t.Error("Expected ends_at to stay after starts_at")
+	}
+	endsAt = f.clock.Add(time.Second)
+	if _, err := f.svc.UpdatePromotion(promotion.ID, models.UpdatePromotionRequest{EndsAt: &endsAt}); err != nil {
+		t.Fatalf("UpdatePromotion returned error: %v", err)
+	}
+	if bonuses, _ := f.svc.Evaluate(promotionDeposit(uuid.New(), 100, endsAt)); len(bonuses) != 0 {
+		t.Errorf("Expected no bonus after the promotion ended, got %+v", bonuses)
+	}
+
+	unused := f.create(t, 10, 1, true)
+	if err := f.svc.DeletePromotion(unused.ID); err != nil {
+		t.Errorf("DeletePromotion returned error: %v", err)
+	}
+	if _, err := f.svc.GetPromotion(unused.ID); !errors.Is(err, ErrPromotionNotFound) {
+		t.Errorf("Expected ErrPromotionNotFound after deleting, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// Reconciliation mismatch paging limits
+const (
+	DefaultMismatchPageSize = 50
+	MaxMismatchPageSize     = 200
+)
+
+// reconciliationBatchSize is how many accounts each database transaction
+// of a run checks
+const reconciliationBatchSize = 500
+
+// ReconciliationService runs the daily check that every account's stored
+// balance matches its transaction history, and that all balances together
+// match what all transactions add up to
+type ReconciliationService struct {
+	repo      repository.ReconciliationRepository
+	batchSize int
+	now       func() time.Time
+}
+
+// NewReconciliationService creates a new reconciliation service
+func NewReconciliationService(repo repository.ReconciliationRepository) *ReconciliationService {
+	return &ReconciliationService{
+		repo:      repo,
+		batchSize: reconciliationBatchSize,
+		now:       time.Now,
+	}
+}
+
+// RunDue carries on with the latest run if it was interrupted, or starts
+// today's run if it has not been started, and checks accounts batch by
+// batch until every one is checked. It returns the run when this call
+// completed it, and nil when there was nothing to do. Each batch is
+// committed on its own, so when ctx is cancelled the run stops after the
+// current batch and the next call carries on from there.
+func (s *ReconciliationService) RunDue(ctx context.Context) (*models.ReconciliationRun, error) {
+	run, err := s.repo.GetLatestRun()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest reconciliation run: %w", err)
+	}
+	today := startOfDay(s.now())
+	if run == nil || (run.Status == models.ReconciliationStatusCompleted && run.RunDate.Before(today)) {
+		run, err = s.repo.StartRun(today)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if run.Status != models.ReconciliationStatusRunning {
+		return nil, nil
+	}
+
+	for done := false; !done; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		run, done, err = s.repo.CheckBatch(run.ID, s.batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check reconciliation batch: %w", err)
+		}
+	}
+
+	run, finished, err := s.repo.FinishRun(run.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish reconciliation run: %w", err)
+	}
+	if !finished {
+		return nil, nil
+	}
+
+	return run, nil
+}
+
+// GetLatestReport returns the most recent run with one page of its
+// mismatches
+func (s *ReconciliationService) GetLatestReport(limit, offset int) (*models.ReconciliationReport, error) {
+	if limit <= 0 {
+		limit = DefaultMismatchPageSize
+	}
+	if limit > MaxMismatchPageSize {
+		limit = MaxMismatchPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	run, err := s.repo.GetLatestRun()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest reconciliation run: %w", err)
+	}
+	if run == nil {
+		return nil, ErrReconciliationNotFound
+	}
+
+	mismatches, err := s.repo.ListMismatches(run.ID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reconciliation mismatches: %w", err)
+	}
+
+	return &models.ReconciliationReport{Run: *run, Mismatches: mismatches, Limit: limit, Offset: offset}, nil
+}
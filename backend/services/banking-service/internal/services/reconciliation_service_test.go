@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakeReconciliationRepo keeps runs in memory and checks a fixed number of
+// accounts, a batch at a time
+type fakeReconciliationRepo struct {
+	repository.ReconciliationRepository
+	runs     []models.ReconciliationRun
+	accounts int
+	batches  int
+}
+
+func (r *fakeReconciliationRepo) run(id uuid.UUID) *models.ReconciliationRun {
+	for i := range r.runs {
+		if r.runs[i].ID == id {
+			return &r.runs[i]
+		}
+	}
+	return nil
+}
+
+func (r *fakeReconciliationRepo) StartRun(runDate time.Time) (*models.ReconciliationRun, error) {
+	for _, run := range r.runs {
+		if run.RunDate.Equal(runDate) {
+			return &run, nil
+		}
+	}
+	r.runs = append(r.runs, models.ReconciliationRun{ID: uuid.New(), RunDate: runDate, Status: models.ReconciliationStatusRunning})
+	run := r.runs[len(r.runs)-1]
+	return &run, nil
+}
+
+func (r *fakeReconciliationRepo) GetLatestRun() (*models.ReconciliationRun, error) {
+	if len(r.runs) == 0 {
+		return nil, nil
+	}
+	run := r.runs[len(r.runs)-1]
+	return &run, nil
+}
+
+func (r *fakeReconciliationRepo) CheckBatch(runID uuid.UUID, batchSize int) (*models.ReconciliationRun, bool, error) {
+	run := r.run(runID)
+	remaining := r.accounts - run.AccountsChecked
+	if remaining == 0 {
+		return run, true, nil
+	}
+	r.batches++
+	run.AccountsChecked += min(batchSize, remaining)
+	return run, false, nil
+}
+
+func (r *fakeReconciliationRepo) FinishRun(runID uuid.UUID) (*models.ReconciliationRun, bool, error) {
+	run := r.run(runID)
+	if run.Status != models.ReconciliationStatusRunning {
+		return run, false, nil
+	}
+	run.Status = models.ReconciliationStatusCompleted
+	return run, true, nil
+}
+
+func (r *fakeReconciliationRepo) ListMismatches(runID uuid.UUID, limit, offset int) ([]models.ReconciliationMismatch, error) {
+	return nil, nil
+}
+
+func TestReconciliationService_RunDueOncePerDay(t *testing.T) {
+	repo := &fakeReconciliationRepo{accounts: 5}
+	service := NewReconciliationService(repo)
+	service.batchSize = 2
+	service.now = func() time.Time { return time.Date(2026, time.October, 17, 2, 0, 0, 0, time.UTC) }
+
+	run, err := service.RunDue(context.Background())
+	if err != nil {
+		t.Fatalf("RunDue returned error: %v", err)
+	}
+	if run == nil || run.Status != models.ReconciliationStatusCompleted || run.AccountsChecked != 5 {
+		t.Fatalf("Expected a completed run checking 5 accounts, got %+v", run)
+	}
+	if repo.batches != 3 {
+		t.Errorf("Expected 5 accounts to be checked in 3 batches of 2, got %d", repo.batches)
+	}
+	if !run.RunDate.Equal(time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the run to be for October 17, got %s", run.RunDate)
+	}
+
+	if run, err := service.RunDue(context.Background()); err != nil || run != nil {
+		t.Errorf("Expected nothing to do once today's run completed, got %+v, %v", run, err)
+	}
+
+	service.now = func() time.Time { return time.Date(2026, time.October, 18, 2, 0, 0, 0, time.UTC) }
+	if run, err := service.RunDue(context.Background()); err != nil || run == nil || len(repo.runs) != 2 {
+		t.Errorf("Expected a new run the next day, got %+v, %v", run, err)
+	}
+}
+
+func TestReconciliationService_RunDueResumesInterruptedRun(t *testing.T) {
+	runID := uuid.New()
+	repo := &fakeReconciliationRepo{accounts: 5, runs: []models.ReconciliationRun{{
+		ID:              runID,
+		RunDate:         time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+		Status:          models.ReconciliationStatusRunning,
+		AccountsChecked: 4,
+	}}}
+	service := NewReconciliationService(repo)
+	service.batchSize = 2
+	service.now = func() time.Time { return time.Date(2026, time.October, 17, 2, 0, 0, 0, time.UTC) }
+
+	run, err := service.RunDue(context.Background())
+	if err != nil {
+		t.Fatalf("RunDue returned error: %v", err)
+	}
+	if run == nil || run.ID != runID || run.AccountsChecked != 5 || repo.batches != 1 {
+		t.Errorf("Expected the interrupted run to check its last account and complete, got %+v after %d batches", run, repo.batches)
+	}
+}
+
+func TestReconciliationService_RunDueStopsWhenCancelled(t *testing.T) {
+	repo := &fakeReconciliationRepo{accounts: 5}
+	service := NewReconciliationService(repo)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := service.RunDue(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(repo.runs) != 1 || repo.runs[0].Status != models.ReconciliationStatusRunning {
+		t.Errorf("Expected the run to be left running to resume later, got %+v", repo.runs)
+	}
+}
+
+func TestReconciliationService_GetLatestReportWithoutRuns(t *testing.T) {
+	service := NewReconciliationService(&fakeReconciliationRepo{})
+
+	if _, err := service.GetLatestReport(0, 0); !errors.Is(err, ErrReconciliationNotFound) {
+		t.Errorf("Expected ErrReconciliationNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// Suspicious activity finding paging limits
+const (
+	DefaultFindingPageSize = 50
+	MaxFindingPageSize     = 200
+	// MaxFindingExportSize is the most findings one export returns
+	MaxFindingExportSize = 10000
+)
+
+// reportCatchUpDays is how many days back GenerateDueReports makes the
+// reports missed while no replica was running
+const reportCatchUpDays = 7
+
+// ReportService makes the daily suspicious activity reports compliance
+// reviews: accounts matching one of the configured rules on a UTC day
+// are written to the day's report as findings, which staff then mark
+// reviewed
+type ReportService struct {
+	repo  repository.SuspiciousActivityRepository
+	rules models.SuspiciousActivityRules
+	now   func() time.Time
+}
+
+// NewReportService creates a new report service making reports with the
+// enabled rules
+func NewReportService(repo repository.SuspiciousActivityRepository, rules models.SuspiciousActivityRules) *ReportService {
+	return &ReportService{
+		repo:  repo,
+		rules: rules,
+		now:   time.Now,
+	}
+}
+
+// GenerateDueReports makes the reports of the last few days that are due
+// and do not have one yet, oldest first, and returns those it made. A
+// day's report is due once the rapid cycle window has passed after the
+// day ends, so withdrawals made the next morning count against its
+// deposits.
+func (s *ReportService) GenerateDueReports() ([]models.SuspiciousActivityReport, error) {
+	due := s.now().UTC()
+	if s.rules.RapidCycle.Enabled {
+		due = due.Add(-s.rules.RapidCycle.Window)
+	}
+	last := startOfDay(due).AddDate(0, 0, -1)
+	first := last.AddDate(0, 0, 1-reportCatchUpDays)
+
+	existing, err := s.repo.ListReports(&first, &last)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suspicious activity reports: %w", err)
+	}
+	reported := make(map[time.Time]bool, len(existing))
+	for _, report := range existing {
+		reported[startOfDay(report.ReportDate)] = true
+	}
+
+	var made []models.SuspiciousActivityReport
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if reported[day] {
+			continue
+		}
+		report, saved, err := s.GenerateSuspiciousActivityReport(day)
+		if err != nil {
+			return made, err
+		}
+		if saved {
+			made = append(made, *report)
+		}
+	}
+
+	return made, nil
+}
+
+// GenerateSuspiciousActivityReport runs the enabled rules over the UTC day
+// holding day and writes their findings to its report. It reports whether
+// the report was written; it is not when the day already has one.
+func (s *ReportService) GenerateSuspiciousActivityReport(day time.Time) (*models.SuspiciousActivityReport, bool, error) {
+	day = startOfDay(day)
+	report := &models.SuspiciousActivityReport{ReportDate: day, Rules: s.rules.Enabled()}
+
+	var findings []models.SuspiciousActivityFinding
+	detections := []struct {
+		enabled bool
+		find    func() ([]models.SuspiciousActivityFinding, error)
+	}{
+		{s.rules.RapidCycle.Enabled, func() ([]models.SuspiciousActivityFinding, error) {
+			return s.repo.FindRapidCycles(day, s.rules.RapidCycle)
+		}},
+		{s.rules.Structuring.Enabled, func() ([]models.SuspiciousActivityFinding, error) {
+			return s.repo.FindStructuring(day, s.rules.Structuring)
+		}},
+		{s.rules.BalanceSwing.Enabled, func() ([]models.SuspiciousActivityFinding, error) {
+			return s.repo.FindBalanceSwings(day, s.rules.BalanceSwing)
+		}},
+	}
+	for _, detection := range detections {
+		if !detection.enabled {
+			continue
+		}
+		found, err := detection.find()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to detect suspicious activity: %w", err)
+		}
+		findings = append(findings, found...)
+	}
+
+	for i := range findings {
+		findings[i].ID = uuid.New()
+		findings[i].Description = s.describe(findings[i])
+	}
+
+	saved, err := s.repo.SaveReport(report, findings)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save suspicious activity report: %w", err)
+	}
+
+	return report, saved, nil
+}
+
+// ListFindings returns one page of findings matching the filter
+func (s *ReportService) ListFindings(filter models.SuspiciousActivityFilter) (*models.SuspiciousActivityPage, error) {
+	if err := validateFindingFilter(filter); err != nil {
+		return nil, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultFindingPageSize
+	}
+	if filter.Limit > MaxFindingPageSize {
+		filter.Limit = MaxFindingPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	findings, total, err := s.repo.ListFindings(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suspicious activity findings: %w", err)
+	}
+
+	return &models.SuspiciousActivityPage{Findings: findings, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// ExportFindings returns up to MaxFindingExportSize findings matching the
+// filter, ignoring its paging
+func (s *ReportService) ExportFindings(filter models.SuspiciousActivityFilter) ([]models.SuspiciousActivityFinding, error) {
+	if err := validateFindingFilter(filter); err != nil {
+		return nil, err
+	}
+	filter.Limit = MaxFindingExportSize
+	filter.Offset = 0
+
+	findings, _, err := s.repo.ListFindings(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export suspicious activity findings: %w", err)
+	}
+
+	return findings, nil
+}
+
+// ReviewFinding marks an open finding reviewed by reviewerID, with a note
+// on what was found
+func (s *ReportService) ReviewFinding(findingID, reviewerID uuid.UUID, request models.ReviewFindingRequest) (*models.SuspiciousActivityFinding, error) {
+	note := strings.TrimSpace(request.Note)
+	if note == "" {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "note", Rule: "required", Message: "is required"}}}
+	}
+
+	finding, err := s.repo.GetFinding(findingID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFindingNotFound, err)
+	}
+	if finding.Status == models.FindingStatusReviewed {
+		return nil, ErrFindingReviewed
+	}
+
+	finding.ReviewedBy = &reviewerID
+	finding.ReviewNote = note
+	if err := s.repo.ReviewFinding(finding); err != nil {
+		return nil, err
+	}
+
+	return finding, nil
+}
+
+// describe explains in words why a finding matched its rule
+func (s *ReportService) describe(finding models.SuspiciousActivityFinding) string {
+	switch finding.Rule {
+	case models.SuspiciousRuleRapidCycle:
+		return fmt.Sprintf("%s totalling %s, each at least %g%% withdrawn within %s",
+			countOf(finding.TransactionCount, "deposit"), models.Amount(finding.Amount), s.rules.RapidCycle.Percent, countOf(int(s.rules.RapidCycle.Window.Hours()), "hour"))
+	case models.SuspiciousRuleStructuring:
+		return fmt.Sprintf("%s totalling %s, each between %s and the alert threshold of %s",
+			countOf(finding.TransactionCount, "transaction"), models.Amount(finding.Amount), models.Amount(s.rules.Structuring.Floor()), models.Amount(s.rules.Structuring.Threshold))
+	case models.SuspiciousRuleBalanceSwing:
+		baseline := 0.0
+		if finding.Baseline != nil {
+			baseline = *finding.Baseline
+		}
+		description := fmt.Sprintf("Balance moved %s over %s", models.Amount(finding.Amount), countOf(finding.TransactionCount, "transaction"))
+		if baseline > 0 {
+			description += fmt.Sprintf(", %.1f times its average daily movement of %s", finding.Amount/baseline, models.Amount(baseline))
+		}
+		return description
+	}
+	return finding.Rule
+}
+
+// validateFindingFilter checks the filter's rule and status are known and
+// its dates are in order
+func validateFindingFilter(filter models.SuspiciousActivityFilter) error {
+	var fields []FieldError
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		fields = append(fields, FieldError{Field: "to", Rule: "gtefield", Message: "must not be before from"})
+	}
+	if filter.Rule != "" && !models.IsSuspiciousActivityRule(filter.Rule) {
+		fields = append(fields, FieldError{Field: "rule", Rule: "oneof", Message: "must be rapid_cycle, structuring or balance_swing"})
+	}
+	if filter.Status != "" && filter.Status != models.FindingStatusOpen && filter.Status != models.FindingStatusReviewed {
+		fields = append(fields, FieldError{Field: "status", Rule: "oneof", Message: "must be open or reviewed"})
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// countOf returns n and noun, adding an s when n is not 1
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// startOfDay returns midnight UTC at the start of t's UTC day
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
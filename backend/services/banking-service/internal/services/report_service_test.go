@@ -0,0 +1,206 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakeSuspiciousActivityRepo returns fixed findings per rule and keeps the
+// reports saved in memory
+type fakeSuspiciousActivityRepo struct {
+	repository.SuspiciousActivityRepository
+	found    map[string][]models.SuspiciousActivityFinding
+	queried  []string
+	reports  []models.SuspiciousActivityReport
+	findings []models.SuspiciousActivityFinding
+}
+
+func (r *fakeSuspiciousActivityRepo) find(rule string) ([]models.SuspiciousActivityFinding, error) {
+	r.queried = append(r.queried, rule)
+	return append([]models.SuspiciousActivityFinding(nil), r.found[rule]...), nil
+}
+
+func (r *fakeSuspiciousActivityRepo) FindRapidCycles(day time.Time, rule models.RapidCycleRule) ([]models.SuspiciousActivityFinding, error) {
+	return r.find(models.SuspiciousRuleRapidCycle)
+}
+
+func (r *fakeSuspiciousActivityRepo) FindStructuring(day time.Time, rule models.StructuringRule) ([]models.SuspiciousActivityFinding, error) {
+	return r.find(models.SuspiciousRuleStructuring)
+}
+
+func (r *fakeSuspiciousActivityRepo) FindBalanceSwings(day time.Time, rule models.BalanceSwingRule) ([]models.SuspiciousActivityFinding, error) {
+	return r.find(models.SuspiciousRuleBalanceSwing)
+}
+
+func (r *fakeSuspiciousActivityRepo) ListReports(from, to *time.Time) ([]models.SuspiciousActivityReport, error) {
+	var reports []models.SuspiciousActivityReport
+	for _, report := range r.reports {
+		if !report.ReportDate.Before(*from) && !report.ReportDate.After(*to) {
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+func (r *fakeSuspiciousActivityRepo) SaveReport(report *models.SuspiciousActivityReport, findings []models.SuspiciousActivityFinding) (bool, error) {
+	for _, existing := range r.reports {
+		if existing.ReportDate.Equal(report.ReportDate) {
+			return false, nil
+		}
+	}
+	report.FindingCount = len(findings)
+	r.reports = append(r.reports, *report)
+	for _, finding := range findings {
+		finding.ReportDate = report.ReportDate
+		finding.Status = models.FindingStatusOpen
+		r.findings = append(r.findings, finding)
+	}
+	return true, nil
+}
+
+func (r *fakeSuspiciousActivityRepo) GetFinding(id uuid.UUID) (*models.SuspiciousActivityFinding, error) {
+	for _, finding := range r.findings {
+		if finding.ID == id {
+			return &finding, nil
+		}
+	}
+	return nil, fmt.Errorf("suspicious activity finding not found")
+}
+
+func (r *fakeSuspiciousActivityRepo) ReviewFinding(finding *models.SuspiciousActivityFinding) error {
+	for i := range r.findings {
+		if r.findings[i].ID == finding.ID {
+			finding.Status = models.FindingStatusReviewed
+			r.findings[i] = *finding
+			return nil
+		}
+	}
+	return fmt.Errorf("suspicious activity finding not found")
+}
+
+// testSuspiciousActivityRules turns every rule on with the default settings
+func testSuspiciousActivityRules() models.SuspiciousActivityRules {
+	return models.SuspiciousActivityRules{
+		RapidCycle:   models.RapidCycleRule{Enabled: true, Window: 24 * time.Hour, Percent: 90},
+		Structuring:  models.StructuringRule{Enabled: true, Threshold: 10000, MarginPercent: 10, MinCount: 3},
+		BalanceSwing: models.BalanceSwingRule{Enabled: true, Multiple: 5, LookbackDays: 90, MinHistoryDays: 7},
+	}
+}
+
+func TestReportService_GenerateSkipsDisabledRules(t *testing.T) {
+	baseline := 610.0
+	repo := &fakeSuspiciousActivityRepo{found: map[string][]models.SuspiciousActivityFinding{
+		models.SuspiciousRuleStructuring:  {{Rule: models.SuspiciousRuleStructuring, AccountID: uuid.New(), TransactionCount: 4, Amount: 38500}},
+		models.SuspiciousRuleBalanceSwing: {{Rule: models.SuspiciousRuleBalanceSwing, AccountID: uuid.New(), TransactionCount: 6, Amount: 5000, Baseline: &baseline}},
+	}}
+	rules := testSuspiciousActivityRules()
+	rules.RapidCycle.Enabled = false
+	service := NewReportService(repo, rules)
+
+	report, saved, err := service.GenerateSuspiciousActivityReport(time.Date(2026, time.October, 17, 15, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GenerateSuspiciousActivityReport returned error: %v", err)
+	}
+	if !saved {
+		t.Fatal("Expected the report to be saved")
+	}
+	if len(repo.queried) != 2 || repo.queried[0] != models.SuspiciousRuleStructuring || repo.queried[1] != models.SuspiciousRuleBalanceSwing {
+		t.Errorf("Expected only the enabled rules to run, got %v", repo.queried)
+	}
+	if !report.ReportDate.Equal(time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)) || len(report.Rules) != 2 || report.FindingCount != 2 {
+		t.Errorf("Expected a report of 2 findings from 2 rules on October 17, got %+v", report)
+	}
+
+	if got, want := repo.findings[0].Description, "4 transactions totalling 38500.00 USD, each between 9000.00 USD and the alert threshold of 10000.00 USD"; got != want {
+		t.Errorf("Expected description %q, got %q", want, got)
+	}
+	if got, want := repo.findings[1].Description, "Balance moved 5000.00 USD over 6 transactions, 8.2 times its average daily movement of 610.00 USD"; got != want {
+		t.Errorf("Expected description %q, got %q", want, got)
+	}
+	if repo.findings[0].ID == uuid.Nil || repo.findings[0].ID == repo.findings[1].ID {
+		t.Errorf("Expected every finding to get its own ID, got %s and %s", repo.findings[0].ID, repo.findings[1].ID)
+	}
+}
+
+func TestReportService_GenerateDueReports(t *testing.T) {
+	reported := time.Date(2026, time.October, 14, 0, 0, 0, 0, time.UTC)
+	repo := &fakeSuspiciousActivityRepo{reports: []models.SuspiciousActivityReport{{ReportDate: reported}}}
+	service := NewReportService(repo, testSuspiciousActivityRules())
+	// With a 24 hour rapid cycle window, October 16 is the last day whose
+	// withdrawals have all been made
+	service.now = func() time.Time { return time.Date(2026, time.October, 18, 1, 0, 0, 0, time.UTC) }
+
+	made, err := service.GenerateDueReports()
+	if err != nil {
+		t.Fatalf("GenerateDueReports returned error: %v", err)
+	}
+	if len(made) != 6 {
+		t.Fatalf("Expected 6 reports for the 7 days up to October 16 without one, got %d", len(made))
+	}
+	if first := made[0].ReportDate; !first.Equal(time.Date(2026, time.October, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the oldest report to be made first, for October 10, got %s", first)
+	}
+	for _, report := range made {
+		if report.ReportDate.Equal(reported) {
+			t.Error("Expected the day that already has a report to be skipped")
+		}
+	}
+	if last := made[len(made)-1].ReportDate; !last.Equal(time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the newest report to be for October 16, got %s", last)
+	}
+
+	made, err = service.GenerateDueReports()
+	if err != nil {
+		t.Fatalf("GenerateDueReports returned error: %v", err)
+	}
+	if len(made) != 0 {
+		t.Errorf("Expected no reports when every due day has one, got %d", len(made))
+	}
+}
+
+func TestReportService_ReviewFinding(t *testing.T) {
+	findingID := uuid.New()
+	repo := &fakeSuspiciousActivityRepo{findings: []models.SuspiciousActivityFinding{{ID: findingID, Status: models.FindingStatusOpen}}}
+	service := NewReportService(repo, testSuspiciousActivityRules())
+	reviewerID := uuid.New()
+
+	if _, err := service.ReviewFinding(findingID, reviewerID, models.ReviewFindingRequest{Note: "   "}); err == nil {
+		t.Error("Expected a blank note to be rejected, got nil")
+	}
+
+	finding, err := service.ReviewFinding(findingID, reviewerID, models.ReviewFindingRequest{Note: " Payroll account, expected "})
+	if err != nil {
+		t.Fatalf("ReviewFinding returned error: %v", err)
+	}
+	if finding.Status != models.FindingStatusReviewed || finding.ReviewedBy == nil || *finding.ReviewedBy != reviewerID || finding.ReviewNote != "Payroll account, expected" {
+		t.Errorf("Expected the finding to be reviewed by %s with the trimmed note, got %+v", reviewerID, finding)
+	}
+
+	if _, err := service.ReviewFinding(findingID, reviewerID, models.ReviewFindingRequest{Note: "Again"}); !errors.Is(err, ErrFindingReviewed) {
+		t.Errorf("Expected ErrFindingReviewed, got %v", err)
+	}
+	if _, err := service.ReviewFinding(uuid.New(), reviewerID, models.ReviewFindingRequest{Note: "Missing"}); !errors.Is(err, ErrFindingNotFound) {
+		t.Errorf("Expected ErrFindingNotFound, got %v", err)
+	}
+}
+
+func TestReportService_ListFindingsValidatesFilter(t *testing.T) {
+	service := NewReportService(&fakeSuspiciousActivityRepo{}, testSuspiciousActivityRules())
+	from := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, -1)
+
+	_, err := service.ListFindings(models.SuspiciousActivityFilter{From: &from, To: &to, Rule: "smurfing", Status: "closed"})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+	if len(validationErr.Fields) != 3 {
+		t.Errorf("Expected the dates, rule and status to be rejected, got %+v", validationErr.Fields)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// roundUpSummaryMonths is how many calendar months, including the current
+// one, the round-up summary covers
+const roundUpSummaryMonths = 12
+
+// RoundUpService handles users' round-up settings. With round-ups on, each
+// withdrawal's change up to the next whole unit is earmarked for a savings
+// goal, as described by TransactionService.ProcessWithdrawal.
+type RoundUpService struct {
+	goalRepo        repository.SavingsGoalRepository
+	accountRepo     repository.AccountRepository
+	transactionRepo repository.TransactionRepository
+	userStatuses    UserStatusSource
+	now             func() time.Time
+}
+
+// NewRoundUpService creates a new round-up service
+func NewRoundUpService(goalRepo repository.SavingsGoalRepository, accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository) *RoundUpService {
+	return &RoundUpService{
+		goalRepo:        goalRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		now:             time.Now,
+	}
+}
+
+// WithUserTimeZones counts the months of the round-up summary in each
+// user's preferred time zone, looked up in statuses. Without it months are
+// counted in UTC.
+func (s *RoundUpService) WithUserTimeZones(statuses UserStatusSource) *RoundUpService {
+	s.userStatuses = statuses
+	return s
+}
+
+// GetSettings returns the user's round-up settings with how much round-ups
+// saved in each of the last 12 calendar months, counted in the user's time
+// zone
+func (s *RoundUpService) GetSettings(userID uuid.UUID) (*models.RoundUpSettings, error) {
+	account, err := s.accountRepo.GetAccountByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	settings := &models.RoundUpSettings{Monthly: []models.RoundUpMonth{}}
+	goal, err := s.goalRepo.GetRoundUpGoal(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if goal != nil {
+		settings.Enabled = true
+		settings.GoalID = &goal.ID
+		settings.GoalName = goal.Name
+	}
+
+	now := s.now().In(s.userLocation(userID))
+	since := time.Date(now.Year(), now.Month()-(roundUpSummaryMonths-1), 1, 0, 0, 0, 0, now.Location())
+	roundUps, err := s.transactionRepo.ListRoundUpsSince(account.ID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-ups are listed newest first, so each month's are together
+	for _, roundUp := range roundUps {
+		month := roundUp.CreatedAt.In(now.Location()).Format("2006-01")
+		last := len(settings.Monthly) - 1
+		if last < 0 || settings.Monthly[last].Month != month {
+			settings.Monthly = append(settings.Monthly, models.RoundUpMonth{Month: month, Amount: models.Amount(0)})
+			last++
+		}
+		settings.Monthly[last].Amount, err = settings.Monthly[last].Amount.Add(models.Amount(roundUp.Amount))
+		if err != nil {
+			return nil, fmt.Errorf("failed to add up round-ups: %w", err)
+		}
+		settings.Monthly[last].Count++
+	}
+
+	return settings, nil
+}
+
+// userLocation returns the user's preferred time zone, or UTC when they
+// have none or it cannot be looked up. The summary is only for display, so
+// a failed lookup does not fail it.
+func (s *RoundUpService) userLocation(userID uuid.UUID) *time.Location {
+	if s.userStatuses == nil {
+		return time.UTC
+	}
+	status, err := s.userStatuses.GetUserStatus(userID.String())
+	if err != nil {
+		log.Printf("Counting round-ups of user %s in UTC: %v", userID, err)
+		return time.UTC
+	}
+	if status.TimeZone == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(status.TimeZone)
+	if err != nil {
+		log.Printf("Counting round-ups of user %s in UTC: %v", userID, err)
+		return time.UTC
+	}
+	return location
+}
+
+// UpdateSettings turns the user's round-ups on, into one of their goals, or
+// off, and returns the new settings
+func (s *RoundUpService) UpdateSettings(userID uuid.UUID, request models.UpdateRoundUpsRequest) (*models.RoundUpSettings, error) {
+	account, err := s.accountRepo.GetAccountByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	var goalID *uuid.UUID
+	if *request.Enabled {
+		if request.GoalID == nil {
+			return nil, &ValidationError{Fields: []FieldError{{Field: "goal_id", Rule: "required", Message: "is required to turn round-ups on"}}}
+		}
+		goal, err := s.goalRepo.GetByID(*request.GoalID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSavingsGoalNotFound, err)
+		}
+		if goal.UserID != userID {
+			return nil, ErrSavingsGoalNotFound
+		}
+		goalID = &goal.ID
+	}
+
+	if err := s.goalRepo.SetRoundUpGoal(account.ID, goalID); err != nil {
+		return nil, err
+	}
+
+	return s.GetSettings(userID)
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// roundUpTransactionRepo lists fixed round-ups
+type roundUpTransactionRepo struct {
+	fakeTransactionRepo
+	roundUps []models.Transaction
+}
+
+func (r *roundUpTransactionRepo) ListRoundUpsSince(accountID uuid.UUID, since time.Time) ([]models.Transaction, error) {
+	var roundUps []models.Transaction
+	for _, roundUp := range r.roundUps {
+		if !roundUp.CreatedAt.Before(since) {
+			roundUps = append(roundUps, roundUp)
+		}
+	}
+	return roundUps, nil
+}
+
+func TestRoundUpService_GetSettings(t *testing.T) {
+	_, goals, userID, goal := newGoalFixture(150, 0)
+	accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: goal.AccountID, UserID: userID, Balance: 150}}}}
+	// Without a time zone preference months are counted in UTC
+	monthStart := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	transactions := &roundUpTransactionRepo{roundUps: []models.Transaction{
+		{Amount: 0.55, CreatedAt: monthStart.Add(time.Hour)},
+		{Amount: 0.25, CreatedAt: monthStart},
+		{Amount: 0.9, CreatedAt: monthStart.Add(-time.Second)},
+		// Older than the 12 months covered
+		{Amount: 0.1, CreatedAt: monthStart.AddDate(-1, 0, 0)},
+	}}
+	svc := NewRoundUpService(goals, accounts, transactions)
+	svc.now = func() time.Time { return monthStart.Add(24 * time.Hour) }
+
+	settings, err := svc.GetSettings(userID)
+	if err != nil {
+		t.Fatalf("GetSettings returned error: %v", err)
+	}
+	if settings.Enabled || settings.GoalID != nil {
+		t.Errorf("Expected round-ups to be off, got %+v", settings)
+	}
+	want := []models.RoundUpMonth{
+		{Month: "2026-03", Amount: models.Amount(0.8), Count: 2},
+		{Month: "2026-02", Amount: models.Amount(0.9), Count: 1},
+	}
+	if !reflect.DeepEqual(settings.Monthly, want) {
+		t.Errorf("Expected monthly summary %+v, got %+v", want, settings.Monthly)
+	}
+}
+
+func TestRoundUpService_GetSettings_UserTimeZone(t *testing.T) {
+	_, goals, userID, goal := newGoalFixture(150, 0)
+	accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: goal.AccountID, UserID: userID, Balance: 150}}}}
+	// New York leaves daylight saving time at 06:00 UTC on 1 November 2026,
+	// six hours after its October ends at 04:00 UTC
+	transactions := &roundUpTransactionRepo{roundUps: []models.Transaction{
+		{Amount: 0.1, CreatedAt: time.Date(2026, time.November, 1, 6, 30, 0, 0, time.UTC)},
+		{Amount: 0.2, CreatedAt: time.Date(2026, time.November, 1, 5, 30, 0, 0, time.UTC)},
+		{Amount: 0.3, CreatedAt: time.Date(2026, time.November, 1, 3, 30, 0, 0, time.UTC)},
+		{Amount: 0.4, CreatedAt: time.Date(2026, time.October, 1, 3, 30, 0, 0, time.UTC)},
+		// In the first month covered in UTC, but before it in New York
+		{Amount: 0.5, CreatedAt: time.Date(2025, time.December, 1, 4, 30, 0, 0, time.UTC)},
+	}}
+	now := time.Date(2026, time.November, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		statuses *fakeUserStatusSource
+		want     []models.RoundUpMonth
+	}{
+		{
+			name:     "New York",
+			statuses: &fakeUserStatusSource{statuses: map[string]models.UserStatus{userID.String(): {Exists: true, TimeZone: "America/New_York"}}},
+			want: []models.RoundUpMonth{
+				{Month: "2026-11", Amount: models.Amount(0.3), Count: 2},
+				{Month: "2026-10", Amount: models.Amount(0.3), Count: 1},
+				{Month: "2026-09", Amount: models.Amount(0.4), Count: 1},
+			},
+		},
+		{
+			name:     "no preference",
+			statuses: &fakeUserStatusSource{statuses: map[string]models.UserStatus{userID.String(): {Exists: true}}},
+			want: []models.RoundUpMonth{
+				{Month: "2026-11", Amount: models.Amount(0.6), Count: 3},
+				{Month: "2026-10", Amount: models.Amount(0.4), Count: 1},
+				{Month: "2025-12", Amount: models.Amount(0.5), Count: 1},
+			},
+		},
+		{
+			name:     "lookup failure",
+			statuses: &fakeUserStatusSource{err: errors.New("connection refused")},
+			want: []models.RoundUpMonth{
+				{Month: "2026-11", Amount: models.Amount(0.6), Count: 3},
+				{Month: "2026-10", Amount: models.Amount(0.4), Count: 1},
+				{Month: "2025-12", Amount: models.Amount(0.5), Count: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewRoundUpService(goals, accounts, transactions).WithUserTimeZones(tt.statuses)
+			svc.now = func() time.Time { return now }
+
+			settings, err := svc.GetSettings(userID)
+			if err != nil {
+				t.Fatalf("GetSettings returned error: %v", err)
+			}
+			if !reflect.DeepEqual(settings.Monthly, tt.want) {
+				t.Errorf("Expected monthly summary %+v, got %+v", tt.want, settings.Monthly)
+			}
+		})
+	}
+}
+
+func TestRoundUpService_UpdateSettings(t *testing.T) {
+	_, goals, userID, goal := newGoalFixture(150, 0)
+	accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: goal.AccountID, UserID: userID, Balance: 150}}}}
+	svc := NewRoundUpService(goals, accounts, &roundUpTransactionRepo{})
+	on, off := true, false
+
+	var validationErr *ValidationError
+	if _, err := svc.UpdateSettings(userID, models.UpdateRoundUpsRequest{Enabled: &on}); !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error turning round-ups on without a goal, got %v", err)
+	}
+
+	otherGoal := &models.SavingsGoal{ID: uuid.New(), UserID: uuid.New(), AccountID: uuid.New(), Name: "Not mine", TargetAmount: 10}
+	goals.add(otherGoal)
+	if _, err := svc.UpdateSettings(userID, models.UpdateRoundUpsRequest{Enabled: &on, GoalID: &otherGoal.ID}); !errors.Is(err, ErrSavingsGoalNotFound) {
+		t.Errorf("Expected %v for another user's goal, got %v", ErrSavingsGoalNotFound, err)
+	}
+
+	settings, err := svc.UpdateSettings(userID, models.UpdateRoundUpsRequest{Enabled: &on, GoalID: &goal.ID})
+	if err != nil {
+		t.Fatalf("UpdateSettings returned error: %v", err)
+	}
+	if !settings.Enabled || settings.GoalID == nil || *settings.GoalID != goal.ID || settings.GoalName != "Bike" {
+		t.Errorf("Expected round-ups into %s, got %+v", goal.ID, settings)
+	}
+
+	settings, err = svc.UpdateSettings(userID, models.UpdateRoundUpsRequest{Enabled: &off, GoalID: &goal.ID})
+	if err != nil {
+		t.Fatalf("UpdateSettings returned error: %v", err)
+	}
+	if settings.Enabled || goals.roundUpGoal != nil {
+		t.Errorf("Expected round-ups to be off, got %+v", settings)
+	}
+}
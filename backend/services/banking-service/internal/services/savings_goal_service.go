@@ -0,0 +1,230 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// SavingsGoalService handles savings goals. Allocating to a goal earmarks
+// part of the account's balance without moving it; earmarked money is not
+// available to allocate again, and withdrawals only take it as described
+// by TransactionService.ProcessWithdrawal.
+type SavingsGoalService struct {
+	goalRepo    repository.SavingsGoalRepository
+	accountRepo repository.AccountRepository
+	now         func() time.Time
+}
+
+// NewSavingsGoalService creates a new savings goal service
+func NewSavingsGoalService(goalRepo repository.SavingsGoalRepository, accountRepo repository.AccountRepository) *SavingsGoalService {
+	return &SavingsGoalService{
+		goalRepo:    goalRepo,
+		accountRepo: accountRepo,
+		now:         time.Now,
+	}
+}
+
+// CreateGoal creates a goal on the user's account with nothing allocated
+// yet
+func (s *SavingsGoalService) CreateGoal(userID uuid.UUID, request models.CreateSavingsGoalRequest) (*models.SavingsGoal, error) {
+	name := strings.TrimSpace(request.Name)
+	if err := s.validateGoal(name, request.TargetDate); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetAccountByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	goal := &models.SavingsGoal{
+		ID:           uuid.New(),
+		UserID:       userID,
+		AccountID:    account.ID,
+		Name:         name,
+		TargetAmount: request.TargetAmount,
+		TargetDate:   request.TargetDate,
+		Strict:       request.Strict,
+	}
+	if err := s.goalRepo.Create(goal); err != nil {
+		return nil, fmt.Errorf("failed to create savings goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+// ListGoals returns the user's goals, oldest first, and how much of the
+// account's balance they earmark
+func (s *SavingsGoalService) ListGoals(userID uuid.UUID) ([]models.SavingsGoal, models.EarmarkedBalance, error) {
+	account, err := s.accountRepo.GetAccountByUserID(userID)
+	if err != nil {
+		return nil, models.EarmarkedBalance{}, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	goals, err := s.goalRepo.ListByAccountID(account.ID)
+	if err != nil {
+		return nil, models.EarmarkedBalance{}, fmt.Errorf("failed to get savings goals: %w", err)
+	}
+	if goals == nil {
+		goals = []models.SavingsGoal{}
+	}
+
+	earmarked, _ := earmarkedFunds(goals)
+	return goals, models.EarmarkedBalance{
+		Balance:   models.Amount(account.Balance),
+		Earmarked: models.Amount(earmarked),
+		Available: models.Amount(roundCents(account.Balance - earmarked)),
+	}, nil
+}
+
+// GetGoal returns one of the user's goals
+func (s *SavingsGoalService) GetGoal(userID, goalID uuid.UUID) (*models.SavingsGoal, error) {
+	goal, err := s.goalRepo.GetByID(goalID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSavingsGoalNotFound, err)
+	}
+	if goal.UserID != userID {
+		return nil, ErrSavingsGoalNotFound
+	}
+	return goal, nil
+}
+
+// UpdateGoal changes the fields of one of the user's goals that request
+// sets. Changing the target completes the goal if enough is already
+// allocated, and reopens a completed goal the new target puts out of reach.
+func (s *SavingsGoalService) UpdateGoal(userID, goalID uuid.UUID, request models.UpdateSavingsGoalRequest) (*models.SavingsGoal, error) {
+	goal, err := s.GetGoal(userID, goalID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := goal.Name
+	if request.Name != nil {
+		name = strings.TrimSpace(*request.Name)
+	}
+	if err := s.validateGoal(name, request.TargetDate); err != nil {
+		return nil, err
+	}
+
+	goal.Name = name
+	if request.TargetDate != nil {
+		goal.TargetDate = request.TargetDate
+	}
+	if request.Strict != nil {
+		goal.Strict = *request.Strict
+	}
+	if request.TargetAmount != nil {
+		goal.TargetAmount = *request.TargetAmount
+		switch {
+		case goal.AllocatedAmount < goal.TargetAmount:
+			goal.CompletedAt = nil
+		case goal.CompletedAt == nil:
+			now := s.now()
+			goal.CompletedAt = &now
+		}
+	}
+
+	if err := s.goalRepo.Update(goal); err != nil {
+		return nil, fmt.Errorf("failed to update savings goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+// DeleteGoal deletes one of the user's goals, making the money earmarked
+// for it available again
+func (s *SavingsGoalService) DeleteGoal(userID, goalID uuid.UUID) error {
+	if _, err := s.GetGoal(userID, goalID); err != nil {
+		return err
+	}
+
+	if err := s.goalRepo.Delete(goalID); err != nil {
+		return fmt.Errorf("failed to delete savings goal: %w", err)
+	}
+
+	return nil
+}
+
+// Allocate earmarks amount of the account's available balance for one of
+// the user's goals
+func (s *SavingsGoalService) Allocate(userID, goalID uuid.UUID, amount float64) (*models.SavingsGoal, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: allocation amount must be greater than zero", ErrInvalidAmount)
+	}
+
+	goal, err := s.GetGoal(userID, goalID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, balance, err := s.ListGoals(userID)
+	if err != nil {
+		return nil, err
+	}
+	if requested := models.Amount(amount); requested.Minor > balance.Available.Minor {
+		return nil, fmt.Errorf("%w: requested %s, available %s", ErrInsufficientAvailableFunds, requested, balance.Available)
+	}
+
+	goal, err = s.goalRepo.ChangeAllocation(goal.ID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate to savings goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+// Deallocate releases amount earmarked for one of the user's goals, making
+// it available again. A completed goal stays completed.
+func (s *SavingsGoalService) Deallocate(userID, goalID uuid.UUID, amount float64) (*models.SavingsGoal, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: release amount must be greater than zero", ErrInvalidAmount)
+	}
+
+	goal, err := s.GetGoal(userID, goalID)
+	if err != nil {
+		return nil, err
+	}
+	if amount > goal.AllocatedAmount {
+		return nil, fmt.Errorf("%w: requested %s, allocated %s", ErrGoalReleaseTooLarge, models.Amount(amount), models.Amount(goal.AllocatedAmount))
+	}
+
+	goal, err = s.goalRepo.ChangeAllocation(goal.ID, -amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release savings goal funds: %w", err)
+	}
+
+	return goal, nil
+}
+
+// validateGoal checks a goal's name and, when set, that its target date is
+// in the future
+func (s *SavingsGoalService) validateGoal(name string, targetDate *time.Time) error {
+	var fieldErrs []FieldError
+	if name == "" {
+		fieldErrs = append(fieldErrs, FieldError{Field: "name", Rule: "required", Message: "is required"})
+	}
+	if targetDate != nil && !targetDate.After(s.now()) {
+		fieldErrs = append(fieldErrs, FieldError{Field: "target_date", Rule: "future", Message: "must be in the future"})
+	}
+	if len(fieldErrs) > 0 {
+		return &ValidationError{Fields: fieldErrs}
+	}
+	return nil
+}
+
+// earmarkedFunds returns how much goals earmark in total, and how much of
+// that is earmarked by strict goals
+func earmarkedFunds(goals []models.SavingsGoal) (total, strict float64) {
+	for _, goal := range goals {
+		total += goal.AllocatedAmount
+		if goal.Strict {
+			strict += goal.AllocatedAmount
+		}
+	}
+	return roundCents(total), roundCents(strict)
+}
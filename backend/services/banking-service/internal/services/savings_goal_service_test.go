@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakeSavingsGoalRepo keeps goals in memory, in the order they were added
+type fakeSavingsGoalRepo struct {
+	repository.SavingsGoalRepository
+	goals []*models.SavingsGoal
+	// completed counts the goals ChangeAllocation completed
+	completed int
+	// roundUpGoal is the goal withdrawals are rounded up into, if any
+	roundUpGoal *uuid.UUID
+}
+
+func newFakeSavingsGoalRepo() *fakeSavingsGoalRepo {
+	return &fakeSavingsGoalRepo{}
+}
+
+func (r *fakeSavingsGoalRepo) add(goal *models.SavingsGoal) {
+	r.goals = append(r.goals, goal)
+}
+
+func (r *fakeSavingsGoalRepo) GetByID(id uuid.UUID) (*models.SavingsGoal, error) {
+	for _, goal := range r.goals {
+		if goal.ID == id {
+			clone := *goal
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("savings goal not found")
+}
+
+func (r *fakeSavingsGoalRepo) ListByAccountID(accountID uuid.UUID) ([]models.SavingsGoal, error) {
+	var goals []models.SavingsGoal
+	for _, goal := range r.goals {
+		if goal.AccountID == accountID {
+			goals = append(goals, *goal)
+		}
+	}
+	return goals, nil
+}
+
+func (r *fakeSavingsGoalRepo) Update(goal *models.SavingsGoal) error {
+	for i, existing := range r.goals {
+		if existing.ID == goal.ID {
+			clone := *goal
+			r.goals[i] = &clone
+			return nil
+		}
+	}
+	return fmt.Errorf("savings goal not found")
+}
+
+func (r *fakeSavingsGoalRepo) ChangeAllocation(goalID uuid.UUID, amount float64) (*models.SavingsGoal, error) {
+	for _, goal := range r.goals {
+		if goal.ID == goalID {
+			goal.AllocatedAmount = roundCents(goal.AllocatedAmount + amount)
+			if goal.CompletedAt == nil && goal.AllocatedAmount >= goal.TargetAmount {
+				now := time.Now()
+				goal.CompletedAt = &now
+				r.completed++
+			}
+			clone := *goal
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("savings goal not found")
+}
+
+func (r *fakeSavingsGoalRepo) GetRoundUpGoal(accountID uuid.UUID) (*models.SavingsGoal, error) {
+	if r.roundUpGoal == nil {
+		return nil, nil
+	}
+	return r.GetByID(*r.roundUpGoal)
+}
+
+func (r *fakeSavingsGoalRepo) SetRoundUpGoal(accountID uuid.UUID, goalID *uuid.UUID) error {
+	r.roundUpGoal = goalID
+	return nil
+}
+
+// newGoalFixture returns a service over an account holding balance with one
+// goal of target 100 that has allocated earmarked
+func newGoalFixture(balance, allocated float64) (*SavingsGoalService, *fakeSavingsGoalRepo, uuid.UUID, *models.SavingsGoal) {
+	userID := uuid.New()
+	accountID := uuid.New()
+	accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: accountID, UserID: userID, Balance: balance}}}}
+	goals := newFakeSavingsGoalRepo()
+	goal := &models.SavingsGoal{ID: uuid.New(), UserID: userID, AccountID: accountID, Name: "Bike", TargetAmount: 100, AllocatedAmount: allocated}
+	goals.add(goal)
+	return NewSavingsGoalService(goals, accounts), goals, userID, goal
+}
+
+func TestSavingsGoalService_Allocate(t *testing.T) {
+	svc, goals, userID, goal := newGoalFixture(150, 40)
+
+	if _, err := svc.Allocate(userID, goal.ID, 110.01); !errors.Is(err, ErrInsufficientAvailableFunds) {
+		t.Fatalf("Expected %v when allocating beyond the available balance, got %v", ErrInsufficientAvailableFunds, err)
+	}
+
+	updated, err := svc.Allocate(userID, goal.ID, 60)
+	if err != nil {
+		t.Fatalf("Allocate returned error: %v", err)
+	}
+	if updated.AllocatedAmount != 100 || updated.CompletedAt == nil {
+		t.Errorf("Expected the goal to be completed with 100 allocated, got %v (completed at %v)", updated.AllocatedAmount, updated.CompletedAt)
+	}
+	if goals.completed != 1 {
+		t.Errorf("Expected the goal to be completed once, got %d", goals.completed)
+	}
+
+	_, balance, err := svc.ListGoals(userID)
+	if err != nil {
+		t.Fatalf("ListGoals returned error: %v", err)
+	}
+	if balance.Earmarked != models.Amount(100) || balance.Available != models.Amount(50) {
+		t.Errorf("Expected 100 earmarked and 50 available, got %+v", balance)
+	}
+}
+
+func TestSavingsGoalService_Deallocate(t *testing.T) {
+	svc, _, userID, goal := newGoalFixture(150, 100)
+	completedAt := time.Now()
+	goal.CompletedAt = &completedAt
+
+	if _, err := svc.Deallocate(userID, goal.ID, 100.01); !errors.Is(err, ErrGoalReleaseTooLarge) {
+		t.Fatalf("Expected %v when releasing more than is allocated, got %v", ErrGoalReleaseTooLarge, err)
+	}
+
+	updated, err := svc.Deallocate(userID, goal.ID, 30)
+	if err != nil {
+		t.Fatalf("Deallocate returned error: %v", err)
+	}
+	if updated.AllocatedAmount != 70 || updated.CompletedAt == nil {
+		t.Errorf("Expected 70 allocated and the goal to stay completed, got %v (completed at %v)", updated.AllocatedAmount, updated.CompletedAt)
+	}
+}
+
+func TestSavingsGoalService_UpdateTarget(t *testing.T) {
+	svc, _, userID, goal := newGoalFixture(150, 80)
+
+	lower := 80.0
+	updated, err := svc.UpdateGoal(userID, goal.ID, models.UpdateSavingsGoalRequest{TargetAmount: &lower})
+	if err != nil {
+		t.Fatalf("UpdateGoal returned error: %v", err)
+	}
+	if updated.CompletedAt == nil {
+		t.Error("Expected lowering the target to the allocated amount to complete the goal")
+	}
+
+	higher := 200.0
+	updated, err = svc.UpdateGoal(userID, goal.ID, models.UpdateSavingsGoalRequest{TargetAmount: &higher})
+	if err != nil {
+		t.Fatalf("UpdateGoal returned error: %v", err)
+	}
+	if updated.CompletedAt != nil {
+		t.Error("Expected raising the target out of reach to reopen the goal")
+	}
+
+	past := time.Now().Add(-time.Hour)
+	var validationErr *ValidationError
+	if _, err := svc.UpdateGoal(userID, goal.ID, models.UpdateSavingsGoalRequest{TargetDate: &past}); !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error for a past target date, got %v", err)
+	}
+}
+
+func TestSavingsGoalService_OtherUsersGoal(t *testing.T) {
+	svc, _, _, goal := newGoalFixture(150, 40)
+	otherUserID := uuid.New()
+
+	if _, err := svc.GetGoal(otherUserID, goal.ID); !errors.Is(err, ErrSavingsGoalNotFound) {
+		t.Errorf("Expected %v for another user's goal, got %v", ErrSavingsGoalNotFound, err)
+	}
+	if _, err := svc.Allocate(otherUserID, goal.ID, 10); !errors.Is(err, ErrSavingsGoalNotFound) {
+		t.Errorf("Expected %v allocating to another user's goal, got %v", ErrSavingsGoalNotFound, err)
+	}
+}
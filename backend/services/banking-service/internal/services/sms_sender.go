@@ -0,0 +1,26 @@
+package services
+
+import (
+	"log"
+)
+
+// SMSSender delivers text messages to users' phones
+type SMSSender interface {
+	Send(to, message string) error
+}
+
+// LogSMSSender writes text messages to the service log instead of delivering
+// them. It is intended for local development where no SMS gateway is
+// available.
+type LogSMSSender struct{}
+
+// NewLogSMSSender creates a new log-backed SMS sender
+func NewLogSMSSender() *LogSMSSender {
+	return &LogSMSSender{}
+}
+
+// Send logs the text message
+func (s *LogSMSSender) Send(to, message string) error {
+	log.Printf("SMS to %s | %s", to, message)
+	return nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// statementPageSize is how many transactions each query reading a
+// statement's transactions returns
+const statementPageSize = 500
+
+// StatementService builds monthly account statements from the transaction
+// history. Months are calendar months in UTC, like free withdrawals and
+// partitions.
+type StatementService struct {
+	transactionRepo repository.TransactionRepository
+	accountRepo     repository.AccountRepository
+	// memberRepo lets members of joint accounts get their statements
+	memberRepo repository.AccountMemberRepository
+	now        func() time.Time
+}
+
+// NewStatementService creates a new statement service
+func NewStatementService(transactionRepo repository.TransactionRepository, accountRepo repository.AccountRepository) *StatementService {
+	return &StatementService{
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+		now:             time.Now,
+	}
+}
+
+// WithJointAccounts lets users get the statements of the accounts
+// memberRepo makes them members of. Without it users only get their own
+// account's.
+func (s *StatementService) WithJointAccounts(memberRepo repository.AccountMemberRepository) *StatementService {
+	s.memberRepo = memberRepo
+	return s
+}
+
+// GetStatement returns the statement for period, a month in YYYY-MM form,
+// of the user's account, or of the joint account accountID selects. It
+// lists every member's transactions. The current month's statement covers
+// the month so far; later months are a *ValidationError, and accounts the
+// user cannot use ErrAccountNotFound.
+func (s *StatementService) GetStatement(userID uuid.UUID, accountID *uuid.UUID, period string) (*models.Statement, error) {
+	start, err := time.Parse(models.StatementPeriodLayout, period)
+	if err != nil {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "period", Rule: "datetime", Message: "must be a month in YYYY-MM format"}}}
+	}
+	if start.After(s.now()) {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "period", Rule: "ltefield", Message: "must not be in the future"}}}
+	}
+
+	account, _, err := memberAccount(s.accountRepo, s.memberRepo, userID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAccountNotFound, err)
+	}
+
+	statement := &models.Statement{
+		UserID:      userID,
+		AccountID:   account.ID,
+		Period:      start.Format(models.StatementPeriodLayout),
+		PeriodStart: start,
+		PeriodEnd:   start.AddDate(0, 1, 0),
+	}
+
+	// The opening balance is what the last transaction before the month
+	// left, or nothing for accounts opened since
+	previous, err := s.transactionRepo.GetTransactionsByAccountID(account.ID, models.TransactionFilter{Until: &statement.PeriodStart, SortDesc: true}, nil, 1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+	if len(previous) > 0 {
+		statement.OpeningBalance = previous[0].BalanceAfter
+	}
+	statement.ClosingBalance = statement.OpeningBalance
+
+	// Read the month's transactions a page at a time, each page starting
+	// after the last one read
+	filter := models.TransactionFilter{Since: &statement.PeriodStart, Until: &statement.PeriodEnd}
+	var cursor *models.Transaction
+	for {
+		page, err := s.transactionRepo.GetTransactionsByAccountID(account.ID, filter, cursor, statementPageSize, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get statement transactions: %w", err)
+		}
+		statement.Transactions = append(statement.Transactions, page...)
+		if len(page) < statementPageSize {
+			break
+		}
+		cursor = &page[len(page)-1]
+	}
+
+	for _, transaction := range statement.Transactions {
+		switch transaction.Type {
+		case models.TransactionTypeDeposit, models.TransactionTypeRefund, models.TransactionTypeTransferIn, models.TransactionTypeBonus:
+			statement.MoneyIn += transaction.Amount
+		case models.TransactionTypeWithdrawal, models.TransactionTypeFee, models.TransactionTypeTransferOut, models.TransactionTypeExternalTransfer, models.TransactionTypeChequeReturn:
+			statement.MoneyOut += transaction.Amount
+		}
+	}
+	statement.MoneyIn = roundCents(statement.MoneyIn)
+	statement.MoneyOut = roundCents(statement.MoneyOut)
+	if count := len(statement.Transactions); count > 0 {
+		statement.ClosingBalance = statement.Transactions[count-1].BalanceAfter
+	}
+
+	return statement, nil
+}
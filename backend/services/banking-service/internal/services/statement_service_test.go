@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakeStatementTransactionRepo lists an account's transactions by
+// creation time, as the real repository does
+type fakeStatementTransactionRepo struct {
+	repository.TransactionRepository
+	transactions []models.Transaction
+	queries      int
+}
+
+func (r *fakeStatementTransactionRepo) GetTransactionsByAccountID(accountID uuid.UUID, filter models.TransactionFilter, before *models.Transaction, limit, offset int) ([]models.Transaction, error) {
+	r.queries++
+	var matched []models.Transaction
+	for _, transaction := range r.transactions {
+		if transaction.AccountID != accountID ||
+			(filter.Since != nil && transaction.CreatedAt.Before(*filter.Since)) ||
+			(filter.Until != nil && !transaction.CreatedAt.Before(*filter.Until)) {
+			continue
+		}
+		matched = append(matched, transaction)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if filter.SortDesc {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+	})
+	if before != nil {
+		for i := range matched {
+			if matched[i].ID == before.ID {
+				matched = matched[i+1:]
+				break
+			}
+		}
+	}
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, nil
+}
+
+func TestStatementService_GetStatement(t *testing.T) {
+	userID, accountID := uuid.New(), uuid.New()
+	accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: accountID, UserID: userID}}}}
+	at := func(day, hour int) time.Time { return time.Date(2026, time.September, day, hour, 0, 0, 0, time.UTC) }
+	transaction := func(kind models.TransactionType, amount, before, after float64, createdAt time.Time) models.Transaction {
+		return models.Transaction{ID: uuid.New(), AccountID: accountID, UserID: userID, Type: kind, Amount: amount, BalanceBefore: before, BalanceAfter: after, CreatedAt: createdAt}
+	}
+	repo := &fakeStatementTransactionRepo{transactions: []models.Transaction{
+		transaction(models.TransactionTypeDeposit, 100, 0, 100, at(1, 0).Add(-time.Hour)),
+		transaction(models.TransactionTypeDeposit, 50.25, 100, 150.25, at(3, 9)),
+		transaction(models.TransactionTypeWithdrawal, 30, 150.25, 120.25, at(10, 12)),
+		transaction(models.TransactionTypeFee, 1.5, 120.25, 118.75, at(10, 12).Add(time.Second)),
+		transaction(models.TransactionTypeRoundUp, 0.75, 118.75, 118.75, at(10, 12).Add(2*time.Second)),
+		transaction(models.TransactionTypeRefund, 1.5, 118.75, 120.25, at(30, 23)),
+		transaction(models.TransactionTypeDeposit, 10, 120.25, 130.25, at(30, 23).Add(time.Hour)),
+	}}
+	svc := NewStatementService(repo, accounts)
+	svc.now = func() time.Time { return at(15, 0).AddDate(0, 2, 0) }
+
+	statement, err := svc.GetStatement(userID, nil, "2026-09")
+	if err != nil {
+		t.Fatalf("GetStatement returned error: %v", err)
+	}
+	if statement.PeriodStart != at(1, 0) || statement.PeriodEnd != at(1, 0).AddDate(0, 1, 0) {
+		t.Errorf("Unexpected period %v to %v", statement.PeriodStart, statement.PeriodEnd)
+	}
+	if len(statement.Transactions) != 5 || !statement.HasActivity() {
+		t.Fatalf("Expected the month's 5 transactions, got %d", len(statement.Transactions))
+	}
+	if statement.OpeningBalance != 100 || statement.ClosingBalance != 120.25 {
+		t.Errorf("Expected balances 100 to 120.25, got %v to %v", statement.OpeningBalance, statement.ClosingBalance)
+	}
+	if statement.MoneyIn != 51.75 || statement.MoneyOut != 31.5 {
+		t.Errorf("Expected 51.75 in and 31.5 out, got %v and %v", statement.MoneyIn, statement.MoneyOut)
+	}
+
+	// Transactions at midnight belong to the month that starts
+	next, err := svc.GetStatement(userID, nil, "2026-10")
+	if err != nil {
+		t.Fatalf("GetStatement returned error: %v", err)
+	}
+	if len(next.Transactions) != 1 || next.OpeningBalance != 120.25 || next.ClosingBalance != 130.25 {
+		t.Errorf("Expected the midnight deposit in October, got %+v", next)
+	}
+
+	// Quiet months carry the balance over
+	quiet, err := svc.GetStatement(userID, nil, "2026-11")
+	if err != nil {
+		t.Fatalf("GetStatement returned error: %v", err)
+	}
+	if quiet.HasActivity() || quiet.OpeningBalance != 130.25 || quiet.ClosingBalance != 130.25 {
+		t.Errorf("Expected an empty statement at 130.25, got %+v", quiet)
+	}
+}
+
+func TestStatementService_GetStatement_ReadsEveryPage(t *testing.T) {
+	userID, accountID := uuid.New(), uuid.New()
+	accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: accountID, UserID: userID}}}}
+	repo := &fakeStatementTransactionRepo{}
+	start := time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < statementPageSize+1; i++ {
+		repo.transactions = append(repo.transactions, models.Transaction{
+			ID: uuid.New(), AccountID: accountID, UserID: userID, Type: models.TransactionTypeDeposit, Amount: 1,
+			BalanceBefore: float64(i), BalanceAfter: float64(i + 1), CreatedAt: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	svc := NewStatementService(repo, accounts)
+
+	statement, err := svc.GetStatement(userID, nil, "2026-09")
+	if err != nil {
+		t.Fatalf("GetStatement returned error: %v", err)
+	}
+	if len(statement.Transactions) != statementPageSize+1 || statement.ClosingBalance != statementPageSize+1 {
+		t.Errorf("Expected every transaction, got %d closing at %v", len(statement.Transactions), statement.ClosingBalance)
+	}
+	// One query for the opening balance and two pages
+	if repo.queries != 3 {
+		t.Errorf("Expected 3 queries, got %d", repo.queries)
+	}
+}
+
+func TestStatementService_GetStatement_Errors(t *testing.T) {
+	userID := uuid.New()
+	accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: uuid.New(), UserID: userID}}}}
+	svc := NewStatementService(&fakeStatementTransactionRepo{}, accounts)
+	svc.now = func() time.Time { return time.Date(2026, time.September, 15, 0, 0, 0, 0, time.UTC) }
+
+	var validationErr *ValidationError
+	for _, period := range []string{"2026-10", "2026-9", "September", ""} {
+		if _, err := svc.GetStatement(userID, nil, period); !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "period" {
+			t.Errorf("%q: expected a period validation error, got %v", period, err)
+		}
+	}
+
+	// The current month is covered so far
+	if _, err := svc.GetStatement(userID, nil, "2026-09"); err != nil {
+		t.Errorf("Expected the current month's statement, got %v", err)
+	}
+	if _, err := svc.GetStatement(uuid.New(), nil, "2026-09"); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("Expected ErrAccountNotFound, got %v", err)
+	}
+	if _, err := svc.GetStatement(uuid.New(), &accounts.accounts[userID].ID, "2026-09"); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("Expected ErrAccountNotFound for another user's account, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// StatsService computes the banking figures shown on the admin dashboard
+type StatsService struct {
+	repo repository.StatsRepository
+	now  func() time.Time
+}
+
+// NewStatsService creates a new stats service
+func NewStatsService(repo repository.StatsRepository) *StatsService {
+	return &StatsService{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// GetStats returns the current stats. The day's activity is counted from
+// midnight UTC.
+func (s *StatsService) GetStats() (*models.BankingStats, error) {
+	now := s.now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	stats, err := s.repo.GetBankingStats(today)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get banking stats: %w", err)
+	}
+	stats.GeneratedAt = now
+	return stats, nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"microbank/banking-service/internal/models"
+)
+
+// fakeStatsRepo returns fixed stats, keeping the cutoff it was asked for
+type fakeStatsRepo struct {
+	since time.Time
+}
+
+func (r *fakeStatsRepo) GetBankingStats(since time.Time) (*models.BankingStats, error) {
+	r.since = since
+	return &models.BankingStats{Accounts: 3, TotalBalance: 150}, nil
+}
+
+func TestStatsService_GetStats(t *testing.T) {
+	repo := &fakeStatsRepo{}
+	svc := NewStatsService(repo)
+	// Already the 19th in Harare, but still the 18th in UTC
+	now := time.Date(2026, time.October, 19, 1, 30, 0, 0, time.FixedZone("CAT", 2*60*60))
+	svc.now = func() time.Time { return now }
+
+	stats, err := svc.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats returned error: %v", err)
+	}
+	if want := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC); !repo.since.Equal(want) {
+		t.Errorf("Expected activity counted from %s, got %s", want, repo.since)
+	}
+	if stats.Accounts != 3 || !stats.GeneratedAt.Equal(now) {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"microbank/banking-service/internal/models"
+)
+
+// TokenRevocationList holds access tokens revoked by the client service
+// until they expire. It lives in process memory; after a restart revoked
+// tokens are still rejected when they are confirmed with the client service.
+type TokenRevocationList struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+	now     func() time.Time
+}
+
+// NewTokenRevocationList creates an empty revocation list
+func NewTokenRevocationList() *TokenRevocationList {
+	return &TokenRevocationList{
+		revoked: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Revoke adds tokens to the list, dropping any that have expired
+func (l *TokenRevocationList) Revoke(tokens []models.RevokedToken) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for jti, expiresAt := range l.revoked {
+		if !expiresAt.After(now) {
+			delete(l.revoked, jti)
+		}
+	}
+	for _, token := range tokens {
+		if token.ExpiresAt.After(now) {
+			l.revoked[token.JTI] = token.ExpiresAt
+		}
+	}
+}
+
+// IsRevoked reports whether a token has been revoked
+func (l *TokenRevocationList) IsRevoked(jti string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expiresAt, ok := l.revoked[jti]
+	return ok && expiresAt.After(l.now())
+}
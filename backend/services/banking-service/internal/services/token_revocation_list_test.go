@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"microbank/banking-service/internal/models"
+)
+
+func TestTokenRevocationList(t *testing.T) {
+	list := NewTokenRevocationList()
+	now := time.Now()
+	list.now = func() time.Time { return now }
+
+	list.Revoke([]models.RevokedToken{
+		{JTI: "short", ExpiresAt: now.Add(time.Minute)},
+		{JTI: "long", ExpiresAt: now.Add(15 * time.Minute)},
+		{JTI: "expired", ExpiresAt: now.Add(-time.Minute)},
+	})
+
+	for jti, want := range map[string]bool{"short": true, "long": true, "expired": false, "unknown": false} {
+		if revoked := list.IsRevoked(jti); revoked != want {
+			t.Errorf("IsRevoked(%q) = %v, want %v", jti, revoked, want)
+		}
+	}
+
+	// Expired revocations lapse and are dropped on the next push
+	now = now.Add(2 * time.Minute)
+	if list.IsRevoked("short") {
+		t.Error("Expected the revocation of an expired token to lapse")
+	}
+	list.Revoke(nil)
+	if _, ok := list.revoked["short"]; ok {
+		t.Error("Expected the expired revocation to be purged")
+	}
+	if !list.IsRevoked("long") {
+		t.Error("Expected long to still be revoked")
+	}
+}
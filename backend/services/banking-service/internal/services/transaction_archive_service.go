@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// Archived transaction paging limits
+const (
+	DefaultArchivedTransactionPageSize = 50
+	MaxArchivedTransactionPageSize     = 200
+)
+
+// TransactionArchiveService keeps the transactions table partitioned by
+// month: it makes partitions before they are needed, and moves old ones to
+// the archive, where staff can still look transactions up
+type TransactionArchiveService struct {
+	partitionRepo      repository.TransactionPartitionRepository
+	archiveAfterMonths int
+	now                func() time.Time
+}
+
+// NewTransactionArchiveService creates a new transaction archive service.
+// Transactions are archived once archiveAfterMonths whole months have
+// passed since the month they were made in; 0 never archives them.
+func NewTransactionArchiveService(partitionRepo repository.TransactionPartitionRepository, archiveAfterMonths int) *TransactionArchiveService {
+	return &TransactionArchiveService{
+		partitionRepo:      partitionRepo,
+		archiveAfterMonths: archiveAfterMonths,
+		now:                time.Now,
+	}
+}
+
+// MaintainPartitions creates the partitions for this month and the next
+// few that are missing, then archives the partitions old enough to be. It
+// returns the names of the partitions created and archived.
+func (s *TransactionArchiveService) MaintainPartitions() (created, archived []string, err error) {
+	now := s.now()
+	created, err = s.partitionRepo.EnsurePartitions(now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create transaction partitions: %w", err)
+	}
+
+	if s.archiveAfterMonths == 0 {
+		return created, nil, nil
+	}
+	archived, err = s.partitionRepo.ArchivePartitions(startOfMonth(now).AddDate(0, -s.archiveAfterMonths, 0))
+	if err != nil {
+		return created, nil, fmt.Errorf("failed to archive transaction partitions: %w", err)
+	}
+
+	return created, archived, nil
+}
+
+// ListArchivedTransactions returns a page of archived transactions, newest
+// first. The archive is only indexed by user and account, so one of them
+// must be given.
+func (s *TransactionArchiveService) ListArchivedTransactions(filter models.ArchivedTransactionFilter) (*models.ArchivedTransactionPage, error) {
+	var fields []FieldError
+	if filter.UserID == nil && filter.AccountID == nil {
+		fields = append(fields, FieldError{Field: "user_id", Rule: "required_without", Message: "is required unless account_id is given"})
+	}
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		fields = append(fields, FieldError{Field: "to", Rule: "gtfield", Message: "must be after from"})
+	}
+	if len(fields) > 0 {
+		return nil, &ValidationError{Fields: fields}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultArchivedTransactionPageSize
+	}
+	if filter.Limit > MaxArchivedTransactionPageSize {
+		filter.Limit = MaxArchivedTransactionPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	transactions, err := s.partitionRepo.GetArchivedTransactions(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived transactions: %w", err)
+	}
+
+	return &models.ArchivedTransactionPage{Transactions: transactions, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// fakePartitionRepo records the months partitions were made and archived
+// for
+type fakePartitionRepo struct {
+	repository.TransactionPartitionRepository
+	ensuredAt      time.Time
+	archivedBefore *time.Time
+	filter         models.ArchivedTransactionFilter
+}
+
+func (r *fakePartitionRepo) EnsurePartitions(now time.Time) ([]string, error) {
+	r.ensuredAt = now
+	return []string{"transactions_y2027m01"}, nil
+}
+
+func (r *fakePartitionRepo) ArchivePartitions(before time.Time) ([]string, error) {
+	r.archivedBefore = &before
+	return []string{"transactions_y2024m09"}, nil
+}
+
+func (r *fakePartitionRepo) GetArchivedTransactions(filter models.ArchivedTransactionFilter) ([]models.Transaction, error) {
+	r.filter = filter
+	return []models.Transaction{{ID: uuid.New()}}, nil
+}
+
+func TestTransactionArchiveService_MaintainPartitions(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		archiveAfterMonths int
+		wantBefore         time.Time
+	}{
+		{name: "archiving off"},
+		{name: "after 24 months", archiveAfterMonths: 24, wantBefore: time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partitions := &fakePartitionRepo{}
+			service := NewTransactionArchiveService(partitions, tt.archiveAfterMonths)
+			service.now = func() time.Time { return now }
+
+			created, archived, err := service.MaintainPartitions()
+			if err != nil {
+				t.Fatalf("MaintainPartitions returned error: %v", err)
+			}
+			if !partitions.ensuredAt.Equal(now) || len(created) != 1 {
+				t.Errorf("Expected partitions to be made from %v, got %v (created %v)", now, partitions.ensuredAt, created)
+			}
+
+			switch {
+			case tt.wantBefore.IsZero() && partitions.archivedBefore != nil:
+				t.Errorf("Expected nothing to be archived, got partitions before %v archived", *partitions.archivedBefore)
+			case !tt.wantBefore.IsZero() && (partitions.archivedBefore == nil || !partitions.archivedBefore.Equal(tt.wantBefore)):
+				t.Errorf("Expected partitions before %v to be archived, got %v", tt.wantBefore, partitions.archivedBefore)
+			case !tt.wantBefore.IsZero() && len(archived) != 1:
+				t.Errorf("Expected the archived partitions to be returned, got %v", archived)
+			}
+		})
+	}
+}
+
+func TestTransactionArchiveService_ListArchivedTransactions(t *testing.T) {
+	userID := uuid.New()
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		filter     models.ArchivedTransactionFilter
+		wantFields []string
+		wantLimit  int
+	}{
+		{name: "neither user nor account", wantFields: []string{"user_id"}},
+		{name: "empty period", filter: models.ArchivedTransactionFilter{UserID: &userID, From: &from, To: &from}, wantFields: []string{"to"}},
+		{name: "default page", filter: models.ArchivedTransactionFilter{UserID: &userID}, wantLimit: DefaultArchivedTransactionPageSize},
+		{name: "capped page", filter: models.ArchivedTransactionFilter{AccountID: &userID, Limit: 1000}, wantLimit: MaxArchivedTransactionPageSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partitions := &fakePartitionRepo{}
+			service := NewTransactionArchiveService(partitions, 24)
+
+			page, err := service.ListArchivedTransactions(tt.filter)
+			if len(tt.wantFields) > 0 {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || len(validationErr.Fields) != len(tt.wantFields) || validationErr.Fields[0].Field != tt.wantFields[0] {
+					t.Fatalf("Expected %v to be invalid, got %v", tt.wantFields, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListArchivedTransactions returned error: %v", err)
+			}
+			if page.Limit != tt.wantLimit || partitions.filter.Limit != tt.wantLimit || len(page.Transactions) != 1 {
+				t.Errorf("Expected a page of up to %d transactions, got %d (%d transactions)", tt.wantLimit, page.Limit, len(page.Transactions))
+			}
+		})
+	}
+}
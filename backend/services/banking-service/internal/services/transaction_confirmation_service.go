@@ -0,0 +1,146 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// TransactionConfirmationService holds withdrawals and transfers over the
+// user's confirmation threshold until the user confirms them with a
+// short-lived, single-use token. Confirming only hands the request back:
+// it is then carried out like any other, so funds and limits are checked
+// when it is confirmed rather than when it was made.
+type TransactionConfirmationService struct {
+	confirmationRepo repository.TransactionConfirmationRepository
+	rules            models.TransactionConfirmationRules
+	now              func() time.Time
+}
+
+// NewTransactionConfirmationService creates a new transaction confirmation
+// service holding withdrawals and transfers as rules describe
+func NewTransactionConfirmationService(confirmationRepo repository.TransactionConfirmationRepository, rules models.TransactionConfirmationRules) *TransactionConfirmationService {
+	return &TransactionConfirmationService{
+		confirmationRepo: confirmationRepo,
+		rules:            rules,
+		now:              time.Now,
+	}
+}
+
+// Threshold returns the amount over which the user's withdrawals and
+// transfers need confirming
+func (s *TransactionConfirmationService) Threshold(userID uuid.UUID) (models.ConfirmationThreshold, error) {
+	threshold, err := s.threshold(userID)
+	if err != nil {
+		return models.ConfirmationThreshold{}, err
+	}
+	return models.ConfirmationThreshold{Threshold: models.Amount(threshold.amount), Default: threshold.isDefault}, nil
+}
+
+// SetThreshold sets the amount over which the user's withdrawals and
+// transfers need confirming
+func (s *TransactionConfirmationService) SetThreshold(userID uuid.UUID, request models.UpdateConfirmationThresholdRequest) (models.ConfirmationThreshold, error) {
+	if err := s.confirmationRepo.SetThreshold(userID, request.Threshold); err != nil {
+		return models.ConfirmationThreshold{}, err
+	}
+	return models.ConfirmationThreshold{Threshold: models.Amount(request.Threshold)}, nil
+}
+
+// Require holds a withdrawal or transfer of amount over the user's
+// threshold until it is confirmed, returning the token to confirm it with.
+// It returns nil for amounts within the threshold, which can be carried
+// out straight away. request is stored as it is, so callers clear its PIN
+// first.
+func (s *TransactionConfirmationService) Require(userID uuid.UUID, kind models.TransactionConfirmationKind, amount float64, request any) (*models.PendingConfirmation, error) {
+	threshold, err := s.threshold(userID)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= threshold.amount {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %w", kind, err)
+	}
+	token, err := generateConfirmationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	confirmation := &models.TransactionConfirmation{
+		UserID:    userID,
+		Kind:      kind,
+		Amount:    amount,
+		Request:   encoded,
+		TokenHash: hashConfirmationToken(token),
+		ExpiresAt: s.now().Add(s.rules.TTL),
+	}
+	if err := s.confirmationRepo.Create(confirmation); err != nil {
+		return nil, err
+	}
+
+	return &models.PendingConfirmation{
+		Token:     token,
+		Kind:      kind,
+		Amount:    models.Amount(amount),
+		Threshold: models.Amount(threshold.amount),
+		ExpiresAt: confirmation.ExpiresAt.UTC(),
+	}, nil
+}
+
+// Confirm uses the user's confirmation token, returning the withdrawal or
+// transfer it held. Tokens can only be used once, before they expire, by
+// the user they were given to; others return ErrConfirmationInvalid.
+func (s *TransactionConfirmationService) Confirm(userID uuid.UUID, token string) (*models.TransactionConfirmation, error) {
+	confirmation, err := s.confirmationRepo.Consume(userID, hashConfirmationToken(token), s.now())
+	if err != nil {
+		return nil, err
+	}
+	if confirmation == nil {
+		return nil, ErrConfirmationInvalid
+	}
+	return confirmation, nil
+}
+
+// confirmationThreshold is a user's threshold, and whether it is the
+// default because they did not choose one
+type confirmationThreshold struct {
+	amount    float64
+	isDefault bool
+}
+
+func (s *TransactionConfirmationService) threshold(userID uuid.UUID) (confirmationThreshold, error) {
+	chosen, err := s.confirmationRepo.GetThreshold(userID)
+	if err != nil {
+		return confirmationThreshold{}, err
+	}
+	if chosen == nil {
+		return confirmationThreshold{amount: s.rules.DefaultThreshold, isDefault: true}, nil
+	}
+	return confirmationThreshold{amount: *chosen}, nil
+}
+
+// generateConfirmationToken returns a random URL-safe confirmation token
+func generateConfirmationToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// hashConfirmationToken returns the SHA-256 hex digest of a confirmation
+// token for storage
+func hashConfirmationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+)
+
+// fakeTransactionConfirmationRepo keeps thresholds and confirmations in
+// memory, using tokens once as the database does
+type fakeTransactionConfirmationRepo struct {
+	thresholds    map[uuid.UUID]float64
+	confirmations map[string]*models.TransactionConfirmation
+}
+
+func newFakeTransactionConfirmationRepo() *fakeTransactionConfirmationRepo {
+	return &fakeTransactionConfirmationRepo{
+		thresholds:    map[uuid.UUID]float64{},
+		confirmations: map[string]*models.TransactionConfirmation{},
+	}
+}
+
+func (r *fakeTransactionConfirmationRepo) GetThreshold(userID uuid.UUID) (*float64, error) {
+	threshold, ok := r.thresholds[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &threshold, nil
+}
+
+func (r *fakeTransactionConfirmationRepo) SetThreshold(userID uuid.UUID, threshold float64) error {
+	r.thresholds[userID] = threshold
+	return nil
+}
+
+func (r *fakeTransactionConfirmationRepo) Create(confirmation *models.TransactionConfirmation) error {
+	confirmation.ID = uuid.New()
+	clone := *confirmation
+	r.confirmations[confirmation.TokenHash] = &clone
+	return nil
+}
+
+func (r *fakeTransactionConfirmationRepo) Consume(userID uuid.UUID, tokenHash string, now time.Time) (*models.TransactionConfirmation, error) {
+	confirmation, ok := r.confirmations[tokenHash]
+	if !ok || confirmation.UserID != userID || confirmation.UsedAt != nil || !confirmation.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	confirmation.UsedAt = &now
+	clone := *confirmation
+	return &clone, nil
+}
+
+func TestTransactionConfirmationService_HoldsAmountsOverThreshold(t *testing.T) {
+	repo := newFakeTransactionConfirmationRepo()
+	service := NewTransactionConfirmationService(repo, models.TransactionConfirmationRules{DefaultThreshold: 1000, TTL: 5 * time.Minute})
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	userID := uuid.New()
+
+	// Amounts up to the default threshold are carried out straight away
+	if pending, err := service.Require(userID, models.TransactionConfirmationWithdrawal, 1000, models.TransactionRequest{Amount: 1000}); err != nil || pending != nil {
+		t.Fatalf("Expected 1000 not to need confirming, got %+v (%v)", pending, err)
+	}
+
+	// Larger ones are held with their request
+	request := models.TransferRequest{Amount: 1500, Description: "Rent"}
+	pending, err := service.Require(userID, models.TransactionConfirmationTransfer, 1500, request)
+	if err != nil || pending == nil {
+		t.Fatalf("Expected 1500 to need confirming, got %+v (%v)", pending, err)
+	}
+	if pending.Token == "" || !pending.ExpiresAt.Equal(now.Add(5*time.Minute)) || pending.Threshold.Float64() != 1000 {
+		t.Errorf("Expected a token expiring in 5m over the default threshold, got %+v", pending)
+	}
+	if _, stored := repo.confirmations[pending.Token]; stored {
+		t.Error("Expected only the token's hash to be stored")
+	}
+
+	// Confirming hands the request back, once
+	confirmation, err := service.Confirm(userID, pending.Token)
+	if err != nil {
+		t.Fatalf("Confirm returned error: %v", err)
+	}
+	var confirmed models.TransferRequest
+	if err := json.Unmarshal(confirmation.Request, &confirmed); err != nil || confirmed != request || confirmation.Kind != models.TransactionConfirmationTransfer {
+		t.Errorf("Expected the transfer request back, got %s %+v (%v)", confirmation.Kind, confirmed, err)
+	}
+	if _, err := service.Confirm(userID, pending.Token); !errors.Is(err, ErrConfirmationInvalid) {
+		t.Errorf("Expected a used token to be refused, got %v", err)
+	}
+}
+
+func TestTransactionConfirmationService_RefusesOtherUsersAndExpiredTokens(t *testing.T) {
+	repo := newFakeTransactionConfirmationRepo()
+	service := NewTransactionConfirmationService(repo, models.TransactionConfirmationRules{DefaultThreshold: 1000, TTL: 5 * time.Minute})
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	userID := uuid.New()
+
+	pending, err := service.Require(userID, models.TransactionConfirmationWithdrawal, 2000, models.TransactionRequest{Amount: 2000})
+	if err != nil || pending == nil {
+		t.Fatalf("Expected 2000 to need confirming, got %+v (%v)", pending, err)
+	}
+
+	if _, err := service.Confirm(uuid.New(), pending.Token); !errors.Is(err, ErrConfirmationInvalid) {
+		t.Errorf("Expected another user's token to be refused, got %v", err)
+	}
+
+	now = now.Add(5 * time.Minute)
+	if _, err := service.Confirm(userID, pending.Token); !errors.Is(err, ErrConfirmationInvalid) {
+		t.Errorf("Expected an expired token to be refused, got %v", err)
+	}
+}
+
+func TestTransactionConfirmationService_UserThreshold(t *testing.T) {
+	repo := newFakeTransactionConfirmationRepo()
+	service := NewTransactionConfirmationService(repo, models.TransactionConfirmationRules{DefaultThreshold: 1000, TTL: 5 * time.Minute})
+	userID := uuid.New()
+
+	threshold, err := service.Threshold(userID)
+	if err != nil || !threshold.Default || threshold.Threshold.Float64() != 1000 {
+		t.Fatalf("Expected the default threshold of 1000, got %+v (%v)", threshold, err)
+	}
+
+	threshold, err = service.SetThreshold(userID, models.UpdateConfirmationThresholdRequest{Threshold: 200})
+	if err != nil || threshold.Default || threshold.Threshold.Float64() != 200 {
+		t.Fatalf("Expected a threshold of 200, got %+v (%v)", threshold, err)
+	}
+
+	// The user's threshold replaces the default
+	if pending, err := service.Require(userID, models.TransactionConfirmationWithdrawal, 500, models.TransactionRequest{Amount: 500}); err != nil || pending == nil {
+		t.Errorf("Expected 500 to need confirming over a threshold of 200, got %+v (%v)", pending, err)
+	}
+	if pending, err := service.Require(uuid.New(), models.TransactionConfirmationWithdrawal, 500, models.TransactionRequest{Amount: 500}); err != nil || pending != nil {
+		t.Errorf("Expected other users to keep the default threshold, got %+v (%v)", pending, err)
+	}
+}
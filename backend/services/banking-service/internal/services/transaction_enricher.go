@@ -0,0 +1,62 @@
+package services
+
+import (
+	"fmt"
+
+	"microbank/banking-service/internal/models"
+)
+
+// UserDetailSource looks up users' details on the client-service. Failed
+// lookups return an error matching resilience.ErrDependencyUnavailable.
+type UserDetailSource interface {
+	GetUserDetails(userIDs []string) (map[string]models.UserDetail, error)
+}
+
+// TransactionEnricher adds customers' details to transactions shown to
+// staff
+type TransactionEnricher struct {
+	details UserDetailSource
+}
+
+// NewTransactionEnricher creates a new transaction enricher
+func NewTransactionEnricher(details UserDetailSource) *TransactionEnricher {
+	return &TransactionEnricher{
+		details: details,
+	}
+}
+
+// Enrich returns the transactions with their customer's email and name,
+// looking up every customer on the page at once. Customers the
+// client-service does not know are left without details. When the lookup
+// fails, every transaction is still returned, without details, along with
+// the error.
+func (e *TransactionEnricher) Enrich(transactions []models.Transaction) ([]models.AdminTransactionResponse, error) {
+	enriched := make([]models.AdminTransactionResponse, 0, len(transactions))
+	for _, transaction := range transactions {
+		enriched = append(enriched, models.AdminTransactionResponse{TransactionResponse: transaction.ToResponse()})
+	}
+	if len(transactions) == 0 {
+		return enriched, nil
+	}
+
+	userIDs := make([]string, 0, len(transactions))
+	for _, transaction := range transactions {
+		userIDs = append(userIDs, transaction.UserID.String())
+	}
+	details, err := e.details.GetUserDetails(userIDs)
+	if err != nil {
+		return enriched, fmt.Errorf("failed to look up customer details: %w", err)
+	}
+
+	for i := range enriched {
+		detail, ok := details[enriched[i].UserID.String()]
+		if !ok || !detail.Exists {
+			continue
+		}
+		email, name := detail.Email, detail.Name
+		enriched[i].CustomerEmail = &email
+		enriched[i].CustomerName = &name
+	}
+
+	return enriched, nil
+}
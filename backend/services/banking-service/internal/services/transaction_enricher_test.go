@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/resilience"
+)
+
+// fakeUserDetailSource returns fixed details and records its lookups
+type fakeUserDetailSource struct {
+	details map[string]models.UserDetail
+	err     error
+	lookups [][]string
+}
+
+func (s *fakeUserDetailSource) GetUserDetails(userIDs []string) (map[string]models.UserDetail, error) {
+	s.lookups = append(s.lookups, userIDs)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.details, nil
+}
+
+func TestTransactionEnricher_Enrich(t *testing.T) {
+	customer, purged := uuid.New(), uuid.New()
+	transactions := []models.Transaction{
+		{ID: uuid.New(), UserID: customer, Type: models.TransactionTypeDeposit},
+		{ID: uuid.New(), UserID: purged, Type: models.TransactionTypeDeposit},
+		{ID: uuid.New(), UserID: customer, Type: models.TransactionTypeWithdrawal},
+	}
+
+	source := &fakeUserDetailSource{details: map[string]models.UserDetail{
+		customer.String(): {UserID: customer.String(), Exists: true, Email: "client@example.com", Name: "Test Client"},
+		purged.String():   {UserID: purged.String()},
+	}}
+	enriched, err := NewTransactionEnricher(source).Enrich(transactions)
+	if err != nil {
+		t.Fatalf("Enrich returned error: %v", err)
+	}
+	if len(source.lookups) != 1 {
+		t.Errorf("Expected 1 lookup for the page, got %d", len(source.lookups))
+	}
+	if len(enriched) != 3 || enriched[2].ID != transactions[2].ID {
+		t.Fatalf("Expected the transactions in order, got %+v", enriched)
+	}
+	for _, i := range []int{0, 2} {
+		if enriched[i].CustomerEmail == nil || *enriched[i].CustomerEmail != "client@example.com" || enriched[i].CustomerName == nil || *enriched[i].CustomerName != "Test Client" {
+			t.Errorf("Expected transaction %d to have its customer's details, got %+v", i, enriched[i])
+		}
+	}
+	if enriched[1].CustomerEmail != nil || enriched[1].CustomerName != nil {
+		t.Errorf("Expected no details for an unknown customer, got %+v", enriched[1])
+	}
+}
+
+func TestTransactionEnricher_EnrichWithoutClientService(t *testing.T) {
+	transactions := []models.Transaction{{ID: uuid.New(), UserID: uuid.New()}}
+	source := &fakeUserDetailSource{err: &resilience.DependencyError{Dependency: "client-service", Err: errors.New("connection refused")}}
+
+	enriched, err := NewTransactionEnricher(source).Enrich(transactions)
+	if !errors.Is(err, resilience.ErrDependencyUnavailable) {
+		t.Errorf("Expected ErrDependencyUnavailable, got %v", err)
+	}
+	if len(enriched) != 1 || enriched[0].ID != transactions[0].ID || enriched[0].CustomerEmail != nil || enriched[0].CustomerName != nil {
+		t.Errorf("Expected the transaction without customer details, got %+v", enriched)
+	}
+
+	// An empty page needs no lookup
+	if enriched, err := NewTransactionEnricher(source).Enrich(nil); err != nil || len(enriched) != 0 || len(source.lookups) != 1 {
+		t.Errorf("Expected an empty page without a lookup, got %+v, %v (%d lookups)", enriched, err, len(source.lookups))
+	}
+}
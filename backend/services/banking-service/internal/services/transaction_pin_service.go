@@ -0,0 +1,199 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+)
+
+// PasswordVerifier checks users' passwords on the client-service. Failed
+// checks return an error matching resilience.ErrDependencyUnavailable.
+// *UserStatusClient satisfies it.
+type PasswordVerifier interface {
+	VerifyPassword(userID, password string) (bool, error)
+}
+
+// TransactionPINService manages the optional transaction PINs users set to
+// confirm withdrawals and transfers. PINs are stored as bcrypt hashes and
+// never logged; wrong PINs are counted and lock the PIN for a while once
+// there are too many in a row.
+type TransactionPINService struct {
+	pinRepo     repository.TransactionPINRepository
+	accountRepo repository.AccountRepository
+	passwords   PasswordVerifier
+	rules       models.TransactionPINRules
+	cost        int
+	now         func() time.Time
+}
+
+// NewTransactionPINService creates a new transaction PIN service locking
+// PINs as rules describe
+func NewTransactionPINService(pinRepo repository.TransactionPINRepository, accountRepo repository.AccountRepository, passwords PasswordVerifier, rules models.TransactionPINRules) *TransactionPINService {
+	return &TransactionPINService{
+		pinRepo:     pinRepo,
+		accountRepo: accountRepo,
+		passwords:   passwords,
+		rules:       rules,
+		cost:        bcrypt.DefaultCost,
+		now:         time.Now,
+	}
+}
+
+// Status returns whether the user has a transaction PIN, needs to set a
+// new one after a reset, or is locked out of it
+func (s *TransactionPINService) Status(userID uuid.UUID) (models.TransactionPINStatus, error) {
+	pin, err := s.pinRepo.GetByUserID(userID)
+	if err != nil {
+		return models.TransactionPINStatus{}, fmt.Errorf("failed to get transaction PIN: %w", err)
+	}
+	return pin.StatusAt(s.now()), nil
+}
+
+// SetPIN sets the user's transaction PIN. Changing a PIN needs the current
+// one or the user's password, and setting one after staff reset it needs
+// the password; wrong ones count towards the lockout like wrong PINs.
+func (s *TransactionPINService) SetPIN(userID uuid.UUID, request models.SetTransactionPINRequest) (models.TransactionPINStatus, error) {
+	account, err := s.accountRepo.GetAccountByUserID(userID)
+	if err != nil {
+		return models.TransactionPINStatus{}, fmt.Errorf("%w: %w", ErrAccountNotFound, err)
+	}
+
+	pin, err := s.pinRepo.GetByUserID(userID)
+	if err != nil {
+		return models.TransactionPINStatus{}, fmt.Errorf("failed to get transaction PIN: %w", err)
+	}
+	if pin != nil {
+		switch {
+		case request.CurrentPIN != "" && pin.PINHash != "":
+			err = s.attempt(pin, func() (bool, error) {
+				return pinMatches(pin.PINHash, request.CurrentPIN), nil
+			}, ErrInvalidTransactionPIN)
+		case request.Password != "":
+			err = s.attempt(pin, func() (bool, error) {
+				valid, err := s.passwords.VerifyPassword(userID.String(), request.Password)
+				if err != nil {
+					return false, fmt.Errorf("failed to verify password: %w", err)
+				}
+				return valid, nil
+			}, ErrInvalidPassword)
+		case pin.PINHash == "":
+			err = &ValidationError{Fields: []FieldError{{Field: "password", Rule: "required", Message: "is required to set a new PIN after a reset"}}}
+		default:
+			err = &ValidationError{Fields: []FieldError{{Field: "current_pin", Rule: "required_without", Message: "is required unless password is given"}}}
+		}
+		if err != nil {
+			return models.TransactionPINStatus{}, err
+		}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(request.PIN), s.cost)
+	if err != nil {
+		return models.TransactionPINStatus{}, fmt.Errorf("failed to hash transaction PIN: %w", err)
+	}
+	saved := &models.TransactionPIN{AccountID: account.ID, UserID: userID, PINHash: string(hash)}
+	if err := s.pinRepo.Save(saved); err != nil {
+		return models.TransactionPINStatus{}, fmt.Errorf("failed to save transaction PIN: %w", err)
+	}
+
+	return saved.StatusAt(s.now()), nil
+}
+
+// Verify checks the PIN given with a withdrawal or transfer. Users without
+// a PIN pass whatever is given. Otherwise it fails with
+// ErrTransactionPINResetRequired after a reset, ErrTransactionPINRequired
+// when no PIN is given, a *PINAttemptError when it is wrong and a
+// *TransactionPINLockedError while the PIN is locked.
+func (s *TransactionPINService) Verify(userID uuid.UUID, pin string) error {
+	record, err := s.pinRepo.GetByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction PIN: %w", err)
+	}
+
+	switch {
+	case record == nil:
+		return nil
+	case record.ResetRequired:
+		return ErrTransactionPINResetRequired
+	case pin == "":
+		return ErrTransactionPINRequired
+	}
+
+	return s.attempt(record, func() (bool, error) {
+		return pinMatches(record.PINHash, pin), nil
+	}, ErrInvalidTransactionPIN)
+}
+
+// Reset removes the transaction PIN of an account on behalf of staff.
+// Withdrawals and transfers are refused until its user sets a new one with
+// their password. It returns ErrTransactionPINNotSet when the account has
+// no PIN.
+func (s *TransactionPINService) Reset(accountID uuid.UUID, reset models.TransactionPINReset) error {
+	pin, err := s.pinRepo.Reset(accountID, reset)
+	if err != nil {
+		return fmt.Errorf("failed to reset transaction PIN: %w", err)
+	}
+	if pin == nil {
+		return ErrTransactionPINNotSet
+	}
+	return nil
+}
+
+// attempt counts an attempt at the PIN before running check, so attempts
+// made at the same time cannot get past the limit, and forgets it when
+// check passes. A failed check returns a *PINAttemptError wrapping wrong,
+// or a *TransactionPINLockedError once it locks the PIN.
+func (s *TransactionPINService) attempt(pin *models.TransactionPIN, check func() (bool, error), wrong error) error {
+	now := s.now()
+	if pin.Locked(now) {
+		return &TransactionPINLockedError{Until: *pin.LockedUntil}
+	}
+
+	counted, err := s.pinRepo.RecordAttempt(pin.AccountID, s.rules, now)
+	if err != nil {
+		return fmt.Errorf("failed to count transaction PIN attempt: %w", err)
+	}
+	if counted == nil {
+		// Attempts made at the same time locked it
+		return s.lockedError(pin.UserID, now)
+	}
+
+	ok, err := check()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if counted.Locked(now) {
+			return &TransactionPINLockedError{Until: *counted.LockedUntil}
+		}
+		return &PINAttemptError{Err: wrong, AttemptsRemaining: s.rules.MaxAttempts - counted.FailedAttempts}
+	}
+
+	if err := s.pinRepo.ClearAttempts(pin.AccountID); err != nil {
+		return fmt.Errorf("failed to clear transaction PIN attempts: %w", err)
+	}
+	return nil
+}
+
+// lockedError returns the lockout of the user's PIN, read again after an
+// attempt could not be counted
+func (s *TransactionPINService) lockedError(userID uuid.UUID, now time.Time) error {
+	pin, err := s.pinRepo.GetByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction PIN: %w", err)
+	}
+	if pin == nil || !pin.Locked(now) {
+		return fmt.Errorf("transaction PIN changed while it was checked")
+	}
+	return &TransactionPINLockedError{Until: *pin.LockedUntil}
+}
+
+// pinMatches reports whether pin is the one hashed. bcrypt compares the
+// hashes in constant time, so how long it takes says nothing about how
+// close the PIN was.
+func pinMatches(hash, pin string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(pin)) == nil
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"microbank/banking-service/internal/models"
+)
+
+// fakeTransactionPINRepo keeps transaction PINs in memory, counting
+// attempts as the database does
+type fakeTransactionPINRepo struct {
+	pins   map[uuid.UUID]*models.TransactionPIN
+	resets []models.TransactionPINReset
+}
+
+func (r *fakeTransactionPINRepo) GetByUserID(userID uuid.UUID) (*models.TransactionPIN, error) {
+	for _, pin := range r.pins {
+		if pin.UserID == userID {
+			clone := *pin
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeTransactionPINRepo) Save(pin *models.TransactionPIN) error {
+	pin.FailedAttempts = 0
+	pin.LockedUntil = nil
+	pin.ResetRequired = false
+	clone := *pin
+	r.pins[pin.AccountID] = &clone
+	return nil
+}
+
+func (r *fakeTransactionPINRepo) RecordAttempt(accountID uuid.UUID, rules models.TransactionPINRules, now time.Time) (*models.TransactionPIN, error) {
+	pin, ok := r.pins[accountID]
+	if !ok || pin.Locked(now) {
+		return nil, nil
+	}
+	if pin.LockedUntil != nil {
+		pin.FailedAttempts = 0
+		pin.LockedUntil = nil
+	}
+	pin.FailedAttempts++
+	if pin.FailedAttempts >= rules.MaxAttempts {
+		lockedUntil := now.Add(rules.Lockout)
+		pin.LockedUntil = &lockedUntil
+	}
+	clone := *pin
+	return &clone, nil
+}
+
+func (r *fakeTransactionPINRepo) ClearAttempts(accountID uuid.UUID) error {
+	if pin, ok := r.pins[accountID]; ok {
+		pin.FailedAttempts = 0
+		pin.LockedUntil = nil
+	}
+	return nil
+}
+
+func (r *fakeTransactionPINRepo) Reset(accountID uuid.UUID, reset models.TransactionPINReset) (*models.TransactionPIN, error) {
+	pin, ok := r.pins[accountID]
+	if !ok || pin.PINHash == "" {
+		return nil, nil
+	}
+	pin.PINHash = ""
+	pin.FailedAttempts = 0
+	pin.LockedUntil = nil
+	pin.ResetRequired = true
+	r.resets = append(r.resets, reset)
+	clone := *pin
+	return &clone, nil
+}
+
+// fakePasswordVerifier accepts one password for every user
+type fakePasswordVerifier struct {
+	password string
+	err      error
+}
+
+func (v *fakePasswordVerifier) VerifyPassword(userID, password string) (bool, error) {
+	if v.err != nil {
+		return false, v.err
+	}
+	return password == v.password, nil
+}
+
+// pinFixture is a user with an account and no PIN, with a service locking
+// after three wrong attempts for 15 minutes on a clock tests move
+type pinFixture struct {
+	user  uuid.UUID
+	pins  *fakeTransactionPINRepo
+	svc   *TransactionPINService
+	clock time.Time
+}
+
+func newPINFixture() *pinFixture {
+	f := &pinFixture{user: uuid.New(), clock: time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)}
+	f.pins = &fakeTransactionPINRepo{pins: map[uuid.UUID]*models.TransactionPIN{}}
+	f.svc = NewTransactionPINService(f.pins, newBeneficiaryAccounts(f.user), &fakePasswordVerifier{password: "Str0ng!Passw0rd"}, models.TransactionPINRules{MaxAttempts: 3, Lockout: 15 * time.Minute})
+	f.svc.cost = bcrypt.MinCost
+	f.svc.now = func() time.Time { return f.clock }
+	return f
+}
+
+func (f *pinFixture) setPIN(t *testing.T, request models.SetTransactionPINRequest) {
+	t.Helper()
+	if _, err := f.svc.SetPIN(f.user, request); err != nil {
+		t.Fatalf("SetPIN returned error: %v", err)
+	}
+}
+
+func TestTransactionPINService_Verify(t *testing.T) {
+	f := newPINFixture()
+
+	if err := f.svc.Verify(f.user, ""); err != nil {
+		t.Errorf("Expected users without a PIN to pass, got %v", err)
+	}
+
+	f.setPIN(t, models.SetTransactionPINRequest{PIN: "4821"})
+	for _, pin := range f.pins.pins {
+		if pin.PINHash == "" || pin.PINHash == "4821" {
+			t.Fatalf("Expected the PIN to be stored hashed, got %q", pin.PINHash)
+		}
+	}
+
+	if err := f.svc.Verify(f.user, ""); !errors.Is(err, ErrTransactionPINRequired) {
+		t.Errorf("Expected ErrTransactionPINRequired without a PIN, got %v", err)
+	}
+	if err := f.svc.Verify(f.user, "4821"); err != nil {
+		t.Errorf("Expected the right PIN to pass, got %v", err)
+	}
+
+	// Two wrong PINs leave one attempt, and a right one forgets them
+	var attempt *PINAttemptError
+	for _, want := range []int{2, 1} {
+		if err := f.svc.Verify(f.user, "0000"); !errors.As(err, &attempt) || !errors.Is(err, ErrInvalidTransactionPIN) || attempt.AttemptsRemaining != want {
+			t.Fatalf("Expected a wrong PIN with %d attempts remaining, got %v", want, err)
+		}
+	}
+	if err := f.svc.Verify(f.user, "4821"); err != nil {
+		t.Fatalf("Expected the right PIN to pass, got %v", err)
+	}
+	if err := f.svc.Verify(f.user, "0000"); !errors.As(err, &attempt) || attempt.AttemptsRemaining != 2 {
+		t.Errorf("Expected the right PIN to reset the count, got %v", err)
+	}
+}
+
+func TestTransactionPINService_Lockout(t *testing.T) {
+	f := newPINFixture()
+	f.setPIN(t, models.SetTransactionPINRequest{PIN: "4821"})
+
+	f.svc.Verify(f.user, "0000")
+	f.svc.Verify(f.user, "1111")
+	var locked *TransactionPINLockedError
+	if err := f.svc.Verify(f.user, "2222"); !errors.As(err, &locked) || !errors.Is(err, ErrTransactionPINLocked) || !locked.Until.Equal(f.clock.Add(15*time.Minute)) {
+		t.Fatalf("Expected the third wrong PIN to lock it for 15 minutes, got %v", err)
+	}
+
+	// Even the right PIN is refused while locked, and not counted
+	f.clock = f.clock.Add(15*time.Minute - time.Second)
+	if err := f.svc.Verify(f.user, "4821"); !errors.Is(err, ErrTransactionPINLocked) {
+		t.Errorf("Expected the right PIN to be refused while locked, got %v", err)
+	}
+	if _, err := f.svc.SetPIN(f.user, models.SetTransactionPINRequest{PIN: "9999", CurrentPIN: "4821"}); !errors.Is(err, ErrTransactionPINLocked) {
+		t.Errorf("Expected changing the PIN to be refused while locked, got %v", err)
+	}
+	if status, err := f.svc.Status(f.user); err != nil || status.LockedUntil == nil || !status.PINSet {
+		t.Errorf("Expected the status to show the lockout, got %+v (%v)", status, err)
+	}
+
+	// Once the lockout ends attempts are counted afresh
+	f.clock = f.clock.Add(time.Second)
+	var attempt *PINAttemptError
+	if err := f.svc.Verify(f.user, "0000"); !errors.As(err, &attempt) || attempt.AttemptsRemaining != 2 {
+		t.Errorf("Expected a fresh count after the lockout, got %v", err)
+	}
+	if err := f.svc.Verify(f.user, "4821"); err != nil {
+		t.Errorf("Expected the right PIN to pass after the lockout, got %v", err)
+	}
+}
+
+func TestTransactionPINService_SetPIN(t *testing.T) {
+	f := newPINFixture()
+
+	if _, err := f.svc.SetPIN(uuid.New(), models.SetTransactionPINRequest{PIN: "4821"}); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("Expected ErrAccountNotFound for a user without an account, got %v", err)
+	}
+
+	f.setPIN(t, models.SetTransactionPINRequest{PIN: "4821"})
+
+	tests := []struct {
+		name      string
+		request   models.SetTransactionPINRequest
+		wantField string
+		wantErr   error
+	}{
+		{name: "neither current PIN nor password", request: models.SetTransactionPINRequest{PIN: "1234"}, wantField: "current_pin"},
+		{name: "wrong current PIN", request: models.SetTransactionPINRequest{PIN: "1234", CurrentPIN: "0000"}, wantErr: ErrInvalidTransactionPIN},
+		{name: "wrong password", request: models.SetTransactionPINRequest{PIN: "1234", Password: "guess"}, wantErr: ErrInvalidPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.svc.SetPIN(f.user, tt.request)
+			var validationErr *ValidationError
+			switch {
+			case tt.wantField != "":
+				if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != tt.wantField {
+					t.Errorf("Expected a validation error on %s, got %v", tt.wantField, err)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+	if err := f.svc.Verify(f.user, "4821"); err != nil {
+		t.Fatalf("Expected the PIN to be unchanged, got %v", err)
+	}
+
+	f.setPIN(t, models.SetTransactionPINRequest{PIN: "1234", CurrentPIN: "4821"})
+	f.setPIN(t, models.SetTransactionPINRequest{PIN: "5678", Password: "Str0ng!Passw0rd"})
+	if err := f.svc.Verify(f.user, "5678"); err != nil {
+		t.Errorf("Expected the changed PIN to pass, got %v", err)
+	}
+
+	f.svc.passwords = &fakePasswordVerifier{err: errors.New("client-service down")}
+	if _, err := f.svc.SetPIN(f.user, models.SetTransactionPINRequest{PIN: "1234", Password: "Str0ng!Passw0rd"}); err == nil {
+		t.Error("Expected an error when the password cannot be checked")
+	}
+}
+
+func TestTransactionPINService_Reset(t *testing.T) {
+	f := newPINFixture()
+	account, _ := f.svc.accountRepo.GetAccountByUserID(f.user)
+	staffID := uuid.New()
+
+	if err := f.svc.Reset(account.ID, models.TransactionPINReset{ActorID: staffID}); !errors.Is(err, ErrTransactionPINNotSet) {
+		t.Errorf("Expected ErrTransactionPINNotSet without a PIN, got %v", err)
+	}
+
+	f.setPIN(t, models.SetTransactionPINRequest{PIN: "4821"})
+	if err := f.svc.Reset(account.ID, models.TransactionPINReset{ActorID: staffID, RequestID: "req-1"}); err != nil {
+		t.Fatalf("Reset returned error: %v", err)
+	}
+	if len(f.pins.resets) != 1 || f.pins.resets[0].ActorID != staffID {
+		t.Errorf("Expected the reset to be recorded, got %+v", f.pins.resets)
+	}
+	if err := f.svc.Reset(account.ID, models.TransactionPINReset{ActorID: staffID}); !errors.Is(err, ErrTransactionPINNotSet) {
+		t.Errorf("Expected a second reset to find no PIN, got %v", err)
+	}
+
+	// Even the old PIN is refused until the user sets a new one
+	if err := f.svc.Verify(f.user, "4821"); !errors.Is(err, ErrTransactionPINResetRequired) {
+		t.Errorf("Expected ErrTransactionPINResetRequired after a reset, got %v", err)
+	}
+	if status, _ := f.svc.Status(f.user); status.PINSet || !status.ResetRequired {
+		t.Errorf("Expected the status to ask for a new PIN, got %+v", status)
+	}
+
+	// Only the password will do to set it
+	var validationErr *ValidationError
+	if _, err := f.svc.SetPIN(f.user, models.SetTransactionPINRequest{PIN: "1234", CurrentPIN: "4821"}); !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "password" {
+		t.Errorf("Expected the password to be required after a reset, got %v", err)
+	}
+	f.setPIN(t, models.SetTransactionPINRequest{PIN: "1234", Password: "Str0ng!Passw0rd"})
+	if err := f.svc.Verify(f.user, "1234"); err != nil {
+		t.Errorf("Expected the new PIN to pass, got %v", err)
+	}
+}
@@ -1,117 +1,810 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"microbank/banking-service/internal/models"
 	"microbank/banking-service/internal/repository"
+	"microbank/pkg/money"
 )
 
+// transactionExportBatchSize is how many transactions an export reads at a
+// time
+const transactionExportBatchSize = 500
+
+// minVersionReads is how many times an account is read waiting for it to
+// reach a min_version, minVersionRetryDelay apart
+const (
+	minVersionReads      = 5
+	minVersionRetryDelay = 50 * time.Millisecond
+)
+
+// UserStatusSource looks up a user's current status on the client-service.
+// *UserStatusClient satisfies it.
+type UserStatusSource interface {
+	GetUserStatus(userID string) (models.UserStatus, error)
+}
+
+// DepositBonuses pays the promotion bonuses a saved deposit earned.
+// *PromotionService satisfies it.
+type DepositBonuses interface {
+	Evaluate(deposit *models.Transaction) ([]models.Transaction, error)
+}
+
 // TransactionService handles transaction-related business logic
 type TransactionService struct {
 	transactionRepo repository.TransactionRepository
 	accountRepo     repository.AccountRepository
+	userStatuses    UserStatusSource
+	kycLimit        float64
+	fees            models.WithdrawalFees
+	goalRepo        repository.SavingsGoalRepository
+	beneficiaryRepo repository.BeneficiaryRepository
+	beneficiaries   models.BeneficiaryRules
+	// externalTransferRepo holds the funds of pending external transfers
+	externalTransferRepo repository.ExternalTransferRepository
+	// chequeRepo records cheque deposits, holding them until they clear
+	chequeRepo repository.ChequeRepository
+	cheques    models.ChequeRules
+	// bonuses pays promotion bonuses on deposits
+	bonuses DepositBonuses
+	// memberRepo lets members of joint accounts use them
+	memberRepo repository.AccountMemberRepository
+	// descriptionLength is how many characters descriptions are cut to
+	descriptionLength int
+	now               func() time.Time
 }
 
 // NewTransactionService creates a new transaction service
 func NewTransactionService(transactionRepo repository.TransactionRepository, accountRepo repository.AccountRepository) *TransactionService {
 	return &TransactionService{
-		transactionRepo: transactionRepo,
-		accountRepo:     accountRepo,
+		transactionRepo:   transactionRepo,
+		accountRepo:       accountRepo,
+		descriptionLength: DefaultDescriptionLength,
+		now:               time.Now,
 	}
 }
 
-// ProcessDeposit processes a deposit transaction
-func (s *TransactionService) ProcessDeposit(userID uuid.UUID, amount float64, description string) (*models.Transaction, error) {
-	// Validate amount
-	if amount <= 0 {
-		return nil, fmt.Errorf("deposit amount must be greater than zero")
+// WithKYCLimit makes withdrawals of more than limit require a verified
+// identity, looking the user's KYC status up in statuses. Without it no
+// withdrawal is checked.
+func (s *TransactionService) WithKYCLimit(statuses UserStatusSource, limit float64) *TransactionService {
+	s.userStatuses = statuses
+	s.kycLimit = limit
+	return s
+}
+
+// WithWithdrawalFees charges fees on withdrawals beyond the free monthly
+// allowance. Without it withdrawals are free.
+func (s *TransactionService) WithWithdrawalFees(fees models.WithdrawalFees) *TransactionService {
+	s.fees = fees
+	return s
+}
+
+// WithSavingsGoals keeps withdrawals from taking money earmarked for the
+// savings goals in goalRepo without saying so. Without it earmarks are
+// ignored.
+func (s *TransactionService) WithSavingsGoals(goalRepo repository.SavingsGoalRepository) *TransactionService {
+	s.goalRepo = goalRepo
+	return s
+}
+
+// WithBeneficiaries lets transfers be made to the beneficiaries in
+// beneficiaryRepo, and holds transfers to the cooling-off period of rules.
+// Without it transfers can only be made to an account ID, and none is
+// held.
+func (s *TransactionService) WithBeneficiaries(beneficiaryRepo repository.BeneficiaryRepository, rules models.BeneficiaryRules) *TransactionService {
+	s.beneficiaryRepo = beneficiaryRepo
+	s.beneficiaries = rules
+	return s
+}
+
+// WithExternalTransfers lets money be sent to other banks through
+// externalTransferRepo, and keeps withdrawals and transfers from spending
+// what pending external transfers hold. Without it no external transfer
+// can be made.
+func (s *TransactionService) WithExternalTransfers(externalTransferRepo repository.ExternalTransferRepository) *TransactionService {
+	s.externalTransferRepo = externalTransferRepo
+	return s
+}
+
+// WithCheques lets staff record cheque deposits through chequeRepo, held
+// for the default hold of rules unless they say otherwise, and keeps
+// withdrawals and transfers from spending cheques still on hold. Without it
+// no cheque can be deposited.
+func (s *TransactionService) WithCheques(chequeRepo repository.ChequeRepository, rules models.ChequeRules) *TransactionService {
+	s.chequeRepo = chequeRepo
+	s.cheques = rules
+	return s
+}
+
+// WithPromotions pays the bonuses of the promotions in bonuses on the
+// deposits that earn them. Without it no bonus is paid.
+func (s *TransactionService) WithPromotions(bonuses DepositBonuses) *TransactionService {
+	s.bonuses = bonuses
+	return s
+}
+
+// WithJointAccounts lets users select the accounts memberRepo makes them
+// members of, and holds members to their spending limits. Without it users
+// only use their own account.
+func (s *TransactionService) WithJointAccounts(memberRepo repository.AccountMemberRepository) *TransactionService {
+	s.memberRepo = memberRepo
+	return s
+}
+
+// WithDescriptionLength cuts transaction descriptions to length characters
+// instead of DefaultDescriptionLength
+func (s *TransactionService) WithDescriptionLength(length int) *TransactionService {
+	s.descriptionLength = length
+	return s
+}
+
+// AccountFor returns the account a user acts on, their own unless
+// accountID selects a joint account they are a member of, with their
+// membership of it. Accounts they cannot use are ErrAccountNotFound.
+func (s *TransactionService) AccountFor(userID uuid.UUID, accountID *uuid.UUID) (*models.Account, *models.AccountMember, error) {
+	return memberAccount(s.accountRepo, s.memberRepo, userID, accountID)
+}
+
+// AccountAtVersion returns the account a user acts on, as AccountFor does,
+// reading it again from the primary database until it reaches minVersion
+// so a client reads its own writes. It waits for at most minVersionReads
+// reads and then returns the newest account it read, whatever its version.
+func (s *TransactionService) AccountAtVersion(userID uuid.UUID, accountID *uuid.UUID, minVersion int64) (*models.Account, error) {
+	account, _, err := s.AccountFor(userID, accountID)
+	for reads := 1; err == nil && account.Version < minVersion && reads < minVersionReads; reads++ {
+		time.Sleep(minVersionRetryDelay)
+		account, _, err = s.AccountFor(userID, accountID)
+	}
+	if err != nil {
+		return nil, err
 	}
+	return account, nil
+}
 
-	// Get or create account for user
-	account, err := s.accountRepo.GetOrCreateAccount(userID)
+// ProcessDeposit processes a deposit transaction, and returns it with the
+// promotion bonuses it earned. The deposit stands even when a bonus cannot
+// be paid.
+func (s *TransactionService) ProcessDeposit(userID uuid.UUID, request models.TransactionRequest) (*models.Transaction, []models.Transaction, error) {
+	transaction, err := s.prepareDeposit(userID, request.AccountID, request.Amount, request.Description)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get or create account: %w", err)
+		return nil, nil, err
 	}
 
-	// Calculate new balance
-	balanceBefore := account.Balance
-	balanceAfter := balanceBefore + amount
+	// Save the transaction and update the account balance together
+	if err := s.transactionRepo.CreateDeposit(transaction); err != nil {
+		return nil, nil, fmt.Errorf("failed to save transaction: %w", err)
+	}
+
+	return transaction, s.payBonuses(transaction), nil
+}
+
+// payBonuses pays the promotion bonuses a saved deposit earned, logging
+// those that cannot be paid
+func (s *TransactionService) payBonuses(deposit *models.Transaction) []models.Transaction {
+	if s.bonuses == nil {
+		return nil
+	}
+	bonuses, err := s.bonuses.Evaluate(deposit)
+	if err != nil {
+		log.Printf("Failed to pay promotion bonuses on deposit %s: %v", deposit.ID, err)
+	}
+	return bonuses
+}
+
+// prepareDeposit checks a deposit into the account accountID selects and
+// creates its transaction record. Without a selection it goes to the
+// user's own account, which is created if they have none. The balances are
+// set when the deposit is saved.
+func (s *TransactionService) prepareDeposit(userID uuid.UUID, accountID *uuid.UUID, amount float64, description string) (*models.Transaction, error) {
+	// Validate amount
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: deposit amount must be greater than zero", ErrInvalidAmount)
+	}
+
+	// Get or create account for user, or the joint account they selected
+	var account *models.Account
+	var err error
+	if accountID == nil {
+		account, err = s.accountRepo.GetOrCreateAccount(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get or create account: %w", err)
+		}
+	} else if account, _, err = s.AccountFor(userID, accountID); err != nil {
+		return nil, err
+	}
+	if account.IsFrozen() {
+		return nil, ErrAccountFrozen
+	}
 
 	// Create transaction record
 	transaction := &models.Transaction{
-		ID:            uuid.New(),
-		AccountID:     account.ID,
-		UserID:        userID,
-		Type:          models.TransactionTypeDeposit,
-		Amount:        amount,
-		BalanceBefore: balanceBefore,
-		BalanceAfter:  balanceAfter,
-		Description:   description,
-		CreatedAt:     time.Now(),
+		ID:        uuid.New(),
+		AccountID: account.ID,
+		UserID:    userID,
+		Type:      models.TransactionTypeDeposit,
+		Amount:    amount,
+		CreatedAt: s.now(),
 	}
+	s.describe(transaction, description)
+	return transaction, nil
+}
 
-	// Save transaction to database
-	if err := s.transactionRepo.CreateTransaction(transaction); err != nil {
-		return nil, fmt.Errorf("failed to save transaction: %w", err)
+// ProcessChequeDeposit records a cheque taken by the staff member staffID
+// for a user with an account, returning ErrAccountNotFound for users
+// without one. Its amount is deposited at once but held until
+// request.HoldUntil, or the default hold from now, which must be in the
+// future and at most models.MaxChequeHold away.
+func (s *TransactionService) ProcessChequeDeposit(staffID uuid.UUID, request models.ChequeDepositRequest) (*models.ChequeDeposit, *models.Transaction, error) {
+	if s.chequeRepo == nil {
+		return nil, nil, fmt.Errorf("cheque deposits are not enabled")
 	}
 
-	// Update account balance
-	if err := s.accountRepo.UpdateBalance(account.ID, balanceAfter); err != nil {
-		return nil, fmt.Errorf("failed to update account balance: %w", err)
+	now := s.now()
+	holdUntil := now.Add(s.cheques.DefaultHold)
+	if request.HoldUntil != nil {
+		holdUntil = *request.HoldUntil
+		if !holdUntil.After(now) || holdUntil.After(now.Add(models.MaxChequeHold)) {
+			return nil, nil, &ValidationError{Fields: []FieldError{{Field: "hold_until", Rule: "range", Message: "must be in the future and at most 30 days away"}}}
+		}
 	}
 
-	return transaction, nil
+	// Cheques are only taken for customers who have an account
+	account, err := s.accountRepo.GetAccountByUserID(request.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrAccountNotFound, err)
+	}
+	if account.IsFrozen() {
+		return nil, nil, ErrAccountFrozen
+	}
+
+	transaction := &models.Transaction{
+		ID:        uuid.New(),
+		AccountID: account.ID,
+		UserID:    request.UserID,
+		Type:      models.TransactionTypeDeposit,
+		Amount:    request.Amount,
+		CreatedAt: now,
+	}
+	description := request.Description
+	if strings.TrimSpace(description) == "" {
+		description = "Cheque deposit #" + request.ChequeNumber
+	}
+	s.describe(transaction, description)
+
+	cheque := &models.ChequeDeposit{
+		ID:           uuid.New(),
+		UserID:       request.UserID,
+		AccountID:    account.ID,
+		Amount:       request.Amount,
+		ChequeNumber: request.ChequeNumber,
+		HoldUntil:    holdUntil,
+		RecordedBy:   staffID,
+	}
+
+	// Save the deposit, its balance change and the hold together
+	if err := s.chequeRepo.Create(cheque, transaction); err != nil {
+		return nil, nil, fmt.Errorf("failed to save cheque deposit: %w", err)
+	}
+
+	return cheque, transaction, nil
 }
 
-// ProcessWithdrawal processes a withdrawal transaction
-func (s *TransactionService) ProcessWithdrawal(userID uuid.UUID, amount float64, description string) (*models.Transaction, error) {
+// describe sets the description of transaction, cleaned up by
+// sanitizeDescription. Descriptions cut short keep their original as
+// RawDescription.
+func (s *TransactionService) describe(transaction *models.Transaction, description string) {
+	transaction.Description, transaction.RawDescription = sanitizeDescription(description, s.descriptionLength)
+}
+
+// ProcessWithdrawal processes a withdrawal transaction. A fee charged on
+// the withdrawal is posted as a second transaction linked to it. Money
+// earmarked for savings goals is only used when the rest of the balance
+// does not cover the withdrawal and its fee: strict goals then refuse it,
+// and other goals give up what is needed, newest goal first. Otherwise the
+// withdrawal may be rounded up into a goal, as described by addRoundUp.
+// Members of joint accounts withdraw at most their spending limit at once.
+func (s *TransactionService) ProcessWithdrawal(userID uuid.UUID, request models.TransactionRequest) (*models.Withdrawal, error) {
+	amount, description := request.Amount, request.Description
+
 	// Validate amount
 	if amount <= 0 {
-		return nil, fmt.Errorf("withdrawal amount must be greater than zero")
+		return nil, fmt.Errorf("%w: withdrawal amount must be greater than zero", ErrInvalidAmount)
 	}
 
-	// Get account for user
-	account, err := s.accountRepo.GetAccountByUserID(userID)
+	// Get account for user, or the joint account they selected
+	account, member, err := s.AccountFor(userID, request.AccountID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get account: %w", err)
+		return nil, err
+	}
+	if account.IsFrozen() {
+		return nil, ErrAccountFrozen
+	}
+	if err := checkSpendingLimit(member, amount); err != nil {
+		return nil, err
 	}
 
-	// Check if user has sufficient funds
-	if account.Balance < amount {
-		return nil, fmt.Errorf("insufficient funds: requested %f, available %f", amount, account.Balance)
+	// Large withdrawals need a verified identity
+	if err := s.checkKYC(userID, amount); err != nil {
+		return nil, err
 	}
 
-	// Calculate new balance
-	balanceBefore := account.Balance
-	balanceAfter := balanceBefore - amount
+	// Withdrawals beyond the free monthly allowance are charged a fee
+	now := s.now()
+	feeAmount, err := s.withdrawalFee(account.ID, amount, now)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create transaction record
-	transaction := &models.Transaction{
-		ID:            uuid.New(),
-		AccountID:     account.ID,
-		UserID:        userID,
-		Type:          models.TransactionTypeWithdrawal,
-		Amount:        amount,
-		BalanceBefore: balanceBefore,
-		BalanceAfter:  balanceAfter,
-		Description:   description,
-		CreatedAt:     time.Now(),
+	// Check if user has sufficient funds for the withdrawal and its fee,
+	// leaving what is held for pending external transfers and cheques
+	held, err := s.HeldFunds(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if available := roundCents(account.Balance - held); available < roundCents(amount+feeAmount) {
+		return nil, &InsufficientFundsError{Requested: amount, Fee: feeAmount, Available: available}
 	}
 
-	// Save transaction to database
-	if err := s.transactionRepo.CreateTransaction(transaction); err != nil {
-		return nil, fmt.Errorf("failed to save transaction: %w", err)
+	// Take earmarked money only from goals that allow it
+	releases, err := s.goalReleases(account, held, amount, feeAmount)
+	if err != nil {
+		return nil, err
 	}
 
-	// Update account balance
-	if err := s.accountRepo.UpdateBalance(account.ID, balanceAfter); err != nil {
-		return nil, fmt.Errorf("failed to update account balance: %w", err)
+	// Create transaction records
+	withdrawal := &models.Withdrawal{
+		Transaction: &models.Transaction{
+			ID:            uuid.New(),
+			AccountID:     account.ID,
+			UserID:        userID,
+			Type:          models.TransactionTypeWithdrawal,
+			Amount:        amount,
+			BalanceBefore: account.Balance,
+			BalanceAfter:  account.Balance - amount,
+			CreatedAt:     now,
+		},
+		Fees:         s.fees,
+		GoalReleases: releases,
+	}
+	s.describe(withdrawal.Transaction, description)
+	if feeAmount > 0 {
+		withdrawal.Fee = models.NewWithdrawalFee(withdrawal.Transaction, feeAmount)
 	}
 
-	return transaction, nil
+	// Withdrawals that keep clear of earmarked money may be rounded up
+	if len(releases) == 0 {
+		s.addRoundUp(withdrawal, held)
+	}
+
+	// Save the transactions, release goal funds and update the account
+	// balance together; the free allowance and the funds are checked again
+	// with the account locked
+	if err := s.transactionRepo.CreateWithdrawal(withdrawal); err != nil {
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			if withdrawal.Fee != nil {
+				feeAmount = withdrawal.Fee.Amount
+			}
+			return nil, &InsufficientFundsError{Requested: amount, Fee: feeAmount}
+		}
+		return nil, fmt.Errorf("failed to save withdrawal: %w", err)
+	}
+
+	return withdrawal, nil
+}
+
+// ProcessTransfer moves money from the user's account, or the joint
+// account they select, to another, given by its ID or by one of the user's
+// beneficiaries, as a transfer_out transaction on the user's account and a
+// transfer_in on the other, each linked to the other. Transfers are free
+// and never take money earmarked for savings goals or held by pending
+// external transfers and cheques on hold. Transfers over the large
+// transfer limit need the destination to have been a beneficiary for the
+// cooling-off period, however it is given, and a verified identity as
+// withdrawals do.
+func (s *TransactionService) ProcessTransfer(userID uuid.UUID, request models.TransferRequest) (*models.Transfer, error) {
+	if request.Amount <= 0 {
+		return nil, fmt.Errorf("%w: transfer amount must be greater than zero", ErrInvalidAmount)
+	}
+	if (request.DestinationAccountID == nil) == (request.BeneficiaryID == nil) {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "destination_account_id", Rule: "required_without", Message: "exactly one of destination_account_id and beneficiary_id is required"}}}
+	}
+
+	account, member, err := s.AccountFor(userID, request.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.IsFrozen() {
+		return nil, ErrAccountFrozen
+	}
+	if err := checkSpendingLimit(member, request.Amount); err != nil {
+		return nil, err
+	}
+
+	beneficiary, destinationID, err := s.transferDestination(userID, request)
+	if err != nil {
+		return nil, err
+	}
+	if destinationID == account.ID {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "destination_account_id", Rule: "ne", Message: "must be another account"}}}
+	}
+
+	destination, err := s.accountRepo.GetAccountByID(destinationID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDestinationAccountNotFound, err)
+	}
+	if destination.IsFrozen() {
+		return nil, ErrDestinationAccountUnavailable
+	}
+
+	// Large transfers only go to beneficiaries past their cooling-off
+	// period
+	now := s.now()
+	if s.beneficiaryRepo != nil && request.Amount > s.beneficiaries.LargeTransferLimit {
+		if beneficiary == nil {
+			return nil, &BeneficiaryCoolingOffError{Limit: s.beneficiaries.LargeTransferLimit}
+		}
+		if allowedAt := beneficiary.LargeTransfersAllowedAt(s.beneficiaries); now.Before(allowedAt) {
+			return nil, &BeneficiaryCoolingOffError{Limit: s.beneficiaries.LargeTransferLimit, AllowedAt: &allowedAt}
+		}
+	}
+
+	// Large transfers need a verified identity
+	if err := s.checkKYC(userID, request.Amount); err != nil {
+		return nil, err
+	}
+
+	// Only money no savings goal earmarks and no external transfer holds
+	// can be transferred
+	if err := s.checkSpendable(account, request.Amount); err != nil {
+		return nil, err
+	}
+
+	// Create transaction records. Their balances are set when they are
+	// saved, from the locked accounts.
+	transfer := &models.Transfer{
+		Out: &models.Transaction{
+			ID:        uuid.New(),
+			AccountID: account.ID,
+			UserID:    userID,
+			Type:      models.TransactionTypeTransferOut,
+			Amount:    request.Amount,
+			CreatedAt: now,
+		},
+		In: &models.Transaction{
+			ID:        uuid.New(),
+			AccountID: destination.ID,
+			UserID:    destination.UserID,
+			Type:      models.TransactionTypeTransferIn,
+			Amount:    request.Amount,
+			CreatedAt: now,
+		},
+	}
+	if beneficiary != nil {
+		transfer.BeneficiaryID = &beneficiary.ID
+	}
+	transfer.Out.RelatedTransactionID = &transfer.In.ID
+	transfer.In.RelatedTransactionID = &transfer.Out.ID
+	s.describe(transfer.Out, request.Description)
+	transfer.In.Description, transfer.In.RawDescription = transfer.Out.Description, transfer.Out.RawDescription
+
+	// Save both transactions and move the money together
+	if err := s.transactionRepo.CreateTransfer(transfer); err != nil {
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return nil, &InsufficientFundsError{Requested: request.Amount}
+		}
+		return nil, fmt.Errorf("failed to save transfer: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// transferDestination returns the account a transfer goes to and the
+// user's beneficiary for it, which is nil when they have not saved it
+func (s *TransactionService) transferDestination(userID uuid.UUID, request models.TransferRequest) (*models.Beneficiary, uuid.UUID, error) {
+	if request.BeneficiaryID != nil {
+		if s.beneficiaryRepo == nil {
+			return nil, uuid.Nil, ErrBeneficiaryNotFound
+		}
+		beneficiary, err := s.beneficiaryRepo.GetByID(*request.BeneficiaryID)
+		if err != nil {
+			return nil, uuid.Nil, fmt.Errorf("failed to get beneficiary: %w", err)
+		}
+		if beneficiary == nil || beneficiary.UserID != userID {
+			return nil, uuid.Nil, ErrBeneficiaryNotFound
+		}
+		return beneficiary, beneficiary.AccountID, nil
+	}
+
+	if s.beneficiaryRepo == nil {
+		return nil, *request.DestinationAccountID, nil
+	}
+	beneficiary, err := s.beneficiaryRepo.GetByAccountID(userID, *request.DestinationAccountID)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("failed to get beneficiary: %w", err)
+	}
+	return beneficiary, *request.DestinationAccountID, nil
+}
+
+// ProcessExternalTransfer sends money from the user's account, or the
+// joint account they select, to an account at another bank. The transfer
+// is pending until it settles: its amount stays in the balance but is
+// held, so it cannot be spent, and is only taken out when the transfer
+// completes. Like transfers, external transfers never take money earmarked
+// for savings goals, and large ones need a verified identity.
+func (s *TransactionService) ProcessExternalTransfer(userID uuid.UUID, request models.ExternalTransferRequest) (*models.ExternalTransfer, error) {
+	if s.externalTransferRepo == nil {
+		return nil, fmt.Errorf("external transfers are not enabled")
+	}
+	if request.Amount <= 0 {
+		return nil, fmt.Errorf("%w: transfer amount must be greater than zero", ErrInvalidAmount)
+	}
+	holder := strings.TrimSpace(request.AccountHolderName)
+	var fields []FieldError
+	if !validRoutingNumber(request.RoutingNumber) {
+		fields = append(fields, FieldError{Field: "routing_number", Rule: "aba", Message: "is not a valid routing number"})
+	}
+	if holder == "" {
+		fields = append(fields, FieldError{Field: "account_holder_name", Rule: "required", Message: "is required"})
+	}
+	if len(fields) > 0 {
+		return nil, &ValidationError{Fields: fields}
+	}
+
+	account, member, err := s.AccountFor(userID, request.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.IsFrozen() {
+		return nil, ErrAccountFrozen
+	}
+	if err := checkSpendingLimit(member, request.Amount); err != nil {
+		return nil, err
+	}
+
+	// Large transfers need a verified identity
+	if err := s.checkKYC(userID, request.Amount); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSpendable(account, request.Amount); err != nil {
+		return nil, err
+	}
+
+	transfer := &models.ExternalTransfer{
+		ID:                uuid.New(),
+		UserID:            userID,
+		AccountID:         account.ID,
+		Amount:            request.Amount,
+		RoutingNumber:     request.RoutingNumber,
+		AccountNumber:     request.AccountNumber,
+		AccountHolderName: holder,
+	}
+	transfer.Description, _ = sanitizeDescription(request.Description, s.descriptionLength)
+
+	// Hold the amount; the account is locked while holds are added up
+	if err := s.externalTransferRepo.Create(transfer); err != nil {
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return nil, &InsufficientFundsError{Requested: request.Amount}
+		}
+		return nil, fmt.Errorf("failed to save external transfer: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// addRoundUp earmarks the change of a withdrawal up to the next whole unit
+// for the user's round-up goal, when they have one and the change is
+// available once the withdrawal and its fee are paid and held is set
+// aside. A round-up never fails the withdrawal, so problems are logged and
+// the round-up skipped.
+func (s *TransactionService) addRoundUp(withdrawal *models.Withdrawal, held float64) {
+	if s.goalRepo == nil {
+		return
+	}
+	transaction := withdrawal.Transaction
+	amount := roundCents(math.Ceil(transaction.Amount) - transaction.Amount)
+	if amount <= 0 {
+		return
+	}
+
+	goal, err := s.goalRepo.GetRoundUpGoal(transaction.AccountID)
+	if err != nil {
+		log.Printf("Skipping round-up of withdrawal %s: %v", transaction.ID, err)
+		return
+	}
+	if goal == nil {
+		return
+	}
+	goals, err := s.goalRepo.ListByAccountID(transaction.AccountID)
+	if err != nil {
+		log.Printf("Skipping round-up of withdrawal %s: %v", transaction.ID, err)
+		return
+	}
+
+	balance := transaction.BalanceAfter
+	if withdrawal.Fee != nil {
+		balance = withdrawal.Fee.BalanceAfter
+	}
+	earmarked, _ := earmarkedFunds(goals)
+	if roundCents(balance-earmarked-held) < amount {
+		return
+	}
+
+	withdrawal.RoundUp = &models.Transaction{
+		ID:                   uuid.New(),
+		AccountID:            transaction.AccountID,
+		UserID:               transaction.UserID,
+		Type:                 models.TransactionTypeRoundUp,
+		Amount:               amount,
+		BalanceBefore:        balance,
+		BalanceAfter:         balance,
+		CreatedAt:            transaction.CreatedAt,
+		RelatedTransactionID: &transaction.ID,
+	}
+	s.describe(withdrawal.RoundUp, "Round-up to "+goal.Name)
+	withdrawal.RoundUpGoalID = goal.ID
+}
+
+// goalReleases returns the earmarked money a withdrawal of amount and fee
+// takes from the account's savings goals, or an *EarmarkedFundsError when
+// it would need money earmarked for strict goals. Held funds, of pending
+// external transfers and cheques on hold, are never released.
+func (s *TransactionService) goalReleases(account *models.Account, held, amount, fee float64) ([]models.SavingsGoalRelease, error) {
+	if s.goalRepo == nil {
+		return nil, nil
+	}
+
+	goals, err := s.goalRepo.ListByAccountID(account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get savings goals: %w", err)
+	}
+
+	earmarked, strict := earmarkedFunds(goals)
+	shortfall := roundCents(amount + fee - (account.Balance - held - earmarked))
+	if shortfall <= 0 {
+		return nil, nil
+	}
+	if withdrawable := roundCents(account.Balance - held - strict); roundCents(amount+fee) > withdrawable {
+		return nil, &EarmarkedFundsError{Requested: amount, Fee: fee, Withdrawable: withdrawable}
+	}
+
+	// Goals are listed oldest first; the newest have had the least time to
+	// build up, so they give up their money first
+	var releases []models.SavingsGoalRelease
+	for i := len(goals) - 1; i >= 0 && shortfall > 0; i-- {
+		goal := goals[i]
+		if goal.Strict || goal.AllocatedAmount <= 0 {
+			continue
+		}
+		released := math.Min(goal.AllocatedAmount, shortfall)
+		releases = append(releases, models.SavingsGoalRelease{GoalID: goal.ID, Name: goal.Name, Amount: released})
+		shortfall = roundCents(shortfall - released)
+	}
+
+	return releases, nil
+}
+
+// checkSpendable returns an *InsufficientFundsError when amount is more
+// than the account's balance less what is held on it,
+// and an *EarmarkedFundsError when it would need money earmarked for
+// savings goals
+func (s *TransactionService) checkSpendable(account *models.Account, amount float64) error {
+	held, err := s.HeldFunds(account.ID)
+	if err != nil {
+		return err
+	}
+	available := roundCents(account.Balance - held)
+	if available < amount {
+		return &InsufficientFundsError{Requested: amount, Available: available}
+	}
+
+	if s.goalRepo != nil {
+		goals, err := s.goalRepo.ListByAccountID(account.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get savings goals: %w", err)
+		}
+		earmarked, _ := earmarkedFunds(goals)
+		available = roundCents(available - earmarked)
+	}
+	if available < amount {
+		return &EarmarkedFundsError{Requested: amount, Withdrawable: available}
+	}
+	return nil
+}
+
+// HeldFunds returns what is held on an account: the amounts of pending
+// external transfers and of cheques still on hold
+func (s *TransactionService) HeldFunds(accountID uuid.UUID) (float64, error) {
+	var held float64
+	if s.externalTransferRepo != nil {
+		transfers, err := s.externalTransferRepo.HeldByAccountID(accountID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get held funds: %w", err)
+		}
+		held += transfers
+	}
+	if s.chequeRepo != nil {
+		cheques, err := s.chequeRepo.HeldByAccountID(accountID, s.now())
+		if err != nil {
+			return 0, fmt.Errorf("failed to get held cheques: %w", err)
+		}
+		held += cheques
+	}
+	return roundCents(held), nil
+}
+
+// checkSpendingLimit returns a *SpendingLimitError when amount is more
+// than the member may withdraw or transfer at once. Owners, and users of
+// accounts that cannot be shared, have no limit.
+func checkSpendingLimit(member *models.AccountMember, amount float64) error {
+	if member == nil || member.IsOwner() || member.SpendingLimit == nil || amount <= *member.SpendingLimit {
+		return nil
+	}
+	return &SpendingLimitError{Limit: *member.SpendingLimit}
+}
+
+// withdrawalFee returns the fee on a withdrawal of amount from an account
+// at now. It is zero while the account has free withdrawals left in the
+// calendar month. Withdrawals made at once may count the same free
+// withdrawal, so the allowance is checked again when the withdrawal is
+// saved.
+func (s *TransactionService) withdrawalFee(accountID uuid.UUID, amount float64, now time.Time) (float64, error) {
+	if !s.fees.Enabled() {
+		return 0, nil
+	}
+
+	if s.fees.FreePerMonth > 0 {
+		made, err := s.transactionRepo.CountWithdrawalsSince(accountID, startOfMonth(now))
+		if err != nil {
+			return 0, fmt.Errorf("failed to count this month's withdrawals: %w", err)
+		}
+		if made < s.fees.FreePerMonth {
+			return 0, nil
+		}
+	}
+
+	return s.fees.FeeFor(amount), nil
+}
+
+// startOfMonth returns midnight UTC on the first day of t's month in UTC.
+// Free withdrawals and partitions count calendar months in UTC, whatever
+// the customer's time zone.
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// roundCents rounds amount to the cent
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// checkKYC returns a *KYCRequiredError when a withdrawal of amount needs a
+// verified identity and the user does not have one. Lookups that fail
+// return an error matching resilience.ErrDependencyUnavailable, so the
+// withdrawal is refused rather than let through unchecked.
+func (s *TransactionService) checkKYC(userID uuid.UUID, amount float64) error {
+	if s.userStatuses == nil || amount <= s.kycLimit {
+		return nil
+	}
+
+	status, err := s.userStatuses.GetUserStatus(userID.String())
+	if err != nil {
+		return fmt.Errorf("failed to check KYC status: %w", err)
+	}
+	if status.KYCStatus != models.KYCStatusVerified {
+		return &KYCRequiredError{Limit: s.kycLimit, KYCStatus: status.KYCStatus}
+	}
+	return nil
 }
 
 // GetTransactionByID retrieves a specific transaction
@@ -124,8 +817,24 @@ func (s *TransactionService) GetTransactionByID(transactionID uuid.UUID) (*model
 	return transaction, nil
 }
 
-// GetTransactionsByUserID retrieves transactions for a specific user
-func (s *TransactionService) GetTransactionsByUserID(userID uuid.UUID, limit, offset int) ([]models.Transaction, error) {
+// CanView reports whether a user may see a transaction: one they made, or
+// any made on an account they are a member of
+func (s *TransactionService) CanView(userID uuid.UUID, transaction *models.Transaction) (bool, error) {
+	if transaction.UserID == userID || s.memberRepo == nil {
+		return transaction.UserID == userID, nil
+	}
+	member, err := s.memberRepo.GetMember(transaction.AccountID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get account member: %w", err)
+	}
+	return member != nil, nil
+}
+
+// GetTransactionsByAccountID retrieves the transactions on an account,
+// whichever member made them, that match filter, in its order. When
+// before, the ID of one of the account's transactions, is set, the page
+// starts after it and offset is counted from there.
+func (s *TransactionService) GetTransactionsByAccountID(accountID uuid.UUID, filter models.TransactionFilter, before *uuid.UUID, limit, offset int) ([]models.Transaction, error) {
 	// Set default values if not provided
 	if limit <= 0 {
 		limit = 50
@@ -134,7 +843,19 @@ func (s *TransactionService) GetTransactionsByUserID(userID uuid.UUID, limit, of
 		offset = 0
 	}
 
-	transactions, err := s.transactionRepo.GetTransactionsByUserID(userID, limit, offset)
+	var cursor *models.Transaction
+	if before != nil {
+		transaction, err := s.transactionRepo.GetTransactionByID(*before)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrTransactionNotFound, err)
+		}
+		if transaction.AccountID != accountID {
+			return nil, ErrTransactionNotFound
+		}
+		cursor = transaction
+	}
+
+	transactions, err := s.transactionRepo.GetTransactionsByAccountID(accountID, filter, cursor, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -142,9 +863,10 @@ func (s *TransactionService) GetTransactionsByUserID(userID uuid.UUID, limit, of
 	return transactions, nil
 }
 
-// GetTransactionCountByUserID gets the total count of transactions for a user
-func (s *TransactionService) GetTransactionCountByUserID(userID uuid.UUID) (int, error) {
-	count, err := s.transactionRepo.GetTransactionCountByUserID(userID)
+// GetTransactionCountByAccountID counts an account's transactions matching
+// filter
+func (s *TransactionService) GetTransactionCountByAccountID(accountID uuid.UUID, filter models.TransactionFilter) (int, error) {
+	count, err := s.transactionRepo.GetTransactionCountByAccountID(accountID, filter)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get transaction count: %w", err)
 	}
@@ -152,8 +874,9 @@ func (s *TransactionService) GetTransactionCountByUserID(userID uuid.UUID) (int,
 	return count, nil
 }
 
-// GetAllTransactions retrieves all transactions (for admin purposes)
-func (s *TransactionService) GetAllTransactions(limit, offset int) ([]models.Transaction, error) {
+// GetAllTransactions retrieves every user's transactions matching filter
+// (for admin purposes)
+func (s *TransactionService) GetAllTransactions(filter models.TransactionFilter, limit, offset int) ([]models.Transaction, error) {
 	// Set default values if not provided
 	if limit <= 0 {
 		limit = 100
@@ -162,10 +885,98 @@ func (s *TransactionService) GetAllTransactions(limit, offset int) ([]models.Tra
 		offset = 0
 	}
 
-	transactions, err := s.transactionRepo.GetAllTransactions(limit, offset)
+	transactions, err := s.transactionRepo.GetAllTransactions(filter, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
 
 	return transactions, nil
 }
+
+// CountAllTransactions counts every user's transactions matching filter
+// (for admin purposes)
+func (s *TransactionService) CountAllTransactions(filter models.TransactionFilter) (int, error) {
+	count, err := s.transactionRepo.CountAllTransactions(filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+
+	return count, nil
+}
+
+// ExportTransactions passes every transaction matching filter, in its
+// order, to write and returns how many were written. Transactions are read
+// in batches, and write is called with the last of each batch flushed set,
+// so the caller can send what it has. The export stops when ctx is done.
+func (s *TransactionService) ExportTransactions(ctx context.Context, filter models.TransactionFilter, write func(transaction *models.Transaction, flush bool) error) (int, error) {
+	written := 0
+	var after *models.Transaction
+	for {
+		transactions, err := s.transactionRepo.GetTransactionsAfter(ctx, filter, after, transactionExportBatchSize)
+		if err != nil {
+			return written, fmt.Errorf("failed to get transactions: %w", err)
+		}
+		for i := range transactions {
+			if err := write(&transactions[i], i == len(transactions)-1); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if len(transactions) < transactionExportBatchSize {
+			return written, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		after = &transactions[len(transactions)-1]
+	}
+}
+
+// ExportJournal passes the journal entry of every transaction made on the
+// UTC days from through to, oldest first, to write and returns the totals
+// of the lines written. Round-ups post nothing, so their entries are
+// empty. Every entry is checked to balance before it is written. Flushing
+// works as in ExportTransactions.
+func (s *TransactionService) ExportJournal(ctx context.Context, from, to time.Time, write func(entry []models.JournalLine, flush bool) error) (models.JournalTotals, error) {
+	totals := models.JournalTotals{Debit: money.New(0, models.Currency), Credit: money.New(0, models.Currency)}
+	from = startOfDay(from)
+	until := startOfDay(to).AddDate(0, 0, 1)
+	if !until.After(from) {
+		return totals, &ValidationError{Fields: []FieldError{{Field: "to", Rule: "gtefield", Message: "must not be before from"}}}
+	}
+
+	filter := models.TransactionFilter{Since: &from, Until: &until}
+	_, err := s.ExportTransactions(ctx, filter, func(transaction *models.Transaction, flush bool) error {
+		entry := transaction.JournalEntry()
+		debit, credit := money.New(0, models.Currency), money.New(0, models.Currency)
+		for _, line := range entry {
+			var err error
+			if debit, err = debit.Add(line.Debit); err != nil {
+				return err
+			}
+			if credit, err = credit.Add(line.Credit); err != nil {
+				return err
+			}
+		}
+		if debit != credit {
+			return fmt.Errorf("journal entry of transaction %s does not balance: %s debited, %s credited", transaction.ID, debit, credit)
+		}
+
+		if err := write(entry, flush); err != nil {
+			return err
+		}
+		var err error
+		if totals.Debit, err = totals.Debit.Add(debit); err != nil {
+			return err
+		}
+		if totals.Credit, err = totals.Credit.Add(credit); err != nil {
+			return err
+		}
+		if len(entry) > 0 {
+			totals.Transactions++
+			totals.Lines += len(entry)
+		}
+		return nil
+	})
+	return totals, err
+}
@@ -0,0 +1,772 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+	"microbank/pkg/resilience"
+)
+
+// balanceAccountRepo is a fakeAccountRepo that also reads and updates
+// balances
+type balanceAccountRepo struct {
+	fakeAccountRepo
+}
+
+func (r *balanceAccountRepo) GetAccountByUserID(userID uuid.UUID) (*models.Account, error) {
+	account, ok := r.accounts[userID]
+	if !ok {
+		return nil, fmt.Errorf("account not found")
+	}
+	clone := *account
+	return &clone, nil
+}
+
+func (r *balanceAccountRepo) GetAccountByID(id uuid.UUID) (*models.Account, error) {
+	for _, account := range r.accounts {
+		if account.ID == id {
+			clone := *account
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("account not found")
+}
+
+func (r *balanceAccountRepo) UpdateBalance(accountID uuid.UUID, newBalance float64) error {
+	for _, account := range r.accounts {
+		if account.ID == accountID {
+			account.Balance = newBalance
+			return nil
+		}
+	}
+	return fmt.Errorf("account not found")
+}
+
+// fakeTransactionRepo records created transactions, updating balances in
+// accounts
+type fakeTransactionRepo struct {
+	repository.TransactionRepository
+	accounts    repository.AccountRepository
+	created     []models.Transaction
+	withdrawals []models.Withdrawal
+}
+
+func (r *fakeTransactionRepo) CreateTransaction(transaction *models.Transaction) error {
+	r.created = append(r.created, *transaction)
+	return nil
+}
+
+func (r *fakeTransactionRepo) CreateWithdrawal(withdrawal *models.Withdrawal) error {
+	r.withdrawals = append(r.withdrawals, *withdrawal)
+	r.created = append(r.created, *withdrawal.Transaction)
+	balance := withdrawal.Transaction.BalanceAfter
+	if withdrawal.Fee != nil {
+		r.created = append(r.created, *withdrawal.Fee)
+		balance = withdrawal.Fee.BalanceAfter
+	}
+	return r.accounts.UpdateBalance(withdrawal.Transaction.AccountID, balance)
+}
+
+func (r *fakeTransactionRepo) CreateTransfer(transfer *models.Transfer) error {
+	for _, side := range []struct {
+		transaction *models.Transaction
+		sign        float64
+	}{{transfer.Out, -1}, {transfer.In, 1}} {
+		account, err := r.accounts.GetAccountByID(side.transaction.AccountID)
+		if err != nil {
+			return err
+		}
+		side.transaction.BalanceBefore = account.Balance
+		side.transaction.BalanceAfter = roundCents(account.Balance + side.sign*side.transaction.Amount)
+		if side.transaction.BalanceAfter < 0 {
+			return repository.ErrInsufficientBalance
+		}
+	}
+	for _, transaction := range []*models.Transaction{transfer.Out, transfer.In} {
+		r.created = append(r.created, *transaction)
+		if err := r.accounts.UpdateBalance(transaction.AccountID, transaction.BalanceAfter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeTransactionRepo) CountWithdrawalsSince(accountID uuid.UUID, since time.Time) (int, error) {
+	count := 0
+	for _, transaction := range r.created {
+		if transaction.AccountID == accountID && transaction.Type == models.TransactionTypeWithdrawal && !transaction.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// fakeUserStatusSource returns fixed statuses and counts its lookups
+type fakeUserStatusSource struct {
+	statuses map[string]models.UserStatus
+	err      error
+	lookups  int
+}
+
+func (s *fakeUserStatusSource) GetUserStatus(userID string) (models.UserStatus, error) {
+	s.lookups++
+	if s.err != nil {
+		return models.UserStatus{}, s.err
+	}
+	return s.statuses[userID], nil
+}
+
+func TestTransactionService_ProcessWithdrawal_KYCLimit(t *testing.T) {
+	unavailable := &resilience.DependencyError{Dependency: "client-service", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name        string
+		amount      float64
+		kycStatus   string
+		lookupErr   error
+		wantErr     error
+		wantLookups int
+	}{
+		{name: "at the limit", amount: 100, kycStatus: "none"},
+		{name: "over the limit, verified", amount: 150, kycStatus: models.KYCStatusVerified, wantLookups: 1},
+		{name: "over the limit, pending", amount: 150, kycStatus: "pending", wantErr: ErrKYCRequired, wantLookups: 1},
+		{name: "over the limit, rejected", amount: 150, kycStatus: "rejected", wantErr: ErrKYCRequired, wantLookups: 1},
+		{name: "over the limit, lookup fails", amount: 150, lookupErr: unavailable, wantErr: resilience.ErrDependencyUnavailable, wantLookups: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: uuid.New(), UserID: userID, Balance: 500}}}}
+			transactions := &fakeTransactionRepo{accounts: accounts}
+			statuses := &fakeUserStatusSource{statuses: map[string]models.UserStatus{userID.String(): {UserID: userID.String(), Exists: true, KYCStatus: tt.kycStatus}}, err: tt.lookupErr}
+			svc := NewTransactionService(transactions, accounts).WithKYCLimit(statuses, 100)
+
+			_, err := svc.ProcessWithdrawal(userID, models.TransactionRequest{Amount: tt.amount, Description: "ATM"})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ProcessWithdrawal returned error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if statuses.lookups != tt.wantLookups {
+				t.Errorf("Expected %d status lookups, got %d", tt.wantLookups, statuses.lookups)
+			}
+
+			wantBalance := 500.0
+			if tt.wantErr == nil {
+				wantBalance -= tt.amount
+			}
+			if balance := accounts.accounts[userID].Balance; balance != wantBalance {
+				t.Errorf("Expected balance %v, got %v", wantBalance, balance)
+			}
+
+			var kycRequired *KYCRequiredError
+			if errors.As(err, &kycRequired) && (kycRequired.Limit != 100 || kycRequired.KYCStatus != tt.kycStatus) {
+				t.Errorf("Unexpected KYC required error: %+v", kycRequired)
+			}
+		})
+	}
+}
+
+func TestTransactionService_ProcessWithdrawal_WithoutKYCLimit(t *testing.T) {
+	userID := uuid.New()
+	accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: uuid.New(), UserID: userID, Balance: 5000}}}}
+	svc := NewTransactionService(&fakeTransactionRepo{accounts: accounts}, accounts)
+
+	if _, err := svc.ProcessWithdrawal(userID, models.TransactionRequest{Amount: 4000, Description: "Rent"}); err != nil {
+		t.Fatalf("Expected withdrawals to go unchecked without a KYC limit, got %v", err)
+	}
+}
+
+func TestTransactionService_ProcessWithdrawal_CleansDescription(t *testing.T) {
+	userID := uuid.New()
+	accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: uuid.New(), UserID: userID, Balance: 500}}}}
+	transactions := &fakeTransactionRepo{accounts: accounts}
+	svc := NewTransactionService(transactions, accounts).WithDescriptionLength(20)
+
+	long := "ATM\x1b withdrawal at " + strings.Repeat("Main Street ", 5)
+	withdrawal, err := svc.ProcessWithdrawal(userID, models.TransactionRequest{Amount: 10, Description: long})
+	if err != nil {
+		t.Fatalf("ProcessWithdrawal returned error: %v", err)
+	}
+	saved := transactions.created[0]
+	if saved.Description != "ATM withdrawal at M…" || withdrawal.Transaction.Description != saved.Description {
+		t.Errorf("Expected the description cleaned and cut short, got %q", saved.Description)
+	}
+	if saved.RawDescription == nil || *saved.RawDescription != long {
+		t.Errorf("Expected the original description kept for audit, got %v", saved.RawDescription)
+	}
+}
+
+func TestTransactionService_ProcessWithdrawal_Fees(t *testing.T) {
+	tests := []struct {
+		name        string
+		fees        models.WithdrawalFees
+		amount      float64
+		wantFee     float64
+		wantErr     error
+		wantBalance float64
+	}{
+		{name: "no fees", amount: 100, wantBalance: 400},
+		{name: "flat fee", fees: models.WithdrawalFees{Flat: 1.5}, amount: 100, wantFee: 1.5, wantBalance: 398.5},
+		{name: "percentage fee", fees: models.WithdrawalFees{Percent: 1}, amount: 250, wantFee: 2.5, wantBalance: 247.5},
+		{name: "flat and percentage fee, rounded", fees: models.WithdrawalFees{Flat: 0.5, Percent: 0.333}, amount: 100, wantFee: 0.83, wantBalance: 399.17},
+		{name: "fee on top of the whole balance", fees: models.WithdrawalFees{Flat: 1}, amount: 500, wantFee: 1, wantErr: ErrInsufficientFunds, wantBalance: 500},
+		{name: "fee within the whole balance", fees: models.WithdrawalFees{Flat: 1}, amount: 499, wantFee: 1, wantBalance: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: uuid.New(), UserID: userID, Balance: 500}}}}
+			transactions := &fakeTransactionRepo{accounts: accounts}
+			svc := NewTransactionService(transactions, accounts).WithWithdrawalFees(tt.fees)
+
+			result, err := svc.ProcessWithdrawal(userID, models.TransactionRequest{Amount: tt.amount, Description: "ATM"})
+			if balance := accounts.accounts[userID].Balance; balance != tt.wantBalance {
+				t.Errorf("Expected balance %v, got %v", tt.wantBalance, balance)
+			}
+			if tt.wantErr != nil {
+				var insufficientFunds *InsufficientFundsError
+				if !errors.Is(err, tt.wantErr) || !errors.As(err, &insufficientFunds) || insufficientFunds.Fee != tt.wantFee {
+					t.Fatalf("Expected %v with a fee of %v, got %v", tt.wantErr, tt.wantFee, err)
+				}
+				if len(transactions.created) != 0 {
+					t.Errorf("Expected no transactions, got %d", len(transactions.created))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessWithdrawal returned error: %v", err)
+			}
+
+			withdrawal, fee := result.Transaction, result.Fee
+			if tt.wantFee == 0 {
+				if fee != nil {
+					t.Errorf("Expected no fee, got %+v", fee)
+				}
+				return
+			}
+			if fee == nil {
+				t.Fatalf("Expected a fee of %v, got none", tt.wantFee)
+			}
+			if fee.Type != models.TransactionTypeFee || fee.Amount != tt.wantFee {
+				t.Errorf("Expected a fee transaction of %v, got %s of %v", tt.wantFee, fee.Type, fee.Amount)
+			}
+			if fee.RelatedTransactionID == nil || *fee.RelatedTransactionID != withdrawal.ID {
+				t.Errorf("Expected the fee to be linked to withdrawal %s, got %v", withdrawal.ID, fee.RelatedTransactionID)
+			}
+			if fee.BalanceBefore != withdrawal.BalanceAfter || fee.BalanceAfter != tt.wantBalance {
+				t.Errorf("Expected the fee to take the balance from %v to %v, got %v to %v", withdrawal.BalanceAfter, tt.wantBalance, fee.BalanceBefore, fee.BalanceAfter)
+			}
+		})
+	}
+}
+
+func TestTransactionService_ProcessWithdrawal_FreeAllowanceMonthBoundary(t *testing.T) {
+	// Months are counted in UTC, whatever zone the clock reads in
+	location := time.FixedZone("UTC+2", 2*60*60)
+	monthStart := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC).In(location)
+
+	tests := []struct {
+		name string
+		// earlier are the times of withdrawals already made
+		earlier []time.Time
+		now     time.Time
+		wantFee bool
+	}{
+		{name: "allowance left", earlier: []time.Time{monthStart}, now: monthStart.Add(time.Hour)},
+		{name: "allowance used", earlier: []time.Time{monthStart, monthStart.Add(time.Minute)}, now: monthStart.Add(time.Hour), wantFee: true},
+		{name: "allowance used last month", earlier: []time.Time{monthStart.Add(-time.Second), monthStart.Add(-time.Minute)}, now: monthStart},
+		{name: "allowance used up to the last second of the month", earlier: []time.Time{monthStart.Add(-time.Hour), monthStart.Add(-time.Minute)}, now: monthStart.Add(-time.Second), wantFee: true},
+		{name: "one withdrawal each side of the boundary", earlier: []time.Time{monthStart.Add(-time.Second), monthStart}, now: monthStart.Add(time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			accountID := uuid.New()
+			accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: accountID, UserID: userID, Balance: 500}}}}
+			transactions := &fakeTransactionRepo{accounts: accounts}
+			for _, at := range tt.earlier {
+				transactions.created = append(transactions.created, models.Transaction{AccountID: accountID, Type: models.TransactionTypeWithdrawal, Amount: 10, CreatedAt: at})
+			}
+			svc := NewTransactionService(transactions, accounts).WithWithdrawalFees(models.WithdrawalFees{Flat: 2, FreePerMonth: 2})
+			svc.now = func() time.Time { return tt.now }
+
+			result, err := svc.ProcessWithdrawal(userID, models.TransactionRequest{Amount: 10, Description: "ATM"})
+			if err != nil {
+				t.Fatalf("ProcessWithdrawal returned error: %v", err)
+			}
+			fee := result.Fee
+			if tt.wantFee && (fee == nil || fee.Amount != 2) {
+				t.Errorf("Expected a fee of 2, got %+v", fee)
+			}
+			if !tt.wantFee && fee != nil {
+				t.Errorf("Expected a free withdrawal, got a fee of %v", fee.Amount)
+			}
+		})
+	}
+}
+
+func TestTransactionService_ProcessWithdrawal_SavingsGoals(t *testing.T) {
+	tests := []struct {
+		name string
+		// strict says whether each goal is strict; each earmarks 100, the
+		// last being the newest
+		strict       []bool
+		amount       float64
+		wantErr      error
+		wantReleases []float64
+	}{
+		{name: "within the available balance", strict: []bool{true, false}, amount: 300},
+		{name: "releases from the newest goal first", strict: []bool{false, false}, amount: 350, wantReleases: []float64{0, 50}},
+		{name: "releases across goals", strict: []bool{false, false}, amount: 450, wantReleases: []float64{50, 100}},
+		{name: "skips strict goals", strict: []bool{false, true}, amount: 350, wantReleases: []float64{50, 0}},
+		{name: "refused by strict goals", strict: []bool{false, true}, amount: 450, wantErr: ErrFundsEarmarked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			accountID := uuid.New()
+			accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: accountID, UserID: userID, Balance: 500}}}}
+			transactions := &fakeTransactionRepo{accounts: accounts}
+			goals := newFakeSavingsGoalRepo()
+			var goalIDs []uuid.UUID
+			for i, strict := range tt.strict {
+				goal := &models.SavingsGoal{ID: uuid.New(), UserID: userID, AccountID: accountID, Name: fmt.Sprintf("Goal %d", i), TargetAmount: 1000, AllocatedAmount: 100, Strict: strict}
+				goals.add(goal)
+				goalIDs = append(goalIDs, goal.ID)
+			}
+			svc := NewTransactionService(transactions, accounts).WithSavingsGoals(goals)
+
+			result, err := svc.ProcessWithdrawal(userID, models.TransactionRequest{Amount: tt.amount, Description: "ATM"})
+			if tt.wantErr != nil {
+				var earmarked *EarmarkedFundsError
+				if !errors.Is(err, tt.wantErr) || !errors.As(err, &earmarked) || earmarked.Withdrawable != 400 {
+					t.Fatalf("Expected %v with 400 withdrawable, got %v", tt.wantErr, err)
+				}
+				if len(transactions.created) != 0 {
+					t.Errorf("Expected no transactions, got %d", len(transactions.created))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessWithdrawal returned error: %v", err)
+			}
+
+			released := make(map[uuid.UUID]float64)
+			for _, release := range result.GoalReleases {
+				released[release.GoalID] = release.Amount
+			}
+			for i, goalID := range goalIDs {
+				want := 0.0
+				if tt.wantReleases != nil {
+					want = tt.wantReleases[i]
+				}
+				if released[goalID] != want {
+					t.Errorf("Expected %v released from goal %d, got %v", want, i, released[goalID])
+				}
+			}
+		})
+	}
+}
+
+func TestTransactionService_ProcessWithdrawal_RoundUps(t *testing.T) {
+	tests := []struct {
+		name string
+		// allocated is already earmarked for the round-up goal
+		allocated   float64
+		fees        models.WithdrawalFees
+		amount      float64
+		noGoal      bool
+		wantRoundUp float64
+	}{
+		{name: "rounds up the change", amount: 12.45, wantRoundUp: 0.55},
+		{name: "whole amounts are not rounded up", amount: 12},
+		{name: "round-ups are off", amount: 12.45, noGoal: true},
+		{name: "change is not available", allocated: 87.5, amount: 12.45},
+		{name: "change is available after the fee", allocated: 86.5, fees: models.WithdrawalFees{Flat: 0.5}, amount: 12.45, wantRoundUp: 0.55},
+		{name: "withdrawal takes earmarked money", allocated: 95, amount: 12.45},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			accountID := uuid.New()
+			accounts := &balanceAccountRepo{fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: accountID, UserID: userID, Balance: 100}}}}
+			transactions := &fakeTransactionRepo{accounts: accounts}
+			goals := newFakeSavingsGoalRepo()
+			goal := &models.SavingsGoal{ID: uuid.New(), UserID: userID, AccountID: accountID, Name: "Holiday", TargetAmount: 1000, AllocatedAmount: tt.allocated}
+			goals.add(goal)
+			if !tt.noGoal {
+				goals.roundUpGoal = &goal.ID
+			}
+			svc := NewTransactionService(transactions, accounts).WithWithdrawalFees(tt.fees).WithSavingsGoals(goals)
+
+			result, err := svc.ProcessWithdrawal(userID, models.TransactionRequest{Amount: tt.amount, Description: "Coffee"})
+			if err != nil {
+				t.Fatalf("ProcessWithdrawal returned error: %v", err)
+			}
+
+			if tt.wantRoundUp == 0 {
+				if result.RoundUp != nil {
+					t.Errorf("Expected no round-up, got %v", result.RoundUp.Amount)
+				}
+				return
+			}
+			roundUp := result.RoundUp
+			if roundUp == nil {
+				t.Fatalf("Expected a round-up of %v, got none", tt.wantRoundUp)
+			}
+			if roundUp.Type != models.TransactionTypeRoundUp || roundUp.Amount != tt.wantRoundUp || result.RoundUpGoalID != goal.ID {
+				t.Errorf("Expected a round-up of %v into goal %s, got %s of %v into %s", tt.wantRoundUp, goal.ID, roundUp.Type, roundUp.Amount, result.RoundUpGoalID)
+			}
+			if roundUp.RelatedTransactionID == nil || *roundUp.RelatedTransactionID != result.Transaction.ID {
+				t.Errorf("Expected the round-up to be linked to withdrawal %s, got %v", result.Transaction.ID, roundUp.RelatedTransactionID)
+			}
+			if roundUp.BalanceBefore != roundUp.BalanceAfter || roundUp.Description != "Round-up to Holiday" {
+				t.Errorf("Expected a labelled round-up leaving the balance alone, got %q from %v to %v", roundUp.Description, roundUp.BalanceBefore, roundUp.BalanceAfter)
+			}
+		})
+	}
+}
+
+// pagedTransactionRepo serves transactions in pages after a cursor
+type pagedTransactionRepo struct {
+	repository.TransactionRepository
+	transactions []models.Transaction
+	pages        int
+	filter       models.TransactionFilter
+}
+
+func (r *pagedTransactionRepo) GetTransactionsAfter(ctx context.Context, filter models.TransactionFilter, after *models.Transaction, limit int) ([]models.Transaction, error) {
+	r.pages++
+	r.filter = filter
+	start := 0
+	if after != nil {
+		for i, transaction := range r.transactions {
+			if transaction.ID == after.ID {
+				start = i + 1
+			}
+		}
+	}
+	end := start + limit
+	if end > len(r.transactions) {
+		end = len(r.transactions)
+	}
+	return r.transactions[start:end], nil
+}
+
+func TestTransactionService_ExportTransactions(t *testing.T) {
+	repo := &pagedTransactionRepo{}
+	for i := 0; i < transactionExportBatchSize+1; i++ {
+		repo.transactions = append(repo.transactions, models.Transaction{ID: uuid.New()})
+	}
+	service := NewTransactionService(repo, nil)
+
+	var exported []uuid.UUID
+	flushes := 0
+	written, err := service.ExportTransactions(context.Background(), models.TransactionFilter{}, func(transaction *models.Transaction, flush bool) error {
+		exported = append(exported, transaction.ID)
+		if flush {
+			flushes++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportTransactions returned error: %v", err)
+	}
+	if written != len(repo.transactions) || len(exported) != written || exported[written-1] != repo.transactions[written-1].ID {
+		t.Fatalf("Expected all %d transactions in order, got %d", len(repo.transactions), written)
+	}
+	if repo.pages != 2 || flushes != 2 {
+		t.Errorf("Expected two pages each flushed once, got %d pages and %d flushes", repo.pages, flushes)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	repo.pages = 0
+	written, err = service.ExportTransactions(ctx, models.TransactionFilter{}, func(transaction *models.Transaction, flush bool) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || repo.pages != 1 || written != transactionExportBatchSize {
+		t.Errorf("Expected the export to stop after the page it was cancelled in, got %d rows, %d pages (%v)", written, repo.pages, err)
+	}
+}
+
+func TestTransactionService_ExportJournalBalances(t *testing.T) {
+	accountID := uuid.New()
+	repo := &pagedTransactionRepo{transactions: []models.Transaction{
+		{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeDeposit, Amount: 100},
+		{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeWithdrawal, Amount: 40.1},
+		{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeFee, Amount: 2.5},
+		{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeRoundUp, Amount: 0.9},
+		{ID: uuid.New(), AccountID: accountID, Type: models.TransactionTypeRefund, Amount: 40.1},
+	}}
+	service := NewTransactionService(repo, nil)
+	from := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC)
+
+	var lines []models.JournalLine
+	totals, err := service.ExportJournal(context.Background(), from, to, func(entry []models.JournalLine, flush bool) error {
+		if len(entry) != 0 && len(entry) != 2 {
+			t.Errorf("Expected an entry of a debit and a credit line, got %d lines", len(entry))
+		}
+		lines = append(lines, entry...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportJournal returned error: %v", err)
+	}
+
+	if repo.filter.Since == nil || !repo.filter.Since.Equal(from) || repo.filter.Until == nil || !repo.filter.Until.Equal(time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected transactions from October 1 up to November 1, got %v to %v", repo.filter.Since, repo.filter.Until)
+	}
+	if totals.Transactions != 4 || totals.Lines != 8 {
+		t.Errorf("Expected 8 lines for the 4 transactions that move money, got %d for %d", totals.Lines, totals.Transactions)
+	}
+	if totals.Debit != totals.Credit || totals.Debit.Amount() != "182.70" {
+		t.Errorf("Expected 182.70 debited and credited, got %s and %s", totals.Debit, totals.Credit)
+	}
+
+	fee := lines[4:6]
+	if fee[0].Account != models.JournalAccountCustomerDeposits || fee[0].Debit.Amount() != "2.50" || fee[0].CustomerAccountID == nil || *fee[0].CustomerAccountID != accountID {
+		t.Errorf("Expected the fee to debit the customer's deposits, got %+v", fee[0])
+	}
+	if fee[1].Account != models.JournalAccountFeeIncome || fee[1].Credit.Amount() != "2.50" || fee[1].CustomerAccountID != nil {
+		t.Errorf("Expected the fee to credit fee income, got %+v", fee[1])
+	}
+}
+
+func TestTransactionService_ExportJournalRejectsReversedPeriod(t *testing.T) {
+	service := NewTransactionService(&pagedTransactionRepo{}, nil)
+	from := time.Date(2026, time.October, 2, 0, 0, 0, 0, time.UTC)
+
+	_, err := service.ExportJournal(context.Background(), from, from.AddDate(0, 0, -1), func(entry []models.JournalLine, flush bool) error { return nil })
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+}
+
+func TestTransactionService_ProcessTransfer(t *testing.T) {
+	sender, recipient := uuid.New(), uuid.New()
+	accounts := newBeneficiaryAccounts(sender, recipient)
+	transactions := &fakeTransactionRepo{accounts: accounts}
+	svc := NewTransactionService(transactions, accounts).WithBeneficiaries(&fakeBeneficiaryRepo{}, testBeneficiaryRules)
+	now := time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	destination := accounts.accounts[recipient].ID
+	transfer, err := svc.ProcessTransfer(sender, models.TransferRequest{Amount: 250.5, Description: "  Rent\tOctober ", DestinationAccountID: &destination})
+	if err != nil {
+		t.Fatalf("ProcessTransfer returned error: %v", err)
+	}
+
+	out, in := transfer.Out, transfer.In
+	if out.Type != models.TransactionTypeTransferOut || out.UserID != sender || out.BalanceBefore != 1000 || out.BalanceAfter != 749.5 {
+		t.Errorf("Unexpected sender's transaction: %+v", out)
+	}
+	if in.Type != models.TransactionTypeTransferIn || in.UserID != recipient || in.AccountID != destination || in.BalanceAfter != 1250.5 {
+		t.Errorf("Unexpected recipient's transaction: %+v", in)
+	}
+	if *out.RelatedTransactionID != in.ID || *in.RelatedTransactionID != out.ID {
+		t.Error("Expected the two sides of the transfer to be linked to each other")
+	}
+	if out.Description != "Rent October" || in.Description != out.Description || !out.CreatedAt.Equal(now) {
+		t.Errorf("Expected both sides described and made now, got %q and %q at %v", out.Description, in.Description, out.CreatedAt)
+	}
+	if accounts.accounts[sender].Balance != 749.5 || accounts.accounts[recipient].Balance != 1250.5 {
+		t.Errorf("Expected balances of 749.5 and 1250.5, got %v and %v", accounts.accounts[sender].Balance, accounts.accounts[recipient].Balance)
+	}
+	if response := transfer.ToResponse(); response.Transaction.ID != out.ID || response.DestinationAccountID != destination || response.BeneficiaryID != nil {
+		t.Errorf("Expected the response to show the sender's side, got %+v", response)
+	}
+}
+
+func TestTransactionService_ProcessTransfer_CoolingOff(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)
+	coolingOffEnds := now.Add(23 * time.Hour)
+
+	tests := []struct {
+		name          string
+		amount        float64
+		savedAgo      time.Duration
+		notSaved      bool
+		byBeneficiary bool
+		wantAllowedAt *time.Time
+		wantErr       bool
+	}{
+		{name: "small transfer to a new beneficiary", amount: 500, savedAgo: time.Hour, byBeneficiary: true},
+		{name: "large transfer to a new beneficiary", amount: 500.01, savedAgo: time.Hour, byBeneficiary: true, wantErr: true, wantAllowedAt: &coolingOffEnds},
+		{name: "large transfer once cooled off", amount: 900, savedAgo: 24 * time.Hour, byBeneficiary: true},
+		{name: "large transfer by account ID to a new beneficiary", amount: 900, savedAgo: time.Hour, wantErr: true, wantAllowedAt: &coolingOffEnds},
+		{name: "large transfer by account ID to a cooled off beneficiary", amount: 900, savedAgo: 48 * time.Hour},
+		{name: "small transfer to an account not saved", amount: 100, notSaved: true},
+		{name: "large transfer to an account not saved", amount: 900, notSaved: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender, recipient := uuid.New(), uuid.New()
+			accounts := newBeneficiaryAccounts(sender, recipient)
+			beneficiaries := &fakeBeneficiaryRepo{}
+			destination := accounts.accounts[recipient].ID
+			beneficiary := &models.Beneficiary{ID: uuid.New(), UserID: sender, Nickname: "Landlord", AccountID: destination, CreatedAt: now.Add(-tt.savedAgo)}
+			if !tt.notSaved {
+				beneficiaries.beneficiaries = append(beneficiaries.beneficiaries, beneficiary)
+			}
+			svc := NewTransactionService(&fakeTransactionRepo{accounts: accounts}, accounts).WithBeneficiaries(beneficiaries, testBeneficiaryRules)
+			svc.now = func() time.Time { return now }
+
+			request := models.TransferRequest{Amount: tt.amount, DestinationAccountID: &destination}
+			if tt.byBeneficiary {
+				request = models.TransferRequest{Amount: tt.amount, BeneficiaryID: &beneficiary.ID}
+			}
+			transfer, err := svc.ProcessTransfer(sender, request)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("ProcessTransfer returned error: %v", err)
+				}
+				if !tt.notSaved && (transfer.BeneficiaryID == nil || *transfer.BeneficiaryID != beneficiary.ID) {
+					t.Errorf("Expected the transfer to be made to the beneficiary, got %v", transfer.BeneficiaryID)
+				}
+				return
+			}
+
+			var coolingOff *BeneficiaryCoolingOffError
+			if !errors.As(err, &coolingOff) || !errors.Is(err, ErrBeneficiaryCoolingOff) {
+				t.Fatalf("Expected a cooling-off error, got %v", err)
+			}
+			if coolingOff.Limit != 500 {
+				t.Errorf("Expected the limit of 500, got %v", coolingOff.Limit)
+			}
+			if (coolingOff.AllowedAt == nil) != (tt.wantAllowedAt == nil) || (tt.wantAllowedAt != nil && !coolingOff.AllowedAt.Equal(*tt.wantAllowedAt)) {
+				t.Errorf("Expected large transfers to be allowed at %v, got %v", tt.wantAllowedAt, coolingOff.AllowedAt)
+			}
+			if accounts.accounts[sender].Balance != 1000 {
+				t.Errorf("Expected the balance to be untouched, got %v", accounts.accounts[sender].Balance)
+			}
+		})
+	}
+}
+
+func TestTransactionService_ProcessTransfer_Refused(t *testing.T) {
+	sender, recipient, other := uuid.New(), uuid.New(), uuid.New()
+	frozenAt := time.Now()
+
+	tests := []struct {
+		name    string
+		setup   func(accounts *balanceAccountRepo, goals *fakeSavingsGoalRepo)
+		request func(accounts *balanceAccountRepo, beneficiaries *fakeBeneficiaryRepo) models.TransferRequest
+		wantErr error
+	}{
+		{
+			name: "no destination",
+			request: func(*balanceAccountRepo, *fakeBeneficiaryRepo) models.TransferRequest {
+				return models.TransferRequest{Amount: 10}
+			},
+		},
+		{
+			name: "both destinations",
+			request: func(accounts *balanceAccountRepo, beneficiaries *fakeBeneficiaryRepo) models.TransferRequest {
+				return models.TransferRequest{Amount: 10, DestinationAccountID: &accounts.accounts[recipient].ID, BeneficiaryID: &beneficiaries.beneficiaries[0].ID}
+			},
+		},
+		{
+			name: "own account",
+			request: func(accounts *balanceAccountRepo, _ *fakeBeneficiaryRepo) models.TransferRequest {
+				return models.TransferRequest{Amount: 10, DestinationAccountID: &accounts.accounts[sender].ID}
+			},
+		},
+		{
+			name: "unknown account",
+			request: func(*balanceAccountRepo, *fakeBeneficiaryRepo) models.TransferRequest {
+				destination := uuid.New()
+				return models.TransferRequest{Amount: 10, DestinationAccountID: &destination}
+			},
+			wantErr: ErrDestinationAccountNotFound,
+		},
+		{
+			name: "another user's beneficiary",
+			request: func(_ *balanceAccountRepo, beneficiaries *fakeBeneficiaryRepo) models.TransferRequest {
+				return models.TransferRequest{Amount: 10, BeneficiaryID: &beneficiaries.beneficiaries[1].ID}
+			},
+			wantErr: ErrBeneficiaryNotFound,
+		},
+		{
+			name: "frozen destination",
+			setup: func(accounts *balanceAccountRepo, _ *fakeSavingsGoalRepo) {
+				accounts.accounts[recipient].FrozenAt = &frozenAt
+			},
+			request: func(accounts *balanceAccountRepo, _ *fakeBeneficiaryRepo) models.TransferRequest {
+				return models.TransferRequest{Amount: 10, DestinationAccountID: &accounts.accounts[recipient].ID}
+			},
+			wantErr: ErrDestinationAccountUnavailable,
+		},
+		{
+			name: "frozen sender",
+			setup: func(accounts *balanceAccountRepo, _ *fakeSavingsGoalRepo) {
+				accounts.accounts[sender].FrozenAt = &frozenAt
+			},
+			request: func(accounts *balanceAccountRepo, _ *fakeBeneficiaryRepo) models.TransferRequest {
+				return models.TransferRequest{Amount: 10, DestinationAccountID: &accounts.accounts[recipient].ID}
+			},
+			wantErr: ErrAccountFrozen,
+		},
+		{
+			name: "more than the balance",
+			request: func(_ *balanceAccountRepo, beneficiaries *fakeBeneficiaryRepo) models.TransferRequest {
+				return models.TransferRequest{Amount: 1000.01, BeneficiaryID: &beneficiaries.beneficiaries[0].ID}
+			},
+			wantErr: ErrInsufficientFunds,
+		},
+		{
+			name: "earmarked money",
+			setup: func(accounts *balanceAccountRepo, goals *fakeSavingsGoalRepo) {
+				goals.add(&models.SavingsGoal{ID: uuid.New(), AccountID: accounts.accounts[sender].ID, TargetAmount: 500, AllocatedAmount: 300})
+			},
+			request: func(_ *balanceAccountRepo, beneficiaries *fakeBeneficiaryRepo) models.TransferRequest {
+				return models.TransferRequest{Amount: 700.01, BeneficiaryID: &beneficiaries.beneficiaries[0].ID}
+			},
+			wantErr: ErrFundsEarmarked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts := newBeneficiaryAccounts(sender, recipient, other)
+			goals := newFakeSavingsGoalRepo()
+			beneficiaries := &fakeBeneficiaryRepo{beneficiaries: []*models.Beneficiary{
+				{ID: uuid.New(), UserID: sender, AccountID: accounts.accounts[recipient].ID, CreatedAt: time.Now().Add(-48 * time.Hour)},
+				{ID: uuid.New(), UserID: other, AccountID: accounts.accounts[recipient].ID, CreatedAt: time.Now().Add(-48 * time.Hour)},
+			}}
+			if tt.setup != nil {
+				tt.setup(accounts, goals)
+			}
+			transactions := &fakeTransactionRepo{accounts: accounts}
+			svc := NewTransactionService(transactions, accounts).WithSavingsGoals(goals).WithBeneficiaries(beneficiaries, models.BeneficiaryRules{MaxPerUser: 2, LargeTransferLimit: 5000})
+
+			_, err := svc.ProcessTransfer(sender, tt.request(accounts, beneficiaries))
+			if tt.wantErr == nil {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("Expected a validation error, got %v", err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if len(transactions.created) != 0 {
+				t.Errorf("Expected no transactions, got %d", len(transactions.created))
+			}
+		})
+	}
+}
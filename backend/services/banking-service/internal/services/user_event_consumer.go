@@ -0,0 +1,59 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"microbank/pkg/events"
+)
+
+// UserEventConsumer applies user lifecycle events published by the client
+// service. Accounts of blacklisted users are frozen as soon as the event
+// arrives rather than at the user's next token check.
+type UserEventConsumer struct {
+	accountService *AccountService
+}
+
+// NewUserEventConsumer creates a new user event consumer
+func NewUserEventConsumer(accountService *AccountService) *UserEventConsumer {
+	return &UserEventConsumer{
+		accountService: accountService,
+	}
+}
+
+// Handle applies the event and reports whether this service acted on its
+// type; other types are accepted and ignored. Applying an event twice has
+// no further effect.
+func (c *UserEventConsumer) Handle(event events.Event) (bool, error) {
+	switch event.Type {
+	case events.TypeUserBlacklisted:
+		var payload events.UserBlacklisted
+		if err := events.Decode(event, &payload); err != nil {
+			return false, err
+		}
+		frozen, err := c.accountService.FreezeAccount(payload.UserID)
+		if err != nil {
+			return false, fmt.Errorf("failed to apply %s: %w", event.Type, err)
+		}
+		if frozen {
+			log.Printf("Froze account of blacklisted user %s", payload.UserID)
+		}
+		return true, nil
+
+	case events.TypeUserUnblacklisted:
+		var payload events.UserUnblacklisted
+		if err := events.Decode(event, &payload); err != nil {
+			return false, err
+		}
+		unfrozen, err := c.accountService.UnfreezeAccount(payload.UserID)
+		if err != nil {
+			return false, fmt.Errorf("failed to apply %s: %w", event.Type, err)
+		}
+		if unfrozen {
+			log.Printf("Unfroze account of reinstated user %s", payload.UserID)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
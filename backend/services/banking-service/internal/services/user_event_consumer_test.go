@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/banking-service/internal/models"
+	"microbank/banking-service/internal/repository"
+	"microbank/pkg/events"
+)
+
+// fakeAccountRepo keeps accounts in memory. Methods the tests do not use
+// panic through the embedded nil interface.
+type fakeAccountRepo struct {
+	repository.AccountRepository
+	accounts map[uuid.UUID]*models.Account
+}
+
+func (r *fakeAccountRepo) SetFrozen(userID uuid.UUID, frozen bool) (bool, error) {
+	account, ok := r.accounts[userID]
+	if !ok || account.IsFrozen() == frozen {
+		return false, nil
+	}
+	account.FrozenAt = nil
+	if frozen {
+		now := time.Now()
+		account.FrozenAt = &now
+	}
+	return true, nil
+}
+
+func mustEvent(t *testing.T, eventType string, payload any) events.Event {
+	t.Helper()
+	event, err := events.New(eventType, events.SourceClientService, payload)
+	if err != nil {
+		t.Fatalf("events.New returned error: %v", err)
+	}
+	return event
+}
+
+func TestUserEventConsumer_FreezesBlacklistedUsers(t *testing.T) {
+	userID := uuid.New()
+	repo := &fakeAccountRepo{accounts: map[uuid.UUID]*models.Account{userID: {ID: uuid.New(), UserID: userID}}}
+	consumer := NewUserEventConsumer(NewAccountService(repo))
+
+	blacklisted := mustEvent(t, events.TypeUserBlacklisted, events.UserBlacklisted{UserID: userID, Reason: "Fraud"})
+	for i := 0; i < 2; i++ {
+		handled, err := consumer.Handle(blacklisted)
+		if err != nil || !handled {
+			t.Fatalf("Expected blacklist to be handled, got %v (%v)", handled, err)
+		}
+	}
+	if !repo.accounts[userID].IsFrozen() {
+		t.Fatal("Expected the account to be frozen")
+	}
+
+	unblacklisted := mustEvent(t, events.TypeUserUnblacklisted, events.UserUnblacklisted{UserID: userID})
+	if handled, err := consumer.Handle(unblacklisted); err != nil || !handled {
+		t.Fatalf("Expected lift to be handled, got %v (%v)", handled, err)
+	}
+	if repo.accounts[userID].IsFrozen() {
+		t.Error("Expected the account to be unfrozen")
+	}
+}
+
+func TestUserEventConsumer_IgnoresOtherTypes(t *testing.T) {
+	consumer := NewUserEventConsumer(NewAccountService(&fakeAccountRepo{}))
+
+	registered := mustEvent(t, events.TypeUserRegistered, events.UserRegistered{UserID: uuid.New()})
+	if handled, err := consumer.Handle(registered); err != nil || handled {
+		t.Errorf("Expected user.registered to be ignored, got %v (%v)", handled, err)
+	}
+}
+
+func TestUserEventConsumer_RejectsUnsupportedVersion(t *testing.T) {
+	consumer := NewUserEventConsumer(NewAccountService(&fakeAccountRepo{}))
+
+	event := mustEvent(t, events.TypeUserBlacklisted, events.UserBlacklisted{UserID: uuid.New()})
+	event.Version++
+	if _, err := consumer.Handle(event); !errors.Is(err, events.ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/httpx"
+	"microbank/pkg/resilience"
+)
+
+// userStatusTimeout bounds each user status lookup
+const userStatusTimeout = 2 * time.Second
+
+// DefaultUserStatusTTL is how long looked up statuses are reused, matching
+// the max-age the client-service sends with them
+const DefaultUserStatusTTL = 5 * time.Second
+
+// maxUserDetailBatchSize is the most users the client-service looks up in
+// one details call
+const maxUserDetailBatchSize = 100
+
+// cachedUserStatus is a user status and when it stops being reused
+type cachedUserStatus struct {
+	status    models.UserStatus
+	expiresAt time.Time
+}
+
+// cachedUserDetail is a user's details and when they stop being reused
+type cachedUserDetail struct {
+	detail    models.UserDetail
+	expiresAt time.Time
+}
+
+// UserStatusClient looks up users' current status on the client-service
+// internal API, authenticating with the shared service token. Statuses are
+// reused for a short TTL, so changes take up to that long to be seen. It
+// also looks up users' details, which are reused for the same TTL. Failed
+// lookups return an error matching
+// resilience.ErrDependencyUnavailable.
+type UserStatusClient struct {
+	baseURL      string
+	serviceToken string
+	ttl          time.Duration
+	httpClient   *http.Client
+	client       *resilience.Client
+
+	mu      sync.Mutex
+	cache   map[string]cachedUserStatus
+	details map[string]cachedUserDetail
+	now     func() time.Time
+}
+
+// NewUserStatusClient creates a user status client reusing statuses for
+// ttl, retrying and breaking its calls as config describes
+func NewUserStatusClient(baseURL, serviceToken string, ttl time.Duration, config resilience.Config) *UserStatusClient {
+	httpClient := &http.Client{}
+	return &UserStatusClient{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		serviceToken: serviceToken,
+		ttl:          ttl,
+		httpClient:   httpClient,
+		client:       resilience.NewClient("client-service", httpClient, config),
+		cache:        make(map[string]cachedUserStatus),
+		details:      make(map[string]cachedUserDetail),
+		now:          time.Now,
+	}
+}
+
+// WithTransport makes the client send its requests through transport, such
+// as one presenting a client certificate
+func (c *UserStatusClient) WithTransport(transport http.RoundTripper) *UserStatusClient {
+	c.httpClient.Transport = transport
+	return c
+}
+
+// GetUserStatus returns a user's current status
+func (c *UserStatusClient) GetUserStatus(userID string) (models.UserStatus, error) {
+	if status, ok := c.cached(userID); ok {
+		return status, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/internal/users/"+url.PathEscape(userID)+"/status", nil)
+	if err != nil {
+		return models.UserStatus{}, fmt.Errorf("failed to build client-service request: %w", err)
+	}
+	req.Header.Set("X-Service-Token", c.serviceToken)
+
+	resp, err := c.client.Do(req, userStatusTimeout)
+	if err != nil {
+		return models.UserStatus{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return models.UserStatus{}, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("returned status %d", resp.StatusCode)}
+	}
+
+	var status models.UserStatus
+	if err := json.NewDecoder(resp.Body).Decode(&httpx.Envelope{Data: &status}); err != nil {
+		return models.UserStatus{}, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("failed to decode user status: %w", err)}
+	}
+
+	c.store(userID, status)
+	return status, nil
+}
+
+// cached returns the user's status if it was looked up within the TTL
+func (c *UserStatusClient) cached(userID string) (models.UserStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.cache[userID]
+	if !ok || !entry.expiresAt.After(c.now()) {
+		return models.UserStatus{}, false
+	}
+	return entry.status, true
+}
+
+// store keeps the user's status for the TTL, dropping expired entries
+func (c *UserStatusClient) store(userID string, status models.UserStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for id, entry := range c.cache {
+		if !entry.expiresAt.After(now) {
+			delete(c.cache, id)
+		}
+	}
+	c.cache[userID] = cachedUserStatus{status: status, expiresAt: now.Add(c.ttl)}
+}
+
+// VerifyPassword reports whether password is the user's password. It is
+// never cached, and users the client-service does not know, or who have no
+// password, are reported with false.
+func (c *UserStatusClient) VerifyPassword(userID, password string) (bool, error) {
+	body, err := json.Marshal(map[string]string{"password": password})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode password check: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/internal/users/"+url.PathEscape(userID)+"/password/verify", bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to build client-service request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Token", c.serviceToken)
+
+	resp, err := c.client.Do(req, userStatusTimeout)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("returned status %d", resp.StatusCode)}
+	}
+
+	var response struct {
+		Valid bool `json:"valid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&httpx.Envelope{Data: &response}); err != nil {
+		return false, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("failed to decode password check: %w", err)}
+	}
+
+	return response.Valid, nil
+}
+
+// LookupUserByEmail returns the details of the user with email. It is never
+// cached, and emails the client-service does not know are reported with
+// Exists false.
+func (c *UserStatusClient) LookupUserByEmail(email string) (models.UserDetail, error) {
+	body, err := json.Marshal(map[string]string{"email": email})
+	if err != nil {
+		return models.UserDetail{}, fmt.Errorf("failed to encode user lookup: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/internal/users/lookup", bytes.NewReader(body))
+	if err != nil {
+		return models.UserDetail{}, fmt.Errorf("failed to build client-service request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Token", c.serviceToken)
+
+	resp, err := c.client.Do(req, userStatusTimeout)
+	if err != nil {
+		return models.UserDetail{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return models.UserDetail{}, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("returned status %d", resp.StatusCode)}
+	}
+
+	var detail models.UserDetail
+	if err := json.NewDecoder(resp.Body).Decode(&httpx.Envelope{Data: &detail}); err != nil {
+		return models.UserDetail{}, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("failed to decode user lookup: %w", err)}
+	}
+
+	return detail, nil
+}
+
+// GetNotificationRecipient returns where and how a user wants an account
+// activity event delivered. It is never cached, and users the
+// client-service does not know, or who were deleted, are reported with
+// Exists false.
+func (c *UserStatusClient) GetNotificationRecipient(userID, event string) (models.NotificationRecipient, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/internal/users/"+url.PathEscape(userID)+"/notifications/"+url.PathEscape(event), nil)
+	if err != nil {
+		return models.NotificationRecipient{}, fmt.Errorf("failed to build client-service request: %w", err)
+	}
+	req.Header.Set("X-Service-Token", c.serviceToken)
+
+	resp, err := c.client.Do(req, userStatusTimeout)
+	if err != nil {
+		return models.NotificationRecipient{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return models.NotificationRecipient{}, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("returned status %d", resp.StatusCode)}
+	}
+
+	var recipient models.NotificationRecipient
+	if err := json.NewDecoder(resp.Body).Decode(&httpx.Envelope{Data: &recipient}); err != nil {
+		return models.NotificationRecipient{}, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("failed to decode notification recipient: %w", err)}
+	}
+
+	return recipient, nil
+}
+
+// GetUserDetails returns the details of each of the users, by ID. Users
+// whose details were not looked up within the TTL are looked up together,
+// up to 100 in one call.
+func (c *UserStatusClient) GetUserDetails(userIDs []string) (map[string]models.UserDetail, error) {
+	details, missing := c.cachedDetails(userIDs)
+	for start := 0; start < len(missing); start += maxUserDetailBatchSize {
+		end := min(start+maxUserDetailBatchSize, len(missing))
+		fetched, err := c.fetchUserDetails(missing[start:end])
+		if err != nil {
+			return nil, err
+		}
+		c.storeDetails(fetched)
+		for _, detail := range fetched {
+			details[detail.UserID] = detail
+		}
+	}
+
+	return details, nil
+}
+
+// fetchUserDetails looks the users' details up on the client-service
+func (c *UserStatusClient) fetchUserDetails(userIDs []string) ([]models.UserDetail, error) {
+	body, err := json.Marshal(map[string][]string{"user_ids": userIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode user details request: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/internal/users/details", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client-service request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Token", c.serviceToken)
+
+	resp, err := c.client.Do(req, userStatusTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("returned status %d", resp.StatusCode)}
+	}
+
+	var response struct {
+		Users []models.UserDetail `json:"users"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&httpx.Envelope{Data: &response}); err != nil {
+		return nil, &resilience.DependencyError{Dependency: "client-service", Err: fmt.Errorf("failed to decode user details: %w", err)}
+	}
+
+	return response.Users, nil
+}
+
+// cachedDetails returns the details of the users looked up within the TTL,
+// and the IDs of the others without duplicates
+func (c *UserStatusClient) cachedDetails(userIDs []string) (map[string]models.UserDetail, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	details := make(map[string]models.UserDetail, len(userIDs))
+	seen := make(map[string]bool, len(userIDs))
+	var missing []string
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		if entry, ok := c.details[userID]; ok && entry.expiresAt.After(now) {
+			details[userID] = entry.detail
+			continue
+		}
+		missing = append(missing, userID)
+	}
+	return details, missing
+}
+
+// storeDetails keeps the users' details for the TTL, dropping expired
+// entries
+func (c *UserStatusClient) storeDetails(details []models.UserDetail) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for id, entry := range c.details {
+		if !entry.expiresAt.After(now) {
+			delete(c.details, id)
+		}
+	}
+	for _, detail := range details {
+		c.details[detail.UserID] = cachedUserDetail{detail: detail, expiresAt: now.Add(c.ttl)}
+	}
+}
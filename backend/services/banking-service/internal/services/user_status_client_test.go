@@ -0,0 +1,246 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"microbank/banking-service/internal/models"
+	"microbank/pkg/httpx"
+	"microbank/pkg/resilience"
+)
+
+func TestUserStatusClient_GetUserStatus(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("X-Service-Token") != "service-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/internal/users/admin-1/status":
+			json.NewEncoder(w).Encode(httpx.Envelope{Data: models.UserStatus{UserID: "admin-1", Exists: true, IsAdmin: true}})
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := NewUserStatusClient(server.URL+"/", "service-token", DefaultUserStatusTTL, resilience.DefaultConfig)
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	status, err := client.GetUserStatus("admin-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !status.Exists || !status.IsAdmin {
+		t.Errorf("Expected an existing admin, got %+v", status)
+	}
+
+	// Within the TTL the status is reused
+	if _, err := client.GetUserStatus("admin-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call within the TTL, got %d", calls)
+	}
+
+	// Once it expires it is looked up again
+	now = now.Add(DefaultUserStatusTTL)
+	if _, err := client.GetUserStatus("admin-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls after the TTL, got %d", calls)
+	}
+
+	_, err = client.GetUserStatus("broken")
+	if !errors.Is(err, resilience.ErrDependencyUnavailable) {
+		t.Errorf("Expected ErrDependencyUnavailable, got %v", err)
+	}
+}
+
+func TestUserStatusClient_VerifyPassword(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Service-Token") != "service-token" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var request struct {
+			Password string `json:"password"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		switch r.URL.Path {
+		case "/internal/users/user-1/password/verify":
+			json.NewEncoder(w).Encode(httpx.Envelope{Data: map[string]bool{"valid": request.Password == "Str0ng!Passw0rd"}})
+		case "/internal/users/gone/password/verify":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := NewUserStatusClient(server.URL+"/", "service-token", DefaultUserStatusTTL, resilience.DefaultConfig)
+
+	tests := []struct {
+		name      string
+		userID    string
+		password  string
+		wantValid bool
+		wantErr   error
+	}{
+		{name: "right password", userID: "user-1", password: "Str0ng!Passw0rd", wantValid: true},
+		{name: "wrong password", userID: "user-1", password: "guess"},
+		{name: "unknown user", userID: "gone", password: "Str0ng!Passw0rd"},
+		{name: "client-service failing", userID: "broken", password: "Str0ng!Passw0rd", wantErr: resilience.ErrDependencyUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := client.VerifyPassword(tt.userID, tt.password)
+			if !errors.Is(err, tt.wantErr) || valid != tt.wantValid {
+				t.Errorf("Expected %v (%v), got %v (%v)", tt.wantValid, tt.wantErr, valid, err)
+			}
+		})
+	}
+}
+
+func TestUserStatusClient_LookupUserByEmail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/internal/users/lookup" || r.Header.Get("X-Service-Token") != "service-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var request struct {
+			Email string `json:"email"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		switch request.Email {
+		case "partner@example.com":
+			json.NewEncoder(w).Encode(httpx.Envelope{Data: models.UserDetail{UserID: "user-2", Exists: true, Email: request.Email, Name: "Partner"}})
+		case "broken@example.com":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			json.NewEncoder(w).Encode(httpx.Envelope{Data: models.UserDetail{}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewUserStatusClient(server.URL, "service-token", DefaultUserStatusTTL, resilience.DefaultConfig)
+
+	detail, err := client.LookupUserByEmail("partner@example.com")
+	if err != nil || !detail.Exists || detail.UserID != "user-2" {
+		t.Errorf("Expected the partner's details, got %+v (%v)", detail, err)
+	}
+	if detail, err := client.LookupUserByEmail("nobody@example.com"); err != nil || detail.Exists {
+		t.Errorf("Expected an unknown email to be reported as not existing, got %+v (%v)", detail, err)
+	}
+	if _, err := client.LookupUserByEmail("broken@example.com"); !errors.Is(err, resilience.ErrDependencyUnavailable) {
+		t.Errorf("Expected ErrDependencyUnavailable, got %v", err)
+	}
+}
+
+func TestUserStatusClient_GetNotificationRecipient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.Header.Get("X-Service-Token") != "service-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Path {
+		case "/internal/users/user-1/notifications/low_balance":
+			json.NewEncoder(w).Encode(httpx.Envelope{Data: models.NotificationRecipient{
+				UserID:      "user-1",
+				Exists:      true,
+				Email:       "user@example.com",
+				PhoneNumber: "+15551234567",
+				Channels:    models.NotificationChannels{Email: true, SMS: true},
+			}})
+		case "/internal/users/user-2/notifications/low_balance":
+			json.NewEncoder(w).Encode(httpx.Envelope{Data: models.NotificationRecipient{UserID: "user-2"}})
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := NewUserStatusClient(server.URL, "service-token", DefaultUserStatusTTL, resilience.DefaultConfig)
+
+	recipient, err := client.GetNotificationRecipient("user-1", models.NotificationEventLowBalance)
+	if err != nil || !recipient.Exists || !recipient.Channels.SMS || recipient.Channels.Webhook || recipient.PhoneNumber != "+15551234567" {
+		t.Errorf("Expected user-1 by email and SMS, got %+v (%v)", recipient, err)
+	}
+	if recipient, err := client.GetNotificationRecipient("user-2", models.NotificationEventLowBalance); err != nil || recipient.Exists {
+		t.Errorf("Expected user-2 to be reported as not existing, got %+v (%v)", recipient, err)
+	}
+	if _, err := client.GetNotificationRecipient("user-3", models.NotificationEventLowBalance); !errors.Is(err, resilience.ErrDependencyUnavailable) {
+		t.Errorf("Expected ErrDependencyUnavailable, got %v", err)
+	}
+}
+
+func TestUserStatusClient_GetUserDetails(t *testing.T) {
+	var requests [][]string
+	down := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/internal/users/details" || r.Header.Get("X-Service-Token") != "service-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var request struct {
+			UserIDs []string `json:"user_ids"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request.UserIDs)
+
+		users := make([]models.UserDetail, 0, len(request.UserIDs))
+		for _, id := range request.UserIDs {
+			users = append(users, models.UserDetail{UserID: id, Exists: id != "gone", Email: id + "@example.com"})
+		}
+		json.NewEncoder(w).Encode(httpx.Envelope{Data: map[string]any{"users": users}})
+	}))
+	defer server.Close()
+
+	client := NewUserStatusClient(server.URL, "service-token", DefaultUserStatusTTL, resilience.DefaultConfig)
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	// A page's users are looked up in one call, without duplicates
+	details, err := client.GetUserDetails([]string{"user-1", "user-2", "user-1", "gone"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(requests) != 1 || len(requests[0]) != 3 {
+		t.Fatalf("Expected 1 call for 3 users, got %v", requests)
+	}
+	if details["user-2"].Email != "user-2@example.com" || details["gone"].Exists {
+		t.Errorf("Expected the users' details, got %+v", details)
+	}
+
+	// Within the TTL only users not looked up yet are asked for
+	if _, err := client.GetUserDetails([]string{"user-1", "user-3"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(requests) != 2 || len(requests[1]) != 1 || requests[1][0] != "user-3" {
+		t.Errorf("Expected only user-3 to be looked up, got %v", requests)
+	}
+
+	// Cached details are served while the client-service is down, but
+	// others cannot be looked up
+	down = true
+	if _, err := client.GetUserDetails([]string{"user-1"}); err != nil {
+		t.Errorf("Expected cached details, got %v", err)
+	}
+	now = now.Add(DefaultUserStatusTTL)
+	_, err = client.GetUserDetails([]string{"user-1"})
+	if !errors.Is(err, resilience.ErrDependencyUnavailable) {
+		t.Errorf("Expected ErrDependencyUnavailable, got %v", err)
+	}
+}
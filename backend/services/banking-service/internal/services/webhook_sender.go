@@ -0,0 +1,86 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Webhook signature headers. The signature is "sha256=" and the hex
+// HMAC-SHA256, under the webhook's secret, of the timestamp, a full stop
+// and the body, so receivers can check both where a payload came from and
+// that it is not an old one sent again.
+const (
+	WebhookSignatureHeader = "X-Microbank-Signature"
+	WebhookTimestampHeader = "X-Microbank-Timestamp"
+)
+
+// DefaultWebhookTimeout bounds each webhook call
+const DefaultWebhookTimeout = 10 * time.Second
+
+// WebhookSender posts notifications to users' webhooks
+type WebhookSender interface {
+	Send(ctx context.Context, url, secret string, payload []byte) error
+}
+
+// HTTPWebhookSender posts webhook payloads as signed JSON. Redirects are
+// not followed, and any response but a 2xx is a failed delivery.
+type HTTPWebhookSender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewHTTPWebhookSender creates a webhook sender whose calls time out after
+// timeout
+func NewHTTPWebhookSender(timeout time.Duration) *HTTPWebhookSender {
+	return &HTTPWebhookSender{
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// Send posts payload to url, signed with secret
+func (s *HTTPWebhookSender) Send(ctx context.Context, url, secret string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(secret, timestamp, payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook call failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhook returns the signature of a webhook payload sent at
+// timestamp, in Unix seconds, under secret
+func SignWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -1,27 +1,62 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"math/rand"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
+	// Embedded, so users' time zones load on hosts without a zone database
+	_ "time/tzdata"
 
+	"microbank/client-service/internal/config"
 	"microbank/client-service/internal/handlers"
 	"microbank/client-service/internal/middleware"
 	"microbank/client-service/internal/repository"
 	"microbank/client-service/internal/services"
+	"microbank/pkg/authmw"
+	"microbank/pkg/events"
+	sharedjwt "microbank/pkg/jwt"
+	"microbank/pkg/mailer"
+	"microbank/pkg/redact"
+	"microbank/pkg/resilience"
+	"microbank/pkg/tlsserver"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
 func main() {
+	// Work in UTC whatever the host's zone, so every time is written and
+	// returned in UTC
+	time.Local = time.UTC
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using system environment variables")
 	}
 
+	// Load and check the configuration before anything is started
+	validateOnly := flag.Bool("validate-config", false, "check the configuration and exit with status 0 if it is valid or 1 if not")
+	flag.Parse()
+	cfg, err := config.Load()
+	if *validateOnly {
+		exitAfterValidation(err)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize database connection
-	db, err := repository.NewPostgresDB()
+	db, err := repository.NewPostgresDB(cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -30,27 +65,234 @@ func main() {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	passwordResetTokenRepo := repository.NewPasswordResetTokenRepository(db)
+	magicLinkTokenRepo := repository.NewMagicLinkTokenRepository(db)
+	loginEventRepo := repository.NewLoginEventRepository(db)
+	passwordHistoryRepo := repository.NewPasswordHistoryRepository(db)
+	blacklistRepo := repository.NewBlacklistRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	phoneVerificationRepo := repository.NewPhoneVerificationRepository(db)
+	loginOTPRepo := repository.NewLoginOTPRepository(db)
+	notificationPreferenceRepo := repository.NewNotificationPreferenceRepository(db)
+	userPreferenceRepo := repository.NewUserPreferenceRepository(db)
+	knownDeviceRepo := repository.NewKnownDeviceRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	oauthStateRepo := repository.NewOAuthStateRepository(db)
+	personalAccessTokenRepo := repository.NewPersonalAccessTokenRepository(db)
+	kycRepo := repository.NewKYCRepository(db)
+	kycDocumentRepo := repository.NewKYCDocumentRepository(db)
+	downloadArtifactRepo := repository.NewDownloadArtifactRepository(db)
+	statementRepo := repository.NewStatementRepository(db)
+
+	// Initialize email and SMS senders. Email goes to the log unless
+	// SMTP_HOST is set, and is queued so requests never wait on delivery.
+	emailSender := mailer.NewQueue(cfg.EmailSender, mailer.DefaultQueueWorkers, mailer.DefaultQueueSize)
+	smsSender := services.NewLogSMSSender()
+
+	// Initialize access token signing and verification
+	tokenKeys := cfg.TokenKeys
+	log.Printf("Signing access tokens with %s (kid %q)", tokenKeys.Algorithm(), tokenKeys.ActiveKID())
+	log.Printf("Verifying access tokens with kids %v (tokens without a kid accepted: %t)", tokenKeys.KeyIDs(), tokenKeys.AcceptsUnnamedHMAC())
+	tokenManager := sharedjwt.NewTokenManagerWithKeys(tokenKeys, tokenKeys, cfg.TokenTTLs.Access, cfg.TokenTTLs.Refresh).
+		WithShortRefreshTTL(cfg.TokenTTLs.ShortRefresh).
+		WithValidateOptions(cfg.ValidateOptions...)
+	revocations := cfg.Revocations
+
+	if len(cfg.OAuthProviders) == 0 {
+		log.Println("Google sign-in disabled: GOOGLE_CLIENT_ID, GOOGLE_CLIENT_SECRET and GOOGLE_REDIRECT_URL are not set")
+	}
+	if cfg.KYCDocuments == nil {
+		log.Println("KYC document uploads disabled: KYC_DOCUMENT_ENCRYPTION_KEY is not set")
+	}
+	if cfg.Downloads == nil {
+		log.Println("Download links disabled: DOWNLOAD_ENCRYPTION_KEY is not set")
+	}
+
+	// Initialize banking-service client
+	bankingClient := services.NewHTTPBankingClient(cfg.BankingInternalURL, cfg.InternalServiceToken, cfg.Resilience)
+	if cfg.Mutual != nil {
+		bankingClient.WithTransport(cfg.Mutual.ClientTransport())
+	}
+
+	// Provision bank accounts for new users in the background
+	accountProvisioner := services.NewAccountProvisioner(bankingClient)
+
+	// "reconcile-accounts" creates missing bank accounts and exits instead
+	// of serving
+	if flag.Arg(0) == "reconcile-accounts" {
+		reconcileAccounts(accountProvisioner, userRepo)
+		return
+	}
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, refreshTokenRepo)
-	userService := services.NewUserService(userRepo)
+	deletionRetention := cfg.UserDeletionRetention
+	passwordHistory := services.NewPasswordHistoryService(passwordHistoryRepo, cfg.PasswordHasher, cfg.PasswordHistorySize)
+	notificationPreferenceService := services.NewNotificationPreferenceService(notificationPreferenceRepo)
+	userPreferenceService := services.NewUserPreferenceService(userPreferenceRepo)
+	loginAlertService := services.NewLoginAlertService(knownDeviceRepo, userRepo, refreshTokenRepo, emailSender, services.NewNoopGeoIPResolver(), notificationPreferenceService)
+	invitationService := services.NewInvitationService(invitationRepo, cfg.RegistrationMode)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, loginEventRepo, cfg.PasswordPolicy, cfg.PasswordHasher, passwordHistory, bankingClient, deletionRetention, loginAlertService, invitationService, tokenManager, revocations, cfg.MaxRefreshTokensPerUser, accountProvisioner).
+		WithLastLoginOnRefresh(cfg.LastLoginOnRefresh)
+	oauthService := services.NewOAuthService(oauthStateRepo, userRepo, authService, cfg.OAuthProviders...)
+	userService := services.NewUserService(userRepo, refreshTokenRepo, loginEventRepo, blacklistRepo, auditLogRepo, bankingClient, deletionRetention)
+	passwordResetService := services.NewPasswordResetService(userRepo, refreshTokenRepo, passwordResetTokenRepo, emailSender, cfg.PasswordPolicy, cfg.PasswordHasher, passwordHistory)
+	adminUserService := services.NewAdminUserService(userRepo, passwordResetTokenRepo, emailSender, accountProvisioner)
+	emailChangeService := services.NewEmailChangeService(userRepo, refreshTokenRepo, emailSender, cfg.PasswordHasher)
+	phoneVerificationService := services.NewPhoneVerificationService(userRepo, phoneVerificationRepo, smsSender)
+	loginOTPService := services.NewLoginOTPService(userRepo, loginOTPRepo, smsSender, authService)
+	magicLinkService := services.NewMagicLinkService(userRepo, magicLinkTokenRepo, emailSender, authService)
+	auditLogService := services.NewAuditLogService(auditLogRepo)
+	adminStatsService := services.NewAdminStatsService(userRepo, refreshTokenRepo)
+	adminDashboardService := services.NewAdminDashboardService(adminStatsService, bankingClient)
+	userExportService := services.NewUserExportService(userRepo, auditLogRepo)
+	tokenRevocationService := services.NewTokenRevocationService(userRepo, refreshTokenRepo, auditLogRepo, revocations, bankingClient)
+	personalAccessTokenService := services.NewPersonalAccessTokenService(personalAccessTokenRepo, userRepo)
+	impersonationService := services.NewImpersonationService(userRepo, auditLogRepo, tokenManager, revocations, bankingClient)
+	kycService := services.NewKYCService(kycRepo, userRepo)
+	var kycDocumentService *services.KYCDocumentService
+	if cfg.KYCDocuments != nil {
+		kycDocumentService = services.NewKYCDocumentService(kycDocumentRepo, kycRepo, auditLogRepo, cfg.KYCDocuments, cfg.KYCDocumentRetention)
+	}
+	var downloadService *services.DownloadService
+	if cfg.Downloads != nil {
+		downloadService = services.NewDownloadService(downloadArtifactRepo, userRepo, cfg.Downloads, cfg.DownloadTokenSecret, cfg.DownloadTTL, cfg.DownloadMaxCount)
+		userExportService.WithDownloads(downloadService, emailSender)
+	}
+	// Statements are emailed without the queue, so failed sends are seen
+	// and retried
+	statementService := services.NewStatementService(statementRepo, userRepo, bankingClient, cfg.EmailSender, notificationPreferenceService).
+		WithSkipInactive(cfg.StatementSkipInactive)
+
+	// Start login event retention cleanup
+	go purgeLoginEventsPeriodically(userService, cfg.LoginEventRetention)
+
+	// Start expired blacklist lifting
+	go liftExpiredBlacklistsPeriodically(userService)
+
+	// Start purging users past their restore window
+	go purgeDeletedUsersPeriodically(userService)
+
+	// Start expired refresh token cleanup, stopped on shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var background sync.WaitGroup
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		cleanupRefreshTokensPeriodically(ctx, userService, cfg.RefreshTokenCleanupInterval)
+	}()
+
+	// Start publishing user lifecycle events from the outbox, stopped on
+	// shutdown
+	eventPublisher := events.NewHTTPPublisher(cfg.EventsURL, cfg.InternalServiceToken)
+	if cfg.Mutual != nil {
+		eventPublisher.WithTransport(cfg.Mutual.ClientTransport())
+	}
+	eventRelay := events.NewRelay(db.DB, eventPublisher, events.DefaultRelayBatchSize)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		eventRelay.Run(ctx, events.DefaultRelayInterval)
+	}()
+
+	// Start deleting KYC documents past their retention, stopped on
+	// shutdown
+	if kycDocumentService != nil {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			purgeKYCDocumentsPeriodically(ctx, kycDocumentService)
+		}()
+	}
+
+	// Start deleting expired downloads, stopped on shutdown
+	if downloadService != nil {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			purgeDownloadsPeriodically(ctx, downloadService)
+		}()
+	}
+
+	// Start emailing monthly statements to subscribers, stopped on shutdown
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		deliverStatementsPeriodically(ctx, statementService)
+	}()
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(userService)
+	authHandler := handlers.NewAuthHandler(authService, passwordResetService, cfg.AuthCookies)
+	userHandler := handlers.NewUserHandler(userService, emailChangeService)
+	phoneVerificationHandler := handlers.NewPhoneVerificationHandler(phoneVerificationService)
+	loginOTPHandler := handlers.NewLoginOTPHandler(loginOTPService, cfg.AuthCookies)
+	magicLinkHandler := handlers.NewMagicLinkHandler(magicLinkService, cfg.AuthCookies, os.Getenv("MAGIC_LINK_REDIRECT_URL"))
+	notificationPreferenceHandler := handlers.NewNotificationPreferenceHandler(notificationPreferenceService)
+	statementHandler := handlers.NewStatementHandler(statementService)
+	userPreferenceHandler := handlers.NewUserPreferenceHandler(userPreferenceService)
+	loginAlertHandler := handlers.NewLoginAlertHandler(loginAlertService)
 	adminHandler := handlers.NewAdminHandler(userService)
+	auditLogHandler := handlers.NewAuditLogHandler(auditLogService)
+	adminStatsHandler := handlers.NewAdminStatsHandler(adminStatsService)
+	adminDashboardHandler := handlers.NewAdminDashboardHandler(adminDashboardService)
+	userExportHandler := handlers.NewUserExportHandler(userExportService)
+	userStatusHandler := handlers.NewUserStatusHandler(userService, notificationPreferenceService)
+	eventHandler := handlers.NewEventHandler(
+		services.NewSavingsGoalEventConsumer(userRepo, emailSender, notificationPreferenceService),
+		services.NewDisputeEventConsumer(userRepo, auditLogRepo, emailSender, notificationPreferenceService),
+		services.NewExternalTransferEventConsumer(userRepo, emailSender, notificationPreferenceService),
+		services.NewMaintenanceEventConsumer(auditLogRepo),
+		services.NewTransactionPINEventConsumer(auditLogRepo),
+	)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+	adminUserHandler := handlers.NewAdminUserHandler(adminUserService)
+	oauthHandler := handlers.NewOAuthHandler(oauthService)
+	jwksHandler := handlers.NewJWKSHandler(tokenKeys)
+	tokenRevocationHandler := handlers.NewTokenRevocationHandler(tokenRevocationService)
+	personalAccessTokenHandler := handlers.NewPersonalAccessTokenHandler(personalAccessTokenService)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService)
+	kycHandler := handlers.NewKYCHandler(kycService)
+	var kycDocumentHandler *handlers.KYCDocumentHandler
+	if kycDocumentService != nil {
+		kycDocumentHandler = handlers.NewKYCDocumentHandler(kycDocumentService)
+	}
+	var downloadHandler *handlers.DownloadHandler
+	if downloadService != nil {
+		downloadHandler = handlers.NewDownloadHandler(downloadService)
+	}
 
 	// Set Gin mode
-	if os.Getenv("GIN_MODE") == "release" {
-		gin.SetMode(gin.ReleaseMode)
+	gin.SetMode(cfg.GinMode)
+
+	// Optionally reject request bodies with fields the endpoint does not accept
+	handlers.RejectUnknownFields(cfg.RejectUnknownFields)
+
+	// Initialize rate limiting for auth endpoints
+	trustedProxies := cfg.TrustedProxies
+	rateLimitStore := middleware.NewInMemoryRateLimitStore()
+	rateLimit := func(name string, requests int, window time.Duration) gin.HandlerFunc {
+		return middleware.RateLimit(rateLimitStore, trustedProxies, middleware.LoadRateLimitConfig(name, requests, window))
 	}
+	globalRateLimit := func(name string, requests int, window time.Duration) gin.HandlerFunc {
+		return middleware.GlobalRateLimit(rateLimitStore, middleware.LoadRateLimitConfig(name, requests, window))
+	}
+
+	// Block IPs that present too many unknown refresh tokens
+	refreshGuard := middleware.NewRefreshGuard(middleware.LoadRefreshGuardConfig())
+	refreshGuardHandler := handlers.NewRefreshGuardHandler(refreshGuard)
 
 	// Create router
 	r := gin.Default()
 
+	// Only trust X-Forwarded-For from configured proxies when resolving c.ClientIP()
+	if err := r.SetTrustedProxies(trustedProxies.CIDRs()); err != nil {
+		log.Fatalf("Failed to set trusted proxies: %v", err)
+	}
+
 	// Add middleware
-	r.Use(middleware.CORS())
-	r.Use(middleware.Logger())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.CORS(cfg.CORS))
+	r.Use(middleware.Logger(redact.LogRedactionFromEnv()))
 	r.Use(middleware.Recovery())
 
 	// Health check endpoint
@@ -62,50 +304,399 @@ func main() {
 		})
 	})
 
+	// Circuit breaker state for inter-service calls, and refresh token
+	// guessing
+	r.GET("/metrics", func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		resilience.WriteMetrics(c.Writer)
+		refreshGuard.WriteMetrics(c.Writer)
+	})
+
+	// Public keys for verifying access tokens
+	r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// Public routes
 	api := r.Group("/api/v1")
+	// Every API endpoint takes JSON; bound the body before anything reads it
+	api.Use(middleware.JSONBody(cfg.BodyLimits.MaxBytes))
 	{
 		// Auth routes
 		auth := api.Group("/auth")
 		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
-			auth.POST("/refresh", authHandler.RefreshToken)
-			// Validate token requires authentication
-			auth.GET("/validate", middleware.AuthMiddleware(), authHandler.ValidateToken)
+			auth.POST("/register", rateLimit("register", 5, time.Hour), authHandler.Register)
+			auth.POST("/login", rateLimit("login", 10, time.Minute), authHandler.Login)
+			auth.POST("/otp/request", rateLimit("otp-request", 5, time.Hour), loginOTPHandler.RequestCode)
+			auth.POST("/otp/verify", rateLimit("otp-verify", 10, time.Minute), loginOTPHandler.VerifyCode)
+			auth.POST("/magic-link", rateLimit("magic-link", 5, time.Hour), magicLinkHandler.RequestLink)
+			auth.GET("/magic-link/consume", rateLimit("magic-link-consume", 20, time.Minute), magicLinkHandler.ConsumeLink)
+			auth.POST("/refresh", middleware.GuardRefresh(refreshGuard, trustedProxies), rateLimit("refresh", 30, time.Minute), globalRateLimit("refresh-global", 600, time.Minute), middleware.CSRF(), authHandler.RefreshToken)
+			auth.POST("/logout", middleware.CSRF(), authHandler.Logout)
+			auth.POST("/forgot-password", rateLimit("forgot-password", 5, time.Hour), authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
+			auth.POST("/email/verify", userHandler.VerifyEmailChange)
+			auth.POST("/email/cancel", userHandler.CancelEmailChange)
+			auth.POST("/login/report", loginAlertHandler.ReportLogin)
+			auth.GET("/oauth/:provider/start", rateLimit("oauth-start", 20, time.Minute), oauthHandler.StartOAuth)
+			auth.GET("/oauth/:provider/callback", rateLimit("oauth-callback", 20, time.Minute), oauthHandler.Callback)
+			// Validate token requires authentication. Personal access tokens
+			// are only accepted here, where the banking service confirms them.
+			auth.GET("/validate", middleware.AuthMiddleware(tokenManager, userRepo, revocations, middleware.PersonalAccessTokens(personalAccessTokenService)), authHandler.ValidateToken)
+		}
+
+		// Download links carry their own signed token, so they need no
+		// session
+		if downloadHandler != nil {
+			api.GET("/downloads/:token", rateLimit("download", 30, time.Minute), downloadHandler.Download)
 		}
 
 		// Protected routes
 		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.CSRF(), middleware.AuthMiddleware(tokenManager, userRepo, revocations))
 		{
-			// Profile routes
+			// Ends the impersonation session the request was made in
+			protected.POST("/auth/impersonation/end", impersonationHandler.EndImpersonation)
+
+			// Profile routes. Admins impersonating the user cannot change
+			// their credentials or grant themselves lasting access.
+			userOnly := middleware.ForbidImpersonation()
 			profile := protected.Group("/profile")
 			{
 				profile.GET("", userHandler.GetProfile)
 				profile.PUT("", userHandler.UpdateProfile)
+				profile.PATCH("", userHandler.UpdateProfile)
+				profile.DELETE("", userOnly, authHandler.DeleteAccount)
+				profile.PUT("/password", userOnly, authHandler.ChangePassword)
+				profile.PUT("/email", userOnly, userHandler.ChangeEmail)
+				profile.POST("/phone/verify/start", phoneVerificationHandler.StartPhoneVerification)
+				profile.POST("/phone/verify/confirm", phoneVerificationHandler.ConfirmPhoneVerification)
+				profile.GET("/notifications", notificationPreferenceHandler.GetPreferences)
+				profile.PUT("/notifications", notificationPreferenceHandler.UpdatePreferences)
+				profile.GET("/statements/subscription", statementHandler.GetSubscription)
+				profile.PUT("/statements/subscription", statementHandler.UpdateSubscription)
+				profile.DELETE("/statements/subscription", statementHandler.DeleteSubscription)
+				profile.GET("/preferences", userPreferenceHandler.GetPreferences)
+				profile.PUT("/preferences", userPreferenceHandler.UpdatePreferences)
+				profile.GET("/login-history", userHandler.GetLoginHistory)
+				profile.GET("/oauth/:provider/link", userOnly, oauthHandler.StartOAuthLink)
+				profile.GET("/tokens", personalAccessTokenHandler.ListTokens)
+				profile.POST("/tokens", userOnly, personalAccessTokenHandler.CreateToken)
+				profile.DELETE("/tokens/:id", userOnly, personalAccessTokenHandler.RevokeToken)
+				profile.GET("/kyc", kycHandler.GetStatus)
+				profile.POST("/kyc", userOnly, kycHandler.Submit)
 			}
 
-			// Admin routes - require admin role
+			// Admin routes - require a staff role, and each route the
+			// permission it needs
 			admin := protected.Group("/admin")
-			admin.Use(middleware.AdminMiddleware())
+			admin.Use(middleware.StaffMiddleware(userRepo))
 			{
-				admin.GET("/clients", adminHandler.GetAllClients)
-				admin.POST("/clients/:id/blacklist", adminHandler.BlacklistClient)
-				admin.DELETE("/clients/:id/blacklist", adminHandler.RemoveFromBlacklist)
+				can := middleware.RequirePermission
+				admin.GET("/stats", can(authmw.PermissionClientsRead), adminStatsHandler.GetStats)
+				admin.GET("/reports/signups", can(authmw.PermissionClientsRead), adminStatsHandler.GetSignupReport)
+				admin.GET("/dashboard", can(authmw.PermissionClientsRead), adminDashboardHandler.GetDashboard)
+				admin.GET("/clients", can(authmw.PermissionClientsRead), adminHandler.GetAllClients)
+				admin.HEAD("/clients", can(authmw.PermissionClientsRead), adminHandler.GetAllClients)
+				admin.POST("/clients", can(authmw.PermissionClientsCreate), adminUserHandler.CreateClient)
+				admin.GET("/clients/export", can(authmw.PermissionClientsExport), userExportHandler.ExportClients)
+				if downloadHandler != nil {
+					admin.POST("/clients/export", can(authmw.PermissionClientsExport), userExportHandler.RequestClientsExport)
+				}
+				admin.GET("/clients/:id", can(authmw.PermissionClientsRead), adminHandler.GetClient)
+				admin.POST("/clients/blacklist-batch", can(authmw.PermissionClientsBlacklist), adminHandler.BlacklistClients)
+				admin.POST("/clients/unblacklist-batch", can(authmw.PermissionClientsBlacklist), adminHandler.RemoveClientsFromBlacklist)
+				admin.DELETE("/clients/:id", can(authmw.PermissionClientsDelete), adminHandler.DeleteClient)
+				admin.POST("/clients/:id/restore", can(authmw.PermissionClientsDelete), adminHandler.RestoreClient)
+				admin.POST("/clients/:id/admin-role", can(authmw.PermissionRolesManage), adminHandler.GrantAdminRole)
+				admin.DELETE("/clients/:id/admin-role", can(authmw.PermissionRolesManage), adminHandler.RevokeAdminRole)
+				admin.POST("/clients/:id/roles", can(authmw.PermissionRolesManage), adminHandler.GrantRole)
+				admin.DELETE("/clients/:id/roles/:role", can(authmw.PermissionRolesManage), adminHandler.RevokeRole)
+				admin.POST("/clients/:id/blacklist", can(authmw.PermissionClientsBlacklist), adminHandler.BlacklistClient)
+				admin.DELETE("/clients/:id/blacklist", can(authmw.PermissionClientsBlacklist), adminHandler.RemoveFromBlacklist)
+				admin.GET("/clients/:id/blacklist-history", can(authmw.PermissionClientsRead), adminHandler.GetClientBlacklistHistory)
+				admin.GET("/clients/:id/login-history", can(authmw.PermissionClientsRead), adminHandler.GetClientLoginHistory)
+				admin.POST("/clients/:id/force-logout", can(authmw.PermissionSessionsRevoke), tokenRevocationHandler.ForceLogout)
+				admin.POST("/clients/:id/impersonate", can(authmw.PermissionClientsImpersonate), impersonationHandler.StartImpersonation)
+				admin.POST("/clients/:id/kyc/approve", can(authmw.PermissionKYCReview), kycHandler.Approve)
+				admin.POST("/clients/:id/kyc/reject", can(authmw.PermissionKYCReview), kycHandler.Reject)
+				admin.POST("/clients/:id/statements/:period/send", can(authmw.PermissionTransactionsRead), statementHandler.SendStatement)
+				admin.GET("/kyc", can(authmw.PermissionKYCReview), kycHandler.ListSubmissions)
+				if kycDocumentHandler != nil {
+					admin.GET("/clients/:id/kyc/documents", can(authmw.PermissionKYCReview), kycDocumentHandler.ListDocuments)
+					admin.GET("/kyc/documents/:id", can(authmw.PermissionKYCReview), kycDocumentHandler.Download)
+				}
+				admin.GET("/invitations", can(authmw.PermissionInvitationsManage), invitationHandler.ListInvitations)
+				admin.POST("/invitations", can(authmw.PermissionInvitationsManage), invitationHandler.CreateInvitation)
+				admin.DELETE("/invitations/:id", can(authmw.PermissionInvitationsManage), invitationHandler.RevokeInvitation)
+				admin.GET("/audit-log", can(authmw.PermissionAuditRead), auditLogHandler.GetAuditLog)
+				admin.GET("/audit-log/export", can(authmw.PermissionAuditRead), auditLogHandler.ExportAuditLog)
+				admin.POST("/maintenance/cleanup-tokens", can(authmw.PermissionMaintenanceRun), adminHandler.CleanupRefreshTokens)
+				admin.GET("/security/refresh-blocks", can(authmw.PermissionSessionsRevoke), refreshGuardHandler.ListBlocks)
+				admin.DELETE("/security/refresh-blocks/:ip", can(authmw.PermissionSessionsRevoke), refreshGuardHandler.ClearBlock)
 			}
 		}
 	}
 
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8081"
+	// Upload routes take multipart bodies, so they are bounded by the
+	// upload limit instead of the JSON checks
+	if kycDocumentHandler != nil {
+		uploads := r.Group("/api/v1")
+		uploads.Use(middleware.UploadBody(cfg.BodyLimits.MaxUploadBytes))
+		uploads.Use(middleware.CSRF(), middleware.AuthMiddleware(tokenManager, userRepo, revocations))
+		{
+			uploads.POST("/profile/kyc/documents", middleware.ForbidImpersonation(), kycDocumentHandler.Upload)
+		}
+	}
+
+	// Internal routes - called by other services, never exposed publicly.
+	// With mutual TLS they get their own listener that only accepts the
+	// certificates of allowed services.
+	internalRouter := r
+	if cfg.Mutual != nil {
+		internalRouter = gin.New()
+		internalRouter.Use(middleware.RequestID())
+		internalRouter.Use(middleware.Logger(redact.LogRedactionFromEnv()))
+		internalRouter.Use(middleware.Recovery())
+	}
+	internal := internalRouter.Group("/internal")
+	internal.Use(middleware.ServiceAuthMiddleware(cfg.InternalServiceToken))
+	internal.Use(middleware.JSONBody(cfg.BodyLimits.MaxBytes))
+	{
+		internal.POST("/token-revocations", tokenRevocationHandler.PushRevocations)
+		internal.GET("/users/:id/status", userStatusHandler.GetUserStatus)
+		internal.POST("/users/status", userStatusHandler.GetUserStatuses)
+		internal.POST("/users/details", userStatusHandler.GetUserDetails)
+		internal.POST("/users/lookup", userStatusHandler.LookupUser)
+		internal.GET("/users/:id/notifications/:event", userStatusHandler.GetNotificationRecipient)
+		internal.POST("/users/:id/password/verify", authHandler.VerifyPassword)
+		internal.POST("/events", eventHandler.HandleEvent)
+	}
+
+	server := &http.Server{Addr: ":" + strconv.Itoa(cfg.Port), Handler: r}
+	go func() {
+		log.Printf("Client Service starting on port %d (TLS: %t)", cfg.Port, cfg.Certificates != nil)
+		if err := tlsserver.ListenAndServe(server, cfg.Certificates); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// With TLS, pick up renewed certificates and optionally redirect plain
+	// HTTP to HTTPS
+	var redirectServer *http.Server
+	if cfg.Certificates != nil {
+		go cfg.Certificates.Watch(ctx, tlsserver.DefaultReloadInterval)
+		if cfg.TLS.RedirectPort != 0 {
+			redirectServer = tlsserver.NewRedirectServer(cfg.TLS.RedirectPort, cfg.Port)
+			go func() {
+				log.Printf("Redirecting HTTP on port %d to HTTPS", cfg.TLS.RedirectPort)
+				if err := redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Fatalf("Failed to start HTTP redirect server: %v", err)
+				}
+			}()
+		}
+	}
+
+	// With mutual TLS, serve the internal routes on their own listener
+	var internalServer *http.Server
+	if cfg.Mutual != nil {
+		go cfg.Mutual.Certificates().Watch(ctx, tlsserver.DefaultReloadInterval)
+		internalServer = cfg.Mutual.NewServer(cfg.InternalPort, internalRouter)
+		go func() {
+			log.Printf("Serving internal routes with mutual TLS on port %d", cfg.InternalPort)
+			if err := internalServer.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Failed to start internal server: %v", err)
+			}
+		}()
 	}
 
-	log.Printf("Client Service starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	<-ctx.Done()
+	log.Println("Client Service shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if redirectServer != nil {
+		redirectServer.Shutdown(shutdownCtx)
+	}
+	if internalServer != nil {
+		internalServer.Shutdown(shutdownCtx)
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown failed: %v", err)
+	}
+	background.Wait()
+	accountProvisioner.Wait()
+	userExportService.Wait()
+	if err := emailSender.Close(shutdownCtx); err != nil {
+		log.Printf("Failed to deliver queued emails: %v", err)
+	}
+}
+
+// exitAfterValidation reports the result of -validate-config and exits with
+// status 1 if the configuration is invalid, or 0 otherwise
+func exitAfterValidation(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println("Configuration is valid")
+	os.Exit(0)
+}
+
+// reconcileAccounts gives every user without a bank account one, exiting
+// with an error status if any could not be created
+func reconcileAccounts(provisioner *services.AccountProvisioner, userRepo repository.UserRepository) {
+	result, err := provisioner.ReconcileAccounts(userRepo)
+	if err != nil {
+		log.Fatalf("Account reconciliation failed after checking %d users: %v", result.Checked, err)
+	}
+
+	log.Printf("Account reconciliation checked %d users: %d accounts created, %d failed", result.Checked, result.Created, result.Failed)
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// purgeLoginEventsPeriodically deletes expired login events once a day
+func purgeLoginEventsPeriodically(userService *services.UserService, retention time.Duration) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := userService.PurgeLoginEvents(retention)
+		if err != nil {
+			log.Printf("Login event cleanup failed: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Login event cleanup removed %d events", deleted)
+		}
+		<-ticker.C
+	}
+}
+
+// liftExpiredBlacklistsPeriodically reinstates users whose temporary
+// blacklist has expired once a minute
+func liftExpiredBlacklistsPeriodically(userService *services.UserService) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		lifted, err := userService.LiftExpiredBlacklists()
+		if err != nil {
+			log.Printf("Expired blacklist cleanup failed: %v", err)
+		} else if lifted > 0 {
+			log.Printf("Lifted %d expired blacklists", lifted)
+		}
+		<-ticker.C
+	}
+}
+
+// purgeDeletedUsersPeriodically permanently removes users past their
+// restore window once an hour
+func purgeDeletedUsersPeriodically(userService *services.UserService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		purged, err := userService.PurgeDeletedUsers()
+		if err != nil {
+			log.Printf("Deleted user purge failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d deleted users", purged)
+		}
+		<-ticker.C
 	}
 }
 
+// cleanupRefreshTokensPeriodically deletes expired refresh tokens until ctx
+// is cancelled. Each wait adds up to 10% jitter to the interval so replicas
+// started together do not all delete at the same moment.
+func cleanupRefreshTokensPeriodically(ctx context.Context, userService *services.UserService, interval time.Duration) {
+	for {
+		deleted, err := userService.CleanupExpiredRefreshTokens(ctx)
+		if err != nil {
+			log.Printf("Refresh token cleanup failed: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Refresh token cleanup removed %d tokens", deleted)
+		}
+
+		wait := interval + time.Duration(rand.Int63n(int64(interval)/10+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// purgeKYCDocumentsPeriodically deletes KYC documents past their retention
+// once an hour until ctx is cancelled
+func purgeKYCDocumentsPeriodically(ctx context.Context, kycDocumentService *services.KYCDocumentService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := kycDocumentService.PurgeExpired(ctx)
+		if err != nil {
+			log.Printf("KYC document purge failed after deleting %d documents: %v", deleted, err)
+		} else if deleted > 0 {
+			log.Printf("Purged %d KYC documents", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDownloadsPeriodically deletes expired downloads once an hour until
+// ctx is cancelled
+func purgeDownloadsPeriodically(ctx context.Context, downloadService *services.DownloadService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := downloadService.PurgeExpired(ctx)
+		if err != nil {
+			log.Printf("Download purge failed after deleting %d downloads: %v", deleted, err)
+		} else if deleted > 0 {
+			log.Printf("Purged %d downloads", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverStatementsPeriodically schedules the statements that have come
+// due and sends those whose attempt is due once an hour until ctx is
+// cancelled
+func deliverStatementsPeriodically(ctx context.Context, statementService *services.StatementService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		scheduled, err := statementService.ScheduleDue()
+		if err != nil {
+			log.Printf("Statement scheduling failed after scheduling %d statements: %v", scheduled, err)
+		} else if scheduled > 0 {
+			log.Printf("Scheduled %d statements", scheduled)
+		}
+
+		sent, err := statementService.DeliverDue(ctx)
+		if err != nil {
+			log.Printf("Statement delivery failed after sending %d statements: %v", sent, err)
+		} else if sent > 0 {
+			log.Printf("Sent %d statements", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package authcookie delivers tokens to browsers in HttpOnly cookies instead
+// of response bodies, so scripts injected into a page cannot read them.
+// Because browsers send cookies on cross-site requests too, every session
+// also gets a CSRF token that state-changing requests must echo in a header
+// (the double-submit pattern).
+package authcookie
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"microbank/pkg/authmw"
+)
+
+const (
+	// RefreshTokenCookie holds the refresh token, sent only to the auth
+	// endpoints
+	RefreshTokenCookie = "refresh_token"
+	// AccessTokenCookie holds the access token when Config.AccessToken is
+	// set. The banking-service reads it too.
+	AccessTokenCookie = authmw.AccessTokenCookie
+	// CSRFTokenCookie holds the CSRF token. It is readable by scripts so the
+	// page can copy it into CSRFHeader.
+	CSRFTokenCookie = authmw.CSRFTokenCookie
+	// CSRFHeader carries the CSRF token on state-changing requests
+	CSRFHeader = authmw.CSRFHeader
+
+	// RefreshTokenPath scopes the refresh token cookie to the auth endpoints
+	RefreshTokenPath = "/api/v1/auth"
+	// AccessTokenPath scopes the access token cookie to the API
+	AccessTokenPath = "/api/v1"
+)
+
+// Mode selects when tokens are delivered in cookies
+type Mode string
+
+const (
+	// ModeOff never sets cookies
+	ModeOff Mode = "off"
+	// ModeRequest sets cookies for logins that ask for them with use_cookies
+	ModeRequest Mode = "request"
+	// ModeAlways sets cookies for every login
+	ModeAlways Mode = "always"
+)
+
+// Config controls cookie delivery and the cookies' attributes
+type Config struct {
+	Mode Mode
+	// AccessToken also delivers the access token in a cookie, leaving
+	// nothing for scripts to store
+	AccessToken bool
+	Secure      bool
+	SameSite    http.SameSite
+	Domain      string
+}
+
+// LoadFromEnv reads AUTH_COOKIE_MODE (off, request or always; default
+// request), AUTH_COOKIE_ACCESS_TOKEN (default false), AUTH_COOKIE_SECURE
+// (default true), AUTH_COOKIE_SAMESITE (strict, lax or none; default strict)
+// and AUTH_COOKIE_DOMAIN (default the host that set the cookie)
+func LoadFromEnv() (*Config, error) {
+	config := &Config{
+		Mode:     ModeRequest,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		Domain:   os.Getenv("AUTH_COOKIE_DOMAIN"),
+	}
+
+	switch mode := Mode(strings.ToLower(os.Getenv("AUTH_COOKIE_MODE"))); mode {
+	case "":
+	case ModeOff, ModeRequest, ModeAlways:
+		config.Mode = mode
+	default:
+		return nil, fmt.Errorf("invalid AUTH_COOKIE_MODE %q: must be off, request or always", mode)
+	}
+
+	for _, setting := range []struct {
+		name   string
+		target *bool
+	}{
+		{"AUTH_COOKIE_ACCESS_TOKEN", &config.AccessToken},
+		{"AUTH_COOKIE_SECURE", &config.Secure},
+	} {
+		value := os.Getenv(setting.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", setting.name, value, err)
+		}
+		*setting.target = parsed
+	}
+
+	switch value := strings.ToLower(os.Getenv("AUTH_COOKIE_SAMESITE")); value {
+	case "", "strict":
+	case "lax":
+		config.SameSite = http.SameSiteLaxMode
+	case "none":
+		if !config.Secure {
+			return nil, fmt.Errorf("AUTH_COOKIE_SAMESITE=none requires AUTH_COOKIE_SECURE")
+		}
+		config.SameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("invalid AUTH_COOKIE_SAMESITE %q: must be strict, lax or none", value)
+	}
+
+	return config, nil
+}
+
+// Enabled reports whether cookies may be used at all. A nil config is off.
+func (c *Config) Enabled() bool {
+	return c != nil && c.Mode != ModeOff
+}
+
+// Use reports whether a login should get cookies, given whether it asked
+// for them
+func (c *Config) Use(requested bool) bool {
+	if !c.Enabled() {
+		return false
+	}
+	return c.Mode == ModeAlways || requested
+}
+
+// SetSession sets the refresh token and CSRF token cookies, and the access
+// token cookie when configured, and returns the CSRF token. The refresh and
+// CSRF cookies expire with the refresh token; the access token cookie lasts
+// for the browser session and is replaced on every refresh.
+func (c *Config) SetSession(w http.ResponseWriter, refreshToken string, refreshExpiresAt time.Time, accessToken string) (string, error) {
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, c.cookie(RefreshTokenCookie, refreshToken, RefreshTokenPath, refreshExpiresAt, true))
+	http.SetCookie(w, c.cookie(CSRFTokenCookie, csrfToken, "/", refreshExpiresAt, false))
+	if c.AccessToken {
+		c.SetAccessToken(w, accessToken)
+	}
+	return csrfToken, nil
+}
+
+// SetAccessToken sets the access token cookie
+func (c *Config) SetAccessToken(w http.ResponseWriter, accessToken string) {
+	http.SetCookie(w, c.cookie(AccessTokenCookie, accessToken, AccessTokenPath, time.Time{}, true))
+}
+
+// Clear expires every auth cookie
+func (c *Config) Clear(w http.ResponseWriter) {
+	for _, cookie := range []*http.Cookie{
+		c.cookie(RefreshTokenCookie, "", RefreshTokenPath, time.Time{}, true),
+		c.cookie(AccessTokenCookie, "", AccessTokenPath, time.Time{}, true),
+		c.cookie(CSRFTokenCookie, "", "/", time.Time{}, false),
+	} {
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+	}
+}
+
+// cookie builds a cookie with the configured attributes. A zero expiry makes
+// a session cookie.
+func (c *Config) cookie(name, value, path string, expires time.Time, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   c.Domain,
+		Expires:  expires,
+		Secure:   c.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.SameSite,
+	}
+}
+
+// newCSRFToken returns a random URL-safe token
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate CSRF token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,99 @@
+package authcookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoadFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{name: "defaults", want: Config{Mode: ModeRequest, Secure: true, SameSite: http.SameSiteStrictMode}},
+		{
+			name: "overrides",
+			env:  map[string]string{"AUTH_COOKIE_MODE": "always", "AUTH_COOKIE_ACCESS_TOKEN": "true", "AUTH_COOKIE_SECURE": "false", "AUTH_COOKIE_SAMESITE": "lax", "AUTH_COOKIE_DOMAIN": "example.com"},
+			want: Config{Mode: ModeAlways, AccessToken: true, Secure: false, SameSite: http.SameSiteLaxMode, Domain: "example.com"},
+		},
+		{name: "unknown mode", env: map[string]string{"AUTH_COOKIE_MODE": "sometimes"}, wantErr: true},
+		{name: "invalid boolean", env: map[string]string{"AUTH_COOKIE_SECURE": "maybe"}, wantErr: true},
+		{name: "unknown same site", env: map[string]string{"AUTH_COOKIE_SAMESITE": "loose"}, wantErr: true},
+		{name: "same site none without secure", env: map[string]string{"AUTH_COOKIE_SAMESITE": "none", "AUTH_COOKIE_SECURE": "false"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"AUTH_COOKIE_MODE", "AUTH_COOKIE_ACCESS_TOKEN", "AUTH_COOKIE_SECURE", "AUTH_COOKIE_SAMESITE", "AUTH_COOKIE_DOMAIN"} {
+				t.Setenv(name, tt.env[name])
+			}
+			got, err := LoadFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFromEnv error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && *got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, *got)
+			}
+		})
+	}
+}
+
+func TestConfig_Use(t *testing.T) {
+	var off *Config
+	if off.Use(true) {
+		t.Error("Expected a nil config never to use cookies")
+	}
+	if (&Config{Mode: ModeOff}).Use(true) {
+		t.Error("Expected off mode never to use cookies")
+	}
+	if (&Config{Mode: ModeRequest}).Use(false) || !(&Config{Mode: ModeRequest}).Use(true) {
+		t.Error("Expected request mode to use cookies only when asked")
+	}
+	if !(&Config{Mode: ModeAlways}).Use(false) {
+		t.Error("Expected always mode to use cookies without being asked")
+	}
+}
+
+func TestConfig_SetSession(t *testing.T) {
+	config := &Config{Mode: ModeRequest, AccessToken: true, Secure: true, SameSite: http.SameSiteStrictMode}
+	expiresAt := time.Now().Add(time.Hour)
+
+	w := httptest.NewRecorder()
+	csrfToken, err := config.SetSession(w, "refresh", expiresAt, "access")
+	if err != nil {
+		t.Fatalf("SetSession returned error: %v", err)
+	}
+	if csrfToken == "" {
+		t.Fatal("Expected a CSRF token")
+	}
+
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		path     string
+		httpOnly bool
+	}{
+		{RefreshTokenCookie, "refresh", RefreshTokenPath, true},
+		{AccessTokenCookie, "access", AccessTokenPath, true},
+		{CSRFTokenCookie, csrfToken, "/", false},
+	}
+	for _, tt := range tests {
+		cookie, ok := cookies[tt.name]
+		if !ok {
+			t.Errorf("Expected a %s cookie", tt.name)
+			continue
+		}
+		if cookie.Value != tt.value || cookie.Path != tt.path || cookie.HttpOnly != tt.httpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteStrictMode {
+			t.Errorf("Unexpected %s cookie: %+v", tt.name, cookie)
+		}
+	}
+}
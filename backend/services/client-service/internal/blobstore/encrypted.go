@@ -0,0 +1,79 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the size of the AES-256 keys blobs are encrypted with
+const KeySize = 32
+
+// encryptionVersion is the first byte of every encrypted blob, so the
+// format can change without losing older blobs
+const encryptionVersion byte = 1
+
+// EncryptedStore encrypts blobs with AES-256-GCM before handing them to
+// another store, so the backing disk or bucket only ever holds ciphertext.
+// Each blob is sealed with a random nonce and bound to its key, so a blob
+// copied to another key fails to decrypt.
+type EncryptedStore struct {
+	store Store
+	aead  cipher.AEAD
+}
+
+// NewEncryptedStore wraps store, encrypting with the KeySize-byte key
+func NewEncryptedStore(store Store, key []byte) (*EncryptedStore, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedStore{store: store, aead: aead}, nil
+}
+
+// Put encrypts data and stores it under key
+func (s *EncryptedStore) Put(ctx context.Context, key string, data []byte) error {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := make([]byte, 0, 1+len(nonce)+len(data)+s.aead.Overhead())
+	sealed = append(sealed, encryptionVersion)
+	sealed = append(sealed, nonce...)
+	sealed = s.aead.Seal(sealed, nonce, data, []byte(key))
+	return s.store.Put(ctx, key, sealed)
+}
+
+// Get fetches the blob under key and decrypts it
+func (s *EncryptedStore) Get(ctx context.Context, key string) ([]byte, error) {
+	sealed, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := s.aead.NonceSize()
+	if len(sealed) < 1+nonceSize || sealed[0] != encryptionVersion {
+		return nil, errors.New("blob is not encrypted in a known format")
+	}
+	data, err := s.aead.Open(nil, sealed[1:1+nonceSize], sealed[1+nonceSize:], []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt blob: %w", err)
+	}
+	return data, nil
+}
+
+// Delete removes the blob under key
+func (s *EncryptedStore) Delete(ctx context.Context, key string) error {
+	return s.store.Delete(ctx, key)
+}
@@ -0,0 +1,73 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestEncryptedStore(t *testing.T) {
+	ctx := context.Background()
+	backing, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	store, err := NewEncryptedStore(backing, testKey(1))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	plaintext := []byte("%PDF-1.7 passport scan")
+	if err := store.Put(ctx, "user-1/doc-1", plaintext); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	sealed, err := backing.Get(ctx, "user-1/doc-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if bytes.Contains(sealed, []byte("passport")) {
+		t.Errorf("Expected the stored blob to be encrypted")
+	}
+
+	data, err := store.Get(ctx, "user-1/doc-1")
+	if err != nil || !bytes.Equal(data, plaintext) {
+		t.Fatalf("Expected the decrypted blob, got %q, %v", data, err)
+	}
+
+	// A blob copied to another key does not decrypt
+	if err := backing.Put(ctx, "user-2/doc-1", sealed); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := store.Get(ctx, "user-2/doc-1"); err == nil {
+		t.Errorf("Expected a blob moved to another key to fail to decrypt")
+	}
+
+	// Nor does a tampered blob
+	sealed[len(sealed)-1] ^= 0xff
+	if err := backing.Put(ctx, "user-1/doc-1", sealed); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := store.Get(ctx, "user-1/doc-1"); err == nil {
+		t.Errorf("Expected a tampered blob to fail to decrypt")
+	}
+
+	// Nor one read with another key
+	if err := store.Put(ctx, "user-1/doc-2", plaintext); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	other, _ := NewEncryptedStore(backing, testKey(2))
+	if _, err := other.Get(ctx, "user-1/doc-2"); err == nil {
+		t.Errorf("Expected a blob read with the wrong key to fail to decrypt")
+	}
+}
+
+func TestNewEncryptedStore_KeySize(t *testing.T) {
+	if _, err := NewEncryptedStore(nil, []byte("short")); err == nil {
+		t.Errorf("Expected a short key to be rejected")
+	}
+}
@@ -0,0 +1,56 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := store.Put(ctx, "user-1/doc-1", []byte("scan")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, err := store.Get(ctx, "user-1/doc-1")
+	if err != nil || string(data) != "scan" {
+		t.Fatalf("Expected the stored blob, got %q, %v", data, err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "user-1", "doc-1"))
+	if err != nil {
+		t.Fatalf("Expected the blob to be a file, got %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("Expected the blob to be readable only by its owner, got %v", perm)
+	}
+
+	if err := store.Delete(ctx, "user-1/doc-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := store.Get(ctx, "user-1/doc-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.Delete(ctx, "user-1/doc-1"); err != nil {
+		t.Errorf("Expected deleting a missing blob to succeed, got %v", err)
+	}
+}
+
+func TestLocalStore_InvalidKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../escape", "a/../../b", "a//b", "a/", "a b"} {
+		if err := store.Put(context.Background(), key, []byte("x")); err == nil {
+			t.Errorf("Expected key %q to be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Timeout bounds each request to the bucket, including the upload or
+// download of the blob
+const s3Timeout = 30 * time.Second
+
+// s3DefaultRegion is used when S3Config.Region is empty
+const s3DefaultRegion = "us-east-1"
+
+// S3Config locates an S3-compatible bucket, such as AWS S3 or MinIO
+type S3Config struct {
+	// Endpoint is the service's base URL, e.g. https://s3.eu-west-1.amazonaws.com
+	Endpoint        string
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3Store keeps blobs as objects in an S3-compatible bucket, addressed
+// path-style as <endpoint>/<bucket>/<key> so it works with services that
+// do not support virtual-hosted buckets. Requests are signed with AWS
+// Signature Version 4.
+type S3Store struct {
+	endpoint        *url.URL
+	bucket          string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	client          *http.Client
+	now             func() time.Time
+}
+
+// NewS3Store creates a store for the bucket in cfg
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("S3 endpoint must be an http or https URL, got %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("S3 access key ID and secret access key are required")
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	return &S3Store{
+		endpoint:        endpoint,
+		bucket:          cfg.Bucket,
+		region:          region,
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		client:          &http.Client{Timeout: s3Timeout},
+		now:             time.Now,
+	}, nil
+}
+
+// Put uploads data as the object key
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(http.MethodPut, resp)
+	}
+	return nil
+}
+
+// Get downloads the object key
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read S3 object: %w", err)
+		}
+		return data, nil
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, s3Error(http.MethodGet, resp)
+	}
+}
+
+// Delete removes the object key
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s3Error(http.MethodDelete, resp)
+	}
+}
+
+// do sends a signed request for the object key with body
+func (s *S3Store) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	target := *s.endpoint
+	target.Path = strings.TrimRight(target.Path, "/") + "/" + s.bucket + "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	if body == nil {
+		req.Body, req.ContentLength = http.NoBody, 0
+	}
+
+	payloadHash := sha256.Sum256(body)
+	signV4(req, hex.EncodeToString(payloadHash[:]), s.region, s.accessKeyID, s.secretAccessKey, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 %s failed: %w", method, err)
+	}
+	return resp, nil
+}
+
+// s3Error describes an unexpected S3 response, including the start of its
+// error document
+func s3Error(method string, resp *http.Response) error {
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("S3 %s failed with status %d: %s", method, resp.StatusCode, strings.TrimSpace(string(detail)))
+}
+
+// signV4 signs req for S3 with AWS Signature Version 4, covering the host
+// and every header already set on req. payloadHash is the hex SHA-256 of
+// the body.
+func signV4(req *http.Request, payloadHash, region, accessKeyID, secretAccessKey string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := []byte("AWS4" + secretAccessKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,105 @@
+// Package blobstore keeps files the service must hold on to, such as KYC
+// document scans, on local disk or in an S3-compatible bucket. Stores
+// returned by NewFromEnv encrypt every blob before it leaves the process.
+package blobstore
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNotFound is returned by Get for keys that hold no blob
+var ErrNotFound = errors.New("blob not found")
+
+// Store holds blobs under slash-separated keys made of letters, digits,
+// dashes, underscores and dots
+type Store interface {
+	// Put stores data under key, replacing any blob already there
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns the blob under key, or ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the blob under key. Deleting a missing blob is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Store backends selectable with <prefix>STORE
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// NewFromEnv creates the encrypted store configured by the environment
+// variables starting with prefix, such as KYC_DOCUMENT_:
+//
+//   - <prefix>ENCRYPTION_KEY is the base64-encoded 32-byte AES key. Without
+//     it no store is configured, and NewFromEnv returns nil.
+//   - <prefix>STORE is local (the default) or s3.
+//   - <prefix>DIR is the directory of the local store.
+//   - <prefix>S3_ENDPOINT, <prefix>S3_BUCKET, <prefix>S3_REGION,
+//     <prefix>S3_ACCESS_KEY_ID and <prefix>S3_SECRET_ACCESS_KEY configure
+//     the s3 store.
+func NewFromEnv(prefix, defaultDir string) (Store, error) {
+	encodedKey := os.Getenv(prefix + "ENCRYPTION_KEY")
+	if encodedKey == "" {
+		if backend := os.Getenv(prefix + "STORE"); backend != "" {
+			return nil, fmt.Errorf("%sSTORE is set but %sENCRYPTION_KEY is not", prefix, prefix)
+		}
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("%sENCRYPTION_KEY must be %d bytes, base64-encoded", prefix, KeySize)
+	}
+
+	var store Store
+	switch backend := strings.ToLower(os.Getenv(prefix + "STORE")); backend {
+	case "", BackendLocal:
+		dir := os.Getenv(prefix + "DIR")
+		if dir == "" {
+			dir = defaultDir
+		}
+		store, err = NewLocalStore(dir)
+	case BackendS3:
+		store, err = NewS3Store(S3Config{
+			Endpoint:        os.Getenv(prefix + "S3_ENDPOINT"),
+			Bucket:          os.Getenv(prefix + "S3_BUCKET"),
+			Region:          os.Getenv(prefix + "S3_REGION"),
+			AccessKeyID:     os.Getenv(prefix + "S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv(prefix + "S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("invalid %sSTORE %q: must be %s or %s", prefix, backend, BackendLocal, BackendS3)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%sSTORE: %w", prefix, err)
+	}
+
+	return NewEncryptedStore(store, key)
+}
+
+// checkKey rejects keys that are empty, absolute, or could climb out of a
+// local store's directory
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+		for _, r := range segment {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			case r == '-', r == '_', r == '.':
+			default:
+				return fmt.Errorf("invalid blob key %q", key)
+			}
+		}
+	}
+	return nil
+}
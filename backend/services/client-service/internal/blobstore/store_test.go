@@ -0,0 +1,76 @@
+package blobstore
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(testKey(1))
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "not configured", wantNil: true},
+		{name: "store without a key", env: map[string]string{"TEST_STORE": "local"}, wantErr: true},
+		{name: "key not base64", env: map[string]string{"TEST_ENCRYPTION_KEY": "not base64!"}, wantErr: true},
+		{name: "key too short", env: map[string]string{"TEST_ENCRYPTION_KEY": base64.StdEncoding.EncodeToString([]byte("short"))}, wantErr: true},
+		{name: "local", env: map[string]string{"TEST_ENCRYPTION_KEY": key}},
+		{name: "unknown store", env: map[string]string{"TEST_ENCRYPTION_KEY": key, "TEST_STORE": "ftp"}, wantErr: true},
+		{name: "s3 without a bucket", env: map[string]string{"TEST_ENCRYPTION_KEY": key, "TEST_STORE": "s3", "TEST_S3_ENDPOINT": "https://s3.example.com"}, wantErr: true},
+		{name: "s3", env: map[string]string{
+			"TEST_ENCRYPTION_KEY":       key,
+			"TEST_STORE":                "s3",
+			"TEST_S3_ENDPOINT":          "https://s3.example.com",
+			"TEST_S3_BUCKET":            "documents",
+			"TEST_S3_ACCESS_KEY_ID":     "key",
+			"TEST_S3_SECRET_ACCESS_KEY": "secret",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"ENCRYPTION_KEY", "STORE", "DIR", "S3_ENDPOINT", "S3_BUCKET", "S3_REGION", "S3_ACCESS_KEY_ID", "S3_SECRET_ACCESS_KEY"} {
+				t.Setenv("TEST_"+name, tt.env["TEST_"+name])
+			}
+
+			store, err := NewFromEnv("TEST_", t.TempDir())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if (store == nil) != tt.wantNil {
+				t.Fatalf("Expected nil store %t, got %v", tt.wantNil, store)
+			}
+		})
+	}
+}
+
+func TestNewFromEnv_Encrypts(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TEST_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(testKey(1)))
+	t.Setenv("TEST_STORE", "")
+	t.Setenv("TEST_DIR", dir)
+
+	store, err := NewFromEnv("TEST_", "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := store.Put(context.Background(), "doc", []byte("scan")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	local, _ := NewLocalStore(dir)
+	sealed, err := local.Get(context.Background(), "doc")
+	if err != nil || string(sealed) == "scan" {
+		t.Errorf("Expected an encrypted blob in %s, got %q, %v", dir, sealed, err)
+	}
+}
@@ -0,0 +1,286 @@
+// Package config loads the client-service configuration from the
+// environment and checks it before anything is started, so a bad
+// deployment fails at once with every problem listed instead of at the
+// first request that needs the broken setting.
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"microbank/client-service/internal/authcookie"
+	"microbank/client-service/internal/blobstore"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/passwordhash"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+	"microbank/client-service/internal/services"
+	"microbank/client-service/internal/tokenkeys"
+	"microbank/pkg/bodylimit"
+	sharedconfig "microbank/pkg/config"
+	"microbank/pkg/cors"
+	sharedjwt "microbank/pkg/jwt"
+	"microbank/pkg/mailer"
+	"microbank/pkg/resilience"
+	"microbank/pkg/tlsserver"
+)
+
+// Config holds the validated client-service configuration
+type Config struct {
+	Port                 int
+	GinMode              string
+	Database             sharedconfig.Database
+	InternalServiceToken string
+	BankingServiceURL    string
+	BankingInternalURL   string
+	EventsURL            string
+	RejectUnknownFields  bool
+
+	// TLS and Certificates are nil when the service serves plain HTTP
+	TLS          *tlsserver.Config
+	Certificates *tlsserver.CertReloader
+	// Mutual is nil, and InternalPort 0, when internal routes are served
+	// alongside the public ones rather than on their own mutual TLS
+	// listener
+	Mutual       *tlsserver.Mutual
+	InternalPort int
+
+	TokenKeys       *tokenkeys.KeySet
+	TokenTTLs       sharedjwt.TTLs
+	ValidateOptions []sharedjwt.ValidateOption
+	Revocations     revocation.Store
+
+	EmailSender      mailer.EmailSender
+	PasswordPolicy   *passwordpolicy.Policy
+	PasswordHasher   *passwordhash.Hasher
+	AuthCookies      *authcookie.Config
+	RegistrationMode services.RegistrationMode
+	OAuthProviders   []services.OAuthProvider
+	TrustedProxies   *middleware.TrustedProxies
+	CORS             *cors.Policy
+	BodyLimits       bodylimit.Limits
+	// KYCDocuments is nil when no document store is configured, and KYC
+	// document uploads are disabled
+	KYCDocuments blobstore.Store
+	// Downloads is nil when no download store is configured, and exports
+	// are not offered as download links. DownloadTokenSecret signs the
+	// links.
+	Downloads           blobstore.Store
+	DownloadTokenSecret string
+	// Resilience sets the retries and circuit breaker of calls to the
+	// banking-service
+	Resilience resilience.Config
+	// LastLoginOnRefresh counts access token refreshes as logins
+	LastLoginOnRefresh bool
+
+	// How long stored data is kept, and how much of it each user may have
+	LoginEventRetention         time.Duration
+	UserDeletionRetention       time.Duration
+	RefreshTokenCleanupInterval time.Duration
+	// MaxRefreshTokensPerUser is 0 for no limit
+	MaxRefreshTokensPerUser int
+	KYCDocumentRetention    time.Duration
+	DownloadTTL             time.Duration
+	DownloadMaxCount        int
+	// PasswordHistorySize is 0 when previous passwords can be reused
+	PasswordHistorySize   int
+	StatementSkipInactive bool
+}
+
+// Load reads the configuration from the environment. The error, when not
+// nil, is a *sharedconfig.Error listing every problem found.
+func Load() (*Config, error) {
+	var problems sharedconfig.Problems
+	cfg := &Config{}
+	var err error
+
+	cfg.Port, err = sharedconfig.PortFromEnv("PORT", 8081)
+	problems.Add(err)
+	cfg.GinMode, err = sharedconfig.OneOfEnv("GIN_MODE", "debug", "debug", "release", "test")
+	problems.Add(err)
+	cfg.Database, err = sharedconfig.DatabaseFromEnv("client_service")
+	problems.Add(err)
+	cfg.RejectUnknownFields, err = sharedconfig.BoolFromEnv("REJECT_UNKNOWN_FIELDS", false)
+	problems.Add(err)
+
+	cfg.TLS, err = tlsserver.ConfigFromEnv()
+	problems.Add(err)
+	if cfg.TLS != nil {
+		if cfg.TLS.RedirectPort == cfg.Port {
+			problems.Addf("TLS_REDIRECT_PORT must differ from PORT")
+		}
+		// A certificate that does not match its key fails here rather than
+		// at the first handshake
+		cfg.Certificates, err = tlsserver.NewCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		problems.Add(err)
+	}
+
+	mutualConfig, err := tlsserver.MutualConfigFromEnv("banking-service")
+	problems.Add(err)
+	if mutualConfig != nil {
+		cfg.InternalPort = mutualConfig.InternalPort
+		if cfg.InternalPort == cfg.Port || (cfg.TLS != nil && cfg.InternalPort == cfg.TLS.RedirectPort) {
+			problems.Addf("INTERNAL_PORT must differ from PORT and TLS_REDIRECT_PORT")
+		}
+		cfg.Mutual, err = tlsserver.NewMutual(mutualConfig)
+		problems.Add(err)
+	}
+
+	// The internal token authenticates calls in both directions between the
+	// services, so it is held to the same standard as a signing secret
+	cfg.InternalServiceToken, err = sharedconfig.RequiredEnv("INTERNAL_SERVICE_TOKEN")
+	if err == nil {
+		err = sharedconfig.CheckSecret("INTERNAL_SERVICE_TOKEN", cfg.InternalServiceToken)
+	}
+	problems.Add(err)
+
+	cfg.BankingServiceURL, err = sharedconfig.URLFromEnv("BANKING_SERVICE_URL", "http://localhost:8080")
+	problems.Add(err)
+	cfg.BankingInternalURL, err = sharedconfig.URLFromEnv("BANKING_SERVICE_INTERNAL_URL", cfg.BankingServiceURL)
+	problems.Add(err)
+	cfg.EventsURL, err = sharedconfig.URLFromEnv("EVENTS_PUBLISH_URL", strings.TrimRight(cfg.BankingInternalURL, "/")+"/internal/events")
+	problems.Add(err)
+	if cfg.Mutual != nil {
+		for _, setting := range []struct{ name, value string }{
+			{"BANKING_SERVICE_INTERNAL_URL", cfg.BankingInternalURL},
+			{"EVENTS_PUBLISH_URL", cfg.EventsURL},
+		} {
+			if !strings.HasPrefix(setting.value, "https://") {
+				problems.Addf("%s must be an https URL with mutual TLS", setting.name)
+			}
+		}
+	}
+
+	checkSigningConfig(&problems)
+	if cfg.TokenKeys, err = tokenkeys.LoadFromEnv(); err != nil {
+		problems.Add(fmt.Errorf("JWT signing: %w", err))
+	}
+	cfg.TokenTTLs, err = sharedjwt.TTLsFromEnv()
+	problems.Add(err)
+	cfg.ValidateOptions, err = sharedjwt.ValidateOptionsFromEnv()
+	problems.Add(err)
+	cfg.Revocations, err = revocation.NewFromEnv()
+	problems.Add(err)
+
+	if cfg.EmailSender, err = mailer.NewFromEnv(); err != nil {
+		problems.Add(fmt.Errorf("email: %w", err))
+	}
+	if cfg.PasswordPolicy, err = passwordpolicy.LoadFromEnv(); err != nil {
+		problems.Add(fmt.Errorf("password policy: %w", err))
+	}
+	if cfg.PasswordHasher, err = passwordhash.LoadFromEnv(); err != nil {
+		problems.Add(fmt.Errorf("password hashing: %w", err))
+	}
+	if cfg.AuthCookies, err = authcookie.LoadFromEnv(); err != nil {
+		problems.Add(fmt.Errorf("auth cookies: %w", err))
+	}
+	if cfg.RegistrationMode, err = services.ParseRegistrationMode(os.Getenv("REGISTRATION_MODE")); err != nil {
+		problems.Add(fmt.Errorf("REGISTRATION_MODE: %w", err))
+	}
+	cfg.LastLoginOnRefresh, err = sharedconfig.BoolFromEnv("LAST_LOGIN_ON_REFRESH", false)
+	problems.Add(err)
+	if cfg.TrustedProxies, err = middleware.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES")); err != nil {
+		problems.Add(fmt.Errorf("TRUSTED_PROXIES: %w", err))
+	}
+
+	// Google sign-in is optional, but half a configuration is a mistake
+	if err := sharedconfig.AllOrNone("GOOGLE_CLIENT_ID", "GOOGLE_CLIENT_SECRET", "GOOGLE_REDIRECT_URL"); err != nil {
+		problems.Add(err)
+	} else if clientID := os.Getenv("GOOGLE_CLIENT_ID"); clientID != "" {
+		cfg.OAuthProviders = append(cfg.OAuthProviders, services.NewGoogleOAuthProvider(clientID, os.Getenv("GOOGLE_CLIENT_SECRET"), os.Getenv("GOOGLE_REDIRECT_URL")))
+	}
+
+	cfg.BodyLimits, err = bodylimit.LimitsFromEnv()
+	problems.Add(err)
+	if cfg.KYCDocuments, err = blobstore.NewFromEnv("KYC_DOCUMENT_", "data/kyc-documents"); err != nil {
+		problems.Add(fmt.Errorf("KYC documents: %w", err))
+	}
+	if cfg.Downloads, err = blobstore.NewFromEnv("DOWNLOAD_", "data/downloads"); err != nil {
+		problems.Add(fmt.Errorf("downloads: %w", err))
+	}
+	if cfg.Downloads != nil {
+		cfg.DownloadTokenSecret, err = sharedconfig.RequiredEnv("DOWNLOAD_TOKEN_SECRET")
+		if err == nil {
+			err = sharedconfig.CheckSecret("DOWNLOAD_TOKEN_SECRET", cfg.DownloadTokenSecret)
+		}
+		problems.Add(err)
+	}
+	cfg.Resilience, err = resilience.ConfigFromEnv()
+	problems.Add(err)
+
+	loadRetention(cfg, &problems)
+
+	corsConfig, err := cors.ConfigFromEnv()
+	if err == nil {
+		cfg.CORS, err = cors.New(corsConfig)
+	}
+	if err != nil {
+		problems.Add(fmt.Errorf("CORS: %w", err))
+	}
+
+	if err := problems.Err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadRetention reads how long stored data is kept and how much of it each
+// user may have
+func loadRetention(cfg *Config, problems *sharedconfig.Problems) {
+	var err error
+	cfg.LoginEventRetention, err = daysFromEnv("LOGIN_EVENT_RETENTION_DAYS", 90)
+	problems.Add(err)
+	cfg.UserDeletionRetention, err = daysFromEnv("USER_DELETION_RETENTION_DAYS", 30)
+	problems.Add(err)
+	cfg.RefreshTokenCleanupInterval, err = sharedconfig.DurationFromEnv("REFRESH_TOKEN_CLEANUP_INTERVAL", time.Hour)
+	problems.Add(err)
+	cfg.MaxRefreshTokensPerUser, err = sharedconfig.IntFromEnv("MAX_REFRESH_TOKENS_PER_USER", 5, 0)
+	problems.Add(err)
+	cfg.KYCDocumentRetention, err = daysFromEnv("KYC_DOCUMENT_RETENTION_DAYS", 30)
+	problems.Add(err)
+	cfg.DownloadTTL, err = sharedconfig.DurationFromEnv("DOWNLOAD_TTL", 24*time.Hour)
+	problems.Add(err)
+	cfg.DownloadMaxCount, err = sharedconfig.IntFromEnv("DOWNLOAD_MAX_COUNT", 3, 1)
+	problems.Add(err)
+	cfg.PasswordHistorySize, err = sharedconfig.IntFromEnv("PASSWORD_HISTORY_SIZE", 0, 0)
+	problems.Add(err)
+	cfg.StatementSkipInactive, err = sharedconfig.BoolFromEnv("STATEMENT_SKIP_INACTIVE", true)
+	problems.Add(err)
+}
+
+// daysFromEnv returns the whole number of days in the environment variable
+// name, or fallback days when it is not set
+func daysFromEnv(name string, fallback int) (time.Duration, error) {
+	days, err := sharedconfig.IntFromEnv(name, fallback, 1)
+	return time.Duration(days) * 24 * time.Hour, err
+}
+
+// checkSigningConfig checks the JWT settings tokenkeys.LoadFromEnv would
+// otherwise accept: HS256 ignoring RSA key files it was given, and secrets
+// too weak to sign with
+func checkSigningConfig(problems *sharedconfig.Problems) {
+	if strings.EqualFold(os.Getenv("JWT_SIGNING_ALGORITHM"), tokenkeys.AlgorithmHS256) {
+		for _, name := range []string{"JWT_PRIVATE_KEY_FILE", "JWT_PUBLIC_KEY_FILES"} {
+			if os.Getenv(name) != "" {
+				problems.Addf("%s cannot be used with JWT_SIGNING_ALGORITHM=HS256", name)
+			}
+		}
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		problems.Add(sharedconfig.CheckSecret("JWT_SECRET", secret))
+	}
+	// Malformed JWT_KEYS are reported by tokenkeys.LoadFromEnv
+	keys, _ := tokenkeys.ParseHMACKeys(os.Getenv("JWT_KEYS"))
+	kids := make([]string, 0, len(keys))
+	for kid := range keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	for _, kid := range kids {
+		problems.Add(sharedconfig.CheckSecret(fmt.Sprintf("JWT_KEYS secret %q", kid), keys[kid]))
+	}
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	sharedconfig "microbank/pkg/config"
+)
+
+const testSecret = "q3Jx9VbT0mWc7LkZpR2sYd5HnE8aFu4G"
+
+// setValidEnv sets a minimal valid configuration, clearing the settings the
+// tests change
+func setValidEnv(t *testing.T) {
+	t.Helper()
+	env := map[string]string{
+		"PORT":                         "",
+		"GIN_MODE":                     "",
+		"DB_PORT":                      "",
+		"DB_PASSWORD":                  "password",
+		"TLS_CERT_FILE":                "",
+		"TLS_KEY_FILE":                 "",
+		"TLS_REDIRECT_PORT":            "",
+		"MTLS_CERT_FILE":               "",
+		"MTLS_KEY_FILE":                "",
+		"MTLS_CA_FILE":                 "",
+		"MTLS_ALLOWED_PEERS":           "",
+		"INTERNAL_PORT":                "",
+		"INTERNAL_SERVICE_TOKEN":       testSecret,
+		"BANKING_SERVICE_URL":          "",
+		"BANKING_SERVICE_INTERNAL_URL": "",
+		"EVENTS_PUBLISH_URL":           "",
+		"JWT_SIGNING_ALGORITHM":        "HS256",
+		"JWT_SECRET":                   testSecret,
+		"JWT_KEYS":                     "",
+		"JWT_ACTIVE_KID":               "",
+		"JWT_PRIVATE_KEY_FILE":         "",
+		"JWT_PUBLIC_KEY_FILES":         "",
+		"JWT_ACCESS_TOKEN_TTL":         "",
+		"GOOGLE_CLIENT_ID":             "",
+		"GOOGLE_CLIENT_SECRET":         "",
+		"GOOGLE_REDIRECT_URL":          "",
+		"REVOCATION_REDIS_URL":         "",
+		"SMTP_HOST":                    "",
+		"KYC_DOCUMENT_ENCRYPTION_KEY":  "",
+		"KYC_DOCUMENT_STORE":           "",
+		"DOWNLOAD_ENCRYPTION_KEY":      "",
+		"DOWNLOAD_STORE":               "",
+		"DOWNLOAD_TOKEN_SECRET":        "",
+		"LAST_LOGIN_ON_REFRESH":        "",
+		"LOGIN_EVENT_RETENTION_DAYS":   "",
+		"MAX_REFRESH_TOKENS_PER_USER":  "",
+		"DOWNLOAD_TTL":                 "",
+		"PASSWORD_HISTORY_SIZE":        "",
+		"STATEMENT_SKIP_INACTIVE":      "",
+	}
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	setValidEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Port != 8081 || cfg.GinMode != "debug" {
+		t.Errorf("Expected port 8081 in debug mode, got %d in %s mode", cfg.Port, cfg.GinMode)
+	}
+	if cfg.EventsURL != "http://localhost:8080/internal/events" {
+		t.Errorf("Expected events to go to the banking service, got %s", cfg.EventsURL)
+	}
+	if len(cfg.OAuthProviders) != 0 {
+		t.Errorf("Expected Google sign-in to be disabled, got %d providers", len(cfg.OAuthProviders))
+	}
+	if cfg.KYCDocuments != nil {
+		t.Errorf("Expected KYC document uploads to be disabled, got %T", cfg.KYCDocuments)
+	}
+	if cfg.Downloads != nil {
+		t.Errorf("Expected download links to be disabled, got %T", cfg.Downloads)
+	}
+	if cfg.LastLoginOnRefresh {
+		t.Error("Expected token refreshes not to count as logins")
+	}
+	if cfg.LoginEventRetention != 90*24*time.Hour || cfg.MaxRefreshTokensPerUser != 5 || cfg.DownloadTTL != 24*time.Hour || cfg.PasswordHistorySize != 0 || !cfg.StatementSkipInactive {
+		t.Errorf("Expected the default retention and limits, got %s, %d sessions, %s, %d passwords, skip inactive %v",
+			cfg.LoginEventRetention, cfg.MaxRefreshTokensPerUser, cfg.DownloadTTL, cfg.PasswordHistorySize, cfg.StatementSkipInactive)
+	}
+
+	t.Setenv("LAST_LOGIN_ON_REFRESH", "true")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.LastLoginOnRefresh {
+		t.Error("Expected LAST_LOGIN_ON_REFRESH=true to count token refreshes as logins")
+	}
+}
+
+func TestLoad_ReportsEveryProblem(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("PORT", "99999")
+	t.Setenv("GIN_MODE", "production")
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("TLS_CERT_FILE", "/nonexistent/cert.pem")
+	t.Setenv("TLS_KEY_FILE", "/nonexistent/key.pem")
+	t.Setenv("MTLS_CA_FILE", "/etc/microbank/ca.pem")
+	t.Setenv("INTERNAL_SERVICE_TOKEN", "")
+	t.Setenv("JWT_SECRET", "microBankSecret")
+	t.Setenv("JWT_PRIVATE_KEY_FILE", "./keys/jwt-private.pem")
+	t.Setenv("JWT_ACCESS_TOKEN_TTL", "1440")
+	t.Setenv("GOOGLE_CLIENT_ID", "client-id")
+	t.Setenv("KYC_DOCUMENT_STORE", "s3")
+	t.Setenv("DOWNLOAD_ENCRYPTION_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	t.Setenv("DOWNLOAD_DIR", t.TempDir())
+	t.Setenv("LAST_LOGIN_ON_REFRESH", "sometimes")
+	t.Setenv("LOGIN_EVENT_RETENTION_DAYS", "0")
+	t.Setenv("MAX_REFRESH_TOKENS_PER_USER", "-1")
+	t.Setenv("DOWNLOAD_TTL", "24")
+	t.Setenv("PASSWORD_HISTORY_SIZE", "five")
+	t.Setenv("STATEMENT_SKIP_INACTIVE", "sometimes")
+
+	_, err := Load()
+	var configErr *sharedconfig.Error
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected a *sharedconfig.Error, got %v", err)
+	}
+
+	for _, want := range []string{
+		"invalid PORT",
+		"invalid GIN_MODE",
+		"DB_PASSWORD is required",
+		"failed to read TLS file",
+		"mutual TLS requires MTLS_CERT_FILE, MTLS_KEY_FILE, INTERNAL_PORT",
+		"INTERNAL_SERVICE_TOKEN is required",
+		"JWT_PRIVATE_KEY_FILE cannot be used with JWT_SIGNING_ALGORITHM=HS256",
+		"JWT_SECRET must be at least 32 characters",
+		"JWT_ACCESS_TOKEN_TTL must be shorter",
+		"missing GOOGLE_CLIENT_SECRET, GOOGLE_REDIRECT_URL",
+		"KYC_DOCUMENT_STORE is set but KYC_DOCUMENT_ENCRYPTION_KEY is not",
+		"DOWNLOAD_TOKEN_SECRET is required",
+		"invalid LAST_LOGIN_ON_REFRESH",
+		"invalid LOGIN_EVENT_RETENTION_DAYS",
+		"invalid MAX_REFRESH_TOKENS_PER_USER",
+		"invalid DOWNLOAD_TTL",
+		"invalid PASSWORD_HISTORY_SIZE",
+		"invalid STATEMENT_SKIP_INACTIVE",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to report %q, got:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "microBankSecret") {
+		t.Error("Expected the error not to contain the secret")
+	}
+}
+
+func TestLoad_WeakSecrets(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("INTERNAL_SERVICE_TOKEN", strings.Repeat("token", 8))
+	t.Setenv("JWT_KEYS", "2024:"+testSecret+",2025:short")
+	t.Setenv("JWT_ACTIVE_KID", "2024")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected weak secrets to be rejected")
+	}
+	for _, want := range []string{"INTERNAL_SERVICE_TOKEN is too predictable", `JWT_KEYS secret "2025"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to report %q, got:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), `"2024"`) {
+		t.Errorf("Expected the strong key to be accepted, got:\n%v", err)
+	}
+}
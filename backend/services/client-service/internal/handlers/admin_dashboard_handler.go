@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// AdminDashboardHandler handles the cross-service admin dashboard
+type AdminDashboardHandler struct {
+	dashboardService *services.AdminDashboardService
+}
+
+// NewAdminDashboardHandler creates a new admin dashboard handler
+func NewAdminDashboardHandler(dashboardService *services.AdminDashboardService) *AdminDashboardHandler {
+	return &AdminDashboardHandler{
+		dashboardService: dashboardService,
+	}
+}
+
+// GetDashboard retrieves the stats of every service (admin only). Each
+// section reports whether it is available; one whose service is down is
+// marked unavailable instead of failing the whole response. Sections are
+// cached for 30 seconds.
+func (h *AdminDashboardHandler) GetDashboard(c *gin.Context) {
+	dashboard := h.dashboardService.GetDashboard()
+
+	users := gin.H{"available": false}
+	if dashboard.Users != nil {
+		users = adminStatsResponse(dashboard.Users)
+		users["available"] = true
+	}
+
+	banking := gin.H{"available": false}
+	if dashboard.Banking != nil {
+		banking = bankingStatsResponse(dashboard.Banking)
+		banking["available"] = true
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Dashboard retrieved successfully",
+		"dashboard": gin.H{
+			"users":        users,
+			"banking":      banking,
+			"generated_at": dashboard.GeneratedAt,
+		},
+	})
+}
+
+// bankingStatsResponse returns the JSON representation of banking stats
+func bankingStatsResponse(stats *models.BankingStats) gin.H {
+	return gin.H{
+		"accounts":                 stats.Accounts,
+		"total_balance":            stats.TotalBalance,
+		"transactions_today":       stats.TransactionsToday,
+		"volume_today":             stats.VolumeToday,
+		"open_disputes":            stats.OpenDisputes,
+		"open_suspicious_activity": stats.OpenSuspiciousActivity,
+		"generated_at":             stats.GeneratedAt,
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+)
+
+func TestAdminDashboardHandler_GetDashboard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	statsService := services.NewAdminStatsService(newFakeUserRepo(newTestUser(t, "user@example.com")), newFakeRefreshTokenRepo())
+	bankingClient := &fakeBankingClient{stats: models.BankingStats{Accounts: 3, TotalBalance: 250.5}}
+
+	type section struct {
+		Available    bool    `json:"available"`
+		TotalUsers   int     `json:"total_users"`
+		Accounts     int     `json:"accounts"`
+		TotalBalance float64 `json:"total_balance"`
+	}
+	getDashboard := func(t *testing.T) (users, banking section) {
+		t.Helper()
+		// A new service each time, so nothing is served from its cache
+		handler := NewAdminDashboardHandler(services.NewAdminDashboardService(statsService, bankingClient))
+		r := gin.New()
+		r.GET("/admin/dashboard", handler.GetDashboard)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/dashboard", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Dashboard struct {
+				Users   section `json:"users"`
+				Banking section `json:"banking"`
+			} `json:"dashboard"`
+		}
+		if err := decodeData(w, &response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return response.Dashboard.Users, response.Dashboard.Banking
+	}
+
+	users, banking := getDashboard(t)
+	if !users.Available || users.TotalUsers != 1 {
+		t.Errorf("Unexpected users section: %+v", users)
+	}
+	if !banking.Available || banking.Accounts != 3 || banking.TotalBalance != 250.5 {
+		t.Errorf("Unexpected banking section: %+v", banking)
+	}
+
+	// With the banking-service down only its section is unavailable
+	bankingClient.err = errors.New("banking-service unavailable")
+	users, banking = getDashboard(t)
+	if !users.Available || users.TotalUsers != 1 {
+		t.Errorf("Expected the users section despite the banking-service being down, got %+v", users)
+	}
+	if banking != (section{}) {
+		t.Errorf("Expected the banking section to be unavailable, got %+v", banking)
+	}
+}
@@ -1,11 +1,20 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
 	"microbank/client-service/internal/services"
+	"microbank/pkg/authmw"
+	"microbank/pkg/httpx"
 )
 
 // AdminHandler handles administrative HTTP requests
@@ -20,127 +29,792 @@ func NewAdminHandler(userService *services.UserService) *AdminHandler {
 	}
 }
 
-// GetAllClients retrieves all users (admin only)
+// GetAllClients retrieves a page of users (admin only). See
+// parseListUsersOptions for the supported query parameters. HEAD requests
+// only count the matching users, for the X-Total-Count and X-Total-Pages
+// headers every response carries.
 func (h *AdminHandler) GetAllClients(c *gin.Context) {
+	opts, err := parseListUsersOptions(c)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_QUERY_PARAMETER",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if c.Request.Method == http.MethodHead {
+		page, err := h.userService.CountUsers(opts)
+		if err != nil {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "FETCH_USERS_FAILED",
+				Message: "Failed to fetch users",
+				Details: middleware.ErrorDetails(c, err),
+			})
+			return
+		}
+		httpx.RespondTotal(c, page.Total, page.Limit)
+		return
+	}
+
 	// Get users
-	users, err := h.userService.GetAllUsers()
+	page, err := h.userService.ListUsers(opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "FETCH_USERS_FAILED",
-				"message": "Failed to fetch users",
-				"details": err.Error(),
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_USERS_FAILED",
+			Message: "Failed to fetch users",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
 
 	// Convert users to response format
 	var userResponses []gin.H
-	for _, user := range users {
+	for _, user := range page.Users {
 		userResponses = append(userResponses, gin.H{
 			"id":             user.ID,
 			"email":          user.Email,
 			"name":           user.Name,
 			"is_blacklisted": user.IsBlacklisted,
 			"is_admin":       user.IsAdmin,
+			"last_login_at":  user.LastLoginAt,
+			"deleted_at":     user.DeletedAt,
 			"created_at":     user.CreatedAt,
 			"updated_at":     user.UpdatedAt,
 		})
 	}
 
 	// Return users
-	c.JSON(http.StatusOK, gin.H{
+	httpx.SetTotalHeaders(c, page.Total, page.Limit)
+	httpx.RespondPage(c, gin.H{
 		"message": "Users retrieved successfully",
 		"users":   userResponses,
 		"count":   len(userResponses),
-	})
+	}, httpx.NewPagination(page.Limit, page.Offset, len(userResponses), page.Total))
 }
 
-// BlacklistClient adds a user to the blacklist (admin only)
-func (h *AdminHandler) BlacklistClient(c *gin.Context) {
+// GetClient retrieves a single user's full detail (admin only). Each view
+// is written to the audit log.
+func (h *AdminHandler) GetClient(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
 	// Get user ID from URL parameter
 	userIDStr := c.Param("id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "INVALID_USER_ID",
-				"message": "Invalid user ID format",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
 		})
 		return
 	}
 
-	// Blacklist user
-	if err := h.userService.BlacklistUser(userID); err != nil {
-		if err.Error() == "user not found" {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": gin.H{
-					"code":    "USER_NOT_FOUND",
-					"message": "User not found",
-				},
+	// Get client detail
+	detail, err := h.userService.GetClientDetail(actor, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
 			})
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "BLACKLIST_FAILED",
-				"message": "Failed to blacklist user",
-				"details": err.Error(),
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_USER_FAILED",
+			Message: "Failed to fetch user",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	user := detail.User
+
+	// Return client detail
+	httpx.RespondOK(c, gin.H{
+		"message": "User retrieved successfully",
+		"user": gin.H{
+			"id":             user.ID,
+			"email":          user.Email,
+			"name":           user.Name,
+			"is_admin":       user.IsAdmin,
+			"is_blacklisted": user.IsBlacklisted,
+			"blacklist": gin.H{
+				"reason":     user.BlacklistReason,
+				"expires_at": user.BlacklistExpiresAt,
+			},
+			"email_verification": gin.H{
+				"verified":            user.IsEmailVerified(),
+				"verified_at":         user.EmailVerifiedAt,
+				"pending_email":       user.PendingEmail,
+				"change_requested_at": user.EmailChangeRequestedAt,
 			},
+			"last_login_at":   user.LastLoginAt,
+			"active_sessions": detail.ActiveSessions,
+			"created_at":      user.CreatedAt,
+			"updated_at":      user.UpdatedAt,
+		},
+	})
+}
+
+// DeleteClient soft-deletes a user (admin only). The user can be restored
+// until the deletion retention window passes. Deleting another admin
+// requires ?force=true.
+func (h *AdminHandler) DeleteClient(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
 		})
 		return
 	}
 
+	force, _ := strconv.ParseBool(c.Query("force"))
+
+	// Delete user
+	if err := h.userService.DeleteUser(actor, userID, force); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+		case errors.Is(err, services.ErrCannotDeleteSelf):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "CANNOT_DELETE_SELF",
+				Message: "You cannot delete your own account",
+			})
+		case errors.Is(err, services.ErrAdminDeleteRequiresForce):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "ADMIN_DELETE_REQUIRES_FORCE",
+				Message: "Deleting an admin requires force=true",
+			})
+		case errors.Is(err, services.ErrBankingServiceUnavailable):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadGateway,
+				Code:    "BANKING_SERVICE_UNAVAILABLE",
+				Message: "Could not notify the banking service; the user was not deleted",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "DELETE_USER_FAILED",
+				Message: "Failed to delete user",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
 	// Return success response
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User blacklisted successfully",
+	httpx.RespondOK(c, gin.H{
+		"message": "User deleted successfully",
 		"user_id": userID,
 	})
 }
 
-// RemoveFromBlacklist removes a user from the blacklist (admin only)
+// RestoreClient restores a soft-deleted user within the deletion retention
+// window (admin only)
+func (h *AdminHandler) RestoreClient(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Restore user
+	user, err := h.userService.RestoreUser(actor, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+		case errors.Is(err, services.ErrUserNotDeleted):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "USER_NOT_DELETED",
+				Message: "User is not deleted",
+			})
+		case errors.Is(err, services.ErrRestoreWindowExpired):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusGone,
+				Code:    "RESTORE_WINDOW_EXPIRED",
+				Message: "The user was deleted too long ago to be restored",
+			})
+		case errors.Is(err, services.ErrBankingServiceUnavailable):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadGateway,
+				Code:    "BANKING_SERVICE_UNAVAILABLE",
+				Message: "Could not notify the banking service; the user was not restored",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "RESTORE_USER_FAILED",
+				Message: "Failed to restore user",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return restored user
+	httpx.RespondOK(c, gin.H{
+		"message": "User restored successfully",
+		"user":    user.ToResponse(),
+	})
+}
+
+// GrantAdminRole makes a user an admin (admin only)
+func (h *AdminHandler) GrantAdminRole(c *gin.Context) {
+	h.setRole(c, authmw.RoleAdmin, true)
+}
+
+// RevokeAdminRole removes a user's admin role (admin only)
+func (h *AdminHandler) RevokeAdminRole(c *gin.Context) {
+	h.setRole(c, authmw.RoleAdmin, false)
+}
+
+// GrantRole gives a user the role named in the request body (admin only)
+func (h *AdminHandler) GrantRole(c *gin.Context) {
+	var request models.RoleRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	h.setRole(c, request.Role, true)
+}
+
+// RevokeRole removes the role named in the URL from a user (admin only)
+func (h *AdminHandler) RevokeRole(c *gin.Context) {
+	h.setRole(c, c.Param("role"), false)
+}
+
+// setRole applies a role change requested by the acting admin
+func (h *AdminHandler) setRole(c *gin.Context, role string, grant bool) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Update the role
+	if err := h.userService.SetRole(actor, userID, role, grant); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRole):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_ROLE",
+				Message: "Unknown role",
+				Details: map[string][]string{"roles": authmw.Roles},
+			})
+		case errors.Is(err, services.ErrUserNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+		case errors.Is(err, services.ErrCannotDemoteSelf):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "CANNOT_DEMOTE_SELF",
+				Message: "You cannot revoke your own admin role",
+			})
+		case errors.Is(err, services.ErrLastAdmin):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "LAST_ADMIN",
+				Message: "The last remaining admin cannot be demoted",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "UPDATE_ROLE_FAILED",
+				Message: "Failed to update role",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return success response
+	message := "Role granted successfully"
+	if !grant {
+		message = "Role revoked successfully"
+	}
+	if role == authmw.RoleAdmin {
+		message = "Admin " + strings.ToLower(message)
+	}
+	response := gin.H{
+		"message": message,
+		"user_id": userID,
+		"role":    role,
+		"granted": grant,
+	}
+	if role == authmw.RoleAdmin {
+		response["is_admin"] = grant
+	}
+	httpx.RespondOK(c, response)
+}
+
+// BlacklistClient adds a user to the blacklist with a reason and optional
+// expiry (admin only)
+func (h *AdminHandler) BlacklistClient(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get user ID from URL parameter
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Bind and validate request body
+	var request models.BlacklistRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Blacklist user
+	if err := h.userService.BlacklistUser(actor, userID, request); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+		case errors.Is(err, services.ErrBlacklistReasonRequired), errors.Is(err, services.ErrInvalidBlacklistExpiry):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "VALIDATION_ERROR",
+				Message: err.Error(),
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "BLACKLIST_FAILED",
+				Message: "Failed to blacklist user",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message":    "User blacklisted successfully",
+		"user_id":    userID,
+		"reason":     strings.TrimSpace(request.Reason),
+		"expires_at": request.ExpiresAt,
+	})
+}
+
+// RemoveFromBlacklist removes a user from the blacklist (admin only). An
+// optional reason query parameter is kept in the blacklist history.
 func (h *AdminHandler) RemoveFromBlacklist(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
 	// Get user ID from URL parameter
 	userIDStr := c.Param("id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "INVALID_USER_ID",
-				"message": "Invalid user ID format",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
 		})
 		return
 	}
 
 	// Remove user from blacklist
-	if err := h.userService.RemoveFromBlacklist(userID); err != nil {
-		if err.Error() == "user not found" {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": gin.H{
-					"code":    "USER_NOT_FOUND",
-					"message": "User not found",
-				},
+	if err := h.userService.RemoveFromBlacklist(actor, userID, c.Query("reason")); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
 			})
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "REMOVE_FROM_BLACKLIST_FAILED",
-				"message": "Failed to remove user from blacklist",
-				"details": err.Error(),
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "REMOVE_FROM_BLACKLIST_FAILED",
+			Message: "Failed to remove user from blacklist",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
 
 	// Return success response
-	c.JSON(http.StatusOK, gin.H{
+	httpx.RespondOK(c, gin.H{
 		"message": "User removed from blacklist successfully",
 		"user_id": userID,
 	})
 }
+
+// BlacklistClients blacklists up to 500 users at once with a shared reason
+// (admin only)
+func (h *AdminHandler) BlacklistClients(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.BatchBlacklistRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Blacklist users
+	results, err := h.userService.BlacklistUsers(actor, request)
+	if err != nil {
+		if isBlacklistBatchValidationError(err) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "VALIDATION_ERROR",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "BLACKLIST_FAILED",
+			Message: "Failed to blacklist users",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return per-user results
+	httpx.RespondOK(c, gin.H{
+		"message": "Batch blacklist processed",
+		"results": results,
+		"summary": summarizeBlacklistBatch(results),
+	})
+}
+
+// RemoveClientsFromBlacklist lifts the blacklist of up to 500 users at once
+// (admin only)
+func (h *AdminHandler) RemoveClientsFromBlacklist(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.BatchUnblacklistRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Remove users from blacklist
+	results, err := h.userService.RemoveUsersFromBlacklist(actor, request)
+	if err != nil {
+		if isBlacklistBatchValidationError(err) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "VALIDATION_ERROR",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "REMOVE_FROM_BLACKLIST_FAILED",
+			Message: "Failed to remove users from blacklist",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return per-user results
+	httpx.RespondOK(c, gin.H{
+		"message": "Batch unblacklist processed",
+		"results": results,
+		"summary": summarizeBlacklistBatch(results),
+	})
+}
+
+// isBlacklistBatchValidationError reports whether a batch blacklist error
+// was caused by the request rather than the server
+func isBlacklistBatchValidationError(err error) bool {
+	return errors.Is(err, services.ErrBlacklistReasonRequired) ||
+		errors.Is(err, services.ErrInvalidBlacklistExpiry) ||
+		errors.Is(err, services.ErrEmptyBlacklistBatch) ||
+		errors.Is(err, services.ErrBlacklistBatchTooLarge)
+}
+
+// summarizeBlacklistBatch counts batch results by status
+func summarizeBlacklistBatch(results []models.BlacklistBatchResult) map[string]int {
+	summary := make(map[string]int)
+	for _, result := range results {
+		summary[result.Status]++
+	}
+	return summary
+}
+
+// GetClientBlacklistHistory retrieves every blacklist and unblacklist of a user (admin only)
+func (h *AdminHandler) GetClientBlacklistHistory(c *gin.Context) {
+	// Get user ID from URL parameter
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Get blacklist history
+	entries, err := h.userService.GetBlacklistHistory(userID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_BLACKLIST_HISTORY_FAILED",
+			Message: "Failed to fetch blacklist history",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return blacklist history
+	httpx.RespondOK(c, gin.H{
+		"message": "Blacklist history retrieved successfully",
+		"user_id": userID,
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+// GetClientLoginHistory retrieves a user's recent login attempts (admin only)
+func (h *AdminHandler) GetClientLoginHistory(c *gin.Context) {
+	// Get user ID from URL parameter
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	// Get login history
+	events, err := h.userService.GetLoginHistory(userID, limit)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_LOGIN_HISTORY_FAILED",
+			Message: "Failed to fetch login history",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return login history
+	httpx.RespondOK(c, gin.H{
+		"message": "Login history retrieved successfully",
+		"user_id": userID,
+		"events":  events,
+		"count":   len(events),
+	})
+}
+
+// CleanupRefreshTokens deletes expired refresh tokens immediately instead of
+// waiting for the background cleanup (admin only)
+func (h *AdminHandler) CleanupRefreshTokens(c *gin.Context) {
+	deleted, err := h.userService.CleanupExpiredRefreshTokens(c.Request.Context())
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "TOKEN_CLEANUP_FAILED",
+			Message: "Failed to clean up refresh tokens",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Expired refresh tokens cleaned up",
+		"deleted": deleted,
+	})
+}
+
+// auditActorFromContext returns the acting admin (set by AuthMiddleware) and
+// the request ID (set by RequestID), writing a 500 response and returning
+// false when the admin is missing
+func auditActorFromContext(c *gin.Context) (models.AuditActor, bool) {
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
+		})
+		return models.AuditActor{}, false
+	}
+	return models.AuditActor{AdminID: adminID, RequestID: c.GetString("request_id")}, true
+}
+
+// parseListUsersOptions reads the admin listing query parameters: limit,
+// offset, is_blacklisted, is_admin, deleted, created_after, created_before,
+// inactive_since, registration_source, search, sort (created_at or email)
+// and order (asc or desc)
+func parseListUsersOptions(c *gin.Context) (models.ListUsersOptions, error) {
+	var opts models.ListUsersOptions
+
+	// Pagination
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return opts, fmt.Errorf("limit must be a positive integer")
+		}
+		opts.Limit = limit
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return opts, fmt.Errorf("offset must be a non-negative integer")
+		}
+		opts.Offset = offset
+	}
+
+	// Boolean filters
+	for name, target := range map[string]**bool{
+		"is_blacklisted": &opts.IsBlacklisted,
+		"is_admin":       &opts.IsAdmin,
+	} {
+		if value := c.Query(name); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("%s must be true or false", name)
+			}
+			*target = &parsed
+		}
+	}
+
+	// Deleted users are listed instead of active ones with deleted=true
+	if value := c.Query("deleted"); value != "" {
+		deleted, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, fmt.Errorf("deleted must be true or false")
+		}
+		opts.Deleted = deleted
+	}
+
+	// Date filters
+	for name, target := range map[string]**time.Time{
+		"created_after":  &opts.CreatedAfter,
+		"created_before": &opts.CreatedBefore,
+		"inactive_since": &opts.InactiveSince,
+	} {
+		if value := c.Query(name); value != "" {
+			parsed, err := parseDateParam(value)
+			if err != nil {
+				return opts, fmt.Errorf("%s must be an RFC3339 timestamp or YYYY-MM-DD date", name)
+			}
+			*target = &parsed
+		}
+	}
+
+	if value := c.Query("registration_source"); value != "" {
+		if !models.IsValidRegistrationSource(value) {
+			return opts, fmt.Errorf("registration_source must be one of %s", strings.Join(models.RegistrationSources, ", "))
+		}
+		opts.RegistrationSource = value
+	}
+
+	opts.Search = strings.TrimSpace(c.Query("search"))
+
+	// Sorting
+	opts.SortBy = c.DefaultQuery("sort", models.UserSortCreatedAt)
+	if opts.SortBy != models.UserSortCreatedAt && opts.SortBy != models.UserSortEmail {
+		return opts, fmt.Errorf("sort must be created_at or email")
+	}
+
+	defaultOrder := "desc"
+	if opts.SortBy == models.UserSortEmail {
+		defaultOrder = "asc"
+	}
+	switch c.DefaultQuery("order", defaultOrder) {
+	case "asc":
+		opts.SortDesc = false
+	case "desc":
+		opts.SortDesc = true
+	default:
+		return opts, fmt.Errorf("order must be asc or desc")
+	}
+
+	return opts, nil
+}
+
+// parseDateParam parses an RFC3339 timestamp or a YYYY-MM-DD date
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
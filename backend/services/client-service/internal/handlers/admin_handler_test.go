@@ -0,0 +1,817 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/revocation"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/authmw"
+)
+
+// testDeletionRetention is how long soft-deleted users can be restored in
+// the handler tests
+const testDeletionRetention = 30 * 24 * time.Hour
+
+func TestAdminHandler_BlacklistClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := newTestUser(t, "admin@example.com")
+	admin.IsAdmin = true
+	user := newTestUser(t, "client@example.com")
+	userRepo := newFakeUserRepo(admin, user)
+	blacklistRepo := &fakeBlacklistRepo{}
+	userRepo.blacklist = blacklistRepo
+	handler := NewAdminHandler(services.NewUserService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, blacklistRepo, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention))
+
+	r := gin.New()
+	r.POST("/admin/clients/:id/blacklist", func(c *gin.Context) {
+		c.Set("user_id", admin.ID.String())
+		handler.BlacklistClient(c)
+	})
+
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name       string
+		id         string
+		body       interface{}
+		wantStatus int
+	}{
+		{name: "existing user", id: user.ID.String(), body: gin.H{"reason": "chargeback fraud"}, wantStatus: http.StatusOK},
+		{name: "missing reason", id: user.ID.String(), body: gin.H{}, wantStatus: http.StatusBadRequest},
+		{name: "blank reason", id: user.ID.String(), body: gin.H{"reason": "   "}, wantStatus: http.StatusBadRequest},
+		{name: "expiry in the past", id: user.ID.String(), body: gin.H{"reason": "fraud", "expires_at": past}, wantStatus: http.StatusBadRequest},
+		{name: "unknown user", id: uuid.New().String(), body: gin.H{"reason": "fraud"}, wantStatus: http.StatusNotFound},
+		{name: "malformed id", id: "not-a-uuid", body: gin.H{"reason": "fraud"}, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := postJSON(t, r, "/admin/clients/"+tt.id+"/blacklist", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if !user.IsBlacklisted || user.BlacklistReason != "chargeback fraud" {
+		t.Errorf("Expected user blacklisted with reason, got blacklisted=%v reason=%q", user.IsBlacklisted, user.BlacklistReason)
+	}
+	if len(blacklistRepo.entries) != 1 || blacklistRepo.entries[0].AdminID == nil || *blacklistRepo.entries[0].AdminID != admin.ID {
+		t.Errorf("Expected one history entry recorded by the admin, got %+v", blacklistRepo.entries)
+	}
+	if len(userRepo.audits) != 1 || userRepo.audits[0].Action != models.AuditActionBlacklist || userRepo.audits[0].Metadata["reason"] != "chargeback fraud" {
+		t.Errorf("Expected one blacklist audit entry, got %+v", userRepo.audits)
+	}
+}
+
+func TestAdminHandler_BlacklistClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := newTestUser(t, "admin@example.com")
+	admin.IsAdmin = true
+	target := newTestUser(t, "target@example.com")
+	banned := newTestUser(t, "banned@example.com")
+	banned.IsBlacklisted = true
+	missing := uuid.New()
+	userRepo := newFakeUserRepo(admin, target, banned)
+	refreshRepo := newFakeRefreshTokenRepo()
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: target.ID, TokenHash: "target", ExpiresAt: time.Now().Add(time.Hour)})
+	blacklistRepo := &fakeBlacklistRepo{}
+	userRepo.blacklist = blacklistRepo
+	handler := NewAdminHandler(services.NewUserService(userRepo, refreshRepo, &fakeLoginEventRepo{}, blacklistRepo, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention))
+
+	r := gin.New()
+	r.POST("/admin/clients/blacklist-batch", func(c *gin.Context) {
+		c.Set("user_id", admin.ID.String())
+		handler.BlacklistClients(c)
+	})
+
+	if w, _ := postJSON(t, r, "/admin/clients/blacklist-batch", gin.H{"user_ids": []uuid.UUID{}, "reason": "fraud ring"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty batch, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, _ := postJSON(t, r, "/admin/clients/blacklist-batch", gin.H{
+		"user_ids": []uuid.UUID{target.ID, banned.ID, missing, target.ID},
+		"reason":   "fraud ring",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Results []models.BlacklistBatchResult `json:"results"`
+		Summary map[string]int                `json:"summary"`
+	}
+	if err := decodeData(w, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := []models.BlacklistBatchResult{
+		{UserID: target.ID, Status: models.BlacklistBatchOK},
+		{UserID: banned.ID, Status: models.BlacklistBatchAlreadyBlacklisted},
+		{UserID: missing, Status: models.BlacklistBatchNotFound},
+	}
+	if len(response.Results) != len(want) {
+		t.Fatalf("Expected %d deduplicated results, got %+v", len(want), response.Results)
+	}
+	for i := range want {
+		if response.Results[i] != want[i] {
+			t.Errorf("Expected result %d to be %+v, got %+v", i, want[i], response.Results[i])
+		}
+	}
+	if response.Summary[models.BlacklistBatchOK] != 1 || response.Summary[models.BlacklistBatchNotFound] != 1 {
+		t.Errorf("Unexpected summary: %v", response.Summary)
+	}
+
+	if !target.IsBlacklisted || target.BlacklistReason != "fraud ring" {
+		t.Errorf("Expected target blacklisted with reason, got blacklisted=%v reason=%q", target.IsBlacklisted, target.BlacklistReason)
+	}
+	if sessions, _ := refreshRepo.CountActiveByUserID(target.ID); sessions != 0 {
+		t.Errorf("Expected target sessions revoked, got %d", sessions)
+	}
+	if len(userRepo.audits) != 1 || *userRepo.audits[0].TargetUserID != target.ID {
+		t.Errorf("Expected one audit entry for the target, got %+v", userRepo.audits)
+	}
+	if len(blacklistRepo.entries) != 1 {
+		t.Errorf("Expected one history entry, got %+v", blacklistRepo.entries)
+	}
+}
+
+func TestAdminHandler_RemoveClientsFromBlacklist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := newTestUser(t, "admin@example.com")
+	admin.IsAdmin = true
+	banned := newTestUser(t, "banned@example.com")
+	banned.IsBlacklisted = true
+	active := newTestUser(t, "active@example.com")
+	userRepo := newFakeUserRepo(admin, banned, active)
+	handler := NewAdminHandler(services.NewUserService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention))
+
+	r := gin.New()
+	r.POST("/admin/clients/unblacklist-batch", func(c *gin.Context) {
+		c.Set("user_id", admin.ID.String())
+		handler.RemoveClientsFromBlacklist(c)
+	})
+
+	if w, _ := postJSON(t, r, "/admin/clients/unblacklist-batch", gin.H{}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without user_ids, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, _ := postJSON(t, r, "/admin/clients/unblacklist-batch", gin.H{"user_ids": []uuid.UUID{banned.ID, active.ID}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Results []models.BlacklistBatchResult `json:"results"`
+	}
+	if err := decodeData(w, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Results) != 2 || response.Results[0].Status != models.BlacklistBatchOK || response.Results[1].Status != models.BlacklistBatchNotBlacklisted {
+		t.Errorf("Unexpected results: %+v", response.Results)
+	}
+	if banned.IsBlacklisted {
+		t.Error("Expected blacklist lifted")
+	}
+}
+
+func TestParseListUsersOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantErr   bool
+		wantSort  string
+		wantDesc  bool
+		wantLimit int
+	}{
+		{name: "defaults", query: "", wantSort: "created_at", wantDesc: true},
+		{name: "email sorts ascending by default", query: "sort=email", wantSort: "email", wantDesc: false},
+		{name: "explicit order", query: "sort=created_at&order=asc&limit=25", wantSort: "created_at", wantDesc: false, wantLimit: 25},
+		{name: "unknown sort", query: "sort=password_hash", wantErr: true},
+		{name: "bad order", query: "order=sideways", wantErr: true},
+		{name: "bad limit", query: "limit=-1", wantErr: true},
+		{name: "bad boolean", query: "is_admin=maybe", wantErr: true},
+		{name: "bad date", query: "created_after=yesterday", wantErr: true},
+		{name: "registration source", query: "registration_source=invite", wantSort: "created_at", wantDesc: true},
+		{name: "unknown registration source", query: "registration_source=referral", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/clients?"+tt.query, nil)
+
+			opts, err := parseListUsersOptions(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.SortBy != tt.wantSort || opts.SortDesc != tt.wantDesc || opts.Limit != tt.wantLimit {
+				t.Errorf("Unexpected options: %+v", opts)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_GetAllClients_CapsPageSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userRepo := &listingUserRepo{}
+	handler := NewAdminHandler(services.NewUserService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention))
+
+	r := gin.New()
+	r.GET("/admin/clients", handler.GetAllClients)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/clients?limit=100000", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if userRepo.lastOpts.Limit != services.MaxUserPageSize {
+		t.Errorf("Expected limit to be capped at %d, got %d", services.MaxUserPageSize, userRepo.lastOpts.Limit)
+	}
+}
+
+func TestAdminHandler_GetAllClients_Head(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var users []*models.User
+	for i := 0; i < 5; i++ {
+		user := newTestUser(t, fmt.Sprintf("client%d@example.com", i))
+		user.IsBlacklisted = i < 3
+		users = append(users, user)
+	}
+	handler := NewAdminHandler(services.NewUserService(newFakeUserRepo(users...), newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention))
+
+	r := gin.New()
+	r.HEAD("/admin/clients", handler.GetAllClients)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantCount string
+		wantPages string
+	}{
+		{name: "default page size", query: "", wantCount: "5", wantPages: "1"},
+		{name: "filtered", query: "?is_blacklisted=true&limit=2", wantCount: "3", wantPages: "2"},
+		{name: "filtered, other value", query: "?is_blacklisted=false&limit=2", wantCount: "2", wantPages: "1"},
+		{name: "oversized page is capped", query: "?limit=100000", wantCount: "5", wantPages: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/admin/clients"+tt.query, nil))
+
+			if w.Code != http.StatusOK || w.Body.Len() != 0 {
+				t.Fatalf("Expected 200 without a body, got %d: %s", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("X-Total-Count"); got != tt.wantCount {
+				t.Errorf("Expected X-Total-Count %s, got %q", tt.wantCount, got)
+			}
+			if got := w.Header().Get("X-Total-Pages"); got != tt.wantPages {
+				t.Errorf("Expected X-Total-Pages %s, got %q", tt.wantPages, got)
+			}
+		})
+	}
+
+	// Invalid filters are refused as they are on GET
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/admin/clients?is_blacklisted=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid filter, got %d", w.Code)
+	}
+}
+
+func TestAdminHandler_GetAllClients_HeadEmpty(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewAdminHandler(services.NewUserService(newFakeUserRepo(), newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention))
+	r := gin.New()
+	r.HEAD("/admin/clients", handler.GetAllClients)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/admin/clients?is_blacklisted=true", nil))
+	if w.Code != http.StatusOK || w.Header().Get("X-Total-Count") != "0" || w.Header().Get("X-Total-Pages") != "0" {
+		t.Errorf("Expected no users and no pages, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestAdminHandler_GetAllClients_TotalHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userRepo := &listingUserRepo{total: 7}
+	handler := NewAdminHandler(services.NewUserService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention))
+	r := gin.New()
+	r.GET("/admin/clients", handler.GetAllClients)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/clients?limit=3", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Total-Count") != "7" || w.Header().Get("X-Total-Pages") != "3" {
+		t.Errorf("Expected 7 users in 3 pages, got %v", w.Header())
+	}
+}
+
+func TestAdminHandler_GetClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := newTestUser(t, "client@example.com")
+	refreshRepo := newFakeRefreshTokenRepo()
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: user.ID, TokenHash: "active", ExpiresAt: time.Now().Add(time.Hour)})
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: user.ID, TokenHash: "expired", ExpiresAt: time.Now().Add(-time.Hour)})
+	auditLogRepo := &fakeAuditLogRepo{}
+	handler := NewAdminHandler(services.NewUserService(newFakeUserRepo(user), refreshRepo, &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, auditLogRepo, &fakeBankingClient{}, testDeletionRetention))
+
+	adminID := uuid.New()
+	r := gin.New()
+	r.GET("/admin/clients/:id", func(c *gin.Context) {
+		c.Set("user_id", adminID.String())
+		c.Set("request_id", "req-view")
+		handler.GetClient(c)
+	})
+
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+	}{
+		{name: "existing user", id: user.ID.String(), wantStatus: http.StatusOK},
+		{name: "unknown user", id: uuid.New().String(), wantStatus: http.StatusNotFound},
+		{name: "malformed id", id: "not-a-uuid", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/clients/"+tt.id, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				User struct {
+					Email          string `json:"email"`
+					ActiveSessions int    `json:"active_sessions"`
+				} `json:"user"`
+			}
+			if err := decodeData(w, &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.User.Email != user.Email {
+				t.Errorf("Expected email %q, got %q", user.Email, response.User.Email)
+			}
+			if response.User.ActiveSessions != 1 {
+				t.Errorf("Expected 1 active session, got %d", response.User.ActiveSessions)
+			}
+		})
+	}
+
+	// Only the view of the existing user is recorded
+	if len(auditLogRepo.entries) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d", len(auditLogRepo.entries))
+	}
+	entry := auditLogRepo.entries[0]
+	if entry.Action != models.AuditActionViewClient || entry.AdminID != adminID || entry.TargetUserID == nil || *entry.TargetUserID != user.ID || entry.RequestID != "req-view" {
+		t.Errorf("Expected a user.view entry for the client by the admin, got %+v", entry)
+	}
+}
+
+func TestAdminHandler_DeleteClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	caller := newTestUser(t, "caller@example.com")
+	caller.IsAdmin = true
+
+	tests := []struct {
+		name        string
+		targetAdmin bool
+		self        bool
+		query       string
+		bankingErr  error
+		wantStatus  int
+		wantCode    string
+		wantDeleted bool
+	}{
+		{name: "regular user", wantStatus: http.StatusOK, wantDeleted: true},
+		{name: "own account", self: true, wantStatus: http.StatusBadRequest, wantCode: "CANNOT_DELETE_SELF"},
+		{name: "admin without force", targetAdmin: true, wantStatus: http.StatusConflict, wantCode: "ADMIN_DELETE_REQUIRES_FORCE"},
+		{name: "admin with force", targetAdmin: true, query: "?force=true", wantStatus: http.StatusOK, wantDeleted: true},
+		{name: "banking-service down", bankingErr: errors.New("connection refused"), wantStatus: http.StatusBadGateway, wantCode: "BANKING_SERVICE_UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := newTestUser(t, "target@example.com")
+			target.IsAdmin = tt.targetAdmin
+			if tt.self {
+				target = caller
+			}
+
+			userRepo := newFakeUserRepo(caller, target)
+			refreshRepo := newFakeRefreshTokenRepo()
+			refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: target.ID, TokenHash: "session", ExpiresAt: time.Now().Add(time.Hour)})
+			banking := &fakeBankingClient{err: tt.bankingErr}
+			handler := NewAdminHandler(services.NewUserService(userRepo, refreshRepo, &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, banking, testDeletionRetention))
+
+			r := gin.New()
+			r.DELETE("/admin/clients/:id", func(c *gin.Context) {
+				c.Set("user_id", caller.ID.String())
+				c.Set("request_id", "req-1")
+				handler.DeleteClient(c)
+			})
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/clients/"+target.ID.String()+tt.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+
+			if code := decodeErrorCode(t, w); code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+			}
+
+			_, err := userRepo.GetUserByID(target.ID)
+			if deleted := err != nil; deleted != tt.wantDeleted {
+				t.Errorf("Expected deleted=%v, got %v", tt.wantDeleted, deleted)
+			}
+			if tt.wantDeleted {
+				if len(banking.notified) != 1 || banking.notified[0] != target.ID {
+					t.Errorf("Expected banking-service to be notified about %s, got %v", target.ID, banking.notified)
+				}
+				if n, _ := refreshRepo.CountActiveByUserID(target.ID); n != 0 {
+					t.Errorf("Expected sessions to be revoked, %d remain", n)
+				}
+			}
+			wantAudits := 0
+			if tt.wantDeleted {
+				wantAudits = 1
+			}
+			if len(userRepo.audits) != wantAudits {
+				t.Fatalf("Expected %d audit entries, got %+v", wantAudits, userRepo.audits)
+			}
+			if tt.wantDeleted {
+				audit := userRepo.audits[0]
+				if audit.Action != models.AuditActionDelete || audit.AdminID != caller.ID || *audit.TargetUserID != target.ID || audit.RequestID != "req-1" {
+					t.Errorf("Unexpected audit entry: %+v", audit)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminHandler_RestoreClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	caller := newTestUser(t, "caller@example.com")
+	caller.IsAdmin = true
+
+	tests := []struct {
+		name         string
+		deletedAgo   time.Duration
+		notDeleted   bool
+		bankingErr   error
+		wantStatus   int
+		wantCode     string
+		wantRestored bool
+	}{
+		{name: "within window", deletedAgo: 24 * time.Hour, wantStatus: http.StatusOK, wantRestored: true},
+		{name: "not deleted", notDeleted: true, wantStatus: http.StatusConflict, wantCode: "USER_NOT_DELETED"},
+		{name: "window expired", deletedAgo: testDeletionRetention + time.Hour, wantStatus: http.StatusGone, wantCode: "RESTORE_WINDOW_EXPIRED"},
+		{name: "banking-service down", deletedAgo: time.Hour, bankingErr: errors.New("connection refused"), wantStatus: http.StatusBadGateway, wantCode: "BANKING_SERVICE_UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := newTestUser(t, "target@example.com")
+			if !tt.notDeleted {
+				deletedAt := time.Now().Add(-tt.deletedAgo)
+				target.DeletedAt = &deletedAt
+			}
+
+			userRepo := newFakeUserRepo(caller, target)
+			banking := &fakeBankingClient{err: tt.bankingErr}
+			handler := NewAdminHandler(services.NewUserService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, banking, testDeletionRetention))
+
+			r := gin.New()
+			r.POST("/admin/clients/:id/restore", func(c *gin.Context) {
+				c.Set("user_id", caller.ID.String())
+				c.Set("request_id", "req-1")
+				handler.RestoreClient(c)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/clients/"+target.ID.String()+"/restore", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+
+			if code := decodeErrorCode(t, w); code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+			}
+
+			if tt.notDeleted {
+				return
+			}
+			_, err := userRepo.GetUserByID(target.ID)
+			if restored := err == nil; restored != tt.wantRestored {
+				t.Errorf("Expected restored=%v, got %v", tt.wantRestored, restored)
+			}
+			if tt.wantRestored {
+				if len(banking.restored) != 1 || banking.restored[0] != target.ID {
+					t.Errorf("Expected banking-service to be notified about %s, got %v", target.ID, banking.restored)
+				}
+				if len(userRepo.audits) != 1 || userRepo.audits[0].Action != models.AuditActionRestore {
+					t.Errorf("Expected a restore audit entry, got %+v", userRepo.audits)
+				}
+			} else if len(userRepo.audits) != 0 {
+				t.Errorf("Expected no audit entries, got %+v", userRepo.audits)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_AdminRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		method      string
+		self        bool
+		targetAdmin bool
+		otherAdmins int
+		suspended   int
+		staleCaller bool
+		wantStatus  int
+		wantCode    string
+		wantAdmin   bool
+	}{
+		{name: "grant", method: http.MethodPost, wantStatus: http.StatusOK, wantAdmin: true},
+		{name: "revoke", method: http.MethodDelete, targetAdmin: true, wantStatus: http.StatusOK, wantAdmin: false},
+		{name: "revoke own role", method: http.MethodDelete, self: true, otherAdmins: 1, wantStatus: http.StatusBadRequest, wantCode: "CANNOT_DEMOTE_SELF", wantAdmin: true},
+		{name: "revoke last admin", method: http.MethodDelete, targetAdmin: true, staleCaller: true, wantStatus: http.StatusConflict, wantCode: "LAST_ADMIN", wantAdmin: true},
+		{name: "revoke last admin who can sign in", method: http.MethodDelete, targetAdmin: true, staleCaller: true, suspended: 1, wantStatus: http.StatusConflict, wantCode: "LAST_ADMIN", wantAdmin: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A stale caller still holds an admin token but was demoted in the database
+			caller := newTestUser(t, "caller@example.com")
+			setAdmin(caller, !tt.staleCaller)
+			target := newTestUser(t, "target@example.com")
+			setAdmin(target, tt.targetAdmin)
+			if tt.self {
+				target = caller
+			}
+
+			users := []*models.User{caller, target}
+			for i := 0; i < tt.otherAdmins; i++ {
+				other := newTestUser(t, "other@example.com")
+				setAdmin(other, true)
+				users = append(users, other)
+			}
+			for i := 0; i < tt.suspended; i++ {
+				other := newTestUser(t, "suspended@example.com")
+				setAdmin(other, true)
+				other.IsBlacklisted = true
+				users = append(users, other)
+			}
+			userRepo := newFakeUserRepo(users...)
+			handler := NewAdminHandler(services.NewUserService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention))
+
+			r := gin.New()
+			route := func(c *gin.Context) {
+				c.Set("user_id", caller.ID.String())
+				if c.Request.Method == http.MethodPost {
+					handler.GrantAdminRole(c)
+				} else {
+					handler.RevokeAdminRole(c)
+				}
+			}
+			r.POST("/admin/clients/:id/admin-role", route)
+			r.DELETE("/admin/clients/:id/admin-role", route)
+
+			req := httptest.NewRequest(tt.method, "/admin/clients/"+target.ID.String()+"/admin-role", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if code := decodeErrorCode(t, w); code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+			}
+			stored, _ := userRepo.GetUserByID(target.ID)
+			if stored.IsAdmin != tt.wantAdmin {
+				t.Errorf("Expected is_admin=%v, got %v", tt.wantAdmin, stored.IsAdmin)
+			}
+			if tt.wantStatus == http.StatusOK && stored.TokenVersion != 1 {
+				t.Errorf("Expected token version to be bumped, got %d", stored.TokenVersion)
+			}
+			if tt.wantStatus == http.StatusOK {
+				wantAction := models.AuditActionGrantAdmin
+				if !tt.wantAdmin {
+					wantAction = models.AuditActionRevokeAdmin
+				}
+				if len(userRepo.audits) != 1 || userRepo.audits[0].Action != wantAction {
+					t.Errorf("Expected one %s audit entry, got %+v", wantAction, userRepo.audits)
+				}
+			} else if len(userRepo.audits) != 0 {
+				t.Errorf("Expected no audit entries for a rejected change, got %+v", userRepo.audits)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_Roles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		method      string
+		role        string
+		targetRoles []string
+		wantStatus  int
+		wantCode    string
+		wantRoles   []string
+		wantAction  string
+	}{
+		{name: "grant support", method: http.MethodPost, role: authmw.RoleSupport, targetRoles: []string{}, wantStatus: http.StatusOK, wantRoles: []string{authmw.RoleSupport}, wantAction: models.AuditActionGrantRole},
+		{name: "grant held role", method: http.MethodPost, role: authmw.RoleSupport, targetRoles: []string{authmw.RoleSupport}, wantStatus: http.StatusOK, wantRoles: []string{authmw.RoleSupport}},
+		{name: "revoke auditor", method: http.MethodDelete, role: authmw.RoleAuditor, targetRoles: []string{authmw.RoleAuditor, authmw.RoleSupport}, wantStatus: http.StatusOK, wantRoles: []string{authmw.RoleSupport}, wantAction: models.AuditActionRevokeRole},
+		{name: "grant unknown role", method: http.MethodPost, role: "cashier", targetRoles: []string{}, wantStatus: http.StatusBadRequest, wantCode: "INVALID_ROLE", wantRoles: []string{}},
+		{name: "revoke unknown role", method: http.MethodDelete, role: "cashier", targetRoles: []string{}, wantStatus: http.StatusBadRequest, wantCode: "INVALID_ROLE", wantRoles: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caller := newTestUser(t, "caller@example.com")
+			setAdmin(caller, true)
+			target := newTestUser(t, "target@example.com")
+			target.Roles = tt.targetRoles
+
+			userRepo := newFakeUserRepo(caller, target)
+			handler := NewAdminHandler(services.NewUserService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention))
+
+			r := gin.New()
+			setCaller := func(c *gin.Context) { c.Set("user_id", caller.ID.String()) }
+			r.POST("/admin/clients/:id/roles", setCaller, handler.GrantRole)
+			r.DELETE("/admin/clients/:id/roles/:role", setCaller, handler.RevokeRole)
+
+			var req *http.Request
+			if tt.method == http.MethodPost {
+				body := `{"role":"` + tt.role + `"}`
+				req = httptest.NewRequest(http.MethodPost, "/admin/clients/"+target.ID.String()+"/roles", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+			} else {
+				req = httptest.NewRequest(http.MethodDelete, "/admin/clients/"+target.ID.String()+"/roles/"+tt.role, nil)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if code := decodeErrorCode(t, w); code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+			}
+			stored, _ := userRepo.GetUserByID(target.ID)
+			if !reflect.DeepEqual(stored.Roles, tt.wantRoles) {
+				t.Errorf("Expected roles %v, got %v", tt.wantRoles, stored.Roles)
+			}
+			if stored.IsAdmin {
+				t.Errorf("Expected a staff role change to leave is_admin alone")
+			}
+			if tt.wantAction == "" {
+				if len(userRepo.audits) != 0 {
+					t.Errorf("Expected no audit entries, got %+v", userRepo.audits)
+				}
+				return
+			}
+			if len(userRepo.audits) != 1 || userRepo.audits[0].Action != tt.wantAction || userRepo.audits[0].Metadata["role"] != tt.role {
+				t.Errorf("Expected one %s audit entry for %s, got %+v", tt.wantAction, tt.role, userRepo.audits)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_BlacklistRevokesIssuedTokens(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	admin := newTestUser(t, "admin@example.com")
+	admin.IsAdmin = true
+	user := newTestUser(t, "client@example.com")
+	userRepo := newFakeUserRepo(admin, user)
+	refreshRepo := newFakeRefreshTokenRepo()
+
+	r := newAuthRouter(userRepo, refreshRepo)
+	r.GET("/protected", middleware.AuthMiddleware(testTokens, userRepo, revocation.NewMemoryStore()), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	adminHandler := NewAdminHandler(services.NewUserService(userRepo, refreshRepo, &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention))
+	r.POST("/admin/clients/:id/blacklist", func(c *gin.Context) {
+		c.Set("user_id", admin.ID.String())
+		adminHandler.BlacklistClient(c)
+	})
+
+	// Log in before the blacklist is applied
+	w, _ := postJSON(t, r, "/auth/login", gin.H{"email": user.Email, "password": testPassword})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Login failed with status %d: %s", w.Code, w.Body.String())
+	}
+	var login struct {
+		Tokens struct {
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+		} `json:"tokens"`
+	}
+	if err := decodeData(w, &login); err != nil {
+		t.Fatalf("failed to decode login response: %v", err)
+	}
+
+	getProtected := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+login.Tokens.AccessToken)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := getProtected(); w.Code != http.StatusOK {
+		t.Fatalf("Expected access token to work before blacklisting, got %d", w.Code)
+	}
+
+	if w, _ := postJSON(t, r, "/admin/clients/"+user.ID.String()+"/blacklist", gin.H{"reason": "fraud"}); w.Code != http.StatusOK {
+		t.Fatalf("Blacklist failed with status %d: %s", w.Code, w.Body.String())
+	}
+
+	w = getProtected()
+	if w.Code != http.StatusUnauthorized || decodeErrorCode(t, w) != "TOKEN_REVOKED" {
+		t.Errorf("Expected 401 TOKEN_REVOKED for the old access token, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := refreshRepo.GetByToken(login.Tokens.RefreshToken); err == nil {
+		t.Error("Expected the refresh token to be revoked")
+	}
+	if w, code := postJSON(t, r, "/auth/refresh", gin.H{"refresh_token": login.Tokens.RefreshToken}); w.Code != http.StatusUnauthorized || code != "INVALID_REFRESH_TOKEN" {
+		t.Errorf("Expected 401 INVALID_REFRESH_TOKEN, got %d %q", w.Code, code)
+	}
+}
+
+func TestAdminHandler_CleanupRefreshTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := newTestUser(t, "client@example.com")
+	refreshRepo := newFakeRefreshTokenRepo()
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: user.ID, TokenHash: "expired", ExpiresAt: time.Now().Add(-time.Minute)})
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: user.ID, TokenHash: "active", ExpiresAt: time.Now().Add(time.Hour)})
+	handler := NewAdminHandler(services.NewUserService(newFakeUserRepo(user), refreshRepo, &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention))
+
+	r := gin.New()
+	r.POST("/admin/maintenance/cleanup-tokens", handler.CleanupRefreshTokens)
+
+	w, _ := postJSON(t, r, "/admin/maintenance/cleanup-tokens", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Cleanup failed with status %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Deleted int64 `json:"deleted"`
+	}
+	if err := decodeData(w, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Deleted != 1 {
+		t.Errorf("Expected 1 token deleted, got %d", response.Deleted)
+	}
+	if _, err := refreshRepo.GetByToken("active"); err != nil {
+		t.Error("Expected the active refresh token to be kept")
+	}
+	if _, err := refreshRepo.GetByToken("expired"); err == nil {
+		t.Error("Expected the expired refresh token to be deleted")
+	}
+}
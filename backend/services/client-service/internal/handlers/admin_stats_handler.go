@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// AdminStatsHandler handles admin dashboard stats HTTP requests
+type AdminStatsHandler struct {
+	statsService *services.AdminStatsService
+}
+
+// NewAdminStatsHandler creates a new admin stats handler
+func NewAdminStatsHandler(statsService *services.AdminStatsService) *AdminStatsHandler {
+	return &AdminStatsHandler{
+		statsService: statsService,
+	}
+}
+
+// GetStats retrieves user signup and engagement stats (admin only). Stats
+// are cached for a minute; generated_at tells when they were computed.
+func (h *AdminStatsHandler) GetStats(c *gin.Context) {
+	stats, err := h.statsService.GetStats()
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_STATS_FAILED",
+			Message: "Failed to fetch stats",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Stats retrieved successfully",
+		"stats":   adminStatsResponse(stats),
+	})
+}
+
+// defaultSignupReportWeeks is how many weeks a signup report covers when
+// the weeks query parameter is not given
+const defaultSignupReportWeeks = 12
+
+// GetSignupReport counts signups by registration source for each of the
+// last weeks UTC weeks (admin only). weeks defaults to 12 and may be up to
+// services.MaxSignupReportWeeks; the current week is included.
+func (h *AdminStatsHandler) GetSignupReport(c *gin.Context) {
+	weeks := defaultSignupReportWeeks
+	if value := c.Query("weeks"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > services.MaxSignupReportWeeks {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_QUERY_PARAMETER",
+				Message: fmt.Sprintf("weeks must be an integer between 1 and %d", services.MaxSignupReportWeeks),
+			})
+			return
+		}
+		weeks = parsed
+	}
+
+	// Get report
+	report, err := h.statsService.GetSignupReport(weeks)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_SIGNUP_REPORT_FAILED",
+			Message: "Failed to fetch signup report",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return report
+	httpx.RespondOK(c, gin.H{
+		"message": "Signup report retrieved successfully",
+		"report":  signupReportResponse(report),
+	})
+}
+
+// signupReportResponse returns the JSON representation of a signup report,
+// with each source's total over all of its weeks
+func signupReportResponse(report []models.SignupWeek) gin.H {
+	weeks := make([]gin.H, 0, len(report))
+	totals := make(map[string]int, len(models.RegistrationSources))
+	total := 0
+	for _, week := range report {
+		weeks = append(weeks, gin.H{
+			"week_start": week.WeekStart.Format(models.DateLayout),
+			"total":      week.Total(),
+			"by_source":  week.BySource,
+		})
+		for source, count := range week.BySource {
+			totals[source] += count
+		}
+		total += week.Total()
+	}
+
+	return gin.H{
+		"weeks":     weeks,
+		"by_source": totals,
+		"total":     total,
+	}
+}
+
+// adminStatsResponse returns the JSON representation of admin stats
+func adminStatsResponse(stats *models.AdminStats) gin.H {
+	return gin.H{
+		"total_users": stats.Users.Total,
+		"new_registrations": gin.H{
+			"today":      stats.Users.NewToday,
+			"this_week":  stats.Users.NewThisWeek,
+			"this_month": stats.Users.NewThisMonth,
+		},
+		"blacklisted_users":      stats.Users.Blacklisted,
+		"verified_email_percent": stats.VerifiedEmailPercent(),
+		"active_sessions":        stats.ActiveSessions,
+		"generated_at":           stats.GeneratedAt,
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+)
+
+func TestAdminStatsHandler_GetStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	verified := time.Now()
+	verifiedUser := newTestUser(t, "verified@example.com")
+	verifiedUser.CreatedAt = time.Now()
+	verifiedUser.EmailVerifiedAt = &verified
+	blacklistedUser := newTestUser(t, "blacklisted@example.com")
+	blacklistedUser.IsBlacklisted = true
+	refreshRepo := newFakeRefreshTokenRepo()
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: verifiedUser.ID, TokenHash: "active", ExpiresAt: time.Now().Add(time.Hour)})
+	handler := NewAdminStatsHandler(services.NewAdminStatsService(newFakeUserRepo(verifiedUser, blacklistedUser), refreshRepo))
+
+	r := gin.New()
+	r.GET("/admin/stats", handler.GetStats)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Stats struct {
+			TotalUsers       int `json:"total_users"`
+			NewRegistrations struct {
+				Today int `json:"today"`
+			} `json:"new_registrations"`
+			BlacklistedUsers     int     `json:"blacklisted_users"`
+			VerifiedEmailPercent float64 `json:"verified_email_percent"`
+			ActiveSessions       int     `json:"active_sessions"`
+		} `json:"stats"`
+	}
+	if err := decodeData(w, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	stats := response.Stats
+	if stats.TotalUsers != 2 || stats.NewRegistrations.Today != 1 || stats.BlacklistedUsers != 1 || stats.VerifiedEmailPercent != 50 || stats.ActiveSessions != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestAdminStatsHandler_GetSignupReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	selfUser := newTestUser(t, "self@example.com")
+	selfUser.CreatedAt = time.Now()
+	selfUser.RegistrationSource = models.RegistrationSourceSelf
+	invitedUser := newTestUser(t, "invited@example.com")
+	invitedUser.CreatedAt = time.Now()
+	invitedUser.RegistrationSource = models.RegistrationSourceInvite
+	handler := NewAdminStatsHandler(services.NewAdminStatsService(newFakeUserRepo(selfUser, invitedUser), newFakeRefreshTokenRepo()))
+
+	r := gin.New()
+	r.GET("/admin/reports/signups", handler.GetSignupReport)
+
+	for _, weeks := range []string{"0", "105", "many"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/reports/signups?weeks="+weeks, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for weeks=%s, got %d", weeks, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/reports/signups?weeks=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Report struct {
+			Weeks []struct {
+				WeekStart string         `json:"week_start"`
+				Total     int            `json:"total"`
+				BySource  map[string]int `json:"by_source"`
+			} `json:"weeks"`
+			BySource map[string]int `json:"by_source"`
+			Total    int            `json:"total"`
+		} `json:"report"`
+	}
+	if err := decodeData(w, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	report := response.Report
+	if len(report.Weeks) != 1 || report.Weeks[0].Total != 2 || report.Total != 2 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if report.BySource["self"] != 1 || report.BySource["invite"] != 1 || report.BySource["oauth_google"] != 0 {
+		t.Errorf("Unexpected signups by source: %v", report.BySource)
+	}
+	if _, ok := report.Weeks[0].BySource["admin_created"]; !ok {
+		t.Errorf("Expected every source in the week, got %v", report.Weeks[0].BySource)
+	}
+}
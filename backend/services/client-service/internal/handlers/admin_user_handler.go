@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// AdminUserHandler handles HTTP requests of staff opening accounts on
+// customers' behalf
+type AdminUserHandler struct {
+	adminUserService *services.AdminUserService
+}
+
+// NewAdminUserHandler creates a new admin user handler
+func NewAdminUserHandler(adminUserService *services.AdminUserService) *AdminUserHandler {
+	return &AdminUserHandler{
+		adminUserService: adminUserService,
+	}
+}
+
+// CreateClient creates a user without a password and emails them a link to
+// set one (staff only)
+func (h *AdminUserHandler) CreateClient(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.AdminUserCreation
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Create user
+	created, err := h.adminUserService.CreateUser(actor, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserExists):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "USER_EXISTS",
+				Message: "User with this email already exists",
+			})
+		case errors.Is(err, services.ErrEmailPendingDeletion):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "EMAIL_PENDING_DELETION",
+				Message: "This email belongs to a deleted account and cannot be reused yet",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "CREATE_USER_FAILED",
+				Message: "Failed to create user",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return created user
+	httpx.RespondCreated(c, gin.H{
+		"message":          "User created successfully",
+		"user":             created.User.ToResponse(),
+		"setup_email_sent": created.SetupEmailSent,
+	})
+}
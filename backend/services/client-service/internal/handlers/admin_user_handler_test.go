@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/mailer"
+)
+
+func TestAdminUserHandler_CreateClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userRepo := newFakeUserRepo()
+	resetRepo := &fakeResetTokenRepo{}
+	handler := NewAdminUserHandler(services.NewAdminUserService(userRepo, resetRepo, mailer.NewLogSender(log.New(io.Discard, "", 0)), nil))
+	adminID := uuid.New()
+
+	r := newAuthRouter(userRepo, newFakeRefreshTokenRepo())
+	r.POST("/admin/clients", func(c *gin.Context) {
+		c.Set("user_id", adminID.String())
+		handler.CreateClient(c)
+	})
+
+	if w, _ := postJSON(t, r, "/admin/clients", gin.H{"email": "not-an-email", "name": "Branch Customer"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid email, got %d", w.Code)
+	}
+
+	w, _ := postJSON(t, r, "/admin/clients", gin.H{"email": "branch@example.com", "name": "Branch Customer"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		User           models.UserResponse `json:"user"`
+		SetupEmailSent bool                `json:"setup_email_sent"`
+	}
+	if err := decodeData(w, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !response.SetupEmailSent || response.User.HasPassword || response.User.RegistrationSource != models.RegistrationSourceAdminCreated {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+	if len(resetRepo.tokens) != 1 || resetRepo.tokens[0].UserID != response.User.ID {
+		t.Errorf("Expected a setup token for the new user, got %+v", resetRepo.tokens)
+	}
+	if len(userRepo.audits) != 1 || userRepo.audits[0].AdminID != adminID || userRepo.audits[0].Action != models.AuditActionCreateUser {
+		t.Errorf("Expected the creating admin to be audited, got %+v", userRepo.audits)
+	}
+
+	if _, code := postJSON(t, r, "/admin/clients", gin.H{"email": "branch@example.com", "name": "Branch Customer"}); code != "USER_EXISTS" {
+		t.Errorf("Expected USER_EXISTS for a taken email, got %q", code)
+	}
+
+	// Logging in before the password is set points at the setup flow
+	w, code := postJSON(t, r, "/auth/login", gin.H{"email": "branch@example.com", "password": "Correct-Horse-42"})
+	if w.Code != http.StatusForbidden || code != "PASSWORD_SETUP_REQUIRED" {
+		t.Errorf("Expected 403 PASSWORD_SETUP_REQUIRED, got %d %q", w.Code, code)
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// AuditLogHandler handles admin audit log HTTP requests
+type AuditLogHandler struct {
+	auditLogService *services.AuditLogService
+}
+
+// NewAuditLogHandler creates a new audit log handler
+func NewAuditLogHandler(auditLogService *services.AuditLogService) *AuditLogHandler {
+	return &AuditLogHandler{
+		auditLogService: auditLogService,
+	}
+}
+
+// GetAuditLog retrieves a page of the admin audit log (admin only). See
+// parseAuditLogFilter for the supported query parameters.
+func (h *AuditLogHandler) GetAuditLog(c *gin.Context) {
+	filter, err := parseAuditLogFilter(c)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_QUERY_PARAMETER",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get audit log
+	page, err := h.auditLogService.ListAuditLog(filter)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_AUDIT_LOG_FAILED",
+			Message: "Failed to fetch audit log",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	entries := page.Entries
+	if entries == nil {
+		entries = []models.AuditLogEntry{}
+	}
+
+	// Return audit log
+	httpx.RespondPage(c, gin.H{
+		"message": "Audit log retrieved successfully",
+		"entries": entries,
+	}, httpx.NewPagination(page.Limit, page.Offset, len(entries), page.Total))
+}
+
+// ExportAuditLog downloads the audit log entries matching the filters as
+// CSV, newest first (admin only)
+func (h *AuditLogHandler) ExportAuditLog(c *gin.Context) {
+	filter, err := parseAuditLogFilter(c)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_QUERY_PARAMETER",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get audit log
+	entries, err := h.auditLogService.ExportAuditLog(filter)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "EXPORT_AUDIT_LOG_FAILED",
+			Message: "Failed to export audit log",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="audit-log.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "created_at", "admin_id", "action", "target_user_id", "request_id", "metadata"})
+	for _, entry := range entries {
+		targetUserID := ""
+		if entry.TargetUserID != nil {
+			targetUserID = entry.TargetUserID.String()
+		}
+		metadata, _ := json.Marshal(entry.Metadata)
+		w.Write([]string{
+			entry.ID.String(),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			entry.AdminID.String(),
+			entry.Action,
+			targetUserID,
+			services.EscapeCSVFormula(entry.RequestID),
+			services.EscapeCSVFormula(string(metadata)),
+		})
+	}
+	w.Flush()
+}
+
+// parseAuditLogFilter reads the audit log query parameters: limit, offset,
+// admin_id, target_user_id and action
+func parseAuditLogFilter(c *gin.Context) (models.AuditLogFilter, error) {
+	var filter models.AuditLogFilter
+
+	// Pagination
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return filter, fmt.Errorf("offset must be a non-negative integer")
+		}
+		filter.Offset = offset
+	}
+
+	// ID filters
+	for name, target := range map[string]**uuid.UUID{
+		"admin_id":       &filter.AdminID,
+		"target_user_id": &filter.TargetUserID,
+	} {
+		if value := c.Query(name); value != "" {
+			parsed, err := uuid.Parse(value)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be a valid UUID", name)
+			}
+			*target = &parsed
+		}
+	}
+
+	filter.Action = c.Query("action")
+
+	return filter, nil
+}
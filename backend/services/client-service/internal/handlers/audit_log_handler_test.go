@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+)
+
+func newAuditLogRouter(repo *fakeAuditLogRepo) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewAuditLogHandler(services.NewAuditLogService(repo))
+
+	r := gin.New()
+	r.GET("/admin/audit-log", handler.GetAuditLog)
+	r.GET("/admin/audit-log/export", handler.ExportAuditLog)
+	return r
+}
+
+func testAuditLogEntries() []models.AuditLogEntry {
+	adminID := uuid.New()
+	targetID := uuid.New()
+	now := time.Now()
+	return []models.AuditLogEntry{
+		{ID: uuid.New(), AdminID: adminID, Action: models.AuditActionDelete, TargetUserID: &targetID, Metadata: map[string]interface{}{"email": "gone@example.com"}, RequestID: "req-2", CreatedAt: now},
+		{ID: uuid.New(), AdminID: adminID, Action: models.AuditActionBlacklist, TargetUserID: &targetID, Metadata: map[string]interface{}{"reason": "fraud, confirmed"}, RequestID: "req-1", CreatedAt: now.Add(-time.Hour)},
+	}
+}
+
+func TestAuditLogHandler_GetAuditLog(t *testing.T) {
+	repo := &fakeAuditLogRepo{entries: testAuditLogEntries()}
+	r := newAuditLogRouter(repo)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCount  int
+	}{
+		{name: "all entries", query: "", wantStatus: http.StatusOK, wantCount: 2},
+		{name: "filtered by action", query: "?action=user.delete", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "paged", query: "?limit=1&offset=1", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "malformed admin id", query: "?admin_id=nope", wantStatus: http.StatusBadRequest},
+		{name: "malformed limit", query: "?limit=0", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit-log"+tt.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Data struct {
+					Entries []models.AuditLogEntry `json:"entries"`
+				} `json:"data"`
+				Pagination struct {
+					Total int `json:"total"`
+				} `json:"pagination"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(body.Data.Entries) != tt.wantCount {
+				t.Errorf("Expected %d entries, got %d", tt.wantCount, len(body.Data.Entries))
+			}
+		})
+	}
+}
+
+func TestAuditLogHandler_ExportAuditLog(t *testing.T) {
+	entries := testAuditLogEntries()
+	entries[1].RequestID = `=HYPERLINK("http://evil.example")`
+	repo := &fakeAuditLogRepo{entries: entries}
+	r := newAuditLogRouter(repo)
+
+	adminID := entries[0].AdminID
+	req := httptest.NewRequest(http.MethodGet, "/admin/audit-log/export?limit=1&admin_id="+adminID.String(), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Expected a CSV content type, got %q", ct)
+	}
+	if repo.lastFilter.AdminID == nil || *repo.lastFilter.AdminID != adminID {
+		t.Errorf("Expected the admin filter to be applied, got %+v", repo.lastFilter)
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+	// Exports ignore paging, so both entries are included after the header
+	if len(records) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %d records", len(records))
+	}
+	if records[0][3] != "action" || records[2][3] != models.AuditActionBlacklist {
+		t.Errorf("Unexpected CSV contents: %v", records)
+	}
+	if records[2][6] != `{"reason":"fraud, confirmed"}` {
+		t.Errorf("Expected metadata as JSON, got %q", records[2][6])
+	}
+	if records[2][5] != `'=HYPERLINK("http://evil.example")` {
+		t.Errorf("Expected the formula request ID to be escaped, got %q", records[2][5])
+	}
+}
@@ -1,24 +1,37 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 
+	"microbank/client-service/internal/authcookie"
+	"microbank/client-service/internal/middleware"
 	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordpolicy"
 	"microbank/client-service/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"microbank/pkg/authmw"
+	"microbank/pkg/httpx"
 )
 
 // AuthHandler handles authentication-related HTTP requests
 type AuthHandler struct {
-	authService *services.AuthService
+	authService          *services.AuthService
+	passwordResetService *services.PasswordResetService
+	cookies              *authcookie.Config
 }
 
-// NewAuthHandler creates a new authentication handler
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+// NewAuthHandler creates a new authentication handler. Tokens are delivered
+// in cookies as cookies allows; a nil cookies keeps them in response bodies.
+func NewAuthHandler(authService *services.AuthService, passwordResetService *services.PasswordResetService, cookies *authcookie.Config) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:          authService,
+		passwordResetService: passwordResetService,
+		cookies:              cookies,
 	}
 }
 
@@ -27,192 +40,392 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	var registration models.UserRegistration
 
 	// Bind and validate request body
-	if err := c.ShouldBindJSON(&registration); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "Invalid request data",
-				"details": err.Error(),
-			},
-		})
+	if !bindJSON(c, &registration) {
 		return
 	}
 
 	// Register user
 	user, err := h.authService.RegisterUser(registration)
 	if err != nil {
+		if respondPasswordPolicyError(c, "password", err) {
+			return
+		}
+
 		// Check for specific error types
-		if err.Error() == "user with email "+registration.Email+" already exists" {
-			c.JSON(http.StatusConflict, gin.H{
-				"error": gin.H{
-					"code":    "USER_EXISTS",
-					"message": "User with this email already exists",
-				},
+		if errors.Is(err, services.ErrUserExists) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "USER_EXISTS",
+				Message: "User with this email already exists",
 			})
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "REGISTRATION_FAILED",
-				"message": "Failed to register user",
-				"details": err.Error(),
-			},
+		if respondRegistrationGateError(c, err) {
+			return
+		}
+
+		if errors.Is(err, services.ErrEmailPendingDeletion) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "EMAIL_PENDING_DELETION",
+				Message: "This email belongs to a deleted account and cannot be reused yet",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "REGISTRATION_FAILED",
+			Message: "Failed to register user",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
 
 	// Return success response
-	c.JSON(http.StatusCreated, gin.H{
+	httpx.RespondCreated(c, gin.H{
 		"message": "User registered successfully",
 		"user":    user.ToResponse(),
 	})
 }
 
+// respondRegistrationGateError writes a 403 when err means the registration
+// mode or invitation does not allow the registration, and reports whether
+// it did
+func respondRegistrationGateError(c *gin.Context, err error) bool {
+	var code, message string
+	switch {
+	case errors.Is(err, services.ErrRegistrationClosed):
+		code, message = "REGISTRATION_CLOSED", "Registration is currently closed"
+	case errors.Is(err, services.ErrInvitationRequired):
+		code, message = "INVITATION_REQUIRED", "An invitation code is required to register"
+	case errors.Is(err, services.ErrInvalidInvitation):
+		code, message = "INVALID_INVITATION_CODE", "Invitation code is invalid"
+	case errors.Is(err, services.ErrInvitationUsed):
+		code, message = "INVITATION_USED", "Invitation code has already been used"
+	case errors.Is(err, services.ErrInvitationRevoked):
+		code, message = "INVITATION_REVOKED", "Invitation code has been revoked"
+	case errors.Is(err, services.ErrInvitationExpired):
+		code, message = "INVITATION_EXPIRED", "Invitation code has expired"
+	case errors.Is(err, services.ErrInvitationEmailMismatch):
+		code, message = "INVITATION_EMAIL_MISMATCH", "Invitation code was issued for a different email"
+	default:
+		return false
+	}
+
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusForbidden,
+		Code:    code,
+		Message: message,
+	})
+	return true
+}
+
 // Login handles user authentication
 func (h *AuthHandler) Login(c *gin.Context) {
 	var login models.UserLogin
 
 	// Bind and validate request body
-	if err := c.ShouldBindJSON(&login); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "Invalid request data",
-				"details": err.Error(),
-			},
-		})
+	if !bindJSON(c, &login) {
 		return
 	}
 
 	// Authenticate user
-	user, accessToken, refreshToken, err := h.authService.LoginUser(login)
+	meta := models.LoginMetadata{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	user, session, err := h.authService.LoginUser(login, meta)
 	if err != nil {
 		// Check for specific error types
-		if err.Error() == "invalid credentials" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "INVALID_CREDENTIALS",
-					"message": "Invalid email or password",
-				},
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusUnauthorized,
+				Code:    "INVALID_CREDENTIALS",
+				Message: "Invalid email or password",
 			})
 			return
 		}
 
-		if err.Error() == "account has been suspended" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": gin.H{
-					"code":    "ACCOUNT_SUSPENDED",
-					"message": "Your account has been suspended",
-				},
+		if errors.Is(err, services.ErrAccountDeleted) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "ACCOUNT_DELETED",
+				Message: "This account has been deleted",
 			})
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "LOGIN_FAILED",
-				"message": "Failed to authenticate user",
-				"details": err.Error(),
-			},
+		if respondSuspendedError(c, err) || respondPasswordResetRequiredError(c, err) || respondPasswordSetupRequiredError(c, err) {
+			return
+		}
+
+		if errors.Is(err, services.ErrBankingServiceUnavailable) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadGateway,
+				Code:    "BANKING_SERVICE_UNAVAILABLE",
+				Message: "Could not cancel the pending account deletion; please try again later",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "LOGIN_FAILED",
+			Message: "Failed to authenticate user",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
 
+	respondSession(c, h.cookies, login.UseCookies, user, session)
+}
+
+// respondSession writes the response for a successful sign-in. In cookie
+// mode the refresh token, and optionally the access token, are kept out of
+// reach of scripts.
+func respondSession(c *gin.Context, cookies *authcookie.Config, useCookies bool, user *models.User, session *services.Session) {
+	tokens := gin.H{
+		"access_token":             session.AccessToken,
+		"refresh_token":            session.RefreshToken,
+		"refresh_token_expires_at": session.RefreshTokenExpiresAt,
+		"token_type":               "Bearer",
+	}
+
+	if cookies.Use(useCookies) {
+		csrfToken, err := cookies.SetSession(c.Writer, session.RefreshToken, session.RefreshTokenExpiresAt, session.AccessToken)
+		if err != nil {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "LOGIN_FAILED",
+				Message: "Failed to authenticate user",
+				Details: middleware.ErrorDetails(c, err),
+			})
+			return
+		}
+		delete(tokens, "refresh_token")
+		if cookies.AccessToken {
+			delete(tokens, "access_token")
+		}
+		tokens["csrf_token"] = csrfToken
+	}
+
 	// Return success response with tokens
-	c.JSON(http.StatusOK, gin.H{
+	httpx.RespondOK(c, gin.H{
 		"message": "Login successful",
 		"user":    user.ToResponse(),
-		"tokens": gin.H{
-			"access_token":  accessToken,
-			"refresh_token": refreshToken,
-			"token_type":    "Bearer",
-		},
+		"tokens":  tokens,
 	})
 }
 
 // RefreshToken handles token refresh requests
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	refreshToken, fromCookie, ok := h.requestRefreshToken(c)
+	if !ok {
+		return
+	}
+
+	// Refresh token
+	accessToken, err := h.authService.RefreshToken(refreshToken)
+	if err != nil {
+		// Check for specific error types
+		if errors.Is(err, services.ErrRefreshTokenInvalid) {
+			// Count guesses so the address can be blocked; expired tokens
+			// are not counted
+			middleware.MarkInvalidRefreshToken(c)
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusUnauthorized,
+				Code:    "INVALID_REFRESH_TOKEN",
+				Message: "Invalid refresh token",
+			})
+			return
+		}
+
+		if errors.Is(err, services.ErrRefreshTokenExpired) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusUnauthorized,
+				Code:    "REFRESH_TOKEN_EXPIRED",
+				Message: "Refresh token has expired",
+			})
+			return
+		}
+
+		if respondSuspendedError(c, err) {
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "TOKEN_REFRESH_FAILED",
+			Message: "Failed to refresh token",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	tokens := gin.H{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+	}
+	if fromCookie && h.cookies.AccessToken {
+		h.cookies.SetAccessToken(c.Writer, accessToken)
+		delete(tokens, "access_token")
+	}
+
+	// Return new access token
+	httpx.RespondOK(c, gin.H{
+		"message": "Token refreshed successfully",
+		"tokens":  tokens,
+	})
+}
+
+// Logout ends the session holding the refresh token in the request body or
+// cookie, and clears the auth cookies
+func (h *AuthHandler) Logout(c *gin.Context) {
+	refreshToken, _, ok := h.requestRefreshToken(c)
+	if !ok {
+		return
+	}
+
+	if h.cookies.Enabled() {
+		h.cookies.Clear(c.Writer)
+	}
+
+	// An unknown token has nothing left to end
+	if err := h.authService.Logout(refreshToken); err != nil && !errors.Is(err, services.ErrRefreshTokenInvalid) {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "LOGOUT_FAILED",
+			Message: "Failed to log out",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Logged out successfully",
+	})
+}
+
+// requestRefreshToken reads the refresh token from the request body, or from
+// the refresh token cookie when the body has none, and reports whether it
+// came from the cookie. It writes a 400 response and returns false when
+// there is no token.
+func (h *AuthHandler) requestRefreshToken(c *gin.Context) (string, bool, bool) {
 	var request struct {
-		RefreshToken string `json:"refresh_token" binding:"required"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
-	// Bind and validate request body
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "Invalid request data",
-				"details": err.Error(),
-			},
+	// Bind the request body, which cookie mode clients may leave empty
+	if c.Request.ContentLength != 0 {
+		if !bindJSON(c, &request) {
+			return "", false, false
+		}
+	}
+	if request.RefreshToken != "" {
+		return request.RefreshToken, false, true
+	}
+
+	if h.cookies.Enabled() {
+		if cookie, err := c.Cookie(authcookie.RefreshTokenCookie); err == nil && cookie != "" {
+			return cookie, true, true
+		}
+	}
+
+	respondValidationError(c, fieldValidationError("refresh_token", "required", "is required"))
+	return "", false, false
+}
+
+// ChangePassword changes the authenticated user's password
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	// Get user ID from context (set by AuthMiddleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
 		})
 		return
 	}
 
-	// Refresh token
-	accessToken, err := h.authService.RefreshToken(request.RefreshToken)
+	// Parse user ID
+	userUUID, err := uuid.Parse(userID.(string))
 	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Bind and validate request body
+	var change models.PasswordChange
+	if !bindJSON(c, &change) {
+		return
+	}
+
+	// Change password
+	if err := h.authService.ChangePassword(userUUID, change); err != nil {
+		if respondPasswordPolicyError(c, "new_password", err) || respondPasswordNotSetError(c, err) {
+			return
+		}
+
 		// Check for specific error types
-		if err.Error() == "invalid refresh token" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "INVALID_REFRESH_TOKEN",
-					"message": "Invalid refresh token",
-				},
+		if errors.Is(err, services.ErrInvalidCurrentPassword) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "INVALID_CURRENT_PASSWORD",
+				Message: "Current password is incorrect",
 			})
 			return
 		}
 
-		if err.Error() == "refresh token expired" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "REFRESH_TOKEN_EXPIRED",
-					"message": "Refresh token has expired",
-				},
+		if errors.Is(err, services.ErrPasswordUnchanged) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "PASSWORD_REUSED",
+				Message: "New password must differ from the current password",
 			})
 			return
 		}
 
-		if err.Error() == "account has been suspended" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": gin.H{
-					"code":    "ACCOUNT_SUSPENDED",
-					"message": "Your account has been suspended",
-				},
+		if errors.Is(err, services.ErrPasswordRecentlyUsed) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "PASSWORD_RECENTLY_USED",
+				Message: "New password matches a recently used password",
 			})
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "TOKEN_REFRESH_FAILED",
-				"message": "Failed to refresh token",
-				"details": err.Error(),
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "PASSWORD_CHANGE_FAILED",
+			Message: "Failed to change password",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
 
-	// Return new access token
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Token refreshed successfully",
-		"tokens": gin.H{
-			"access_token": accessToken,
-			"token_type":   "Bearer",
-		},
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message": "Password changed successfully",
 	})
 }
 
-// ValidateToken validates the current access token
-func (h *AuthHandler) ValidateToken(c *gin.Context) {
-	// Get user information from context (set by AuthMiddleware)
+// DeleteAccount deletes the current user's own account after a grace period
+// during which logging back in cancels the deletion
+func (h *AuthHandler) DeleteAccount(c *gin.Context) {
+	// Get user ID from context (set by AuthMiddleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "User information not found in context",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
 		})
 		return
 	}
@@ -220,27 +433,383 @@ func (h *AuthHandler) ValidateToken(c *gin.Context) {
 	// Parse user ID
 	userUUID, err := uuid.Parse(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Invalid user ID format",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
 		})
 		return
 	}
 
+	// Bind and validate request body
+	var request models.AccountDeletion
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Delete account
+	purgeAt, err := h.authService.DeleteAccount(userUUID, request)
+	if err != nil {
+		if respondPasswordNotSetError(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, services.ErrInvalidCurrentPassword):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "INVALID_CURRENT_PASSWORD",
+				Message: "Password is incorrect",
+			})
+		case errors.Is(err, services.ErrAdminSelfDeletion):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "ADMIN_SELF_DELETION",
+				Message: "Admins must have their admin role revoked before deleting their account",
+			})
+		case errors.Is(err, services.ErrAccountBalanceNotZero):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "ACCOUNT_BALANCE_NOT_ZERO",
+				Message: "Withdraw your remaining balance before deleting your account",
+			})
+		case errors.Is(err, services.ErrBankingServiceUnavailable):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadGateway,
+				Code:    "BANKING_SERVICE_UNAVAILABLE",
+				Message: "Could not reach the banking service; the account was not deleted",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "DELETE_ACCOUNT_FAILED",
+				Message: "Failed to delete account",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
 
-        // Return user information from context
-        email, _ := c.Get("email")
-        name, _ := c.Get("name")
-        isAdmin, _ := c.Get("is_admin")
-        c.JSON(http.StatusOK, gin.H{
-                "message": "Token is valid",
-                "user": gin.H{
-                        "id": userUUID,
-                        "email": email,
-                        "name": name,
-                        "is_admin": isAdmin,
-                },
-        })
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message":  "Account scheduled for deletion. Log in before the purge date to cancel.",
+		"purge_at": purgeAt,
+	})
+}
+
+// ForgotPassword starts the password reset flow
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var request models.ForgotPasswordRequest
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Always respond the same way so the endpoint cannot be used to discover accounts
+	if err := h.passwordResetService.RequestPasswordReset(request.Email); err != nil {
+		log.Printf("Password reset request failed: %v", err)
+	}
+
+	httpx.Respond(c, http.StatusAccepted, gin.H{
+		"message": "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+// ResetPassword completes the password reset flow
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var request models.ResetPasswordRequest
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Reset password
+	if err := h.passwordResetService.ResetPassword(request); err != nil {
+		if respondPasswordPolicyError(c, "new_password", err) {
+			return
+		}
+
+		// Check for specific error types
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_RESET_TOKEN",
+				Message: "Invalid or already used reset token",
+			})
+			return
+		}
+
+		if errors.Is(err, services.ErrResetTokenExpired) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "RESET_TOKEN_EXPIRED",
+				Message: "Reset token has expired",
+			})
+			return
+		}
+
+		if errors.Is(err, services.ErrPasswordRecentlyUsed) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "PASSWORD_RECENTLY_USED",
+				Message: "New password matches a recently used password",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "PASSWORD_RESET_FAILED",
+			Message: "Failed to reset password",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message": "Password reset successfully",
+	})
+}
+
+// ValidateToken checks the current access token against the database and
+// returns the user's current state. With ?view=service the response is cut
+// down to what other services need to accept or reject the token.
+func (h *AuthHandler) ValidateToken(c *gin.Context) {
+	// The middleware has already looked up personal access tokens
+	if scopes, personal := authmw.ContextScopes(c); personal {
+		respondPersonalAccessToken(c, scopes)
+		return
+	}
+
+	// The middleware has already checked the header format
+	tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	// Check the token against the current user
+	validation, err := h.authService.ValidateToken(tokenString)
+	if err != nil {
+		if respondSuspendedError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrTokenRevoked) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusUnauthorized,
+				Code:    "TOKEN_REVOKED",
+				Message: "Token has been revoked, please log in again",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusUnauthorized,
+				Code:    "USER_NOT_FOUND",
+				Message: "User no longer exists",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusUnauthorized,
+			Code:    "INVALID_TOKEN",
+			Message: "Invalid or expired token",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	user := validation.User
+	claimsStale := len(validation.StaleClaims) > 0
+
+	// Services only need to know whether to accept the token
+	if c.Query("view") == "service" {
+		httpx.RespondOK(c, gin.H{
+			"valid":          true,
+			"user_id":        user.ID,
+			"is_admin":       user.IsAdmin,
+			"is_blacklisted": user.IsBlacklisted,
+			"claims_stale":   claimsStale,
+		})
+		return
+	}
+
+	// Return the user's current information
+	httpx.RespondOK(c, gin.H{
+		"message":      "Token is valid",
+		"user":         user.ToResponse(),
+		"claims_stale": claimsStale,
+		"stale_claims": validation.StaleClaims,
+	})
+}
+
+// VerifyPassword reports whether the password given is the user's (internal
+// only). The password is never logged.
+func (h *AuthHandler) VerifyPassword(c *gin.Context) {
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	var request models.PasswordVerification
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Check password
+	valid, err := h.authService.VerifyPassword(userID, request.Password)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "VERIFY_PASSWORD_FAILED",
+			Message: "Failed to verify password",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"valid": valid,
+	})
+}
+
+// respondPersonalAccessToken answers a token validation for a personal
+// access token, whose owner the middleware has confirmed may act
+func respondPersonalAccessToken(c *gin.Context, scopes []string) {
+	if c.Query("view") == "service" {
+		httpx.RespondOK(c, gin.H{
+			"valid":          true,
+			"user_id":        c.GetString("user_id"),
+			"is_admin":       false,
+			"is_blacklisted": false,
+			"claims_stale":   false,
+			"scopes":         scopes,
+		})
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Token is valid",
+		"user_id": c.GetString("user_id"),
+		"scopes":  scopes,
+	})
+}
+
+// respondSuspendedError writes a 403 when err is an account suspension and
+// reports whether it did. Temporary suspensions use a distinct code and
+// include when they end.
+func respondSuspendedError(c *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrAccountSuspended) {
+		return false
+	}
+
+	var suspension *services.SuspensionError
+	if errors.As(err, &suspension) && suspension.IsTemporary() {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusForbidden,
+			Code:    "ACCOUNT_SUSPENDED_TEMPORARILY",
+			Message: "Your account has been temporarily suspended",
+			Details: gin.H{
+				"suspended_until": suspension.Until,
+			},
+		})
+		return true
+	}
+
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusForbidden,
+		Code:    "ACCOUNT_SUSPENDED",
+		Message: "Your account has been suspended",
+	})
+	return true
+}
+
+// respondPasswordResetRequiredError writes a 403 when err means an admin
+// required a password reset before the user logs in again, and reports
+// whether it did
+func respondPasswordResetRequiredError(c *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrPasswordResetRequired) {
+		return false
+	}
+
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusForbidden,
+		Code:    "PASSWORD_RESET_REQUIRED",
+		Message: "You must reset your password before logging in",
+	})
+	return true
+}
+
+// respondPasswordSetupRequiredError writes a 403 when err means the user
+// was created by staff and has not set up their password yet, and reports
+// whether it did
+func respondPasswordSetupRequiredError(c *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrPasswordSetupRequired) {
+		return false
+	}
+
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusForbidden,
+		Code:    "PASSWORD_SETUP_REQUIRED",
+		Message: "Set up your password from the link emailed to you before logging in",
+	})
+	return true
+}
+
+// respondPasswordNotSetError writes a 409 when err means the user signed up
+// with an OAuth provider and has no password yet, and reports whether it did
+func respondPasswordNotSetError(c *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrPasswordNotSet) {
+		return false
+	}
+
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusConflict,
+		Code:    "PASSWORD_NOT_SET",
+		Message: "Your account has no password; set one with a password reset first",
+	})
+	return true
+}
+
+// respondPasswordPolicyError writes a 400 listing every failed password rule
+// when err is a policy violation, and reports whether it did
+func respondPasswordPolicyError(c *gin.Context, field string, err error) bool {
+	var violationErr *passwordpolicy.ViolationError
+	if !errors.As(err, &violationErr) {
+		return false
+	}
+
+	details := make([]gin.H, 0, len(violationErr.Violations))
+	for _, v := range violationErr.Violations {
+		details = append(details, gin.H{
+			"field":   field,
+			"rule":    v.Rule,
+			"message": v.Message,
+		})
+	}
+
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusBadRequest,
+		Code:    "WEAK_PASSWORD",
+		Message: "Password does not meet the password policy",
+		Details: details,
+	})
+	return true
 }
@@ -0,0 +1,548 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"microbank/client-service/internal/authcookie"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordhash"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+	"microbank/client-service/internal/services"
+)
+
+const testPassword = "password123"
+
+func newTestUser(t *testing.T, email string) *models.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	return &models.User{
+		ID:           uuid.New(),
+		Email:        email,
+		Name:         "Test User",
+		PasswordHash: string(hash),
+	}
+}
+
+func newAuthRouter(userRepo *fakeUserRepo, refreshRepo *fakeRefreshTokenRepo) *gin.Engine {
+	return newAuthRouterWithBanking(userRepo, refreshRepo, &fakeBankingClient{})
+}
+
+func newAuthRouterWithBanking(userRepo *fakeUserRepo, refreshRepo *fakeRefreshTokenRepo, banking *fakeBankingClient) *gin.Engine {
+	return newAuthRouterWithRevocations(userRepo, refreshRepo, banking, revocation.NewMemoryStore())
+}
+
+func newAuthRouterWithRevocations(userRepo *fakeUserRepo, refreshRepo *fakeRefreshTokenRepo, banking *fakeBankingClient, revocations revocation.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	hasher := passwordhash.NewBcrypt(bcrypt.MinCost)
+	authService := services.NewAuthService(userRepo, refreshRepo, &fakeLoginEventRepo{}, passwordpolicy.Default(), hasher, nil, banking, testDeletionRetention, nil, nil, testTokens, revocations, 0, nil)
+	handler := NewAuthHandler(authService, nil, nil)
+
+	r := gin.New()
+	r.POST("/auth/register", handler.Register)
+	r.POST("/auth/login", handler.Login)
+	r.POST("/auth/refresh", handler.RefreshToken)
+	r.GET("/auth/validate", middleware.AuthMiddleware(testTokens, userRepo, revocations), handler.ValidateToken)
+	return r
+}
+
+// postJSON sends body to path and returns the recorder and decoded error code
+func postJSON(t *testing.T, r *gin.Engine, path string, body interface{}) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("failed to marshal body: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, decodeErrorCode(t, w)
+}
+
+// decodeData decodes the data of a JSON response into v
+func decodeData(w *httptest.ResponseRecorder, v interface{}) error {
+	envelope := struct {
+		Data interface{} `json:"data"`
+	}{Data: v}
+	return json.Unmarshal(w.Body.Bytes(), &envelope)
+}
+
+// decodeErrorCode returns the error code from a JSON error response, or ""
+func decodeErrorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var response struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return response.Error.Code
+}
+
+func TestAuthHandler_Register(t *testing.T) {
+	existing := newTestUser(t, "existing@example.com")
+	deletedAt := time.Now().Add(-time.Hour)
+	deleted := newTestUser(t, "deleted@example.com")
+	deleted.DeletedAt = &deletedAt
+	r := newAuthRouter(newFakeUserRepo(existing, deleted), newFakeRefreshTokenRepo())
+
+	tests := []struct {
+		name       string
+		email      string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "new user", email: "new@example.com", wantStatus: http.StatusCreated},
+		{name: "existing email", email: existing.Email, wantStatus: http.StatusConflict, wantCode: "USER_EXISTS"},
+		{name: "deleted account email", email: deleted.Email, wantStatus: http.StatusConflict, wantCode: "EMAIL_PENDING_DELETION"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, code := postJSON(t, r, "/auth/register", gin.H{
+				"email":    tt.email,
+				"name":     "New User",
+				"password": "Str0ngEnough",
+			})
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+			}
+		})
+	}
+}
+
+func TestAuthHandler_Login(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	active := newTestUser(t, "active@example.com")
+	suspended := newTestUser(t, "suspended@example.com")
+	suspended.IsBlacklisted = true
+	until := time.Now().Add(24 * time.Hour)
+	temporary := newTestUser(t, "temporary@example.com")
+	temporary.IsBlacklisted = true
+	temporary.BlacklistExpiresAt = &until
+	deletedAt := time.Now().Add(-time.Hour)
+	deleted := newTestUser(t, "deleted@example.com")
+	deleted.DeletedAt = &deletedAt
+	r := newAuthRouter(newFakeUserRepo(active, suspended, temporary, deleted), newFakeRefreshTokenRepo())
+
+	tests := []struct {
+		name       string
+		email      string
+		password   string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "valid credentials", email: active.Email, password: testPassword, wantStatus: http.StatusOK},
+		{name: "wrong password", email: active.Email, password: "wrong", wantStatus: http.StatusUnauthorized, wantCode: "INVALID_CREDENTIALS"},
+		{name: "unknown email", email: "nobody@example.com", password: testPassword, wantStatus: http.StatusUnauthorized, wantCode: "INVALID_CREDENTIALS"},
+		{name: "suspended account", email: suspended.Email, password: testPassword, wantStatus: http.StatusForbidden, wantCode: "ACCOUNT_SUSPENDED"},
+		{name: "temporarily suspended account", email: temporary.Email, password: testPassword, wantStatus: http.StatusForbidden, wantCode: "ACCOUNT_SUSPENDED_TEMPORARILY"},
+		{name: "deleted account", email: deleted.Email, password: testPassword, wantStatus: http.StatusForbidden, wantCode: "ACCOUNT_DELETED"},
+		{name: "deleted account wrong password", email: deleted.Email, password: "wrong", wantStatus: http.StatusUnauthorized, wantCode: "INVALID_CREDENTIALS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, code := postJSON(t, r, "/auth/login", gin.H{"email": tt.email, "password": tt.password})
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+			}
+		})
+	}
+}
+
+func TestAuthHandler_ValidateToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		mutate     func(user *models.User, userRepo *fakeUserRepo)
+		query      string
+		wantStatus int
+		wantCode   string
+		wantStale  []string
+	}{
+		{name: "unchanged user", mutate: func(*models.User, *fakeUserRepo) {}, wantStatus: http.StatusOK, wantStale: []string{}},
+		{name: "name changed since login", mutate: func(u *models.User, _ *fakeUserRepo) { u.Name = "Renamed User" }, wantStatus: http.StatusOK, wantStale: []string{"name"}},
+		{name: "admin granted without a new token version", mutate: func(u *models.User, _ *fakeUserRepo) { u.IsAdmin = true }, wantStatus: http.StatusOK, wantStale: []string{"is_admin"}},
+		{name: "blacklisted since login", mutate: func(u *models.User, _ *fakeUserRepo) { u.IsBlacklisted = true }, wantStatus: http.StatusForbidden, wantCode: "ACCOUNT_SUSPENDED"},
+		{name: "deleted since login", mutate: func(u *models.User, r *fakeUserRepo) { delete(r.users, u.ID) }, wantStatus: http.StatusUnauthorized, wantCode: "TOKEN_REVOKED"},
+		{name: "service view", mutate: func(*models.User, *fakeUserRepo) {}, query: "?view=service", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, "client@example.com")
+			userRepo := newFakeUserRepo(user)
+			r := newAuthRouter(userRepo, newFakeRefreshTokenRepo())
+
+			w, _ := postJSON(t, r, "/auth/login", gin.H{"email": user.Email, "password": testPassword})
+			var login struct {
+				Tokens struct {
+					AccessToken string `json:"access_token"`
+				} `json:"tokens"`
+			}
+			if err := decodeData(w, &login); err != nil {
+				t.Fatalf("failed to decode login response: %v", err)
+			}
+
+			tt.mutate(user, userRepo)
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/validate"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+login.Tokens.AccessToken)
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus || decodeErrorCode(t, w) != tt.wantCode {
+				t.Fatalf("Expected %d %q, got %d: %s", tt.wantStatus, tt.wantCode, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Valid       bool     `json:"valid"`
+				UserID      string   `json:"user_id"`
+				ClaimsStale bool     `json:"claims_stale"`
+				StaleClaims []string `json:"stale_claims"`
+				User        *struct {
+					Name    string `json:"name"`
+					IsAdmin bool   `json:"is_admin"`
+				} `json:"user"`
+			}
+			if err := decodeData(w, &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if tt.query != "" {
+				if !response.Valid || response.UserID != user.ID.String() || response.User != nil {
+					t.Errorf("Expected the lightweight service response, got %s", w.Body.String())
+				}
+				return
+			}
+			if response.User == nil || response.User.Name != user.Name || response.User.IsAdmin != user.IsAdmin {
+				t.Errorf("Expected the current user state, got %s", w.Body.String())
+			}
+			if response.ClaimsStale != (len(tt.wantStale) > 0) || strings.Join(response.StaleClaims, ",") != strings.Join(tt.wantStale, ",") {
+				t.Errorf("Expected stale claims %v, got %v (claims_stale %v)", tt.wantStale, response.StaleClaims, response.ClaimsStale)
+			}
+		})
+	}
+}
+
+func TestAuthHandler_RefreshToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	active := newTestUser(t, "active@example.com")
+	suspended := newTestUser(t, "suspended@example.com")
+	suspended.IsBlacklisted = true
+
+	refreshRepo := newFakeRefreshTokenRepo()
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: active.ID, TokenHash: hashedToken("valid"), ExpiresAt: time.Now().Add(time.Hour)})
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: active.ID, TokenHash: hashedToken("expired"), ExpiresAt: time.Now().Add(-time.Hour)})
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: suspended.ID, TokenHash: hashedToken("suspended"), ExpiresAt: time.Now().Add(time.Hour)})
+	r := newAuthRouter(newFakeUserRepo(active, suspended), refreshRepo)
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "valid token", token: "valid", wantStatus: http.StatusOK},
+		{name: "unknown token", token: "unknown", wantStatus: http.StatusUnauthorized, wantCode: "INVALID_REFRESH_TOKEN"},
+		{name: "expired token", token: "expired", wantStatus: http.StatusUnauthorized, wantCode: "REFRESH_TOKEN_EXPIRED"},
+		{name: "suspended account", token: "suspended", wantStatus: http.StatusForbidden, wantCode: "ACCOUNT_SUSPENDED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, code := postJSON(t, r, "/auth/refresh", gin.H{"refresh_token": tt.token})
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+			}
+		})
+	}
+}
+
+func TestAuthHandler_RefreshTokenGuard(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	gin.SetMode(gin.TestMode)
+
+	user := newTestUser(t, "active@example.com")
+	refreshRepo := newFakeRefreshTokenRepo()
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: user.ID, TokenHash: hashedToken("expired"), ExpiresAt: time.Now().Add(-time.Hour)})
+
+	hasher := passwordhash.NewBcrypt(bcrypt.MinCost)
+	handler := NewAuthHandler(services.NewAuthService(newFakeUserRepo(user), refreshRepo, &fakeLoginEventRepo{}, passwordpolicy.Default(), hasher, nil, &fakeBankingClient{}, testDeletionRetention, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil), nil, nil)
+	guard := middleware.NewRefreshGuard(middleware.RefreshGuardConfig{Threshold: 2, Window: time.Minute, BlockDuration: time.Hour})
+	r := gin.New()
+	r.POST("/auth/refresh", middleware.GuardRefresh(guard, nil), handler.RefreshToken)
+
+	// Retrying with an expired token is never counted
+	for i := 0; i < 3; i++ {
+		if w, code := postJSON(t, r, "/auth/refresh", gin.H{"refresh_token": "expired"}); code != "REFRESH_TOKEN_EXPIRED" {
+			t.Fatalf("Expected REFRESH_TOKEN_EXPIRED, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, code := postJSON(t, r, "/auth/refresh", gin.H{"refresh_token": "guess"}); code != "INVALID_REFRESH_TOKEN" {
+			t.Fatalf("Expected INVALID_REFRESH_TOKEN, got %q", code)
+		}
+	}
+
+	w, code := postJSON(t, r, "/auth/refresh", gin.H{"refresh_token": "expired"})
+	if w.Code != http.StatusTooManyRequests || code != "REFRESH_BLOCKED" {
+		t.Errorf("Expected 429 REFRESH_BLOCKED once the threshold is reached, got %d %q", w.Code, code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+	if blocks := guard.Blocks(); len(blocks) != 1 || blocks[0].InvalidAttempts != 2 {
+		t.Errorf("Expected one block after 2 invalid attempts, got %+v", blocks)
+	}
+}
+
+func TestAuthHandler_DeleteAccount(t *testing.T) {
+	tests := []struct {
+		name        string
+		password    string
+		admin       bool
+		noPassword  bool
+		balance     float64
+		bankingErr  error
+		wantStatus  int
+		wantCode    string
+		wantDeleted bool
+	}{
+		{name: "zero balance", password: testPassword, wantStatus: http.StatusOK, wantDeleted: true},
+		{name: "wrong password", password: "wrong", wantStatus: http.StatusForbidden, wantCode: "INVALID_CURRENT_PASSWORD"},
+		{name: "no password", password: testPassword, noPassword: true, wantStatus: http.StatusConflict, wantCode: "PASSWORD_NOT_SET"},
+		{name: "admin", password: testPassword, admin: true, wantStatus: http.StatusConflict, wantCode: "ADMIN_SELF_DELETION"},
+		{name: "remaining balance", password: testPassword, balance: 10, wantStatus: http.StatusConflict, wantCode: "ACCOUNT_BALANCE_NOT_ZERO"},
+		{name: "banking-service down", password: testPassword, bankingErr: errors.New("connection refused"), wantStatus: http.StatusBadGateway, wantCode: "BANKING_SERVICE_UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, "leaving@example.com")
+			user.IsAdmin = tt.admin
+			if tt.noPassword {
+				user.PasswordHash = ""
+			}
+			userRepo := newFakeUserRepo(user)
+			refreshRepo := newFakeRefreshTokenRepo()
+			refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: user.ID, TokenHash: "session", ExpiresAt: time.Now().Add(time.Hour)})
+			banking := &fakeBankingClient{balance: tt.balance, err: tt.bankingErr}
+
+			hasher := passwordhash.NewBcrypt(bcrypt.MinCost)
+			handler := NewAuthHandler(services.NewAuthService(userRepo, refreshRepo, &fakeLoginEventRepo{}, passwordpolicy.Default(), hasher, nil, banking, testDeletionRetention, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil), nil, nil)
+			r := gin.New()
+			r.DELETE("/profile", func(c *gin.Context) {
+				c.Set("user_id", user.ID.String())
+				handler.DeleteAccount(c)
+			})
+
+			payload, _ := json.Marshal(gin.H{"password": tt.password})
+			req := httptest.NewRequest(http.MethodDelete, "/profile", bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if code := decodeErrorCode(t, w); code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+			}
+
+			if deleted := user.DeletedAt != nil; deleted != tt.wantDeleted {
+				t.Fatalf("Expected deleted=%v, got %v", tt.wantDeleted, deleted)
+			}
+			if !tt.wantDeleted {
+				if n, _ := refreshRepo.CountActiveByUserID(user.ID); n != 1 {
+					t.Errorf("Expected the session to be kept, %d remain", n)
+				}
+				return
+			}
+			if !user.SelfDeleted {
+				t.Error("Expected the deletion to be marked as self-requested")
+			}
+			if n, _ := refreshRepo.CountActiveByUserID(user.ID); n != 0 {
+				t.Errorf("Expected sessions to be revoked, %d remain", n)
+			}
+			if len(banking.notified) != 1 || banking.notified[0] != user.ID {
+				t.Errorf("Expected banking-service to be notified about %s, got %v", user.ID, banking.notified)
+			}
+		})
+	}
+}
+
+func TestAuthHandler_LoginCancelsSelfDeletion(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	tests := []struct {
+		name          string
+		deletedAgo    time.Duration
+		bankingErr    error
+		wantStatus    int
+		wantCode      string
+		wantCancelled bool
+	}{
+		{name: "within grace period", deletedAgo: 24 * time.Hour, wantStatus: http.StatusOK, wantCancelled: true},
+		{name: "grace period over", deletedAgo: testDeletionRetention + time.Hour, wantStatus: http.StatusForbidden, wantCode: "ACCOUNT_DELETED"},
+		{name: "banking-service down", deletedAgo: time.Hour, bankingErr: errors.New("connection refused"), wantStatus: http.StatusBadGateway, wantCode: "BANKING_SERVICE_UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deletedAt := time.Now().Add(-tt.deletedAgo)
+			user := newTestUser(t, "returning@example.com")
+			user.DeletedAt = &deletedAt
+			user.SelfDeleted = true
+			banking := &fakeBankingClient{err: tt.bankingErr}
+			r := newAuthRouterWithBanking(newFakeUserRepo(user), newFakeRefreshTokenRepo(), banking)
+
+			w, code := postJSON(t, r, "/auth/login", gin.H{"email": user.Email, "password": testPassword})
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+			}
+
+			if cancelled := user.DeletedAt == nil; cancelled != tt.wantCancelled {
+				t.Errorf("Expected cancelled=%v, got %v", tt.wantCancelled, cancelled)
+			}
+			if tt.wantCancelled && (len(banking.restored) != 1 || banking.restored[0] != user.ID) {
+				t.Errorf("Expected banking-service to be notified about %s, got %v", user.ID, banking.restored)
+			}
+		})
+	}
+}
+
+func TestAuthHandler_CookieMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := newTestUser(t, "client@example.com")
+	userRepo := newFakeUserRepo(user)
+	refreshRepo := newFakeRefreshTokenRepo()
+	revocations := revocation.NewMemoryStore()
+	authService := services.NewAuthService(userRepo, refreshRepo, &fakeLoginEventRepo{}, passwordpolicy.Default(), passwordhash.NewBcrypt(bcrypt.MinCost), nil, nil, testDeletionRetention, nil, nil, testTokens, revocations, 0, nil)
+	handler := NewAuthHandler(authService, nil, &authcookie.Config{Mode: authcookie.ModeRequest, AccessToken: true, Secure: true, SameSite: http.SameSiteStrictMode})
+
+	r := gin.New()
+	r.POST("/auth/login", handler.Login)
+	r.POST("/auth/refresh", middleware.CSRF(), handler.RefreshToken)
+	r.POST("/auth/logout", middleware.CSRF(), handler.Logout)
+	r.GET("/protected", middleware.CSRF(), middleware.AuthMiddleware(testTokens, userRepo, revocations), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	type loginTokens struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		CSRFToken    string `json:"csrf_token"`
+	}
+	login := func(useCookies bool) (*httptest.ResponseRecorder, loginTokens) {
+		w, _ := postJSON(t, r, "/auth/login", gin.H{"email": user.Email, "password": testPassword, "use_cookies": useCookies})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Login failed with status %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Tokens loginTokens `json:"tokens"`
+		}
+		if err := decodeData(w, &response); err != nil {
+			t.Fatalf("failed to decode login response: %v", err)
+		}
+		return w, response.Tokens
+	}
+	// send replays the given cookies, as a browser would
+	send := func(method, path string, cookies []*http.Cookie, csrfToken string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+		if csrfToken != "" {
+			req.Header.Set(authcookie.CSRFHeader, csrfToken)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Body mode is unchanged for clients that do not ask for cookies
+	w, tokens := login(false)
+	if len(w.Result().Cookies()) != 0 || tokens.AccessToken == "" || tokens.RefreshToken == "" || tokens.CSRFToken != "" {
+		t.Errorf("Expected tokens in the body and no cookies, got %+v and %v", tokens, w.Result().Cookies())
+	}
+	if w, _ := postJSON(t, r, "/auth/logout", gin.H{"refresh_token": tokens.RefreshToken}); w.Code != http.StatusOK {
+		t.Errorf("Expected body logout to succeed without a CSRF token, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := refreshRepo.GetByToken(tokens.RefreshToken); err == nil {
+		t.Error("Expected logout to delete the refresh token")
+	}
+
+	// Cookie mode keeps every token out of the body
+	w, tokens = login(true)
+	cookies := w.Result().Cookies()
+	if tokens.AccessToken != "" || tokens.RefreshToken != "" || tokens.CSRFToken == "" {
+		t.Errorf("Expected only the CSRF token in the body, got %+v", tokens)
+	}
+	if len(cookies) != 3 {
+		t.Fatalf("Expected refresh, access and CSRF cookies, got %v", cookies)
+	}
+
+	if w := send(http.MethodGet, "/protected", cookies, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the access token cookie to authenticate, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/auth/refresh", cookies, ""); w.Code != http.StatusForbidden || decodeErrorCode(t, w) != "INVALID_CSRF_TOKEN" {
+		t.Errorf("Expected 403 INVALID_CSRF_TOKEN without the CSRF header, got %d: %s", w.Code, w.Body.String())
+	}
+	w = send(http.MethodPost, "/auth/refresh", cookies, tokens.CSRFToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected cookie refresh to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	if refreshed := w.Result().Cookies(); len(refreshed) != 1 || refreshed[0].Name != authcookie.AccessTokenCookie {
+		t.Errorf("Expected the refresh to replace the access token cookie, got %v", refreshed)
+	}
+
+	w = send(http.MethodPost, "/auth/logout", cookies, tokens.CSRFToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected cookie logout to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge >= 0 {
+			t.Errorf("Expected the %s cookie to be cleared, got %+v", cookie.Name, cookie)
+		}
+	}
+	if w := send(http.MethodPost, "/auth/refresh", cookies, tokens.CSRFToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the logged out refresh token to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bindingTestItem struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type bindingTestRequest struct {
+	Email  string            `json:"email" binding:"required,email"`
+	Name   string            `json:"name" binding:"omitempty,min=2"`
+	Amount float64           `json:"amount" binding:"omitempty,gt=0"`
+	Items  []bindingTestItem `json:"items" binding:"omitempty,max=2,dive"`
+}
+
+type fieldErrorResponse struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func newBindingRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/bind", func(c *gin.Context) {
+		var request bindingTestRequest
+		if !bindJSON(c, &request) {
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+// postRawJSON posts body as is and returns the response and any field errors
+func postRawJSON(t *testing.T, r *gin.Engine, body string) (*httptest.ResponseRecorder, []fieldErrorResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/bind", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response struct {
+		Error struct {
+			Code    string               `json:"code"`
+			Details []fieldErrorResponse `json:"details"`
+		} `json:"error"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code == http.StatusBadRequest && response.Error.Code != "VALIDATION_ERROR" {
+		t.Errorf("Expected code VALIDATION_ERROR, got %q", response.Error.Code)
+	}
+	return w, response.Error.Details
+}
+
+func TestBindJSON(t *testing.T) {
+	r := newBindingRouter()
+
+	tests := []struct {
+		name string
+		body string
+		want []fieldErrorResponse
+	}{
+		{"valid", `{"email":"a@example.com","items":[{"code":"123456"}]}`, nil},
+		{"missing field", `{}`, []fieldErrorResponse{{"email", "required", "is required"}}},
+		{
+			"several invalid fields",
+			`{"email":"nope","name":"A","amount":-1}`,
+			[]fieldErrorResponse{
+				{"email", "email", "must be a valid email address"},
+				{"name", "min", "must be at least 2 characters"},
+				{"amount", "gt", "must be greater than 0"},
+			},
+		},
+		{
+			"too many items",
+			`{"email":"a@example.com","items":[{"code":"123456"},{"code":"123456"},{"code":"123456"}]}`,
+			[]fieldErrorResponse{{"items", "max", "must be at most 2 items"}},
+		},
+		{
+			"nested field",
+			`{"email":"a@example.com","items":[{"code":"123456"},{"code":"12ab"}]}`,
+			[]fieldErrorResponse{{"items[1].code", "len", "must be exactly 6 characters"}},
+		},
+		{"wrong type", `{"email":"a@example.com","amount":"ten"}`, []fieldErrorResponse{{"amount", "type", "must be a number"}}},
+		{"malformed JSON", `{"email":`, []fieldErrorResponse{{"body", "json", "must be valid JSON"}}},
+		{"empty body", ``, []fieldErrorResponse{{"body", "required", "is required"}}},
+		{"unknown fields allowed", `{"email":"a@example.com","extra":true}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, details := postRawJSON(t, r, tt.body)
+			if tt.want == nil {
+				if w.Code != http.StatusNoContent {
+					t.Fatalf("Expected 204, got %d: %s", w.Code, w.Body.String())
+				}
+				return
+			}
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected 400, got %d", w.Code)
+			}
+			if !reflect.DeepEqual(details, tt.want) {
+				t.Errorf("Expected details %+v, got %+v", tt.want, details)
+			}
+		})
+	}
+}
+
+func TestBindJSON_RejectUnknownFields(t *testing.T) {
+	defer RejectUnknownFields(false)
+	r := newBindingRouter()
+
+	RejectUnknownFields(true)
+
+	w, details := postRawJSON(t, r, `{"email":"a@example.com","extra":true}`)
+	want := []fieldErrorResponse{{"extra", "unknown", "is not an accepted field"}}
+	if w.Code != http.StatusBadRequest || !reflect.DeepEqual(details, want) {
+		t.Errorf("Expected 400 with %+v, got %d %+v", want, w.Code, details)
+	}
+}
+
+func TestBindJSON_BodyTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/bind", func(c *gin.Context) {
+		// As middleware.JSONBody does for a body sent without a length
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 16)
+		var request bindingTestRequest
+		if !bindJSON(c, &request) {
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/bind", strings.NewReader(`{"email":"jane@example.com"}`))
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), "PAYLOAD_TOO_LARGE") {
+		t.Errorf("Expected 413 PAYLOAD_TOO_LARGE, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// DownloadHandler hands out generated files, such as exports, through
+// signed download links
+type DownloadHandler struct {
+	downloadService *services.DownloadService
+}
+
+// NewDownloadHandler creates a new download handler
+func NewDownloadHandler(downloadService *services.DownloadService) *DownloadHandler {
+	return &DownloadHandler{
+		downloadService: downloadService,
+	}
+}
+
+// Download sends the file a download link points to as an attachment. The
+// token in the link is the credential, so the request needs no session.
+// Links that have expired or been used up get 410 with the endpoint that
+// generates the file again.
+func (h *DownloadHandler) Download(c *gin.Context) {
+	// The token must not leak through caches or the Referer of links in
+	// the file
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+
+	artifact, data, err := h.downloadService.Download(c.Request.Context(), c.Param("token"), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		var gone *services.DownloadGoneError
+		switch {
+		case errors.Is(err, services.ErrInvalidDownloadToken):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "DOWNLOAD_NOT_FOUND",
+				Message: "Download not found",
+			})
+		case errors.As(err, &gone):
+			details := gin.H{}
+			if path := services.DownloadReRequestPath(gone.Kind); path != "" {
+				details["kind"] = gone.Kind
+				details["request_again"] = gin.H{"method": http.MethodPost, "path": path}
+			}
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusGone,
+				Code:    "DOWNLOAD_EXPIRED",
+				Message: "This download link has expired or been used up; request the file again",
+				Details: details,
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "DOWNLOAD_FAILED",
+				Message: "Failed to download file",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Send the file. Browsers must not guess a different type for it.
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": artifact.FileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, artifact.ContentType, data)
+}
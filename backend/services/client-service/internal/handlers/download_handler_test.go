@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/blobstore"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+)
+
+func TestDownloadHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := newTestUser(t, "admin@example.com")
+	userRepo := newFakeUserRepo(admin)
+	store, err := blobstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create blob store: %v", err)
+	}
+	downloadService := services.NewDownloadService(&fakeDownloadArtifactRepo{}, userRepo, store, "q3Jx9VbT0mWc7LkZpR2sYd5HnE8aFu4G", time.Hour, 1)
+	handler := NewDownloadHandler(downloadService)
+
+	r := gin.New()
+	r.GET("/downloads/:token", handler.Download)
+
+	_, link, err := downloadService.Publish(context.Background(), admin.ID, models.DownloadKindClientsExport, "clients.csv", "text/csv", []byte("id\n1\n"))
+	if err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	path := "/downloads/" + link[strings.LastIndex(link, "/")+1:]
+
+	// Unknown tokens
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/downloads/not-a-token", nil))
+	if w.Code != http.StatusNotFound || decodeErrorCode(t, w) != "DOWNLOAD_NOT_FOUND" {
+		t.Errorf("Expected 404 DOWNLOAD_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The first download gets the file
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code != http.StatusOK || w.Body.String() != "id\n1\n" {
+		t.Fatalf("Expected the file, got %d: %s", w.Code, w.Body.String())
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename=clients.csv` {
+		t.Errorf("Unexpected Content-Disposition %q", cd)
+	}
+	if w.Header().Get("Cache-Control") != "no-store" || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("Expected the download not to be cached or sniffed, got %v", w.Header())
+	}
+
+	// Replays are gone, with the way to ask for the export again
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code != http.StatusGone || decodeErrorCode(t, w) != "DOWNLOAD_EXPIRED" {
+		t.Fatalf("Expected 410 DOWNLOAD_EXPIRED, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"path":"/api/v1/admin/clients/export"`) {
+		t.Errorf("Expected the export endpoint in the details, got %s", w.Body.String())
+	}
+}
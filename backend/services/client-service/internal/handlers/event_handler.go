@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+	"microbank/pkg/events"
+	"microbank/pkg/httpx"
+)
+
+// EventConsumer applies the events it acts on and reports whether it acted
+// on event's type
+type EventConsumer interface {
+	Handle(event events.Event) (bool, error)
+}
+
+// EventHandler receives events published by other services
+type EventHandler struct {
+	consumers []EventConsumer
+}
+
+// NewEventHandler creates a new event handler that offers each event to
+// every consumer, in order
+func NewEventHandler(consumers ...EventConsumer) *EventHandler {
+	return &EventHandler{
+		consumers: consumers,
+	}
+}
+
+// HandleEvent applies an event published by another service (internal
+// only). Event types this service does not act on are acknowledged and
+// ignored. A payload version this service does not understand yet is
+// refused with 422 so the publisher retries it after an upgrade.
+func (h *EventHandler) HandleEvent(c *gin.Context) {
+	var event events.Event
+
+	// Bind and validate request body
+	if !bindJSON(c, &event) {
+		return
+	}
+
+	// Apply the event
+	handled, err := h.handle(event)
+	if err != nil {
+		if errors.Is(err, events.ErrUnsupportedVersion) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "UNSUPPORTED_EVENT_VERSION",
+				Message: "Event version is not supported",
+				Details: middleware.ErrorDetails(c, err),
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "HANDLE_EVENT_FAILED",
+			Message: "Failed to handle event",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message":  "Event received",
+		"event_id": event.ID,
+		"handled":  handled,
+	})
+}
+
+// handle offers event to every consumer, stopping at the first error, and
+// reports whether any of them acted on it
+func (h *EventHandler) handle(event events.Event) (bool, error) {
+	handled := false
+	for _, consumer := range h.consumers {
+		consumerHandled, err := consumer.Handle(event)
+		if err != nil {
+			return false, err
+		}
+		handled = handled || consumerHandled
+	}
+	return handled, nil
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/events"
+	"microbank/pkg/mailer"
+)
+
+func TestEventHandler_HandleEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := newTestUser(t, "client@example.com")
+	consumer := services.NewSavingsGoalEventConsumer(newFakeUserRepo(user), mailer.NewLogSender(log.New(io.Discard, "", 0)), services.NewNotificationPreferenceService(&fakeNotificationPreferenceRepo{}))
+	handler := NewEventHandler(consumer)
+	r := gin.New()
+	r.POST("/internal/events", handler.HandleEvent)
+
+	completed, err := events.New(events.TypeSavingsGoalCompleted, events.SourceBankingService, events.SavingsGoalCompleted{
+		GoalID:       uuid.New(),
+		UserID:       user.ID,
+		Name:         "New laptop",
+		TargetAmount: 1200,
+		CompletedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("events.New returned error: %v", err)
+	}
+	future := completed
+	future.Version = 99
+
+	tests := []struct {
+		name        string
+		event       events.Event
+		wantStatus  int
+		wantHandled bool
+	}{
+		{name: "completed goal", event: completed, wantStatus: http.StatusOK, wantHandled: true},
+		{name: "unknown type", event: events.Event{ID: uuid.New(), Type: "account.opened", Version: 1, Source: events.SourceBankingService, OccurredAt: time.Now(), Payload: json.RawMessage(`{}`)}, wantStatus: http.StatusOK},
+		{name: "unsupported version", event: future, wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.event)
+			req := httptest.NewRequest(http.MethodPost, "/internal/events", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var response struct {
+				Data struct {
+					Handled bool `json:"handled"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Data.Handled != tt.wantHandled {
+				t.Errorf("Expected handled %v, got %v", tt.wantHandled, response.Data.Handled)
+			}
+		})
+	}
+}
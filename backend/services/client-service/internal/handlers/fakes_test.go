@@ -0,0 +1,1163 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/authmw"
+	sharedjwt "microbank/pkg/jwt"
+)
+
+// hashedToken returns the hash a refresh token is stored under
+func hashedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// testTokens signs access tokens with the secret tests set as JWT_SECRET
+var testTokens = sharedjwt.NewTokenManager("test-secret", 15*time.Minute, 7*24*time.Hour)
+
+// fakeUserRepo is an in-memory UserRepository. Methods not needed by the
+// handler tests panic through the embedded nil interface.
+type fakeUserRepo struct {
+	repository.UserRepository
+	mu     sync.Mutex
+	users  map[uuid.UUID]*models.User
+	audits []models.AuditLogEntry
+
+	// invitations is where CreateInvitedUser marks invitations used
+	invitations *fakeInvitationRepo
+	// blacklist is where blacklist status changes record their history
+	blacklist *fakeBlacklistRepo
+}
+
+func newFakeUserRepo(users ...*models.User) *fakeUserRepo {
+	r := &fakeUserRepo{users: make(map[uuid.UUID]*models.User)}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+// setAdmin grants or revokes u's admin role the way the database stores it
+func setAdmin(u *models.User, admin bool) {
+	u.IsAdmin = admin
+	u.Roles = []string{}
+	if admin {
+		u.Roles = []string{authmw.RoleAdmin}
+	}
+}
+
+func (r *fakeUserRepo) CreateUser(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = user
+	return nil
+}
+
+// CreateUserByAdmin creates the user and keeps the audit entry
+func (r *fakeUserRepo) CreateUserByAdmin(user *models.User, audit *models.AuditLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.CreatedAt = time.Now()
+	r.users[user.ID] = user
+	r.recordAudit(audit)
+	return nil
+}
+
+// CreateInvitedUser creates the user and marks the invitation used in
+// invitations, failing like the real repository if it is no longer usable
+func (r *fakeUserRepo) CreateInvitedUser(user *models.User, invitationID uuid.UUID) error {
+	if err := r.invitations.markUsed(invitationID, user.ID); err != nil {
+		return err
+	}
+	return r.CreateUser(user)
+}
+
+func (r *fakeUserRepo) UserExists(email string) (bool, error) {
+	_, err := r.GetUserByEmail(email)
+	return err == nil, nil
+}
+
+func (r *fakeUserRepo) GetUserByID(id uuid.UUID) (*models.User, error) {
+	return r.findByID(id, false)
+}
+
+func (r *fakeUserRepo) GetUserByEmail(email string) (*models.User, error) {
+	return r.findByEmail(email, false)
+}
+
+func (r *fakeUserRepo) GetDeletedUserByID(id uuid.UUID) (*models.User, error) {
+	return r.findByID(id, true)
+}
+
+func (r *fakeUserRepo) GetDeletedUserByEmail(email string) (*models.User, error) {
+	return r.findByEmail(email, true)
+}
+
+func (r *fakeUserRepo) findByID(id uuid.UUID, deleted bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[id]; ok && (u.DeletedAt != nil) == deleted {
+		return u, nil
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (r *fakeUserRepo) findByEmail(email string, deleted bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Email == email && (u.DeletedAt != nil) == deleted {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (r *fakeUserRepo) UpdateUser(userID uuid.UUID, profile models.UserProfile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return fmt.Errorf("user not found for update")
+	}
+	if profile.Name != nil {
+		u.Name = *profile.Name
+	}
+	if profile.PhoneNumber != nil {
+		u.PhoneNumber = *profile.PhoneNumber
+	}
+	if profile.DateOfBirth != nil {
+		u.DateOfBirth = nil
+		if dob, err := time.Parse(models.DateLayout, *profile.DateOfBirth); err == nil {
+			u.DateOfBirth = &dob
+		}
+	}
+	if profile.TimeZone != nil {
+		u.TimeZone = *profile.TimeZone
+	}
+	if a := profile.Address; a != nil {
+		for _, f := range []struct {
+			value  *string
+			target *string
+		}{{a.Line1, &u.AddressLine1}, {a.City, &u.AddressCity}, {a.Country, &u.AddressCountry}} {
+			if f.value != nil {
+				*f.target = *f.value
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateLastLogin(userID uuid.UUID, at time.Time) error {
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateBlacklistStatus(userID uuid.UUID, isBlacklisted bool, reason string, expiresAt *time.Time, entry *models.BlacklistEntry, audit *models.AuditLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u := r.users[userID]
+	u.IsBlacklisted, u.BlacklistReason, u.BlacklistExpiresAt = isBlacklisted, reason, expiresAt
+	u.TokenVersion++
+	r.recordBlacklistEntry(entry)
+	r.recordAudit(audit)
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateBlacklistStatusBatch(userIDs []uuid.UUID, isBlacklisted bool, reason string, expiresAt *time.Time, history map[uuid.UUID]*models.BlacklistEntry, audits map[uuid.UUID]*models.AuditLogEntry) (map[uuid.UUID]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := make(map[uuid.UUID]string, len(userIDs))
+	for _, id := range userIDs {
+		u, ok := r.users[id]
+		switch {
+		case !ok || u.DeletedAt != nil:
+			results[id] = models.BlacklistBatchNotFound
+		case u.IsBlacklisted == isBlacklisted && isBlacklisted:
+			results[id] = models.BlacklistBatchAlreadyBlacklisted
+		case u.IsBlacklisted == isBlacklisted:
+			results[id] = models.BlacklistBatchNotBlacklisted
+		default:
+			u.IsBlacklisted, u.BlacklistReason, u.BlacklistExpiresAt = isBlacklisted, reason, expiresAt
+			u.TokenVersion++
+			r.recordBlacklistEntry(history[id])
+			r.recordAudit(audits[id])
+			results[id] = models.BlacklistBatchOK
+		}
+	}
+	return results, nil
+}
+
+func (r *fakeUserRepo) LiftExpiredBlacklists(now time.Time) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var lifted []uuid.UUID
+	for _, u := range r.users {
+		if u.IsBlacklisted && u.BlacklistExpiresAt != nil && !u.BlacklistExpiresAt.After(now) {
+			u.IsBlacklisted, u.BlacklistReason, u.BlacklistExpiresAt = false, "", nil
+			u.TokenVersion++
+			r.recordBlacklistEntry(&models.BlacklistEntry{ID: uuid.New(), UserID: u.ID, Action: models.BlacklistActionRemove, Reason: models.BlacklistReasonExpired, CreatedAt: now})
+			lifted = append(lifted, u.ID)
+		}
+	}
+	return lifted, nil
+}
+
+func (r *fakeUserRepo) DeleteUser(id uuid.UUID, selfDeleted bool, audit *models.AuditLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.users[id].DeletedAt = &now
+	r.users[id].SelfDeleted = selfDeleted
+	r.users[id].TokenVersion++
+	r.recordAudit(audit)
+	return nil
+}
+
+func (r *fakeUserRepo) RestoreUser(id uuid.UUID, deletedAfter time.Time, audit *models.AuditLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt == nil || !u.DeletedAt.After(deletedAfter) {
+		return fmt.Errorf("user not found")
+	}
+	u.DeletedAt, u.SelfDeleted = nil, false
+	r.recordAudit(audit)
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateRole(userID uuid.UUID, role string, grant bool, audit *models.AuditLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u := r.users[userID]
+	if role == authmw.RoleAdmin && !grant && !r.hasOtherAdmin(userID) {
+		return repository.ErrLastAdmin
+	}
+	roles := []string{}
+	for _, held := range u.Roles {
+		if held != role {
+			roles = append(roles, held)
+		}
+	}
+	if grant {
+		roles = append(roles, role)
+	}
+	u.Roles = roles
+	if role == authmw.RoleAdmin {
+		u.IsAdmin = grant
+	}
+	u.TokenVersion++
+	r.recordAudit(audit)
+	return nil
+}
+
+// recordBlacklistEntry keeps the history entry written alongside a
+// blacklist status change, when r.blacklist is set. Callers must hold r.mu.
+func (r *fakeUserRepo) recordBlacklistEntry(entry *models.BlacklistEntry) {
+	if entry != nil && r.blacklist != nil {
+		r.blacklist.Create(entry)
+	}
+}
+
+// recordAudit keeps the audit entry written alongside a mutation. Callers
+// must hold r.mu.
+func (r *fakeUserRepo) recordAudit(audit *models.AuditLogEntry) {
+	if audit != nil {
+		r.audits = append(r.audits, *audit)
+	}
+}
+
+func (r *fakeUserRepo) GetTokenVersion(userID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok || u.DeletedAt != nil {
+		return 0, fmt.Errorf("user not found")
+	}
+	return u.TokenVersion, nil
+}
+
+func (r *fakeUserRepo) IncrementTokenVersion(userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[userID].TokenVersion++
+	return nil
+}
+
+func (r *fakeUserRepo) RequirePasswordReset(userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[userID].MustResetPassword = true
+	r.users[userID].TokenVersion++
+	return nil
+}
+
+// hasOtherAdmin reports whether an admin other than userID can sign in.
+// Callers must hold r.mu.
+func (r *fakeUserRepo) hasOtherAdmin(userID uuid.UUID) bool {
+	for _, u := range r.users {
+		if u.ID != userID && u.IsAdmin && u.DeletedAt == nil && !u.IsBlacklisted {
+			return true
+		}
+	}
+	return false
+}
+
+// CountUsers counts active users; stats tests only look at totals
+func (r *fakeUserRepo) CountUsers(today, thisWeek, thisMonth time.Time) (*models.UserCounts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var counts models.UserCounts
+	for _, u := range r.users {
+		if u.DeletedAt != nil {
+			continue
+		}
+		counts.Total++
+		if !u.CreatedAt.Before(today) {
+			counts.NewToday++
+		}
+		if u.IsBlacklisted {
+			counts.Blacklisted++
+		}
+		if u.EmailVerifiedAt != nil {
+			counts.EmailVerified++
+		}
+	}
+	return &counts, nil
+}
+
+// CountSignupsBySource counts each user registered since the given time
+// on its own, in the week starting on since; report tests cover one week
+func (r *fakeUserRepo) CountSignupsBySource(since time.Time) ([]models.SignupCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var counts []models.SignupCount
+	for _, u := range r.users {
+		if !u.CreatedAt.Before(since) {
+			counts = append(counts, models.SignupCount{WeekStart: since, Source: u.RegistrationSource, Count: 1})
+		}
+	}
+	return counts, nil
+}
+
+// fakeRefreshTokenRepo is an in-memory RefreshTokenRepository
+type fakeRefreshTokenRepo struct {
+	repository.RefreshTokenRepository
+	mu     sync.Mutex
+	tokens map[string]*models.RefreshToken
+}
+
+func newFakeRefreshTokenRepo() *fakeRefreshTokenRepo {
+	return &fakeRefreshTokenRepo{tokens: make(map[string]*models.RefreshToken)}
+}
+
+func (r *fakeRefreshTokenRepo) Create(token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) GetByToken(tokenHash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.tokens[tokenHash]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("refresh token not found")
+}
+
+func (r *fakeRefreshTokenRepo) CountActiveByUserID(userID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, t := range r.tokens {
+		if t.UserID == userID && time.Now().Before(t.ExpiresAt) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeRefreshTokenRepo) CountActive() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, t := range r.tokens {
+		if time.Now().Before(t.ExpiresAt) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeRefreshTokenRepo) Delete(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, t := range r.tokens {
+		if t.ID == id {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) DeleteByUserID(userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, t := range r.tokens {
+		if t.UserID == userID {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) DeleteByUserIDs(userIDs []uuid.UUID) error {
+	for _, userID := range userIDs {
+		if err := r.DeleteByUserID(userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) CleanupExpiredTokens(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for hash, t := range r.tokens {
+		if !time.Now().Before(t.ExpiresAt) {
+			delete(r.tokens, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// fakeLoginEventRepo discards login events
+type fakeLoginEventRepo struct {
+	repository.LoginEventRepository
+}
+
+func (r *fakeLoginEventRepo) Create(event *models.LoginEvent) error {
+	return nil
+}
+
+// CountMatchingUsers counts active users, honoring only the is_blacklisted
+// filter
+func (r *fakeUserRepo) CountMatchingUsers(opts models.ListUsersOptions) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.matchingUsers(opts)), nil
+}
+
+// GetUsersAfter walks active users by creation time and ID, honoring only
+// the is_blacklisted filter and the sort direction
+func (r *fakeUserRepo) GetUsersAfter(opts models.ListUsersOptions, after *models.User, limit int) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	matched := r.matchingUsers(opts)
+	less := func(a, b *models.User) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt) != opts.SortDesc
+		}
+		if a.ID == b.ID {
+			return false
+		}
+		return (a.ID.String() < b.ID.String()) != opts.SortDesc
+	}
+	sort.Slice(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+
+	var users []models.User
+	for _, u := range matched {
+		if after != nil && !less(after, u) {
+			continue
+		}
+		if len(users) == limit {
+			break
+		}
+		users = append(users, *u)
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepo) matchingUsers(opts models.ListUsersOptions) []*models.User {
+	var matched []*models.User
+	for _, u := range r.users {
+		if u.DeletedAt != nil || (opts.IsBlacklisted != nil && u.IsBlacklisted != *opts.IsBlacklisted) {
+			continue
+		}
+		matched = append(matched, u)
+	}
+	return matched
+}
+
+func (r *fakeUserRepo) GetUserStatuses(userIDs []uuid.UUID) (map[uuid.UUID]models.UserStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make(map[uuid.UUID]models.UserStatus)
+	for _, id := range userIDs {
+		if u, ok := r.users[id]; ok {
+			statuses[id] = models.UserStatus{
+				UserID:        id,
+				Exists:        true,
+				IsBlacklisted: u.IsBlacklisted,
+				IsAdmin:       u.IsAdmin,
+				IsDeleted:     u.DeletedAt != nil,
+				EmailVerified: u.IsEmailVerified(),
+				TokenVersion:  u.TokenVersion,
+				KYCStatus:     u.KYCStatus,
+				Roles:         append([]string{}, u.Roles...),
+			}
+		}
+	}
+	return statuses, nil
+}
+
+func (r *fakeUserRepo) GetUserDetails(userIDs []uuid.UUID) (map[uuid.UUID]models.UserDetail, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	details := make(map[uuid.UUID]models.UserDetail)
+	for _, id := range userIDs {
+		if u, ok := r.users[id]; ok {
+			details[id] = models.UserDetail{UserID: id, Exists: true, Email: u.Email, Name: u.Name, IsDeleted: u.DeletedAt != nil}
+		}
+	}
+	return details, nil
+}
+
+// listingUserRepo records the options passed to GetAllUsers and reports a
+// fixed total
+type listingUserRepo struct {
+	fakeUserRepo
+	lastOpts models.ListUsersOptions
+	total    int
+}
+
+func (r *listingUserRepo) GetAllUsers(opts models.ListUsersOptions) ([]models.User, int, error) {
+	r.lastOpts = opts
+	return nil, r.total, nil
+}
+
+// fakeBlacklistRepo is an in-memory BlacklistRepository
+type fakeBlacklistRepo struct {
+	mu      sync.Mutex
+	entries []models.BlacklistEntry
+}
+
+func (r *fakeBlacklistRepo) Create(entry *models.BlacklistEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *fakeBlacklistRepo) GetByUserID(userID uuid.UUID) ([]models.BlacklistEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []models.BlacklistEntry
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if r.entries[i].UserID == userID {
+			entries = append(entries, r.entries[i])
+		}
+	}
+	return entries, nil
+}
+
+// fakeAuditLogRepo stores audit log entries and serves them, applying the
+// action filter and paging
+type fakeAuditLogRepo struct {
+	entries    []models.AuditLogEntry
+	lastFilter models.AuditLogFilter
+}
+
+func (r *fakeAuditLogRepo) Create(entry *models.AuditLogEntry) error {
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *fakeAuditLogRepo) List(filter models.AuditLogFilter) ([]models.AuditLogEntry, int, error) {
+	r.lastFilter = filter
+	var matched []models.AuditLogEntry
+	for _, entry := range r.entries {
+		if filter.Action == "" || entry.Action == filter.Action {
+			matched = append(matched, entry)
+		}
+	}
+	total := len(matched)
+	if filter.Offset >= total {
+		return nil, total, nil
+	}
+	end := filter.Offset + filter.Limit
+	if end > total {
+		end = total
+	}
+	return matched[filter.Offset:end], total, nil
+}
+
+// fakeBankingClient records deletion and restore notifications and pushed
+// revocations, reports a fixed balance, stats and statement and can be made
+// to fail
+type fakeBankingClient struct {
+	notified  []uuid.UUID
+	restored  []uuid.UUID
+	revoked   []models.RevokedToken
+	balance   float64
+	stats     models.BankingStats
+	statement models.Statement
+	err       error
+}
+
+func (c *fakeBankingClient) ProvisionAccount(userID uuid.UUID) (bool, error) {
+	return c.err == nil, c.err
+}
+
+func (c *fakeBankingClient) NotifyUserDeleted(userID uuid.UUID) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.notified = append(c.notified, userID)
+	return nil
+}
+
+func (c *fakeBankingClient) NotifyUserRestored(userID uuid.UUID) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.restored = append(c.restored, userID)
+	return nil
+}
+
+func (c *fakeBankingClient) GetUserBalance(userID uuid.UUID) (float64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.balance, nil
+}
+
+func (c *fakeBankingClient) RevokeTokens(tokens []models.RevokedToken) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.revoked = append(c.revoked, tokens...)
+	return nil
+}
+
+func (c *fakeBankingClient) GetStats() (*models.BankingStats, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	stats := c.stats
+	return &stats, nil
+}
+
+func (c *fakeBankingClient) GetStatement(userID uuid.UUID, period string) (*models.Statement, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	statement := c.statement
+	statement.Period = period
+	return &statement, nil
+}
+
+// fakeNotificationPreferenceRepo is an in-memory NotificationPreferenceRepository
+type fakeNotificationPreferenceRepo struct {
+	mu          sync.Mutex
+	preferences []models.NotificationPreference
+}
+
+func (r *fakeNotificationPreferenceRepo) GetByUserID(userID uuid.UUID) ([]models.NotificationPreference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []models.NotificationPreference
+	for _, p := range r.preferences {
+		if p.UserID == userID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeNotificationPreferenceRepo) Upsert(preferences []models.NotificationPreference) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range preferences {
+		replaced := false
+		for i := range r.preferences {
+			if r.preferences[i].UserID == p.UserID && r.preferences[i].EventType == p.EventType {
+				r.preferences[i], replaced = p, true
+			}
+		}
+		if !replaced {
+			r.preferences = append(r.preferences, p)
+		}
+	}
+	return nil
+}
+
+// fakeUserPreferenceRepo is an in-memory UserPreferenceRepository
+type fakeUserPreferenceRepo struct {
+	mu          sync.Mutex
+	preferences map[uuid.UUID]models.UserPreferences
+}
+
+func newFakeUserPreferenceRepo(userIDs ...uuid.UUID) *fakeUserPreferenceRepo {
+	r := &fakeUserPreferenceRepo{preferences: make(map[uuid.UUID]models.UserPreferences)}
+	for _, id := range userIDs {
+		r.preferences[id] = models.UserPreferences{}
+	}
+	return r
+}
+
+func (r *fakeUserPreferenceRepo) GetByUserID(userID uuid.UUID) (*models.UserPreferences, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	preferences, ok := r.preferences[userID]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &preferences, nil
+}
+
+func (r *fakeUserPreferenceRepo) Update(userID uuid.UUID, update models.UserPreferencesUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	preferences, ok := r.preferences[userID]
+	if !ok {
+		return fmt.Errorf("user not found for update")
+	}
+	if update.Locale != nil {
+		preferences.Locale = *update.Locale
+	}
+	if update.TimeZone != nil {
+		preferences.TimeZone = *update.TimeZone
+	}
+	r.preferences[userID] = preferences
+	return nil
+}
+
+// fakeMagicLinkTokenRepo is a MagicLinkTokenRepository that has issued no
+// tokens
+type fakeMagicLinkTokenRepo struct{}
+
+func (fakeMagicLinkTokenRepo) Create(token *models.MagicLinkToken) error {
+	return nil
+}
+
+func (fakeMagicLinkTokenRepo) GetByTokenHash(tokenHash string) (*models.MagicLinkToken, error) {
+	return nil, fmt.Errorf("sign-in link token not found")
+}
+
+func (fakeMagicLinkTokenRepo) MarkUsed(id uuid.UUID) error {
+	return fmt.Errorf("sign-in link token not found")
+}
+
+func (fakeMagicLinkTokenRepo) CountRecentByUserID(userID uuid.UUID, since time.Time) (int, error) {
+	return 0, nil
+}
+
+// fakeResetTokenRepo is a PasswordResetTokenRepository that keeps the
+// tokens it is given
+type fakeResetTokenRepo struct {
+	repository.PasswordResetTokenRepository
+	mu     sync.Mutex
+	tokens []models.PasswordResetToken
+}
+
+func (r *fakeResetTokenRepo) Create(token *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens = append(r.tokens, *token)
+	return nil
+}
+
+// fakeInvitationRepo is an in-memory InvitationRepository
+type fakeInvitationRepo struct {
+	mu          sync.Mutex
+	invitations []*models.Invitation
+	audits      []models.AuditLogEntry
+}
+
+func (r *fakeInvitationRepo) Create(invitation *models.Invitation, audit *models.AuditLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *invitation
+	r.invitations = append(r.invitations, &clone)
+	if audit != nil {
+		r.audits = append(r.audits, *audit)
+	}
+	return nil
+}
+
+func (r *fakeInvitationRepo) find(match func(*models.Invitation) bool) (*models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, i := range r.invitations {
+		if match(i) {
+			clone := *i
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("invitation not found")
+}
+
+func (r *fakeInvitationRepo) GetByID(id uuid.UUID) (*models.Invitation, error) {
+	return r.find(func(i *models.Invitation) bool { return i.ID == id })
+}
+
+func (r *fakeInvitationRepo) GetByCode(code string) (*models.Invitation, error) {
+	return r.find(func(i *models.Invitation) bool { return i.Code == code })
+}
+
+func (r *fakeInvitationRepo) List(opts models.ListInvitationsOptions) ([]models.Invitation, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched []models.Invitation
+	for _, i := range r.invitations {
+		if opts.Status == "" || i.Status() == opts.Status {
+			matched = append(matched, *i)
+		}
+	}
+	total := len(matched)
+	if opts.Offset >= total {
+		return nil, total, nil
+	}
+	end := opts.Offset + opts.Limit
+	if end > total {
+		end = total
+	}
+	return matched[opts.Offset:end], total, nil
+}
+
+func (r *fakeInvitationRepo) Revoke(id uuid.UUID, audit *models.AuditLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, i := range r.invitations {
+		if i.ID == id && i.UsedAt == nil && i.RevokedAt == nil {
+			now := time.Now()
+			i.RevokedAt = &now
+			if audit != nil {
+				r.audits = append(r.audits, *audit)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("invitation already used or revoked")
+}
+
+func (r *fakeInvitationRepo) markUsed(id, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, i := range r.invitations {
+		if i.ID == id && i.Status() == models.InvitationStatusActive {
+			now := time.Now()
+			i.UsedBy, i.UsedAt = &userID, &now
+			return nil
+		}
+	}
+	return fmt.Errorf("invitation is no longer available")
+}
+
+// fakePersonalAccessTokenRepo is an in-memory PersonalAccessTokenRepository
+type fakePersonalAccessTokenRepo struct {
+	mu     sync.Mutex
+	tokens []*models.PersonalAccessToken
+}
+
+func (r *fakePersonalAccessTokenRepo) Create(token *models.PersonalAccessToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *token
+	r.tokens = append(r.tokens, &clone)
+	return nil
+}
+
+func (r *fakePersonalAccessTokenRepo) GetByTokenHash(tokenHash string) (*models.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			clone := *token
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("personal access token not found")
+}
+
+func (r *fakePersonalAccessTokenRepo) ListByUserID(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tokens := []models.PersonalAccessToken{}
+	for _, token := range r.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, *token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *fakePersonalAccessTokenRepo) Delete(userID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, token := range r.tokens {
+		if token.ID == id && token.UserID == userID {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("personal access token not found")
+}
+
+func (r *fakePersonalAccessTokenRepo) TouchLastUsed(id uuid.UUID, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.ID == id {
+			token.LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+// fakeKYCRepo is an in-memory KYCRepository that keeps the KYC status of
+// the users in step with their submissions
+type fakeKYCRepo struct {
+	mu          sync.Mutex
+	users       *fakeUserRepo
+	submissions []*models.KYCSubmission
+}
+
+func (r *fakeKYCRepo) setStatus(userID uuid.UUID, status string) {
+	r.users.mu.Lock()
+	defer r.users.mu.Unlock()
+	r.users.users[userID].KYCStatus = status
+}
+
+func (r *fakeKYCRepo) Submit(submission *models.KYCSubmission) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	submission.Status = models.KYCStatusPending
+	clone := *submission
+	r.submissions = append(r.submissions, &clone)
+	r.setStatus(submission.UserID, models.KYCStatusPending)
+	return nil
+}
+
+func (r *fakeKYCRepo) GetLatestByUserID(userID uuid.UUID) (*models.KYCSubmission, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.submissions) - 1; i >= 0; i-- {
+		if r.submissions[i].UserID == userID {
+			clone := *r.submissions[i]
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("KYC submission not found")
+}
+
+func (r *fakeKYCRepo) Review(submission *models.KYCSubmission, audit *models.AuditLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.submissions {
+		if stored.ID == submission.ID && stored.Status == models.KYCStatusPending {
+			*stored = *submission
+			r.users.mu.Lock()
+			defer r.users.mu.Unlock()
+			r.users.users[submission.UserID].KYCStatus = submission.Status
+			r.users.recordAudit(audit)
+			return nil
+		}
+	}
+	return fmt.Errorf("KYC submission already reviewed")
+}
+
+func (r *fakeKYCRepo) List(opts models.ListKYCSubmissionsOptions) ([]models.KYCSubmission, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matching []models.KYCSubmission
+	for _, submission := range r.submissions {
+		if opts.Status == "" || submission.Status == opts.Status {
+			matching = append(matching, *submission)
+		}
+	}
+	return matching, len(matching), nil
+}
+
+// fakeKYCDocumentRepo is an in-memory KYCDocumentRepository. Methods not
+// needed by the handler tests panic.
+type fakeKYCDocumentRepo struct {
+	repository.KYCDocumentRepository
+	mu        sync.Mutex
+	documents []models.KYCDocument
+}
+
+func (r *fakeKYCDocumentRepo) Create(document *models.KYCDocument, maxPerSubmission int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.documents = append(r.documents, *document)
+	return nil
+}
+
+func (r *fakeKYCDocumentRepo) GetByID(id uuid.UUID) (*models.KYCDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, document := range r.documents {
+		if document.ID == id {
+			return &document, nil
+		}
+	}
+	return nil, fmt.Errorf("KYC document not found")
+}
+
+func (r *fakeKYCDocumentRepo) ListBySubmissionID(submissionID uuid.UUID) ([]models.KYCDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var documents []models.KYCDocument
+	for _, document := range r.documents {
+		if *document.SubmissionID == submissionID {
+			documents = append(documents, document)
+		}
+	}
+	return documents, nil
+}
+
+// fakeDownloadArtifactRepo is an in-memory DownloadArtifactRepository.
+// Methods not needed by the handler tests panic.
+type fakeDownloadArtifactRepo struct {
+	repository.DownloadArtifactRepository
+	mu        sync.Mutex
+	artifacts []*models.DownloadArtifact
+}
+
+func (r *fakeDownloadArtifactRepo) Create(artifact *models.DownloadArtifact) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *artifact
+	r.artifacts = append(r.artifacts, &clone)
+	return nil
+}
+
+func (r *fakeDownloadArtifactRepo) GetByID(id uuid.UUID) (*models.DownloadArtifact, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, artifact := range r.artifacts {
+		if artifact.ID == id {
+			clone := *artifact
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("download artifact not found")
+}
+
+func (r *fakeDownloadArtifactRepo) ClaimDownload(id uuid.UUID, access *models.DownloadAccess, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, artifact := range r.artifacts {
+		if artifact.ID == id && artifact.DownloadCount < artifact.MaxDownloads && artifact.ExpiresAt.After(now) {
+			artifact.DownloadCount++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeStatementRepo is an in-memory StatementRepository. Methods not needed
+// by the handler tests panic.
+type fakeStatementRepo struct {
+	repository.StatementRepository
+	mu            sync.Mutex
+	subscriptions map[uuid.UUID]models.StatementSubscription
+	deliveries    []*models.StatementDelivery
+}
+
+func (r *fakeStatementRepo) GetSubscription(userID uuid.UUID) (*models.StatementSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription, ok := r.subscriptions[userID]
+	if !ok {
+		return nil, fmt.Errorf("statement subscription not found")
+	}
+	return &subscription, nil
+}
+
+func (r *fakeStatementRepo) SaveSubscription(subscription *models.StatementSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.subscriptions == nil {
+		r.subscriptions = make(map[uuid.UUID]models.StatementSubscription)
+	}
+	subscription.CreatedAt = time.Now()
+	subscription.UpdatedAt = subscription.CreatedAt
+	r.subscriptions[subscription.UserID] = *subscription
+	return nil
+}
+
+func (r *fakeStatementRepo) DeleteSubscription(userID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.subscriptions[userID]
+	delete(r.subscriptions, userID)
+	return ok, nil
+}
+
+func (r *fakeStatementRepo) CreateDelivery(delivery *models.StatementDelivery) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.deliveries {
+		if existing.UserID == delivery.UserID && existing.Period == delivery.Period {
+			return false, nil
+		}
+	}
+	clone := *delivery
+	r.deliveries = append(r.deliveries, &clone)
+	return true, nil
+}
+
+func (r *fakeStatementRepo) GetDelivery(userID uuid.UUID, period string) (*models.StatementDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range r.deliveries {
+		if delivery.UserID == userID && delivery.Period == period {
+			clone := *delivery
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("statement delivery not found")
+}
+
+func (r *fakeStatementRepo) ClaimDelivery(id uuid.UUID, requestedBy uuid.UUID, now time.Time, lease time.Duration) (*models.StatementDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range r.deliveries {
+		if delivery.ID == id && delivery.Status != models.StatementDeliverySent && delivery.Status != models.StatementDeliverySending {
+			delivery.Status = models.StatementDeliverySending
+			delivery.RequestedBy = &requestedBy
+			clone := *delivery
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeStatementRepo) UpdateDelivery(delivery *models.StatementDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.deliveries {
+		if existing.ID == delivery.ID {
+			clone := *delivery
+			r.deliveries[i] = &clone
+			return nil
+		}
+	}
+	return fmt.Errorf("statement delivery not found")
+}
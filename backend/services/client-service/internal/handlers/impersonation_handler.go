@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/authmw"
+	"microbank/pkg/httpx"
+)
+
+// ImpersonationHandler handles admins acting as a user
+type ImpersonationHandler struct {
+	impersonationService *services.ImpersonationService
+}
+
+// NewImpersonationHandler creates a new impersonation handler
+func NewImpersonationHandler(impersonationService *services.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+	}
+}
+
+// StartImpersonation issues a short-lived access token for acting as a
+// client (admin only)
+func (h *ImpersonationHandler) StartImpersonation(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get user ID from URL parameter
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Start the session
+	session, err := h.impersonationService.StartImpersonation(actor, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+		case errors.Is(err, services.ErrCannotImpersonateStaff):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "CANNOT_IMPERSONATE_STAFF",
+				Message: "Admins and other staff cannot be impersonated",
+			})
+		case errors.Is(err, services.ErrAccountSuspended):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "ACCOUNT_SUSPENDED",
+				Message: "Blacklisted users cannot be impersonated",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "IMPERSONATION_FAILED",
+				Message: "Failed to start impersonation",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return the token; there is no refresh token
+	httpx.RespondCreated(c, gin.H{
+		"message":         "Impersonation started",
+		"user_id":         session.UserID,
+		"impersonator_id": session.ImpersonatorID,
+		"tokens": gin.H{
+			"access_token": session.AccessToken,
+			"token_type":   "Bearer",
+			"expires_at":   session.ExpiresAt,
+		},
+	})
+}
+
+// EndImpersonation revokes the impersonation token the request was made
+// with
+func (h *ImpersonationHandler) EndImpersonation(c *gin.Context) {
+	impersonatorID, err := uuid.Parse(authmw.ContextImpersonatorID(c))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "NOT_IMPERSONATING",
+			Message: "The request was not made with an impersonation token",
+		})
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// The session is logged under the admin who started it
+	actor := models.AuditActor{AdminID: impersonatorID, RequestID: c.GetString("request_id")}
+	if err := h.impersonationService.EndImpersonation(actor, userID, c.GetString(authmw.TokenIDKey)); err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "END_IMPERSONATION_FAILED",
+			Message: "Failed to end impersonation",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Impersonation ended",
+		"user_id": userID,
+	})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/revocation"
+	"microbank/client-service/internal/services"
+)
+
+func TestImpersonationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := newTestUser(t, "admin@example.com")
+	setAdmin(admin, true)
+	user := newTestUser(t, "client@example.com")
+	userRepo := newFakeUserRepo(admin, user)
+	auditRepo := &fakeAuditLogRepo{}
+	banking := &fakeBankingClient{}
+	revocations := revocation.NewMemoryStore()
+	handler := NewImpersonationHandler(services.NewImpersonationService(userRepo, auditRepo, testTokens, revocations, banking))
+
+	r := gin.New()
+	auth := middleware.AuthMiddleware(testTokens, userRepo, revocations)
+	r.POST("/admin/clients/:id/impersonate", func(c *gin.Context) {
+		c.Set("user_id", admin.ID.String())
+		handler.StartImpersonation(c)
+	})
+	r.POST("/auth/impersonation/end", auth, handler.EndImpersonation)
+	r.GET("/profile", auth, func(c *gin.Context) { c.String(http.StatusOK, c.GetString("user_id")) })
+	r.PUT("/profile/password", auth, middleware.ForbidImpersonation(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Admins cannot be impersonated
+	if w, code := postJSON(t, r, "/admin/clients/"+admin.ID.String()+"/impersonate", nil); w.Code != http.StatusForbidden || code != "CANNOT_IMPERSONATE_STAFF" {
+		t.Fatalf("Expected 403 CANNOT_IMPERSONATE_STAFF, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, _ := postJSON(t, r, "/admin/clients/"+user.ID.String()+"/impersonate", nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var started struct {
+		ImpersonatorID string `json:"impersonator_id"`
+		Tokens         struct {
+			AccessToken string `json:"access_token"`
+		} `json:"tokens"`
+	}
+	if err := decodeData(w, &started); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	token := started.Tokens.AccessToken
+	if started.ImpersonatorID != admin.ID.String() {
+		t.Errorf("Expected impersonator %s, got %s", admin.ID, started.ImpersonatorID)
+	}
+
+	// The admin sees what the user sees, but cannot change their password
+	if w := send(http.MethodGet, "/profile", token); w.Code != http.StatusOK || w.Body.String() != user.ID.String() {
+		t.Errorf("Expected to act as the user, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPut, "/profile/password", token); w.Code != http.StatusForbidden || decodeErrorCode(t, w) != "IMPERSONATION_FORBIDDEN" {
+		t.Errorf("Expected 403 IMPERSONATION_FORBIDDEN, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Ending the session revokes the token
+	if w := send(http.MethodPost, "/auth/impersonation/end", token); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodGet, "/profile", token); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the ended session's token to be rejected, got %d", w.Code)
+	}
+	if len(banking.revoked) != 1 {
+		t.Errorf("Expected the revocation to be pushed to the banking-service, got %+v", banking.revoked)
+	}
+
+	var actions []string
+	for _, entry := range auditRepo.entries {
+		if entry.AdminID != admin.ID || *entry.TargetUserID != user.ID {
+			t.Errorf("Expected entries by the admin about the user, got %+v", entry)
+		}
+		actions = append(actions, entry.Action)
+	}
+	if len(actions) != 2 || actions[0] != models.AuditActionImpersonationStart || actions[1] != models.AuditActionImpersonationEnd {
+		t.Errorf("Expected the start and end to be audited, got %v", actions)
+	}
+}
+
+func TestImpersonationHandler_EndWithoutImpersonating(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := newTestUser(t, "client@example.com")
+	handler := NewImpersonationHandler(nil)
+
+	r := gin.New()
+	r.POST("/auth/impersonation/end", func(c *gin.Context) {
+		c.Set("user_id", user.ID.String())
+		handler.EndImpersonation(c)
+	})
+
+	if w, code := postJSON(t, r, "/auth/impersonation/end", nil); w.Code != http.StatusBadRequest || code != "NOT_IMPERSONATING" {
+		t.Errorf("Expected 400 NOT_IMPERSONATING, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// InvitationHandler handles admin invitation HTTP requests
+type InvitationHandler struct {
+	invitationService *services.InvitationService
+}
+
+// NewInvitationHandler creates a new invitation handler
+func NewInvitationHandler(invitationService *services.InvitationService) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+	}
+}
+
+// CreateInvitation creates an invitation code, optionally bound to an email
+// (admin only)
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body. An empty body creates an open
+	// invitation with the default expiry.
+	var request models.InvitationRequest
+	if c.Request.ContentLength != 0 {
+		if !bindJSON(c, &request) {
+			return
+		}
+	}
+
+	// Create invitation
+	invitation, err := h.invitationService.CreateInvitation(actor, request)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "CREATE_INVITATION_FAILED",
+			Message: "Failed to create invitation",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return created invitation
+	httpx.RespondCreated(c, gin.H{
+		"message":    "Invitation created successfully",
+		"invitation": invitation.ToResponse(),
+	})
+}
+
+// ListInvitations retrieves a page of invitations, newest first (admin
+// only). It accepts limit, offset and status (active, used, expired or
+// revoked).
+func (h *InvitationHandler) ListInvitations(c *gin.Context) {
+	opts, err := parseListInvitationsOptions(c)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_QUERY_PARAMETER",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get invitations
+	page, err := h.invitationService.ListInvitations(opts)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_INVITATIONS_FAILED",
+			Message: "Failed to fetch invitations",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	invitations := make([]models.InvitationResponse, 0, len(page.Invitations))
+	for i := range page.Invitations {
+		invitations = append(invitations, page.Invitations[i].ToResponse())
+	}
+
+	// Return invitations
+	httpx.RespondPage(c, gin.H{
+		"message":     "Invitations retrieved successfully",
+		"invitations": invitations,
+	}, httpx.NewPagination(page.Limit, page.Offset, len(invitations), page.Total))
+}
+
+// RevokeInvitation stops an unused invitation from being used (admin only)
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get invitation ID from URL parameter
+	invitationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_INVITATION_ID",
+			Message: "Invalid invitation ID format",
+		})
+		return
+	}
+
+	// Revoke invitation
+	if err := h.invitationService.RevokeInvitation(actor, invitationID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvitationNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "INVITATION_NOT_FOUND",
+				Message: "Invitation not found",
+			})
+		case errors.Is(err, services.ErrInvitationUsed):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "INVITATION_USED",
+				Message: "Invitation has already been used",
+			})
+		case errors.Is(err, services.ErrInvitationRevoked):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "INVITATION_REVOKED",
+				Message: "Invitation has already been revoked",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "REVOKE_INVITATION_FAILED",
+				Message: "Failed to revoke invitation",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message":       "Invitation revoked successfully",
+		"invitation_id": invitationID,
+	})
+}
+
+// parseListInvitationsOptions reads the invitation listing query parameters
+func parseListInvitationsOptions(c *gin.Context) (models.ListInvitationsOptions, error) {
+	var opts models.ListInvitationsOptions
+
+	// Pagination
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return opts, fmt.Errorf("limit must be a positive integer")
+		}
+		opts.Limit = limit
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return opts, fmt.Errorf("offset must be a non-negative integer")
+		}
+		opts.Offset = offset
+	}
+
+	// Status filter
+	switch status := c.Query("status"); status {
+	case "", models.InvitationStatusActive, models.InvitationStatusUsed, models.InvitationStatusExpired, models.InvitationStatusRevoked:
+		opts.Status = status
+	default:
+		return opts, fmt.Errorf("status must be one of active, used, expired or revoked")
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordhash"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+	"microbank/client-service/internal/services"
+)
+
+// newInvitationRouter wires registration and the admin invitation endpoints
+// to the same invitation repository
+func newInvitationRouter(t *testing.T, mode services.RegistrationMode, invitations *fakeInvitationRepo, userRepo *fakeUserRepo) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	userRepo.invitations = invitations
+	invitationService := services.NewInvitationService(invitations, mode)
+	hasher := passwordhash.NewBcrypt(bcrypt.MinCost)
+	authService := services.NewAuthService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, passwordpolicy.Default(), hasher, nil, &fakeBankingClient{}, testDeletionRetention, nil, invitationService, testTokens, revocation.NewMemoryStore(), 0, nil)
+	authHandler := NewAuthHandler(authService, nil, nil)
+	invitationHandler := NewInvitationHandler(invitationService)
+
+	adminID := uuid.New()
+	r := gin.New()
+	r.POST("/auth/register", authHandler.Register)
+	admin := r.Group("/admin", func(c *gin.Context) { c.Set("user_id", adminID.String()) })
+	admin.GET("/invitations", invitationHandler.ListInvitations)
+	admin.POST("/invitations", invitationHandler.CreateInvitation)
+	admin.DELETE("/invitations/:id", invitationHandler.RevokeInvitation)
+	return r
+}
+
+func TestAuthHandler_RegisterInviteOnly(t *testing.T) {
+	now := time.Now()
+	usedAt := now.Add(-time.Hour)
+	invitations := &fakeInvitationRepo{invitations: []*models.Invitation{
+		{ID: uuid.New(), Code: "OPEN-2345", ExpiresAt: now.Add(time.Hour)},
+		{ID: uuid.New(), Code: "BOUN-D234", Email: "invited@example.com", ExpiresAt: now.Add(time.Hour)},
+		{ID: uuid.New(), Code: "USED-2345", ExpiresAt: now.Add(time.Hour), UsedAt: &usedAt},
+		{ID: uuid.New(), Code: "GONE-2345", ExpiresAt: now.Add(time.Hour), RevokedAt: &usedAt},
+		{ID: uuid.New(), Code: "LATE-2345", ExpiresAt: now.Add(-time.Minute)},
+	}}
+	userRepo := newFakeUserRepo()
+	r := newInvitationRouter(t, services.RegistrationInviteOnly, invitations, userRepo)
+
+	tests := []struct {
+		name       string
+		email      string
+		code       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "no code", email: "a@example.com", wantStatus: http.StatusForbidden, wantCode: "INVITATION_REQUIRED"},
+		{name: "unknown code", email: "a@example.com", code: "NOPE-2345", wantStatus: http.StatusForbidden, wantCode: "INVALID_INVITATION_CODE"},
+		{name: "used code", email: "a@example.com", code: "USED-2345", wantStatus: http.StatusForbidden, wantCode: "INVITATION_USED"},
+		{name: "revoked code", email: "a@example.com", code: "GONE-2345", wantStatus: http.StatusForbidden, wantCode: "INVITATION_REVOKED"},
+		{name: "expired code", email: "a@example.com", code: "LATE-2345", wantStatus: http.StatusForbidden, wantCode: "INVITATION_EXPIRED"},
+		{name: "wrong email", email: "a@example.com", code: "BOUN-D234", wantStatus: http.StatusForbidden, wantCode: "INVITATION_EMAIL_MISMATCH"},
+		{name: "bound code typed loosely", email: "Invited@example.com", code: " bound234 ", wantStatus: http.StatusCreated},
+		{name: "open code", email: "b@example.com", code: "OPEN-2345", wantStatus: http.StatusCreated},
+		{name: "open code reused", email: "c@example.com", code: "OPEN-2345", wantStatus: http.StatusForbidden, wantCode: "INVITATION_USED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, code := postJSON(t, r, "/auth/register", gin.H{
+				"email":           tt.email,
+				"name":            "New User",
+				"password":        "Str0ngEnough",
+				"invitation_code": tt.code,
+			})
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+			}
+		})
+	}
+
+	if used := invitations.invitations[0]; used.UsedBy == nil {
+		t.Error("Expected the open invitation to record who used it")
+	}
+	if invited, _ := userRepo.GetUserByEmail("b@example.com"); invited == nil || invited.RegistrationSource != models.RegistrationSourceInvite {
+		t.Errorf("Expected the invited user to be recorded as invited, got %+v", invited)
+	}
+}
+
+func TestAuthHandler_RegisterClosed(t *testing.T) {
+	invitations := &fakeInvitationRepo{invitations: []*models.Invitation{
+		{ID: uuid.New(), Code: "OPEN-2345", ExpiresAt: time.Now().Add(time.Hour)},
+	}}
+	r := newInvitationRouter(t, services.RegistrationClosed, invitations, newFakeUserRepo())
+
+	w, code := postJSON(t, r, "/auth/register", gin.H{
+		"email":           "a@example.com",
+		"name":            "New User",
+		"password":        "Str0ngEnough",
+		"invitation_code": "OPEN-2345",
+	})
+	if w.Code != http.StatusForbidden || code != "REGISTRATION_CLOSED" {
+		t.Errorf("Expected 403 REGISTRATION_CLOSED, got %d %q", w.Code, code)
+	}
+}
+
+func TestInvitationHandler_Lifecycle(t *testing.T) {
+	invitations := &fakeInvitationRepo{}
+	r := newInvitationRouter(t, services.RegistrationInviteOnly, invitations, newFakeUserRepo())
+
+	// Create an email-bound invitation
+	w, _ := postJSON(t, r, "/admin/invitations", gin.H{"email": "friend@example.com", "expires_in_days": 3})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Invitation models.InvitationResponse `json:"invitation"`
+	}
+	decodeData(w, &created)
+	if created.Invitation.Status != models.InvitationStatusActive || created.Invitation.Email != "friend@example.com" {
+		t.Errorf("Unexpected invitation: %+v", created.Invitation)
+	}
+	if len(created.Invitation.Code) != 9 || created.Invitation.Code[4] != '-' {
+		t.Errorf("Expected a code like ABCD-2345, got %q", created.Invitation.Code)
+	}
+	if days := time.Until(created.Invitation.ExpiresAt).Hours() / 24; days < 2.9 || days > 3 {
+		t.Errorf("Expected the invitation to expire in 3 days, got %.2f", days)
+	}
+
+	// An empty body creates an open invitation
+	req := httptest.NewRequest(http.MethodPost, "/admin/invitations", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for an empty body, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Revoke the first one, twice
+	path := "/admin/invitations/" + created.Invitation.ID.String()
+	for i, want := range []int{http.StatusOK, http.StatusConflict} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path, nil))
+		if w.Code != want {
+			t.Errorf("revoke %d: expected status %d, got %d: %s", i+1, want, w.Code, w.Body.String())
+		}
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/invitations/"+uuid.NewString(), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown invitation, got %d", w.Code)
+	}
+
+	// List by status
+	for query, want := range map[string]int{"": 2, "?status=revoked": 1, "?status=active": 1, "?status=used": 0} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/invitations"+query, nil))
+		var listed struct {
+			Invitations []models.InvitationResponse `json:"invitations"`
+		}
+		decodeData(w, &listed)
+		if w.Code != http.StatusOK || len(listed.Invitations) != want {
+			t.Errorf("list %q: expected %d invitations, got %d (status %d)", query, want, len(listed.Invitations), w.Code)
+		}
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/invitations?status=pending", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown status, got %d", w.Code)
+	}
+
+	// Creation and revocation are audited without a target user
+	if len(invitations.audits) != 3 {
+		t.Fatalf("Expected 3 audit entries, got %d", len(invitations.audits))
+	}
+	for _, entry := range invitations.audits {
+		if entry.TargetUserID != nil || !strings.HasPrefix(entry.Action, "invitation.") {
+			t.Errorf("Unexpected audit entry: %+v", entry)
+		}
+	}
+}
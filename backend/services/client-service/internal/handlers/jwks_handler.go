@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/tokenkeys"
+)
+
+// JWKSHandler publishes the public keys access tokens are signed with
+type JWKSHandler struct {
+	keys *tokenkeys.KeySet
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(keys *tokenkeys.KeySet) *JWKSHandler {
+	return &JWKSHandler{
+		keys: keys,
+	}
+}
+
+// GetJWKS returns the JSON Web Key Set other services verify access tokens
+// with. Verifiers may cache it briefly and should refetch it when a token
+// names a key they do not know.
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/bodylimit"
+	"microbank/pkg/httpx"
+)
+
+// KYCDocumentHandler handles uploads and downloads of KYC document scans
+type KYCDocumentHandler struct {
+	documentService *services.KYCDocumentService
+}
+
+// NewKYCDocumentHandler creates a new KYC document handler
+func NewKYCDocumentHandler(documentService *services.KYCDocumentService) *KYCDocumentHandler {
+	return &KYCDocumentHandler{
+		documentService: documentService,
+	}
+}
+
+// Upload stores a scan, sent as the multipart form field "file", against
+// the current user's pending KYC submission. The body is bounded by
+// middleware.UploadBody.
+func (h *KYCDocumentHandler) Upload(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Read the uploaded file
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		if tooLarge, ok := bodylimit.IsTooLarge(err); ok {
+			httpx.RespondError(c, tooLarge)
+			return
+		}
+		if errors.Is(err, http.ErrNotMultipart) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusUnsupportedMediaType,
+				Code:    "UNSUPPORTED_MEDIA_TYPE",
+				Message: "Request body must be multipart/form-data",
+			})
+			return
+		}
+		respondValidationError(c, fieldValidationError("file", "required", "is required"))
+		return
+	}
+	defer c.Request.MultipartForm.RemoveAll()
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		respondValidationError(c, fieldValidationError("file", "required", "could not be read"))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondValidationError(c, fieldValidationError("file", "required", "could not be read"))
+		return
+	}
+
+	// Store the document
+	document, err := h.documentService.Upload(c.Request.Context(), userUUID, fileHeader.Filename, data)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, services.ErrKYCDocumentType):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusUnsupportedMediaType,
+				Code:    "UNSUPPORTED_DOCUMENT_TYPE",
+				Message: "Documents must be JPEG, PNG or PDF files",
+			})
+		case errors.Is(err, services.ErrKYCNotPending):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "KYC_NOT_PENDING",
+				Message: "Submit your KYC details before uploading documents",
+			})
+		case errors.Is(err, services.ErrKYCDocumentLimitReached):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "KYC_DOCUMENT_LIMIT_REACHED",
+				Message: "No more documents can be uploaded for this submission",
+				Details: map[string]int{"max_documents": services.MaxKYCDocumentsPerSubmission},
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "KYC_DOCUMENT_UPLOAD_FAILED",
+				Message: "Failed to upload KYC document",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return document
+	httpx.RespondCreated(c, gin.H{
+		"message":  "KYC document uploaded successfully",
+		"document": document,
+	})
+}
+
+// ListDocuments retrieves the documents of a client's latest KYC
+// submission (staff only)
+func (h *KYCDocumentHandler) ListDocuments(c *gin.Context) {
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Get documents
+	documents, err := h.documentService.ListForUser(userID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_KYC_DOCUMENTS_FAILED",
+			Message: "Failed to fetch KYC documents",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return documents
+	httpx.RespondOK(c, gin.H{
+		"message":   "KYC documents retrieved successfully",
+		"documents": documents,
+	})
+}
+
+// Download sends a KYC document's contents as an attachment (staff only).
+// Every download is recorded in the audit log.
+func (h *KYCDocumentHandler) Download(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get document ID from URL parameter
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_DOCUMENT_ID",
+			Message: "Invalid document ID format",
+		})
+		return
+	}
+
+	// Get document
+	document, data, err := h.documentService.Download(c.Request.Context(), actor, documentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrKYCDocumentNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "KYC_DOCUMENT_NOT_FOUND",
+				Message: "KYC document not found",
+			})
+		case errors.Is(err, services.ErrCannotReviewOwnKYC):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "CANNOT_REVIEW_OWN_KYC",
+				Message: "You cannot review your own KYC details",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "KYC_DOCUMENT_DOWNLOAD_FAILED",
+				Message: "Failed to download KYC document",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Send the file. It is never cached, and browsers must not guess a
+	// different type for it.
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": document.FileName}))
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, document.ContentType, data)
+}
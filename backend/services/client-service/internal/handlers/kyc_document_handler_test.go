@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/blobstore"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+)
+
+// multipartUpload builds a request uploading content as the form field
+// "file"
+func multipartUpload(t *testing.T, path, fileName string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	part.Write(content)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestKYCDocumentHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reviewer := newTestUser(t, "support@example.com")
+	user := newTestUser(t, "client@example.com")
+	userRepo := newFakeUserRepo(reviewer, user)
+	kycRepo := &fakeKYCRepo{users: userRepo}
+	auditLogRepo := &fakeAuditLogRepo{}
+	store, err := blobstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create blob store: %v", err)
+	}
+	kycHandler := NewKYCHandler(services.NewKYCService(kycRepo, userRepo))
+	handler := NewKYCDocumentHandler(services.NewKYCDocumentService(&fakeKYCDocumentRepo{}, kycRepo, auditLogRepo, store, time.Hour))
+
+	r := gin.New()
+	asUser := func(c *gin.Context) { c.Set("user_id", user.ID.String()) }
+	asReviewer := func(c *gin.Context) { c.Set("user_id", reviewer.ID.String()) }
+	r.POST("/profile/kyc", asUser, kycHandler.Submit)
+	r.POST("/profile/kyc/documents", middleware.UploadBody(1024), asUser, handler.Upload)
+	r.GET("/admin/clients/:id/kyc/documents", asReviewer, handler.ListDocuments)
+	r.GET("/admin/kyc/documents/:id", asReviewer, handler.Download)
+	// The user downloading their own documents, as if they were staff
+	r.GET("/own/kyc/documents/:id", asUser, handler.Download)
+
+	upload := func(fileName string, content []byte) (*httptest.ResponseRecorder, string) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, multipartUpload(t, "/profile/kyc/documents", fileName, content))
+		return w, decodeErrorCode(t, w)
+	}
+	pdf := []byte("%PDF-1.7\npassport scan")
+
+	// Documents back a pending submission
+	if w, code := upload("passport.pdf", pdf); w.Code != http.StatusConflict || code != "KYC_NOT_PENDING" {
+		t.Fatalf("Expected 409 KYC_NOT_PENDING before submitting, got %d: %s", w.Code, w.Body.String())
+	}
+	if w, _ := postJSON(t, r, "/profile/kyc", gin.H{"document_type": "passport", "document_number": "A1234567", "country": "ZA"}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w, code := upload("passport.pdf", []byte("just some text")); w.Code != http.StatusUnsupportedMediaType || code != "UNSUPPORTED_DOCUMENT_TYPE" {
+		t.Errorf("Expected 415 UNSUPPORTED_DOCUMENT_TYPE, got %d: %s", w.Code, w.Body.String())
+	}
+	if w, code := upload("passport.pdf", append([]byte("%PDF-1.7\n"), bytes.Repeat([]byte("x"), 2048)...)); w.Code != http.StatusRequestEntityTooLarge || code != "PAYLOAD_TOO_LARGE" {
+		t.Errorf("Expected 413 PAYLOAD_TOO_LARGE, got %d: %s", w.Code, w.Body.String())
+	}
+	if w, code := postJSON(t, r, "/profile/kyc/documents", gin.H{"file": "passport.pdf"}); w.Code != http.StatusUnsupportedMediaType || code != "UNSUPPORTED_MEDIA_TYPE" {
+		t.Errorf("Expected 415 UNSUPPORTED_MEDIA_TYPE for a JSON body, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, _ := upload("passport.pdf", pdf)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var uploaded struct {
+		Document models.KYCDocument `json:"document"`
+	}
+	if err := decodeData(w, &uploaded); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if strings.Contains(w.Body.String(), "storage_key") {
+		t.Errorf("Expected the storage key to stay private, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/clients/"+user.ID.String()+"/kyc/documents", nil))
+	var listed struct {
+		Documents []models.KYCDocument `json:"documents"`
+	}
+	if err := decodeData(w, &listed); err != nil || len(listed.Documents) != 1 || listed.Documents[0].ID != uploaded.Document.ID {
+		t.Errorf("Expected the uploaded document to be listed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Reviewers download the file as an attachment, and each download is
+	// audited
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/kyc/documents/"+uploaded.Document.ID.String(), nil))
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), pdf) {
+		t.Fatalf("Expected the document, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/pdf" {
+		t.Errorf("Expected Content-Type application/pdf, got %s", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=passport.pdf` {
+		t.Errorf("Expected an attachment, got %s", got)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Expected Cache-Control no-store, got %s", got)
+	}
+	if len(auditLogRepo.entries) != 1 || auditLogRepo.entries[0].Action != models.AuditActionViewKYCDocument {
+		t.Errorf("Expected the download to be audited, got %+v", auditLogRepo.entries)
+	}
+
+	// Staff cannot open their own documents
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/own/kyc/documents/"+uploaded.Document.ID.String(), nil))
+	if code := decodeErrorCode(t, w); w.Code != http.StatusForbidden || code != "CANNOT_REVIEW_OWN_KYC" {
+		t.Errorf("Expected 403 CANNOT_REVIEW_OWN_KYC, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/kyc/documents/"+user.ID.String(), nil))
+	if code := decodeErrorCode(t, w); w.Code != http.StatusNotFound || code != "KYC_DOCUMENT_NOT_FOUND" {
+		t.Errorf("Expected 404 KYC_DOCUMENT_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// KYCHandler handles identity verification (KYC) HTTP requests
+type KYCHandler struct {
+	kycService *services.KYCService
+}
+
+// NewKYCHandler creates a new KYC handler
+func NewKYCHandler(kycService *services.KYCService) *KYCHandler {
+	return &KYCHandler{
+		kycService: kycService,
+	}
+}
+
+// GetStatus retrieves the current user's KYC status and latest submission
+func (h *KYCHandler) GetStatus(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get status
+	status, err := h.kycService.GetStatus(userUUID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_KYC_FAILED",
+			Message: "Failed to fetch KYC status",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return status
+	httpx.RespondOK(c, gin.H{
+		"message":    "KYC status retrieved successfully",
+		"kyc_status": status.Status,
+		"submission": status.Submission,
+	})
+}
+
+// Submit records the current user's identity document details for review
+func (h *KYCHandler) Submit(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.KYCSubmissionRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Submit details
+	submission, err := h.kycService.Submit(userUUID, request)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+		case errors.Is(err, services.ErrKYCPending):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "KYC_PENDING",
+				Message: "Your KYC details are already awaiting review",
+			})
+		case errors.Is(err, services.ErrKYCAlreadyVerified):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "KYC_ALREADY_VERIFIED",
+				Message: "Your identity is already verified",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "KYC_SUBMISSION_FAILED",
+				Message: "Failed to submit KYC details",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return submission
+	httpx.RespondCreated(c, gin.H{
+		"message":    "KYC details submitted for review",
+		"kyc_status": submission.Status,
+		"submission": submission,
+	})
+}
+
+// ListSubmissions retrieves a page of KYC submissions, oldest first,
+// optionally filtered by status (staff only)
+func (h *KYCHandler) ListSubmissions(c *gin.Context) {
+	opts, err := parseListKYCSubmissionsOptions(c)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_QUERY_PARAMETER",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Get submissions
+	page, err := h.kycService.ListSubmissions(opts)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_KYC_SUBMISSIONS_FAILED",
+			Message: "Failed to fetch KYC submissions",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	submissions := page.Submissions
+	if submissions == nil {
+		submissions = []models.KYCSubmission{}
+	}
+
+	// Return submissions
+	httpx.RespondPage(c, gin.H{
+		"message":     "KYC submissions retrieved successfully",
+		"submissions": submissions,
+	}, httpx.NewPagination(page.Limit, page.Offset, len(submissions), page.Total))
+}
+
+// Approve verifies a client's pending KYC details (staff only)
+func (h *KYCHandler) Approve(c *gin.Context) {
+	h.review(c, h.kycService.Approve, "KYC details approved")
+}
+
+// Reject turns down a client's pending KYC details, with notes saying why
+// (staff only)
+func (h *KYCHandler) Reject(c *gin.Context) {
+	h.review(c, h.kycService.Reject, "KYC details rejected")
+}
+
+// review records a reviewer's decision, made with decide, on the pending
+// KYC details of the client named in the URL
+func (h *KYCHandler) review(c *gin.Context, decide func(models.AuditActor, uuid.UUID, string) (*models.KYCSubmission, error), message string) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Bind and validate request body. Notes are optional when approving.
+	var request models.KYCReviewRequest
+	if c.Request.ContentLength != 0 {
+		if !bindJSON(c, &request) {
+			return
+		}
+	}
+
+	// Record the decision
+	submission, err := decide(actor, userID, request.Notes)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+		case errors.Is(err, services.ErrKYCNotPending):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "KYC_NOT_PENDING",
+				Message: "The client has no KYC details awaiting review",
+			})
+		case errors.Is(err, services.ErrKYCNotesRequired):
+			respondValidationError(c, fieldValidationError("notes", "required", "is required when rejecting"))
+		case errors.Is(err, services.ErrCannotReviewOwnKYC):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "CANNOT_REVIEW_OWN_KYC",
+				Message: "You cannot review your own KYC details",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "KYC_REVIEW_FAILED",
+				Message: "Failed to review KYC details",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return the reviewed submission
+	httpx.RespondOK(c, gin.H{
+		"message":    message,
+		"kyc_status": submission.Status,
+		"submission": submission,
+	})
+}
+
+// parseListKYCSubmissionsOptions reads paging and the status filter from
+// the query string
+func parseListKYCSubmissionsOptions(c *gin.Context) (models.ListKYCSubmissionsOptions, error) {
+	var opts models.ListKYCSubmissionsOptions
+
+	// Pagination
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return opts, fmt.Errorf("limit must be a positive integer")
+		}
+		opts.Limit = limit
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return opts, fmt.Errorf("offset must be a non-negative integer")
+		}
+		opts.Offset = offset
+	}
+
+	// Status filter
+	switch status := c.Query("status"); status {
+	case "", models.KYCStatusPending, models.KYCStatusVerified, models.KYCStatusRejected:
+		opts.Status = status
+	default:
+		return opts, fmt.Errorf("status must be one of pending, verified or rejected")
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+)
+
+func TestKYCHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reviewer := newTestUser(t, "support@example.com")
+	user := newTestUser(t, "client@example.com")
+	userRepo := newFakeUserRepo(reviewer, user)
+	handler := NewKYCHandler(services.NewKYCService(&fakeKYCRepo{users: userRepo}, userRepo))
+
+	r := gin.New()
+	asUser := func(c *gin.Context) { c.Set("user_id", user.ID.String()) }
+	asReviewer := func(c *gin.Context) { c.Set("user_id", reviewer.ID.String()) }
+	r.GET("/profile/kyc", asUser, handler.GetStatus)
+	r.POST("/profile/kyc", asUser, handler.Submit)
+	r.GET("/admin/kyc", asReviewer, handler.ListSubmissions)
+	r.POST("/admin/clients/:id/kyc/approve", asReviewer, handler.Approve)
+	r.POST("/admin/clients/:id/kyc/reject", asReviewer, handler.Reject)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	kycStatus := func() string {
+		t.Helper()
+		w := get("/profile/kyc")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			KYCStatus string `json:"kyc_status"`
+		}
+		if err := decodeData(w, &response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return response.KYCStatus
+	}
+	details := gin.H{"document_type": "passport", "document_number": "A1234567", "country": "ZA"}
+	approve := "/admin/clients/" + user.ID.String() + "/kyc/approve"
+	reject := "/admin/clients/" + user.ID.String() + "/kyc/reject"
+
+	if status := kycStatus(); status != models.KYCStatusNone {
+		t.Errorf("Expected KYC status none before submitting, got %s", status)
+	}
+
+	steps := []struct {
+		name       string
+		path       string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+		wantKYC    string
+	}{
+		{name: "unknown document type", path: "/profile/kyc", body: gin.H{"document_type": "library_card", "document_number": "1", "country": "ZA"}, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_ERROR", wantKYC: models.KYCStatusNone},
+		{name: "unknown country", path: "/profile/kyc", body: gin.H{"document_type": "passport", "document_number": "A1234567", "country": "XX"}, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_ERROR", wantKYC: models.KYCStatusNone},
+		{name: "nothing to review", path: approve, wantStatus: http.StatusConflict, wantCode: "KYC_NOT_PENDING", wantKYC: models.KYCStatusNone},
+		{name: "submit", path: "/profile/kyc", body: details, wantStatus: http.StatusCreated, wantKYC: models.KYCStatusPending},
+		{name: "submit while pending", path: "/profile/kyc", body: details, wantStatus: http.StatusConflict, wantCode: "KYC_PENDING", wantKYC: models.KYCStatusPending},
+		{name: "reject without notes", path: reject, body: gin.H{}, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_ERROR", wantKYC: models.KYCStatusPending},
+		{name: "reject", path: reject, body: gin.H{"notes": "Passport has expired"}, wantStatus: http.StatusOK, wantKYC: models.KYCStatusRejected},
+		{name: "resubmit", path: "/profile/kyc", body: details, wantStatus: http.StatusCreated, wantKYC: models.KYCStatusPending},
+		{name: "approve", path: approve, wantStatus: http.StatusOK, wantKYC: models.KYCStatusVerified},
+		{name: "submit once verified", path: "/profile/kyc", body: details, wantStatus: http.StatusConflict, wantCode: "KYC_ALREADY_VERIFIED", wantKYC: models.KYCStatusVerified},
+	}
+
+	for _, step := range steps {
+		w, code := postJSON(t, r, step.path, step.body)
+		if w.Code != step.wantStatus || code != step.wantCode {
+			t.Fatalf("%s: expected %d %s, got %d: %s", step.name, step.wantStatus, step.wantCode, w.Code, w.Body.String())
+		}
+		if status := kycStatus(); status != step.wantKYC {
+			t.Fatalf("%s: expected KYC status %s, got %s", step.name, step.wantKYC, status)
+		}
+	}
+
+	if len(userRepo.audits) != 2 || userRepo.audits[0].Action != models.AuditActionRejectKYC || userRepo.audits[1].Action != models.AuditActionApproveKYC {
+		t.Errorf("Expected the rejection and approval to be audited, got %+v", userRepo.audits)
+	}
+
+	w := get("/admin/kyc?status=rejected")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var listed struct {
+		Submissions []models.KYCSubmission `json:"submissions"`
+	}
+	if err := decodeData(w, &listed); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(listed.Submissions) != 1 || listed.Submissions[0].ReviewNotes != "Passport has expired" {
+		t.Errorf("Expected the rejected submission, got %+v", listed.Submissions)
+	}
+
+	if w := get("/admin/kyc?status=none"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown status filter, got %d", w.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// LoginAlertHandler handles responses to new-device login alerts
+type LoginAlertHandler struct {
+	loginAlertService *services.LoginAlertService
+}
+
+// NewLoginAlertHandler creates a new login alert handler
+func NewLoginAlertHandler(loginAlertService *services.LoginAlertService) *LoginAlertHandler {
+	return &LoginAlertHandler{
+		loginAlertService: loginAlertService,
+	}
+}
+
+// ReportLogin signs a user out everywhere after they report that a
+// new-device login was not them
+func (h *LoginAlertHandler) ReportLogin(c *gin.Context) {
+	var request models.LoginReport
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Revoke sessions
+	if err := h.loginAlertService.ReportLogin(request.Token); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidLoginReportToken):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_LOGIN_REPORT_TOKEN",
+				Message: "Invalid login report token",
+			})
+		case errors.Is(err, services.ErrLoginReportTokenExpired):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "LOGIN_REPORT_TOKEN_EXPIRED",
+				Message: "Login report token has expired",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "LOGIN_REPORT_FAILED",
+				Message: "Failed to sign out sessions",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message": "All sessions have been signed out. Reset your password to secure your account.",
+	})
+}
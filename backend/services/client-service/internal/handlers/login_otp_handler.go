@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/authcookie"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// LoginOTPHandler handles SMS one-time code login HTTP requests
+type LoginOTPHandler struct {
+	loginOTPService *services.LoginOTPService
+	cookies         *authcookie.Config
+}
+
+// NewLoginOTPHandler creates a new SMS login handler. Tokens are delivered
+// like password logins, in cookies as cookies allows.
+func NewLoginOTPHandler(loginOTPService *services.LoginOTPService, cookies *authcookie.Config) *LoginOTPHandler {
+	return &LoginOTPHandler{
+		loginOTPService: loginOTPService,
+		cookies:         cookies,
+	}
+}
+
+// RequestCode texts a login code to a verified phone number
+func (h *LoginOTPHandler) RequestCode(c *gin.Context) {
+	var request models.LoginOTPRequest
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Always respond the same way so the endpoint cannot be used to discover accounts
+	if err := h.loginOTPService.RequestLoginOTP(request); err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		log.Printf("SMS login code request failed: %v", err)
+	}
+
+	httpx.Respond(c, http.StatusAccepted, gin.H{
+		"message": "If an account has verified this phone number, a login code has been sent",
+	})
+}
+
+// VerifyCode exchanges a phone number and login code for the same tokens a
+// password login returns
+func (h *LoginOTPHandler) VerifyCode(c *gin.Context) {
+	var verify models.LoginOTPVerify
+
+	// Bind and validate request body
+	if !bindJSON(c, &verify) {
+		return
+	}
+
+	// Authenticate user
+	meta := models.LoginMetadata{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	user, session, err := h.loginOTPService.VerifyLoginOTP(verify, meta)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidLoginCode) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusUnauthorized,
+				Code:    "INVALID_LOGIN_CODE",
+				Message: "Invalid phone number or login code",
+			})
+			return
+		}
+
+		if respondSuspendedError(c, err) || respondPasswordResetRequiredError(c, err) || respondPasswordSetupRequiredError(c, err) {
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "LOGIN_FAILED",
+			Message: "Failed to authenticate user",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	respondSession(c, h.cookies, verify.UseCookies, user, session)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/authcookie"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// MagicLinkHandler handles passwordless email sign-in HTTP requests
+type MagicLinkHandler struct {
+	magicLinkService *services.MagicLinkService
+	cookies          *authcookie.Config
+	redirectURL      string
+}
+
+// NewMagicLinkHandler creates a new sign-in link handler. Tokens are
+// delivered like password logins, in cookies as cookies allows. A non-empty
+// redirectURL sends users who follow a link to that frontend page instead,
+// with the outcome in the URL fragment.
+func NewMagicLinkHandler(magicLinkService *services.MagicLinkService, cookies *authcookie.Config, redirectURL string) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinkService: magicLinkService,
+		cookies:          cookies,
+		redirectURL:      redirectURL,
+	}
+}
+
+// RequestLink emails a sign-in link to an account
+func (h *MagicLinkHandler) RequestLink(c *gin.Context) {
+	var request models.MagicLinkRequest
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Always respond the same way so the endpoint cannot be used to discover accounts
+	if err := h.magicLinkService.RequestMagicLink(request); err != nil {
+		log.Printf("Sign-in link request failed: %v", err)
+	}
+
+	httpx.Respond(c, http.StatusAccepted, gin.H{
+		"message": "If an account exists for this email, a sign-in link has been sent",
+	})
+}
+
+// ConsumeLink exchanges the token from a sign-in link for the same tokens a
+// password login returns
+func (h *MagicLinkHandler) ConsumeLink(c *gin.Context) {
+	// The token is in the URL, so keep the response out of caches and
+	// referrers
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+
+	var consume models.MagicLinkConsume
+
+	// Bind and validate query parameters
+	if !bindQuery(c, &consume) {
+		return
+	}
+
+	// Authenticate user
+	meta := models.LoginMetadata{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	user, session, err := h.magicLinkService.ConsumeMagicLink(consume.Token, meta)
+	if h.redirectURL != "" {
+		h.redirect(c, session, err)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMagicLink):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_MAGIC_LINK",
+				Message: "Invalid or already used sign-in link",
+			})
+		case errors.Is(err, services.ErrMagicLinkExpired):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "MAGIC_LINK_EXPIRED",
+				Message: "Sign-in link has expired; please request a new one",
+			})
+		case respondSuspendedError(c, err) || respondPasswordResetRequiredError(c, err) || respondPasswordSetupRequiredError(c, err):
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "LOGIN_FAILED",
+				Message: "Failed to authenticate user",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	respondSession(c, h.cookies, false, user, session)
+}
+
+// redirect sends the user to the frontend with the tokens, or the error
+// code, in the URL fragment. Fragments are not sent to servers, so the
+// tokens only reach the frontend's scripts. In cookie mode the tokens are
+// set as cookies and only what scripts need is in the fragment.
+func (h *MagicLinkHandler) redirect(c *gin.Context, session *services.Session, err error) {
+	fragment := url.Values{}
+	if err != nil {
+		code := magicLinkErrorCode(err)
+		if code == "LOGIN_FAILED" {
+			log.Printf("Sign-in link consumption failed: %v", err)
+		}
+		fragment.Set("error", code)
+		c.Redirect(http.StatusFound, h.redirectURL+"#"+fragment.Encode())
+		return
+	}
+
+	fragment.Set("access_token", session.AccessToken)
+	fragment.Set("refresh_token", session.RefreshToken)
+	fragment.Set("refresh_token_expires_at", session.RefreshTokenExpiresAt.UTC().Format(time.RFC3339))
+	fragment.Set("token_type", "Bearer")
+
+	if h.cookies.Use(false) {
+		csrfToken, err := h.cookies.SetSession(c.Writer, session.RefreshToken, session.RefreshTokenExpiresAt, session.AccessToken)
+		if err != nil {
+			log.Printf("Failed to set session cookies after sign-in link: %v", err)
+			c.Redirect(http.StatusFound, h.redirectURL+"#"+url.Values{"error": {"LOGIN_FAILED"}}.Encode())
+			return
+		}
+		fragment.Del("refresh_token")
+		if h.cookies.AccessToken {
+			fragment.Del("access_token")
+		}
+		fragment.Set("csrf_token", csrfToken)
+	}
+
+	c.Redirect(http.StatusFound, h.redirectURL+"#"+fragment.Encode())
+}
+
+// magicLinkErrorCode returns the error code a redirect reports for err,
+// matching the codes of the JSON responses
+func magicLinkErrorCode(err error) string {
+	var suspension *services.SuspensionError
+	switch {
+	case errors.Is(err, services.ErrInvalidMagicLink):
+		return "INVALID_MAGIC_LINK"
+	case errors.Is(err, services.ErrMagicLinkExpired):
+		return "MAGIC_LINK_EXPIRED"
+	case errors.As(err, &suspension) && suspension.IsTemporary():
+		return "ACCOUNT_SUSPENDED_TEMPORARILY"
+	case errors.Is(err, services.ErrAccountSuspended):
+		return "ACCOUNT_SUSPENDED"
+	case errors.Is(err, services.ErrPasswordResetRequired):
+		return "PASSWORD_RESET_REQUIRED"
+	case errors.Is(err, services.ErrPasswordSetupRequired):
+		return "PASSWORD_SETUP_REQUIRED"
+	default:
+		return "LOGIN_FAILED"
+	}
+}
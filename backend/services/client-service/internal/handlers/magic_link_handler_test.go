@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/authcookie"
+	"microbank/client-service/internal/services"
+)
+
+func TestMagicLinkHandler_ConsumeInvalidLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := services.NewMagicLinkService(newFakeUserRepo(), fakeMagicLinkTokenRepo{}, nil, nil)
+
+	tests := []struct {
+		name         string
+		redirectURL  string
+		wantStatus   int
+		wantCode     string
+		wantLocation string
+	}{
+		{name: "json", wantStatus: http.StatusBadRequest, wantCode: "INVALID_MAGIC_LINK"},
+		{name: "redirect", redirectURL: "https://app.example.com/signed-in", wantStatus: http.StatusFound, wantLocation: "https://app.example.com/signed-in#error=INVALID_MAGIC_LINK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewMagicLinkHandler(svc, &authcookie.Config{}, tt.redirectURL)
+			r := gin.New()
+			r.GET("/auth/magic-link/consume", handler.ConsumeLink)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/magic-link/consume?token=unknown", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := decodeErrorCode(t, w); code != tt.wantCode {
+					t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+				}
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Expected Location %q, got %q", tt.wantLocation, got)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Expected Cache-Control no-store, got %q", got)
+			}
+		})
+	}
+}
+
+func TestMagicLinkHandler_ConsumeRequiresToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewMagicLinkHandler(services.NewMagicLinkService(nil, nil, nil, nil), &authcookie.Config{}, "")
+	r := gin.New()
+	r.GET("/auth/magic-link/consume", handler.ConsumeLink)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/magic-link/consume", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	if code := decodeErrorCode(t, w); code != "VALIDATION_ERROR" {
+		t.Errorf("Expected code %q, got %q", "VALIDATION_ERROR", code)
+	}
+}
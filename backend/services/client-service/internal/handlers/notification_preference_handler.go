@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// NotificationPreferenceHandler handles notification preference HTTP requests
+type NotificationPreferenceHandler struct {
+	notificationPreferenceService *services.NotificationPreferenceService
+}
+
+// NewNotificationPreferenceHandler creates a new notification preference handler
+func NewNotificationPreferenceHandler(notificationPreferenceService *services.NotificationPreferenceService) *NotificationPreferenceHandler {
+	return &NotificationPreferenceHandler{
+		notificationPreferenceService: notificationPreferenceService,
+	}
+}
+
+// GetPreferences returns the current user's channels for every notification event
+func (h *NotificationPreferenceHandler) GetPreferences(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get preferences
+	preferences, err := h.notificationPreferenceService.GetPreferences(userUUID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "NOTIFICATION_PREFERENCES_FAILED",
+			Message: "Failed to retrieve notification preferences",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return preferences
+	httpx.RespondOK(c, gin.H{
+		"message":     "Notification preferences retrieved successfully",
+		"preferences": preferences,
+	})
+}
+
+// UpdatePreferences changes the current user's channels for the supplied
+// notification events
+func (h *NotificationPreferenceHandler) UpdatePreferences(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.NotificationPreferencesUpdate
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Update preferences
+	preferences, err := h.notificationPreferenceService.UpdatePreferences(userUUID, request)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "NOTIFICATION_PREFERENCES_UPDATE_FAILED",
+			Message: "Failed to update notification preferences",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return updated preferences
+	httpx.RespondOK(c, gin.H{
+		"message":     "Notification preferences updated successfully",
+		"preferences": preferences,
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+)
+
+func TestNotificationPreferenceHandler_UpdatePreferences(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantLogin  models.NotificationChannels
+	}{
+		{
+			name:       "enable SMS login alerts",
+			body:       `{"preferences": {"login_alert": {"sms": true}}}`,
+			wantStatus: http.StatusOK,
+			wantLogin:  models.NotificationChannels{Email: true, SMS: true},
+		},
+		{
+			name:       "unknown event",
+			body:       `{"preferences": {"login_alert": {"sms": true}, "birthday": {"email": true}}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing preferences",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			handler := NewNotificationPreferenceHandler(services.NewNotificationPreferenceService(&fakeNotificationPreferenceRepo{}))
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set("user_id", userID.String()) })
+			r.GET("/profile/notifications", handler.GetPreferences)
+			r.PUT("/profile/notifications", handler.UpdatePreferences)
+
+			req := httptest.NewRequest(http.MethodPut, "/profile/notifications", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			// The change is visible on a fresh read
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/profile/notifications", nil))
+
+			var response struct {
+				Preferences models.NotificationPreferences `json:"preferences"`
+			}
+			if err := decodeData(w, &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if got := response.Preferences[models.NotificationEventLoginAlert]; got != tt.wantLogin {
+				t.Errorf("Expected login alerts %+v, got %+v", tt.wantLogin, got)
+			}
+			if got := response.Preferences[models.NotificationEventStatementReady]; got != (models.NotificationChannels{Email: true}) {
+				t.Errorf("Expected statement_ready to keep its default, got %+v", got)
+			}
+		})
+	}
+}
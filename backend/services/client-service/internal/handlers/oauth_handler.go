@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// OAuthHandler handles sign-in with third-party identity providers
+type OAuthHandler struct {
+	oauthService *services.OAuthService
+}
+
+// NewOAuthHandler creates a new OAuth handler
+func NewOAuthHandler(oauthService *services.OAuthService) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+	}
+}
+
+// StartOAuth returns the provider's consent page URL for signing in
+func (h *OAuthHandler) StartOAuth(c *gin.Context) {
+	h.start(c, false)
+}
+
+// StartOAuthLink returns the provider's consent page URL for linking the
+// provider to the current user
+func (h *OAuthHandler) StartOAuthLink(c *gin.Context) {
+	h.start(c, true)
+}
+
+// start begins an OAuth flow, linking to the current user when link is set
+func (h *OAuthHandler) start(c *gin.Context, link bool) {
+	var userID *uuid.UUID
+	if link {
+		userUUID, ok := userIDFromContext(c)
+		if !ok {
+			return
+		}
+		userID = &userUUID
+	}
+
+	// Start OAuth flow
+	authURL, err := h.oauthService.StartOAuth(c.Param("provider"), userID)
+	if err != nil {
+		if respondOAuthError(c, err) {
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "OAUTH_START_FAILED",
+			Message: "Failed to start sign-in",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return consent page URL
+	httpx.RespondOK(c, gin.H{
+		"message":           "Redirect the user to the authorization URL",
+		"authorization_url": authURL,
+	})
+}
+
+// Callback completes an OAuth flow after the provider redirects back. It
+// signs the user in, or links the provider when the flow was started by a
+// signed-in user.
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	var callback models.OAuthCallback
+
+	// Bind and validate query parameters
+	if !bindQuery(c, &callback) {
+		return
+	}
+
+	// Complete OAuth flow
+	meta := models.LoginMetadata{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	result, err := h.oauthService.CompleteOAuth(c.Param("provider"), callback, meta)
+	if err != nil {
+		if respondOAuthError(c, err) || respondSuspendedError(c, err) || respondPasswordResetRequiredError(c, err) || respondPasswordSetupRequiredError(c, err) || respondRegistrationGateError(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, services.ErrEmailPendingDeletion):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "EMAIL_PENDING_DELETION",
+				Message: "This email belongs to a deleted account and cannot be reused yet",
+			})
+		case errors.Is(err, services.ErrUserNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "OAUTH_FAILED",
+				Message: "Failed to complete sign-in",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Linking leaves the current session as it is
+	if result.AccessToken == "" {
+		httpx.RespondOK(c, gin.H{
+			"message": "Account linked successfully",
+			"user":    result.User.ToResponse(),
+		})
+		return
+	}
+
+	// Return success response with tokens
+	httpx.RespondOK(c, gin.H{
+		"message": "Login successful",
+		"user":    result.User.ToResponse(),
+		"created": result.Created,
+		"tokens": gin.H{
+			"access_token":             result.AccessToken,
+			"refresh_token":            result.RefreshToken,
+			"refresh_token_expires_at": result.RefreshTokenExpiresAt,
+			"token_type":               "Bearer",
+		},
+	})
+}
+
+// respondOAuthError writes a response when err is an OAuth flow failure
+// and reports whether it did
+func respondOAuthError(c *gin.Context, err error) bool {
+	status, code, message := http.StatusBadRequest, "", ""
+	switch {
+	case errors.Is(err, services.ErrOAuthNotConfigured):
+		status, code, message = http.StatusNotFound, "OAUTH_PROVIDER_NOT_CONFIGURED", "This sign-in provider is not available"
+	case errors.Is(err, services.ErrInvalidOAuthState):
+		code, message = "INVALID_OAUTH_STATE", "Sign-in request is invalid or was already used"
+	case errors.Is(err, services.ErrOAuthStateExpired):
+		code, message = "OAUTH_STATE_EXPIRED", "Sign-in request has expired; please start again"
+	case errors.Is(err, services.ErrOAuthExchangeFailed):
+		status, code, message = http.StatusBadGateway, "OAUTH_EXCHANGE_FAILED", "The sign-in provider rejected the request"
+	case errors.Is(err, services.ErrOAuthEmailNotVerified):
+		status, code, message = http.StatusForbidden, "OAUTH_EMAIL_NOT_VERIFIED", "The provider has not verified your email address"
+	case errors.Is(err, services.ErrOAuthAccountLinked):
+		status, code, message = http.StatusConflict, "OAUTH_ACCOUNT_LINKED", "This provider account is linked to a different user"
+	default:
+		return false
+	}
+
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	})
+	return true
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/services"
+)
+
+func TestOAuthHandler_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewOAuthHandler(services.NewOAuthService(nil, nil, nil))
+	r := gin.New()
+	r.GET("/auth/oauth/:provider/start", handler.StartOAuth)
+	r.GET("/auth/oauth/:provider/callback", handler.Callback)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "unconfigured provider", path: "/auth/oauth/google/start", wantStatus: http.StatusNotFound, wantCode: "OAUTH_PROVIDER_NOT_CONFIGURED"},
+		{name: "callback without code", path: "/auth/oauth/google/callback?state=abc", wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_ERROR"},
+		{name: "callback for unconfigured provider", path: "/auth/oauth/google/callback?state=abc&code=xyz", wantStatus: http.StatusNotFound, wantCode: "OAUTH_PROVIDER_NOT_CONFIGURED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if code := decodeErrorCode(t, w); code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/authmw"
+	"microbank/pkg/httpx"
+)
+
+// PersonalAccessTokenHandler handles personal access token HTTP requests
+type PersonalAccessTokenHandler struct {
+	tokenService *services.PersonalAccessTokenService
+}
+
+// NewPersonalAccessTokenHandler creates a new personal access token handler
+func NewPersonalAccessTokenHandler(tokenService *services.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{
+		tokenService: tokenService,
+	}
+}
+
+// CreateToken creates a personal access token for the current user. The
+// token is only shown in this response.
+func (h *PersonalAccessTokenHandler) CreateToken(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.PersonalAccessTokenRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Create token
+	token, err := h.tokenService.CreateToken(userUUID, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidScope):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_SCOPE",
+				Message: "Unknown scope",
+				Details: gin.H{"error": err.Error(), "scopes": authmw.Scopes},
+			})
+		case errors.Is(err, services.ErrPersonalAccessTokenNameRequired):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "TOKEN_NAME_REQUIRED",
+				Message: "A token name is required",
+			})
+		case errors.Is(err, services.ErrInvalidPersonalAccessTokenExpiry):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_TOKEN_EXPIRY",
+				Message: "Token expiry must be in the future",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "CREATE_TOKEN_FAILED",
+				Message: "Failed to create personal access token",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return created token
+	httpx.RespondCreated(c, gin.H{
+		"message": "Personal access token created successfully; copy it now, it will not be shown again",
+		"token":   token,
+	})
+}
+
+// ListTokens retrieves the current user's personal access tokens, without
+// their secrets
+func (h *PersonalAccessTokenHandler) ListTokens(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get tokens
+	tokens, err := h.tokenService.ListTokens(userUUID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_TOKENS_FAILED",
+			Message: "Failed to fetch personal access tokens",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return tokens
+	httpx.RespondOK(c, gin.H{
+		"message": "Personal access tokens retrieved successfully",
+		"tokens":  tokens,
+	})
+}
+
+// RevokeToken deletes one of the current user's personal access tokens
+func (h *PersonalAccessTokenHandler) RevokeToken(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get token ID from URL parameter
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_TOKEN_ID",
+			Message: "Invalid token ID format",
+		})
+		return
+	}
+
+	// Revoke token
+	if err := h.tokenService.RevokeToken(userUUID, tokenID); err != nil {
+		if errors.Is(err, services.ErrPersonalAccessTokenNotFound) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "TOKEN_NOT_FOUND",
+				Message: "Personal access token not found",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "REVOKE_TOKEN_FAILED",
+			Message: "Failed to revoke personal access token",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message":  "Personal access token revoked successfully",
+		"token_id": tokenID,
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/revocation"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/authmw"
+)
+
+func TestPersonalAccessTokenHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := newTestUser(t, "user@example.com")
+	setAdmin(user, true)
+	userRepo := newFakeUserRepo(user)
+	tokenRepo := &fakePersonalAccessTokenRepo{}
+	tokenService := services.NewPersonalAccessTokenService(tokenRepo, userRepo)
+	handler := NewPersonalAccessTokenHandler(tokenService)
+	authHandler := NewAuthHandler(nil, nil, nil)
+	revocations := revocation.NewMemoryStore()
+
+	r := gin.New()
+	setUser := func(c *gin.Context) { c.Set("user_id", user.ID.String()) }
+	r.POST("/profile/tokens", setUser, handler.CreateToken)
+	r.GET("/profile/tokens", setUser, handler.ListTokens)
+	r.DELETE("/profile/tokens/:id", setUser, handler.RevokeToken)
+	r.GET("/auth/validate", middleware.AuthMiddleware(testTokens, userRepo, revocations, middleware.PersonalAccessTokens(tokenService)), authHandler.ValidateToken)
+	r.GET("/profile", middleware.AuthMiddleware(testTokens, userRepo, revocations), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Unknown scopes are refused
+	if w, code := postJSON(t, r, "/profile/tokens", map[string]interface{}{"name": "ci", "scopes": []string{"write:balance"}}); w.Code != http.StatusBadRequest || code != "INVALID_SCOPE" {
+		t.Fatalf("Expected 400 INVALID_SCOPE, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The token is shown once, on creation
+	w, _ := postJSON(t, r, "/profile/tokens", map[string]interface{}{"name": "ci", "scopes": []string{authmw.ScopeReadBalance}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Token models.CreatedPersonalAccessToken `json:"token"`
+	}
+	if err := decodeData(w, &created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	secret := created.Token.Token
+	if !strings.HasPrefix(secret, authmw.PersonalAccessTokenPrefix) {
+		t.Fatalf("Expected a pat_ token, got %q", secret)
+	}
+
+	if w := send(http.MethodGet, "/profile/tokens", ""); w.Code != http.StatusOK || strings.Contains(w.Body.String(), secret) || !strings.Contains(w.Body.String(), created.Token.ID.String()) {
+		t.Errorf("Expected the token to be listed without its secret, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The banking service confirms the token through the validate endpoint
+	w = send(http.MethodGet, "/auth/validate?view=service", secret)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var validation struct {
+		UserID  string   `json:"user_id"`
+		IsAdmin bool     `json:"is_admin"`
+		Scopes  []string `json:"scopes"`
+	}
+	if err := decodeData(w, &validation); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if validation.UserID != user.ID.String() || validation.IsAdmin || !reflect.DeepEqual(validation.Scopes, []string{authmw.ScopeReadBalance}) {
+		t.Errorf("Unexpected validation: %+v", validation)
+	}
+
+	// Other routes do not accept personal access tokens
+	if w := send(http.MethodGet, "/profile", secret); w.Code != http.StatusUnauthorized || decodeErrorCode(t, w) != "INVALID_TOKEN" {
+		t.Errorf("Expected 401 INVALID_TOKEN, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Revoked tokens stop working at once
+	if w := send(http.MethodDelete, "/profile/tokens/"+created.Token.ID.String(), ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodDelete, "/profile/tokens/"+created.Token.ID.String(), ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodGet, "/auth/validate?view=service", secret); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// PhoneVerificationHandler handles phone number verification HTTP requests
+type PhoneVerificationHandler struct {
+	phoneVerificationService *services.PhoneVerificationService
+}
+
+// NewPhoneVerificationHandler creates a new phone verification handler
+func NewPhoneVerificationHandler(phoneVerificationService *services.PhoneVerificationService) *PhoneVerificationHandler {
+	return &PhoneVerificationHandler{
+		phoneVerificationService: phoneVerificationService,
+	}
+}
+
+// StartPhoneVerification texts a verification code to the current user's
+// phone number
+func (h *PhoneVerificationHandler) StartPhoneVerification(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Send code
+	if err := h.phoneVerificationService.StartPhoneVerification(userUUID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPhoneNumberMissing):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "PHONE_NUMBER_MISSING",
+				Message: "Add a phone number to your profile first",
+			})
+		case errors.Is(err, services.ErrPhoneAlreadyVerified):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "PHONE_ALREADY_VERIFIED",
+				Message: "Phone number is already verified",
+			})
+		case errors.Is(err, services.ErrPhoneVerificationRateLimited):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusTooManyRequests,
+				Code:    "TOO_MANY_VERIFICATION_CODES",
+				Message: "Too many verification codes requested; try again later",
+			})
+		case errors.Is(err, services.ErrSMSDeliveryFailed):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadGateway,
+				Code:    "SMS_DELIVERY_FAILED",
+				Message: "Failed to send the verification code",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "PHONE_VERIFICATION_FAILED",
+				Message: "Failed to start phone verification",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return success response
+	httpx.Respond(c, http.StatusAccepted, gin.H{
+		"message": "Verification code sent",
+	})
+}
+
+// ConfirmPhoneVerification checks the code the current user received and
+// marks their phone number verified
+func (h *PhoneVerificationHandler) ConfirmPhoneVerification(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.PhoneVerificationConfirm
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Check code
+	user, err := h.phoneVerificationService.ConfirmPhoneVerification(userUUID, request.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidVerificationCode):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_VERIFICATION_CODE",
+				Message: "Verification code is invalid",
+			})
+		case errors.Is(err, services.ErrVerificationCodeExpired):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "VERIFICATION_CODE_EXPIRED",
+				Message: "Verification code has expired",
+			})
+		case errors.Is(err, services.ErrVerificationAttemptsExceeded):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusTooManyRequests,
+				Code:    "VERIFICATION_ATTEMPTS_EXCEEDED",
+				Message: "Too many attempts; request a new code",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "PHONE_VERIFICATION_FAILED",
+				Message: "Failed to verify phone number",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	// Return updated profile
+	httpx.RespondOK(c, gin.H{
+		"message": "Phone number verified successfully",
+		"profile": user.ToResponse(),
+	})
+}
+
+// userIDFromContext reads the authenticated user's ID set by AuthMiddleware.
+// It writes a 500 and returns false if the ID is missing or malformed.
+func userIDFromContext(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
+		})
+		return uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
+		})
+		return uuid.Nil, false
+	}
+
+	return userUUID, true
+}
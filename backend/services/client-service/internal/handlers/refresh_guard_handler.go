@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+	"microbank/pkg/httpx"
+)
+
+// RefreshGuardHandler handles HTTP requests to view and clear the IPs
+// blocked from refreshing tokens
+type RefreshGuardHandler struct {
+	guard *middleware.RefreshGuard
+}
+
+// NewRefreshGuardHandler creates a new refresh guard handler
+func NewRefreshGuardHandler(guard *middleware.RefreshGuard) *RefreshGuardHandler {
+	return &RefreshGuardHandler{
+		guard: guard,
+	}
+}
+
+// ListBlocks lists the IPs blocked from refreshing tokens now (admin only)
+func (h *RefreshGuardHandler) ListBlocks(c *gin.Context) {
+	httpx.RespondOK(c, gin.H{
+		"message": "Refresh blocks retrieved successfully",
+		"blocks":  h.guard.Blocks(),
+	})
+}
+
+// ClearBlock lets an IP refresh tokens again before its block ends (admin
+// only)
+func (h *RefreshGuardHandler) ClearBlock(c *gin.Context) {
+	ip := c.Param("ip")
+	if !h.guard.Unblock(ip) {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "REFRESH_BLOCK_NOT_FOUND",
+			Message: "No refresh block found for this IP",
+		})
+		return
+	}
+
+	log.Printf("Admin %s cleared the refresh block on %s", c.GetString("user_id"), ip)
+
+	httpx.RespondOK(c, gin.H{
+		"message": "Refresh block cleared successfully",
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+)
+
+func TestRefreshGuardHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	guard := middleware.NewRefreshGuard(middleware.RefreshGuardConfig{Threshold: 1, Window: time.Minute, BlockDuration: time.Hour})
+	guard.RecordInvalid("2001:db8::1")
+	handler := NewRefreshGuardHandler(guard)
+
+	r := gin.New()
+	r.GET("/admin/security/refresh-blocks", handler.ListBlocks)
+	r.DELETE("/admin/security/refresh-blocks/:ip", handler.ClearBlock)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/security/refresh-blocks", nil))
+	var response struct {
+		Blocks []middleware.RefreshBlock `json:"blocks"`
+	}
+	if err := decodeData(w, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Blocks) != 1 || response.Blocks[0].IP != "2001:db8::1" {
+		t.Fatalf("Expected the blocked IP to be listed, got %+v", response.Blocks)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/security/refresh-blocks/2001:db8::1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if blocks := guard.Blocks(); len(blocks) != 0 {
+		t.Errorf("Expected the block to be cleared, got %+v", blocks)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/security/refresh-blocks/2001:db8::1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an IP that is not blocked, got %d", w.Code)
+	}
+	if code := decodeErrorCode(t, w); code != "REFRESH_BLOCK_NOT_FOUND" {
+		t.Errorf("Expected code %q, got %q", "REFRESH_BLOCK_NOT_FOUND", code)
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// StatementHandler handles monthly statement email HTTP requests
+type StatementHandler struct {
+	statementService *services.StatementService
+}
+
+// NewStatementHandler creates a new statement handler
+func NewStatementHandler(statementService *services.StatementService) *StatementHandler {
+	return &StatementHandler{
+		statementService: statementService,
+	}
+}
+
+// GetSubscription returns the current user's statement email subscription
+func (h *StatementHandler) GetSubscription(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get subscription
+	subscription, err := h.statementService.GetSubscription(userUUID)
+	if err != nil {
+		respondStatementSubscriptionNotFound(c)
+		return
+	}
+
+	// Return subscription
+	httpx.RespondOK(c, gin.H{
+		"message":      "Statement subscription retrieved successfully",
+		"subscription": subscription,
+	})
+}
+
+// UpdateSubscription subscribes the current user to monthly statement
+// emails on the requested day, or changes the day of their subscription
+func (h *StatementHandler) UpdateSubscription(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.StatementSubscriptionRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Save subscription
+	subscription, err := h.statementService.Subscribe(userUUID, request)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "STATEMENT_SUBSCRIPTION_FAILED",
+			Message: "Failed to save statement subscription",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return subscription
+	httpx.RespondOK(c, gin.H{
+		"message":      "Statement subscription saved successfully",
+		"subscription": subscription,
+	})
+}
+
+// DeleteSubscription unsubscribes the current user from statement emails
+func (h *StatementHandler) DeleteSubscription(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Remove subscription
+	if err := h.statementService.Unsubscribe(userUUID); err != nil {
+		if errors.Is(err, services.ErrStatementSubscriptionNotFound) {
+			respondStatementSubscriptionNotFound(c)
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "STATEMENT_UNSUBSCRIBE_FAILED",
+			Message: "Failed to remove statement subscription",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message": "Statement subscription removed successfully",
+	})
+}
+
+// SendStatement emails a client their statement for the month in the URL
+// straight away (staff only). A failed attempt is answered 202 and retried
+// in the background.
+func (h *StatementHandler) SendStatement(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Send statement
+	delivery, err := h.statementService.SendNow(userID, c.Param("period"), actor.AdminID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidStatementPeriod):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_STATEMENT_PERIOD",
+				Message: "Statement period must be a past month in YYYY-MM form",
+			})
+		case errors.Is(err, services.ErrUserNotFound):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+		case errors.Is(err, services.ErrStatementAlreadySent):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "STATEMENT_ALREADY_SENT",
+				Message: "The statement for this month has already been sent",
+			})
+		case errors.Is(err, services.ErrStatementDeliveryInProgress):
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "STATEMENT_DELIVERY_IN_PROGRESS",
+				Message: "The statement for this month is being sent",
+			})
+		default:
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusInternalServerError,
+				Code:    "STATEMENT_SEND_FAILED",
+				Message: "Failed to send statement",
+				Details: middleware.ErrorDetails(c, err),
+			})
+		}
+		return
+	}
+
+	if delivery.Status != models.StatementDeliverySent {
+		httpx.Respond(c, http.StatusAccepted, gin.H{
+			"message":  "Statement could not be sent yet and will be retried",
+			"delivery": delivery,
+		})
+		return
+	}
+
+	// Return delivery
+	httpx.RespondOK(c, gin.H{
+		"message":  "Statement sent successfully",
+		"delivery": delivery,
+	})
+}
+
+// respondStatementSubscriptionNotFound writes the 404 for a user without a
+// statement subscription
+func respondStatementSubscriptionNotFound(c *gin.Context) {
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusNotFound,
+		Code:    "STATEMENT_SUBSCRIPTION_NOT_FOUND",
+		Message: "You are not subscribed to statement emails",
+	})
+}
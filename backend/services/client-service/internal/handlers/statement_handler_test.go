@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/mailer"
+)
+
+func TestStatementHandler_Subscription(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := newTestUser(t, "client@example.com")
+	userRepo := newFakeUserRepo(user)
+	handler := NewStatementHandler(services.NewStatementService(&fakeStatementRepo{}, userRepo, &fakeBankingClient{}, mailer.NewLogSender(log.New(io.Discard, "", 0)), services.NewNotificationPreferenceService(&fakeNotificationPreferenceRepo{})))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", user.ID.String()) })
+	r.GET("/profile/statements/subscription", handler.GetSubscription)
+	r.PUT("/profile/statements/subscription", handler.UpdateSubscription)
+	r.DELETE("/profile/statements/subscription", handler.DeleteSubscription)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/profile/statements/subscription", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	steps := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantDay    int
+	}{
+		{name: "not subscribed", method: http.MethodGet, wantStatus: http.StatusNotFound, wantCode: "STATEMENT_SUBSCRIPTION_NOT_FOUND"},
+		{name: "day out of range", method: http.MethodPut, body: `{"delivery_day": 31}`, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_ERROR"},
+		{name: "missing day", method: http.MethodPut, body: `{}`, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_ERROR"},
+		{name: "subscribe", method: http.MethodPut, body: `{"delivery_day": 3}`, wantStatus: http.StatusOK, wantDay: 3},
+		{name: "read back", method: http.MethodGet, wantStatus: http.StatusOK, wantDay: 3},
+		{name: "unsubscribe", method: http.MethodDelete, wantStatus: http.StatusOK},
+		{name: "unsubscribe again", method: http.MethodDelete, wantStatus: http.StatusNotFound, wantCode: "STATEMENT_SUBSCRIPTION_NOT_FOUND"},
+	}
+
+	for _, step := range steps {
+		w := do(step.method, step.body)
+		if w.Code != step.wantStatus {
+			t.Fatalf("%s: expected status %d, got %d: %s", step.name, step.wantStatus, w.Code, w.Body.String())
+		}
+		if step.wantCode != "" {
+			if code := decodeErrorCode(t, w); code != step.wantCode {
+				t.Errorf("%s: expected error code %s, got %s", step.name, step.wantCode, code)
+			}
+		}
+		if step.wantDay != 0 {
+			var response struct {
+				Subscription models.StatementSubscription `json:"subscription"`
+			}
+			if err := decodeData(w, &response); err != nil {
+				t.Fatalf("%s: failed to decode response: %v", step.name, err)
+			}
+			if response.Subscription.DeliveryDay != step.wantDay {
+				t.Errorf("%s: expected delivery day %d, got %d", step.name, step.wantDay, response.Subscription.DeliveryDay)
+			}
+		}
+	}
+}
+
+func TestStatementHandler_SendStatement(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := newTestUser(t, "support@example.com")
+	user := newTestUser(t, "client@example.com")
+	userRepo := newFakeUserRepo(admin, user)
+	banking := &fakeBankingClient{statement: models.Statement{Currency: "USD", OpeningBalance: 10, ClosingBalance: 10}}
+	handler := NewStatementHandler(services.NewStatementService(&fakeStatementRepo{}, userRepo, banking, mailer.NewLogSender(log.New(io.Discard, "", 0)), services.NewNotificationPreferenceService(&fakeNotificationPreferenceRepo{})))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", admin.ID.String()) })
+	r.POST("/admin/clients/:id/statements/:period/send", handler.SendStatement)
+
+	send := func(userID, period string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/clients/"+userID+"/statements/"+period+"/send", nil))
+		return w
+	}
+
+	steps := []struct {
+		name       string
+		userID     string
+		period     string
+		fail       bool
+		wantStatus int
+		wantCode   string
+		wantState  string
+	}{
+		{name: "invalid user ID", userID: "not-a-uuid", period: "2020-01", wantStatus: http.StatusBadRequest, wantCode: "INVALID_USER_ID"},
+		{name: "unknown user", userID: uuid.NewString(), period: "2020-01", wantStatus: http.StatusNotFound, wantCode: "USER_NOT_FOUND"},
+		{name: "malformed period", userID: user.ID.String(), period: "2020-1", wantStatus: http.StatusBadRequest, wantCode: "INVALID_STATEMENT_PERIOD"},
+		{name: "current month", userID: user.ID.String(), period: "9999-01", wantStatus: http.StatusBadRequest, wantCode: "INVALID_STATEMENT_PERIOD"},
+		{name: "banking unavailable", userID: user.ID.String(), period: "2020-01", fail: true, wantStatus: http.StatusAccepted, wantState: models.StatementDeliveryPending},
+		{name: "sent", userID: user.ID.String(), period: "2020-01", wantStatus: http.StatusOK, wantState: models.StatementDeliverySent},
+		{name: "sent twice", userID: user.ID.String(), period: "2020-01", wantStatus: http.StatusConflict, wantCode: "STATEMENT_ALREADY_SENT"},
+	}
+
+	for _, step := range steps {
+		banking.err = nil
+		if step.fail {
+			banking.err = errors.New("banking service unavailable")
+		}
+		w := send(step.userID, step.period)
+		if w.Code != step.wantStatus {
+			t.Fatalf("%s: expected status %d, got %d: %s", step.name, step.wantStatus, w.Code, w.Body.String())
+		}
+		if step.wantCode != "" {
+			if code := decodeErrorCode(t, w); code != step.wantCode {
+				t.Errorf("%s: expected error code %s, got %s", step.name, step.wantCode, code)
+			}
+			continue
+		}
+
+		var response struct {
+			Delivery models.StatementDelivery `json:"delivery"`
+		}
+		if err := decodeData(w, &response); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", step.name, err)
+		}
+		if response.Delivery.Status != step.wantState || response.Delivery.RequestedBy == nil || *response.Delivery.RequestedBy != admin.ID {
+			t.Errorf("%s: expected a %s delivery requested by the admin, got %+v", step.name, step.wantState, response.Delivery)
+		}
+	}
+}
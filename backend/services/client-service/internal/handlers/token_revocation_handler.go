@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// TokenRevocationHandler handles revoking access tokens before they expire
+type TokenRevocationHandler struct {
+	revocationService *services.TokenRevocationService
+}
+
+// NewTokenRevocationHandler creates a new token revocation handler
+func NewTokenRevocationHandler(revocationService *services.TokenRevocationService) *TokenRevocationHandler {
+	return &TokenRevocationHandler{
+		revocationService: revocationService,
+	}
+}
+
+// ForceLogout ends every session of a user, revoking their refresh tokens
+// and all of their unexpired access tokens, and optionally requires a
+// password reset before they log in again (admin only)
+func (h *TokenRevocationHandler) ForceLogout(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get user ID from URL parameter
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Bind the optional request body
+	var request models.ForceLogoutRequest
+	if c.Request.ContentLength != 0 {
+		if !bindJSON(c, &request) {
+			return
+		}
+	}
+
+	// Force logout
+	result, err := h.revocationService.ForceLogout(actor, userID, request.MustResetPassword)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusNotFound,
+				Code:    "USER_NOT_FOUND",
+				Message: "User not found",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FORCE_LOGOUT_FAILED",
+			Message: "Failed to log user out",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message":               "User logged out of all sessions",
+		"user_id":               userID,
+		"sessions_terminated":   result.SessionsTerminated,
+		"access_tokens_revoked": result.AccessTokensRevoked,
+		"must_reset_password":   request.MustResetPassword,
+	})
+}
+
+// PushRevocations adds access tokens revoked by another service to the
+// revocation list (internal only)
+func (h *TokenRevocationHandler) PushRevocations(c *gin.Context) {
+	var request models.TokenRevocationRequest
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Revoke tokens
+	if err := h.revocationService.RevokeTokens(request.Tokens); err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "REVOCATION_FAILED",
+			Message: "Failed to revoke tokens",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message": "Tokens revoked successfully",
+		"revoked": len(request.Tokens),
+	})
+}
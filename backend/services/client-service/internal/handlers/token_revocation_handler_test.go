@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/revocation"
+	"microbank/client-service/internal/services"
+)
+
+func TestTokenRevocationHandler_ForceLogout(t *testing.T) {
+	admin := newTestUser(t, "admin@example.com")
+	admin.IsAdmin = true
+	user := newTestUser(t, "client@example.com")
+	userRepo := newFakeUserRepo(admin, user)
+	refreshRepo := newFakeRefreshTokenRepo()
+	banking := &fakeBankingClient{}
+	auditRepo := &fakeAuditLogRepo{}
+	revocations := revocation.NewMemoryStore()
+
+	r := newAuthRouterWithRevocations(userRepo, refreshRepo, banking, revocations)
+	r.GET("/protected", middleware.AuthMiddleware(testTokens, userRepo, revocations), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	handler := NewTokenRevocationHandler(services.NewTokenRevocationService(userRepo, refreshRepo, auditRepo, revocations, banking))
+	r.POST("/admin/clients/:id/force-logout", func(c *gin.Context) {
+		c.Set("user_id", admin.ID.String())
+		handler.ForceLogout(c)
+	})
+
+	w, _ := postJSON(t, r, "/auth/login", gin.H{"email": user.Email, "password": testPassword})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Login failed with status %d: %s", w.Code, w.Body.String())
+	}
+	var login struct {
+		Tokens struct {
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+		} `json:"tokens"`
+	}
+	if err := decodeData(w, &login); err != nil {
+		t.Fatalf("failed to decode login response: %v", err)
+	}
+
+	getProtected := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+login.Tokens.AccessToken)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := getProtected(); w.Code != http.StatusOK {
+		t.Fatalf("Expected access token to work before the force logout, got %d", w.Code)
+	}
+
+	w, _ = postJSON(t, r, "/admin/clients/"+user.ID.String()+"/force-logout", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Force logout failed with status %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		SessionsTerminated  int `json:"sessions_terminated"`
+		AccessTokensRevoked int `json:"access_tokens_revoked"`
+	}
+	if err := decodeData(w, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.SessionsTerminated != 1 || response.AccessTokensRevoked != 1 {
+		t.Errorf("Expected 1 session ended and 1 access token revoked, got %+v", response)
+	}
+	if user.TokenVersion != 1 {
+		t.Errorf("Expected the token version to be bumped, got %d", user.TokenVersion)
+	}
+
+	w = getProtected()
+	if w.Code != http.StatusUnauthorized || decodeErrorCode(t, w) != "TOKEN_REVOKED" {
+		t.Errorf("Expected 401 TOKEN_REVOKED for the old access token, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := refreshRepo.GetByToken(login.Tokens.RefreshToken); err == nil {
+		t.Error("Expected the refresh token to be revoked")
+	}
+	if len(banking.revoked) != 1 {
+		t.Errorf("Expected the revocation to be pushed to the banking-service, got %+v", banking.revoked)
+	}
+	if len(auditRepo.entries) != 1 || auditRepo.entries[0].Action != models.AuditActionForceLogout || auditRepo.entries[0].AdminID != admin.ID {
+		t.Errorf("Expected a force logout audit entry by the admin, got %+v", auditRepo.entries)
+	}
+
+	// Logging in again works unless a password reset is required
+	if w, _ := postJSON(t, r, "/auth/login", gin.H{"email": user.Email, "password": testPassword}); w.Code != http.StatusOK {
+		t.Fatalf("Expected login to work after a force logout, got %d: %s", w.Code, w.Body.String())
+	}
+	if w, _ := postJSON(t, r, "/admin/clients/"+user.ID.String()+"/force-logout", gin.H{"must_reset_password": true}); w.Code != http.StatusOK {
+		t.Fatalf("Force logout failed with status %d: %s", w.Code, w.Body.String())
+	}
+	if w, code := postJSON(t, r, "/auth/login", gin.H{"email": user.Email, "password": testPassword}); w.Code != http.StatusForbidden || code != "PASSWORD_RESET_REQUIRED" {
+		t.Errorf("Expected 403 PASSWORD_RESET_REQUIRED, got %d %q", w.Code, code)
+	}
+
+	// Unknown users are reported
+	if w, code := postJSON(t, r, "/admin/clients/"+uuid.New().String()+"/force-logout", nil); w.Code != http.StatusNotFound || code != "USER_NOT_FOUND" {
+		t.Errorf("Expected 404 USER_NOT_FOUND, got %d %q", w.Code, code)
+	}
+	if w, code := postJSON(t, r, "/admin/clients/not-a-uuid/force-logout", nil); w.Code != http.StatusBadRequest || code != "INVALID_USER_ID" {
+		t.Errorf("Expected 400 INVALID_USER_ID, got %d %q", w.Code, code)
+	}
+}
+
+func TestTokenRevocationHandler_PushRevocations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	revocations := revocation.NewMemoryStore()
+	handler := NewTokenRevocationHandler(services.NewTokenRevocationService(newFakeUserRepo(), newFakeRefreshTokenRepo(), &fakeAuditLogRepo{}, revocations, nil))
+	r := gin.New()
+	r.POST("/internal/token-revocations", handler.PushRevocations)
+
+	tests := []struct {
+		name       string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{"empty list", gin.H{"tokens": []gin.H{}}, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"missing jti", gin.H{"tokens": []gin.H{{"expires_at": time.Now().Add(time.Minute)}}}, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"valid", gin.H{"tokens": []gin.H{{"jti": "pushed", "expires_at": time.Now().Add(time.Minute)}}}, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, code := postJSON(t, r, "/internal/token-revocations", tt.body)
+			if w.Code != tt.wantStatus || code != tt.wantCode {
+				t.Errorf("Expected %d %q, got %d %q: %s", tt.wantStatus, tt.wantCode, w.Code, code, w.Body.String())
+			}
+		})
+	}
+
+	if revoked, _ := revocations.IsRevoked("pushed"); !revoked {
+		t.Error("Expected the pushed token to be revoked")
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// UserExportHandler handles exporting the user list
+type UserExportHandler struct {
+	exportService *services.UserExportService
+}
+
+// NewUserExportHandler creates a new user export handler
+func NewUserExportHandler(exportService *services.UserExportService) *UserExportHandler {
+	return &UserExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportClients streams every user matching the listing filters as CSV
+// (admin only). It takes the same query parameters as the listing, except
+// that limit and offset are ignored.
+func (h *UserExportHandler) ExportClients(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	opts, err := parseListUsersOptions(c)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_QUERY_PARAMETER",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// The CSV response starts with the first row, so errors found before
+	// then can still be reported as JSON
+	w := csv.NewWriter(c.Writer)
+	started := false
+	start := func() {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", `attachment; filename="clients-`+time.Now().UTC().Format("20060102")+`.csv"`)
+		c.Status(http.StatusOK)
+		w.Write(services.UserExportHeader)
+		started = true
+	}
+
+	// Export users
+	written, err := h.exportService.ExportUsers(actor, opts, func(user *models.User) error {
+		if !started {
+			start()
+		}
+		w.Write(services.UserExportRow(user))
+		return w.Error()
+	})
+	if err != nil && !started {
+		respondUserExportError(c, err)
+		return
+	}
+	if err != nil {
+		// Headers are already sent, so the export can only be cut short
+		log.Printf("User export by %s stopped after %d rows: %v", actor.AdminID, written, err)
+		w.Flush()
+		c.Abort()
+		return
+	}
+
+	if !started {
+		start()
+	}
+	w.Flush()
+}
+
+// RequestClientsExport starts exporting every user matching the listing
+// filters as CSV in the background (admin only). The admin is emailed a
+// download link once the export is ready. It takes the same query
+// parameters as ExportClients.
+func (h *UserExportHandler) RequestClientsExport(c *gin.Context) {
+	actor, ok := auditActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	opts, err := parseListUsersOptions(c)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_QUERY_PARAMETER",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	total, err := h.exportService.ExportUsersAsync(actor, opts)
+	if err != nil {
+		respondUserExportError(c, err)
+		return
+	}
+
+	httpx.Respond(c, http.StatusAccepted, gin.H{
+		"message":   "The export is being generated; a download link will be emailed to you",
+		"row_count": total,
+	})
+}
+
+// respondUserExportError responds with an export that could not be started
+func respondUserExportError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrUserExportTooLarge) {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "EXPORT_TOO_LARGE",
+			Message: err.Error(),
+			Details: gin.H{"max_rows": services.MaxUserExportRows},
+		})
+		return
+	}
+
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusInternalServerError,
+		Code:    "EXPORT_USERS_FAILED",
+		Message: "Failed to export users",
+		Details: middleware.ErrorDetails(c, err),
+	})
+}
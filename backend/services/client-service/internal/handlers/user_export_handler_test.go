@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/blobstore"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/mailer"
+)
+
+func TestUserExportHandler_ExportClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := newTestUser(t, "admin@example.com")
+	admin.IsAdmin = true
+	banned := newTestUser(t, "banned@example.com")
+	banned.IsBlacklisted, banned.BlacklistReason = true, "fraud, confirmed"
+	auditRepo := &fakeAuditLogRepo{}
+	handler := NewUserExportHandler(services.NewUserExportService(newFakeUserRepo(admin, banned), auditRepo))
+
+	r := gin.New()
+	r.GET("/admin/clients/export", func(c *gin.Context) {
+		c.Set("user_id", admin.ID.String())
+		handler.ExportClients(c)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/clients/export?is_blacklisted=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a malformed filter, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/clients/export?is_blacklisted=true", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Expected a CSV content type, got %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment;") {
+		t.Errorf("Expected an attachment, got %q", cd)
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+	if len(records) != 2 || strings.Join(records[0], ",") != strings.Join(services.UserExportHeader, ",") {
+		t.Fatalf("Expected a header and one row, got %v", records)
+	}
+	if row := records[1]; row[0] != banned.ID.String() || row[3] != "true" || row[4] != "fraud, confirmed" {
+		t.Errorf("Unexpected row: %v", row)
+	}
+
+	if len(auditRepo.entries) != 1 || auditRepo.entries[0].Action != models.AuditActionExportUsers || auditRepo.entries[0].AdminID != admin.ID {
+		t.Errorf("Expected one export audit entry by the admin, got %+v", auditRepo.entries)
+	}
+}
+
+func TestUserExportHandler_RequestClientsExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := newTestUser(t, "admin@example.com")
+	admin.IsAdmin = true
+	userRepo := newFakeUserRepo(admin, newTestUser(t, "client@example.com"))
+	store, err := blobstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create blob store: %v", err)
+	}
+	artifactRepo := &fakeDownloadArtifactRepo{}
+	downloadService := services.NewDownloadService(artifactRepo, userRepo, store, "q3Jx9VbT0mWc7LkZpR2sYd5HnE8aFu4G", time.Hour, 1)
+	exportService := services.NewUserExportService(userRepo, &fakeAuditLogRepo{}).
+		WithDownloads(downloadService, mailer.NewLogSender(log.New(io.Discard, "", 0)))
+	handler := NewUserExportHandler(exportService)
+
+	r := gin.New()
+	r.POST("/admin/clients/export", func(c *gin.Context) {
+		c.Set("user_id", admin.ID.String())
+		handler.RequestClientsExport(c)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/clients/export?is_blacklisted=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a malformed filter, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/clients/export", nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var data struct {
+		RowCount int `json:"row_count"`
+	}
+	if err := decodeData(w, &data); err != nil || data.RowCount != 2 {
+		t.Errorf("Expected row_count 2, got %d (%v)", data.RowCount, err)
+	}
+
+	exportService.Wait()
+	if len(artifactRepo.artifacts) != 1 || artifactRepo.artifacts[0].UserID != admin.ID {
+		t.Errorf("Expected the export published for the admin, got %+v", artifactRepo.artifacts)
+	}
+}
@@ -1,23 +1,29 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
 	"microbank/client-service/internal/models"
 	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
 )
 
 // UserHandler handles user profile-related HTTP requests
 type UserHandler struct {
-	userService *services.UserService
+	userService        *services.UserService
+	emailChangeService *services.EmailChangeService
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(userService *services.UserService) *UserHandler {
+func NewUserHandler(userService *services.UserService, emailChangeService *services.EmailChangeService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:        userService,
+		emailChangeService: emailChangeService,
 	}
 }
 
@@ -26,11 +32,10 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	// Get user ID from context (set by AuthMiddleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "User information not found in context",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
 		})
 		return
 	}
@@ -38,11 +43,10 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	// Parse user ID
 	userUUID, err := uuid.Parse(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Invalid user ID format",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
 		})
 		return
 	}
@@ -50,33 +54,32 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	// Get user profile
 	user, err := h.userService.GetUserByID(userUUID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": gin.H{
-				"code":    "USER_NOT_FOUND",
-				"message": "User not found",
-				"details": err.Error(),
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "USER_NOT_FOUND",
+			Message: "User not found",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
 
 	// Return user profile
-	c.JSON(http.StatusOK, gin.H{
+	httpx.RespondOK(c, gin.H{
 		"message": "Profile retrieved successfully",
 		"profile": user.ToResponse(),
 	})
 }
 
-// UpdateProfile updates the current user's profile
+// UpdateProfile updates the supplied fields of the current user's profile,
+// leaving the others unchanged
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	// Get user ID from context (set by AuthMiddleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "User information not found in context",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
 		})
 		return
 	}
@@ -84,44 +87,264 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	// Parse user ID
 	userUUID, err := uuid.Parse(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_ERROR",
-				"message": "Invalid user ID format",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
 		})
 		return
 	}
 
 	// Bind and validate request body
 	var profile models.UserProfile
-	if err := c.ShouldBindJSON(&profile); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "Invalid request data",
-				"details": err.Error(),
-			},
-		})
+	if !bindJSON(c, &profile) {
 		return
 	}
 
 	// Update user profile
 	user, err := h.userService.UpdateUserProfile(userUUID, profile)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "PROFILE_UPDATE_FAILED",
-				"message": "Failed to update profile",
-				"details": err.Error(),
-			},
+		if respondValidationError(c, err) {
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "PROFILE_UPDATE_FAILED",
+			Message: "Failed to update profile",
+			Details: middleware.ErrorDetails(c, err),
 		})
 		return
 	}
 
 	// Return updated profile
-	c.JSON(http.StatusOK, gin.H{
+	httpx.RespondOK(c, gin.H{
 		"message": "Profile updated successfully",
 		"profile": user.ToResponse(),
 	})
 }
+
+// ChangeEmail starts an email change for the current user
+func (h *UserHandler) ChangeEmail(c *gin.Context) {
+	// Get user ID from context (set by AuthMiddleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
+		})
+		return
+	}
+
+	// Parse user ID
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Bind and validate request body
+	var change models.EmailChange
+	if !bindJSON(c, &change) {
+		return
+	}
+
+	// Request email change
+	user, err := h.emailChangeService.RequestEmailChange(userUUID, change)
+	if err != nil {
+		if respondPasswordNotSetError(c, err) {
+			return
+		}
+
+		// Check for specific error types
+		if errors.Is(err, services.ErrInvalidCurrentPassword) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "INVALID_CURRENT_PASSWORD",
+				Message: "Current password is incorrect",
+			})
+			return
+		}
+
+		if errors.Is(err, services.ErrEmailUnchanged) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "EMAIL_UNCHANGED",
+				Message: "New email must differ from the current email",
+			})
+			return
+		}
+
+		if errors.Is(err, services.ErrEmailInUse) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusConflict,
+				Code:    "EMAIL_IN_USE",
+				Message: "Email is already in use",
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "EMAIL_CHANGE_FAILED",
+			Message: "Failed to change email",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return pending change
+	httpx.Respond(c, http.StatusAccepted, gin.H{
+		"message": "Verification email sent to the new address",
+		"profile": user.ToResponse(),
+	})
+}
+
+// VerifyEmailChange confirms a pending email change from the emailed link
+func (h *UserHandler) VerifyEmailChange(c *gin.Context) {
+	var request models.EmailChangeToken
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Confirm email change
+	user, err := h.emailChangeService.VerifyEmailChange(request.Token)
+	if err != nil {
+		h.respondEmailChangeTokenError(c, err)
+		return
+	}
+
+	// Return updated profile
+	httpx.RespondOK(c, gin.H{
+		"message": "Email changed successfully",
+		"profile": user.ToResponse(),
+	})
+}
+
+// CancelEmailChange cancels or rolls back an email change from the emailed link
+func (h *UserHandler) CancelEmailChange(c *gin.Context) {
+	var request models.EmailChangeToken
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Cancel email change
+	if err := h.emailChangeService.CancelEmailChange(request.Token); err != nil {
+		h.respondEmailChangeTokenError(c, err)
+		return
+	}
+
+	// Return success response
+	httpx.RespondOK(c, gin.H{
+		"message": "Email change cancelled successfully",
+	})
+}
+
+// respondEmailChangeTokenError maps email change token errors to responses
+func (h *UserHandler) respondEmailChangeTokenError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidEmailChangeToken) {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_EMAIL_CHANGE_TOKEN",
+			Message: "Invalid email change token",
+		})
+		return
+	}
+
+	if errors.Is(err, services.ErrEmailChangeTokenExpired) {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "EMAIL_CHANGE_TOKEN_EXPIRED",
+			Message: "Email change token has expired",
+		})
+		return
+	}
+
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusInternalServerError,
+		Code:    "EMAIL_CHANGE_FAILED",
+		Message: "Failed to process email change",
+		Details: middleware.ErrorDetails(c, err),
+	})
+}
+
+// GetLoginHistory retrieves the current user's recent login attempts
+func (h *UserHandler) GetLoginHistory(c *gin.Context) {
+	// Get user ID from context (set by AuthMiddleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "User information not found in context",
+		})
+		return
+	}
+
+	// Parse user ID
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_ERROR",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	// Get login history
+	events, err := h.userService.GetLoginHistory(userUUID, limit)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_LOGIN_HISTORY_FAILED",
+			Message: "Failed to fetch login history",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return login history
+	httpx.RespondOK(c, gin.H{
+		"message": "Login history retrieved successfully",
+		"events":  events,
+		"count":   len(events),
+	})
+}
+
+// respondValidationError writes a 400 listing every invalid field when err
+// is a validation error, and reports whether it did
+func respondValidationError(c *gin.Context, err error) bool {
+	var validationErr *services.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+
+	details := make([]gin.H, 0, len(validationErr.Fields))
+	for _, f := range validationErr.Fields {
+		details = append(details, gin.H{
+			"field":   f.Field,
+			"rule":    f.Rule,
+			"message": f.Message,
+		})
+	}
+
+	httpx.RespondError(c, &httpx.AppError{
+		Status:  http.StatusBadRequest,
+		Code:    "VALIDATION_ERROR",
+		Message: "One or more fields are invalid",
+		Details: details,
+	})
+	return true
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+)
+
+func TestUserHandler_UpdateProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantFields  []string
+		wantProfile models.UserResponse
+	}{
+		{
+			name:        "name only keeps other fields",
+			body:        `{"name": "Renamed User"}`,
+			wantStatus:  http.StatusOK,
+			wantProfile: models.UserResponse{Name: "Renamed User", PhoneNumber: "+27821234567", Address: &models.Address{City: "Cape Town", Country: "ZA"}},
+		},
+		{
+			name:        "partial address",
+			body:        `{"address": {"line1": "1 Long Street", "country": "na"}}`,
+			wantStatus:  http.StatusOK,
+			wantProfile: models.UserResponse{Name: "Test User", PhoneNumber: "+27821234567", Address: &models.Address{Line1: "1 Long Street", City: "Cape Town", Country: "NA"}},
+		},
+		{
+			name:        "clear phone number",
+			body:        `{"phone_number": "", "date_of_birth": "1990-02-01"}`,
+			wantStatus:  http.StatusOK,
+			wantProfile: models.UserResponse{Name: "Test User", DateOfBirth: "1990-02-01", Address: &models.Address{City: "Cape Town", Country: "ZA"}},
+		},
+		{
+			name:       "invalid fields",
+			body:       `{"name": "A", "phone_number": "082 123 4567", "date_of_birth": "2020-01-01"}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"name", "phone_number", "date_of_birth"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, "profile@example.com")
+			user.PhoneNumber = "+27821234567"
+			user.AddressCity, user.AddressCountry = "Cape Town", "ZA"
+			userRepo := newFakeUserRepo(user)
+			handler := NewUserHandler(services.NewUserService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention), nil)
+
+			r := gin.New()
+			r.PATCH("/profile", func(c *gin.Context) {
+				c.Set("user_id", user.ID.String())
+				handler.UpdateProfile(c)
+			})
+
+			req := httptest.NewRequest(http.MethodPatch, "/profile", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+
+			var response struct {
+				Data struct {
+					Profile models.UserResponse `json:"profile"`
+				} `json:"data"`
+				Error struct {
+					Details []struct {
+						Field string `json:"field"`
+					} `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				if len(response.Error.Details) != len(tt.wantFields) {
+					t.Fatalf("Expected invalid fields %v, got %+v", tt.wantFields, response.Error.Details)
+				}
+				for i, field := range tt.wantFields {
+					if response.Error.Details[i].Field != field {
+						t.Errorf("Expected invalid field %q, got %q", field, response.Error.Details[i].Field)
+					}
+				}
+				return
+			}
+
+			got := response.Data.Profile
+			if got.Name != tt.wantProfile.Name || got.PhoneNumber != tt.wantProfile.PhoneNumber || got.DateOfBirth != tt.wantProfile.DateOfBirth {
+				t.Errorf("Unexpected profile: %+v", got)
+			}
+			if got.Address == nil || *got.Address != *tt.wantProfile.Address {
+				t.Errorf("Expected address %+v, got %+v", tt.wantProfile.Address, got.Address)
+			}
+		})
+	}
+}
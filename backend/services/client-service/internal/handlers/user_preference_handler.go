@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// UserPreferenceHandler handles locale and time zone preference HTTP requests
+type UserPreferenceHandler struct {
+	userPreferenceService *services.UserPreferenceService
+}
+
+// NewUserPreferenceHandler creates a new user preference handler
+func NewUserPreferenceHandler(userPreferenceService *services.UserPreferenceService) *UserPreferenceHandler {
+	return &UserPreferenceHandler{
+		userPreferenceService: userPreferenceService,
+	}
+}
+
+// GetPreferences returns the current user's locale and time zone
+func (h *UserPreferenceHandler) GetPreferences(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Get preferences
+	preferences, err := h.userPreferenceService.GetPreferences(userUUID)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "USER_NOT_FOUND",
+			Message: "User not found",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return preferences
+	httpx.RespondOK(c, gin.H{
+		"message":     "Preferences retrieved successfully",
+		"preferences": preferences,
+	})
+}
+
+// UpdatePreferences changes the current user's locale and time zone, leaving
+// whichever is left out unchanged
+func (h *UserPreferenceHandler) UpdatePreferences(c *gin.Context) {
+	userUUID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Bind and validate request body
+	var request models.UserPreferencesUpdate
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Update preferences
+	preferences, err := h.userPreferenceService.UpdatePreferences(userUUID, request)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "USER_NOT_FOUND",
+			Message: "User not found",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	// Return updated preferences
+	httpx.RespondOK(c, gin.H{
+		"message":     "Preferences updated successfully",
+		"preferences": preferences,
+	})
+}
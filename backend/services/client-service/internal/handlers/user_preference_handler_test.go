@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+)
+
+func TestUserPreferenceHandler_UpdatePreferences(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		body            string
+		wantStatus      int
+		wantPreferences models.UserPreferences
+	}{
+		{
+			name:            "set locale and time zone",
+			body:            `{"locale": "AF", "timezone": "Africa/Johannesburg"}`,
+			wantStatus:      http.StatusOK,
+			wantPreferences: models.UserPreferences{Locale: "af", TimeZone: "Africa/Johannesburg"},
+		},
+		{
+			name:            "locale only keeps the default time zone",
+			body:            `{"locale": "fr"}`,
+			wantStatus:      http.StatusOK,
+			wantPreferences: models.UserPreferences{Locale: "fr", TimeZone: "UTC"},
+		},
+		{
+			name:       "unsupported locale",
+			body:       `{"locale": "tlh"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown time zone",
+			body:       `{"locale": "en", "timezone": "Mars/Olympus_Mons"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			repo := newFakeUserPreferenceRepo(userID)
+			handler := NewUserPreferenceHandler(services.NewUserPreferenceService(repo))
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set("user_id", userID.String()) })
+			r.GET("/profile/preferences", handler.GetPreferences)
+			r.PUT("/profile/preferences", handler.UpdatePreferences)
+
+			req := httptest.NewRequest(http.MethodPut, "/profile/preferences", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if repo.preferences[userID] != (models.UserPreferences{}) {
+					t.Errorf("Expected nothing to be saved, got %+v", repo.preferences[userID])
+				}
+				return
+			}
+
+			// The change is visible on a fresh read
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/profile/preferences", nil))
+
+			var response struct {
+				Preferences models.UserPreferences `json:"preferences"`
+			}
+			if err := decodeData(w, &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Preferences != tt.wantPreferences {
+				t.Errorf("Expected preferences %+v, got %+v", tt.wantPreferences, response.Preferences)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/middleware"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+)
+
+// userStatusCacheControl lets callers reuse a status for a few seconds.
+// Blacklisting also reaches the banking-service as an event, so a briefly
+// stale answer is not the only safeguard.
+const userStatusCacheControl = "private, max-age=5"
+
+// UserStatusHandler answers other services' questions about users
+type UserStatusHandler struct {
+	userService                   *services.UserService
+	notificationPreferenceService *services.NotificationPreferenceService
+}
+
+// NewUserStatusHandler creates a new user status handler
+func NewUserStatusHandler(userService *services.UserService, notificationPreferenceService *services.NotificationPreferenceService) *UserStatusHandler {
+	return &UserStatusHandler{
+		userService:                   userService,
+		notificationPreferenceService: notificationPreferenceService,
+	}
+}
+
+// GetUserStatus reports whether a user exists and may act right now
+// (internal only). Unknown users are reported with exists false.
+func (h *UserStatusHandler) GetUserStatus(c *gin.Context) {
+	// Get user ID from URL parameter
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	// Get status
+	statuses, err := h.userService.GetUserStatuses([]uuid.UUID{userID})
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_USER_STATUS_FAILED",
+			Message: "Failed to fetch user status",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", userStatusCacheControl)
+	httpx.RespondOK(c, statuses[0])
+}
+
+// GetUserStatuses reports the status of up to 100 users at once, in
+// request order with duplicates dropped (internal only)
+func (h *UserStatusHandler) GetUserStatuses(c *gin.Context) {
+	var request models.UserStatusBatchRequest
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Get statuses
+	statuses, err := h.userService.GetUserStatuses(request.UserIDs)
+	if err != nil {
+		if errors.Is(err, services.ErrUserStatusBatchTooLarge) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "VALIDATION_ERROR",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_USER_STATUS_FAILED",
+			Message: "Failed to fetch user statuses",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"users": statuses,
+	})
+}
+
+// GetUserDetails reports the email and name of up to 100 users at once, in
+// request order with duplicates dropped (internal only). Unlike statuses,
+// details are not marked cacheable.
+func (h *UserStatusHandler) GetUserDetails(c *gin.Context) {
+	var request models.UserStatusBatchRequest
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	// Get details
+	details, err := h.userService.GetUserDetails(request.UserIDs)
+	if err != nil {
+		if errors.Is(err, services.ErrUserStatusBatchTooLarge) {
+			httpx.RespondError(c, &httpx.AppError{
+				Status:  http.StatusBadRequest,
+				Code:    "VALIDATION_ERROR",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_USER_DETAILS_FAILED",
+			Message: "Failed to fetch user details",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	httpx.RespondOK(c, gin.H{
+		"users": details,
+	})
+}
+
+// GetNotificationRecipient reports where and how a user wants a
+// notification event delivered (internal only), for services dispatching
+// notifications. Unknown and deleted users are reported with exists false.
+func (h *UserStatusHandler) GetNotificationRecipient(c *gin.Context) {
+	// Get user ID from URL parameter
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_USER_ID",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+	event := c.Param("event")
+	if !models.IsNotificationEvent(event) {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_NOTIFICATION_EVENT",
+			Message: "Unknown notification event type",
+		})
+		return
+	}
+
+	// Get user
+	user, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		httpx.RespondOK(c, models.NotificationRecipient{UserID: userID})
+		return
+	}
+
+	// Get their channels for the event
+	recipient, err := h.notificationPreferenceService.RecipientFor(user, event)
+	if err != nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "FETCH_NOTIFICATION_RECIPIENT_FAILED",
+			Message: "Failed to fetch notification preferences",
+			Details: middleware.ErrorDetails(c, err),
+		})
+		return
+	}
+
+	httpx.RespondOK(c, recipient)
+}
+
+// LookupUser reports the details of the user with an email (internal
+// only). Unknown and deleted users are reported with exists false. The
+// email is sent in the body so it stays out of access logs.
+func (h *UserStatusHandler) LookupUser(c *gin.Context) {
+	var request models.UserLookupRequest
+
+	// Bind and validate request body
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	httpx.RespondOK(c, h.userService.LookupUserByEmail(request.Email))
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/services"
+)
+
+func newUserStatusRouter(users ...*models.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewUserStatusHandler(services.NewUserService(newFakeUserRepo(users...), newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, &fakeBlacklistRepo{}, &fakeAuditLogRepo{}, &fakeBankingClient{}, testDeletionRetention), services.NewNotificationPreferenceService(&fakeNotificationPreferenceRepo{}))
+
+	r := gin.New()
+	r.GET("/internal/users/:id/status", handler.GetUserStatus)
+	r.POST("/internal/users/status", handler.GetUserStatuses)
+	r.POST("/internal/users/details", handler.GetUserDetails)
+	r.POST("/internal/users/lookup", handler.LookupUser)
+	r.GET("/internal/users/:id/notifications/:event", handler.GetNotificationRecipient)
+	return r
+}
+
+func TestUserStatusHandler_GetUserStatus(t *testing.T) {
+	user := newTestUser(t, "client@example.com")
+	user.IsBlacklisted = true
+	user.TokenVersion = 3
+	deleted := newTestUser(t, "gone@example.com")
+	deletedAt := time.Now()
+	deleted.DeletedAt = &deletedAt
+	r := newUserStatusRouter(user, deleted)
+
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+		want       models.UserStatus
+	}{
+		{name: "blacklisted user", id: user.ID.String(), wantStatus: http.StatusOK, want: models.UserStatus{UserID: user.ID, Exists: true, IsBlacklisted: true, TokenVersion: 3, Roles: []string{}}},
+		{name: "deleted user", id: deleted.ID.String(), wantStatus: http.StatusOK, want: models.UserStatus{UserID: deleted.ID, Exists: true, IsDeleted: true, Roles: []string{}}},
+		{name: "unknown user", id: uuid.Nil.String(), wantStatus: http.StatusOK, want: models.UserStatus{UserID: uuid.Nil, Roles: []string{}}},
+		{name: "malformed id", id: "not-a-uuid", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/internal/users/"+tt.id+"/status", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if cc := w.Header().Get("Cache-Control"); cc != userStatusCacheControl {
+				t.Errorf("Expected Cache-Control %q, got %q", userStatusCacheControl, cc)
+			}
+			var got models.UserStatus
+			if err := decodeData(w, &got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUserStatusHandler_GetUserStatuses(t *testing.T) {
+	user := newTestUser(t, "client@example.com")
+	r := newUserStatusRouter(user)
+	unknown := uuid.New()
+
+	tooMany := make([]uuid.UUID, models.MaxUserStatusBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = uuid.New()
+	}
+	if w, _ := postJSON(t, r, "/internal/users/status", gin.H{"user_ids": tooMany}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for too many IDs, got %d", w.Code)
+	}
+	if w, _ := postJSON(t, r, "/internal/users/status", gin.H{"user_ids": []uuid.UUID{}}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for no IDs, got %d", w.Code)
+	}
+
+	w, _ := postJSON(t, r, "/internal/users/status", gin.H{"user_ids": []uuid.UUID{unknown, user.ID, unknown}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Users []models.UserStatus `json:"users"`
+	}
+	if err := decodeData(w, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Users) != 2 {
+		t.Fatalf("Expected 2 deduplicated statuses, got %+v", response.Users)
+	}
+	if response.Users[0].UserID != unknown || response.Users[0].Exists {
+		t.Errorf("Expected the unknown user first and not existing, got %+v", response.Users[0])
+	}
+	if response.Users[1].UserID != user.ID || !response.Users[1].Exists {
+		t.Errorf("Expected the known user second and existing, got %+v", response.Users[1])
+	}
+}
+
+func TestUserStatusHandler_GetUserDetails(t *testing.T) {
+	user := newTestUser(t, "client@example.com")
+	r := newUserStatusRouter(user)
+	unknown := uuid.New()
+
+	w, _ := postJSON(t, r, "/internal/users/details", gin.H{"user_ids": []uuid.UUID{user.ID, unknown, user.ID}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "" {
+		t.Errorf("Expected no Cache-Control, got %q", cc)
+	}
+	var response struct {
+		Users []models.UserDetail `json:"users"`
+	}
+	if err := decodeData(w, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := []models.UserDetail{
+		{UserID: user.ID, Exists: true, Email: user.Email, Name: user.Name},
+		{UserID: unknown},
+	}
+	if !reflect.DeepEqual(response.Users, want) {
+		t.Errorf("Expected %+v, got %+v", want, response.Users)
+	}
+}
+
+func TestUserStatusHandler_LookupUser(t *testing.T) {
+	user := newTestUser(t, "client@example.com")
+	r := newUserStatusRouter(user)
+
+	tests := []struct {
+		email string
+		want  models.UserDetail
+	}{
+		{email: "client@example.com", want: models.UserDetail{UserID: user.ID, Exists: true, Email: user.Email, Name: user.Name}},
+		{email: "nobody@example.com", want: models.UserDetail{}},
+	}
+	for _, tt := range tests {
+		w, _ := postJSON(t, r, "/internal/users/lookup", gin.H{"email": tt.email})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var detail models.UserDetail
+		if err := decodeData(w, &detail); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if detail != tt.want {
+			t.Errorf("Expected %+v for %s, got %+v", tt.want, tt.email, detail)
+		}
+	}
+
+	if w, _ := postJSON(t, r, "/internal/users/lookup", gin.H{"email": "not-an-email"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid email, got %d", w.Code)
+	}
+}
+
+func TestUserStatusHandler_GetNotificationRecipient(t *testing.T) {
+	verified := newTestUser(t, "client@example.com")
+	verified.PhoneNumber = "+15551234567"
+	verifiedAt := time.Now()
+	verified.PhoneVerifiedAt = &verifiedAt
+	unverified := newTestUser(t, "other@example.com")
+	unverified.PhoneNumber = "+15557654321"
+	r := newUserStatusRouter(verified, unverified)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		want       models.NotificationRecipient
+	}{
+		{
+			name:       "verified phone",
+			path:       "/internal/users/" + verified.ID.String() + "/notifications/large_transaction",
+			wantStatus: http.StatusOK,
+			want:       models.NotificationRecipient{UserID: verified.ID, Exists: true, Email: verified.Email, Name: verified.Name, PhoneNumber: verified.PhoneNumber, Channels: models.NotificationChannels{Email: true}},
+		},
+		{
+			name:       "unverified phone left out",
+			path:       "/internal/users/" + unverified.ID.String() + "/notifications/low_balance",
+			wantStatus: http.StatusOK,
+			want:       models.NotificationRecipient{UserID: unverified.ID, Exists: true, Email: unverified.Email, Name: unverified.Name, Channels: models.NotificationChannels{Email: true}},
+		},
+		{
+			name:       "unknown user",
+			path:       "/internal/users/" + uuid.Nil.String() + "/notifications/low_balance",
+			wantStatus: http.StatusOK,
+			want:       models.NotificationRecipient{UserID: uuid.Nil},
+		},
+		{
+			name:       "unknown event",
+			path:       "/internal/users/" + verified.ID.String() + "/notifications/birthday",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var recipient models.NotificationRecipient
+			if err := decodeData(w, &recipient); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if recipient != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, recipient)
+			}
+		})
+	}
+}
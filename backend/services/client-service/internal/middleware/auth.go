@@ -1,190 +1,191 @@
 package middleware
 
 import (
-	"fmt"
 	"net/http"
-	"os"
-	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/authcookie"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/authmw"
+	"microbank/pkg/httpx"
+	sharedjwt "microbank/pkg/jwt"
 )
 
-// Claims represents the JWT claims structure
-type Claims struct {
-	UserID        string `json:"user_id"`
-	Email         string `json:"email"`
-	Name          string `json:"name"`
-	IsAdmin       bool   `json:"is_admin"`
-	IsBlacklisted bool   `json:"is_blacklisted"`
-	jwt.RegisteredClaims
+// TokenVersionSource looks up a user's current token version. Role and
+// blacklist changes bump the version, making earlier access tokens stale.
+type TokenVersionSource interface {
+	GetTokenVersion(userID uuid.UUID) (int, error)
 }
 
-// AuthMiddleware validates JWT tokens and extracts user information
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get the Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "MISSING_TOKEN",
-					"message": "Authorization header is required",
-				},
-			})
-			c.Abort()
-			return
-		}
+// RoleSource looks up a user's current record, whose roles StaffMiddleware
+// trusts over the token's claim
+type RoleSource interface {
+	GetUserByID(id uuid.UUID) (*models.User, error)
+}
 
-		// Check if the header starts with "Bearer "
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "INVALID_TOKEN_FORMAT",
-					"message": "Token must be in format: Bearer <token>",
-				},
-			})
-			c.Abort()
-			return
-		}
+// TokenVersionVerifiedKey is set in the context once AuthMiddleware has
+// matched the token's version against the user's current one. Role changes
+// bump the version, so the token's roles claim is then current.
+const TokenVersionVerifiedKey = "token_version_verified"
 
-		// Extract the token
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+// RevocationChecker reports whether an access token has been revoked by its
+// jti claim
+type RevocationChecker interface {
+	IsRevoked(jti string) (bool, error)
+}
+
+// PersonalAccessTokenAuthenticator looks up the personal access token a
+// request was made with and its owner
+type PersonalAccessTokenAuthenticator interface {
+	Authenticate(token string) (*models.PersonalAccessToken, *models.User, error)
+}
+
+// AuthMiddleware validates JWT tokens with tokens and extracts user
+// information. Tokens whose version no longer matches the user's current one,
+// or whose ID is on the revocation list, are rejected. opts can accept
+// other kinds of token, such as with PersonalAccessTokens.
+func AuthMiddleware(tokens *sharedjwt.TokenManager, versions TokenVersionSource, revocations RevocationChecker, opts ...authmw.Option) gin.HandlerFunc {
+	opts = append([]authmw.Option{
+		// Browsers in cookie mode send the token in a cookie
+		authmw.WithCookie(authcookie.AccessTokenCookie),
+		authmw.RequireClaims(authmw.ClaimUserID),
+		authmw.WithErrorDetails(ErrorDetails),
+		// Reject tokens issued before the user's last role or status change,
+		// and tokens revoked individually
+		authmw.WithCheck(func(c authmw.Context, _ string, claims *sharedjwt.Claims) error {
+			if !tokenVersionCurrent(versions, claims) || tokenRevoked(revocations, claims) {
+				return &httpx.AppError{
+					Status:  http.StatusUnauthorized,
+					Code:    "TOKEN_REVOKED",
+					Message: "Token has been revoked, please log in again",
+				}
+			}
+			c.Set(TokenVersionVerifiedKey, true)
+			return nil
+		}),
+	}, opts...)
+	auth := authmw.New(tokens, opts...)
+	return func(c *gin.Context) {
+		auth.Handle(c)
+	}
+}
 
-		// Parse and validate the token
-		claims, err := parseAndValidateToken(tokenString)
+// PersonalAccessTokens makes AuthMiddleware accept personal access tokens,
+// looked up with tokens. Requests made with one act as its owner, without
+// their staff roles, and carry its scopes.
+func PersonalAccessTokens(tokens PersonalAccessTokenAuthenticator) authmw.Option {
+	return authmw.WithPersonalAccessTokens(func(_ authmw.Context, token string) (*sharedjwt.Claims, []string, error) {
+		stored, user, err := tokens.Authenticate(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": gin.H{
-					"code":    "INVALID_TOKEN",
-					"message": "Invalid or expired token",
-					"details": err.Error(),
-				},
-			})
-			c.Abort()
-			return
+			return nil, nil, err
 		}
-
-		// Check if user is blacklisted
-		if claims.IsBlacklisted {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": gin.H{
-					"code":    "USER_BLACKLISTED",
-					"message": "User account has been suspended",
-				},
-			})
-			c.Abort()
-			return
+		claims := &sharedjwt.Claims{
+			UserID:        user.ID.String(),
+			Email:         user.Email,
+			Name:          user.Name,
+			IsBlacklisted: user.IsBlacklisted,
 		}
+		return claims, stored.Scopes, nil
+	})
+}
 
-		// Store user information in context for handlers to use
-		c.Set("user_id", claims.UserID)
-		c.Set("email", claims.Email)
-		c.Set("name", claims.Name)
-		c.Set("is_admin", claims.IsAdmin)
-		c.Set("is_blacklisted", claims.IsBlacklisted)
-
-		c.Next()
+// tokenVersionCurrent reports whether the token carries the user's current
+// token version. Unknown users and failed lookups count as stale.
+func tokenVersionCurrent(versions TokenVersionSource, claims *sharedjwt.Claims) bool {
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return false
 	}
-}
 
-// parseAndValidateToken parses and validates a JWT token using MapClaims
-func parseAndValidateToken(tokenString string) (*Claims, error) {
-	// Get JWT secret from environment
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET environment variable not set")
+	version, err := versions.GetTokenVersion(userID)
+	if err != nil {
+		return false
 	}
 
-	// Parse token using MapClaims first
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwtSecret), nil
-	})
+	return claims.TokenVersion == version
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse token: %w", err)
+// tokenRevoked reports whether the token is on the revocation list. Failed
+// lookups count as revoked.
+func tokenRevoked(revocations RevocationChecker, claims *sharedjwt.Claims) bool {
+	if claims.ID == "" {
+		return false
 	}
 
-	// Extract claims from MapClaims
-	if mapClaims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Convert MapClaims to our Claims struct
-		claims := &Claims{}
-		
-		// Extract user_id
-		if userID, exists := mapClaims["user_id"]; exists {
-			if userIDStr, ok := userID.(string); ok {
-				claims.UserID = userIDStr
-			} else {
-				return nil, fmt.Errorf("invalid user_id type in token")
-			}
-		} else {
-			return nil, fmt.Errorf("user_id not found in token")
-		}
+	revoked, err := revocations.IsRevoked(claims.ID)
+	return err != nil || revoked
+}
 
-		// Extract email
-		if email, exists := mapClaims["email"]; exists {
-			if emailStr, ok := email.(string); ok {
-				claims.Email = emailStr
-			}
+// StaffMiddleware ensures the user holds a staff role; RequirePermission
+// then decides what each route needs. The token's roles are trusted only
+// when AuthMiddleware has verified its token version; otherwise users
+// looks up the user's current roles, which replace the token's, and
+// requests from users left without any get 403 ADMIN_REVOKED.
+func StaffMiddleware(users RoleSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(authmw.ContextRoles(c)) == 0 {
+			httpx.AbortWithError(c, &httpx.AppError{
+				Status:  http.StatusForbidden,
+				Code:    "INSUFFICIENT_PERMISSIONS",
+				Message: "Admin privileges required",
+			})
+			return
 		}
 
-		// Extract name
-		if name, exists := mapClaims["name"]; exists {
-			if nameStr, ok := name.(string); ok {
-				claims.Name = nameStr
+		if !c.GetBool(TokenVersionVerifiedKey) {
+			roles := currentRoles(users, c.GetString("user_id"))
+			if len(roles) == 0 {
+				httpx.AbortWithError(c, &httpx.AppError{
+					Status:  http.StatusForbidden,
+					Code:    "ADMIN_REVOKED",
+					Message: "Admin privileges have been revoked",
+				})
+				return
 			}
+			c.Set(authmw.RolesKey, roles)
 		}
 
-		// Extract is_admin
-		if isAdmin, exists := mapClaims["is_admin"]; exists {
-			if isAdminBool, ok := isAdmin.(bool); ok {
-				claims.IsAdmin = isAdminBool
-			}
-		}
+		c.Next()
+	}
+}
 
-		// Extract is_blacklisted
-		if isBlacklisted, exists := mapClaims["is_blacklisted"]; exists {
-			if isBlacklistedBool, ok := isBlacklisted.(bool); ok {
-				claims.IsBlacklisted = isBlacklistedBool
-			}
-		}
+// currentRoles returns the user's current roles. Unknown users and failed
+// lookups have none.
+func currentRoles(users RoleSource, userID string) []string {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil
+	}
 
-		return claims, nil
+	user, err := users.GetUserByID(id)
+	if err != nil {
+		return nil
 	}
 
-	return nil, fmt.Errorf("invalid token")
+	return user.Roles
 }
 
-// AdminMiddleware ensures the user has admin privileges
-func AdminMiddleware() gin.HandlerFunc {
+// RequireRole lets through users holding any of roles
+func RequireRole(roles ...string) gin.HandlerFunc {
+	check := authmw.RequireRole(roles...)
 	return func(c *gin.Context) {
-		isAdmin, exists := c.Get("is_admin")
-		if !exists {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": gin.H{
-					"code":    "INTERNAL_ERROR",
-					"message": "User information not found in context",
-				},
-			})
-			c.Abort()
-			return
-		}
+		check(c)
+	}
+}
 
-		if !isAdmin.(bool) {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": gin.H{
-					"code":    "INSUFFICIENT_PERMISSIONS",
-					"message": "Admin privileges required",
-				},
-			})
-			c.Abort()
-			return
-		}
+// RequirePermission lets through users whose roles grant permission
+func RequirePermission(permission string) gin.HandlerFunc {
+	check := authmw.RequirePermission(permission)
+	return func(c *gin.Context) {
+		check(c)
+	}
+}
 
-		c.Next()
+// ForbidImpersonation rejects requests made with an impersonation token,
+// for actions only the user may take
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authmw.ForbidImpersonation(c)
 	}
 }
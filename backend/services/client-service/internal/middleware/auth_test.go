@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/authcookie"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/authmw"
+	sharedjwt "microbank/pkg/jwt"
+)
+
+// fakeVersionSource maps user IDs to their current token version
+type fakeVersionSource map[uuid.UUID]int
+
+func (s fakeVersionSource) GetTokenVersion(userID uuid.UUID) (int, error) {
+	version, ok := s[userID]
+	if !ok {
+		return 0, fmt.Errorf("user not found")
+	}
+	return version, nil
+}
+
+// fakeRevocations is a set of revoked token IDs
+type fakeRevocations map[string]bool
+
+func (r fakeRevocations) IsRevoked(jti string) (bool, error) {
+	return r[jti], nil
+}
+
+// fakeRoleSource maps user IDs to their current roles
+type fakeRoleSource map[uuid.UUID][]string
+
+func (s fakeRoleSource) GetUserByID(id uuid.UUID) (*models.User, error) {
+	roles, ok := s[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &models.User{ID: id, Roles: roles}, nil
+}
+
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	claims["exp"] = time.Now().Add(time.Minute).Unix()
+	claims["type"] = sharedjwt.TokenTypeAccess
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestAuthMiddleware_TokenVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	current := uuid.New()
+	bumped := uuid.New()
+	versions := fakeVersionSource{current: 0, bumped: 2}
+
+	tests := []struct {
+		name       string
+		claims     jwt.MapClaims
+		wantStatus int
+	}{
+		{name: "current version", claims: jwt.MapClaims{"user_id": bumped.String(), "token_version": 2}, wantStatus: http.StatusOK},
+		{name: "stale version", claims: jwt.MapClaims{"user_id": bumped.String(), "token_version": 1}, wantStatus: http.StatusUnauthorized},
+		{name: "token without version", claims: jwt.MapClaims{"user_id": current.String()}, wantStatus: http.StatusOK},
+		{name: "unknown user", claims: jwt.MapClaims{"user_id": uuid.New().String(), "token_version": 0}, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/protected", AuthMiddleware(sharedjwt.NewTokenManager("test-secret", time.Minute, time.Hour), versions, fakeRevocations{}), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+signTestToken(t, tt.claims))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_RevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+	versions := fakeVersionSource{userID: 0}
+	revocations := fakeRevocations{"revoked-jti": true}
+
+	tests := []struct {
+		name       string
+		claims     jwt.MapClaims
+		wantStatus int
+	}{
+		{name: "active token", claims: jwt.MapClaims{"user_id": userID.String(), "jti": "active-jti"}, wantStatus: http.StatusOK},
+		{name: "revoked token", claims: jwt.MapClaims{"user_id": userID.String(), "jti": "revoked-jti"}, wantStatus: http.StatusUnauthorized},
+		{name: "token without jti", claims: jwt.MapClaims{"user_id": userID.String()}, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/protected", AuthMiddleware(sharedjwt.NewTokenManager("test-secret", time.Minute, time.Hour), versions, revocations), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+signTestToken(t, tt.claims))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_AccessTokenCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+	versions := fakeVersionSource{userID: 0}
+	token := signTestToken(t, jwt.MapClaims{"user_id": userID.String()})
+
+	tests := []struct {
+		name       string
+		header     string
+		cookie     string
+		wantStatus int
+	}{
+		{name: "cookie only", cookie: token, wantStatus: http.StatusOK},
+		{name: "header wins over cookie", header: "Bearer not-a-token", cookie: token, wantStatus: http.StatusUnauthorized},
+		{name: "neither", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/protected", AuthMiddleware(sharedjwt.NewTokenManager("test-secret", time.Minute, time.Hour), versions, fakeRevocations{}), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: authcookie.AccessTokenCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestStaffMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := uuid.New()
+	demoted := uuid.New()
+	support := uuid.New()
+	users := fakeRoleSource{admin: {authmw.RoleAdmin}, demoted: {}, support: {authmw.RoleSupport}}
+
+	tests := []struct {
+		name            string
+		userID          uuid.UUID
+		roles           []string
+		versionVerified bool
+		permission      string
+		wantStatus      int
+		wantCode        string
+	}{
+		{name: "admin role, version verified", userID: demoted, roles: []string{authmw.RoleAdmin}, versionVerified: true, permission: authmw.PermissionClientsBlacklist, wantStatus: http.StatusOK},
+		{name: "admin role, still admin", userID: admin, roles: []string{authmw.RoleAdmin}, permission: authmw.PermissionClientsBlacklist, wantStatus: http.StatusOK},
+		{name: "admin role, admin revoked", userID: demoted, roles: []string{authmw.RoleAdmin}, permission: authmw.PermissionClientsRead, wantStatus: http.StatusForbidden, wantCode: "ADMIN_REVOKED"},
+		{name: "admin role, unknown user", userID: uuid.New(), roles: []string{authmw.RoleAdmin}, permission: authmw.PermissionClientsRead, wantStatus: http.StatusForbidden, wantCode: "ADMIN_REVOKED"},
+		{name: "admin role, now support only", userID: support, roles: []string{authmw.RoleAdmin}, permission: authmw.PermissionClientsBlacklist, wantStatus: http.StatusForbidden, wantCode: "INSUFFICIENT_PERMISSIONS"},
+		{name: "support reads clients", userID: support, roles: []string{authmw.RoleSupport}, versionVerified: true, permission: authmw.PermissionClientsRead, wantStatus: http.StatusOK},
+		{name: "support cannot blacklist", userID: support, roles: []string{authmw.RoleSupport}, versionVerified: true, permission: authmw.PermissionClientsBlacklist, wantStatus: http.StatusForbidden, wantCode: "INSUFFICIENT_PERMISSIONS"},
+		{name: "no role", userID: admin, versionVerified: true, permission: authmw.PermissionClientsRead, wantStatus: http.StatusForbidden, wantCode: "INSUFFICIENT_PERMISSIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/admin", func(c *gin.Context) {
+				c.Set("user_id", tt.userID.String())
+				c.Set(authmw.RolesKey, tt.roles)
+				if tt.versionVerified {
+					c.Set(TokenVersionVerifiedKey, true)
+				}
+			}, StaffMiddleware(users), RequirePermission(tt.permission), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), tt.wantCode) {
+				t.Errorf("Expected error code %s, got %s", tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestJSONBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const maxBytes = 32
+
+	r := gin.New()
+	r.POST("/json", JSONBody(maxBytes), func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// A JSON string padded to exactly size bytes
+	body := func(size int) string {
+		return `"` + strings.Repeat("x", size-2) + `"`
+	}
+
+	tests := []struct {
+		name          string
+		body          string
+		contentType   string
+		unknownLength bool
+		wantStatus    int
+	}{
+		{name: "exactly at the limit", body: body(maxBytes), contentType: "application/json", wantStatus: http.StatusNoContent},
+		{name: "one byte over", body: body(maxBytes + 1), contentType: "application/json", wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "one byte over without a length", body: body(maxBytes + 1), contentType: "application/json", unknownLength: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "not json", body: "a=b", contentType: "application/x-www-form-urlencoded", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "no content type", body: "{}", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "no body", wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/json", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUploadBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.POST("/upload", UploadBody(8), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for size, want := range map[int]int{8: http.StatusNoContent, 9: http.StatusRequestEntityTooLarge} {
+		req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("x", size)))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != want {
+			t.Errorf("Expected %d for a %d byte upload, got %d", want, size, w.Code)
+		}
+	}
+}
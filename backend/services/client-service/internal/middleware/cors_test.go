@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/pkg/cors"
+	sharedjwt "microbank/pkg/jwt"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policy, err := cors.New(cors.Config{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true})
+	if err != nil {
+		t.Fatalf("cors.New returned error: %v", err)
+	}
+
+	// Protected routes register only POST; preflights must still be
+	// answered without credentials
+	r := gin.New()
+	r.Use(CORS(policy))
+	protected := r.Group("/api/v1")
+	protected.Use(AuthMiddleware(sharedjwt.NewTokenManager("test-secret", time.Minute, time.Hour), fakeVersionSource{}, fakeRevocations{}))
+	protected.POST("/accounts", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name            string
+		method          string
+		origin          string
+		wantStatus      int
+		wantAllowOrigin string
+	}{
+		{name: "allowed preflight", method: http.MethodOptions, origin: "https://app.example.com", wantStatus: http.StatusNoContent, wantAllowOrigin: "https://app.example.com"},
+		{name: "disallowed preflight", method: http.MethodOptions, origin: "https://evil.example.com", wantStatus: http.StatusNoContent},
+		{name: "allowed request still needs auth", method: http.MethodPost, origin: "https://app.example.com", wantStatus: http.StatusUnauthorized, wantAllowOrigin: "https://app.example.com"},
+		{name: "disallowed request", method: http.MethodPost, origin: "https://evil.example.com", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/accounts", nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.wantAllowOrigin, got)
+			}
+		})
+	}
+}
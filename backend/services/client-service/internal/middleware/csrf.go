@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/authcookie"
+	"microbank/pkg/authmw"
+	"microbank/pkg/httpx"
+)
+
+// CSRF requires the double-submit CSRF token on state-changing requests that
+// carry an auth cookie. Requests that send their tokens in headers or bodies
+// cannot be forged by another site and pass through.
+func CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := authmw.CheckCSRF(c.Request, authcookie.RefreshTokenCookie, authcookie.AccessTokenCookie); err != nil {
+			httpx.AbortWithError(c, err)
+			return
+		}
+
+		c.Next()
+	}
+}
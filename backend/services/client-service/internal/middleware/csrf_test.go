@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/authcookie"
+)
+
+func TestCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(CSRF())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		method     string
+		authCookie bool
+		csrfCookie string
+		csrfHeader string
+		wantStatus int
+	}{
+		{name: "safe method with cookie", method: http.MethodGet, authCookie: true, wantStatus: http.StatusOK},
+		{name: "no auth cookie", method: http.MethodPost, wantStatus: http.StatusOK},
+		{name: "missing header", method: http.MethodPost, authCookie: true, csrfCookie: "token", wantStatus: http.StatusForbidden},
+		{name: "mismatched header", method: http.MethodPost, authCookie: true, csrfCookie: "token", csrfHeader: "forged", wantStatus: http.StatusForbidden},
+		{name: "missing csrf cookie", method: http.MethodPost, authCookie: true, csrfHeader: "token", wantStatus: http.StatusForbidden},
+		{name: "matching header", method: http.MethodPost, authCookie: true, csrfCookie: "token", csrfHeader: "token", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.authCookie {
+				req.AddCookie(&http.Cookie{Name: authcookie.RefreshTokenCookie, Value: "refresh"})
+			}
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: authcookie.CSRFTokenCookie, Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(authcookie.CSRFHeader, tt.csrfHeader)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/httpx"
+	"microbank/pkg/redact"
+)
+
+// ErrorDetails returns the details to send for a failed request. The error
+// is logged with the request ID, with email addresses and tokens masked.
+// Clients see its text only in debug mode; otherwise they get the message of
+// a public service error it wraps, or a generic message pointing at the
+// request ID.
+func ErrorDetails(c httpx.Context, err error) string {
+	log.Printf("Request %s failed: %s", c.GetString("request_id"), redact.Text(err.Error()))
+	return redact.PublicDetail(err, gin.Mode() == gin.DebugMode, services.PublicErrors...)
+}
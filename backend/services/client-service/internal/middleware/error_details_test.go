@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"microbank/client-service/internal/services"
+	"microbank/pkg/redact"
+)
+
+func TestErrorDetails(t *testing.T) {
+	dbErr := errors.New(`pq: duplicate key value violates unique constraint "users_email_key" (jane@example.com)`)
+
+	tests := []struct {
+		name string
+		mode string
+		err  error
+		want string
+	}{
+		{name: "internal error in release mode", mode: gin.ReleaseMode, err: fmt.Errorf("failed to create user: %w", dbErr), want: redact.GenericDetail},
+		{name: "public error in release mode", mode: gin.ReleaseMode, err: fmt.Errorf("%w: %w", services.ErrUserExists, dbErr), want: services.ErrUserExists.Error()},
+		{name: "internal error in debug mode", mode: gin.DebugMode, err: dbErr, want: dbErr.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(tt.mode)
+			defer gin.SetMode(gin.TestMode)
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Set("request_id", "abc-123")
+			if got := ErrorDetails(c, tt.err); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
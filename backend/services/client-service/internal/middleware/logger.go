@@ -4,31 +4,51 @@ import (
 	"fmt"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
+	"microbank/pkg/authmw"
+	"microbank/pkg/redact"
 )
 
-// Logger provides structured logging for HTTP requests
-func Logger() gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		// Generate request ID for correlation
-		requestID := uuid.New().String()
-		
-		// Store request ID in context for handlers to use
-		if param.Keys == nil {
-			param.Keys = make(map[string]interface{})
+// Logger provides structured logging for HTTP requests. It must run after
+// RequestID so each entry carries the ID the client was sent. With
+// redactPII set, email addresses and secret query values such as reset
+// tokens are masked in the logged path.
+func Logger(redactPII bool) gin.HandlerFunc {
+	return gin.LoggerWithFormatter(logFormatter(redactPII))
+}
+
+// logFormatter formats one request log line, ending with the request ID
+// stored by RequestID, or "-" if there is none. Requests made with an
+// impersonation token also name the impersonating admin, who is the real
+// actor, and the user they acted as.
+func logFormatter(redactPII bool) gin.LogFormatter {
+	return func(param gin.LogFormatterParams) string {
+		requestID, _ := param.Keys["request_id"].(string)
+		if requestID == "" {
+			requestID = "-"
+		}
+
+		path := param.Path
+		if redactPII {
+			path = redact.RequestURI(path)
+		}
+
+		impersonation := ""
+		if impersonator, _ := param.Keys[authmw.ImpersonatorIDKey].(string); impersonator != "" {
+			userID, _ := param.Keys[authmw.UserIDKey].(string)
+			impersonation = fmt.Sprintf(" | impersonator=%s user=%s", impersonator, userID)
 		}
-		param.Keys["request_id"] = requestID
 
 		// Format the log entry
-		return fmt.Sprintf("[%s] %s | %s | %d | %s | %s | %s | %s\n",
+		return fmt.Sprintf("[%s] %s | %s | %d | %s | %s | %s | %s%s\n",
 			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
 			param.Method,
-			param.Path,
+			path,
 			param.StatusCode,
 			param.Latency,
 			param.ClientIP,
 			param.Request.UserAgent(),
 			requestID,
+			impersonation,
 		)
-	})
+	}
 }
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"microbank/pkg/authmw"
+)
+
+func TestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		redactPII bool
+		wantPath  string
+	}{
+		{name: "redacted", redactPII: true, wantPath: "/verify?email=j%2A%2A%2A%40example.com&token=sha256%3A"},
+		{name: "raw", redactPII: false, wantPath: "/verify?email=jane@example.com&token=secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			r := gin.New()
+			r.Use(RequestID(), gin.LoggerWithConfig(gin.LoggerConfig{Formatter: logFormatter(tt.redactPII), Output: &out}))
+			r.GET("/verify", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/verify?email=jane@example.com&token=secret", nil)
+			req.Header.Set(RequestIDHeader, "abc-123")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			line := out.String()
+			if !strings.HasSuffix(line, "| abc-123\n") {
+				t.Errorf("Expected the log entry to end with the request ID, got %q", line)
+			}
+			if !strings.Contains(line, "| "+tt.wantPath) {
+				t.Errorf("Expected the path %q to be logged, got %q", tt.wantPath, line)
+			}
+		})
+	}
+}
+
+func TestLogger_Impersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var out bytes.Buffer
+	r := gin.New()
+	r.Use(RequestID(), gin.LoggerWithConfig(gin.LoggerConfig{Formatter: logFormatter(false), Output: &out}))
+	r.GET("/profile", func(c *gin.Context) {
+		c.Set(authmw.UserIDKey, "user-1")
+		c.Set(authmw.ImpersonatorIDKey, "admin-1")
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if line := out.String(); !strings.HasSuffix(line, "| abc-123 | impersonator=admin-1 user=user-1\n") {
+		t.Errorf("Expected the log entry to name the impersonator, got %q", line)
+	}
+}
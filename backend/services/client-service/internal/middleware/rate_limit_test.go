@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRateLimitedRouter(t *testing.T, trusted string, requests int) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	proxies, err := ParseTrustedProxies(trusted)
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies returned error: %v", err)
+	}
+
+	r := gin.New()
+	r.POST("/login", RateLimit(NewInMemoryRateLimitStore(), proxies, RateLimitConfig{
+		Name:     "login",
+		Requests: requests,
+		Window:   time.Minute,
+	}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func doRequest(r *gin.Engine, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_BlocksWithRetryAfter(t *testing.T) {
+	r := newRateLimitedRouter(t, "", 2)
+
+	for i := 0; i < 2; i++ {
+		if w := doRequest(r, "203.0.113.7:1234", ""); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+
+	w := doRequest(r, "203.0.113.7:1234", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header on 429 response")
+	}
+}
+
+func TestRateLimit_IgnoresForwardedForWithoutTrustedProxy(t *testing.T) {
+	r := newRateLimitedRouter(t, "", 1)
+
+	if w := doRequest(r, "203.0.113.7:1234", "198.51.100.1"); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	// A spoofed X-Forwarded-For must not grant a fresh bucket
+	if w := doRequest(r, "203.0.113.7:1234", "198.51.100.2"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 when only X-Forwarded-For changes, got %d", w.Code)
+	}
+}
+
+func TestRateLimit_HonorsForwardedForFromTrustedProxy(t *testing.T) {
+	r := newRateLimitedRouter(t, "10.0.0.0/8", 1)
+
+	if w := doRequest(r, "10.0.0.5:1234", "198.51.100.1"); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	if w := doRequest(r, "10.0.0.5:1234", "198.51.100.2"); w.Code != http.StatusOK {
+		t.Errorf("Expected distinct clients behind a trusted proxy to have separate limits, got %d", w.Code)
+	}
+
+	if w := doRequest(r, "10.0.0.5:1234", "198.51.100.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 for repeated client behind trusted proxy, got %d", w.Code)
+	}
+}
+
+func TestGlobalRateLimit_SharedAcrossClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", GlobalRateLimit(NewInMemoryRateLimitStore(), RateLimitConfig{
+		Name:     "login-global",
+		Requests: 2,
+		Window:   time.Minute,
+	}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	doRequest(r, "203.0.113.7:1234", "")
+	doRequest(r, "203.0.113.8:1234", "")
+
+	w := doRequest(r, "203.0.113.9:1234", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 once all clients together reach the limit, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header on 429 response")
+	}
+}
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.1, 10.1.0.0/16")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies returned error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		expected     string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:1234", expected: "203.0.113.7"},
+		{name: "untrusted peer with header", remoteAddr: "203.0.113.7:1234", forwardedFor: "198.51.100.1", expected: "203.0.113.7"},
+		{name: "trusted peer", remoteAddr: "10.0.0.1:1234", forwardedFor: "198.51.100.1", expected: "198.51.100.1"},
+		{name: "client-prepended spoof", remoteAddr: "10.0.0.1:1234", forwardedFor: "1.2.3.4, 198.51.100.1", expected: "198.51.100.1"},
+		{name: "chained trusted proxies", remoteAddr: "10.0.0.1:1234", forwardedFor: "198.51.100.1, 10.1.2.3", expected: "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if got := proxies.ClientIP(req); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"runtime/debug"
 
 	"github.com/gin-gonic/gin"
+	"microbank/pkg/httpx"
 )
 
 // Recovery recovers from panics and provides proper error responses
@@ -15,11 +16,10 @@ func Recovery() gin.HandlerFunc {
 		c.Error(fmt.Errorf("panic recovered: %v\n%s", recovered, debug.Stack()))
 
 		// Return a generic error response to the client
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": gin.H{
-				"code":    "INTERNAL_SERVER_ERROR",
-				"message": "An unexpected error occurred",
-			},
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_SERVER_ERROR",
+			Message: "An unexpected error occurred",
 		})
 	})
 }
@@ -0,0 +1,255 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/pkg/httpx"
+)
+
+// invalidRefreshTokenKey is the context key MarkInvalidRefreshToken sets
+const invalidRefreshTokenKey = "invalid_refresh_token"
+
+// RefreshGuardConfig describes when client IPs are blocked from refreshing
+// tokens
+type RefreshGuardConfig struct {
+	// Threshold is how many unknown refresh tokens an IP may present per
+	// Window before it is blocked
+	Threshold int
+	Window    time.Duration
+	// BlockDuration is how long a block lasts
+	BlockDuration time.Duration
+}
+
+// LoadRefreshGuardConfig reads the refresh guard config from
+// REFRESH_GUARD_THRESHOLD, REFRESH_GUARD_WINDOW and
+// REFRESH_GUARD_BLOCK_DURATION, falling back to 20 unknown tokens per 15
+// minutes and a one hour block
+func LoadRefreshGuardConfig() RefreshGuardConfig {
+	config := RefreshGuardConfig{Threshold: 20, Window: 15 * time.Minute, BlockDuration: time.Hour}
+
+	if value := os.Getenv("REFRESH_GUARD_THRESHOLD"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			config.Threshold = n
+		}
+	}
+
+	if value := os.Getenv("REFRESH_GUARD_WINDOW"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			config.Window = d
+		}
+	}
+
+	if value := os.Getenv("REFRESH_GUARD_BLOCK_DURATION"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			config.BlockDuration = d
+		}
+	}
+
+	return config
+}
+
+// RefreshBlock is a client IP blocked from refreshing tokens
+type RefreshBlock struct {
+	IP string `json:"ip"`
+	// InvalidAttempts is how many unknown refresh tokens the IP presented
+	// in the window that got it blocked
+	InvalidAttempts int       `json:"invalid_attempts"`
+	BlockedAt       time.Time `json:"blocked_at"`
+	BlockedUntil    time.Time `json:"blocked_until"`
+}
+
+// RefreshGuard counts the unknown refresh tokens each client IP presents
+// and temporarily blocks IPs that present too many, which stops guessing
+// refresh tokens. Expired tokens are not counted, so clients retrying with
+// a token that has just expired are never blocked. State is kept in process
+// memory, like InMemoryRateLimitStore. It is safe for concurrent use.
+type RefreshGuard struct {
+	config RefreshGuardConfig
+
+	mu              sync.Mutex
+	failures        map[string][]time.Time
+	blocks          map[string]*RefreshBlock
+	invalidAttempts int64
+	blocksTotal     int64
+	lastSweep       time.Time
+	now             func() time.Time
+}
+
+// NewRefreshGuard creates a new refresh guard
+func NewRefreshGuard(config RefreshGuardConfig) *RefreshGuard {
+	return &RefreshGuard{
+		config:    config,
+		failures:  make(map[string][]time.Time),
+		blocks:    make(map[string]*RefreshBlock),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// RecordInvalid counts an unknown refresh token presented by ip, blocking
+// ip once it reaches the threshold
+func (g *RefreshGuard) RecordInvalid(ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.sweep(now)
+	g.invalidAttempts++
+
+	// Drop failures that have slid out of the window
+	cutoff := now.Add(-g.config.Window)
+	times := g.failures[ip]
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	times = append(times[i:], now)
+	g.failures[ip] = times
+
+	if len(times) >= g.config.Threshold {
+		if _, blocked := g.blocks[ip]; !blocked {
+			g.blocksTotal++
+		}
+		g.blocks[ip] = &RefreshBlock{
+			IP:              ip,
+			InvalidAttempts: len(times),
+			BlockedAt:       now,
+			BlockedUntil:    now.Add(g.config.BlockDuration),
+		}
+		delete(g.failures, ip)
+	}
+}
+
+// blockedFor reports whether ip is blocked and, if so, for how much longer
+func (g *RefreshGuard) blockedFor(ip string) (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	block, ok := g.blocks[ip]
+	if !ok {
+		return 0, false
+	}
+
+	remaining := block.BlockedUntil.Sub(g.now())
+	if remaining <= 0 {
+		delete(g.blocks, ip)
+		return 0, false
+	}
+	return remaining, true
+}
+
+// Blocks returns the IPs blocked now, the most recently blocked first
+func (g *RefreshGuard) Blocks() []RefreshBlock {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	blocks := make([]RefreshBlock, 0, len(g.blocks))
+	for ip, block := range g.blocks {
+		if !block.BlockedUntil.After(now) {
+			delete(g.blocks, ip)
+			continue
+		}
+		blocks = append(blocks, *block)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].BlockedAt.After(blocks[j].BlockedAt)
+	})
+	return blocks
+}
+
+// Unblock lifts the block on ip and forgets its unknown tokens, reporting
+// whether ip was blocked
+func (g *RefreshGuard) Unblock(ip string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	block, ok := g.blocks[ip]
+	delete(g.blocks, ip)
+	delete(g.failures, ip)
+	return ok && block.BlockedUntil.After(g.now())
+}
+
+// sweep removes IPs whose failures have all slid out of the window, and
+// blocks that have ended
+func (g *RefreshGuard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	g.lastSweep = now
+
+	cutoff := now.Add(-g.config.Window)
+	for ip, times := range g.failures {
+		if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+			delete(g.failures, ip)
+		}
+	}
+	for ip, block := range g.blocks {
+		if !block.BlockedUntil.After(now) {
+			delete(g.blocks, ip)
+		}
+	}
+}
+
+// WriteMetrics writes the guard's counters in the Prometheus text format
+func (g *RefreshGuard) WriteMetrics(w io.Writer) {
+	g.mu.Lock()
+	invalidAttempts, blocksTotal := g.invalidAttempts, g.blocksTotal
+	active := 0
+	now := g.now()
+	for _, block := range g.blocks {
+		if block.BlockedUntil.After(now) {
+			active++
+		}
+	}
+	g.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP microbank_refresh_token_invalid_attempts_total Refresh requests that presented an unknown refresh token.")
+	fmt.Fprintln(w, "# TYPE microbank_refresh_token_invalid_attempts_total counter")
+	fmt.Fprintf(w, "microbank_refresh_token_invalid_attempts_total %d\n", invalidAttempts)
+
+	fmt.Fprintln(w, "# HELP microbank_refresh_blocks_total Times a client IP has been blocked from refreshing tokens.")
+	fmt.Fprintln(w, "# TYPE microbank_refresh_blocks_total counter")
+	fmt.Fprintf(w, "microbank_refresh_blocks_total %d\n", blocksTotal)
+
+	fmt.Fprintln(w, "# HELP microbank_refresh_blocked_ips Client IPs blocked from refreshing tokens now.")
+	fmt.Fprintln(w, "# TYPE microbank_refresh_blocked_ips gauge")
+	fmt.Fprintf(w, "microbank_refresh_blocked_ips %d\n", active)
+}
+
+// MarkInvalidRefreshToken tells GuardRefresh that the request presented an
+// unknown refresh token
+func MarkInvalidRefreshToken(c *gin.Context) {
+	c.Set(invalidRefreshTokenKey, true)
+}
+
+// GuardRefresh refuses requests from IPs the guard has blocked with 429,
+// and records requests the handler marked with MarkInvalidRefreshToken
+func GuardRefresh(guard *RefreshGuard, proxies *TrustedProxies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := proxies.ClientIP(c.Request)
+
+		if retryAfter, blocked := guard.blockedFor(ip); blocked {
+			abortTooManyRequests(c, retryAfter, &httpx.AppError{
+				Status:  http.StatusTooManyRequests,
+				Code:    "REFRESH_BLOCKED",
+				Message: "Too many invalid refresh tokens from this address, please try again later",
+			})
+			return
+		}
+
+		c.Next()
+
+		if c.GetBool(invalidRefreshTokenKey) {
+			guard.RecordInvalid(ip)
+		}
+	}
+}
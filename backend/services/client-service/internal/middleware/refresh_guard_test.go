@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRefreshGuard(now *time.Time) *RefreshGuard {
+	guard := NewRefreshGuard(RefreshGuardConfig{Threshold: 3, Window: 10 * time.Minute, BlockDuration: time.Hour})
+	guard.now = func() time.Time { return *now }
+	return guard
+}
+
+func TestRefreshGuard_BlocksAtThreshold(t *testing.T) {
+	now := time.Now()
+	guard := newTestRefreshGuard(&now)
+
+	guard.RecordInvalid("203.0.113.7")
+	guard.RecordInvalid("203.0.113.7")
+	if _, blocked := guard.blockedFor("203.0.113.7"); blocked {
+		t.Fatal("Expected no block below the threshold")
+	}
+
+	guard.RecordInvalid("203.0.113.7")
+	retryAfter, blocked := guard.blockedFor("203.0.113.7")
+	if !blocked || retryAfter != time.Hour {
+		t.Fatalf("Expected a one hour block, got %v %s", blocked, retryAfter)
+	}
+	if _, blocked := guard.blockedFor("203.0.113.8"); blocked {
+		t.Error("Expected other addresses not to be blocked")
+	}
+
+	now = now.Add(time.Hour)
+	if _, blocked := guard.blockedFor("203.0.113.7"); blocked {
+		t.Error("Expected the block to end after its duration")
+	}
+}
+
+func TestRefreshGuard_FailuresSlideOutOfWindow(t *testing.T) {
+	now := time.Now()
+	guard := newTestRefreshGuard(&now)
+
+	guard.RecordInvalid("203.0.113.7")
+	guard.RecordInvalid("203.0.113.7")
+	now = now.Add(11 * time.Minute)
+	guard.RecordInvalid("203.0.113.7")
+
+	if _, blocked := guard.blockedFor("203.0.113.7"); blocked {
+		t.Error("Expected failures outside the window not to count")
+	}
+}
+
+func TestRefreshGuard_Unblock(t *testing.T) {
+	now := time.Now()
+	guard := newTestRefreshGuard(&now)
+
+	for i := 0; i < 3; i++ {
+		guard.RecordInvalid("203.0.113.7")
+	}
+	if blocks := guard.Blocks(); len(blocks) != 1 || blocks[0].IP != "203.0.113.7" {
+		t.Fatalf("Expected one block, got %+v", blocks)
+	}
+
+	if !guard.Unblock("203.0.113.7") {
+		t.Error("Expected Unblock to report the block")
+	}
+	if guard.Unblock("203.0.113.7") {
+		t.Error("Expected a second Unblock to find nothing")
+	}
+	if blocks := guard.Blocks(); len(blocks) != 0 {
+		t.Errorf("Expected no blocks, got %+v", blocks)
+	}
+}
+
+func TestRefreshGuard_WriteMetrics(t *testing.T) {
+	now := time.Now()
+	guard := newTestRefreshGuard(&now)
+	for i := 0; i < 4; i++ {
+		guard.RecordInvalid("203.0.113.7")
+	}
+
+	var out strings.Builder
+	guard.WriteMetrics(&out)
+
+	for _, want := range []string{
+		"microbank_refresh_token_invalid_attempts_total 4",
+		"microbank_refresh_blocks_total 1",
+		"microbank_refresh_blocked_ips 1",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
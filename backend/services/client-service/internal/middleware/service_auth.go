@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"microbank/pkg/httpx"
+)
+
+// ServiceTokenHeader carries the shared secret on internal service-to-service calls
+const ServiceTokenHeader = "X-Service-Token"
+
+// ServiceAuthMiddleware restricts internal routes to callers presenting the
+// shared INTERNAL_SERVICE_TOKEN, expected. All requests are rejected when
+// expected is empty.
+func ServiceAuthMiddleware(expected string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader(ServiceTokenHeader)
+
+		if expected == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+			httpx.AbortWithError(c, &httpx.AppError{
+				Status:  http.StatusUnauthorized,
+				Code:    "INVALID_SERVICE_TOKEN",
+				Message: "A valid service token is required",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// BankingStats are the banking-service's figures for the admin dashboard:
+// the money held, the day's activity and the alerts waiting for staff
+type BankingStats struct {
+	Accounts               int       `json:"accounts"`
+	TotalBalance           float64   `json:"total_balance"`
+	TransactionsToday      int       `json:"transactions_today"`
+	VolumeToday            float64   `json:"volume_today"`
+	OpenDisputes           int       `json:"open_disputes"`
+	OpenSuspiciousActivity int       `json:"open_suspicious_activity"`
+	GeneratedAt            time.Time `json:"generated_at"`
+}
+
+// AdminDashboard brings together the stats of every service for the admin
+// dashboard. A section whose service could not be reached is nil, so the
+// rest of the dashboard can still be shown.
+type AdminDashboard struct {
+	Users       *AdminStats
+	Banking     *BankingStats
+	GeneratedAt time.Time
+}
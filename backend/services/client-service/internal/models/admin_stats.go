@@ -0,0 +1,57 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// UserCounts are aggregate counts over active (not deleted) users
+type UserCounts struct {
+	Total         int
+	NewToday      int
+	NewThisWeek   int
+	NewThisMonth  int
+	Blacklisted   int
+	EmailVerified int
+}
+
+// AdminStats summarizes signups and engagement for the admin dashboard
+type AdminStats struct {
+	Users          UserCounts
+	ActiveSessions int
+	GeneratedAt    time.Time
+}
+
+// VerifiedEmailPercent returns the share of users with a verified email, in
+// percent rounded to one decimal place
+func (s *AdminStats) VerifiedEmailPercent() float64 {
+	if s.Users.Total == 0 {
+		return 0
+	}
+	return math.Round(float64(s.Users.EmailVerified)*1000/float64(s.Users.Total)) / 10
+}
+
+// SignupCount is the number of users who registered through one source in
+// the week starting on WeekStart
+type SignupCount struct {
+	WeekStart time.Time
+	Source    string
+	Count     int
+}
+
+// SignupWeek counts the users who registered in the week starting on
+// WeekStart (a Monday, in UTC) by registration source. Every known source
+// is present, with zero when nobody registered through it.
+type SignupWeek struct {
+	WeekStart time.Time
+	BySource  map[string]int
+}
+
+// Total returns the number of users who registered in the week
+func (w *SignupWeek) Total() int {
+	total := 0
+	for _, count := range w.BySource {
+		total += count
+	}
+	return total
+}
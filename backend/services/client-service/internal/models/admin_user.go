@@ -0,0 +1,16 @@
+package models
+
+// AdminUserCreation represents the data staff give to open an account on a
+// customer's behalf. The customer chooses their own password from the
+// setup link emailed to them.
+type AdminUserCreation struct {
+	Email string `json:"email" binding:"required,email"`
+	Name  string `json:"name" binding:"required,min=2,max=100"`
+}
+
+// AdminCreatedUser is a user created by staff, along with whether their
+// password setup link could be emailed
+type AdminCreatedUser struct {
+	User           *User
+	SetupEmailSent bool
+}
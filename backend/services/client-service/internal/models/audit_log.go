@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audited admin actions
+const (
+	AuditActionBlacklist   = "user.blacklist"
+	AuditActionUnblacklist = "user.unblacklist"
+	AuditActionGrantAdmin  = "user.grant_admin"
+	AuditActionRevokeAdmin = "user.revoke_admin"
+	AuditActionGrantRole   = "user.grant_role"
+	AuditActionRevokeRole  = "user.revoke_role"
+	AuditActionDelete      = "user.delete"
+	AuditActionRestore     = "user.restore"
+	AuditActionForceLogout = "user.force_logout"
+	AuditActionExportUsers = "user.export"
+	AuditActionCreateUser  = "user.create"
+	AuditActionViewClient  = "user.view"
+
+	AuditActionImpersonationStart = "user.impersonation_start"
+	AuditActionImpersonationEnd   = "user.impersonation_end"
+
+	AuditActionApproveKYC      = "user.kyc_approve"
+	AuditActionRejectKYC       = "user.kyc_reject"
+	AuditActionViewKYCDocument = "user.kyc_document_view"
+
+	AuditActionCreateInvitation = "invitation.create"
+	AuditActionRevokeInvitation = "invitation.revoke"
+
+	// Dispute actions are taken in the banking service and logged from its
+	// dispute.status_changed events
+	AuditActionAssignDispute  = "transaction.dispute_assign"
+	AuditActionResolveDispute = "transaction.dispute_resolve"
+
+	// Transactions are paused and resumed in the banking service and
+	// logged from its maintenance.changed events
+	AuditActionPauseTransactions  = "maintenance.pause_transactions"
+	AuditActionResumeTransactions = "maintenance.resume_transactions"
+
+	// Transaction PINs are reset in the banking service and logged from
+	// its transaction_pin.reset events
+	AuditActionResetTransactionPIN = "account.transaction_pin_reset"
+)
+
+// AuditActor identifies the admin performing an action and the request it
+// was made in
+type AuditActor struct {
+	AdminID   uuid.UUID
+	RequestID string
+}
+
+// AuditLogEntry records a single admin action. TargetUserID is kept after
+// the target is deleted so the log stays complete.
+type AuditLogEntry struct {
+	ID           uuid.UUID              `json:"id" db:"id"`
+	AdminID      uuid.UUID              `json:"admin_id" db:"admin_id"`
+	Action       string                 `json:"action" db:"action"`
+	TargetUserID *uuid.UUID             `json:"target_user_id" db:"target_user_id"`
+	Metadata     map[string]interface{} `json:"metadata" db:"metadata"`
+	RequestID    string                 `json:"request_id" db:"request_id"`
+	CreatedAt    time.Time              `json:"created_at" db:"created_at"`
+}
+
+// AuditLogFilter controls filtering and paging of the audit log. Nil or
+// empty filters are not applied.
+type AuditLogFilter struct {
+	AdminID      *uuid.UUID
+	TargetUserID *uuid.UUID
+	Action       string
+	Limit        int
+	Offset       int
+}
+
+// AuditLogPage is one page of the audit log along with the total number of
+// entries matching the filters
+type AuditLogPage struct {
+	Entries []AuditLogEntry
+	Total   int
+	Limit   int
+	Offset  int
+}
+
+// HasMore reports whether further pages exist after this one
+func (p *AuditLogPage) HasMore() bool {
+	return p.Offset+len(p.Entries) < p.Total
+}
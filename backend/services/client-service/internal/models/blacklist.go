@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Blacklist history actions
+const (
+	BlacklistActionAdd    = "blacklist"
+	BlacklistActionRemove = "unblacklist"
+)
+
+// BlacklistReasonExpired is recorded when a temporary blacklist lapses
+const BlacklistReasonExpired = "blacklist expired"
+
+// BlacklistEntry records a single blacklist or unblacklist of a user. AdminID
+// is nil when the system lifted an expired blacklist.
+type BlacklistEntry struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	Action    string     `json:"action" db:"action"`
+	Reason    string     `json:"reason" db:"reason"`
+	ExpiresAt *time.Time `json:"expires_at" db:"expires_at"`
+	AdminID   *uuid.UUID `json:"admin_id" db:"admin_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// BlacklistRequest represents an admin request to blacklist a user. A nil
+// ExpiresAt blacklists the user indefinitely.
+type BlacklistRequest struct {
+	Reason    string     `json:"reason" binding:"required,max=500"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// MaxBlacklistBatchSize caps the users in one batch blacklist request
+const MaxBlacklistBatchSize = 500
+
+// Batch blacklist outcomes, reported for each requested user ID
+const (
+	BlacklistBatchOK                 = "ok"
+	BlacklistBatchNotFound           = "not_found"
+	BlacklistBatchAlreadyBlacklisted = "already_blacklisted"
+	BlacklistBatchNotBlacklisted     = "not_blacklisted"
+)
+
+// BatchBlacklistRequest represents an admin request to blacklist several
+// users with a shared reason and optional expiry. Duplicate IDs are ignored.
+type BatchBlacklistRequest struct {
+	UserIDs   []uuid.UUID `json:"user_ids" binding:"required,min=1,max=500"`
+	Reason    string      `json:"reason" binding:"required,max=500"`
+	ExpiresAt *time.Time  `json:"expires_at"`
+}
+
+// BatchUnblacklistRequest represents an admin request to lift the blacklist
+// of several users. Duplicate IDs are ignored.
+type BatchUnblacklistRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids" binding:"required,min=1,max=500"`
+	Reason  string      `json:"reason" binding:"max=500"`
+}
+
+// BlacklistBatchResult is the outcome of a batch request for one user
+type BlacklistBatchResult struct {
+	UserID uuid.UUID `json:"user_id"`
+	Status string    `json:"status"`
+}
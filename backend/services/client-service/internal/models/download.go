@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of download artifacts
+const (
+	// DownloadKindClientsExport is the admin export of the user list
+	DownloadKindClientsExport = "clients_export"
+)
+
+// DownloadArtifact is a generated file, such as an export, kept for the
+// user it was generated for to download a limited number of times before
+// it expires. The file itself is kept encrypted in the download store under
+// StorageKey.
+type DownloadArtifact struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	UserID        uuid.UUID `json:"user_id" db:"user_id"`
+	Kind          string    `json:"kind" db:"kind"`
+	FileName      string    `json:"file_name" db:"file_name"`
+	ContentType   string    `json:"content_type" db:"content_type"`
+	SizeBytes     int64     `json:"size_bytes" db:"size_bytes"`
+	StorageKey    string    `json:"-" db:"storage_key"`
+	MaxDownloads  int       `json:"max_downloads" db:"max_downloads"`
+	DownloadCount int       `json:"download_count" db:"download_count"`
+	ExpiresAt     time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// IsExpired checks if the artifact can no longer be downloaded
+func (a *DownloadArtifact) IsExpired() bool {
+	return time.Now().After(a.ExpiresAt)
+}
+
+// IsExhausted checks if the artifact has been downloaded as often as it may
+// be
+func (a *DownloadArtifact) IsExhausted() bool {
+	return a.DownloadCount >= a.MaxDownloads
+}
+
+// DownloadAccess records one download of an artifact
+type DownloadAccess struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	ArtifactID uuid.UUID `json:"artifact_id" db:"artifact_id"`
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
+	IPAddress  string    `json:"ip_address" db:"ip_address"`
+	UserAgent  string    `json:"user_agent" db:"user_agent"`
+	AccessedAt time.Time `json:"accessed_at" db:"accessed_at"`
+}
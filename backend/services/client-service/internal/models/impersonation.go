@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImpersonationSession is a short-lived access token issued to an admin to
+// act as a user
+type ImpersonationSession struct {
+	AccessToken    string
+	ExpiresAt      time.Time
+	UserID         uuid.UUID
+	ImpersonatorID uuid.UUID
+}
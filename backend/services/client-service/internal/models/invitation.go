@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation statuses, derived from an invitation's timestamps
+const (
+	InvitationStatusActive  = "active"
+	InvitationStatusUsed    = "used"
+	InvitationStatusExpired = "expired"
+	InvitationStatusRevoked = "revoked"
+)
+
+// Invitation represents a code that lets someone register while
+// registration is invite-only. An invitation bound to an email can only be
+// used to register that email.
+type Invitation struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Code      string     `json:"code" db:"code"`
+	Email     string     `json:"email,omitempty" db:"email"`
+	CreatedBy *uuid.UUID `json:"created_by" db:"created_by"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedBy    *uuid.UUID `json:"used_by,omitempty" db:"used_by"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Status reports whether the invitation is active, used, expired or revoked
+func (i *Invitation) Status() string {
+	switch {
+	case i.UsedAt != nil:
+		return InvitationStatusUsed
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case time.Now().After(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusActive
+	}
+}
+
+// InvitationResponse represents an invitation as returned to admins
+type InvitationResponse struct {
+	Invitation
+	Status string `json:"status"`
+}
+
+// ToResponse converts an Invitation to an InvitationResponse
+func (i *Invitation) ToResponse() InvitationResponse {
+	return InvitationResponse{Invitation: *i, Status: i.Status()}
+}
+
+// InvitationRequest represents an admin's request to create an invitation.
+// ExpiresInDays defaults to 7.
+type InvitationRequest struct {
+	Email         string `json:"email" binding:"omitempty,email"`
+	ExpiresInDays int    `json:"expires_in_days" binding:"omitempty,min=1,max=90"`
+}
+
+// ListInvitationsOptions controls filtering and paging of invitation
+// listings. An empty Status lists every invitation.
+type ListInvitationsOptions struct {
+	Status string
+	Limit  int
+	Offset int
+}
+
+// InvitationPage is one page of invitations along with the total number
+// matching the filters
+type InvitationPage struct {
+	Invitations []Invitation
+	Total       int
+	Limit       int
+	Offset      int
+}
+
+// HasMore reports whether further pages exist after this one
+func (p *InvitationPage) HasMore() bool {
+	return p.Offset+len(p.Invitations) < p.Total
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KnownDevice represents a device a user has logged in from. A device is
+// identified by a fingerprint of its user agent and network.
+type KnownDevice struct {
+	ID                uuid.UUID `json:"id" db:"id"`
+	UserID            uuid.UUID `json:"user_id" db:"user_id"`
+	Fingerprint       string    `json:"-" db:"fingerprint"`
+	UserAgent         string    `json:"user_agent" db:"user_agent"`
+	IPAddress         string    `json:"ip_address" db:"ip_address"`
+	ReportTokenHash   string    `json:"-" db:"report_token_hash"`
+	ReportTokenExpiry time.Time `json:"-" db:"report_token_expires_at"`
+	FirstSeenAt       time.Time `json:"first_seen_at" db:"first_seen_at"`
+	LastSeenAt        time.Time `json:"last_seen_at" db:"last_seen_at"`
+}
+
+// LoginReport represents a user's report that a new-device login was not them
+type LoginReport struct {
+	Token string `json:"token" binding:"required"`
+}
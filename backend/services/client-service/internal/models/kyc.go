@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KYC statuses of a user and of their submissions. Users start with
+// KYCStatusNone; a submission makes them pending until a reviewer approves
+// or rejects it.
+const (
+	KYCStatusNone     = "none"
+	KYCStatusPending  = "pending"
+	KYCStatusVerified = "verified"
+	KYCStatusRejected = "rejected"
+)
+
+// KYC document types a user may submit
+const (
+	KYCDocumentPassport       = "passport"
+	KYCDocumentNationalID     = "national_id"
+	KYCDocumentDriversLicense = "drivers_license"
+)
+
+// KYCSubmission is one set of identity details a user submitted for
+// verification, along with the reviewer's decision once made. Country is
+// the ISO 3166-1 alpha-2 code of the issuing country.
+type KYCSubmission struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	UserID         uuid.UUID  `json:"user_id" db:"user_id"`
+	DocumentType   string     `json:"document_type" db:"document_type"`
+	DocumentNumber string     `json:"document_number" db:"document_number"`
+	Country        string     `json:"country" db:"country"`
+	Status         string     `json:"status" db:"status"`
+	ReviewerID     *uuid.UUID `json:"reviewer_id,omitempty" db:"reviewer_id"`
+	ReviewNotes    string     `json:"review_notes,omitempty" db:"review_notes"`
+	SubmittedAt    time.Time  `json:"submitted_at" db:"submitted_at"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+// KYCSubmissionRequest represents the identity details a user submits
+type KYCSubmissionRequest struct {
+	DocumentType   string `json:"document_type" binding:"required,oneof=passport national_id drivers_license"`
+	DocumentNumber string `json:"document_number" binding:"required,max=50"`
+	Country        string `json:"country" binding:"required"`
+}
+
+// KYCReviewRequest carries a reviewer's notes on a decision. Notes are
+// required when rejecting, so the user knows what to fix.
+type KYCReviewRequest struct {
+	Notes string `json:"notes" binding:"max=1000"`
+}
+
+// KYCStatusResponse is a user's KYC status along with their latest
+// submission, if any
+type KYCStatusResponse struct {
+	Status     string         `json:"status"`
+	Submission *KYCSubmission `json:"submission"`
+}
+
+// ListKYCSubmissionsOptions controls filtering and paging of KYC
+// submission listings. An empty Status lists every submission.
+type ListKYCSubmissionsOptions struct {
+	Status string
+	Limit  int
+	Offset int
+}
+
+// KYCSubmissionPage is one page of KYC submissions along with the total
+// number matching the filters
+type KYCSubmissionPage struct {
+	Submissions []KYCSubmission
+	Total       int
+	Limit       int
+	Offset      int
+}
+
+// KYCDocument is an uploaded scan backing a KYC submission. The file itself
+// is kept encrypted in the document store under StorageKey.
+type KYCDocument struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	SubmissionID *uuid.UUID `json:"submission_id" db:"submission_id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	FileName     string     `json:"file_name" db:"file_name"`
+	ContentType  string     `json:"content_type" db:"content_type"`
+	SizeBytes    int64      `json:"size_bytes" db:"size_bytes"`
+	StorageKey   string     `json:"-" db:"storage_key"`
+	UploadedAt   time.Time  `json:"uploaded_at" db:"uploaded_at"`
+}
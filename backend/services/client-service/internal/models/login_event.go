@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Login failure reasons recorded on LoginEvent
+const (
+	LoginFailureUnknownEmail     = "unknown_email"
+	LoginFailureInvalidPassword  = "invalid_password"
+	LoginFailureAccountSuspended = "account_suspended"
+	LoginFailureAccountDeleted   = "account_deleted"
+	LoginFailurePasswordReset    = "password_reset_required"
+	LoginFailurePasswordSetup    = "password_setup_required"
+	LoginFailureInternalError    = "internal_error"
+	LoginFailureInvalidCode      = "invalid_code"
+	LoginFailureCodeExpired      = "code_expired"
+	LoginFailureTooManyAttempts  = "too_many_attempts"
+)
+
+// Ways of signing in recorded on LoginEvent
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodOAuth     = "oauth"
+	LoginMethodSMSOTP    = "sms_otp"
+	LoginMethodMagicLink = "magic_link"
+)
+
+// LoginEvent represents a single login attempt, successful or not
+type LoginEvent struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	UserID        *uuid.UUID `json:"user_id,omitempty" db:"user_id"`
+	Email         string     `json:"email" db:"email"`
+	Success       bool       `json:"success" db:"success"`
+	Method        string     `json:"method,omitempty" db:"method"`
+	IPAddress     string     `json:"ip_address" db:"ip_address"`
+	UserAgent     string     `json:"user_agent" db:"user_agent"`
+	FailureReason string     `json:"failure_reason,omitempty" db:"failure_reason"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// LoginMetadata carries request details recorded alongside a login attempt
+type LoginMetadata struct {
+	IPAddress string
+	UserAgent string
+	// Method is how the user is signing in. It is set by the service
+	// handling the attempt.
+	Method string
+}
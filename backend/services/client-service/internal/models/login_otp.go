@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginOTPCode represents a one-time code sent by SMS to sign a user in
+// without their password
+type LoginOTPCode struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	PhoneNumber string     `json:"phone_number" db:"phone_number"`
+	CodeHash    string     `json:"-" db:"code_hash"`
+	Attempts    int        `json:"attempts" db:"attempts"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt      *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsExpired checks if the login code has expired
+func (c *LoginOTPCode) IsExpired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+// IsUsed checks if the login code has already been consumed
+func (c *LoginOTPCode) IsUsed() bool {
+	return c.UsedAt != nil
+}
+
+// LoginOTPRequest represents a request for a login code by SMS
+type LoginOTPRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+}
+
+// LoginOTPVerify represents the data needed to sign in with a login code
+type LoginOTPVerify struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+	Code        string `json:"code" binding:"required,len=6,numeric"`
+	RememberMe  bool   `json:"remember_me"`
+	UseCookies  bool   `json:"use_cookies"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MagicLinkToken represents a single-use token emailed to sign a user in
+// without their password
+type MagicLinkToken struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// MagicLinkRequest represents the data needed to request a sign-in link
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// MagicLinkConsume represents the token from a sign-in link
+type MagicLinkConsume struct {
+	Token string `form:"token" binding:"required"`
+}
+
+// IsExpired checks if the sign-in link has expired
+func (t *MagicLinkToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// IsUsed checks if the sign-in link has already been used
+func (t *MagicLinkToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification event types users can opt in to or out of
+const (
+	NotificationEventLoginAlert       = "login_alert"
+	NotificationEventLargeTransaction = "large_transaction"
+	NotificationEventStatementReady   = "statement_ready"
+	NotificationEventGoalCompleted    = "goal_completed"
+	NotificationEventDisputeUpdated   = "dispute_updated"
+	// NotificationEventExternalTransferFailed is sent when a transfer to
+	// another bank fails and its hold is released
+	NotificationEventExternalTransferFailed = "external_transfer_failed"
+	// NotificationEventLowBalance is sent when an account's balance drops
+	// below the threshold its holder set in the banking service
+	NotificationEventLowBalance = "low_balance"
+)
+
+// NotificationEvents lists every known notification event type in display order
+var NotificationEvents = []string{
+	NotificationEventLoginAlert,
+	NotificationEventLargeTransaction,
+	NotificationEventStatementReady,
+	NotificationEventGoalCompleted,
+	NotificationEventDisputeUpdated,
+	NotificationEventExternalTransferFailed,
+	NotificationEventLowBalance,
+}
+
+// IsNotificationEvent reports whether event is a known notification event type
+func IsNotificationEvent(event string) bool {
+	for _, e := range NotificationEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationChannels says which channels an event is delivered on
+type NotificationChannels struct {
+	Email   bool `json:"email"`
+	SMS     bool `json:"sms"`
+	Webhook bool `json:"webhook"`
+}
+
+// DefaultNotificationChannels returns the channels used for an event the user
+// has not configured. Every event goes by email only.
+func DefaultNotificationChannels(event string) NotificationChannels {
+	return NotificationChannels{Email: true}
+}
+
+// NotificationPreference represents a user's stored channel choices for one
+// event type
+type NotificationPreference struct {
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	EventType string    `json:"event_type" db:"event_type"`
+	NotificationChannels
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationPreferences maps each event type to its channels
+type NotificationPreferences map[string]NotificationChannels
+
+// NotificationPreferencesUpdate represents a request to change notification
+// preferences. Events that are left out keep their current channels.
+type NotificationPreferencesUpdate struct {
+	Preferences map[string]NotificationChannelsUpdate `json:"preferences" binding:"required"`
+}
+
+// NotificationChannelsUpdate represents the channels to change for one event.
+// Channels that are left out keep their current value.
+type NotificationChannelsUpdate struct {
+	Email   *bool `json:"email"`
+	SMS     *bool `json:"sms"`
+	Webhook *bool `json:"webhook"`
+}
+
+// Apply returns channels with the supplied fields of u applied
+func (u NotificationChannelsUpdate) Apply(channels NotificationChannels) NotificationChannels {
+	if u.Email != nil {
+		channels.Email = *u.Email
+	}
+	if u.SMS != nil {
+		channels.SMS = *u.SMS
+	}
+	if u.Webhook != nil {
+		channels.Webhook = *u.Webhook
+	}
+	return channels
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuth providers users can sign in with
+const (
+	OAuthProviderGoogle = "google"
+)
+
+// OAuthState represents a pending OAuth sign-in. The state value sent to
+// the provider is stored hashed and is single-use. UserID is set when an
+// authenticated user is linking the provider to their account.
+type OAuthState struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	StateHash string     `json:"-" db:"state_hash"`
+	Nonce     string     `json:"-" db:"nonce"`
+	Provider  string     `json:"provider" db:"provider"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" db:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsExpired checks if the state has expired
+func (s *OAuthState) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// OAuthIdentity is the verified identity a provider returned for a user
+type OAuthIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OAuthCallback represents the query parameters a provider redirects back with
+type OAuthCallback struct {
+	State string `form:"state" binding:"required"`
+	Code  string `form:"code" binding:"required"`
+}
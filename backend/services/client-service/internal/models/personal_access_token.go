@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PersonalAccessToken represents a named token a user creates to script
+// against their own account. Only a hash of the token is stored; the token
+// itself is shown once, when it is created.
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"-" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsExpired checks if the token has an expiry that has passed
+func (t *PersonalAccessToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// PersonalAccessTokenRequest represents the data needed to create a
+// personal access token. Tokens without an expiry last until revoked.
+type PersonalAccessTokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedPersonalAccessToken is a newly created token along with its
+// secret, which is not shown again
+type CreatedPersonalAccessToken struct {
+	PersonalAccessToken
+	Token string `json:"token"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhoneVerificationCode represents a one-time code sent by SMS to confirm a
+// user's phone number
+type PhoneVerificationCode struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	PhoneNumber string     `json:"phone_number" db:"phone_number"`
+	CodeHash    string     `json:"-" db:"code_hash"`
+	Attempts    int        `json:"attempts" db:"attempts"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt      *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// PhoneVerificationConfirm represents the code a user received by SMS
+type PhoneVerificationConfirm struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// IsExpired checks if the verification code has expired
+func (c *PhoneVerificationCode) IsExpired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+// IsUsed checks if the verification code has already been consumed
+func (c *PhoneVerificationCode) IsUsed() bool {
+	return c.UsedAt != nil
+}
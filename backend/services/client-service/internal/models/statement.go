@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatementPeriodLayout is the YYYY-MM form statement periods are named in
+const StatementPeriodLayout = "2006-01"
+
+// Statement delivery days run from 1 to 28, so every month has the day
+const (
+	MinStatementDeliveryDay = 1
+	MaxStatementDeliveryDay = 28
+)
+
+// Statement delivery statuses. Pending deliveries are waiting for their
+// first attempt or a retry, and sending ones are being attempted; failed
+// ones have used up their attempts.
+const (
+	StatementDeliveryPending = "pending"
+	StatementDeliverySending = "sending"
+	StatementDeliverySent    = "sent"
+	StatementDeliverySkipped = "skipped"
+	StatementDeliveryFailed  = "failed"
+)
+
+// StatementSubscription is a user's opt-in to have each monthly statement
+// emailed to them on their chosen day of the following month
+type StatementSubscription struct {
+	UserID      uuid.UUID `json:"-" db:"user_id"`
+	DeliveryDay int       `json:"delivery_day" db:"delivery_day"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// StatementSubscriptionRequest represents a request to subscribe to
+// statement emails or change the delivery day
+type StatementSubscriptionRequest struct {
+	DeliveryDay int `json:"delivery_day" binding:"required,min=1,max=28"`
+}
+
+// StatementDelivery records the emailing of one user's statement for one
+// period. There is at most one per user and period, so a statement is
+// never sent twice.
+type StatementDelivery struct {
+	ID       uuid.UUID `json:"id" db:"id"`
+	UserID   uuid.UUID `json:"user_id" db:"user_id"`
+	Period   string    `json:"period" db:"period"`
+	Status   string    `json:"status" db:"status"`
+	Attempts int       `json:"attempts" db:"attempts"`
+	// LastError says why the last attempt failed, or why the statement was
+	// skipped
+	LastError string `json:"last_error,omitempty" db:"last_error"`
+	// NextAttemptAt is when a pending delivery is next tried. While one is
+	// sending it is when the attempt's claim runs out, after which a
+	// delivery left sending by a replica that stopped is tried again.
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+	// RequestedBy is the admin who asked for the statement to be sent, or
+	// nil for scheduled deliveries
+	RequestedBy *uuid.UUID `json:"requested_by,omitempty" db:"requested_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Statement is a user's monthly account statement as the banking-service
+// reports it
+type Statement struct {
+	Period         string                 `json:"period"`
+	PeriodStart    time.Time              `json:"period_start"`
+	PeriodEnd      time.Time              `json:"period_end"`
+	Currency       string                 `json:"currency"`
+	OpeningBalance float64                `json:"opening_balance"`
+	ClosingBalance float64                `json:"closing_balance"`
+	MoneyIn        float64                `json:"money_in"`
+	MoneyOut       float64                `json:"money_out"`
+	Transactions   []StatementTransaction `json:"transactions"`
+}
+
+// StatementTransaction is one transaction on a statement
+type StatementTransaction struct {
+	ID           uuid.UUID `json:"id"`
+	Type         string    `json:"type"`
+	Amount       float64   `json:"amount"`
+	BalanceAfter float64   `json:"balance_after"`
+	Description  string    `json:"description"`
+	CreatedAt    time.Time `json:"created_at"`
+}
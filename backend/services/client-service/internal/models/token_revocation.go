@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// RevokedToken identifies a revoked access token by its jti claim. It stays
+// revoked until ExpiresAt, after which the token is rejected as expired.
+type RevokedToken struct {
+	JTI       string    `json:"jti" binding:"required,max=64"`
+	ExpiresAt time.Time `json:"expires_at" binding:"required"`
+}
+
+// ForceLogoutRequest represents the options of an admin force logout. The
+// body is optional.
+type ForceLogoutRequest struct {
+	// MustResetPassword blocks login until the user resets their password
+	MustResetPassword bool `json:"must_reset_password"`
+}
+
+// ForceLogoutResult reports what a force logout ended
+type ForceLogoutResult struct {
+	SessionsTerminated  int
+	AccessTokensRevoked int
+}
+
+// TokenRevocationRequest represents revocations pushed by another service
+type TokenRevocationRequest struct {
+	Tokens []RevokedToken `json:"tokens" binding:"required,min=1,max=1000,dive"`
+}
@@ -8,43 +8,179 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID           uuid.UUID `json:"id" db:"id"`
-	Email        string    `json:"email" db:"email" binding:"required,email"`
-	Name         string    `json:"name" db:"name" binding:"required,min=2,max=100"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	IsBlacklisted bool     `json:"is_blacklisted" db:"is_blacklisted"`
-	IsAdmin      bool      `json:"is_admin" db:"is_admin"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID                     uuid.UUID  `json:"id" db:"id"`
+	Email                  string     `json:"email" db:"email" binding:"required,email"`
+	Name                   string     `json:"name" db:"name" binding:"required,min=2,max=100"`
+	PasswordHash           string     `json:"-" db:"password_hash"`
+	IsBlacklisted          bool       `json:"is_blacklisted" db:"is_blacklisted"`
+	BlacklistReason        string     `json:"-" db:"blacklist_reason"`
+	BlacklistExpiresAt     *time.Time `json:"-" db:"blacklist_expires_at"`
+	IsAdmin                bool       `json:"is_admin" db:"is_admin"`
+	Roles                  []string   `json:"roles" db:"-"`
+	PendingEmail           string     `json:"pending_email,omitempty" db:"pending_email"`
+	PendingEmailToken      string     `json:"-" db:"pending_email_token"`
+	EmailChangeRequestedAt *time.Time `json:"-" db:"email_change_requested_at"`
+	EmailChangeCancelToken string     `json:"-" db:"email_change_cancel_token"`
+	PreviousEmail          string     `json:"-" db:"previous_email"`
+	EmailVerifiedAt        *time.Time `json:"email_verified_at" db:"email_verified_at"`
+	PhoneNumber            string     `json:"phone_number,omitempty" db:"phone_number"`
+	PhoneVerifiedAt        *time.Time `json:"phone_verified_at,omitempty" db:"phone_verified_at"`
+	DateOfBirth            *time.Time `json:"date_of_birth,omitempty" db:"date_of_birth"`
+	AddressLine1           string     `json:"address_line1,omitempty" db:"address_line1"`
+	AddressCity            string     `json:"address_city,omitempty" db:"address_city"`
+	AddressCountry         string     `json:"address_country,omitempty" db:"address_country"`
+	TimeZone               string     `json:"timezone,omitempty" db:"timezone"`
+	OAuthProvider          string     `json:"-" db:"oauth_provider"`
+	OAuthSubject           string     `json:"-" db:"oauth_subject"`
+	TokenVersion           int        `json:"-" db:"token_version"`
+	MustResetPassword      bool       `json:"-" db:"must_reset_password"`
+	MustSetPassword        bool       `json:"-" db:"must_set_password"`
+	KYCStatus              string     `json:"kyc_status" db:"kyc_status"`
+	RegistrationSource     string     `json:"registration_source" db:"registration_source"`
+	LastLoginAt            *time.Time `json:"last_login_at" db:"last_login_at"`
+	DeletedAt              *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	SelfDeleted            bool       `json:"-" db:"self_deleted"`
+	CreatedAt              time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Registration sources record how a user's account was created, so
+// organic signups can be told apart from invited and OAuth ones. Users
+// created through an OAuth provider have the source
+// OAuthRegistrationSource returns for it.
+const (
+	RegistrationSourceSelf         = "self"
+	RegistrationSourceInvite       = "invite"
+	RegistrationSourceOAuthGoogle  = "oauth_google"
+	RegistrationSourceAdminCreated = "admin_created"
+)
+
+// RegistrationSources lists every registration source, in report order
+var RegistrationSources = []string{
+	RegistrationSourceSelf,
+	RegistrationSourceInvite,
+	RegistrationSourceOAuthGoogle,
+	RegistrationSourceAdminCreated,
+}
+
+// IsValidRegistrationSource checks if source is a known registration source
+func IsValidRegistrationSource(source string) bool {
+	for _, s := range RegistrationSources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthRegistrationSource returns the registration source of users created
+// through an OAuth provider
+func OAuthRegistrationSource(provider string) string {
+	return "oauth_" + provider
 }
 
 // UserRegistration represents the data needed to register a new user
 type UserRegistration struct {
-	Email    string `json:"email" binding:"required,email"`
-	Name     string `json:"name" binding:"required,min=2,max=100"`
-	Password string `json:"password" binding:"required,min=8"`
+	Email          string `json:"email" binding:"required,email"`
+	Name           string `json:"name" binding:"required,min=2,max=100"`
+	Password       string `json:"password" binding:"required"`
+	InvitationCode string `json:"invitation_code"`
 }
 
 // UserLogin represents the data needed to login a user
 type UserLogin struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	RememberMe bool   `json:"remember_me"`
+	UseCookies bool   `json:"use_cookies"`
 }
 
-// UserProfile represents the user profile data that can be updated
+// DateLayout is the format of date-only fields such as date_of_birth
+const DateLayout = "2006-01-02"
+
+// UserProfile represents a partial profile update. Nil fields are left
+// unchanged, and an empty string clears an optional field. Values are
+// checked by the user service so every invalid field can be reported.
 type UserProfile struct {
-	Name string `json:"name" binding:"required,min=2,max=100"`
+	Name        *string         `json:"name"`
+	PhoneNumber *string         `json:"phone_number"`
+	DateOfBirth *string         `json:"date_of_birth"`
+	Address     *AddressProfile `json:"address"`
+	// TimeZone is an IANA time zone such as Africa/Johannesburg. It only
+	// changes how times are grouped for display, such as by month in
+	// summaries; times are always stored and returned in UTC.
+	TimeZone *string `json:"timezone"`
+}
+
+// AddressProfile is the address part of a partial profile update
+type AddressProfile struct {
+	Line1   *string `json:"line1"`
+	City    *string `json:"city"`
+	Country *string `json:"country"`
+}
+
+// Address is a user's postal address. Country is an ISO 3166-1 alpha-2 code.
+type Address struct {
+	Line1   string `json:"line1"`
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+// PasswordChange represents the data needed to change a user's password
+type PasswordChange struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// EmailChange represents the data needed to request an email change
+type EmailChange struct {
+	NewEmail        string `json:"new_email" binding:"required,email"`
+	CurrentPassword string `json:"current_password" binding:"required"`
+}
+
+// AccountDeletion represents the data needed for users to delete their own account
+type AccountDeletion struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// PasswordVerification represents another service's request to check a
+// user's password
+type PasswordVerification struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// RoleRequest names a role to grant
+type RoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// EmailChangeToken represents a verification or cancellation token from an email link
+type EmailChangeToken struct {
+	Token string `json:"token" binding:"required"`
 }
 
 // UserResponse represents the user data sent in responses (excludes sensitive info)
 type UserResponse struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	Name         string    `json:"name"`
-	IsBlacklisted bool     `json:"is_blacklisted"`
-	IsAdmin      bool      `json:"is_admin"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                 uuid.UUID  `json:"id"`
+	Email              string     `json:"email"`
+	Name               string     `json:"name"`
+	IsBlacklisted      bool       `json:"is_blacklisted"`
+	IsAdmin            bool       `json:"is_admin"`
+	Roles              []string   `json:"roles"`
+	PendingEmail       string     `json:"pending_email,omitempty"`
+	EmailVerified      bool       `json:"email_verified"`
+	PhoneNumber        string     `json:"phone_number,omitempty"`
+	PhoneVerified      bool       `json:"phone_verified"`
+	DateOfBirth        string     `json:"date_of_birth,omitempty"`
+	Address            *Address   `json:"address,omitempty"`
+	TimeZone           string     `json:"timezone"`
+	OAuthProvider      string     `json:"oauth_provider,omitempty"`
+	HasPassword        bool       `json:"has_password"`
+	KYCStatus          string     `json:"kyc_status"`
+	RegistrationSource string     `json:"registration_source"`
+	LastLoginAt        *time.Time `json:"last_login_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
 }
 
 // RefreshToken represents a refresh token for JWT authentication
@@ -59,14 +195,88 @@ type RefreshToken struct {
 // ToResponse converts a User to UserResponse
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:           u.ID,
-		Email:        u.Email,
-		Name:         u.Name,
-		IsBlacklisted: u.IsBlacklisted,
-		IsAdmin:      u.IsAdmin,
-		CreatedAt:    u.CreatedAt,
-		UpdatedAt:    u.UpdatedAt,
+		ID:                 u.ID,
+		Email:              u.Email,
+		Name:               u.Name,
+		IsBlacklisted:      u.IsBlacklisted,
+		IsAdmin:            u.IsAdmin,
+		Roles:              u.roles(),
+		PendingEmail:       u.PendingEmail,
+		EmailVerified:      u.IsEmailVerified(),
+		PhoneNumber:        u.PhoneNumber,
+		PhoneVerified:      u.IsPhoneVerified(),
+		DateOfBirth:        u.dateOfBirth(),
+		Address:            u.address(),
+		TimeZone:           u.timeZone(),
+		OAuthProvider:      u.OAuthProvider,
+		HasPassword:        u.HasPassword(),
+		KYCStatus:          u.kycStatus(),
+		RegistrationSource: u.RegistrationSource,
+		LastLoginAt:        u.LastLoginAt,
+		CreatedAt:          u.CreatedAt,
+		UpdatedAt:          u.UpdatedAt,
+	}
+}
+
+// dateOfBirth formats the user's date of birth, or returns "" if unset
+func (u *User) dateOfBirth() string {
+	if u.DateOfBirth == nil {
+		return ""
+	}
+	return u.DateOfBirth.Format(DateLayout)
+}
+
+// timeZone returns the user's preferred time zone, UTC when they have not
+// chosen one
+func (u *User) timeZone() string {
+	if u.TimeZone == "" {
+		return "UTC"
+	}
+	return u.TimeZone
+}
+
+// roles returns the user's roles, never nil so responses list none as []
+func (u *User) roles() []string {
+	if u.Roles == nil {
+		return []string{}
 	}
+	return u.Roles
+}
+
+// kycStatus returns the user's KYC status, treating users built without
+// one as never having submitted
+func (u *User) kycStatus() string {
+	if u.KYCStatus == "" {
+		return KYCStatusNone
+	}
+	return u.KYCStatus
+}
+
+// address returns the user's address, or nil if no part of it is set
+func (u *User) address() *Address {
+	if u.AddressLine1 == "" && u.AddressCity == "" && u.AddressCountry == "" {
+		return nil
+	}
+	return &Address{Line1: u.AddressLine1, City: u.AddressCity, Country: u.AddressCountry}
+}
+
+// IsEmailVerified reports whether the user's current email address has been
+// confirmed by following a verification link
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsPhoneVerified reports whether the user's current phone number has been
+// confirmed with an SMS code
+func (u *User) IsPhoneVerified() bool {
+	return u.PhoneNumber != "" && u.PhoneVerifiedAt != nil
+}
+
+// HasPassword reports whether the user can sign in with a password. Users
+// created through an OAuth provider have no password until they set one
+// with a password reset.
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != ""
 }
 
 // IsValid checks if the user is valid for operations
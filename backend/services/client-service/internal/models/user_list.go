@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Sortable columns for user listings
+const (
+	UserSortCreatedAt = "created_at"
+	UserSortEmail     = "email"
+)
+
+// ListUsersOptions controls filtering, sorting and paging of user listings.
+// Nil and empty filters are not applied. Deleted lists soft-deleted users
+// instead of active ones.
+type ListUsersOptions struct {
+	Limit              int
+	Offset             int
+	IsBlacklisted      *bool
+	IsAdmin            *bool
+	CreatedAfter       *time.Time
+	CreatedBefore      *time.Time
+	InactiveSince      *time.Time
+	RegistrationSource string
+	Deleted            bool
+	Search             string
+	SortBy             string
+	SortDesc           bool
+}
+
+// ClientDetail is the admin view of a single user
+type ClientDetail struct {
+	User           *User
+	ActiveSessions int
+}
+
+// UserPage is one page of a user listing along with the total number of
+// users matching the filters
+type UserPage struct {
+	Users  []User
+	Total  int
+	Limit  int
+	Offset int
+}
+
+// HasMore reports whether further pages exist after this one
+func (p *UserPage) HasMore() bool {
+	return p.Offset+len(p.Users) < p.Total
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultLocale is the locale used for users who have not chosen one
+const DefaultLocale = "en"
+
+// DefaultTimeZone is the time zone used for users who have not chosen one
+const DefaultTimeZone = "UTC"
+
+// SupportedLocales lists the locales messages can be rendered in, in display
+// order. A locale is only added here once its message catalog exists.
+var SupportedLocales = []string{"en", "af", "fr", "pt", "zu"}
+
+// SupportedLocale returns the supported locale matching locale, ignoring
+// case, and whether there is one
+func SupportedLocale(locale string) (string, bool) {
+	for _, l := range SupportedLocales {
+		if strings.EqualFold(l, locale) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// UserPreferences is how a user wants messages, statements and summaries
+// presented. Unset preferences are filled in with the defaults.
+type UserPreferences struct {
+	Locale string `json:"locale"`
+	// TimeZone is an IANA time zone such as Africa/Johannesburg. It is the
+	// same setting as the profile's timezone.
+	TimeZone string `json:"timezone"`
+}
+
+// DefaultUserPreferences returns the preferences of a user who has not
+// chosen any
+func DefaultUserPreferences() UserPreferences {
+	return UserPreferences{Locale: DefaultLocale, TimeZone: DefaultTimeZone}
+}
+
+// WithDefaults returns p with unset preferences replaced by the defaults
+func (p UserPreferences) WithDefaults() UserPreferences {
+	if p.Locale == "" {
+		p.Locale = DefaultLocale
+	}
+	if p.TimeZone == "" {
+		p.TimeZone = DefaultTimeZone
+	}
+	return p
+}
+
+// Location returns the time zone to group the user's times in. Time zones
+// are validated when they are saved, so UTC is only used for a zone the
+// server's time zone database no longer knows.
+func (p UserPreferences) Location() *time.Location {
+	location, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// UserPreferencesUpdate represents a request to change preferences. Nil
+// fields are left unchanged, and an empty string resets a preference to its
+// default.
+type UserPreferencesUpdate struct {
+	Locale   *string `json:"locale"`
+	TimeZone *string `json:"timezone"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "github.com/google/uuid"
+
+// MaxUserStatusBatchSize is the most users one status lookup can cover
+const MaxUserStatusBatchSize = 100
+
+// UserStatus is the compact view of a user that other services check
+// before acting for them. Unknown IDs have Exists false and zero values
+// elsewhere; soft-deleted users exist with IsDeleted set.
+type UserStatus struct {
+	UserID        uuid.UUID `json:"user_id"`
+	Exists        bool      `json:"exists"`
+	IsBlacklisted bool      `json:"is_blacklisted"`
+	IsAdmin       bool      `json:"is_admin"`
+	IsDeleted     bool      `json:"is_deleted"`
+	EmailVerified bool      `json:"email_verified"`
+	TokenVersion  int       `json:"token_version"`
+	KYCStatus     string    `json:"kyc_status"`
+	Roles         []string  `json:"roles"`
+	TimeZone      string    `json:"timezone"`
+}
+
+// NotificationRecipient is where and how a user wants one notification
+// event delivered, for services dispatching notifications. Unknown and
+// deleted users have Exists false and no channels. PhoneNumber is only set
+// once it is verified.
+type NotificationRecipient struct {
+	UserID      uuid.UUID            `json:"user_id"`
+	Exists      bool                 `json:"exists"`
+	Email       string               `json:"email"`
+	Name        string               `json:"name"`
+	PhoneNumber string               `json:"phone_number,omitempty"`
+	Channels    NotificationChannels `json:"channels"`
+}
+
+// UserStatusBatchRequest represents a status lookup for several users
+type UserStatusBatchRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids" binding:"required,min=1,max=100"`
+}
+
+// UserLookupRequest represents a lookup of a user by email
+type UserLookupRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// UserDetail is who a user is, for other services showing users to staff.
+// Unknown IDs have Exists false and empty details; soft-deleted users keep
+// theirs with IsDeleted set.
+type UserDetail struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Exists    bool      `json:"exists"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	IsDeleted bool      `json:"is_deleted"`
+}
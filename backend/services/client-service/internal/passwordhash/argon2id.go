@@ -0,0 +1,131 @@
+package passwordhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2idPrefix starts every encoded Argon2id hash
+const argon2idPrefix = "$argon2id$"
+
+// Argon2Params are the tunable Argon2id parameters
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params returns the OWASP-recommended baseline parameters
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Argon2id hashes passwords with Argon2id, encoded in the PHC string format
+type Argon2id struct {
+	params Argon2Params
+}
+
+// NewArgon2id creates an Argon2id hasher
+func NewArgon2id(params Argon2Params) *Argon2id {
+	return &Argon2id{params: params}
+}
+
+// Hash hashes the password with a random salt
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.params.Iterations, a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		a.params.Memory,
+		a.params.Iterations,
+		a.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify checks the password against an encoded Argon2id hash using the
+// parameters stored in the hash
+func (a *Argon2id) Verify(hash, password string) error {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, candidate) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// NeedsRehash reports whether the hash was made with weaker parameters
+func (a *Argon2id) NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory < a.params.Memory ||
+		params.Iterations < a.params.Iterations ||
+		params.Parallelism < a.params.Parallelism
+}
+
+// handles reports whether the hash is an Argon2id hash
+func (a *Argon2id) handles(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+// decodeArgon2id parses $argon2id$v=19$m=...,t=...,p=...$salt$key
+func decodeArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
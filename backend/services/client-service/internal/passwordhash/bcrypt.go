@@ -0,0 +1,51 @@
+package passwordhash
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt hashes passwords with bcrypt at a fixed cost
+type Bcrypt struct {
+	cost int
+}
+
+// NewBcrypt creates a bcrypt hasher. Cost is not bounds-checked here so
+// tests can use bcrypt.MinCost; use New for configured hashers.
+func NewBcrypt(cost int) *Bcrypt {
+	return &Bcrypt{cost: cost}
+}
+
+// Hash hashes the password
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify checks the password against a bcrypt hash
+func (b *Bcrypt) Verify(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	return err
+}
+
+// NeedsRehash reports whether the hash was made with a lower cost
+func (b *Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost < b.cost
+}
+
+// handles reports whether the hash is a bcrypt hash
+func (b *Bcrypt) handles(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
@@ -0,0 +1,113 @@
+// Package passwordhash hashes and verifies user passwords, supporting more
+// than one algorithm so stored hashes can be upgraded transparently.
+package passwordhash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrMismatch is returned when a password does not match a hash
+var ErrMismatch = errors.New("password does not match hash")
+
+// Algorithm names accepted by PASSWORD_HASH_ALGORITHM
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// Bounds for the configurable bcrypt cost
+const (
+	MinBcryptCost = 10
+	MaxBcryptCost = 16
+)
+
+// PasswordHasher hashes new passwords and verifies stored hashes
+type PasswordHasher interface {
+	// Hash returns an encoded hash of the password
+	Hash(password string) (string, error)
+	// Verify returns nil if the password matches the encoded hash, or ErrMismatch
+	Verify(hash, password string) error
+	// NeedsRehash reports whether a hash was produced with a different
+	// algorithm or weaker parameters than the current configuration
+	NeedsRehash(hash string) bool
+}
+
+// algorithm is implemented by each concrete hashing scheme
+type algorithm interface {
+	PasswordHasher
+	// handles reports whether the encoded hash belongs to this algorithm
+	handles(hash string) bool
+}
+
+// Hasher hashes with a primary algorithm and verifies hashes from any
+// supported algorithm, picking the verifier by the hash prefix
+type Hasher struct {
+	primary    algorithm
+	algorithms []algorithm
+}
+
+// New creates a hasher that hashes with primary and can also verify hashes
+// produced by the other supported algorithms
+func New(primary string, bcryptCost int, argon2Params Argon2Params) (*Hasher, error) {
+	if bcryptCost < MinBcryptCost || bcryptCost > MaxBcryptCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d, got %d", MinBcryptCost, MaxBcryptCost, bcryptCost)
+	}
+
+	bcryptHasher := NewBcrypt(bcryptCost)
+	argon2Hasher := NewArgon2id(argon2Params)
+
+	h := &Hasher{algorithms: []algorithm{bcryptHasher, argon2Hasher}}
+	switch primary {
+	case AlgorithmBcrypt, "":
+		h.primary = bcryptHasher
+	case AlgorithmArgon2id:
+		h.primary = argon2Hasher
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", primary)
+	}
+
+	return h, nil
+}
+
+// LoadFromEnv creates a hasher from PASSWORD_HASH_ALGORITHM (bcrypt or
+// argon2id, default bcrypt) and BCRYPT_COST (default 10)
+func LoadFromEnv() (*Hasher, error) {
+	cost := MinBcryptCost
+	if value := os.Getenv("BCRYPT_COST"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("BCRYPT_COST must be an integer: %w", err)
+		}
+		cost = n
+	}
+
+	return New(strings.ToLower(os.Getenv("PASSWORD_HASH_ALGORITHM")), cost, DefaultArgon2Params())
+}
+
+// Hash hashes the password with the primary algorithm
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.primary.Hash(password)
+}
+
+// Verify checks the password using the algorithm that produced the hash
+func (h *Hasher) Verify(hash, password string) error {
+	for _, a := range h.algorithms {
+		if a.handles(hash) {
+			return a.Verify(hash, password)
+		}
+	}
+	return fmt.Errorf("unrecognised password hash format")
+}
+
+// NeedsRehash reports whether the hash should be replaced with one from the
+// primary algorithm and its current parameters
+func (h *Hasher) NeedsRehash(hash string) bool {
+	if !h.primary.handles(hash) {
+		return true
+	}
+	return h.primary.NeedsRehash(hash)
+}
@@ -0,0 +1,105 @@
+package passwordhash
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params keeps Argon2id fast in tests
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHasher_RoundTrip(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmBcrypt, AlgorithmArgon2id} {
+		t.Run(algorithm, func(t *testing.T) {
+			h, err := New(algorithm, MinBcryptCost, testArgon2Params)
+			if err != nil {
+				t.Fatalf("New returned error: %v", err)
+			}
+
+			hash, err := h.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash returned error: %v", err)
+			}
+
+			if err := h.Verify(hash, "correct horse"); err != nil {
+				t.Errorf("Expected password to verify, got %v", err)
+			}
+			if err := h.Verify(hash, "wrong horse"); !errors.Is(err, ErrMismatch) {
+				t.Errorf("Expected ErrMismatch, got %v", err)
+			}
+			if h.NeedsRehash(hash) {
+				t.Error("Expected a fresh hash not to need rehashing")
+			}
+		})
+	}
+}
+
+func TestHasher_VerifiesOtherAlgorithm(t *testing.T) {
+	oldHash, err := NewBcrypt(bcrypt.MinCost).Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash returned error: %v", err)
+	}
+
+	h, err := New(AlgorithmArgon2id, MinBcryptCost, testArgon2Params)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	if err := h.Verify(oldHash, "correct horse"); err != nil {
+		t.Errorf("Expected bcrypt hash to verify under argon2id primary, got %v", err)
+	}
+	if !h.NeedsRehash(oldHash) {
+		t.Error("Expected bcrypt hash to need rehashing under argon2id primary")
+	}
+}
+
+func TestHasher_NeedsRehashOnLowerCost(t *testing.T) {
+	lowCostHash, err := NewBcrypt(MinBcryptCost).Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash returned error: %v", err)
+	}
+
+	h, err := New(AlgorithmBcrypt, MinBcryptCost+1, testArgon2Params)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	if !h.NeedsRehash(lowCostHash) {
+		t.Error("Expected lower-cost hash to need rehashing")
+	}
+}
+
+func TestArgon2id_NeedsRehashOnWeakerParams(t *testing.T) {
+	weak := NewArgon2id(testArgon2Params)
+	hash, err := weak.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash returned error: %v", err)
+	}
+
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+	if !NewArgon2id(stronger).NeedsRehash(hash) {
+		t.Error("Expected hash with fewer iterations to need rehashing")
+	}
+}
+
+func TestNew_RejectsInvalidConfig(t *testing.T) {
+	if _, err := New(AlgorithmBcrypt, MinBcryptCost-1, testArgon2Params); err == nil {
+		t.Error("Expected error for bcrypt cost below bound")
+	}
+	if _, err := New(AlgorithmBcrypt, MaxBcryptCost+1, testArgon2Params); err == nil {
+		t.Error("Expected error for bcrypt cost above bound")
+	}
+	if _, err := New("md5", MinBcryptCost, testArgon2Params); err == nil {
+		t.Error("Expected error for unsupported algorithm")
+	}
+}
+
+func TestHasher_UnrecognisedHash(t *testing.T) {
+	h, _ := New(AlgorithmBcrypt, MinBcryptCost, testArgon2Params)
+	if err := h.Verify("plaintext", "plaintext"); err == nil || errors.Is(err, ErrMismatch) {
+		t.Errorf("Expected a format error for an unrecognised hash, got %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+// Package passwordpolicy validates candidate passwords against configurable
+// strength rules.
+package passwordpolicy
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Rule names reported in violations
+const (
+	RuleMinLength    = "min_length"
+	RuleUppercase    = "uppercase"
+	RuleLowercase    = "lowercase"
+	RuleDigit        = "digit"
+	RuleSymbol       = "symbol"
+	RulePersonalInfo = "personal_info"
+	RuleBreached     = "breached"
+)
+
+// minPersonalTokenLength ignores very short name/email fragments that would
+// otherwise reject far too many passwords
+const minPersonalTokenLength = 3
+
+//go:embed breached_passwords.txt
+var bundledBreachedPasswords string
+
+// Violation describes a single rule a password failed
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ViolationError is returned when a password does not satisfy the policy
+type ViolationError struct {
+	Violations []Violation
+}
+
+// Error implements the error interface
+func (e *ViolationError) Error() string {
+	rules := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		rules = append(rules, v.Rule)
+	}
+	return "password does not meet policy: " + strings.Join(rules, ", ")
+}
+
+// Policy holds the password strength rules
+type Policy struct {
+	MinLength          int
+	RequireUppercase   bool
+	RequireLowercase   bool
+	RequireDigit       bool
+	RequireSymbol      bool
+	RejectPersonalInfo bool
+	RejectBreached     bool
+	breached           map[string]struct{}
+}
+
+// Default returns the default policy using the bundled breached password list
+func Default() *Policy {
+	p := &Policy{
+		MinLength:          8,
+		RequireLowercase:   true,
+		RequireDigit:       true,
+		RejectPersonalInfo: true,
+		RejectBreached:     true,
+	}
+	p.breached, _ = parseList(strings.NewReader(bundledBreachedPasswords))
+	return p
+}
+
+// LoadFromEnv builds a policy from the default, overridden by
+// PASSWORD_MIN_LENGTH, PASSWORD_REQUIRE_{UPPERCASE,LOWERCASE,DIGIT,SYMBOL},
+// PASSWORD_REJECT_PERSONAL_INFO, PASSWORD_REJECT_BREACHED and
+// PASSWORD_BREACHED_LIST_PATH
+func LoadFromEnv() (*Policy, error) {
+	p := Default()
+
+	if value := os.Getenv("PASSWORD_MIN_LENGTH"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 72 {
+			return nil, fmt.Errorf("PASSWORD_MIN_LENGTH must be between 1 and 72")
+		}
+		p.MinLength = n
+	}
+
+	bools := []struct {
+		key    string
+		target *bool
+	}{
+		{"PASSWORD_REQUIRE_UPPERCASE", &p.RequireUppercase},
+		{"PASSWORD_REQUIRE_LOWERCASE", &p.RequireLowercase},
+		{"PASSWORD_REQUIRE_DIGIT", &p.RequireDigit},
+		{"PASSWORD_REQUIRE_SYMBOL", &p.RequireSymbol},
+		{"PASSWORD_REJECT_PERSONAL_INFO", &p.RejectPersonalInfo},
+		{"PASSWORD_REJECT_BREACHED", &p.RejectBreached},
+	}
+	for _, b := range bools {
+		if value := os.Getenv(b.key); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a boolean: %w", b.key, err)
+			}
+			*b.target = parsed
+		}
+	}
+
+	if path := os.Getenv("PASSWORD_BREACHED_LIST_PATH"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open breached password list: %w", err)
+		}
+		defer file.Close()
+
+		breached, err := parseList(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read breached password list: %w", err)
+		}
+		p.breached = breached
+	}
+
+	return p, nil
+}
+
+// Validate checks a password and returns a *ViolationError listing every
+// failed rule, or nil. personalInfo holds values such as the user's email
+// and name that must not appear in the password.
+func (p *Policy) Validate(password string, personalInfo ...string) error {
+	var violations []Violation
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("Password must be at least %d characters long", p.MinLength),
+		})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUppercase && !hasUpper {
+		violations = append(violations, Violation{Rule: RuleUppercase, Message: "Password must contain an uppercase letter"})
+	}
+	if p.RequireLowercase && !hasLower {
+		violations = append(violations, Violation{Rule: RuleLowercase, Message: "Password must contain a lowercase letter"})
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Rule: RuleDigit, Message: "Password must contain a digit"})
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Rule: RuleSymbol, Message: "Password must contain a symbol"})
+	}
+
+	if p.RejectPersonalInfo && containsPersonalInfo(password, personalInfo) {
+		violations = append(violations, Violation{Rule: RulePersonalInfo, Message: "Password must not contain your name or email"})
+	}
+
+	if p.RejectBreached {
+		if _, found := p.breached[strings.ToLower(password)]; found {
+			violations = append(violations, Violation{Rule: RuleBreached, Message: "Password appears in a list of commonly breached passwords"})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+	return nil
+}
+
+// containsPersonalInfo reports whether the password contains the email
+// local part or any part of the name, case-insensitively
+func containsPersonalInfo(password string, personalInfo []string) bool {
+	lowered := strings.ToLower(password)
+
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if at := strings.Index(info, "@"); at != -1 {
+			info = info[:at]
+		}
+
+		tokens := strings.FieldsFunc(info, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, token := range tokens {
+			if len(token) >= minPersonalTokenLength && strings.Contains(lowered, token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseList reads one password per line, skipping blanks and # comments
+func parseList(r io.Reader) (map[string]struct{}, error) {
+	list := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,156 @@
+package passwordpolicy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func rulesOf(err error) []string {
+	var violationErr *ViolationError
+	if !errors.As(err, &violationErr) {
+		return nil
+	}
+	rules := make([]string, 0, len(violationErr.Violations))
+	for _, v := range violationErr.Violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	policy := Default()
+	policy.RequireUppercase = true
+	policy.RequireSymbol = true
+
+	tests := []struct {
+		name     string
+		password string
+		personal []string
+		expected []string
+	}{
+		{
+			name:     "strong password",
+			password: "Tr1cky-Horse-Battery",
+			personal: []string{"andile.mbele@example.com", "Andile Mbele"},
+			expected: nil,
+		},
+		{
+			name:     "too short",
+			password: "Ab1!",
+			expected: []string{RuleMinLength},
+		},
+		{
+			name:     "single character class",
+			password: "aaaaaaaaaa",
+			expected: []string{RuleUppercase, RuleDigit, RuleSymbol},
+		},
+		{
+			name:     "contains name",
+			password: "Mbele-2024-Secure",
+			personal: []string{"andile@example.com", "Andile Mbele"},
+			expected: []string{RulePersonalInfo},
+		},
+		{
+			name:     "contains email local part",
+			password: "Xandile#2024",
+			personal: []string{"andile@example.com"},
+			expected: []string{RulePersonalInfo},
+		},
+		{
+			name:     "breached password",
+			password: "P@ssw0rd",
+			expected: []string{RuleBreached},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, tt.personal...)
+			got := rulesOf(err)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected violations %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// bundledBreachedCount is the number of passwords on the embedded list.
+// Change it only when the list is deliberately replaced.
+const bundledBreachedCount = 7235
+
+func TestDefault_BundledBreachedList(t *testing.T) {
+	p := Default()
+	if len(p.breached) != bundledBreachedCount {
+		t.Errorf("Expected %d bundled breached passwords, got %d", bundledBreachedCount, len(p.breached))
+	}
+	for _, password := range []string{"password", "trustno1", "qwerty123"} {
+		if _, ok := p.breached[password]; !ok {
+			t.Errorf("Expected %q on the bundled breached list", password)
+		}
+	}
+}
+
+func TestPolicy_ShortNameTokensIgnored(t *testing.T) {
+	policy := Default()
+
+	// "Al" is too short to be meaningful and would reject far too much
+	if err := policy.Validate("totally4lright", "al@example.com", "Al Li"); err != nil {
+		t.Errorf("Expected short personal tokens to be ignored, got %v", err)
+	}
+}
+
+func TestPolicy_DisabledRules(t *testing.T) {
+	policy := &Policy{MinLength: 8}
+
+	if err := policy.Validate("password"); err != nil {
+		t.Errorf("Expected no violations with only a length rule, got %v", err)
+	}
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	listPath := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(listPath, []byte("# custom list\nCorrectHorse42\n"), 0o600); err != nil {
+		t.Fatalf("failed to write list: %v", err)
+	}
+
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("PASSWORD_REQUIRE_UPPERCASE", "true")
+	t.Setenv("PASSWORD_BREACHED_LIST_PATH", listPath)
+
+	policy, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv returned error: %v", err)
+	}
+
+	if policy.MinLength != 12 || !policy.RequireUppercase {
+		t.Errorf("Expected env overrides to apply, got %+v", policy)
+	}
+
+	if rules := rulesOf(policy.Validate("correcthorse42")); !reflect.DeepEqual(rules, []string{RuleUppercase, RuleBreached}) {
+		t.Errorf("Expected custom breached list to be used, got %v", rules)
+	}
+}
+
+func TestLoadFromEnv_InvalidValues(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"PASSWORD_MIN_LENGTH", "zero"},
+		{"PASSWORD_MIN_LENGTH", "0"},
+		{"PASSWORD_REQUIRE_DIGIT", "sometimes"},
+		{"PASSWORD_BREACHED_LIST_PATH", "/does/not/exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			if _, err := LoadFromEnv(); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// AuditLogRepositoryImpl handles all database operations related to the admin audit log
+type AuditLogRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *PostgresDB) AuditLogRepository {
+	return &AuditLogRepositoryImpl{db: db}
+}
+
+// insertAuditLogEntry writes an audit log entry using tx, so the entry
+// commits or rolls back together with the action it describes. A nil entry
+// is ignored, and an entry whose ID is already logged is skipped, so
+// entries made from redelivered events are written once.
+func insertAuditLogEntry(tx *sql.Tx, entry *models.AuditLogEntry) error {
+	if entry == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO admin_audit_log (id, admin_id, action, target_user_id, metadata, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+		ON CONFLICT (id) DO NOTHING`
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	metadata := entry.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit log metadata: %w", err)
+	}
+
+	_, err = tx.Exec(
+		query,
+		entry.ID,
+		entry.AdminID,
+		entry.Action,
+		entry.TargetUserID,
+		metadataJSON,
+		entry.RequestID,
+		entry.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create audit log entry: %w", err)
+	}
+
+	return nil
+}
+
+// Create writes an audit log entry for an action that has no other
+// database changes to commit with
+func (r *AuditLogRepositoryImpl) Create(entry *models.AuditLogEntry) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		return insertAuditLogEntry(tx, entry)
+	})
+}
+
+// List retrieves one page of audit log entries matching the filter, newest
+// first, along with the total number of matches
+func (r *AuditLogRepositoryImpl) List(filter models.AuditLogFilter) ([]models.AuditLogEntry, int, error) {
+	where, args := buildAuditLogFilters(filter)
+
+	// Count all matches
+	var total int
+	countQuery := `SELECT COUNT(*) FROM admin_audit_log` + where
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit log entries: %w", err)
+	}
+
+	// Fetch the requested page
+	query := fmt.Sprintf(`
+		SELECT id, admin_id, action, target_user_id, metadata, COALESCE(request_id, ''), created_at
+		FROM admin_audit_log%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+
+	rows, err := r.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditLogEntry
+	for rows.Next() {
+		var entry models.AuditLogEntry
+		var targetUserID uuid.NullUUID
+		var metadataJSON []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.AdminID,
+			&entry.Action,
+			&targetUserID,
+			&metadataJSON,
+			&entry.RequestID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit log row: %w", err)
+		}
+		if targetUserID.Valid {
+			entry.TargetUserID = &targetUserID.UUID
+		}
+		if err := json.Unmarshal(metadataJSON, &entry.Metadata); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode audit log metadata: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating over audit log rows: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// buildAuditLogFilters turns the filter into a WHERE clause and its
+// positional arguments
+func buildAuditLogFilters(filter models.AuditLogFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.AdminID != nil {
+		add("admin_id = $%d", *filter.AdminID)
+	}
+	if filter.TargetUserID != nil {
+		add("target_user_id = $%d", *filter.TargetUserID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "\n\t\tWHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func TestUserRepository_UpdateRoleWritesAuditInTransaction(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+
+	userID := uuid.New()
+	audit := &models.AuditLogEntry{ID: uuid.New(), AdminID: uuid.New(), Action: models.AuditActionGrantAdmin, TargetUserID: &userID}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users")).
+		WithArgs("admin", true, sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users_roles")).
+		WithArgs(userID, "admin").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO admin_audit_log")).
+		WithArgs(audit.ID, audit.AdminID, audit.Action, audit.TargetUserID, []byte("{}"), "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.UpdateRole(userID, "admin", true, audit); err != nil {
+		t.Fatalf("UpdateRole returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_UpdateRoleRevokesAdminWhileAnotherRemains(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+
+	userID, otherID := uuid.New(), uuid.New()
+	audit := &models.AuditLogEntry{ID: uuid.New(), AdminID: otherID, Action: models.AuditActionRevokeAdmin, TargetUserID: &userID}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE is_admin = true AND deleted_at IS NULL AND is_blacklisted = false")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID).AddRow(otherID))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users")).
+		WithArgs("admin", false, sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users_roles")).
+		WithArgs(userID, "admin").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO admin_audit_log")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.UpdateRole(userID, "admin", false, audit); err != nil {
+		t.Fatalf("UpdateRole returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_UpdateRoleKeepsLastAdmin(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+
+	// Any other admin has been demoted concurrently or is blacklisted, so
+	// the lock finds only the user
+	userID := uuid.New()
+	audit := &models.AuditLogEntry{ID: uuid.New(), AdminID: uuid.New(), Action: models.AuditActionRevokeAdmin, TargetUserID: &userID}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE is_admin = true AND deleted_at IS NULL AND is_blacklisted = false")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+	mock.ExpectRollback()
+
+	if err := repo.UpdateRole(userID, "admin", false, audit); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("Expected ErrLastAdmin, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_DeleteUserRollsBackWhenAuditFails(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+
+	userID := uuid.New()
+	audit := &models.AuditLogEntry{ID: uuid.New(), AdminID: uuid.New(), Action: models.AuditActionDelete, TargetUserID: &userID}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SET deleted_at = $1")).
+		WithArgs(sqlmock.AnyArg(), false, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO admin_audit_log")).
+		WillReturnError(errors.New("disk full"))
+	mock.ExpectRollback()
+
+	if err := repo.DeleteUser(userID, false, audit); err == nil {
+		t.Fatal("Expected an error when the audit entry cannot be written")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBuildAuditLogFilters(t *testing.T) {
+	adminID := uuid.New()
+
+	where, args := buildAuditLogFilters(models.AuditLogFilter{
+		AdminID: &adminID,
+		Action:  models.AuditActionDelete,
+	})
+
+	want := "\n\t\tWHERE admin_id = $1 AND action = $2"
+	if where != want {
+		t.Errorf("Expected where clause %q, got %q", want, where)
+	}
+	if len(args) != 2 || args[0] != adminID || args[1] != models.AuditActionDelete {
+		t.Errorf("Unexpected args: %#v", args)
+	}
+
+	if where, args := buildAuditLogFilters(models.AuditLogFilter{}); where != "" || len(args) != 0 {
+		t.Errorf("Expected no filters, got %q %v", where, args)
+	}
+}
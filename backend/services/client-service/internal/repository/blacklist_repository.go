@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// BlacklistRepositoryImpl handles all database operations related to blacklist history
+type BlacklistRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewBlacklistRepository creates a new blacklist repository
+func NewBlacklistRepository(db *PostgresDB) BlacklistRepository {
+	return &BlacklistRepositoryImpl{db: db}
+}
+
+// insertBlacklistEntry records a blacklist or unblacklist action using tx,
+// so the history commits or rolls back together with the status change. A
+// nil entry is ignored.
+func insertBlacklistEntry(tx *sql.Tx, entry *models.BlacklistEntry) error {
+	if entry == nil {
+		return nil
+	}
+
+	query := `
+		INSERT INTO blacklist_entries (id, user_id, action, reason, expires_at, admin_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	_, err := tx.Exec(
+		query,
+		entry.ID,
+		entry.UserID,
+		entry.Action,
+		entry.Reason,
+		entry.ExpiresAt,
+		entry.AdminID,
+		entry.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create blacklist entry: %w", err)
+	}
+
+	return nil
+}
+
+// Create records a blacklist or unblacklist action that has no status
+// change to commit with
+func (r *BlacklistRepositoryImpl) Create(entry *models.BlacklistEntry) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		return insertBlacklistEntry(tx, entry)
+	})
+}
+
+// GetByUserID retrieves a user's blacklist history, newest first
+func (r *BlacklistRepositoryImpl) GetByUserID(userID uuid.UUID) ([]models.BlacklistEntry, error) {
+	query := `
+		SELECT id, user_id, action, reason, expires_at, admin_id, created_at
+		FROM blacklist_entries
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blacklist entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.BlacklistEntry
+	for rows.Next() {
+		var entry models.BlacklistEntry
+		var adminID uuid.NullUUID
+		err := rows.Scan(
+			&entry.ID,
+			&entry.UserID,
+			&entry.Action,
+			&entry.Reason,
+			&entry.ExpiresAt,
+			&adminID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan blacklist entry row: %w", err)
+		}
+		if adminID.Valid {
+			entry.AdminID = &adminID.UUID
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over blacklist entry rows: %w", err)
+	}
+
+	return entries, nil
+}
@@ -4,7 +4,10 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
+
+	sharedconfig "microbank/pkg/config"
+	"microbank/pkg/events"
+	"microbank/pkg/pgtime"
 
 	_ "github.com/lib/pq"
 )
@@ -15,21 +18,9 @@ type PostgresDB struct {
 }
 
 // NewPostgresDB creates a new PostgreSQL database connection
-func NewPostgresDB() (*PostgresDB, error) {
-	// Get database connection parameters from environment
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "postgres")
-	password := getEnv("DB_PASSWORD", "password")
-	dbname := getEnv("DB_NAME", "client_service")
-	sslmode := getEnv("DB_SSLMODE", "disable")
-
-	// Build connection string
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		host, port, user, password, dbname, sslmode)
-
+func NewPostgresDB(config sharedconfig.Database) (*PostgresDB, error) {
 	// Open database connection
-	db, err := sql.Open("postgres", connStr)
+	db, err := sql.Open("postgres", config.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
@@ -46,15 +37,36 @@ func NewPostgresDB() (*PostgresDB, error) {
 	log.Println("Successfully connected to PostgreSQL database")
 
 	// Initialize database schema
-	if err := initSchema(db); err != nil {
+	if err := initSchema(db, config.LegacyTimeZone); err != nil {
 		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
 	}
 
 	return &PostgresDB{db}, nil
 }
 
-// initSchema creates the necessary database tables if they don't exist
-func initSchema(db *sql.DB) error {
+// withTx runs fn inside a transaction, committing when it returns nil and
+// rolling back otherwise
+func (db *PostgresDB) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// initSchema creates the necessary database tables if they don't exist,
+// and migrates timestamp columns written in legacyZone to timestamptz
+func initSchema(db *sql.DB, legacyZone string) error {
 	// Create users table
 	createUsersTable := `
 	CREATE TABLE IF NOT EXISTS users (
@@ -64,18 +76,370 @@ func initSchema(db *sql.DB) error {
 		password_hash VARCHAR(255) NOT NULL,
 		is_blacklisted BOOLEAN DEFAULT FALSE,
 		is_admin BOOLEAN DEFAULT FALSE,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
 	);`
 
+	// Add email change columns to users table
+	alterUsersEmailChange := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255);
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email_token VARCHAR(255);
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS email_change_requested_at TIMESTAMPTZ;
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS email_change_cancel_token VARCHAR(255);
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS previous_email VARCHAR(255);
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;`
+
+	// Add activity tracking columns to users table
+	alterUsersActivity := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMPTZ;`
+
+	// Add blacklist context columns to users table
+	alterUsersBlacklist := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS blacklist_reason TEXT;
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS blacklist_expires_at TIMESTAMPTZ;`
+
+	// Add extended profile columns to users table
+	alterUsersProfile := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_number VARCHAR(16);
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS date_of_birth DATE;
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS address_line1 VARCHAR(255);
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS address_city VARCHAR(100);
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS address_country CHAR(2);
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMPTZ;`
+
+	// Add the preferred time zone to users table. It is an IANA name, and
+	// unset means UTC.
+	alterUsersTimeZone := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64);`
+
+	// Add the preferred locale to users table. Unset means the default
+	// locale.
+	alterUsersLocale := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(16);`
+
+	// Add soft deletion to users table
+	alterUsersSoftDelete := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS self_deleted BOOLEAN NOT NULL DEFAULT FALSE;`
+
+	// Add token versioning so role and status changes invalidate issued tokens
+	alterUsersTokenVersion := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;`
+
+	// Add the flag set by an admin force logout that blocks login until the
+	// user resets their password
+	alterUsersMustResetPassword := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS must_reset_password BOOLEAN NOT NULL DEFAULT FALSE;`
+
+	// Add the flag of users created by staff, who have no password and
+	// cannot log in until they set one from their setup link
+	alterUsersMustSetPassword := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS must_set_password BOOLEAN NOT NULL DEFAULT FALSE;`
+
+	// Add OAuth sign-in columns to users table. Users created through an
+	// OAuth provider have no password hash.
+	alterUsersOAuth := `
+	ALTER TABLE users ALTER COLUMN password_hash DROP NOT NULL;
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS oauth_provider VARCHAR(20);
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS oauth_subject VARCHAR(255);`
+
+	// Add the identity verification (KYC) status, one of the
+	// models.KYCStatus values
+	alterUsersKYC := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS kyc_status VARCHAR(20) NOT NULL DEFAULT 'none';`
+
+	// Add how each user was created, one of the models.RegistrationSource
+	// values. Users who registered before sources were recorded signed up
+	// themselves, so the default backfills them as 'self'.
+	alterUsersRegistrationSource := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS registration_source VARCHAR(32) NOT NULL DEFAULT 'self';`
+
 	// Create refresh_tokens table
 	createRefreshTokensTable := `
 	CREATE TABLE IF NOT EXISTS refresh_tokens (
 		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
 		token_hash VARCHAR(255) NOT NULL,
-		expires_at TIMESTAMP NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		expires_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Refresh tokens were once stored as issued; store only their SHA-256
+	// hashes. Hashes are 64 hex characters and issued tokens never are, so
+	// rows are only hashed once.
+	hashRefreshTokens := `
+	UPDATE refresh_tokens SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex')
+	WHERE length(token_hash) <> 64;`
+
+	// Create password_reset_tokens table
+	createPasswordResetTokensTable := `
+	CREATE TABLE IF NOT EXISTS password_reset_tokens (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		token_hash VARCHAR(255) UNIQUE NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create magic_link_tokens table
+	createMagicLinkTokensTable := `
+	CREATE TABLE IF NOT EXISTS magic_link_tokens (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		token_hash VARCHAR(255) UNIQUE NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create phone_verification_codes table
+	createPhoneVerificationCodesTable := `
+	CREATE TABLE IF NOT EXISTS phone_verification_codes (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		phone_number VARCHAR(16) NOT NULL,
+		code_hash VARCHAR(255) NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create login_otp_codes table. Codes are looked up by the phone number
+	// they were sent to.
+	createLoginOTPCodesTable := `
+	CREATE TABLE IF NOT EXISTS login_otp_codes (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		phone_number VARCHAR(16) NOT NULL,
+		code_hash VARCHAR(255) NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create login_events table
+	createLoginEventsTable := `
+	CREATE TABLE IF NOT EXISTS login_events (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		email VARCHAR(255) NOT NULL,
+		success BOOLEAN NOT NULL,
+		ip_address VARCHAR(45),
+		user_agent TEXT,
+		failure_reason VARCHAR(50),
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Add the sign-in method to login_events table. Events recorded before
+	// it was added have none.
+	alterLoginEventsMethod := `
+	ALTER TABLE login_events ADD COLUMN IF NOT EXISTS method VARCHAR(20);`
+
+	// Create blacklist_entries table
+	createBlacklistEntriesTable := `
+	CREATE TABLE IF NOT EXISTS blacklist_entries (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		action VARCHAR(20) NOT NULL CHECK (action IN ('blacklist', 'unblacklist')),
+		reason TEXT NOT NULL,
+		expires_at TIMESTAMPTZ,
+		admin_id UUID REFERENCES users(id) ON DELETE SET NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create admin_audit_log table. Admin and target IDs are deliberately not
+	// foreign keys so entries survive the deletion of either user.
+	createAdminAuditLogTable := `
+	CREATE TABLE IF NOT EXISTS admin_audit_log (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		admin_id UUID NOT NULL,
+		action VARCHAR(50) NOT NULL,
+		target_user_id UUID,
+		metadata JSONB NOT NULL DEFAULT '{}',
+		request_id VARCHAR(100),
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create users_roles table. Users who were admins before roles were
+	// added get the admin role; is_admin is kept in step with it.
+	createUsersRolesTable := `
+	CREATE TABLE IF NOT EXISTS users_roles (
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		role VARCHAR(20) NOT NULL CHECK (role IN ('admin', 'support', 'auditor', 'teller')),
+		granted_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, role)
+	);
+	ALTER TABLE users_roles DROP CONSTRAINT IF EXISTS users_roles_role_check;
+	ALTER TABLE users_roles ADD CONSTRAINT users_roles_role_check CHECK (role IN ('admin', 'support', 'auditor', 'teller'));
+	INSERT INTO users_roles (user_id, role)
+	SELECT id, 'admin' FROM users WHERE is_admin = true
+	ON CONFLICT DO NOTHING;`
+
+	// Create password_history table
+	createPasswordHistoryTable := `
+	CREATE TABLE IF NOT EXISTS password_history (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		password_hash VARCHAR(255) NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create oauth_states table. Each state is deleted when its callback
+	// arrives, so it can only be used once.
+	createOAuthStatesTable := `
+	CREATE TABLE IF NOT EXISTS oauth_states (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		state_hash VARCHAR(255) UNIQUE NOT NULL,
+		nonce VARCHAR(255) NOT NULL,
+		provider VARCHAR(20) NOT NULL,
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		expires_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create invitations table
+	createInvitationsTable := `
+	CREATE TABLE IF NOT EXISTS invitations (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		code VARCHAR(20) UNIQUE NOT NULL,
+		email VARCHAR(255),
+		created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_by UUID REFERENCES users(id) ON DELETE SET NULL,
+		used_at TIMESTAMPTZ,
+		revoked_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create known_devices table
+	createKnownDevicesTable := `
+	CREATE TABLE IF NOT EXISTS known_devices (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		fingerprint VARCHAR(64) NOT NULL,
+		user_agent TEXT,
+		ip_address VARCHAR(45),
+		report_token_hash VARCHAR(255),
+		report_token_expires_at TIMESTAMPTZ,
+		first_seen_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		last_seen_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, fingerprint)
+	);`
+
+	// Create notification_preferences table. Events without a row use the
+	// defaults from models.DefaultNotificationChannels.
+	createNotificationPreferencesTable := `
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+		event_type VARCHAR(50) NOT NULL,
+		email BOOLEAN NOT NULL,
+		sms BOOLEAN NOT NULL,
+		webhook BOOLEAN NOT NULL,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, event_type)
+	);`
+
+	// Create personal_access_tokens table. Only a hash of each token is
+	// stored.
+	createPersonalAccessTokensTable := `
+	CREATE TABLE IF NOT EXISTS personal_access_tokens (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name VARCHAR(100) NOT NULL,
+		token_hash VARCHAR(255) UNIQUE NOT NULL,
+		scopes TEXT[] NOT NULL,
+		expires_at TIMESTAMPTZ,
+		last_used_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create kyc_submissions table. Every submission is kept, so a
+	// rejected one stays on record after the user resubmits.
+	createKYCSubmissionsTable := `
+	CREATE TABLE IF NOT EXISTS kyc_submissions (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		document_type VARCHAR(20) NOT NULL,
+		document_number VARCHAR(50) NOT NULL,
+		country CHAR(2) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		reviewer_id UUID REFERENCES users(id) ON DELETE SET NULL,
+		review_notes TEXT,
+		submitted_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		reviewed_at TIMESTAMPTZ
+	);`
+
+	// Create kyc_documents table. Documents are not tied to their user, and
+	// outlive their submission when the user is purged, so the blobs they
+	// point to are still found and deleted.
+	createKYCDocumentsTable := `
+	CREATE TABLE IF NOT EXISTS kyc_documents (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		submission_id UUID REFERENCES kyc_submissions(id) ON DELETE SET NULL,
+		user_id UUID NOT NULL,
+		file_name VARCHAR(255) NOT NULL,
+		content_type VARCHAR(100) NOT NULL,
+		size_bytes BIGINT NOT NULL,
+		storage_key VARCHAR(255) UNIQUE NOT NULL,
+		uploaded_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create download_artifacts and download_accesses tables. Artifacts are
+	// not tied to their user, so the files they point to are still found and
+	// deleted when the user is purged.
+	createDownloadArtifactsTable := `
+	CREATE TABLE IF NOT EXISTS download_artifacts (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL,
+		kind VARCHAR(50) NOT NULL,
+		file_name VARCHAR(255) NOT NULL,
+		content_type VARCHAR(100) NOT NULL,
+		size_bytes BIGINT NOT NULL,
+		storage_key VARCHAR(255) UNIQUE NOT NULL,
+		max_downloads INTEGER NOT NULL,
+		download_count INTEGER NOT NULL DEFAULT 0,
+		expires_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	createDownloadAccessesTable := `
+	CREATE TABLE IF NOT EXISTS download_accesses (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		artifact_id UUID NOT NULL REFERENCES download_artifacts(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL,
+		ip_address VARCHAR(45),
+		user_agent TEXT,
+		accessed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create statement_subscriptions and statement_deliveries tables. Each
+	// user has at most one delivery per period, so a statement is never
+	// emailed twice.
+	createStatementSubscriptionsTable := `
+	CREATE TABLE IF NOT EXISTS statement_subscriptions (
+		user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		delivery_day INTEGER NOT NULL CHECK (delivery_day BETWEEN 1 AND 28),
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	createStatementDeliveriesTable := `
+	CREATE TABLE IF NOT EXISTS statement_deliveries (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		period CHAR(7) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		next_attempt_at TIMESTAMPTZ,
+		sent_at TIMESTAMPTZ,
+		requested_by UUID,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, period)
 	);`
 
 	// Create indexes for better performance
@@ -83,10 +447,41 @@ func initSchema(db *sql.DB) error {
 	CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
 	CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
 	CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
-	CREATE INDEX IF NOT EXISTS idx_users_blacklisted ON users(is_blacklisted);`
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens(token_hash);
+	CREATE INDEX IF NOT EXISTS idx_users_blacklisted ON users(is_blacklisted);
+	CREATE INDEX IF NOT EXISTS idx_users_roles_role ON users_roles(role);
+	CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_magic_link_tokens_user_id ON magic_link_tokens(user_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_phone_verification_codes_user_id ON phone_verification_codes(user_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_login_otp_codes_phone_number ON login_otp_codes(phone_number, created_at DESC);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_users_pending_email ON users(pending_email) WHERE pending_email IS NOT NULL;
+	CREATE INDEX IF NOT EXISTS idx_users_pending_email_token ON users(pending_email_token) WHERE pending_email_token IS NOT NULL;
+	CREATE INDEX IF NOT EXISTS idx_users_email_change_cancel_token ON users(email_change_cancel_token) WHERE email_change_cancel_token IS NOT NULL;
+	CREATE INDEX IF NOT EXISTS idx_users_last_login_at ON users(last_login_at);
+	CREATE INDEX IF NOT EXISTS idx_login_events_user_id ON login_events(user_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_login_events_created_at ON login_events(created_at);
+	CREATE INDEX IF NOT EXISTS idx_password_history_user_id ON password_history(user_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_blacklist_entries_user_id ON blacklist_entries(user_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_users_blacklist_expires_at ON users(blacklist_expires_at) WHERE blacklist_expires_at IS NOT NULL;
+	CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at) WHERE deleted_at IS NOT NULL;
+	CREATE INDEX IF NOT EXISTS idx_users_registration_source ON users(registration_source, created_at);
+	CREATE INDEX IF NOT EXISTS idx_known_devices_report_token_hash ON known_devices(report_token_hash) WHERE report_token_hash IS NOT NULL;
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oauth_subject ON users(oauth_provider, oauth_subject) WHERE oauth_subject IS NOT NULL;
+	CREATE INDEX IF NOT EXISTS idx_oauth_states_expires_at ON oauth_states(expires_at);
+	CREATE INDEX IF NOT EXISTS idx_invitations_created_at ON invitations(created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens(user_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_kyc_submissions_user_id ON kyc_submissions(user_id, submitted_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_kyc_submissions_status ON kyc_submissions(status, submitted_at);
+	CREATE INDEX IF NOT EXISTS idx_kyc_documents_submission_id ON kyc_documents(submission_id, uploaded_at);
+	CREATE INDEX IF NOT EXISTS idx_download_artifacts_expires_at ON download_artifacts(expires_at);
+	CREATE INDEX IF NOT EXISTS idx_download_accesses_artifact_id ON download_accesses(artifact_id, accessed_at);
+	CREATE INDEX IF NOT EXISTS idx_statement_deliveries_next_attempt_at ON statement_deliveries(next_attempt_at) WHERE status IN ('pending', 'sending');
+	CREATE INDEX IF NOT EXISTS idx_admin_audit_log_created_at ON admin_audit_log(created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_admin_audit_log_admin_id ON admin_audit_log(admin_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_admin_audit_log_target_user_id ON admin_audit_log(target_user_id, created_at DESC);`
 
 	// Execute schema creation
-	queries := []string{createUsersTable, createRefreshTokensTable, createIndexes}
+	queries := []string{createUsersTable, alterUsersEmailChange, alterUsersActivity, alterUsersBlacklist, alterUsersTokenVersion, alterUsersMustResetPassword, alterUsersMustSetPassword, alterUsersSoftDelete, alterUsersProfile, alterUsersTimeZone, alterUsersLocale, alterUsersOAuth, alterUsersKYC, alterUsersRegistrationSource, createUsersRolesTable, createRefreshTokensTable, hashRefreshTokens, createPasswordResetTokensTable, createMagicLinkTokensTable, createPhoneVerificationCodesTable, createLoginOTPCodesTable, createLoginEventsTable, alterLoginEventsMethod, createPasswordHistoryTable, createBlacklistEntriesTable, createAdminAuditLogTable, createNotificationPreferencesTable, createKnownDevicesTable, createInvitationsTable, createOAuthStatesTable, createPersonalAccessTokensTable, createKYCSubmissionsTable, createKYCDocumentsTable, createDownloadArtifactsTable, createDownloadAccessesTable, createStatementSubscriptionsTable, createStatementDeliveriesTable, events.OutboxSchema, createIndexes}
 	
 	for _, query := range queries {
 		if _, err := db.Exec(query); err != nil {
@@ -94,14 +489,16 @@ func initSchema(db *sql.DB) error {
 		}
 	}
 
+	// Times were once stored without a zone, as wall-clock times in
+	// legacyZone; store them as instants
+	converted, err := pgtime.ConvertColumns(db, legacyZone, "public")
+	if err != nil {
+		return err
+	}
+	if len(converted) > 0 {
+		log.Printf("Converted timestamp columns written in %s to timestamptz: %v", legacyZone, converted)
+	}
+
 	log.Println("Database schema initialized successfully")
 	return nil
 }
-
-// getEnv gets an environment variable with a fallback default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
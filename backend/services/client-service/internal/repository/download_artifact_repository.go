@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// downloadArtifactColumns is the column list scanned by scanDownloadArtifact
+const downloadArtifactColumns = `id, user_id, kind, file_name, content_type, size_bytes, storage_key, max_downloads, download_count, expires_at, created_at`
+
+// DownloadArtifactRepositoryImpl handles all database operations related to
+// generated files offered for download
+type DownloadArtifactRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewDownloadArtifactRepository creates a new download artifact repository
+func NewDownloadArtifactRepository(db *PostgresDB) DownloadArtifactRepository {
+	return &DownloadArtifactRepositoryImpl{db: db}
+}
+
+// scanDownloadArtifact scans a single artifact selected with
+// downloadArtifactColumns
+func scanDownloadArtifact(row rowScanner) (*models.DownloadArtifact, error) {
+	artifact := &models.DownloadArtifact{}
+	err := row.Scan(
+		&artifact.ID,
+		&artifact.UserID,
+		&artifact.Kind,
+		&artifact.FileName,
+		&artifact.ContentType,
+		&artifact.SizeBytes,
+		&artifact.StorageKey,
+		&artifact.MaxDownloads,
+		&artifact.DownloadCount,
+		&artifact.ExpiresAt,
+		&artifact.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return artifact, nil
+}
+
+// Create records a stored artifact
+func (r *DownloadArtifactRepositoryImpl) Create(artifact *models.DownloadArtifact) error {
+	query := `
+		INSERT INTO download_artifacts (id, user_id, kind, file_name, content_type, size_bytes, storage_key, max_downloads, download_count, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	if artifact.CreatedAt.IsZero() {
+		artifact.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(
+		query,
+		artifact.ID,
+		artifact.UserID,
+		artifact.Kind,
+		artifact.FileName,
+		artifact.ContentType,
+		artifact.SizeBytes,
+		artifact.StorageKey,
+		artifact.MaxDownloads,
+		artifact.DownloadCount,
+		artifact.ExpiresAt,
+		artifact.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create download artifact: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an artifact by ID
+func (r *DownloadArtifactRepositoryImpl) GetByID(id uuid.UUID) (*models.DownloadArtifact, error) {
+	query := `SELECT ` + downloadArtifactColumns + ` FROM download_artifacts WHERE id = $1`
+
+	artifact, err := scanDownloadArtifact(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("download artifact not found")
+		}
+		return nil, fmt.Errorf("failed to get download artifact: %w", err)
+	}
+
+	return artifact, nil
+}
+
+// ClaimDownload counts one download of an artifact and records the access,
+// reporting false without recording anything when the artifact has expired
+// by now or has no downloads left. The count is checked and raised in one
+// statement, so concurrent requests cannot download it more often than
+// allowed.
+func (r *DownloadArtifactRepositoryImpl) ClaimDownload(id uuid.UUID, access *models.DownloadAccess, now time.Time) (bool, error) {
+	claimQuery := `
+		UPDATE download_artifacts
+		SET download_count = download_count + 1
+		WHERE id = $1 AND download_count < max_downloads AND expires_at > $2`
+
+	insertQuery := `
+		INSERT INTO download_accesses (id, artifact_id, user_id, ip_address, user_agent, accessed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	if access.AccessedAt.IsZero() {
+		access.AccessedAt = now
+	}
+
+	claimed := false
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(claimQuery, id, now)
+		if err != nil {
+			return fmt.Errorf("failed to claim download: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(insertQuery, access.ID, id, access.UserID, access.IPAddress, access.UserAgent, access.AccessedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record download: %w", err)
+		}
+		claimed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return claimed, nil
+}
+
+// ListExpired retrieves up to limit artifacts that expired before the
+// cutoff, the longest expired first
+func (r *DownloadArtifactRepositoryImpl) ListExpired(before time.Time, limit int) ([]models.DownloadArtifact, error) {
+	query := `
+		SELECT ` + downloadArtifactColumns + `
+		FROM download_artifacts
+		WHERE expires_at < $1
+		ORDER BY expires_at
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query download artifacts: %w", err)
+	}
+	defer rows.Close()
+
+	var artifacts []models.DownloadArtifact
+	for rows.Next() {
+		artifact, err := scanDownloadArtifact(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan download artifact row: %w", err)
+		}
+		artifacts = append(artifacts, *artifact)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over download artifact rows: %w", err)
+	}
+
+	return artifacts, nil
+}
+
+// Delete removes an artifact's metadata and its access records. Its file
+// must be deleted from the download store first.
+func (r *DownloadArtifactRepositoryImpl) Delete(id uuid.UUID) error {
+	if _, err := r.db.Exec(`DELETE FROM download_artifacts WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete download artifact: %w", err)
+	}
+	return nil
+}
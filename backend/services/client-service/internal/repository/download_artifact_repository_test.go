@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func TestDownloadArtifactRepository_ClaimDownload(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		want         bool
+	}{
+		{name: "downloads left", rowsAffected: 1, want: true},
+		{name: "expired or used up", rowsAffected: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewDownloadArtifactRepository(db)
+			id, now := uuid.New(), time.Now()
+			access := &models.DownloadAccess{ID: uuid.New(), UserID: uuid.New(), IPAddress: "203.0.113.1", UserAgent: "curl"}
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE download_artifacts")).
+				WithArgs(id, now).
+				WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+			if tt.want {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO download_accesses")).
+					WithArgs(access.ID, id, access.UserID, access.IPAddress, access.UserAgent, now).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			mock.ExpectCommit()
+
+			claimed, err := repo.ClaimDownload(id, access, now)
+			if err != nil {
+				t.Fatalf("ClaimDownload returned error: %v", err)
+			}
+			if claimed != tt.want {
+				t.Errorf("Expected claimed %v, got %v", tt.want, claimed)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"microbank/client-service/internal/models"
 )
@@ -8,22 +11,208 @@ import (
 // UserRepository defines the interface for user data operations
 type UserRepository interface {
 	CreateUser(user *models.User) error
+	CreateUserByAdmin(user *models.User, audit *models.AuditLogEntry) error
+	CreateInvitedUser(user *models.User, invitationID uuid.UUID) error
 	GetUserByID(id uuid.UUID) (*models.User, error)
 	GetUserByEmail(email string) (*models.User, error)
-	UpdateUser(user *models.User) error
-	UpdateBlacklistStatus(userID uuid.UUID, isBlacklisted bool) error
-	GetAllUsers() ([]models.User, error)
-	DeleteUser(id uuid.UUID) error
+	GetUserByOAuthSubject(provider, subject string) (*models.User, error)
+	GetUserByVerifiedPhone(phoneNumber string) (*models.User, error)
+	LinkOAuthIdentity(userID uuid.UUID, provider, subject string, emailVerified bool) error
+	GetDeletedUserByID(id uuid.UUID) (*models.User, error)
+	GetDeletedUserByEmail(email string) (*models.User, error)
+	UpdateUser(userID uuid.UUID, profile models.UserProfile) error
+	MarkPhoneVerified(userID uuid.UUID, phoneNumber string) error
+	IncrementTokenVersion(userID uuid.UUID) error
+	RequirePasswordReset(userID uuid.UUID) error
+	UpdatePassword(userID uuid.UUID, passwordHash string) error
+	UpdateBlacklistStatus(userID uuid.UUID, isBlacklisted bool, reason string, expiresAt *time.Time, entry *models.BlacklistEntry, audit *models.AuditLogEntry) error
+	UpdateBlacklistStatusBatch(userIDs []uuid.UUID, isBlacklisted bool, reason string, expiresAt *time.Time, history map[uuid.UUID]*models.BlacklistEntry, audits map[uuid.UUID]*models.AuditLogEntry) (map[uuid.UUID]string, error)
+	LiftExpiredBlacklists(now time.Time) ([]uuid.UUID, error)
+	UpdateRole(userID uuid.UUID, role string, grant bool, audit *models.AuditLogEntry) error
+	CountUsers(today, thisWeek, thisMonth time.Time) (*models.UserCounts, error)
+	CountSignupsBySource(since time.Time) ([]models.SignupCount, error)
+	GetTokenVersion(userID uuid.UUID) (int, error)
+	GetUserStatuses(userIDs []uuid.UUID) (map[uuid.UUID]models.UserStatus, error)
+	GetUserDetails(userIDs []uuid.UUID) (map[uuid.UUID]models.UserDetail, error)
+	GetAllUsers(opts models.ListUsersOptions) ([]models.User, int, error)
+	CountMatchingUsers(opts models.ListUsersOptions) (int, error)
+	GetUsersAfter(opts models.ListUsersOptions, after *models.User, limit int) ([]models.User, error)
+	UpdateLastLogin(userID uuid.UUID, at time.Time) error
+	DeleteUser(id uuid.UUID, selfDeleted bool, audit *models.AuditLogEntry) error
+	RestoreUser(id uuid.UUID, deletedAfter time.Time, audit *models.AuditLogEntry) error
+	PurgeDeletedUsers(before time.Time) ([]uuid.UUID, error)
 	UserExists(email string) (bool, error)
+	EmailInUse(email string, excludeUserID uuid.UUID) (bool, error)
+	SetPendingEmail(userID uuid.UUID, pendingEmail, tokenHash, cancelTokenHash string) error
+	GetUserByPendingEmailToken(tokenHash string) (*models.User, error)
+	GetUserByEmailChangeCancelToken(tokenHash string) (*models.User, error)
+	ConfirmPendingEmail(userID uuid.UUID) error
+	CancelEmailChange(userID uuid.UUID) error
 }
 
 // RefreshTokenRepository defines the interface for refresh token operations
 type RefreshTokenRepository interface {
 	Create(refreshToken *models.RefreshToken) error
+	CreateWithLimit(refreshToken *models.RefreshToken, maxPerUser int) (int64, error)
 	GetByToken(tokenHash string) (*models.RefreshToken, error)
 	GetByUserID(userID uuid.UUID) ([]models.RefreshToken, error)
+	CountActiveByUserID(userID uuid.UUID) (int, error)
+	CountActive() (int, error)
 	Delete(id uuid.UUID) error
 	DeleteByUserID(userID uuid.UUID) error
-	DeleteExpired() error
-	CleanupExpiredTokens() error
+	DeleteByUserIDs(userIDs []uuid.UUID) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+	CleanupExpiredTokens(ctx context.Context) (int64, error)
+}
+
+// PasswordResetTokenRepository defines the interface for password reset token operations
+type PasswordResetTokenRepository interface {
+	Create(token *models.PasswordResetToken) error
+	GetByTokenHash(tokenHash string) (*models.PasswordResetToken, error)
+	MarkUsed(id uuid.UUID) error
+	CountRecentByUserID(userID uuid.UUID, since time.Time) (int, error)
+	DeleteByUserID(userID uuid.UUID) error
+}
+
+// MagicLinkTokenRepository defines the interface for sign-in link token operations
+type MagicLinkTokenRepository interface {
+	Create(token *models.MagicLinkToken) error
+	GetByTokenHash(tokenHash string) (*models.MagicLinkToken, error)
+	MarkUsed(id uuid.UUID) error
+	CountRecentByUserID(userID uuid.UUID, since time.Time) (int, error)
+}
+
+// PhoneVerificationRepository defines the interface for phone verification code operations
+type PhoneVerificationRepository interface {
+	Create(code *models.PhoneVerificationCode) error
+	GetLatestByUserID(userID uuid.UUID) (*models.PhoneVerificationCode, error)
+	IncrementAttempts(id uuid.UUID) (int, error)
+	MarkUsed(id uuid.UUID) error
+	CountRecentByUserID(userID uuid.UUID, since time.Time) (int, error)
+}
+
+// LoginOTPRepository defines the interface for SMS login code operations
+type LoginOTPRepository interface {
+	Create(code *models.LoginOTPCode) error
+	GetLatestByPhoneNumber(phoneNumber string) (*models.LoginOTPCode, error)
+	IncrementAttempts(id uuid.UUID) (int, error)
+	MarkUsed(id uuid.UUID) error
+	CountRecentByPhoneNumber(phoneNumber string, since time.Time) (int, error)
+}
+
+// OAuthStateRepository defines the interface for pending OAuth sign-in operations
+type OAuthStateRepository interface {
+	Create(state *models.OAuthState) error
+	Consume(stateHash string) (*models.OAuthState, error)
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+// InvitationRepository defines the interface for invitation operations.
+// Invitations are marked used by UserRepository.CreateInvitedUser.
+type InvitationRepository interface {
+	Create(invitation *models.Invitation, audit *models.AuditLogEntry) error
+	GetByID(id uuid.UUID) (*models.Invitation, error)
+	GetByCode(code string) (*models.Invitation, error)
+	List(opts models.ListInvitationsOptions) ([]models.Invitation, int, error)
+	Revoke(id uuid.UUID, audit *models.AuditLogEntry) error
+}
+
+// PersonalAccessTokenRepository defines the interface for personal access
+// token operations
+type PersonalAccessTokenRepository interface {
+	Create(token *models.PersonalAccessToken) error
+	GetByTokenHash(tokenHash string) (*models.PersonalAccessToken, error)
+	ListByUserID(userID uuid.UUID) ([]models.PersonalAccessToken, error)
+	Delete(userID, id uuid.UUID) error
+	TouchLastUsed(id uuid.UUID, usedAt time.Time) error
+}
+
+// KYCRepository defines the interface for identity verification (KYC)
+// operations. Submitting and reviewing also set the user's kyc_status and
+// record a user.kyc_status_changed event in the same transaction.
+type KYCRepository interface {
+	Submit(submission *models.KYCSubmission) error
+	GetLatestByUserID(userID uuid.UUID) (*models.KYCSubmission, error)
+	Review(submission *models.KYCSubmission, audit *models.AuditLogEntry) error
+	List(opts models.ListKYCSubmissionsOptions) ([]models.KYCSubmission, int, error)
+}
+
+// KYCDocumentRepository defines the interface for the metadata of uploaded
+// KYC documents. The files themselves are kept in a blobstore.Store.
+type KYCDocumentRepository interface {
+	Create(document *models.KYCDocument, maxPerSubmission int) error
+	GetByID(id uuid.UUID) (*models.KYCDocument, error)
+	ListBySubmissionID(submissionID uuid.UUID) ([]models.KYCDocument, error)
+	ListExpired(reviewedBefore time.Time, limit int) ([]models.KYCDocument, error)
+	Delete(id uuid.UUID) error
+}
+
+// DownloadArtifactRepository defines the interface for download artifact operations
+type DownloadArtifactRepository interface {
+	Create(artifact *models.DownloadArtifact) error
+	GetByID(id uuid.UUID) (*models.DownloadArtifact, error)
+	ClaimDownload(id uuid.UUID, access *models.DownloadAccess, now time.Time) (bool, error)
+	ListExpired(before time.Time, limit int) ([]models.DownloadArtifact, error)
+	Delete(id uuid.UUID) error
+}
+
+// StatementRepository defines the interface for statement email
+// subscription and delivery operations
+type StatementRepository interface {
+	GetSubscription(userID uuid.UUID) (*models.StatementSubscription, error)
+	SaveSubscription(subscription *models.StatementSubscription) error
+	DeleteSubscription(userID uuid.UUID) (bool, error)
+	ListDueSubscriptions(period string, day, limit int) ([]models.StatementSubscription, error)
+	CreateDelivery(delivery *models.StatementDelivery) (bool, error)
+	GetDelivery(userID uuid.UUID, period string) (*models.StatementDelivery, error)
+	ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.StatementDelivery, error)
+	ClaimDelivery(id uuid.UUID, requestedBy uuid.UUID, now time.Time, lease time.Duration) (*models.StatementDelivery, error)
+	UpdateDelivery(delivery *models.StatementDelivery) error
+}
+
+// KnownDeviceRepository defines the interface for known device operations
+type KnownDeviceRepository interface {
+	RecordLogin(device *models.KnownDevice) (bool, error)
+	CountByUserID(userID uuid.UUID) (int, error)
+	GetByReportTokenHash(tokenHash string) (*models.KnownDevice, error)
+	Delete(id uuid.UUID) error
+}
+
+// NotificationPreferenceRepository defines the interface for notification preference operations
+type NotificationPreferenceRepository interface {
+	GetByUserID(userID uuid.UUID) ([]models.NotificationPreference, error)
+	Upsert(preferences []models.NotificationPreference) error
+}
+
+// UserPreferenceRepository defines the interface for locale and time zone preference operations
+type UserPreferenceRepository interface {
+	GetByUserID(userID uuid.UUID) (*models.UserPreferences, error)
+	Update(userID uuid.UUID, update models.UserPreferencesUpdate) error
+}
+
+// PasswordHistoryRepository defines the interface for password history operations
+type PasswordHistoryRepository interface {
+	Create(entry *models.PasswordHistoryEntry) error
+	GetRecentByUserID(userID uuid.UUID, limit int) ([]models.PasswordHistoryEntry, error)
+	PruneByUserID(userID uuid.UUID, keep int) error
+}
+
+// BlacklistRepository defines the interface for blacklist history operations
+type BlacklistRepository interface {
+	Create(entry *models.BlacklistEntry) error
+	GetByUserID(userID uuid.UUID) ([]models.BlacklistEntry, error)
+}
+
+// AuditLogRepository defines the interface for reading the admin audit log.
+// Entries are written by the UserRepository mutations they describe.
+type AuditLogRepository interface {
+	Create(entry *models.AuditLogEntry) error
+	List(filter models.AuditLogFilter) ([]models.AuditLogEntry, int, error)
+}
+
+// LoginEventRepository defines the interface for login audit operations
+type LoginEventRepository interface {
+	Create(event *models.LoginEvent) error
+	GetByUserID(userID uuid.UUID, limit int) ([]models.LoginEvent, error)
+	DeleteOlderThan(cutoff time.Time) (int64, error)
 }
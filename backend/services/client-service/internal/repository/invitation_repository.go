@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// invitationColumns is the column list scanned by scanInvitation
+const invitationColumns = `id, code, COALESCE(email, ''), created_by, expires_at, used_by, used_at, revoked_at, created_at`
+
+// InvitationRepositoryImpl handles all database operations related to invitations
+type InvitationRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(db *PostgresDB) InvitationRepository {
+	return &InvitationRepositoryImpl{db: db}
+}
+
+// scanInvitation scans a single invitation selected with invitationColumns
+func scanInvitation(row rowScanner) (*models.Invitation, error) {
+	invitation := &models.Invitation{}
+	var createdBy, usedBy uuid.NullUUID
+	var usedAt, revokedAt sql.NullTime
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.Code,
+		&invitation.Email,
+		&createdBy,
+		&invitation.ExpiresAt,
+		&usedBy,
+		&usedAt,
+		&revokedAt,
+		&invitation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if createdBy.Valid {
+		invitation.CreatedBy = &createdBy.UUID
+	}
+	if usedBy.Valid {
+		invitation.UsedBy = &usedBy.UUID
+	}
+	if usedAt.Valid {
+		invitation.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		invitation.RevokedAt = &revokedAt.Time
+	}
+
+	return invitation, nil
+}
+
+// Create stores a new invitation. The optional audit entry is written in the
+// same transaction.
+func (r *InvitationRepositoryImpl) Create(invitation *models.Invitation, audit *models.AuditLogEntry) error {
+	query := `
+		INSERT INTO invitations (id, code, email, created_by, expires_at, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)`
+
+	if invitation.CreatedAt.IsZero() {
+		invitation.CreatedAt = time.Now()
+	}
+
+	return r.db.withTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			query,
+			invitation.ID,
+			invitation.Code,
+			invitation.Email,
+			invitation.CreatedBy,
+			invitation.ExpiresAt,
+			invitation.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create invitation: %w", err)
+		}
+
+		return insertAuditLogEntry(tx, audit)
+	})
+}
+
+// GetByID retrieves an invitation by its ID
+func (r *InvitationRepositoryImpl) GetByID(id uuid.UUID) (*models.Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM invitations WHERE id = $1`
+
+	invitation, err := scanInvitation(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invitation not found")
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// GetByCode retrieves an invitation by its code
+func (r *InvitationRepositoryImpl) GetByCode(code string) (*models.Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM invitations WHERE code = $1`
+
+	invitation, err := scanInvitation(r.db.QueryRow(query, code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invitation not found")
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// List retrieves a page of invitations, newest first, along with the total
+// number matching the status filter
+func (r *InvitationRepositoryImpl) List(opts models.ListInvitationsOptions) ([]models.Invitation, int, error) {
+	where, args := buildInvitationFilters(opts, time.Now())
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM invitations`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count invitations: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM invitations%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, invitationColumns, where, len(args)+1, len(args)+2)
+
+	rows, err := r.db.Query(query, append(args, opts.Limit, opts.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []models.Invitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan invitation row: %w", err)
+		}
+		invitations = append(invitations, *invitation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating over invitation rows: %w", err)
+	}
+
+	return invitations, total, nil
+}
+
+// buildInvitationFilters turns the status filter into a WHERE clause and its
+// positional arguments
+func buildInvitationFilters(opts models.ListInvitationsOptions, now time.Time) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	switch opts.Status {
+	case models.InvitationStatusActive:
+		args = append(args, now)
+		conditions = append(conditions, "used_at IS NULL", "revoked_at IS NULL", fmt.Sprintf("expires_at > $%d", len(args)))
+	case models.InvitationStatusUsed:
+		conditions = append(conditions, "used_at IS NOT NULL")
+	case models.InvitationStatusRevoked:
+		conditions = append(conditions, "used_at IS NULL", "revoked_at IS NOT NULL")
+	case models.InvitationStatusExpired:
+		args = append(args, now)
+		conditions = append(conditions, "used_at IS NULL", "revoked_at IS NULL", fmt.Sprintf("expires_at <= $%d", len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "\n\t\tWHERE " + strings.Join(conditions, " AND "), args
+}
+
+// Revoke marks an unused invitation as revoked. The optional audit entry is
+// written in the same transaction.
+func (r *InvitationRepositoryImpl) Revoke(id uuid.UUID, audit *models.AuditLogEntry) error {
+	query := `
+		UPDATE invitations
+		SET revoked_at = $1
+		WHERE id = $2 AND used_at IS NULL AND revoked_at IS NULL`
+
+	return r.db.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(query, time.Now(), id)
+		if err != nil {
+			return fmt.Errorf("failed to revoke invitation: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("invitation already used or revoked")
+		}
+
+		return insertAuditLogEntry(tx, audit)
+	})
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// KnownDeviceRepositoryImpl handles all database operations related to known devices
+type KnownDeviceRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewKnownDeviceRepository creates a new known device repository
+func NewKnownDeviceRepository(db *PostgresDB) KnownDeviceRepository {
+	return &KnownDeviceRepositoryImpl{db: db}
+}
+
+// RecordLogin marks a device as seen by its user. A device that is already
+// known has its last-seen time, IP address and user agent refreshed; an
+// unknown one is stored along with its report token. It reports whether the
+// device was new.
+func (r *KnownDeviceRepositoryImpl) RecordLogin(device *models.KnownDevice) (bool, error) {
+	updateQuery := `
+		UPDATE known_devices
+		SET last_seen_at = $1, ip_address = $2, user_agent = $3
+		WHERE user_id = $4 AND fingerprint = $5`
+
+	insertQuery := `
+		INSERT INTO known_devices (id, user_id, fingerprint, user_agent, ip_address, report_token_hash, report_token_expires_at, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		ON CONFLICT (user_id, fingerprint) DO NOTHING`
+
+	if device.LastSeenAt.IsZero() {
+		device.LastSeenAt = time.Now()
+	}
+
+	result, err := r.db.Exec(updateQuery, device.LastSeenAt, device.IPAddress, device.UserAgent, device.UserID, device.Fingerprint)
+	if err != nil {
+		return false, fmt.Errorf("failed to update known device: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rowsAffected > 0 {
+		return false, nil
+	}
+
+	device.FirstSeenAt = device.LastSeenAt
+	result, err = r.db.Exec(
+		insertQuery,
+		device.ID,
+		device.UserID,
+		device.Fingerprint,
+		device.UserAgent,
+		device.IPAddress,
+		device.ReportTokenHash,
+		device.ReportTokenExpiry,
+		device.FirstSeenAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create known device: %w", err)
+	}
+
+	// A concurrent login from the same device may have inserted it first
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// CountByUserID counts the devices a user has logged in from
+func (r *KnownDeviceRepositoryImpl) CountByUserID(userID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM known_devices WHERE user_id = $1`
+
+	var count int
+	if err := r.db.QueryRow(query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count known devices: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetByReportTokenHash retrieves the device a new-device alert was sent for
+func (r *KnownDeviceRepositoryImpl) GetByReportTokenHash(tokenHash string) (*models.KnownDevice, error) {
+	query := `
+		SELECT id, user_id, fingerprint, user_agent, ip_address, report_token_hash, report_token_expires_at, first_seen_at, last_seen_at
+		FROM known_devices WHERE report_token_hash = $1`
+
+	device := &models.KnownDevice{}
+	var userAgent, ipAddress sql.NullString
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&device.ID,
+		&device.UserID,
+		&device.Fingerprint,
+		&userAgent,
+		&ipAddress,
+		&device.ReportTokenHash,
+		&device.ReportTokenExpiry,
+		&device.FirstSeenAt,
+		&device.LastSeenAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("known device not found")
+		}
+		return nil, fmt.Errorf("failed to get known device: %w", err)
+	}
+
+	device.UserAgent = userAgent.String
+	device.IPAddress = ipAddress.String
+	return device, nil
+}
+
+// Delete forgets a device, so the next login from it is treated as new
+func (r *KnownDeviceRepositoryImpl) Delete(id uuid.UUID) error {
+	query := `DELETE FROM known_devices WHERE id = $1`
+
+	if _, err := r.db.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to delete known device: %w", err)
+	}
+
+	return nil
+}
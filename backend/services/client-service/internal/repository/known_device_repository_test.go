@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func TestKnownDeviceRepository_RecordLogin(t *testing.T) {
+	tests := []struct {
+		name         string
+		updated      int64
+		inserted     int64
+		expectInsert bool
+		wantNew      bool
+	}{
+		{name: "known device", updated: 1, wantNew: false},
+		{name: "new device", updated: 0, inserted: 1, expectInsert: true, wantNew: true},
+		{name: "inserted concurrently", updated: 0, inserted: 0, expectInsert: true, wantNew: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewKnownDeviceRepository(db)
+			device := &models.KnownDevice{ID: uuid.New(), UserID: uuid.New(), Fingerprint: "fp", LastSeenAt: time.Now()}
+
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE known_devices")).
+				WithArgs(device.LastSeenAt, device.IPAddress, device.UserAgent, device.UserID, "fp").
+				WillReturnResult(sqlmock.NewResult(0, tt.updated))
+			if tt.expectInsert {
+				mock.ExpectExec(regexp.QuoteMeta("ON CONFLICT (user_id, fingerprint) DO NOTHING")).
+					WillReturnResult(sqlmock.NewResult(0, tt.inserted))
+			}
+
+			isNew, err := repo.RecordLogin(device)
+			if err != nil {
+				t.Fatalf("RecordLogin returned error: %v", err)
+			}
+			if isNew != tt.wantNew {
+				t.Errorf("Expected new=%v, got %v", tt.wantNew, isNew)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// kycDocumentColumns is the column list scanned by scanKYCDocument
+const kycDocumentColumns = `id, submission_id, user_id, file_name, content_type, size_bytes, storage_key, uploaded_at`
+
+// KYCDocumentRepositoryImpl handles all database operations related to
+// uploaded KYC documents
+type KYCDocumentRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewKYCDocumentRepository creates a new KYC document repository
+func NewKYCDocumentRepository(db *PostgresDB) KYCDocumentRepository {
+	return &KYCDocumentRepositoryImpl{db: db}
+}
+
+// scanKYCDocument scans a single document selected with kycDocumentColumns
+func scanKYCDocument(row rowScanner) (*models.KYCDocument, error) {
+	document := &models.KYCDocument{}
+	var submissionID uuid.NullUUID
+	err := row.Scan(
+		&document.ID,
+		&submissionID,
+		&document.UserID,
+		&document.FileName,
+		&document.ContentType,
+		&document.SizeBytes,
+		&document.StorageKey,
+		&document.UploadedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if submissionID.Valid {
+		document.SubmissionID = &submissionID.UUID
+	}
+
+	return document, nil
+}
+
+// Create records an uploaded document against its submission, which must
+// still be pending and hold fewer than maxPerSubmission documents
+func (r *KYCDocumentRepositoryImpl) Create(document *models.KYCDocument, maxPerSubmission int) error {
+	insertQuery := `
+		INSERT INTO kyc_documents (id, submission_id, user_id, file_name, content_type, size_bytes, storage_key, uploaded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	if document.UploadedAt.IsZero() {
+		document.UploadedAt = time.Now()
+	}
+
+	return r.db.withTx(func(tx *sql.Tx) error {
+		// Lock the submission so a review or another upload cannot slip in
+		// between the checks and the insert
+		var status string
+		err := tx.QueryRow(`SELECT status FROM kyc_submissions WHERE id = $1 FOR UPDATE`, document.SubmissionID).Scan(&status)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("KYC submission not found")
+			}
+			return fmt.Errorf("failed to lock KYC submission: %w", err)
+		}
+		if status != models.KYCStatusPending {
+			return fmt.Errorf("KYC submission already reviewed")
+		}
+
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM kyc_documents WHERE submission_id = $1`, document.SubmissionID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count KYC documents: %w", err)
+		}
+		if count >= maxPerSubmission {
+			return fmt.Errorf("KYC submission already has %d documents", count)
+		}
+
+		_, err = tx.Exec(
+			insertQuery,
+			document.ID,
+			document.SubmissionID,
+			document.UserID,
+			document.FileName,
+			document.ContentType,
+			document.SizeBytes,
+			document.StorageKey,
+			document.UploadedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create KYC document: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetByID retrieves a document by ID
+func (r *KYCDocumentRepositoryImpl) GetByID(id uuid.UUID) (*models.KYCDocument, error) {
+	query := `SELECT ` + kycDocumentColumns + ` FROM kyc_documents WHERE id = $1`
+
+	document, err := scanKYCDocument(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("KYC document not found")
+		}
+		return nil, fmt.Errorf("failed to get KYC document: %w", err)
+	}
+
+	return document, nil
+}
+
+// ListBySubmissionID retrieves a submission's documents in upload order
+func (r *KYCDocumentRepositoryImpl) ListBySubmissionID(submissionID uuid.UUID) ([]models.KYCDocument, error) {
+	query := `
+		SELECT ` + kycDocumentColumns + `
+		FROM kyc_documents
+		WHERE submission_id = $1
+		ORDER BY uploaded_at`
+
+	return r.list(query, submissionID)
+}
+
+// ListExpired retrieves up to limit documents due for deletion: those of
+// submissions reviewed before the cutoff, and those whose submission was
+// removed along with its user
+func (r *KYCDocumentRepositoryImpl) ListExpired(reviewedBefore time.Time, limit int) ([]models.KYCDocument, error) {
+	query := `
+		SELECT d.id, d.submission_id, d.user_id, d.file_name, d.content_type, d.size_bytes, d.storage_key, d.uploaded_at
+		FROM kyc_documents d
+		LEFT JOIN kyc_submissions s ON s.id = d.submission_id
+		WHERE s.id IS NULL OR s.reviewed_at < $1
+		ORDER BY d.uploaded_at
+		LIMIT $2`
+
+	return r.list(query, reviewedBefore, limit)
+}
+
+// list runs a query selecting kycDocumentColumns
+func (r *KYCDocumentRepositoryImpl) list(query string, args ...interface{}) ([]models.KYCDocument, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query KYC documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []models.KYCDocument
+	for rows.Next() {
+		document, err := scanKYCDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan KYC document row: %w", err)
+		}
+		documents = append(documents, *document)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over KYC document rows: %w", err)
+	}
+
+	return documents, nil
+}
+
+// Delete removes a document's metadata. Its file must be deleted from the
+// document store first.
+func (r *KYCDocumentRepositoryImpl) Delete(id uuid.UUID) error {
+	if _, err := r.db.Exec(`DELETE FROM kyc_documents WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete KYC document: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func TestKYCDocumentRepository_Create(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewKYCDocumentRepository(db)
+	submissionID := uuid.New()
+	document := &models.KYCDocument{ID: uuid.New(), SubmissionID: &submissionID, UserID: uuid.New(), FileName: "passport.pdf", ContentType: "application/pdf", SizeBytes: 1024, StorageKey: "user/doc"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status FROM kyc_submissions WHERE id = $1 FOR UPDATE")).
+		WithArgs(&submissionID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.KYCStatusPending))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM kyc_documents WHERE submission_id = $1")).
+		WithArgs(&submissionID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO kyc_documents")).
+		WithArgs(document.ID, &submissionID, document.UserID, "passport.pdf", "application/pdf", int64(1024), "user/doc", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Create(document, 2); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestKYCDocumentRepository_CreateRefused(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		count  int
+	}{
+		{name: "reviewed submission", status: models.KYCStatusVerified},
+		{name: "too many documents", status: models.KYCStatusPending, count: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewKYCDocumentRepository(db)
+			submissionID := uuid.New()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT status FROM kyc_submissions")).
+				WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(tt.status))
+			if tt.status == models.KYCStatusPending {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM kyc_documents")).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+			}
+			mock.ExpectRollback()
+
+			if err := repo.Create(&models.KYCDocument{ID: uuid.New(), SubmissionID: &submissionID}, 2); err == nil {
+				t.Fatal("Expected Create to fail")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestKYCDocumentRepository_ListExpired(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewKYCDocumentRepository(db)
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	orphanID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE s.id IS NULL OR s.reviewed_at < $1")).
+		WithArgs(cutoff, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "submission_id", "user_id", "file_name", "content_type", "size_bytes", "storage_key", "uploaded_at"}).
+			AddRow(orphanID, nil, uuid.New(), "id.png", "image/png", 512, "user/orphan", time.Now()))
+
+	documents, err := repo.ListExpired(cutoff, 100)
+	if err != nil {
+		t.Fatalf("ListExpired returned error: %v", err)
+	}
+	if len(documents) != 1 || documents[0].ID != orphanID || documents[0].SubmissionID != nil {
+		t.Errorf("Expected the orphaned document, got %+v", documents)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/events"
+)
+
+// kycSubmissionColumns is the column list scanned by scanKYCSubmission
+const kycSubmissionColumns = `id, user_id, document_type, document_number, country, status,
+		reviewer_id, COALESCE(review_notes, ''), submitted_at, reviewed_at`
+
+// KYCRepositoryImpl handles all database operations related to identity
+// verification
+type KYCRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewKYCRepository creates a new KYC repository
+func NewKYCRepository(db *PostgresDB) KYCRepository {
+	return &KYCRepositoryImpl{db: db}
+}
+
+// scanKYCSubmission scans a single submission selected with
+// kycSubmissionColumns
+func scanKYCSubmission(row rowScanner) (*models.KYCSubmission, error) {
+	submission := &models.KYCSubmission{}
+	var reviewerID uuid.NullUUID
+	var reviewedAt sql.NullTime
+	err := row.Scan(
+		&submission.ID,
+		&submission.UserID,
+		&submission.DocumentType,
+		&submission.DocumentNumber,
+		&submission.Country,
+		&submission.Status,
+		&reviewerID,
+		&submission.ReviewNotes,
+		&submission.SubmittedAt,
+		&reviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if reviewerID.Valid {
+		submission.ReviewerID = &reviewerID.UUID
+	}
+	if reviewedAt.Valid {
+		submission.ReviewedAt = &reviewedAt.Time
+	}
+
+	return submission, nil
+}
+
+// Submit stores a pending submission and makes the user's KYC status
+// pending. Users who are already pending or verified cannot submit.
+func (r *KYCRepositoryImpl) Submit(submission *models.KYCSubmission) error {
+	insertQuery := `
+		INSERT INTO kyc_submissions (id, user_id, document_type, document_number, country, status, submitted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if submission.SubmittedAt.IsZero() {
+		submission.SubmittedAt = time.Now()
+	}
+	submission.Status = models.KYCStatusPending
+
+	return r.db.withTx(func(tx *sql.Tx) error {
+		var previous string
+		err := tx.QueryRow(`SELECT kyc_status FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, submission.UserID).Scan(&previous)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("user not found")
+			}
+			return fmt.Errorf("failed to lock user: %w", err)
+		}
+		if previous == models.KYCStatusPending || previous == models.KYCStatusVerified {
+			return fmt.Errorf("user's KYC status is already %s", previous)
+		}
+
+		_, err = tx.Exec(
+			insertQuery,
+			submission.ID,
+			submission.UserID,
+			submission.DocumentType,
+			submission.DocumentNumber,
+			submission.Country,
+			submission.Status,
+			submission.SubmittedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create KYC submission: %w", err)
+		}
+
+		return setKYCStatus(tx, submission.UserID, previous, models.KYCStatusPending, submission.SubmittedAt)
+	})
+}
+
+// GetLatestByUserID retrieves the user's most recent submission
+func (r *KYCRepositoryImpl) GetLatestByUserID(userID uuid.UUID) (*models.KYCSubmission, error) {
+	query := `
+		SELECT ` + kycSubmissionColumns + `
+		FROM kyc_submissions
+		WHERE user_id = $1
+		ORDER BY submitted_at DESC
+		LIMIT 1`
+
+	submission, err := scanKYCSubmission(r.db.QueryRow(query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("KYC submission not found")
+		}
+		return nil, fmt.Errorf("failed to get KYC submission: %w", err)
+	}
+
+	return submission, nil
+}
+
+// Review records the reviewer's decision, set on submission, on a pending
+// submission and gives its user the same KYC status. The optional audit
+// entry is written in the same transaction.
+func (r *KYCRepositoryImpl) Review(submission *models.KYCSubmission, audit *models.AuditLogEntry) error {
+	query := `
+		UPDATE kyc_submissions
+		SET status = $1, reviewer_id = $2, review_notes = NULLIF($3, ''), reviewed_at = $4
+		WHERE id = $5 AND status = $6`
+
+	if submission.ReviewedAt == nil {
+		now := time.Now()
+		submission.ReviewedAt = &now
+	}
+
+	return r.db.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			query,
+			submission.Status,
+			submission.ReviewerID,
+			submission.ReviewNotes,
+			*submission.ReviewedAt,
+			submission.ID,
+			models.KYCStatusPending,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to review KYC submission: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("KYC submission already reviewed")
+		}
+
+		if err := setKYCStatus(tx, submission.UserID, models.KYCStatusPending, submission.Status, *submission.ReviewedAt); err != nil {
+			return err
+		}
+
+		return insertAuditLogEntry(tx, audit)
+	})
+}
+
+// setKYCStatus changes the user's KYC status from previous to status and
+// records a user.kyc_status_changed event
+func setKYCStatus(tx *sql.Tx, userID uuid.UUID, previous, status string, at time.Time) error {
+	query := `UPDATE users SET kyc_status = $1, updated_at = $2 WHERE id = $3`
+
+	if _, err := tx.Exec(query, status, at, userID); err != nil {
+		return fmt.Errorf("failed to update KYC status: %w", err)
+	}
+
+	return writeUserEvent(tx, events.TypeUserKYCStatusChanged, events.UserKYCStatusChanged{
+		UserID:         userID,
+		Status:         status,
+		PreviousStatus: previous,
+		ChangedAt:      at.UTC(),
+	})
+}
+
+// List retrieves a page of submissions, oldest first so reviewers work
+// through the queue in order, along with the total number matching the
+// status filter
+func (r *KYCRepositoryImpl) List(opts models.ListKYCSubmissionsOptions) ([]models.KYCSubmission, int, error) {
+	where := ""
+	var args []interface{}
+	if opts.Status != "" {
+		args = append(args, opts.Status)
+		where = "\n\t\tWHERE status = $1"
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM kyc_submissions`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count KYC submissions: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM kyc_submissions%s
+		ORDER BY submitted_at
+		LIMIT $%d OFFSET $%d`, kycSubmissionColumns, where, len(args)+1, len(args)+2)
+
+	rows, err := r.db.Query(query, append(args, opts.Limit, opts.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query KYC submissions: %w", err)
+	}
+	defer rows.Close()
+
+	var submissions []models.KYCSubmission
+	for rows.Next() {
+		submission, err := scanKYCSubmission(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan KYC submission row: %w", err)
+		}
+		submissions = append(submissions, *submission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating over KYC submission rows: %w", err)
+	}
+
+	return submissions, total, nil
+}
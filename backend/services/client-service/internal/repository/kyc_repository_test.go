@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/events"
+)
+
+func TestKYCRepository_SubmitAfterRejection(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewKYCRepository(db)
+	submission := &models.KYCSubmission{ID: uuid.New(), UserID: uuid.New(), DocumentType: models.KYCDocumentPassport, DocumentNumber: "A1234567", Country: "ZA"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT kyc_status FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE")).
+		WithArgs(submission.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"kyc_status"}).AddRow(models.KYCStatusRejected))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO kyc_submissions")).
+		WithArgs(submission.ID, submission.UserID, "passport", "A1234567", "ZA", models.KYCStatusPending, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET kyc_status = $1")).
+		WithArgs(models.KYCStatusPending, sqlmock.AnyArg(), submission.UserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WithArgs(sqlmock.AnyArg(), events.TypeUserKYCStatusChanged, 1, events.SourceClientService, payloadContains(`"status":"pending","previous_status":"rejected"`), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Submit(submission); err != nil {
+		t.Fatalf("Submit returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestKYCRepository_SubmitWhilePending(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewKYCRepository(db)
+	submission := &models.KYCSubmission{ID: uuid.New(), UserID: uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT kyc_status FROM users")).
+		WillReturnRows(sqlmock.NewRows([]string{"kyc_status"}).AddRow(models.KYCStatusPending))
+	mock.ExpectRollback()
+
+	if err := repo.Submit(submission); err == nil {
+		t.Fatal("Expected submitting while a submission is pending to fail")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestKYCRepository_ReviewWritesEventAndAudit(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewKYCRepository(db)
+	reviewerID := uuid.New()
+	submission := &models.KYCSubmission{ID: uuid.New(), UserID: uuid.New(), Status: models.KYCStatusVerified, ReviewerID: &reviewerID}
+	audit := &models.AuditLogEntry{ID: uuid.New(), AdminID: reviewerID, Action: models.AuditActionApproveKYC, TargetUserID: &submission.UserID}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE kyc_submissions")).
+		WithArgs(models.KYCStatusVerified, &reviewerID, "", sqlmock.AnyArg(), submission.ID, models.KYCStatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET kyc_status = $1")).
+		WithArgs(models.KYCStatusVerified, sqlmock.AnyArg(), submission.UserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WithArgs(sqlmock.AnyArg(), events.TypeUserKYCStatusChanged, 1, events.SourceClientService, payloadContains(`"status":"verified","previous_status":"pending"`), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO admin_audit_log")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Review(submission, audit); err != nil {
+		t.Fatalf("Review returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestKYCRepository_ReviewAlreadyReviewed(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewKYCRepository(db)
+	submission := &models.KYCSubmission{ID: uuid.New(), UserID: uuid.New(), Status: models.KYCStatusRejected}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE kyc_submissions")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if err := repo.Review(submission, nil); err == nil {
+		t.Fatal("Expected reviewing a submission twice to fail")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
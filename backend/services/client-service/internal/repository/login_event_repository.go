@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// LoginEventRepositoryImpl handles all database operations related to login events
+type LoginEventRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewLoginEventRepository creates a new login event repository
+func NewLoginEventRepository(db *PostgresDB) LoginEventRepository {
+	return &LoginEventRepositoryImpl{db: db}
+}
+
+// Create records a login attempt
+func (r *LoginEventRepositoryImpl) Create(event *models.LoginEvent) error {
+	query := `
+		INSERT INTO login_events (id, user_id, email, success, method, ip_address, user_agent, failure_reason, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, NULLIF($8, ''), $9)`
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(
+		query,
+		event.ID,
+		event.UserID,
+		event.Email,
+		event.Success,
+		event.Method,
+		event.IPAddress,
+		event.UserAgent,
+		event.FailureReason,
+		event.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create login event: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID retrieves the most recent login events for a user
+func (r *LoginEventRepositoryImpl) GetByUserID(userID uuid.UUID, limit int) ([]models.LoginEvent, error) {
+	query := `
+		SELECT id, user_id, email, success, COALESCE(method, ''), COALESCE(ip_address, ''), COALESCE(user_agent, ''),
+			COALESCE(failure_reason, ''), created_at
+		FROM login_events
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query login events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.LoginEvent
+	for rows.Next() {
+		var event models.LoginEvent
+		var eventUserID uuid.NullUUID
+		err := rows.Scan(
+			&event.ID,
+			&eventUserID,
+			&event.Email,
+			&event.Success,
+			&event.Method,
+			&event.IPAddress,
+			&event.UserAgent,
+			&event.FailureReason,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan login event row: %w", err)
+		}
+		if eventUserID.Valid {
+			event.UserID = &eventUserID.UUID
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over login event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// DeleteOlderThan deletes login events created before the cutoff
+func (r *LoginEventRepositoryImpl) DeleteOlderThan(cutoff time.Time) (int64, error) {
+	query := `DELETE FROM login_events WHERE created_at < $1`
+
+	result, err := r.db.Exec(query, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old login events: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
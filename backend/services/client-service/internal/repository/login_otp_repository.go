@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// LoginOTPRepositoryImpl handles all database operations related to SMS login codes
+type LoginOTPRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewLoginOTPRepository creates a new SMS login code repository
+func NewLoginOTPRepository(db *PostgresDB) LoginOTPRepository {
+	return &LoginOTPRepositoryImpl{db: db}
+}
+
+// Create stores a new login code
+func (r *LoginOTPRepositoryImpl) Create(code *models.LoginOTPCode) error {
+	query := `
+		INSERT INTO login_otp_codes (id, user_id, phone_number, code_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	if code.CreatedAt.IsZero() {
+		code.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(
+		query,
+		code.ID,
+		code.UserID,
+		code.PhoneNumber,
+		code.CodeHash,
+		code.ExpiresAt,
+		code.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create login code: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestByPhoneNumber retrieves the most recently issued login code for a phone number
+func (r *LoginOTPRepositoryImpl) GetLatestByPhoneNumber(phoneNumber string) (*models.LoginOTPCode, error) {
+	query := `
+		SELECT id, user_id, phone_number, code_hash, attempts, expires_at, used_at, created_at
+		FROM login_otp_codes WHERE phone_number = $1
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	code := &models.LoginOTPCode{}
+	var usedAt sql.NullTime
+	err := r.db.QueryRow(query, phoneNumber).Scan(
+		&code.ID,
+		&code.UserID,
+		&code.PhoneNumber,
+		&code.CodeHash,
+		&code.Attempts,
+		&code.ExpiresAt,
+		&usedAt,
+		&code.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("login code not found")
+		}
+		return nil, fmt.Errorf("failed to get login code: %w", err)
+	}
+
+	if usedAt.Valid {
+		code.UsedAt = &usedAt.Time
+	}
+
+	return code, nil
+}
+
+// IncrementAttempts records a sign-in attempt against a code and returns the
+// new attempt count. The increment is atomic, so concurrent guesses cannot
+// exceed the attempt limit.
+func (r *LoginOTPRepositoryImpl) IncrementAttempts(id uuid.UUID) (int, error) {
+	query := `
+		UPDATE login_otp_codes
+		SET attempts = attempts + 1
+		WHERE id = $1
+		RETURNING attempts`
+
+	var attempts int
+	if err := r.db.QueryRow(query, id).Scan(&attempts); err != nil {
+		return 0, fmt.Errorf("failed to record login code attempt: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// MarkUsed consumes a login code. The update only succeeds for a code that
+// has not been used yet, so concurrent sign-ins cannot both win.
+func (r *LoginOTPRepositoryImpl) MarkUsed(id uuid.UUID) error {
+	query := `
+		UPDATE login_otp_codes
+		SET used_at = $1
+		WHERE id = $2 AND used_at IS NULL`
+
+	result, err := r.db.Exec(query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark login code as used: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("login code already used")
+	}
+
+	return nil
+}
+
+// CountRecentByPhoneNumber counts the login codes sent to a phone number since the given time
+func (r *LoginOTPRepositoryImpl) CountRecentByPhoneNumber(phoneNumber string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM login_otp_codes WHERE phone_number = $1 AND created_at >= $2`
+
+	var count int
+	err := r.db.QueryRow(query, phoneNumber, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count login codes: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// MagicLinkTokenRepositoryImpl handles all database operations related to sign-in link tokens
+type MagicLinkTokenRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewMagicLinkTokenRepository creates a new sign-in link token repository
+func NewMagicLinkTokenRepository(db *PostgresDB) MagicLinkTokenRepository {
+	return &MagicLinkTokenRepositoryImpl{db: db}
+}
+
+// Create stores a new sign-in link token
+func (r *MagicLinkTokenRepositoryImpl) Create(token *models.MagicLinkToken) error {
+	query := `
+		INSERT INTO magic_link_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(
+		query,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create sign-in link token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByTokenHash retrieves a sign-in link token by its hash
+func (r *MagicLinkTokenRepositoryImpl) GetByTokenHash(tokenHash string) (*models.MagicLinkToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at
+		FROM magic_link_tokens WHERE token_hash = $1`
+
+	token := &models.MagicLinkToken{}
+	var usedAt sql.NullTime
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&usedAt,
+		&token.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("sign-in link token not found")
+		}
+		return nil, fmt.Errorf("failed to get sign-in link token: %w", err)
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return token, nil
+}
+
+// MarkUsed consumes a sign-in link token. The update only succeeds for a
+// token that has not been used yet, so concurrent consumers cannot both win.
+func (r *MagicLinkTokenRepositoryImpl) MarkUsed(id uuid.UUID) error {
+	query := `
+		UPDATE magic_link_tokens
+		SET used_at = $1
+		WHERE id = $2 AND used_at IS NULL`
+
+	result, err := r.db.Exec(query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark sign-in link token as used: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("sign-in link token already used")
+	}
+
+	return nil
+}
+
+// CountRecentByUserID counts the sign-in link tokens issued to a user since the given time
+func (r *MagicLinkTokenRepositoryImpl) CountRecentByUserID(userID uuid.UUID, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM magic_link_tokens WHERE user_id = $1 AND created_at >= $2`
+
+	var count int
+	err := r.db.QueryRow(query, userID, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count sign-in link tokens: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestMagicLinkTokenRepository_MarkUsed(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		wantErr      bool
+	}{
+		{name: "unused token", rowsAffected: 1, wantErr: false},
+		{name: "already used token", rowsAffected: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewMagicLinkTokenRepository(db)
+			id := uuid.New()
+
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE magic_link_tokens")).
+				WithArgs(sqlmock.AnyArg(), id).
+				WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+
+			err := repo.MarkUsed(id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// NotificationPreferenceRepositoryImpl handles all database operations related to notification preferences
+type NotificationPreferenceRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewNotificationPreferenceRepository creates a new notification preference repository
+func NewNotificationPreferenceRepository(db *PostgresDB) NotificationPreferenceRepository {
+	return &NotificationPreferenceRepositoryImpl{db: db}
+}
+
+// GetByUserID retrieves every stored notification preference for a user.
+// Events the user never configured have no row.
+func (r *NotificationPreferenceRepositoryImpl) GetByUserID(userID uuid.UUID) ([]models.NotificationPreference, error) {
+	query := `
+		SELECT user_id, event_type, email, sms, webhook, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	var preferences []models.NotificationPreference
+	for rows.Next() {
+		var p models.NotificationPreference
+		if err := rows.Scan(&p.UserID, &p.EventType, &p.Email, &p.SMS, &p.Webhook, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification preference row: %w", err)
+		}
+		preferences = append(preferences, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over notification preference rows: %w", err)
+	}
+
+	return preferences, nil
+}
+
+// Upsert creates or replaces notification preferences in a single transaction
+func (r *NotificationPreferenceRepositoryImpl) Upsert(preferences []models.NotificationPreference) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, event_type, email, sms, webhook, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, event_type) DO UPDATE
+		SET email = EXCLUDED.email, sms = EXCLUDED.sms, webhook = EXCLUDED.webhook, updated_at = EXCLUDED.updated_at`
+
+	now := time.Now()
+	return r.db.withTx(func(tx *sql.Tx) error {
+		for i := range preferences {
+			p := &preferences[i]
+			p.UpdatedAt = now
+			if _, err := tx.Exec(query, p.UserID, p.EventType, p.Email, p.SMS, p.Webhook, p.UpdatedAt); err != nil {
+				return fmt.Errorf("failed to save %s notification preference: %w", p.EventType, err)
+			}
+		}
+		return nil
+	})
+}
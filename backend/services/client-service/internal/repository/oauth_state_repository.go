@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// OAuthStateRepositoryImpl handles all database operations related to pending OAuth sign-ins
+type OAuthStateRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewOAuthStateRepository creates a new OAuth state repository
+func NewOAuthStateRepository(db *PostgresDB) OAuthStateRepository {
+	return &OAuthStateRepositoryImpl{db: db}
+}
+
+// Create stores a new pending OAuth sign-in
+func (r *OAuthStateRepositoryImpl) Create(state *models.OAuthState) error {
+	query := `
+		INSERT INTO oauth_states (id, state_hash, nonce, provider, user_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if state.CreatedAt.IsZero() {
+		state.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(
+		query,
+		state.ID,
+		state.StateHash,
+		state.Nonce,
+		state.Provider,
+		state.UserID,
+		state.ExpiresAt,
+		state.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create OAuth state: %w", err)
+	}
+
+	return nil
+}
+
+// Consume deletes and returns the pending sign-in with the given state hash,
+// so a state can only be used once even by concurrent callbacks. Expired
+// states are returned too; callers must check IsExpired.
+func (r *OAuthStateRepositoryImpl) Consume(stateHash string) (*models.OAuthState, error) {
+	query := `
+		DELETE FROM oauth_states WHERE state_hash = $1
+		RETURNING id, state_hash, nonce, provider, user_id, expires_at, created_at`
+
+	state := &models.OAuthState{}
+	var userID uuid.NullUUID
+	err := r.db.QueryRow(query, stateHash).Scan(
+		&state.ID,
+		&state.StateHash,
+		&state.Nonce,
+		&state.Provider,
+		&userID,
+		&state.ExpiresAt,
+		&state.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("OAuth state not found")
+		}
+		return nil, fmt.Errorf("failed to consume OAuth state: %w", err)
+	}
+
+	if userID.Valid {
+		state.UserID = &userID.UUID
+	}
+
+	return state, nil
+}
+
+// DeleteExpired removes pending sign-ins that expired before the given time
+// and returns how many were removed
+func (r *OAuthStateRepositoryImpl) DeleteExpired(before time.Time) (int64, error) {
+	query := `DELETE FROM oauth_states WHERE expires_at < $1`
+
+	result, err := r.db.Exec(query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired OAuth states: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestOAuthStateRepository_Consume(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewOAuthStateRepository(db)
+
+	id, userID := uuid.New(), uuid.New()
+	columns := []string{"id", "state_hash", "nonce", "provider", "user_id", "expires_at", "created_at"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM oauth_states WHERE state_hash = $1")).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(id, "hash", "nonce", "google", userID, time.Now().Add(time.Minute), time.Now()))
+	mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM oauth_states WHERE state_hash = $1")).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	state, err := repo.Consume("hash")
+	if err != nil {
+		t.Fatalf("Consume returned error: %v", err)
+	}
+	if state.UserID == nil || *state.UserID != userID || state.Nonce != "nonce" || state.IsExpired() {
+		t.Errorf("Unexpected state: %+v", state)
+	}
+
+	if _, err := repo.Consume("hash"); err == nil {
+		t.Error("Expected a consumed state to be gone")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// PasswordHistoryRepositoryImpl handles all database operations related to password history
+type PasswordHistoryRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewPasswordHistoryRepository creates a new password history repository
+func NewPasswordHistoryRepository(db *PostgresDB) PasswordHistoryRepository {
+	return &PasswordHistoryRepositoryImpl{db: db}
+}
+
+// Create stores a password hash in the user's history
+func (r *PasswordHistoryRepositoryImpl) Create(entry *models.PasswordHistoryEntry) error {
+	query := `
+		INSERT INTO password_history (id, user_id, password_hash, created_at)
+		VALUES ($1, $2, $3, $4)`
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(query, entry.ID, entry.UserID, entry.PasswordHash, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create password history entry: %w", err)
+	}
+
+	return nil
+}
+
+// GetRecentByUserID retrieves the most recent password hashes for a user
+func (r *PasswordHistoryRepositoryImpl) GetRecentByUserID(userID uuid.UUID, limit int) ([]models.PasswordHistoryEntry, error) {
+	query := `
+		SELECT id, user_id, password_hash, created_at
+		FROM password_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query password history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.PasswordHistoryEntry
+	for rows.Next() {
+		var entry models.PasswordHistoryEntry
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.PasswordHash, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan password history row: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over password history rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// PruneByUserID deletes all but the most recent keep entries for a user
+func (r *PasswordHistoryRepositoryImpl) PruneByUserID(userID uuid.UUID, keep int) error {
+	query := `
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history
+			WHERE user_id = $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		)`
+
+	if _, err := r.db.Exec(query, userID, keep); err != nil {
+		return fmt.Errorf("failed to prune password history: %w", err)
+	}
+
+	return nil
+}
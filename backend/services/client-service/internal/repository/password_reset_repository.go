@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// PasswordResetTokenRepositoryImpl handles all database operations related to password reset tokens
+type PasswordResetTokenRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewPasswordResetTokenRepository creates a new password reset token repository
+func NewPasswordResetTokenRepository(db *PostgresDB) PasswordResetTokenRepository {
+	return &PasswordResetTokenRepositoryImpl{db: db}
+}
+
+// Create stores a new password reset token
+func (r *PasswordResetTokenRepositoryImpl) Create(token *models.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(
+		query,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByTokenHash retrieves a password reset token by its hash
+func (r *PasswordResetTokenRepositoryImpl) GetByTokenHash(tokenHash string) (*models.PasswordResetToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at
+		FROM password_reset_tokens WHERE token_hash = $1`
+
+	token := &models.PasswordResetToken{}
+	var usedAt sql.NullTime
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&usedAt,
+		&token.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("password reset token not found")
+		}
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return token, nil
+}
+
+// MarkUsed consumes a password reset token. The update only succeeds for a
+// token that has not been used yet, so concurrent consumers cannot both win.
+func (r *PasswordResetTokenRepositoryImpl) MarkUsed(id uuid.UUID) error {
+	query := `
+		UPDATE password_reset_tokens
+		SET used_at = $1
+		WHERE id = $2 AND used_at IS NULL`
+
+	result, err := r.db.Exec(query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark password reset token as used: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("password reset token already used")
+	}
+
+	return nil
+}
+
+// CountRecentByUserID counts the reset tokens issued to a user since the given time
+func (r *PasswordResetTokenRepositoryImpl) CountRecentByUserID(userID uuid.UUID, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = $1 AND created_at >= $2`
+
+	var count int
+	err := r.db.QueryRow(query, userID, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count password reset tokens: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteByUserID deletes all password reset tokens for a specific user
+func (r *PasswordResetTokenRepositoryImpl) DeleteByUserID(userID uuid.UUID) error {
+	query := `DELETE FROM password_reset_tokens WHERE user_id = $1`
+
+	_, err := r.db.Exec(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete password reset tokens by user ID: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func newMockDB(t *testing.T) (*PostgresDB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &PostgresDB{db}, mock
+}
+
+func TestPasswordResetTokenRepository_GetByTokenHash(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewPasswordResetTokenRepository(db)
+
+	id, userID := uuid.New(), uuid.New()
+	expiresAt := time.Now().Add(-time.Minute)
+	usedAt := time.Now().Add(-2 * time.Minute)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM password_reset_tokens WHERE token_hash = $1")).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at", "used_at", "created_at"}).
+			AddRow(id, userID, "hash", expiresAt, usedAt, time.Now().Add(-time.Hour)))
+
+	token, err := repo.GetByTokenHash("hash")
+	if err != nil {
+		t.Fatalf("GetByTokenHash returned error: %v", err)
+	}
+
+	if !token.IsExpired() {
+		t.Error("Expected token to be expired")
+	}
+	if !token.IsUsed() {
+		t.Error("Expected token to be marked as used")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPasswordResetTokenRepository_MarkUsed(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		wantErr      bool
+	}{
+		{name: "unused token", rowsAffected: 1, wantErr: false},
+		{name: "already used token", rowsAffected: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewPasswordResetTokenRepository(db)
+			id := uuid.New()
+
+			mock.ExpectExec(regexp.QuoteMeta("WHERE id = $2 AND used_at IS NULL")).
+				WithArgs(sqlmock.AnyArg(), id).
+				WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+
+			err := repo.MarkUsed(id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"microbank/client-service/internal/models"
+)
+
+// personalAccessTokenColumns is the column list scanned by
+// scanPersonalAccessToken
+const personalAccessTokenColumns = `id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at`
+
+// PersonalAccessTokenRepositoryImpl handles all database operations related to personal access tokens
+type PersonalAccessTokenRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewPersonalAccessTokenRepository creates a new personal access token repository
+func NewPersonalAccessTokenRepository(db *PostgresDB) PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepositoryImpl{db: db}
+}
+
+// scanPersonalAccessToken scans a single token selected with
+// personalAccessTokenColumns
+func scanPersonalAccessToken(row rowScanner) (*models.PersonalAccessToken, error) {
+	token := &models.PersonalAccessToken{}
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		pq.Array(&token.Scopes),
+		&expiresAt,
+		&lastUsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return token, nil
+}
+
+// Create stores a new personal access token
+func (r *PersonalAccessTokenRepositoryImpl) Create(token *models.PersonalAccessToken) error {
+	query := `
+		INSERT INTO personal_access_tokens (id, user_id, name, token_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(
+		query,
+		token.ID,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		pq.Array(token.Scopes),
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create personal access token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByTokenHash retrieves a personal access token by its hash
+func (r *PersonalAccessTokenRepositoryImpl) GetByTokenHash(tokenHash string) (*models.PersonalAccessToken, error) {
+	query := `SELECT ` + personalAccessTokenColumns + ` FROM personal_access_tokens WHERE token_hash = $1`
+
+	token, err := scanPersonalAccessToken(r.db.QueryRow(query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("personal access token not found")
+		}
+		return nil, fmt.Errorf("failed to get personal access token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListByUserID retrieves a user's personal access tokens, newest first
+func (r *PersonalAccessTokenRepositoryImpl) ListByUserID(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	query := `
+		SELECT ` + personalAccessTokenColumns + `
+		FROM personal_access_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query personal access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan personal access token row: %w", err)
+		}
+		tokens = append(tokens, *token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over personal access token rows: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Delete deletes one of a user's personal access tokens. Tokens of other
+// users are treated as not found.
+func (r *PersonalAccessTokenRepositoryImpl) Delete(userID, id uuid.UUID) error {
+	query := `DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete personal access token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("personal access token not found")
+	}
+
+	return nil
+}
+
+// TouchLastUsed records when a personal access token was last used
+func (r *PersonalAccessTokenRepositoryImpl) TouchLastUsed(id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE personal_access_tokens SET last_used_at = $1 WHERE id = $2`
+
+	if _, err := r.db.Exec(query, usedAt, id); err != nil {
+		return fmt.Errorf("failed to update personal access token last use: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestPersonalAccessTokenRepository_GetByTokenHash(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewPersonalAccessTokenRepository(db)
+
+	id, userID := uuid.New(), uuid.New()
+	lastUsedAt := time.Now().Add(-time.Minute)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM personal_access_tokens WHERE token_hash = $1")).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "token_hash", "scopes", "expires_at", "last_used_at", "created_at"}).
+			AddRow(id, userID, "ci", "hash", "{read:balance,read:transactions}", nil, lastUsedAt, time.Now().Add(-time.Hour)))
+
+	token, err := repo.GetByTokenHash("hash")
+	if err != nil {
+		t.Fatalf("GetByTokenHash returned error: %v", err)
+	}
+
+	if token.UserID != userID || token.Name != "ci" {
+		t.Errorf("Unexpected token: %+v", token)
+	}
+	if want := []string{"read:balance", "read:transactions"}; !reflect.DeepEqual(token.Scopes, want) {
+		t.Errorf("Expected scopes %v, got %v", want, token.Scopes)
+	}
+	if token.ExpiresAt != nil || token.IsExpired() {
+		t.Errorf("Expected a token without an expiry, got %v", token.ExpiresAt)
+	}
+	if token.LastUsedAt == nil || !token.LastUsedAt.Equal(lastUsedAt) {
+		t.Errorf("Expected last_used_at %v, got %v", lastUsedAt, token.LastUsedAt)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPersonalAccessTokenRepository_DeleteScopedToOwner(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewPersonalAccessTokenRepository(db)
+
+	id, userID := uuid.New(), uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2")).
+		WithArgs(id, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := repo.Delete(userID, id); err == nil {
+		t.Error("Expected deleting a token the user does not own to fail")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// PhoneVerificationRepositoryImpl handles all database operations related to phone verification codes
+type PhoneVerificationRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewPhoneVerificationRepository creates a new phone verification code repository
+func NewPhoneVerificationRepository(db *PostgresDB) PhoneVerificationRepository {
+	return &PhoneVerificationRepositoryImpl{db: db}
+}
+
+// Create stores a new phone verification code
+func (r *PhoneVerificationRepositoryImpl) Create(code *models.PhoneVerificationCode) error {
+	query := `
+		INSERT INTO phone_verification_codes (id, user_id, phone_number, code_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	if code.CreatedAt.IsZero() {
+		code.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(
+		query,
+		code.ID,
+		code.UserID,
+		code.PhoneNumber,
+		code.CodeHash,
+		code.ExpiresAt,
+		code.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create phone verification code: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestByUserID retrieves the most recently issued verification code for a user
+func (r *PhoneVerificationRepositoryImpl) GetLatestByUserID(userID uuid.UUID) (*models.PhoneVerificationCode, error) {
+	query := `
+		SELECT id, user_id, phone_number, code_hash, attempts, expires_at, used_at, created_at
+		FROM phone_verification_codes WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	code := &models.PhoneVerificationCode{}
+	var usedAt sql.NullTime
+	err := r.db.QueryRow(query, userID).Scan(
+		&code.ID,
+		&code.UserID,
+		&code.PhoneNumber,
+		&code.CodeHash,
+		&code.Attempts,
+		&code.ExpiresAt,
+		&usedAt,
+		&code.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("phone verification code not found")
+		}
+		return nil, fmt.Errorf("failed to get phone verification code: %w", err)
+	}
+
+	if usedAt.Valid {
+		code.UsedAt = &usedAt.Time
+	}
+
+	return code, nil
+}
+
+// IncrementAttempts records a confirmation attempt against a code and
+// returns the new attempt count. The increment is atomic, so concurrent
+// guesses cannot exceed the attempt limit.
+func (r *PhoneVerificationRepositoryImpl) IncrementAttempts(id uuid.UUID) (int, error) {
+	query := `
+		UPDATE phone_verification_codes
+		SET attempts = attempts + 1
+		WHERE id = $1
+		RETURNING attempts`
+
+	var attempts int
+	if err := r.db.QueryRow(query, id).Scan(&attempts); err != nil {
+		return 0, fmt.Errorf("failed to record phone verification attempt: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// MarkUsed consumes a verification code. The update only succeeds for a
+// code that has not been used yet, so concurrent consumers cannot both win.
+func (r *PhoneVerificationRepositoryImpl) MarkUsed(id uuid.UUID) error {
+	query := `
+		UPDATE phone_verification_codes
+		SET used_at = $1
+		WHERE id = $2 AND used_at IS NULL`
+
+	result, err := r.db.Exec(query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark phone verification code as used: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("phone verification code already used")
+	}
+
+	return nil
+}
+
+// CountRecentByUserID counts the verification codes sent to a user since the given time
+func (r *PhoneVerificationRepositoryImpl) CountRecentByUserID(userID uuid.UUID, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM phone_verification_codes WHERE user_id = $1 AND created_at >= $2`
+
+	var count int
+	err := r.db.QueryRow(query, userID, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count phone verification codes: %w", err)
+	}
+
+	return count, nil
+}
@@ -1,11 +1,13 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"microbank/client-service/internal/models"
 )
 
@@ -41,6 +43,71 @@ func (r *RefreshTokenRepositoryImpl) Create(refreshToken *models.RefreshToken) e
 	return nil
 }
 
+// CreateWithLimit creates a new refresh token and, in the same transaction,
+// deletes the user's oldest tokens so that at most maxPerUser remain. The
+// user's row is locked first so concurrent logins cannot both slip past the
+// limit. It returns how many tokens were evicted.
+func (r *RefreshTokenRepositoryImpl) CreateWithLimit(refreshToken *models.RefreshToken, maxPerUser int) (int64, error) {
+	lockQuery := `SELECT id FROM users WHERE id = $1 FOR UPDATE`
+
+	insertQuery := `
+		INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	// Tokens are ranked newest first, as there is no last-used time to rank
+	// by, and everything past the limit is evicted
+	evictQuery := `
+		DELETE FROM refresh_tokens WHERE id IN (
+			SELECT id FROM refresh_tokens WHERE user_id = $1
+			ORDER BY created_at DESC, id DESC
+			OFFSET $2
+		)`
+
+	if refreshToken.CreatedAt.IsZero() {
+		refreshToken.CreatedAt = time.Now()
+	}
+
+	var evicted int64
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		var lockedID uuid.UUID
+		if err := tx.QueryRow(lockQuery, refreshToken.UserID).Scan(&lockedID); err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("user not found")
+			}
+			return fmt.Errorf("failed to lock user: %w", err)
+		}
+
+		_, err := tx.Exec(
+			insertQuery,
+			refreshToken.ID,
+			refreshToken.UserID,
+			refreshToken.TokenHash,
+			refreshToken.ExpiresAt,
+			refreshToken.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create refresh token: %w", err)
+		}
+
+		result, err := tx.Exec(evictQuery, refreshToken.UserID, maxPerUser)
+		if err != nil {
+			return fmt.Errorf("failed to evict old refresh tokens: %w", err)
+		}
+
+		evicted, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return evicted, nil
+}
+
 // GetByToken retrieves a refresh token by its hash
 func (r *RefreshTokenRepositoryImpl) GetByToken(tokenHash string) (*models.RefreshToken, error) {
 	query := `
@@ -102,6 +169,30 @@ func (r *RefreshTokenRepositoryImpl) GetByUserID(userID uuid.UUID) ([]models.Ref
 	return refreshTokens, nil
 }
 
+// CountActiveByUserID counts a user's unexpired refresh tokens
+func (r *RefreshTokenRepositoryImpl) CountActiveByUserID(userID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM refresh_tokens WHERE user_id = $1 AND expires_at > $2`
+
+	var count int
+	if err := r.db.QueryRow(query, userID, time.Now()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count refresh tokens: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountActive counts unexpired refresh tokens across all users
+func (r *RefreshTokenRepositoryImpl) CountActive() (int, error) {
+	query := `SELECT COUNT(*) FROM refresh_tokens WHERE expires_at > $1`
+
+	var count int
+	if err := r.db.QueryRow(query, time.Now()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count refresh tokens: %w", err)
+	}
+
+	return count, nil
+}
+
 // Delete deletes a specific refresh token
 func (r *RefreshTokenRepositoryImpl) Delete(id uuid.UUID) error {
 	query := `DELETE FROM refresh_tokens WHERE id = $1`
@@ -135,19 +226,37 @@ func (r *RefreshTokenRepositoryImpl) DeleteByUserID(userID uuid.UUID) error {
 	return nil
 }
 
-// DeleteExpired deletes all expired refresh tokens
-func (r *RefreshTokenRepositoryImpl) DeleteExpired() error {
-	query := `DELETE FROM refresh_tokens WHERE expires_at < $1`
+// DeleteByUserIDs deletes the refresh tokens of several users at once
+func (r *RefreshTokenRepositoryImpl) DeleteByUserIDs(userIDs []uuid.UUID) error {
+	query := `DELETE FROM refresh_tokens WHERE user_id = ANY($1)`
 
-	_, err := r.db.Exec(query, time.Now())
+	_, err := r.db.Exec(query, pq.Array(userIDs))
 	if err != nil {
-		return fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+		return fmt.Errorf("failed to delete refresh tokens by user IDs: %w", err)
 	}
 
 	return nil
 }
 
+// DeleteExpired deletes refresh tokens that expired before the given time
+// and returns how many were removed
+func (r *RefreshTokenRepositoryImpl) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM refresh_tokens WHERE expires_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
 // CleanupExpiredTokens removes expired tokens (should be called periodically)
-func (r *RefreshTokenRepositoryImpl) CleanupExpiredTokens() error {
-	return r.DeleteExpired()
+func (r *RefreshTokenRepositoryImpl) CleanupExpiredTokens(ctx context.Context) (int64, error) {
+	return r.DeleteExpired(ctx, time.Now())
 }
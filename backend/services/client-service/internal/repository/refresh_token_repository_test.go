@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func TestRefreshTokenRepository_DeleteExpiredReturnsCount(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewRefreshTokenRepository(db)
+
+	before := time.Now()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM refresh_tokens WHERE expires_at < $1")).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	deleted, err := repo.DeleteExpired(context.Background(), before)
+	if err != nil {
+		t.Fatalf("DeleteExpired returned error: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("Expected 3 tokens deleted, got %d", deleted)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRefreshTokenRepository_CreateWithLimitEvictsInTransaction(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewRefreshTokenRepository(db)
+
+	token := &models.RefreshToken{ID: uuid.New(), UserID: uuid.New(), TokenHash: "token", ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users WHERE id = $1 FOR UPDATE")).
+		WithArgs(token.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(token.UserID))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO refresh_tokens")).
+		WithArgs(token.ID, token.UserID, token.TokenHash, token.ExpiresAt, token.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM refresh_tokens WHERE id IN")).
+		WithArgs(token.UserID, 5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	evicted, err := repo.CreateWithLimit(token, 5)
+	if err != nil {
+		t.Fatalf("CreateWithLimit returned error: %v", err)
+	}
+	if evicted != 2 {
+		t.Errorf("Expected 2 tokens evicted, got %d", evicted)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRefreshTokenRepository_CreateWithLimitRollsBackWhenEvictionFails(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewRefreshTokenRepository(db)
+
+	token := &models.RefreshToken{ID: uuid.New(), UserID: uuid.New(), TokenHash: "token", ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users WHERE id = $1 FOR UPDATE")).
+		WithArgs(token.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(token.UserID))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO refresh_tokens")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM refresh_tokens WHERE id IN")).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	if _, err := repo.CreateWithLimit(token, 5); err == nil {
+		t.Fatal("Expected CreateWithLimit to fail")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
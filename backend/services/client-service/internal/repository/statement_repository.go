@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// statementDeliveryColumns is the column list scanned by
+// scanStatementDelivery
+const statementDeliveryColumns = `id, user_id, period, status, attempts, COALESCE(last_error, ''), next_attempt_at, sent_at, requested_by, created_at, updated_at`
+
+// StatementRepositoryImpl handles all database operations related to
+// statement email subscriptions and deliveries
+type StatementRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewStatementRepository creates a new statement repository
+func NewStatementRepository(db *PostgresDB) StatementRepository {
+	return &StatementRepositoryImpl{db: db}
+}
+
+// scanStatementDelivery scans a single delivery selected with
+// statementDeliveryColumns
+func scanStatementDelivery(row rowScanner) (*models.StatementDelivery, error) {
+	delivery := &models.StatementDelivery{}
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.UserID,
+		&delivery.Period,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.LastError,
+		&delivery.NextAttemptAt,
+		&delivery.SentAt,
+		&delivery.RequestedBy,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// GetSubscription retrieves a user's statement subscription
+func (r *StatementRepositoryImpl) GetSubscription(userID uuid.UUID) (*models.StatementSubscription, error) {
+	query := `
+		SELECT user_id, delivery_day, created_at, updated_at
+		FROM statement_subscriptions
+		WHERE user_id = $1`
+
+	subscription := &models.StatementSubscription{}
+	err := r.db.QueryRow(query, userID).Scan(&subscription.UserID, &subscription.DeliveryDay, &subscription.CreatedAt, &subscription.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("statement subscription not found")
+		}
+		return nil, fmt.Errorf("failed to get statement subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// SaveSubscription creates a user's subscription or changes its delivery
+// day, filling in its timestamps
+func (r *StatementRepositoryImpl) SaveSubscription(subscription *models.StatementSubscription) error {
+	query := `
+		INSERT INTO statement_subscriptions (user_id, delivery_day, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET delivery_day = EXCLUDED.delivery_day, updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRow(query, subscription.UserID, subscription.DeliveryDay, time.Now()).Scan(&subscription.CreatedAt, &subscription.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save statement subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscription removes a user's subscription, reporting whether they
+// had one
+func (r *StatementRepositoryImpl) DeleteSubscription(userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM statement_subscriptions WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete statement subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// ListDueSubscriptions retrieves up to limit subscriptions of users who are
+// not deleted, whose delivery day is day or earlier and who have no
+// delivery for period yet
+func (r *StatementRepositoryImpl) ListDueSubscriptions(period string, day, limit int) ([]models.StatementSubscription, error) {
+	query := `
+		SELECT s.user_id, s.delivery_day, s.created_at, s.updated_at
+		FROM statement_subscriptions s
+		JOIN users u ON u.id = s.user_id AND u.deleted_at IS NULL
+		WHERE s.delivery_day <= $2
+			AND NOT EXISTS (SELECT 1 FROM statement_deliveries d WHERE d.user_id = s.user_id AND d.period = $1)
+		ORDER BY s.user_id
+		LIMIT $3`
+
+	rows, err := r.db.Query(query, period, day, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statement subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []models.StatementSubscription
+	for rows.Next() {
+		var s models.StatementSubscription
+		if err := rows.Scan(&s.UserID, &s.DeliveryDay, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan statement subscription row: %w", err)
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over statement subscription rows: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// CreateDelivery records a delivery and reports whether it was created. It
+// is not when the user already has one for the period.
+func (r *StatementRepositoryImpl) CreateDelivery(delivery *models.StatementDelivery) (bool, error) {
+	query := `
+		INSERT INTO statement_deliveries (id, user_id, period, status, attempts, next_attempt_at, requested_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		ON CONFLICT (user_id, period) DO NOTHING`
+
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = time.Now()
+	}
+	delivery.UpdatedAt = delivery.CreatedAt
+
+	result, err := r.db.Exec(query, delivery.ID, delivery.UserID, delivery.Period, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.RequestedBy, delivery.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create statement delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetDelivery retrieves a user's delivery for a period
+func (r *StatementRepositoryImpl) GetDelivery(userID uuid.UUID, period string) (*models.StatementDelivery, error) {
+	query := `SELECT ` + statementDeliveryColumns + ` FROM statement_deliveries WHERE user_id = $1 AND period = $2`
+
+	delivery, err := scanStatementDelivery(r.db.QueryRow(query, userID, period))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("statement delivery not found")
+		}
+		return nil, fmt.Errorf("failed to get statement delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// ClaimDueDeliveries claims up to limit deliveries whose next attempt is
+// due by now, longest waiting first: pending ones, and sending ones whose
+// claim has run out. They are marked sending until lease from now, so no
+// other replica claims them while they are sent. Rows another replica is
+// claiming are skipped.
+func (r *StatementRepositoryImpl) ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.StatementDelivery, error) {
+	query := `
+		UPDATE statement_deliveries
+		SET status = $4, next_attempt_at = $2, updated_at = $1
+		WHERE id IN (
+			SELECT id FROM statement_deliveries
+			WHERE status IN ($3, $4) AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + statementDeliveryColumns
+
+	rows, err := r.db.Query(query, now, now.Add(lease), models.StatementDeliveryPending, models.StatementDeliverySending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim statement deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.StatementDelivery
+	for rows.Next() {
+		delivery, err := scanStatementDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan statement delivery row: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over statement delivery rows: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDelivery claims a delivery an admin asked to send now, whatever its
+// next attempt, marking it sending until lease from now. It returns nil
+// when the delivery was already sent or is being sent.
+func (r *StatementRepositoryImpl) ClaimDelivery(id uuid.UUID, requestedBy uuid.UUID, now time.Time, lease time.Duration) (*models.StatementDelivery, error) {
+	query := `
+		UPDATE statement_deliveries
+		SET status = $5, next_attempt_at = $3, requested_by = $4, updated_at = $2
+		WHERE id = $1 AND status <> $6 AND (status <> $5 OR next_attempt_at <= $2)
+		RETURNING ` + statementDeliveryColumns
+
+	delivery, err := scanStatementDelivery(r.db.QueryRow(query, id, now, now.Add(lease), requestedBy, models.StatementDeliverySending, models.StatementDeliverySent))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim statement delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// UpdateDelivery writes the outcome of an attempt: a delivery's status,
+// attempts, last error, next attempt and sent time
+func (r *StatementRepositoryImpl) UpdateDelivery(delivery *models.StatementDelivery) error {
+	query := `
+		UPDATE statement_deliveries
+		SET status = $2, attempts = $3, last_error = NULLIF($4, ''), next_attempt_at = $5, sent_at = $6, updated_at = $7
+		WHERE id = $1`
+
+	delivery.UpdatedAt = time.Now()
+	_, err := r.db.Exec(query, delivery.ID, delivery.Status, delivery.Attempts, delivery.LastError, delivery.NextAttemptAt, delivery.SentAt, delivery.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update statement delivery: %w", err)
+	}
+	return nil
+}
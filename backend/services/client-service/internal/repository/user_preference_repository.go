@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// UserPreferenceRepositoryImpl handles all database operations related to locale and time zone preferences
+type UserPreferenceRepositoryImpl struct {
+	db *PostgresDB
+}
+
+// NewUserPreferenceRepository creates a new user preference repository
+func NewUserPreferenceRepository(db *PostgresDB) UserPreferenceRepository {
+	return &UserPreferenceRepositoryImpl{db: db}
+}
+
+// GetByUserID retrieves a user's stored preferences. Preferences the user
+// never set are empty.
+func (r *UserPreferenceRepositoryImpl) GetByUserID(userID uuid.UUID) (*models.UserPreferences, error) {
+	query := `
+		SELECT COALESCE(locale, ''), COALESCE(timezone, '')
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	var preferences models.UserPreferences
+	if err := r.db.QueryRow(query, userID).Scan(&preferences.Locale, &preferences.TimeZone); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get user preferences: %w", err)
+	}
+
+	return &preferences, nil
+}
+
+// Update writes the supplied preferences, leaving the others unchanged.
+// Empty strings clear a preference so the default applies.
+func (r *UserPreferenceRepositoryImpl) Update(userID uuid.UUID, update models.UserPreferencesUpdate) error {
+	query := `
+		UPDATE users
+		SET locale = CASE WHEN $1 THEN NULLIF($2, '') ELSE locale END,
+			timezone = CASE WHEN $3 THEN NULLIF($4, '') ELSE timezone END,
+			updated_at = $5
+		WHERE id = $6 AND deleted_at IS NULL`
+
+	locale, setLocale := optionalString(update.Locale)
+	timeZone, setTimeZone := optionalString(update.TimeZone)
+
+	result, err := r.db.Exec(query, setLocale, locale, setTimeZone, timeZone, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user preferences: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found for update")
+	}
+
+	return nil
+}
+
+// optionalString returns the value of s and whether it was supplied
+func optionalString(s *string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	return *s, true
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func TestUserPreferenceRepository_UpdateLeavesUnsuppliedPreferences(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserPreferenceRepository(db)
+	userID := uuid.New()
+	locale := "af"
+
+	mock.ExpectExec(regexp.QuoteMeta("SET locale = CASE WHEN $1 THEN NULLIF($2, '') ELSE locale END")).
+		WithArgs(true, "af", false, "", sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.Update(userID, models.UserPreferencesUpdate{Locale: &locale}); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
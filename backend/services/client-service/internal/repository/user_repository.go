@@ -2,13 +2,114 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"microbank/client-service/internal/models"
+	"microbank/pkg/events"
 )
 
+// ErrLastAdmin is returned by UpdateRole, when nothing is written, if
+// revoking the admin role would leave no admin who can sign in
+var ErrLastAdmin = errors.New("the last remaining admin cannot be demoted")
+
+// userColumns lists the users table columns in the order scanUser expects
+const userColumns = `id, email, name, COALESCE(password_hash, ''), is_blacklisted,
+		COALESCE(blacklist_reason, ''), blacklist_expires_at, is_admin,
+		COALESCE(pending_email, ''), COALESCE(pending_email_token, ''), email_change_requested_at,
+		COALESCE(email_change_cancel_token, ''), COALESCE(previous_email, ''),
+		email_verified_at, COALESCE(phone_number, ''), phone_verified_at, date_of_birth, COALESCE(address_line1, ''),
+		COALESCE(address_city, ''), COALESCE(address_country, ''), COALESCE(timezone, ''),
+		COALESCE(oauth_provider, ''), COALESCE(oauth_subject, ''),
+		token_version, must_reset_password, must_set_password, kyc_status, registration_source, last_login_at, deleted_at, self_deleted, created_at, updated_at,
+		` + rolesColumn
+
+// rolesColumn selects a user's roles, in name order, as an array
+const rolesColumn = `ARRAY(SELECT role FROM users_roles WHERE users_roles.user_id = users.id ORDER BY role)`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// rowQuerier is implemented by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// scanUser scans a single user selected with userColumns
+func scanUser(row rowScanner) (*models.User, error) {
+	user := &models.User{}
+	var blacklistExpiresAt, emailChangeRequestedAt, emailVerifiedAt, phoneVerifiedAt, dateOfBirth, lastLoginAt, deletedAt sql.NullTime
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.PasswordHash,
+		&user.IsBlacklisted,
+		&user.BlacklistReason,
+		&blacklistExpiresAt,
+		&user.IsAdmin,
+		&user.PendingEmail,
+		&user.PendingEmailToken,
+		&emailChangeRequestedAt,
+		&user.EmailChangeCancelToken,
+		&user.PreviousEmail,
+		&emailVerifiedAt,
+		&user.PhoneNumber,
+		&phoneVerifiedAt,
+		&dateOfBirth,
+		&user.AddressLine1,
+		&user.AddressCity,
+		&user.AddressCountry,
+		&user.TimeZone,
+		&user.OAuthProvider,
+		&user.OAuthSubject,
+		&user.TokenVersion,
+		&user.MustResetPassword,
+		&user.MustSetPassword,
+		&user.KYCStatus,
+		&user.RegistrationSource,
+		&lastLoginAt,
+		&deletedAt,
+		&user.SelfDeleted,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		pq.Array(&user.Roles),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if blacklistExpiresAt.Valid {
+		user.BlacklistExpiresAt = &blacklistExpiresAt.Time
+	}
+	if emailChangeRequestedAt.Valid {
+		user.EmailChangeRequestedAt = &emailChangeRequestedAt.Time
+	}
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	if phoneVerifiedAt.Valid {
+		user.PhoneVerifiedAt = &phoneVerifiedAt.Time
+	}
+	if dateOfBirth.Valid {
+		user.DateOfBirth = &dateOfBirth.Time
+	}
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+
+	return user, nil
+}
+
 // UserRepositoryImpl handles all database operations related to users
 type UserRepositoryImpl struct {
 	db *PostgresDB
@@ -19,18 +120,116 @@ func NewUserRepository(db *PostgresDB) UserRepository {
 	return &UserRepositoryImpl{db: db}
 }
 
-// CreateUser creates a new user in the database
+// CreateUser creates a new user in the database and records a
+// user.registered event in the same transaction
 func (r *UserRepositoryImpl) CreateUser(user *models.User) error {
+	method := "password"
+	if user.OAuthProvider != "" {
+		method = user.OAuthProvider
+	}
+
+	return r.db.withTx(func(tx *sql.Tx) error {
+		if err := insertUser(tx, user); err != nil {
+			return err
+		}
+
+		return writeUserEvent(tx, events.TypeUserRegistered, events.UserRegistered{
+			UserID:       user.ID,
+			Method:       method,
+			RegisteredAt: user.CreatedAt.UTC(),
+		})
+	})
+}
+
+// CreateInvitedUser creates a new user and marks the invitation they
+// registered with as used, in one transaction. It fails without creating
+// the user if the invitation has been used, revoked or has expired. A
+// user.registered event is recorded in the same transaction.
+func (r *UserRepositoryImpl) CreateInvitedUser(user *models.User, invitationID uuid.UUID) error {
+	query := `
+		UPDATE invitations
+		SET used_by = $1, used_at = $2
+		WHERE id = $3 AND used_at IS NULL AND revoked_at IS NULL AND expires_at > $2`
+
+	return r.db.withTx(func(tx *sql.Tx) error {
+		if err := insertUser(tx, user); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(query, user.ID, user.CreatedAt, invitationID)
+		if err != nil {
+			return fmt.Errorf("failed to mark invitation used: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("invitation is no longer available")
+		}
+
+		return writeUserEvent(tx, events.TypeUserRegistered, events.UserRegistered{
+			UserID:       user.ID,
+			Method:       "invitation",
+			RegisteredAt: user.CreatedAt.UTC(),
+		})
+	})
+}
+
+// CreateUserByAdmin creates a user on a customer's behalf. The audit entry
+// recording the admin who created them and a user.registered event are
+// written in the same transaction.
+func (r *UserRepositoryImpl) CreateUserByAdmin(user *models.User, audit *models.AuditLogEntry) error {
+	return r.db.withTx(func(tx *sql.Tx) error {
+		if err := insertUser(tx, user); err != nil {
+			return err
+		}
+
+		if err := insertAuditLogEntry(tx, audit); err != nil {
+			return err
+		}
+
+		return writeUserEvent(tx, events.TypeUserRegistered, events.UserRegistered{
+			UserID:       user.ID,
+			Method:       "admin",
+			RegisteredAt: user.CreatedAt.UTC(),
+		})
+	})
+}
+
+// writeUserEvent records a user lifecycle event in the outbox using tx, so
+// it is published only if the change it describes commits
+func writeUserEvent(tx *sql.Tx, eventType string, payload any) error {
+	event, err := events.New(eventType, events.SourceClientService, payload)
+	if err != nil {
+		return err
+	}
+
+	return events.WriteOutbox(tx, event)
+}
+
+// insertUser writes a new user row through db or a transaction. Users
+// created through an OAuth provider or by staff have no password hash,
+// which is stored as NULL. Users without a registration source are recorded as having
+// signed up themselves, like the column default.
+func insertUser(q rowQuerier, user *models.User) error {
 	query := `
-		INSERT INTO users (id, email, name, password_hash, is_blacklisted, is_admin, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO users (id, email, name, password_hash, is_blacklisted, is_admin,
+			email_verified_at, oauth_provider, oauth_subject, must_set_password, registration_source,
+			created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12, $13)
 		RETURNING id`
 
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
+	if user.RegistrationSource == "" {
+		user.RegistrationSource = models.RegistrationSourceSelf
+	}
 
-	err := r.db.QueryRow(
+	err := q.QueryRow(
 		query,
 		user.ID,
 		user.Email,
@@ -38,6 +237,11 @@ func (r *UserRepositoryImpl) CreateUser(user *models.User) error {
 		user.PasswordHash,
 		user.IsBlacklisted,
 		user.IsAdmin,
+		user.EmailVerifiedAt,
+		user.OAuthProvider,
+		user.OAuthSubject,
+		user.MustSetPassword,
+		user.RegistrationSource,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID)
@@ -49,24 +253,13 @@ func (r *UserRepositoryImpl) CreateUser(user *models.User) error {
 	return nil
 }
 
-// GetUserByID retrieves a user by their ID
+// GetUserByID retrieves an active (not soft-deleted) user by their ID
 func (r *UserRepositoryImpl) GetUserByID(id uuid.UUID) (*models.User, error) {
 	query := `
-		SELECT id, email, name, password_hash, is_blacklisted, is_admin, created_at, updated_at
-		FROM users WHERE id = $1`
-
-	user := &models.User{}
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Name,
-		&user.PasswordHash,
-		&user.IsBlacklisted,
-		&user.IsAdmin,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+		SELECT ` + userColumns + `
+		FROM users WHERE id = $1 AND deleted_at IS NULL`
 
+	user, err := scanUser(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("user not found: %w", err)
@@ -77,24 +270,13 @@ func (r *UserRepositoryImpl) GetUserByID(id uuid.UUID) (*models.User, error) {
 	return user, nil
 }
 
-// GetUserByEmail retrieves a user by their email address
+// GetUserByEmail retrieves an active (not soft-deleted) user by their email address
 func (r *UserRepositoryImpl) GetUserByEmail(email string) (*models.User, error) {
 	query := `
-		SELECT id, email, name, password_hash, is_blacklisted, is_admin, created_at, updated_at
-		FROM users WHERE email = $1`
-
-	user := &models.User{}
-	err := r.db.QueryRow(query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Name,
-		&user.PasswordHash,
-		&user.IsBlacklisted,
-		&user.IsAdmin,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+		SELECT ` + userColumns + `
+		FROM users WHERE email = $1 AND deleted_at IS NULL`
 
+	user, err := scanUser(r.db.QueryRow(query, email))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("user not found: %w", err)
@@ -105,16 +287,104 @@ func (r *UserRepositoryImpl) GetUserByEmail(email string) (*models.User, error)
 	return user, nil
 }
 
-// UpdateUser updates an existing user's information
-func (r *UserRepositoryImpl) UpdateUser(user *models.User) error {
+// GetUserByVerifiedPhone retrieves the active (not soft-deleted) user who
+// has verified a phone number. Phone numbers are not unique, so a number
+// verified by more than one user matches nobody.
+func (r *UserRepositoryImpl) GetUserByVerifiedPhone(phoneNumber string) (*models.User, error) {
 	query := `
-		UPDATE users 
-		SET name = $1, updated_at = $2
-		WHERE id = $3`
+		SELECT ` + userColumns + `
+		FROM users WHERE phone_number = $1 AND phone_verified_at IS NOT NULL AND deleted_at IS NULL
+		LIMIT 2`
+
+	rows, err := r.db.Query(query, phoneNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by phone number: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user row: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over user rows: %w", err)
+	}
+
+	if len(users) != 1 {
+		return nil, fmt.Errorf("user not found: %d users verified this phone number", len(users))
+	}
+
+	return users[0], nil
+}
+
+// GetUserByOAuthSubject retrieves an active (not soft-deleted) user by the
+// subject identifier an OAuth provider issued for them
+func (r *UserRepositoryImpl) GetUserByOAuthSubject(provider, subject string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users WHERE oauth_provider = $1 AND oauth_subject = $2 AND deleted_at IS NULL`
+
+	user, err := scanUser(r.db.QueryRow(query, provider, subject))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get user by OAuth subject: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetDeletedUserByID retrieves a soft-deleted user by their ID
+func (r *UserRepositoryImpl) GetDeletedUserByID(id uuid.UUID) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	user, err := scanUser(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("deleted user not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get deleted user by ID: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetDeletedUserByEmail retrieves a soft-deleted user by their email address
+func (r *UserRepositoryImpl) GetDeletedUserByEmail(email string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users WHERE email = $1 AND deleted_at IS NOT NULL`
+
+	user, err := scanUser(r.db.QueryRow(query, email))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("deleted user not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get deleted user by email: %w", err)
+	}
+
+	return user, nil
+}
 
-	user.UpdatedAt = time.Now()
+// UpdateUser applies a partial profile update, writing only the supplied
+// columns so concurrent edits to other fields are not overwritten
+func (r *UserRepositoryImpl) UpdateUser(userID uuid.UUID, profile models.UserProfile) error {
+	set, args := buildProfileUpdate(profile, time.Now())
+	args = append(args, userID)
+	query := `
+		UPDATE users 
+		SET ` + set + fmt.Sprintf(`
+		WHERE id = $%d AND deleted_at IS NULL`, len(args))
 
-	result, err := r.db.Exec(query, user.Name, user.UpdatedAt, user.ID)
+	result, err := r.db.Exec(query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
@@ -131,16 +401,51 @@ func (r *UserRepositoryImpl) UpdateUser(user *models.User) error {
 	return nil
 }
 
-// UpdateBlacklistStatus updates a user's blacklist status
-func (r *UserRepositoryImpl) UpdateBlacklistStatus(userID uuid.UUID, isBlacklisted bool) error {
+// buildProfileUpdate builds the SET clause and arguments for a partial
+// profile update. Only supplied fields are written, and empty strings clear
+// optional columns.
+func buildProfileUpdate(profile models.UserProfile, now time.Time) (string, []interface{}) {
+	var assignments []string
+	var args []interface{}
+
+	set := func(assignment string, value *string) {
+		if value == nil {
+			return
+		}
+		args = append(args, *value)
+		assignments = append(assignments, fmt.Sprintf(assignment, len(args)))
+	}
+
+	set("name = $%d", profile.Name)
+	// A changed phone number must be verified again
+	set("phone_number = NULLIF($%[1]d, ''), "+
+		"phone_verified_at = CASE WHEN phone_number IS NOT DISTINCT FROM NULLIF($%[1]d, '') THEN phone_verified_at END", profile.PhoneNumber)
+	set("date_of_birth = NULLIF($%d, '')::date", profile.DateOfBirth)
+	if profile.Address != nil {
+		set("address_line1 = NULLIF($%d, '')", profile.Address.Line1)
+		set("address_city = NULLIF($%d, '')", profile.Address.City)
+		set("address_country = NULLIF($%d, '')", profile.Address.Country)
+	}
+	set("timezone = NULLIF($%d, '')", profile.TimeZone)
+
+	args = append(args, now)
+	assignments = append(assignments, fmt.Sprintf("updated_at = $%d", len(args)))
+
+	return strings.Join(assignments, ", "), args
+}
+
+// MarkPhoneVerified records that a user confirmed their phone number. It
+// only succeeds while the number on file is still the one that was
+// verified, so a change made in the meantime is never marked verified.
+func (r *UserRepositoryImpl) MarkPhoneVerified(userID uuid.UUID, phoneNumber string) error {
 	query := `
 		UPDATE users 
-		SET is_blacklisted = $1, updated_at = $2
-		WHERE id = $3`
+		SET phone_verified_at = $1, updated_at = $1
+		WHERE id = $2 AND phone_number = $3 AND deleted_at IS NULL`
 
-	result, err := r.db.Exec(query, isBlacklisted, time.Now(), userID)
+	result, err := r.db.Exec(query, time.Now(), userID, phoneNumber)
 	if err != nil {
-		return fmt.Errorf("failed to update blacklist status: %w", err)
+		return fmt.Errorf("failed to mark phone verified: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -149,58 +454,51 @@ func (r *UserRepositoryImpl) UpdateBlacklistStatus(userID uuid.UUID, isBlacklist
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found for blacklist update")
+		return fmt.Errorf("phone number changed before verification")
 	}
 
 	return nil
 }
 
-// GetAllUsers retrieves all users (for admin purposes)
-func (r *UserRepositoryImpl) GetAllUsers() ([]models.User, error) {
+// LinkOAuthIdentity links an OAuth provider subject to a user, replacing any
+// identity linked before. Linking with a verified provider email also marks
+// the user's email verified.
+func (r *UserRepositoryImpl) LinkOAuthIdentity(userID uuid.UUID, provider, subject string, emailVerified bool) error {
 	query := `
-		SELECT id, email, name, password_hash, is_blacklisted, is_admin, created_at, updated_at
-		FROM users
-		ORDER BY created_at DESC`
+		UPDATE users 
+		SET oauth_provider = $1, oauth_subject = $2,
+			email_verified_at = CASE WHEN $3 THEN COALESCE(email_verified_at, $4) ELSE email_verified_at END,
+			updated_at = $4
+		WHERE id = $5 AND deleted_at IS NULL`
 
-	rows, err := r.db.Query(query)
+	result, err := r.db.Exec(query, provider, subject, emailVerified, time.Now(), userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query users: %w", err)
+		return fmt.Errorf("failed to link OAuth identity: %w", err)
 	}
-	defer rows.Close()
 
-	var users []models.User
-	for rows.Next() {
-		var user models.User
-		err := rows.Scan(
-			&user.ID,
-			&user.Email,
-			&user.Name,
-			&user.PasswordHash,
-			&user.IsBlacklisted,
-			&user.IsAdmin,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan user row: %w", err)
-		}
-		users = append(users, user)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over user rows: %w", err)
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found for OAuth link")
 	}
 
-	return users, nil
+	return nil
 }
 
-// DeleteUser deletes a user from the database
-func (r *UserRepositoryImpl) DeleteUser(id uuid.UUID) error {
-	query := `DELETE FROM users WHERE id = $1`
+// UpdatePassword replaces a user's password hash, lifting any requirement
+// to reset or set a password before logging in
+func (r *UserRepositoryImpl) UpdatePassword(userID uuid.UUID, passwordHash string) error {
+	query := `
+		UPDATE users 
+		SET password_hash = $1, must_reset_password = FALSE, must_set_password = FALSE, updated_at = $2
+		WHERE id = $3`
 
-	result, err := r.db.Exec(query, id)
+	result, err := r.db.Exec(query, passwordHash, time.Now(), userID)
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return fmt.Errorf("failed to update password: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -209,21 +507,904 @@ func (r *UserRepositoryImpl) DeleteUser(id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found for deletion")
+		return fmt.Errorf("user not found for password update")
 	}
 
 	return nil
 }
 
-// UserExists checks if a user with the given email exists
-func (r *UserRepositoryImpl) UserExists(email string) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
+// UpdateBlacklistStatus updates a user's blacklist status along with the
+// reason and optional expiry, which are cleared when lifting a blacklist.
+// The user's token version is bumped so issued access tokens become stale.
+// The optional history and audit entries and a user.blacklisted or
+// user.unblacklisted event are written in the same transaction.
+func (r *UserRepositoryImpl) UpdateBlacklistStatus(userID uuid.UUID, isBlacklisted bool, reason string, expiresAt *time.Time, entry *models.BlacklistEntry, audit *models.AuditLogEntry) error {
+	query := `
+		UPDATE users 
+		SET is_blacklisted = $1, blacklist_reason = NULLIF($2, ''), blacklist_expires_at = $3,
+			token_version = token_version + 1, updated_at = $4
+		WHERE id = $5`
 
-	var exists bool
-	err := r.db.QueryRow(query, email).Scan(&exists)
+	now := time.Now()
+	return r.db.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(query, isBlacklisted, reason, expiresAt, now, userID)
+		if err != nil {
+			return fmt.Errorf("failed to update blacklist status: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("user not found for blacklist update")
+		}
+
+		if err := writeBlacklistEvent(tx, userID, isBlacklisted, reason, expiresAt, now); err != nil {
+			return err
+		}
+		if err := insertBlacklistEntry(tx, entry); err != nil {
+			return err
+		}
+
+		return insertAuditLogEntry(tx, audit)
+	})
+}
+
+// UpdateBlacklistStatusBatch applies a blacklist status change to several
+// users in one transaction and reports the outcome for each ID. Deleted or
+// unknown users are not found, and users already in the requested state are
+// left alone. Each changed user gets its entries from history and audits
+// and a lifecycle event in the same transaction.
+func (r *UserRepositoryImpl) UpdateBlacklistStatusBatch(userIDs []uuid.UUID, isBlacklisted bool, reason string, expiresAt *time.Time, history map[uuid.UUID]*models.BlacklistEntry, audits map[uuid.UUID]*models.AuditLogEntry) (map[uuid.UUID]string, error) {
+	selectQuery := `
+		SELECT id, is_blacklisted FROM users
+		WHERE id = ANY($1) AND deleted_at IS NULL
+		FOR UPDATE`
+
+	updateQuery := `
+		UPDATE users 
+		SET is_blacklisted = $1, blacklist_reason = NULLIF($2, ''), blacklist_expires_at = $3,
+			token_version = token_version + 1, updated_at = $4
+		WHERE id = ANY($5)`
+
+	unchanged := models.BlacklistBatchAlreadyBlacklisted
+	if !isBlacklisted {
+		unchanged = models.BlacklistBatchNotBlacklisted
+	}
+
+	results := make(map[uuid.UUID]string, len(userIDs))
+	for _, id := range userIDs {
+		results[id] = models.BlacklistBatchNotFound
+	}
+
+	now := time.Now()
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		rows, err := tx.Query(selectQuery, pq.Array(userIDs))
+		if err != nil {
+			return fmt.Errorf("failed to lock users for blacklist update: %w", err)
+		}
+		defer rows.Close()
+
+		var changed []uuid.UUID
+		for rows.Next() {
+			var id uuid.UUID
+			var current bool
+			if err := rows.Scan(&id, &current); err != nil {
+				return fmt.Errorf("failed to scan user row: %w", err)
+			}
+			if current == isBlacklisted {
+				results[id] = unchanged
+				continue
+			}
+			results[id] = models.BlacklistBatchOK
+			changed = append(changed, id)
+		}
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("error iterating over user rows: %w", err)
+		}
+
+		if len(changed) == 0 {
+			return nil
+		}
+
+		if _, err := tx.Exec(updateQuery, isBlacklisted, reason, expiresAt, now, pq.Array(changed)); err != nil {
+			return fmt.Errorf("failed to update blacklist status: %w", err)
+		}
+
+		for _, id := range changed {
+			if err := writeBlacklistEvent(tx, id, isBlacklisted, reason, expiresAt, now); err != nil {
+				return err
+			}
+			if err := insertBlacklistEntry(tx, history[id]); err != nil {
+				return err
+			}
+			if err := insertAuditLogEntry(tx, audits[id]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
-		return false, fmt.Errorf("failed to check if user exists: %w", err)
+		return nil, err
 	}
 
-	return exists, nil
+	return results, nil
+}
+
+// writeBlacklistEvent records a user.blacklisted or user.unblacklisted event
+// for a status change made by an admin
+func writeBlacklistEvent(tx *sql.Tx, userID uuid.UUID, isBlacklisted bool, reason string, expiresAt *time.Time, at time.Time) error {
+	if isBlacklisted {
+		return writeUserEvent(tx, events.TypeUserBlacklisted, events.UserBlacklisted{
+			UserID:        userID,
+			Reason:        reason,
+			ExpiresAt:     expiresAt,
+			BlacklistedAt: at.UTC(),
+		})
+	}
+
+	return writeUserEvent(tx, events.TypeUserUnblacklisted, events.UserUnblacklisted{
+		UserID:          userID,
+		UnblacklistedAt: at.UTC(),
+	})
+}
+
+// LiftExpiredBlacklists removes every blacklist whose expiry has passed and
+// returns the IDs of the users it reinstated. A history entry with
+// models.BlacklistReasonExpired and a user.unblacklisted event are recorded
+// for each in the same transaction.
+func (r *UserRepositoryImpl) LiftExpiredBlacklists(now time.Time) ([]uuid.UUID, error) {
+	query := `
+		UPDATE users 
+		SET is_blacklisted = false, blacklist_reason = NULL, blacklist_expires_at = NULL,
+			token_version = token_version + 1, updated_at = $1
+		WHERE is_blacklisted = true AND blacklist_expires_at IS NOT NULL AND blacklist_expires_at <= $1
+		RETURNING id`
+
+	var userIDs []uuid.UUID
+	err := r.db.withTx(func(tx *sql.Tx) error {
+		rows, err := tx.Query(query, now)
+		if err != nil {
+			return fmt.Errorf("failed to lift expired blacklists: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				return fmt.Errorf("failed to scan lifted user id: %w", err)
+			}
+			userIDs = append(userIDs, id)
+		}
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("error iterating over lifted user rows: %w", err)
+		}
+
+		for _, id := range userIDs {
+			err := writeUserEvent(tx, events.TypeUserUnblacklisted, events.UserUnblacklisted{
+				UserID:          id,
+				Expired:         true,
+				UnblacklistedAt: now.UTC(),
+			})
+			if err != nil {
+				return err
+			}
+
+			err = insertBlacklistEntry(tx, &models.BlacklistEntry{
+				ID:        uuid.New(),
+				UserID:    id,
+				Action:    models.BlacklistActionRemove,
+				Reason:    models.BlacklistReasonExpired,
+				CreatedAt: now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// UpdateRole grants or revokes a role and bumps the user's token version so
+// access tokens carrying the old roles become stale. The admin role is
+// mirrored in is_admin. The optional audit entry is written in the same
+// transaction. Revoking the admin role locks the admins who can sign in,
+// so admins demoting each other at once cannot both succeed, and fails
+// with ErrLastAdmin when the user is the last of them.
+func (r *UserRepositoryImpl) UpdateRole(userID uuid.UUID, role string, grant bool, audit *models.AuditLogEntry) error {
+	roleQuery := `DELETE FROM users_roles WHERE user_id = $1 AND role = $2`
+	if grant {
+		roleQuery = `INSERT INTO users_roles (user_id, role) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	}
+
+	query := `
+		UPDATE users 
+		SET is_admin = CASE WHEN $1 = 'admin' THEN $2 ELSE is_admin END,
+			token_version = token_version + 1, updated_at = $3
+		WHERE id = $4`
+
+	return r.db.withTx(func(tx *sql.Tx) error {
+		if role == "admin" && !grant {
+			if err := checkOtherAdmin(tx, userID); err != nil {
+				return err
+			}
+		}
+
+		result, err := tx.Exec(query, role, grant, time.Now(), userID)
+		if err != nil {
+			return fmt.Errorf("failed to update roles: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("user not found for role update")
+		}
+
+		if _, err := tx.Exec(roleQuery, userID, role); err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+
+		return insertAuditLogEntry(tx, audit)
+	})
+}
+
+// GetTokenVersion retrieves an active user's current token version
+func (r *UserRepositoryImpl) GetTokenVersion(userID uuid.UUID) (int, error) {
+	query := `SELECT token_version FROM users WHERE id = $1 AND deleted_at IS NULL`
+
+	var version int
+	if err := r.db.QueryRow(query, userID).Scan(&version); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("user not found")
+		}
+		return 0, fmt.Errorf("failed to get token version: %w", err)
+	}
+
+	return version, nil
+}
+
+// GetUserStatuses retrieves the status of the given users, including
+// soft-deleted ones. IDs without a user are left out of the map.
+func (r *UserRepositoryImpl) GetUserStatuses(userIDs []uuid.UUID) (map[uuid.UUID]models.UserStatus, error) {
+	query := `
+		SELECT id, is_blacklisted, is_admin, deleted_at IS NOT NULL, email_verified_at IS NOT NULL, token_version,
+			kyc_status, ` + rolesColumn + `, COALESCE(timezone, 'UTC')
+		FROM users
+		WHERE id = ANY($1)`
+
+	rows, err := r.db.Query(query, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user statuses: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := make(map[uuid.UUID]models.UserStatus, len(userIDs))
+	for rows.Next() {
+		status := models.UserStatus{Exists: true}
+		if err := rows.Scan(&status.UserID, &status.IsBlacklisted, &status.IsAdmin, &status.IsDeleted, &status.EmailVerified, &status.TokenVersion, &status.KYCStatus, pq.Array(&status.Roles), &status.TimeZone); err != nil {
+			return nil, fmt.Errorf("failed to scan user status row: %w", err)
+		}
+		statuses[status.UserID] = status
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over user status rows: %w", err)
+	}
+
+	return statuses, nil
+}
+
+// GetUserDetails retrieves the email and name of the given users, including
+// soft-deleted ones. IDs without a user are left out of the map.
+func (r *UserRepositoryImpl) GetUserDetails(userIDs []uuid.UUID) (map[uuid.UUID]models.UserDetail, error) {
+	query := `SELECT id, email, name, deleted_at IS NOT NULL FROM users WHERE id = ANY($1)`
+
+	rows, err := r.db.Query(query, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user details: %w", err)
+	}
+	defer rows.Close()
+
+	details := make(map[uuid.UUID]models.UserDetail, len(userIDs))
+	for rows.Next() {
+		detail := models.UserDetail{Exists: true}
+		if err := rows.Scan(&detail.UserID, &detail.Email, &detail.Name, &detail.IsDeleted); err != nil {
+			return nil, fmt.Errorf("failed to scan user detail row: %w", err)
+		}
+		details[detail.UserID] = detail
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over user detail rows: %w", err)
+	}
+
+	return details, nil
+}
+
+// IncrementTokenVersion bumps a user's token version so every access token
+// issued so far becomes stale
+func (r *UserRepositoryImpl) IncrementTokenVersion(userID uuid.UUID) error {
+	query := `UPDATE users SET token_version = token_version + 1, updated_at = $1 WHERE id = $2 AND deleted_at IS NULL`
+
+	result, err := r.db.Exec(query, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to increment token version: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found for token version update")
+	}
+
+	return nil
+}
+
+// RequirePasswordReset blocks a user's logins until they reset their
+// password, and bumps their token version so issued access tokens become
+// stale. UpdatePassword clears the flag.
+func (r *UserRepositoryImpl) RequirePasswordReset(userID uuid.UUID) error {
+	query := `
+		UPDATE users
+		SET must_reset_password = TRUE, token_version = token_version + 1, updated_at = $1
+		WHERE id = $2 AND deleted_at IS NULL`
+
+	result, err := r.db.Exec(query, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to require password reset: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found for password reset requirement")
+	}
+
+	return nil
+}
+
+// checkOtherAdmin locks, in ID order, the active admins who are not
+// blacklisted and returns ErrLastAdmin unless one of them is not userID.
+// Locked rows demoted by a concurrent transaction are re-checked once it
+// commits, so they no longer count.
+func checkOtherAdmin(tx *sql.Tx, userID uuid.UUID) error {
+	query := `
+		SELECT id FROM users
+		WHERE is_admin = true AND deleted_at IS NULL AND is_blacklisted = false
+		ORDER BY id
+		FOR UPDATE`
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return fmt.Errorf("failed to lock admins: %w", err)
+	}
+	defer rows.Close()
+
+	others := 0
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to scan admin row: %w", err)
+		}
+		if id != userID {
+			others++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over admin rows: %w", err)
+	}
+
+	if others == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// CountUsers counts active users in total, those registered since each of
+// the given times, blacklisted users and users with a verified email, in a
+// single pass over the table
+func (r *UserRepositoryImpl) CountUsers(today, thisWeek, thisMonth time.Time) (*models.UserCounts, error) {
+	query := `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE created_at >= $1),
+			COUNT(*) FILTER (WHERE created_at >= $2),
+			COUNT(*) FILTER (WHERE created_at >= $3),
+			COUNT(*) FILTER (WHERE is_blacklisted),
+			COUNT(*) FILTER (WHERE email_verified_at IS NOT NULL)
+		FROM users
+		WHERE deleted_at IS NULL`
+
+	var counts models.UserCounts
+	err := r.db.QueryRow(query, today, thisWeek, thisMonth).Scan(
+		&counts.Total,
+		&counts.NewToday,
+		&counts.NewThisWeek,
+		&counts.NewThisMonth,
+		&counts.Blacklisted,
+		&counts.EmailVerified,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return &counts, nil
+}
+
+// CountSignupsBySource counts the users who registered since the given
+// time by UTC week (starting Monday) and registration source, oldest week
+// first. Deleted users are counted too, since they still signed up in
+// their week.
+func (r *UserRepositoryImpl) CountSignupsBySource(since time.Time) ([]models.SignupCount, error) {
+	query := `
+		SELECT date_trunc('week', created_at AT TIME ZONE 'UTC') AS week, registration_source, COUNT(*)
+		FROM users
+		WHERE created_at >= $1
+		GROUP BY week, registration_source
+		ORDER BY week, registration_source`
+
+	rows, err := r.db.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count signups: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []models.SignupCount
+	for rows.Next() {
+		var count models.SignupCount
+		if err := rows.Scan(&count.WeekStart, &count.Source, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan signup count row: %w", err)
+		}
+		// The week is a timestamp without time zone, in UTC
+		count.WeekStart = time.Date(count.WeekStart.Year(), count.WeekStart.Month(), count.WeekStart.Day(), 0, 0, 0, 0, time.UTC)
+		counts = append(counts, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over signup count rows: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetAllUsers retrieves one page of users matching the options along with
+// the total number of matches (for admin purposes)
+func (r *UserRepositoryImpl) GetAllUsers(opts models.ListUsersOptions) ([]models.User, int, error) {
+	where, args := buildUserFilters(opts)
+
+	// Count all matches
+	var total int
+	countQuery := `SELECT COUNT(*) FROM users` + where
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	// Fetch the requested page
+	sortColumn, direction := userSortOrder(opts)
+	query := `
+		SELECT ` + userColumns + `
+		FROM users` + where + `
+		ORDER BY ` + sortColumn + ` ` + direction + `, id ` + direction + fmt.Sprintf(`
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := r.db.Query(query, append(args, opts.Limit, opts.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user row: %w", err)
+		}
+		users = append(users, *user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating over user rows: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// CountMatchingUsers counts the users matching the listing filters
+func (r *UserRepositoryImpl) CountMatchingUsers(opts models.ListUsersOptions) (int, error) {
+	where, args := buildUserFilters(opts)
+
+	var total int
+	query := `SELECT COUNT(*) FROM users` + where
+	if err := r.db.QueryRow(query, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetUsersAfter retrieves up to limit users matching the listing filters
+// that sort after the given user, or the first users when after is nil.
+// Paging options are ignored; callers walk the whole listing by passing
+// the last user of each batch, which stays cheap however deep they go.
+func (r *UserRepositoryImpl) GetUsersAfter(opts models.ListUsersOptions, after *models.User, limit int) ([]models.User, error) {
+	where, args := buildUserFilters(opts)
+	sortColumn, direction := userSortOrder(opts)
+
+	if after != nil {
+		comparison := ">"
+		if opts.SortDesc {
+			comparison = "<"
+		}
+		var sortValue interface{} = after.CreatedAt
+		if opts.SortBy == models.UserSortEmail {
+			sortValue = after.Email
+		}
+		args = append(args, sortValue, after.ID)
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", sortColumn, comparison, len(args)-1, len(args))
+	}
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users` + where + `
+		ORDER BY ` + sortColumn + ` ` + direction + `, id ` + direction + fmt.Sprintf(`
+		LIMIT $%d`, len(args)+1)
+
+	rows, err := r.db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user row: %w", err)
+		}
+		users = append(users, *user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over user rows: %w", err)
+	}
+
+	return users, nil
+}
+
+// userSortOrder returns the column and direction a listing is sorted by.
+// Ties are broken by id in the same direction.
+func userSortOrder(opts models.ListUsersOptions) (string, string) {
+	direction := "ASC"
+	if opts.SortDesc {
+		direction = "DESC"
+	}
+	sortColumn := "created_at"
+	if opts.SortBy == models.UserSortEmail {
+		sortColumn = "email"
+	}
+	return sortColumn, direction
+}
+
+// buildUserFilters turns listing options into a WHERE clause and its
+// positional arguments
+func buildUserFilters(opts models.ListUsersOptions) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	if opts.Deleted {
+		conditions = []string{"deleted_at IS NOT NULL"}
+	}
+	var args []interface{}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if opts.IsBlacklisted != nil {
+		add("is_blacklisted = $%d", *opts.IsBlacklisted)
+	}
+	if opts.IsAdmin != nil {
+		add("is_admin = $%d", *opts.IsAdmin)
+	}
+	if opts.CreatedAfter != nil {
+		add("created_at >= $%d", *opts.CreatedAfter)
+	}
+	if opts.CreatedBefore != nil {
+		add("created_at < $%d", *opts.CreatedBefore)
+	}
+	if opts.InactiveSince != nil {
+		add("(last_login_at IS NULL OR last_login_at < $%d)", *opts.InactiveSince)
+	}
+	if opts.RegistrationSource != "" {
+		add("registration_source = $%d", opts.RegistrationSource)
+	}
+	if opts.Search != "" {
+		add("(email ILIKE $%[1]d OR name ILIKE $%[1]d)", "%"+escapeLike(opts.Search)+"%")
+	}
+
+	return "\n\t\tWHERE " + strings.Join(conditions, " AND "), args
+}
+
+// escapeLike escapes LIKE wildcards so search terms match literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// UpdateLastLogin sets only the last_login_at column so concurrent profile
+// edits are never overwritten
+func (r *UserRepositoryImpl) UpdateLastLogin(userID uuid.UUID, at time.Time) error {
+	query := `UPDATE users SET last_login_at = $1 WHERE id = $2`
+
+	result, err := r.db.Exec(query, at, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update last login: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found for last login update")
+	}
+
+	return nil
+}
+
+// DeleteUser soft-deletes a user by ID, bumping their token version so
+// issued access tokens become stale and dropping any pending email change.
+// selfDeleted marks a deletion the user requested, which logging back in
+// cancels. The optional audit entry and a user.deleted event are written in
+// the same transaction.
+func (r *UserRepositoryImpl) DeleteUser(id uuid.UUID, selfDeleted bool, audit *models.AuditLogEntry) error {
+	query := `
+		UPDATE users 
+		SET deleted_at = $1, self_deleted = $2, token_version = token_version + 1, updated_at = $1,
+			pending_email = NULL, pending_email_token = NULL, email_change_cancel_token = NULL,
+			email_change_requested_at = NULL
+		WHERE id = $3 AND deleted_at IS NULL`
+
+	now := time.Now()
+	return r.db.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(query, now, selfDeleted, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("user not found for deletion")
+		}
+
+		err = writeUserEvent(tx, events.TypeUserDeleted, events.UserDeleted{
+			UserID:      id,
+			SelfDeleted: selfDeleted,
+			DeletedAt:   now.UTC(),
+		})
+		if err != nil {
+			return err
+		}
+
+		return insertAuditLogEntry(tx, audit)
+	})
+}
+
+// RestoreUser clears a soft deletion made after deletedAfter. The optional
+// audit entry is written in the same transaction.
+func (r *UserRepositoryImpl) RestoreUser(id uuid.UUID, deletedAfter time.Time, audit *models.AuditLogEntry) error {
+	query := `
+		UPDATE users 
+		SET deleted_at = NULL, self_deleted = FALSE, updated_at = $1
+		WHERE id = $2 AND deleted_at IS NOT NULL AND deleted_at > $3`
+
+	return r.db.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(query, time.Now(), id, deletedAfter)
+		if err != nil {
+			return fmt.Errorf("failed to restore user: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("deleted user not found for restore")
+		}
+
+		return insertAuditLogEntry(tx, audit)
+	})
+}
+
+// PurgeDeletedUsers permanently removes users soft-deleted before the cutoff
+// and returns their IDs
+func (r *UserRepositoryImpl) PurgeDeletedUsers(before time.Time) ([]uuid.UUID, error) {
+	query := `DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING id`
+
+	rows, err := r.db.Query(query, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan purged user id: %w", err)
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over purged user rows: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// UserExists checks if an active user with the given email exists, either as
+// their current address or as an address they are in the process of
+// switching to
+func (r *UserRepositoryImpl) UserExists(email string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE (email = $1 OR pending_email = $1) AND deleted_at IS NULL)`
+
+	var exists bool
+	err := r.db.QueryRow(query, email).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if user exists: %w", err)
+	}
+
+	return exists, nil
+}
+
+// EmailInUse checks if an email is taken by any user other than
+// excludeUserID. Soft-deleted users still hold their address until purged.
+func (r *UserRepositoryImpl) EmailInUse(email string, excludeUserID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE (email = $1 OR pending_email = $1) AND id <> $2)`
+
+	var exists bool
+	err := r.db.QueryRow(query, email, excludeUserID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if email is in use: %w", err)
+	}
+
+	return exists, nil
+}
+
+// SetPendingEmail records a requested email change awaiting verification
+func (r *UserRepositoryImpl) SetPendingEmail(userID uuid.UUID, pendingEmail, tokenHash, cancelTokenHash string) error {
+	query := `
+		UPDATE users 
+		SET pending_email = $1, pending_email_token = $2, email_change_cancel_token = $3,
+			email_change_requested_at = $4, previous_email = NULL, updated_at = $4
+		WHERE id = $5`
+
+	result, err := r.db.Exec(query, pendingEmail, tokenHash, cancelTokenHash, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to set pending email: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found for email change")
+	}
+
+	return nil
+}
+
+// GetUserByPendingEmailToken retrieves the user owning an email verification token
+func (r *UserRepositoryImpl) GetUserByPendingEmailToken(tokenHash string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users WHERE pending_email_token = $1 AND deleted_at IS NULL`
+
+	user, err := scanUser(r.db.QueryRow(query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get user by email token: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserByEmailChangeCancelToken retrieves the user owning an email change cancellation token
+func (r *UserRepositoryImpl) GetUserByEmailChangeCancelToken(tokenHash string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users WHERE email_change_cancel_token = $1 AND deleted_at IS NULL`
+
+	user, err := scanUser(r.db.QueryRow(query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get user by cancel token: %w", err)
+	}
+
+	return user, nil
+}
+
+// ConfirmPendingEmail swaps a verified pending email in as the user's email,
+// keeping the old address so the change can be rolled back
+func (r *UserRepositoryImpl) ConfirmPendingEmail(userID uuid.UUID) error {
+	query := `
+		UPDATE users 
+		SET previous_email = email, email = pending_email, email_verified_at = $1,
+			pending_email = NULL, pending_email_token = NULL, updated_at = $1
+		WHERE id = $2 AND pending_email IS NOT NULL`
+
+	result, err := r.db.Exec(query, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to confirm pending email: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no pending email to confirm")
+	}
+
+	return nil
+}
+
+// CancelEmailChange discards a pending email change, restoring the previous
+// email if the change was already confirmed
+func (r *UserRepositoryImpl) CancelEmailChange(userID uuid.UUID) error {
+	query := `
+		UPDATE users 
+		SET email = COALESCE(previous_email, email), previous_email = NULL,
+			email_verified_at = CASE WHEN previous_email IS NULL THEN email_verified_at END,
+			pending_email = NULL, pending_email_token = NULL,
+			email_change_cancel_token = NULL, email_change_requested_at = NULL, updated_at = $1
+		WHERE id = $2`
+
+	result, err := r.db.Exec(query, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel email change: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found for email change cancellation")
+	}
+
+	return nil
 }
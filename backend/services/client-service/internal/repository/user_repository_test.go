@@ -0,0 +1,508 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/events"
+)
+
+func TestBuildUserFilters(t *testing.T) {
+	blacklisted := true
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	where, args := buildUserFilters(models.ListUsersOptions{
+		IsBlacklisted: &blacklisted,
+		CreatedAfter:  &since,
+		Search:        "50%_off",
+	})
+
+	want := "\n\t\tWHERE deleted_at IS NULL AND is_blacklisted = $1 AND created_at >= $2 AND (email ILIKE $3 OR name ILIKE $3)"
+	if where != want {
+		t.Errorf("Expected where clause %q, got %q", want, where)
+	}
+	if len(args) != 3 || args[2] != `%50\%\_off%` {
+		t.Errorf("Unexpected args: %#v", args)
+	}
+
+	if where, args := buildUserFilters(models.ListUsersOptions{}); where != "\n\t\tWHERE deleted_at IS NULL" || len(args) != 0 {
+		t.Errorf("Expected only the active users filter, got %q %v", where, args)
+	}
+	if where, _ := buildUserFilters(models.ListUsersOptions{Deleted: true}); where != "\n\t\tWHERE deleted_at IS NOT NULL" {
+		t.Errorf("Expected only the deleted users filter, got %q", where)
+	}
+	if where, args := buildUserFilters(models.ListUsersOptions{RegistrationSource: models.RegistrationSourceInvite}); where != "\n\t\tWHERE deleted_at IS NULL AND registration_source = $1" || len(args) != 1 || args[0] != "invite" {
+		t.Errorf("Expected the registration source filter, got %q %v", where, args)
+	}
+}
+
+func TestUserRepository_GetAllUsers(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+
+	admin := false
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM users\n\t\tWHERE deleted_at IS NULL AND is_admin = $1")).
+		WithArgs(false).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+	mock.ExpectQuery(regexp.QuoteMeta("ORDER BY email ASC, id ASC")).
+		WithArgs(false, 10, 20).
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	users, total, err := repo.GetAllUsers(models.ListUsersOptions{
+		Limit:   10,
+		Offset:  20,
+		IsAdmin: &admin,
+		SortBy:  models.UserSortEmail,
+	})
+	if err != nil {
+		t.Fatalf("GetAllUsers returned error: %v", err)
+	}
+	if total != 42 {
+		t.Errorf("Expected total 42, got %d", total)
+	}
+	if len(users) != 0 {
+		t.Errorf("Expected no users, got %d", len(users))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBuildProfileUpdate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	phone, city := "+27821234567", ""
+
+	set, args := buildProfileUpdate(models.UserProfile{
+		PhoneNumber: &phone,
+		Address:     &models.AddressProfile{City: &city},
+	}, now)
+
+	want := "phone_number = NULLIF($1, ''), phone_verified_at = CASE WHEN phone_number IS NOT DISTINCT FROM NULLIF($1, '') THEN phone_verified_at END, " +
+		"address_city = NULLIF($2, ''), updated_at = $3"
+	if set != want {
+		t.Errorf("Expected set clause %q, got %q", want, set)
+	}
+	if len(args) != 3 || args[0] != phone || args[1] != "" || args[2] != now {
+		t.Errorf("Unexpected args: %#v", args)
+	}
+
+	if set, _ := buildProfileUpdate(models.UserProfile{}, now); set != "updated_at = $1" {
+		t.Errorf("Expected only updated_at for an empty update, got %q", set)
+	}
+}
+
+func TestUserRepository_CreateInvitedUser(t *testing.T) {
+	tests := []struct {
+		name          string
+		invitationsOK int64
+		wantErr       bool
+	}{
+		{name: "invitation redeemed", invitationsOK: 1},
+		{name: "invitation taken meanwhile", invitationsOK: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewUserRepository(db)
+			user := &models.User{ID: uuid.New(), Email: "invited@example.com", Name: "Invited"}
+			invitationID := uuid.New()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users")).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(user.ID))
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE invitations")).
+				WithArgs(user.ID, sqlmock.AnyArg(), invitationID).
+				WillReturnResult(sqlmock.NewResult(0, tt.invitationsOK))
+			if tt.wantErr {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+					WithArgs(sqlmock.AnyArg(), events.TypeUserRegistered, 1, events.SourceClientService, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			err := repo.CreateInvitedUser(user, invitationID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestUserRepository_CountUsers(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+
+	today := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	thisWeek := time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)
+	thisMonth := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("COUNT(*) FILTER (WHERE email_verified_at IS NOT NULL)\n\t\tFROM users\n\t\tWHERE deleted_at IS NULL")).
+		WithArgs(today, thisWeek, thisMonth).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "today", "week", "month", "blacklisted", "verified"}).AddRow(40, 1, 5, 12, 3, 30))
+
+	counts, err := repo.CountUsers(today, thisWeek, thisMonth)
+	if err != nil {
+		t.Fatalf("CountUsers returned error: %v", err)
+	}
+	want := models.UserCounts{Total: 40, NewToday: 1, NewThisWeek: 5, NewThisMonth: 12, Blacklisted: 3, EmailVerified: 30}
+	if *counts != want {
+		t.Errorf("Expected %+v, got %+v", want, *counts)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_CountSignupsBySource(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+
+	since := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	week := time.Date(2024, 5, 13, 0, 0, 0, 0, time.FixedZone("", 0))
+	mock.ExpectQuery(regexp.QuoteMeta("GROUP BY week, registration_source")).
+		WithArgs(since).
+		WillReturnRows(sqlmock.NewRows([]string{"week", "registration_source", "count"}).
+			AddRow(week, "oauth_google", 2).
+			AddRow(week, "self", 5))
+
+	counts, err := repo.CountSignupsBySource(since)
+	if err != nil {
+		t.Fatalf("CountSignupsBySource returned error: %v", err)
+	}
+	if len(counts) != 2 || counts[1].Source != models.RegistrationSourceSelf || counts[1].Count != 5 || counts[1].WeekStart != time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("Unexpected signup counts: %+v", counts)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// payloadContains matches an outbox payload argument containing a JSON
+// fragment
+type payloadContains string
+
+func (p payloadContains) Match(v driver.Value) bool {
+	payload, ok := v.([]byte)
+	return ok && strings.Contains(string(payload), string(p))
+}
+
+func TestUserRepository_CreateUserWritesRegisteredEvent(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+	user := &models.User{ID: uuid.New(), Email: "jane@example.com", Name: "Jane", OAuthProvider: "google"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(user.ID))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WithArgs(sqlmock.AnyArg(), events.TypeUserRegistered, 1, events.SourceClientService, payloadContains(`"method":"google"`), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_CreateUserByAdminAuditsTheAdmin(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+	user := &models.User{ID: uuid.New(), Email: "branch@example.com", Name: "Branch Customer", MustSetPassword: true, RegistrationSource: models.RegistrationSourceAdminCreated}
+	adminID := uuid.New()
+	audit := &models.AuditLogEntry{ID: uuid.New(), AdminID: adminID, Action: models.AuditActionCreateUser, TargetUserID: &user.ID}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users")).
+		WithArgs(user.ID, user.Email, user.Name, "", false, false, nil, "", "", true, "admin_created", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(user.ID))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO admin_audit_log")).
+		WithArgs(audit.ID, adminID, models.AuditActionCreateUser, &user.ID, sqlmock.AnyArg(), "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WithArgs(sqlmock.AnyArg(), events.TypeUserRegistered, 1, events.SourceClientService, payloadContains(`"method":"admin"`), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.CreateUserByAdmin(user, audit); err != nil {
+		t.Fatalf("CreateUserByAdmin returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_CreateUserRollsBackWhenOutboxFails(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+	user := &models.User{ID: uuid.New(), Email: "jane@example.com", Name: "Jane"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(user.ID))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WillReturnError(errors.New("disk full"))
+	mock.ExpectRollback()
+
+	if err := repo.CreateUser(user); err == nil {
+		t.Fatal("Expected an error when the event cannot be written")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_UpdateBlacklistStatusWritesEvent(t *testing.T) {
+	tests := []struct {
+		name          string
+		isBlacklisted bool
+		wantType      string
+	}{
+		{name: "blacklist", isBlacklisted: true, wantType: events.TypeUserBlacklisted},
+		{name: "lift", isBlacklisted: false, wantType: events.TypeUserUnblacklisted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			repo := NewUserRepository(db)
+			userID, adminID := uuid.New(), uuid.New()
+			entry := &models.BlacklistEntry{ID: uuid.New(), UserID: userID, Action: models.BlacklistActionAdd, Reason: "Fraud", AdminID: &adminID}
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("SET is_blacklisted = $1")).
+				WithArgs(tt.isBlacklisted, "Fraud", nil, sqlmock.AnyArg(), userID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+				WithArgs(sqlmock.AnyArg(), tt.wantType, 1, events.SourceClientService, payloadContains(userID.String()), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO blacklist_entries")).
+				WithArgs(entry.ID, userID, models.BlacklistActionAdd, "Fraud", nil, &adminID, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			if err := repo.UpdateBlacklistStatus(userID, tt.isBlacklisted, "Fraud", nil, entry, nil); err != nil {
+				t.Fatalf("UpdateBlacklistStatus returned error: %v", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestUserRepository_UpdateBlacklistStatusRollsBackWithoutHistory(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+	userID := uuid.New()
+	entry := &models.BlacklistEntry{ID: uuid.New(), UserID: userID, Action: models.BlacklistActionAdd, Reason: "Fraud"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SET is_blacklisted = $1")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO blacklist_entries")).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	if err := repo.UpdateBlacklistStatus(userID, true, "Fraud", nil, entry, nil); err == nil {
+		t.Fatal("Expected an error when the history entry cannot be recorded, got nil")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_LiftExpiredBlacklistsWritesEvents(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+	now := time.Now()
+	first, second := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SET is_blacklisted = false")).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(first).AddRow(second))
+	for _, id := range []uuid.UUID{first, second} {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+			WithArgs(sqlmock.AnyArg(), events.TypeUserUnblacklisted, 1, events.SourceClientService, payloadContains(`"user_id":"`+id.String()+`","expired":true`), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO blacklist_entries")).
+			WithArgs(sqlmock.AnyArg(), id, models.BlacklistActionRemove, models.BlacklistReasonExpired, nil, nil, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	lifted, err := repo.LiftExpiredBlacklists(now)
+	if err != nil {
+		t.Fatalf("LiftExpiredBlacklists returned error: %v", err)
+	}
+	if len(lifted) != 2 {
+		t.Errorf("Expected 2 lifted users, got %v", lifted)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_UpdateBlacklistStatusBatch(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+	target, banned, missing := uuid.New(), uuid.New(), uuid.New()
+	audit := &models.AuditLogEntry{ID: uuid.New(), AdminID: uuid.New(), Action: models.AuditActionBlacklist, TargetUserID: &target}
+	history := map[uuid.UUID]*models.BlacklistEntry{
+		target: {ID: uuid.New(), UserID: target, Action: models.BlacklistActionAdd, Reason: "Fraud ring", AdminID: &audit.AdminID},
+		banned: {ID: uuid.New(), UserID: banned, Action: models.BlacklistActionAdd, Reason: "Fraud ring", AdminID: &audit.AdminID},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, is_blacklisted FROM users")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "is_blacklisted"}).AddRow(target, false).AddRow(banned, true))
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id = ANY($5)")).
+		WithArgs(true, "Fraud ring", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_outbox")).
+		WithArgs(sqlmock.AnyArg(), events.TypeUserBlacklisted, 1, events.SourceClientService, payloadContains(target.String()), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO blacklist_entries")).
+		WithArgs(history[target].ID, target, models.BlacklistActionAdd, "Fraud ring", nil, &audit.AdminID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO admin_audit_log")).
+		WithArgs(audit.ID, audit.AdminID, audit.Action, audit.TargetUserID, []byte("{}"), "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	results, err := repo.UpdateBlacklistStatusBatch([]uuid.UUID{target, banned, missing}, true, "Fraud ring", nil, history, map[uuid.UUID]*models.AuditLogEntry{target: audit})
+	if err != nil {
+		t.Fatalf("UpdateBlacklistStatusBatch returned error: %v", err)
+	}
+	want := map[uuid.UUID]string{
+		target:  models.BlacklistBatchOK,
+		banned:  models.BlacklistBatchAlreadyBlacklisted,
+		missing: models.BlacklistBatchNotFound,
+	}
+	for id, status := range want {
+		if results[id] != status {
+			t.Errorf("Expected %s to be %q, got %q", id, status, results[id])
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_GetUsersAfter(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+
+	blacklisted := true
+	after := &models.User{ID: uuid.New(), Email: "m@example.com"}
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE deleted_at IS NULL AND is_blacklisted = $1 AND (email, id) < ($2, $3)\n\t\tORDER BY email DESC, id DESC\n\t\tLIMIT $4")).
+		WithArgs(true, after.Email, after.ID, 500).
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	users, err := repo.GetUsersAfter(models.ListUsersOptions{
+		IsBlacklisted: &blacklisted,
+		SortBy:        models.UserSortEmail,
+		SortDesc:      true,
+		Limit:         10,
+		Offset:        20,
+	}, after, 500)
+	if err != nil {
+		t.Fatalf("GetUsersAfter returned error: %v", err)
+	}
+	if len(users) != 0 {
+		t.Errorf("Expected no users, got %d", len(users))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_GetUserStatuses(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+	active, deleted := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, is_blacklisted, is_admin, deleted_at IS NOT NULL, email_verified_at IS NOT NULL, token_version")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "is_blacklisted", "is_admin", "deleted", "verified", "token_version", "kyc_status", "roles", "timezone"}).
+			AddRow(active, true, true, false, true, 4, "verified", "{admin,support}", "Africa/Johannesburg").
+			AddRow(deleted, false, false, true, false, 1, "none", "{}", "UTC"))
+
+	statuses, err := repo.GetUserStatuses([]uuid.UUID{active, deleted, uuid.New()})
+	if err != nil {
+		t.Fatalf("GetUserStatuses returned error: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 statuses, got %v", statuses)
+	}
+	want := models.UserStatus{UserID: active, Exists: true, IsBlacklisted: true, IsAdmin: true, EmailVerified: true, TokenVersion: 4, KYCStatus: "verified", Roles: []string{"admin", "support"}, TimeZone: "Africa/Johannesburg"}
+	if !reflect.DeepEqual(statuses[active], want) {
+		t.Errorf("Expected %+v, got %+v", want, statuses[active])
+	}
+	if !statuses[deleted].IsDeleted {
+		t.Errorf("Expected the deleted user to be marked deleted, got %+v", statuses[deleted])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUserRepository_GetUserDetails(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewUserRepository(db)
+	active, deleted := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, email, name, deleted_at IS NOT NULL FROM users WHERE id = ANY($1)")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "name", "deleted"}).
+			AddRow(active, "client@example.com", "Test Client", false).
+			AddRow(deleted, "gone@example.com", "Gone Client", true))
+
+	details, err := repo.GetUserDetails([]uuid.UUID{active, deleted, uuid.New()})
+	if err != nil {
+		t.Fatalf("GetUserDetails returned error: %v", err)
+	}
+	if len(details) != 2 {
+		t.Fatalf("Expected 2 details, got %v", details)
+	}
+	want := models.UserDetail{UserID: active, Exists: true, Email: "client@example.com", Name: "Test Client"}
+	if details[active] != want {
+		t.Errorf("Expected %+v, got %+v", want, details[active])
+	}
+	if !details[deleted].IsDeleted {
+		t.Errorf("Expected the deleted user to be marked deleted, got %+v", details[deleted])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
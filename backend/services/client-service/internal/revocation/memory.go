@@ -0,0 +1,119 @@
+package revocation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// memoryPurgeInterval is the minimum time between sweeps of expired tokens
+// as new tokens are tracked
+const memoryPurgeInterval = time.Minute
+
+// MemoryStore keeps revocations in process memory. Revocations are lost on
+// restart and are not shared between instances.
+type MemoryStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+	issued  map[uuid.UUID]map[string]time.Time
+	now     func() time.Time
+
+	purgedAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		revoked: make(map[string]time.Time),
+		issued:  make(map[uuid.UUID]map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Track remembers a token issued to a user until it expires
+func (s *MemoryStore) Track(userID uuid.UUID, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Expired tokens are dropped as new ones are issued
+	if s.now().Sub(s.purgedAt) >= memoryPurgeInterval {
+		s.purge()
+	}
+
+	tokens := s.issued[userID]
+	if tokens == nil {
+		tokens = make(map[string]time.Time)
+		s.issued[userID] = tokens
+	}
+	tokens[jti] = expiresAt
+	return nil
+}
+
+// Revoke adds tokens to the revocation list. Tokens that have already
+// expired are skipped.
+func (s *MemoryStore) Revoke(tokens []models.RevokedToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purge()
+	now := s.now()
+	for _, token := range tokens {
+		if token.ExpiresAt.After(now) {
+			s.revoked[token.JTI] = token.ExpiresAt
+		}
+	}
+	return nil
+}
+
+// IsRevoked reports whether a token has been revoked
+func (s *MemoryStore) IsRevoked(jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.revoked[jti]
+	return ok && expiresAt.After(s.now()), nil
+}
+
+// RevokeUser revokes every unexpired token tracked for a user and returns
+// them
+func (s *MemoryStore) RevokeUser(userID uuid.UUID) ([]models.RevokedToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purge()
+	now := s.now()
+	var tokens []models.RevokedToken
+	for jti, expiresAt := range s.issued[userID] {
+		if expiresAt.After(now) {
+			s.revoked[jti] = expiresAt
+			tokens = append(tokens, models.RevokedToken{JTI: jti, ExpiresAt: expiresAt})
+		}
+	}
+	delete(s.issued, userID)
+
+	return tokens, nil
+}
+
+// purge drops revoked and tracked tokens that have expired. The caller
+// must hold mu.
+func (s *MemoryStore) purge() {
+	now := s.now()
+	for jti, expiresAt := range s.revoked {
+		if !expiresAt.After(now) {
+			delete(s.revoked, jti)
+		}
+	}
+	for userID, tokens := range s.issued {
+		for jti, expiresAt := range tokens {
+			if !expiresAt.After(now) {
+				delete(tokens, jti)
+			}
+		}
+		if len(tokens) == 0 {
+			delete(s.issued, userID)
+		}
+	}
+	s.purgedAt = now
+}
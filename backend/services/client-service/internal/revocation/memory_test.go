@@ -0,0 +1,89 @@
+package revocation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func TestMemoryStore_RevokeUser(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	userID, otherID := uuid.New(), uuid.New()
+	store.Track(userID, "jti-1", now.Add(10*time.Minute))
+	store.Track(userID, "jti-2", now.Add(15*time.Minute))
+	store.Track(userID, "expired", now.Add(-time.Minute))
+	store.Track(otherID, "other", now.Add(15*time.Minute))
+
+	tokens, err := store.RevokeUser(userID)
+	if err != nil {
+		t.Fatalf("RevokeUser returned error: %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("Expected the 2 unexpired tokens to be revoked, got %+v", tokens)
+	}
+
+	for jti, want := range map[string]bool{"jti-1": true, "jti-2": true, "expired": false, "other": false} {
+		if revoked, _ := store.IsRevoked(jti); revoked != want {
+			t.Errorf("IsRevoked(%q) = %v, want %v", jti, revoked, want)
+		}
+	}
+
+	// Revocations lapse once the token would have expired anyway
+	now = now.Add(11 * time.Minute)
+	if revoked, _ := store.IsRevoked("jti-1"); revoked {
+		t.Error("Expected the revocation of an expired token to lapse")
+	}
+	if revoked, _ := store.IsRevoked("jti-2"); !revoked {
+		t.Error("Expected jti-2 to still be revoked")
+	}
+
+	// Nothing is left to revoke for the user
+	if tokens, _ := store.RevokeUser(userID); len(tokens) != 0 {
+		t.Errorf("Expected no tokens on a second force logout, got %+v", tokens)
+	}
+}
+
+func TestMemoryStore_Revoke(t *testing.T) {
+	store := NewMemoryStore()
+
+	err := store.Revoke([]models.RevokedToken{
+		{JTI: "pushed", ExpiresAt: time.Now().Add(time.Minute)},
+		{JTI: "already-expired", ExpiresAt: time.Now().Add(-time.Minute)},
+	})
+	if err != nil {
+		t.Fatalf("Revoke returned error: %v", err)
+	}
+
+	if revoked, _ := store.IsRevoked("pushed"); !revoked {
+		t.Error("Expected the pushed token to be revoked")
+	}
+	if _, ok := store.revoked["already-expired"]; ok {
+		t.Error("Expected an expired token not to be stored")
+	}
+}
+
+func TestMemoryStore_PurgesExpiredTokens(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	idle := uuid.New()
+	store.Track(idle, "idle", now.Add(time.Minute))
+	store.Revoke([]models.RevokedToken{{JTI: "revoked", ExpiresAt: now.Add(time.Minute)}})
+
+	// The next token tracked after the interval sweeps both lists
+	now = now.Add(2 * memoryPurgeInterval)
+	store.Track(uuid.New(), "active", now.Add(time.Minute))
+
+	if _, ok := store.issued[idle]; ok {
+		t.Error("Expected the idle user's expired tokens to be purged")
+	}
+	if _, ok := store.revoked["revoked"]; ok {
+		t.Error("Expected the expired revocation to be purged")
+	}
+}
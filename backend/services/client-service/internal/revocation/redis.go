@@ -0,0 +1,272 @@
+package revocation
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// Key prefixes used in Redis
+const (
+	redisRevokedPrefix = "revoked_token:"
+	redisIssuedPrefix  = "user_tokens:"
+)
+
+// redisTimeout bounds each command, including connecting
+const redisTimeout = 2 * time.Second
+
+// RedisStore keeps revocations in Redis so every instance shares them. Each
+// revoked token is a key that expires with the token, and the tokens issued
+// to a user are a sorted set scored by expiry.
+type RedisStore struct {
+	addr     string
+	password string
+	db       int
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	now    func() time.Time
+}
+
+// NewRedisStore creates a store for a redis://[:password@]host:port[/db]
+// URL. The connection is opened on first use.
+func NewRedisStore(rawURL string) (*RedisStore, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "redis" || parsed.Host == "" {
+		return nil, fmt.Errorf("expected redis://host:port, got %q", parsed.Redacted())
+	}
+
+	s := &RedisStore{addr: parsed.Host, now: time.Now}
+	if !strings.Contains(s.addr, ":") {
+		s.addr += ":6379"
+	}
+	if parsed.User != nil {
+		s.password, _ = parsed.User.Password()
+	}
+	if path := strings.Trim(parsed.Path, "/"); path != "" {
+		if s.db, err = strconv.Atoi(path); err != nil {
+			return nil, fmt.Errorf("invalid database number %q", path)
+		}
+	}
+	return s, nil
+}
+
+// Track remembers a token issued to a user until it expires
+func (s *RedisStore) Track(userID uuid.UUID, jti string, expiresAt time.Time) error {
+	key := redisIssuedPrefix + userID.String()
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.do("ZREMRANGEBYSCORE", key, "-inf", strconv.FormatInt(now.UnixMilli(), 10)); err != nil {
+		return err
+	}
+	if _, err := s.do("ZADD", key, strconv.FormatInt(expiresAt.UnixMilli(), 10), jti); err != nil {
+		return err
+	}
+	// Access tokens share one lifetime, so the newest token expires last
+	_, err := s.do("PEXPIREAT", key, strconv.FormatInt(expiresAt.UnixMilli(), 10))
+	return err
+}
+
+// Revoke adds tokens to the revocation list. Tokens that have already
+// expired are skipped.
+func (s *RedisStore) Revoke(tokens []models.RevokedToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revoke(tokens)
+}
+
+// IsRevoked reports whether a token has been revoked
+func (s *RedisStore) IsRevoked(jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reply, err := s.do("EXISTS", redisRevokedPrefix+jti)
+	if err != nil {
+		return false, err
+	}
+	count, _ := reply.(int64)
+	return count > 0, nil
+}
+
+// RevokeUser revokes every unexpired token tracked for a user and returns
+// them
+func (s *RedisStore) RevokeUser(userID uuid.UUID) ([]models.RevokedToken, error) {
+	key := redisIssuedPrefix + userID.String()
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reply, err := s.do("ZRANGEBYSCORE", key, "("+strconv.FormatInt(now.UnixMilli(), 10), "+inf", "WITHSCORES")
+	if err != nil {
+		return nil, err
+	}
+	items, _ := reply.([]interface{})
+
+	var tokens []models.RevokedToken
+	for i := 0; i+1 < len(items); i += 2 {
+		jti, _ := items[i].(string)
+		score, _ := items[i+1].(string)
+		expiresAt, err := strconv.ParseInt(score, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry for token %q: %w", jti, err)
+		}
+		tokens = append(tokens, models.RevokedToken{JTI: jti, ExpiresAt: time.UnixMilli(expiresAt)})
+	}
+
+	if err := s.revoke(tokens); err != nil {
+		return nil, err
+	}
+	if _, err := s.do("DEL", key); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// revoke stores each unexpired token as a key that expires with it. The
+// caller must hold mu.
+func (s *RedisStore) revoke(tokens []models.RevokedToken) error {
+	now := s.now()
+	for _, token := range tokens {
+		ttl := token.ExpiresAt.Sub(now).Milliseconds()
+		if ttl <= 0 {
+			continue
+		}
+		if _, err := s.do("SET", redisRevokedPrefix+token.JTI, "1", "PX", strconv.FormatInt(ttl, 10)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// do sends a command and returns its reply, connecting first if needed. A
+// failed connection is dropped so the next command reconnects. The caller
+// must hold mu.
+func (s *RedisStore) do(args ...string) (interface{}, error) {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := s.roundTrip(args)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		s.conn.Close()
+		s.conn, s.reader = nil, nil
+	}
+	return reply, err
+}
+
+// connect dials Redis and authenticates and selects the database if
+// configured. The caller must hold mu.
+func (s *RedisStore) connect() error {
+	conn, err := net.DialTimeout("tcp", s.addr, redisTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	s.conn, s.reader = conn, bufio.NewReader(conn)
+
+	var setup [][]string
+	if s.password != "" {
+		setup = append(setup, []string{"AUTH", s.password})
+	}
+	if s.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.db)})
+	}
+	for _, args := range setup {
+		if _, err := s.roundTrip(args); err != nil {
+			conn.Close()
+			s.conn, s.reader = nil, nil
+			return fmt.Errorf("failed to set up redis connection: %w", err)
+		}
+	}
+	return nil
+}
+
+// roundTrip writes a command as a RESP array and reads one reply
+func (s *RedisStore) roundTrip(args []string) (interface{}, error) {
+	s.conn.SetDeadline(time.Now().Add(redisTimeout))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(s.conn, b.String()); err != nil {
+		return nil, fmt.Errorf("failed to write redis command: %w", err)
+	}
+
+	return readReply(s.reader)
+}
+
+// redisError is an error reply from Redis. The connection stays usable.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// readReply reads a RESP reply. Simple and bulk strings are returned as
+// strings, integers as int64, arrays as []interface{} and nil bulk strings
+// as nil.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redis reply: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("failed to read redis reply: %w", err)
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unexpected redis reply %q", line)
+	}
+}
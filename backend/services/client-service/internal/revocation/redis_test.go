@@ -0,0 +1,224 @@
+package revocation
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// fakeRedis speaks enough RESP to serve the commands RedisStore sends. Key
+// expiry is recorded but not enforced.
+type fakeRedis struct {
+	mu       sync.Mutex
+	password string
+	strings  map[string]string
+	expiries map[string]string
+	sets     map[string]map[string]int64
+	commands []string
+}
+
+func newFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeRedis{
+		password: password,
+		strings:  make(map[string]string),
+		expiries: make(map[string]string),
+		sets:     make(map[string]map[string]int64),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := f.password == ""
+
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]interface{})
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+
+		if !authenticated && args[0] != "AUTH" {
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		if args[0] == "AUTH" {
+			authenticated = args[1] == f.password
+		}
+		fmt.Fprint(conn, f.handle(args))
+	}
+}
+
+func (f *fakeRedis) handle(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, strings.Join(args, " "))
+
+	switch args[0] {
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "SET":
+		f.strings[args[1]] = args[2]
+		f.expiries[args[1]] = strings.Join(args[3:], " ")
+		return "+OK\r\n"
+	case "EXISTS":
+		if _, ok := f.strings[args[1]]; ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "ZADD":
+		if f.sets[args[1]] == nil {
+			f.sets[args[1]] = make(map[string]int64)
+		}
+		score, _ := strconv.ParseInt(args[2], 10, 64)
+		f.sets[args[1]][args[3]] = score
+		return ":1\r\n"
+	case "ZREMRANGEBYSCORE":
+		max, _ := strconv.ParseInt(args[3], 10, 64)
+		removed := 0
+		for member, score := range f.sets[args[1]] {
+			if score <= max {
+				delete(f.sets[args[1]], member)
+				removed++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", removed)
+	case "ZRANGEBYSCORE":
+		min, _ := strconv.ParseInt(strings.TrimPrefix(args[2], "("), 10, 64)
+		var members []string
+		for member, score := range f.sets[args[1]] {
+			if score > min {
+				members = append(members, member)
+			}
+		}
+		sort.Strings(members)
+		reply := fmt.Sprintf("*%d\r\n", len(members)*2)
+		for _, member := range members {
+			score := strconv.FormatInt(f.sets[args[1]][member], 10)
+			reply += fmt.Sprintf("$%d\r\n%s\r\n$%d\r\n%s\r\n", len(member), member, len(score), score)
+		}
+		return reply
+	case "PEXPIREAT":
+		f.expiries[args[1]] = args[2]
+		return ":1\r\n"
+	case "DEL":
+		delete(f.sets, args[1])
+		return ":1\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	fake, addr := newFakeRedis(t, "secret")
+	store, err := NewRedisStore("redis://:secret@" + addr + "/2")
+	if err != nil {
+		t.Fatalf("NewRedisStore returned error: %v", err)
+	}
+	// Redis scores hold milliseconds
+	now := time.UnixMilli(time.Now().UnixMilli())
+	store.now = func() time.Time { return now }
+
+	userID := uuid.New()
+	expiresAt := now.Add(15 * time.Minute)
+	if err := store.Track(userID, "jti-1", expiresAt); err != nil {
+		t.Fatalf("Track returned error: %v", err)
+	}
+	store.Track(userID, "jti-2", expiresAt)
+
+	if revoked, err := store.IsRevoked("jti-1"); err != nil || revoked {
+		t.Fatalf("Expected jti-1 not to be revoked yet, got %v, %v", revoked, err)
+	}
+
+	tokens, err := store.RevokeUser(userID)
+	if err != nil {
+		t.Fatalf("RevokeUser returned error: %v", err)
+	}
+	if len(tokens) != 2 || tokens[0].JTI != "jti-1" || tokens[0].ExpiresAt.UnixMilli() != expiresAt.UnixMilli() {
+		t.Fatalf("Unexpected revoked tokens: %+v", tokens)
+	}
+	if revoked, _ := store.IsRevoked("jti-2"); !revoked {
+		t.Error("Expected jti-2 to be revoked")
+	}
+	if want := "PX 900000"; fake.expiries[redisRevokedPrefix+"jti-1"] != want {
+		t.Errorf("Expected the revocation to expire with the token (%s), got %q", want, fake.expiries[redisRevokedPrefix+"jti-1"])
+	}
+	if _, ok := fake.sets[redisIssuedPrefix+userID.String()]; ok {
+		t.Error("Expected the user's tracked tokens to be cleared")
+	}
+
+	if err := store.Revoke([]models.RevokedToken{{JTI: "pushed", ExpiresAt: now.Add(time.Minute)}}); err != nil {
+		t.Fatalf("Revoke returned error: %v", err)
+	}
+	if revoked, _ := store.IsRevoked("pushed"); !revoked {
+		t.Error("Expected the pushed token to be revoked")
+	}
+
+	if fake.commands[0] != "AUTH secret" || fake.commands[1] != "SELECT 2" {
+		t.Errorf("Expected the connection to authenticate and select the database, got %v", fake.commands[:2])
+	}
+}
+
+func TestRedisStore_Reconnects(t *testing.T) {
+	fake, addr := newFakeRedis(t, "")
+	store, _ := NewRedisStore("redis://" + addr)
+
+	if _, err := store.IsRevoked("jti"); err != nil {
+		t.Fatalf("IsRevoked returned error: %v", err)
+	}
+
+	// A dropped connection is replaced on the next command
+	store.conn.Close()
+	if _, err := store.IsRevoked("jti"); err == nil {
+		t.Fatal("Expected an error on the closed connection")
+	}
+	if _, err := store.IsRevoked("jti"); err != nil {
+		t.Fatalf("Expected the store to reconnect, got %v", err)
+	}
+	if len(fake.commands) != 2 {
+		t.Errorf("Expected 2 commands to reach the server, got %v", fake.commands)
+	}
+}
+
+func TestNewRedisStore_InvalidURL(t *testing.T) {
+	for _, rawURL := range []string{"http://localhost:6379", "redis://", "redis://localhost/db"} {
+		if _, err := NewRedisStore(rawURL); err == nil {
+			t.Errorf("Expected an error for %q", rawURL)
+		}
+	}
+
+	store, err := NewRedisStore("redis://localhost")
+	if err != nil || store.addr != "localhost:6379" {
+		t.Errorf("Expected the default port, got %v, %v", store, err)
+	}
+}
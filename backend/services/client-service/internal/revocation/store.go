@@ -0,0 +1,41 @@
+// Package revocation keeps the list of access tokens revoked before their
+// expiry, identified by their jti claim, and the tokens issued to each user
+// so all of a user's tokens can be revoked at once.
+package revocation
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+// Store holds revoked token IDs until the tokens would have expired anyway
+type Store interface {
+	// Track remembers a token issued to a user until it expires
+	Track(userID uuid.UUID, jti string, expiresAt time.Time) error
+	// Revoke adds tokens to the revocation list
+	Revoke(tokens []models.RevokedToken) error
+	// IsRevoked reports whether a token has been revoked
+	IsRevoked(jti string) (bool, error)
+	// RevokeUser revokes every unexpired token tracked for a user and
+	// returns them
+	RevokeUser(userID uuid.UUID) ([]models.RevokedToken, error)
+}
+
+// NewFromEnv creates a Redis store when REVOCATION_REDIS_URL is set, so
+// revocations are shared by every instance, or an in-memory store otherwise
+func NewFromEnv() (Store, error) {
+	redisURL := os.Getenv("REVOCATION_REDIS_URL")
+	if redisURL == "" {
+		return NewMemoryStore(), nil
+	}
+
+	store, err := NewRedisStore(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REVOCATION_REDIS_URL: %w", err)
+	}
+	return store, nil
+}
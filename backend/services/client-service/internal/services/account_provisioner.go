@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+// Retry settings for account provisioning. The delay doubles after each
+// failed attempt, so a user is given up on after about 15 seconds.
+const (
+	accountProvisionAttempts = 5
+	accountProvisionBackoff  = time.Second
+)
+
+// AccountProvisioner creates banking-service accounts for new users in the
+// background, so registration neither waits for nor fails with the
+// banking-service
+type AccountProvisioner struct {
+	bankingClient BankingClient
+	attempts      int
+	backoff       time.Duration
+	wg            sync.WaitGroup
+}
+
+// NewAccountProvisioner creates a new account provisioner
+func NewAccountProvisioner(bankingClient BankingClient) *AccountProvisioner {
+	return &AccountProvisioner{
+		bankingClient: bankingClient,
+		attempts:      accountProvisionAttempts,
+		backoff:       accountProvisionBackoff,
+	}
+}
+
+// ProvisionAsync starts creating the user's account and returns
+// immediately. Users whose account still could not be created once retries
+// run out are logged and picked up by ReconcileAccounts. A nil provisioner
+// does nothing.
+func (p *AccountProvisioner) ProvisionAsync(userID uuid.UUID) {
+	if p == nil {
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if _, err := p.provision(userID); err != nil {
+			log.Printf("Failed to provision account for user %s: %v", userID, err)
+		}
+	}()
+}
+
+// Wait blocks until background provisioning has finished
+func (p *AccountProvisioner) Wait() {
+	p.wg.Wait()
+}
+
+// provision asks the banking-service for the account until it succeeds or
+// the attempts run out, and reports whether the account was created
+func (p *AccountProvisioner) provision(userID uuid.UUID) (bool, error) {
+	delay := p.backoff
+	for attempt := 1; ; attempt++ {
+		created, err := p.bankingClient.ProvisionAccount(userID)
+		if err == nil {
+			return created, nil
+		}
+		if attempt >= p.attempts {
+			return false, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// AccountReconciliation summarizes a ReconcileAccounts run
+type AccountReconciliation struct {
+	Checked int
+	Created int
+	Failed  int
+}
+
+// ReconcileAccounts makes sure every active user has an account. Since
+// provisioning is idempotent, each user is provisioned again and users who
+// had no account get one. Users are paged through oldest first so new
+// registrations do not shift the pages.
+func (p *AccountProvisioner) ReconcileAccounts(userRepo repository.UserRepository) (*AccountReconciliation, error) {
+	result := &AccountReconciliation{}
+	opts := models.ListUsersOptions{Limit: MaxUserPageSize, SortBy: models.UserSortCreatedAt}
+
+	for {
+		users, _, err := userRepo.GetAllUsers(opts)
+		if err != nil {
+			return result, fmt.Errorf("failed to list users: %w", err)
+		}
+
+		for _, user := range users {
+			result.Checked++
+			created, err := p.provision(user.ID)
+			switch {
+			case err != nil:
+				result.Failed++
+				log.Printf("Failed to provision account for user %s: %v", user.ID, err)
+			case created:
+				result.Created++
+				log.Printf("Created missing account for user %s", user.ID)
+			}
+		}
+
+		if len(users) < opts.Limit {
+			return result, nil
+		}
+		opts.Offset += len(users)
+	}
+}
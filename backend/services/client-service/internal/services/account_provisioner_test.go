@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+)
+
+// accountBankingClient keeps the set of users with accounts and fails the
+// first failures calls for each user
+type accountBankingClient struct {
+	BankingClient
+	mu       sync.Mutex
+	accounts map[uuid.UUID]bool
+	calls    map[uuid.UUID]int
+	failures int
+}
+
+func newAccountBankingClient(failures int, existing ...uuid.UUID) *accountBankingClient {
+	c := &accountBankingClient{accounts: make(map[uuid.UUID]bool), calls: make(map[uuid.UUID]int), failures: failures}
+	for _, id := range existing {
+		c.accounts[id] = true
+	}
+	return c
+}
+
+func (c *accountBankingClient) ProvisionAccount(userID uuid.UUID) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[userID]++
+	if c.calls[userID] <= c.failures {
+		return false, errors.New("connection refused")
+	}
+	if c.accounts[userID] {
+		return false, nil
+	}
+	c.accounts[userID] = true
+	return true, nil
+}
+
+func (c *accountBankingClient) hasAccount(userID uuid.UUID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.accounts[userID]
+}
+
+func newTestAccountProvisioner(banking BankingClient) *AccountProvisioner {
+	p := NewAccountProvisioner(banking)
+	p.backoff = time.Millisecond
+	return p
+}
+
+func TestAccountProvisioner_RetriesWithBackoff(t *testing.T) {
+	userID := uuid.New()
+
+	banking := newAccountBankingClient(2)
+	p := newTestAccountProvisioner(banking)
+	p.ProvisionAsync(userID)
+	p.Wait()
+	if !banking.hasAccount(userID) || banking.calls[userID] != 3 {
+		t.Errorf("Expected the account after 3 calls, got %d calls", banking.calls[userID])
+	}
+
+	down := newAccountBankingClient(accountProvisionAttempts)
+	p = newTestAccountProvisioner(down)
+	if _, err := p.provision(userID); err == nil {
+		t.Error("Expected provisioning to give up")
+	}
+	if down.calls[userID] != accountProvisionAttempts {
+		t.Errorf("Expected %d attempts, got %d", accountProvisionAttempts, down.calls[userID])
+	}
+}
+
+func TestAuthService_RegisterUserProvisionsAccount(t *testing.T) {
+	banking := newAccountBankingClient(1)
+	provisioner := newTestAccountProvisioner(banking)
+	authService := NewAuthService(newFakeUserRepo(), newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, provisioner)
+
+	user, err := authService.RegisterUser(models.UserRegistration{Email: "new@example.com", Name: "New User", Password: "Correct-Horse-42"})
+	if err != nil {
+		t.Fatalf("RegisterUser returned error: %v", err)
+	}
+	if user.RegistrationSource != models.RegistrationSourceSelf {
+		t.Errorf("Expected a self registration, got %q", user.RegistrationSource)
+	}
+
+	provisioner.Wait()
+	if !banking.hasAccount(user.ID) {
+		t.Error("Expected an account to be provisioned for the new user")
+	}
+}
+
+// listingUserRepo pages through a fixed list of users
+type listingUserRepo struct {
+	*fakeUserRepo
+	users []models.User
+}
+
+func (r *listingUserRepo) GetAllUsers(opts models.ListUsersOptions) ([]models.User, int, error) {
+	start := min(opts.Offset, len(r.users))
+	end := min(start+opts.Limit, len(r.users))
+	return r.users[start:end], len(r.users), nil
+}
+
+func TestAccountProvisioner_ReconcileAccounts(t *testing.T) {
+	var users []models.User
+	for i := 0; i < MaxUserPageSize+5; i++ {
+		users = append(users, models.User{ID: uuid.New()})
+	}
+	banking := newAccountBankingClient(0, users[0].ID, users[MaxUserPageSize].ID)
+
+	result, err := newTestAccountProvisioner(banking).ReconcileAccounts(&listingUserRepo{users: users})
+	if err != nil {
+		t.Fatalf("ReconcileAccounts returned error: %v", err)
+	}
+	want := AccountReconciliation{Checked: len(users), Created: len(users) - 2}
+	if *result != want {
+		t.Errorf("Expected %+v, got %+v", want, *result)
+	}
+	for _, user := range users {
+		if !banking.hasAccount(user.ID) {
+			t.Fatalf("Expected user %s to have an account", user.ID)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"microbank/client-service/internal/models"
+)
+
+// adminDashboardCacheTTL is how long GetDashboard reuses each section before
+// fetching it again
+const adminDashboardCacheTTL = 30 * time.Second
+
+// AdminDashboardService brings together the admin stats of this service and
+// the banking-service for the admin dashboard
+type AdminDashboardService struct {
+	statsService  *AdminStatsService
+	bankingClient BankingClient
+	now           func() time.Time
+
+	mu        sync.Mutex
+	users     *models.AdminStats
+	usersAt   time.Time
+	banking   *models.BankingStats
+	bankingAt time.Time
+}
+
+// NewAdminDashboardService creates a new admin dashboard service
+func NewAdminDashboardService(statsService *AdminStatsService, bankingClient BankingClient) *AdminDashboardService {
+	return &AdminDashboardService{
+		statsService:  statsService,
+		bankingClient: bankingClient,
+		now:           time.Now,
+	}
+}
+
+// GetDashboard returns the stats of every service, fetched concurrently.
+// Sections are reused for adminDashboardCacheTTL. A section that cannot be
+// fetched is left nil rather than failing the dashboard, and is not cached,
+// so the next call tries its service again.
+func (s *AdminDashboardService) GetDashboard() *models.AdminDashboard {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	var wg sync.WaitGroup
+
+	if s.users == nil || !now.Before(s.usersAt.Add(adminDashboardCacheTTL)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			users, err := s.statsService.GetStats()
+			if err != nil {
+				log.Printf("Admin dashboard: user stats unavailable: %v", err)
+				s.users = nil
+				return
+			}
+			s.users, s.usersAt = users, now
+		}()
+	}
+
+	if s.banking == nil || !now.Before(s.bankingAt.Add(adminDashboardCacheTTL)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			banking, err := s.bankingClient.GetStats()
+			if err != nil {
+				log.Printf("Admin dashboard: banking stats unavailable: %v", err)
+				s.banking = nil
+				return
+			}
+			s.banking, s.bankingAt = banking, now
+		}()
+	}
+
+	wg.Wait()
+
+	dashboard := &models.AdminDashboard{GeneratedAt: now}
+	if s.users != nil {
+		users := *s.users
+		dashboard.Users = &users
+	}
+	if s.banking != nil {
+		banking := *s.banking
+		dashboard.Banking = &banking
+	}
+	return dashboard
+}
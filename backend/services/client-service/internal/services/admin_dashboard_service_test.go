@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/resilience"
+)
+
+func TestAdminDashboardService_GetDashboard(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	statsService := NewAdminStatsService(newFakeUserRepo(&models.User{ID: uuid.New(), CreatedAt: now}), newFakeRefreshTokenRepo())
+	statsService.now = func() time.Time { return now }
+	bankingClient := &fakeBankingClient{stats: models.BankingStats{Accounts: 3, TotalBalance: 250.5}}
+
+	svc := NewAdminDashboardService(statsService, bankingClient)
+	svc.now = func() time.Time { return now }
+
+	dashboard := svc.GetDashboard()
+	if dashboard.Users == nil || dashboard.Users.Users.Total != 1 {
+		t.Errorf("Expected the user stats, got %+v", dashboard.Users)
+	}
+	if dashboard.Banking == nil || dashboard.Banking.Accounts != 3 || dashboard.Banking.TotalBalance != 250.5 {
+		t.Errorf("Expected the banking stats, got %+v", dashboard.Banking)
+	}
+	if !dashboard.GeneratedAt.Equal(now) {
+		t.Errorf("Expected the dashboard generated at %v, got %v", now, dashboard.GeneratedAt)
+	}
+}
+
+func TestAdminDashboardService_GetDashboardWithBankingDown(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	statsService := NewAdminStatsService(newFakeUserRepo(&models.User{ID: uuid.New(), CreatedAt: now}), newFakeRefreshTokenRepo())
+	statsService.now = func() time.Time { return now }
+	bankingClient := &fakeBankingClient{
+		stats: models.BankingStats{Accounts: 3},
+		err:   fmt.Errorf("%w: connection refused", resilience.ErrDependencyUnavailable),
+	}
+
+	svc := NewAdminDashboardService(statsService, bankingClient)
+	svc.now = func() time.Time { return now }
+
+	// The banking section is unavailable but the rest of the dashboard is shown
+	dashboard := svc.GetDashboard()
+	if dashboard.Banking != nil {
+		t.Errorf("Expected the banking section to be unavailable, got %+v", dashboard.Banking)
+	}
+	if dashboard.Users == nil || dashboard.Users.Users.Total != 1 {
+		t.Errorf("Expected the user stats despite the banking-service being down, got %+v", dashboard.Users)
+	}
+
+	// Failed sections are not cached, so the next call tries again
+	bankingClient.err = nil
+	dashboard = svc.GetDashboard()
+	if dashboard.Banking == nil || dashboard.Banking.Accounts != 3 {
+		t.Errorf("Expected the banking stats once the banking-service is back, got %+v", dashboard.Banking)
+	}
+	if bankingClient.statsCalls != 2 {
+		t.Errorf("Expected the banking-service to be asked twice, got %d", bankingClient.statsCalls)
+	}
+}
+
+func TestAdminDashboardService_GetDashboardCachesFor30Seconds(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	statsService := NewAdminStatsService(newFakeUserRepo(), newFakeRefreshTokenRepo())
+	statsService.now = func() time.Time { return now }
+	bankingClient := &fakeBankingClient{stats: models.BankingStats{Accounts: 3}}
+
+	svc := NewAdminDashboardService(statsService, bankingClient)
+	svc.now = func() time.Time { return now }
+	svc.GetDashboard()
+
+	bankingClient.stats.Accounts = 4
+	svc.now = func() time.Time { return now.Add(29 * time.Second) }
+	if dashboard := svc.GetDashboard(); dashboard.Banking.Accounts != 3 || bankingClient.statsCalls != 1 {
+		t.Errorf("Expected cached banking stats, got %d accounts after %d calls", dashboard.Banking.Accounts, bankingClient.statsCalls)
+	}
+
+	svc.now = func() time.Time { return now.Add(30 * time.Second) }
+	if dashboard := svc.GetDashboard(); dashboard.Banking.Accounts != 4 || bankingClient.statsCalls != 2 {
+		t.Errorf("Expected fresh banking stats, got %d accounts after %d calls", dashboard.Banking.Accounts, bankingClient.statsCalls)
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+// adminStatsCacheTTL is how long GetStats reuses computed stats before
+// querying again
+const adminStatsCacheTTL = time.Minute
+
+// MaxSignupReportWeeks is the most weeks a signup report may cover
+const MaxSignupReportWeeks = 104
+
+// AdminStatsService computes signup and engagement stats for admins
+type AdminStatsService struct {
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	now              func() time.Time
+
+	mu     sync.Mutex
+	cached *models.AdminStats
+}
+
+// NewAdminStatsService creates a new admin stats service
+func NewAdminStatsService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository) *AdminStatsService {
+	return &AdminStatsService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		now:              time.Now,
+	}
+}
+
+// GetStats returns the current stats. They are computed with aggregate
+// queries and reused for adminStatsCacheTTL, so GeneratedAt may lag behind.
+// Days, weeks (starting Monday) and months are counted in UTC.
+func (s *AdminStatsService) GetStats() (*models.AdminStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	if s.cached != nil && now.Before(s.cached.GeneratedAt.Add(adminStatsCacheTTL)) {
+		stats := *s.cached
+		return &stats, nil
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	thisWeek := weekStart(today)
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	users, err := s.userRepo.CountUsers(today, thisWeek, thisMonth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	sessions, err := s.refreshTokenRepo.CountActive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active sessions: %w", err)
+	}
+
+	s.cached = &models.AdminStats{
+		Users:          *users,
+		ActiveSessions: sessions,
+		GeneratedAt:    now,
+	}
+	stats := *s.cached
+	return &stats, nil
+}
+
+// GetSignupReport counts signups by registration source for each of the
+// last weeks UTC weeks (starting Monday), the current week included,
+// oldest first. Weeks without signups are included with zero counts.
+func (s *AdminStatsService) GetSignupReport(weeks int) ([]models.SignupWeek, error) {
+	if weeks < 1 || weeks > MaxSignupReportWeeks {
+		return nil, fmt.Errorf("weeks must be between 1 and %d", MaxSignupReportWeeks)
+	}
+
+	now := s.now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	since := weekStart(today).AddDate(0, 0, -7*(weeks-1))
+
+	counts, err := s.userRepo.CountSignupsBySource(since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count signups: %w", err)
+	}
+
+	report := make([]models.SignupWeek, weeks)
+	for i := range report {
+		report[i].WeekStart = since.AddDate(0, 0, 7*i)
+		report[i].BySource = make(map[string]int, len(models.RegistrationSources))
+		for _, source := range models.RegistrationSources {
+			report[i].BySource[source] = 0
+		}
+	}
+	for _, count := range counts {
+		i := int(count.WeekStart.Sub(since).Hours()) / (7 * 24)
+		if i < 0 || i >= weeks {
+			continue
+		}
+		report[i].BySource[count.Source] += count.Count
+	}
+
+	return report, nil
+}
+
+// weekStart returns the Monday starting the week of day, a UTC midnight
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func TestAdminStatsService_GetStats(t *testing.T) {
+	// Wednesday 15 May 2024; the week started on Monday the 13th
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	verified := now.Add(-time.Hour)
+	deleted := now.Add(-time.Hour)
+
+	userRepo := newFakeUserRepo(
+		&models.User{ID: uuid.New(), CreatedAt: now.Add(-time.Hour), EmailVerifiedAt: &verified},
+		&models.User{ID: uuid.New(), CreatedAt: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), IsBlacklisted: true},
+		&models.User{ID: uuid.New(), CreatedAt: time.Date(2024, 5, 12, 23, 59, 0, 0, time.UTC), EmailVerifiedAt: &verified},
+		&models.User{ID: uuid.New(), CreatedAt: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)},
+		&models.User{ID: uuid.New(), CreatedAt: now.Add(-time.Hour), DeletedAt: &deleted},
+	)
+	refreshRepo := newFakeRefreshTokenRepo()
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)})
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), ExpiresAt: time.Now().Add(-time.Hour)})
+
+	svc := NewAdminStatsService(userRepo, refreshRepo)
+	svc.now = func() time.Time { return now }
+
+	stats, err := svc.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats returned error: %v", err)
+	}
+	want := models.UserCounts{Total: 4, NewToday: 1, NewThisWeek: 2, NewThisMonth: 3, Blacklisted: 1, EmailVerified: 2}
+	if stats.Users != want {
+		t.Errorf("Expected %+v, got %+v", want, stats.Users)
+	}
+	if stats.ActiveSessions != 1 {
+		t.Errorf("Expected 1 active session, got %d", stats.ActiveSessions)
+	}
+	if got := stats.VerifiedEmailPercent(); got != 50 {
+		t.Errorf("Expected 50%% verified, got %v", got)
+	}
+	if !stats.GeneratedAt.Equal(now) {
+		t.Errorf("Expected stats generated at %v, got %v", now, stats.GeneratedAt)
+	}
+}
+
+func TestAdminStatsService_GetStatsCachesForAMinute(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	userRepo := newFakeUserRepo(&models.User{ID: uuid.New(), CreatedAt: now})
+	svc := NewAdminStatsService(userRepo, newFakeRefreshTokenRepo())
+	svc.now = func() time.Time { return now }
+
+	if _, err := svc.GetStats(); err != nil {
+		t.Fatalf("GetStats returned error: %v", err)
+	}
+	userRepo.CreateUser(&models.User{ID: uuid.New(), CreatedAt: now})
+
+	now = now.Add(59 * time.Second)
+	stats, err := svc.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats returned error: %v", err)
+	}
+	if stats.Users.Total != 1 {
+		t.Errorf("Expected cached stats with 1 user, got %d", stats.Users.Total)
+	}
+
+	now = now.Add(time.Second)
+	stats, err = svc.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats returned error: %v", err)
+	}
+	if stats.Users.Total != 2 {
+		t.Errorf("Expected fresh stats with 2 users, got %d", stats.Users.Total)
+	}
+}
+
+func TestAdminStats_VerifiedEmailPercent(t *testing.T) {
+	tests := []struct {
+		total, verified int
+		want            float64
+	}{
+		{0, 0, 0},
+		{3, 2, 66.7},
+		{8, 8, 100},
+	}
+	for _, tt := range tests {
+		stats := models.AdminStats{Users: models.UserCounts{Total: tt.total, EmailVerified: tt.verified}}
+		if got := stats.VerifiedEmailPercent(); got != tt.want {
+			t.Errorf("VerifiedEmailPercent(%d of %d) = %v, want %v", tt.verified, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestAdminStatsService_GetSignupReport(t *testing.T) {
+	// Wednesday 15 May 2024; the week started on Monday the 13th
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	deleted := now.Add(-time.Hour)
+
+	userRepo := newFakeUserRepo(
+		&models.User{ID: uuid.New(), CreatedAt: now.Add(-time.Hour), RegistrationSource: models.RegistrationSourceSelf},
+		&models.User{ID: uuid.New(), CreatedAt: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), RegistrationSource: models.RegistrationSourceOAuthGoogle},
+		&models.User{ID: uuid.New(), CreatedAt: now.Add(-time.Hour), RegistrationSource: models.RegistrationSourceSelf, DeletedAt: &deleted},
+		&models.User{ID: uuid.New(), CreatedAt: time.Date(2024, 5, 12, 23, 59, 0, 0, time.UTC), RegistrationSource: models.RegistrationSourceInvite},
+		&models.User{ID: uuid.New(), CreatedAt: time.Date(2024, 4, 28, 12, 0, 0, 0, time.UTC), RegistrationSource: models.RegistrationSourceSelf},
+	)
+	svc := NewAdminStatsService(userRepo, newFakeRefreshTokenRepo())
+	svc.now = func() time.Time { return now }
+
+	report, err := svc.GetSignupReport(3)
+	if err != nil {
+		t.Fatalf("GetSignupReport returned error: %v", err)
+	}
+	if len(report) != 3 {
+		t.Fatalf("Expected 3 weeks, got %d", len(report))
+	}
+
+	want := []struct {
+		weekStart time.Time
+		bySource  map[string]int
+	}{
+		{time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC), map[string]int{"self": 0, "invite": 0, "oauth_google": 0, "admin_created": 0}},
+		{time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC), map[string]int{"self": 0, "invite": 1, "oauth_google": 0, "admin_created": 0}},
+		{time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), map[string]int{"self": 2, "invite": 0, "oauth_google": 1, "admin_created": 0}},
+	}
+	for i, w := range want {
+		if !report[i].WeekStart.Equal(w.weekStart) {
+			t.Errorf("Expected week %d to start on %v, got %v", i, w.weekStart, report[i].WeekStart)
+		}
+		for source, count := range w.bySource {
+			if report[i].BySource[source] != count {
+				t.Errorf("Expected %d %s signups in week %d, got %d", count, source, i, report[i].BySource[source])
+			}
+		}
+	}
+	if report[2].Total() != 3 {
+		t.Errorf("Expected 3 signups this week, got %d", report[2].Total())
+	}
+
+	for _, weeks := range []int{0, MaxSignupReportWeeks + 1} {
+		if _, err := svc.GetSignupReport(weeks); err == nil {
+			t.Errorf("Expected an error for %d weeks", weeks)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/mailer"
+)
+
+// passwordSetupTokenTTL is how long the setup link of a user created by
+// staff stays valid. It is longer than a reset link, since the customer
+// may not expect the email.
+const passwordSetupTokenTTL = 72 * time.Hour
+
+// AdminUserService lets staff open accounts on customers' behalf
+type AdminUserService struct {
+	userRepo       repository.UserRepository
+	resetTokenRepo repository.PasswordResetTokenRepository
+	emailSender    mailer.EmailSender
+	accounts       *AccountProvisioner
+}
+
+// NewAdminUserService creates a new admin user service. A nil accounts
+// leaves the bank account to the first deposit.
+func NewAdminUserService(userRepo repository.UserRepository, resetTokenRepo repository.PasswordResetTokenRepository, emailSender mailer.EmailSender, accounts *AccountProvisioner) *AdminUserService {
+	return &AdminUserService{
+		userRepo:       userRepo,
+		resetTokenRepo: resetTokenRepo,
+		emailSender:    emailSender,
+		accounts:       accounts,
+	}
+}
+
+// CreateUser creates a user without a password on a customer's behalf and
+// emails them a link to choose one. They cannot log in until they have.
+// The registration mode does not apply. A failure to send the link does
+// not undo the user, who can still get a link with a password reset, so
+// it is only reported in the result.
+func (s *AdminUserService) CreateUser(actor models.AuditActor, request models.AdminUserCreation) (*models.AdminCreatedUser, error) {
+	// Check if user already exists
+	exists, err := s.userRepo.UserExists(request.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user existence: %w", err)
+	}
+
+	if exists {
+		return nil, fmt.Errorf("%w: %s", ErrUserExists, request.Email)
+	}
+
+	// A soft-deleted account keeps its email until it is purged
+	if _, err := s.userRepo.GetDeletedUserByEmail(request.Email); err == nil {
+		return nil, ErrEmailPendingDeletion
+	}
+
+	// Create user
+	user := &models.User{
+		ID:                 uuid.New(),
+		Email:              request.Email,
+		Name:               request.Name,
+		MustSetPassword:    true,
+		RegistrationSource: models.RegistrationSourceAdminCreated,
+	}
+
+	audit := newAuditLogEntry(actor, models.AuditActionCreateUser, user.ID, map[string]interface{}{
+		"email": user.Email,
+	})
+	if err := s.userRepo.CreateUserByAdmin(user, audit); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	// Create the bank account in the background
+	s.accounts.ProvisionAsync(user.ID)
+
+	result := &models.AdminCreatedUser{User: user}
+	if err := s.sendSetupLink(user); err != nil {
+		log.Printf("Failed to send password setup link to user %s: %v", user.ID, err)
+	} else {
+		result.SetupEmailSent = true
+	}
+
+	return result, nil
+}
+
+// sendSetupLink issues a password setup token and emails it to the user.
+// Setup tokens are password reset tokens with a longer expiry, consumed by
+// the same reset endpoint.
+func (s *AdminUserService) sendSetupLink(user *models.User) error {
+	// Generate a random token; only its hash is stored
+	token, err := generateSecureToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate setup token: %w", err)
+	}
+
+	setupToken := &models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(passwordSetupTokenTTL),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.resetTokenRepo.Create(setupToken); err != nil {
+		return fmt.Errorf("failed to save setup token: %w", err)
+	}
+
+	// Send the setup link
+	link := fmt.Sprintf("%s?token=%s", passwordSetupURL(), token)
+	data := map[string]string{"Name": user.Name, "Link": link}
+	if err := sendEmail(s.emailSender, user.Email, user.ID, "password_setup", data); err != nil {
+		return fmt.Errorf("failed to send setup email: %w", err)
+	}
+
+	return nil
+}
+
+// passwordSetupURL returns the frontend page that accepts setup tokens
+func passwordSetupURL() string {
+	if url := os.Getenv("PASSWORD_SETUP_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000/set-password"
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+)
+
+func TestAdminUserService_CreateUserRequiresPasswordSetup(t *testing.T) {
+	userRepo := newFakeUserRepo()
+	resetRepo := newFakeResetTokenRepo()
+	sender := &fakeEmailSender{}
+	loginEvents := &fakeLoginEventRepo{}
+	svc := NewAdminUserService(userRepo, resetRepo, sender, nil)
+	authService := NewAuthService(userRepo, newFakeRefreshTokenRepo(), loginEvents, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil)
+	resetService := NewPasswordResetService(userRepo, newFakeRefreshTokenRepo(), resetRepo, sender, passwordpolicy.Default(), testHasher, nil)
+	actor := models.AuditActor{AdminID: uuid.New()}
+
+	created, err := svc.CreateUser(actor, models.AdminUserCreation{Email: "branch@example.com", Name: "Branch Customer"})
+	if err != nil {
+		t.Fatalf("CreateUser returned error: %v", err)
+	}
+	user := created.User
+	if !created.SetupEmailSent || !user.MustSetPassword || user.HasPassword() || user.RegistrationSource != models.RegistrationSourceAdminCreated {
+		t.Fatalf("Unexpected created user: %+v (email sent %v)", user, created.SetupEmailSent)
+	}
+
+	// The setup link outlives a reset link
+	for _, token := range resetRepo.tokens {
+		if token.UserID != user.ID || token.ExpiresAt.Before(time.Now().Add(passwordSetupTokenTTL-time.Minute)) {
+			t.Errorf("Expected a %v setup token for the user, got %+v", passwordSetupTokenTTL, token)
+		}
+	}
+	email, ok := sender.last()
+	if !ok || email.to != "branch@example.com" || email.subject != "Set up your Microbank password" {
+		t.Fatalf("Expected a setup email, got %+v", email)
+	}
+
+	// Login is blocked until the password is set
+	login := models.UserLogin{Email: "branch@example.com", Password: "Correct-Horse-42"}
+	if _, _, err := authService.LoginUser(login, models.LoginMetadata{}); !errors.Is(err, ErrPasswordSetupRequired) {
+		t.Fatalf("Expected ErrPasswordSetupRequired, got %v", err)
+	}
+	if last := loginEvents.events[len(loginEvents.events)-1]; last.FailureReason != models.LoginFailurePasswordSetup {
+		t.Errorf("Expected a password setup failure to be recorded, got %q", last.FailureReason)
+	}
+
+	token := extractToken(t, email.body)
+	if err := resetService.ResetPassword(models.ResetPasswordRequest{Token: token, NewPassword: login.Password}); err != nil {
+		t.Fatalf("ResetPassword returned error: %v", err)
+	}
+	if _, _, err := authService.LoginUser(login, models.LoginMetadata{}); err != nil {
+		t.Errorf("Expected login to succeed once the password is set, got %v", err)
+	}
+
+	if _, err := svc.CreateUser(actor, models.AdminUserCreation{Email: "branch@example.com", Name: "Someone Else"}); !errors.Is(err, ErrUserExists) {
+		t.Errorf("Expected ErrUserExists for a taken email, got %v", err)
+	}
+}
+
+func TestAdminUserService_CreateUserReportsUnsentSetupEmail(t *testing.T) {
+	userRepo := newFakeUserRepo()
+	svc := NewAdminUserService(userRepo, newFakeResetTokenRepo(), &fakeEmailSender{err: errors.New("smtp down")}, nil)
+
+	created, err := svc.CreateUser(models.AuditActor{AdminID: uuid.New()}, models.AdminUserCreation{Email: "branch@example.com", Name: "Branch Customer"})
+	if err != nil {
+		t.Fatalf("CreateUser returned error: %v", err)
+	}
+	if created.SetupEmailSent {
+		t.Error("Expected the setup email to be reported as not sent")
+	}
+	if stored, _ := userRepo.GetUserByEmail("branch@example.com"); stored == nil {
+		t.Error("Expected the user to be created anyway")
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+// Page size bounds for audit log listings and exports
+const (
+	DefaultAuditLogPageSize = 50
+	MaxAuditLogPageSize     = 200
+	MaxAuditLogExportSize   = 10000
+)
+
+// AuditLogService handles reading the admin audit log
+type AuditLogService struct {
+	auditLogRepo repository.AuditLogRepository
+}
+
+// NewAuditLogService creates a new audit log service
+func NewAuditLogService(auditLogRepo repository.AuditLogRepository) *AuditLogService {
+	return &AuditLogService{
+		auditLogRepo: auditLogRepo,
+	}
+}
+
+// ListAuditLog retrieves one page of audit log entries matching the filter.
+// The page size is clamped to MaxAuditLogPageSize.
+func (s *AuditLogService) ListAuditLog(filter models.AuditLogFilter) (*models.AuditLogPage, error) {
+	// Set default values if not provided
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditLogPageSize
+	}
+	if filter.Limit > MaxAuditLogPageSize {
+		filter.Limit = MaxAuditLogPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	entries, total, err := s.auditLogRepo.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+
+	return &models.AuditLogPage{
+		Entries: entries,
+		Total:   total,
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+	}, nil
+}
+
+// ExportAuditLog retrieves up to MaxAuditLogExportSize of the newest audit
+// log entries matching the filter, ignoring its paging
+func (s *AuditLogService) ExportAuditLog(filter models.AuditLogFilter) ([]models.AuditLogEntry, error) {
+	filter.Limit = MaxAuditLogExportSize
+	filter.Offset = 0
+
+	entries, _, err := s.auditLogRepo.List(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// newAuditLogEntry builds the audit log entry for an admin action on a user
+func newAuditLogEntry(actor models.AuditActor, action string, targetUserID uuid.UUID, metadata map[string]interface{}) *models.AuditLogEntry {
+	return &models.AuditLogEntry{
+		ID:           uuid.New(),
+		AdminID:      actor.AdminID,
+		Action:       action,
+		TargetUserID: &targetUserID,
+		Metadata:     metadata,
+		RequestID:    actor.RequestID,
+		CreatedAt:    time.Now(),
+	}
+}
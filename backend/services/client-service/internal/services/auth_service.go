@@ -2,32 +2,91 @@ package services
 
 import (
 	"fmt"
-	"os"
+	"log"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordhash"
+	"microbank/client-service/internal/passwordpolicy"
 	"microbank/client-service/internal/repository"
+	"microbank/client-service/internal/revocation"
+	"microbank/pkg/authmw"
+	sharedjwt "microbank/pkg/jwt"
+	"microbank/pkg/redact"
 )
 
 // AuthService handles authentication-related business logic
 type AuthService struct {
 	userRepo         repository.UserRepository
 	refreshTokenRepo repository.RefreshTokenRepository
+	loginEventRepo   repository.LoginEventRepository
+	passwordPolicy   *passwordpolicy.Policy
+	passwordHasher   passwordhash.PasswordHasher
+	passwordHistory  *PasswordHistoryService
+	bankingClient    BankingClient
+	deletionGrace    time.Duration
+	loginAlerts      *LoginAlertService
+	invitations      *InvitationService
+	tokens           *sharedjwt.TokenManager
+	revocations      revocation.Store
+	maxRefreshTokens int
+	accounts         *AccountProvisioner
+
+	// lastLoginOnRefresh counts token refreshes as logins
+	lastLoginOnRefresh bool
 }
 
-// NewAuthService creates a new authentication service
-func NewAuthService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository) *AuthService {
+// NewAuthService creates a new authentication service. Users who delete
+// their own account can cancel the deletion by logging in within
+// deletionGrace. A nil loginAlerts turns new-device alerts off, and a nil
+// invitations leaves registration open. Access tokens are signed and
+// verified with tokens, and their IDs are tracked in revocations so they
+// can be revoked before they expire. Each user keeps at most
+// maxRefreshTokens refresh tokens, the oldest being evicted on login; zero
+// means no limit. New users get a bank account through accounts; a nil
+// accounts leaves that to the first deposit.
+func NewAuthService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, loginEventRepo repository.LoginEventRepository, passwordPolicy *passwordpolicy.Policy, passwordHasher passwordhash.PasswordHasher, passwordHistory *PasswordHistoryService, bankingClient BankingClient, deletionGrace time.Duration, loginAlerts *LoginAlertService, invitations *InvitationService, tokens *sharedjwt.TokenManager, revocations revocation.Store, maxRefreshTokens int, accounts *AccountProvisioner) *AuthService {
 	return &AuthService{
 		userRepo:         userRepo,
 		refreshTokenRepo: refreshTokenRepo,
+		loginEventRepo:   loginEventRepo,
+		passwordPolicy:   passwordPolicy,
+		passwordHasher:   passwordHasher,
+		passwordHistory:  passwordHistory,
+		bankingClient:    bankingClient,
+		deletionGrace:    deletionGrace,
+		loginAlerts:      loginAlerts,
+		invitations:      invitations,
+		tokens:           tokens,
+		revocations:      revocations,
+		maxRefreshTokens: maxRefreshTokens,
+		accounts:         accounts,
 	}
 }
 
-// RegisterUser handles user registration
+// WithLastLoginOnRefresh sets whether refreshing an access token updates
+// the user's last login, as logging in does
+func (s *AuthService) WithLastLoginOnRefresh(enabled bool) *AuthService {
+	s.lastLoginOnRefresh = enabled
+	return s
+}
+
+// RegisterUser handles user registration. When registration is
+// invite-only, the invitation is marked used in the same transaction that
+// creates the user.
 func (s *AuthService) RegisterUser(registration models.UserRegistration) (*models.User, error) {
+	// Check the registration mode and invitation before revealing whether
+	// the email is taken
+	var invitation *models.Invitation
+	if s.invitations != nil {
+		var err error
+		invitation, err = s.invitations.checkRegistration(registration.Email, registration.InvitationCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Check if user already exists
 	exists, err := s.userRepo.UserExists(registration.Email)
 	if err != nil {
@@ -35,88 +94,256 @@ func (s *AuthService) RegisterUser(registration models.UserRegistration) (*model
 	}
 
 	if exists {
-		return nil, fmt.Errorf("user with email %s already exists", registration.Email)
+		return nil, fmt.Errorf("%w: %s", ErrUserExists, registration.Email)
+	}
+
+	// A soft-deleted account keeps its email until it is purged
+	if _, err := s.userRepo.GetDeletedUserByEmail(registration.Email); err == nil {
+		return nil, ErrEmailPendingDeletion
+	}
+
+	// Enforce password policy
+	if err := s.passwordPolicy.Validate(registration.Password, registration.Email, registration.Name); err != nil {
+		return nil, err
 	}
 
 	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(registration.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordHasher.Hash(registration.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	// Create user
 	user := &models.User{
-		ID:           uuid.New(),
-		Email:        registration.Email,
-		Name:         registration.Name,
-		PasswordHash: string(hashedPassword),
-		IsBlacklisted: false,
-		IsAdmin:      false,
+		ID:                 uuid.New(),
+		Email:              registration.Email,
+		Name:               registration.Name,
+		PasswordHash:       hashedPassword,
+		IsBlacklisted:      false,
+		IsAdmin:            false,
+		RegistrationSource: models.RegistrationSourceSelf,
 	}
 
 	// Save user to database
+	if invitation != nil {
+		user.RegistrationSource = models.RegistrationSourceInvite
+		if err := s.userRepo.CreateInvitedUser(user, invitation.ID); err != nil {
+			// Report why if the invitation was used or revoked meanwhile
+			if _, checkErr := s.invitations.checkRegistration(registration.Email, registration.InvitationCode); checkErr != nil {
+				return nil, checkErr
+			}
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+		s.accounts.ProvisionAsync(user.ID)
+		return user, nil
+	}
+
 	if err := s.userRepo.CreateUser(user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	// Create the bank account in the background
+	s.accounts.ProvisionAsync(user.ID)
+
 	return user, nil
 }
 
-// LoginUser handles user authentication
-func (s *AuthService) LoginUser(login models.UserLogin) (*models.User, string, string, error) {
+// Session holds the tokens issued when a user signs in
+type Session struct {
+	AccessToken           string
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+// LoginUser handles user authentication. Every attempt is recorded in the
+// login audit trail. Users who ask to be remembered get a long-lived refresh
+// token; others get a short-lived one.
+func (s *AuthService) LoginUser(login models.UserLogin, meta models.LoginMetadata) (*models.User, *Session, error) {
+	meta.Method = models.LoginMethodPassword
+
 	// Get user by email
 	user, err := s.userRepo.GetUserByEmail(login.Email)
 	if err != nil {
-		return nil, "", "", fmt.Errorf("invalid credentials")
+		user, err = s.loginDeletedUser(login, meta)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Check if user is blacklisted
 	if user.IsBlacklisted {
-		return nil, "", "", fmt.Errorf("account has been suspended")
+		s.recordLoginEvent(&user.ID, login.Email, meta, models.LoginFailureAccountSuspended)
+		return nil, nil, suspensionError(user)
 	}
 
-	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(login.Password)); err != nil {
-		return nil, "", "", fmt.Errorf("invalid credentials")
+	// Users created by staff choose a password from their setup link first
+	if user.MustSetPassword {
+		s.recordLoginEvent(&user.ID, login.Email, meta, models.LoginFailurePasswordSetup)
+		return nil, nil, ErrPasswordSetupRequired
+	}
+
+	// Verify password. Users who signed up with an OAuth provider have none
+	// until they set one with a password reset.
+	if !user.HasPassword() || s.passwordHasher.Verify(user.PasswordHash, login.Password) != nil {
+		s.recordLoginEvent(&user.ID, login.Email, meta, models.LoginFailureInvalidPassword)
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	// Upgrade the stored hash if it was made with an older algorithm or cost
+	if s.passwordHasher.NeedsRehash(user.PasswordHash) {
+		s.upgradePasswordHash(user, login.Password)
+	}
+
+	session, err := s.startSession(user, login.Email, meta, login.RememberMe)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, session, nil
+}
+
+// startSession issues tokens for an authenticated user and records the
+// successful login. It is shared by every way of signing in.
+func (s *AuthService) startSession(user *models.User, email string, meta models.LoginMetadata, rememberMe bool) (*Session, error) {
+	// An admin force logout may require a password reset first
+	if user.MustResetPassword {
+		s.recordLoginEvent(&user.ID, email, meta, models.LoginFailurePasswordReset)
+		return nil, ErrPasswordResetRequired
+	}
+
+	// Users created by staff must set a password first, however they sign in
+	if user.MustSetPassword {
+		s.recordLoginEvent(&user.ID, email, meta, models.LoginFailurePasswordSetup)
+		return nil, ErrPasswordSetupRequired
 	}
 
 	// Generate JWT token
 	accessToken, err := s.generateAccessToken(user)
 	if err != nil {
-		return nil, "", "", fmt.Errorf("failed to generate access token: %w", err)
+		s.recordLoginEvent(&user.ID, email, meta, models.LoginFailureInternalError)
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
 	// Generate refresh token
-	refreshToken, err := s.generateRefreshToken(user.ID)
+	refreshToken, expiresAt, err := s.generateRefreshToken(user.ID, rememberMe)
+	if err != nil {
+		s.recordLoginEvent(&user.ID, email, meta, models.LoginFailureInternalError)
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	s.recordLoginEvent(&user.ID, email, meta, "")
+	s.touchLastLogin(user)
+	if s.loginAlerts != nil {
+		s.loginAlerts.CheckLogin(user, meta, time.Now())
+	}
+	return &Session{AccessToken: accessToken, RefreshToken: refreshToken, RefreshTokenExpiresAt: expiresAt}, nil
+}
+
+// loginDeletedUser handles a login attempt for an email with no active
+// account. Users who deleted their own account get it back by logging in
+// within the grace period; every other attempt fails. Only a correct
+// password reveals that the account was deleted, so deletions cannot be
+// probed by email alone.
+func (s *AuthService) loginDeletedUser(login models.UserLogin, meta models.LoginMetadata) (*models.User, error) {
+	deleted, err := s.userRepo.GetDeletedUserByEmail(login.Email)
+	if err != nil || s.passwordHasher.Verify(deleted.PasswordHash, login.Password) != nil {
+		s.recordLoginEvent(nil, login.Email, meta, models.LoginFailureUnknownEmail)
+		return nil, ErrInvalidCredentials
+	}
+
+	deletedAfter := time.Now().Add(-s.deletionGrace)
+	if !deleted.SelfDeleted || !deleted.DeletedAt.After(deletedAfter) {
+		s.recordLoginEvent(&deleted.ID, login.Email, meta, models.LoginFailureAccountDeleted)
+		return nil, ErrAccountDeleted
+	}
+
+	// Cancel the deletion, letting the banking-service clear the orphaned
+	// account flag first
+	if err := s.bankingClient.NotifyUserRestored(deleted.ID); err != nil {
+		s.recordLoginEvent(&deleted.ID, login.Email, meta, models.LoginFailureInternalError)
+		return nil, fmt.Errorf("%w: %w", ErrBankingServiceUnavailable, err)
+	}
+	if err := s.userRepo.RestoreUser(deleted.ID, deletedAfter, nil); err != nil {
+		s.recordLoginEvent(&deleted.ID, login.Email, meta, models.LoginFailureInternalError)
+		return nil, fmt.Errorf("failed to cancel account deletion: %w", err)
+	}
+
+	log.Printf("Cancelled self-service deletion of user %s on login", deleted.ID)
+	deleted.DeletedAt, deleted.SelfDeleted = nil, false
+	return deleted, nil
+}
+
+// upgradePasswordHash re-hashes a just-verified password with the current
+// algorithm and cost. Errors are logged rather than returned so an upgrade
+// can never block a login.
+func (s *AuthService) upgradePasswordHash(user *models.User, password string) {
+	hashedPassword, err := s.passwordHasher.Hash(password)
 	if err != nil {
-		return nil, "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+		log.Printf("Failed to upgrade password hash for user %s: %v", user.ID, err)
+		return
 	}
 
-	return user, accessToken, refreshToken, nil
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		log.Printf("Failed to store upgraded password hash for user %s: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = hashedPassword
+}
+
+// touchLastLogin records the user's latest activity. Errors are logged
+// rather than returned so tracking can never block a login.
+func (s *AuthService) touchLastLogin(user *models.User) {
+	now := time.Now()
+	if err := s.userRepo.UpdateLastLogin(user.ID, now); err != nil {
+		log.Printf("Failed to update last login for user %s: %v", user.ID, err)
+		return
+	}
+	user.LastLoginAt = &now
+}
+
+// recordLoginEvent writes a login attempt to the audit trail. An empty
+// failureReason marks a successful login. Errors are logged rather than
+// returned so auditing can never block a login.
+func (s *AuthService) recordLoginEvent(userID *uuid.UUID, email string, meta models.LoginMetadata, failureReason string) {
+	event := &models.LoginEvent{
+		ID:            uuid.New(),
+		UserID:        userID,
+		Email:         email,
+		Success:       failureReason == "",
+		Method:        meta.Method,
+		IPAddress:     meta.IPAddress,
+		UserAgent:     meta.UserAgent,
+		FailureReason: failureReason,
+		CreatedAt:     time.Now(),
+	}
+
+	if err := s.loginEventRepo.Create(event); err != nil {
+		log.Printf("Failed to record login event for %s: %v", redact.Email(email), redact.Text(err.Error()))
+	}
 }
 
 // RefreshToken generates a new access token using a refresh token
 func (s *AuthService) RefreshToken(refreshTokenString string) (string, error) {
-	// Validate refresh token
-	refreshToken, err := s.refreshTokenRepo.GetByToken(refreshTokenString)
+	// Validate refresh token. Only hashes are stored, so the lookup costs the
+	// same whether or not the token exists.
+	refreshToken, err := s.refreshTokenRepo.GetByToken(hashToken(refreshTokenString))
 	if err != nil {
-		return "", fmt.Errorf("invalid refresh token: %w", err)
+		return "", fmt.Errorf("%w: %w", ErrRefreshTokenInvalid, err)
 	}
 
 	// Check if refresh token is expired
 	if time.Now().After(refreshToken.ExpiresAt) {
-		return "", fmt.Errorf("refresh token expired")
+		return "", ErrRefreshTokenExpired
 	}
 
 	// Get user
 	user, err := s.userRepo.GetUserByID(refreshToken.UserID)
 	if err != nil {
-		return "", fmt.Errorf("user not found: %w", err)
+		return "", fmt.Errorf("%w: %w", ErrUserNotFound, err)
 	}
 
 	// Check if user is blacklisted
 	if user.IsBlacklisted {
-		return "", fmt.Errorf("account has been suspended")
+		return "", suspensionError(user)
 	}
 
 	// Generate new access token
@@ -125,73 +352,271 @@ func (s *AuthService) RefreshToken(refreshTokenString string) (string, error) {
 		return "", fmt.Errorf("failed to generate access token: %w", err)
 	}
 
+	// Optionally count token refreshes as activity
+	if s.lastLoginOnRefresh {
+		s.touchLastLogin(user)
+	}
+
 	return accessToken, nil
 }
 
-// ValidateToken validates an access token and returns user information
-func (s *AuthService) ValidateToken(tokenString string) (*models.User, error) {
+// Logout ends the session holding a refresh token by deleting the token.
+// Unknown tokens return ErrRefreshTokenInvalid.
+func (s *AuthService) Logout(refreshTokenString string) error {
+	refreshToken, err := s.refreshTokenRepo.GetByToken(hashToken(refreshTokenString))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRefreshTokenInvalid, err)
+	}
+
+	if err := s.refreshTokenRepo.Delete(refreshToken.ID); err != nil {
+		return fmt.Errorf("failed to delete refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// TokenValidation is the current state of the user an access token was
+// issued to
+type TokenValidation struct {
+	User *models.User
+	// StaleClaims names the claims that no longer match the user, such as a
+	// changed name or a revoked admin role
+	StaleClaims []string
+}
+
+// ValidateToken validates an access token against the database. The user
+// is reloaded, so tokens of users deleted, blacklisted or logged out since
+// the token was issued are rejected even though the token itself is valid.
+func (s *AuthService) ValidateToken(tokenString string) (*TokenValidation, error) {
 	// Parse and validate the token
-	claims, err := s.parseToken(tokenString)
+	claims, err := s.tokens.ValidateToken(tokenString)
 	if err != nil {
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
 
-	// Extract user ID from claims map
-	userIDStr, ok := (*claims)["user_id"].(string)
-	if !ok {
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
 		return nil, fmt.Errorf("invalid user ID in token")
 	}
 
 	// Get user from database to ensure data is current
-	user, err := s.userRepo.GetUserByID(uuid.MustParse(userIDStr))
+	user, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
 	}
 
 	// Check if user is blacklisted
 	if user.IsBlacklisted {
-		return nil, fmt.Errorf("account has been suspended")
+		return nil, suspensionError(user)
 	}
 
-	return user, nil
+	// Reject tokens issued before the user's last role or status change
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, ErrTokenRevoked
+	}
+
+	// Reject tokens revoked individually, such as by a force logout
+	if claims.ID != "" {
+		revoked, err := s.revocations.IsRevoked(claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	return &TokenValidation{User: user, StaleClaims: staleClaims(claims, user)}, nil
 }
 
-// generateAccessToken creates a new JWT access token
-func (s *AuthService) generateAccessToken(user *models.User) (string, error) {
-	// Get JWT secret from environment
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return "", fmt.Errorf("JWT_SECRET environment variable not set")
+// staleClaims lists the claims whose values differ from the user's current
+// state
+func staleClaims(claims *sharedjwt.Claims, user *models.User) []string {
+	stale := []string{}
+	if claims.Email != user.Email {
+		stale = append(stale, "email")
+	}
+	if claims.Name != user.Name {
+		stale = append(stale, "name")
+	}
+	if claims.IsAdmin != user.IsAdmin {
+		stale = append(stale, "is_admin")
+	}
+	if !sameRoles(authmw.ClaimRoles(claims), user.Roles) {
+		stale = append(stale, "roles")
+	}
+	if claims.IsBlacklisted != user.IsBlacklisted {
+		stale = append(stale, "is_blacklisted")
 	}
+	return stale
+}
 
-	// Create claims
-	claims := jwt.MapClaims{
-		"user_id":        user.ID.String(),
-		"email":          user.Email,
-		"name":           user.Name,
-		"is_admin":       user.IsAdmin,
-		"is_blacklisted": user.IsBlacklisted,
-		"exp":            time.Now().Add(15 * time.Minute).Unix(), // 15 minutes expiry
-		"iat":            time.Now().Unix(),
-		"type":           "access",
+// sameRoles reports whether a and b hold the same roles, in any order
+func sameRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, role := range a {
+		if !authmw.HasRole(b, role) {
+			return false
+		}
 	}
+	return true
+}
 
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// ChangePassword verifies the current password, stores the new one and
+// revokes every refresh token so other sessions must log in again
+func (s *AuthService) ChangePassword(userID uuid.UUID, change models.PasswordChange) error {
+	// Get user
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
 
-	// Sign token
-	tokenString, err := token.SignedString([]byte(jwtSecret))
+	// Users without a password set one with a password reset instead
+	if !user.HasPassword() {
+		return ErrPasswordNotSet
+	}
+
+	// Verify current password
+	if err := s.passwordHasher.Verify(user.PasswordHash, change.CurrentPassword); err != nil {
+		return ErrInvalidCurrentPassword
+	}
+
+	// Reject reusing the identical password
+	if err := s.passwordHasher.Verify(user.PasswordHash, change.NewPassword); err == nil {
+		return ErrPasswordUnchanged
+	}
+
+	// Enforce password policy
+	if err := s.passwordPolicy.Validate(change.NewPassword, user.Email, user.Name); err != nil {
+		return err
+	}
+
+	// Reject recently used passwords
+	if err := s.passwordHistory.CheckReuse(user, change.NewPassword); err != nil {
+		return err
+	}
+
+	// Hash the new password
+	hashedPassword, err := s.passwordHasher.Hash(change.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// Save new password hash
+	if err := s.userRepo.UpdatePassword(userID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	s.passwordHistory.Record(userID, hashedPassword)
+
+	// Revoke all sessions
+	if err := s.refreshTokenRepo.DeleteByUserID(userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAccount soft-deletes the user's own account once their password is
+// confirmed and their banking balance is zero. All sessions are revoked. The
+// account is purged once the grace period passes unless the user logs back
+// in first. It returns when the account will be purged.
+func (s *AuthService) DeleteAccount(userID uuid.UUID, request models.AccountDeletion) (time.Time, error) {
+	// Get user
+	user, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+		return time.Time{}, fmt.Errorf("%w: %w", ErrUserNotFound, err)
 	}
 
+	// Verify password
+	if !user.HasPassword() {
+		return time.Time{}, ErrPasswordNotSet
+	}
+	if err := s.passwordHasher.Verify(user.PasswordHash, request.Password); err != nil {
+		return time.Time{}, ErrInvalidCurrentPassword
+	}
 
+	// Admins must have their role revoked first so the last admin cannot
+	// lock everyone out
+	if user.IsAdmin {
+		return time.Time{}, ErrAdminSelfDeletion
+	}
+
+	// Money must be withdrawn before the account goes away
+	balance, err := s.bankingClient.GetUserBalance(userID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %w", ErrBankingServiceUnavailable, err)
+	}
+	if balance != 0 {
+		return time.Time{}, ErrAccountBalanceNotZero
+	}
+
+	// Revoke all sessions
+	if err := s.refreshTokenRepo.DeleteByUserID(userID); err != nil {
+		return time.Time{}, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	// Let the banking-service flag the orphaned account
+	if err := s.bankingClient.NotifyUserDeleted(userID); err != nil {
+		return time.Time{}, fmt.Errorf("%w: %w", ErrBankingServiceUnavailable, err)
+	}
+
+	// Delete user
+	deletedAt := time.Now()
+	if err := s.userRepo.DeleteUser(userID, true, nil); err != nil {
+		return time.Time{}, fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return deletedAt.Add(s.deletionGrace), nil
+}
+
+// VerifyPassword reports whether password is the user's, for services that
+// ask the user to confirm an action with it. Users without a password never
+// match.
+func (s *AuthService) VerifyPassword(userID uuid.UUID, password string) (bool, error) {
+	// Get user
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	if !user.HasPassword() {
+		return false, nil
+	}
+	return s.passwordHasher.Verify(user.PasswordHash, password) == nil, nil
+}
+
+// generateAccessToken creates a new JWT access token
+func (s *AuthService) generateAccessToken(user *models.User) (string, error) {
+	claims := &sharedjwt.Claims{
+		UserID:        user.ID.String(),
+		Email:         user.Email,
+		Name:          user.Name,
+		IsAdmin:       user.IsAdmin,
+		IsBlacklisted: user.IsBlacklisted,
+		Roles:         user.Roles,
+		TokenVersion:  user.TokenVersion,
+	}
+
+	// Sign token with the active key
+	tokenString, err := s.tokens.GenerateAccessToken(claims)
+	if err != nil {
+		return "", err
+	}
+
+	// Remember the token so a force logout can revoke it
+	if err := s.revocations.Track(user.ID, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return "", fmt.Errorf("failed to track access token: %w", err)
+	}
 
 	return tokenString, nil
 }
 
-// generateRefreshToken creates a new refresh token
-func (s *AuthService) generateRefreshToken(userID uuid.UUID) (string, error) {
+// generateRefreshToken creates a new refresh token, evicting the user's
+// oldest tokens when they would exceed maxRefreshTokens. It returns the
+// token and when it expires.
+func (s *AuthService) generateRefreshToken(userID uuid.UUID, rememberMe bool) (string, time.Time, error) {
 	// Generate a random refresh token
 	refreshToken := uuid.New().String()
 
@@ -199,42 +624,26 @@ func (s *AuthService) generateRefreshToken(userID uuid.UUID) (string, error) {
 	refreshTokenRecord := &models.RefreshToken{
 		ID:        uuid.New(),
 		UserID:    userID,
-		TokenHash: refreshToken, // In production, hash this token
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour), // 7 days expiry
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.tokens.RefreshTokenTTL(rememberMe)),
+		CreatedAt: time.Now(),
 	}
 
 	// Save refresh token to database
-	if err := s.refreshTokenRepo.Create(refreshTokenRecord); err != nil {
-		return "", fmt.Errorf("failed to save refresh token: %w", err)
-	}
-
-	return refreshToken, nil
-}
-
-// parseToken parses and validates a JWT token
-func (s *AuthService) parseToken(tokenString string) (*jwt.MapClaims, error) {
-	// Get JWT secret from environment
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET environment variable not set")
-	}
-
-	// Parse token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	if s.maxRefreshTokens <= 0 {
+		if err := s.refreshTokenRepo.Create(refreshTokenRecord); err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to save refresh token: %w", err)
 		}
-		return []byte(jwtSecret), nil
-	})
+		return refreshToken, refreshTokenRecord.ExpiresAt, nil
+	}
 
+	evicted, err := s.refreshTokenRepo.CreateWithLimit(refreshTokenRecord, s.maxRefreshTokens)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse token: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to save refresh token: %w", err)
 	}
-
-	// Extract claims
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return &claims, nil
+	if evicted > 0 {
+		log.Printf("Evicted %d refresh tokens for user %s over the limit of %d", evicted, userID, s.maxRefreshTokens)
 	}
-	return nil, fmt.Errorf("invalid token")
+
+	return refreshToken, refreshTokenRecord.ExpiresAt, nil
 }
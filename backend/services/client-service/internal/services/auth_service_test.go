@@ -0,0 +1,264 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordhash"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+)
+
+func TestAuthService_LoginUser_RecordsEvents(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	user := newTestUser(t, "password123")
+	events := &fakeLoginEventRepo{}
+	svc := NewAuthService(newFakeUserRepo(user), newFakeRefreshTokenRepo(), events, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil)
+	meta := models.LoginMetadata{IPAddress: "203.0.113.7", UserAgent: "test-agent"}
+
+	svc.LoginUser(models.UserLogin{Email: "nobody@example.com", Password: "password123"}, meta)
+	svc.LoginUser(models.UserLogin{Email: user.Email, Password: "wrong"}, meta)
+	if _, _, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: "password123"}, meta); err != nil {
+		t.Fatalf("LoginUser returned error: %v", err)
+	}
+
+	expected := []struct {
+		success bool
+		reason  string
+		hasUser bool
+	}{
+		{success: false, reason: models.LoginFailureUnknownEmail, hasUser: false},
+		{success: false, reason: models.LoginFailureInvalidPassword, hasUser: true},
+		{success: true, reason: "", hasUser: true},
+	}
+
+	if len(events.events) != len(expected) {
+		t.Fatalf("Expected %d login events, got %d", len(expected), len(events.events))
+	}
+
+	for i, want := range expected {
+		got := events.events[i]
+		if got.Success != want.success || got.FailureReason != want.reason || (got.UserID != nil) != want.hasUser {
+			t.Errorf("event %d: expected success=%v reason=%q hasUser=%v, got success=%v reason=%q hasUser=%v",
+				i, want.success, want.reason, want.hasUser, got.Success, got.FailureReason, got.UserID != nil)
+		}
+		if got.IPAddress != meta.IPAddress || got.UserAgent != meta.UserAgent {
+			t.Errorf("event %d: expected request metadata to be recorded", i)
+		}
+		if got.Method != models.LoginMethodPassword {
+			t.Errorf("event %d: expected method %q, got %q", i, models.LoginMethodPassword, got.Method)
+		}
+	}
+}
+
+func TestAuthService_LoginUser_AuditFailureDoesNotBlockLogin(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	user := newTestUser(t, "password123")
+	events := &fakeLoginEventRepo{err: errors.New("database unavailable")}
+	svc := NewAuthService(newFakeUserRepo(user), newFakeRefreshTokenRepo(), events, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil)
+
+	_, session, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: "password123"}, models.LoginMetadata{})
+	if err != nil {
+		t.Fatalf("Expected login to succeed despite audit failure, got %v", err)
+	}
+	if session.AccessToken == "" || session.RefreshToken == "" {
+		t.Error("Expected tokens to be issued")
+	}
+}
+
+func TestAuthService_LoginUser_UpdatesLastLogin(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	user := newTestUser(t, "password123")
+	userRepo := newFakeUserRepo(user)
+	svc := NewAuthService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil)
+
+	svc.LoginUser(models.UserLogin{Email: user.Email, Password: "wrong"}, models.LoginMetadata{})
+	if stored, _ := userRepo.GetUserByID(user.ID); stored.LastLoginAt != nil {
+		t.Error("Expected failed login to leave last_login_at unset")
+	}
+
+	loggedIn, _, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: "password123"}, models.LoginMetadata{})
+	if err != nil {
+		t.Fatalf("LoginUser returned error: %v", err)
+	}
+	if loggedIn.LastLoginAt == nil {
+		t.Error("Expected returned user to carry last_login_at")
+	}
+	if stored, _ := userRepo.GetUserByID(user.ID); stored.LastLoginAt == nil {
+		t.Error("Expected last_login_at to be stored")
+	}
+}
+
+func TestAuthService_RefreshToken_LastLoginOnRefresh(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		user := newTestUser(t, "password123")
+		userRepo := newFakeUserRepo(user)
+		svc := NewAuthService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil).
+			WithLastLoginOnRefresh(enabled)
+
+		_, session, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: "password123"}, models.LoginMetadata{})
+		if err != nil {
+			t.Fatalf("LoginUser returned error: %v", err)
+		}
+		userRepo.users[user.ID].LastLoginAt = nil
+
+		if _, err := svc.RefreshToken(session.RefreshToken); err != nil {
+			t.Fatalf("RefreshToken returned error: %v", err)
+		}
+		if stored, _ := userRepo.GetUserByID(user.ID); (stored.LastLoginAt != nil) != enabled {
+			t.Errorf("LAST_LOGIN_ON_REFRESH=%v: expected last_login_at set to be %v, got %v", enabled, enabled, stored.LastLoginAt)
+		}
+	}
+}
+
+func TestAuthService_LoginUser_UpgradesWeakHash(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	user := newTestUser(t, "password123")
+	userRepo := newFakeUserRepo(user)
+	hasher := passwordhash.NewBcrypt(bcrypt.MinCost + 1)
+	svc := NewAuthService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, passwordpolicy.Default(), hasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil)
+
+	if _, _, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: "password123"}, models.LoginMetadata{}); err != nil {
+		t.Fatalf("LoginUser returned error: %v", err)
+	}
+
+	stored, _ := userRepo.GetUserByID(user.ID)
+	cost, err := bcrypt.Cost([]byte(stored.PasswordHash))
+	if err != nil {
+		t.Fatalf("stored hash is not bcrypt: %v", err)
+	}
+	if cost != bcrypt.MinCost+1 {
+		t.Errorf("Expected hash to be upgraded to cost %d, got %d", bcrypt.MinCost+1, cost)
+	}
+	if err := hasher.Verify(stored.PasswordHash, "password123"); err != nil {
+		t.Errorf("Expected upgraded hash to verify, got %v", err)
+	}
+}
+
+func TestAuthService_ValidateToken_RevokedToken(t *testing.T) {
+	user := newTestUser(t, "password123")
+	revocations := revocation.NewMemoryStore()
+	svc := NewAuthService(newFakeUserRepo(user), newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocations, 0, nil)
+
+	_, session, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: "password123"}, models.LoginMetadata{})
+	if err != nil {
+		t.Fatalf("LoginUser returned error: %v", err)
+	}
+	accessToken := session.AccessToken
+	validation, err := svc.ValidateToken(accessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken returned error: %v", err)
+	}
+	if validation.User.ID != user.ID || len(validation.StaleClaims) != 0 {
+		t.Errorf("Unexpected validation: %+v", validation)
+	}
+
+	revocations.RevokeUser(user.ID)
+	if _, err := svc.ValidateToken(accessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected ErrTokenRevoked after a force logout, got %v", err)
+	}
+}
+
+func TestAuthService_LoginUser_EvictsOldestRefreshTokens(t *testing.T) {
+	user := newTestUser(t, "password123")
+	refreshRepo := newFakeRefreshTokenRepo()
+	svc := NewAuthService(newFakeUserRepo(user), refreshRepo, &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 2, nil)
+
+	var refreshTokens []string
+	for i := 0; i < 3; i++ {
+		_, session, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: "password123"}, models.LoginMetadata{})
+		if err != nil {
+			t.Fatalf("LoginUser returned error: %v", err)
+		}
+		refreshTokens = append(refreshTokens, session.RefreshToken)
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := refreshRepo.countForUser(user.ID); n != 2 {
+		t.Errorf("Expected 2 refresh tokens to remain, got %d", n)
+	}
+	if refreshRepo.hasToken(hashToken(refreshTokens[0])) {
+		t.Error("Expected the oldest refresh token to be evicted")
+	}
+	if !refreshRepo.hasToken(hashToken(refreshTokens[1])) || !refreshRepo.hasToken(hashToken(refreshTokens[2])) {
+		t.Error("Expected the newest refresh tokens to be kept")
+	}
+}
+
+func TestAuthService_RefreshTokensStoredHashed(t *testing.T) {
+	user := newTestUser(t, "password123")
+	refreshRepo := newFakeRefreshTokenRepo()
+	svc := NewAuthService(newFakeUserRepo(user), refreshRepo, &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil)
+
+	_, session, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: "password123"}, models.LoginMetadata{})
+	if err != nil {
+		t.Fatalf("LoginUser returned error: %v", err)
+	}
+	if refreshRepo.hasToken(session.RefreshToken) || !refreshRepo.hasToken(hashToken(session.RefreshToken)) {
+		t.Error("Expected only the hash of the refresh token to be stored")
+	}
+
+	if _, err := svc.RefreshToken(session.RefreshToken); err != nil {
+		t.Errorf("RefreshToken returned error: %v", err)
+	}
+	// The stored hash is not itself a refresh token
+	if _, err := svc.RefreshToken(hashToken(session.RefreshToken)); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Errorf("Expected ErrRefreshTokenInvalid for the stored hash, got %v", err)
+	}
+}
+
+func TestAuthService_LoginUser_ConcurrentLoginsRespectLimit(t *testing.T) {
+	user := newTestUser(t, "password123")
+	refreshRepo := newFakeRefreshTokenRepo()
+	svc := NewAuthService(newFakeUserRepo(user), refreshRepo, &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 3, nil)
+
+	// Race more logins than the limit allows
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: "password123"}, models.LoginMetadata{}); err != nil {
+				t.Errorf("LoginUser returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := refreshRepo.countForUser(user.ID); n != 3 {
+		t.Errorf("Expected concurrent logins to leave 3 refresh tokens, got %d", n)
+	}
+}
+
+func TestAuthService_LoginUser_RememberMeChoosesRefreshLifetime(t *testing.T) {
+	user := newTestUser(t, "password123")
+	tokens := testTokens.WithShortRefreshTTL(12 * time.Hour)
+	svc := NewAuthService(newFakeUserRepo(user), newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, tokens, revocation.NewMemoryStore(), 0, nil)
+
+	tests := []struct {
+		rememberMe bool
+		wantTTL    time.Duration
+	}{
+		{rememberMe: false, wantTTL: 12 * time.Hour},
+		{rememberMe: true, wantTTL: 7 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		before := time.Now()
+		_, session, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: "password123", RememberMe: tt.rememberMe}, models.LoginMetadata{})
+		if err != nil {
+			t.Fatalf("LoginUser returned error: %v", err)
+		}
+		if got := session.RefreshTokenExpiresAt.Sub(before); got < tt.wantTTL || got > tt.wantTTL+time.Minute {
+			t.Errorf("remember_me=%v: expected the refresh token to live %v, got %v", tt.rememberMe, tt.wantTTL, got)
+		}
+	}
+}
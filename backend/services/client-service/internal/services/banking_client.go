@@ -0,0 +1,255 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/resilience"
+)
+
+// Timeouts of each banking-service call. Balance and stats lookups are
+// retried, so each attempt gets less time. Statements can list a month of
+// transactions, so they get more.
+const (
+	bankingCallTimeout     = 5 * time.Second
+	balanceLookupTimeout   = 2 * time.Second
+	statsLookupTimeout     = 2 * time.Second
+	statementLookupTimeout = 10 * time.Second
+)
+
+// BankingClient notifies the banking-service about user lifecycle changes
+// and revoked access tokens, and looks up account state that affects them
+// and the banking figures of the admin dashboard and users' statements
+type BankingClient interface {
+	ProvisionAccount(userID uuid.UUID) (bool, error)
+	NotifyUserDeleted(userID uuid.UUID) error
+	NotifyUserRestored(userID uuid.UUID) error
+	GetUserBalance(userID uuid.UUID) (float64, error)
+	RevokeTokens(tokens []models.RevokedToken) error
+	GetStats() (*models.BankingStats, error)
+	GetStatement(userID uuid.UUID, period string) (*models.Statement, error)
+}
+
+// HTTPBankingClient calls the banking-service internal API, authenticating
+// with the shared service token. Calls that fail return an error matching
+// resilience.ErrDependencyUnavailable.
+type HTTPBankingClient struct {
+	baseURL      string
+	serviceToken string
+	httpClient   *http.Client
+	client       *resilience.Client
+}
+
+// NewHTTPBankingClient creates a new banking-service client, retrying and
+// breaking its calls as config describes
+func NewHTTPBankingClient(baseURL, serviceToken string, config resilience.Config) *HTTPBankingClient {
+	httpClient := &http.Client{}
+	return &HTTPBankingClient{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		serviceToken: serviceToken,
+		httpClient:   httpClient,
+		client:       resilience.NewClient("banking-service", httpClient, config),
+	}
+}
+
+// WithTransport makes the client send its requests through transport, such
+// as one presenting a client certificate
+func (c *HTTPBankingClient) WithTransport(transport http.RoundTripper) *HTTPBankingClient {
+	c.httpClient.Transport = transport
+	return c
+}
+
+// ProvisionAccount asks the banking-service to create the user's account and
+// reports whether it was created. Users who already have one keep it.
+func (c *HTTPBankingClient) ProvisionAccount(userID uuid.UUID) (bool, error) {
+	body, err := json.Marshal(map[string]uuid.UUID{"user_id": userID})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode account request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/internal/accounts", bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to build banking-service request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Token", c.serviceToken)
+
+	resp, err := c.client.Do(req, bankingCallTimeout)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return true, nil
+	case http.StatusOK:
+		return false, nil
+	default:
+		return false, fmt.Errorf("banking-service returned status %d", resp.StatusCode)
+	}
+}
+
+// NotifyUserDeleted asks the banking-service to flag the user's account as orphaned
+func (c *HTTPBankingClient) NotifyUserDeleted(userID uuid.UUID) error {
+	return c.notify(userID, "deleted")
+}
+
+// NotifyUserRestored asks the banking-service to clear the orphaned flag on
+// the user's account
+func (c *HTTPBankingClient) NotifyUserRestored(userID uuid.UUID) error {
+	return c.notify(userID, "restored")
+}
+
+// notify posts a user lifecycle event to the banking-service internal API
+func (c *HTTPBankingClient) notify(userID uuid.UUID, event string) error {
+	url := fmt.Sprintf("%s/internal/users/%s/%s", c.baseURL, userID, event)
+
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build banking-service request: %w", err)
+	}
+	req.Header.Set("X-Service-Token", c.serviceToken)
+
+	resp, err := c.client.Do(req, bankingCallTimeout)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("banking-service returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GetUserBalance returns the balance of the user's account. Users without an
+// account have a balance of zero.
+func (c *HTTPBankingClient) GetUserBalance(userID uuid.UUID) (float64, error) {
+	url := fmt.Sprintf("%s/internal/users/%s/balance", c.baseURL, userID)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build banking-service request: %w", err)
+	}
+	req.Header.Set("X-Service-Token", c.serviceToken)
+
+	resp, err := c.client.Do(req, balanceLookupTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("banking-service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Balance float64 `json:"balance"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode banking-service response: %w", err)
+	}
+
+	return body.Data.Balance, nil
+}
+
+// RevokeTokens pushes revoked access tokens to the banking-service so it
+// rejects them without waiting for them to expire
+func (c *HTTPBankingClient) RevokeTokens(tokens []models.RevokedToken) error {
+	body, err := json.Marshal(models.TokenRevocationRequest{Tokens: tokens})
+	if err != nil {
+		return fmt.Errorf("failed to encode token revocations: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/internal/token-revocations", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build banking-service request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Token", c.serviceToken)
+
+	resp, err := c.client.Do(req, bankingCallTimeout)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("banking-service returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GetStats returns the banking figures of the admin dashboard
+func (c *HTTPBankingClient) GetStats() (*models.BankingStats, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/internal/stats", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build banking-service request: %w", err)
+	}
+	req.Header.Set("X-Service-Token", c.serviceToken)
+
+	resp, err := c.client.Do(req, statsLookupTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("banking-service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Stats models.BankingStats `json:"stats"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode banking-service response: %w", err)
+	}
+
+	return &body.Data.Stats, nil
+}
+
+// GetStatement returns a user's statement for period, a month in YYYY-MM
+// form
+func (c *HTTPBankingClient) GetStatement(userID uuid.UUID, period string) (*models.Statement, error) {
+	url := fmt.Sprintf("%s/internal/users/%s/statements/%s", c.baseURL, userID, period)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build banking-service request: %w", err)
+	}
+	req.Header.Set("X-Service-Token", c.serviceToken)
+
+	resp, err := c.client.Do(req, statementLookupTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("banking-service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Statement models.Statement `json:"statement"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode banking-service response: %w", err)
+	}
+
+	return &body.Data.Statement, nil
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/resilience"
+)
+
+func TestHTTPBankingClient_NotifyUserDeleted(t *testing.T) {
+	userID := uuid.New()
+
+	var gotPath, gotToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotToken = r.Header.Get("X-Service-Token")
+		if gotToken != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	if err := NewHTTPBankingClient(server.URL+"/", "secret", resilience.DefaultConfig).NotifyUserDeleted(userID); err != nil {
+		t.Fatalf("NotifyUserDeleted returned error: %v", err)
+	}
+	if want := "/internal/users/" + userID.String() + "/deleted"; gotPath != want {
+		t.Errorf("Expected path %q, got %q", want, gotPath)
+	}
+
+	if err := NewHTTPBankingClient(server.URL, "wrong", resilience.DefaultConfig).NotifyUserDeleted(userID); err == nil {
+		t.Error("Expected an error when the banking-service rejects the call")
+	}
+}
+
+func TestHTTPBankingClient_NotifyUserRestored(t *testing.T) {
+	userID := uuid.New()
+
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	if err := NewHTTPBankingClient(server.URL, "secret", resilience.DefaultConfig).NotifyUserRestored(userID); err != nil {
+		t.Fatalf("NotifyUserRestored returned error: %v", err)
+	}
+	if want := "/internal/users/" + userID.String() + "/restored"; gotPath != want {
+		t.Errorf("Expected path %q, got %q", want, gotPath)
+	}
+}
+
+func TestHTTPBankingClient_GetUserBalance(t *testing.T) {
+	userID := uuid.New()
+
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if r.Header.Get("X-Service-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":{"user_id":"` + userID.String() + `","balance":12.5,"has_account":true},"request_id":"req-1"}`))
+	}))
+	defer server.Close()
+
+	balance, err := NewHTTPBankingClient(server.URL, "secret", resilience.DefaultConfig).GetUserBalance(userID)
+	if err != nil {
+		t.Fatalf("GetUserBalance returned error: %v", err)
+	}
+	if balance != 12.5 {
+		t.Errorf("Expected balance 12.5, got %v", balance)
+	}
+	if want := "/internal/users/" + userID.String() + "/balance"; gotPath != want {
+		t.Errorf("Expected path %q, got %q", want, gotPath)
+	}
+
+	if _, err := NewHTTPBankingClient(server.URL, "wrong", resilience.DefaultConfig).GetUserBalance(userID); err == nil {
+		t.Error("Expected an error when the banking-service rejects the call")
+	}
+}
+
+func TestHTTPBankingClient_RevokeTokens(t *testing.T) {
+	var gotPath string
+	var gotBody models.TokenRevocationRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if r.Header.Get("X-Service-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tokens := []models.RevokedToken{{JTI: "jti-1", ExpiresAt: time.Now().Add(time.Minute).UTC().Truncate(time.Second)}}
+	if err := NewHTTPBankingClient(server.URL, "secret", resilience.DefaultConfig).RevokeTokens(tokens); err != nil {
+		t.Fatalf("RevokeTokens returned error: %v", err)
+	}
+	if gotPath != "/internal/token-revocations" {
+		t.Errorf("Expected path /internal/token-revocations, got %q", gotPath)
+	}
+	if len(gotBody.Tokens) != 1 || gotBody.Tokens[0].JTI != "jti-1" || !gotBody.Tokens[0].ExpiresAt.Equal(tokens[0].ExpiresAt) {
+		t.Errorf("Unexpected request body: %+v", gotBody)
+	}
+
+	if err := NewHTTPBankingClient(server.URL, "wrong", resilience.DefaultConfig).RevokeTokens(tokens); err == nil {
+		t.Error("Expected an error when the banking-service rejects the call")
+	}
+}
+
+func TestHTTPBankingClient_ProvisionAccount(t *testing.T) {
+	userID := uuid.New()
+
+	status := http.StatusCreated
+	var gotUserID uuid.UUID
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			UserID uuid.UUID `json:"user_id"`
+		}
+		if r.URL.Path != "/internal/accounts" || r.Header.Get("X-Service-Token") != "secret" || json.NewDecoder(r.Body).Decode(&body) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		gotUserID = body.UserID
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := NewHTTPBankingClient(server.URL, "secret", resilience.DefaultConfig)
+	if created, err := client.ProvisionAccount(userID); err != nil || !created {
+		t.Fatalf("Expected the account to be created, got %v, %v", created, err)
+	}
+	if gotUserID != userID {
+		t.Errorf("Expected user ID %s, got %s", userID, gotUserID)
+	}
+
+	status = http.StatusOK
+	if created, err := client.ProvisionAccount(userID); err != nil || created {
+		t.Errorf("Expected an existing account, got %v, %v", created, err)
+	}
+
+	status = http.StatusInternalServerError
+	if _, err := client.ProvisionAccount(userID); err == nil {
+		t.Error("Expected an error when the banking-service fails")
+	}
+}
+
+func TestHTTPBankingClient_GetStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/stats" || r.Header.Get("X-Service-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":{"stats":{"accounts":3,"total_balance":250.5,"transactions_today":2,"volume_today":40,"open_disputes":1,"open_suspicious_activity":0,"generated_at":"2024-05-15T10:00:00Z"}},"request_id":"req-1"}`))
+	}))
+	defer server.Close()
+
+	stats, err := NewHTTPBankingClient(server.URL, "secret", resilience.DefaultConfig).GetStats()
+	if err != nil {
+		t.Fatalf("GetStats returned error: %v", err)
+	}
+	want := models.BankingStats{Accounts: 3, TotalBalance: 250.5, TransactionsToday: 2, VolumeToday: 40, OpenDisputes: 1, GeneratedAt: time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)}
+	if *stats != want {
+		t.Errorf("Expected %+v, got %+v", want, *stats)
+	}
+
+	if _, err := NewHTTPBankingClient(server.URL, "wrong", resilience.DefaultConfig).GetStats(); err == nil {
+		t.Error("Expected an error when the banking-service rejects the call")
+	}
+}
+
+func TestHTTPBankingClient_GetStatement(t *testing.T) {
+	userID := uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/users/"+userID.String()+"/statements/2024-04" || r.Header.Get("X-Service-Token") != "secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":{"message":"Statement retrieved successfully","statement":{"period":"2024-04","period_start":"2024-04-01T00:00:00Z","period_end":"2024-05-01T00:00:00Z","currency":"USD","opening_balance":10.00,"closing_balance":35.50,"money_in":25.50,"money_out":0.00,"transactions":[{"id":"` + userID.String() + `","type":"deposit","amount":25.50,"balance_after":35.50,"description":"Salary","created_at":"2024-04-02T09:00:00Z"}]}},"request_id":"req-1"}`))
+	}))
+	defer server.Close()
+
+	statement, err := NewHTTPBankingClient(server.URL, "secret", resilience.DefaultConfig).GetStatement(userID, "2024-04")
+	if err != nil {
+		t.Fatalf("GetStatement returned error: %v", err)
+	}
+	if statement.Period != "2024-04" || statement.OpeningBalance != 10 || statement.ClosingBalance != 35.5 || len(statement.Transactions) != 1 || statement.Transactions[0].Description != "Salary" {
+		t.Errorf("Unexpected statement %+v", statement)
+	}
+
+	if _, err := NewHTTPBankingClient(server.URL, "secret", resilience.DefaultConfig).GetStatement(userID, "2024-05"); err == nil {
+		t.Error("Expected an error when the banking-service has no statement")
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/events"
+	"microbank/pkg/mailer"
+)
+
+// disputeStatusMessages tell users where their dispute now stands, given
+// the disputed amount
+var disputeStatusMessages = map[string]string{
+	"open":              "We received your dispute of %s. Our team will review it and let you know what we find.",
+	"investigating":     "A member of our team is now investigating your dispute of %s.",
+	"resolved_refunded": "Your dispute of %s was upheld, and the amount has been refunded to your account.",
+	"resolved_rejected": "After reviewing your dispute of %s, we were unable to uphold it.",
+}
+
+// DisputeEventConsumer records staff changes to transaction disputes,
+// published by the banking service, in the audit log, and tells users
+// about every change to their disputes
+type DisputeEventConsumer struct {
+	userRepo     repository.UserRepository
+	auditLogRepo repository.AuditLogRepository
+	emailSender  mailer.EmailSender
+	preferences  *NotificationPreferenceService
+}
+
+// NewDisputeEventConsumer creates a new dispute event consumer
+func NewDisputeEventConsumer(userRepo repository.UserRepository, auditLogRepo repository.AuditLogRepository, emailSender mailer.EmailSender, preferences *NotificationPreferenceService) *DisputeEventConsumer {
+	return &DisputeEventConsumer{
+		userRepo:     userRepo,
+		auditLogRepo: auditLogRepo,
+		emailSender:  emailSender,
+		preferences:  preferences,
+	}
+}
+
+// Handle applies the event and reports whether this service acted on its
+// type; other types are accepted and ignored. Assignments and resolutions
+// are written to the audit log under the event's ID, so a redelivered
+// event is logged once. The dispute's owner is emailed unless they have
+// turned dispute_updated emails off. An email that fails to send is
+// returned as an error so the event is retried.
+func (c *DisputeEventConsumer) Handle(event events.Event) (bool, error) {
+	if event.Type != events.TypeDisputeStatusChanged {
+		return false, nil
+	}
+
+	var payload events.DisputeStatusChanged
+	if err := events.Decode(event, &payload); err != nil {
+		return false, err
+	}
+
+	if action := disputeAuditAction(payload.Status); action != "" {
+		metadata := map[string]interface{}{
+			"dispute_id":      payload.DisputeID,
+			"transaction_id":  payload.TransactionID,
+			"status":          payload.Status,
+			"previous_status": payload.PreviousStatus,
+			"amount":          payload.Amount,
+		}
+		if payload.AssignedTo != nil {
+			metadata["assigned_to"] = *payload.AssignedTo
+		}
+		if payload.Note != "" {
+			metadata["note"] = payload.Note
+		}
+		if payload.RefundTransactionID != nil {
+			metadata["refund_transaction_id"] = *payload.RefundTransactionID
+		}
+		entry := newAuditLogEntry(models.AuditActor{AdminID: payload.ActorID, RequestID: payload.RequestID}, action, payload.UserID, metadata)
+		entry.ID = event.ID
+		entry.CreatedAt = payload.ChangedAt
+		if err := c.auditLogRepo.Create(entry); err != nil {
+			return false, fmt.Errorf("failed to log dispute %s change: %w", payload.DisputeID, err)
+		}
+	}
+
+	user, err := c.userRepo.GetUserByID(payload.UserID)
+	if err != nil {
+		// The user may have been deleted since the dispute was filed
+		log.Printf("Not notifying user %s of dispute %s: %v", payload.UserID, payload.DisputeID, err)
+		return true, nil
+	}
+
+	channels, err := c.preferences.ChannelsFor(user.ID, models.NotificationEventDisputeUpdated)
+	if err != nil {
+		log.Printf("Failed to load notification preferences for user %s, using defaults: %v", user.ID, err)
+		channels = models.DefaultNotificationChannels(models.NotificationEventDisputeUpdated)
+	}
+	message, known := disputeStatusMessages[payload.Status]
+	if !channels.Email || !known {
+		return true, nil
+	}
+
+	data := map[string]string{
+		"Name":    user.Name,
+		"Message": fmt.Sprintf(message, fmt.Sprintf("%.2f", payload.Amount)),
+		"Link":    disputesURL(),
+		"Note":    "",
+	}
+	// Notes left when resolving are written for the user; others may be
+	// internal
+	if disputeAuditAction(payload.Status) == models.AuditActionResolveDispute {
+		data["Note"] = payload.Note
+	}
+	if err := sendEmail(c.emailSender, user.Email, user.ID, "dispute_updated", data); err != nil {
+		return false, fmt.Errorf("failed to email dispute %s change: %w", payload.DisputeID, err)
+	}
+
+	return true, nil
+}
+
+// disputeAuditAction returns the audit log action of staff moving a
+// dispute to status, or "" for statuses users set
+func disputeAuditAction(status string) string {
+	switch status {
+	case "investigating":
+		return models.AuditActionAssignDispute
+	case "resolved_refunded", "resolved_rejected":
+		return models.AuditActionResolveDispute
+	}
+	return ""
+}
+
+// disputesURL returns the frontend page that lists a user's disputes
+func disputesURL() string {
+	if url := os.Getenv("DISPUTES_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000/disputes"
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/events"
+)
+
+func TestDisputeEventConsumer_Handle(t *testing.T) {
+	user := newTestUser(t, "password123")
+	sender := &fakeEmailSender{}
+	audits := &fakeAuditLogRepo{}
+	preferences := NewNotificationPreferenceService(&fakeNotificationPreferenceRepo{})
+	consumer := NewDisputeEventConsumer(newFakeUserRepo(user), audits, sender, preferences)
+	staffID := uuid.New()
+	refundID := uuid.New()
+
+	newEvent := func(payload events.DisputeStatusChanged) events.Event {
+		payload.DisputeID = uuid.MustParse("9a8b7c6d-5e4f-4a3b-9c2d-1e0f9a8b7c6d")
+		payload.TransactionID = uuid.New()
+		payload.UserID = user.ID
+		payload.Amount = 49.99
+		payload.ChangedAt = time.Now().UTC()
+		event, err := events.New(events.TypeDisputeStatusChanged, events.SourceBankingService, payload)
+		if err != nil {
+			t.Fatalf("events.New returned error: %v", err)
+		}
+		return event
+	}
+
+	// Filing a dispute emails the user but is not a staff action
+	filed := newEvent(events.DisputeStatusChanged{Status: "open", ActorID: user.ID})
+	if handled, err := consumer.Handle(filed); err != nil || !handled {
+		t.Fatalf("Expected the event to be handled, got %v (%v)", handled, err)
+	}
+	if len(audits.entries) != 0 {
+		t.Errorf("Expected no audit log entry for a filed dispute, got %+v", audits.entries)
+	}
+	email, ok := sender.last()
+	if !ok || email.to != user.Email || !strings.Contains(email.subject, "dispute") || !strings.Contains(email.body, "We received your dispute of 49.99") {
+		t.Errorf("Expected a dispute received email to %s, got %+v", user.Email, email)
+	}
+
+	// Resolving it is logged as the staff member's, with the note shown to
+	// the user
+	resolved := newEvent(events.DisputeStatusChanged{
+		Status:              "resolved_refunded",
+		PreviousStatus:      "investigating",
+		ActorID:             staffID,
+		AssignedTo:          &staffID,
+		Note:                "Card was stolen",
+		RefundTransactionID: &refundID,
+		RequestID:           "req-1",
+	})
+	if handled, err := consumer.Handle(resolved); err != nil || !handled {
+		t.Fatalf("Expected the event to be handled, got %v (%v)", handled, err)
+	}
+	if len(audits.entries) != 1 {
+		t.Fatalf("Expected one audit log entry, got %d", len(audits.entries))
+	}
+	entry := audits.entries[0]
+	if entry.ID != resolved.ID || entry.AdminID != staffID || entry.Action != models.AuditActionResolveDispute || entry.RequestID != "req-1" {
+		t.Errorf("Expected a dispute resolution by %s logged under the event ID, got %+v", staffID, entry)
+	}
+	if entry.TargetUserID == nil || *entry.TargetUserID != user.ID || entry.Metadata["refund_transaction_id"] != refundID {
+		t.Errorf("Expected the entry to target %s and name refund %s, got %+v", user.ID, refundID, entry)
+	}
+	email, _ = sender.last()
+	for _, want := range []string{"refunded", "Card was stolen"} {
+		if !strings.Contains(email.body, want) {
+			t.Errorf("Expected the email to mention %q:\n%s", want, email.body)
+		}
+	}
+
+	// Users who turn the emails off are still audited but get none
+	off := false
+	if _, err := preferences.UpdatePreferences(user.ID, models.NotificationPreferencesUpdate{
+		Preferences: map[string]models.NotificationChannelsUpdate{models.NotificationEventDisputeUpdated: {Email: &off}},
+	}); err != nil {
+		t.Fatalf("UpdatePreferences returned error: %v", err)
+	}
+	assigned := newEvent(events.DisputeStatusChanged{Status: "investigating", PreviousStatus: "open", ActorID: staffID, AssignedTo: &staffID})
+	if handled, err := consumer.Handle(assigned); err != nil || !handled {
+		t.Fatalf("Expected the event to be handled, got %v (%v)", handled, err)
+	}
+	if len(sender.sent) != 2 {
+		t.Errorf("Expected no email with dispute_updated turned off, got %d emails", len(sender.sent))
+	}
+	if len(audits.entries) != 2 || audits.entries[1].Action != models.AuditActionAssignDispute {
+		t.Errorf("Expected the assignment to be logged, got %+v", audits.entries)
+	}
+
+	other, err := events.New(events.TypeSavingsGoalCompleted, events.SourceBankingService, events.SavingsGoalCompleted{UserID: user.ID})
+	if err != nil {
+		t.Fatalf("events.New returned error: %v", err)
+	}
+	if handled, err := consumer.Handle(other); err != nil || handled {
+		t.Errorf("Expected other event types to be ignored, got %v (%v)", handled, err)
+	}
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/blobstore"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+// downloadPurgeBatchSize bounds how many artifacts each purge pass deletes
+const downloadPurgeBatchSize = 100
+
+// downloadReRequestPaths are the endpoints that generate each kind of
+// artifact again, for clients whose link has expired or been used up
+var downloadReRequestPaths = map[string]string{
+	models.DownloadKindClientsExport: "/api/v1/admin/clients/export",
+}
+
+// DownloadReRequestPath returns the endpoint that generates an artifact of
+// the kind again, or "" for kinds that cannot be requested again
+func DownloadReRequestPath(kind string) string {
+	return downloadReRequestPaths[kind]
+}
+
+// DownloadService keeps generated files, such as exports, for the user they
+// were generated for. Files are held encrypted in a blob store and handed
+// out through links carrying a signed token that names the artifact, its
+// user and its expiry, so the link alone cannot be altered to reach another
+// file or to outlive its expiry. Each artifact can be downloaded a limited
+// number of times, and every download is recorded.
+type DownloadService struct {
+	artifactRepo repository.DownloadArtifactRepository
+	userRepo     repository.UserRepository
+	store        blobstore.Store
+	secret       []byte
+	ttl          time.Duration
+	maxDownloads int
+	now          func() time.Time
+}
+
+// NewDownloadService creates a new download service. Tokens are signed
+// with secret, and artifacts can be downloaded maxDownloads times within
+// ttl of being published.
+func NewDownloadService(artifactRepo repository.DownloadArtifactRepository, userRepo repository.UserRepository, store blobstore.Store, secret string, ttl time.Duration, maxDownloads int) *DownloadService {
+	return &DownloadService{
+		artifactRepo: artifactRepo,
+		userRepo:     userRepo,
+		store:        store,
+		secret:       []byte(secret),
+		ttl:          ttl,
+		maxDownloads: maxDownloads,
+		now:          time.Now,
+	}
+}
+
+// Publish stores a generated file for the user and returns its record and
+// the link to download it from
+func (s *DownloadService) Publish(ctx context.Context, userID uuid.UUID, kind, fileName, contentType string, data []byte) (*models.DownloadArtifact, string, error) {
+	artifactID := uuid.New()
+	now := s.now()
+	artifact := &models.DownloadArtifact{
+		ID:           artifactID,
+		UserID:       userID,
+		Kind:         kind,
+		FileName:     cleanFileName(fileName),
+		ContentType:  contentType,
+		SizeBytes:    int64(len(data)),
+		StorageKey:   userID.String() + "/" + artifactID.String(),
+		MaxDownloads: s.maxDownloads,
+		ExpiresAt:    now.Add(s.ttl),
+		CreatedAt:    now,
+	}
+
+	if err := s.store.Put(ctx, artifact.StorageKey, data); err != nil {
+		return nil, "", fmt.Errorf("failed to store download: %w", err)
+	}
+	if err := s.artifactRepo.Create(artifact); err != nil {
+		// Do not leave an unrecorded file behind
+		if deleteErr := s.store.Delete(ctx, artifact.StorageKey); deleteErr != nil {
+			log.Printf("Failed to delete unrecorded download %s: %v", artifact.ID, deleteErr)
+		}
+		return nil, "", fmt.Errorf("failed to record download: %w", err)
+	}
+
+	return artifact, downloadURL() + s.signToken(artifact), nil
+}
+
+// Download returns an artifact and its contents to the holder of a token,
+// counting the download. Tokens that were not signed by the service, or
+// name an artifact or user that no longer exists, are ErrInvalidDownloadToken.
+// Artifacts past their expiry or out of downloads are a *DownloadGoneError.
+func (s *DownloadService) Download(ctx context.Context, token, ipAddress, userAgent string) (*models.DownloadArtifact, []byte, error) {
+	claims, ok := s.parseToken(token)
+	if !ok {
+		return nil, nil, ErrInvalidDownloadToken
+	}
+	now := s.now()
+
+	artifact, err := s.artifactRepo.GetByID(claims.artifactID)
+	if err != nil {
+		// The artifact of an expired link may already have been purged
+		if !now.Before(claims.expiresAt) {
+			return nil, nil, &DownloadGoneError{}
+		}
+		return nil, nil, ErrInvalidDownloadToken
+	}
+	if artifact.UserID != claims.userID {
+		return nil, nil, ErrInvalidDownloadToken
+	}
+	if !now.Before(claims.expiresAt) || !now.Before(artifact.ExpiresAt) || artifact.IsExhausted() {
+		return nil, nil, &DownloadGoneError{Kind: artifact.Kind}
+	}
+
+	// Files are only handed to users who could still sign in
+	user, err := s.userRepo.GetUserByID(artifact.UserID)
+	if err != nil || user.IsBlacklisted {
+		return nil, nil, ErrInvalidDownloadToken
+	}
+
+	access := &models.DownloadAccess{
+		ID:         uuid.New(),
+		ArtifactID: artifact.ID,
+		UserID:     artifact.UserID,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		AccessedAt: now,
+	}
+	claimed, err := s.artifactRepo.ClaimDownload(artifact.ID, access, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to record download: %w", err)
+	}
+	if !claimed {
+		return nil, nil, &DownloadGoneError{Kind: artifact.Kind}
+	}
+	artifact.DownloadCount++
+
+	data, err := s.store.Get(ctx, artifact.StorageKey)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return nil, nil, &DownloadGoneError{Kind: artifact.Kind}
+		}
+		return nil, nil, fmt.Errorf("failed to read download: %w", err)
+	}
+
+	return artifact, data, nil
+}
+
+// PurgeExpired permanently deletes artifacts that have expired, returning
+// how many were deleted. Each file is deleted before its record, so a
+// failure leaves the record to be retried on the next pass.
+func (s *DownloadService) PurgeExpired(ctx context.Context) (int, error) {
+	deleted := 0
+	for {
+		artifacts, err := s.artifactRepo.ListExpired(s.now(), downloadPurgeBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to list expired downloads: %w", err)
+		}
+
+		for _, artifact := range artifacts {
+			if err := s.store.Delete(ctx, artifact.StorageKey); err != nil {
+				return deleted, fmt.Errorf("failed to delete download %s: %w", artifact.ID, err)
+			}
+			if err := s.artifactRepo.Delete(artifact.ID); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+
+		if len(artifacts) < downloadPurgeBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// downloadClaims are the contents of a download token
+type downloadClaims struct {
+	artifactID uuid.UUID
+	userID     uuid.UUID
+	expiresAt  time.Time
+}
+
+// signToken returns the token naming the artifact, its user and its expiry:
+// the base64url payload and its HMAC-SHA256 signature, joined by a dot
+func (s *DownloadService) signToken(artifact *models.DownloadArtifact) string {
+	payload := fmt.Sprintf("%s|%s|%d", artifact.ID, artifact.UserID, artifact.ExpiresAt.Unix())
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(s.sign([]byte(payload)))
+}
+
+// parseToken checks a token's signature and returns its claims
+func (s *DownloadService) parseToken(token string) (downloadClaims, bool) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return downloadClaims{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return downloadClaims{}, false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.sign(payload)) {
+		return downloadClaims{}, false
+	}
+
+	parts := bytes.Split(payload, []byte("|"))
+	if len(parts) != 3 {
+		return downloadClaims{}, false
+	}
+	artifactID, err := uuid.ParseBytes(parts[0])
+	if err != nil {
+		return downloadClaims{}, false
+	}
+	userID, err := uuid.ParseBytes(parts[1])
+	if err != nil {
+		return downloadClaims{}, false
+	}
+	expiresAt, err := strconv.ParseInt(string(parts[2]), 10, 64)
+	if err != nil {
+		return downloadClaims{}, false
+	}
+
+	return downloadClaims{artifactID: artifactID, userID: userID, expiresAt: time.Unix(expiresAt, 0)}, true
+}
+
+// sign returns the HMAC-SHA256 of payload under the service's secret
+func (s *DownloadService) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// downloadURL returns the address download links point to, to which the
+// token is appended
+func downloadURL() string {
+	if link := os.Getenv("DOWNLOAD_URL"); link != "" {
+		return strings.TrimRight(link, "/") + "/"
+	}
+	return "http://localhost:8000/api/v1/downloads/"
+}
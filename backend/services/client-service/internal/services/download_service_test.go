@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/blobstore"
+	"microbank/client-service/internal/models"
+)
+
+const testDownloadSecret = "q3Jx9VbT0mWc7LkZpR2sYd5HnE8aFu4G"
+
+func newTestDownloadService(t *testing.T, users ...*models.User) (*DownloadService, *fakeDownloadArtifactRepo, *blobstore.LocalStore) {
+	t.Helper()
+	backing, err := blobstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create blob store: %v", err)
+	}
+	store, err := blobstore.NewEncryptedStore(backing, bytes.Repeat([]byte{7}, blobstore.KeySize))
+	if err != nil {
+		t.Fatalf("Failed to create encrypted store: %v", err)
+	}
+	artifactRepo := &fakeDownloadArtifactRepo{}
+	return NewDownloadService(artifactRepo, newFakeUserRepo(users...), store, testDownloadSecret, time.Hour, 2), artifactRepo, backing
+}
+
+// downloadToken returns the token at the end of a download link
+func downloadToken(t *testing.T, link string) string {
+	t.Helper()
+	prefix := "http://localhost:8000/api/v1/downloads/"
+	if !strings.HasPrefix(link, prefix) {
+		t.Fatalf("Unexpected download link %q", link)
+	}
+	return strings.TrimPrefix(link, prefix)
+}
+
+func TestDownloadService_PublishAndDownload(t *testing.T) {
+	ctx := context.Background()
+	user := newTestUser(t, "password123")
+	svc, artifactRepo, backing := newTestDownloadService(t, user)
+	contents := []byte("id,email\n1,a@example.com\n")
+
+	artifact, link, err := svc.Publish(ctx, user.ID, models.DownloadKindClientsExport, "clients.csv", "text/csv", contents)
+	if err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	token := downloadToken(t, link)
+
+	// The file is stored encrypted
+	stored, err := backing.Get(ctx, artifact.StorageKey)
+	if err != nil {
+		t.Fatalf("Failed to read stored file: %v", err)
+	}
+	if bytes.Contains(stored, contents) {
+		t.Error("Expected the file to be stored encrypted")
+	}
+
+	// Each download is counted and recorded, up to the limit
+	for i := 1; i <= 2; i++ {
+		downloaded, data, err := svc.Download(ctx, token, "203.0.113.1", "curl")
+		if err != nil {
+			t.Fatalf("Download %d returned error: %v", i, err)
+		}
+		if !bytes.Equal(data, contents) || downloaded.DownloadCount != i || downloaded.FileName != "clients.csv" {
+			t.Errorf("Unexpected download %d: %+v %q", i, downloaded, data)
+		}
+	}
+	if len(artifactRepo.accesses) != 2 || artifactRepo.accesses[0].IPAddress != "203.0.113.1" || artifactRepo.accesses[0].UserID != user.ID {
+		t.Errorf("Expected both downloads recorded, got %+v", artifactRepo.accesses)
+	}
+
+	// Used up
+	var gone *DownloadGoneError
+	if _, _, err := svc.Download(ctx, token, "203.0.113.1", "curl"); !errors.As(err, &gone) || gone.Kind != models.DownloadKindClientsExport {
+		t.Errorf("Expected a gone export after the last download, got %v", err)
+	}
+	if len(artifactRepo.accesses) != 2 {
+		t.Errorf("Expected the refused download not to be recorded, got %d accesses", len(artifactRepo.accesses))
+	}
+}
+
+func TestDownloadService_RejectsTamperedTokens(t *testing.T) {
+	ctx := context.Background()
+	user := newTestUser(t, "password123")
+	other := newTestUser(t, "password123")
+	svc, _, _ := newTestDownloadService(t, user, other)
+
+	artifact, link, err := svc.Publish(ctx, user.ID, models.DownloadKindClientsExport, "clients.csv", "text/csv", []byte("id\n"))
+	if err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	token := downloadToken(t, link)
+	_, signature, _ := strings.Cut(token, ".")
+
+	// Tokens signed with another secret, altered to name another user or a
+	// later expiry, or naming an artifact that is not theirs are refused
+	forged := NewDownloadService(nil, nil, nil, "another-secret-entirely-32-bytes", time.Hour, 1)
+	otherUser := *artifact
+	otherUser.UserID = other.ID
+	extended := *artifact
+	extended.ExpiresAt = extended.ExpiresAt.Add(24 * time.Hour)
+	otherPayload, _, _ := strings.Cut(svc.signToken(&otherUser), ".")
+	extendedPayload, _, _ := strings.Cut(svc.signToken(&extended), ".")
+
+	for name, token := range map[string]string{
+		"garbage":        "not-a-token",
+		"wrong secret":   forged.signToken(artifact),
+		"other user":     otherPayload + "." + signature,
+		"owner mismatch": svc.signToken(&otherUser),
+		"later expiry":   extendedPayload + "." + signature,
+		"empty":          "",
+		"unknown object": svc.signToken(&models.DownloadArtifact{ID: uuid.New(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}),
+	} {
+		if _, _, err := svc.Download(ctx, token, "203.0.113.1", "curl"); !errors.Is(err, ErrInvalidDownloadToken) {
+			t.Errorf("%s: expected ErrInvalidDownloadToken, got %v", name, err)
+		}
+	}
+
+	// Tokens of users who have since been suspended are refused too
+	user.IsBlacklisted = true
+	if _, _, err := svc.Download(ctx, token, "203.0.113.1", "curl"); !errors.Is(err, ErrInvalidDownloadToken) {
+		t.Errorf("Expected a suspended user's link to be refused, got %v", err)
+	}
+}
+
+func TestDownloadService_ExpiryAndPurge(t *testing.T) {
+	ctx := context.Background()
+	user := newTestUser(t, "password123")
+	svc, artifactRepo, backing := newTestDownloadService(t, user)
+
+	artifact, link, err := svc.Publish(ctx, user.ID, models.DownloadKindClientsExport, "clients.csv", "text/csv", []byte("id\n"))
+	if err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	token := downloadToken(t, link)
+
+	svc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	var gone *DownloadGoneError
+	if _, _, err := svc.Download(ctx, token, "203.0.113.1", "curl"); !errors.As(err, &gone) || gone.Kind != models.DownloadKindClientsExport {
+		t.Errorf("Expected an expired link to be gone, got %v", err)
+	}
+
+	deleted, err := svc.PurgeExpired(ctx)
+	if err != nil || deleted != 1 {
+		t.Fatalf("Expected one download purged, got %d, %v", deleted, err)
+	}
+	if _, err := backing.Get(ctx, artifact.StorageKey); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Errorf("Expected the file to be deleted, got %v", err)
+	}
+	if len(artifactRepo.artifacts) != 0 {
+		t.Errorf("Expected the record to be deleted, got %d", len(artifactRepo.artifacts))
+	}
+
+	// Links to purged artifacts are still reported as expired
+	if _, _, err := svc.Download(ctx, token, "203.0.113.1", "curl"); !errors.Is(err, ErrDownloadGone) {
+		t.Errorf("Expected a purged link to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordhash"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/mailer"
+)
+
+// emailChangeWindow is how long a pending email change can be verified, and
+// how long the old address can roll it back
+const emailChangeWindow = 24 * time.Hour
+
+// EmailChangeService handles verified email address changes
+type EmailChangeService struct {
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	emailSender      mailer.EmailSender
+	passwordHasher   passwordhash.PasswordHasher
+}
+
+// NewEmailChangeService creates a new email change service
+func NewEmailChangeService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, emailSender mailer.EmailSender, passwordHasher passwordhash.PasswordHasher) *EmailChangeService {
+	return &EmailChangeService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		emailSender:      emailSender,
+		passwordHasher:   passwordHasher,
+	}
+}
+
+// RequestEmailChange stores the new address as pending, emails a verification
+// link to it and a cancellation link to the current address
+func (s *EmailChangeService) RequestEmailChange(userID uuid.UUID, change models.EmailChange) (*models.User, error) {
+	// Get user
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	// Verify current password
+	if !user.HasPassword() {
+		return nil, ErrPasswordNotSet
+	}
+	if err := s.passwordHasher.Verify(user.PasswordHash, change.CurrentPassword); err != nil {
+		return nil, ErrInvalidCurrentPassword
+	}
+
+	newEmail := strings.TrimSpace(change.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, ErrEmailUnchanged
+	}
+
+	// Check the address is not taken or pending for another account
+	inUse, err := s.userRepo.EmailInUse(newEmail, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email availability: %w", err)
+	}
+	if inUse {
+		return nil, ErrEmailInUse
+	}
+
+	// Generate verification and cancellation tokens; only hashes are stored
+	verifyToken, err := generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	cancelToken, err := generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate cancellation token: %w", err)
+	}
+
+	if err := s.userRepo.SetPendingEmail(user.ID, newEmail, hashToken(verifyToken), hashToken(cancelToken)); err != nil {
+		return nil, fmt.Errorf("failed to save pending email: %w", err)
+	}
+	user.PendingEmail = newEmail
+
+	// Ask the new address to confirm the change
+	verifyData := map[string]string{
+		"Name": user.Name,
+		"Link": fmt.Sprintf("%s?token=%s", emailVerifyURL(), verifyToken),
+	}
+	if err := sendEmail(s.emailSender, newEmail, user.ID, "email_change_verify", verifyData); err != nil {
+		return nil, fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	// Let the current address cancel or roll back the change
+	cancelData := map[string]string{
+		"Name":     user.Name,
+		"NewEmail": newEmail,
+		"Link":     fmt.Sprintf("%s?token=%s", emailCancelURL(), cancelToken),
+	}
+	if err := sendEmail(s.emailSender, user.Email, user.ID, "email_change_notice", cancelData); err != nil {
+		return nil, fmt.Errorf("failed to send cancellation email: %w", err)
+	}
+
+	return user, nil
+}
+
+// VerifyEmailChange swaps the pending address in once its owner confirms it
+func (s *EmailChangeService) VerifyEmailChange(token string) (*models.User, error) {
+	user, err := s.userRepo.GetUserByPendingEmailToken(hashToken(token))
+	if err != nil {
+		return nil, ErrInvalidEmailChangeToken
+	}
+
+	if user.EmailChangeRequestedAt == nil || time.Since(*user.EmailChangeRequestedAt) > emailChangeWindow {
+		return nil, ErrEmailChangeTokenExpired
+	}
+
+	if err := s.userRepo.ConfirmPendingEmail(user.ID); err != nil {
+		return nil, fmt.Errorf("failed to confirm email change: %w", err)
+	}
+
+	return s.userRepo.GetUserByID(user.ID)
+}
+
+// CancelEmailChange discards a pending change, or rolls back a confirmed one,
+// within the change window. Sessions are revoked in case the change was
+// made by someone who had taken over the account.
+func (s *EmailChangeService) CancelEmailChange(token string) error {
+	user, err := s.userRepo.GetUserByEmailChangeCancelToken(hashToken(token))
+	if err != nil {
+		return ErrInvalidEmailChangeToken
+	}
+
+	if user.EmailChangeRequestedAt == nil || time.Since(*user.EmailChangeRequestedAt) > emailChangeWindow {
+		return ErrEmailChangeTokenExpired
+	}
+
+	if err := s.userRepo.CancelEmailChange(user.ID); err != nil {
+		return fmt.Errorf("failed to cancel email change: %w", err)
+	}
+
+	if user.PreviousEmail != "" {
+		if err := s.refreshTokenRepo.DeleteByUserID(user.ID); err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// emailVerifyURL returns the frontend page that confirms email changes
+func emailVerifyURL() string {
+	if url := os.Getenv("EMAIL_VERIFY_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000/verify-email"
+}
+
+// emailCancelURL returns the frontend page that cancels email changes
+func emailCancelURL() string {
+	if url := os.Getenv("EMAIL_CHANGE_CANCEL_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000/cancel-email-change"
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"microbank/client-service/internal/models"
+)
+
+func TestEmailChangeService_VerifyAndRollback(t *testing.T) {
+	user := newTestUser(t, "password123")
+	userRepo := newFakeUserRepo(user)
+	sender := &fakeEmailSender{}
+	svc := NewEmailChangeService(userRepo, newFakeRefreshTokenRepo(), sender, testHasher)
+
+	pending, err := svc.RequestEmailChange(user.ID, models.EmailChange{NewEmail: "new@example.com", CurrentPassword: "password123"})
+	if err != nil {
+		t.Fatalf("RequestEmailChange returned error: %v", err)
+	}
+	if pending.Email != user.Email || pending.PendingEmail != "new@example.com" {
+		t.Errorf("Expected email to stay %s with pending new@example.com, got %s / %s", user.Email, pending.Email, pending.PendingEmail)
+	}
+
+	if len(sender.sent) != 2 {
+		t.Fatalf("Expected verification and notification emails, got %d", len(sender.sent))
+	}
+	verifyEmail, cancelEmail := sender.sent[0], sender.sent[1]
+	if verifyEmail.to != "new@example.com" || cancelEmail.to != user.Email {
+		t.Errorf("Unexpected recipients: %s, %s", verifyEmail.to, cancelEmail.to)
+	}
+
+	verified, err := svc.VerifyEmailChange(extractToken(t, verifyEmail.body))
+	if err != nil {
+		t.Fatalf("VerifyEmailChange returned error: %v", err)
+	}
+	if verified.Email != "new@example.com" {
+		t.Errorf("Expected email to be swapped in, got %s", verified.Email)
+	}
+
+	if err := svc.CancelEmailChange(extractToken(t, cancelEmail.body)); err != nil {
+		t.Fatalf("CancelEmailChange returned error: %v", err)
+	}
+	restored, _ := userRepo.GetUserByID(user.ID)
+	if restored.Email != user.Email {
+		t.Errorf("Expected email to be rolled back to %s, got %s", user.Email, restored.Email)
+	}
+}
+
+func TestEmailChangeService_RequestErrors(t *testing.T) {
+	user := newTestUser(t, "password123")
+	other := newTestUser(t, "password123")
+	other.Email = "taken@example.com"
+	svc := NewEmailChangeService(newFakeUserRepo(user, other), newFakeRefreshTokenRepo(), &fakeEmailSender{}, testHasher)
+
+	tests := []struct {
+		name    string
+		change  models.EmailChange
+		wantErr error
+	}{
+		{
+			name:    "wrong password",
+			change:  models.EmailChange{NewEmail: "new@example.com", CurrentPassword: "wrong"},
+			wantErr: ErrInvalidCurrentPassword,
+		},
+		{
+			name:    "same email",
+			change:  models.EmailChange{NewEmail: user.Email, CurrentPassword: "password123"},
+			wantErr: ErrEmailUnchanged,
+		},
+		{
+			name:    "email taken",
+			change:  models.EmailChange{NewEmail: "taken@example.com", CurrentPassword: "password123"},
+			wantErr: ErrEmailInUse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.RequestEmailChange(user.ID, tt.change)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"microbank/pkg/mailer"
+)
+
+// sendEmail renders the named email template and sends it to a user's
+// address
+func sendEmail(sender mailer.EmailSender, to string, userID uuid.UUID, template string, data any) error {
+	msg, err := mailer.Templates.Render(template, data)
+	if err != nil {
+		return err
+	}
+	msg.To = to
+	msg.Metadata.UserID = userID
+	return sender.Send(context.Background(), msg)
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"microbank/client-service/internal/models"
+)
+
+// Sentinel errors returned (usually wrapped) by the services. Handlers
+// match them with errors.Is to choose a response.
+var (
+	ErrUserExists          = errors.New("user already exists")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrAccountSuspended    = errors.New("account has been suspended")
+	ErrAccountDeleted      = errors.New("account has been deleted")
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	ErrTokenRevoked        = errors.New("token has been revoked")
+
+	ErrCannotDeleteSelf          = errors.New("admins cannot delete their own account")
+	ErrAdminDeleteRequiresForce  = errors.New("deleting an admin requires force")
+	ErrBankingServiceUnavailable = errors.New("banking-service request failed")
+	ErrCannotDemoteSelf          = errors.New("admins cannot revoke their own admin role")
+	ErrLastAdmin                 = errors.New("the last remaining admin cannot be demoted")
+	ErrInvalidRole               = errors.New("unknown role")
+	ErrBlacklistReasonRequired   = errors.New("a blacklist reason is required")
+	ErrInvalidBlacklistExpiry    = errors.New("blacklist expiry must be in the future")
+	ErrEmptyBlacklistBatch       = errors.New("at least one user ID is required")
+	ErrBlacklistBatchTooLarge    = errors.New("too many user IDs in one batch")
+	ErrUserExportTooLarge        = errors.New("too many users match the export filters")
+	ErrUserStatusBatchTooLarge   = errors.New("too many user IDs in one status lookup")
+	ErrUserNotDeleted            = errors.New("user is not deleted")
+	ErrRestoreWindowExpired      = errors.New("the restore window for this user has passed")
+	ErrAccountBalanceNotZero     = errors.New("account balance must be zero before deletion")
+	ErrAdminSelfDeletion         = errors.New("admins cannot delete their own account")
+	ErrCannotImpersonateStaff    = errors.New("staff users cannot be impersonated")
+
+	ErrInvalidCurrentPassword = errors.New("invalid current password")
+	ErrPasswordUnchanged      = errors.New("new password must differ from current password")
+	ErrPasswordRecentlyUsed   = errors.New("password was used recently")
+	ErrPasswordNotSet         = errors.New("account has no password")
+	ErrPasswordResetRequired  = errors.New("password must be reset before logging in")
+	ErrPasswordSetupRequired  = errors.New("password must be set up before logging in")
+	ErrInvalidResetToken      = errors.New("invalid reset token")
+	ErrResetTokenExpired      = errors.New("reset token expired")
+	ErrInvalidMagicLink       = errors.New("invalid sign-in link")
+	ErrMagicLinkExpired       = errors.New("sign-in link expired")
+
+	ErrPhoneNumberMissing           = errors.New("no phone number on file")
+	ErrPhoneAlreadyVerified         = errors.New("phone number already verified")
+	ErrPhoneVerificationRateLimited = errors.New("too many verification codes requested")
+	ErrSMSDeliveryFailed            = errors.New("failed to send SMS")
+	ErrInvalidVerificationCode      = errors.New("invalid verification code")
+	ErrVerificationCodeExpired      = errors.New("verification code expired")
+	ErrVerificationAttemptsExceeded = errors.New("too many verification attempts")
+	ErrInvalidLoginCode             = errors.New("invalid phone number or login code")
+
+	ErrRegistrationClosed      = errors.New("registration is closed")
+	ErrInvitationRequired      = errors.New("an invitation code is required")
+	ErrInvalidInvitation       = errors.New("invalid invitation code")
+	ErrInvitationNotFound      = errors.New("invitation not found")
+	ErrInvitationUsed          = errors.New("invitation has already been used")
+	ErrInvitationRevoked       = errors.New("invitation has been revoked")
+	ErrInvitationExpired       = errors.New("invitation has expired")
+	ErrInvitationEmailMismatch = errors.New("invitation is for a different email")
+
+	ErrOAuthNotConfigured    = errors.New("OAuth provider is not configured")
+	ErrInvalidOAuthState     = errors.New("invalid OAuth state")
+	ErrOAuthStateExpired     = errors.New("OAuth state expired")
+	ErrOAuthExchangeFailed   = errors.New("OAuth provider rejected the sign-in")
+	ErrOAuthEmailNotVerified = errors.New("OAuth provider email is not verified")
+	ErrOAuthAccountLinked    = errors.New("OAuth account is linked to another user")
+
+	ErrInvalidLoginReportToken = errors.New("invalid login report token")
+	ErrLoginReportTokenExpired = errors.New("login report token expired")
+
+	ErrEmailUnchanged          = errors.New("new email must differ from current email")
+	ErrEmailInUse              = errors.New("email already in use")
+	ErrEmailPendingDeletion    = errors.New("email belongs to a deleted account awaiting purge")
+	ErrInvalidEmailChangeToken = errors.New("invalid email change token")
+	ErrEmailChangeTokenExpired = errors.New("email change token expired")
+
+	ErrPersonalAccessTokenNameRequired  = errors.New("a token name is required")
+	ErrInvalidPersonalAccessTokenExpiry = errors.New("token expiry must be in the future")
+	ErrInvalidScope                     = errors.New("unknown scope")
+	ErrPersonalAccessTokenNotFound      = errors.New("personal access token not found")
+	ErrInvalidPersonalAccessToken       = errors.New("invalid personal access token")
+
+	ErrKYCPending         = errors.New("KYC details are already awaiting review")
+	ErrKYCAlreadyVerified = errors.New("identity is already verified")
+	ErrKYCNotPending      = errors.New("no KYC details are awaiting review")
+	ErrKYCNotesRequired   = errors.New("notes are required when rejecting KYC details")
+	ErrCannotReviewOwnKYC = errors.New("reviewers cannot review their own KYC details")
+
+	ErrKYCDocumentNotFound     = errors.New("KYC document not found")
+	ErrKYCDocumentType         = errors.New("KYC documents must be JPEG, PNG or PDF files")
+	ErrKYCDocumentLimitReached = errors.New("KYC submission already has the maximum number of documents")
+
+	ErrInvalidDownloadToken = errors.New("invalid download link")
+	ErrDownloadGone         = errors.New("download link has expired or been used up")
+
+	ErrStatementSubscriptionNotFound = errors.New("not subscribed to statement emails")
+	ErrInvalidStatementPeriod        = errors.New("statement period must be a past month in YYYY-MM format")
+	ErrStatementAlreadySent          = errors.New("statement has already been sent for this period")
+	ErrStatementDeliveryInProgress   = errors.New("statement is being sent for this period")
+)
+
+// PublicErrors are the sentinel errors whose messages may be shown to
+// clients in error details. Other error text stays in the server logs.
+var PublicErrors = []error{
+	ErrUserExists, ErrUserNotFound, ErrInvalidCredentials, ErrAccountSuspended,
+	ErrAccountDeleted, ErrRefreshTokenInvalid, ErrRefreshTokenExpired,
+	ErrTokenRevoked,
+
+	ErrCannotDeleteSelf, ErrAdminDeleteRequiresForce,
+	ErrBankingServiceUnavailable, ErrCannotDemoteSelf, ErrLastAdmin, ErrInvalidRole,
+	ErrBlacklistReasonRequired, ErrInvalidBlacklistExpiry,
+	ErrEmptyBlacklistBatch, ErrBlacklistBatchTooLarge, ErrUserExportTooLarge,
+	ErrUserStatusBatchTooLarge, ErrUserNotDeleted, ErrRestoreWindowExpired,
+	ErrAccountBalanceNotZero, ErrAdminSelfDeletion, ErrCannotImpersonateStaff,
+
+	ErrInvalidCurrentPassword, ErrPasswordUnchanged, ErrPasswordRecentlyUsed,
+	ErrPasswordNotSet, ErrInvalidResetToken, ErrResetTokenExpired,
+	ErrInvalidMagicLink, ErrMagicLinkExpired,
+
+	ErrPhoneNumberMissing, ErrPhoneAlreadyVerified,
+	ErrPhoneVerificationRateLimited, ErrSMSDeliveryFailed,
+	ErrInvalidVerificationCode, ErrVerificationCodeExpired,
+	ErrVerificationAttemptsExceeded, ErrInvalidLoginCode,
+
+	ErrRegistrationClosed, ErrInvitationRequired, ErrInvalidInvitation,
+	ErrInvitationNotFound, ErrInvitationUsed, ErrInvitationRevoked,
+	ErrInvitationExpired, ErrInvitationEmailMismatch,
+
+	ErrOAuthNotConfigured, ErrInvalidOAuthState, ErrOAuthStateExpired,
+	ErrOAuthExchangeFailed, ErrOAuthEmailNotVerified, ErrOAuthAccountLinked,
+
+	ErrInvalidLoginReportToken, ErrLoginReportTokenExpired,
+
+	ErrEmailUnchanged, ErrEmailInUse, ErrEmailPendingDeletion,
+	ErrInvalidEmailChangeToken, ErrEmailChangeTokenExpired,
+
+	ErrPersonalAccessTokenNameRequired, ErrInvalidPersonalAccessTokenExpiry,
+	ErrInvalidScope, ErrPersonalAccessTokenNotFound, ErrInvalidPersonalAccessToken,
+
+	ErrKYCPending, ErrKYCAlreadyVerified, ErrKYCNotPending, ErrKYCNotesRequired,
+	ErrCannotReviewOwnKYC, ErrKYCDocumentNotFound, ErrKYCDocumentType,
+	ErrKYCDocumentLimitReached,
+
+	ErrInvalidDownloadToken, ErrDownloadGone,
+
+	ErrStatementSubscriptionNotFound, ErrInvalidStatementPeriod,
+	ErrStatementAlreadySent, ErrStatementDeliveryInProgress,
+}
+
+// FieldError describes why one field of a request is invalid
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+// ValidationError reports every invalid field of a request at once
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Field + ": " + f.Message
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+// SuspensionError reports that an account is blacklisted and until when. It
+// matches ErrAccountSuspended with errors.Is. A nil Until means the
+// suspension has no end date.
+type SuspensionError struct {
+	Until *time.Time
+}
+
+func (e *SuspensionError) Error() string {
+	if e.Until != nil {
+		return fmt.Sprintf("%s until %s", ErrAccountSuspended, e.Until.UTC().Format(time.RFC3339))
+	}
+	return ErrAccountSuspended.Error()
+}
+
+// Is makes errors.Is(err, ErrAccountSuspended) true for suspension errors
+func (e *SuspensionError) Is(target error) bool {
+	return target == ErrAccountSuspended
+}
+
+// IsTemporary reports whether the suspension lifts automatically
+func (e *SuspensionError) IsTemporary() bool {
+	return e.Until != nil
+}
+
+// DownloadGoneError reports that a download link has expired or been used
+// up, and the kind of artifact it was for when that is still known. It
+// matches ErrDownloadGone with errors.Is.
+type DownloadGoneError struct {
+	Kind string
+}
+
+func (e *DownloadGoneError) Error() string {
+	return ErrDownloadGone.Error()
+}
+
+// Is makes errors.Is(err, ErrDownloadGone) true for gone downloads
+func (e *DownloadGoneError) Is(target error) bool {
+	return target == ErrDownloadGone
+}
+
+// suspensionError describes a blacklisted user's suspension
+func suspensionError(user *models.User) error {
+	return &SuspensionError{Until: user.BlacklistExpiresAt}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/events"
+	"microbank/pkg/mailer"
+)
+
+// externalTransferFailureReasons explain the reasons the banking service
+// fails external transfers for on its own; reasons given by staff are
+// shown as they are
+var externalTransferFailureReasons = map[string]string{
+	"insufficient_funds":         "Your balance no longer covered the transfer when it was due to settle.",
+	"rejected_by_receiving_bank": "The receiving bank turned the transfer down.",
+}
+
+// ExternalTransferEventConsumer tells users about transfers to other banks
+// that failed, published by the banking service
+type ExternalTransferEventConsumer struct {
+	userRepo    repository.UserRepository
+	emailSender mailer.EmailSender
+	preferences *NotificationPreferenceService
+}
+
+// NewExternalTransferEventConsumer creates a new external transfer event
+// consumer
+func NewExternalTransferEventConsumer(userRepo repository.UserRepository, emailSender mailer.EmailSender, preferences *NotificationPreferenceService) *ExternalTransferEventConsumer {
+	return &ExternalTransferEventConsumer{
+		userRepo:    userRepo,
+		emailSender: emailSender,
+		preferences: preferences,
+	}
+}
+
+// Handle applies the event and reports whether this service acted on its
+// type; other types are accepted and ignored. A failed transfer is emailed
+// to its sender unless they have turned external_transfer_failed emails
+// off. An email that fails to send is returned as an error so the event is
+// retried.
+func (c *ExternalTransferEventConsumer) Handle(event events.Event) (bool, error) {
+	if event.Type != events.TypeExternalTransferFailed {
+		return false, nil
+	}
+
+	var payload events.ExternalTransferFailed
+	if err := events.Decode(event, &payload); err != nil {
+		return false, err
+	}
+
+	user, err := c.userRepo.GetUserByID(payload.UserID)
+	if err != nil {
+		// The user may have been deleted since the transfer was made
+		log.Printf("Not notifying user %s of failed external transfer %s: %v", payload.UserID, payload.TransferID, err)
+		return true, nil
+	}
+
+	channels, err := c.preferences.ChannelsFor(user.ID, models.NotificationEventExternalTransferFailed)
+	if err != nil {
+		log.Printf("Failed to load notification preferences for user %s, using defaults: %v", user.ID, err)
+		channels = models.DefaultNotificationChannels(models.NotificationEventExternalTransferFailed)
+	}
+	if !channels.Email {
+		return true, nil
+	}
+
+	reason, known := externalTransferFailureReasons[payload.Reason]
+	if !known {
+		reason = payload.Reason
+	}
+	data := map[string]string{
+		"Name":              user.Name,
+		"Amount":            fmt.Sprintf("%.2f", payload.Amount),
+		"AccountNumber":     payload.AccountNumber,
+		"AccountHolderName": payload.AccountHolderName,
+		"Reason":            reason,
+		"Link":              externalTransfersURL(),
+	}
+	if err := sendEmail(c.emailSender, user.Email, user.ID, "external_transfer_failed", data); err != nil {
+		return false, fmt.Errorf("failed to email failed external transfer %s: %w", payload.TransferID, err)
+	}
+
+	return true, nil
+}
+
+// externalTransfersURL returns the frontend page that lists a user's
+// transfers to other banks
+func externalTransfersURL() string {
+	if url := os.Getenv("EXTERNAL_TRANSFERS_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000/transfers"
+}
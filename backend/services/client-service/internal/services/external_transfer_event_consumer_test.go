@@ -0,0 +1,87 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/events"
+)
+
+func TestExternalTransferEventConsumer_EmailsFailedTransfers(t *testing.T) {
+	user := newTestUser(t, "password123")
+	sender := &fakeEmailSender{}
+	preferences := NewNotificationPreferenceService(&fakeNotificationPreferenceRepo{})
+	consumer := NewExternalTransferEventConsumer(newFakeUserRepo(user), sender, preferences)
+
+	newFailed := func(reason string) events.Event {
+		t.Helper()
+		event, err := events.New(events.TypeExternalTransferFailed, events.SourceBankingService, events.ExternalTransferFailed{
+			TransferID:        uuid.New(),
+			UserID:            user.ID,
+			Amount:            250,
+			AccountNumber:     "****6789",
+			AccountHolderName: "Tendai Moyo",
+			Reason:            reason,
+			FailedAt:          time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("events.New returned error: %v", err)
+		}
+		return event
+	}
+
+	tests := []struct {
+		reason string
+		want   string
+	}{
+		{reason: "rejected_by_receiving_bank", want: "The receiving bank turned the transfer down."},
+		{reason: "insufficient_funds", want: "Your balance no longer covered the transfer"},
+		{reason: "Account closed at the receiving bank", want: "Account closed at the receiving bank"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			handled, err := consumer.Handle(newFailed(tt.reason))
+			if err != nil || !handled {
+				t.Fatalf("Expected the event to be handled, got %v (%v)", handled, err)
+			}
+			email, ok := sender.last()
+			if !ok {
+				t.Fatal("Expected a failed transfer email")
+			}
+			if email.to != user.Email || !strings.Contains(email.subject, "transfer") {
+				t.Errorf("Expected a transfer email to %s, got %q to %s", user.Email, email.subject, email.to)
+			}
+			for _, want := range []string{"250.00", "****6789", "Tendai Moyo", tt.want} {
+				if !strings.Contains(email.body, want) {
+					t.Errorf("Expected the email to mention %q:\n%s", want, email.body)
+				}
+			}
+		})
+	}
+
+	// Users who turn the emails off get none
+	off := false
+	if _, err := preferences.UpdatePreferences(user.ID, models.NotificationPreferencesUpdate{
+		Preferences: map[string]models.NotificationChannelsUpdate{models.NotificationEventExternalTransferFailed: {Email: &off}},
+	}); err != nil {
+		t.Fatalf("UpdatePreferences returned error: %v", err)
+	}
+	sent := len(sender.sent)
+	if handled, err := consumer.Handle(newFailed("insufficient_funds")); err != nil || !handled {
+		t.Fatalf("Expected the event to be handled, got %v (%v)", handled, err)
+	}
+	if len(sender.sent) != sent {
+		t.Errorf("Expected no email with external_transfer_failed turned off, got %d more", len(sender.sent)-sent)
+	}
+
+	other, err := events.New(events.TypeUserRegistered, events.SourceClientService, events.UserRegistered{UserID: user.ID})
+	if err != nil {
+		t.Fatalf("events.New returned error: %v", err)
+	}
+	if handled, err := consumer.Handle(other); err != nil || handled {
+		t.Errorf("Expected other event types to be ignored, got %v (%v)", handled, err)
+	}
+}
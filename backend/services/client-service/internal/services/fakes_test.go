@@ -0,0 +1,1424 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordhash"
+	"microbank/client-service/internal/repository"
+	sharedjwt "microbank/pkg/jwt"
+	"microbank/pkg/mailer"
+)
+
+// testHasher matches the cost newTestUser hashes with, so logins in tests
+// do not trigger a hash upgrade unless a test asks for one
+var testHasher = passwordhash.NewBcrypt(bcrypt.MinCost)
+
+// testTokens signs access tokens with the secret tests set as JWT_SECRET
+var testTokens = sharedjwt.NewTokenManager("test-secret", 15*time.Minute, 7*24*time.Hour)
+
+// fakeUserRepo is an in-memory UserRepository. Methods that a test does not
+// exercise fall through to the embedded nil interface and panic.
+type fakeUserRepo struct {
+	repository.UserRepository
+	mu    sync.Mutex
+	users map[uuid.UUID]*models.User
+}
+
+func newFakeUserRepo(users ...*models.User) *fakeUserRepo {
+	r := &fakeUserRepo{users: make(map[uuid.UUID]*models.User)}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *fakeUserRepo) GetUserByID(id uuid.UUID) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	clone := *u
+	return &clone, nil
+}
+
+func (r *fakeUserRepo) GetUserByEmail(email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Email == email {
+			clone := *u
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (r *fakeUserRepo) GetUserByVerifiedPhone(phoneNumber string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []*models.User
+	for _, u := range r.users {
+		if u.PhoneNumber == phoneNumber && u.IsPhoneVerified() && u.DeletedAt == nil {
+			found = append(found, u)
+		}
+	}
+	if len(found) != 1 {
+		return nil, fmt.Errorf("user not found")
+	}
+	clone := *found[0]
+	return &clone, nil
+}
+
+func (r *fakeUserRepo) UpdatePassword(userID uuid.UUID, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return fmt.Errorf("user not found for password update")
+	}
+	u.PasswordHash = passwordHash
+	u.MustResetPassword = false
+	u.MustSetPassword = false
+	return nil
+}
+
+// fakeRefreshTokenRepo is an in-memory RefreshTokenRepository
+type fakeRefreshTokenRepo struct {
+	repository.RefreshTokenRepository
+	mu     sync.Mutex
+	tokens map[uuid.UUID]models.RefreshToken
+}
+
+func newFakeRefreshTokenRepo() *fakeRefreshTokenRepo {
+	return &fakeRefreshTokenRepo{tokens: make(map[uuid.UUID]models.RefreshToken)}
+}
+
+func (r *fakeRefreshTokenRepo) Create(token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+// CreateWithLimit holds the lock across the insert and the eviction, like
+// the row lock the real repository takes on the user
+func (r *fakeRefreshTokenRepo) CreateWithLimit(token *models.RefreshToken, maxPerUser int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.ID] = *token
+
+	var owned []models.RefreshToken
+	for _, t := range r.tokens {
+		if t.UserID == token.UserID {
+			owned = append(owned, t)
+		}
+	}
+	sort.Slice(owned, func(i, j int) bool { return owned[i].CreatedAt.After(owned[j].CreatedAt) })
+
+	var evicted int64
+	for _, t := range owned[min(maxPerUser, len(owned)):] {
+		delete(r.tokens, t.ID)
+		evicted++
+	}
+	return evicted, nil
+}
+
+func (r *fakeRefreshTokenRepo) GetByToken(tokenHash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("refresh token not found")
+}
+
+func (r *fakeRefreshTokenRepo) DeleteByUserID(userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, t := range r.tokens {
+		if t.UserID == userID {
+			delete(r.tokens, id)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) hasToken(tokenHash string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeRefreshTokenRepo) CountActiveByUserID(userID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, t := range r.tokens {
+		if t.UserID == userID && time.Now().Before(t.ExpiresAt) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeRefreshTokenRepo) countForUser(userID uuid.UUID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, t := range r.tokens {
+		if t.UserID == userID {
+			n++
+		}
+	}
+	return n
+}
+
+// fakeAuditLogRepo records audit log entries
+type fakeAuditLogRepo struct {
+	repository.AuditLogRepository
+	entries []models.AuditLogEntry
+}
+
+func (r *fakeAuditLogRepo) Create(entry *models.AuditLogEntry) error {
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+// fakeBankingClient records pushed token revocations, reports fixed stats
+// and statements by period, and can be made to fail
+type fakeBankingClient struct {
+	BankingClient
+	revoked    []models.RevokedToken
+	stats      models.BankingStats
+	statsCalls int
+	statements map[string]*models.Statement
+	err        error
+}
+
+func (c *fakeBankingClient) RevokeTokens(tokens []models.RevokedToken) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.revoked = append(c.revoked, tokens...)
+	return nil
+}
+
+func (c *fakeBankingClient) GetStats() (*models.BankingStats, error) {
+	c.statsCalls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	stats := c.stats
+	return &stats, nil
+}
+
+func (c *fakeBankingClient) GetStatement(userID uuid.UUID, period string) (*models.Statement, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	statement, ok := c.statements[period]
+	if !ok {
+		return nil, fmt.Errorf("statement not found")
+	}
+	return statement, nil
+}
+
+// fakeResetTokenRepo is an in-memory PasswordResetTokenRepository
+type fakeResetTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]*models.PasswordResetToken
+}
+
+func newFakeResetTokenRepo() *fakeResetTokenRepo {
+	return &fakeResetTokenRepo{tokens: make(map[uuid.UUID]*models.PasswordResetToken)}
+}
+
+func (r *fakeResetTokenRepo) Create(token *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *token
+	r.tokens[token.ID] = &clone
+	return nil
+}
+
+func (r *fakeResetTokenRepo) GetByTokenHash(tokenHash string) (*models.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			clone := *t
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("password reset token not found")
+}
+
+func (r *fakeResetTokenRepo) MarkUsed(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[id]
+	if !ok || t.UsedAt != nil {
+		return fmt.Errorf("password reset token already used")
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return nil
+}
+
+func (r *fakeResetTokenRepo) CountRecentByUserID(userID uuid.UUID, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, t := range r.tokens {
+		if t.UserID == userID && !t.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeResetTokenRepo) DeleteByUserID(userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, t := range r.tokens {
+		if t.UserID == userID {
+			delete(r.tokens, id)
+		}
+	}
+	return nil
+}
+
+// fakeMagicLinkTokenRepo is an in-memory MagicLinkTokenRepository
+type fakeMagicLinkTokenRepo struct {
+	mu     sync.Mutex
+	tokens []*models.MagicLinkToken
+}
+
+func (r *fakeMagicLinkTokenRepo) Create(token *models.MagicLinkToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *token
+	r.tokens = append(r.tokens, &clone)
+	return nil
+}
+
+func (r *fakeMagicLinkTokenRepo) GetByTokenHash(tokenHash string) (*models.MagicLinkToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			clone := *t
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("sign-in link token not found")
+}
+
+func (r *fakeMagicLinkTokenRepo) MarkUsed(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.ID == id && t.UsedAt == nil {
+			now := time.Now()
+			t.UsedAt = &now
+			return nil
+		}
+	}
+	return fmt.Errorf("sign-in link token already used")
+}
+
+func (r *fakeMagicLinkTokenRepo) CountRecentByUserID(userID uuid.UUID, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, t := range r.tokens {
+		if t.UserID == userID && !t.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// fakePhoneVerificationRepo is an in-memory PhoneVerificationRepository
+type fakePhoneVerificationRepo struct {
+	mu    sync.Mutex
+	codes []*models.PhoneVerificationCode
+}
+
+func (r *fakePhoneVerificationRepo) Create(code *models.PhoneVerificationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *code
+	r.codes = append(r.codes, &clone)
+	return nil
+}
+
+func (r *fakePhoneVerificationRepo) GetLatestByUserID(userID uuid.UUID) (*models.PhoneVerificationCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.codes) - 1; i >= 0; i-- {
+		if r.codes[i].UserID == userID {
+			clone := *r.codes[i]
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("phone verification code not found")
+}
+
+func (r *fakePhoneVerificationRepo) IncrementAttempts(id uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.codes {
+		if c.ID == id {
+			c.Attempts++
+			return c.Attempts, nil
+		}
+	}
+	return 0, fmt.Errorf("phone verification code not found")
+}
+
+func (r *fakePhoneVerificationRepo) MarkUsed(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.codes {
+		if c.ID == id && c.UsedAt == nil {
+			now := time.Now()
+			c.UsedAt = &now
+			return nil
+		}
+	}
+	return fmt.Errorf("phone verification code already used")
+}
+
+func (r *fakePhoneVerificationRepo) CountRecentByUserID(userID uuid.UUID, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, c := range r.codes {
+		if c.UserID == userID && !c.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// fakeLoginOTPRepo is an in-memory LoginOTPRepository
+type fakeLoginOTPRepo struct {
+	mu    sync.Mutex
+	codes []*models.LoginOTPCode
+}
+
+func (r *fakeLoginOTPRepo) Create(code *models.LoginOTPCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *code
+	r.codes = append(r.codes, &clone)
+	return nil
+}
+
+func (r *fakeLoginOTPRepo) GetLatestByPhoneNumber(phoneNumber string) (*models.LoginOTPCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.codes) - 1; i >= 0; i-- {
+		if r.codes[i].PhoneNumber == phoneNumber {
+			clone := *r.codes[i]
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("login code not found")
+}
+
+func (r *fakeLoginOTPRepo) IncrementAttempts(id uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.codes {
+		if c.ID == id {
+			c.Attempts++
+			return c.Attempts, nil
+		}
+	}
+	return 0, fmt.Errorf("login code not found")
+}
+
+func (r *fakeLoginOTPRepo) MarkUsed(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.codes {
+		if c.ID == id && c.UsedAt == nil {
+			now := time.Now()
+			c.UsedAt = &now
+			return nil
+		}
+	}
+	return fmt.Errorf("login code already used")
+}
+
+func (r *fakeLoginOTPRepo) CountRecentByPhoneNumber(phoneNumber string, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, c := range r.codes {
+		if c.PhoneNumber == phoneNumber && !c.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// fakeSMSSender records text messages instead of sending them and can be
+// made to fail
+type fakeSMSSender struct {
+	mu   sync.Mutex
+	sent []sentSMS
+	err  error
+}
+
+// sentSMS is a message captured by fakeSMSSender
+type sentSMS struct {
+	to, message string
+}
+
+func (s *fakeSMSSender) Send(to, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, sentSMS{to: to, message: message})
+	return nil
+}
+
+// sentEmail is a message captured by fakeEmailSender
+type sentEmail struct {
+	to, subject, body string
+}
+
+// fakeEmailSender records emails instead of sending them and can be made
+// to fail
+type fakeEmailSender struct {
+	mu   sync.Mutex
+	sent []sentEmail
+	err  error
+}
+
+func (s *fakeEmailSender) Send(ctx context.Context, msg mailer.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, sentEmail{to: msg.To, subject: msg.Subject, body: msg.Text})
+	return nil
+}
+
+func (s *fakeEmailSender) last() (sentEmail, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.sent) == 0 {
+		return sentEmail{}, false
+	}
+	return s.sent[len(s.sent)-1], true
+}
+
+func (r *fakeUserRepo) EmailInUse(email string, excludeUserID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.ID != excludeUserID && (u.Email == email || u.PendingEmail == email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeUserRepo) SetPendingEmail(userID uuid.UUID, pendingEmail, tokenHash, cancelTokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return fmt.Errorf("user not found for email change")
+	}
+	now := time.Now()
+	u.PendingEmail = pendingEmail
+	u.PendingEmailToken = tokenHash
+	u.EmailChangeCancelToken = cancelTokenHash
+	u.EmailChangeRequestedAt = &now
+	u.PreviousEmail = ""
+	return nil
+}
+
+func (r *fakeUserRepo) findBy(match func(*models.User) bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if match(u) {
+			clone := *u
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (r *fakeUserRepo) MarkPhoneVerified(userID uuid.UUID, phoneNumber string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok || u.PhoneNumber != phoneNumber {
+		return fmt.Errorf("phone number changed before verification")
+	}
+	now := time.Now()
+	u.PhoneVerifiedAt = &now
+	return nil
+}
+
+func (r *fakeUserRepo) IncrementTokenVersion(userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return fmt.Errorf("user not found")
+	}
+	u.TokenVersion++
+	return nil
+}
+
+func (r *fakeUserRepo) RequirePasswordReset(userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return fmt.Errorf("user not found")
+	}
+	u.MustResetPassword = true
+	u.TokenVersion++
+	return nil
+}
+
+func (r *fakeUserRepo) UserExists(email string) (bool, error) {
+	_, err := r.findBy(func(u *models.User) bool { return u.DeletedAt == nil && u.Email == email })
+	return err == nil, nil
+}
+
+func (r *fakeUserRepo) GetDeletedUserByEmail(email string) (*models.User, error) {
+	return r.findBy(func(u *models.User) bool { return u.DeletedAt != nil && u.Email == email })
+}
+
+func (r *fakeUserRepo) GetUserByPendingEmailToken(tokenHash string) (*models.User, error) {
+	return r.findBy(func(u *models.User) bool { return u.PendingEmailToken != "" && u.PendingEmailToken == tokenHash })
+}
+
+func (r *fakeUserRepo) GetUserByEmailChangeCancelToken(tokenHash string) (*models.User, error) {
+	return r.findBy(func(u *models.User) bool {
+		return u.EmailChangeCancelToken != "" && u.EmailChangeCancelToken == tokenHash
+	})
+}
+
+func (r *fakeUserRepo) ConfirmPendingEmail(userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok || u.PendingEmail == "" {
+		return fmt.Errorf("no pending email to confirm")
+	}
+	now := time.Now()
+	u.PreviousEmail, u.Email = u.Email, u.PendingEmail
+	u.PendingEmail, u.PendingEmailToken = "", ""
+	u.EmailVerifiedAt = &now
+	return nil
+}
+
+func (r *fakeUserRepo) CancelEmailChange(userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return fmt.Errorf("user not found for email change cancellation")
+	}
+	if u.PreviousEmail != "" {
+		u.Email = u.PreviousEmail
+		u.EmailVerifiedAt = nil
+	}
+	u.PreviousEmail, u.PendingEmail, u.PendingEmailToken, u.EmailChangeCancelToken = "", "", "", ""
+	u.EmailChangeRequestedAt = nil
+	return nil
+}
+
+// fakeLoginEventRepo is an in-memory LoginEventRepository
+type fakeLoginEventRepo struct {
+	mu     sync.Mutex
+	events []models.LoginEvent
+	err    error
+}
+
+func (r *fakeLoginEventRepo) Create(event *models.LoginEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeLoginEventRepo) GetByUserID(userID uuid.UUID, limit int) ([]models.LoginEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []models.LoginEvent
+	for i := len(r.events) - 1; i >= 0 && len(events) < limit; i-- {
+		if r.events[i].UserID != nil && *r.events[i].UserID == userID {
+			events = append(events, r.events[i])
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeLoginEventRepo) DeleteOlderThan(cutoff time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.events[:0]
+	for _, e := range r.events {
+		if !e.CreatedAt.Before(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	deleted := int64(len(r.events) - len(kept))
+	r.events = kept
+	return deleted, nil
+}
+
+func (r *fakeUserRepo) UpdateLastLogin(userID uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return fmt.Errorf("user not found for last login update")
+	}
+	u.LastLoginAt = &at
+	return nil
+}
+
+// fakePasswordHistoryRepo is an in-memory PasswordHistoryRepository
+type fakePasswordHistoryRepo struct {
+	mu      sync.Mutex
+	entries []models.PasswordHistoryEntry
+}
+
+func (r *fakePasswordHistoryRepo) Create(entry *models.PasswordHistoryEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *fakePasswordHistoryRepo) GetRecentByUserID(userID uuid.UUID, limit int) ([]models.PasswordHistoryEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []models.PasswordHistoryEntry
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		if r.entries[i].UserID == userID {
+			entries = append(entries, r.entries[i])
+		}
+	}
+	return entries, nil
+}
+
+func (r *fakePasswordHistoryRepo) PruneByUserID(userID uuid.UUID, keep int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var kept []models.PasswordHistoryEntry
+	seen := 0
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if r.entries[i].UserID == userID {
+			if seen >= keep {
+				continue
+			}
+			seen++
+		}
+		kept = append([]models.PasswordHistoryEntry{r.entries[i]}, kept...)
+	}
+	r.entries = kept
+	return nil
+}
+
+func (r *fakePasswordHistoryRepo) countForUser(userID uuid.UUID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, e := range r.entries {
+		if e.UserID == userID {
+			count++
+		}
+	}
+	return count
+}
+
+// fakeNotificationPreferenceRepo is an in-memory NotificationPreferenceRepository
+// that counts reads
+type fakeNotificationPreferenceRepo struct {
+	mu          sync.Mutex
+	preferences map[uuid.UUID]map[string]models.NotificationPreference
+	reads       int
+}
+
+func (r *fakeNotificationPreferenceRepo) GetByUserID(userID uuid.UUID) ([]models.NotificationPreference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reads++
+	var out []models.NotificationPreference
+	for _, p := range r.preferences[userID] {
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (r *fakeNotificationPreferenceRepo) Upsert(preferences []models.NotificationPreference) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.preferences == nil {
+		r.preferences = make(map[uuid.UUID]map[string]models.NotificationPreference)
+	}
+	for _, p := range preferences {
+		if r.preferences[p.UserID] == nil {
+			r.preferences[p.UserID] = make(map[string]models.NotificationPreference)
+		}
+		r.preferences[p.UserID][p.EventType] = p
+	}
+	return nil
+}
+
+// fakeUserPreferenceRepo is an in-memory UserPreferenceRepository
+// that counts reads
+type fakeUserPreferenceRepo struct {
+	mu          sync.Mutex
+	preferences map[uuid.UUID]models.UserPreferences
+	reads       int
+}
+
+func newFakeUserPreferenceRepo(userIDs ...uuid.UUID) *fakeUserPreferenceRepo {
+	r := &fakeUserPreferenceRepo{preferences: make(map[uuid.UUID]models.UserPreferences)}
+	for _, id := range userIDs {
+		r.preferences[id] = models.UserPreferences{}
+	}
+	return r
+}
+
+func (r *fakeUserPreferenceRepo) GetByUserID(userID uuid.UUID) (*models.UserPreferences, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reads++
+	preferences, ok := r.preferences[userID]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &preferences, nil
+}
+
+func (r *fakeUserPreferenceRepo) Update(userID uuid.UUID, update models.UserPreferencesUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	preferences, ok := r.preferences[userID]
+	if !ok {
+		return fmt.Errorf("user not found for update")
+	}
+	if update.Locale != nil {
+		preferences.Locale = *update.Locale
+	}
+	if update.TimeZone != nil {
+		preferences.TimeZone = *update.TimeZone
+	}
+	r.preferences[userID] = preferences
+	return nil
+}
+
+// fakeKnownDeviceRepo is an in-memory KnownDeviceRepository
+type fakeKnownDeviceRepo struct {
+	mu      sync.Mutex
+	devices []*models.KnownDevice
+}
+
+func (r *fakeKnownDeviceRepo) RecordLogin(device *models.KnownDevice) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.devices {
+		if d.UserID == device.UserID && d.Fingerprint == device.Fingerprint {
+			d.LastSeenAt, d.IPAddress, d.UserAgent = device.LastSeenAt, device.IPAddress, device.UserAgent
+			return false, nil
+		}
+	}
+	clone := *device
+	clone.FirstSeenAt = device.LastSeenAt
+	r.devices = append(r.devices, &clone)
+	return true, nil
+}
+
+func (r *fakeKnownDeviceRepo) CountByUserID(userID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, d := range r.devices {
+		if d.UserID == userID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeKnownDeviceRepo) GetByReportTokenHash(tokenHash string) (*models.KnownDevice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.devices {
+		if d.ReportTokenHash == tokenHash {
+			clone := *d
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("known device not found")
+}
+
+func (r *fakeKnownDeviceRepo) Delete(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, d := range r.devices {
+		if d.ID == id {
+			r.devices = append(r.devices[:i], r.devices[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// fakeGeoIPResolver places every IP address in the same city
+type fakeGeoIPResolver struct {
+	location string
+}
+
+func (r fakeGeoIPResolver) Locate(ip string) (string, error) {
+	return r.location, nil
+}
+
+func (r *fakeUserRepo) CreateUser(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *user
+	r.users[user.ID] = &clone
+	return nil
+}
+
+// CreateUserByAdmin creates the user; the audit entry is not kept
+func (r *fakeUserRepo) CreateUserByAdmin(user *models.User, audit *models.AuditLogEntry) error {
+	return r.CreateUser(user)
+}
+
+func (r *fakeUserRepo) GetUserByOAuthSubject(provider, subject string) (*models.User, error) {
+	return r.findBy(func(u *models.User) bool {
+		return u.DeletedAt == nil && u.OAuthProvider == provider && u.OAuthSubject == subject
+	})
+}
+
+func (r *fakeUserRepo) LinkOAuthIdentity(userID uuid.UUID, provider, subject string, emailVerified bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return fmt.Errorf("user not found for OAuth link")
+	}
+	u.OAuthProvider, u.OAuthSubject = provider, subject
+	if emailVerified && u.EmailVerifiedAt == nil {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+	}
+	return nil
+}
+
+// fakeOAuthStateRepo is an in-memory OAuthStateRepository
+type fakeOAuthStateRepo struct {
+	mu     sync.Mutex
+	states map[string]*models.OAuthState
+}
+
+func newFakeOAuthStateRepo() *fakeOAuthStateRepo {
+	return &fakeOAuthStateRepo{states: make(map[string]*models.OAuthState)}
+}
+
+func (r *fakeOAuthStateRepo) Create(state *models.OAuthState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *state
+	r.states[state.StateHash] = &clone
+	return nil
+}
+
+func (r *fakeOAuthStateRepo) Consume(stateHash string) (*models.OAuthState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[stateHash]
+	if !ok {
+		return nil, fmt.Errorf("OAuth state not found")
+	}
+	delete(r.states, stateHash)
+	return state, nil
+}
+
+func (r *fakeOAuthStateRepo) DeleteExpired(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for hash, state := range r.states {
+		if state.ExpiresAt.Before(before) {
+			delete(r.states, hash)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// fakePersonalAccessTokenRepo is an in-memory PersonalAccessTokenRepository
+// keyed by token hash
+type fakePersonalAccessTokenRepo struct {
+	mu      sync.Mutex
+	tokens  map[string]*models.PersonalAccessToken
+	touches int
+}
+
+func newFakePersonalAccessTokenRepo() *fakePersonalAccessTokenRepo {
+	return &fakePersonalAccessTokenRepo{tokens: make(map[string]*models.PersonalAccessToken)}
+}
+
+func (r *fakePersonalAccessTokenRepo) Create(token *models.PersonalAccessToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *token
+	r.tokens[token.TokenHash] = &clone
+	return nil
+}
+
+func (r *fakePersonalAccessTokenRepo) GetByTokenHash(tokenHash string) (*models.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, fmt.Errorf("personal access token not found")
+	}
+	clone := *token
+	return &clone, nil
+}
+
+func (r *fakePersonalAccessTokenRepo) ListByUserID(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tokens := []models.PersonalAccessToken{}
+	for _, token := range r.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, *token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *fakePersonalAccessTokenRepo) Delete(userID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, token := range r.tokens {
+		if token.ID == id && token.UserID == userID {
+			delete(r.tokens, hash)
+			return nil
+		}
+	}
+	return fmt.Errorf("personal access token not found")
+}
+
+func (r *fakePersonalAccessTokenRepo) TouchLastUsed(id uuid.UUID, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.ID == id {
+			token.LastUsedAt = &usedAt
+			r.touches++
+		}
+	}
+	return nil
+}
+
+// fakeKYCRepo is an in-memory KYCRepository that keeps the KYC status of
+// the users in step with their submissions
+type fakeKYCRepo struct {
+	mu          sync.Mutex
+	users       *fakeUserRepo
+	submissions []*models.KYCSubmission
+	audits      []models.AuditLogEntry
+}
+
+func newFakeKYCRepo(users *fakeUserRepo) *fakeKYCRepo {
+	return &fakeKYCRepo{users: users}
+}
+
+func (r *fakeKYCRepo) setStatus(userID uuid.UUID, status string) {
+	r.users.mu.Lock()
+	defer r.users.mu.Unlock()
+	r.users.users[userID].KYCStatus = status
+}
+
+func (r *fakeKYCRepo) Submit(submission *models.KYCSubmission) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	submission.Status = models.KYCStatusPending
+	clone := *submission
+	r.submissions = append(r.submissions, &clone)
+	r.setStatus(submission.UserID, models.KYCStatusPending)
+	return nil
+}
+
+func (r *fakeKYCRepo) GetLatestByUserID(userID uuid.UUID) (*models.KYCSubmission, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.submissions) - 1; i >= 0; i-- {
+		if r.submissions[i].UserID == userID {
+			clone := *r.submissions[i]
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("KYC submission not found")
+}
+
+func (r *fakeKYCRepo) Review(submission *models.KYCSubmission, audit *models.AuditLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.submissions {
+		if stored.ID == submission.ID && stored.Status == models.KYCStatusPending {
+			*stored = *submission
+			r.setStatus(submission.UserID, submission.Status)
+			if audit != nil {
+				r.audits = append(r.audits, *audit)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("KYC submission already reviewed")
+}
+
+func (r *fakeKYCRepo) List(opts models.ListKYCSubmissionsOptions) ([]models.KYCSubmission, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matching []models.KYCSubmission
+	for _, submission := range r.submissions {
+		if opts.Status == "" || submission.Status == opts.Status {
+			matching = append(matching, *submission)
+		}
+	}
+	total := len(matching)
+	if opts.Offset > total {
+		opts.Offset = total
+	}
+	matching = matching[opts.Offset:]
+	if len(matching) > opts.Limit {
+		matching = matching[:opts.Limit]
+	}
+	return matching, total, nil
+}
+
+// fakeKYCDocumentRepo is an in-memory KYCDocumentRepository. Documents
+// expire once their submission in kyc is reviewed before the cutoff, or is
+// gone.
+type fakeKYCDocumentRepo struct {
+	mu        sync.Mutex
+	kyc       *fakeKYCRepo
+	documents []*models.KYCDocument
+}
+
+func (r *fakeKYCDocumentRepo) Create(document *models.KYCDocument, maxPerSubmission int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, stored := range r.documents {
+		if *stored.SubmissionID == *document.SubmissionID {
+			count++
+		}
+	}
+	if count >= maxPerSubmission {
+		return fmt.Errorf("KYC submission already has %d documents", count)
+	}
+	clone := *document
+	r.documents = append(r.documents, &clone)
+	return nil
+}
+
+func (r *fakeKYCDocumentRepo) GetByID(id uuid.UUID) (*models.KYCDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.documents {
+		if stored.ID == id {
+			clone := *stored
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("KYC document not found")
+}
+
+func (r *fakeKYCDocumentRepo) ListBySubmissionID(submissionID uuid.UUID) ([]models.KYCDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var documents []models.KYCDocument
+	for _, stored := range r.documents {
+		if stored.SubmissionID != nil && *stored.SubmissionID == submissionID {
+			documents = append(documents, *stored)
+		}
+	}
+	return documents, nil
+}
+
+func (r *fakeKYCDocumentRepo) ListExpired(reviewedBefore time.Time, limit int) ([]models.KYCDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.kyc.mu.Lock()
+	defer r.kyc.mu.Unlock()
+	var documents []models.KYCDocument
+	for _, stored := range r.documents {
+		var submission *models.KYCSubmission
+		for _, candidate := range r.kyc.submissions {
+			if stored.SubmissionID != nil && candidate.ID == *stored.SubmissionID {
+				submission = candidate
+			}
+		}
+		if submission == nil || (submission.ReviewedAt != nil && submission.ReviewedAt.Before(reviewedBefore)) {
+			documents = append(documents, *stored)
+		}
+		if len(documents) == limit {
+			break
+		}
+	}
+	return documents, nil
+}
+
+func (r *fakeKYCDocumentRepo) Delete(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, stored := range r.documents {
+		if stored.ID == id {
+			r.documents = append(r.documents[:i], r.documents[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// fakeOAuthProvider returns a fixed identity for any code, checking the
+// nonce it was given matches the one in the consent URL
+type fakeOAuthProvider struct {
+	identity models.OAuthIdentity
+	err      error
+	nonces   map[string]string
+}
+
+func (p *fakeOAuthProvider) Name() string {
+	return models.OAuthProviderGoogle
+}
+
+func (p *fakeOAuthProvider) AuthCodeURL(state, nonce string) string {
+	if p.nonces == nil {
+		p.nonces = make(map[string]string)
+	}
+	p.nonces[state] = nonce
+	return "https://provider.example/auth?state=" + state
+}
+
+func (p *fakeOAuthProvider) Exchange(code, nonce string) (*models.OAuthIdentity, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if code != "nonce:"+nonce {
+		return nil, fmt.Errorf("nonce mismatch")
+	}
+	identity := p.identity
+	return &identity, nil
+}
+
+// CountUsers counts the in-memory users like the aggregate query does
+func (r *fakeUserRepo) CountUsers(today, thisWeek, thisMonth time.Time) (*models.UserCounts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var counts models.UserCounts
+	for _, u := range r.users {
+		if u.DeletedAt != nil {
+			continue
+		}
+		counts.Total++
+		if !u.CreatedAt.Before(today) {
+			counts.NewToday++
+		}
+		if !u.CreatedAt.Before(thisWeek) {
+			counts.NewThisWeek++
+		}
+		if !u.CreatedAt.Before(thisMonth) {
+			counts.NewThisMonth++
+		}
+		if u.IsBlacklisted {
+			counts.Blacklisted++
+		}
+		if u.EmailVerifiedAt != nil {
+			counts.EmailVerified++
+		}
+	}
+	return &counts, nil
+}
+
+// CountSignupsBySource counts each user registered since the given time
+// on its own; the report adds up counts for the same week and source
+func (r *fakeUserRepo) CountSignupsBySource(since time.Time) ([]models.SignupCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var counts []models.SignupCount
+	for _, u := range r.users {
+		if u.CreatedAt.Before(since) {
+			continue
+		}
+		created := u.CreatedAt.UTC()
+		day := time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, time.UTC)
+		counts = append(counts, models.SignupCount{WeekStart: weekStart(day), Source: u.RegistrationSource, Count: 1})
+	}
+	return counts, nil
+}
+
+// CountMatchingUsers counts active users, honoring only the is_blacklisted
+// filter
+func (r *fakeUserRepo) CountMatchingUsers(opts models.ListUsersOptions) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.matchingUsers(opts)), nil
+}
+
+// GetUsersAfter walks active users by creation time and ID, honoring only
+// the is_blacklisted filter and the sort direction
+func (r *fakeUserRepo) GetUsersAfter(opts models.ListUsersOptions, after *models.User, limit int) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	matched := r.matchingUsers(opts)
+	less := func(a, b *models.User) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt) != opts.SortDesc
+		}
+		if a.ID == b.ID {
+			return false
+		}
+		return (a.ID.String() < b.ID.String()) != opts.SortDesc
+	}
+	sort.Slice(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+
+	var users []models.User
+	for _, u := range matched {
+		if after != nil && !less(after, u) {
+			continue
+		}
+		if len(users) == limit {
+			break
+		}
+		users = append(users, *u)
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepo) matchingUsers(opts models.ListUsersOptions) []*models.User {
+	var matched []*models.User
+	for _, u := range r.users {
+		if u.DeletedAt != nil || (opts.IsBlacklisted != nil && u.IsBlacklisted != *opts.IsBlacklisted) {
+			continue
+		}
+		matched = append(matched, u)
+	}
+	return matched
+}
+
+func (r *fakeRefreshTokenRepo) CountActive() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, t := range r.tokens {
+		if time.Now().Before(t.ExpiresAt) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// fakeDownloadArtifactRepo is an in-memory DownloadArtifactRepository that
+// keeps the accesses it records
+type fakeDownloadArtifactRepo struct {
+	mu        sync.Mutex
+	artifacts []*models.DownloadArtifact
+	accesses  []models.DownloadAccess
+}
+
+func (r *fakeDownloadArtifactRepo) Create(artifact *models.DownloadArtifact) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clone := *artifact
+	r.artifacts = append(r.artifacts, &clone)
+	return nil
+}
+
+func (r *fakeDownloadArtifactRepo) GetByID(id uuid.UUID) (*models.DownloadArtifact, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, artifact := range r.artifacts {
+		if artifact.ID == id {
+			clone := *artifact
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("download artifact not found")
+}
+
+func (r *fakeDownloadArtifactRepo) ClaimDownload(id uuid.UUID, access *models.DownloadAccess, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, artifact := range r.artifacts {
+		if artifact.ID == id && artifact.DownloadCount < artifact.MaxDownloads && artifact.ExpiresAt.After(now) {
+			artifact.DownloadCount++
+			r.accesses = append(r.accesses, *access)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeDownloadArtifactRepo) ListExpired(before time.Time, limit int) ([]models.DownloadArtifact, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var artifacts []models.DownloadArtifact
+	for _, artifact := range r.artifacts {
+		if artifact.ExpiresAt.Before(before) {
+			artifacts = append(artifacts, *artifact)
+		}
+		if len(artifacts) == limit {
+			break
+		}
+	}
+	return artifacts, nil
+}
+
+func (r *fakeDownloadArtifactRepo) Delete(id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, artifact := range r.artifacts {
+		if artifact.ID == id {
+			r.artifacts = append(r.artifacts[:i], r.artifacts[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
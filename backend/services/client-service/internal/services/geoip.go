@@ -0,0 +1,21 @@
+package services
+
+// GeoIPResolver looks up the approximate location of an IP address, such as
+// "Cape Town, ZA". An empty location means it is unknown.
+type GeoIPResolver interface {
+	Locate(ip string) (string, error)
+}
+
+// NoopGeoIPResolver reports every location as unknown. It is used when no
+// GeoIP database is configured.
+type NoopGeoIPResolver struct{}
+
+// NewNoopGeoIPResolver creates a new GeoIP resolver that knows no locations
+func NewNoopGeoIPResolver() *NoopGeoIPResolver {
+	return &NoopGeoIPResolver{}
+}
+
+// Locate returns an empty location
+func (r *NoopGeoIPResolver) Locate(ip string) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"microbank/client-service/internal/models"
+)
+
+// Google OAuth 2.0 and OpenID Connect endpoints
+const (
+	googleAuthURL  = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+	googleCertsURL = "https://www.googleapis.com/oauth2/v3/certs"
+)
+
+// googleIssuers are the values Google uses for the iss claim of ID tokens
+var googleIssuers = []string{"https://accounts.google.com", "accounts.google.com"}
+
+// googleKeyRefreshInterval is the minimum time between fetches of Google's
+// signing keys when an ID token names a key that is not cached
+const googleKeyRefreshInterval = time.Minute
+
+// OAuthProvider signs users in with a third-party identity provider using
+// the authorization code flow
+type OAuthProvider interface {
+	// Name identifies the provider, such as models.OAuthProviderGoogle
+	Name() string
+	// AuthCodeURL returns the consent page URL the user is sent to
+	AuthCodeURL(state, nonce string) string
+	// Exchange trades an authorization code for the user's verified
+	// identity, checking the ID token was issued for nonce
+	Exchange(code, nonce string) (*models.OAuthIdentity, error)
+}
+
+// GoogleOAuthProvider signs users in with Google. ID tokens are verified
+// against Google's published signing keys, which are cached.
+type GoogleOAuthProvider struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	authURL      string
+	tokenURL     string
+	certsURL     string
+	httpClient   *http.Client
+
+	mu            sync.Mutex
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewGoogleOAuthProvider creates a new Google sign-in provider. redirectURL
+// must match a redirect URI registered for the OAuth client.
+func NewGoogleOAuthProvider(clientID, clientSecret, redirectURL string) *GoogleOAuthProvider {
+	return &GoogleOAuthProvider{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		authURL:      googleAuthURL,
+		tokenURL:     googleTokenURL,
+		certsURL:     googleCertsURL,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns models.OAuthProviderGoogle
+func (p *GoogleOAuthProvider) Name() string {
+	return models.OAuthProviderGoogle
+}
+
+// AuthCodeURL returns Google's consent page URL asking for the user's email
+// address and basic profile
+func (p *GoogleOAuthProvider) AuthCodeURL(state, nonce string) string {
+	params := url.Values{
+		"client_id":     {p.clientID},
+		"redirect_uri":  {p.redirectURL},
+		"response_type": {"code"},
+		"scope":         {"openid email profile"},
+		"state":         {state},
+		"nonce":         {nonce},
+		"prompt":        {"select_account"},
+	}
+	return p.authURL + "?" + params.Encode()
+}
+
+// Exchange trades an authorization code for tokens and returns the identity
+// in the verified ID token
+func (p *GoogleOAuthProvider) Exchange(code, nonce string) (*models.OAuthIdentity, error) {
+	form := url.Values{
+		"code":          {code},
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+		"redirect_uri":  {p.redirectURL},
+		"grant_type":    {"authorization_code"},
+	}
+
+	resp, err := p.httpClient.PostForm(p.tokenURL, form)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Google token endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode Google token response: %w", err)
+	}
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("google token response has no ID token")
+	}
+
+	return p.verifyIDToken(body.IDToken, nonce)
+}
+
+// verifyIDToken checks the ID token's signature, issuer, audience, expiry
+// and nonce, and returns the identity it asserts
+func (p *GoogleOAuthProvider) verifyIDToken(idToken, nonce string) (*models.OAuthIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	issuer, _ := claims["iss"].(string)
+	validIssuer := false
+	for _, iss := range googleIssuers {
+		if issuer == iss {
+			validIssuer = true
+			break
+		}
+	}
+	if !validIssuer {
+		return nil, fmt.Errorf("invalid ID token: unexpected issuer %q", issuer)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("invalid ID token: nonce mismatch")
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("invalid ID token: missing subject")
+	}
+
+	identity := &models.OAuthIdentity{
+		Provider: models.OAuthProviderGoogle,
+		Subject:  subject,
+	}
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+
+	// email_verified is a boolean, but older tokens encode it as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	return identity, nil
+}
+
+// signingKey returns Google's public key with the given key ID, fetching
+// the current keys if it is not cached. Keys are refetched at most once per
+// googleKeyRefreshInterval so unknown key IDs cannot flood Google.
+func (p *GoogleOAuthProvider) signingKey(kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetchedAt) < googleKeyRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := p.fetchKeys()
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// fetchKeys downloads Google's JSON Web Key Set
+func (p *GoogleOAuthProvider) fetchKeys() (map[string]*rsa.PublicKey, error) {
+	resp, err := p.httpClient.Get(p.certsURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Google signing keys: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google signing keys endpoint returned status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode Google signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.N, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus for signing key %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.E, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent for signing key %q: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newTestGoogleProvider returns a provider talking to a fake Google that
+// answers every code with idToken and publishes key under kid "key-1"
+func newTestGoogleProvider(t *testing.T, key *rsa.PrivateKey, idToken *string) *GoogleOAuthProvider {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "authorization_code" || r.FormValue("client_secret") != "secret" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": *idToken})
+	})
+	mux.HandleFunc("/certs", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "key-1",
+				"kty": "RSA",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	p := NewGoogleOAuthProvider("client-id", "secret", "https://app.example/callback")
+	p.tokenURL, p.certsURL = server.URL+"/token", server.URL+"/certs"
+	return p
+}
+
+func signTestIDToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign ID token: %v", err)
+	}
+	return signed
+}
+
+func testIDTokenClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            "https://accounts.google.com",
+		"aud":            "client-id",
+		"sub":            "1234567890",
+		"email":          "user@gmail.com",
+		"email_verified": true,
+		"name":           "Google User",
+		"nonce":          "nonce-1",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestGoogleOAuthProvider_AuthCodeURL(t *testing.T) {
+	p := NewGoogleOAuthProvider("client-id", "secret", "https://app.example/callback")
+
+	parsed, err := url.Parse(p.AuthCodeURL("state-1", "nonce-1"))
+	if err != nil {
+		t.Fatalf("invalid consent URL: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("state") != "state-1" || query.Get("nonce") != "nonce-1" || query.Get("client_id") != "client-id" {
+		t.Errorf("Unexpected consent URL parameters: %v", query)
+	}
+	if !strings.Contains(query.Get("scope"), "openid") {
+		t.Errorf("Expected the openid scope, got %q", query.Get("scope"))
+	}
+}
+
+func TestGoogleOAuthProvider_Exchange(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	var idToken string
+	p := newTestGoogleProvider(t, key, &idToken)
+
+	idToken = signTestIDToken(t, key, "key-1", testIDTokenClaims())
+	identity, err := p.Exchange("code", "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange returned error: %v", err)
+	}
+	if identity.Subject != "1234567890" || identity.Email != "user@gmail.com" || !identity.EmailVerified || identity.Name != "Google User" {
+		t.Errorf("Unexpected identity: %+v", identity)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		key    *rsa.PrivateKey
+		modify func(jwt.MapClaims)
+		nonce  string
+	}{
+		{name: "nonce mismatch", key: key, modify: func(jwt.MapClaims) {}, nonce: "other-nonce"},
+		{name: "wrong audience", key: key, modify: func(c jwt.MapClaims) { c["aud"] = "another-client" }, nonce: "nonce-1"},
+		{name: "wrong issuer", key: key, modify: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }, nonce: "nonce-1"},
+		{name: "expired", key: key, modify: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, nonce: "nonce-1"},
+		{name: "wrong signing key", key: otherKey, modify: func(jwt.MapClaims) {}, nonce: "nonce-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := testIDTokenClaims()
+			tt.modify(claims)
+			idToken = signTestIDToken(t, tt.key, "key-1", claims)
+
+			if _, err := p.Exchange("code", tt.nonce); err == nil {
+				t.Error("Expected the ID token to be rejected")
+			}
+		})
+	}
+}
+
+func TestGoogleOAuthProvider_UnknownKeyID(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	var idToken string
+	p := newTestGoogleProvider(t, key, &idToken)
+
+	idToken = signTestIDToken(t, key, "key-2", testIDTokenClaims())
+	if _, err := p.Exchange("code", "nonce-1"); err == nil {
+		t.Fatal("Expected an ID token signed with an unpublished key to be rejected")
+	}
+}
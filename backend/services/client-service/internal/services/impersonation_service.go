@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/client-service/internal/revocation"
+	sharedjwt "microbank/pkg/jwt"
+)
+
+// ImpersonationTTL is how long an impersonation token lives. There is no
+// refresh token, so the admin must start a new session after it.
+const ImpersonationTTL = 5 * time.Minute
+
+// ImpersonationService lets admins act as a user to see what they see.
+// Every session is recorded in the admin audit log.
+type ImpersonationService struct {
+	userRepo      repository.UserRepository
+	auditLogRepo  repository.AuditLogRepository
+	tokens        *sharedjwt.TokenManager
+	revocations   revocation.Store
+	bankingClient BankingClient
+}
+
+// NewImpersonationService creates a new impersonation service. Its tokens
+// are signed by tokens but live for ImpersonationTTL.
+func NewImpersonationService(userRepo repository.UserRepository, auditLogRepo repository.AuditLogRepository, tokens *sharedjwt.TokenManager, revocations revocation.Store, bankingClient BankingClient) *ImpersonationService {
+	return &ImpersonationService{
+		userRepo:      userRepo,
+		auditLogRepo:  auditLogRepo,
+		tokens:        tokens.WithAccessTTL(ImpersonationTTL),
+		revocations:   revocations,
+		bankingClient: bankingClient,
+	}
+}
+
+// StartImpersonation issues the acting admin an access token for the user.
+// The token names the admin in its impersonator_id claim and carries none
+// of the user's roles. Staff users, including admins, cannot be
+// impersonated. The token carries the user's token version and is tracked
+// like a login's, so a force logout of the user also ends the session.
+func (s *ImpersonationService) StartImpersonation(actor models.AuditActor, userID uuid.UUID) (*models.ImpersonationSession, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+	if user.IsAdmin || len(user.Roles) > 0 {
+		return nil, ErrCannotImpersonateStaff
+	}
+	if user.IsBlacklisted {
+		return nil, ErrAccountSuspended
+	}
+
+	claims := &sharedjwt.Claims{
+		UserID:         user.ID.String(),
+		Email:          user.Email,
+		Name:           user.Name,
+		ImpersonatorID: actor.AdminID.String(),
+		TokenVersion:   user.TokenVersion,
+	}
+	token, err := s.tokens.GenerateAccessToken(claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue impersonation token: %w", err)
+	}
+	if err := s.revocations.Track(user.ID, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return nil, fmt.Errorf("failed to track impersonation token: %w", err)
+	}
+
+	audit := newAuditLogEntry(actor, models.AuditActionImpersonationStart, userID, map[string]interface{}{
+		"token_id":   claims.ID,
+		"expires_at": claims.ExpiresAt.Time,
+	})
+	if err := s.auditLogRepo.Create(audit); err != nil {
+		// Sessions nobody can trace must not be handed out
+		return nil, fmt.Errorf("failed to record impersonation: %w", err)
+	}
+
+	return &models.ImpersonationSession{
+		AccessToken:    token,
+		ExpiresAt:      claims.ExpiresAt.Time,
+		UserID:         user.ID,
+		ImpersonatorID: actor.AdminID,
+	}, nil
+}
+
+// EndImpersonation revokes the impersonation token with the given jti, here
+// and at the banking-service, and records the end of the session under the
+// admin who started it
+func (s *ImpersonationService) EndImpersonation(actor models.AuditActor, userID uuid.UUID, tokenID string) error {
+	// The token expires within ImpersonationTTL, so keeping it on the list
+	// that long outlives it
+	tokens := []models.RevokedToken{{JTI: tokenID, ExpiresAt: time.Now().Add(ImpersonationTTL)}}
+	if err := s.revocations.Revoke(tokens); err != nil {
+		return fmt.Errorf("failed to revoke impersonation token: %w", err)
+	}
+	if s.bankingClient != nil {
+		if err := s.bankingClient.RevokeTokens(tokens); err != nil {
+			log.Printf("Failed to push revocation of impersonation token %s to banking-service: %v", tokenID, err)
+		}
+	}
+
+	audit := newAuditLogEntry(actor, models.AuditActionImpersonationEnd, userID, map[string]interface{}{
+		"token_id": tokenID,
+	})
+	if err := s.auditLogRepo.Create(audit); err != nil {
+		log.Printf("Failed to record the end of impersonation of user %s by %s: %v", userID, actor.AdminID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/revocation"
+)
+
+func TestImpersonationService_StartImpersonation(t *testing.T) {
+	user := newTestUser(t, "password123")
+	user.TokenVersion = 2
+	userRepo := newFakeUserRepo(user)
+	auditRepo := &fakeAuditLogRepo{}
+	revocations := revocation.NewMemoryStore()
+	svc := NewImpersonationService(userRepo, auditRepo, testTokens, revocations, &fakeBankingClient{})
+
+	actor := models.AuditActor{AdminID: uuid.New(), RequestID: "req-1"}
+	session, err := svc.StartImpersonation(actor, user.ID)
+	if err != nil {
+		t.Fatalf("StartImpersonation returned error: %v", err)
+	}
+
+	claims, err := testTokens.ValidateToken(session.AccessToken)
+	if err != nil {
+		t.Fatalf("failed to parse impersonation token: %v", err)
+	}
+	if claims.UserID != user.ID.String() || claims.ImpersonatorID != actor.AdminID.String() || claims.TokenVersion != 2 {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl > ImpersonationTTL || ttl < ImpersonationTTL-time.Minute {
+		t.Errorf("Expected the token to live for %v, got %v", ImpersonationTTL, ttl)
+	}
+
+	// A force logout of the user ends the session
+	revoked, _ := revocations.RevokeUser(user.ID)
+	if len(revoked) != 1 || revoked[0].JTI != claims.ID {
+		t.Errorf("Expected the impersonation token to be tracked, got %+v", revoked)
+	}
+
+	if len(auditRepo.entries) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d", len(auditRepo.entries))
+	}
+	entry := auditRepo.entries[0]
+	if entry.Action != models.AuditActionImpersonationStart || entry.AdminID != actor.AdminID || *entry.TargetUserID != user.ID || entry.Metadata["token_id"] != claims.ID {
+		t.Errorf("Unexpected audit entry: %+v", entry)
+	}
+}
+
+func TestImpersonationService_StartImpersonation_Refused(t *testing.T) {
+	admin := newTestUser(t, "password123")
+	admin.IsAdmin = true
+	admin.Roles = []string{"admin"}
+	support := newTestUser(t, "password123")
+	support.Roles = []string{"support"}
+	blacklisted := newTestUser(t, "password123")
+	blacklisted.IsBlacklisted = true
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		wantErr error
+	}{
+		{name: "admin", userID: admin.ID, wantErr: ErrCannotImpersonateStaff},
+		{name: "other staff", userID: support.ID, wantErr: ErrCannotImpersonateStaff},
+		{name: "blacklisted", userID: blacklisted.ID, wantErr: ErrAccountSuspended},
+		{name: "unknown user", userID: uuid.New(), wantErr: ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditRepo := &fakeAuditLogRepo{}
+			svc := NewImpersonationService(newFakeUserRepo(admin, support, blacklisted), auditRepo, testTokens, revocation.NewMemoryStore(), nil)
+
+			_, err := svc.StartImpersonation(models.AuditActor{AdminID: uuid.New()}, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if len(auditRepo.entries) != 0 {
+				t.Errorf("Expected nothing to be audited, got %+v", auditRepo.entries)
+			}
+		})
+	}
+}
+
+func TestImpersonationService_EndImpersonation(t *testing.T) {
+	user := newTestUser(t, "password123")
+	auditRepo := &fakeAuditLogRepo{}
+	revocations := revocation.NewMemoryStore()
+	banking := &fakeBankingClient{}
+	svc := NewImpersonationService(newFakeUserRepo(user), auditRepo, testTokens, revocations, banking)
+
+	actor := models.AuditActor{AdminID: uuid.New()}
+	if err := svc.EndImpersonation(actor, user.ID, "token-1"); err != nil {
+		t.Fatalf("EndImpersonation returned error: %v", err)
+	}
+
+	if revoked, _ := revocations.IsRevoked("token-1"); !revoked {
+		t.Error("Expected the impersonation token to be revoked")
+	}
+	if len(banking.revoked) != 1 || banking.revoked[0].JTI != "token-1" {
+		t.Errorf("Expected the revocation to be pushed to the banking-service, got %+v", banking.revoked)
+	}
+	if len(auditRepo.entries) != 1 || auditRepo.entries[0].Action != models.AuditActionImpersonationEnd || auditRepo.entries[0].AdminID != actor.AdminID {
+		t.Errorf("Expected an impersonation end audit entry by the admin, got %+v", auditRepo.entries)
+	}
+}
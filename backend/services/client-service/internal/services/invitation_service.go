@@ -0,0 +1,223 @@
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+// RegistrationMode controls who may register
+type RegistrationMode string
+
+// Registration modes
+const (
+	RegistrationOpen       RegistrationMode = "open"
+	RegistrationInviteOnly RegistrationMode = "invite_only"
+	RegistrationClosed     RegistrationMode = "closed"
+)
+
+// ParseRegistrationMode parses a REGISTRATION_MODE value. An empty value
+// means open registration.
+func ParseRegistrationMode(value string) (RegistrationMode, error) {
+	switch mode := RegistrationMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return RegistrationOpen, nil
+	case RegistrationOpen, RegistrationInviteOnly, RegistrationClosed:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown registration mode %q (want open, invite_only or closed)", value)
+	}
+}
+
+// Invitation settings
+const (
+	// DefaultInvitationExpiryDays is how long an invitation lasts when the
+	// admin does not say
+	DefaultInvitationExpiryDays = 7
+	DefaultInvitationPageSize   = 50
+	MaxInvitationPageSize       = 200
+
+	// invitationCodeAlphabet leaves out characters that are easy to misread
+	invitationCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	invitationCodeLength   = 8
+)
+
+// InvitationService manages invitations and decides whether a registration
+// is allowed under the configured registration mode
+type InvitationService struct {
+	invitationRepo repository.InvitationRepository
+	mode           RegistrationMode
+}
+
+// NewInvitationService creates a new invitation service
+func NewInvitationService(invitationRepo repository.InvitationRepository, mode RegistrationMode) *InvitationService {
+	return &InvitationService{
+		invitationRepo: invitationRepo,
+		mode:           mode,
+	}
+}
+
+// CreateInvitation creates an invitation on behalf of an admin
+func (s *InvitationService) CreateInvitation(actor models.AuditActor, request models.InvitationRequest) (*models.Invitation, error) {
+	code, err := generateInvitationCode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation code: %w", err)
+	}
+
+	days := request.ExpiresInDays
+	if days <= 0 {
+		days = DefaultInvitationExpiryDays
+	}
+
+	now := time.Now()
+	adminID := actor.AdminID
+	invitation := &models.Invitation{
+		ID:        uuid.New(),
+		Code:      code,
+		Email:     strings.TrimSpace(request.Email),
+		CreatedBy: &adminID,
+		ExpiresAt: now.AddDate(0, 0, days),
+		CreatedAt: now,
+	}
+
+	audit := newInvitationAuditLogEntry(actor, models.AuditActionCreateInvitation, invitation)
+	if err := s.invitationRepo.Create(invitation, audit); err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// ListInvitations retrieves one page of invitations, optionally filtered by
+// status. The page size is clamped to MaxInvitationPageSize.
+func (s *InvitationService) ListInvitations(opts models.ListInvitationsOptions) (*models.InvitationPage, error) {
+	// Set default values if not provided
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultInvitationPageSize
+	}
+	if opts.Limit > MaxInvitationPageSize {
+		opts.Limit = MaxInvitationPageSize
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+
+	invitations, total, err := s.invitationRepo.List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	return &models.InvitationPage{
+		Invitations: invitations,
+		Total:       total,
+		Limit:       opts.Limit,
+		Offset:      opts.Offset,
+	}, nil
+}
+
+// RevokeInvitation stops an unused invitation from being used on behalf of
+// an admin
+func (s *InvitationService) RevokeInvitation(actor models.AuditActor, id uuid.UUID) error {
+	invitation, err := s.invitationRepo.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvitationNotFound, err)
+	}
+
+	switch invitation.Status() {
+	case models.InvitationStatusUsed:
+		return ErrInvitationUsed
+	case models.InvitationStatusRevoked:
+		return ErrInvitationRevoked
+	}
+
+	audit := newInvitationAuditLogEntry(actor, models.AuditActionRevokeInvitation, invitation)
+	if err := s.invitationRepo.Revoke(id, audit); err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	return nil
+}
+
+// checkRegistration decides whether email may register with code under the
+// registration mode. It returns the invitation to redeem, or nil when
+// registration is open.
+func (s *InvitationService) checkRegistration(email, code string) (*models.Invitation, error) {
+	switch s.mode {
+	case RegistrationClosed:
+		return nil, ErrRegistrationClosed
+	case RegistrationInviteOnly:
+	default:
+		return nil, nil
+	}
+
+	code = normalizeInvitationCode(code)
+	if code == "" {
+		return nil, ErrInvitationRequired
+	}
+
+	invitation, err := s.invitationRepo.GetByCode(code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInvitation, err)
+	}
+
+	switch invitation.Status() {
+	case models.InvitationStatusUsed:
+		return nil, ErrInvitationUsed
+	case models.InvitationStatusRevoked:
+		return nil, ErrInvitationRevoked
+	case models.InvitationStatusExpired:
+		return nil, ErrInvitationExpired
+	}
+
+	if invitation.Email != "" && !strings.EqualFold(invitation.Email, strings.TrimSpace(email)) {
+		return nil, ErrInvitationEmailMismatch
+	}
+
+	return invitation, nil
+}
+
+// generateInvitationCode returns a random code such as "K7QM-2XPA"
+func generateInvitationCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(invitationCodeAlphabet)))
+	for i := 0; i < invitationCodeLength; i++ {
+		if i == invitationCodeLength/2 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random number: %w", err)
+		}
+		b.WriteByte(invitationCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// normalizeInvitationCode upper-cases a code as typed by a user and restores
+// the dash if it was left out
+func normalizeInvitationCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) == invitationCodeLength && !strings.Contains(code, "-") {
+		code = code[:invitationCodeLength/2] + "-" + code[invitationCodeLength/2:]
+	}
+	return code
+}
+
+// newInvitationAuditLogEntry builds the audit log entry for an admin action
+// on an invitation. Invitations are not users, so there is no target user.
+func newInvitationAuditLogEntry(actor models.AuditActor, action string, invitation *models.Invitation) *models.AuditLogEntry {
+	metadata := map[string]interface{}{"invitation_id": invitation.ID.String()}
+	if invitation.Email != "" {
+		metadata["email"] = invitation.Email
+	}
+
+	entry := newAuditLogEntry(actor, action, uuid.Nil, metadata)
+	entry.TargetUserID = nil
+	return entry
+}
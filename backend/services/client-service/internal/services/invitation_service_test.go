@@ -0,0 +1,49 @@
+package services
+
+import "testing"
+
+func TestParseRegistrationMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    RegistrationMode
+		wantErr bool
+	}{
+		{value: "", want: RegistrationOpen},
+		{value: "open", want: RegistrationOpen},
+		{value: " Invite_Only ", want: RegistrationInviteOnly},
+		{value: "closed", want: RegistrationClosed},
+		{value: "private", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRegistrationMode(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRegistrationMode(%q): expected error %v, got %v", tt.value, tt.wantErr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRegistrationMode(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeInvitationCode(t *testing.T) {
+	for input, want := range map[string]string{
+		"K7QM-2XPA":   "K7QM-2XPA",
+		" k7qm-2xpa ": "K7QM-2XPA",
+		"k7qm2xpa":    "K7QM-2XPA",
+		"":            "",
+	} {
+		if got := normalizeInvitationCode(input); got != want {
+			t.Errorf("normalizeInvitationCode(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	code, err := generateInvitationCode()
+	if err != nil {
+		t.Fatalf("generateInvitationCode returned error: %v", err)
+	}
+	if normalizeInvitationCode(code) != code {
+		t.Errorf("Expected generated code %q to already be normalized", code)
+	}
+}
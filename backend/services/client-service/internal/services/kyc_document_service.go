@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/blobstore"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+// MaxKYCDocumentsPerSubmission bounds how many documents may be uploaded
+// for one submission, such as the front and back of an ID card
+const MaxKYCDocumentsPerSubmission = 5
+
+// kycDocumentPurgeBatchSize bounds how many documents each purge pass
+// deletes
+const kycDocumentPurgeBatchSize = 100
+
+// kycDocumentTypes are the content types documents may have, detected from
+// their contents rather than trusted from the upload
+var kycDocumentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"application/pdf": true,
+}
+
+// KYCDocumentService keeps the scans backing KYC submissions. Users upload
+// them while their submission is pending, and reviewers download them.
+// Files are held encrypted in a blob store and deleted for good once their
+// submission has been decided for the retention period.
+type KYCDocumentService struct {
+	documentRepo repository.KYCDocumentRepository
+	kycRepo      repository.KYCRepository
+	auditLogRepo repository.AuditLogRepository
+	store        blobstore.Store
+	retention    time.Duration
+	now          func() time.Time
+}
+
+// NewKYCDocumentService creates a new KYC document service. Documents are
+// deleted retention after their submission is approved or rejected.
+func NewKYCDocumentService(documentRepo repository.KYCDocumentRepository, kycRepo repository.KYCRepository, auditLogRepo repository.AuditLogRepository, store blobstore.Store, retention time.Duration) *KYCDocumentService {
+	return &KYCDocumentService{
+		documentRepo: documentRepo,
+		kycRepo:      kycRepo,
+		auditLogRepo: auditLogRepo,
+		store:        store,
+		retention:    retention,
+		now:          time.Now,
+	}
+}
+
+// Upload stores a document against the user's pending submission. Only
+// JPEG, PNG and PDF files are accepted, judged by their contents.
+func (s *KYCDocumentService) Upload(ctx context.Context, userID uuid.UUID, fileName string, data []byte) (*models.KYCDocument, error) {
+	if len(data) == 0 {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "file", Rule: "required", Message: "must not be empty"}}}
+	}
+	contentType := http.DetectContentType(data)
+	if !kycDocumentTypes[contentType] {
+		return nil, ErrKYCDocumentType
+	}
+
+	submission, err := s.kycRepo.GetLatestByUserID(userID)
+	if err != nil || submission.Status != models.KYCStatusPending {
+		return nil, ErrKYCNotPending
+	}
+	existing, err := s.documentRepo.ListBySubmissionID(submission.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list KYC documents: %w", err)
+	}
+	if len(existing) >= MaxKYCDocumentsPerSubmission {
+		return nil, ErrKYCDocumentLimitReached
+	}
+
+	documentID := uuid.New()
+	document := &models.KYCDocument{
+		ID:           documentID,
+		SubmissionID: &submission.ID,
+		UserID:       userID,
+		FileName:     cleanFileName(fileName),
+		ContentType:  contentType,
+		SizeBytes:    int64(len(data)),
+		StorageKey:   userID.String() + "/" + documentID.String(),
+		UploadedAt:   s.now(),
+	}
+
+	if err := s.store.Put(ctx, document.StorageKey, data); err != nil {
+		return nil, fmt.Errorf("failed to store KYC document: %w", err)
+	}
+	if err := s.documentRepo.Create(document, MaxKYCDocumentsPerSubmission); err != nil {
+		// Do not leave an unrecorded file behind
+		if deleteErr := s.store.Delete(ctx, document.StorageKey); deleteErr != nil {
+			log.Printf("Failed to delete unrecorded KYC document %s: %v", document.ID, deleteErr)
+		}
+		return nil, fmt.Errorf("failed to record KYC document: %w", err)
+	}
+
+	return document, nil
+}
+
+// ListForUser returns the documents of the user's latest submission, for
+// reviewers. Users without a submission have none.
+func (s *KYCDocumentService) ListForUser(userID uuid.UUID) ([]models.KYCDocument, error) {
+	submission, err := s.kycRepo.GetLatestByUserID(userID)
+	if err != nil {
+		return []models.KYCDocument{}, nil
+	}
+
+	documents, err := s.documentRepo.ListBySubmissionID(submission.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list KYC documents: %w", err)
+	}
+	if documents == nil {
+		documents = []models.KYCDocument{}
+	}
+	return documents, nil
+}
+
+// Download returns a document and its contents to a reviewer. Reviewers
+// cannot open their own documents. Each download is recorded in the audit
+// log before the contents are read, so none goes unrecorded.
+func (s *KYCDocumentService) Download(ctx context.Context, actor models.AuditActor, documentID uuid.UUID) (*models.KYCDocument, []byte, error) {
+	document, err := s.documentRepo.GetByID(documentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrKYCDocumentNotFound, err)
+	}
+	if document.UserID == actor.AdminID {
+		return nil, nil, ErrCannotReviewOwnKYC
+	}
+
+	audit := newAuditLogEntry(actor, models.AuditActionViewKYCDocument, document.UserID, map[string]interface{}{
+		"document_id":   document.ID,
+		"submission_id": document.SubmissionID,
+		"file_name":     document.FileName,
+	})
+	if err := s.auditLogRepo.Create(audit); err != nil {
+		return nil, nil, fmt.Errorf("failed to record KYC document download: %w", err)
+	}
+
+	data, err := s.store.Get(ctx, document.StorageKey)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return nil, nil, fmt.Errorf("%w: file is missing from the document store", ErrKYCDocumentNotFound)
+		}
+		return nil, nil, fmt.Errorf("failed to read KYC document: %w", err)
+	}
+
+	return document, data, nil
+}
+
+// PurgeExpired permanently deletes the documents of submissions decided
+// more than the retention period ago, and those left behind by purged
+// users, returning how many were deleted. Each file is deleted before its
+// record, so a failure leaves the record to be retried on the next pass.
+func (s *KYCDocumentService) PurgeExpired(ctx context.Context) (int, error) {
+	deleted := 0
+	for {
+		documents, err := s.documentRepo.ListExpired(s.now().Add(-s.retention), kycDocumentPurgeBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to list expired KYC documents: %w", err)
+		}
+
+		for _, document := range documents {
+			if err := s.store.Delete(ctx, document.StorageKey); err != nil {
+				return deleted, fmt.Errorf("failed to delete KYC document %s: %w", document.ID, err)
+			}
+			if err := s.documentRepo.Delete(document.ID); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+
+		if len(documents) < kycDocumentPurgeBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// cleanFileName keeps the base name of an uploaded file, without control
+// characters or quotes that could break a Content-Disposition header, and
+// at most 255 bytes long
+func cleanFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		name = "document"
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/blobstore"
+	"microbank/client-service/internal/models"
+)
+
+var testPDF = []byte("%PDF-1.7\nscan of a passport")
+
+func newTestKYCDocumentService(t *testing.T) (*KYCDocumentService, *KYCService, *models.User, *blobstore.LocalStore, *fakeAuditLogRepo) {
+	t.Helper()
+	user := newTestUser(t, "password123")
+	userRepo := newFakeUserRepo(user)
+	kycRepo := newFakeKYCRepo(userRepo)
+	auditLogRepo := &fakeAuditLogRepo{}
+
+	backing, err := blobstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create blob store: %v", err)
+	}
+	store, err := blobstore.NewEncryptedStore(backing, bytes.Repeat([]byte{7}, blobstore.KeySize))
+	if err != nil {
+		t.Fatalf("Failed to create encrypted store: %v", err)
+	}
+
+	documentRepo := &fakeKYCDocumentRepo{kyc: kycRepo}
+	return NewKYCDocumentService(documentRepo, kycRepo, auditLogRepo, store, 30*24*time.Hour), NewKYCService(kycRepo, userRepo), user, backing, auditLogRepo
+}
+
+func TestKYCDocumentService_UploadAndDownload(t *testing.T) {
+	ctx := context.Background()
+	svc, kycService, user, backing, auditLogRepo := newTestKYCDocumentService(t)
+	reviewer := models.AuditActor{AdminID: uuid.New(), RequestID: "req-1"}
+
+	// Documents back a pending submission
+	if _, err := svc.Upload(ctx, user.ID, "passport.pdf", testPDF); !errors.Is(err, ErrKYCNotPending) {
+		t.Errorf("Expected ErrKYCNotPending before submitting, got %v", err)
+	}
+	request := models.KYCSubmissionRequest{DocumentType: models.KYCDocumentPassport, DocumentNumber: "A1234567", Country: "ZA"}
+	if _, err := kycService.Submit(user.ID, request); err != nil {
+		t.Fatalf("Submit returned error: %v", err)
+	}
+
+	// The type is judged by the contents, not the name
+	if _, err := svc.Upload(ctx, user.ID, "passport.pdf", []byte("#!/bin/sh\nrm -rf /")); !errors.Is(err, ErrKYCDocumentType) {
+		t.Errorf("Expected ErrKYCDocumentType, got %v", err)
+	}
+
+	document, err := svc.Upload(ctx, user.ID, `C:\scans\"passport".pdf`, testPDF)
+	if err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+	if document.ContentType != "application/pdf" || document.FileName != "passport.pdf" || document.SizeBytes != int64(len(testPDF)) {
+		t.Errorf("Unexpected document: %+v", document)
+	}
+	sealed, err := backing.Get(ctx, document.StorageKey)
+	if err != nil || bytes.Contains(sealed, []byte("passport")) {
+		t.Errorf("Expected the document to be stored encrypted, got %q, %v", sealed, err)
+	}
+
+	listed, err := svc.ListForUser(user.ID)
+	if err != nil || len(listed) != 1 || listed[0].ID != document.ID {
+		t.Errorf("Expected the uploaded document to be listed, got %+v, %v", listed, err)
+	}
+
+	// Reviewers cannot open their own documents
+	if _, _, err := svc.Download(ctx, models.AuditActor{AdminID: user.ID}, document.ID); !errors.Is(err, ErrCannotReviewOwnKYC) {
+		t.Errorf("Expected ErrCannotReviewOwnKYC, got %v", err)
+	}
+	if len(auditLogRepo.entries) != 0 {
+		t.Errorf("Expected a refused download not to be audited, got %+v", auditLogRepo.entries)
+	}
+
+	downloaded, data, err := svc.Download(ctx, reviewer, document.ID)
+	if err != nil {
+		t.Fatalf("Download returned error: %v", err)
+	}
+	if downloaded.ID != document.ID || !bytes.Equal(data, testPDF) {
+		t.Errorf("Expected the uploaded document, got %+v %q", downloaded, data)
+	}
+	if len(auditLogRepo.entries) != 1 {
+		t.Fatalf("Expected the download to be audited, got %+v", auditLogRepo.entries)
+	}
+	entry := auditLogRepo.entries[0]
+	if entry.Action != models.AuditActionViewKYCDocument || entry.AdminID != reviewer.AdminID || *entry.TargetUserID != user.ID || entry.Metadata["document_id"] != document.ID {
+		t.Errorf("Unexpected audit entry: %+v", entry)
+	}
+
+	if _, _, err := svc.Download(ctx, reviewer, uuid.New()); !errors.Is(err, ErrKYCDocumentNotFound) {
+		t.Errorf("Expected ErrKYCDocumentNotFound, got %v", err)
+	}
+}
+
+func TestKYCDocumentService_UploadLimit(t *testing.T) {
+	ctx := context.Background()
+	svc, kycService, user, _, _ := newTestKYCDocumentService(t)
+	request := models.KYCSubmissionRequest{DocumentType: models.KYCDocumentNationalID, DocumentNumber: "8001015009087", Country: "ZA"}
+	if _, err := kycService.Submit(user.ID, request); err != nil {
+		t.Fatalf("Submit returned error: %v", err)
+	}
+
+	png := []byte("\x89PNG\r\n\x1a\nimage data")
+	for i := 0; i < MaxKYCDocumentsPerSubmission; i++ {
+		if _, err := svc.Upload(ctx, user.ID, "side.png", png); err != nil {
+			t.Fatalf("Upload %d returned error: %v", i+1, err)
+		}
+	}
+	if _, err := svc.Upload(ctx, user.ID, "side.png", png); !errors.Is(err, ErrKYCDocumentLimitReached) {
+		t.Errorf("Expected ErrKYCDocumentLimitReached, got %v", err)
+	}
+}
+
+func TestKYCDocumentService_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	svc, kycService, user, backing, _ := newTestKYCDocumentService(t)
+	reviewer := models.AuditActor{AdminID: uuid.New(), RequestID: "req-1"}
+
+	request := models.KYCSubmissionRequest{DocumentType: models.KYCDocumentPassport, DocumentNumber: "A1234567", Country: "ZA"}
+	if _, err := kycService.Submit(user.ID, request); err != nil {
+		t.Fatalf("Submit returned error: %v", err)
+	}
+	document, err := svc.Upload(ctx, user.ID, "passport.pdf", testPDF)
+	if err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+
+	// Pending submissions keep their documents
+	if deleted, err := svc.PurgeExpired(ctx); err != nil || deleted != 0 {
+		t.Errorf("Expected nothing to be purged while pending, got %d, %v", deleted, err)
+	}
+
+	if _, err := kycService.Approve(reviewer, user.ID, ""); err != nil {
+		t.Fatalf("Approve returned error: %v", err)
+	}
+	if deleted, err := svc.PurgeExpired(ctx); err != nil || deleted != 0 {
+		t.Errorf("Expected nothing to be purged within the retention period, got %d, %v", deleted, err)
+	}
+
+	svc.now = func() time.Time { return time.Now().Add(31 * 24 * time.Hour) }
+	deleted, err := svc.PurgeExpired(ctx)
+	if err != nil || deleted != 1 {
+		t.Fatalf("Expected the document to be purged, got %d, %v", deleted, err)
+	}
+	if _, err := backing.Get(ctx, document.StorageKey); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Errorf("Expected the file to be deleted, got %v", err)
+	}
+	if documents, _ := svc.ListForUser(user.ID); len(documents) != 0 {
+		t.Errorf("Expected the document record to be deleted, got %+v", documents)
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+// KYC submission listing page sizes
+const (
+	DefaultKYCSubmissionPageSize = 50
+	MaxKYCSubmissionPageSize     = 200
+)
+
+// KYCService runs identity verification (KYC): users submit their identity
+// document details, and staff approve or reject them. A rejected user may
+// submit again. Every decision is recorded in the admin audit log.
+type KYCService struct {
+	kycRepo  repository.KYCRepository
+	userRepo repository.UserRepository
+	now      func() time.Time
+}
+
+// NewKYCService creates a new KYC service
+func NewKYCService(kycRepo repository.KYCRepository, userRepo repository.UserRepository) *KYCService {
+	return &KYCService{
+		kycRepo:  kycRepo,
+		userRepo: userRepo,
+		now:      time.Now,
+	}
+}
+
+// GetStatus returns the user's KYC status and their latest submission, if
+// they have made one
+func (s *KYCService) GetStatus(userID uuid.UUID) (*models.KYCStatusResponse, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	if user.KYCStatus == "" || user.KYCStatus == models.KYCStatusNone {
+		return &models.KYCStatusResponse{Status: models.KYCStatusNone}, nil
+	}
+
+	submission, err := s.kycRepo.GetLatestByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get KYC submission: %w", err)
+	}
+	return &models.KYCStatusResponse{Status: user.KYCStatus, Submission: submission}, nil
+}
+
+// Submit records the user's identity document details for review. Users
+// whose details are awaiting review or already verified cannot submit.
+func (s *KYCService) Submit(userID uuid.UUID, request models.KYCSubmissionRequest) (*models.KYCSubmission, error) {
+	documentNumber := strings.TrimSpace(request.DocumentNumber)
+	country := strings.ToUpper(strings.TrimSpace(request.Country))
+
+	var fields []FieldError
+	if documentNumber == "" {
+		fields = append(fields, FieldError{Field: "document_number", Rule: "required", Message: "is required"})
+	}
+	if !isCountryCode(country) {
+		fields = append(fields, FieldError{Field: "country", Rule: "iso3166", Message: "must be an ISO 3166-1 alpha-2 country code, e.g. ZA"})
+	}
+	if len(fields) > 0 {
+		return nil, &ValidationError{Fields: fields}
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+	switch user.KYCStatus {
+	case models.KYCStatusPending:
+		return nil, ErrKYCPending
+	case models.KYCStatusVerified:
+		return nil, ErrKYCAlreadyVerified
+	}
+
+	submission := &models.KYCSubmission{
+		ID:             uuid.New(),
+		UserID:         userID,
+		DocumentType:   request.DocumentType,
+		DocumentNumber: documentNumber,
+		Country:        country,
+		SubmittedAt:    s.now(),
+	}
+	if err := s.kycRepo.Submit(submission); err != nil {
+		return nil, fmt.Errorf("failed to submit KYC details: %w", err)
+	}
+
+	return submission, nil
+}
+
+// Approve verifies the user's pending KYC details on behalf of a reviewer
+func (s *KYCService) Approve(actor models.AuditActor, userID uuid.UUID, notes string) (*models.KYCSubmission, error) {
+	return s.review(actor, userID, models.KYCStatusVerified, notes)
+}
+
+// Reject turns down the user's pending KYC details on behalf of a
+// reviewer, who must say why in notes. The user may then submit again.
+func (s *KYCService) Reject(actor models.AuditActor, userID uuid.UUID, notes string) (*models.KYCSubmission, error) {
+	return s.review(actor, userID, models.KYCStatusRejected, notes)
+}
+
+// review records a reviewer's decision on the user's pending submission
+func (s *KYCService) review(actor models.AuditActor, userID uuid.UUID, status, notes string) (*models.KYCSubmission, error) {
+	notes = strings.TrimSpace(notes)
+	if status == models.KYCStatusRejected && notes == "" {
+		return nil, ErrKYCNotesRequired
+	}
+	if actor.AdminID == userID {
+		return nil, ErrCannotReviewOwnKYC
+	}
+
+	if _, err := s.userRepo.GetUserByID(userID); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+	submission, err := s.kycRepo.GetLatestByUserID(userID)
+	if err != nil || submission.Status != models.KYCStatusPending {
+		return nil, ErrKYCNotPending
+	}
+
+	reviewedAt := s.now()
+	submission.Status = status
+	submission.ReviewerID = &actor.AdminID
+	submission.ReviewNotes = notes
+	submission.ReviewedAt = &reviewedAt
+
+	action := models.AuditActionApproveKYC
+	if status == models.KYCStatusRejected {
+		action = models.AuditActionRejectKYC
+	}
+	audit := newAuditLogEntry(actor, action, userID, map[string]interface{}{
+		"submission_id": submission.ID,
+		"notes":         notes,
+	})
+
+	if err := s.kycRepo.Review(submission, audit); err != nil {
+		return nil, fmt.Errorf("failed to review KYC details: %w", err)
+	}
+
+	return submission, nil
+}
+
+// ListSubmissions retrieves a page of KYC submissions, oldest first,
+// optionally filtered by status. The page size is clamped to
+// MaxKYCSubmissionPageSize.
+func (s *KYCService) ListSubmissions(opts models.ListKYCSubmissionsOptions) (*models.KYCSubmissionPage, error) {
+	// Set default values if not provided
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultKYCSubmissionPageSize
+	}
+	if opts.Limit > MaxKYCSubmissionPageSize {
+		opts.Limit = MaxKYCSubmissionPageSize
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+
+	submissions, total, err := s.kycRepo.List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list KYC submissions: %w", err)
+	}
+
+	return &models.KYCSubmissionPage{
+		Submissions: submissions,
+		Total:       total,
+		Limit:       opts.Limit,
+		Offset:      opts.Offset,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func TestKYCService_SubmitAndReview(t *testing.T) {
+	user := newTestUser(t, "password123")
+	userRepo := newFakeUserRepo(user)
+	kycRepo := newFakeKYCRepo(userRepo)
+	svc := NewKYCService(kycRepo, userRepo)
+	reviewer := models.AuditActor{AdminID: uuid.New(), RequestID: "req-1"}
+
+	request := models.KYCSubmissionRequest{DocumentType: models.KYCDocumentPassport, DocumentNumber: " A1234567 ", Country: "za"}
+	submission, err := svc.Submit(user.ID, request)
+	if err != nil {
+		t.Fatalf("Submit returned error: %v", err)
+	}
+	if submission.DocumentNumber != "A1234567" || submission.Country != "ZA" || submission.Status != models.KYCStatusPending {
+		t.Errorf("Unexpected submission: %+v", submission)
+	}
+
+	// A second submission waits for the first to be reviewed
+	if _, err := svc.Submit(user.ID, request); !errors.Is(err, ErrKYCPending) {
+		t.Errorf("Expected ErrKYCPending, got %v", err)
+	}
+
+	// Rejected details may be submitted again
+	if _, err := svc.Reject(reviewer, user.ID, ""); !errors.Is(err, ErrKYCNotesRequired) {
+		t.Errorf("Expected ErrKYCNotesRequired, got %v", err)
+	}
+	if _, err := svc.Reject(reviewer, user.ID, "Document is illegible"); err != nil {
+		t.Fatalf("Reject returned error: %v", err)
+	}
+	status, err := svc.GetStatus(user.ID)
+	if err != nil {
+		t.Fatalf("GetStatus returned error: %v", err)
+	}
+	if status.Status != models.KYCStatusRejected || status.Submission == nil || status.Submission.ReviewNotes != "Document is illegible" {
+		t.Errorf("Expected the rejected submission with its notes, got %+v", status)
+	}
+	if _, err := svc.Submit(user.ID, request); err != nil {
+		t.Fatalf("Expected resubmission after rejection to succeed, got %v", err)
+	}
+
+	reviewed, err := svc.Approve(reviewer, user.ID, "")
+	if err != nil {
+		t.Fatalf("Approve returned error: %v", err)
+	}
+	if reviewed.Status != models.KYCStatusVerified || reviewed.ReviewerID == nil || *reviewed.ReviewerID != reviewer.AdminID || reviewed.ReviewedAt == nil {
+		t.Errorf("Unexpected reviewed submission: %+v", reviewed)
+	}
+	if _, err := svc.Submit(user.ID, request); !errors.Is(err, ErrKYCAlreadyVerified) {
+		t.Errorf("Expected ErrKYCAlreadyVerified, got %v", err)
+	}
+	if _, err := svc.Approve(reviewer, user.ID, ""); !errors.Is(err, ErrKYCNotPending) {
+		t.Errorf("Expected ErrKYCNotPending, got %v", err)
+	}
+
+	if len(kycRepo.audits) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %d", len(kycRepo.audits))
+	}
+	for i, want := range []string{models.AuditActionRejectKYC, models.AuditActionApproveKYC} {
+		entry := kycRepo.audits[i]
+		if entry.Action != want || entry.AdminID != reviewer.AdminID || *entry.TargetUserID != user.ID {
+			t.Errorf("Expected audit entry %d to be %s by the reviewer, got %+v", i, want, entry)
+		}
+	}
+}
+
+func TestKYCService_Submit_Invalid(t *testing.T) {
+	user := newTestUser(t, "password123")
+	userRepo := newFakeUserRepo(user)
+	svc := NewKYCService(newFakeKYCRepo(userRepo), userRepo)
+
+	_, err := svc.Submit(user.ID, models.KYCSubmissionRequest{DocumentType: models.KYCDocumentNationalID, DocumentNumber: "  ", Country: "XX"})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a *ValidationError, got %v", err)
+	}
+	if len(validationErr.Fields) != 2 || validationErr.Fields[0].Field != "document_number" || validationErr.Fields[1].Field != "country" {
+		t.Errorf("Expected document_number and country to be invalid, got %+v", validationErr.Fields)
+	}
+}
+
+func TestKYCService_Review_Refused(t *testing.T) {
+	user := newTestUser(t, "password123")
+	userRepo := newFakeUserRepo(user)
+	svc := NewKYCService(newFakeKYCRepo(userRepo), userRepo)
+
+	tests := []struct {
+		name    string
+		actor   models.AuditActor
+		userID  uuid.UUID
+		wantErr error
+	}{
+		{name: "nothing submitted", actor: models.AuditActor{AdminID: uuid.New()}, userID: user.ID, wantErr: ErrKYCNotPending},
+		{name: "own details", actor: models.AuditActor{AdminID: user.ID}, userID: user.ID, wantErr: ErrCannotReviewOwnKYC},
+		{name: "unknown user", actor: models.AuditActor{AdminID: uuid.New()}, userID: uuid.New(), wantErr: ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.Approve(tt.actor, tt.userID, ""); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
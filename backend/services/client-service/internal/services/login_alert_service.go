@@ -0,0 +1,173 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/mailer"
+)
+
+// loginReportWindow is how long the "this wasn't me" link in a new-device
+// alert stays valid
+const loginReportWindow = 7 * 24 * time.Hour
+
+// LoginAlertService tells users when their account is accessed from a device
+// they have not used before
+type LoginAlertService struct {
+	deviceRepo       repository.KnownDeviceRepository
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	emailSender      mailer.EmailSender
+	geoIP            GeoIPResolver
+	preferences      *NotificationPreferenceService
+}
+
+// NewLoginAlertService creates a new login alert service
+func NewLoginAlertService(deviceRepo repository.KnownDeviceRepository, userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, emailSender mailer.EmailSender, geoIP GeoIPResolver, preferences *NotificationPreferenceService) *LoginAlertService {
+	return &LoginAlertService{
+		deviceRepo:       deviceRepo,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		emailSender:      emailSender,
+		geoIP:            geoIP,
+		preferences:      preferences,
+	}
+}
+
+// CheckLogin records the device behind a successful login and emails the
+// user when it is new, unless they have turned login alerts off. The first
+// device a user logs in from is recorded without an alert. Errors are logged
+// rather than returned so alerts can never block a login.
+func (s *LoginAlertService) CheckLogin(user *models.User, meta models.LoginMetadata, at time.Time) {
+	known, err := s.deviceRepo.CountByUserID(user.ID)
+	if err != nil {
+		log.Printf("Failed to count known devices for user %s: %v", user.ID, err)
+		return
+	}
+
+	reportToken, err := generateSecureToken()
+	if err != nil {
+		log.Printf("Failed to generate login report token for user %s: %v", user.ID, err)
+		return
+	}
+
+	device := &models.KnownDevice{
+		ID:                uuid.New(),
+		UserID:            user.ID,
+		Fingerprint:       deviceFingerprint(meta),
+		UserAgent:         meta.UserAgent,
+		IPAddress:         meta.IPAddress,
+		ReportTokenHash:   hashToken(reportToken),
+		ReportTokenExpiry: at.Add(loginReportWindow),
+		LastSeenAt:        at,
+	}
+
+	isNew, err := s.deviceRepo.RecordLogin(device)
+	if err != nil {
+		log.Printf("Failed to record device for user %s: %v", user.ID, err)
+		return
+	}
+	if !isNew || known == 0 {
+		return
+	}
+
+	channels, err := s.preferences.ChannelsFor(user.ID, models.NotificationEventLoginAlert)
+	if err != nil {
+		log.Printf("Failed to load notification preferences for user %s, using defaults: %v", user.ID, err)
+		channels = models.DefaultNotificationChannels(models.NotificationEventLoginAlert)
+	}
+	if !channels.Email {
+		return
+	}
+
+	location, err := s.geoIP.Locate(meta.IPAddress)
+	if err != nil {
+		log.Printf("Failed to locate IP address %s: %v", meta.IPAddress, err)
+	}
+	if location == "" {
+		location = "Unknown"
+	}
+
+	data := map[string]string{
+		"Name":      user.Name,
+		"Time":      at.UTC().Format("2 January 2006 15:04 MST"),
+		"Location":  location,
+		"IPAddress": orUnknown(meta.IPAddress),
+		"Device":    orUnknown(meta.UserAgent),
+		"Link":      fmt.Sprintf("%s?token=%s", loginReportURL(), reportToken),
+	}
+	if err := sendEmail(s.emailSender, user.Email, user.ID, "login_alert", data); err != nil {
+		log.Printf("Failed to send new device alert to user %s: %v", user.ID, err)
+	}
+}
+
+// ReportLogin handles the "this wasn't me" link from a new-device alert. It
+// signs the user out everywhere and forgets the device, so another login
+// from it raises a new alert.
+func (s *LoginAlertService) ReportLogin(token string) error {
+	device, err := s.deviceRepo.GetByReportTokenHash(hashToken(token))
+	if err != nil {
+		return ErrInvalidLoginReportToken
+	}
+
+	if time.Now().After(device.ReportTokenExpiry) {
+		return ErrLoginReportTokenExpired
+	}
+
+	if err := s.refreshTokenRepo.DeleteByUserID(device.UserID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if err := s.userRepo.IncrementTokenVersion(device.UserID); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	if err := s.deviceRepo.Delete(device.ID); err != nil {
+		return fmt.Errorf("failed to forget reported device: %w", err)
+	}
+
+	return nil
+}
+
+// deviceFingerprint identifies the device behind a login by its user agent
+// and network. Only the network prefix of the IP address is used (/24 for
+// IPv4, /64 for IPv6) so a changing address from the same provider does not
+// look like a new device.
+func deviceFingerprint(meta models.LoginMetadata) string {
+	network := meta.IPAddress
+	if ip := net.ParseIP(meta.IPAddress); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			network = ip4.Mask(net.CIDRMask(24, 32)).String()
+		} else {
+			network = ip.Mask(net.CIDRMask(64, 128)).String()
+		}
+	}
+
+	sum := sha256.Sum256([]byte(strings.TrimSpace(meta.UserAgent) + "|" + network))
+	return hex.EncodeToString(sum[:])
+}
+
+// orUnknown returns s, or "Unknown" when it is empty
+func orUnknown(s string) string {
+	if s == "" {
+		return "Unknown"
+	}
+	return s
+}
+
+// loginReportURL returns the frontend page that reports an unrecognised login
+func loginReportURL() string {
+	if url := os.Getenv("LOGIN_REPORT_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000/report-login"
+}
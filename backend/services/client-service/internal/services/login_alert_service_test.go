@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+)
+
+func newTestLoginAlertService(user *models.User) (*LoginAlertService, *fakeUserRepo, *fakeRefreshTokenRepo, *fakeKnownDeviceRepo, *fakeEmailSender, *NotificationPreferenceService) {
+	userRepo := newFakeUserRepo(user)
+	refreshRepo := newFakeRefreshTokenRepo()
+	deviceRepo := &fakeKnownDeviceRepo{}
+	sender := &fakeEmailSender{}
+	preferences := NewNotificationPreferenceService(&fakeNotificationPreferenceRepo{})
+	svc := NewLoginAlertService(deviceRepo, userRepo, refreshRepo, sender, fakeGeoIPResolver{location: "Cape Town, ZA"}, preferences)
+	return svc, userRepo, refreshRepo, deviceRepo, sender, preferences
+}
+
+func TestLoginAlertService_CheckLogin(t *testing.T) {
+	user := newTestUser(t, "password123")
+	svc, _, _, deviceRepo, sender, _ := newTestLoginAlertService(user)
+	laptop := models.LoginMetadata{IPAddress: "203.0.113.7", UserAgent: "Firefox/128.0"}
+	at := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+
+	svc.CheckLogin(user, laptop, at)
+	if len(sender.sent) != 0 {
+		t.Fatalf("Expected the first device to be recorded silently, got %d emails", len(sender.sent))
+	}
+
+	// Same browser on another address in the same network
+	svc.CheckLogin(user, models.LoginMetadata{IPAddress: "203.0.113.99", UserAgent: "Firefox/128.0"}, at)
+	if len(sender.sent) != 0 {
+		t.Fatalf("Expected a known device not to raise an alert, got %d emails", len(sender.sent))
+	}
+
+	svc.CheckLogin(user, models.LoginMetadata{IPAddress: "198.51.100.20", UserAgent: "Safari/17.4"}, at)
+	email, ok := sender.last()
+	if !ok {
+		t.Fatal("Expected a new device alert")
+	}
+	if email.to != user.Email {
+		t.Errorf("Expected the alert to go to %s, got %s", user.Email, email.to)
+	}
+	for _, want := range []string{"14 March 2026 09:30 UTC", "Cape Town, ZA", "198.51.100.20", "Safari/17.4", "?token="} {
+		if !strings.Contains(email.body, want) {
+			t.Errorf("Expected the alert to mention %q:\n%s", want, email.body)
+		}
+	}
+	if len(deviceRepo.devices) != 2 {
+		t.Errorf("Expected 2 known devices, got %d", len(deviceRepo.devices))
+	}
+}
+
+func TestLoginAlertService_RespectsPreferences(t *testing.T) {
+	user := newTestUser(t, "password123")
+	svc, _, _, deviceRepo, sender, preferences := newTestLoginAlertService(user)
+
+	off := false
+	if _, err := preferences.UpdatePreferences(user.ID, models.NotificationPreferencesUpdate{
+		Preferences: map[string]models.NotificationChannelsUpdate{models.NotificationEventLoginAlert: {Email: &off}},
+	}); err != nil {
+		t.Fatalf("UpdatePreferences returned error: %v", err)
+	}
+
+	svc.CheckLogin(user, models.LoginMetadata{IPAddress: "203.0.113.7", UserAgent: "Firefox/128.0"}, time.Now())
+	svc.CheckLogin(user, models.LoginMetadata{IPAddress: "198.51.100.20", UserAgent: "Safari/17.4"}, time.Now())
+
+	if len(sender.sent) != 0 {
+		t.Errorf("Expected no alert with login alerts turned off, got %d emails", len(sender.sent))
+	}
+	if len(deviceRepo.devices) != 2 {
+		t.Errorf("Expected devices to be recorded anyway, got %d", len(deviceRepo.devices))
+	}
+}
+
+func TestLoginAlertService_SendFailureDoesNotBlockLogin(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	user := newTestUser(t, "password123")
+	alerts, userRepo, refreshRepo, _, sender, _ := newTestLoginAlertService(user)
+	sender.err = errors.New("smtp timeout")
+	svc := NewAuthService(userRepo, refreshRepo, &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, alerts, nil, testTokens, revocation.NewMemoryStore(), 0, nil)
+
+	for _, meta := range []models.LoginMetadata{
+		{IPAddress: "203.0.113.7", UserAgent: "Firefox/128.0"},
+		{IPAddress: "198.51.100.20", UserAgent: "Safari/17.4"},
+	} {
+		if _, _, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: "password123"}, meta); err != nil {
+			t.Fatalf("LoginUser returned error: %v", err)
+		}
+	}
+}
+
+func TestLoginAlertService_ReportLogin(t *testing.T) {
+	user := newTestUser(t, "password123")
+	svc, userRepo, refreshRepo, deviceRepo, sender, _ := newTestLoginAlertService(user)
+	refreshRepo.Create(&models.RefreshToken{UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+
+	svc.CheckLogin(user, models.LoginMetadata{IPAddress: "203.0.113.7", UserAgent: "Firefox/128.0"}, time.Now())
+	svc.CheckLogin(user, models.LoginMetadata{IPAddress: "198.51.100.20", UserAgent: "Safari/17.4"}, time.Now())
+	email, ok := sender.last()
+	if !ok {
+		t.Fatal("Expected a new device alert")
+	}
+	token := extractToken(t, email.body)
+
+	if err := svc.ReportLogin(token); err != nil {
+		t.Fatalf("ReportLogin returned error: %v", err)
+	}
+	if n := refreshRepo.countForUser(user.ID); n != 0 {
+		t.Errorf("Expected all refresh tokens to be revoked, %d remain", n)
+	}
+	if reported, _ := userRepo.GetUserByID(user.ID); reported.TokenVersion != 1 {
+		t.Errorf("Expected the token version to be bumped, got %d", reported.TokenVersion)
+	}
+	if len(deviceRepo.devices) != 1 {
+		t.Errorf("Expected the reported device to be forgotten, %d devices remain", len(deviceRepo.devices))
+	}
+
+	if err := svc.ReportLogin(token); !errors.Is(err, ErrInvalidLoginReportToken) {
+		t.Errorf("Expected a used link to be rejected, got %v", err)
+	}
+}
+
+func TestLoginAlertService_ReportLoginExpired(t *testing.T) {
+	user := newTestUser(t, "password123")
+	svc, _, _, _, sender, _ := newTestLoginAlertService(user)
+	at := time.Now().Add(-loginReportWindow - time.Minute)
+
+	svc.CheckLogin(user, models.LoginMetadata{IPAddress: "203.0.113.7", UserAgent: "Firefox/128.0"}, at)
+	svc.CheckLogin(user, models.LoginMetadata{IPAddress: "198.51.100.20", UserAgent: "Safari/17.4"}, at)
+	email, _ := sender.last()
+
+	if err := svc.ReportLogin(extractToken(t, email.body)); !errors.Is(err, ErrLoginReportTokenExpired) {
+		t.Errorf("Expected ErrLoginReportTokenExpired, got %v", err)
+	}
+}
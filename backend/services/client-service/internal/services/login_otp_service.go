@@ -0,0 +1,160 @@
+package services
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+const (
+	// loginOTPCodeTTL is how long an SMS login code stays valid
+	loginOTPCodeTTL = 5 * time.Minute
+	// maxLoginOTPsPerHour caps how many login codes a single phone number can be sent
+	maxLoginOTPsPerHour = 5
+	// maxLoginOTPAttempts caps how many guesses a single login code allows
+	maxLoginOTPAttempts = 5
+)
+
+// LoginOTPService signs users in with one-time codes sent by SMS to their
+// verified phone number
+type LoginOTPService struct {
+	userRepo    repository.UserRepository
+	codeRepo    repository.LoginOTPRepository
+	smsSender   SMSSender
+	authService *AuthService
+}
+
+// NewLoginOTPService creates a new SMS login service. Sign-ins are issued
+// sessions by authService.
+func NewLoginOTPService(userRepo repository.UserRepository, codeRepo repository.LoginOTPRepository, smsSender SMSSender, authService *AuthService) *LoginOTPService {
+	return &LoginOTPService{
+		userRepo:    userRepo,
+		codeRepo:    codeRepo,
+		smsSender:   smsSender,
+		authService: authService,
+	}
+}
+
+// RequestLoginOTP sends a 6-digit login code to a phone number. Numbers no
+// single user has verified and rate-limited numbers are silently ignored so
+// callers cannot tell whether an account exists. Each new code replaces the
+// previous one.
+func (s *LoginOTPService) RequestLoginOTP(request models.LoginOTPRequest) error {
+	phoneNumber := strings.TrimSpace(request.PhoneNumber)
+	if !e164Pattern.MatchString(phoneNumber) {
+		return &ValidationError{Fields: []FieldError{{
+			Field:   "phone_number",
+			Rule:    "e164",
+			Message: "must be in E.164 format, e.g. +27821234567",
+		}}}
+	}
+
+	// Get user by verified phone number
+	user, err := s.userRepo.GetUserByVerifiedPhone(phoneNumber)
+	if err != nil {
+		return nil
+	}
+
+	// Rate-limit codes per phone number
+	count, err := s.codeRepo.CountRecentByPhoneNumber(phoneNumber, time.Now().Add(-time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to count recent login codes: %w", err)
+	}
+	if count >= maxLoginOTPsPerHour {
+		log.Printf("SMS login code rate limit reached for user %s", user.ID)
+		return nil
+	}
+
+	// Generate a random code; only its hash is stored
+	code, err := generateVerificationCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate login code: %w", err)
+	}
+
+	loginCode := &models.LoginOTPCode{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		PhoneNumber: phoneNumber,
+		ExpiresAt:   time.Now().Add(loginOTPCodeTTL),
+		CreatedAt:   time.Now(),
+	}
+	loginCode.CodeHash = hashVerificationCode(loginCode.ID, code)
+
+	if err := s.codeRepo.Create(loginCode); err != nil {
+		return fmt.Errorf("failed to save login code: %w", err)
+	}
+
+	// Send the code
+	message := fmt.Sprintf("Your Microbank login code is %s. It expires in 5 minutes. Never share it with anyone.", code)
+	if err := s.smsSender.Send(phoneNumber, message); err != nil {
+		return fmt.Errorf("%w: %w", ErrSMSDeliveryFailed, err)
+	}
+
+	return nil
+}
+
+// VerifyLoginOTP checks a code against the latest one sent to a phone number
+// and signs its user in when it matches. Every way a code can fail returns
+// ErrInvalidLoginCode so callers cannot tell whether an account exists; the
+// login audit trail records the actual reason. Every check counts against
+// the code's attempt limit.
+func (s *LoginOTPService) VerifyLoginOTP(verify models.LoginOTPVerify, meta models.LoginMetadata) (*models.User, *Session, error) {
+	meta.Method = models.LoginMethodSMSOTP
+	phoneNumber := strings.TrimSpace(verify.PhoneNumber)
+
+	// Get user by verified phone number
+	user, err := s.userRepo.GetUserByVerifiedPhone(phoneNumber)
+	if err != nil {
+		return nil, nil, ErrInvalidLoginCode
+	}
+
+	// Look up the latest code, which must be for this user
+	loginCode, err := s.codeRepo.GetLatestByPhoneNumber(phoneNumber)
+	if err != nil || loginCode.IsUsed() || loginCode.UserID != user.ID {
+		s.authService.recordLoginEvent(&user.ID, user.Email, meta, models.LoginFailureInvalidCode)
+		return nil, nil, ErrInvalidLoginCode
+	}
+
+	if loginCode.IsExpired() {
+		s.authService.recordLoginEvent(&user.ID, user.Email, meta, models.LoginFailureCodeExpired)
+		return nil, nil, ErrInvalidLoginCode
+	}
+
+	attempts, err := s.codeRepo.IncrementAttempts(loginCode.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to record login code attempt: %w", err)
+	}
+	if attempts > maxLoginOTPAttempts {
+		s.authService.recordLoginEvent(&user.ID, user.Email, meta, models.LoginFailureTooManyAttempts)
+		return nil, nil, ErrInvalidLoginCode
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashVerificationCode(loginCode.ID, verify.Code)), []byte(loginCode.CodeHash)) != 1 {
+		s.authService.recordLoginEvent(&user.ID, user.Email, meta, models.LoginFailureInvalidCode)
+		return nil, nil, ErrInvalidLoginCode
+	}
+
+	// Consume the code before signing in so it cannot be replayed
+	if err := s.codeRepo.MarkUsed(loginCode.ID); err != nil {
+		s.authService.recordLoginEvent(&user.ID, user.Email, meta, models.LoginFailureInvalidCode)
+		return nil, nil, ErrInvalidLoginCode
+	}
+
+	// Check if user is blacklisted
+	if user.IsBlacklisted {
+		s.authService.recordLoginEvent(&user.ID, user.Email, meta, models.LoginFailureAccountSuspended)
+		return nil, nil, suspensionError(user)
+	}
+
+	session, err := s.authService.startSession(user, user.Email, meta, verify.RememberMe)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, session, nil
+}
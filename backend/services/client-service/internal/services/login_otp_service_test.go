@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+)
+
+func newTestLoginOTPService(t *testing.T) (*LoginOTPService, *models.User, *fakeLoginOTPRepo, *fakeSMSSender, *fakeLoginEventRepo) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	user := newTestUser(t, "password123")
+	verifiedAt := time.Now()
+	user.PhoneNumber, user.PhoneVerifiedAt = "+27821234567", &verifiedAt
+	userRepo := newFakeUserRepo(user)
+	events := &fakeLoginEventRepo{}
+	authService := NewAuthService(userRepo, newFakeRefreshTokenRepo(), events, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil)
+	codeRepo := &fakeLoginOTPRepo{}
+	sender := &fakeSMSSender{}
+	return NewLoginOTPService(userRepo, codeRepo, sender, authService), user, codeRepo, sender, events
+}
+
+func TestLoginOTPService_RequestAndVerify(t *testing.T) {
+	svc, user, codeRepo, sender, events := newTestLoginOTPService(t)
+
+	if err := svc.RequestLoginOTP(models.LoginOTPRequest{PhoneNumber: user.PhoneNumber}); err != nil {
+		t.Fatalf("RequestLoginOTP returned error: %v", err)
+	}
+	code := sentCode(t, sender)
+	if stored := codeRepo.codes[0]; stored.CodeHash == code || stored.ExpiresAt.After(time.Now().Add(loginOTPCodeTTL)) {
+		t.Errorf("Expected a hashed code expiring within %s, got %+v", loginOTPCodeTTL, stored)
+	}
+
+	signedIn, session, err := svc.VerifyLoginOTP(models.LoginOTPVerify{PhoneNumber: user.PhoneNumber, Code: code}, models.LoginMetadata{})
+	if err != nil {
+		t.Fatalf("VerifyLoginOTP returned error: %v", err)
+	}
+	if signedIn.ID != user.ID || session.AccessToken == "" || session.RefreshToken == "" {
+		t.Errorf("Expected a session for %s, got user %s and %+v", user.ID, signedIn.ID, session)
+	}
+	if got := events.events[len(events.events)-1]; !got.Success || got.Method != models.LoginMethodSMSOTP {
+		t.Errorf("Expected a successful sms_otp login event, got %+v", got)
+	}
+
+	if _, _, err := svc.VerifyLoginOTP(models.LoginOTPVerify{PhoneNumber: user.PhoneNumber, Code: code}, models.LoginMetadata{}); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Errorf("Expected a used code to be rejected, got %v", err)
+	}
+}
+
+func TestLoginOTPService_UnknownNumbersLookTheSame(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		prepare func(user *models.User)
+	}{
+		{name: "no user has the number", prepare: func(user *models.User) { user.PhoneNumber = "+27829999999" }},
+		{name: "number not verified", prepare: func(user *models.User) { user.PhoneVerifiedAt = nil }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, user, _, sender, events := newTestLoginOTPService(t)
+			tt.prepare(user)
+
+			if err := svc.RequestLoginOTP(models.LoginOTPRequest{PhoneNumber: "+27821234567"}); err != nil {
+				t.Errorf("Expected unknown numbers to be accepted silently, got %v", err)
+			}
+			if len(sender.sent) != 0 {
+				t.Errorf("Expected no text message, got %d", len(sender.sent))
+			}
+
+			if _, _, err := svc.VerifyLoginOTP(models.LoginOTPVerify{PhoneNumber: "+27821234567", Code: "123456"}, models.LoginMetadata{}); !errors.Is(err, ErrInvalidLoginCode) {
+				t.Errorf("Expected ErrInvalidLoginCode, got %v", err)
+			}
+			if len(events.events) != 0 {
+				t.Errorf("Expected no login events for an unknown number, got %d", len(events.events))
+			}
+		})
+	}
+
+	svc, _, _, _, _ := newTestLoginOTPService(t)
+	var validationErr *ValidationError
+	if err := svc.RequestLoginOTP(models.LoginOTPRequest{PhoneNumber: "0821234567"}); !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error for a number not in E.164 format, got %v", err)
+	}
+}
+
+func TestLoginOTPService_VerifyErrors(t *testing.T) {
+	t.Run("wrong code", func(t *testing.T) {
+		svc, user, _, sender, events := newTestLoginOTPService(t)
+		svc.RequestLoginOTP(models.LoginOTPRequest{PhoneNumber: user.PhoneNumber})
+		wrong := "000000"
+		if sentCode(t, sender) == wrong {
+			wrong = "111111"
+		}
+		if _, _, err := svc.VerifyLoginOTP(models.LoginOTPVerify{PhoneNumber: user.PhoneNumber, Code: wrong}, models.LoginMetadata{}); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Errorf("Expected ErrInvalidLoginCode, got %v", err)
+		}
+		if got := events.events[0]; got.FailureReason != models.LoginFailureInvalidCode || got.Method != models.LoginMethodSMSOTP {
+			t.Errorf("Expected an invalid_code sms_otp event, got %+v", got)
+		}
+	})
+
+	t.Run("expired code", func(t *testing.T) {
+		svc, user, codeRepo, sender, events := newTestLoginOTPService(t)
+		svc.RequestLoginOTP(models.LoginOTPRequest{PhoneNumber: user.PhoneNumber})
+		codeRepo.codes[0].ExpiresAt = time.Now().Add(-time.Second)
+		if _, _, err := svc.VerifyLoginOTP(models.LoginOTPVerify{PhoneNumber: user.PhoneNumber, Code: sentCode(t, sender)}, models.LoginMetadata{}); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Errorf("Expected ErrInvalidLoginCode, got %v", err)
+		}
+		if got := events.events[0].FailureReason; got != models.LoginFailureCodeExpired {
+			t.Errorf("Expected the expiry to be recorded, got %q", got)
+		}
+	})
+
+	t.Run("attempts exceeded", func(t *testing.T) {
+		svc, user, codeRepo, sender, events := newTestLoginOTPService(t)
+		svc.RequestLoginOTP(models.LoginOTPRequest{PhoneNumber: user.PhoneNumber})
+		codeRepo.codes[0].Attempts = maxLoginOTPAttempts
+		if _, _, err := svc.VerifyLoginOTP(models.LoginOTPVerify{PhoneNumber: user.PhoneNumber, Code: sentCode(t, sender)}, models.LoginMetadata{}); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Errorf("Expected even the right code to be rejected, got %v", err)
+		}
+		if got := events.events[0].FailureReason; got != models.LoginFailureTooManyAttempts {
+			t.Errorf("Expected the exhausted attempts to be recorded, got %q", got)
+		}
+	})
+
+	t.Run("suspended user", func(t *testing.T) {
+		svc, user, _, sender, _ := newTestLoginOTPService(t)
+		user.IsBlacklisted = true
+		svc.RequestLoginOTP(models.LoginOTPRequest{PhoneNumber: user.PhoneNumber})
+		if _, _, err := svc.VerifyLoginOTP(models.LoginOTPVerify{PhoneNumber: user.PhoneNumber, Code: sentCode(t, sender)}, models.LoginMetadata{}); !errors.Is(err, ErrAccountSuspended) {
+			t.Errorf("Expected ErrAccountSuspended, got %v", err)
+		}
+	})
+}
+
+func TestLoginOTPService_RateLimitedPerNumber(t *testing.T) {
+	svc, user, _, sender, _ := newTestLoginOTPService(t)
+
+	for i := 0; i < maxLoginOTPsPerHour+1; i++ {
+		if err := svc.RequestLoginOTP(models.LoginOTPRequest{PhoneNumber: user.PhoneNumber}); err != nil {
+			t.Fatalf("RequestLoginOTP returned error: %v", err)
+		}
+	}
+	if len(sender.sent) != maxLoginOTPsPerHour {
+		t.Errorf("Expected %d text messages, got %d", maxLoginOTPsPerHour, len(sender.sent))
+	}
+}
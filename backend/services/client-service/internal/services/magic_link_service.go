@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/mailer"
+)
+
+const (
+	// magicLinkTTL is how long a sign-in link stays valid
+	magicLinkTTL = 15 * time.Minute
+	// maxMagicLinksPerHour caps how many sign-in links a single account can receive
+	maxMagicLinksPerHour = 3
+)
+
+// MagicLinkService signs users in with single-use links emailed to them
+type MagicLinkService struct {
+	userRepo    repository.UserRepository
+	tokenRepo   repository.MagicLinkTokenRepository
+	emailSender mailer.EmailSender
+	authService *AuthService
+}
+
+// NewMagicLinkService creates a new sign-in link service. Sign-ins are
+// issued sessions by authService.
+func NewMagicLinkService(userRepo repository.UserRepository, tokenRepo repository.MagicLinkTokenRepository, emailSender mailer.EmailSender, authService *AuthService) *MagicLinkService {
+	return &MagicLinkService{
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		emailSender: emailSender,
+		authService: authService,
+	}
+}
+
+// RequestMagicLink issues a sign-in token and emails a link with it to the
+// user. Unknown emails and rate-limited accounts are silently ignored so
+// callers cannot tell whether an account exists.
+func (s *MagicLinkService) RequestMagicLink(request models.MagicLinkRequest) error {
+	// Get user by email
+	user, err := s.userRepo.GetUserByEmail(request.Email)
+	if err != nil {
+		return nil
+	}
+
+	// Rate-limit links per account
+	count, err := s.tokenRepo.CountRecentByUserID(user.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to count recent sign-in links: %w", err)
+	}
+	if count >= maxMagicLinksPerHour {
+		log.Printf("Sign-in link rate limit reached for user %s", user.ID)
+		return nil
+	}
+
+	// Generate a random token; only its hash is stored
+	token, err := generateSecureToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate sign-in token: %w", err)
+	}
+
+	magicLink := &models.MagicLinkToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(magicLinkTTL),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.tokenRepo.Create(magicLink); err != nil {
+		return fmt.Errorf("failed to save sign-in token: %w", err)
+	}
+
+	// Send the sign-in link
+	link := fmt.Sprintf("%s?token=%s", magicLinkURL(), url.QueryEscape(token))
+	data := map[string]string{"Name": user.Name, "Link": link}
+	if err := sendEmail(s.emailSender, user.Email, user.ID, "magic_link", data); err != nil {
+		return fmt.Errorf("failed to send sign-in email: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeMagicLink exchanges a sign-in token for a session. The token is
+// used up even when the sign-in is then refused, for example because the
+// user has been suspended since the link was sent.
+func (s *MagicLinkService) ConsumeMagicLink(token string, meta models.LoginMetadata) (*models.User, *Session, error) {
+	meta.Method = models.LoginMethodMagicLink
+
+	// Look up token
+	magicLink, err := s.tokenRepo.GetByTokenHash(hashToken(token))
+	if err != nil {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	if magicLink.IsUsed() {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	if magicLink.IsExpired() {
+		return nil, nil, ErrMagicLinkExpired
+	}
+
+	// Consume the token before signing in so it cannot be replayed
+	if err := s.tokenRepo.MarkUsed(magicLink.ID); err != nil {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	// The user may have been deleted since the link was sent
+	user, err := s.userRepo.GetUserByID(magicLink.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	// Check if user is blacklisted
+	if user.IsBlacklisted {
+		s.authService.recordLoginEvent(&user.ID, user.Email, meta, models.LoginFailureAccountSuspended)
+		return nil, nil, suspensionError(user)
+	}
+
+	// There is no remember-me choice when following a link, so these
+	// sessions get the short refresh token lifetime
+	session, err := s.authService.startSession(user, user.Email, meta, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, session, nil
+}
+
+// magicLinkURL returns the address sign-in links point to, which consumes
+// their token
+func magicLinkURL() string {
+	if link := os.Getenv("MAGIC_LINK_URL"); link != "" {
+		return link
+	}
+	return "http://localhost:8000/api/v1/auth/magic-link/consume"
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+)
+
+func newTestMagicLinkService(t *testing.T) (*MagicLinkService, *models.User, *fakeMagicLinkTokenRepo, *fakeEmailSender, *fakeLoginEventRepo) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	user := newTestUser(t, "password123")
+	userRepo := newFakeUserRepo(user)
+	events := &fakeLoginEventRepo{}
+	authService := NewAuthService(userRepo, newFakeRefreshTokenRepo(), events, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil)
+	tokenRepo := &fakeMagicLinkTokenRepo{}
+	sender := &fakeEmailSender{}
+	return NewMagicLinkService(userRepo, tokenRepo, sender, authService), user, tokenRepo, sender, events
+}
+
+// sentMagicLinkToken returns the token from the last sign-in email
+func sentMagicLinkToken(t *testing.T, sender *fakeEmailSender) string {
+	t.Helper()
+	email, ok := sender.last()
+	if !ok {
+		t.Fatal("expected a sign-in email to be sent")
+	}
+	return extractToken(t, email.body)
+}
+
+func TestMagicLinkService_RequestAndConsume(t *testing.T) {
+	t.Setenv("MAGIC_LINK_URL", "https://app.example.com/magic")
+	svc, user, tokenRepo, sender, events := newTestMagicLinkService(t)
+
+	if err := svc.RequestMagicLink(models.MagicLinkRequest{Email: user.Email}); err != nil {
+		t.Fatalf("RequestMagicLink returned error: %v", err)
+	}
+	email, _ := sender.last()
+	if email.to != user.Email {
+		t.Errorf("Expected email to %s, got %s", user.Email, email.to)
+	}
+	token := sentMagicLinkToken(t, sender)
+	if stored := tokenRepo.tokens[0]; stored.TokenHash == token || stored.ExpiresAt.After(time.Now().Add(magicLinkTTL)) {
+		t.Errorf("Expected a hashed token expiring within %s, got %+v", magicLinkTTL, stored)
+	}
+
+	signedIn, session, err := svc.ConsumeMagicLink(token, models.LoginMetadata{})
+	if err != nil {
+		t.Fatalf("ConsumeMagicLink returned error: %v", err)
+	}
+	if signedIn.ID != user.ID || session.AccessToken == "" || session.RefreshToken == "" {
+		t.Errorf("Expected a session for %s, got user %s and %+v", user.ID, signedIn.ID, session)
+	}
+	if got := events.events[0]; !got.Success || got.Method != models.LoginMethodMagicLink {
+		t.Errorf("Expected a successful magic_link event, got %+v", got)
+	}
+
+	// Links are single use
+	if _, _, err := svc.ConsumeMagicLink(token, models.LoginMetadata{}); !errors.Is(err, ErrInvalidMagicLink) {
+		t.Errorf("Expected ErrInvalidMagicLink for a used link, got %v", err)
+	}
+}
+
+func TestMagicLinkService_UnknownEmail(t *testing.T) {
+	svc, _, tokenRepo, sender, _ := newTestMagicLinkService(t)
+
+	if err := svc.RequestMagicLink(models.MagicLinkRequest{Email: "nobody@example.com"}); err != nil {
+		t.Errorf("Expected unknown emails to be accepted silently, got %v", err)
+	}
+	if len(sender.sent) != 0 || len(tokenRepo.tokens) != 0 {
+		t.Errorf("Expected no email or token, got %d emails and %d tokens", len(sender.sent), len(tokenRepo.tokens))
+	}
+}
+
+func TestMagicLinkService_ConsumeErrors(t *testing.T) {
+	t.Run("unknown token", func(t *testing.T) {
+		svc, _, _, _, _ := newTestMagicLinkService(t)
+		if _, _, err := svc.ConsumeMagicLink("not-a-token", models.LoginMetadata{}); !errors.Is(err, ErrInvalidMagicLink) {
+			t.Errorf("Expected ErrInvalidMagicLink, got %v", err)
+		}
+	})
+
+	t.Run("expired link", func(t *testing.T) {
+		svc, user, tokenRepo, sender, _ := newTestMagicLinkService(t)
+		svc.RequestMagicLink(models.MagicLinkRequest{Email: user.Email})
+		tokenRepo.tokens[0].ExpiresAt = time.Now().Add(-time.Second)
+		if _, _, err := svc.ConsumeMagicLink(sentMagicLinkToken(t, sender), models.LoginMetadata{}); !errors.Is(err, ErrMagicLinkExpired) {
+			t.Errorf("Expected ErrMagicLinkExpired, got %v", err)
+		}
+	})
+
+	t.Run("suspended after the link was sent", func(t *testing.T) {
+		svc, user, tokenRepo, sender, events := newTestMagicLinkService(t)
+		svc.RequestMagicLink(models.MagicLinkRequest{Email: user.Email})
+		user.IsBlacklisted = true
+		if _, _, err := svc.ConsumeMagicLink(sentMagicLinkToken(t, sender), models.LoginMetadata{}); !errors.Is(err, ErrAccountSuspended) {
+			t.Errorf("Expected ErrAccountSuspended, got %v", err)
+		}
+		if got := events.events[0]; got.Success || got.FailureReason != models.LoginFailureAccountSuspended || got.Method != models.LoginMethodMagicLink {
+			t.Errorf("Expected a refused magic_link event, got %+v", got)
+		}
+		if !tokenRepo.tokens[0].IsUsed() {
+			t.Error("Expected the link to be used up")
+		}
+	})
+}
+
+func TestMagicLinkService_RateLimitedPerAccount(t *testing.T) {
+	svc, user, _, sender, _ := newTestMagicLinkService(t)
+
+	for i := 0; i < maxMagicLinksPerHour+1; i++ {
+		if err := svc.RequestMagicLink(models.MagicLinkRequest{Email: user.Email}); err != nil {
+			t.Fatalf("RequestMagicLink returned error: %v", err)
+		}
+	}
+	if len(sender.sent) != maxMagicLinksPerHour {
+		t.Errorf("Expected %d emails, got %d", maxMagicLinksPerHour, len(sender.sent))
+	}
+}
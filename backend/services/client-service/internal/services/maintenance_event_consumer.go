@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/events"
+)
+
+// MaintenanceEventConsumer records the pausing and resuming of
+// transactions, published by the banking service, in the audit log
+type MaintenanceEventConsumer struct {
+	auditLogRepo repository.AuditLogRepository
+}
+
+// NewMaintenanceEventConsumer creates a new maintenance event consumer
+func NewMaintenanceEventConsumer(auditLogRepo repository.AuditLogRepository) *MaintenanceEventConsumer {
+	return &MaintenanceEventConsumer{
+		auditLogRepo: auditLogRepo,
+	}
+}
+
+// Handle applies the event and reports whether this service acted on its
+// type; other types are accepted and ignored. Each change is written to the
+// audit log under the event's ID, so a redelivered event is logged once.
+// Pauses made from the environment at startup are logged under the nil
+// UUID.
+func (c *MaintenanceEventConsumer) Handle(event events.Event) (bool, error) {
+	if event.Type != events.TypeMaintenanceChanged {
+		return false, nil
+	}
+
+	var payload events.MaintenanceChanged
+	if err := events.Decode(event, &payload); err != nil {
+		return false, err
+	}
+
+	action := models.AuditActionResumeTransactions
+	metadata := map[string]interface{}{}
+	if payload.Enabled {
+		action = models.AuditActionPauseTransactions
+		metadata["message"] = payload.Message
+		if payload.EndsAt != nil {
+			metadata["ends_at"] = *payload.EndsAt
+		}
+	}
+
+	entry := newAuditLogEntry(models.AuditActor{AdminID: payload.ActorID, RequestID: payload.RequestID}, action, payload.ActorID, metadata)
+	entry.ID = event.ID
+	entry.TargetUserID = nil
+	entry.CreatedAt = payload.ChangedAt
+	if err := c.auditLogRepo.Create(entry); err != nil {
+		return false, fmt.Errorf("failed to log maintenance change: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/events"
+)
+
+func TestMaintenanceEventConsumer_Handle(t *testing.T) {
+	audits := &fakeAuditLogRepo{}
+	consumer := NewMaintenanceEventConsumer(audits)
+	staffID := uuid.New()
+	endsAt := time.Now().Add(time.Hour).UTC()
+
+	paused, err := events.New(events.TypeMaintenanceChanged, events.SourceBankingService, events.MaintenanceChanged{
+		Enabled:   true,
+		Message:   "Database upgrade",
+		EndsAt:    &endsAt,
+		ActorID:   staffID,
+		RequestID: "req-1",
+		ChangedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("events.New returned error: %v", err)
+	}
+	if handled, err := consumer.Handle(paused); err != nil || !handled {
+		t.Fatalf("Expected the event to be handled, got %v (%v)", handled, err)
+	}
+	if len(audits.entries) != 1 {
+		t.Fatalf("Expected one audit log entry, got %d", len(audits.entries))
+	}
+	entry := audits.entries[0]
+	if entry.ID != paused.ID || entry.AdminID != staffID || entry.Action != models.AuditActionPauseTransactions || entry.RequestID != "req-1" {
+		t.Errorf("Expected a pause by %s logged under the event ID, got %+v", staffID, entry)
+	}
+	if entry.TargetUserID != nil || entry.Metadata["message"] != "Database upgrade" || entry.Metadata["ends_at"] != endsAt {
+		t.Errorf("Expected an entry without a target naming the message and end time, got %+v", entry)
+	}
+
+	resumed, err := events.New(events.TypeMaintenanceChanged, events.SourceBankingService, events.MaintenanceChanged{ActorID: staffID, ChangedAt: time.Now().UTC()})
+	if err != nil {
+		t.Fatalf("events.New returned error: %v", err)
+	}
+	if handled, err := consumer.Handle(resumed); err != nil || !handled {
+		t.Fatalf("Expected the event to be handled, got %v (%v)", handled, err)
+	}
+	if len(audits.entries) != 2 || audits.entries[1].Action != models.AuditActionResumeTransactions {
+		t.Errorf("Expected the resumption to be logged, got %+v", audits.entries)
+	}
+
+	other, err := events.New(events.TypeSavingsGoalCompleted, events.SourceBankingService, events.SavingsGoalCompleted{UserID: uuid.New()})
+	if err != nil {
+		t.Fatalf("events.New returned error: %v", err)
+	}
+	if handled, err := consumer.Handle(other); err != nil || handled {
+		t.Errorf("Expected other event types to be ignored, got %v (%v)", handled, err)
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+// notificationPreferenceCacheTTL is how long ChannelsFor reuses a user's
+// preferences before reloading them
+const notificationPreferenceCacheTTL = 30 * time.Second
+
+// NotificationPreferenceService manages which channels users receive each
+// notification event on
+type NotificationPreferenceService struct {
+	repo repository.NotificationPreferenceRepository
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedNotificationPreferences
+}
+
+// cachedNotificationPreferences is a user's preferences and when they stop
+// being reused
+type cachedNotificationPreferences struct {
+	preferences models.NotificationPreferences
+	expiresAt   time.Time
+}
+
+// NewNotificationPreferenceService creates a new notification preference service
+func NewNotificationPreferenceService(repo repository.NotificationPreferenceRepository) *NotificationPreferenceService {
+	return &NotificationPreferenceService{
+		repo:  repo,
+		cache: make(map[uuid.UUID]cachedNotificationPreferences),
+	}
+}
+
+// GetPreferences returns the channels for every known event, filling in
+// defaults for events the user has not configured
+func (s *NotificationPreferenceService) GetPreferences(userID uuid.UUID) (models.NotificationPreferences, error) {
+	stored, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	preferences := make(models.NotificationPreferences, len(models.NotificationEvents))
+	for _, event := range models.NotificationEvents {
+		preferences[event] = models.DefaultNotificationChannels(event)
+	}
+	for _, p := range stored {
+		if models.IsNotificationEvent(p.EventType) {
+			preferences[p.EventType] = p.NotificationChannels
+		}
+	}
+
+	s.store(userID, preferences)
+	return clonePreferences(preferences), nil
+}
+
+// UpdatePreferences changes the supplied channels of the supplied events and
+// returns the user's full preferences. Unknown event types are rejected with a
+// ValidationError and nothing is saved.
+func (s *NotificationPreferenceService) UpdatePreferences(userID uuid.UUID, update models.NotificationPreferencesUpdate) (models.NotificationPreferences, error) {
+	events := make([]string, 0, len(update.Preferences))
+	for event := range update.Preferences {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	var fieldErrs []FieldError
+	for _, event := range events {
+		if !models.IsNotificationEvent(event) {
+			fieldErrs = append(fieldErrs, FieldError{
+				Field:   "preferences." + event,
+				Rule:    "event_type",
+				Message: "unknown notification event type",
+			})
+		}
+	}
+	if len(fieldErrs) > 0 {
+		return nil, &ValidationError{Fields: fieldErrs}
+	}
+
+	current, err := s.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.NotificationPreference, 0, len(events))
+	for _, event := range events {
+		channels := update.Preferences[event].Apply(current[event])
+		current[event] = channels
+		changes = append(changes, models.NotificationPreference{
+			UserID:               userID,
+			EventType:            event,
+			NotificationChannels: channels,
+		})
+	}
+
+	if len(changes) > 0 {
+		if err := s.repo.Upsert(changes); err != nil {
+			s.invalidate(userID)
+			return nil, fmt.Errorf("failed to update notification preferences: %w", err)
+		}
+	}
+
+	s.store(userID, current)
+	return clonePreferences(current), nil
+}
+
+// ChannelsFor returns the channels an event should be delivered on for a
+// user. It is meant for notification dispatch: preferences are loaded with a
+// single query and reused for a short time.
+func (s *NotificationPreferenceService) ChannelsFor(userID uuid.UUID, event string) (models.NotificationChannels, error) {
+	if !models.IsNotificationEvent(event) {
+		return models.NotificationChannels{}, fmt.Errorf("unknown notification event type %q", event)
+	}
+
+	s.mu.Lock()
+	cached, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.preferences[event], nil
+	}
+
+	preferences, err := s.GetPreferences(userID)
+	if err != nil {
+		return models.NotificationChannels{}, err
+	}
+	return preferences[event], nil
+}
+
+// RecipientFor returns where and how user wants event delivered, for
+// other services dispatching it. Deleted users are reported as not
+// existing, and phone numbers only once they are verified.
+func (s *NotificationPreferenceService) RecipientFor(user *models.User, event string) (models.NotificationRecipient, error) {
+	recipient := models.NotificationRecipient{UserID: user.ID}
+	if user.DeletedAt != nil {
+		return recipient, nil
+	}
+
+	channels, err := s.ChannelsFor(user.ID, event)
+	if err != nil {
+		return models.NotificationRecipient{}, err
+	}
+
+	recipient.Exists = true
+	recipient.Email = user.Email
+	recipient.Name = user.Name
+	if user.IsPhoneVerified() {
+		recipient.PhoneNumber = user.PhoneNumber
+	}
+	recipient.Channels = channels
+	return recipient, nil
+}
+
+// store caches a user's preferences
+func (s *NotificationPreferenceService) store(userID uuid.UUID, preferences models.NotificationPreferences) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[userID] = cachedNotificationPreferences{
+		preferences: clonePreferences(preferences),
+		expiresAt:   time.Now().Add(notificationPreferenceCacheTTL),
+	}
+}
+
+// invalidate drops a user's cached preferences
+func (s *NotificationPreferenceService) invalidate(userID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, userID)
+}
+
+// clonePreferences copies preferences so callers cannot modify the cache
+func clonePreferences(preferences models.NotificationPreferences) models.NotificationPreferences {
+	clone := make(models.NotificationPreferences, len(preferences))
+	for event, channels := range preferences {
+		clone[event] = channels
+	}
+	return clone
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestNotificationPreferenceService_Defaults(t *testing.T) {
+	svc := NewNotificationPreferenceService(&fakeNotificationPreferenceRepo{})
+
+	preferences, err := svc.GetPreferences(uuid.New())
+	if err != nil {
+		t.Fatalf("GetPreferences returned error: %v", err)
+	}
+	if len(preferences) != len(models.NotificationEvents) {
+		t.Fatalf("Expected %d events, got %d", len(models.NotificationEvents), len(preferences))
+	}
+	for _, event := range models.NotificationEvents {
+		if preferences[event] != (models.NotificationChannels{Email: true}) {
+			t.Errorf("Expected %s to default to email only, got %+v", event, preferences[event])
+		}
+	}
+}
+
+func TestNotificationPreferenceService_UpdatePreferences(t *testing.T) {
+	repo := &fakeNotificationPreferenceRepo{}
+	svc := NewNotificationPreferenceService(repo)
+	userID := uuid.New()
+
+	updated, err := svc.UpdatePreferences(userID, models.NotificationPreferencesUpdate{
+		Preferences: map[string]models.NotificationChannelsUpdate{
+			models.NotificationEventLoginAlert: {SMS: boolPtr(true)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpdatePreferences returned error: %v", err)
+	}
+	if got := updated[models.NotificationEventLoginAlert]; got != (models.NotificationChannels{Email: true, SMS: true}) {
+		t.Errorf("Expected login alerts by email and SMS, got %+v", got)
+	}
+	if got := updated[models.NotificationEventStatementReady]; got != (models.NotificationChannels{Email: true}) {
+		t.Errorf("Expected untouched events to keep defaults, got %+v", got)
+	}
+	if n := len(repo.preferences[userID]); n != 1 {
+		t.Errorf("Expected only the changed event to be stored, got %d rows", n)
+	}
+
+	_, err = svc.UpdatePreferences(userID, models.NotificationPreferencesUpdate{
+		Preferences: map[string]models.NotificationChannelsUpdate{
+			models.NotificationEventLoginAlert: {Email: boolPtr(false)},
+			"password_changed":                 {Email: boolPtr(false)},
+		},
+	})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "preferences.password_changed" {
+		t.Fatalf("Expected a validation error for the unknown event, got %v", err)
+	}
+	if repo.preferences[userID][models.NotificationEventLoginAlert].Email != true {
+		t.Error("Expected nothing to be saved when an event is unknown")
+	}
+}
+
+func TestNotificationPreferenceService_ChannelsForCaches(t *testing.T) {
+	repo := &fakeNotificationPreferenceRepo{}
+	svc := NewNotificationPreferenceService(repo)
+	userID := uuid.New()
+
+	for i := 0; i < 3; i++ {
+		if _, err := svc.ChannelsFor(userID, models.NotificationEventLargeTransaction); err != nil {
+			t.Fatalf("ChannelsFor returned error: %v", err)
+		}
+	}
+	if repo.reads != 1 {
+		t.Errorf("Expected preferences to be loaded once, got %d reads", repo.reads)
+	}
+
+	if _, err := svc.UpdatePreferences(userID, models.NotificationPreferencesUpdate{
+		Preferences: map[string]models.NotificationChannelsUpdate{
+			models.NotificationEventLargeTransaction: {Email: boolPtr(false), Webhook: boolPtr(true)},
+		},
+	}); err != nil {
+		t.Fatalf("UpdatePreferences returned error: %v", err)
+	}
+
+	channels, err := svc.ChannelsFor(userID, models.NotificationEventLargeTransaction)
+	if err != nil {
+		t.Fatalf("ChannelsFor returned error: %v", err)
+	}
+	if channels != (models.NotificationChannels{Webhook: true}) {
+		t.Errorf("Expected the update to be visible immediately, got %+v", channels)
+	}
+
+	if _, err := svc.ChannelsFor(userID, "unknown"); err == nil {
+		t.Error("Expected an error for an unknown event type")
+	}
+}
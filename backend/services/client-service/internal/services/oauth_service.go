@@ -0,0 +1,257 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+// oauthStateWindow is how long a user has to finish signing in with a
+// provider after starting
+const oauthStateWindow = 10 * time.Minute
+
+// OAuthResult is the outcome of a completed OAuth callback. Tokens are only
+// issued when the user signed in; linking a provider to a signed-in user
+// leaves their session as it is.
+type OAuthResult struct {
+	User                  *models.User
+	AccessToken           string
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+	Created               bool
+	Linked                bool
+}
+
+// OAuthService signs users in with third-party identity providers and links
+// provider accounts to existing users
+type OAuthService struct {
+	stateRepo   repository.OAuthStateRepository
+	userRepo    repository.UserRepository
+	authService *AuthService
+	providers   map[string]OAuthProvider
+}
+
+// NewOAuthService creates a new OAuth service for the given providers.
+// Sign-ins are issued sessions by authService, and new users are subject to
+// its registration mode.
+func NewOAuthService(stateRepo repository.OAuthStateRepository, userRepo repository.UserRepository, authService *AuthService, providers ...OAuthProvider) *OAuthService {
+	byName := make(map[string]OAuthProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+
+	return &OAuthService{
+		stateRepo:   stateRepo,
+		userRepo:    userRepo,
+		authService: authService,
+		providers:   byName,
+	}
+}
+
+// StartOAuth begins signing in with a provider and returns the consent page
+// URL to send the user to. A non-nil userID links the provider to that user
+// instead of signing in.
+func (s *OAuthService) StartOAuth(provider string, userID *uuid.UUID) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrOAuthNotConfigured
+	}
+
+	state, err := generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate OAuth state: %w", err)
+	}
+	nonce, err := generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate OAuth nonce: %w", err)
+	}
+
+	now := time.Now()
+	pending := &models.OAuthState{
+		ID:        uuid.New(),
+		StateHash: hashToken(state),
+		Nonce:     nonce,
+		Provider:  provider,
+		UserID:    userID,
+		ExpiresAt: now.Add(oauthStateWindow),
+		CreatedAt: now,
+	}
+	if err := s.stateRepo.Create(pending); err != nil {
+		return "", fmt.Errorf("failed to store OAuth state: %w", err)
+	}
+
+	// Abandoned sign-ins are cleared as new ones start
+	if _, err := s.stateRepo.DeleteExpired(now); err != nil {
+		log.Printf("Failed to delete expired OAuth states: %v", err)
+	}
+
+	return p.AuthCodeURL(state, nonce), nil
+}
+
+// CompleteOAuth handles the provider's callback. The state is consumed so
+// it cannot be replayed, and the provider's ID token must carry the nonce
+// stored with it. Depending on the state and who the identity belongs to,
+// the provider is linked to the user who started the flow, a user with the
+// identity or the same verified email is signed in, or a new user without a
+// password is created and signed in.
+func (s *OAuthService) CompleteOAuth(provider string, callback models.OAuthCallback, meta models.LoginMetadata) (*OAuthResult, error) {
+	meta.Method = models.LoginMethodOAuth
+
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrOAuthNotConfigured
+	}
+
+	state, err := s.stateRepo.Consume(hashToken(callback.State))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOAuthState, err)
+	}
+	if state.Provider != provider {
+		return nil, ErrInvalidOAuthState
+	}
+	if state.IsExpired() {
+		return nil, ErrOAuthStateExpired
+	}
+
+	identity, err := p.Exchange(callback.Code, state.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOAuthExchangeFailed, err)
+	}
+
+	if state.UserID != nil {
+		return s.linkIdentity(*state.UserID, identity)
+	}
+	return s.signIn(identity, meta)
+}
+
+// linkIdentity links a provider identity to a signed-in user. An identity
+// can only belong to one user.
+func (s *OAuthService) linkIdentity(userID uuid.UUID, identity *models.OAuthIdentity) (*OAuthResult, error) {
+	if owner, err := s.userRepo.GetUserByOAuthSubject(identity.Provider, identity.Subject); err == nil && owner.ID != userID {
+		return nil, ErrOAuthAccountLinked
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	// A verified provider email only vouches for the user's own address
+	emailVerified := identity.EmailVerified && strings.EqualFold(identity.Email, user.Email)
+	if err := s.userRepo.LinkOAuthIdentity(user.ID, identity.Provider, identity.Subject, emailVerified); err != nil {
+		return nil, fmt.Errorf("failed to link OAuth identity: %w", err)
+	}
+
+	user.OAuthProvider, user.OAuthSubject = identity.Provider, identity.Subject
+	if emailVerified && user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	return &OAuthResult{User: user, Linked: true}, nil
+}
+
+// signIn finds or creates the user for a provider identity and issues them a
+// session
+func (s *OAuthService) signIn(identity *models.OAuthIdentity, meta models.LoginMetadata) (*OAuthResult, error) {
+	result := &OAuthResult{}
+
+	user, err := s.userRepo.GetUserByOAuthSubject(identity.Provider, identity.Subject)
+	if err != nil {
+		// Only a verified email may be matched to an account or used to
+		// create one
+		if !identity.EmailVerified || identity.Email == "" {
+			return nil, ErrOAuthEmailNotVerified
+		}
+
+		user, err = s.userRepo.GetUserByEmail(identity.Email)
+		if err == nil {
+			if user.OAuthSubject != "" {
+				return nil, ErrOAuthAccountLinked
+			}
+			if err := s.userRepo.LinkOAuthIdentity(user.ID, identity.Provider, identity.Subject, true); err != nil {
+				return nil, fmt.Errorf("failed to link OAuth identity: %w", err)
+			}
+			user.OAuthProvider, user.OAuthSubject = identity.Provider, identity.Subject
+			result.Linked = true
+		} else {
+			user, err = s.createUser(identity)
+			if err != nil {
+				return nil, err
+			}
+			result.Created = true
+		}
+	}
+
+	// Check if user is blacklisted
+	if user.IsBlacklisted {
+		s.authService.recordLoginEvent(&user.ID, user.Email, meta, models.LoginFailureAccountSuspended)
+		return nil, suspensionError(user)
+	}
+
+	// There is no remember-me choice when signing in with a provider, so
+	// these sessions get the short refresh token lifetime
+	session, err := s.authService.startSession(user, user.Email, meta, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result.User = user
+	result.AccessToken, result.RefreshToken, result.RefreshTokenExpiresAt = session.AccessToken, session.RefreshToken, session.RefreshTokenExpiresAt
+	return result, nil
+}
+
+// createUser registers a new user for a provider identity. The user has no
+// password and their email is already verified by the provider.
+func (s *OAuthService) createUser(identity *models.OAuthIdentity) (*models.User, error) {
+	// OAuth sign-ups carry no invitation code, so invite-only registration
+	// turns them away
+	if s.authService.invitations != nil {
+		if _, err := s.authService.invitations.checkRegistration(identity.Email, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	// A soft-deleted account keeps its email until it is purged
+	if _, err := s.userRepo.GetDeletedUserByEmail(identity.Email); err == nil {
+		return nil, ErrEmailPendingDeletion
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:                 uuid.New(),
+		Email:              identity.Email,
+		Name:               oauthUserName(identity),
+		EmailVerifiedAt:    &now,
+		OAuthProvider:      identity.Provider,
+		OAuthSubject:       identity.Subject,
+		RegistrationSource: models.OAuthRegistrationSource(identity.Provider),
+	}
+
+	if err := s.userRepo.CreateUser(user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	// Create the bank account in the background
+	s.authService.accounts.ProvisionAsync(user.ID)
+
+	return user, nil
+}
+
+// oauthUserName returns a display name for a new user, falling back to the
+// local part of their email when the provider has no usable name
+func oauthUserName(identity *models.OAuthIdentity) string {
+	name := strings.TrimSpace(identity.Name)
+	if len([]rune(name)) < 2 {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[:100])
+	}
+	return name
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+)
+
+// newTestOAuthService returns an OAuth service whose provider asserts the
+// given identity
+func newTestOAuthService(userRepo *fakeUserRepo, identity models.OAuthIdentity, invitations *InvitationService) (*OAuthService, *fakeOAuthProvider, *fakeOAuthStateRepo) {
+	provider := &fakeOAuthProvider{identity: identity}
+	stateRepo := newFakeOAuthStateRepo()
+	authService := NewAuthService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, invitations, testTokens, revocation.NewMemoryStore(), 0, nil)
+	return NewOAuthService(stateRepo, userRepo, authService, provider), provider, stateRepo
+}
+
+// completeTestOAuth runs a full flow as the provider would redirect back
+func completeTestOAuth(t *testing.T, svc *OAuthService, provider *fakeOAuthProvider, start func() (string, error)) (*OAuthResult, error) {
+	t.Helper()
+	authURL, err := start()
+	if err != nil {
+		t.Fatalf("StartOAuth returned error: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid consent URL %q: %v", authURL, err)
+	}
+	state := parsed.Query().Get("state")
+
+	callback := models.OAuthCallback{State: state, Code: "nonce:" + provider.nonces[state]}
+	return svc.CompleteOAuth(models.OAuthProviderGoogle, callback, models.LoginMetadata{})
+}
+
+func googleIdentity(email string, verified bool) models.OAuthIdentity {
+	return models.OAuthIdentity{
+		Provider:      models.OAuthProviderGoogle,
+		Subject:       "google-subject-1",
+		Email:         email,
+		EmailVerified: verified,
+		Name:          "Google User",
+	}
+}
+
+func TestOAuthService_CreatesUserWithoutPassword(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	userRepo := newFakeUserRepo()
+	svc, provider, _ := newTestOAuthService(userRepo, googleIdentity("new@example.com", true), nil)
+
+	result, err := completeTestOAuth(t, svc, provider, func() (string, error) {
+		return svc.StartOAuth(models.OAuthProviderGoogle, nil)
+	})
+	if err != nil {
+		t.Fatalf("CompleteOAuth returned error: %v", err)
+	}
+	if !result.Created || result.AccessToken == "" || result.RefreshToken == "" {
+		t.Fatalf("Expected a new signed-in user, got %+v", result)
+	}
+
+	stored, _ := userRepo.GetUserByEmail("new@example.com")
+	if stored == nil || stored.HasPassword() || !stored.IsEmailVerified() || stored.OAuthSubject != "google-subject-1" || stored.RegistrationSource != models.RegistrationSourceOAuthGoogle {
+		t.Fatalf("Unexpected stored user: %+v", stored)
+	}
+
+	// Signing in again finds the user by subject
+	again, err := completeTestOAuth(t, svc, provider, func() (string, error) {
+		return svc.StartOAuth(models.OAuthProviderGoogle, nil)
+	})
+	if err != nil || again.Created || again.User.ID != stored.ID {
+		t.Fatalf("Expected the same user to sign in, got %+v, %v", again, err)
+	}
+}
+
+func TestOAuthService_SignsInAndLinksUserWithVerifiedEmail(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	user := newTestUser(t, "password123")
+	userRepo := newFakeUserRepo(user)
+	svc, provider, _ := newTestOAuthService(userRepo, googleIdentity(user.Email, true), nil)
+
+	result, err := completeTestOAuth(t, svc, provider, func() (string, error) {
+		return svc.StartOAuth(models.OAuthProviderGoogle, nil)
+	})
+	if err != nil {
+		t.Fatalf("CompleteOAuth returned error: %v", err)
+	}
+	if result.User.ID != user.ID || result.Created || !result.Linked {
+		t.Fatalf("Expected the existing user to be signed in and linked, got %+v", result)
+	}
+
+	stored, _ := userRepo.GetUserByID(user.ID)
+	if stored.OAuthSubject != "google-subject-1" || !stored.HasPassword() {
+		t.Errorf("Expected the subject linked and the password kept, got %+v", stored)
+	}
+}
+
+func TestOAuthService_RejectsUnverifiedEmail(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	user := newTestUser(t, "password123")
+	svc, provider, _ := newTestOAuthService(newFakeUserRepo(user), googleIdentity(user.Email, false), nil)
+
+	_, err := completeTestOAuth(t, svc, provider, func() (string, error) {
+		return svc.StartOAuth(models.OAuthProviderGoogle, nil)
+	})
+	if !errors.Is(err, ErrOAuthEmailNotVerified) {
+		t.Fatalf("Expected ErrOAuthEmailNotVerified, got %v", err)
+	}
+}
+
+func TestOAuthService_LinksSignedInUser(t *testing.T) {
+	user := newTestUser(t, "password123")
+	other := newTestUser(t, "password123")
+	other.Email = "other@example.com"
+	userRepo := newFakeUserRepo(user, other)
+	svc, provider, _ := newTestOAuthService(userRepo, googleIdentity("someone@gmail.com", true), nil)
+
+	result, err := completeTestOAuth(t, svc, provider, func() (string, error) {
+		return svc.StartOAuth(models.OAuthProviderGoogle, &user.ID)
+	})
+	if err != nil {
+		t.Fatalf("CompleteOAuth returned error: %v", err)
+	}
+	if !result.Linked || result.AccessToken != "" {
+		t.Fatalf("Expected a link without new tokens, got %+v", result)
+	}
+
+	stored, _ := userRepo.GetUserByID(user.ID)
+	if stored.OAuthSubject != "google-subject-1" || stored.IsEmailVerified() {
+		t.Errorf("Expected the subject linked without verifying a different email, got %+v", stored)
+	}
+
+	// The same Google account cannot be linked to a second user
+	_, err = completeTestOAuth(t, svc, provider, func() (string, error) {
+		return svc.StartOAuth(models.OAuthProviderGoogle, &other.ID)
+	})
+	if !errors.Is(err, ErrOAuthAccountLinked) {
+		t.Fatalf("Expected ErrOAuthAccountLinked, got %v", err)
+	}
+}
+
+func TestOAuthService_StateIsSingleUseAndExpires(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	svc, provider, stateRepo := newTestOAuthService(newFakeUserRepo(), googleIdentity("new@example.com", true), nil)
+
+	authURL, err := svc.StartOAuth(models.OAuthProviderGoogle, nil)
+	if err != nil {
+		t.Fatalf("StartOAuth returned error: %v", err)
+	}
+	parsed, _ := url.Parse(authURL)
+	state := parsed.Query().Get("state")
+	callback := models.OAuthCallback{State: state, Code: "nonce:" + provider.nonces[state]}
+
+	if _, err := svc.CompleteOAuth(models.OAuthProviderGoogle, callback, models.LoginMetadata{}); err != nil {
+		t.Fatalf("CompleteOAuth returned error: %v", err)
+	}
+	if _, err := svc.CompleteOAuth(models.OAuthProviderGoogle, callback, models.LoginMetadata{}); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Fatalf("Expected a replayed state to be rejected, got %v", err)
+	}
+
+	authURL, _ = svc.StartOAuth(models.OAuthProviderGoogle, nil)
+	parsed, _ = url.Parse(authURL)
+	state = parsed.Query().Get("state")
+	stateRepo.states[hashToken(state)].ExpiresAt = time.Now().Add(-time.Second)
+
+	callback = models.OAuthCallback{State: state, Code: "nonce:" + provider.nonces[state]}
+	if _, err := svc.CompleteOAuth(models.OAuthProviderGoogle, callback, models.LoginMetadata{}); !errors.Is(err, ErrOAuthStateExpired) {
+		t.Fatalf("Expected ErrOAuthStateExpired, got %v", err)
+	}
+}
+
+func TestOAuthService_RespectsRegistrationMode(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	invitations := NewInvitationService(nil, RegistrationClosed)
+	svc, provider, _ := newTestOAuthService(newFakeUserRepo(), googleIdentity("new@example.com", true), invitations)
+
+	_, err := completeTestOAuth(t, svc, provider, func() (string, error) {
+		return svc.StartOAuth(models.OAuthProviderGoogle, nil)
+	})
+	if !errors.Is(err, ErrRegistrationClosed) {
+		t.Fatalf("Expected ErrRegistrationClosed, got %v", err)
+	}
+}
+
+func TestOAuthService_UnconfiguredProvider(t *testing.T) {
+	stateRepo := newFakeOAuthStateRepo()
+	svc := NewOAuthService(stateRepo, newFakeUserRepo(), nil)
+
+	if _, err := svc.StartOAuth(models.OAuthProviderGoogle, nil); !errors.Is(err, ErrOAuthNotConfigured) {
+		t.Fatalf("Expected ErrOAuthNotConfigured, got %v", err)
+	}
+}
+
+func TestAuthService_PasswordlessUser(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	user := newTestUser(t, "password123")
+	user.PasswordHash = ""
+	svc := NewAuthService(newFakeUserRepo(user), newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil)
+
+	if _, _, err := svc.LoginUser(models.UserLogin{Email: user.Email, Password: ""}, models.LoginMetadata{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected password login to fail, got %v", err)
+	}
+	if err := svc.ChangePassword(user.ID, models.PasswordChange{CurrentPassword: "x", NewPassword: "NewPassword123!"}); !errors.Is(err, ErrPasswordNotSet) {
+		t.Errorf("Expected ErrPasswordNotSet from ChangePassword, got %v", err)
+	}
+	if _, err := svc.DeleteAccount(user.ID, models.AccountDeletion{Password: "x"}); !errors.Is(err, ErrPasswordNotSet) {
+		t.Errorf("Expected ErrPasswordNotSet from DeleteAccount, got %v", err)
+	}
+}
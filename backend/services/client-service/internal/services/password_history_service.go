@@ -0,0 +1,87 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordhash"
+	"microbank/client-service/internal/repository"
+)
+
+// PasswordHistoryService prevents users from rotating back to a recently
+// used password. A size of zero disables history entirely.
+type PasswordHistoryService struct {
+	historyRepo    repository.PasswordHistoryRepository
+	passwordHasher passwordhash.PasswordHasher
+	size           int
+}
+
+// NewPasswordHistoryService creates a new password history service that
+// remembers the last size passwords per user
+func NewPasswordHistoryService(historyRepo repository.PasswordHistoryRepository, passwordHasher passwordhash.PasswordHasher, size int) *PasswordHistoryService {
+	return &PasswordHistoryService{
+		historyRepo:    historyRepo,
+		passwordHasher: passwordHasher,
+		size:           size,
+	}
+}
+
+// Enabled reports whether password history is being enforced
+func (s *PasswordHistoryService) Enabled() bool {
+	return s != nil && s.size > 0
+}
+
+// CheckReuse returns an error if the candidate matches the user's current
+// password or one of their last size passwords
+func (s *PasswordHistoryService) CheckReuse(user *models.User, candidate string) error {
+	if !s.Enabled() {
+		return nil
+	}
+
+	// Check current password
+	if err := s.passwordHasher.Verify(user.PasswordHash, candidate); err == nil {
+		return ErrPasswordRecentlyUsed
+	}
+
+	// Check previous passwords
+	entries, err := s.historyRepo.GetRecentByUserID(user.ID, s.size)
+	if err != nil {
+		return fmt.Errorf("failed to load password history: %w", err)
+	}
+
+	for _, entry := range entries {
+		if err := s.passwordHasher.Verify(entry.PasswordHash, candidate); err == nil {
+			return ErrPasswordRecentlyUsed
+		}
+	}
+
+	return nil
+}
+
+// Record stores a newly set password hash and prunes entries beyond the
+// configured size. Failures are logged since the password change itself
+// has already been applied.
+func (s *PasswordHistoryService) Record(userID uuid.UUID, passwordHash string) {
+	if !s.Enabled() {
+		return
+	}
+
+	entry := &models.PasswordHistoryEntry{
+		ID:           uuid.New(),
+		UserID:       userID,
+		PasswordHash: passwordHash,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.historyRepo.Create(entry); err != nil {
+		log.Printf("Failed to record password history for user %s: %v", userID, err)
+		return
+	}
+
+	if err := s.historyRepo.PruneByUserID(userID, s.size); err != nil {
+		log.Printf("Failed to prune password history for user %s: %v", userID, err)
+	}
+}
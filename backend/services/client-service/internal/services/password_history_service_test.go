@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+)
+
+func newTestAuthServiceWithHistory(user *models.User, size int) (*AuthService, *fakePasswordHistoryRepo) {
+	historyRepo := &fakePasswordHistoryRepo{}
+	history := NewPasswordHistoryService(historyRepo, testHasher, size)
+	svc := NewAuthService(newFakeUserRepo(user), newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, history, nil, 0, nil, nil, testTokens, revocation.NewMemoryStore(), 0, nil)
+	return svc, historyRepo
+}
+
+func TestPasswordHistory_ChangePasswordRejectsRecentPasswords(t *testing.T) {
+	user := newTestUser(t, "original1")
+	svc, historyRepo := newTestAuthServiceWithHistory(user, 2)
+
+	change := func(current, next string) error {
+		return svc.ChangePassword(user.ID, models.PasswordChange{CurrentPassword: current, NewPassword: next})
+	}
+
+	if err := change("original1", "second22"); err != nil {
+		t.Fatalf("first change returned error: %v", err)
+	}
+	if err := change("second22", "third333"); err != nil {
+		t.Fatalf("second change returned error: %v", err)
+	}
+
+	// second22 is still within the last two passwords
+	err := change("third333", "second22")
+	if !errors.Is(err, ErrPasswordRecentlyUsed) {
+		t.Fatalf("Expected recently used error, got %v", err)
+	}
+
+	if err := change("third333", "fourth44"); err != nil {
+		t.Fatalf("third change returned error: %v", err)
+	}
+
+	// History is pruned to the configured size
+	if got := historyRepo.countForUser(user.ID); got != 2 {
+		t.Errorf("Expected 2 history entries after pruning, got %d", got)
+	}
+
+	// second22 has now fallen out of the window
+	if err := change("fourth44", "second22"); err != nil {
+		t.Errorf("Expected password outside history window to be accepted, got %v", err)
+	}
+}
+
+func TestPasswordHistory_DisabledWhenSizeZero(t *testing.T) {
+	user := newTestUser(t, "original1")
+	svc, historyRepo := newTestAuthServiceWithHistory(user, 0)
+
+	if err := svc.ChangePassword(user.ID, models.PasswordChange{CurrentPassword: "original1", NewPassword: "second22"}); err != nil {
+		t.Fatalf("ChangePassword returned error: %v", err)
+	}
+	if err := svc.ChangePassword(user.ID, models.PasswordChange{CurrentPassword: "second22", NewPassword: "original1"}); err != nil {
+		t.Errorf("Expected reuse to be allowed with history disabled, got %v", err)
+	}
+
+	if got := historyRepo.countForUser(user.ID); got != 0 {
+		t.Errorf("Expected no history to be written when disabled, got %d entries", got)
+	}
+}
+
+func TestPasswordHistory_ResetRejectsCurrentPassword(t *testing.T) {
+	user := newTestUser(t, "original1")
+	userRepo := newFakeUserRepo(user)
+	sender := &fakeEmailSender{}
+	history := NewPasswordHistoryService(&fakePasswordHistoryRepo{}, testHasher, 3)
+	svc := NewPasswordResetService(userRepo, newFakeRefreshTokenRepo(), newFakeResetTokenRepo(), sender, passwordpolicy.Default(), testHasher, history)
+
+	if err := svc.RequestPasswordReset(user.Email); err != nil {
+		t.Fatalf("RequestPasswordReset returned error: %v", err)
+	}
+	email, _ := sender.last()
+	token := extractToken(t, email.body)
+
+	err := svc.ResetPassword(models.ResetPasswordRequest{Token: token, NewPassword: "original1"})
+	if !errors.Is(err, ErrPasswordRecentlyUsed) {
+		t.Fatalf("Expected recently used error, got %v", err)
+	}
+
+	// The token is not consumed by a rejected password
+	if err := svc.ResetPassword(models.ResetPasswordRequest{Token: token, NewPassword: "brandnew1"}); err != nil {
+		t.Errorf("Expected reset with a fresh password to succeed, got %v", err)
+	}
+}
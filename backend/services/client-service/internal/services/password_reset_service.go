@@ -0,0 +1,174 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordhash"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/mailer"
+)
+
+const (
+	// passwordResetTokenTTL is how long a reset token stays valid
+	passwordResetTokenTTL = 30 * time.Minute
+	// maxPasswordResetsPerHour caps how many reset emails a single account can receive
+	maxPasswordResetsPerHour = 3
+)
+
+// PasswordResetService handles the forgot/reset password flow
+type PasswordResetService struct {
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	resetTokenRepo   repository.PasswordResetTokenRepository
+	emailSender      mailer.EmailSender
+	passwordPolicy   *passwordpolicy.Policy
+	passwordHasher   passwordhash.PasswordHasher
+	passwordHistory  *PasswordHistoryService
+}
+
+// NewPasswordResetService creates a new password reset service
+func NewPasswordResetService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, resetTokenRepo repository.PasswordResetTokenRepository, emailSender mailer.EmailSender, passwordPolicy *passwordpolicy.Policy, passwordHasher passwordhash.PasswordHasher, passwordHistory *PasswordHistoryService) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		resetTokenRepo:   resetTokenRepo,
+		emailSender:      emailSender,
+		passwordPolicy:   passwordPolicy,
+		passwordHasher:   passwordHasher,
+		passwordHistory:  passwordHistory,
+	}
+}
+
+// RequestPasswordReset issues a reset token and emails it to the user. Unknown
+// emails and rate-limited accounts are silently ignored so callers cannot tell
+// whether an account exists.
+func (s *PasswordResetService) RequestPasswordReset(email string) error {
+	// Get user by email
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	// Rate-limit reset requests per account
+	count, err := s.resetTokenRepo.CountRecentByUserID(user.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to count recent reset requests: %w", err)
+	}
+	if count >= maxPasswordResetsPerHour {
+		log.Printf("Password reset rate limit reached for user %s", user.ID)
+		return nil
+	}
+
+	// Generate a random token; only its hash is stored
+	token, err := generateSecureToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	resetToken := &models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTokenTTL),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.resetTokenRepo.Create(resetToken); err != nil {
+		return fmt.Errorf("failed to save reset token: %w", err)
+	}
+
+	// Send the reset link
+	link := fmt.Sprintf("%s?token=%s", passwordResetURL(), token)
+	data := map[string]string{"Name": user.Name, "Link": link}
+	if err := sendEmail(s.emailSender, user.Email, user.ID, "password_reset", data); err != nil {
+		return fmt.Errorf("failed to send reset email: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword consumes a reset token and sets a new password
+func (s *PasswordResetService) ResetPassword(request models.ResetPasswordRequest) error {
+	// Look up token
+	resetToken, err := s.resetTokenRepo.GetByTokenHash(hashToken(request.Token))
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	if resetToken.IsUsed() {
+		return ErrInvalidResetToken
+	}
+
+	if resetToken.IsExpired() {
+		return ErrResetTokenExpired
+	}
+
+	// Enforce password policy
+	user, err := s.userRepo.GetUserByID(resetToken.UserID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+	if err := s.passwordPolicy.Validate(request.NewPassword, user.Email, user.Name); err != nil {
+		return err
+	}
+
+	// Reject recently used passwords
+	if err := s.passwordHistory.CheckReuse(user, request.NewPassword); err != nil {
+		return err
+	}
+
+	// Hash the new password
+	hashedPassword, err := s.passwordHasher.Hash(request.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// Consume the token before applying the change so it cannot be replayed
+	if err := s.resetTokenRepo.MarkUsed(resetToken.ID); err != nil {
+		return ErrInvalidResetToken
+	}
+
+	if err := s.userRepo.UpdatePassword(resetToken.UserID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	s.passwordHistory.Record(resetToken.UserID, hashedPassword)
+
+	// Revoke all sessions
+	if err := s.refreshTokenRepo.DeleteByUserID(resetToken.UserID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// passwordResetURL returns the frontend page that accepts reset tokens
+func passwordResetURL() string {
+	if url := os.Getenv("PASSWORD_RESET_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000/reset-password"
+}
+
+// generateSecureToken returns a random URL-safe token
+func generateSecureToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// hashToken returns the SHA-256 hex digest of a token for storage
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordpolicy"
+)
+
+func newTestUser(t *testing.T, password string) *models.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	return &models.User{
+		ID:           uuid.New(),
+		Email:        "test@example.com",
+		Name:         "Test User",
+		PasswordHash: string(hash),
+	}
+}
+
+// extractToken pulls the reset token out of the emailed link
+func extractToken(t *testing.T, body string) string {
+	t.Helper()
+	idx := strings.Index(body, "?token=")
+	if idx == -1 {
+		t.Fatalf("reset email does not contain a token: %q", body)
+	}
+	return strings.Fields(body[idx+len("?token="):])[0]
+}
+
+func newTestPasswordResetService(user *models.User) (*PasswordResetService, *fakeUserRepo, *fakeRefreshTokenRepo, *fakeResetTokenRepo, *fakeEmailSender) {
+	userRepo := newFakeUserRepo(user)
+	refreshRepo := newFakeRefreshTokenRepo()
+	resetRepo := newFakeResetTokenRepo()
+	sender := &fakeEmailSender{}
+	return NewPasswordResetService(userRepo, refreshRepo, resetRepo, sender, passwordpolicy.Default(), testHasher, nil), userRepo, refreshRepo, resetRepo, sender
+}
+
+func TestPasswordResetService_ResetPassword(t *testing.T) {
+	user := newTestUser(t, "oldpassword")
+	svc, userRepo, refreshRepo, _, sender := newTestPasswordResetService(user)
+	refreshRepo.Create(&models.RefreshToken{ID: uuid.New(), UserID: user.ID})
+
+	if err := svc.RequestPasswordReset(user.Email); err != nil {
+		t.Fatalf("RequestPasswordReset returned error: %v", err)
+	}
+
+	email, ok := sender.last()
+	if !ok {
+		t.Fatal("expected a reset email to be sent")
+	}
+	if email.to != user.Email {
+		t.Errorf("Expected email to %s, got %s", user.Email, email.to)
+	}
+
+	token := extractToken(t, email.body)
+	if err := svc.ResetPassword(models.ResetPasswordRequest{Token: token, NewPassword: "newpassword1"}); err != nil {
+		t.Fatalf("ResetPassword returned error: %v", err)
+	}
+
+	updated, _ := userRepo.GetUserByID(user.ID)
+	if err := bcrypt.CompareHashAndPassword([]byte(updated.PasswordHash), []byte("newpassword1")); err != nil {
+		t.Error("Expected password hash to match the new password")
+	}
+
+	if n := refreshRepo.countForUser(user.ID); n != 0 {
+		t.Errorf("Expected refresh tokens to be revoked, %d remain", n)
+	}
+}
+
+func TestPasswordResetService_TokenReuse(t *testing.T) {
+	user := newTestUser(t, "oldpassword")
+	svc, _, _, _, sender := newTestPasswordResetService(user)
+
+	if err := svc.RequestPasswordReset(user.Email); err != nil {
+		t.Fatalf("RequestPasswordReset returned error: %v", err)
+	}
+	email, _ := sender.last()
+	token := extractToken(t, email.body)
+
+	if err := svc.ResetPassword(models.ResetPasswordRequest{Token: token, NewPassword: "newpassword1"}); err != nil {
+		t.Fatalf("first ResetPassword returned error: %v", err)
+	}
+
+	err := svc.ResetPassword(models.ResetPasswordRequest{Token: token, NewPassword: "anotherpassword2"})
+	if !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("Expected invalid reset token error on reuse, got %v", err)
+	}
+}
+
+func TestPasswordResetService_ExpiredToken(t *testing.T) {
+	user := newTestUser(t, "oldpassword")
+	svc, userRepo, _, resetRepo, _ := newTestPasswordResetService(user)
+
+	token := "expired-token"
+	resetRepo.Create(&models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(-time.Minute),
+		CreatedAt: time.Now().Add(-31 * time.Minute),
+	})
+
+	err := svc.ResetPassword(models.ResetPasswordRequest{Token: token, NewPassword: "newpassword1"})
+	if !errors.Is(err, ErrResetTokenExpired) {
+		t.Errorf("Expected reset token expired error, got %v", err)
+	}
+
+	unchanged, _ := userRepo.GetUserByID(user.ID)
+	if unchanged.PasswordHash != user.PasswordHash {
+		t.Error("Expected password to remain unchanged for an expired token")
+	}
+}
+
+func TestPasswordResetService_UnknownEmail(t *testing.T) {
+	user := newTestUser(t, "oldpassword")
+	svc, _, _, _, sender := newTestPasswordResetService(user)
+
+	if err := svc.RequestPasswordReset("nobody@example.com"); err != nil {
+		t.Errorf("Expected no error for unknown email, got %v", err)
+	}
+	if _, ok := sender.last(); ok {
+		t.Error("Expected no email to be sent for an unknown address")
+	}
+}
+
+func TestPasswordResetService_RateLimit(t *testing.T) {
+	user := newTestUser(t, "oldpassword")
+	svc, _, _, _, sender := newTestPasswordResetService(user)
+
+	for i := 0; i < maxPasswordResetsPerHour+2; i++ {
+		if err := svc.RequestPasswordReset(user.Email); err != nil {
+			t.Fatalf("RequestPasswordReset returned error: %v", err)
+		}
+	}
+
+	if len(sender.sent) != maxPasswordResetsPerHour {
+		t.Errorf("Expected %d emails, got %d", maxPasswordResetsPerHour, len(sender.sent))
+	}
+}
+
+func TestPasswordResetService_EnforcesPolicy(t *testing.T) {
+	user := newTestUser(t, "oldpassword1")
+	svc, userRepo, _, _, sender := newTestPasswordResetService(user)
+
+	if err := svc.RequestPasswordReset(user.Email); err != nil {
+		t.Fatalf("RequestPasswordReset returned error: %v", err)
+	}
+	email, _ := sender.last()
+	token := extractToken(t, email.body)
+
+	err := svc.ResetPassword(models.ResetPasswordRequest{Token: token, NewPassword: "password1"})
+	var violationErr *passwordpolicy.ViolationError
+	if !errors.As(err, &violationErr) {
+		t.Fatalf("Expected a policy violation, got %v", err)
+	}
+
+	// A rejected password must not consume the token
+	if err := svc.ResetPassword(models.ResetPasswordRequest{Token: token, NewPassword: "Str0ng-enough"}); err != nil {
+		t.Fatalf("Expected token to remain usable after a policy rejection, got %v", err)
+	}
+
+	updated, _ := userRepo.GetUserByID(user.ID)
+	if err := bcrypt.CompareHashAndPassword([]byte(updated.PasswordHash), []byte("Str0ng-enough")); err != nil {
+		t.Error("Expected password hash to match the new password")
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/authmw"
+)
+
+// personalAccessTokenUseResolution is how out of date a token's
+// last_used_at may get before a request updates it, so scripts calling in
+// a loop do not write on every request
+const personalAccessTokenUseResolution = time.Minute
+
+// PersonalAccessTokenService manages the personal access tokens users
+// script against their own account with, and authenticates requests made
+// with them
+type PersonalAccessTokenService struct {
+	tokenRepo repository.PersonalAccessTokenRepository
+	userRepo  repository.UserRepository
+}
+
+// NewPersonalAccessTokenService creates a new personal access token service
+func NewPersonalAccessTokenService(tokenRepo repository.PersonalAccessTokenRepository, userRepo repository.UserRepository) *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// CreateToken creates a named token for a user with the requested scopes.
+// The token itself is only returned here; just its hash is stored.
+func (s *PersonalAccessTokenService) CreateToken(userID uuid.UUID, request models.PersonalAccessTokenRequest) (*models.CreatedPersonalAccessToken, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, ErrPersonalAccessTokenNameRequired
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidPersonalAccessTokenExpiry
+	}
+
+	// Keep each scope once, in the order given
+	scopes := make([]string, 0, len(request.Scopes))
+	seen := make(map[string]bool, len(request.Scopes))
+	for _, scope := range request.Scopes {
+		if !authmw.ValidScope(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	secret, err := generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate personal access token: %w", err)
+	}
+	token := authmw.PersonalAccessTokenPrefix + secret
+
+	created := &models.CreatedPersonalAccessToken{
+		PersonalAccessToken: models.PersonalAccessToken{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Name:      name,
+			TokenHash: hashToken(token),
+			Scopes:    scopes,
+			ExpiresAt: request.ExpiresAt,
+			CreatedAt: time.Now(),
+		},
+		Token: token,
+	}
+	if err := s.tokenRepo.Create(&created.PersonalAccessToken); err != nil {
+		return nil, fmt.Errorf("failed to save personal access token: %w", err)
+	}
+
+	return created, nil
+}
+
+// ListTokens retrieves a user's tokens, newest first. Their secrets are not
+// included.
+func (s *PersonalAccessTokenService) ListTokens(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	tokens, err := s.tokenRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list personal access tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken deletes one of a user's tokens, which stops working at once
+func (s *PersonalAccessTokenService) RevokeToken(userID, tokenID uuid.UUID) error {
+	if err := s.tokenRepo.Delete(userID, tokenID); err != nil {
+		return fmt.Errorf("%w: %w", ErrPersonalAccessTokenNotFound, err)
+	}
+	return nil
+}
+
+// Authenticate looks up the token a request was made with and its owner.
+// Unknown and expired tokens, and tokens of deleted users, are rejected
+// with ErrInvalidPersonalAccessToken. Blacklisted owners are returned for
+// the caller to reject.
+func (s *PersonalAccessTokenService) Authenticate(token string) (*models.PersonalAccessToken, *models.User, error) {
+	stored, err := s.tokenRepo.GetByTokenHash(hashToken(token))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidPersonalAccessToken, err)
+	}
+	if stored.IsExpired() {
+		return nil, nil, ErrInvalidPersonalAccessToken
+	}
+
+	user, err := s.userRepo.GetUserByID(stored.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidPersonalAccessToken, err)
+	}
+
+	// Record the use; a failure here should not fail the request
+	now := time.Now()
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= personalAccessTokenUseResolution {
+		if err := s.tokenRepo.TouchLastUsed(stored.ID, now); err != nil {
+			log.Printf("Failed to record use of personal access token %s: %v", stored.ID, err)
+		} else {
+			stored.LastUsedAt = &now
+		}
+	}
+
+	return stored, user, nil
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/authmw"
+)
+
+func TestPersonalAccessTokenService_CreateToken(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		request    models.PersonalAccessTokenRequest
+		wantErr    error
+		wantScopes []string
+	}{
+		{name: "valid", request: models.PersonalAccessTokenRequest{Name: " ci ", Scopes: []string{authmw.ScopeReadBalance, authmw.ScopeReadBalance, authmw.ScopeReadTransactions}, ExpiresAt: &future}, wantScopes: []string{authmw.ScopeReadBalance, authmw.ScopeReadTransactions}},
+		{name: "blank name", request: models.PersonalAccessTokenRequest{Name: "  ", Scopes: []string{authmw.ScopeReadBalance}}, wantErr: ErrPersonalAccessTokenNameRequired},
+		{name: "unknown scope", request: models.PersonalAccessTokenRequest{Name: "ci", Scopes: []string{"write:balance"}}, wantErr: ErrInvalidScope},
+		{name: "expiry in the past", request: models.PersonalAccessTokenRequest{Name: "ci", Scopes: []string{authmw.ScopeReadBalance}, ExpiresAt: &past}, wantErr: ErrInvalidPersonalAccessTokenExpiry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakePersonalAccessTokenRepo()
+			service := NewPersonalAccessTokenService(repo, newFakeUserRepo())
+			userID := uuid.New()
+
+			created, err := service.CreateToken(userID, tt.request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if len(repo.tokens) != 0 {
+					t.Errorf("Expected no token to be stored, got %d", len(repo.tokens))
+				}
+				return
+			}
+
+			if !strings.HasPrefix(created.Token, authmw.PersonalAccessTokenPrefix) || len(created.Token) < 40 {
+				t.Errorf("Expected a long pat_ token, got %q", created.Token)
+			}
+			if created.Name != "ci" || !reflect.DeepEqual(created.Scopes, tt.wantScopes) {
+				t.Errorf("Unexpected token: %+v", created.PersonalAccessToken)
+			}
+			stored, ok := repo.tokens[hashToken(created.Token)]
+			if !ok || stored.UserID != userID {
+				t.Fatalf("Expected the token's hash to be stored for the user")
+			}
+			if strings.Contains(stored.TokenHash, created.Token) {
+				t.Errorf("Expected the token itself not to be stored")
+			}
+		})
+	}
+}
+
+func TestPersonalAccessTokenService_Authenticate(t *testing.T) {
+	owner := newTestUser(t, "password")
+	repo := newFakePersonalAccessTokenRepo()
+	service := NewPersonalAccessTokenService(repo, newFakeUserRepo(owner))
+
+	request := models.PersonalAccessTokenRequest{Name: "ci", Scopes: []string{authmw.ScopeReadBalance}}
+	valid, err := service.CreateToken(owner.ID, request)
+	if err != nil {
+		t.Fatalf("CreateToken returned error: %v", err)
+	}
+	orphaned, err := service.CreateToken(uuid.New(), request)
+	if err != nil {
+		t.Fatalf("CreateToken returned error: %v", err)
+	}
+	expired, err := service.CreateToken(owner.ID, request)
+	if err != nil {
+		t.Fatalf("CreateToken returned error: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	repo.tokens[hashToken(expired.Token)].ExpiresAt = &past
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid token", token: valid.Token},
+		{name: "unknown token", token: "pat_unknown", wantErr: true},
+		{name: "expired token", token: expired.Token, wantErr: true},
+		{name: "owner gone", token: orphaned.Token, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, user, err := service.Authenticate(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPersonalAccessToken) {
+					t.Errorf("Expected ErrInvalidPersonalAccessToken, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate returned error: %v", err)
+			}
+			if user.ID != owner.ID || !reflect.DeepEqual(token.Scopes, request.Scopes) {
+				t.Errorf("Expected the owner and scopes, got %s and %v", user.ID, token.Scopes)
+			}
+			if token.LastUsedAt == nil {
+				t.Errorf("Expected last_used_at to be set")
+			}
+		})
+	}
+
+	// A second use within the resolution does not write again
+	if _, _, err := service.Authenticate(valid.Token); err != nil {
+		t.Fatalf("Authenticate returned error: %v", err)
+	}
+	if repo.touches != 1 {
+		t.Errorf("Expected one last_used_at update, got %d", repo.touches)
+	}
+}
+
+func TestPersonalAccessTokenService_RevokeToken(t *testing.T) {
+	repo := newFakePersonalAccessTokenRepo()
+	service := NewPersonalAccessTokenService(repo, newFakeUserRepo())
+	userID := uuid.New()
+
+	created, err := service.CreateToken(userID, models.PersonalAccessTokenRequest{Name: "ci", Scopes: []string{authmw.ScopeReadBalance}})
+	if err != nil {
+		t.Fatalf("CreateToken returned error: %v", err)
+	}
+
+	if err := service.RevokeToken(uuid.New(), created.ID); !errors.Is(err, ErrPersonalAccessTokenNotFound) {
+		t.Errorf("Expected another user's revoke to fail with ErrPersonalAccessTokenNotFound, got %v", err)
+	}
+	if err := service.RevokeToken(userID, created.ID); err != nil {
+		t.Fatalf("RevokeToken returned error: %v", err)
+	}
+	if _, _, err := service.Authenticate(created.Token); !errors.Is(err, ErrInvalidPersonalAccessToken) {
+		t.Errorf("Expected a revoked token to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+const (
+	// phoneVerificationCodeTTL is how long an SMS code stays valid
+	phoneVerificationCodeTTL = 10 * time.Minute
+	// maxPhoneVerificationsPerHour caps how many codes a single account can be sent
+	maxPhoneVerificationsPerHour = 3
+	// maxPhoneVerificationAttempts caps how many guesses a single code allows
+	maxPhoneVerificationAttempts = 5
+)
+
+// PhoneVerificationService confirms users' phone numbers with SMS codes
+type PhoneVerificationService struct {
+	userRepo  repository.UserRepository
+	codeRepo  repository.PhoneVerificationRepository
+	smsSender SMSSender
+}
+
+// NewPhoneVerificationService creates a new phone verification service
+func NewPhoneVerificationService(userRepo repository.UserRepository, codeRepo repository.PhoneVerificationRepository, smsSender SMSSender) *PhoneVerificationService {
+	return &PhoneVerificationService{
+		userRepo:  userRepo,
+		codeRepo:  codeRepo,
+		smsSender: smsSender,
+	}
+}
+
+// StartPhoneVerification sends a 6-digit code to the user's phone number.
+// Each new code replaces the previous one.
+func (s *PhoneVerificationService) StartPhoneVerification(userID uuid.UUID) error {
+	// Get user
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	if user.PhoneNumber == "" {
+		return ErrPhoneNumberMissing
+	}
+	if user.IsPhoneVerified() {
+		return ErrPhoneAlreadyVerified
+	}
+
+	// Rate-limit codes per account
+	count, err := s.codeRepo.CountRecentByUserID(userID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to count recent verification codes: %w", err)
+	}
+	if count >= maxPhoneVerificationsPerHour {
+		return ErrPhoneVerificationRateLimited
+	}
+
+	// Generate a random code; only its hash is stored
+	code, err := generateVerificationCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	verification := &models.PhoneVerificationCode{
+		ID:          uuid.New(),
+		UserID:      userID,
+		PhoneNumber: user.PhoneNumber,
+		ExpiresAt:   time.Now().Add(phoneVerificationCodeTTL),
+		CreatedAt:   time.Now(),
+	}
+	verification.CodeHash = hashVerificationCode(verification.ID, code)
+
+	if err := s.codeRepo.Create(verification); err != nil {
+		return fmt.Errorf("failed to save verification code: %w", err)
+	}
+
+	// Send the code
+	message := fmt.Sprintf("Your Microbank verification code is %s. It expires in 10 minutes.", code)
+	if err := s.smsSender.Send(user.PhoneNumber, message); err != nil {
+		return fmt.Errorf("%w: %w", ErrSMSDeliveryFailed, err)
+	}
+
+	return nil
+}
+
+// ConfirmPhoneVerification checks a code against the latest one sent to the
+// user and marks their phone number verified when it matches. Every check
+// counts against the code's attempt limit.
+func (s *PhoneVerificationService) ConfirmPhoneVerification(userID uuid.UUID, code string) (*models.User, error) {
+	// Get user
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	// Look up the latest code, which must be for the current number
+	verification, err := s.codeRepo.GetLatestByUserID(userID)
+	if err != nil || verification.IsUsed() || verification.PhoneNumber != user.PhoneNumber {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	if verification.IsExpired() {
+		return nil, ErrVerificationCodeExpired
+	}
+
+	attempts, err := s.codeRepo.IncrementAttempts(verification.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record verification attempt: %w", err)
+	}
+	if attempts > maxPhoneVerificationAttempts {
+		return nil, ErrVerificationAttemptsExceeded
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashVerificationCode(verification.ID, code)), []byte(verification.CodeHash)) != 1 {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	// Consume the code before applying the change so it cannot be replayed
+	if err := s.codeRepo.MarkUsed(verification.ID); err != nil {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	if err := s.userRepo.MarkPhoneVerified(userID, verification.PhoneNumber); err != nil {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	// Get updated user
+	user, err = s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// generateVerificationCode returns a random 6-digit code
+func generateVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashVerificationCode hashes a code for storage. The code's ID is mixed in
+// so identical codes never share a hash.
+func hashVerificationCode(id uuid.UUID, code string) string {
+	return hashToken(id.String() + ":" + code)
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"microbank/client-service/internal/models"
+)
+
+// smsCodePattern finds the verification code in a text message
+var smsCodePattern = regexp.MustCompile(`\b[0-9]{6}\b`)
+
+func newTestPhoneVerificationService(t *testing.T) (*PhoneVerificationService, *models.User, *fakeUserRepo, *fakePhoneVerificationRepo, *fakeSMSSender) {
+	t.Helper()
+	user := newTestUser(t, "password123")
+	user.PhoneNumber = "+27821234567"
+	userRepo := newFakeUserRepo(user)
+	codeRepo := &fakePhoneVerificationRepo{}
+	sender := &fakeSMSSender{}
+	return NewPhoneVerificationService(userRepo, codeRepo, sender), user, userRepo, codeRepo, sender
+}
+
+// sentCode returns the code from the latest text message
+func sentCode(t *testing.T, sender *fakeSMSSender) string {
+	t.Helper()
+	if len(sender.sent) == 0 {
+		t.Fatal("Expected a text message to be sent")
+	}
+	code := smsCodePattern.FindString(sender.sent[len(sender.sent)-1].message)
+	if code == "" {
+		t.Fatalf("text message does not contain a code: %q", sender.sent[len(sender.sent)-1].message)
+	}
+	return code
+}
+
+func TestPhoneVerificationService_StartAndConfirm(t *testing.T) {
+	svc, user, _, codeRepo, sender := newTestPhoneVerificationService(t)
+
+	if err := svc.StartPhoneVerification(user.ID); err != nil {
+		t.Fatalf("StartPhoneVerification returned error: %v", err)
+	}
+	if sender.sent[0].to != user.PhoneNumber {
+		t.Errorf("Expected the code to be sent to %s, got %s", user.PhoneNumber, sender.sent[0].to)
+	}
+	code := sentCode(t, sender)
+	if stored := codeRepo.codes[0]; stored.CodeHash == code || stored.CodeHash == hashToken(code) {
+		t.Error("Expected only a salted hash of the code to be stored")
+	}
+
+	verified, err := svc.ConfirmPhoneVerification(user.ID, code)
+	if err != nil {
+		t.Fatalf("ConfirmPhoneVerification returned error: %v", err)
+	}
+	if !verified.ToResponse().PhoneVerified {
+		t.Error("Expected the phone number to be verified")
+	}
+
+	if _, err := svc.ConfirmPhoneVerification(user.ID, code); !errors.Is(err, ErrInvalidVerificationCode) {
+		t.Errorf("Expected a used code to be rejected, got %v", err)
+	}
+	if err := svc.StartPhoneVerification(user.ID); !errors.Is(err, ErrPhoneAlreadyVerified) {
+		t.Errorf("Expected ErrPhoneAlreadyVerified, got %v", err)
+	}
+}
+
+func TestPhoneVerificationService_StartErrors(t *testing.T) {
+	t.Run("no phone number", func(t *testing.T) {
+		svc, user, _, _, _ := newTestPhoneVerificationService(t)
+		user.PhoneNumber = ""
+		if err := svc.StartPhoneVerification(user.ID); !errors.Is(err, ErrPhoneNumberMissing) {
+			t.Errorf("Expected ErrPhoneNumberMissing, got %v", err)
+		}
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		svc, user, _, _, sender := newTestPhoneVerificationService(t)
+		for i := 0; i < maxPhoneVerificationsPerHour; i++ {
+			if err := svc.StartPhoneVerification(user.ID); err != nil {
+				t.Fatalf("send %d returned error: %v", i+1, err)
+			}
+		}
+		if err := svc.StartPhoneVerification(user.ID); !errors.Is(err, ErrPhoneVerificationRateLimited) {
+			t.Errorf("Expected ErrPhoneVerificationRateLimited, got %v", err)
+		}
+		if len(sender.sent) != maxPhoneVerificationsPerHour {
+			t.Errorf("Expected %d messages, got %d", maxPhoneVerificationsPerHour, len(sender.sent))
+		}
+	})
+
+	t.Run("SMS gateway down", func(t *testing.T) {
+		svc, user, _, _, sender := newTestPhoneVerificationService(t)
+		sender.err = errors.New("gateway timeout")
+		if err := svc.StartPhoneVerification(user.ID); !errors.Is(err, ErrSMSDeliveryFailed) {
+			t.Errorf("Expected ErrSMSDeliveryFailed, got %v", err)
+		}
+	})
+}
+
+func TestPhoneVerificationService_ConfirmErrors(t *testing.T) {
+	t.Run("attempt limit", func(t *testing.T) {
+		svc, user, _, _, sender := newTestPhoneVerificationService(t)
+		svc.StartPhoneVerification(user.ID)
+		code := sentCode(t, sender)
+		wrong := "000000"
+		if code == wrong {
+			wrong = "111111"
+		}
+
+		for i := 0; i < maxPhoneVerificationAttempts; i++ {
+			if _, err := svc.ConfirmPhoneVerification(user.ID, wrong); !errors.Is(err, ErrInvalidVerificationCode) {
+				t.Fatalf("attempt %d: expected ErrInvalidVerificationCode, got %v", i+1, err)
+			}
+		}
+		if _, err := svc.ConfirmPhoneVerification(user.ID, code); !errors.Is(err, ErrVerificationAttemptsExceeded) {
+			t.Errorf("Expected the correct code to be refused after the limit, got %v", err)
+		}
+	})
+
+	t.Run("expired code", func(t *testing.T) {
+		svc, user, _, codeRepo, sender := newTestPhoneVerificationService(t)
+		svc.StartPhoneVerification(user.ID)
+		codeRepo.codes[0].ExpiresAt = time.Now().Add(-time.Minute)
+		if _, err := svc.ConfirmPhoneVerification(user.ID, sentCode(t, sender)); !errors.Is(err, ErrVerificationCodeExpired) {
+			t.Errorf("Expected ErrVerificationCodeExpired, got %v", err)
+		}
+	})
+
+	t.Run("number changed after sending", func(t *testing.T) {
+		svc, user, _, _, sender := newTestPhoneVerificationService(t)
+		svc.StartPhoneVerification(user.ID)
+		user.PhoneNumber = "+27831234567"
+		if _, err := svc.ConfirmPhoneVerification(user.ID, sentCode(t, sender)); !errors.Is(err, ErrInvalidVerificationCode) {
+			t.Errorf("Expected ErrInvalidVerificationCode, got %v", err)
+		}
+		if user.PhoneVerifiedAt != nil {
+			t.Error("Expected the new number to stay unverified")
+		}
+	})
+
+	t.Run("no code sent", func(t *testing.T) {
+		svc, user, _, _, _ := newTestPhoneVerificationService(t)
+		if _, err := svc.ConfirmPhoneVerification(user.ID, "123456"); !errors.Is(err, ErrInvalidVerificationCode) {
+			t.Errorf("Expected ErrInvalidVerificationCode, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"microbank/client-service/internal/models"
+)
+
+// MinimumAge is the youngest a user may be, in years
+const MinimumAge = 18
+
+// e164Pattern matches an E.164 phone number: a plus sign followed by up to
+// 15 digits, the first of which is not zero
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// normalizeProfile trims the supplied fields of a profile update in place
+// and upper-cases the country code, then validates them. All invalid fields
+// are reported together in a *ValidationError.
+func normalizeProfile(profile *models.UserProfile, now time.Time) error {
+	var fields []FieldError
+	invalid := func(field, rule, message string) {
+		fields = append(fields, FieldError{Field: field, Rule: rule, Message: message})
+	}
+
+	trim := func(value *string) {
+		if value != nil {
+			*value = strings.TrimSpace(*value)
+		}
+	}
+	trim(profile.Name)
+	trim(profile.PhoneNumber)
+	trim(profile.DateOfBirth)
+	trim(profile.TimeZone)
+
+	if profile.Name != nil {
+		if n := utf8.RuneCountInString(*profile.Name); n < 2 || n > 100 {
+			invalid("name", "length", "must be between 2 and 100 characters")
+		}
+	}
+
+	if profile.PhoneNumber != nil && *profile.PhoneNumber != "" && !e164Pattern.MatchString(*profile.PhoneNumber) {
+		invalid("phone_number", "e164", "must be in E.164 format, e.g. +27821234567")
+	}
+
+	if profile.DateOfBirth != nil && *profile.DateOfBirth != "" {
+		dob, err := time.Parse(models.DateLayout, *profile.DateOfBirth)
+		switch {
+		case err != nil:
+			invalid("date_of_birth", "format", "must be a date in YYYY-MM-DD format")
+		case dob.After(now):
+			invalid("date_of_birth", "past", "must be in the past")
+		case ageOn(dob, now) < MinimumAge:
+			invalid("date_of_birth", "minimum_age", "you must be at least 18 years old")
+		}
+	}
+
+	if profile.TimeZone != nil && *profile.TimeZone != "" && !isTimeZone(*profile.TimeZone) {
+		invalid("timezone", "iana", "must be an IANA time zone, e.g. Africa/Johannesburg")
+	}
+
+	if address := profile.Address; address != nil {
+		trim(address.Line1)
+		trim(address.City)
+		trim(address.Country)
+
+		if address.Line1 != nil && utf8.RuneCountInString(*address.Line1) > 255 {
+			invalid("address.line1", "length", "must be at most 255 characters")
+		}
+		if address.City != nil && utf8.RuneCountInString(*address.City) > 100 {
+			invalid("address.city", "length", "must be at most 100 characters")
+		}
+		if address.Country != nil && *address.Country != "" {
+			*address.Country = strings.ToUpper(*address.Country)
+			if !isCountryCode(*address.Country) {
+				invalid("address.country", "iso3166", "must be an ISO 3166-1 alpha-2 country code, e.g. ZA")
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// ageOn returns how many full years old someone born on dob is at now
+func ageOn(dob, now time.Time) int {
+	age := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// isTimeZone reports whether name is an IANA time zone. "Local" is the
+// server's zone rather than a place, so it is refused.
+func isTimeZone(name string) bool {
+	if name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// isCountryCode reports whether code is an officially assigned ISO 3166-1
+// alpha-2 country code
+func isCountryCode(code string) bool {
+	return len(code) == 2 && strings.Contains(countryCodes, " "+code+" ")
+}
+
+// countryCodes lists the officially assigned ISO 3166-1 alpha-2 codes,
+// space-delimited so lookups match whole codes only
+const countryCodes = " AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ " +
+	"BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ " +
+	"CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ " +
+	"DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR " +
+	"GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY " +
+	"HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP " +
+	"KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY " +
+	"MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ " +
+	"NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW PY " +
+	"QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ " +
+	"TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY UZ " +
+	"VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW "
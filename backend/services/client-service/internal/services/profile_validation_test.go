@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"microbank/client-service/internal/models"
+)
+
+func TestNormalizeProfile(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name       string
+		profile    models.UserProfile
+		wantFields []string
+	}{
+		{name: "empty update", profile: models.UserProfile{}},
+		{name: "valid fields", profile: models.UserProfile{
+			Name:        str("Andile Mbele"),
+			PhoneNumber: str("+27821234567"),
+			DateOfBirth: str("2008-06-15"),
+			Address:     &models.AddressProfile{Line1: str("1 Long Street"), City: str("Cape Town"), Country: str("za")},
+			TimeZone:    str("Africa/Johannesburg"),
+		}},
+		{name: "clearing optional fields", profile: models.UserProfile{PhoneNumber: str(""), DateOfBirth: str(""), Address: &models.AddressProfile{Country: str("")}, TimeZone: str("")}},
+		{name: "short name", profile: models.UserProfile{Name: str(" A ")}, wantFields: []string{"name"}},
+		{name: "local phone number", profile: models.UserProfile{PhoneNumber: str("0821234567")}, wantFields: []string{"phone_number"}},
+		{name: "malformed date", profile: models.UserProfile{DateOfBirth: str("15/06/2000")}, wantFields: []string{"date_of_birth"}},
+		{name: "future date", profile: models.UserProfile{DateOfBirth: str("2030-01-01")}, wantFields: []string{"date_of_birth"}},
+		{name: "day before 18th birthday", profile: models.UserProfile{DateOfBirth: str("2008-06-16")}, wantFields: []string{"date_of_birth"}},
+		{name: "unknown time zone", profile: models.UserProfile{TimeZone: str("Africa/Atlantis")}, wantFields: []string{"timezone"}},
+		{name: "server's time zone", profile: models.UserProfile{TimeZone: str("Local")}, wantFields: []string{"timezone"}},
+		{name: "offset instead of time zone", profile: models.UserProfile{TimeZone: str("+02:00")}, wantFields: []string{"timezone"}},
+		{name: "unknown country", profile: models.UserProfile{Address: &models.AddressProfile{Country: str("XX")}}, wantFields: []string{"address.country"}},
+		{name: "several invalid fields", profile: models.UserProfile{Name: str(""), PhoneNumber: str("+0123"), Address: &models.AddressProfile{Country: str("ZAF")}}, wantFields: []string{"name", "phone_number", "address.country"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := normalizeProfile(&tt.profile, now)
+
+			var validationErr *ValidationError
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected a validation error, got %v", err)
+			}
+			if len(validationErr.Fields) != len(tt.wantFields) {
+				t.Fatalf("Expected invalid fields %v, got %+v", tt.wantFields, validationErr.Fields)
+			}
+			for i, field := range tt.wantFields {
+				if validationErr.Fields[i].Field != field {
+					t.Errorf("Expected invalid field %q, got %q", field, validationErr.Fields[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeProfile_NormalizesValues(t *testing.T) {
+	name, country := "  Andile Mbele  ", "za"
+	profile := models.UserProfile{Name: &name, Address: &models.AddressProfile{Country: &country}}
+
+	if err := normalizeProfile(&profile, time.Now()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if *profile.Name != "Andile Mbele" {
+		t.Errorf("Expected the name to be trimmed, got %q", *profile.Name)
+	}
+	if *profile.Address.Country != "ZA" {
+		t.Errorf("Expected the country to be upper-cased, got %q", *profile.Address.Country)
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/events"
+	"microbank/pkg/mailer"
+)
+
+// SavingsGoalEventConsumer tells users about savings goal events published
+// by the banking service
+type SavingsGoalEventConsumer struct {
+	userRepo    repository.UserRepository
+	emailSender mailer.EmailSender
+	preferences *NotificationPreferenceService
+}
+
+// NewSavingsGoalEventConsumer creates a new savings goal event consumer
+func NewSavingsGoalEventConsumer(userRepo repository.UserRepository, emailSender mailer.EmailSender, preferences *NotificationPreferenceService) *SavingsGoalEventConsumer {
+	return &SavingsGoalEventConsumer{
+		userRepo:    userRepo,
+		emailSender: emailSender,
+		preferences: preferences,
+	}
+}
+
+// Handle applies the event and reports whether this service acted on its
+// type; other types are accepted and ignored. A completed goal is emailed
+// to its owner unless they have turned goal_completed emails off. An email
+// that fails to send is returned as an error so the event is retried.
+func (c *SavingsGoalEventConsumer) Handle(event events.Event) (bool, error) {
+	if event.Type != events.TypeSavingsGoalCompleted {
+		return false, nil
+	}
+
+	var payload events.SavingsGoalCompleted
+	if err := events.Decode(event, &payload); err != nil {
+		return false, err
+	}
+
+	user, err := c.userRepo.GetUserByID(payload.UserID)
+	if err != nil {
+		// The user may have been deleted since the goal was completed
+		log.Printf("Not notifying user %s of completed savings goal %s: %v", payload.UserID, payload.GoalID, err)
+		return true, nil
+	}
+
+	channels, err := c.preferences.ChannelsFor(user.ID, models.NotificationEventGoalCompleted)
+	if err != nil {
+		log.Printf("Failed to load notification preferences for user %s, using defaults: %v", user.ID, err)
+		channels = models.DefaultNotificationChannels(models.NotificationEventGoalCompleted)
+	}
+	if !channels.Email {
+		return true, nil
+	}
+
+	data := map[string]string{
+		"Name":         user.Name,
+		"GoalName":     payload.Name,
+		"TargetAmount": fmt.Sprintf("%.2f", payload.TargetAmount),
+		"Link":         savingsGoalsURL(),
+	}
+	if err := sendEmail(c.emailSender, user.Email, user.ID, "savings_goal_completed", data); err != nil {
+		return false, fmt.Errorf("failed to email completed savings goal %s: %w", payload.GoalID, err)
+	}
+
+	return true, nil
+}
+
+// savingsGoalsURL returns the frontend page that lists a user's savings
+// goals
+func savingsGoalsURL() string {
+	if url := os.Getenv("SAVINGS_GOALS_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000/goals"
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/events"
+)
+
+func TestSavingsGoalEventConsumer_EmailsCompletedGoals(t *testing.T) {
+	user := newTestUser(t, "password123")
+	sender := &fakeEmailSender{}
+	preferences := NewNotificationPreferenceService(&fakeNotificationPreferenceRepo{})
+	consumer := NewSavingsGoalEventConsumer(newFakeUserRepo(user), sender, preferences)
+
+	completed, err := events.New(events.TypeSavingsGoalCompleted, events.SourceBankingService, events.SavingsGoalCompleted{
+		GoalID:       uuid.New(),
+		UserID:       user.ID,
+		Name:         "New laptop",
+		TargetAmount: 1200,
+		CompletedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("events.New returned error: %v", err)
+	}
+
+	handled, err := consumer.Handle(completed)
+	if err != nil || !handled {
+		t.Fatalf("Expected the event to be handled, got %v (%v)", handled, err)
+	}
+	email, ok := sender.last()
+	if !ok {
+		t.Fatal("Expected a goal completed email")
+	}
+	if email.to != user.Email || !strings.Contains(email.subject, "savings goal") {
+		t.Errorf("Expected a savings goal email to %s, got %q to %s", user.Email, email.subject, email.to)
+	}
+	for _, want := range []string{"New laptop", "1200.00"} {
+		if !strings.Contains(email.body, want) {
+			t.Errorf("Expected the email to mention %q:\n%s", want, email.body)
+		}
+	}
+
+	// Users who turn the emails off get none
+	off := false
+	if _, err := preferences.UpdatePreferences(user.ID, models.NotificationPreferencesUpdate{
+		Preferences: map[string]models.NotificationChannelsUpdate{models.NotificationEventGoalCompleted: {Email: &off}},
+	}); err != nil {
+		t.Fatalf("UpdatePreferences returned error: %v", err)
+	}
+	if handled, err := consumer.Handle(completed); err != nil || !handled {
+		t.Fatalf("Expected the event to be handled, got %v (%v)", handled, err)
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("Expected no email with goal_completed turned off, got %d emails", len(sender.sent))
+	}
+
+	other, err := events.New(events.TypeUserRegistered, events.SourceClientService, events.UserRegistered{UserID: user.ID})
+	if err != nil {
+		t.Fatalf("events.New returned error: %v", err)
+	}
+	if handled, err := consumer.Handle(other); err != nil || handled {
+		t.Errorf("Expected other event types to be ignored, got %v (%v)", handled, err)
+	}
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/mailer"
+)
+
+// Statement delivery tuning
+const (
+	// statementBatchSize bounds how many subscriptions each scheduling
+	// query and deliveries each claim returns
+	statementBatchSize = 100
+	// statementDeliveryLease is how long a claimed delivery is kept from
+	// other replicas while it is sent
+	statementDeliveryLease = 10 * time.Minute
+	// statementRetryDelay is the wait after the first failed attempt,
+	// doubled after each further one
+	statementRetryDelay = 15 * time.Minute
+	// DefaultStatementMaxAttempts is how many times a statement is tried
+	// before its delivery is marked failed
+	DefaultStatementMaxAttempts = 5
+)
+
+// StatementService emails users who subscribe their monthly statement on
+// their chosen day of the following month. Statements are read from the
+// banking-service. Each user has at most one delivery per month, which
+// records whether it was sent, skipped or failed, so a statement is never
+// sent twice; failed attempts are retried with backoff.
+type StatementService struct {
+	repo          repository.StatementRepository
+	userRepo      repository.UserRepository
+	bankingClient BankingClient
+	emailSender   mailer.EmailSender
+	preferences   *NotificationPreferenceService
+	skipInactive  bool
+	maxAttempts   int
+	now           func() time.Time
+}
+
+// NewStatementService creates a new statement service. Statements of
+// months without transactions are skipped unless WithSkipInactive turns
+// that off. emailSender should deliver before returning, rather than
+// queue, so failures are seen and retried.
+func NewStatementService(repo repository.StatementRepository, userRepo repository.UserRepository, bankingClient BankingClient, emailSender mailer.EmailSender, preferences *NotificationPreferenceService) *StatementService {
+	return &StatementService{
+		repo:          repo,
+		userRepo:      userRepo,
+		bankingClient: bankingClient,
+		emailSender:   emailSender,
+		preferences:   preferences,
+		skipInactive:  true,
+		maxAttempts:   DefaultStatementMaxAttempts,
+		now:           time.Now,
+	}
+}
+
+// WithSkipInactive sets whether scheduled statements of months without
+// transactions are skipped rather than sent
+func (s *StatementService) WithSkipInactive(skip bool) *StatementService {
+	s.skipInactive = skip
+	return s
+}
+
+// GetSubscription returns a user's subscription, or
+// ErrStatementSubscriptionNotFound when they have none
+func (s *StatementService) GetSubscription(userID uuid.UUID) (*models.StatementSubscription, error) {
+	subscription, err := s.repo.GetSubscription(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStatementSubscriptionNotFound, err)
+	}
+	return subscription, nil
+}
+
+// Subscribe opts a user in to statement emails on the requested day of
+// each month, or changes the day of their subscription
+func (s *StatementService) Subscribe(userID uuid.UUID, request models.StatementSubscriptionRequest) (*models.StatementSubscription, error) {
+	if request.DeliveryDay < models.MinStatementDeliveryDay || request.DeliveryDay > models.MaxStatementDeliveryDay {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "delivery_day", Rule: "max", Message: "must be between 1 and 28"}}}
+	}
+
+	subscription := &models.StatementSubscription{UserID: userID, DeliveryDay: request.DeliveryDay}
+	if err := s.repo.SaveSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// Unsubscribe opts a user out of statement emails, returning
+// ErrStatementSubscriptionNotFound when they were not subscribed
+func (s *StatementService) Unsubscribe(userID uuid.UUID) error {
+	deleted, err := s.repo.DeleteSubscription(userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrStatementSubscriptionNotFound
+	}
+	return nil
+}
+
+// ScheduleDue makes a pending delivery of last month's statement for each
+// subscriber whose delivery day has come and who has none yet, returning
+// how many it made. Months are calendar months in UTC.
+func (s *StatementService) ScheduleDue() (int, error) {
+	now := s.now().UTC()
+	period := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format(models.StatementPeriodLayout)
+
+	scheduled := 0
+	for {
+		subscriptions, err := s.repo.ListDueSubscriptions(period, now.Day(), statementBatchSize)
+		if err != nil {
+			return scheduled, fmt.Errorf("failed to list due statement subscriptions: %w", err)
+		}
+
+		for _, subscription := range subscriptions {
+			created, err := s.repo.CreateDelivery(&models.StatementDelivery{
+				ID:            uuid.New(),
+				UserID:        subscription.UserID,
+				Period:        period,
+				Status:        models.StatementDeliveryPending,
+				NextAttemptAt: &now,
+				CreatedAt:     now,
+			})
+			if err != nil {
+				return scheduled, err
+			}
+			if created {
+				scheduled++
+			}
+		}
+
+		if len(subscriptions) < statementBatchSize {
+			return scheduled, nil
+		}
+	}
+}
+
+// DeliverDue sends the pending statements whose next attempt is due,
+// returning how many were sent. Failures are recorded on their delivery
+// and retried later, so only errors reading or writing deliveries are
+// returned.
+func (s *StatementService) DeliverDue(ctx context.Context) (int, error) {
+	sent := 0
+	for ctx.Err() == nil {
+		deliveries, err := s.repo.ClaimDueDeliveries(s.now(), statementDeliveryLease, statementBatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for i := range deliveries {
+			if err := s.deliver(&deliveries[i], true); err != nil {
+				return sent, err
+			}
+			if deliveries[i].Status == models.StatementDeliverySent {
+				sent++
+			}
+		}
+
+		if len(deliveries) < statementBatchSize {
+			break
+		}
+	}
+	return sent, nil
+}
+
+// SendNow sends a user's statement for period, a past month in YYYY-MM
+// form, at an admin's request, whether or not the user subscribes, turned
+// statement emails off or had any transactions. It returns the delivery
+// with the outcome of the attempt. Statements already sent for the period
+// are ErrStatementAlreadySent, so asking again never sends a second copy.
+func (s *StatementService) SendNow(userID uuid.UUID, period string, requestedBy uuid.UUID) (*models.StatementDelivery, error) {
+	start, err := time.Parse(models.StatementPeriodLayout, period)
+	now := s.now().UTC()
+	if err != nil || !start.Before(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)) {
+		return nil, ErrInvalidStatementPeriod
+	}
+	if _, err := s.userRepo.GetUserByID(userID); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	if _, err := s.repo.CreateDelivery(&models.StatementDelivery{
+		ID:            uuid.New(),
+		UserID:        userID,
+		Period:        period,
+		Status:        models.StatementDeliveryPending,
+		NextAttemptAt: &now,
+		RequestedBy:   &requestedBy,
+		CreatedAt:     now,
+	}); err != nil {
+		return nil, err
+	}
+	delivery, err := s.repo.GetDelivery(userID, period)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status == models.StatementDeliverySent {
+		return delivery, ErrStatementAlreadySent
+	}
+
+	claimed, err := s.repo.ClaimDelivery(delivery.ID, requestedBy, now, statementDeliveryLease)
+	if err != nil {
+		return nil, err
+	}
+	if claimed == nil {
+		// Sent, or being sent, since it was read
+		return delivery, ErrStatementDeliveryInProgress
+	}
+
+	if err := s.deliver(claimed, false); err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// deliver makes one attempt at a claimed delivery and records its outcome.
+// Statements of users who have since been deleted are skipped. Scheduled
+// ones are also skipped when the user turned statement emails off, or when
+// the month had no transactions and skipInactive is set. Failed attempts
+// are retried after a delay that doubles each time, until the delivery has
+// used up its attempts and is marked failed.
+func (s *StatementService) deliver(delivery *models.StatementDelivery, scheduled bool) error {
+	delivery.Attempts++
+	delivery.LastError = ""
+	delivery.NextAttemptAt = nil
+
+	err := s.send(delivery, scheduled)
+	switch {
+	case delivery.Status == models.StatementDeliverySkipped:
+		log.Printf("Skipped statement %s for user %s: %s", delivery.Period, delivery.UserID, delivery.LastError)
+	case err == nil:
+		sentAt := s.now()
+		delivery.Status = models.StatementDeliverySent
+		delivery.SentAt = &sentAt
+	case delivery.Attempts >= s.maxAttempts:
+		delivery.Status = models.StatementDeliveryFailed
+		delivery.LastError = err.Error()
+		log.Printf("Gave up on statement %s for user %s after %d attempts: %v", delivery.Period, delivery.UserID, delivery.Attempts, err)
+	default:
+		retryAt := s.now().Add(statementRetryDelay << (delivery.Attempts - 1))
+		delivery.Status = models.StatementDeliveryPending
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = &retryAt
+		log.Printf("Statement %s for user %s failed, retrying at %s: %v", delivery.Period, delivery.UserID, retryAt.Format(time.RFC3339), err)
+	}
+
+	return s.repo.UpdateDelivery(delivery)
+}
+
+// send reads a delivery's statement and emails it, or marks the delivery
+// skipped when there is nothing to send
+func (s *StatementService) send(delivery *models.StatementDelivery, scheduled bool) error {
+	user, err := s.userRepo.GetUserByID(delivery.UserID)
+	if err != nil {
+		delivery.Status = models.StatementDeliverySkipped
+		delivery.LastError = "user not found"
+		return nil
+	}
+
+	if scheduled {
+		channels, err := s.preferences.ChannelsFor(user.ID, models.NotificationEventStatementReady)
+		if err != nil {
+			log.Printf("Failed to load notification preferences for user %s, using defaults: %v", user.ID, err)
+			channels = models.DefaultNotificationChannels(models.NotificationEventStatementReady)
+		}
+		if !channels.Email {
+			delivery.Status = models.StatementDeliverySkipped
+			delivery.LastError = "statement emails turned off"
+			return nil
+		}
+	}
+
+	statement, err := s.bankingClient.GetStatement(delivery.UserID, delivery.Period)
+	if err != nil {
+		return fmt.Errorf("failed to get statement: %w", err)
+	}
+	if scheduled && s.skipInactive && len(statement.Transactions) == 0 {
+		delivery.Status = models.StatementDeliverySkipped
+		delivery.LastError = "no transactions in the period"
+		return nil
+	}
+
+	if err := sendEmail(s.emailSender, user.Email, user.ID, "statement", statementEmailData(user, statement)); err != nil {
+		return fmt.Errorf("failed to email statement: %w", err)
+	}
+	return nil
+}
+
+// statementEmailData formats a statement for the statement email. Money
+// leaving the account is shown negative; round-ups, which leave the
+// balance as it is, are shown unsigned.
+func statementEmailData(user *models.User, statement *models.Statement) map[string]any {
+	transactions := make([]map[string]string, len(statement.Transactions))
+	for i, transaction := range statement.Transactions {
+		amount := fmt.Sprintf("%.2f", transaction.Amount)
+		switch transaction.Type {
+		case "deposit", "refund", "transfer_in":
+			amount = "+" + amount
+		case "withdrawal", "fee", "transfer_out", "external_transfer", "cheque_return":
+			amount = "-" + amount
+		}
+		description := transaction.Description
+		if description == "" {
+			description = transaction.Type
+		}
+		transactions[i] = map[string]string{
+			"Date":        transaction.CreatedAt.UTC().Format("2 Jan"),
+			"Description": description,
+			"Amount":      amount,
+			"Balance":     fmt.Sprintf("%.2f", transaction.BalanceAfter),
+		}
+	}
+
+	return map[string]any{
+		"Name":           user.Name,
+		"Month":          statement.PeriodStart.UTC().Format("January 2006"),
+		"Currency":       statement.Currency,
+		"OpeningBalance": fmt.Sprintf("%.2f", statement.OpeningBalance),
+		"ClosingBalance": fmt.Sprintf("%.2f", statement.ClosingBalance),
+		"MoneyIn":        fmt.Sprintf("%.2f", statement.MoneyIn),
+		"MoneyOut":       fmt.Sprintf("%.2f", statement.MoneyOut),
+		"Transactions":   transactions,
+		"Link":           statementsURL(),
+	}
+}
+
+// statementsURL returns the frontend page that lists a user's statements
+func statementsURL() string {
+	if url := os.Getenv("STATEMENTS_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000/statements"
+}
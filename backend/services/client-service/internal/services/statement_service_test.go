@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+// fakeStatementRepo is an in-memory StatementRepository
+type fakeStatementRepo struct {
+	repository.StatementRepository
+	mu            sync.Mutex
+	subscriptions map[uuid.UUID]models.StatementSubscription
+	deliveries    []*models.StatementDelivery
+}
+
+func newFakeStatementRepo() *fakeStatementRepo {
+	return &fakeStatementRepo{subscriptions: make(map[uuid.UUID]models.StatementSubscription)}
+}
+
+func (r *fakeStatementRepo) GetSubscription(userID uuid.UUID) (*models.StatementSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription, ok := r.subscriptions[userID]
+	if !ok {
+		return nil, fmt.Errorf("statement subscription not found")
+	}
+	return &subscription, nil
+}
+
+func (r *fakeStatementRepo) SaveSubscription(subscription *models.StatementSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription.UpdatedAt = time.Now()
+	subscription.CreatedAt = subscription.UpdatedAt
+	if existing, ok := r.subscriptions[subscription.UserID]; ok {
+		subscription.CreatedAt = existing.CreatedAt
+	}
+	r.subscriptions[subscription.UserID] = *subscription
+	return nil
+}
+
+func (r *fakeStatementRepo) DeleteSubscription(userID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.subscriptions[userID]
+	delete(r.subscriptions, userID)
+	return ok, nil
+}
+
+func (r *fakeStatementRepo) ListDueSubscriptions(period string, day, limit int) ([]models.StatementSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []models.StatementSubscription
+	for _, subscription := range r.subscriptions {
+		if subscription.DeliveryDay <= day && r.find(subscription.UserID, period) == nil {
+			due = append(due, subscription)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].UserID.String() < due[j].UserID.String() })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *fakeStatementRepo) CreateDelivery(delivery *models.StatementDelivery) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.find(delivery.UserID, delivery.Period) != nil {
+		return false, nil
+	}
+	clone := *delivery
+	r.deliveries = append(r.deliveries, &clone)
+	return true, nil
+}
+
+func (r *fakeStatementRepo) GetDelivery(userID uuid.UUID, period string) (*models.StatementDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery := r.find(userID, period)
+	if delivery == nil {
+		return nil, fmt.Errorf("statement delivery not found")
+	}
+	clone := *delivery
+	return &clone, nil
+}
+
+func (r *fakeStatementRepo) ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.StatementDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claimed []models.StatementDelivery
+	for _, delivery := range r.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if (delivery.Status == models.StatementDeliveryPending || delivery.Status == models.StatementDeliverySending) && !delivery.NextAttemptAt.After(now) {
+			leaseEnd := now.Add(lease)
+			delivery.Status = models.StatementDeliverySending
+			delivery.NextAttemptAt = &leaseEnd
+			claimed = append(claimed, *delivery)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *fakeStatementRepo) ClaimDelivery(id uuid.UUID, requestedBy uuid.UUID, now time.Time, lease time.Duration) (*models.StatementDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range r.deliveries {
+		if delivery.ID != id {
+			continue
+		}
+		if delivery.Status == models.StatementDeliverySent ||
+			(delivery.Status == models.StatementDeliverySending && delivery.NextAttemptAt.After(now)) {
+			return nil, nil
+		}
+		leaseEnd := now.Add(lease)
+		delivery.Status = models.StatementDeliverySending
+		delivery.NextAttemptAt = &leaseEnd
+		delivery.RequestedBy = &requestedBy
+		clone := *delivery
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeStatementRepo) UpdateDelivery(delivery *models.StatementDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.deliveries {
+		if existing.ID == delivery.ID {
+			clone := *delivery
+			r.deliveries[i] = &clone
+			return nil
+		}
+	}
+	return fmt.Errorf("statement delivery not found")
+}
+
+// find returns the delivery of a user's statement for period; callers hold
+// the lock
+func (r *fakeStatementRepo) find(userID uuid.UUID, period string) *models.StatementDelivery {
+	for _, delivery := range r.deliveries {
+		if delivery.UserID == userID && delivery.Period == period {
+			return delivery
+		}
+	}
+	return nil
+}
+
+func newTestStatementService(t *testing.T, now time.Time) (*StatementService, *models.User, *fakeStatementRepo, *fakeBankingClient, *fakeEmailSender) {
+	t.Helper()
+	user := newTestUser(t, "password123")
+	repo := newFakeStatementRepo()
+	banking := &fakeBankingClient{statements: map[string]*models.Statement{
+		"2026-09": {
+			Period:         "2026-09",
+			PeriodStart:    time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC),
+			Currency:       "USD",
+			OpeningBalance: 100,
+			ClosingBalance: 120.25,
+			MoneyIn:        50.25,
+			MoneyOut:       30,
+			Transactions: []models.StatementTransaction{
+				{ID: uuid.New(), Type: "deposit", Amount: 50.25, BalanceAfter: 150.25, Description: "Salary", CreatedAt: time.Date(2026, time.September, 3, 9, 0, 0, 0, time.UTC)},
+				{ID: uuid.New(), Type: "withdrawal", Amount: 30, BalanceAfter: 120.25, CreatedAt: time.Date(2026, time.September, 10, 12, 0, 0, 0, time.UTC)},
+			},
+		},
+		"2026-08": {Period: "2026-08", PeriodStart: time.Date(2026, time.August, 1, 0, 0, 0, 0, time.UTC), Currency: "USD", OpeningBalance: 100, ClosingBalance: 100},
+	}}
+	sender := &fakeEmailSender{}
+	svc := NewStatementService(repo, newFakeUserRepo(user), banking, sender, NewNotificationPreferenceService(&fakeNotificationPreferenceRepo{}))
+	svc.now = func() time.Time { return now }
+	return svc, user, repo, banking, sender
+}
+
+func TestStatementService_Subscribe(t *testing.T) {
+	svc, user, _, _, _ := newTestStatementService(t, time.Now())
+
+	if _, err := svc.GetSubscription(user.ID); !errors.Is(err, ErrStatementSubscriptionNotFound) {
+		t.Errorf("Expected ErrStatementSubscriptionNotFound before subscribing, got %v", err)
+	}
+
+	var validationErr *ValidationError
+	if _, err := svc.Subscribe(user.ID, models.StatementSubscriptionRequest{DeliveryDay: 29}); !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error for day 29, got %v", err)
+	}
+
+	if _, err := svc.Subscribe(user.ID, models.StatementSubscriptionRequest{DeliveryDay: 5}); err != nil {
+		t.Fatalf("Subscribe returned error: %v", err)
+	}
+	subscription, err := svc.Subscribe(user.ID, models.StatementSubscriptionRequest{DeliveryDay: 12})
+	if err != nil {
+		t.Fatalf("Subscribe returned error: %v", err)
+	}
+	if subscription.DeliveryDay != 12 {
+		t.Errorf("Expected the delivery day to change to 12, got %d", subscription.DeliveryDay)
+	}
+
+	if err := svc.Unsubscribe(user.ID); err != nil {
+		t.Fatalf("Unsubscribe returned error: %v", err)
+	}
+	if err := svc.Unsubscribe(user.ID); !errors.Is(err, ErrStatementSubscriptionNotFound) {
+		t.Errorf("Expected ErrStatementSubscriptionNotFound unsubscribing twice, got %v", err)
+	}
+}
+
+func TestStatementService_ScheduleAndDeliver(t *testing.T) {
+	now := time.Date(2026, time.October, 5, 6, 0, 0, 0, time.UTC)
+	svc, user, repo, _, sender := newTestStatementService(t, now)
+	late := uuid.New()
+	repo.subscriptions[user.ID] = models.StatementSubscription{UserID: user.ID, DeliveryDay: 5}
+	repo.subscriptions[late] = models.StatementSubscription{UserID: late, DeliveryDay: 6}
+
+	scheduled, err := svc.ScheduleDue()
+	if err != nil {
+		t.Fatalf("ScheduleDue returned error: %v", err)
+	}
+	if scheduled != 1 {
+		t.Fatalf("Expected only the subscription due today to be scheduled, got %d", scheduled)
+	}
+	if scheduled, _ := svc.ScheduleDue(); scheduled != 0 {
+		t.Errorf("Expected scheduling again to make no deliveries, got %d", scheduled)
+	}
+
+	sent, err := svc.DeliverDue(context.Background())
+	if err != nil {
+		t.Fatalf("DeliverDue returned error: %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("Expected 1 statement sent, got %d", sent)
+	}
+	email, _ := sender.last()
+	if email.to != user.Email || !strings.Contains(email.subject, "September 2026") {
+		t.Errorf("Unexpected statement email to %s: %s", email.to, email.subject)
+	}
+	for _, want := range []string{"120.25", "+50.25", "-30.00", "Salary", "3 Sep"} {
+		if !strings.Contains(email.body, want) {
+			t.Errorf("Expected the statement to mention %q:\n%s", want, email.body)
+		}
+	}
+	delivery, _ := repo.GetDelivery(user.ID, "2026-09")
+	if delivery.Status != models.StatementDeliverySent || delivery.Attempts != 1 || delivery.SentAt == nil {
+		t.Errorf("Expected the delivery to be sent on the first attempt, got %+v", delivery)
+	}
+
+	// Delivered statements are never sent again
+	if sent, _ := svc.DeliverDue(context.Background()); sent != 0 || len(sender.sent) != 1 {
+		t.Errorf("Expected no second email, got %d sent", len(sender.sent))
+	}
+}
+
+func TestStatementService_SkipsInactiveMonths(t *testing.T) {
+	now := time.Date(2026, time.September, 1, 6, 0, 0, 0, time.UTC)
+	svc, user, repo, _, sender := newTestStatementService(t, now)
+	repo.subscriptions[user.ID] = models.StatementSubscription{UserID: user.ID, DeliveryDay: 1}
+
+	svc.ScheduleDue()
+	if _, err := svc.DeliverDue(context.Background()); err != nil {
+		t.Fatalf("DeliverDue returned error: %v", err)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("Expected no email for a month without transactions, got %d", len(sender.sent))
+	}
+	delivery, _ := repo.GetDelivery(user.ID, "2026-08")
+	if delivery.Status != models.StatementDeliverySkipped {
+		t.Errorf("Expected the delivery to be skipped, got %s", delivery.Status)
+	}
+
+	// With skipping turned off the empty statement goes out
+	svc, user, repo, _, sender = newTestStatementService(t, now)
+	svc.WithSkipInactive(false)
+	repo.subscriptions[user.ID] = models.StatementSubscription{UserID: user.ID, DeliveryDay: 1}
+	svc.ScheduleDue()
+	svc.DeliverDue(context.Background())
+	email, ok := sender.last()
+	if !ok || !strings.Contains(email.body, "no transactions") {
+		t.Errorf("Expected an empty statement to be sent, got %+v", email)
+	}
+}
+
+func TestStatementService_RespectsPreferences(t *testing.T) {
+	now := time.Date(2026, time.October, 1, 6, 0, 0, 0, time.UTC)
+	svc, user, repo, _, sender := newTestStatementService(t, now)
+	repo.subscriptions[user.ID] = models.StatementSubscription{UserID: user.ID, DeliveryDay: 1}
+
+	off := false
+	if _, err := svc.preferences.UpdatePreferences(user.ID, models.NotificationPreferencesUpdate{
+		Preferences: map[string]models.NotificationChannelsUpdate{models.NotificationEventStatementReady: {Email: &off}},
+	}); err != nil {
+		t.Fatalf("UpdatePreferences returned error: %v", err)
+	}
+
+	svc.ScheduleDue()
+	svc.DeliverDue(context.Background())
+	if len(sender.sent) != 0 {
+		t.Errorf("Expected no email with statement emails turned off, got %d", len(sender.sent))
+	}
+	delivery, _ := repo.GetDelivery(user.ID, "2026-09")
+	if delivery.Status != models.StatementDeliverySkipped {
+		t.Errorf("Expected the delivery to be skipped, got %s", delivery.Status)
+	}
+}
+
+func TestStatementService_RetriesWithBackoff(t *testing.T) {
+	now := time.Date(2026, time.October, 1, 6, 0, 0, 0, time.UTC)
+	svc, user, repo, banking, sender := newTestStatementService(t, now)
+	svc.now = func() time.Time { return now }
+	svc.maxAttempts = 3
+	repo.subscriptions[user.ID] = models.StatementSubscription{UserID: user.ID, DeliveryDay: 1}
+	banking.err = errors.New("banking service unavailable")
+	svc.ScheduleDue()
+
+	delays := []time.Duration{statementRetryDelay, 2 * statementRetryDelay}
+	for i, delay := range delays {
+		svc.DeliverDue(context.Background())
+		delivery, _ := repo.GetDelivery(user.ID, "2026-09")
+		if delivery.Status != models.StatementDeliveryPending || delivery.Attempts != i+1 || !strings.Contains(delivery.LastError, "unavailable") {
+			t.Fatalf("Attempt %d: expected a pending retry, got %+v", i+1, delivery)
+		}
+		if want := now.Add(delay); !delivery.NextAttemptAt.Equal(want) {
+			t.Fatalf("Attempt %d: expected a retry at %s, got %s", i+1, want, delivery.NextAttemptAt)
+		}
+
+		// Not retried before it is due
+		svc.DeliverDue(context.Background())
+		if again, _ := repo.GetDelivery(user.ID, "2026-09"); again.Attempts != i+1 {
+			t.Fatalf("Attempt %d: expected no early retry, got %d attempts", i+1, again.Attempts)
+		}
+		now = now.Add(delay)
+	}
+
+	svc.DeliverDue(context.Background())
+	delivery, _ := repo.GetDelivery(user.ID, "2026-09")
+	if delivery.Status != models.StatementDeliveryFailed || delivery.Attempts != 3 || delivery.NextAttemptAt != nil {
+		t.Errorf("Expected the delivery to fail after 3 attempts, got %+v", delivery)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("Expected no email, got %d", len(sender.sent))
+	}
+}
+
+func TestStatementService_SendNow(t *testing.T) {
+	now := time.Date(2026, time.October, 1, 6, 0, 0, 0, time.UTC)
+	svc, user, repo, banking, sender := newTestStatementService(t, now)
+	admin := uuid.New()
+
+	for _, period := range []string{"2026-10", "2026-13", "Sept"} {
+		if _, err := svc.SendNow(user.ID, period, admin); !errors.Is(err, ErrInvalidStatementPeriod) {
+			t.Errorf("%q: expected ErrInvalidStatementPeriod, got %v", period, err)
+		}
+	}
+	if _, err := svc.SendNow(uuid.New(), "2026-09", admin); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	// A failed attempt can be sent again straight away
+	banking.err = errors.New("banking service unavailable")
+	delivery, err := svc.SendNow(user.ID, "2026-09", admin)
+	if err != nil || delivery.Status != models.StatementDeliveryPending {
+		t.Fatalf("Expected a pending retry, got %+v, %v", delivery, err)
+	}
+	banking.err = nil
+	delivery, err = svc.SendNow(user.ID, "2026-09", admin)
+	if err != nil {
+		t.Fatalf("SendNow returned error: %v", err)
+	}
+	if delivery.Status != models.StatementDeliverySent || delivery.Attempts != 2 || *delivery.RequestedBy != admin {
+		t.Errorf("Expected the admin's delivery to be sent, got %+v", delivery)
+	}
+
+	if _, err := svc.SendNow(user.ID, "2026-09", admin); !errors.Is(err, ErrStatementAlreadySent) {
+		t.Errorf("Expected ErrStatementAlreadySent, got %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("Expected exactly 1 email, got %d", len(sender.sent))
+	}
+
+	// Sent whether or not the month had transactions
+	if delivery, err := svc.SendNow(user.ID, "2026-08", admin); err != nil || delivery.Status != models.StatementDeliverySent {
+		t.Errorf("Expected the empty statement to be sent, got %+v, %v", delivery, err)
+	}
+	if repo.find(user.ID, "2026-08") == nil {
+		t.Error("Expected the delivery to be recorded")
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/client-service/internal/revocation"
+)
+
+// TokenRevocationService revokes access tokens before they expire and
+// shares the revocations with the banking-service
+type TokenRevocationService struct {
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	auditLogRepo     repository.AuditLogRepository
+	revocations      revocation.Store
+	bankingClient    BankingClient
+}
+
+// NewTokenRevocationService creates a new token revocation service
+func NewTokenRevocationService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, auditLogRepo repository.AuditLogRepository, revocations revocation.Store, bankingClient BankingClient) *TokenRevocationService {
+	return &TokenRevocationService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		auditLogRepo:     auditLogRepo,
+		revocations:      revocations,
+		bankingClient:    bankingClient,
+	}
+}
+
+// RevokeTokens adds tokens pushed by another service to the revocation list
+func (s *TokenRevocationService) RevokeTokens(tokens []models.RevokedToken) error {
+	if err := s.revocations.Revoke(tokens); err != nil {
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+	return nil
+}
+
+// ForceLogout ends every session of a user on behalf of an admin. All
+// refresh tokens are deleted, the user's token version is bumped so issued
+// access tokens fail validation, and every access token issued to the user
+// that has not expired is revoked, including at the banking-service. With
+// mustResetPassword the user cannot log in again until they reset their
+// password.
+func (s *TokenRevocationService) ForceLogout(actor models.AuditActor, userID uuid.UUID, mustResetPassword bool) (*models.ForceLogoutResult, error) {
+	// Check if user exists
+	if _, err := s.userRepo.GetUserByID(userID); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	// Revoke all sessions, counting those still live
+	sessions, err := s.refreshTokenRepo.CountActiveByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count sessions: %w", err)
+	}
+	if err := s.refreshTokenRepo.DeleteByUserID(userID); err != nil {
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if mustResetPassword {
+		err = s.userRepo.RequirePasswordReset(userID)
+	} else {
+		err = s.userRepo.IncrementTokenVersion(userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to invalidate access tokens: %w", err)
+	}
+
+	tokens, err := s.revocations.RevokeUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	// The banking-service also confirms every token with this service, so
+	// the tokens are rejected there even if the push fails
+	if len(tokens) > 0 && s.bankingClient != nil {
+		if err := s.bankingClient.RevokeTokens(tokens); err != nil {
+			log.Printf("Failed to push token revocations for user %s to banking-service: %v", userID, err)
+		}
+	}
+
+	audit := newAuditLogEntry(actor, models.AuditActionForceLogout, userID, map[string]interface{}{
+		"sessions_terminated":   sessions,
+		"access_tokens_revoked": len(tokens),
+		"must_reset_password":   mustResetPassword,
+	})
+	if err := s.auditLogRepo.Create(audit); err != nil {
+		log.Printf("Failed to record force logout of user %s: %v", userID, err)
+	}
+
+	return &models.ForceLogoutResult{SessionsTerminated: sessions, AccessTokensRevoked: len(tokens)}, nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/passwordpolicy"
+	"microbank/client-service/internal/revocation"
+)
+
+func TestTokenRevocationService_ForceLogout(t *testing.T) {
+	user := newTestUser(t, "password123")
+	userRepo := newFakeUserRepo(user)
+	refreshRepo := newFakeRefreshTokenRepo()
+	revocations := revocation.NewMemoryStore()
+	authService := NewAuthService(userRepo, refreshRepo, &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocations, 0, nil)
+
+	// Two sessions are signed in
+	var accessTokens []string
+	for i := 0; i < 2; i++ {
+		_, session, err := authService.LoginUser(models.UserLogin{Email: user.Email, Password: "password123"}, models.LoginMetadata{})
+		if err != nil {
+			t.Fatalf("LoginUser returned error: %v", err)
+		}
+		accessTokens = append(accessTokens, session.AccessToken)
+	}
+
+	auditRepo := &fakeAuditLogRepo{}
+	banking := &fakeBankingClient{}
+	svc := NewTokenRevocationService(userRepo, refreshRepo, auditRepo, revocations, banking)
+
+	actor := models.AuditActor{AdminID: uuid.New()}
+	result, err := svc.ForceLogout(actor, user.ID, false)
+	if err != nil {
+		t.Fatalf("ForceLogout returned error: %v", err)
+	}
+	if result.SessionsTerminated != 2 || result.AccessTokensRevoked != 2 || len(banking.revoked) != 2 {
+		t.Errorf("Expected 2 sessions ended and 2 access tokens revoked and pushed, got %+v and %d", result, len(banking.revoked))
+	}
+	if user.TokenVersion != 1 || user.MustResetPassword {
+		t.Errorf("Expected only the token version to change, got version %d and must reset %v", user.TokenVersion, user.MustResetPassword)
+	}
+	if n := refreshRepo.countForUser(user.ID); n != 0 {
+		t.Errorf("Expected all refresh tokens to be revoked, %d remain", n)
+	}
+	for _, accessToken := range accessTokens {
+		claims, err := testTokens.ValidateToken(accessToken)
+		if err != nil {
+			t.Fatalf("failed to parse access token: %v", err)
+		}
+		if isRevoked, _ := revocations.IsRevoked(claims.ID); !isRevoked {
+			t.Errorf("Expected access token %s to be revoked", claims.ID)
+		}
+	}
+	if len(auditRepo.entries) != 1 || auditRepo.entries[0].Action != models.AuditActionForceLogout {
+		t.Errorf("Expected a force logout audit entry, got %+v", auditRepo.entries)
+	}
+}
+
+func TestTokenRevocationService_ForceLogoutSurvivesBankingFailure(t *testing.T) {
+	user := newTestUser(t, "password123")
+	revocations := revocation.NewMemoryStore()
+	revocations.Track(user.ID, "jti-1", time.Now().Add(time.Minute))
+	banking := &fakeBankingClient{err: errors.New("connection refused")}
+	svc := NewTokenRevocationService(newFakeUserRepo(user), newFakeRefreshTokenRepo(), &fakeAuditLogRepo{}, revocations, banking)
+
+	if _, err := svc.ForceLogout(models.AuditActor{AdminID: uuid.New()}, user.ID, false); err != nil {
+		t.Fatalf("Expected the force logout to succeed without the banking-service, got %v", err)
+	}
+	if revoked, _ := revocations.IsRevoked("jti-1"); !revoked {
+		t.Error("Expected the token to be revoked locally")
+	}
+}
+
+func TestTokenRevocationService_ForceLogoutUnknownUser(t *testing.T) {
+	svc := NewTokenRevocationService(newFakeUserRepo(), newFakeRefreshTokenRepo(), &fakeAuditLogRepo{}, revocation.NewMemoryStore(), nil)
+
+	if _, err := svc.ForceLogout(models.AuditActor{AdminID: uuid.New()}, uuid.New(), false); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestTokenRevocationService_ForceLogoutRequiresPasswordReset(t *testing.T) {
+	user := newTestUser(t, "password123")
+	userRepo := newFakeUserRepo(user)
+	revocations := revocation.NewMemoryStore()
+	authService := NewAuthService(userRepo, newFakeRefreshTokenRepo(), &fakeLoginEventRepo{}, passwordpolicy.Default(), testHasher, nil, nil, 0, nil, nil, testTokens, revocations, 0, nil)
+	svc := NewTokenRevocationService(userRepo, newFakeRefreshTokenRepo(), &fakeAuditLogRepo{}, revocations, nil)
+
+	if _, err := svc.ForceLogout(models.AuditActor{AdminID: uuid.New()}, user.ID, true); err != nil {
+		t.Fatalf("ForceLogout returned error: %v", err)
+	}
+	login := models.UserLogin{Email: user.Email, Password: "password123"}
+	if _, _, err := authService.LoginUser(login, models.LoginMetadata{}); !errors.Is(err, ErrPasswordResetRequired) {
+		t.Fatalf("Expected ErrPasswordResetRequired, got %v", err)
+	}
+
+	// Setting a new password lifts the requirement
+	if err := userRepo.UpdatePassword(user.ID, user.PasswordHash); err != nil {
+		t.Fatalf("UpdatePassword returned error: %v", err)
+	}
+	if _, _, err := authService.LoginUser(login, models.LoginMetadata{}); err != nil {
+		t.Errorf("Expected login to work after the reset, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/events"
+)
+
+// TransactionPINEventConsumer records staff resetting users' transaction
+// PINs, published by the banking service, in the audit log
+type TransactionPINEventConsumer struct {
+	auditLogRepo repository.AuditLogRepository
+}
+
+// NewTransactionPINEventConsumer creates a new transaction PIN event
+// consumer
+func NewTransactionPINEventConsumer(auditLogRepo repository.AuditLogRepository) *TransactionPINEventConsumer {
+	return &TransactionPINEventConsumer{
+		auditLogRepo: auditLogRepo,
+	}
+}
+
+// Handle applies the event and reports whether this service acted on its
+// type; other types are accepted and ignored. Each reset is written to the
+// audit log under the event's ID, so a redelivered event is logged once.
+// The events carry nothing about the PIN itself.
+func (c *TransactionPINEventConsumer) Handle(event events.Event) (bool, error) {
+	if event.Type != events.TypeTransactionPINReset {
+		return false, nil
+	}
+
+	var payload events.TransactionPINReset
+	if err := events.Decode(event, &payload); err != nil {
+		return false, err
+	}
+
+	metadata := map[string]interface{}{
+		"account_id": payload.AccountID,
+	}
+	entry := newAuditLogEntry(models.AuditActor{AdminID: payload.ActorID, RequestID: payload.RequestID}, models.AuditActionResetTransactionPIN, payload.UserID, metadata)
+	entry.ID = event.ID
+	entry.CreatedAt = payload.ResetAt
+	if err := c.auditLogRepo.Create(entry); err != nil {
+		return false, fmt.Errorf("failed to log transaction PIN reset: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/pkg/events"
+)
+
+func TestTransactionPINEventConsumer_Handle(t *testing.T) {
+	audits := &fakeAuditLogRepo{}
+	consumer := NewTransactionPINEventConsumer(audits)
+	staffID := uuid.New()
+	userID := uuid.New()
+	accountID := uuid.New()
+
+	reset, err := events.New(events.TypeTransactionPINReset, events.SourceBankingService, events.TransactionPINReset{
+		UserID:    userID,
+		AccountID: accountID,
+		ActorID:   staffID,
+		RequestID: "req-1",
+		ResetAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("events.New returned error: %v", err)
+	}
+	if handled, err := consumer.Handle(reset); err != nil || !handled {
+		t.Fatalf("Expected the event to be handled, got %v (%v)", handled, err)
+	}
+	if len(audits.entries) != 1 {
+		t.Fatalf("Expected one audit log entry, got %d", len(audits.entries))
+	}
+	entry := audits.entries[0]
+	if entry.ID != reset.ID || entry.AdminID != staffID || entry.Action != models.AuditActionResetTransactionPIN || entry.RequestID != "req-1" {
+		t.Errorf("Expected a reset by %s logged under the event ID, got %+v", staffID, entry)
+	}
+	if entry.TargetUserID == nil || *entry.TargetUserID != userID || entry.Metadata["account_id"] != accountID || len(entry.Metadata) != 1 {
+		t.Errorf("Expected an entry targeting %s naming only the account, got %+v", userID, entry)
+	}
+
+	other, err := events.New(events.TypeSavingsGoalCompleted, events.SourceBankingService, events.SavingsGoalCompleted{UserID: uuid.New()})
+	if err != nil {
+		t.Fatalf("events.New returned error: %v", err)
+	}
+	if handled, err := consumer.Handle(other); err != nil || handled {
+		t.Errorf("Expected other event types to be ignored, got %v (%v)", handled, err)
+	}
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+	"microbank/pkg/mailer"
+)
+
+// Bounds for user exports. Exports matching more than MaxUserExportRows
+// users are refused, so an admin who forgot a filter gets an error instead
+// of a full dump.
+const (
+	MaxUserExportRows   = 50000
+	userExportBatchSize = 500
+)
+
+// UserExportHeader lists the columns of the user export
+var UserExportHeader = []string{"id", "email", "name", "is_blacklisted", "blacklist_reason", "email_verified", "created_at", "last_login_at"}
+
+// UserExportRow formats a user as a row of the user export. Fields users
+// can set are escaped with EscapeCSVFormula.
+func UserExportRow(user *models.User) []string {
+	lastLoginAt := ""
+	if user.LastLoginAt != nil {
+		lastLoginAt = user.LastLoginAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		user.ID.String(),
+		EscapeCSVFormula(user.Email),
+		EscapeCSVFormula(user.Name),
+		strconv.FormatBool(user.IsBlacklisted),
+		EscapeCSVFormula(user.BlacklistReason),
+		strconv.FormatBool(user.IsEmailVerified()),
+		user.CreatedAt.UTC().Format(time.RFC3339),
+		lastLoginAt,
+	}
+}
+
+// EscapeCSVFormula prefixes value with a quote when it starts with a
+// character spreadsheets read as the start of a formula, so an exported
+// value such as =HYPERLINK(...) is shown as text rather than run
+func EscapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// UserExportService handles exporting the user list for compliance
+type UserExportService struct {
+	userRepo     repository.UserRepository
+	auditLogRepo repository.AuditLogRepository
+	downloads    *DownloadService
+	emailSender  mailer.EmailSender
+	wg           sync.WaitGroup
+}
+
+// NewUserExportService creates a new user export service
+func NewUserExportService(userRepo repository.UserRepository, auditLogRepo repository.AuditLogRepository) *UserExportService {
+	return &UserExportService{
+		userRepo:     userRepo,
+		auditLogRepo: auditLogRepo,
+	}
+}
+
+// WithDownloads lets the service generate exports in the background,
+// publishing them through downloads and emailing the admin who asked for
+// them a link
+func (s *UserExportService) WithDownloads(downloads *DownloadService, emailSender mailer.EmailSender) *UserExportService {
+	s.downloads = downloads
+	s.emailSender = emailSender
+	return s
+}
+
+// ExportUsers passes every user matching the listing filters to write, in
+// the listing's sort order, and returns how many were written. Paging
+// options are ignored. The export is recorded in the audit log before the
+// first user is written, and nothing is written if the record fails.
+func (s *UserExportService) ExportUsers(actor models.AuditActor, opts models.ListUsersOptions, write func(user *models.User) error) (int, error) {
+	if _, err := s.startExport(actor, opts); err != nil {
+		return 0, err
+	}
+	return s.writeUsers(opts, write)
+}
+
+// ExportUsersAsync starts exporting every user matching the listing filters
+// as CSV in the background and returns how many users match. The admin is
+// emailed a download link once the export is ready. The row cap and the
+// audit record are checked before returning, so a refused export is
+// reported at once.
+func (s *UserExportService) ExportUsersAsync(actor models.AuditActor, opts models.ListUsersOptions) (int, error) {
+	if s.downloads == nil {
+		return 0, fmt.Errorf("downloads are not configured")
+	}
+	admin, err := s.userRepo.GetUserByID(actor.AdminID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get admin: %w", err)
+	}
+
+	total, err := s.startExport(actor, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.publishExport(admin, opts); err != nil {
+			log.Printf("Background user export for %s failed: %v", admin.ID, err)
+		}
+	}()
+
+	return total, nil
+}
+
+// Wait blocks until background exports have finished
+func (s *UserExportService) Wait() {
+	s.wg.Wait()
+}
+
+// publishExport writes the export, publishes it for the admin to download
+// and emails them the link
+func (s *UserExportService) publishExport(admin *models.User, opts models.ListUsersOptions) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(UserExportHeader)
+	if _, err := s.writeUsers(opts, func(user *models.User) error {
+		w.Write(UserExportRow(user))
+		return w.Error()
+	}); err != nil {
+		return err
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	fileName := "clients-" + time.Now().UTC().Format("20060102") + ".csv"
+	artifact, link, err := s.downloads.Publish(context.Background(), admin.ID, models.DownloadKindClientsExport, fileName, "text/csv", buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	data := map[string]string{
+		"Name": admin.Name,
+		"Link": link,
+		"Time": artifact.ExpiresAt.UTC().Format("2 January 2006 15:04 MST"),
+	}
+	if err := sendEmail(s.emailSender, admin.Email, admin.ID, "export_ready", data); err != nil {
+		return fmt.Errorf("failed to send export email: %w", err)
+	}
+	return nil
+}
+
+// startExport checks the export is within the row cap and records it in
+// the audit log, returning how many users match
+func (s *UserExportService) startExport(actor models.AuditActor, opts models.ListUsersOptions) (int, error) {
+	total, err := s.userRepo.CountMatchingUsers(opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	if total > MaxUserExportRows {
+		return 0, fmt.Errorf("%w: %d users match, the limit is %d; narrow the filters", ErrUserExportTooLarge, total, MaxUserExportRows)
+	}
+
+	// Record the export
+	audit := newAuditLogEntry(actor, models.AuditActionExportUsers, uuid.Nil, map[string]interface{}{
+		"filters":   userExportFilters(opts),
+		"row_count": total,
+	})
+	audit.TargetUserID = nil
+	if err := s.auditLogRepo.Create(audit); err != nil {
+		return 0, fmt.Errorf("failed to record user export: %w", err)
+	}
+
+	return total, nil
+}
+
+// writeUsers passes every user matching the listing filters to write, in
+// the listing's sort order, and returns how many were written
+func (s *UserExportService) writeUsers(opts models.ListUsersOptions, write func(user *models.User) error) (int, error) {
+	// Walk the listing in batches
+	written := 0
+	var after *models.User
+	for {
+		users, err := s.userRepo.GetUsersAfter(opts, after, userExportBatchSize)
+		if err != nil {
+			return written, fmt.Errorf("failed to get users: %w", err)
+		}
+		for i := range users {
+			if err := write(&users[i]); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if len(users) < userExportBatchSize {
+			return written, nil
+		}
+		after = &users[len(users)-1]
+	}
+}
+
+// userExportFilters describes the filters and sort order applied to an
+// export for its audit entry. Filters that were not set are left out.
+func userExportFilters(opts models.ListUsersOptions) map[string]interface{} {
+	filters := map[string]interface{}{
+		"sort": opts.SortBy,
+	}
+	if opts.SortDesc {
+		filters["order"] = "desc"
+	} else {
+		filters["order"] = "asc"
+	}
+
+	if opts.IsBlacklisted != nil {
+		filters["is_blacklisted"] = *opts.IsBlacklisted
+	}
+	if opts.IsAdmin != nil {
+		filters["is_admin"] = *opts.IsAdmin
+	}
+	if opts.Deleted {
+		filters["deleted"] = true
+	}
+	for name, value := range map[string]*time.Time{
+		"created_after":  opts.CreatedAfter,
+		"created_before": opts.CreatedBefore,
+		"inactive_since": opts.InactiveSince,
+	} {
+		if value != nil {
+			filters[name] = value.UTC().Format(time.RFC3339)
+		}
+	}
+	if opts.RegistrationSource != "" {
+		filters["registration_source"] = opts.RegistrationSource
+	}
+	if opts.Search != "" {
+		filters["search"] = opts.Search
+	}
+
+	return filters
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func TestUserExportService_ExportUsers(t *testing.T) {
+	// More users than one batch, all but one blacklisted
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var users []*models.User
+	for i := 0; i < userExportBatchSize+2; i++ {
+		users = append(users, &models.User{ID: uuid.New(), Email: uuid.NewString() + "@example.com", IsBlacklisted: true, CreatedAt: start.Add(time.Duration(i) * time.Minute)})
+	}
+	users[0].IsBlacklisted = false
+	auditRepo := &fakeAuditLogRepo{}
+	svc := NewUserExportService(newFakeUserRepo(users...), auditRepo)
+
+	blacklisted := true
+	opts := models.ListUsersOptions{IsBlacklisted: &blacklisted, SortBy: models.UserSortCreatedAt, SortDesc: true, Limit: 10}
+	seen := make(map[uuid.UUID]bool)
+	var last time.Time
+	written, err := svc.ExportUsers(models.AuditActor{AdminID: uuid.New()}, opts, func(user *models.User) error {
+		if seen[user.ID] {
+			t.Fatalf("user %s exported twice", user.ID)
+		}
+		if !last.IsZero() && !user.CreatedAt.Before(last) {
+			t.Fatalf("users out of order: %s after %s", user.CreatedAt, last)
+		}
+		seen[user.ID], last = true, user.CreatedAt
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportUsers returned error: %v", err)
+	}
+	if written != userExportBatchSize+1 || len(seen) != written {
+		t.Errorf("Expected %d users exported, got %d", userExportBatchSize+1, written)
+	}
+
+	if len(auditRepo.entries) != 1 {
+		t.Fatalf("Expected one audit entry, got %d", len(auditRepo.entries))
+	}
+	entry := auditRepo.entries[0]
+	filters, _ := entry.Metadata["filters"].(map[string]interface{})
+	if entry.Action != models.AuditActionExportUsers || entry.TargetUserID != nil || filters["is_blacklisted"] != true || filters["order"] != "desc" {
+		t.Errorf("Unexpected audit entry: %+v", entry)
+	}
+	if entry.Metadata["row_count"] != userExportBatchSize+1 {
+		t.Errorf("Expected row_count %d, got %v", userExportBatchSize+1, entry.Metadata["row_count"])
+	}
+}
+
+func TestUserExportRow_EscapesFormulas(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: `=HYPERLINK("http://evil.example","click")`, want: `'=HYPERLINK("http://evil.example","click")`},
+		{value: "+1 555 0100", want: "'+1 555 0100"},
+		{value: "-2+3", want: "'-2+3"},
+		{value: "@SUM(A1)", want: "'@SUM(A1)"},
+		{value: "\t=1", want: "'\t=1"},
+		{value: "\r=1", want: "'\r=1"},
+		{value: "Jane = Doe", want: "Jane = Doe"},
+		{value: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		user := &models.User{ID: uuid.New(), Email: "jane@example.com", Name: tt.value, BlacklistReason: tt.value}
+		row := UserExportRow(user)
+		if row[2] != tt.want || row[4] != tt.want {
+			t.Errorf("Expected %q exported as %q, got name %q and reason %q", tt.value, tt.want, row[2], row[4])
+		}
+		if row[1] != user.Email {
+			t.Errorf("Expected a plain email to be kept, got %q", row[1])
+		}
+	}
+}
+
+func TestUserExportService_ExportUsersRowCap(t *testing.T) {
+	users := make([]*models.User, MaxUserExportRows+1)
+	for i := range users {
+		users[i] = &models.User{ID: uuid.New()}
+	}
+	auditRepo := &fakeAuditLogRepo{}
+	svc := NewUserExportService(newFakeUserRepo(users...), auditRepo)
+
+	_, err := svc.ExportUsers(models.AuditActor{AdminID: uuid.New()}, models.ListUsersOptions{}, func(*models.User) error {
+		t.Fatal("no users should be written")
+		return nil
+	})
+	if !errors.Is(err, ErrUserExportTooLarge) {
+		t.Fatalf("Expected ErrUserExportTooLarge, got %v", err)
+	}
+	if len(auditRepo.entries) != 0 {
+		t.Errorf("Expected no audit entry for a refused export, got %d", len(auditRepo.entries))
+	}
+}
+
+func TestUserExportService_ExportUsersAsync(t *testing.T) {
+	admin := newTestUser(t, "password123")
+	admin.Email, admin.Name = "admin@example.com", "Ada"
+	client := &models.User{ID: uuid.New(), Email: "client@example.com", Name: "Client"}
+	auditRepo := &fakeAuditLogRepo{}
+	downloads, _, _ := newTestDownloadService(t, admin)
+	emailSender := &fakeEmailSender{}
+	svc := NewUserExportService(newFakeUserRepo(admin, client), auditRepo).WithDownloads(downloads, emailSender)
+
+	total, err := svc.ExportUsersAsync(models.AuditActor{AdminID: admin.ID}, models.ListUsersOptions{SortBy: models.UserSortCreatedAt})
+	if err != nil {
+		t.Fatalf("ExportUsersAsync returned error: %v", err)
+	}
+	if total != 2 || len(auditRepo.entries) != 1 {
+		t.Errorf("Expected 2 users matched and the export audited, got %d and %d entries", total, len(auditRepo.entries))
+	}
+	svc.Wait()
+
+	// The admin is emailed a link to the finished export
+	email, ok := emailSender.last()
+	if !ok || email.to != "admin@example.com" {
+		t.Fatalf("Expected the export link emailed to the admin, got %+v", email)
+	}
+	var link string
+	for _, field := range strings.Fields(email.body) {
+		if strings.HasPrefix(field, "http://localhost:8000/api/v1/downloads/") {
+			link = field
+		}
+	}
+	artifact, data, err := downloads.Download(context.Background(), downloadToken(t, link), "203.0.113.1", "curl")
+	if err != nil {
+		t.Fatalf("Download returned error: %v", err)
+	}
+	if artifact.Kind != models.DownloadKindClientsExport || !strings.HasPrefix(string(data), strings.Join(UserExportHeader, ",")+"\n") || !strings.Contains(string(data), "client@example.com") {
+		t.Errorf("Unexpected export %+v:\n%s", artifact, data)
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+	"microbank/client-service/internal/repository"
+)
+
+// userPreferenceCacheTTL is how long PreferencesFor reuses a user's
+// preferences before reloading them
+const userPreferenceCacheTTL = 30 * time.Second
+
+// UserPreferenceService manages the locale and time zone users want
+// messages, statements and summaries presented in
+type UserPreferenceService struct {
+	repo repository.UserPreferenceRepository
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedUserPreferences
+}
+
+// cachedUserPreferences is a user's preferences and when they stop being
+// reused
+type cachedUserPreferences struct {
+	preferences models.UserPreferences
+	expiresAt   time.Time
+}
+
+// NewUserPreferenceService creates a new user preference service
+func NewUserPreferenceService(repo repository.UserPreferenceRepository) *UserPreferenceService {
+	return &UserPreferenceService{
+		repo:  repo,
+		cache: make(map[uuid.UUID]cachedUserPreferences),
+	}
+}
+
+// GetPreferences returns a user's preferences, filling in defaults for those
+// the user has not set
+func (s *UserPreferenceService) GetPreferences(userID uuid.UUID) (models.UserPreferences, error) {
+	stored, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return models.UserPreferences{}, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	preferences := stored.WithDefaults()
+	s.store(userID, preferences)
+	return preferences, nil
+}
+
+// UpdatePreferences changes the supplied preferences and returns the user's
+// full preferences. Unsupported locales and unknown time zones are rejected
+// with a ValidationError and nothing is saved.
+func (s *UserPreferenceService) UpdatePreferences(userID uuid.UUID, update models.UserPreferencesUpdate) (models.UserPreferences, error) {
+	if err := normalizePreferences(&update); err != nil {
+		return models.UserPreferences{}, err
+	}
+
+	if err := s.repo.Update(userID, update); err != nil {
+		s.invalidate(userID)
+		return models.UserPreferences{}, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	return s.GetPreferences(userID)
+}
+
+// PreferencesFor returns the preferences to render a user's emails,
+// statements and summaries with. Preferences are loaded with a single query
+// and reused for a short time, so a time zone changed through the profile
+// may take that long to apply.
+func (s *UserPreferenceService) PreferencesFor(userID uuid.UUID) (models.UserPreferences, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.preferences, nil
+	}
+
+	return s.GetPreferences(userID)
+}
+
+// store caches a user's preferences
+func (s *UserPreferenceService) store(userID uuid.UUID, preferences models.UserPreferences) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[userID] = cachedUserPreferences{
+		preferences: preferences,
+		expiresAt:   time.Now().Add(userPreferenceCacheTTL),
+	}
+}
+
+// invalidate drops a user's cached preferences
+func (s *UserPreferenceService) invalidate(userID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, userID)
+}
+
+// normalizePreferences trims the supplied preferences in place and replaces
+// the locale with its supported spelling, then validates them. All invalid
+// fields are reported together in a *ValidationError.
+func normalizePreferences(update *models.UserPreferencesUpdate) error {
+	var fields []FieldError
+
+	if update.Locale != nil {
+		locale := strings.TrimSpace(*update.Locale)
+		if locale != "" {
+			supported, ok := models.SupportedLocale(locale)
+			if !ok {
+				fields = append(fields, FieldError{
+					Field:   "locale",
+					Rule:    "supported",
+					Message: "must be one of " + strings.Join(models.SupportedLocales, ", "),
+				})
+			}
+			locale = supported
+		}
+		update.Locale = &locale
+	}
+
+	if update.TimeZone != nil {
+		timeZone := strings.TrimSpace(*update.TimeZone)
+		if timeZone != "" && !isTimeZone(timeZone) {
+			fields = append(fields, FieldError{
+				Field:   "timezone",
+				Rule:    "iana",
+				Message: "must be an IANA time zone, e.g. Africa/Johannesburg",
+			})
+		}
+		update.TimeZone = &timeZone
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"microbank/client-service/internal/models"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestUserPreferenceService_Defaults(t *testing.T) {
+	userID := uuid.New()
+	svc := NewUserPreferenceService(newFakeUserPreferenceRepo(userID))
+
+	preferences, err := svc.GetPreferences(userID)
+	if err != nil {
+		t.Fatalf("GetPreferences returned error: %v", err)
+	}
+	if preferences != (models.UserPreferences{Locale: "en", TimeZone: "UTC"}) {
+		t.Errorf("Expected the default preferences, got %+v", preferences)
+	}
+
+	if _, err := svc.GetPreferences(uuid.New()); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound for an unknown user, got %v", err)
+	}
+}
+
+func TestUserPreferenceService_UpdatePreferences(t *testing.T) {
+	userID := uuid.New()
+	repo := newFakeUserPreferenceRepo(userID)
+	svc := NewUserPreferenceService(repo)
+
+	updated, err := svc.UpdatePreferences(userID, models.UserPreferencesUpdate{
+		Locale:   strPtr(" ZU "),
+		TimeZone: strPtr("Africa/Johannesburg"),
+	})
+	if err != nil {
+		t.Fatalf("UpdatePreferences returned error: %v", err)
+	}
+	if updated != (models.UserPreferences{Locale: "zu", TimeZone: "Africa/Johannesburg"}) {
+		t.Errorf("Expected the normalized preferences, got %+v", updated)
+	}
+
+	_, err = svc.UpdatePreferences(userID, models.UserPreferencesUpdate{
+		Locale:   strPtr("xx"),
+		TimeZone: strPtr("Local"),
+	})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) != 2 {
+		t.Fatalf("Expected validation errors for the locale and time zone, got %v", err)
+	}
+	if repo.preferences[userID].Locale != "zu" {
+		t.Error("Expected nothing to be saved when a preference is invalid")
+	}
+
+	// Empty strings reset to the defaults
+	updated, err = svc.UpdatePreferences(userID, models.UserPreferencesUpdate{Locale: strPtr(""), TimeZone: strPtr("")})
+	if err != nil {
+		t.Fatalf("UpdatePreferences returned error: %v", err)
+	}
+	if updated != (models.UserPreferences{Locale: "en", TimeZone: "UTC"}) {
+		t.Errorf("Expected cleared preferences to fall back to the defaults, got %+v", updated)
+	}
+}
+
+func TestUserPreferenceService_PreferencesForCaches(t *testing.T) {
+	userID := uuid.New()
+	repo := newFakeUserPreferenceRepo(userID)
+	svc := NewUserPreferenceService(repo)
+
+	for i := 0; i < 3; i++ {
+		if _, err := svc.PreferencesFor(userID); err != nil {
+			t.Fatalf("PreferencesFor returned error: %v", err)
+		}
+	}
+	if repo.reads != 1 {
+		t.Errorf("Expected preferences to be loaded once, got %d reads", repo.reads)
+	}
+
+	if _, err := svc.UpdatePreferences(userID, models.UserPreferencesUpdate{TimeZone: strPtr("Europe/Lisbon")}); err != nil {
+		t.Fatalf("UpdatePreferences returned error: %v", err)
+	}
+
+	preferences, err := svc.PreferencesFor(userID)
+	if err != nil {
+		t.Fatalf("PreferencesFor returned error: %v", err)
+	}
+	if preferences.Location().String() != "Europe/Lisbon" {
+		t.Errorf("Expected the update to be visible immediately, got %+v", preferences)
+	}
+}
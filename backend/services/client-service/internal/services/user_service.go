@@ -1,22 +1,40 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"microbank/client-service/internal/models"
 	"microbank/client-service/internal/repository"
+	"microbank/pkg/authmw"
 )
 
 // UserService handles user-related business logic
 type UserService struct {
-	userRepo repository.UserRepository
+	userRepo          repository.UserRepository
+	refreshTokenRepo  repository.RefreshTokenRepository
+	loginEventRepo    repository.LoginEventRepository
+	blacklistRepo     repository.BlacklistRepository
+	auditLogRepo      repository.AuditLogRepository
+	bankingClient     BankingClient
+	deletionRetention time.Duration
 }
 
-// NewUserService creates a new user service
-func NewUserService(userRepo repository.UserRepository) *UserService {
+// NewUserService creates a new user service. Deleted users can be restored
+// for deletionRetention before they are purged.
+func NewUserService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, loginEventRepo repository.LoginEventRepository, blacklistRepo repository.BlacklistRepository, auditLogRepo repository.AuditLogRepository, bankingClient BankingClient, deletionRetention time.Duration) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:          userRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		loginEventRepo:    loginEventRepo,
+		blacklistRepo:     blacklistRepo,
+		auditLogRepo:      auditLogRepo,
+		bankingClient:     bankingClient,
+		deletionRetention: deletionRetention,
 	}
 }
 
@@ -30,79 +48,572 @@ func (s *UserService) GetUserByID(userID uuid.UUID) (*models.User, error) {
 	return user, nil
 }
 
-// UpdateUserProfile updates a user's profile information
+// UpdateUserProfile applies a partial update to a user's profile. Only the
+// supplied fields change. Invalid fields are reported together in a
+// *ValidationError.
 func (s *UserService) UpdateUserProfile(userID uuid.UUID, profile models.UserProfile) (*models.User, error) {
-	// Get current user
+	// Validate supplied fields
+	if err := normalizeProfile(&profile, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// Save supplied fields
+	if err := s.userRepo.UpdateUser(userID, profile); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	// Get updated user
 	user, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Update profile fields
-	user.Name = profile.Name
+	return user, nil
+}
 
-	// Save updated user
-	if err := s.userRepo.UpdateUser(user); err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+// GetUserStatuses reports the status of each user for other services, in
+// request order with duplicate IDs dropped. Unknown IDs are reported as not
+// existing rather than as an error.
+func (s *UserService) GetUserStatuses(userIDs []uuid.UUID) ([]models.UserStatus, error) {
+	unique := uniqueUserIDs(userIDs)
+	if len(unique) > models.MaxUserStatusBatchSize {
+		return nil, ErrUserStatusBatchTooLarge
 	}
 
-	return user, nil
+	found, err := s.userRepo.GetUserStatuses(unique)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user statuses: %w", err)
+	}
+
+	statuses := make([]models.UserStatus, 0, len(unique))
+	for _, userID := range unique {
+		status, ok := found[userID]
+		if !ok {
+			status = models.UserStatus{UserID: userID, Roles: []string{}}
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
 }
 
-// GetAllUsers retrieves all users (admin only)
-func (s *UserService) GetAllUsers() ([]models.User, error) {
-	users, err := s.userRepo.GetAllUsers()
+// GetUserDetails reports the email and name of each user for other
+// services, in request order with duplicate IDs dropped. Unknown IDs are
+// reported as not existing rather than as an error.
+func (s *UserService) GetUserDetails(userIDs []uuid.UUID) ([]models.UserDetail, error) {
+	unique := uniqueUserIDs(userIDs)
+	if len(unique) > models.MaxUserStatusBatchSize {
+		return nil, ErrUserStatusBatchTooLarge
+	}
+
+	found, err := s.userRepo.GetUserDetails(unique)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user details: %w", err)
+	}
+
+	details := make([]models.UserDetail, 0, len(unique))
+	for _, userID := range unique {
+		detail, ok := found[userID]
+		if !ok {
+			detail = models.UserDetail{UserID: userID}
+		}
+		details = append(details, detail)
+	}
+
+	return details, nil
+}
+
+// LookupUserByEmail reports the details of the active user with email, for
+// other services inviting users by email. Unknown emails are reported as
+// not existing rather than as an error.
+func (s *UserService) LookupUserByEmail(email string) models.UserDetail {
+	user, err := s.userRepo.GetUserByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return models.UserDetail{}
+	}
+
+	return models.UserDetail{UserID: user.ID, Exists: true, Email: user.Email, Name: user.Name}
+}
+
+// uniqueUserIDs returns userIDs in order without duplicates
+func uniqueUserIDs(userIDs []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	unique := make([]uuid.UUID, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if !seen[userID] {
+			seen[userID] = true
+			unique = append(unique, userID)
+		}
+	}
+	return unique
+}
+
+// Page size bounds for admin user listings
+const (
+	DefaultUserPageSize = 50
+	MaxUserPageSize     = 200
+)
+
+// ListUsers retrieves one page of users matching the options (admin only).
+// The page size is clamped to MaxUserPageSize.
+func (s *UserService) ListUsers(opts models.ListUsersOptions) (*models.UserPage, error) {
+	opts = pageUsers(opts)
+	users, total, err := s.userRepo.GetAllUsers(opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
 
-	return users, nil
+	return &models.UserPage{
+		Users:  users,
+		Total:  total,
+		Limit:  opts.Limit,
+		Offset: opts.Offset,
+	}, nil
+}
+
+// CountUsers returns the page ListUsers would return for the options
+// without its users, only counting the users matching them (admin only)
+func (s *UserService) CountUsers(opts models.ListUsersOptions) (*models.UserPage, error) {
+	opts = pageUsers(opts)
+	total, err := s.userRepo.CountMatchingUsers(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserPage{
+		Total:  total,
+		Limit:  opts.Limit,
+		Offset: opts.Offset,
+	}, nil
+}
+
+// pageUsers applies the default and maximum page size and the minimum
+// offset to listing options
+func pageUsers(opts models.ListUsersOptions) models.ListUsersOptions {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultUserPageSize
+	}
+	if opts.Limit > MaxUserPageSize {
+		opts.Limit = MaxUserPageSize
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+	return opts
+}
+
+// GetClientDetail retrieves a user along with their active session count
+// (admin only). Each view is recorded in the audit log before the detail
+// is returned, so none goes unrecorded.
+func (s *UserService) GetClientDetail(actor models.AuditActor, userID uuid.UUID) (*models.ClientDetail, error) {
+	// Get user
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	audit := newAuditLogEntry(actor, models.AuditActionViewClient, userID, nil)
+	if err := s.auditLogRepo.Create(audit); err != nil {
+		return nil, fmt.Errorf("failed to record client view: %w", err)
+	}
+
+	// Count active sessions
+	sessions, err := s.refreshTokenRepo.CountActiveByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active sessions: %w", err)
+	}
+
+	return &models.ClientDetail{
+		User:           user,
+		ActiveSessions: sessions,
+	}, nil
 }
 
-// BlacklistUser adds a user to the blacklist (admin only)
-func (s *UserService) BlacklistUser(userID uuid.UUID) error {
+// SetRole grants or revokes one of a user's roles on behalf of an admin.
+// Admins cannot revoke their own admin role and the last admin cannot be
+// demoted.
+func (s *UserService) SetRole(actor models.AuditActor, userID uuid.UUID, role string, grant bool) error {
+	if !authmw.ValidRole(role) {
+		return ErrInvalidRole
+	}
+	if role == authmw.RoleAdmin && !grant && actor.AdminID == userID {
+		return ErrCannotDemoteSelf
+	}
+
+	// Check if user exists
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	// Nothing to do if the role is unchanged
+	if authmw.HasRole(user.Roles, role) == grant {
+		return nil
+	}
+
+	// Update the role. Admin role changes keep their own audit actions.
+	var audit *models.AuditLogEntry
+	switch {
+	case role == authmw.RoleAdmin && grant:
+		audit = newAuditLogEntry(actor, models.AuditActionGrantAdmin, userID, nil)
+	case role == authmw.RoleAdmin:
+		audit = newAuditLogEntry(actor, models.AuditActionRevokeAdmin, userID, nil)
+	case grant:
+		audit = newAuditLogEntry(actor, models.AuditActionGrantRole, userID, map[string]interface{}{"role": role})
+	default:
+		audit = newAuditLogEntry(actor, models.AuditActionRevokeRole, userID, map[string]interface{}{"role": role})
+	}
+	if err := s.userRepo.UpdateRole(userID, role, grant, audit); err != nil {
+		if errors.Is(err, repository.ErrLastAdmin) {
+			return ErrLastAdmin
+		}
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	return nil
+}
+
+// BlacklistUser adds a user to the blacklist on behalf of an admin. A nil
+// expiry blacklists the user until an admin lifts it. All of the user's
+// sessions are revoked, and the status change makes issued access tokens stale.
+func (s *UserService) BlacklistUser(actor models.AuditActor, userID uuid.UUID, request models.BlacklistRequest) error {
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return ErrBlacklistReasonRequired
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return ErrInvalidBlacklistExpiry
+	}
+
 	// Check if user exists
 	_, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
+		return fmt.Errorf("%w: %w", ErrUserNotFound, err)
 	}
 
 	// Update blacklist status
-	if err := s.userRepo.UpdateBlacklistStatus(userID, true); err != nil {
+	audit := newAuditLogEntry(actor, models.AuditActionBlacklist, userID, map[string]interface{}{
+		"reason":     reason,
+		"expires_at": request.ExpiresAt,
+	})
+	entry := newBlacklistEntry(actor, userID, models.BlacklistActionAdd, reason, request.ExpiresAt)
+	if err := s.userRepo.UpdateBlacklistStatus(userID, true, reason, request.ExpiresAt, entry, audit); err != nil {
 		return fmt.Errorf("failed to blacklist user: %w", err)
 	}
 
+	// Revoke all sessions
+	if err := s.refreshTokenRepo.DeleteByUserID(userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
 	return nil
 }
 
-// RemoveFromBlacklist removes a user from the blacklist (admin only)
-func (s *UserService) RemoveFromBlacklist(userID uuid.UUID) error {
+// RemoveFromBlacklist removes a user from the blacklist on behalf of an admin
+func (s *UserService) RemoveFromBlacklist(actor models.AuditActor, userID uuid.UUID, reason string) error {
 	// Check if user exists
 	_, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
+		return fmt.Errorf("%w: %w", ErrUserNotFound, err)
 	}
 
 	// Update blacklist status
-	if err := s.userRepo.UpdateBlacklistStatus(userID, false); err != nil {
+	reason = strings.TrimSpace(reason)
+	audit := newAuditLogEntry(actor, models.AuditActionUnblacklist, userID, map[string]interface{}{
+		"reason": reason,
+	})
+	entry := newBlacklistEntry(actor, userID, models.BlacklistActionRemove, reason, nil)
+	if err := s.userRepo.UpdateBlacklistStatus(userID, false, "", nil, entry, audit); err != nil {
 		return fmt.Errorf("failed to remove user from blacklist: %w", err)
 	}
 
 	return nil
 }
 
-// DeleteUser permanently deletes a user (admin only)
-func (s *UserService) DeleteUser(userID uuid.UUID) error {
+// BlacklistUsers blacklists several users at once on behalf of an admin,
+// as during a fraud incident. Duplicate IDs are ignored, and the result
+// reports the outcome for each remaining ID in request order. Status
+// changes commit together; afterwards the blacklisted users' sessions are
+// revoked.
+func (s *UserService) BlacklistUsers(actor models.AuditActor, request models.BatchBlacklistRequest) ([]models.BlacklistBatchResult, error) {
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return nil, ErrBlacklistReasonRequired
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidBlacklistExpiry
+	}
+
+	userIDs, err := uniqueBatchUserIDs(request.UserIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update blacklist status
+	history := make(map[uuid.UUID]*models.BlacklistEntry, len(userIDs))
+	audits := make(map[uuid.UUID]*models.AuditLogEntry, len(userIDs))
+	for _, userID := range userIDs {
+		history[userID] = newBlacklistEntry(actor, userID, models.BlacklistActionAdd, reason, request.ExpiresAt)
+		audits[userID] = newAuditLogEntry(actor, models.AuditActionBlacklist, userID, map[string]interface{}{
+			"reason":     reason,
+			"expires_at": request.ExpiresAt,
+			"batch_size": len(userIDs),
+		})
+	}
+	statuses, err := s.userRepo.UpdateBlacklistStatusBatch(userIDs, true, reason, request.ExpiresAt, history, audits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to blacklist users: %w", err)
+	}
+
+	results, changed := blacklistBatchResults(userIDs, statuses)
+
+	// Revoke all sessions
+	if len(changed) > 0 {
+		if err := s.refreshTokenRepo.DeleteByUserIDs(changed); err != nil {
+			return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+	}
+
+	return results, nil
+}
+
+// RemoveUsersFromBlacklist lifts the blacklist of several users at once on
+// behalf of an admin. Duplicate IDs are ignored, and the result reports
+// the outcome for each remaining ID in request order.
+func (s *UserService) RemoveUsersFromBlacklist(actor models.AuditActor, request models.BatchUnblacklistRequest) ([]models.BlacklistBatchResult, error) {
+	userIDs, err := uniqueBatchUserIDs(request.UserIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update blacklist status
+	reason := strings.TrimSpace(request.Reason)
+	history := make(map[uuid.UUID]*models.BlacklistEntry, len(userIDs))
+	audits := make(map[uuid.UUID]*models.AuditLogEntry, len(userIDs))
+	for _, userID := range userIDs {
+		history[userID] = newBlacklistEntry(actor, userID, models.BlacklistActionRemove, reason, nil)
+		audits[userID] = newAuditLogEntry(actor, models.AuditActionUnblacklist, userID, map[string]interface{}{
+			"reason":     reason,
+			"batch_size": len(userIDs),
+		})
+	}
+	statuses, err := s.userRepo.UpdateBlacklistStatusBatch(userIDs, false, "", nil, history, audits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove users from blacklist: %w", err)
+	}
+
+	results, _ := blacklistBatchResults(userIDs, statuses)
+	return results, nil
+}
+
+// uniqueBatchUserIDs drops repeated IDs, keeping the first occurrence, and
+// checks the batch size
+func uniqueBatchUserIDs(userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	unique := make([]uuid.UUID, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		unique = append(unique, userID)
+	}
+
+	if len(unique) == 0 {
+		return nil, ErrEmptyBlacklistBatch
+	}
+	if len(unique) > models.MaxBlacklistBatchSize {
+		return nil, ErrBlacklistBatchTooLarge
+	}
+
+	return unique, nil
+}
+
+// blacklistBatchResults orders batch outcomes as requested and returns the
+// IDs whose status changed
+func blacklistBatchResults(userIDs []uuid.UUID, statuses map[uuid.UUID]string) ([]models.BlacklistBatchResult, []uuid.UUID) {
+	results := make([]models.BlacklistBatchResult, 0, len(userIDs))
+	var changed []uuid.UUID
+	for _, userID := range userIDs {
+		status, ok := statuses[userID]
+		if !ok {
+			status = models.BlacklistBatchNotFound
+		}
+		results = append(results, models.BlacklistBatchResult{UserID: userID, Status: status})
+		if status == models.BlacklistBatchOK {
+			changed = append(changed, userID)
+		}
+	}
+
+	return results, changed
+}
+
+// LiftExpiredBlacklists reinstates users whose temporary blacklist has
+// expired and returns how many were lifted. Each lift is recorded in the
+// blacklist history without an admin.
+func (s *UserService) LiftExpiredBlacklists() (int, error) {
+	userIDs, err := s.userRepo.LiftExpiredBlacklists(time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to lift expired blacklists: %w", err)
+	}
+
+	return len(userIDs), nil
+}
+
+// GetBlacklistHistory retrieves every blacklist and unblacklist of a user (admin only)
+func (s *UserService) GetBlacklistHistory(userID uuid.UUID) ([]models.BlacklistEntry, error) {
+	entries, err := s.blacklistRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blacklist history: %w", err)
+	}
+
+	return entries, nil
+}
+
+// newBlacklistEntry builds the blacklist history entry of a status change
+// made by actor, to be written with it
+func newBlacklistEntry(actor models.AuditActor, userID uuid.UUID, action, reason string, expiresAt *time.Time) *models.BlacklistEntry {
+	adminID := actor.AdminID
+	return &models.BlacklistEntry{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Action:    action,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+		AdminID:   &adminID,
+		CreatedAt: time.Now(),
+	}
+}
+
+// DeleteUser soft-deletes a user on behalf of an admin. Admins cannot
+// delete themselves, and deleting another admin requires force. Sessions are
+// revoked and the banking-service is notified before the user is marked
+// deleted, so a failed notification leaves the user intact. The user can be
+// restored until the deletion retention period passes.
+func (s *UserService) DeleteUser(actor models.AuditActor, userID uuid.UUID, force bool) error {
+	if actor.AdminID == userID {
+		return ErrCannotDeleteSelf
+	}
+
 	// Check if user exists
-	_, err := s.userRepo.GetUserByID(userID)
+	user, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
-		return fmt.Errorf("user not found: %w", err)
+		return fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	if user.IsAdmin && !force {
+		return ErrAdminDeleteRequiresForce
+	}
+
+	// Revoke all sessions
+	if err := s.refreshTokenRepo.DeleteByUserID(userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	// Let the banking-service flag the orphaned account
+	if err := s.bankingClient.NotifyUserDeleted(userID); err != nil {
+		return fmt.Errorf("%w: %w", ErrBankingServiceUnavailable, err)
 	}
 
 	// Delete user
-	if err := s.userRepo.DeleteUser(userID); err != nil {
+	audit := newAuditLogEntry(actor, models.AuditActionDelete, userID, map[string]interface{}{
+		"email":     user.Email,
+		"was_admin": user.IsAdmin,
+		"force":     force,
+	})
+	if err := s.userRepo.DeleteUser(userID, false, audit); err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
 	return nil
 }
+
+// RestoreUser undoes a soft deletion on behalf of an admin, provided the
+// deletion retention period has not passed. The banking-service is notified
+// first, so a failed notification leaves the user deleted.
+func (s *UserService) RestoreUser(actor models.AuditActor, userID uuid.UUID) (*models.User, error) {
+	// Get deleted user
+	user, err := s.userRepo.GetDeletedUserByID(userID)
+	if err != nil {
+		if _, activeErr := s.userRepo.GetUserByID(userID); activeErr == nil {
+			return nil, ErrUserNotDeleted
+		}
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+
+	deletedAfter := time.Now().Add(-s.deletionRetention)
+	if !user.DeletedAt.After(deletedAfter) {
+		return nil, ErrRestoreWindowExpired
+	}
+
+	// Let the banking-service clear the orphaned account flag
+	if err := s.bankingClient.NotifyUserRestored(userID); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBankingServiceUnavailable, err)
+	}
+
+	// Restore user
+	audit := newAuditLogEntry(actor, models.AuditActionRestore, userID, map[string]interface{}{
+		"email":      user.Email,
+		"deleted_at": user.DeletedAt,
+	})
+	if err := s.userRepo.RestoreUser(userID, deletedAfter, audit); err != nil {
+		return nil, fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	user.DeletedAt = nil
+	return user, nil
+}
+
+// PurgeDeletedUsers permanently removes users whose deletion retention
+// period has passed and returns how many were purged
+func (s *UserService) PurgeDeletedUsers() (int, error) {
+	userIDs, err := s.userRepo.PurgeDeletedUsers(time.Now().Add(-s.deletionRetention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+
+	return len(userIDs), nil
+}
+
+// GetLoginHistory retrieves a user's most recent login attempts
+func (s *UserService) GetLoginHistory(userID uuid.UUID, limit int) ([]models.LoginEvent, error) {
+	// Set default values if not provided
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	events, err := s.loginEventRepo.GetByUserID(userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login history: %w", err)
+	}
+
+	return events, nil
+}
+
+// PurgeLoginEvents deletes login events older than the retention period
+func (s *UserService) PurgeLoginEvents(retention time.Duration) (int64, error) {
+	deleted, err := s.loginEventRepo.DeleteOlderThan(time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge login events: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// refreshTokenCleanupTimeout bounds a single refresh token cleanup so a slow
+// delete cannot hold a connection indefinitely
+const refreshTokenCleanupTimeout = 30 * time.Second
+
+// CleanupExpiredRefreshTokens deletes refresh tokens that can no longer be
+// used to sign in
+func (s *UserService) CleanupExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, refreshTokenCleanupTimeout)
+	defer cancel()
+
+	deleted, err := s.refreshTokenRepo.CleanupExpiredTokens(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clean up refresh tokens: %w", err)
+	}
+
+	return deleted, nil
+}
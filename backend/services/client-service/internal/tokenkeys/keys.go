@@ -0,0 +1,397 @@
+// Package tokenkeys holds the keys access tokens are signed and verified
+// with, and publishes the public ones as a JSON Web Key Set so other
+// services can verify tokens without being able to mint them.
+package tokenkeys
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms accepted by JWT_SIGNING_ALGORITHM
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmHS256 = "HS256"
+)
+
+// JWK is a public key in a JSON Web Key Set
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet signs access tokens with its active key and verifies tokens signed
+// by any key it knows, selected by the kid header. HMAC secrets sign tokens
+// in HS256 mode or are accepted as a fallback for HS256 tokens while
+// services move to RS256. The HMAC secret under the empty kid verifies
+// tokens issued without a kid header.
+type KeySet struct {
+	algorithm  string
+	activeKID  string
+	privateKey *rsa.PrivateKey
+	publicKeys map[string]*rsa.PublicKey
+	kids       []string
+	hmacKeys   map[string][]byte
+}
+
+// NewRSA creates a key set that signs with active and also accepts tokens
+// signed by the private keys of additional, such as a key being retired.
+// hmacFallback maps key IDs to secrets accepted for HS256 tokens.
+func NewRSA(active *rsa.PrivateKey, additional []*rsa.PublicKey, hmacFallback map[string]string) *KeySet {
+	k := &KeySet{
+		algorithm:  AlgorithmRS256,
+		privateKey: active,
+		publicKeys: make(map[string]*rsa.PublicKey),
+		hmacKeys:   hmacKeyBytes(hmacFallback),
+	}
+
+	k.activeKID = k.addPublicKey(&active.PublicKey)
+	for _, key := range additional {
+		k.addPublicKey(key)
+	}
+	return k
+}
+
+// NewHMAC creates a key set that signs and verifies HS256 tokens with a
+// single shared secret and no kid header. It publishes no keys.
+func NewHMAC(secret string) *KeySet {
+	return &KeySet{
+		algorithm:  AlgorithmHS256,
+		publicKeys: make(map[string]*rsa.PublicKey),
+		hmacKeys:   map[string][]byte{"": []byte(secret)},
+	}
+}
+
+// NewHMACKeys creates a key set that signs HS256 tokens with the secret of
+// activeKID and verifies tokens signed with any of keys, which maps key IDs
+// to secrets. Keys other than the active one are retired: they no longer
+// sign, but their tokens are accepted until the key is removed. It
+// publishes no keys.
+func NewHMACKeys(activeKID string, keys map[string]string) (*KeySet, error) {
+	if _, ok := keys[activeKID]; !ok {
+		return nil, fmt.Errorf("active key %q is not loaded", activeKID)
+	}
+	return &KeySet{
+		algorithm:  AlgorithmHS256,
+		activeKID:  activeKID,
+		publicKeys: make(map[string]*rsa.PublicKey),
+		hmacKeys:   hmacKeyBytes(keys),
+	}, nil
+}
+
+// LoadFromEnv creates a key set from JWT_SIGNING_ALGORITHM (RS256 or HS256,
+// default RS256). RS256 signs with the PEM private key in
+// JWT_PRIVATE_KEY_FILE and also publishes the PEM public keys listed in
+// JWT_PUBLIC_KEY_FILES. With JWT_HS256_FALLBACK=true, HS256 tokens signed
+// with the HMAC keys are still accepted.
+//
+// HS256 signs with the JWT_KEYS secret named by JWT_ACTIVE_KID, or with
+// JWT_SECRET when JWT_KEYS is not set. JWT_KEYS is a comma-separated list
+// of kid:secret pairs. JWT_SECRET, when set alongside it, verifies tokens
+// issued without a kid.
+func LoadFromEnv() (*KeySet, error) {
+	algorithm := strings.ToUpper(os.Getenv("JWT_SIGNING_ALGORITHM"))
+
+	hmacKeys, err := ParseHMACKeys(os.Getenv("JWT_KEYS"))
+	if err != nil {
+		return nil, err
+	}
+	secret := os.Getenv("JWT_SECRET")
+
+	switch algorithm {
+	case AlgorithmHS256:
+		if len(hmacKeys) == 0 {
+			if secret == "" {
+				return nil, fmt.Errorf("JWT_SECRET or JWT_KEYS is required for HS256 signing")
+			}
+			return NewHMAC(secret), nil
+		}
+
+		activeKID := os.Getenv("JWT_ACTIVE_KID")
+		if activeKID == "" {
+			return nil, fmt.Errorf("JWT_ACTIVE_KID is required with JWT_KEYS")
+		}
+		if secret != "" {
+			hmacKeys[""] = secret
+		}
+		keys, err := NewHMACKeys(activeKID, hmacKeys)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_ACTIVE_KID: %w", err)
+		}
+		return keys, nil
+	case AlgorithmRS256, "":
+	default:
+		return nil, fmt.Errorf("unsupported JWT signing algorithm %q", algorithm)
+	}
+
+	path := os.Getenv("JWT_PRIVATE_KEY_FILE")
+	if path == "" {
+		return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for RS256 signing")
+	}
+	active, err := loadPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var additional []*rsa.PublicKey
+	for _, path := range strings.Split(os.Getenv("JWT_PUBLIC_KEY_FILES"), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		key, err := loadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		additional = append(additional, key)
+	}
+
+	var fallback map[string]string
+	if value := os.Getenv("JWT_HS256_FALLBACK"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_HS256_FALLBACK must be true or false: %w", err)
+		}
+		if enabled {
+			if secret != "" {
+				hmacKeys[""] = secret
+			}
+			if len(hmacKeys) == 0 {
+				return nil, fmt.Errorf("JWT_SECRET or JWT_KEYS is required when JWT_HS256_FALLBACK is enabled")
+			}
+			fallback = hmacKeys
+		}
+	}
+
+	return NewRSA(active, additional, fallback), nil
+}
+
+// Algorithm returns the algorithm new tokens are signed with
+func (k *KeySet) Algorithm() string {
+	return k.algorithm
+}
+
+// ActiveKID returns the key ID new tokens are signed with, or "" for a
+// single HS256 secret
+func (k *KeySet) ActiveKID() string {
+	return k.activeKID
+}
+
+// KeyIDs returns the IDs of every key tokens are verified with, RSA keys
+// first. The unnamed HMAC secret is not listed.
+func (k *KeySet) KeyIDs() []string {
+	ids := append([]string{}, k.kids...)
+
+	var hmacIDs []string
+	for kid := range k.hmacKeys {
+		if kid != "" {
+			hmacIDs = append(hmacIDs, kid)
+		}
+	}
+	sort.Strings(hmacIDs)
+	return append(ids, hmacIDs...)
+}
+
+// AcceptsUnnamedHMAC reports whether HS256 tokens without a kid header are
+// accepted
+func (k *KeySet) AcceptsUnnamedHMAC() bool {
+	_, ok := k.hmacKeys[""]
+	return ok
+}
+
+// Sign signs claims with the active key and sets its kid header
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if k.algorithm == AlgorithmHS256 {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		if k.activeKID != "" {
+			token.Header["kid"] = k.activeKID
+		}
+		return token.SignedString(k.hmacKeys[k.activeKID])
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = k.activeKID
+	return token.SignedString(k.privateKey)
+}
+
+// JWKS returns the public keys tokens may be signed with
+func (k *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(k.kids))}
+	for _, kid := range k.kids {
+		key := k.publicKeys[kid]
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: AlgorithmRS256,
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	return set
+}
+
+// ValidMethods lists the algorithms accepted when verifying tokens
+func (k *KeySet) ValidMethods() []string {
+	var methods []string
+	if len(k.publicKeys) > 0 {
+		methods = append(methods, AlgorithmRS256)
+	}
+	if len(k.hmacKeys) > 0 {
+		methods = append(methods, AlgorithmHS256)
+	}
+	return methods
+}
+
+// Keyfunc picks the verification key for a token by algorithm and kid
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		secret, ok := k.hmacKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return secret, nil
+	}
+
+	key, ok := k.publicKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// addPublicKey adds a verification key under its thumbprint and returns
+// the key ID
+func (k *KeySet) addPublicKey(key *rsa.PublicKey) string {
+	kid := Thumbprint(key)
+	if _, ok := k.publicKeys[kid]; !ok {
+		k.publicKeys[kid] = key
+		k.kids = append(k.kids, kid)
+	}
+	return kid
+}
+
+// ParseHMACKeys parses a comma-separated list of kid:secret pairs into a map
+// of key IDs to secrets
+func ParseHMACKeys(value string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for i, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		// The entry is not echoed back as it may be a bare secret
+		kid, secret, ok := strings.Cut(pair, ":")
+		if !ok || kid == "" || secret == "" {
+			return nil, fmt.Errorf("invalid JWT key at position %d: expected kid:secret", i+1)
+		}
+		if _, exists := keys[kid]; exists {
+			return nil, fmt.Errorf("duplicate JWT key %q", kid)
+		}
+		keys[kid] = secret
+	}
+	return keys, nil
+}
+
+// hmacKeyBytes converts secrets keyed by key ID to byte slices
+func hmacKeyBytes(keys map[string]string) map[string][]byte {
+	converted := make(map[string][]byte, len(keys))
+	for kid, secret := range keys {
+		converted[kid] = []byte(secret)
+	}
+	return converted
+}
+
+// Thumbprint returns the RFC 7638 JWK thumbprint of an RSA public key,
+// which is used as its key ID so the same key always gets the same ID
+func Thumbprint(key *rsa.PublicKey) string {
+	// Members in lexicographic order, as RFC 7638 requires
+	canonical, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+	})
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// loadPrivateKey reads a PKCS#1 or PKCS#8 PEM RSA private key
+func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// loadPublicKey reads a PKIX or PKCS#1 PEM RSA public key
+func loadPublicKey(path string) (*rsa.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// readPEM reads the first PEM block of a file
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	return block, nil
+}
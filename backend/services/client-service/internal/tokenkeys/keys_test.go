@@ -0,0 +1,285 @@
+package tokenkeys
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func generateTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"user_id": "user-1",
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+func TestKeySet_SignAndParseRS256(t *testing.T) {
+	key := generateTestKey(t)
+	keys := NewRSA(key, nil, nil)
+
+	signed, err := keys.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign returned error: %v", err)
+	}
+
+	token, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("failed to decode token: %v", err)
+	}
+	if token.Method.Alg() != AlgorithmRS256 || token.Header["kid"] != keys.ActiveKID() {
+		t.Errorf("Expected an RS256 token with kid %q, got %s with %v", keys.ActiveKID(), token.Method.Alg(), token.Header["kid"])
+	}
+
+	claims, err := parse(keys, signed)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if claims["user_id"] != "user-1" {
+		t.Errorf("Unexpected claims: %v", claims)
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey := generateTestKey(t)
+	newKey := generateTestKey(t)
+
+	before := NewRSA(oldKey, nil, nil)
+	oldToken, err := before.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign returned error: %v", err)
+	}
+
+	// After rotation the old key is only kept for verification
+	after := NewRSA(newKey, []*rsa.PublicKey{&oldKey.PublicKey}, nil)
+	if after.ActiveKID() == before.ActiveKID() {
+		t.Fatal("Expected the new key to have a different kid")
+	}
+	if _, err := parse(after, oldToken); err != nil {
+		t.Errorf("Expected a token signed with the retired key to verify, got %v", err)
+	}
+
+	jwks := after.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != after.ActiveKID() || jwks.Keys[1].Kid != before.ActiveKID() {
+		t.Errorf("Expected both keys in the JWKS with the active key first, got %+v", jwks.Keys)
+	}
+
+	// Once the old key is dropped its tokens are rejected
+	if _, err := parse(NewRSA(newKey, nil, nil), oldToken); err == nil {
+		t.Error("Expected a token signed with an unknown key to be rejected")
+	}
+}
+
+func TestKeySet_HS256Fallback(t *testing.T) {
+	legacy, err := NewHMAC("test-secret").Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign returned error: %v", err)
+	}
+
+	key := generateTestKey(t)
+	if _, err := parse(NewRSA(key, nil, nil), legacy); err == nil {
+		t.Error("Expected an HS256 token to be rejected without the fallback")
+	}
+	if _, err := parse(NewRSA(key, nil, map[string]string{"": "test-secret"}), legacy); err != nil {
+		t.Errorf("Expected an HS256 token to verify with the fallback, got %v", err)
+	}
+	if _, err := parse(NewRSA(key, nil, map[string]string{"": "other-secret"}), legacy); err == nil {
+		t.Error("Expected an HS256 token signed with another secret to be rejected")
+	}
+}
+
+func TestKeySet_HMACRotation(t *testing.T) {
+	before, err := NewHMACKeys("2026-01", map[string]string{"2026-01": "old-secret"})
+	if err != nil {
+		t.Fatalf("NewHMACKeys returned error: %v", err)
+	}
+	oldToken, err := before.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign returned error: %v", err)
+	}
+
+	token, _, _ := jwt.NewParser().ParseUnverified(oldToken, jwt.MapClaims{})
+	if token.Header["kid"] != "2026-01" {
+		t.Errorf("Expected kid 2026-01, got %v", token.Header["kid"])
+	}
+
+	// The new key signs while the retired key still verifies
+	after, err := NewHMACKeys("2026-02", map[string]string{"2026-01": "old-secret", "2026-02": "new-secret"})
+	if err != nil {
+		t.Fatalf("NewHMACKeys returned error: %v", err)
+	}
+	if _, err := parse(after, oldToken); err != nil {
+		t.Errorf("Expected a token signed with the retired key to verify, got %v", err)
+	}
+	newToken, _ := after.Sign(testClaims())
+	if _, err := parse(before, newToken); err == nil {
+		t.Error("Expected a token signed with the new key to be rejected by the old key set")
+	}
+	if ids := after.KeyIDs(); len(ids) != 2 || ids[0] != "2026-01" || ids[1] != "2026-02" {
+		t.Errorf("Unexpected key IDs: %v", ids)
+	}
+
+	// Removing the retired key revokes its tokens
+	revoked, _ := NewHMACKeys("2026-02", map[string]string{"2026-02": "new-secret"})
+	if _, err := parse(revoked, oldToken); err == nil {
+		t.Error("Expected a token signed with a removed key to be rejected")
+	}
+
+	// A kid cannot select another key's secret
+	if _, err := NewHMACKeys("missing", map[string]string{"2026-02": "new-secret"}); err == nil {
+		t.Error("Expected an error for an active kid that is not loaded")
+	}
+	unnamed, _ := NewHMAC("new-secret").Sign(testClaims())
+	if _, err := parse(after, unnamed); err == nil {
+		t.Error("Expected a token without a kid to be rejected when no unnamed secret is loaded")
+	}
+}
+
+func TestParseHMACKeys(t *testing.T) {
+	keys, err := ParseHMACKeys(" 2026-01:old-secret, 2026-02:new:secret ,")
+	if err != nil {
+		t.Fatalf("ParseHMACKeys returned error: %v", err)
+	}
+	if len(keys) != 2 || keys["2026-01"] != "old-secret" || keys["2026-02"] != "new:secret" {
+		t.Errorf("Unexpected keys: %v", keys)
+	}
+
+	for _, value := range []string{"bare-secret", ":secret", "kid:", "a:1,a:2"} {
+		if _, err := ParseHMACKeys(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestKeySet_HMACPublishesNoKeys(t *testing.T) {
+	keys := NewHMAC("test-secret")
+
+	if keys.ActiveKID() != "" || len(keys.JWKS().Keys) != 0 {
+		t.Errorf("Expected no published keys, got kid %q and %+v", keys.ActiveKID(), keys.JWKS())
+	}
+	// An RS256 token cannot be verified with only a secret
+	rsaToken, _ := NewRSA(generateTestKey(t), nil, nil).Sign(testClaims())
+	if _, err := parse(keys, rsaToken); err == nil {
+		t.Error("Expected an RS256 token to be rejected")
+	}
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	active := generateTestKey(t)
+	retired := generateTestKey(t)
+
+	privatePath := filepath.Join(dir, "private.pem")
+	writePEM(t, privatePath, "PRIVATE KEY", func() ([]byte, error) { return x509.MarshalPKCS8PrivateKey(active) })
+	publicPath := filepath.Join(dir, "retired.pem")
+	writePEM(t, publicPath, "PUBLIC KEY", func() ([]byte, error) { return x509.MarshalPKIXPublicKey(&retired.PublicKey) })
+
+	t.Run("RS256 with an additional key", func(t *testing.T) {
+		t.Setenv("JWT_SIGNING_ALGORITHM", "")
+		t.Setenv("JWT_PRIVATE_KEY_FILE", privatePath)
+		t.Setenv("JWT_PUBLIC_KEY_FILES", publicPath)
+
+		keys, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("LoadFromEnv returned error: %v", err)
+		}
+		if keys.Algorithm() != AlgorithmRS256 || keys.ActiveKID() != Thumbprint(&active.PublicKey) {
+			t.Errorf("Unexpected key set: %s %s", keys.Algorithm(), keys.ActiveKID())
+		}
+		if len(keys.JWKS().Keys) != 2 {
+			t.Errorf("Expected 2 published keys, got %d", len(keys.JWKS().Keys))
+		}
+	})
+
+	t.Run("HS256", func(t *testing.T) {
+		t.Setenv("JWT_SIGNING_ALGORITHM", "hs256")
+		t.Setenv("JWT_SECRET", "test-secret")
+
+		keys, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("LoadFromEnv returned error: %v", err)
+		}
+		if keys.Algorithm() != AlgorithmHS256 {
+			t.Errorf("Expected HS256, got %s", keys.Algorithm())
+		}
+	})
+
+	t.Run("HS256 with named keys", func(t *testing.T) {
+		t.Setenv("JWT_SIGNING_ALGORITHM", "HS256")
+		t.Setenv("JWT_KEYS", "2026-01:old-secret,2026-02:new-secret")
+		t.Setenv("JWT_ACTIVE_KID", "2026-02")
+		t.Setenv("JWT_SECRET", "test-secret")
+
+		keys, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("LoadFromEnv returned error: %v", err)
+		}
+		if keys.ActiveKID() != "2026-02" || len(keys.KeyIDs()) != 2 || !keys.AcceptsUnnamedHMAC() {
+			t.Errorf("Unexpected key set: active %q, kids %v", keys.ActiveKID(), keys.KeyIDs())
+		}
+	})
+
+	errorCases := []struct {
+		name string
+		env  map[string]string
+	}{
+		{name: "missing private key", env: map[string]string{"JWT_PRIVATE_KEY_FILE": ""}},
+		{name: "unreadable private key", env: map[string]string{"JWT_PRIVATE_KEY_FILE": filepath.Join(dir, "missing.pem")}},
+		{name: "unknown algorithm", env: map[string]string{"JWT_SIGNING_ALGORITHM": "ES256"}},
+		{name: "fallback without secret", env: map[string]string{"JWT_PRIVATE_KEY_FILE": privatePath, "JWT_HS256_FALLBACK": "true", "JWT_SECRET": ""}},
+		{name: "HS256 without secret", env: map[string]string{"JWT_SIGNING_ALGORITHM": "HS256", "JWT_SECRET": ""}},
+		{name: "named keys without active kid", env: map[string]string{"JWT_SIGNING_ALGORITHM": "HS256", "JWT_KEYS": "k1:secret", "JWT_ACTIVE_KID": ""}},
+		{name: "active kid not loaded", env: map[string]string{"JWT_SIGNING_ALGORITHM": "HS256", "JWT_KEYS": "k1:secret", "JWT_ACTIVE_KID": "k2"}},
+		{name: "malformed named keys", env: map[string]string{"JWT_SIGNING_ALGORITHM": "HS256", "JWT_KEYS": "k1"}},
+	}
+
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_SIGNING_ALGORITHM", "")
+			t.Setenv("JWT_PUBLIC_KEY_FILES", "")
+			t.Setenv("JWT_KEYS", "")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			if _, err := LoadFromEnv(); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func writePEM(t *testing.T, path, blockType string, marshal func() ([]byte, error)) {
+	t.Helper()
+	der, err := marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+}
+
+// parse verifies a token with keys the way the shared token manager does
+func parse(keys *KeySet, tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods())); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
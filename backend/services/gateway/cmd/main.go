@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"microbank/gateway/internal/config"
+	"microbank/gateway/internal/middleware"
+	"microbank/gateway/internal/proxy"
+	"microbank/pkg/redact"
+	"microbank/pkg/tlsserver"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Load and check the configuration before anything is started
+	validateOnly := flag.Bool("validate-config", false, "check the configuration and exit with status 0 if it is valid or 1 if not")
+	flag.Parse()
+	cfg, err := config.Load()
+	if *validateOnly {
+		exitAfterValidation(err)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Initialize the proxy to the upstream services
+	gateway := proxy.New(cfg.Routes, cfg.TrustedProxies, cfg.UpstreamTimeout)
+	for _, route := range cfg.Routes.Routes() {
+		log.Printf("Routing %s to %s (%s)", route.Prefix, route.Upstream.Name, route.Upstream.URL.Redacted())
+	}
+
+	// Set Gin mode
+	gin.SetMode(cfg.GinMode)
+
+	// Create router
+	r := gin.Default()
+
+	// Only trust X-Forwarded-For from configured proxies when resolving c.ClientIP()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies.CIDRs()); err != nil {
+		log.Fatalf("Failed to set trusted proxies: %v", err)
+	}
+
+	// Add middleware. The upstreams' own CORS headers are replaced by the
+	// gateway's, and they reuse the request ID it assigns.
+	r.Use(middleware.RequestID())
+	r.Use(middleware.CORS(cfg.CORS))
+	r.Use(middleware.Logger(redact.LogRedactionFromEnv()))
+	r.Use(middleware.Recovery())
+
+	// Health check endpoint, reporting the upstreams' health as well
+	r.GET("/health", gateway.Health)
+
+	// Every other request is forwarded according to the route table
+	r.NoRoute(middleware.RateLimit(middleware.NewInMemoryRateLimitStore(), cfg.TrustedProxies, cfg.RateLimit), gateway.Proxy)
+
+	server := &http.Server{Addr: ":" + strconv.Itoa(cfg.Port), Handler: r, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		log.Printf("Gateway starting on port %d (TLS: %t)", cfg.Port, cfg.Certificates != nil)
+		if err := tlsserver.ListenAndServe(server, cfg.Certificates); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// With TLS, pick up renewed certificates and optionally redirect plain
+	// HTTP to HTTPS
+	var redirectServer *http.Server
+	if cfg.Certificates != nil {
+		go cfg.Certificates.Watch(ctx, tlsserver.DefaultReloadInterval)
+		if cfg.TLS.RedirectPort != 0 {
+			redirectServer = tlsserver.NewRedirectServer(cfg.TLS.RedirectPort, cfg.Port)
+			go func() {
+				log.Printf("Redirecting HTTP on port %d to HTTPS", cfg.TLS.RedirectPort)
+				if err := redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Fatalf("Failed to start HTTP redirect server: %v", err)
+				}
+			}()
+		}
+	}
+
+	<-ctx.Done()
+	log.Println("Gateway shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if redirectServer != nil {
+		redirectServer.Shutdown(shutdownCtx)
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown failed: %v", err)
+	}
+}
+
+// exitAfterValidation reports the result of -validate-config and exits with
+// status 1 if the configuration is invalid, or 0 otherwise
+func exitAfterValidation(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println("Configuration is valid")
+	os.Exit(0)
+}
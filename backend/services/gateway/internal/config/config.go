@@ -0,0 +1,96 @@
+// Package config loads the gateway configuration from the environment and
+// checks it before anything is started, so a bad deployment fails at once
+// with every problem listed.
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"microbank/gateway/internal/middleware"
+	"microbank/gateway/internal/proxy"
+	sharedconfig "microbank/pkg/config"
+	"microbank/pkg/cors"
+	"microbank/pkg/tlsserver"
+)
+
+// defaultUpstreamTimeout is how long an upstream may take to start
+// responding
+const defaultUpstreamTimeout = 30 * time.Second
+
+// Config holds the validated gateway configuration
+type Config struct {
+	Port    int
+	GinMode string
+
+	// TLS and Certificates are nil when the gateway serves plain HTTP
+	TLS          *tlsserver.Config
+	Certificates *tlsserver.CertReloader
+
+	Routes          *proxy.Table
+	UpstreamTimeout time.Duration
+	TrustedProxies  *middleware.TrustedProxies
+	RateLimit       middleware.RateLimitConfig
+	CORS            *cors.Policy
+}
+
+// Load reads the configuration from the environment. The error, when not
+// nil, is a *sharedconfig.Error listing every problem found.
+func Load() (*Config, error) {
+	var problems sharedconfig.Problems
+	cfg := &Config{}
+	var err error
+
+	cfg.Port, err = sharedconfig.PortFromEnv("PORT", 8000)
+	problems.Add(err)
+	cfg.GinMode, err = sharedconfig.OneOfEnv("GIN_MODE", "debug", "debug", "release", "test")
+	problems.Add(err)
+
+	cfg.TLS, err = tlsserver.ConfigFromEnv()
+	problems.Add(err)
+	if cfg.TLS != nil {
+		if cfg.TLS.RedirectPort == cfg.Port {
+			problems.Addf("TLS_REDIRECT_PORT must differ from PORT")
+		}
+		cfg.Certificates, err = tlsserver.NewCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		problems.Add(err)
+	}
+
+	cfg.Routes, err = proxy.ParseTable(envOr("GATEWAY_UPSTREAMS", proxy.DefaultUpstreams), envOr("GATEWAY_ROUTES", proxy.DefaultRoutes))
+	if err != nil {
+		problems.Add(fmt.Errorf("GATEWAY_UPSTREAMS and GATEWAY_ROUTES: %w", err))
+	}
+
+	cfg.UpstreamTimeout = defaultUpstreamTimeout
+	if value := os.Getenv("GATEWAY_UPSTREAM_TIMEOUT"); value != "" {
+		if cfg.UpstreamTimeout, err = time.ParseDuration(value); err != nil || cfg.UpstreamTimeout <= 0 {
+			problems.Addf("invalid GATEWAY_UPSTREAM_TIMEOUT %q: must be a positive duration such as 30s", value)
+		}
+	}
+
+	cfg.TrustedProxies, err = middleware.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	problems.Add(err)
+	cfg.RateLimit = middleware.LoadRateLimitConfig("gateway", 300, time.Minute)
+
+	corsConfig, err := cors.ConfigFromEnv()
+	if err == nil {
+		cfg.CORS, err = cors.New(corsConfig)
+	}
+	if err != nil {
+		problems.Add(fmt.Errorf("CORS: %w", err))
+	}
+
+	if err := problems.Err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// envOr returns the environment variable name, or fallback if it is unset
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	sharedconfig "microbank/pkg/config"
+)
+
+// setValidEnv clears the settings the tests change, leaving the defaults
+func setValidEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"PORT",
+		"GIN_MODE",
+		"TLS_CERT_FILE",
+		"TLS_KEY_FILE",
+		"TLS_REDIRECT_PORT",
+		"GATEWAY_UPSTREAMS",
+		"GATEWAY_ROUTES",
+		"GATEWAY_UPSTREAM_TIMEOUT",
+		"TRUSTED_PROXIES",
+		"CORS_ALLOWED_ORIGINS",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	setValidEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Port != 8000 || cfg.UpstreamTimeout != 30*time.Second {
+		t.Errorf("Expected port 8000 with a 30s upstream timeout, got %d and %s", cfg.Port, cfg.UpstreamTimeout)
+	}
+	if upstream := cfg.Routes.Match("/api/v1/account/balance"); upstream == nil || upstream.Name != "banking-service" {
+		t.Errorf("Expected the default routes, got %+v", upstream)
+	}
+
+	t.Setenv("GATEWAY_UPSTREAMS", "client-service=http://client:8080,reports=http://reports:8080")
+	t.Setenv("GATEWAY_ROUTES", "/api/v1/auth=client-service,/api/v1/reports=reports")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if upstream := cfg.Routes.Match("/api/v1/reports/monthly"); upstream == nil || upstream.URL.Host != "reports:8080" {
+		t.Errorf("Expected the configured route table, got %+v", upstream)
+	}
+}
+
+func TestLoad_ReportsEveryProblem(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("PORT", "0")
+	t.Setenv("TLS_REDIRECT_PORT", "8080")
+	t.Setenv("GATEWAY_ROUTES", "/api/v1/auth=client-service,/api/v1/reports=reports")
+	t.Setenv("GATEWAY_UPSTREAM_TIMEOUT", "soon")
+	t.Setenv("TRUSTED_PROXIES", "not-an-ip")
+
+	_, err := Load()
+	var configErr *sharedconfig.Error
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected a *sharedconfig.Error, got %v", err)
+	}
+
+	for _, want := range []string{
+		"invalid PORT",
+		"TLS_REDIRECT_PORT requires TLS_CERT_FILE and TLS_KEY_FILE",
+		`unknown upstream "reports"`,
+		"invalid GATEWAY_UPSTREAM_TIMEOUT",
+		"invalid trusted proxy",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to report %q, got:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"microbank/pkg/redact"
+)
+
+// Logger provides structured logging for HTTP requests. It must run after
+// RequestID so each entry carries the ID the client was sent. With
+// redactPII set, email addresses and secret query values such as reset
+// tokens are masked in the logged path.
+func Logger(redactPII bool) gin.HandlerFunc {
+	return gin.LoggerWithFormatter(logFormatter(redactPII))
+}
+
+// logFormatter formats one request log line, ending with the request ID
+// stored by RequestID, or "-" if there is none
+func logFormatter(redactPII bool) gin.LogFormatter {
+	return func(param gin.LogFormatterParams) string {
+		requestID, _ := param.Keys["request_id"].(string)
+		if requestID == "" {
+			requestID = "-"
+		}
+
+		path := param.Path
+		if redactPII {
+			path = redact.RequestURI(path)
+		}
+
+		// Format the log entry
+		return fmt.Sprintf("[%s] %s | %s | %d | %s | %s | %s | %s\n",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.Method,
+			path,
+			param.StatusCode,
+			param.Latency,
+			param.ClientIP,
+			param.Request.UserAgent(),
+			requestID,
+		)
+	}
+}
@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/pkg/httpx"
+)
+
+// RateLimitConfig describes how many requests a client may make per window
+type RateLimitConfig struct {
+	Name     string
+	Requests int
+	Window   time.Duration
+}
+
+// LoadRateLimitConfig builds a rate limit config for a route, allowing the
+// defaults to be overridden with RATE_LIMIT_<NAME>_REQUESTS and
+// RATE_LIMIT_<NAME>_WINDOW (a Go duration such as "15m")
+func LoadRateLimitConfig(name string, requests int, window time.Duration) RateLimitConfig {
+	prefix := "RATE_LIMIT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+
+	if value := os.Getenv(prefix + "_REQUESTS"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			requests = n
+		}
+	}
+
+	if value := os.Getenv(prefix + "_WINDOW"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			window = d
+		}
+	}
+
+	return RateLimitConfig{Name: name, Requests: requests, Window: window}
+}
+
+// RateLimitStore records hits and decides whether a key is over its limit.
+// Implementations must be safe for concurrent use.
+type RateLimitStore interface {
+	// Allow records a hit for key and reports whether it is within limit hits
+	// per window. When it is not, retryAfter is how long until a slot frees up.
+	Allow(key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// InMemoryRateLimitStore is a sliding window log kept in process memory
+type InMemoryRateLimitStore struct {
+	mu        sync.Mutex
+	hits      map[string]*rateLimitEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type rateLimitEntry struct {
+	window time.Duration
+	times  []time.Time
+}
+
+// rateLimitSweepInterval is how often idle keys are dropped from memory
+const rateLimitSweepInterval = time.Minute
+
+// NewInMemoryRateLimitStore creates a new in-memory rate limit store
+func NewInMemoryRateLimitStore() *InMemoryRateLimitStore {
+	return &InMemoryRateLimitStore{
+		hits:      make(map[string]*rateLimitEntry),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow records a hit for key using a sliding window
+func (s *InMemoryRateLimitStore) Allow(key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	entry, ok := s.hits[key]
+	if !ok {
+		entry = &rateLimitEntry{window: window}
+		s.hits[key] = entry
+	}
+
+	// Drop hits that have slid out of the window
+	cutoff := now.Add(-window)
+	i := 0
+	for i < len(entry.times) && !entry.times[i].After(cutoff) {
+		i++
+	}
+	entry.times = entry.times[i:]
+
+	if len(entry.times) >= limit {
+		return false, entry.times[0].Add(window).Sub(now), nil
+	}
+
+	entry.times = append(entry.times, now)
+	return true, 0, nil
+}
+
+// sweep removes keys with no hits left in their window
+func (s *InMemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entry := range s.hits {
+		if len(entry.times) == 0 || !entry.times[len(entry.times)-1].After(now.Add(-entry.window)) {
+			delete(s.hits, key)
+		}
+	}
+}
+
+// TrustedProxies is the set of proxy addresses whose X-Forwarded-For header
+// is believed when resolving the client IP
+type TrustedProxies struct {
+	networks []*net.IPNet
+}
+
+// ParseTrustedProxies parses a comma-separated list of IPs and CIDRs
+func ParseTrustedProxies(value string) (*TrustedProxies, error) {
+	proxies := &TrustedProxies{}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if !strings.Contains(part, "/") {
+			if ip := net.ParseIP(part); ip != nil && ip.To4() != nil {
+				part += "/32"
+			} else {
+				part += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", part, err)
+		}
+		proxies.networks = append(proxies.networks, network)
+	}
+
+	return proxies, nil
+}
+
+// CIDRs returns the trusted proxy networks in CIDR notation
+func (p *TrustedProxies) CIDRs() []string {
+	if p == nil {
+		return nil
+	}
+	cidrs := make([]string, 0, len(p.networks))
+	for _, network := range p.networks {
+		cidrs = append(cidrs, network.String())
+	}
+	return cidrs
+}
+
+// contains reports whether ip belongs to a trusted proxy
+func (p *TrustedProxies) contains(ip net.IP) bool {
+	if p == nil || ip == nil {
+		return false
+	}
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP resolves the real client address. X-Forwarded-For is only
+// consulted when the direct peer is a trusted proxy, and is walked from the
+// right so that entries appended by an untrusted client are never used.
+func (p *TrustedProxies) ClientIP(r *http.Request) string {
+	remoteIP, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		remoteIP = strings.TrimSpace(r.RemoteAddr)
+	}
+
+	if !p.contains(net.ParseIP(remoteIP)) {
+		return remoteIP
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		ip := net.ParseIP(hop)
+		if ip == nil {
+			break
+		}
+		if !p.contains(ip) {
+			return hop
+		}
+	}
+
+	return remoteIP
+}
+
+// ForwardedFor returns the X-Forwarded-For value to send upstream: the
+// chain received from a trusted proxy followed by the direct peer, or just
+// the peer when it is not trusted, so a client cannot forge its address
+func (p *TrustedProxies) ForwardedFor(r *http.Request) string {
+	remoteIP, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		remoteIP = strings.TrimSpace(r.RemoteAddr)
+	}
+
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" && p.contains(net.ParseIP(remoteIP)) {
+		return forwarded + ", " + remoteIP
+	}
+	return remoteIP
+}
+
+// RateLimit throttles requests per client IP using the given store
+func RateLimit(store RateLimitStore, proxies *TrustedProxies, config RateLimitConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := config.Name + ":" + proxies.ClientIP(c.Request)
+
+		allowed, retryAfter, err := store.Allow(key, config.Requests, config.Window)
+		if err != nil {
+			// Fail open so a store outage does not take down the API
+			c.Error(fmt.Errorf("rate limit store error: %w", err))
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			httpx.AbortWithError(c, &httpx.AppError{
+				Status:  http.StatusTooManyRequests,
+				Code:    "RATE_LIMITED",
+				Message: "Too many requests, please try again later",
+				Details: gin.H{
+					"retry_after_seconds": seconds,
+				},
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
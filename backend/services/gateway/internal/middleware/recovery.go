@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"microbank/pkg/httpx"
+)
+
+// Recovery recovers from panics and provides proper error responses
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		// Log the panic with stack trace
+		c.Error(fmt.Errorf("panic recovered: %v\n%s", recovered, debug.Stack()))
+
+		// Return a generic error response to the client
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "INTERNAL_SERVER_ERROR",
+			Message: "An unexpected error occurred",
+		})
+	})
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"microbank/pkg/httpx"
+)
+
+// RequestIDHeader carries the request ID on requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds caller-supplied request IDs
+const maxRequestIDLength = 100
+
+// RequestID assigns every request an ID, reusing the caller's X-Request-ID
+// when one is sent, and stores it in the context under httpx.RequestIDKey.
+// The ID is also set on the request itself so it is forwarded upstream and
+// both services log the same ID.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.New().String()
+		}
+
+		c.Set(httpx.RequestIDKey, requestID)
+		c.Request.Header.Set(RequestIDHeader, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{name: "generated when missing", incoming: ""},
+		{name: "caller id reused", incoming: "abc-123", wantSame: true},
+		{name: "oversized caller id replaced", incoming: strings.Repeat("x", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen, forwarded string
+			r := gin.New()
+			r.GET("/", RequestID(), func(c *gin.Context) {
+				seen = c.GetString("request_id")
+				forwarded = c.Request.Header.Get(RequestIDHeader)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if seen == "" || w.Header().Get(RequestIDHeader) != seen || forwarded != seen {
+				t.Fatalf("Expected the context, request and response to share a request ID, got %q, %q and %q", seen, forwarded, w.Header().Get(RequestIDHeader))
+			}
+			if (seen == tt.incoming) != tt.wantSame {
+				t.Errorf("Expected reuse=%v, got request ID %q", tt.wantSame, seen)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds each upstream health check, so one slow
+// service cannot hold up the gateway's own health check
+const healthCheckTimeout = 3 * time.Second
+
+// upstreamHealth is the state of one upstream in the health response
+type upstreamHealth struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Health reports the gateway as healthy when every upstream's /health
+// answers 200, and as degraded with 503 otherwise. Upstreams are checked
+// at the same time.
+func (g *Gateway) Health(c *gin.Context) {
+	results := make(map[string]upstreamHealth, len(g.table.Upstreams))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, upstream := range g.table.Upstreams {
+		wg.Add(1)
+		go func(upstream *Upstream) {
+			defer wg.Done()
+			result := upstreamHealth{Status: "healthy"}
+			if err := g.checkUpstream(c.Request.Context(), upstream); err != nil {
+				result = upstreamHealth{Status: "unhealthy", Error: err.Error()}
+			}
+			mu.Lock()
+			results[upstream.Name] = result
+			mu.Unlock()
+		}(upstream)
+	}
+	wg.Wait()
+
+	status, code := "healthy", http.StatusOK
+	for _, result := range results {
+		if result.Status != "healthy" {
+			status, code = "degraded", http.StatusServiceUnavailable
+		}
+	}
+
+	c.JSON(code, gin.H{
+		"status":    status,
+		"service":   "gateway",
+		"upstreams": results,
+	})
+}
+
+// checkUpstream calls the upstream's /health endpoint
+func (g *Gateway) checkUpstream(ctx context.Context, upstream *Upstream) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL.JoinPath("/health").String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := g.transport.RoundTrip(req)
+	if err != nil {
+		// The cause names internal addresses, so it is only logged
+		log.Printf("Health check of %s failed: %v", upstream.Name, err)
+		return errors.New("unreachable")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/gateway/internal/middleware"
+	"microbank/pkg/httpx"
+)
+
+// ginContextKey stores the gin context on proxied requests so proxy errors
+// are answered in the response envelope
+type ginContextKey struct{}
+
+// Gateway forwards requests to the upstream their route names
+type Gateway struct {
+	table     *Table
+	proxies   map[*Upstream]*httputil.ReverseProxy
+	transport http.RoundTripper
+}
+
+// New creates a gateway for table. Client addresses are resolved with
+// trusted, and upstreams that have not started responding within timeout
+// fail with 504 Gateway Timeout.
+func New(table *Table, trusted *middleware.TrustedProxies, timeout time.Duration) *Gateway {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+
+	g := &Gateway{
+		table:     table,
+		proxies:   make(map[*Upstream]*httputil.ReverseProxy, len(table.Upstreams)),
+		transport: transport,
+	}
+	for _, upstream := range table.Upstreams {
+		g.proxies[upstream] = newReverseProxy(upstream, trusted, transport)
+	}
+	return g
+}
+
+// newReverseProxy creates the proxy for one upstream
+func newReverseProxy(upstream *Upstream, trusted *middleware.TrustedProxies, transport http.RoundTripper) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(upstream.URL)
+			// X-Forwarded-Host and X-Forwarded-Proto come from SetXForwarded;
+			// the client address is only taken from trusted proxies
+			r.SetXForwarded()
+			r.Out.Header.Set("X-Forwarded-For", trusted.ForwardedFor(r.In))
+			r.Out.Header.Set("X-Real-IP", trusted.ClientIP(r.In))
+		},
+		Transport:      transport,
+		ModifyResponse: stripGatewayHeaders,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("Proxy error for %s %s to %s: %v", r.Method, r.URL.Path, upstream.Name, err)
+			c, _ := r.Context().Value(ginContextKey{}).(*gin.Context)
+			if c == nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			httpx.RespondError(c, upstreamError(err))
+		},
+	}
+}
+
+// stripGatewayHeaders drops the headers the gateway sets itself, so
+// responses do not carry the upstream's copy as well
+func stripGatewayHeaders(resp *http.Response) error {
+	for name := range resp.Header {
+		if strings.HasPrefix(name, "Access-Control-") {
+			resp.Header.Del(name)
+		}
+	}
+	resp.Header.Del(middleware.RequestIDHeader)
+
+	var vary []string
+	for _, value := range resp.Header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" && !strings.EqualFold(field, "Origin") {
+				vary = append(vary, field)
+			}
+		}
+	}
+	resp.Header.Del("Vary")
+	if len(vary) > 0 {
+		resp.Header.Set("Vary", strings.Join(vary, ", "))
+	}
+	return nil
+}
+
+// upstreamError maps a failed upstream call to the error the client sees
+func upstreamError(err error) *httpx.AppError {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &httpx.AppError{
+			Status:  http.StatusGatewayTimeout,
+			Code:    "UPSTREAM_TIMEOUT",
+			Message: "The service did not respond in time",
+			Err:     err,
+		}
+	}
+	return &httpx.AppError{
+		Status:  http.StatusBadGateway,
+		Code:    "UPSTREAM_UNAVAILABLE",
+		Message: "The service is unavailable, please try again later",
+		Err:     err,
+	}
+}
+
+// Proxy forwards the request to the upstream its route names. Paths no
+// route covers, and paths that are not in canonical form, get 404 so that
+// dot segments cannot reach an upstream route the table does not expose.
+func (g *Gateway) Proxy(c *gin.Context) {
+	requestPath := c.Request.URL.Path
+	var upstream *Upstream
+	if cleaned := path.Clean(requestPath); cleaned == strings.TrimSuffix(requestPath, "/") || cleaned == requestPath {
+		upstream = g.table.Match(cleaned)
+	}
+	if upstream == nil {
+		httpx.RespondError(c, &httpx.AppError{
+			Status:  http.StatusNotFound,
+			Code:    "NOT_FOUND",
+			Message: "No service handles this path",
+		})
+		return
+	}
+
+	request := c.Request.WithContext(context.WithValue(c.Request.Context(), ginContextKey{}, c))
+	g.proxies[upstream].ServeHTTP(c.Writer, request)
+}
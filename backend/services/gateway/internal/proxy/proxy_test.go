@@ -0,0 +1,216 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"microbank/gateway/internal/middleware"
+	"microbank/pkg/cors"
+	"microbank/pkg/httpx"
+)
+
+// newUpstream starts a service that echoes what it received and sets the
+// headers the gateway owns, as the real services do
+func newUpstream(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", "https://upstream.example.com")
+		w.Header().Set("Vary", "Origin, Accept-Encoding")
+		w.Header().Set(middleware.RequestIDHeader, r.Header.Get(middleware.RequestIDHeader))
+		json.NewEncoder(w).Encode(map[string]string{
+			"service":         name,
+			"uri":             r.URL.RequestURI(),
+			"request_id":      r.Header.Get(middleware.RequestIDHeader),
+			"forwarded_for":   r.Header.Get("X-Forwarded-For"),
+			"real_ip":         r.Header.Get("X-Real-IP"),
+			"forwarded_proto": r.Header.Get("X-Forwarded-Proto"),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// recorder is a response recorder gin's writer can proxy to;
+// httputil.ReverseProxy needs CloseNotify, which httptest.ResponseRecorder
+// lacks
+type recorder struct {
+	*httptest.ResponseRecorder
+}
+
+func newRecorder() recorder {
+	return recorder{httptest.NewRecorder()}
+}
+
+func (recorder) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+// newTestGateway routes /api/v1/auth to the client service and
+// /api/v1/account to the banking service, with the middleware main uses
+func newTestGateway(t *testing.T, clientURL, bankingURL, trusted string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	table, err := ParseTable("client-service="+clientURL+",banking-service="+bankingURL, "/api/v1/auth=client-service,/api/v1/account=banking-service")
+	if err != nil {
+		t.Fatalf("ParseTable failed: %v", err)
+	}
+	proxies, err := middleware.ParseTrustedProxies(trusted)
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies failed: %v", err)
+	}
+	policy, err := cors.New(cors.Config{AllowedOrigins: []string{"https://app.example.com"}})
+	if err != nil {
+		t.Fatalf("cors.New failed: %v", err)
+	}
+
+	gateway := New(table, proxies, time.Second)
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.CORS(policy))
+	r.GET("/health", gateway.Health)
+	r.NoRoute(gateway.Proxy)
+	return r
+}
+
+func TestGateway_Proxy(t *testing.T) {
+	client, banking := newUpstream(t, "client-service"), newUpstream(t, "banking-service")
+	r := newTestGateway(t, client.URL, banking.URL, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/account/transactions?limit=5", nil)
+	req.RemoteAddr = "203.0.113.7:1234"
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	req.Header.Set("X-Real-IP", "198.51.100.1")
+	req.Header.Set(middleware.RequestIDHeader, "req-123")
+	w := newRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	want := map[string]string{
+		"service":         "banking-service",
+		"uri":             "/api/v1/account/transactions?limit=5",
+		"request_id":      "req-123",
+		"forwarded_for":   "203.0.113.7",
+		"real_ip":         "203.0.113.7",
+		"forwarded_proto": "http",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("Expected upstream to see %s %q, got %q", key, value, got[key])
+		}
+	}
+
+	if values := w.Header().Values("Access-Control-Allow-Origin"); len(values) != 1 || values[0] != "https://app.example.com" {
+		t.Errorf("Expected only the gateway's CORS origin, got %v", values)
+	}
+	if values := w.Header().Values(middleware.RequestIDHeader); len(values) != 1 {
+		t.Errorf("Expected one request ID header, got %v", values)
+	}
+	if values := w.Header().Values("Vary"); len(values) != 2 || values[0] != "Origin" || values[1] != "Accept-Encoding" {
+		t.Errorf("Expected Vary to list Origin once, got %v", values)
+	}
+}
+
+func TestGateway_ProxyHonorsTrustedProxy(t *testing.T) {
+	client := newUpstream(t, "client-service")
+	r := newTestGateway(t, client.URL, client.URL, "10.0.0.0/8")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+	req.RemoteAddr = "10.0.0.5:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	w := newRecorder()
+	r.ServeHTTP(w, req)
+
+	var got map[string]string
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if got["forwarded_for"] != "198.51.100.1, 10.0.0.5" || got["real_ip"] != "198.51.100.1" {
+		t.Errorf("Expected the proxy chain to be kept, got forwarded_for %q and real_ip %q", got["forwarded_for"], got["real_ip"])
+	}
+}
+
+func TestGateway_ProxyErrors(t *testing.T) {
+	client := newUpstream(t, "client-service")
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	r := newTestGateway(t, client.URL, down.URL, "")
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "no route", path: "/internal/events", wantStatus: http.StatusNotFound, wantCode: "NOT_FOUND"},
+		{name: "dot segments", path: "/api/v1/auth/../../../internal/events", wantStatus: http.StatusNotFound, wantCode: "NOT_FOUND"},
+		{name: "upstream down", path: "/api/v1/account/balance", wantStatus: http.StatusBadGateway, wantCode: "UPSTREAM_UNAVAILABLE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tt.path
+			w := newRecorder()
+			r.ServeHTTP(w, req)
+
+			var envelope httpx.Envelope
+			json.Unmarshal(w.Body.Bytes(), &envelope)
+			if w.Code != tt.wantStatus || envelope.Error == nil || envelope.Error.Code != tt.wantCode {
+				t.Fatalf("Expected %d %s, got %d: %s", tt.wantStatus, tt.wantCode, w.Code, w.Body.String())
+			}
+			if envelope.RequestID == "" {
+				t.Error("Expected the error envelope to carry the request ID")
+			}
+		})
+	}
+}
+
+func TestGateway_Health(t *testing.T) {
+	client, banking := newUpstream(t, "client-service"), newUpstream(t, "banking-service")
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+
+	tests := []struct {
+		name       string
+		bankingURL string
+		wantStatus int
+		want       string
+	}{
+		{name: "all healthy", bankingURL: banking.URL, wantStatus: http.StatusOK, want: "healthy"},
+		{name: "upstream unhealthy", bankingURL: down.URL, wantStatus: http.StatusServiceUnavailable, want: "degraded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestGateway(t, client.URL, tt.bankingURL, "")
+			w := newRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			var body struct {
+				Status    string                    `json:"status"`
+				Upstreams map[string]upstreamHealth `json:"upstreams"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if w.Code != tt.wantStatus || body.Status != tt.want {
+				t.Fatalf("Expected %d %s, got %d: %s", tt.wantStatus, tt.want, w.Code, w.Body.String())
+			}
+			if body.Upstreams["client-service"].Status != "healthy" || len(body.Upstreams) != 2 {
+				t.Errorf("Expected both upstreams to be reported, got %+v", body.Upstreams)
+			}
+		})
+	}
+}
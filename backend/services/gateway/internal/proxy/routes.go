@@ -0,0 +1,143 @@
+// Package proxy forwards API requests to the service that owns them. The
+// route table maps path prefixes to named upstreams and is read from the
+// environment, so a new service is added with configuration alone.
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DefaultUpstreams are the services behind the gateway in a local setup
+const DefaultUpstreams = "client-service=http://localhost:8081,banking-service=http://localhost:8080"
+
+// DefaultRoutes sends each API area to the service that owns it
+const DefaultRoutes = "/api/v1/auth=client-service," +
+	"/api/v1/profile=client-service," +
+	"/api/v1/admin=client-service," +
+	"/api/v1/downloads=client-service," +
+	"/api/v1/account=banking-service," +
+	"/api/v1/transactions=banking-service," +
+	"/api/v1/goals=banking-service," +
+	"/api/v1/beneficiaries=banking-service," +
+	"/api/v1/admin/disputes=banking-service," +
+	"/api/v1/admin/transactions=banking-service," +
+	"/api/v1/admin/maintenance=banking-service," +
+	"/api/v1/admin/reports=banking-service," +
+	"/api/v1/admin/reconciliation=banking-service," +
+	"/api/v1/admin/accounts=banking-service," +
+	"/api/v1/admin/export=banking-service," +
+	"/api/v1/admin/external-transfers=banking-service," +
+	"/api/v1/admin/cheques=banking-service," +
+	"/api/v1/admin/promotions=banking-service," +
+	"/api/v1/admin/maintenance/cleanup-tokens=client-service"
+
+// Upstream is a service requests are forwarded to
+type Upstream struct {
+	Name string
+	URL  *url.URL
+}
+
+// Route sends requests whose path starts with Prefix to Upstream
+type Route struct {
+	Prefix   string
+	Upstream *Upstream
+}
+
+// Table holds the upstreams and the routes to them
+type Table struct {
+	Upstreams []*Upstream
+	// routes are ordered longest prefix first, so the most specific route
+	// wins
+	routes []Route
+}
+
+// ParseTable parses upstreams, comma-separated name=URL pairs, and routes,
+// comma-separated prefix=name pairs. Every route must name a known
+// upstream and every upstream must have a route.
+func ParseTable(upstreams, routes string) (*Table, error) {
+	table := &Table{}
+	byName := make(map[string]*Upstream)
+
+	for _, pair := range splitList(upstreams) {
+		name, rawURL, ok := strings.Cut(pair, "=")
+		name, rawURL = strings.TrimSpace(name), strings.TrimSpace(rawURL)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid upstream %q: must be name=URL", pair)
+		}
+		if byName[name] != nil {
+			return nil, fmt.Errorf("upstream %q is defined twice", name)
+		}
+		parsed, err := url.Parse(rawURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid upstream %q: %q must be an http or https URL", name, rawURL)
+		}
+		upstream := &Upstream{Name: name, URL: parsed}
+		byName[name] = upstream
+		table.Upstreams = append(table.Upstreams, upstream)
+	}
+	if len(table.Upstreams) == 0 {
+		return nil, fmt.Errorf("at least one upstream is required")
+	}
+
+	used := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, pair := range splitList(routes) {
+		prefix, name, ok := strings.Cut(pair, "=")
+		prefix, name = strings.TrimRight(strings.TrimSpace(prefix), "/"), strings.TrimSpace(name)
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid route %q: must be /prefix=upstream", pair)
+		}
+		if seen[prefix] {
+			return nil, fmt.Errorf("route %q is defined twice", prefix)
+		}
+		upstream := byName[name]
+		if upstream == nil {
+			return nil, fmt.Errorf("route %q names unknown upstream %q", prefix, name)
+		}
+		seen[prefix] = true
+		used[name] = true
+		table.routes = append(table.routes, Route{Prefix: prefix, Upstream: upstream})
+	}
+
+	for _, upstream := range table.Upstreams {
+		if !used[upstream.Name] {
+			return nil, fmt.Errorf("upstream %q has no routes", upstream.Name)
+		}
+	}
+
+	sort.SliceStable(table.routes, func(i, j int) bool {
+		return len(table.routes[i].Prefix) > len(table.routes[j].Prefix)
+	})
+	return table, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Routes returns the routes, most specific first
+func (t *Table) Routes() []Route {
+	return t.routes
+}
+
+// Match returns the upstream for path, or nil if no route covers it. A
+// prefix only matches whole path segments, so /api/v1/account does not
+// match /api/v1/accounts.
+func (t *Table) Match(path string) *Upstream {
+	for _, route := range t.routes {
+		if path == route.Prefix || strings.HasPrefix(path, route.Prefix+"/") {
+			return route.Upstream
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTable_Defaults(t *testing.T) {
+	table, err := ParseTable(DefaultUpstreams, DefaultRoutes)
+	if err != nil {
+		t.Fatalf("ParseTable failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/api/v1/auth/login", want: "client-service"},
+		{path: "/api/v1/profile", want: "client-service"},
+		{path: "/api/v1/admin/clients/42", want: "client-service"},
+		{path: "/api/v1/downloads/abc.def", want: "client-service"},
+		{path: "/api/v1/account/balance", want: "banking-service"},
+		{path: "/api/v1/transactions/deposit", want: "banking-service"},
+		{path: "/api/v1/goals/abc/allocate", want: "banking-service"},
+		{path: "/api/v1/beneficiaries/abc", want: "banking-service"},
+		{path: "/api/v1/admin/disputes/abc/resolve", want: "banking-service"},
+		{path: "/api/v1/admin/disputes-report", want: "client-service"},
+		{path: "/api/v1/admin/transactions/export", want: "banking-service"},
+		{path: "/api/v1/admin/maintenance", want: "banking-service"},
+		{path: "/api/v1/admin/maintenance/cleanup-tokens", want: "client-service"},
+		{path: "/api/v1/admin/reports/suspicious-activity/export", want: "banking-service"},
+		{path: "/api/v1/admin/reconciliation/latest", want: "banking-service"},
+		{path: "/api/v1/admin/accounts/abc/integrity", want: "banking-service"},
+		{path: "/api/v1/admin/export/journal", want: "banking-service"},
+		{path: "/api/v1/admin/external-transfers/abc/settle", want: "banking-service"},
+		{path: "/api/v1/admin/cheques/abc/return", want: "banking-service"},
+		{path: "/api/v1/admin/promotions/abc", want: "banking-service"},
+		{path: "/api/v1/accounts", want: ""},
+		{path: "/internal/events", want: ""},
+		{path: "/", want: ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if upstream := table.Match(tt.path); upstream != nil {
+			got = upstream.Name
+		}
+		if got != tt.want {
+			t.Errorf("Match(%q): expected %q, got %q", tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestParseTable_LongestPrefixWins(t *testing.T) {
+	table, err := ParseTable("a=http://a:8080,b=http://b:8080", "/api=a,/api/v1/reports/=b")
+	if err != nil {
+		t.Fatalf("ParseTable failed: %v", err)
+	}
+	if upstream := table.Match("/api/v1/reports/monthly"); upstream == nil || upstream.Name != "b" {
+		t.Errorf("Expected the more specific route to win, got %+v", upstream)
+	}
+	if upstream := table.Match("/api/v1/profile"); upstream == nil || upstream.Name != "a" {
+		t.Errorf("Expected the shorter route to match the rest, got %+v", upstream)
+	}
+}
+
+func TestParseTable_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		upstreams string
+		routes    string
+		want      string
+	}{
+		{name: "no upstreams", upstreams: "", routes: "/api=a", want: "at least one upstream"},
+		{name: "malformed upstream", upstreams: "http://a:8080", routes: "/api=a", want: "must be name=URL"},
+		{name: "bad url", upstreams: "a=ftp://a", routes: "/api=a", want: "must be an http or https URL"},
+		{name: "duplicate upstream", upstreams: "a=http://a,a=http://b", routes: "/api=a", want: "defined twice"},
+		{name: "relative prefix", upstreams: "a=http://a", routes: "api=a", want: "must be /prefix=upstream"},
+		{name: "unknown upstream", upstreams: "a=http://a", routes: "/api=a,/other=b", want: `unknown upstream "b"`},
+		{name: "duplicate route", upstreams: "a=http://a", routes: "/api=a,/api/=a", want: "defined twice"},
+		{name: "unused upstream", upstreams: "a=http://a,b=http://b", routes: "/api=a", want: `upstream "b" has no routes`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTable(tt.upstreams, tt.routes)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -43,7 +43,7 @@ export default function SystemOverview() {
         throw new Error("Failed to fetch system statistics");
       }
 
-      const data = await response.json();
+      const { data } = await response.json();
       const users = data.users || [];
 
       const stats: SystemStats = {
@@ -45,7 +45,7 @@ export default function UserManagement() {
         throw new Error("Failed to fetch users");
       }
 
-      const data = await response.json();
+      const { data } = await response.json();
       setUsers(data.users || []);
     } catch (error) {
       setError(
@@ -60,11 +60,12 @@ export const useAuthStore = create<AuthStore>()(
             }
           );
 
-          const data = await response.json();
+          const body = await response.json();
 
           if (!response.ok) {
-            throw new Error(data.error?.message || "Login failed");
+            throw new Error(body.error?.message || "Login failed");
           }
+          const data = body.data;
 
           // Verify the user is an admin
           if (!data.user.is_admin) {
@@ -154,7 +155,7 @@ export const useAuthStore = create<AuthStore>()(
           );
 
           if (response.ok) {
-            const data = await response.json();
+            const { data } = await response.json();
 
             // Verify the user is still an admin
             if (!data.user.is_admin) {
@@ -194,11 +195,11 @@ export const useAuthStore = create<AuthStore>()(
             }
           );
 
-          const data = await response.json();
+          const body = await response.json();
 
           if (response.ok) {
             set({
-              accessToken: data.tokens.access_token,
+              accessToken: body.data.tokens.access_token,
               isLoading: false,
               hasCheckedAuth: true,
             });
@@ -35,7 +35,7 @@ export default function AccountOverview() {
       );
 
       if (response.ok) {
-        const data = await response.json();
+        const { data } = await response.json();
         setAccountData(data);
       } else {
         const errorData = await response.json();
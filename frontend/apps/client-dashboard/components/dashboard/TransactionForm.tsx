@@ -58,26 +58,26 @@ export default function TransactionForm() {
         }
       );
 
-      const data = await response.json();
+      const body = await response.json();
 
       // Debug logging
       console.log("Transaction response:", {
         status: response.status,
         statusText: response.statusText,
-        data,
+        body,
       });
 
       if (response.ok) {
         setMessage({
           type: "success",
-          text: `${type === "deposit" ? "Deposit" : "Withdrawal"} processed successfully! New balance: $${data.transaction.balance_after.toFixed(2)}`,
+          text: `${type === "deposit" ? "Deposit" : "Withdrawal"} processed successfully! New balance: $${body.data.transaction.balance_after.toFixed(2)}`,
         });
         setAmount("");
         setDescription("");
       } else {
         setMessage({
           type: "error",
-          text: data.error?.message || `Failed to process ${type}`,
+          text: body.error?.message || `Failed to process ${type}`,
         });
       }
     } catch (error) {
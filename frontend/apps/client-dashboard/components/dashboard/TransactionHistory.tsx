@@ -5,7 +5,7 @@ import { useAuthStore } from "@/stores/authStore";
 
 interface Transaction {
   id: string;
-  type: "deposit" | "withdrawal";
+  type: "deposit" | "withdrawal" | "fee" | "round_up" | "refund";
   amount: number;
   description: string;
   balance_before: number;
@@ -14,13 +14,16 @@ interface Transaction {
 }
 
 interface TransactionResponse {
-  message: string;
+  data: {
+    message: string;
+    transactions: Transaction[];
+  };
   pagination: {
     count: number;
     limit: number;
     offset: number;
   };
-  transactions: Transaction[];
+  request_id: string;
 }
 
 export default function TransactionHistory() {
@@ -56,9 +59,9 @@ export default function TransactionHistory() {
       );
 
       if (response.ok) {
-        const data: TransactionResponse = await response.json();
+        const { data, pagination }: TransactionResponse = await response.json();
         setTransactions(data.transactions);
-        setTotalCount(data.pagination.count);
+        setTotalCount(pagination.count);
       } else {
         const errorData = await response.json();
         setError(errorData.error?.message || "Failed to fetch transactions");
@@ -214,12 +217,20 @@ export default function TransactionHistory() {
                 <div className="flex items-center space-x-4">
                   <div
                     className={`h-10 w-10 rounded-full flex items-center justify-center ${
-                      transaction.type === "deposit"
+                      transaction.type === "deposit" ||
+                      transaction.type === "refund"
                         ? "bg-green-100 text-green-600"
+                        : transaction.type === "round_up"
+                        ? "bg-blue-100 text-blue-600"
                         : "bg-red-100 text-red-600"
                     }`}
                   >
-                    {transaction.type === "deposit" ? "💰" : "💸"}
+                    {transaction.type === "deposit" ||
+                    transaction.type === "refund"
+                      ? "💰"
+                      : transaction.type === "round_up"
+                      ? "🪙"
+                      : "💸"}
                   </div>
                   <div>
                     <p className="font-medium text-gray-900">
@@ -234,12 +245,20 @@ export default function TransactionHistory() {
                 <div className="text-right">
                   <p
                     className={`font-semibold ${
-                      transaction.type === "deposit"
+                      transaction.type === "deposit" ||
+                      transaction.type === "refund"
                         ? "text-green-600"
+                        : transaction.type === "round_up"
+                        ? "text-blue-600"
                         : "text-red-600"
                     }`}
                   >
-                    {transaction.type === "deposit" ? "+" : "-"}{" "}
+                    {transaction.type === "deposit" ||
+                    transaction.type === "refund"
+                      ? "+ "
+                      : transaction.type === "round_up"
+                      ? ""
+                      : "- "}
                     {formatAmount(transaction.amount)}
                   </p>
                   <p className="text-sm text-gray-500">
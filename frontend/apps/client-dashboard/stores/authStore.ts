@@ -61,11 +61,12 @@ export const useAuthStore = create<AuthStore>()(
             }
           );
 
-          const data = await response.json();
+          const body = await response.json();
 
           if (!response.ok) {
-            throw new Error(data.error?.message || "Login failed");
+            throw new Error(body.error?.message || "Login failed");
           }
+          const data = body.data;
 
           set({
             user: data.user,
@@ -99,11 +100,12 @@ export const useAuthStore = create<AuthStore>()(
             }
           );
 
-          const data = await response.json();
+          const body = await response.json();
 
           if (!response.ok) {
-            throw new Error(data.error?.message || "Registration failed");
+            throw new Error(body.error?.message || "Registration failed");
           }
+          const data = body.data;
 
           set({
             user: data.user,
@@ -206,7 +208,7 @@ export const useAuthStore = create<AuthStore>()(
           );
 
           if (response.ok) {
-            const data = await response.json();
+            const { data } = await response.json();
             console.log("Token validation successful");
             set({ user: data.user, isLoading: false, hasCheckedAuth: true });
           } else {
@@ -244,18 +246,18 @@ export const useAuthStore = create<AuthStore>()(
             }
           );
 
-          const data = await response.json();
-          console.log("Refresh response:", { status: response.status, data });
+          const body = await response.json();
+          console.log("Refresh response:", { status: response.status, body });
 
           if (response.ok) {
             console.log("Token refresh successful, setting new access token");
             set({
-              accessToken: data.tokens.access_token,
+              accessToken: body.data.tokens.access_token,
               isLoading: false,
               hasCheckedAuth: true,
             });
           } else {
-            console.log("Token refresh failed:", data.error);
+            console.log("Token refresh failed:", body.error);
             get().logout();
           }
         } catch (error) {